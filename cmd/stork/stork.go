@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -22,19 +24,27 @@ import (
 	_ "github.com/libopenstorage/stork/drivers/volume/portworx"
 	"github.com/libopenstorage/stork/pkg/apis"
 	"github.com/libopenstorage/stork/pkg/applicationmanager"
+	"github.com/libopenstorage/stork/pkg/cache"
+	"github.com/libopenstorage/stork/pkg/cleanupaudit"
 	"github.com/libopenstorage/stork/pkg/clusterdomains"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/datacopy"
 	"github.com/libopenstorage/stork/pkg/dbg"
 	"github.com/libopenstorage/stork/pkg/extender"
 	"github.com/libopenstorage/stork/pkg/groupsnapshot"
+	"github.com/libopenstorage/stork/pkg/health"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/metrics"
 	"github.com/libopenstorage/stork/pkg/migration"
 	"github.com/libopenstorage/stork/pkg/monitor"
+	"github.com/libopenstorage/stork/pkg/orphanedpv"
 	"github.com/libopenstorage/stork/pkg/pvcwatcher"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
 	"github.com/libopenstorage/stork/pkg/schedule"
 	"github.com/libopenstorage/stork/pkg/snapshot"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	"github.com/libopenstorage/stork/pkg/ttl"
 	"github.com/libopenstorage/stork/pkg/version"
 	"github.com/libopenstorage/stork/pkg/webhookadmission"
 	kdmpapi "github.com/portworx/kdmp/pkg/apis/kdmp/v1alpha1"
@@ -56,6 +66,7 @@ import (
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
+	ctrlcache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -100,7 +111,15 @@ func main() {
 		},
 		cli.StringFlag{
 			Name:  "driver,d",
-			Usage: "Storage driver name",
+			Usage: "Storage driver name. Multiple drivers can be given separated by commas, PVCs are handled by the first driver that owns them and the first driver is used for everything else",
+		},
+		cli.StringSliceFlag{
+			Name:  "driver-selector",
+			Usage: "Restrict the PVCs handled by a driver when multiple drivers are used, in the format <driver>:namespaces=<ns1>,<ns2> or <driver>:storageclasses=<sc1>,<sc2>. Can be given multiple times",
+		},
+		cli.StringSliceFlag{
+			Name:  "watch-namespaces",
+			Usage: "Restrict the stork controllers to the objects in the given namespaces, e.g. to run a stork instance per tenant. Can be given multiple times. Defaults to the watchNamespaces in the StorkConfiguration or all namespaces",
 		},
 		cli.BoolTFlag{
 			Name:  "leader-elect",
@@ -167,6 +186,48 @@ func main() {
 			Name:  "webhook-skip-resources-annotation",
 			Usage: "Application annotation to be used to disable auto updating app scheduler as stork",
 		},
+		cli.BoolFlag{
+			Name:  "webhook-block-inactive-app-scaleup",
+			Usage: "Block scaling up migrated applications on this cluster until the migrations are activated (default: false)",
+		},
+		cli.StringFlag{
+			Name:  "webhook-service-account",
+			Value: "stork-account",
+			Usage: "Service account stork runs as, its requests to scale up migrated applications aren't blocked",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-check-references",
+			Usage: "Deny stork CRs referencing BackupLocations or ClusterPairs that the user isn't allowed to read (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-protect-referenced-crs",
+			Usage: "Deny deleting BackupLocations, ClusterPairs and schedule policies that are still used by active schedules, backups or migrations (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-default-crs",
+			Usage: "Fill in the defaults on stork CRs when they are created (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-placement-hints",
+			Usage: "Add annotations to PVCs so that the driver creates their volumes on the nodes where their pods are scheduled (default: false)",
+		},
+		cli.IntFlag{
+			Name:  "webhook-cert-lifetime",
+			Value: 365,
+			Usage: "Lifetime in days of the generated webhook certificate, which is rotated once a third of its lifetime is left (default: 365)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-datamover-affinity",
+			Usage: "Add node affinity to the pods of the data mover jobs so that they run on the nodes where their PVCs are attached (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-driver-events",
+			Usage: "Accept events pushed by volume drivers on the /events path of the webhook server to reconcile backups, restores, migrations and group snapshots right away. Drivers authenticate with a service account token that can get the objects (default: false)",
+		},
+		cli.BoolFlag{
+			Name:  "webhook-crd-conversion",
+			Usage: "Serve the v1alpha2 version of the stork CRDs and convert objects between v1alpha1 and v1alpha2 (default: false)",
+		},
 		cli.BoolTFlag{
 			Name:  "enable-metrics",
 			Usage: "Enable stork metrics collection for stork resources (default: true)",
@@ -195,6 +256,76 @@ func main() {
 			Value: 4,
 			Usage: "Max threads for apply resources during migration (default: 4)",
 		},
+		cli.IntFlag{
+			Name:  "graceful-shutdown-timeout",
+			Value: 30,
+			Usage: "Time in seconds to wait for controllers to finish in-flight reconciles on shutdown (default: 30 seconds)",
+		},
+		cli.IntFlag{
+			Name:  "health-port",
+			Value: 8098,
+			Usage: "Port for the liveness (/healthz) and readiness (/readyz) endpoints, 0 to disable (default: 8098)",
+		},
+		cli.IntFlag{
+			Name:  "shutdown-drain-period",
+			Value: 5,
+			Usage: "Time in seconds that the extender and webhook keep serving after failing readiness on shutdown, so that the service stops sending them requests (default: 5 seconds)",
+		},
+		cli.IntFlag{
+			Name:  "driver-call-retries",
+			Value: volume.DefaultResilienceConfig.MaxRetries,
+			Usage: "Number of times read calls to the volume driver are retried when the driver can't be reached (default: 3)",
+		},
+		cli.IntFlag{
+			Name:  "driver-call-timeout",
+			Value: int(volume.DefaultResilienceConfig.CallTimeout.Seconds()),
+			Usage: "Time in seconds after which read calls to the volume driver are abandoned (default: 60 seconds)",
+		},
+		cli.IntFlag{
+			Name:  "driver-failure-threshold",
+			Value: volume.DefaultResilienceConfig.FailureThreshold,
+			Usage: "Number of consecutive read calls that fail to reach the volume driver after which it is marked as degraded (default: 5)",
+		},
+		cli.Float64Flag{
+			Name:  "cloud-api-qps",
+			Value: float64(volume.DefaultCloudAPIConfig.QPS),
+			Usage: "Number of calls per second the AWS, GCE and Azure drivers can make to the cloud provider APIs, 0 to disable rate limiting (default: 5)",
+		},
+		cli.IntFlag{
+			Name:  "cloud-api-burst",
+			Value: volume.DefaultCloudAPIConfig.Burst,
+			Usage: "Number of calls the AWS, GCE and Azure drivers can make to the cloud provider APIs at once before being rate limited (default: 10)",
+		},
+		cli.IntFlag{
+			Name:  "cloud-api-cache-ttl",
+			Value: int(volume.DefaultCloudAPIConfig.CacheTTL.Seconds()),
+			Usage: "Time in seconds for which the status of cloud snapshots and disks is cached, 0 to disable caching (default: 10 seconds)",
+		},
+		cli.IntFlag{
+			Name:  "cleanup-audit-interval",
+			Value: 10,
+			Usage: "The interval in minutes to check for resources stuck in Terminating because their cleanup failed (default: 10 minutes)",
+		},
+		cli.IntFlag{
+			Name:  "cleanup-warning-threshold",
+			Value: 60,
+			Usage: "Time in minutes after which resources stuck in Terminating are reported with an event (default: 60 minutes)",
+		},
+		cli.IntFlag{
+			Name:  "cleanup-finalizer-timeout",
+			Value: 0,
+			Usage: "Time in minutes after which the cleanup finalizer is removed from resources stuck in Terminating, leaving behind anything the cleanup would have removed. Disabled if 0 (default: 0)",
+		},
+		cli.IntFlag{
+			Name:  "ttl-check-interval",
+			Value: 5,
+			Usage: "The interval in minutes to check for finished migrations, backups and restores whose TTL has expired (default: 5 minutes)",
+		},
+		cli.IntFlag{
+			Name:  "orphaned-pv-report-interval",
+			Value: 60,
+			Usage: "The interval in minutes to report PVs left behind by failed restores, clones and migrations (default: 60 minutes)",
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -278,10 +409,30 @@ func run(c *cli.Context) {
 	eventBroadcaster.StartRecordingToSink(&core_v1.EventSinkImpl{Interface: k8sClient.CoreV1().Events("")})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, api_v1.EventSource{Component: eventComponentName})
 
+	// The cloud drivers are used for backups even if they aren't the main
+	// volume driver
+	volume.SetCloudAPIConfig(volume.CloudAPIConfig{
+		QPS:      float32(c.Float64("cloud-api-qps")),
+		Burst:    c.Int("cloud-api-burst"),
+		CacheTTL: time.Duration(c.Int("cloud-api-cache-ttl")) * time.Second,
+	})
+
 	var d volume.Driver
 	if driverName != "" {
 		log.Infof("Using driver %v", driverName)
-		d, err = volume.Get(driverName)
+		resilienceConfig := volume.DefaultResilienceConfig
+		resilienceConfig.MaxRetries = c.Int("driver-call-retries")
+		resilienceConfig.CallTimeout = time.Duration(c.Int("driver-call-timeout")) * time.Second
+		resilienceConfig.FailureThreshold = c.Int("driver-failure-threshold")
+		resilienceConfig.OnCallFailure = metrics.IncVolumeDriverCallFailures
+		resilienceConfig.OnStateChange = func(driver string, degraded bool, reason string) {
+			metrics.SetVolumeDriverDegraded(driver, degraded)
+			if err := storkconfig.SetVolumeDriverCondition(driver, degraded, reason); err != nil {
+				log.Warnf("Error updating condition for volume driver %v: %v", driver, err)
+			}
+		}
+		volume.EnableResilience(resilienceConfig)
+		d, err = getDriver(driverName, c.StringSlice("driver-selector"))
 		if err != nil {
 			log.Fatalf("Error getting Stork Driver %v: %v", driverName, err)
 		}
@@ -314,20 +465,79 @@ func run(c *cli.Context) {
 			if err = ext.Start(); err != nil {
 				log.Fatalf("Error starting scheduler extender: %v", err)
 			}
+			health.Register("extender", ext.Ready)
 		}
 		if c.Bool("webhook-controller") {
 			webhook = &webhookadmission.Controller{
-				Driver:       d,
-				Recorder:     recorder,
-				SkipResource: c.String("webhook-skip-resources-annotation"),
+				Driver:                  d,
+				Recorder:                recorder,
+				SkipResource:            c.String("webhook-skip-resources-annotation"),
+				BlockInactiveAppScaleUp: c.Bool("webhook-block-inactive-app-scaleup"),
+				ServiceAccount:          c.String("webhook-service-account"),
+				CheckReferences:         c.Bool("webhook-check-references"),
+				ProtectReferencedCRs:    c.Bool("webhook-protect-referenced-crs"),
+				DefaultCRs:              c.Bool("webhook-default-crs"),
+				ConvertCRDs:             c.Bool("webhook-crd-conversion"),
+				PlacementHints:          c.Bool("webhook-placement-hints"),
+				DataMoverAffinity:       c.Bool("webhook-datamover-affinity"),
+				DriverEvents:            c.Bool("webhook-driver-events"),
+				CertLifetime:            time.Duration(c.Int("webhook-cert-lifetime")) * 24 * time.Hour,
+				AdminNamespace:          getAdminNamespace(c),
 			}
 			if err := webhook.Start(); err != nil {
 				log.Fatalf("error starting webhook controller: %v", err)
 			}
+			health.Register("webhook", webhook.Ready)
 		}
 	}
+	if port := c.Int("health-port"); port > 0 {
+		if err := health.Start(fmt.Sprintf(":%d", port)); err != nil {
+			log.Fatalf("Error starting health server: %v", err)
+		}
+	}
+	// The extender and webhook run on all the replicas, so every replica
+	// drains its connections on shutdown. The controllers are only stopped
+	// by the leader.
+	var leading int32
+	controllerStopChan := make(chan os.Signal, 1)
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signalChan
+		log.Printf("Shutdown signal received, exiting...")
+		// Stop picking up new work before tearing down the components
+		controllers.StartShutdown()
+		health.StartDraining()
+		time.Sleep(time.Duration(c.Int("shutdown-drain-period")) * time.Second)
+		if c.Bool("extender") && ext != nil {
+			if err := ext.Stop(); err != nil {
+				log.Warnf("Error stopping extender: %v", err)
+			}
+		}
+		if c.Bool("webhook-controller") && webhook != nil {
+			if err := webhook.Stop(); err != nil {
+				log.Warnf("error stopping webhook controller %v", err)
+			}
+		}
+		if atomic.LoadInt32(&leading) == 0 {
+			os.Exit(0)
+		}
+		controllerStopChan <- sig
+	}()
 	// Create operator-sdk manager that will manage all controllers.
-	mgr, err := manager.New(config, manager.Options{})
+	gracefulShutdownTimeout := time.Duration(c.Int("graceful-shutdown-timeout")) * time.Second
+	mgrOptions := manager.Options{
+		GracefulShutdownTimeout: &gracefulShutdownTimeout,
+	}
+	watchNamespaces, err := getWatchNamespaces(c)
+	if err != nil {
+		log.Fatalf("Error getting namespaces to watch: %v", err)
+	}
+	if len(watchNamespaces) > 0 {
+		log.Infof("Restricting controllers to namespaces %v", watchNamespaces)
+		mgrOptions.NewCache = ctrlcache.MultiNamespacedCacheBuilder(watchNamespaces)
+	}
+	mgr, err := manager.New(config, mgrOptions)
 	if err != nil {
 		log.Fatalf("Setup controller manager: %v", err)
 	}
@@ -338,7 +548,8 @@ func run(c *cli.Context) {
 	}
 
 	runFunc := func(context.Context) {
-		runStork(mgr, d, recorder, c)
+		atomic.StoreInt32(&leading, 1)
+		runStork(mgr, d, recorder, c, controllerStopChan, watchNamespaces)
 	}
 
 	if c.BoolT("leader-elect") {
@@ -385,17 +596,83 @@ func run(c *cli.Context) {
 	}
 }
 
+func getAdminNamespace(c *cli.Context) string {
+	adminNamespace := c.String("admin-namespace")
+	if adminNamespace == "" {
+		adminNamespace = c.String("migration-admin-namespace")
+	}
+	return adminNamespace
+}
+
 func displayLeader(name string) {
 	log.Infof("new leader detected, current leader: %s", name)
 }
 
-func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorder, c *cli.Context) {
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+// getDriver returns the volume driver. If multiple drivers are given they are
+// wrapped in a driver dispatching the calls for each PVC to the driver
+// owning it.
+func getDriver(driverNames string, selectorValues []string) (volume.Driver, error) {
+	names := strings.Split(driverNames, ",")
+	if len(names) == 1 {
+		return volume.Get(driverNames)
+	}
+	selectors := make(map[string]*volume.DriverSelector)
+	for _, value := range selectorValues {
+		name, selector, err := volume.ParseDriverSelector(value)
+		if err != nil {
+			return nil, err
+		}
+		selectors[name] = selector
+	}
+	drivers := make([]volume.Driver, 0, len(names))
+	for _, name := range names {
+		d, err := volume.Get(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		drivers = append(drivers, d)
+	}
+	return volume.NewMultiDriver(drivers, selectors)
+}
 
+// getWatchNamespaces returns the namespaces the controllers are restricted
+// to, from the flag or the stork configuration
+func getWatchNamespaces(c *cli.Context) ([]string, error) {
+	namespaces := make([]string, 0)
+	for _, value := range c.StringSlice("watch-namespaces") {
+		for _, ns := range strings.Split(value, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+	if len(namespaces) > 0 {
+		return namespaces, nil
+	}
+	return storkconfig.GetWatchNamespaces()
+}
+
+// runStork starts the controllers. The background loops that don't go
+// through the controller manager are restricted to the watch namespaces too,
+// so that a stork instance per tenant doesn't touch objects of other tenants.
+func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorder, c *cli.Context, signalChan chan os.Signal, watchNamespaces []string) {
+	// The backup sync controller, the cleanup monitor and the TTL janitor
+	// get their own channels so that they don't race with the shutdown
+	// handler below for the signal
+	syncStopChan := make(chan os.Signal, 1)
+	cleanupStopChan := make(chan os.Signal, 1)
+	ttlStopChan := make(chan os.Signal, 1)
+	orphanedPVStopChan := make(chan os.Signal, 1)
+
+	if err := storkconfig.Init(); err != nil {
+		log.Fatalf("Error initializing stork configuration: %v", err)
+	}
 	if err := rule.Init(); err != nil {
 		log.Fatalf("Error initializing rule: %v", err)
 	}
+	if err := cache.Init(mgr); err != nil {
+		log.Fatalf("Error initializing cache: %v", err)
+	}
 	qps := c.Int("k8s-api-qps")
 	burst := c.Int("k8s-api-burst")
 	resourceCollector := resourcecollector.ResourceCollector{
@@ -406,19 +683,18 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 	if err := resourceCollector.Init(nil); err != nil {
 		log.Fatalf("Error initializing ResourceCollector: %v", err)
 	}
-	adminNamespace := c.String("admin-namespace")
-	if adminNamespace == "" {
-		adminNamespace = c.String("migration-admin-namespace")
-	}
+	adminNamespace := getAdminNamespace(c)
 
 	monitor := &monitor.Monitor{
 		Driver:      d,
 		IntervalSec: c.Int64("health-monitor-interval"),
 		Recorder:    recorder,
+		Namespaces:  watchNamespaces,
 	}
 	snapshot := &snapshot.Snapshot{
-		Driver:   d,
-		Recorder: recorder,
+		Driver:          d,
+		Recorder:        recorder,
+		WatchNamespaces: watchNamespaces,
 	}
 	if err := schedule.Init(); err != nil {
 		log.Fatalf("Error initializing schedule: %v", err)
@@ -478,8 +754,9 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 			Recorder:          recorder,
 			ResourceCollector: resourceCollector,
 			RsyncTime:         c.Int64("application-backup-sync-interval"),
+			WatchNamespaces:   watchNamespaces,
 		}
-		if err := appManager.Init(mgr, adminNamespace, signalChan); err != nil {
+		if err := appManager.Init(mgr, adminNamespace, syncStopChan); err != nil {
 			log.Fatalf("Error initializing application manager: %v", err)
 		}
 	}
@@ -495,18 +772,43 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 		if err := dataexport.Init(mgr); err != nil {
 			log.Fatalf("Error initializing kdmp controller: %v", err)
 		}
+		dataCopyController := datacopy.NewController(mgr, recorder)
+		if err := dataCopyController.Init(mgr, adminNamespace); err != nil {
+			log.Fatalf("Error initializing data copy controller: %v", err)
+		}
+	}
+	cleanupMonitor := &cleanupaudit.Monitor{
+		Recorder:         recorder,
+		Interval:         time.Duration(c.Int("cleanup-audit-interval")) * time.Minute,
+		WarningThreshold: time.Duration(c.Int("cleanup-warning-threshold")) * time.Minute,
+		RemovalTimeout:   time.Duration(c.Int("cleanup-finalizer-timeout")) * time.Minute,
+		Namespaces:       watchNamespaces,
+	}
+	cleanupMonitor.Start(cleanupStopChan)
+	ttlJanitor := &ttl.Janitor{
+		Interval:   time.Duration(c.Int("ttl-check-interval")) * time.Minute,
+		Namespaces: watchNamespaces,
 	}
-	ctx := context.Background()
+	ttlJanitor.Start(ttlStopChan)
+	orphanedPVMonitor := &orphanedpv.Monitor{
+		Interval:   time.Duration(c.Int("orphaned-pv-report-interval")) * time.Minute,
+		Namespaces: watchNamespaces,
+	}
+	orphanedPVMonitor.Start(orphanedPVStopChan)
+	ctx, cancel := context.WithCancel(context.Background())
 
 	go func() {
 		for {
-			<-signalChan
-			log.Printf("Shutdown signal received, exiting...")
-			if c.Bool("extender") {
-				if err := ext.Stop(); err != nil {
-					log.Warnf("Error stopping extender: %v", err)
-				}
+			sig := <-signalChan
+			// Record where in-progress operations were while still holding
+			// the leadership so that the next leader can pick them up.
+			// Reconciles that are in flight are given the graceful shutdown
+			// timeout to finish first.
+			gracefulShutdownTimeout := time.Duration(c.Int("graceful-shutdown-timeout")) * time.Second
+			if !controllers.WaitForReconciles(gracefulShutdownTimeout) {
+				log.Warnf("Reconciles didn't finish in %v, checkpointing anyway", gracefulShutdownTimeout)
 			}
+			controllers.RunShutdownHandlers()
 			if c.Bool("health-monitor") {
 				if err := monitor.Stop(); err != nil {
 					log.Warnf("Error stopping monitor: %v", err)
@@ -520,12 +822,23 @@ func runStork(mgr manager.Manager, d volume.Driver, recorder record.EventRecorde
 			if err := d.Stop(); err != nil {
 				log.Warnf("Error stopping driver: %v", err)
 			}
-			if c.Bool("webhook-controller") {
-				if err := webhook.Stop(); err != nil {
-					log.Warnf("error stopping webhook controller %v", err)
-				}
+			select {
+			case syncStopChan <- sig:
+			default:
+			}
+			select {
+			case cleanupStopChan <- sig:
+			default:
+			}
+			select {
+			case ttlStopChan <- sig:
+			default:
+			}
+			select {
+			case orphanedPVStopChan <- sig:
+			default:
 			}
-			ctx.Done()
+			cancel()
 		}
 	}()
 
@@ -7,8 +7,6 @@ import (
 	aws_sdk "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
@@ -19,6 +17,7 @@ import (
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/secretprovider"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
@@ -69,36 +68,18 @@ type aws struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+	storkvolume.ProgressNotSupported
 }
 
 func (a *aws) Init(_ interface{}) error {
-
-	s, err := session.NewSession(&aws_sdk.Config{})
-	if err != nil {
-		return err
-	}
-	creds := credentials.NewChainCredentials(
-		[]credentials.Provider{
-			&credentials.EnvProvider{},
-			&ec2rolecreds.EC2RoleProvider{
-				Client: ec2metadata.New(s),
-			},
-			&credentials.SharedCredentialsProvider{},
-		})
-	metadata, err := cloud.NewMetadata()
+	client, err := storkvolume.GetAWSClient()
 	if err != nil {
 		return err
 	}
-
-	s, err = session.NewSession(&aws_sdk.Config{
-		Region:      aws_sdk.String(metadata.GetRegion()),
-		Credentials: creds,
-	})
-	if err != nil {
-		return err
-	}
-	a.client = ec2.New(s)
-
+	a.client = client
 	return nil
 }
 
@@ -185,10 +166,11 @@ func isCsiProvisioner(provisioner string) bool {
 func (a *aws) StartBackup(backup *storkapi.ApplicationBackup,
 	pvcs []v1.PersistentVolumeClaim,
 ) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	client, err := a.getAWSClient(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		return nil, err
 	}
+	clients := a.getAWSClients(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
 	for _, pvc := range pvcs {
@@ -201,6 +183,14 @@ func (a *aws) StartBackup(backup *storkapi.ApplicationBackup,
 		volumeInfo.PersistentVolumeClaimUID = string(pvc.UID)
 		volumeInfo.Namespace = pvc.Namespace
 		volumeInfo.DriverName = storkvolume.AWSDriverName
+		account := storkvolume.GetCloudAccountForPVC(backupLocation, &pvc)
+		if account != "" {
+			volumeInfo.Options = map[string]string{storkvolume.CloudAccountOption: account}
+		}
+		client, err := getClient(clients, account)
+		if err != nil {
+			return nil, err
+		}
 
 		pvName, err := core.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
 		if err != nil {
@@ -263,7 +253,11 @@ func (a *aws) StartBackup(backup *storkapi.ApplicationBackup,
 			var snapshot *ec2.Snapshot
 			var snapErr error
 			err = wait.ExponentialBackoff(apiBackoff, func() (bool, error) {
-				snapshot, snapErr = client.CreateSnapshot(snapshotInput)
+				snapErr = a.throttle().Call(func() error {
+					var err error
+					snapshot, err = client.CreateSnapshot(snapshotInput)
+					return err
+				})
 				if snapErr != nil {
 					if awsErr, ok := snapErr.(awserr.Error); ok {
 						if !isExponentialError(awsErr) {
@@ -302,17 +296,70 @@ func (a *aws) getEBSSnapshot(snapshotID string, filters map[string]string, clien
 		input.Filters = a.getFiltersFromMap(filters)
 	}
 
-	output, err := client.DescribeSnapshots(input)
+	describe := func() (interface{}, error) {
+		return client.DescribeSnapshots(input)
+	}
+	var result interface{}
+	var err error
+	// Only cache lookups by ID, lookups by tags are used to check if a
+	// snapshot has already been created
+	if snapshotID != "" && len(filters) == 0 {
+		result, err = a.throttle().CachedCall("snapshot/"+snapshotID, describe)
+	} else {
+		err = a.throttle().Call(func() error {
+			var callErr error
+			result, callErr = describe()
+			return callErr
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	output := result.(*ec2.DescribeSnapshotsOutput)
 	if len(output.Snapshots) != 1 {
 		return nil, fmt.Errorf("received %v snapshots for %v", len(output.Snapshots), snapshotID)
 	}
 	return output.Snapshots[0], nil
 }
 
+// listStorkSnapshots returns all the snapshots created by stork in the
+// account and region of the client, indexed by snapshot ID. The list is
+// cached so that the status of all the snapshots being polled can be checked
+// with a single call.
+func (a *aws) listStorkSnapshots(client *ec2.EC2) (map[string]*ec2.Snapshot, error) {
+	result, err := a.throttle().CachedCall("snapshots/"+awsAccountKey(client), func() (interface{}, error) {
+		input := &ec2.DescribeSnapshotsInput{
+			OwnerIds: []*string{aws_sdk.String("self")},
+			Filters:  a.getFiltersFromMap(map[string]string{createdByTag: "stork"}),
+		}
+		snapshots := make(map[string]*ec2.Snapshot)
+		err := client.DescribeSnapshotsPages(input, func(output *ec2.DescribeSnapshotsOutput, _ bool) bool {
+			for _, snapshot := range output.Snapshots {
+				snapshots[*snapshot.SnapshotId] = snapshot
+			}
+			return true
+		})
+		return snapshots, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]*ec2.Snapshot), nil
+}
+
+// awsAccountKey returns a key identifying the account and region used by the
+// client
+func awsAccountKey(client *ec2.EC2) string {
+	key := aws_sdk.StringValue(client.Config.Region)
+	if client.Config.Credentials != nil {
+		if creds, err := client.Config.Credentials.Get(); err == nil {
+			key += "/" + creds.AccessKeyID
+		}
+	}
+	return key
+}
+
 func (a *aws) getFiltersFromMap(filters map[string]string) []*ec2.Filter {
 	tagFilters := make([]*ec2.Filter, 0)
 	for k, v := range filters {
@@ -325,21 +372,30 @@ func (a *aws) getFiltersFromMap(filters map[string]string) []*ec2.Filter {
 }
 
 func (a *aws) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	client, err := a.getAWSClient(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return nil, err
-	}
-
+	clients := a.getAWSClients(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.AWSDriverName {
 			continue
 		}
-		snapshot, err := a.getEBSSnapshot(vInfo.BackupID, nil, client)
+		client, err := getClient(clients, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return nil, err
+		}
+		snapshots, err := a.listStorkSnapshots(client)
 		if err != nil {
 			return nil, err
 		}
+		snapshot, ok := snapshots[vInfo.BackupID]
+		if !ok {
+			// Snapshots created after the list was cached need to be
+			// looked up individually
+			snapshot, err = a.getEBSSnapshot(vInfo.BackupID, nil, client)
+			if err != nil {
+				return nil, err
+			}
+		}
 		switch *snapshot.State {
 		case "pending":
 			vInfo.Status = storkapi.ApplicationBackupStatusInProgress
@@ -366,20 +422,25 @@ func (a *aws) CancelBackup(backup *storkapi.ApplicationBackup) error {
 }
 
 func (a *aws) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
-	client, err := a.getAWSClient(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return true, err
-	}
+	clients := a.getAWSClients(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.AWSDriverName {
 			continue
 		}
+		client, err := getClient(clients, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return true, err
+		}
 		input := &ec2.DeleteSnapshotInput{
 			SnapshotId: aws_sdk.String(vInfo.BackupID),
 		}
 
-		_, err := client.DeleteSnapshot(input)
+		err = a.throttle().Call(func() error {
+			_, err := client.DeleteSnapshot(input)
+			return err
+		})
+		a.throttle().Invalidate("snapshot/" + vInfo.BackupID)
 		if err != nil {
 			// Do nothing if snapshot isn't found
 			if awsErr, ok := err.(awserr.Error); ok {
@@ -422,10 +483,7 @@ func (a *aws) StartRestore(
 	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
 	preRestoreObjects []runtime.Unstructured,
 ) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	client, err := a.getAWSClient(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
+	clients := a.getAWSClients(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, backupVolumeInfo := range volumeBackupInfos {
@@ -436,6 +494,15 @@ func (a *aws) StartRestore(
 		volumeInfo.SourceVolume = backupVolumeInfo.Volume
 		volumeInfo.DriverName = storkvolume.AWSDriverName
 		volumeInfo.RestoreVolume = a.generatePVName()
+		// Volumes are restored in the account holding the snapshot
+		account := backupVolumeInfo.Options[storkvolume.CloudAccountOption]
+		if account != "" {
+			volumeInfo.Options = map[string]string{storkvolume.CloudAccountOption: account}
+		}
+		client, err := getClient(clients, account)
+		if err != nil {
+			return nil, err
+		}
 
 		tags := storkvolume.GetApplicationRestoreLabels(restore, volumeInfo)
 		tags[nameTag] = volumeInfo.RestoreVolume
@@ -483,7 +550,11 @@ func (a *aws) StartRestore(
 			var createErr error
 			var createVolume *ec2.Volume
 			err = wait.ExponentialBackoff(apiBackoff, func() (bool, error) {
-				createVolume, createErr = client.CreateVolume(input)
+				createErr = a.throttle().Call(func() error {
+					var err error
+					createVolume, err = client.CreateVolume(input)
+					return err
+				})
 				if createErr != nil {
 					if awsErr, ok := createErr.(awserr.Error); ok {
 						if !isExponentialError(awsErr) {
@@ -517,10 +588,7 @@ func (a *aws) CancelRestore(*storkapi.ApplicationRestore) error {
 }
 
 func (a *aws) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	client, err := a.getAWSClient(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
+	clients := a.getAWSClients(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, vInfo := range restore.Status.Volumes {
@@ -531,6 +599,10 @@ func (a *aws) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 			volumeInfos = append(volumeInfos, vInfo)
 			continue
 		}
+		client, err := getClient(clients, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return nil, err
+		}
 		ebsVolume, err := storkvolume.GetEBSVolume(vInfo.RestoreVolume, nil, client)
 		if err != nil {
 			if awsErr, ok := err.(awserr.Error); ok {
@@ -608,6 +680,11 @@ func (a *aws) CleanupRestoreResources(*storkapi.ApplicationRestore) error {
 	return nil
 }
 
+// throttle returns the throttle shared by all the calls made to the AWS APIs
+func (a *aws) throttle() *storkvolume.CloudAPIThrottle {
+	return storkvolume.GetCloudAPIThrottle(storkvolume.AWSDriverName)
+}
+
 // getAWSClientFromBackupLocation will return a client object using creds referred in backuplocation
 func (a *aws) getAWSClientFromBackupLocation(backupLocationName, ns string) *ec2.EC2 {
 	var client *ec2.EC2
@@ -616,14 +693,22 @@ func (a *aws) getAWSClientFromBackupLocation(backupLocationName, ns string) *ec2
 		logrus.Errorf("error getting backup location %s resource: %v", backupLocationName, err)
 		return nil
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		logrus.Errorf("error getting external secrets for backup location %s: %v", backupLocationName, err)
+		return nil
+	}
 	metadata, err := cloud.NewMetadata()
 	if err != nil {
 		logrus.Errorf("error creating metadata instance: %v", err)
 		return nil
 	}
-	if len(backupLocation.Cluster.SecretConfig) > 0 {
+	if len(backupLocation.Cluster.SecretConfig) > 0 || backupLocation.Cluster.ExternalSecretConfig != nil {
+		region := metadata.GetRegion()
+		if backupLocation.Cluster.AWSClusterConfig.Region != "" {
+			region = backupLocation.Cluster.AWSClusterConfig.Region
+		}
 		s, err := session.NewSession(&aws_sdk.Config{
-			Region:      aws_sdk.String(metadata.GetRegion()),
+			Region:      aws_sdk.String(region),
 			Credentials: credentials.NewStaticCredentials(backupLocation.Cluster.AWSClusterConfig.AccessKeyID, backupLocation.Cluster.AWSClusterConfig.SecretAccessKey, ""),
 		})
 		if err != nil {
@@ -635,6 +720,56 @@ func (a *aws) getAWSClientFromBackupLocation(backupLocationName, ns string) *ec2
 	return client
 }
 
+// getAWSClientForAccount returns a client using the credentials of an
+// account of the backup location. Unlike the cluster credentials, it doesn't
+// fall back to the credentials stork is running with since the volumes are
+// in another account.
+func (a *aws) getAWSClientForAccount(backupLocationName, ns, account string) (*ec2.EC2, error) {
+	backupLocation, err := storkops.Instance().GetBackupLocation(backupLocationName, ns)
+	if err != nil {
+		return nil, err
+	}
+	cloudAccount := backupLocation.Cluster.GetAccount(account)
+	if cloudAccount == nil || cloudAccount.AWSClusterConfig == nil {
+		return nil, fmt.Errorf("AWS credentials for account %v not found in backup location %v/%v", account, ns, backupLocationName)
+	}
+	region := cloudAccount.AWSClusterConfig.Region
+	if region == "" {
+		metadata, err := cloud.NewMetadata()
+		if err != nil {
+			return nil, err
+		}
+		region = metadata.GetRegion()
+	}
+	s, err := session.NewSession(&aws_sdk.Config{
+		Region:      aws_sdk.String(region),
+		Credentials: credentials.NewStaticCredentials(cloudAccount.AWSClusterConfig.AccessKeyID, cloudAccount.AWSClusterConfig.SecretAccessKey, ""),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating aws client session for account %v of backuplocation %v: %v", account, backupLocationName, err)
+	}
+	return ec2.New(s), nil
+}
+
+// getAWSClients returns the clients for the cluster and the accounts of the
+// backup location
+func (a *aws) getAWSClients(backupLocationName, ns string) *storkvolume.CloudClients {
+	return storkvolume.NewCloudClients(func(account string) (interface{}, error) {
+		if account == "" {
+			return a.getAWSClient(backupLocationName, ns)
+		}
+		return a.getAWSClientForAccount(backupLocationName, ns, account)
+	})
+}
+
+func getClient(clients *storkvolume.CloudClients, account string) (*ec2.EC2, error) {
+	client, err := clients.Get(account)
+	if err != nil {
+		return nil, err
+	}
+	return client.(*ec2.EC2), nil
+}
+
 func (a *aws) getAWSClient(backupLocationName, ns string) (*ec2.EC2, error) {
 	var client *ec2.EC2
 	// if backuplocation has creds wrt the cluster, need to use that
@@ -0,0 +1,114 @@
+package volume
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+)
+
+const (
+	// AWSCredentialSourceEnv selects the credentials used by the AWS driver
+	// when the BackupLocation doesn't have cluster credentials. The chain of
+	// all the sources is used if it isn't set.
+	AWSCredentialSourceEnv = "STORK_AWS_CREDENTIAL_SOURCE"
+	// AWSRequireIMDSv2Env can be set to true to fail the initialization of
+	// the AWS driver if the instance metadata service doesn't support
+	// IMDSv2 sessions
+	AWSRequireIMDSv2Env = "STORK_AWS_REQUIRE_IMDSV2"
+
+	// AWSCredentialSourceEnvironment uses the keys from the AWS_ACCESS_KEY_ID
+	// and AWS_SECRET_ACCESS_KEY environment variables
+	AWSCredentialSourceEnvironment = "environment"
+	// AWSCredentialSourceIRSA uses the IAM role for the service account of
+	// the pod, configured through the AWS_ROLE_ARN and
+	// AWS_WEB_IDENTITY_TOKEN_FILE environment variables
+	AWSCredentialSourceIRSA = "irsa"
+	// AWSCredentialSourceInstance uses the IAM role of the instance
+	AWSCredentialSourceInstance = "instance"
+	// AWSCredentialSourceShared uses the shared credentials file
+	AWSCredentialSourceShared = "shared"
+
+	awsRoleARNEnv              = "AWS_ROLE_ARN"
+	awsWebIdentityTokenFileEnv = "AWS_WEB_IDENTITY_TOKEN_FILE"
+	awsRoleSessionNameEnv      = "AWS_ROLE_SESSION_NAME"
+	awsIMDSTokenPath           = "/latest/api/token"
+	awsIMDSTokenTTLHeader      = "X-aws-ec2-metadata-token-ttl-seconds"
+)
+
+// GetAWSCredentials returns the credentials selected by
+// AWSCredentialSourceEnv. The instance metadata service is accessed with
+// IMDSv2 sessions when it supports them.
+func GetAWSCredentials(s *session.Session) (*credentials.Credentials, error) {
+	metadata := ec2metadata.New(s)
+	if required, _ := strconv.ParseBool(os.Getenv(AWSRequireIMDSv2Env)); required {
+		if err := checkIMDSv2(metadata.Endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	irsa := func() credentials.Provider {
+		sessionName := os.Getenv(awsRoleSessionNameEnv)
+		if sessionName == "" {
+			sessionName = "stork-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		}
+		return stscreds.NewWebIdentityRoleProvider(sts.New(s), os.Getenv(awsRoleARNEnv), sessionName, os.Getenv(awsWebIdentityTokenFileEnv))
+	}
+	instance := &ec2rolecreds.EC2RoleProvider{
+		Client: metadata,
+	}
+
+	source := os.Getenv(AWSCredentialSourceEnv)
+	switch source {
+	case "":
+		providers := []credentials.Provider{&credentials.EnvProvider{}}
+		if os.Getenv(awsRoleARNEnv) != "" && os.Getenv(awsWebIdentityTokenFileEnv) != "" {
+			providers = append(providers, irsa())
+		}
+		providers = append(providers, instance, &credentials.SharedCredentialsProvider{})
+		return credentials.NewChainCredentials(providers), nil
+	case AWSCredentialSourceEnvironment:
+		return credentials.NewCredentials(&credentials.EnvProvider{}), nil
+	case AWSCredentialSourceIRSA:
+		if os.Getenv(awsRoleARNEnv) == "" || os.Getenv(awsWebIdentityTokenFileEnv) == "" {
+			return nil, fmt.Errorf("%v and %v need to be set to use IRSA credentials", awsRoleARNEnv, awsWebIdentityTokenFileEnv)
+		}
+		return credentials.NewCredentials(irsa()), nil
+	case AWSCredentialSourceInstance:
+		return credentials.NewCredentials(instance), nil
+	case AWSCredentialSourceShared:
+		return credentials.NewCredentials(&credentials.SharedCredentialsProvider{}), nil
+	}
+	return nil, fmt.Errorf("invalid AWS credential source %v", source)
+}
+
+// checkIMDSv2 returns an error if a session token can't be fetched from the
+// instance metadata service at the endpoint. The AWS SDK only falls back to
+// IMDSv1 if it can't get a token.
+func checkIMDSv2(endpoint string) error {
+	req, err := http.NewRequest(http.MethodPut, endpoint+awsIMDSTokenPath, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(awsIMDSTokenTTLHeader, "60")
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("IMDSv2 is required but a session token couldn't be fetched: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("IMDSv2 is required but fetching a session token failed with status %v", resp.Status)
+	}
+	return nil
+}
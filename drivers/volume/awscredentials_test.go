@@ -0,0 +1,216 @@
+//go:build unittest
+// +build unittest
+
+package volume
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIMDSToken   = "imds-token"
+	testWebIdentity = "web-identity-token"
+)
+
+// awsMetadataServer serves the instance metadata service and STS
+type awsMetadataServer struct {
+	*httptest.Server
+	// imdsv1Only makes requests for IMDSv2 session tokens fail
+	imdsv1Only bool
+	// noRole makes the instance have no IAM role
+	noRole bool
+}
+
+func newAWSMetadataServer(t *testing.T) *awsMetadataServer {
+	s := &awsMetadataServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == awsIMDSTokenPath:
+			if s.imdsv1Only || r.Header.Get(awsIMDSTokenTTLHeader) == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Header().Set(awsIMDSTokenTTLHeader, r.Header.Get(awsIMDSTokenTTLHeader))
+			fmt.Fprint(w, testIMDSToken)
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/":
+			if s.noRole {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, "role")
+		case r.URL.Path == "/latest/meta-data/iam/security-credentials/role":
+			key := "instance-v1-key"
+			if r.Header.Get("X-aws-ec2-metadata-token") == testIMDSToken {
+				key = "instance-v2-key"
+			}
+			fmt.Fprintf(w, `{"Code": "Success", "AccessKeyId": "%v", "SecretAccessKey": "secret", "Token": "token", "Expiration": "2100-01-01T00:00:00Z"}`, key)
+		case r.Method == http.MethodPost && r.URL.Path == "/":
+			require.NoError(t, r.ParseForm())
+			if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" ||
+				r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/stork" ||
+				r.Form.Get("WebIdentityToken") != testWebIdentity {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>irsa-key</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>token</SessionToken>
+      <Expiration>2100-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *awsMetadataServer) session(t *testing.T) *session.Session {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config: aws.Config{
+			Region:   aws.String("us-east-1"),
+			Endpoint: aws.String(s.URL),
+		},
+		EC2IMDSEndpoint: s.URL,
+	})
+	require.NoError(t, err)
+	return sess
+}
+
+// setupAWSCredentialEnv clears the credentials in the environment and sets
+// up the fixtures for IRSA and the shared credentials file. Returns the
+// path of the web identity token.
+func setupAWSCredentialEnv(t *testing.T) string {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte(testWebIdentity), 0600))
+	sharedFile := filepath.Join(dir, "credentials")
+	require.NoError(t, ioutil.WriteFile(sharedFile, []byte("[default]\naws_access_key_id = shared-key\naws_secret_access_key = secret\n"), 0600))
+
+	for _, env := range []string{
+		AWSCredentialSourceEnv, AWSRequireIMDSv2Env,
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_ACCESS_KEY", "AWS_SECRET_KEY", "AWS_SESSION_TOKEN",
+		awsRoleARNEnv, awsWebIdentityTokenFileEnv, awsRoleSessionNameEnv, "AWS_PROFILE",
+	} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", sharedFile)
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	return tokenFile
+}
+
+func TestAWSCredentialsChain(t *testing.T) {
+	server := newAWSMetadataServer(t)
+	tokenFile := setupAWSCredentialEnv(t)
+
+	getKey := func() string {
+		creds, err := GetAWSCredentials(server.session(t))
+		require.NoError(t, err)
+		value, err := creds.Get()
+		require.NoError(t, err)
+		return value.AccessKeyID
+	}
+
+	// Sources are used in the order environment, IRSA, instance, shared file
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv(awsRoleARNEnv, "arn:aws:iam::123456789012:role/stork")
+	t.Setenv(awsWebIdentityTokenFileEnv, tokenFile)
+	require.Equal(t, "env-key", getKey())
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	require.Equal(t, "irsa-key", getKey())
+
+	t.Setenv(awsRoleARNEnv, "")
+	t.Setenv(awsWebIdentityTokenFileEnv, "")
+	require.Equal(t, "instance-v2-key", getKey(), "Instance credentials should be fetched with an IMDSv2 session")
+
+	server.noRole = true
+	require.Equal(t, "shared-key", getKey())
+}
+
+func TestAWSCredentialsSource(t *testing.T) {
+	server := newAWSMetadataServer(t)
+	tokenFile := setupAWSCredentialEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv(awsRoleARNEnv, "arn:aws:iam::123456789012:role/stork")
+	t.Setenv(awsWebIdentityTokenFileEnv, tokenFile)
+
+	for _, test := range []struct {
+		source string
+		key    string
+	}{
+		{AWSCredentialSourceEnvironment, "env-key"},
+		{AWSCredentialSourceIRSA, "irsa-key"},
+		{AWSCredentialSourceInstance, "instance-v2-key"},
+		{AWSCredentialSourceShared, "shared-key"},
+	} {
+		t.Setenv(AWSCredentialSourceEnv, test.source)
+		creds, err := GetAWSCredentials(server.session(t))
+		require.NoError(t, err, test.source)
+		value, err := creds.Get()
+		require.NoError(t, err, test.source)
+		require.Equal(t, test.key, value.AccessKeyID, test.source)
+	}
+
+	// Selected sources don't fall back to the others
+	t.Setenv(AWSCredentialSourceEnv, AWSCredentialSourceInstance)
+	server.noRole = true
+	creds, err := GetAWSCredentials(server.session(t))
+	require.NoError(t, err)
+	_, err = creds.Get()
+	require.Error(t, err, "Expected error getting credentials of instance without a role")
+
+	t.Setenv(AWSCredentialSourceEnv, AWSCredentialSourceIRSA)
+	t.Setenv(awsRoleARNEnv, "")
+	_, err = GetAWSCredentials(server.session(t))
+	require.Error(t, err, "Expected error for IRSA without a role")
+	require.Contains(t, err.Error(), awsRoleARNEnv)
+
+	t.Setenv(AWSCredentialSourceEnv, "invalid")
+	_, err = GetAWSCredentials(server.session(t))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid AWS credential source")
+}
+
+func TestAWSRequireIMDSv2(t *testing.T) {
+	server := newAWSMetadataServer(t)
+	setupAWSCredentialEnv(t)
+	t.Setenv(AWSCredentialSourceEnv, AWSCredentialSourceInstance)
+
+	// IMDSv1 is used by the SDK if IMDSv2 isn't supported unless it is
+	// required
+	server.imdsv1Only = true
+	creds, err := GetAWSCredentials(server.session(t))
+	require.NoError(t, err)
+	value, err := creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "instance-v1-key", value.AccessKeyID)
+
+	t.Setenv(AWSRequireIMDSv2Env, "true")
+	_, err = GetAWSCredentials(server.session(t))
+	require.Error(t, err, "Expected error when IMDSv2 is required but not supported")
+	require.Contains(t, err.Error(), "IMDSv2 is required")
+
+	server.imdsv1Only = false
+	creds, err = GetAWSCredentials(server.session(t))
+	require.NoError(t, err)
+	value, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "instance-v2-key", value.AccessKeyID)
+}
@@ -19,6 +19,7 @@ import (
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/secretprovider"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
@@ -44,6 +45,7 @@ const (
 	subscriptionIDKey         = "subscriptionId"
 	resourceGroupKey          = "resourceGroupName"
 	metadataURL               = "http://169.254.169.254/metadata/instance/compute"
+	diskIDKey                 = "diskID"
 	apiVersion                = "2018-02-01"
 )
 
@@ -58,6 +60,10 @@ type azure struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+	storkvolume.ProgressNotSupported
 }
 
 type azureSession struct {
@@ -65,13 +71,14 @@ type azureSession struct {
 	clientID       string
 	tenantID       string
 	subscriptionID string
+	resourceGroup  string
 	diskClient     compute.DisksClient
 	snapshotClient compute.SnapshotsClient
 }
 
 func (a *azure) Init(_ interface{}) error {
 
-	authorizer, err := auth.NewAuthorizerFromEnvironment()
+	authorizer, err := getAuthorizer()
 	if err != nil {
 		return err
 	}
@@ -224,7 +231,12 @@ func isCsiProvisioner(provisioner string) bool {
 }
 
 func (a *azure) findExistingSnapshot(tags map[string]string, snapshotClient compute.SnapshotsClient) (*compute.Snapshot, error) {
-	snapshotList, err := snapshotClient.List(context.TODO())
+	var snapshotList compute.SnapshotListPage
+	err := a.throttle().Call(func() error {
+		var err error
+		snapshotList, err = snapshotClient.List(context.TODO())
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -244,7 +256,7 @@ func (a *azure) findExistingSnapshot(tags map[string]string, snapshotClient comp
 		}
 		// Move to the next page if there are more snapshots
 		if snapshotList.NotDone() {
-			if err := snapshotList.Next(); err != nil {
+			if err := a.throttle().Call(snapshotList.Next); err != nil {
 				return nil, err
 			}
 		} else {
@@ -258,12 +270,11 @@ func (a *azure) StartBackup(
 	backup *storkapi.ApplicationBackup,
 	pvcs []v1.PersistentVolumeClaim,
 ) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	azureSession, err := a.getAzureSession(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		return nil, err
 	}
-	snapshotClient := azureSession.snapshotClient
-	diskClient := azureSession.diskClient
+	sessions := a.getAzureSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
@@ -272,6 +283,14 @@ func (a *azure) StartBackup(
 			log.ApplicationBackupLog(backup).Warnf("Ignoring PVC %v which is being deleted", pvc.Name)
 			continue
 		}
+		account := storkvolume.GetCloudAccountForPVC(backupLocation, &pvc)
+		azureSession, err := getSession(sessions, account)
+		if err != nil {
+			return nil, err
+		}
+		snapshotClient := azureSession.snapshotClient
+		diskClient := azureSession.diskClient
+
 		volumeInfo := &storkapi.ApplicationBackupVolumeInfo{
 			PersistentVolumeClaim:    pvc.Name,
 			PersistentVolumeClaimUID: string(pvc.UID),
@@ -279,9 +298,12 @@ func (a *azure) StartBackup(
 			DriverName:               storkvolume.AzureDriverName,
 			Volume:                   pvc.Spec.VolumeName,
 			Options: map[string]string{
-				resourceGroupKey: a.resourceGroup,
+				resourceGroupKey: azureSession.resourceGroup,
 			},
 		}
+		if account != "" {
+			volumeInfo.Options[storkvolume.CloudAccountOption] = account
+		}
 		volumeInfos = append(volumeInfos, volumeInfo)
 
 		pvName, err := core.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
@@ -309,10 +331,13 @@ func (a *azure) StartBackup(
 			} else {
 				return nil, fmt.Errorf("azure disk info not found in PV %v", pvName)
 			}
-			disk, err := diskClient.Get(context.TODO(), a.resourceGroup, volume)
+			result, err := a.throttle().CachedCall("disk/"+azureSession.resourceGroup+"/"+volume, func() (interface{}, error) {
+				return diskClient.Get(context.TODO(), azureSession.resourceGroup, volume)
+			})
 			if err != nil {
 				return nil, err
 			}
+			disk := result.(compute.Disk)
 
 			snapshot := compute.Snapshot{
 				Name: to.StringPtr("stork-snapshot-" + string(uuid.NewUUID())),
@@ -328,7 +353,10 @@ func (a *azure) StartBackup(
 			for k, v := range tags {
 				snapshot.Tags[k] = to.StringPtr(v)
 			}
-			_, err = snapshotClient.CreateOrUpdate(context.TODO(), a.resourceGroup, *snapshot.Name, snapshot)
+			err = a.throttle().Call(func() error {
+				_, err := snapshotClient.CreateOrUpdate(context.TODO(), azureSession.resourceGroup, *snapshot.Name, snapshot)
+				return err
+			})
 			if err != nil {
 				return nil, fmt.Errorf("error triggering backup for volume: %v (PVC: %v, Namespace: %v): %v", volume, pvc.Name, pvc.Namespace, err)
 			}
@@ -339,22 +367,36 @@ func (a *azure) StartBackup(
 }
 
 func (a *azure) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	azureSession, err := a.getAzureSession(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	snapshotClient := azureSession.snapshotClient
-
+	sessions := a.getAzureSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.AzureDriverName {
 			continue
 		}
-		snapshot, err := snapshotClient.Get(context.TODO(), a.resourceGroup, vInfo.BackupID)
+		azureSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
 		if err != nil {
 			return nil, err
 		}
+		snapshotClient := azureSession.snapshotClient
+		resourceGroup := azureSession.resourceGroup
+		snapshots, err := a.listStorkSnapshots(snapshotClient, resourceGroup)
+		if err != nil {
+			return nil, err
+		}
+		snapshot, ok := snapshots[vInfo.BackupID]
+		if !ok {
+			// Snapshots created after the list was cached need to be
+			// looked up individually
+			backupID := vInfo.BackupID
+			result, err := a.throttle().CachedCall("snapshot/"+resourceGroup+"/"+backupID, func() (interface{}, error) {
+				return snapshotClient.Get(context.TODO(), resourceGroup, backupID)
+			})
+			if err != nil {
+				return nil, err
+			}
+			snapshot = result.(compute.Snapshot)
+		}
 		switch *snapshot.ProvisioningState {
 		case "Failed":
 			vInfo.Status = storkapi.ApplicationBackupStatusFailed
@@ -375,23 +417,62 @@ func (a *azure) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi
 
 }
 
+// listStorkSnapshots returns all the snapshots created by stork in the
+// subscription and resource group, indexed by name. The list is cached so
+// that the status of all the snapshots being polled can be checked with a
+// single call.
+func (a *azure) listStorkSnapshots(snapshotClient compute.SnapshotsClient, resourceGroup string) (map[string]compute.Snapshot, error) {
+	result, err := a.throttle().CachedCall("snapshots/"+snapshotClient.SubscriptionID+"/"+resourceGroup, func() (interface{}, error) {
+		snapshotList, err := snapshotClient.ListByResourceGroup(context.TODO(), resourceGroup)
+		if err != nil {
+			return nil, err
+		}
+		snapshots := make(map[string]compute.Snapshot)
+		for {
+			for _, snap := range snapshotList.Values() {
+				if createdBy, present := snap.Tags["created-by"]; snap.Name != nil && present && *createdBy == "stork" {
+					snapshots[*snap.Name] = snap
+				}
+			}
+			if !snapshotList.NotDone() {
+				break
+			}
+			// Every page is fetched with a separate call
+			if err := a.throttle().Call(snapshotList.Next); err != nil {
+				return nil, err
+			}
+		}
+		return snapshots, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]compute.Snapshot), nil
+}
+
 func (a *azure) CancelBackup(backup *storkapi.ApplicationBackup) error {
 	_, err := a.DeleteBackup(backup)
 	return err
 }
 
 func (a *azure) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
-	azureSession, err := a.getAzureSession(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return true, err
-	}
-	snapshotClient := azureSession.snapshotClient
+	sessions := a.getAzureSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.AzureDriverName {
 			continue
 		}
-		_, err := snapshotClient.Delete(context.TODO(), a.resourceGroup, vInfo.BackupID)
+		azureSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return true, err
+		}
+		snapshotClient := azureSession.snapshotClient
+		resourceGroup := azureSession.resourceGroup
+		err = a.throttle().Call(func() error {
+			_, err := snapshotClient.Delete(context.TODO(), resourceGroup, vInfo.BackupID)
+			return err
+		})
+		a.throttle().Invalidate("snapshot/" + resourceGroup + "/" + vInfo.BackupID)
 		if err != nil {
 			// Ignore if the snaphot has already been deleted
 			if azureErr, ok := err.(autorest.DetailedError); ok {
@@ -408,18 +489,24 @@ func (a *azure) UpdateMigratedPersistentVolumeSpec(
 	pv *v1.PersistentVolume,
 	vInfo *storkapi.ApplicationRestoreVolumeInfo,
 ) (*v1.PersistentVolume, error) {
-	disk, err := a.diskClient.Get(context.TODO(), a.resourceGroup, pv.Name)
-	if err != nil {
-		return nil, err
+	// The ID of disks restored in another account is recorded when they are
+	// created since the driver doesn't have the credentials for the account
+	diskID := vInfo.Options[diskIDKey]
+	if diskID == "" {
+		disk, err := a.diskClient.Get(context.TODO(), a.resourceGroup, pv.Name)
+		if err != nil {
+			return nil, err
+		}
+		diskID = *disk.ID
 	}
 
 	if pv.Spec.CSI != nil {
-		pv.Spec.CSI.VolumeHandle = *disk.ID
+		pv.Spec.CSI.VolumeHandle = diskID
 		return pv, nil
 	}
 
 	pv.Spec.AzureDisk.DiskName = pv.Name
-	pv.Spec.AzureDisk.DataDiskURI = *disk.ID
+	pv.Spec.AzureDisk.DataDiskURI = diskID
 
 	return pv, nil
 }
@@ -429,7 +516,12 @@ func (a *azure) generatePVName() string {
 }
 
 func (a *azure) findExistingDisk(tags map[string]string, diskClient compute.DisksClient) (*compute.Disk, error) {
-	diskList, err := diskClient.List(context.TODO())
+	var diskList compute.DiskListPage
+	err := a.throttle().Call(func() error {
+		var err error
+		diskList, err = diskClient.List(context.TODO())
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -449,7 +541,7 @@ func (a *azure) findExistingDisk(tags map[string]string, diskClient compute.Disk
 		}
 		// Move to the next page if there are more disks
 		if diskList.NotDone() {
-			if err := diskList.Next(); err != nil {
+			if err := a.throttle().Call(diskList.Next); err != nil {
 				return nil, err
 			}
 		} else {
@@ -472,27 +564,35 @@ func (a *azure) StartRestore(
 	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
 	preRestoreObjects []runtime.Unstructured,
 ) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	azureSession, err := a.getAzureSession(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	snapshotClient := azureSession.snapshotClient
-	diskClient := azureSession.diskClient
+	sessions := a.getAzureSessions(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, backupVolumeInfo := range volumeBackupInfos {
+		// Volumes are restored in the subscription holding the snapshot
+		account := backupVolumeInfo.Options[storkvolume.CloudAccountOption]
+		azureSession, err := getSession(sessions, account)
+		if err != nil {
+			return nil, err
+		}
+		snapshotClient := azureSession.snapshotClient
+		diskClient := azureSession.diskClient
+
 		var resourceGroup string
 		if val, present := backupVolumeInfo.Options[resourceGroupKey]; present {
 			resourceGroup = val
 		} else {
-			resourceGroup = a.resourceGroup
+			resourceGroup = azureSession.resourceGroup
 			logrus.Warnf("missing resource group in snapshot %v, will use current resource group", backupVolumeInfo.BackupID)
 		}
 
-		snapshot, err := snapshotClient.Get(context.TODO(), resourceGroup, backupVolumeInfo.BackupID)
+		backupID := backupVolumeInfo.BackupID
+		result, err := a.throttle().CachedCall("snapshot/"+resourceGroup+"/"+backupID, func() (interface{}, error) {
+			return snapshotClient.Get(context.TODO(), resourceGroup, backupID)
+		})
 		if err != nil {
 			return nil, err
 		}
+		snapshot := result.(compute.Snapshot)
 		volumeInfo := &storkapi.ApplicationRestoreVolumeInfo{
 			PersistentVolumeClaim:    backupVolumeInfo.PersistentVolumeClaim,
 			PersistentVolumeClaimUID: backupVolumeInfo.PersistentVolumeClaimUID,
@@ -523,13 +623,23 @@ func (a *azure) StartRestore(
 			for k, v := range tags {
 				disk.Tags[k] = to.StringPtr(v)
 			}
-			_, err = diskClient.CreateOrUpdate(context.TODO(), a.resourceGroup, *disk.Name, disk)
+			err = a.throttle().Call(func() error {
+				_, err := diskClient.CreateOrUpdate(context.TODO(), azureSession.resourceGroup, *disk.Name, disk)
+				return err
+			})
 			if err != nil {
 				return nil, fmt.Errorf("error triggering restore for volume: %v: %v",
 					backupVolumeInfo.Volume, err)
 			}
 			volumeInfo.RestoreVolume = *disk.Name
 		}
+		if account != "" {
+			volumeInfo.Options = map[string]string{
+				storkvolume.CloudAccountOption: account,
+				diskIDKey: fmt.Sprintf("/subscriptions/%v/resourceGroups/%v/providers/Microsoft.Compute/disks/%v",
+					diskClient.SubscriptionID, azureSession.resourceGroup, volumeInfo.RestoreVolume),
+			}
+		}
 	}
 	return volumeInfos, nil
 }
@@ -540,11 +650,7 @@ func (a *azure) CancelRestore(*storkapi.ApplicationRestore) error {
 }
 
 func (a *azure) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	azureSession, err := a.getAzureSession(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	diskClient := azureSession.diskClient
+	sessions := a.getAzureSessions(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, vInfo := range restore.Status.Volumes {
@@ -555,7 +661,16 @@ func (a *azure) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*stork
 			volumeInfos = append(volumeInfos, vInfo)
 			continue
 		}
-		disk, err := diskClient.Get(context.TODO(), a.resourceGroup, vInfo.RestoreVolume)
+		azureSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return nil, err
+		}
+		diskClient := azureSession.diskClient
+		resourceGroup := azureSession.resourceGroup
+		restoreVolume := vInfo.RestoreVolume
+		result, err := a.throttle().CachedCall("disk/"+resourceGroup+"/"+restoreVolume, func() (interface{}, error) {
+			return diskClient.Get(context.TODO(), resourceGroup, restoreVolume)
+		})
 		if err != nil {
 			if azureErr, ok := err.(autorest.DetailedError); ok {
 				if azureErr.StatusCode == http.StatusNotFound {
@@ -568,6 +683,7 @@ func (a *azure) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*stork
 
 			return nil, err
 		}
+		disk := result.(compute.Disk)
 		switch *disk.ProvisioningState {
 		case "Failed":
 			vInfo.Status = storkapi.ApplicationRestoreStatusFailed
@@ -629,6 +745,11 @@ func (a *azure) CleanupRestoreResources(*storkapi.ApplicationRestore) error {
 	return nil
 }
 
+// throttle returns the throttle shared by all the calls made to the Azure APIs
+func (a *azure) throttle() *storkvolume.CloudAPIThrottle {
+	return storkvolume.GetCloudAPIThrottle(storkvolume.AzureDriverName)
+}
+
 func (a *azure) getAzureClientFromBackupLocation(backupLocationName, ns string) *azureSession {
 	azureSessionWithCred := &azureSession{}
 	backupLocation, err := storkops.Instance().GetBackupLocation(backupLocationName, ns)
@@ -636,8 +757,12 @@ func (a *azure) getAzureClientFromBackupLocation(backupLocationName, ns string)
 		logrus.Errorf("error getting backup location %s resource: %v", backupLocationName, err)
 		return azureSessionWithCred
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		logrus.Errorf("error getting external secrets for backup location %s: %v", backupLocationName, err)
+		return azureSessionWithCred
+	}
 
-	if len(backupLocation.Cluster.SecretConfig) > 0 && backupLocation.Cluster.AzureClusterConfig != nil {
+	if (len(backupLocation.Cluster.SecretConfig) > 0 || backupLocation.Cluster.ExternalSecretConfig != nil) && backupLocation.Cluster.AzureClusterConfig != nil {
 		azureSessionWithCred.clientID = backupLocation.Cluster.AzureClusterConfig.ClientID
 		azureSessionWithCred.clientSecret = backupLocation.Cluster.AzureClusterConfig.ClientSecret
 		azureSessionWithCred.subscriptionID = backupLocation.Cluster.AzureClusterConfig.SubscriptionID
@@ -649,6 +774,7 @@ func (a *azure) getAzureClientFromBackupLocation(backupLocationName, ns string)
 			logrus.Errorf("error creating azure client session for backuplocation %s: %v", backupLocationName, err)
 			return azureSessionWithCred
 		}
+		azureSessionWithCred.resourceGroup = a.resourceGroup
 		azureSessionWithCred.snapshotClient = compute.NewSnapshotsClient(azureSessionWithCred.subscriptionID)
 		azureSessionWithCred.diskClient = compute.NewDisksClient(azureSessionWithCred.subscriptionID)
 		azureSessionWithCred.snapshotClient.Authorizer = authorizer
@@ -666,12 +792,68 @@ func (a *azure) getAzureSession(backupLocationName, ns string) (*azureSession, e
 				return nil, err
 			}
 		}
+		azureSession.resourceGroup = a.resourceGroup
 		azureSession.snapshotClient = a.snapshotClient
 		azureSession.diskClient = a.diskClient
 	}
 	return azureSession, nil
 }
 
+// getAzureSessionForAccount returns a session using the credentials of an
+// account of the backup location. Unlike the cluster credentials, it doesn't
+// fall back to the credentials stork is running with since the volumes are
+// in another subscription.
+func (a *azure) getAzureSessionForAccount(backupLocationName, ns, account string) (*azureSession, error) {
+	backupLocation, err := storkops.Instance().GetBackupLocation(backupLocationName, ns)
+	if err != nil {
+		return nil, err
+	}
+	cloudAccount := backupLocation.Cluster.GetAccount(account)
+	if cloudAccount == nil || cloudAccount.AzureClusterConfig == nil {
+		return nil, fmt.Errorf("azure credentials for account %v not found in backup location %v/%v", account, ns, backupLocationName)
+	}
+	session := &azureSession{
+		clientID:       cloudAccount.AzureClusterConfig.ClientID,
+		clientSecret:   cloudAccount.AzureClusterConfig.ClientSecret,
+		subscriptionID: cloudAccount.AzureClusterConfig.SubscriptionID,
+		tenantID:       cloudAccount.AzureClusterConfig.TenantID,
+		resourceGroup:  cloudAccount.ResourceGroup,
+	}
+	if session.resourceGroup == "" {
+		session.resourceGroup = a.resourceGroup
+	}
+	config := auth.NewClientCredentialsConfig(session.clientID, session.clientSecret, session.tenantID)
+	config.AADEndpoint = azure_rest.PublicCloud.ActiveDirectoryEndpoint
+	authorizer, err := config.Authorizer()
+	if err != nil {
+		return nil, fmt.Errorf("error creating azure client session for account %v of backuplocation %v: %v", account, backupLocationName, err)
+	}
+	session.snapshotClient = compute.NewSnapshotsClient(session.subscriptionID)
+	session.diskClient = compute.NewDisksClient(session.subscriptionID)
+	session.snapshotClient.Authorizer = authorizer
+	session.diskClient.Authorizer = authorizer
+	return session, nil
+}
+
+// getAzureSessions returns the sessions for the cluster and the accounts of
+// the backup location
+func (a *azure) getAzureSessions(backupLocationName, ns string) *storkvolume.CloudClients {
+	return storkvolume.NewCloudClients(func(account string) (interface{}, error) {
+		if account == "" {
+			return a.getAzureSession(backupLocationName, ns)
+		}
+		return a.getAzureSessionForAccount(backupLocationName, ns, account)
+	})
+}
+
+func getSession(sessions *storkvolume.CloudClients, account string) (*azureSession, error) {
+	session, err := sessions.Get(account)
+	if err != nil {
+		return nil, err
+	}
+	return session.(*azureSession), nil
+}
+
 func init() {
 	a := &azure{}
 	err := a.Init(nil)
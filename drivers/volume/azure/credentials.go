@@ -0,0 +1,166 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	azure_rest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+const (
+	// credentialSourceEnv selects the credentials used by the Azure driver
+	// when the BackupLocation doesn't have cluster credentials. Workload
+	// identity is used if it is configured for the pod, otherwise the
+	// credentials are read from the environment.
+	credentialSourceEnv = "STORK_AZURE_CREDENTIAL_SOURCE"
+
+	// credentialSourceEnvironment uses the client secret, certificate or
+	// username and password from the environment
+	credentialSourceEnvironment = "environment"
+	// credentialSourceWorkloadIdentity uses the federated token projected
+	// into the pod by Azure AD workload identity
+	credentialSourceWorkloadIdentity = "workloadidentity"
+	// credentialSourceMSI uses the managed identity of the VM
+	credentialSourceMSI = "msi"
+
+	clientIDEnv           = "AZURE_CLIENT_ID"
+	tenantIDEnv           = "AZURE_TENANT_ID"
+	federatedTokenFileEnv = "AZURE_FEDERATED_TOKEN_FILE"
+	authorityHostEnv      = "AZURE_AUTHORITY_HOST"
+	clientAssertionType   = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	tokenRefreshMargin    = 5 * time.Minute
+)
+
+// getAuthorizer returns the authorizer selected by credentialSourceEnv
+func getAuthorizer() (autorest.Authorizer, error) {
+	source := os.Getenv(credentialSourceEnv)
+	switch source {
+	case "":
+		if os.Getenv(federatedTokenFileEnv) != "" {
+			return getWorkloadIdentityAuthorizer()
+		}
+		return auth.NewAuthorizerFromEnvironment()
+	case credentialSourceEnvironment:
+		return auth.NewAuthorizerFromEnvironment()
+	case credentialSourceWorkloadIdentity:
+		return getWorkloadIdentityAuthorizer()
+	case credentialSourceMSI:
+		return auth.NewMSIConfig().Authorizer()
+	}
+	return nil, fmt.Errorf("invalid Azure credential source %v", source)
+}
+
+// getWorkloadIdentityAuthorizer returns an authorizer exchanging the
+// federated token of the pod for an access token of the application
+// configured for workload identity
+func getWorkloadIdentityAuthorizer() (autorest.Authorizer, error) {
+	clientID := os.Getenv(clientIDEnv)
+	tenantID := os.Getenv(tenantIDEnv)
+	tokenFile := os.Getenv(federatedTokenFileEnv)
+	if clientID == "" || tenantID == "" || tokenFile == "" {
+		return nil, fmt.Errorf("%v, %v and %v need to be set to use workload identity", clientIDEnv, tenantIDEnv, federatedTokenFileEnv)
+	}
+	authorityHost := os.Getenv(authorityHostEnv)
+	if authorityHost == "" {
+		authorityHost = azure_rest.PublicCloud.ActiveDirectoryEndpoint
+	}
+	return autorest.NewBearerAuthorizer(&workloadIdentityToken{
+		tokenURL:  strings.TrimSuffix(authorityHost, "/") + "/" + tenantID + "/oauth2/v2.0/token",
+		clientID:  clientID,
+		tokenFile: tokenFile,
+		scope:     azure_rest.PublicCloud.ResourceManagerEndpoint + ".default",
+	}), nil
+}
+
+// workloadIdentityToken is an access token that is refreshed by exchanging
+// the federated token in the file. The file is read every time the access
+// token is refreshed since the federated token is rotated by kubelet.
+type workloadIdentityToken struct {
+	tokenURL  string
+	clientID  string
+	tokenFile string
+	scope     string
+
+	lock        sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// OAuthToken returns the current access token
+func (t *workloadIdentityToken) OAuthToken() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.accessToken
+}
+
+// EnsureFreshWithContext refreshes the access token if it is about to expire
+func (t *workloadIdentityToken) EnsureFreshWithContext(ctx context.Context) error {
+	t.lock.Lock()
+	fresh := t.accessToken != "" && time.Now().Add(tokenRefreshMargin).Before(t.expiry)
+	t.lock.Unlock()
+	if fresh {
+		return nil
+	}
+	return t.RefreshWithContext(ctx)
+}
+
+// RefreshExchangeWithContext refreshes the access token. Exchanging the token
+// for another resource isn't supported.
+func (t *workloadIdentityToken) RefreshExchangeWithContext(ctx context.Context, _ string) error {
+	return t.RefreshWithContext(ctx)
+}
+
+// RefreshWithContext exchanges the federated token for a new access token
+func (t *workloadIdentityToken) RefreshWithContext(ctx context.Context) error {
+	assertion, err := ioutil.ReadFile(t.tokenFile)
+	if err != nil {
+		return fmt.Errorf("error reading federated token from %v: %v", t.tokenFile, err)
+	}
+	values := url.Values{}
+	values.Set("grant_type", "client_credentials")
+	values.Set("client_id", t.clientID)
+	values.Set("scope", t.scope)
+	values.Set("client_assertion_type", clientAssertionType)
+	values.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.tokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error exchanging federated token: %v", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading access token: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error exchanging federated token: %v: %v", resp.Status, string(body))
+	}
+	token := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("error parsing access token: %v", err)
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.accessToken = token.AccessToken
+	t.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return nil
+}
@@ -0,0 +1,159 @@
+//go:build unittest
+// +build unittest
+
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCredentialEnv clears the credentials in the environment and writes
+// the federated token to a file. Returns the path of the token file.
+func setupCredentialEnv(t *testing.T) string {
+	for _, env := range []string{
+		credentialSourceEnv, clientIDEnv, tenantIDEnv, federatedTokenFileEnv, authorityHostEnv,
+		"AZURE_CLIENT_SECRET", "AZURE_CERTIFICATE_PATH", "AZURE_USERNAME", "AZURE_PASSWORD",
+	} {
+		t.Setenv(env, "")
+	}
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("federated-token-1\n"), 0600))
+	return tokenFile
+}
+
+// newTokenServer returns a server exchanging federated tokens for access
+// tokens named after them. It counts the exchanges in requests.
+func newTokenServer(t *testing.T, requests *int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		require.NoError(t, r.ParseForm())
+		if r.URL.Path != "/tenant/oauth2/v2.0/token" ||
+			r.Form.Get("grant_type") != "client_credentials" ||
+			r.Form.Get("client_id") != "client" ||
+			r.Form.Get("scope") != "https://management.azure.com/.default" ||
+			r.Form.Get("client_assertion_type") != clientAssertionType {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_request"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token": "access-%v", "expires_in": 3600}`, r.Form.Get("client_assertion"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func getTokenProvider(t *testing.T, authorizer autorest.Authorizer) adal.OAuthTokenProvider {
+	bearer, ok := authorizer.(*autorest.BearerAuthorizer)
+	require.True(t, ok, "Expected bearer authorizer, got %T", authorizer)
+	return bearer.TokenProvider()
+}
+
+func TestCredentialSource(t *testing.T) {
+	tokenFile := setupCredentialEnv(t)
+	t.Setenv(clientIDEnv, "client")
+	t.Setenv(tenantIDEnv, "tenant")
+	t.Setenv("AZURE_CLIENT_SECRET", "secret")
+
+	// The secret in the environment is used unless workload identity is
+	// configured for the pod
+	authorizer, err := getAuthorizer()
+	require.NoError(t, err)
+	_, ok := getTokenProvider(t, authorizer).(*adal.ServicePrincipalToken)
+	require.True(t, ok, "Expected credentials from the environment")
+
+	t.Setenv(federatedTokenFileEnv, tokenFile)
+	authorizer, err = getAuthorizer()
+	require.NoError(t, err)
+	_, ok = getTokenProvider(t, authorizer).(*workloadIdentityToken)
+	require.True(t, ok, "Expected workload identity to be preferred")
+
+	t.Setenv(credentialSourceEnv, credentialSourceEnvironment)
+	authorizer, err = getAuthorizer()
+	require.NoError(t, err)
+	_, ok = getTokenProvider(t, authorizer).(*adal.ServicePrincipalToken)
+	require.True(t, ok, "Expected credentials from the environment")
+
+	t.Setenv(credentialSourceEnv, credentialSourceMSI)
+	authorizer, err = getAuthorizer()
+	require.NoError(t, err)
+	_, ok = getTokenProvider(t, authorizer).(*adal.ServicePrincipalToken)
+	require.True(t, ok, "Expected managed identity token")
+
+	t.Setenv(credentialSourceEnv, credentialSourceWorkloadIdentity)
+	t.Setenv(federatedTokenFileEnv, "")
+	_, err = getAuthorizer()
+	require.Error(t, err, "Expected error for workload identity without a federated token")
+	require.Contains(t, err.Error(), federatedTokenFileEnv)
+
+	t.Setenv(credentialSourceEnv, "invalid")
+	_, err = getAuthorizer()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid Azure credential source")
+}
+
+func TestWorkloadIdentityToken(t *testing.T) {
+	tokenFile := setupCredentialEnv(t)
+	requests := 0
+	server := newTokenServer(t, &requests)
+	t.Setenv(clientIDEnv, "client")
+	t.Setenv(tenantIDEnv, "tenant")
+	t.Setenv(federatedTokenFileEnv, tokenFile)
+	t.Setenv(authorityHostEnv, server.URL+"/")
+
+	authorizer, err := getAuthorizer()
+	require.NoError(t, err)
+	req, err := autorest.Prepare(&http.Request{}, authorizer.WithAuthorization())
+	require.NoError(t, err)
+	require.Equal(t, "Bearer access-federated-token-1", req.Header.Get("Authorization"))
+	require.Equal(t, 1, requests)
+
+	// The token is only exchanged again when it is about to expire, and the
+	// rotated federated token is read from the file
+	token := getTokenProvider(t, authorizer).(*workloadIdentityToken)
+	require.NoError(t, token.EnsureFreshWithContext(context.TODO()))
+	require.Equal(t, 1, requests, "Fresh token shouldn't be refreshed")
+
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("federated-token-2"), 0600))
+	token.expiry = time.Now().Add(time.Minute)
+	require.NoError(t, token.EnsureFreshWithContext(context.TODO()))
+	require.Equal(t, 2, requests)
+	require.Equal(t, "access-federated-token-2", token.OAuthToken())
+}
+
+func TestWorkloadIdentityTokenErrors(t *testing.T) {
+	tokenFile := setupCredentialEnv(t)
+	requests := 0
+	server := newTokenServer(t, &requests)
+	t.Setenv(tenantIDEnv, "tenant")
+	t.Setenv(federatedTokenFileEnv, tokenFile)
+	t.Setenv(authorityHostEnv, server.URL)
+
+	t.Setenv(clientIDEnv, "unknown")
+	authorizer, err := getAuthorizer()
+	require.NoError(t, err)
+	token := getTokenProvider(t, authorizer).(*workloadIdentityToken)
+	err = token.RefreshWithContext(context.TODO())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "400 Bad Request")
+	require.Contains(t, err.Error(), "invalid_request")
+
+	t.Setenv(clientIDEnv, "client")
+	t.Setenv(federatedTokenFileEnv, filepath.Join(t.TempDir(), "missing"))
+	authorizer, err = getAuthorizer()
+	require.NoError(t, err)
+	token = getTokenProvider(t, authorizer).(*workloadIdentityToken)
+	err = token.RefreshWithContext(context.TODO())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "error reading federated token")
+}
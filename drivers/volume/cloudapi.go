@@ -0,0 +1,191 @@
+package volume
+
+import (
+	"sync"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+	k8shelper "k8s.io/component-helpers/storage/volume"
+)
+
+// CloudAPIConfig configures the rate limiting and caching of the calls made
+// by the cloud volume drivers to the cloud provider APIs
+type CloudAPIConfig struct {
+	// QPS is the number of calls per second a driver can make to the cloud
+	// provider. Rate limiting is disabled if it is 0.
+	QPS float32
+	// Burst is the number of calls that can be made at once before being
+	// limited to QPS
+	Burst int
+	// CacheTTL is the time for which the result of a read call is reused.
+	// Caching is disabled if it is 0.
+	CacheTTL time.Duration
+}
+
+// DefaultCloudAPIConfig is the default config used for the cloud drivers
+var DefaultCloudAPIConfig = CloudAPIConfig{
+	QPS:      5,
+	Burst:    10,
+	CacheTTL: 10 * time.Second,
+}
+
+var (
+	cloudAPIConfig    = DefaultCloudAPIConfig
+	cloudAPIThrottles = make(map[string]*CloudAPIThrottle)
+	cloudAPILock      sync.Mutex
+)
+
+// SetCloudAPIConfig updates the config used by the throttles of all the cloud
+// drivers
+func SetCloudAPIConfig(config CloudAPIConfig) {
+	cloudAPILock.Lock()
+	defer cloudAPILock.Unlock()
+	cloudAPIConfig = config
+	for _, t := range cloudAPIThrottles {
+		t.configure(config)
+	}
+}
+
+// GetCloudAPIThrottle returns the throttle for the calls made by the driver.
+// The throttle is shared by all the clients of the driver since the cloud
+// provider limits the calls made by the whole account.
+func GetCloudAPIThrottle(driver string) *CloudAPIThrottle {
+	cloudAPILock.Lock()
+	defer cloudAPILock.Unlock()
+	if t, ok := cloudAPIThrottles[driver]; ok {
+		return t
+	}
+	t := &CloudAPIThrottle{}
+	t.configure(cloudAPIConfig)
+	cloudAPIThrottles[driver] = t
+	return t
+}
+
+type cloudAPICacheEntry struct {
+	value  interface{}
+	expiry time.Time
+}
+
+// CloudAPIThrottle rate limits the calls made to a cloud provider API and
+// caches the results of read calls for a short time so that polling the
+// status of a large number of snapshots or disks doesn't get throttled by
+// the cloud provider
+type CloudAPIThrottle struct {
+	lock     sync.Mutex
+	limiter  flowcontrol.RateLimiter
+	cacheTTL time.Duration
+	cache    map[string]cloudAPICacheEntry
+}
+
+func (t *CloudAPIThrottle) configure(config CloudAPIConfig) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if config.QPS > 0 {
+		burst := config.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		t.limiter = flowcontrol.NewTokenBucketRateLimiter(config.QPS, burst)
+	} else {
+		t.limiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	}
+	t.cacheTTL = config.CacheTTL
+	t.cache = make(map[string]cloudAPICacheEntry)
+}
+
+// Call waits until the call is allowed by the rate limiter and then calls fn
+func (t *CloudAPIThrottle) Call(fn func() error) error {
+	t.lock.Lock()
+	limiter := t.limiter
+	t.lock.Unlock()
+	limiter.Accept()
+	return fn()
+}
+
+// CachedCall returns the cached result for the key if it hasn't expired.
+// Otherwise fn is called through the rate limiter and its result is cached
+// if it didn't fail.
+func (t *CloudAPIThrottle) CachedCall(key string, fn func() (interface{}, error)) (interface{}, error) {
+	now := time.Now()
+	t.lock.Lock()
+	if entry, ok := t.cache[key]; ok && now.Before(entry.expiry) {
+		t.lock.Unlock()
+		return entry.value, nil
+	}
+	t.lock.Unlock()
+
+	var value interface{}
+	if err := t.Call(func() error {
+		var err error
+		value, err = fn()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.cacheTTL > 0 {
+		for k, entry := range t.cache {
+			if !now.Before(entry.expiry) {
+				delete(t.cache, k)
+			}
+		}
+		t.cache[key] = cloudAPICacheEntry{value: value, expiry: now.Add(t.cacheTTL)}
+	}
+	return value, nil
+}
+
+// Invalidate removes the cached result for the key. It should be called
+// after the object is changed or deleted.
+func (t *CloudAPIThrottle) Invalidate(key string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.cache, key)
+}
+
+// CloudAccountOption is the option in the volume info of backups and
+// restores recording the account of the BackupLocation holding the volume.
+// It isn't set for volumes using the cluster credentials.
+const CloudAccountOption = "cloudAccount"
+
+// GetCloudAccountForPVC returns the name of the account of the BackupLocation
+// holding the volume of the PVC. It is empty if the volume uses the cluster
+// credentials.
+func GetCloudAccountForPVC(backupLocation *storkapi.BackupLocation, pvc *v1.PersistentVolumeClaim) string {
+	if account := backupLocation.Cluster.GetAccountForStorageClass(k8shelper.GetPersistentVolumeClaimClass(pvc)); account != nil {
+		return account.Name
+	}
+	return ""
+}
+
+// CloudClients creates the client used for each account of a BackupLocation
+// once, so that the client doesn't have to be created for every volume
+type CloudClients struct {
+	create  func(account string) (interface{}, error)
+	clients map[string]interface{}
+}
+
+// NewCloudClients returns a CloudClients creating clients with the given
+// function. An empty account is used for the cluster credentials.
+func NewCloudClients(create func(account string) (interface{}, error)) *CloudClients {
+	return &CloudClients{
+		create:  create,
+		clients: make(map[string]interface{}),
+	}
+}
+
+// Get returns the client for the account
+func (c *CloudClients) Get(account string) (interface{}, error) {
+	if client, ok := c.clients[account]; ok {
+		return client, nil
+	}
+	client, err := c.create(account)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[account] = client
+	return client, nil
+}
@@ -0,0 +1,112 @@
+//go:build unittest
+// +build unittest
+
+package volume
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestCloudAPIThrottleCache(t *testing.T) {
+	defer SetCloudAPIConfig(DefaultCloudAPIConfig)
+	SetCloudAPIConfig(CloudAPIConfig{CacheTTL: time.Minute})
+	throttle := GetCloudAPIThrottle("cachetest")
+	require.Equal(t, throttle, GetCloudAPIThrottle("cachetest"), "Throttle should be shared")
+
+	calls := 0
+	get := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	value, err := throttle.CachedCall("key1", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 1, value)
+	value, err = throttle.CachedCall("key1", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 1, value, "Cached value should be returned")
+	value, err = throttle.CachedCall("key2", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 2, value)
+
+	throttle.Invalidate("key1")
+	value, err = throttle.CachedCall("key1", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 3, value, "Value should be fetched after invalidation")
+
+	// Errors aren't cached
+	_, err = throttle.CachedCall("key3", func() (interface{}, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	require.Error(t, err, "Expected error")
+	value, err = throttle.CachedCall("key3", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 4, value)
+
+	// Reconfiguring clears the cache
+	SetCloudAPIConfig(CloudAPIConfig{})
+	value, err = throttle.CachedCall("key1", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 5, value)
+	value, err = throttle.CachedCall("key1", get)
+	require.NoError(t, err, "Unexpected error")
+	require.Equal(t, 6, value, "Value shouldn't be cached if caching is disabled")
+}
+
+func TestCloudAPIThrottleRateLimit(t *testing.T) {
+	defer SetCloudAPIConfig(DefaultCloudAPIConfig)
+	SetCloudAPIConfig(CloudAPIConfig{QPS: 10, Burst: 1})
+	throttle := GetCloudAPIThrottle("ratelimittest")
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, throttle.Call(func() error { return nil }), "Unexpected error")
+	}
+	require.GreaterOrEqual(t, int64(time.Since(start)), int64(250*time.Millisecond), "Calls should be rate limited")
+}
+
+func TestGetCloudAccountForPVC(t *testing.T) {
+	backupLocation := &storkapi.BackupLocation{
+		Cluster: storkapi.ClusterItem{
+			Type: storkapi.AWSCluster,
+			Accounts: []storkapi.CloudAccount{
+				{Name: "account1", StorageClasses: []string{"sc1", "sc2"}},
+				{Name: "account2", StorageClasses: []string{"sc3"}},
+			},
+		},
+	}
+	pvc := func(storageClass string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{Spec: v1.PersistentVolumeClaimSpec{StorageClassName: &storageClass}}
+	}
+	require.Equal(t, "account1", GetCloudAccountForPVC(backupLocation, pvc("sc2")))
+	require.Equal(t, "account2", GetCloudAccountForPVC(backupLocation, pvc("sc3")))
+	require.Equal(t, "", GetCloudAccountForPVC(backupLocation, pvc("sc4")), "Cluster credentials should be used")
+	require.Equal(t, "", GetCloudAccountForPVC(backupLocation, &v1.PersistentVolumeClaim{}), "Cluster credentials should be used")
+}
+
+func TestCloudClients(t *testing.T) {
+	created := make([]string, 0)
+	clients := NewCloudClients(func(account string) (interface{}, error) {
+		if account == "invalid" {
+			return nil, fmt.Errorf("invalid account")
+		}
+		created = append(created, account)
+		return "client-" + account, nil
+	})
+	for i := 0; i < 2; i++ {
+		client, err := clients.Get("")
+		require.NoError(t, err, "Unexpected error")
+		require.Equal(t, "client-", client)
+		client, err = clients.Get("account1")
+		require.NoError(t, err, "Unexpected error")
+		require.Equal(t, "client-account1", client)
+	}
+	require.Equal(t, []string{"", "account1"}, created, "Clients should be created once")
+	_, err := clients.Get("invalid")
+	require.Error(t, err, "Expected error for invalid account")
+}
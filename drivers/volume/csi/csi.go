@@ -15,8 +15,10 @@ import (
 	storkvolume "github.com/libopenstorage/stork/drivers/volume"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
 	"github.com/libopenstorage/stork/pkg/snapshotter"
@@ -33,6 +35,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -58,6 +62,10 @@ const (
 	optCSISnapshotClassName = "stork.libopenstorage.org/csi-snapshot-class-name"
 	// optVolumeSnapshotContentName is used for recording which vsc to check has been deleted
 	optVolumeSnapshotContentName = "volumesnapshotcontent-name"
+	// optSnapshotReference is set for volumes backed up by referencing an
+	// existing snapshot. These snapshots aren't owned by the backup and are
+	// never deleted by it.
+	optSnapshotReference = "snapshot-reference"
 
 	annPVBindCompleted     = "pv.kubernetes.io/bind-completed"
 	annPVBoundByController = "pv.kubernetes.io/bound-by-controller"
@@ -162,13 +170,18 @@ type csi struct {
 	snapshotClient     *kSnapshotClient.Clientset
 	snapshotter        snapshotter.Driver
 	v1SnapshotRequired bool
+	dynamicClient      dynamic.Interface
+	discoveryClient    discovery.DiscoveryInterface
 
 	storkvolume.ClusterPairNotSupported
 	storkvolume.MigrationNotSupported
-	storkvolume.GroupSnapshotNotSupported
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+	storkvolume.ProgressNotSupported
 }
 
 func (c *csi) Init(_ interface{}) error {
@@ -183,6 +196,15 @@ func (c *csi) Init(_ interface{}) error {
 	}
 	c.snapshotClient = cs
 
+	c.dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	c.discoveryClient, err = discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+
 	c.v1SnapshotRequired, err = version.RequiresV1VolumeSnapshot()
 	if err != nil {
 		return err
@@ -313,6 +335,16 @@ func (c *csi) OwnsPVCForBackup(
 		// If user has forced the backupType in config map or applicationbackup CR, default to generic always
 		return false
 	}
+	if crBackupType == storkapi.ApplicationBackupSnapshotReference {
+		// Existing snapshots can be referenced for all CSI volumes, including
+		// the ones supported natively by other drivers
+		pv, err := coreOps.GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			log.PVCLog(pvc).Warnf("error getting pv %v for pvc %v: %v", pvc.Spec.VolumeName, pvc.Name, err)
+			return false
+		}
+		return pv.Spec.CSI != nil && !storkvolume.IsCSIDriverWithoutSnapshotSupport(pv)
+	}
 	return c.OwnsPVC(coreOps, pvc)
 }
 
@@ -487,20 +519,34 @@ func (c *csi) StartBackup(
 		volumeInfo.Volume = pvc.Spec.VolumeName
 		volumeInfos = append(volumeInfos, volumeInfo)
 
-		vsName := c.getBackupSnapshotName(&pvc, backup)
-		// We should bail-out if snapshotter is not initialized right
-		if c.snapshotter == nil {
-			return nil, fmt.Errorf("found uninitialized snapshotter object")
-		}
-		_, _, csiDriverName, err := c.snapshotter.CreateSnapshot(
-			snapshotter.Name(vsName),
-			snapshotter.PVCName(pvc.Name),
-			snapshotter.PVCNamespace(pvc.Namespace),
-			snapshotter.SnapshotClassName(c.getSnapshotClassName(backup, "")),
-		)
-		if err != nil {
-			c.cancelBackupDuringStartFailure(backup, volumeInfos)
-			return nil, fmt.Errorf("failed to ensure volumesnapshotclass was created: %v", err)
+		var vsName, csiDriverName string
+		var err error
+		if backup.Spec.BackupType == storkapi.ApplicationBackupSnapshotReference {
+			// Only record the latest existing snapshot for the PVC, the data is
+			// expected to have been replicated by the storage backend
+			vsName, csiDriverName, err = c.getLatestSnapshotForPVC(&pvc)
+			if err != nil {
+				c.cancelBackupDuringStartFailure(backup, volumeInfos)
+				return nil, err
+			}
+			volumeInfo.Options[optSnapshotReference] = "true"
+		} else {
+			vsName = c.getBackupSnapshotName(&pvc, backup)
+			// We should bail-out if snapshotter is not initialized right
+			if c.snapshotter == nil {
+				return nil, fmt.Errorf("found uninitialized snapshotter object")
+			}
+			_, _, csiDriverName, err = c.snapshotter.CreateSnapshot(
+				snapshotter.Name(vsName),
+				snapshotter.PVCName(pvc.Name),
+				snapshotter.PVCNamespace(pvc.Namespace),
+				snapshotter.SnapshotClassName(c.getSnapshotClassName(backup, "")),
+				snapshotter.Labels(k8sutils.ProvenanceLabels(backup.Labels[k8sutils.ScheduleNameLabel], backup.CreationTimestamp.Time)),
+			)
+			if err != nil {
+				c.cancelBackupDuringStartFailure(backup, volumeInfos)
+				return nil, fmt.Errorf("failed to ensure volumesnapshotclass was created: %v", err)
+			}
 		}
 
 		volumeInfo.Options[optCSIDriverName] = csiDriverName
@@ -532,6 +578,67 @@ func (c *csi) StartBackup(
 	return volumeInfos, nil
 }
 
+// getLatestSnapshotForPVC returns the name of the latest VolumeSnapshot of the
+// PVC that is ready to use along with the CSI driver of the PVC. Snapshots
+// created by other backups are ignored since they are deleted once the
+// backup is done.
+func (c *csi) getLatestSnapshotForPVC(pvc *v1.PersistentVolumeClaim) (string, string, error) {
+	if c.snapshotClient == nil {
+		if err := c.Init(nil); err != nil {
+			return "", "", err
+		}
+	}
+	pv, err := core.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+	if err != nil {
+		return "", "", err
+	}
+	if pv.Spec.CSI == nil {
+		return "", "", fmt.Errorf("PV %v for PVC %v/%v is not a CSI volume", pv.Name, pvc.Namespace, pvc.Name)
+	}
+
+	var latestName string
+	var latestTimestamp metav1.Time
+	checkSnapshot := func(name string, source *string, ready *bool, creationTimestamp metav1.Time) {
+		if source == nil || *source != pvc.Name || ready == nil || !*ready ||
+			strings.HasPrefix(name, snapshotBackupPrefix+"-") {
+			return
+		}
+		if latestName == "" || latestTimestamp.Before(&creationTimestamp) {
+			latestName = name
+			latestTimestamp = creationTimestamp
+		}
+	}
+	if c.v1SnapshotRequired {
+		snapshots, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(pvc.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		for _, vs := range snapshots.Items {
+			if vs.Status != nil {
+				checkSnapshot(vs.Name, vs.Spec.Source.PersistentVolumeClaimName, vs.Status.ReadyToUse, vs.CreationTimestamp)
+			}
+		}
+	} else {
+		snapshots, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(pvc.Namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return "", "", err
+		}
+		for _, vs := range snapshots.Items {
+			if vs.Status != nil {
+				checkSnapshot(vs.Name, vs.Spec.Source.PersistentVolumeClaimName, vs.Status.ReadyToUse, vs.CreationTimestamp)
+			}
+		}
+	}
+	if latestName == "" {
+		return "", "", fmt.Errorf("no ready VolumeSnapshot found for PVC %v/%v", pvc.Namespace, pvc.Name)
+	}
+	return latestName, pv.Spec.CSI.Driver, nil
+}
+
+func isSnapshotReference(vInfo *storkapi.ApplicationBackupVolumeInfo) bool {
+	return vInfo.Options[optSnapshotReference] == "true"
+}
+
 func (c *csi) getBackupSnapshotName(pvc *v1.PersistentVolumeClaim, backup *storkapi.ApplicationBackup) string {
 	return fmt.Sprintf("%s-%s-%s", snapshotBackupPrefix, getUIDLastSection(backup.UID), getUIDLastSection(pvc.UID))
 }
@@ -542,7 +649,7 @@ func (c *csi) uploadObject(
 	objectName string,
 	data []byte,
 ) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		return err
 	}
@@ -822,6 +929,9 @@ func (c *csi) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.A
 
 		// Not in cleanup state. From here on, we're checking if the PVC snapshot has finished.
 		snapshotName := c.getBackupSnapshotName(pvc, backup)
+		if isSnapshotReference(vInfo) {
+			snapshotName = vInfo.BackupID
+		}
 
 		snapshotInfo, err := c.snapshotter.SnapshotStatus(
 			snapshotName,
@@ -1008,7 +1118,7 @@ func (c *csi) CancelBackup(backup *storkapi.ApplicationBackup) error {
 	if backup.Status.Status == storkapi.ApplicationBackupStatusInProgress {
 		// set of all snapshot classes deleted
 		for _, vInfo := range backup.Status.Volumes {
-			if vInfo.DriverName != storkvolume.CSIDriverName {
+			if vInfo.DriverName != storkvolume.CSIDriverName || isSnapshotReference(vInfo) {
 				continue
 			}
 			snapshotName := vInfo.BackupID
@@ -1078,7 +1188,7 @@ func (c *csi) CancelBackup(backup *storkapi.ApplicationBackup) error {
 }
 
 func (c *csi) cleanupBackupLocation(backup *storkapi.ApplicationBackup) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		// Can't do anything if the backup location is deleted
 		if k8s_errors.IsNotFound(err) {
@@ -1116,7 +1226,7 @@ func (c *csi) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
 	}
 
 	for _, vInfo := range backup.Status.Volumes {
-		if vInfo.DriverName != storkvolume.CSIDriverName {
+		if vInfo.DriverName != storkvolume.CSIDriverName || isSnapshotReference(vInfo) {
 			continue
 		}
 		if backupSuccessful {
@@ -1173,7 +1283,7 @@ func (c *csi) UpdateMigratedPersistentVolumeSpec(
 
 func (c *csi) getRestoreStorageClasses(backup *storkapi.ApplicationBackup, resources []runtime.Unstructured) ([]runtime.Unstructured, error) {
 	storageClasses := make([]storagev1.StorageClass, 0)
-	storageClassesBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.Namespace, storageClassesObjectName)
+	storageClassesBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), storageClassesObjectName)
 	if err != nil {
 		return nil, err
 	}
@@ -1223,13 +1333,23 @@ func (c *csi) downloadObject(
 		return nil, err
 	}
 
+	// The resources of the backup are read through its manifest since they
+	// are stored as blobs by newer layouts
 	objectPath := backup.Status.BackupPath
-	exists, err := bucket.Exists(context.TODO(), filepath.Join(objectPath, objectName))
+	manifest, err := backuplayout.Read(context.TODO(), bucket, objectPath)
+	if err != nil {
+		return nil, err
+	}
+	if hashes, ok := manifest.Blobs[objectName]; ok {
+		return backuplayout.ReadArray(context.TODO(), bucket, hashes, restoreLocation.Location.EncryptionKey)
+	}
+	objectKey := filepath.Join(objectPath, manifest.ObjectKey(objectName))
+	exists, err := bucket.Exists(context.TODO(), objectKey)
 	if err != nil || !exists {
 		return nil, nil
 	}
 
-	data, err := bucket.ReadAll(context.TODO(), filepath.Join(objectPath, objectName))
+	data, err := bucket.ReadAll(context.TODO(), objectKey)
 	if err != nil {
 		return nil, err
 	}
@@ -1250,7 +1370,7 @@ func (c *csi) getCSIBackupObject(backupName, backupNamespace string) (*csiBackup
 		return nil, fmt.Errorf("error getting backup spec for CSI restore: %v", err)
 	}
 
-	backupObjectBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.Namespace, snapshotObjectName)
+	backupObjectBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), snapshotObjectName)
 	if err != nil {
 		return nil, err
 	}
@@ -1287,7 +1407,7 @@ func (c *csi) getBackupResources(restore *storkapi.ApplicationRestore) ([]runtim
 		return nil, fmt.Errorf("error getting backup resources for CSI restore: %v", err)
 	}
 
-	backupObjectBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.Namespace, resourcesObjectName)
+	backupObjectBytes, err := c.downloadObject(backup, backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), resourcesObjectName)
 	if err != nil {
 		return nil, err
 	}
@@ -1574,6 +1694,7 @@ func (c *csi) createRestoreSnapshotsAndPVCs(
 			pvc, err = c.snapshotter.RestoreVolumeClaim(
 				snapshotter.RestoreSnapshotName(vs.(*kSnapshotv1beta1.VolumeSnapshot).Name),
 				snapshotter.RestoreNamespace(c.getDestinationNamespace(restore, pvc.Namespace)),
+				snapshotter.SnapshotNamespace(destNamespace),
 				snapshotter.PVC(*pvc),
 			)
 		case kSnapshotv1.VolumeSnapshot:
@@ -1589,6 +1710,7 @@ func (c *csi) createRestoreSnapshotsAndPVCs(
 			pvc, err = c.snapshotter.RestoreVolumeClaim(
 				snapshotter.RestoreSnapshotName(vs.(*kSnapshotv1.VolumeSnapshot).Name),
 				snapshotter.RestoreNamespace(c.getDestinationNamespace(restore, pvc.Namespace)),
+				snapshotter.SnapshotNamespace(destNamespace),
 				snapshotter.PVC(*pvc),
 			)
 		default:
@@ -1805,7 +1927,7 @@ func (c *csi) CleanupBackupResources(backup *storkapi.ApplicationBackup) error {
 		vsContentMap = make(map[string]*kSnapshotv1beta1.VolumeSnapshotContent)
 	}
 	for _, vInfo := range backup.Status.Volumes {
-		if vInfo.DriverName != storkvolume.CSIDriverName {
+		if vInfo.DriverName != storkvolume.CSIDriverName || isSnapshotReference(vInfo) {
 			continue
 		}
 		// Get PVC we're checking the backup for
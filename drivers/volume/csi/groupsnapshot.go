@@ -0,0 +1,533 @@
+package csi
+
+import (
+	"context"
+	"fmt"
+
+	kSnapshotv1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1"
+	kSnapshotv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/snapshotter"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// optVolumeGroupSnapshotClassName is an option for providing the
+	// VolumeGroupSnapshotClass used for group snapshots
+	optVolumeGroupSnapshotClassName = "stork.libopenstorage.org/csi-volumegroupsnapshot-class-name"
+
+	volumeGroupSnapshotGroup         = "groupsnapshot.storage.k8s.io"
+	volumeGroupSnapshotPlural        = "volumegroupsnapshots"
+	volumeGroupSnapshotContentPlural = "volumegroupsnapshotcontents"
+	volumeSnapshotGroup              = "snapshot.storage.k8s.io"
+	volumeSnapshotPlural             = "volumesnapshots"
+	volumeSnapshotContentPlural      = "volumesnapshotcontents"
+	volumeSnapshotV1Version          = "v1"
+	volumeSnapshotV1beta1Version     = "v1beta1"
+)
+
+// CreateGroupSnapshot creates a VolumeGroupSnapshot for the PVCs selected by
+// the group snapshot if the cluster supports it, so that the backend takes a
+// single consistent snapshot of all the volumes. Falls back to creating a
+// VolumeSnapshot for each PVC otherwise.
+func (c *csi) CreateGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	pvcs, err := k8sutils.GetPVCsForGroupSnapshot(snap.Namespace, snap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	// Clean up the snapshots from the previous attempt when retrying
+	if snap.Status.NumRetries > 0 {
+		if err := c.deleteGroupSnapshotAttempt(snap, snap.Status.NumRetries-1, pvcs); err != nil {
+			return nil, err
+		}
+	}
+
+	labels, annotations, err := snapshotter.RenderGroupSnapshotMetadata(snap)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := c.getVolumeGroupSnapshotResource()
+	if err != nil {
+		return nil, err
+	}
+	if resource != nil {
+		if err := c.createVolumeGroupSnapshot(*resource, snap, labels, annotations); err != nil {
+			return nil, err
+		}
+		log.GroupSnapshotLog(snap).Infof("Created VolumeGroupSnapshot %v", getVolumeGroupSnapshotName(snap, snap.Status.NumRetries))
+		snapshots := make([]*storkapi.VolumeSnapshotStatus, 0)
+		for _, pvc := range pvcs {
+			snapshots = append(snapshots, &storkapi.VolumeSnapshotStatus{
+				TaskID:         getVolumeGroupSnapshotName(snap, snap.Status.NumRetries),
+				ParentVolumeID: pvc.Spec.VolumeName,
+				Conditions:     getGroupSnapshotConditions(snapshotter.StatusInProgress, ""),
+			})
+		}
+		return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snapshots}, nil
+	}
+
+	log.GroupSnapshotLog(snap).Infof("VolumeGroupSnapshot isn't supported, creating a snapshot for each PVC")
+	if c.snapshotter == nil {
+		return nil, fmt.Errorf("found uninitialized snapshotter object")
+	}
+	snapshotClassName, ok := snap.Spec.Options[optCSISnapshotClassName]
+	if !ok {
+		snapshotClassName = "default"
+	}
+	snapshots := make([]*storkapi.VolumeSnapshotStatus, 0)
+	for _, pvc := range pvcs {
+		vsName := getGroupMemberSnapshotName(snap, snap.Status.NumRetries, pvc.Name)
+		_, _, _, err := c.snapshotter.CreateSnapshot(
+			snapshotter.Name(vsName),
+			snapshotter.PVCName(pvc.Name),
+			snapshotter.PVCNamespace(pvc.Namespace),
+			snapshotter.SnapshotClassName(snapshotClassName),
+			snapshotter.Labels(labels),
+			snapshotter.Annotations(annotations),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("error creating snapshot for PVC %v: %v", pvc.Name, err)
+		}
+		snapshots = append(snapshots, &storkapi.VolumeSnapshotStatus{
+			VolumeSnapshotName: vsName,
+			TaskID:             vsName,
+			ParentVolumeID:     pvc.Spec.VolumeName,
+			Conditions:         getGroupSnapshotConditions(snapshotter.StatusInProgress, ""),
+		})
+	}
+	return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snapshots}, nil
+}
+
+// GetGroupSnapshotStatus returns the status of the snapshots of the group
+func (c *csi) GetGroupSnapshotStatus(snap *storkapi.GroupVolumeSnapshot) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	vgs, err := c.getVolumeGroupSnapshot(snap)
+	if err != nil {
+		return nil, err
+	}
+	if vgs != nil {
+		return c.getVolumeGroupSnapshotStatus(snap, vgs)
+	}
+
+	snapshots := make([]*storkapi.VolumeSnapshotStatus, 0)
+	for _, s := range snap.Status.VolumeSnapshots {
+		info, err := c.snapshotter.SnapshotStatus(s.VolumeSnapshotName, snap.Namespace)
+		if err != nil && info.Status != snapshotter.StatusFailed {
+			return nil, err
+		}
+		snapshots = append(snapshots, &storkapi.VolumeSnapshotStatus{
+			VolumeSnapshotName: s.VolumeSnapshotName,
+			TaskID:             s.TaskID,
+			ParentVolumeID:     s.ParentVolumeID,
+			Conditions:         getGroupSnapshotConditions(info.Status, info.Reason),
+		})
+	}
+	return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snapshots}, nil
+}
+
+// DeleteGroupSnapshot deletes the VolumeGroupSnapshot or the VolumeSnapshots
+// created for the group
+func (c *csi) DeleteGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) error {
+	for attempt := 0; attempt <= snap.Status.NumRetries; attempt++ {
+		if err := c.deleteVolumeGroupSnapshot(snap, attempt); err != nil {
+			return err
+		}
+	}
+	// Keep the backend snapshots if the VolumeSnapshotClass retains them
+	snapshotClassName, ok := snap.Spec.Options[optCSISnapshotClassName]
+	if !ok {
+		snapshotClassName = "default"
+	}
+	retain := c.isSnapshotClassRetained(snapshotClassName)
+	for _, s := range snap.Status.VolumeSnapshots {
+		if s.VolumeSnapshotName == "" {
+			continue
+		}
+		if err := c.snapshotter.DeleteSnapshot(s.VolumeSnapshotName, snap.Namespace, retain); err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSnapshotClassRetained returns true if the deletion policy of the
+// VolumeSnapshotClass is Retain
+func (c *csi) isSnapshotClassRetained(snapshotClassName string) bool {
+	vsClass, err := c.getVolumeSnapshotClass(snapshotClassName)
+	if err != nil {
+		logrus.Warnf("Error getting VolumeSnapshotClass %v, snapshots won't be retained: %v", snapshotClassName, err)
+		return false
+	}
+	switch class := vsClass.(type) {
+	case *kSnapshotv1.VolumeSnapshotClass:
+		return class.DeletionPolicy == kSnapshotv1.VolumeSnapshotContentRetain
+	case *kSnapshotv1beta1.VolumeSnapshotClass:
+		return class.DeletionPolicy == kSnapshotv1beta1.VolumeSnapshotContentRetain
+	}
+	return false
+}
+
+// getVolumeGroupSnapshotResource returns the VolumeGroupSnapshot resource in
+// the version preferred by the cluster, falling back to the other versions it
+// serves. Returns nil if the cluster doesn't support VolumeGroupSnapshots.
+func (c *csi) getVolumeGroupSnapshotResource() (*schema.GroupVersionResource, error) {
+	if c.dynamicClient == nil || c.discoveryClient == nil {
+		return nil, nil
+	}
+	groups, err := c.discoveryClient.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups.Groups {
+		if group.Name != volumeGroupSnapshotGroup {
+			continue
+		}
+		versions := []string{group.PreferredVersion.Version}
+		for _, v := range group.Versions {
+			if v.Version != group.PreferredVersion.Version {
+				versions = append(versions, v.Version)
+			}
+		}
+		for _, version := range versions {
+			gv := schema.GroupVersion{Group: volumeGroupSnapshotGroup, Version: version}
+			resources, err := c.discoveryClient.ServerResourcesForGroupVersion(gv.String())
+			if err != nil {
+				if k8s_errors.IsNotFound(err) {
+					continue
+				}
+				return nil, err
+			}
+			for _, r := range resources.APIResources {
+				if r.Name == volumeGroupSnapshotPlural {
+					resource := gv.WithResource(volumeGroupSnapshotPlural)
+					return &resource, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *csi) createVolumeGroupSnapshot(
+	resource schema.GroupVersionResource,
+	snap *storkapi.GroupVolumeSnapshot,
+	labels map[string]string,
+	annotations map[string]string,
+) error {
+	matchLabels := make(map[string]interface{})
+	for k, v := range snap.Spec.PVCSelector.MatchLabels {
+		matchLabels[k] = v
+	}
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": matchLabels,
+			},
+		},
+	}
+	if className, ok := snap.Spec.Options[optVolumeGroupSnapshotClassName]; ok {
+		spec["volumeGroupSnapshotClassName"] = className
+	}
+	vgs := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": resource.GroupVersion().String(),
+			"kind":       "VolumeGroupSnapshot",
+			"spec":       spec,
+		},
+	}
+	vgs.SetName(getVolumeGroupSnapshotName(snap, snap.Status.NumRetries))
+	vgs.SetNamespace(snap.Namespace)
+	vgs.SetLabels(labels)
+	vgs.SetAnnotations(annotations)
+	_, err := c.dynamicClient.Resource(resource).Namespace(snap.Namespace).Create(context.TODO(), vgs, metav1.CreateOptions{})
+	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating VolumeGroupSnapshot: %v", err)
+	}
+	return nil
+}
+
+// getVolumeGroupSnapshot returns the VolumeGroupSnapshot for the current
+// attempt of the group snapshot. Returns nil if the snapshots of the group
+// were created individually.
+func (c *csi) getVolumeGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) (*unstructured.Unstructured, error) {
+	if c.dynamicClient == nil {
+		return nil, nil
+	}
+	for _, s := range snap.Status.VolumeSnapshots {
+		if s.TaskID != getVolumeGroupSnapshotName(snap, snap.Status.NumRetries) {
+			return nil, nil
+		}
+	}
+	resource, err := c.getVolumeGroupSnapshotResource()
+	if err != nil {
+		return nil, err
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("VolumeGroupSnapshots are no longer supported by the cluster")
+	}
+	vgs, err := c.dynamicClient.Resource(*resource).Namespace(snap.Namespace).Get(
+		context.TODO(),
+		getVolumeGroupSnapshotName(snap, snap.Status.NumRetries),
+		metav1.GetOptions{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return nil, fmt.Errorf("VolumeGroupSnapshot for group snapshot %v not found", snap.Name)
+		}
+		return nil, err
+	}
+	return vgs, nil
+}
+
+func (c *csi) getVolumeGroupSnapshotStatus(
+	snap *storkapi.GroupVolumeSnapshot,
+	vgs *unstructured.Unstructured,
+) (*storkvolume.GroupSnapshotCreateResponse, error) {
+	if message, found, _ := unstructured.NestedString(vgs.Object, "status", "error", "message"); found {
+		snapshots := make([]*storkapi.VolumeSnapshotStatus, 0)
+		for _, s := range snap.Status.VolumeSnapshots {
+			snapshots = append(snapshots, &storkapi.VolumeSnapshotStatus{
+				VolumeSnapshotName: s.VolumeSnapshotName,
+				TaskID:             vgs.GetName(),
+				ParentVolumeID:     s.ParentVolumeID,
+				Conditions:         getGroupSnapshotConditions(snapshotter.StatusFailed, message),
+			})
+		}
+		return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snapshots}, nil
+	}
+
+	readyToUse, _, _ := unstructured.NestedBool(vgs.Object, "status", "readyToUse")
+	refs, err := getVolumeGroupSnapshotRefs(vgs)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 && readyToUse {
+		// Newer versions of the API don't list the snapshots in the status
+		if refs, err = c.getOwnedSnapshotRefs(snap, vgs); err != nil {
+			return nil, err
+		}
+	}
+	if len(refs) == 0 {
+		// The member snapshots haven't been created yet
+		return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snap.Status.VolumeSnapshots}, nil
+	}
+
+	snapshots := make([]*storkapi.VolumeSnapshotStatus, 0)
+	for vsName, pvcName := range refs {
+		info, err := c.snapshotter.SnapshotStatus(vsName, snap.Namespace)
+		if err != nil && info.Status != snapshotter.StatusFailed {
+			return nil, err
+		}
+		if pvcName == "" {
+			if vs, ok := info.SnapshotRequest.(*kSnapshotv1.VolumeSnapshot); ok && vs.Spec.Source.PersistentVolumeClaimName != nil {
+				pvcName = *vs.Spec.Source.PersistentVolumeClaimName
+			}
+		}
+		parentVolumeID := pvcName
+		if pvc, err := core.Instance().GetPersistentVolumeClaim(pvcName, snap.Namespace); err == nil {
+			parentVolumeID = pvc.Spec.VolumeName
+		}
+		status := info.Status
+		if status == snapshotter.StatusReady && !readyToUse {
+			status = snapshotter.StatusInProgress
+		}
+		snapshots = append(snapshots, &storkapi.VolumeSnapshotStatus{
+			VolumeSnapshotName: vsName,
+			TaskID:             vgs.GetName(),
+			ParentVolumeID:     parentVolumeID,
+			Conditions:         getGroupSnapshotConditions(status, info.Reason),
+		})
+	}
+	return &storkvolume.GroupSnapshotCreateResponse{Snapshots: snapshots}, nil
+}
+
+// getVolumeGroupSnapshotRefs returns the names of the VolumeSnapshots created
+// for the group mapped to the PVCs they were created from. Older versions of
+// the API only list the snapshots, in which case the PVC names are empty.
+func getVolumeGroupSnapshotRefs(vgs *unstructured.Unstructured) (map[string]string, error) {
+	refs := make(map[string]string)
+	pairs, _, err := unstructured.NestedSlice(vgs.Object, "status", "pvcVolumeSnapshotRefList")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pairs {
+		pair, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		vsName, _, _ := unstructured.NestedString(pair, "volumeSnapshotRef", "name")
+		pvcName, _, _ := unstructured.NestedString(pair, "persistentVolumeClaimRef", "name")
+		if vsName != "" {
+			refs[vsName] = pvcName
+		}
+	}
+	if len(refs) > 0 {
+		return refs, nil
+	}
+
+	snapshotRefs, _, err := unstructured.NestedSlice(vgs.Object, "status", "volumeSnapshotRefList")
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range snapshotRefs {
+		ref, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if vsName, _, _ := unstructured.NestedString(ref, "name"); vsName != "" {
+			refs[vsName] = ""
+		}
+	}
+	return refs, nil
+}
+
+// getOwnedSnapshotRefs returns the names of the VolumeSnapshots owned by the
+// VolumeGroupSnapshot mapped to the PVCs they were created from. The PVCs are
+// found by matching the snapshot handles of the snapshots with the volume
+// handles recorded in the VolumeGroupSnapshotContent.
+func (c *csi) getOwnedSnapshotRefs(
+	snap *storkapi.GroupVolumeSnapshot,
+	vgs *unstructured.Unstructured,
+) (map[string]string, error) {
+	snapshotVersion := volumeSnapshotV1beta1Version
+	if c.v1SnapshotRequired {
+		snapshotVersion = volumeSnapshotV1Version
+	}
+	snapshotResource := schema.GroupVersionResource{Group: volumeSnapshotGroup, Version: snapshotVersion, Resource: volumeSnapshotPlural}
+	contentResource := snapshotResource.GroupVersion().WithResource(volumeSnapshotContentPlural)
+
+	volumeHandles := make(map[string]string)
+	if contentName, _, _ := unstructured.NestedString(vgs.Object, "status", "boundVolumeGroupSnapshotContentName"); contentName != "" {
+		groupContentResource := vgs.GroupVersionKind().GroupVersion().WithResource(volumeGroupSnapshotContentPlural)
+		groupContent, err := c.dynamicClient.Resource(groupContentResource).Get(context.TODO(), contentName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting VolumeGroupSnapshotContent %v: %v", contentName, err)
+		}
+		pairs, _, err := unstructured.NestedSlice(groupContent.Object, "status", "volumeSnapshotHandlePairList")
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			if pair, ok := p.(map[string]interface{}); ok {
+				volumeHandle, _, _ := unstructured.NestedString(pair, "volumeHandle")
+				snapshotHandle, _, _ := unstructured.NestedString(pair, "snapshotHandle")
+				volumeHandles[snapshotHandle] = volumeHandle
+			}
+		}
+	}
+	pvcs, err := k8sutils.GetPVCsForGroupSnapshot(snap.Namespace, snap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	pvcNames := make(map[string]string)
+	for _, pvc := range pvcs {
+		pv, err := core.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		if pv.Spec.CSI != nil {
+			pvcNames[pv.Spec.CSI.VolumeHandle] = pvc.Name
+		}
+	}
+
+	snapshots, err := c.dynamicClient.Resource(snapshotResource).Namespace(snap.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for _, vs := range snapshots.Items {
+		owned := false
+		for _, owner := range vs.GetOwnerReferences() {
+			if owner.UID == vgs.GetUID() {
+				owned = true
+			}
+		}
+		if !owned {
+			continue
+		}
+		pvcName := ""
+		if contentName, _, _ := unstructured.NestedString(vs.Object, "status", "boundVolumeSnapshotContentName"); contentName != "" {
+			content, err := c.dynamicClient.Resource(contentResource).Get(context.TODO(), contentName, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("error getting VolumeSnapshotContent %v: %v", contentName, err)
+			}
+			snapshotHandle, _, _ := unstructured.NestedString(content.Object, "status", "snapshotHandle")
+			pvcName = pvcNames[volumeHandles[snapshotHandle]]
+		}
+		refs[vs.GetName()] = pvcName
+	}
+	return refs, nil
+}
+
+func (c *csi) deleteVolumeGroupSnapshot(snap *storkapi.GroupVolumeSnapshot, attempt int) error {
+	resource, err := c.getVolumeGroupSnapshotResource()
+	if err != nil || resource == nil {
+		return err
+	}
+	err = c.dynamicClient.Resource(*resource).Namespace(snap.Namespace).Delete(
+		context.TODO(),
+		getVolumeGroupSnapshotName(snap, attempt),
+		metav1.DeleteOptions{})
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting VolumeGroupSnapshot: %v", err)
+	}
+	return nil
+}
+
+// deleteGroupSnapshotAttempt deletes the snapshots created by a previous
+// attempt of the group snapshot
+func (c *csi) deleteGroupSnapshotAttempt(
+	snap *storkapi.GroupVolumeSnapshot,
+	attempt int,
+	pvcs []v1.PersistentVolumeClaim,
+) error {
+	if err := c.deleteVolumeGroupSnapshot(snap, attempt); err != nil {
+		return err
+	}
+	if c.snapshotter == nil {
+		return nil
+	}
+	for _, pvc := range pvcs {
+		vsName := getGroupMemberSnapshotName(snap, attempt, pvc.Name)
+		if err := c.snapshotter.DeleteSnapshot(vsName, snap.Namespace, false); err != nil && !k8s_errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func getVolumeGroupSnapshotName(snap *storkapi.GroupVolumeSnapshot, attempt int) string {
+	return fmt.Sprintf("%s-%d", snap.Name, attempt)
+}
+
+func getGroupMemberSnapshotName(snap *storkapi.GroupVolumeSnapshot, attempt int, pvcName string) string {
+	return fmt.Sprintf("%s-%s-%d", snap.Name, pvcName, attempt)
+}
+
+// getGroupSnapshotConditions maps the status of a CSI snapshot to the
+// conditions used in the status of group snapshots
+func getGroupSnapshotConditions(status snapshotter.Status, reason string) []snapv1.VolumeSnapshotCondition {
+	condition := snapv1.VolumeSnapshotCondition{
+		Status:             v1.ConditionTrue,
+		Message:            reason,
+		LastTransitionTime: metav1.Now(),
+	}
+	switch status {
+	case snapshotter.StatusReady:
+		condition.Type = snapv1.VolumeSnapshotConditionReady
+	case snapshotter.StatusFailed:
+		condition.Type = snapv1.VolumeSnapshotConditionError
+	default:
+		condition.Type = snapv1.VolumeSnapshotConditionPending
+	}
+	return []snapv1.VolumeSnapshotCondition{condition}
+}
@@ -0,0 +1,187 @@
+//go:build unittest
+// +build unittest
+
+package csi
+
+import (
+	"context"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	v1beta1GroupSnapshots = &metav1.APIResourceList{
+		GroupVersion: "groupsnapshot.storage.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: volumeGroupSnapshotPlural}, {Name: volumeGroupSnapshotContentPlural}},
+	}
+	v1alpha1GroupSnapshots = &metav1.APIResourceList{
+		GroupVersion: "groupsnapshot.storage.k8s.io/v1alpha1",
+		APIResources: []metav1.APIResource{{Name: volumeGroupSnapshotPlural}, {Name: volumeGroupSnapshotContentPlural}},
+	}
+	v1beta1GroupSnapshotClasses = &metav1.APIResourceList{
+		GroupVersion: "groupsnapshot.storage.k8s.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "volumegroupsnapshotclasses"}},
+	}
+)
+
+func newGroupSnapshotDriver(resources []*metav1.APIResourceList, objects ...runtime.Object) *csi {
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: volumeSnapshotGroup, Version: volumeSnapshotV1Version, Resource: volumeSnapshotPlural}:        "VolumeSnapshotList",
+		{Group: volumeGroupSnapshotGroup, Version: "v1beta1", Resource: volumeGroupSnapshotPlural}:            "VolumeGroupSnapshotList",
+		{Group: volumeGroupSnapshotGroup, Version: "v1alpha1", Resource: volumeGroupSnapshotPlural}:           "VolumeGroupSnapshotList",
+		{Group: volumeSnapshotGroup, Version: volumeSnapshotV1Version, Resource: volumeSnapshotContentPlural}: "VolumeSnapshotContentList",
+		{Group: volumeGroupSnapshotGroup, Version: "v1beta1", Resource: volumeGroupSnapshotContentPlural}:     "VolumeGroupSnapshotContentList",
+	}
+	return &csi{
+		v1SnapshotRequired: true,
+		dynamicClient:      fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...),
+		discoveryClient:    &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: resources}},
+	}
+}
+
+func newGroupSnapshot() *storkapi.GroupVolumeSnapshot {
+	return &storkapi.GroupVolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{Name: "group", Namespace: "test"},
+		Spec: storkapi.GroupVolumeSnapshotSpec{
+			PVCSelector: storkapi.PVCSelectorSpec{
+				LabelSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "mysql"}},
+			},
+			Options: map[string]string{optVolumeGroupSnapshotClassName: "group-class"},
+		},
+	}
+}
+
+func newUnstructured(apiVersion, kind, namespace, name string, object map[string]interface{}) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{Object: object}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func TestGetVolumeGroupSnapshotResource(t *testing.T) {
+	resource, err := (&csi{}).getVolumeGroupSnapshotResource()
+	require.NoError(t, err)
+	require.Nil(t, resource, "VolumeGroupSnapshots should not be used without clients")
+
+	resource, err = newGroupSnapshotDriver(nil).getVolumeGroupSnapshotResource()
+	require.NoError(t, err)
+	require.Nil(t, resource, "VolumeGroupSnapshots should not be used if the group isn't served")
+
+	c := newGroupSnapshotDriver([]*metav1.APIResourceList{v1beta1GroupSnapshots, v1alpha1GroupSnapshots})
+	resource, err = c.getVolumeGroupSnapshotResource()
+	require.NoError(t, err)
+	require.NotNil(t, resource)
+	require.Equal(t, "v1beta1", resource.Version, "preferred version should be used")
+	require.Equal(t, volumeGroupSnapshotPlural, resource.Resource)
+
+	c = newGroupSnapshotDriver([]*metav1.APIResourceList{v1beta1GroupSnapshotClasses, v1alpha1GroupSnapshots})
+	resource, err = c.getVolumeGroupSnapshotResource()
+	require.NoError(t, err)
+	require.NotNil(t, resource)
+	require.Equal(t, "v1alpha1", resource.Version, "version serving VolumeGroupSnapshots should be used")
+}
+
+func TestCreateVolumeGroupSnapshot(t *testing.T) {
+	c := newGroupSnapshotDriver([]*metav1.APIResourceList{v1beta1GroupSnapshots})
+	snap := newGroupSnapshot()
+	resource, err := c.getVolumeGroupSnapshotResource()
+	require.NoError(t, err)
+	require.NoError(t, c.createVolumeGroupSnapshot(*resource, snap, map[string]string{"l": "v"}, nil))
+	// Creating it again for the same attempt should be a no-op
+	require.NoError(t, c.createVolumeGroupSnapshot(*resource, snap, nil, nil))
+
+	vgs, err := c.dynamicClient.Resource(*resource).Namespace("test").Get(
+		context.TODO(), getVolumeGroupSnapshotName(snap, 0), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "groupsnapshot.storage.k8s.io/v1beta1", vgs.GetAPIVersion())
+	require.Equal(t, map[string]string{"l": "v"}, vgs.GetLabels())
+	className, _, _ := unstructured.NestedString(vgs.Object, "spec", "volumeGroupSnapshotClassName")
+	require.Equal(t, "group-class", className)
+	matchLabels, _, _ := unstructured.NestedStringMap(vgs.Object, "spec", "source", "selector", "matchLabels")
+	require.Equal(t, map[string]string{"app": "mysql"}, matchLabels)
+}
+
+func TestGetVolumeGroupSnapshotRefs(t *testing.T) {
+	vgs := newUnstructured("groupsnapshot.storage.k8s.io/v1alpha1", "VolumeGroupSnapshot", "test", "vgs", map[string]interface{}{
+		"status": map[string]interface{}{
+			"pvcVolumeSnapshotRefList": []interface{}{
+				map[string]interface{}{
+					"volumeSnapshotRef":        map[string]interface{}{"name": "snap-1"},
+					"persistentVolumeClaimRef": map[string]interface{}{"name": "pvc-1"},
+				},
+			},
+		},
+	})
+	refs, err := getVolumeGroupSnapshotRefs(vgs)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"snap-1": "pvc-1"}, refs)
+
+	vgs = newUnstructured("groupsnapshot.storage.k8s.io/v1alpha1", "VolumeGroupSnapshot", "test", "vgs", map[string]interface{}{
+		"status": map[string]interface{}{
+			"volumeSnapshotRefList": []interface{}{
+				map[string]interface{}{"name": "snap-1"},
+			},
+		},
+	})
+	refs, err = getVolumeGroupSnapshotRefs(vgs)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"snap-1": ""}, refs)
+}
+
+func TestGetOwnedSnapshotRefs(t *testing.T) {
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-1", Namespace: "test", Labels: map[string]string{"app": "mysql"}},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: v1.PersistentVolumeSpec{
+			PersistentVolumeSource: v1.PersistentVolumeSource{CSI: &v1.CSIPersistentVolumeSource{VolumeHandle: "volume-1"}},
+		},
+	}
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset(pvc, pv)))
+
+	vgs := newUnstructured("groupsnapshot.storage.k8s.io/v1beta1", "VolumeGroupSnapshot", "test", "vgs", map[string]interface{}{
+		"status": map[string]interface{}{
+			"readyToUse":                          true,
+			"boundVolumeGroupSnapshotContentName": "group-content",
+		},
+	})
+	vgs.SetUID(types.UID("vgs-uid"))
+	groupContent := newUnstructured("groupsnapshot.storage.k8s.io/v1beta1", "VolumeGroupSnapshotContent", "", "group-content", map[string]interface{}{
+		"status": map[string]interface{}{
+			"volumeSnapshotHandlePairList": []interface{}{
+				map[string]interface{}{"volumeHandle": "volume-1", "snapshotHandle": "snapshot-1"},
+			},
+		},
+	})
+	member := newUnstructured("snapshot.storage.k8s.io/v1", "VolumeSnapshot", "test", "snap-1", map[string]interface{}{
+		"status": map[string]interface{}{"boundVolumeSnapshotContentName": "content-1"},
+	})
+	member.SetOwnerReferences([]metav1.OwnerReference{{Kind: "VolumeGroupSnapshot", Name: "vgs", UID: "vgs-uid"}})
+	content := newUnstructured("snapshot.storage.k8s.io/v1", "VolumeSnapshotContent", "", "content-1", map[string]interface{}{
+		"status": map[string]interface{}{"snapshotHandle": "snapshot-1"},
+	})
+	other := newUnstructured("snapshot.storage.k8s.io/v1", "VolumeSnapshot", "test", "other", map[string]interface{}{})
+
+	c := newGroupSnapshotDriver([]*metav1.APIResourceList{v1beta1GroupSnapshots}, vgs, groupContent, member, content, other)
+	refs, err := c.getOwnedSnapshotRefs(newGroupSnapshot(), vgs)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"snap-1": "pvc-1"}, refs)
+}
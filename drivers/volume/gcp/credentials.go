@@ -0,0 +1,245 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	compute "google.golang.org/api/compute/v1"
+)
+
+const (
+	// credentialSourceEnv selects the credentials used by the GCP driver when
+	// the BackupLocation doesn't have cluster credentials. If it isn't set,
+	// workload identity federation is used if GOOGLE_APPLICATION_CREDENTIALS
+	// points to an external account config, otherwise the application default
+	// credentials are used.
+	credentialSourceEnv = "STORK_GCP_CREDENTIAL_SOURCE"
+
+	// credentialSourceDefault uses the application default credentials. This
+	// includes the service account of GKE workload identity.
+	credentialSourceDefault = "default"
+	// credentialSourceWorkloadIdentityFederation exchanges the token of an
+	// external identity provider for GCP credentials using the external
+	// account config in GOOGLE_APPLICATION_CREDENTIALS
+	credentialSourceWorkloadIdentityFederation = "workloadidentityfederation"
+	// credentialSourceMetadata uses the service account of the instance
+	credentialSourceMetadata = "metadata"
+
+	applicationCredentialsEnv = "GOOGLE_APPLICATION_CREDENTIALS"
+	externalAccountType       = "external_account"
+	tokenExchangeGrantType    = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType           = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// getTokenSource returns the token source selected by credentialSourceEnv
+func getTokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	source := os.Getenv(credentialSourceEnv)
+	switch source {
+	case "":
+		if config, err := readExternalAccountConfig(); err == nil {
+			return oauth2.ReuseTokenSource(nil, &externalAccountTokenSource{ctx: ctx, config: config}), nil
+		}
+		fallthrough
+	case credentialSourceDefault:
+		creds, err := google.FindDefaultCredentials(ctx, compute.ComputeScope)
+		if err != nil {
+			return nil, err
+		}
+		return creds.TokenSource, nil
+	case credentialSourceWorkloadIdentityFederation:
+		config, err := readExternalAccountConfig()
+		if err != nil {
+			return nil, err
+		}
+		return oauth2.ReuseTokenSource(nil, &externalAccountTokenSource{ctx: ctx, config: config}), nil
+	case credentialSourceMetadata:
+		return google.ComputeTokenSource("", compute.ComputeScope), nil
+	}
+	return nil, fmt.Errorf("invalid GCP credential source %v", source)
+}
+
+// externalAccountConfig is the config file generated for workload identity
+// federation
+type externalAccountConfig struct {
+	Type                           string `json:"type"`
+	Audience                       string `json:"audience"`
+	SubjectTokenType               string `json:"subject_token_type"`
+	TokenURL                       string `json:"token_url"`
+	ServiceAccountImpersonationURL string `json:"service_account_impersonation_url"`
+	CredentialSource               struct {
+		File    string            `json:"file"`
+		URL     string            `json:"url"`
+		Headers map[string]string `json:"headers"`
+		Format  struct {
+			Type                  string `json:"type"`
+			SubjectTokenFieldName string `json:"subject_token_field_name"`
+		} `json:"format"`
+	} `json:"credential_source"`
+}
+
+func readExternalAccountConfig() (*externalAccountConfig, error) {
+	file := os.Getenv(applicationCredentialsEnv)
+	if file == "" {
+		return nil, fmt.Errorf("%v needs to be set to use workload identity federation", applicationCredentialsEnv)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %v: %v", file, err)
+	}
+	config := &externalAccountConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("error parsing %v: %v", file, err)
+	}
+	if config.Type != externalAccountType {
+		return nil, fmt.Errorf("%v isn't an external account config", file)
+	}
+	if config.CredentialSource.File == "" && config.CredentialSource.URL == "" {
+		return nil, fmt.Errorf("credential source not found in %v", file)
+	}
+	return config, nil
+}
+
+// externalAccountTokenSource exchanges the token of the external identity
+// provider for a federated access token with the security token service.
+// The federated token is exchanged for an access token of the service
+// account if impersonation is configured.
+type externalAccountTokenSource struct {
+	ctx    context.Context
+	config *externalAccountConfig
+}
+
+func (s *externalAccountTokenSource) Token() (*oauth2.Token, error) {
+	subjectToken, err := s.subjectToken()
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("grant_type", tokenExchangeGrantType)
+	values.Set("audience", s.config.Audience)
+	values.Set("scope", compute.CloudPlatformScope)
+	values.Set("requested_token_type", accessTokenType)
+	values.Set("subject_token_type", s.config.SubjectTokenType)
+	values.Set("subject_token", subjectToken)
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.config.TokenURL, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	stsToken := struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{}
+	if err := doTokenRequest(req, &stsToken); err != nil {
+		return nil, fmt.Errorf("error exchanging token with %v: %v", s.config.TokenURL, err)
+	}
+	if s.config.ServiceAccountImpersonationURL == "" {
+		return &oauth2.Token{
+			AccessToken: stsToken.AccessToken,
+			TokenType:   "Bearer",
+			Expiry:      time.Now().Add(time.Duration(stsToken.ExpiresIn) * time.Second),
+		}, nil
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"scope": []string{compute.CloudPlatformScope},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err = http.NewRequestWithContext(s.ctx, http.MethodPost, s.config.ServiceAccountImpersonationURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+stsToken.AccessToken)
+	saToken := struct {
+		AccessToken string    `json:"accessToken"`
+		ExpireTime  time.Time `json:"expireTime"`
+	}{}
+	if err := doTokenRequest(req, &saToken); err != nil {
+		return nil, fmt.Errorf("error impersonating service account: %v", err)
+	}
+	return &oauth2.Token{
+		AccessToken: saToken.AccessToken,
+		TokenType:   "Bearer",
+		Expiry:      saToken.ExpireTime,
+	}, nil
+}
+
+// subjectToken returns the token of the external identity provider from the
+// credential source. The token is read every time since it is rotated by the
+// provider.
+func (s *externalAccountTokenSource) subjectToken() (string, error) {
+	source := s.config.CredentialSource
+	var data []byte
+	var err error
+	if source.File != "" {
+		data, err = ioutil.ReadFile(source.File)
+		if err != nil {
+			return "", fmt.Errorf("error reading subject token from %v: %v", source.File, err)
+		}
+	} else {
+		req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, source.URL, nil)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range source.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("error getting subject token from %v: %v", source.URL, err)
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		data, err = ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("error getting subject token from %v: %v", source.URL, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("error getting subject token from %v: %v", source.URL, resp.Status)
+		}
+	}
+
+	if source.Format.Type != "json" {
+		return strings.TrimSpace(string(data)), nil
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", fmt.Errorf("error parsing subject token: %v", err)
+	}
+	token, ok := fields[source.Format.SubjectTokenFieldName].(string)
+	if !ok {
+		return "", fmt.Errorf("subject token field %v not found", source.Format.SubjectTokenFieldName)
+	}
+	return token, nil
+}
+
+func doTokenRequest(req *http.Request, token interface{}) error {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: %v", resp.Status, string(body))
+	}
+	return json.Unmarshal(body, token)
+}
@@ -0,0 +1,222 @@
+//go:build unittest
+// +build unittest
+
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	compute "google.golang.org/api/compute/v1"
+)
+
+const (
+	testAudience         = "//iam.googleapis.com/projects/1/locations/global/workloadIdentityPools/pool/providers/provider"
+	testSubjectTokenType = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// newCredentialServer returns a server for the subject token, the security
+// token service, the service account impersonation and the metadata server
+func newCredentialServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subject":
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"access_token": "url-subject"}`)
+		case "/sts":
+			require.NoError(t, r.ParseForm())
+			if r.Form.Get("grant_type") != tokenExchangeGrantType ||
+				r.Form.Get("audience") != testAudience ||
+				r.Form.Get("subject_token_type") != testSubjectTokenType ||
+				r.Form.Get("requested_token_type") != accessTokenType ||
+				r.Form.Get("scope") != compute.CloudPlatformScope {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error": "invalid_request"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token": "federated-%v", "expires_in": 3600}`, r.Form.Get("subject_token"))
+		case "/impersonate":
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, "Bearer federated-") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			body := struct {
+				Scope []string `json:"scope"`
+			}{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, []string{compute.CloudPlatformScope}, body.Scope)
+			fmt.Fprintf(w, `{"accessToken": "sa-%v", "expireTime": "2100-01-01T00:00:00Z"}`, strings.TrimPrefix(auth, "Bearer "))
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"access_token": "metadata-token", "expires_in": 3600, "token_type": "Bearer"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeCredentialConfig writes the config to a file that
+// GOOGLE_APPLICATION_CREDENTIALS points to
+func writeCredentialConfig(t *testing.T, config map[string]interface{}) {
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, ioutil.WriteFile(file, data, 0600))
+	t.Setenv(applicationCredentialsEnv, file)
+}
+
+// newExternalAccountConfig returns the config for workload identity
+// federation with a file credential source
+func newExternalAccountConfig(t *testing.T, server *httptest.Server) map[string]interface{} {
+	subjectFile := filepath.Join(t.TempDir(), "subject")
+	require.NoError(t, ioutil.WriteFile(subjectFile, []byte("file-subject\n"), 0600))
+	return map[string]interface{}{
+		"type":               externalAccountType,
+		"audience":           testAudience,
+		"subject_token_type": testSubjectTokenType,
+		"token_url":          server.URL + "/sts",
+		"credential_source":  map[string]interface{}{"file": subjectFile},
+	}
+}
+
+func getAccessToken(t *testing.T) string {
+	tokenSource, err := getTokenSource(context.TODO())
+	require.NoError(t, err)
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	return token.AccessToken
+}
+
+func TestCredentialSource(t *testing.T) {
+	server := newCredentialServer(t)
+	t.Setenv(credentialSourceEnv, "")
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	// Workload identity federation is used if the application credentials
+	// are an external account config
+	writeCredentialConfig(t, newExternalAccountConfig(t, server))
+	require.Equal(t, "federated-file-subject", getAccessToken(t))
+
+	t.Setenv(credentialSourceEnv, credentialSourceWorkloadIdentityFederation)
+	require.Equal(t, "federated-file-subject", getAccessToken(t))
+
+	t.Setenv(credentialSourceEnv, credentialSourceMetadata)
+	require.Equal(t, "metadata-token", getAccessToken(t))
+
+	// The default credentials are used for other application credentials
+	writeCredentialConfig(t, map[string]interface{}{
+		"type":          "authorized_user",
+		"client_id":     "client",
+		"client_secret": "secret",
+		"refresh_token": "refresh",
+	})
+	t.Setenv(credentialSourceEnv, "")
+	tokenSource, err := getTokenSource(context.TODO())
+	require.NoError(t, err)
+	_, ok := tokenSource.(*externalAccountTokenSource)
+	require.False(t, ok, "Expected default credentials")
+
+	t.Setenv(credentialSourceEnv, credentialSourceWorkloadIdentityFederation)
+	_, err = getTokenSource(context.TODO())
+	require.Error(t, err, "Expected error for workload identity federation without an external account config")
+	require.Contains(t, err.Error(), "isn't an external account config")
+
+	t.Setenv(credentialSourceEnv, "invalid")
+	_, err = getTokenSource(context.TODO())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid GCP credential source")
+}
+
+func TestExternalAccountTokenSource(t *testing.T) {
+	server := newCredentialServer(t)
+	t.Setenv(credentialSourceEnv, credentialSourceWorkloadIdentityFederation)
+
+	// Subject token from a URL in JSON format
+	config := newExternalAccountConfig(t, server)
+	config["credential_source"] = map[string]interface{}{
+		"url":     server.URL + "/subject",
+		"headers": map[string]string{"Metadata": "true"},
+		"format":  map[string]string{"type": "json", "subject_token_field_name": "access_token"},
+	}
+	writeCredentialConfig(t, config)
+	tokenSource, err := getTokenSource(context.TODO())
+	require.NoError(t, err)
+	token, err := tokenSource.Token()
+	require.NoError(t, err)
+	require.Equal(t, "federated-url-subject", token.AccessToken)
+	require.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+
+	// The federated token is exchanged for a token of the impersonated
+	// service account
+	config = newExternalAccountConfig(t, server)
+	config["service_account_impersonation_url"] = server.URL + "/impersonate"
+	writeCredentialConfig(t, config)
+	tokenSource, err = getTokenSource(context.TODO())
+	require.NoError(t, err)
+	token, err = tokenSource.Token()
+	require.NoError(t, err)
+	require.Equal(t, "sa-federated-file-subject", token.AccessToken)
+	require.Equal(t, 2100, token.Expiry.Year())
+}
+
+func TestExternalAccountTokenSourceErrors(t *testing.T) {
+	server := newCredentialServer(t)
+	t.Setenv(credentialSourceEnv, credentialSourceWorkloadIdentityFederation)
+
+	for _, test := range []struct {
+		name   string
+		modify func(map[string]interface{})
+		err    string
+	}{
+		{"no credential source", func(c map[string]interface{}) {
+			c["credential_source"] = map[string]interface{}{}
+		}, "credential source not found"},
+		{"missing subject token file", func(c map[string]interface{}) {
+			c["credential_source"] = map[string]interface{}{"file": filepath.Join(t.TempDir(), "missing")}
+		}, "error reading subject token"},
+		{"subject token URL error", func(c map[string]interface{}) {
+			c["credential_source"] = map[string]interface{}{"url": server.URL + "/subject"}
+		}, "401 Unauthorized"},
+		{"missing subject token field", func(c map[string]interface{}) {
+			c["credential_source"] = map[string]interface{}{
+				"url":     server.URL + "/subject",
+				"headers": map[string]string{"Metadata": "true"},
+				"format":  map[string]string{"type": "json", "subject_token_field_name": "id_token"},
+			}
+		}, "subject token field id_token not found"},
+		{"token exchange error", func(c map[string]interface{}) {
+			c["audience"] = "other"
+		}, "invalid_request"},
+		{"impersonation error", func(c map[string]interface{}) {
+			c["service_account_impersonation_url"] = server.URL + "/missing"
+		}, "error impersonating service account"},
+	} {
+		config := newExternalAccountConfig(t, server)
+		test.modify(config)
+		writeCredentialConfig(t, config)
+		tokenSource, err := getTokenSource(context.TODO())
+		if err == nil {
+			_, err = tokenSource.Token()
+		}
+		require.Error(t, err, test.name)
+		require.Contains(t, err.Error(), test.err, test.name)
+	}
+}
@@ -13,11 +13,11 @@ import (
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/secretprovider"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
-	"golang.org/x/oauth2/google"
 	compute "google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
@@ -53,6 +53,10 @@ type gcp struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+	storkvolume.ProgressNotSupported
 }
 
 type gcpSession struct {
@@ -72,11 +76,11 @@ func (g *gcp) Init(_ interface{}) error {
 		return fmt.Errorf("error getting projectID for gce: %v", err)
 	}
 
-	creds, err := google.FindDefaultCredentials(context.Background(), compute.ComputeScope)
+	tokenSource, err := getTokenSource(context.Background())
 	if err != nil {
 		return err
 	}
-	g.service, err = compute.NewService(context.TODO(), option.WithTokenSource(creds.TokenSource))
+	g.service, err = compute.NewService(context.TODO(), option.WithTokenSource(tokenSource))
 	if err != nil {
 		return err
 	}
@@ -167,12 +171,11 @@ func isCsiProvisioner(provisioner string) bool {
 func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 	pvcs []v1.PersistentVolumeClaim,
 ) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	gcpSession, err := g.getGCPSession(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		return nil, err
 	}
-	service := gcpSession.service
-	projectID := gcpSession.projectID
+	sessions := g.getGCPSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
@@ -181,6 +184,14 @@ func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 			log.ApplicationBackupLog(backup).Warnf("Ignoring PVC %v which is being deleted", pvc.Name)
 			continue
 		}
+		account := storkvolume.GetCloudAccountForPVC(backupLocation, &pvc)
+		gcpSession, err := getSession(sessions, account)
+		if err != nil {
+			return nil, err
+		}
+		service := gcpSession.service
+		projectID := gcpSession.projectID
+
 		volumeInfo := &storkapi.ApplicationBackupVolumeInfo{}
 		volumeInfo.PersistentVolumeClaim = pvc.Name
 		volumeInfo.PersistentVolumeClaimUID = string(pvc.UID)
@@ -189,6 +200,9 @@ func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 		volumeInfo.Options = map[string]string{
 			"projectID": projectID,
 		}
+		if account != "" {
+			volumeInfo.Options[storkvolume.CloudAccountOption] = account
+		}
 		volumeInfos = append(volumeInfos, volumeInfo)
 
 		pvName, err := core.Instance().GetVolumeForPersistentVolumeClaim(&pvc)
@@ -222,7 +236,13 @@ func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 		labels := storkvolume.GetApplicationBackupLabels(backup, &pvc)
 		filter := g.getFilterFromMap(labels)
 		// First check if the snapshot has already been created with the same labels
-		if snapshots, err := service.Snapshots.List(projectID).Filter(filter).Do(); err == nil && len(snapshots.Items) == 1 {
+		var snapshots *compute.SnapshotList
+		listErr := g.throttle().Call(func() error {
+			var err error
+			snapshots, err = service.Snapshots.List(projectID).Filter(filter).Do()
+			return err
+		})
+		if listErr == nil && len(snapshots.Items) == 1 {
 			volumeInfo.BackupID = snapshots.Items[0].Name
 		} else {
 			if len(volumeInfo.Zones) > 1 {
@@ -236,7 +256,10 @@ func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 				}
 				snapshotCall := service.RegionDisks.CreateSnapshot(projectID, region, pdName, snapshot)
 
-				_, err = snapshotCall.Do()
+				err = g.throttle().Call(func() error {
+					_, err := snapshotCall.Do()
+					return err
+				})
 				if err != nil {
 					return nil, fmt.Errorf("error triggering backup for volume: %v (PVC: %v, Namespace: %v): %v", volume, pvc.Name, pvc.Namespace, err)
 				}
@@ -248,7 +271,10 @@ func (g *gcp) StartBackup(backup *storkapi.ApplicationBackup,
 				}
 				snapshotCall := service.Disks.CreateSnapshot(projectID, volumeInfo.Zones[0], pdName, snapshot)
 
-				_, err = snapshotCall.Do()
+				err = g.throttle().Call(func() error {
+					_, err := snapshotCall.Do()
+					return err
+				})
 				if err != nil {
 					return nil, fmt.Errorf("error triggering backup for volume: %v (PVC: %v, Namespace: %v): %v", volume, pvc.Name, pvc.Namespace, err)
 				}
@@ -281,33 +307,49 @@ func (g *gcp) getRegion(zone string) (string, error) {
 func (g *gcp) getZoneURLs(zones []string, service *compute.Service, projectID string) ([]string, error) {
 	zoneURLs := make([]string, 0)
 	for _, zone := range zones {
-		zoneInfo, err := service.Zones.Get(projectID, zone).Do()
+		zone := zone
+		zoneInfo, err := g.throttle().CachedCall("zone/"+projectID+"/"+zone, func() (interface{}, error) {
+			return service.Zones.Get(projectID, zone).Do()
+		})
 		if err != nil {
 			return nil, err
 		}
-		zoneURLs = append(zoneURLs, zoneInfo.SelfLink)
+		zoneURLs = append(zoneURLs, zoneInfo.(*compute.Zone).SelfLink)
 	}
 	return zoneURLs, nil
 }
 
 func (g *gcp) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
-	gcpSession, err := g.getGCPSession(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	service := gcpSession.service
-	projectID := gcpSession.projectID
-
+	sessions := g.getGCPSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.GCEDriverName {
 			continue
 		}
-		snapshot, err := service.Snapshots.Get(projectID, vInfo.BackupID).Do()
+		gcpSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return nil, err
+		}
+		service := gcpSession.service
+		projectID := gcpSession.projectID
+		snapshots, err := g.listStorkSnapshots(service, projectID)
 		if err != nil {
 			return nil, err
 		}
+		snapshot, ok := snapshots[vInfo.BackupID]
+		if !ok {
+			// Snapshots created after the list was cached need to be
+			// looked up individually
+			backupID := vInfo.BackupID
+			result, err := g.throttle().CachedCall("snapshot/"+projectID+"/"+backupID, func() (interface{}, error) {
+				return service.Snapshots.Get(projectID, backupID).Do()
+			})
+			if err != nil {
+				return nil, err
+			}
+			snapshot = result.(*compute.Snapshot)
+		}
 		switch snapshot.Status {
 		case "CREATING", "UPLOADING":
 			vInfo.Status = storkapi.ApplicationBackupStatusInProgress
@@ -328,23 +370,50 @@ func (g *gcp) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.A
 
 }
 
+// listStorkSnapshots returns all the snapshots created by stork in the
+// project, indexed by name. The list is cached so that the status of all the
+// snapshots being polled can be checked with a single call.
+func (g *gcp) listStorkSnapshots(service *compute.Service, projectID string) (map[string]*compute.Snapshot, error) {
+	result, err := g.throttle().CachedCall("snapshots/"+projectID, func() (interface{}, error) {
+		snapshots := make(map[string]*compute.Snapshot)
+		err := service.Snapshots.List(projectID).Filter(g.getFilterFromMap(map[string]string{"created-by": "stork"})).Pages(context.TODO(),
+			func(list *compute.SnapshotList) error {
+				for _, snapshot := range list.Items {
+					snapshots[snapshot.Name] = snapshot
+				}
+				return nil
+			})
+		return snapshots, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]*compute.Snapshot), nil
+}
+
 func (g *gcp) CancelBackup(backup *storkapi.ApplicationBackup) error {
 	_, err := g.DeleteBackup(backup)
 	return err
 }
 
 func (g *gcp) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
-	gcpSession, err := g.getGCPSession(backup.Spec.BackupLocation, backup.Namespace)
-	if err != nil {
-		return true, err
-	}
-	service := gcpSession.service
+	sessions := g.getGCPSessions(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 
 	for _, vInfo := range backup.Status.Volumes {
 		if vInfo.DriverName != storkvolume.GCEDriverName {
 			continue
 		}
-		_, err := service.Snapshots.Delete(vInfo.Options["projectID"], vInfo.BackupID).Do()
+		gcpSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return true, err
+		}
+		service := gcpSession.service
+		projectID := vInfo.Options["projectID"]
+		err = g.throttle().Call(func() error {
+			_, err := service.Snapshots.Delete(projectID, vInfo.BackupID).Do()
+			return err
+		})
+		g.throttle().Invalidate("snapshot/" + projectID + "/" + vInfo.BackupID)
 		if err != nil {
 			return true, err
 		}
@@ -405,15 +474,9 @@ func (g *gcp) StartRestore(
 	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
 	preRestoreObjects []runtime.Unstructured,
 ) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	gcpSession, err := g.getGCPSession(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	service := gcpSession.service
-	projectID := gcpSession.projectID
+	sessions := g.getGCPSessions(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
-	var nodeZoneList []string
-	nodeZoneList, err = storkvolume.GetNodeZones()
+	nodeZoneList, err := storkvolume.GetNodeZones()
 	if err != nil {
 		return nil, err
 	}
@@ -421,6 +484,15 @@ func (g *gcp) StartRestore(
 	zoneMap := storkvolume.MapZones(backupZoneList, nodeZoneList)
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, backupVolumeInfo := range volumeBackupInfos {
+		// Volumes are restored in the project holding the snapshot
+		account := backupVolumeInfo.Options[storkvolume.CloudAccountOption]
+		gcpSession, err := getSession(sessions, account)
+		if err != nil {
+			return nil, err
+		}
+		service := gcpSession.service
+		projectID := gcpSession.projectID
+
 		volumeInfo := &storkapi.ApplicationRestoreVolumeInfo{
 			PersistentVolumeClaim:    backupVolumeInfo.PersistentVolumeClaim,
 			PersistentVolumeClaimUID: backupVolumeInfo.PersistentVolumeClaimUID,
@@ -429,6 +501,9 @@ func (g *gcp) StartRestore(
 			DriverName:               storkvolume.GCEDriverName,
 			Zones:                    backupVolumeInfo.Zones,
 		}
+		if account != "" {
+			volumeInfo.Options = map[string]string{storkvolume.CloudAccountOption: account}
+		}
 		volumeInfos = append(volumeInfos, volumeInfo)
 		labels := storkvolume.GetApplicationRestoreLabels(restore, volumeInfo)
 		filter := g.getFilterFromMap(labels)
@@ -453,10 +528,19 @@ func (g *gcp) StartRestore(
 			}
 
 			// First check if the disk has already been created with the same labels
-			if disks, err := service.RegionDisks.List(projectID, region).Filter(filter).Do(); err == nil && len(disks.Items) == 1 {
+			var disks *compute.DiskList
+			listErr := g.throttle().Call(func() error {
+				var err error
+				disks, err = service.RegionDisks.List(projectID, region).Filter(filter).Do()
+				return err
+			})
+			if listErr == nil && len(disks.Items) == 1 {
 				volumeInfo.RestoreVolume = disks.Items[0].Name
 			} else {
-				_, err = service.RegionDisks.Insert(projectID, region, disk).Do()
+				err = g.throttle().Call(func() error {
+					_, err := service.RegionDisks.Insert(projectID, region, disk).Do()
+					return err
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -470,10 +554,19 @@ func (g *gcp) StartRestore(
 			}
 			destFullZoneName := destRegion + "-" + zoneMap[destZoneName[2]]
 			// First check if the disk has already been created with the same labels
-			if disks, err := service.Disks.List(projectID, destFullZoneName).Filter(filter).Do(); err == nil && len(disks.Items) == 1 {
+			var disks *compute.DiskList
+			listErr := g.throttle().Call(func() error {
+				var err error
+				disks, err = service.Disks.List(projectID, destFullZoneName).Filter(filter).Do()
+				return err
+			})
+			if listErr == nil && len(disks.Items) == 1 {
 				volumeInfo.RestoreVolume = disks.Items[0].Name
 			} else {
-				_, err := service.Disks.Insert(projectID, destFullZoneName, disk).Do()
+				err := g.throttle().Call(func() error {
+					_, err := service.Disks.Insert(projectID, destFullZoneName, disk).Do()
+					return err
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -491,12 +584,7 @@ func (g *gcp) CancelRestore(restore *storkapi.ApplicationRestore) error {
 }
 
 func (g *gcp) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
-	gcpSession, err := g.getGCPSession(restore.Spec.BackupLocation, restore.Namespace)
-	if err != nil {
-		return nil, err
-	}
-	service := gcpSession.service
-	projectID := gcpSession.projectID
+	sessions := g.getGCPSessions(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	for _, vInfo := range restore.Status.Volumes {
@@ -507,6 +595,12 @@ func (g *gcp) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 			volumeInfos = append(volumeInfos, vInfo)
 			continue
 		}
+		gcpSession, err := getSession(sessions, vInfo.Options[storkvolume.CloudAccountOption])
+		if err != nil {
+			return nil, err
+		}
+		service := gcpSession.service
+		projectID := gcpSession.projectID
 		var status string
 		if len(vInfo.Zones) == 0 {
 			return nil, fmt.Errorf("zones missing for restore volume %v",
@@ -517,7 +611,10 @@ func (g *gcp) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 			if err != nil {
 				return nil, err
 			}
-			disk, err := service.RegionDisks.Get(projectID, region, vInfo.RestoreVolume).Do()
+			restoreVolume := vInfo.RestoreVolume
+			result, err := g.throttle().CachedCall("disk/"+projectID+"/"+region+"/"+restoreVolume, func() (interface{}, error) {
+				return service.RegionDisks.Get(projectID, region, restoreVolume).Do()
+			})
 			if err != nil {
 				if googleErr, ok := err.(*googleapi.Error); ok {
 					if googleErr.Code == http.StatusNotFound {
@@ -529,13 +626,17 @@ func (g *gcp) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 				}
 				return nil, err
 			}
+			disk := result.(*compute.Disk)
 			status = disk.Status
 			// Returns size in GB to the nearest decimal, converting it into bytes
 			// to be consistent with other cloud providers
 			size := disk.SizeGb * 1024 * 1024
 			vInfo.TotalSize = uint64(size)
 		} else {
-			disk, err := service.Disks.Get(projectID, vInfo.Zones[0], vInfo.RestoreVolume).Do()
+			zone, restoreVolume := vInfo.Zones[0], vInfo.RestoreVolume
+			result, err := g.throttle().CachedCall("disk/"+projectID+"/"+zone+"/"+restoreVolume, func() (interface{}, error) {
+				return service.Disks.Get(projectID, zone, restoreVolume).Do()
+			})
 			if err != nil {
 				if googleErr, ok := err.(*googleapi.Error); ok {
 					if googleErr.Code == http.StatusNotFound {
@@ -547,6 +648,7 @@ func (g *gcp) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkap
 				}
 				return nil, err
 			}
+			disk := result.(*compute.Disk)
 			status = disk.Status
 			size := disk.SizeGb * 1024 * 1024
 			vInfo.TotalSize = uint64(size)
@@ -611,6 +713,11 @@ func (g *gcp) CleanupRestoreResources(*storkapi.ApplicationRestore) error {
 	return nil
 }
 
+// throttle returns the throttle shared by all the calls made to the GCE APIs
+func (g *gcp) throttle() *storkvolume.CloudAPIThrottle {
+	return storkvolume.GetCloudAPIThrottle(storkvolume.GCEDriverName)
+}
+
 // getGCPClientFromBackupLocation will return a client object using creds referred in backuplocation
 func (g *gcp) getGCPClientFromBackupLocation(backupLocationName, ns string) *gcpSession {
 	gcpSessionWithCred := &gcpSession{}
@@ -619,8 +726,12 @@ func (g *gcp) getGCPClientFromBackupLocation(backupLocationName, ns string) *gcp
 		logrus.Errorf("error getting backup location %s resource: %v", backupLocationName, err)
 		return gcpSessionWithCred
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		logrus.Errorf("error getting external secrets for backup location %s: %v", backupLocationName, err)
+		return gcpSessionWithCred
+	}
 
-	if len(backupLocation.Cluster.SecretConfig) > 0 && backupLocation.Cluster.GCPClusterConfig != nil {
+	if (len(backupLocation.Cluster.SecretConfig) > 0 || backupLocation.Cluster.ExternalSecretConfig != nil) && backupLocation.Cluster.GCPClusterConfig != nil {
 		ctx := context.Background()
 		gcpSessionWithCred.projectID = backupLocation.Cluster.GCPClusterConfig.ProjectID
 		gcpSessionWithCred.service, err = compute.NewService(ctx, option.WithCredentialsJSON([]byte(backupLocation.Cluster.GCPClusterConfig.AccountKey)))
@@ -647,6 +758,48 @@ func (g *gcp) getGCPSession(backupLocationName, ns string) (*gcpSession, error)
 	return gcpSession, nil
 }
 
+// getGCPSessionForAccount returns a session using the credentials of an
+// account of the backup location. Unlike the cluster credentials, it doesn't
+// fall back to the credentials stork is running with since the volumes are
+// in another project.
+func (g *gcp) getGCPSessionForAccount(backupLocationName, ns, account string) (*gcpSession, error) {
+	backupLocation, err := storkops.Instance().GetBackupLocation(backupLocationName, ns)
+	if err != nil {
+		return nil, err
+	}
+	cloudAccount := backupLocation.Cluster.GetAccount(account)
+	if cloudAccount == nil || cloudAccount.GCPClusterConfig == nil {
+		return nil, fmt.Errorf("GCP credentials for account %v not found in backup location %v/%v", account, ns, backupLocationName)
+	}
+	service, err := compute.NewService(context.Background(), option.WithCredentialsJSON([]byte(cloudAccount.GCPClusterConfig.AccountKey)))
+	if err != nil {
+		return nil, fmt.Errorf("error creating gcp client session for account %v of backuplocation %v: %v", account, backupLocationName, err)
+	}
+	return &gcpSession{
+		projectID: cloudAccount.GCPClusterConfig.ProjectID,
+		service:   service,
+	}, nil
+}
+
+// getGCPSessions returns the sessions for the cluster and the accounts of
+// the backup location
+func (g *gcp) getGCPSessions(backupLocationName, ns string) *storkvolume.CloudClients {
+	return storkvolume.NewCloudClients(func(account string) (interface{}, error) {
+		if account == "" {
+			return g.getGCPSession(backupLocationName, ns)
+		}
+		return g.getGCPSessionForAccount(backupLocationName, ns, account)
+	})
+}
+
+func getSession(sessions *storkvolume.CloudClients, account string) (*gcpSession, error) {
+	session, err := sessions.Get(account)
+	if err != nil {
+		return nil, err
+	}
+	return session.(*gcpSession), nil
+}
+
 func init() {
 	g := &gcp{}
 	err := g.Init(nil)
@@ -1,10 +1,14 @@
 package kdmp
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aquilax/truncate"
@@ -16,6 +20,7 @@ import (
 	"github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/pvcbinding"
 	kdmpapi "github.com/portworx/kdmp/pkg/apis/kdmp/v1alpha1"
 	"github.com/portworx/kdmp/pkg/controllers/dataexport"
 	"github.com/portworx/kdmp/pkg/drivers"
@@ -28,8 +33,10 @@ import (
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -75,14 +82,20 @@ const (
 	// backup related Labels
 	applicationBackupCRNameKey = kdmpAnnotationPrefix + "applicationbackup-cr-name"
 	applicationBackupCRUIDKey  = kdmpAnnotationPrefix + "applicationbackup-cr-uid"
-	backupObjectNameKey        = kdmpAnnotationPrefix + "backupobject-name"
-	backupObjectUIDKey         = kdmpAnnotationPrefix + "backupobject-uid"
+	// applicationBackupCRNamespaceKey is used to report the progress of the
+	// DataExports to the backup
+	applicationBackupCRNamespaceKey = kdmpAnnotationPrefix + "applicationbackup-cr-namespace"
+	backupObjectNameKey             = kdmpAnnotationPrefix + "backupobject-name"
+	backupObjectUIDKey              = kdmpAnnotationPrefix + "backupobject-uid"
 
 	// restore related Labels
 	applicationRestoreCRNameKey = kdmpAnnotationPrefix + "applicationrestore-cr-name"
 	applicationRestoreCRUIDKey  = kdmpAnnotationPrefix + "applicationrestore-cr-uid"
-	restoreObjectNameKey        = kdmpAnnotationPrefix + "restoreobject-name"
-	restoreObjectUIDKey         = kdmpAnnotationPrefix + "restoreobject-uid"
+	// applicationRestoreCRNamespaceKey is used to report the progress of the
+	// DataExports to the restore
+	applicationRestoreCRNamespaceKey = kdmpAnnotationPrefix + "applicationrestore-cr-namespace"
+	restoreObjectNameKey             = kdmpAnnotationPrefix + "restoreobject-name"
+	restoreObjectUIDKey              = kdmpAnnotationPrefix + "restoreobject-uid"
 
 	pvcNameKey = kdmpAnnotationPrefix + "pvc-name"
 	pvcUIDKey  = kdmpAnnotationPrefix + "pvc-uid"
@@ -103,6 +116,14 @@ const (
 	ocpAWSNodeLabelKey        = "topology.ebs.csi.aws.com/zone"
 )
 
+const (
+	// pvcCreateConcurrency is the number of restore PVCs created in parallel
+	pvcCreateConcurrency = 10
+	// restorePVCBindTimeout is how long to wait for the restore PVCs to be
+	// bound before the restore is retried
+	restorePVCBindTimeout = 5 * time.Minute
+)
+
 var volumeAPICallBackoff = wait.Backoff{
 	Duration: volumeinitialDelay,
 	Factor:   volumeFactor,
@@ -120,6 +141,11 @@ type kdmp struct {
 	storkvolume.ClusterDomainsNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+
+	progressStopChannel chan struct{}
 }
 
 func (k *kdmp) Init(_ interface{}) error {
@@ -131,6 +157,10 @@ func (k *kdmp) String() string {
 }
 
 func (k *kdmp) Stop() error {
+	if k.progressStopChannel != nil {
+		close(k.progressStopChannel)
+		k.progressStopChannel = nil
+	}
 	return nil
 }
 
@@ -152,6 +182,24 @@ func (k *kdmp) OwnsPV(pv *v1.PersistentVolume) bool {
 	return true
 }
 
+// BackupDataExportSelector returns the label selector for the DataExports
+// created for the volumes of the backup
+func BackupDataExportSelector(backup *storkapi.ApplicationBackup) string {
+	return labels.Set{
+		applicationBackupCRNameKey: getValidLabel(backup.Name),
+		applicationBackupCRUIDKey:  getValidLabel(getShortUID(string(backup.UID))),
+	}.String()
+}
+
+// RestoreDataExportSelector returns the label selector for the DataExports
+// created for the volumes of the restore
+func RestoreDataExportSelector(restore *storkapi.ApplicationRestore) string {
+	return labels.Set{
+		applicationRestoreCRNameKey: getValidLabel(restore.Name),
+		applicationRestoreCRUIDKey:  getValidLabel(string(restore.UID)),
+	}.String()
+}
+
 func getGenericCRName(opsPrefix, crUID, pvcUID, ns string) string {
 	name := fmt.Sprintf("%s-%s-%s-%s", opsPrefix, getShortUID(crUID), getShortUID(pvcUID), ns)
 	name = getValidLabel(name)
@@ -257,6 +305,9 @@ func (k *kdmp) StartBackup(backup *storkapi.ApplicationBackup,
 	pvcs []v1.PersistentVolumeClaim,
 ) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
 	log.ApplicationBackupLog(backup).Debugf("started generic backup: %v", backup.Name)
+	if err := checkExternalSecret(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace()); err != nil {
+		return nil, err
+	}
 	volumeInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
 	for _, pvc := range pvcs {
 		if pvc.DeletionTimestamp != nil {
@@ -298,6 +349,7 @@ func (k *kdmp) StartBackup(backup *storkapi.ApplicationBackup,
 		labels := make(map[string]string)
 		labels[applicationBackupCRNameKey] = getValidLabel(backup.Name)
 		labels[applicationBackupCRUIDKey] = getValidLabel(getShortUID(string(backup.UID)))
+		labels[applicationBackupCRNamespaceKey] = backup.Namespace
 		labels[pvcNameKey] = getValidLabel(pvc.Name)
 		labels[pvcUIDKey] = getValidLabel(getShortUID(string(pvc.UID)))
 		// If backup from px-backup, update the backup object details in the label
@@ -326,7 +378,7 @@ func (k *kdmp) StartBackup(backup *storkapi.ApplicationBackup,
 		dataExport.Spec.Destination = kdmpapi.DataExportObjectReference{
 			Kind:       reflect.TypeOf(storkapi.BackupLocation{}).Name(),
 			Name:       backup.Spec.BackupLocation,
-			Namespace:  backup.Namespace,
+			Namespace:  backup.GetBackupLocationNamespace(),
 			APIVersion: StorkAPIVersion,
 		}
 		dataExport.Spec.Source = kdmpapi.DataExportObjectReference{
@@ -363,6 +415,22 @@ func (k *kdmp) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.
 			return volumeInfos, err
 		}
 
+		// Deleting the DataExport of a volume, or annotating it to be
+		// cancelled, cancels the whole backup. A DataExport that can't be
+		// found isn't treated as cancelled since the backup data would be
+		// deleted along with it.
+		if isDataExportCancelled(dataExport) {
+			if dataExport.DeletionTimestamp == nil {
+				if err := kdmpShedOps.Instance().DeleteDataExport(crName, vInfo.Namespace); err != nil && !k8serror.IsNotFound(err) {
+					return volumeInfos, fmt.Errorf("failed to delete cancelled DataExport CR [%v]: %v", crName, err)
+				}
+			}
+			vInfo.Status = storkapi.ApplicationBackupStatusCancelled
+			vInfo.Reason = "Volume backup was cancelled"
+			volumeInfos = append(volumeInfos, vInfo)
+			continue
+		}
+
 		if dataExport.Status.Status == kdmpapi.DataExportStatusFailed &&
 			dataExport.Status.Stage == kdmpapi.DataExportStageFinal {
 			vInfo.Status = storkapi.ApplicationBackupStatusFailed
@@ -417,11 +485,24 @@ func isDataExportCompleted(status kdmpapi.ExportStatus) bool {
 	return false
 }
 
+// isDataExportCancelled returns true if the DataExport is being deleted or
+// has been annotated to be cancelled before it completed
+func isDataExportCancelled(dataExport *kdmpapi.DataExport) bool {
+	if isDataExportCompleted(dataExport.Status) {
+		return false
+	}
+	if dataExport.DeletionTimestamp != nil {
+		return true
+	}
+	cancel, _ := strconv.ParseBool(dataExport.Annotations[storkapi.ApplicationBackupCancelAnnotation])
+	return cancel
+}
+
 func (k *kdmp) CancelBackup(backup *storkapi.ApplicationBackup) error {
 	for _, vInfo := range backup.Status.Volumes {
 		crName := getGenericCRName(prefixBackup, string(backup.UID), vInfo.PersistentVolumeClaimUID, vInfo.Namespace)
 		err := kdmpShedOps.Instance().DeleteDataExport(crName, vInfo.Namespace)
-		if err != nil && k8serror.IsNotFound(err) {
+		if err != nil && !k8serror.IsNotFound(err) {
 			errMsg := fmt.Sprintf("failed to delete data export CR [%v]: %v", crName, err)
 			log.ApplicationBackupLog(backup).Errorf("%v", errMsg)
 		}
@@ -445,6 +526,22 @@ func (k *kdmp) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
 	return true, nil
 }
 
+// checkExternalSecret returns an error if the backup location uses an
+// external secret. The data mover jobs get their credentials from secrets
+// that kdmp creates from the backup location itself, which only has the
+// credentials from external secrets while stork is using it.
+func checkExternalSecret(name string, namespace string) error {
+	backupLocation, err := storkops.Instance().GetBackupLocation(name, namespace)
+	if err != nil {
+		return fmt.Errorf("error getting backup location %v/%v: %v", namespace, name, err)
+	}
+	if backupLocation.Location.ExternalSecretConfig != nil {
+		return fmt.Errorf("backup location %v/%v uses an external secret, which isn't supported for volumes backed up by the %v driver",
+			namespace, name, storkvolume.KDMPDriverName)
+	}
+	return nil
+}
+
 func deleteKdmpSnapshot(backup *storkapi.ApplicationBackup) (bool, error) {
 	index := -1
 	for len(backup.Status.Volumes) >= 1 {
@@ -481,7 +578,10 @@ func deleteKdmpSnapshot(backup *storkapi.ApplicationBackup) (bool, error) {
 						labels[backupObjectUIDKey] = getValidLabel(backup.Annotations[pxbackupObjectUIDKey])
 					}
 				}
-				err := dataexport.CreateCredentialsSecret(secretName, backup.Spec.BackupLocation, backup.Namespace, backup.Namespace, labels)
+				if err := checkExternalSecret(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace()); err != nil {
+					return false, err
+				}
+				err := dataexport.CreateCredentialsSecret(secretName, backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace, labels)
 				if err != nil {
 					errMsg := fmt.Sprintf("failed to create secret [%v] in namespace [%v]: %v", secretName, backup.Namespace, err)
 					log.ApplicationBackupLog(backup).Errorf("%v", errMsg)
@@ -653,6 +753,9 @@ func (k *kdmp) StartRestore(
 	objects []runtime.Unstructured,
 ) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
 	log.ApplicationRestoreLog(restore).Debugf("started generic restore: %v", restore.Name)
+	if err := checkExternalSecret(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace()); err != nil {
+		return nil, err
+	}
 	volumeInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	nodes, err := core.Instance().GetNodes()
 	if err != nil {
@@ -683,6 +786,17 @@ func (k *kdmp) StartRestore(
 		splitDestRegion = strings.Split(nodeZone, "-")
 	}
 
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get applicationbackup cr %s/%s: %v", restore.Namespace, restore.Spec.BackupName, err)
+	}
+	if _, ok := backup.Annotations[backupUIDKey]; !ok {
+		msg := fmt.Sprintf("unable to find backup uid from applicationbackup %s/%s", restore.Namespace, restore.Spec.BackupName)
+		return nil, fmt.Errorf(msg)
+	}
+	backupUID := backup.Annotations[backupUIDKey]
+
+	restoreVolumes := make([]*restoreVolume, 0, len(volumeBackupInfos))
 	for _, bkpvInfo := range volumeBackupInfos {
 		var destFullZoneName string
 		volumeInfo := &storkapi.ApplicationRestoreVolumeInfo{}
@@ -722,12 +836,37 @@ func (k *kdmp) StartRestore(
 		volumeInfo.SourceNamespace = bkpvInfo.Namespace
 		volumeInfo.SourceVolume = bkpvInfo.Volume
 		volumeInfo.DriverName = storkvolume.KDMPDriverName
+		pvc.Namespace = restoreNamespace
+		restoreVolumes = append(restoreVolumes, &restoreVolume{
+			backupInfo:           bkpvInfo,
+			restoreInfo:          volumeInfo,
+			pvc:                  pvc,
+			localSnapshotRestore: k.doLocalRestore(restore, bkpvInfo),
+		})
+	}
+
+	// Create all the PVCs up front and wait for them to be bound before
+	// starting any data movement. The data mover would otherwise wait for
+	// each PVC to be bound on its own.
+	if err := createRestorePVCs(restoreVolumes); err != nil {
+		return nil, err
+	}
+	if err := waitForRestorePVCs(restore, restoreVolumes); err != nil {
+		return nil, err
+	}
+
+	for _, rv := range restoreVolumes {
+		bkpvInfo := rv.backupInfo
+		volumeInfo := rv.restoreInfo
+		pvc := rv.pvc
+		restoreNamespace := pvc.Namespace
 
 		// create VolumeBackup CR
 		// Adding required label for debugging
 		labels := make(map[string]string)
 		labels[applicationRestoreCRNameKey] = getValidLabel(restore.Name)
 		labels[applicationRestoreCRUIDKey] = getValidLabel(string(restore.UID))
+		labels[applicationRestoreCRNamespaceKey] = restore.Namespace
 		labels[pvcNameKey] = getValidLabel(bkpvInfo.PersistentVolumeClaim)
 		labels[pvcUIDKey] = getValidLabel(bkpvInfo.PersistentVolumeClaimUID)
 		// If restorefrom px-backup, update the restore object details in the label
@@ -747,7 +886,7 @@ func (k *kdmp) StartRestore(
 		volBackup.Spec.BackupLocation = kdmpapi.DataExportObjectReference{
 			Kind:       reflect.TypeOf(storkapi.BackupLocation{}).Name(),
 			Name:       restore.Spec.BackupLocation,
-			Namespace:  restore.Namespace, // since this can be kube-system in case of multple namespace restore
+			Namespace:  restore.GetBackupLocationNamespace(), // since this can be kube-system in case of multple namespace restore
 			APIVersion: StorkAPIVersion,
 		}
 		volBackup.Spec.Repository = fmt.Sprintf("%s/%s-%s/", prefixRepo, volumeInfo.SourceNamespace, bkpvInfo.PersistentVolumeClaim)
@@ -757,18 +896,6 @@ func (k *kdmp) StartRestore(
 			return nil, err
 		}
 
-		pvc.Namespace = restoreNamespace
-
-		backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
-		if err != nil {
-			return nil, fmt.Errorf("unable to get applicationbackup cr %s/%s: %v", restore.Namespace, restore.Spec.BackupName, err)
-		}
-		var backupUID string
-		if _, ok := backup.Annotations[backupUIDKey]; !ok {
-			msg := fmt.Sprintf("unable to find backup uid from applicationbackup %s/%s", restore.Namespace, restore.Spec.BackupName)
-			return nil, fmt.Errorf(msg)
-		}
-		backupUID = backup.Annotations[backupUIDKey]
 		// create kdmp cr
 		dataExport := &kdmpapi.DataExport{}
 		dataExport.Labels = labels
@@ -787,7 +914,7 @@ func (k *kdmp) StartRestore(
 		dataExport.Namespace = restoreNamespace
 		dataExport.Status.TransferID = volBackup.Namespace + "/" + volBackup.Name
 		dataExport.Status.RestorePVC = pvc
-		dataExport.Status.LocalSnapshotRestore = k.doLocalRestore(restore, bkpvInfo)
+		dataExport.Status.LocalSnapshotRestore = rv.localSnapshotRestore
 		dataExport.Spec.Type = kdmpapi.DataExportKopia
 		if dataExport.Status.LocalSnapshotRestore {
 			dataExport.Spec.SnapshotStorageClass = getVolumeSnapshotClassFromBackupVolumeInfo(bkpvInfo)
@@ -813,6 +940,103 @@ func (k *kdmp) StartRestore(
 	return volumeInfos, nil
 }
 
+// restoreVolume is a volume being restored along with the PVC it is restored
+// to
+type restoreVolume struct {
+	backupInfo  *storkapi.ApplicationBackupVolumeInfo
+	restoreInfo *storkapi.ApplicationRestoreVolumeInfo
+	pvc         *v1.PersistentVolumeClaim
+	// localSnapshotRestore is set if the PVC is restored from the local
+	// snapshot by the data mover, which creates the PVC itself
+	localSnapshotRestore bool
+}
+
+// createRestorePVCs creates the PVCs for all the volumes being restored in
+// parallel. PVCs that already exist, like when the restore is retried, are
+// left as they are.
+func createRestorePVCs(restoreVolumes []*restoreVolume) error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	errs := make([]string, 0)
+	sem := make(chan struct{}, pvcCreateConcurrency)
+	for _, rv := range restoreVolumes {
+		if rv.localSnapshotRestore {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(pvc *v1.PersistentVolumeClaim) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, err := core.Instance().CreatePersistentVolumeClaim(pvc); err != nil && !k8serror.IsAlreadyExists(err) {
+				lock.Lock()
+				errs = append(errs, fmt.Sprintf("%s/%s: %v", pvc.Namespace, pvc.Name, err))
+				lock.Unlock()
+			}
+		}(rv.pvc)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("error creating pvcs for restore: %v", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// waitForRestorePVCs waits for the restored PVCs to be bound. PVCs with a
+// storage class that waits for the first consumer are only bound once the
+// data mover mounts them so they aren't waited for. If the PVCs aren't bound
+// in time the restore is retried.
+func waitForRestorePVCs(restore *storkapi.ApplicationRestore, restoreVolumes []*restoreVolume) error {
+	waitForFirstConsumer := make(map[string]bool)
+	keys := make([]string, 0, len(restoreVolumes))
+	for _, rv := range restoreVolumes {
+		if rv.localSnapshotRestore {
+			continue
+		}
+		storageClassName := k8shelper.GetPersistentVolumeClaimClass(rv.pvc)
+		if storageClassName != "" {
+			wffc, ok := waitForFirstConsumer[storageClassName]
+			if !ok {
+				sc, err := storage.Instance().GetStorageClass(storageClassName)
+				if err != nil {
+					return fmt.Errorf("error getting storage class %v: %v", storageClassName, err)
+				}
+				wffc = sc.VolumeBindingMode != nil && *sc.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer
+				waitForFirstConsumer[storageClassName] = wffc
+			}
+			if wffc {
+				continue
+			}
+		}
+		keys = append(keys, rv.pvc.Namespace+"/"+rv.pvc.Name)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pool, err := pvcbinding.Instance()
+	if err != nil {
+		// The data mover still waits for each of the PVCs to be bound
+		log.ApplicationRestoreLog(restore).Warnf("Not waiting for pvcs to be bound: %v", err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), restorePVCBindTimeout)
+	defer cancel()
+	start := time.Now()
+	unbound, err := pool.Wait(ctx, keys)
+	if err != nil {
+		return &storkvolume.ErrStorageProviderBusy{
+			Reason: fmt.Sprintf("%v of %v pvcs weren't bound within %v: %v",
+				len(unbound), len(keys), restorePVCBindTimeout, strings.Join(unbound, ", ")),
+		}
+	}
+	log.ApplicationRestoreLog(restore).Infof("%v pvcs were bound in %v", len(keys), time.Since(start))
+	return nil
+}
+
 func (k *kdmp) CancelRestore(restore *storkapi.ApplicationRestore) error {
 	for _, vInfo := range restore.Status.Volumes {
 		val, ok := restore.Spec.NamespaceMapping[vInfo.SourceNamespace]
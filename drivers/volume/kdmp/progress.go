@@ -0,0 +1,66 @@
+package kdmp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	kdmpapi "github.com/portworx/kdmp/pkg/apis/kdmp/v1alpha1"
+	kdmpclient "github.com/portworx/kdmp/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WatchProgress reports the progress of backups and restores whenever the
+// status of one of their DataExports changes
+func (k *kdmp) WatchProgress(callback storkvolume.ProgressCallback) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("error getting cluster config: %v", err)
+	}
+	client, err := kdmpclient.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error getting kdmp client: %v", err)
+	}
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.KdmpV1alpha1().DataExports(metav1.NamespaceAll).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.KdmpV1alpha1().DataExports(metav1.NamespaceAll).Watch(context.TODO(), options)
+		},
+	}
+	informer := cache.NewSharedInformer(listWatch, &kdmpapi.DataExport{}, 0)
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldExport, ok := oldObj.(*kdmpapi.DataExport)
+			if !ok {
+				return
+			}
+			newExport, ok := newObj.(*kdmpapi.DataExport)
+			if !ok || reflect.DeepEqual(oldExport.Status, newExport.Status) {
+				return
+			}
+			reportDataExportProgress(newExport, callback)
+		},
+	})
+	k.progressStopChannel = make(chan struct{})
+	go informer.Run(k.progressStopChannel)
+	return nil
+}
+
+// reportDataExportProgress reports the progress of a DataExport to the
+// backup or restore it was created for. DataExports created before the
+// namespace labels were added aren't reported.
+func reportDataExportProgress(export *kdmpapi.DataExport, callback storkvolume.ProgressCallback) {
+	if namespace := export.Labels[applicationBackupCRNamespaceKey]; namespace != "" {
+		callback(storkvolume.ProgressKindBackup, namespace, export.Labels[applicationBackupCRNameKey])
+	}
+	if namespace := export.Labels[applicationRestoreCRNamespaceKey]; namespace != "" {
+		callback(storkvolume.ProgressKindRestore, namespace, export.Labels[applicationRestoreCRNameKey])
+	}
+}
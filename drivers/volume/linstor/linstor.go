@@ -50,6 +50,10 @@ type linstor struct {
 	storkvolume.BackupRestoreNotSupported
 	storkvolume.CloneNotSupported
 	storkvolume.SnapshotRestoreNotSupported
+	storkvolume.QuiesceNotSupported
+	storkvolume.PlacementNotSupported
+	storkvolume.BatchStatusNotSupported
+	storkvolume.ProgressNotSupported
 }
 
 func (l *linstor) linstorClient() (*lclient.Client, error) {
@@ -0,0 +1,632 @@
+package volume
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	v1 "k8s.io/api/core/v1"
+	k8shelper "k8s.io/component-helpers/storage/volume"
+)
+
+// DriverSelector restricts the PVCs that are dispatched to a driver when
+// multiple drivers are used. Empty lists match all PVCs.
+type DriverSelector struct {
+	// Namespaces are the namespaces of the PVCs handled by the driver
+	Namespaces []string
+	// StorageClasses are the storage classes of the PVCs handled by the
+	// driver
+	StorageClasses []string
+}
+
+// Matches returns true if the PVC is selected
+func (s *DriverSelector) Matches(pvc *v1.PersistentVolumeClaim) bool {
+	if len(s.Namespaces) > 0 && !contains(s.Namespaces, pvc.Namespace) {
+		return false
+	}
+	if len(s.StorageClasses) > 0 && !contains(s.StorageClasses, k8shelper.GetPersistentVolumeClaimClass(pvc)) {
+		return false
+	}
+	return true
+}
+
+// ParseDriverSelector parses a selector in the format
+// <driver>:namespaces=<ns1>,<ns2> or <driver>:storageclasses=<sc1>,<sc2>.
+// Both lists can be given separated by a semicolon.
+func ParseDriverSelector(value string) (string, *DriverSelector, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, fmt.Errorf("invalid driver selector %q, expected <driver>:<namespaces|storageclasses>=<values>", value)
+	}
+	selector := &DriverSelector{}
+	for _, list := range strings.Split(parts[1], ";") {
+		kv := strings.SplitN(list, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return "", nil, fmt.Errorf("invalid driver selector %q, expected <driver>:<namespaces|storageclasses>=<values>", value)
+		}
+		values := strings.Split(kv[1], ",")
+		switch kv[0] {
+		case "namespaces":
+			selector.Namespaces = append(selector.Namespaces, values...)
+		case "storageclasses":
+			selector.StorageClasses = append(selector.StorageClasses, values...)
+		default:
+			return "", nil, fmt.Errorf("invalid driver selector %q, unknown key %v", value, kv[0])
+		}
+	}
+	return parts[0], selector, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// MultiDriver runs multiple volume drivers at once. Calls for a PVC are
+// dispatched to the first driver, in the configured order, that owns the PVC
+// and whose selector matches it. Calls for a group of PVCs, like group
+// snapshots and in-place restores, fail if the PVCs are dispatched to
+// different drivers. Calls that aren't for specific volumes, like cluster
+// pairs and migrations, go to the primary driver, which is the first one.
+type MultiDriver struct {
+	Driver
+	drivers   []Driver
+	selectors map[string]*DriverSelector
+}
+
+// NewMultiDriver returns a driver dispatching to the given drivers. The
+// selectors are keyed by the driver name.
+func NewMultiDriver(drivers []Driver, selectors map[string]*DriverSelector) (*MultiDriver, error) {
+	if len(drivers) == 0 {
+		return nil, fmt.Errorf("at least one driver needs to be provided")
+	}
+	if selectors == nil {
+		selectors = make(map[string]*DriverSelector)
+	}
+	return &MultiDriver{
+		Driver:    drivers[0],
+		drivers:   drivers,
+		selectors: selectors,
+	}, nil
+}
+
+// Drivers returns the drivers in the order in which they are checked
+func (m *MultiDriver) Drivers() []Driver {
+	return m.drivers
+}
+
+// GetPVCDriver returns the driver handling the PVC or nil if none of the
+// drivers own it
+func (m *MultiDriver) GetPVCDriver(coreOps core.Ops, pvc *v1.PersistentVolumeClaim) Driver {
+	for _, d := range m.drivers {
+		if selector, ok := m.selectors[d.String()]; ok && !selector.Matches(pvc) {
+			continue
+		}
+		if d.OwnsPVC(coreOps, pvc) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Init initializes all the drivers
+func (m *MultiDriver) Init(config interface{}) error {
+	for _, d := range m.drivers {
+		if err := d.Init(config); err != nil {
+			return fmt.Errorf("error initializing driver %v: %v", d.String(), err)
+		}
+	}
+	return nil
+}
+
+// Stop stops all the drivers
+func (m *MultiDriver) Stop() error {
+	var lastErr error
+	for _, d := range m.drivers {
+		if err := d.Stop(); err != nil {
+			lastErr = fmt.Errorf("error stopping driver %v: %v", d.String(), err)
+		}
+	}
+	return lastErr
+}
+
+// InspectVolume returns the volume from the first driver that has it
+func (m *MultiDriver) InspectVolume(volumeID string) (*Info, error) {
+	var err error
+	for _, d := range m.drivers {
+		var info *Info
+		if info, err = d.InspectVolume(volumeID); err == nil {
+			return info, nil
+		}
+	}
+	return nil, err
+}
+
+// InspectNode returns the node from the first driver that has it
+func (m *MultiDriver) InspectNode(id string) (*NodeInfo, error) {
+	var err error
+	for _, d := range m.drivers {
+		var info *NodeInfo
+		if info, err = d.InspectNode(id); err == nil {
+			return info, nil
+		}
+	}
+	return nil, err
+}
+
+// GetNodes returns the nodes of all the drivers
+func (m *MultiDriver) GetNodes() ([]*NodeInfo, error) {
+	nodes := make([]*NodeInfo, 0)
+	for _, d := range m.drivers {
+		driverNodes, err := d.GetNodes()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, driverNodes...)
+	}
+	return nodes, nil
+}
+
+// GetPodVolumes returns the volumes of the pod from all the drivers. Each
+// driver only gets the PVCs dispatched to it.
+func (m *MultiDriver) GetPodVolumes(podSpec *v1.PodSpec, namespace string, includePendingWFFC bool) ([]*Info, []*Info, error) {
+	specs := make(map[Driver]*v1.PodSpec)
+	for _, vol := range podSpec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim.ClaimName, namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+		d := m.GetPVCDriver(core.Instance(), pvc)
+		if d == nil {
+			continue
+		}
+		if _, ok := specs[d]; !ok {
+			specs[d] = &v1.PodSpec{}
+		}
+		specs[d].Volumes = append(specs[d].Volumes, vol)
+	}
+
+	var volumes, pendingVolumes []*Info
+	for _, d := range m.drivers {
+		spec, ok := specs[d]
+		if !ok {
+			continue
+		}
+		driverVolumes, driverPendingVolumes, err := d.GetPodVolumes(spec, namespace, includePendingWFFC)
+		if err != nil {
+			return nil, nil, err
+		}
+		volumes = append(volumes, driverVolumes...)
+		pendingVolumes = append(pendingVolumes, driverPendingVolumes...)
+	}
+	return volumes, pendingVolumes, nil
+}
+
+// GetVolumeClaimTemplates returns the templates owned by any of the drivers
+func (m *MultiDriver) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) ([]v1.PersistentVolumeClaim, error) {
+	found := make(map[string]bool)
+	claims := make([]v1.PersistentVolumeClaim, 0)
+	for _, d := range m.drivers {
+		driverClaims, err := d.GetVolumeClaimTemplates(templates)
+		if err != nil {
+			return nil, err
+		}
+		for _, claim := range driverClaims {
+			if !found[claim.Name] {
+				found[claim.Name] = true
+				claims = append(claims, claim)
+			}
+		}
+	}
+	return claims, nil
+}
+
+// OwnsPVC returns true if the PVC is dispatched to one of the drivers
+func (m *MultiDriver) OwnsPVC(coreOps core.Ops, pvc *v1.PersistentVolumeClaim) bool {
+	return m.GetPVCDriver(coreOps, pvc) != nil
+}
+
+// OwnsPVCForBackup returns true if the PVC is owned by the driver it is
+// dispatched to
+func (m *MultiDriver) OwnsPVCForBackup(coreOps core.Ops, pvc *v1.PersistentVolumeClaim, cmBackupType string, crBackupType string) bool {
+	for _, d := range m.drivers {
+		if selector, ok := m.selectors[d.String()]; ok && !selector.Matches(pvc) {
+			continue
+		}
+		if d.OwnsPVCForBackup(coreOps, pvc, cmBackupType, crBackupType) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnsPV returns true if the PV is owned by any of the drivers
+func (m *MultiDriver) OwnsPV(pv *v1.PersistentVolume) bool {
+	for _, d := range m.drivers {
+		if d.OwnsPV(pv) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSnapshotType returns the type from the first driver that owns the
+// snapshot
+func (m *MultiDriver) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	var err error
+	for _, d := range m.drivers {
+		var snapType string
+		if snapType, err = d.GetSnapshotType(snap); err == nil {
+			return snapType, nil
+		}
+	}
+	return "", err
+}
+
+// GetPlacementAnnotations calls the driver the PVC is dispatched to
+func (m *MultiDriver) GetPlacementAnnotations(pvc *v1.PersistentVolumeClaim, nodes []*NodeInfo) (map[string]string, error) {
+	d := m.GetPVCDriver(core.Instance(), pvc)
+	if d == nil {
+		return nil, nil
+	}
+	return d.GetPlacementAnnotations(pvc, nodes)
+}
+
+// getPVCsDriver returns the driver handling all the PVCs. A single call can't
+// be split between drivers, so an error is returned if the PVCs are
+// dispatched to different drivers. Calls without PVCs go to the primary
+// driver.
+func (m *MultiDriver) getPVCsDriver(pvcs []*v1.PersistentVolumeClaim) (Driver, error) {
+	var driver Driver
+	for _, pvc := range pvcs {
+		d := m.GetPVCDriver(core.Instance(), pvc)
+		if d == nil {
+			return nil, fmt.Errorf("none of the drivers own pvc %v/%v", pvc.Namespace, pvc.Name)
+		}
+		if driver != nil && d != driver {
+			return nil, fmt.Errorf("pvc %v/%v is handled by driver %v and can't be used with pvcs handled by driver %v in the same operation",
+				pvc.Namespace, pvc.Name, d.String(), driver.String())
+		}
+		driver = d
+	}
+	if driver == nil {
+		return m.Driver, nil
+	}
+	return driver, nil
+}
+
+// getPVDriver returns the driver handling the PVC the PV is bound to, or the
+// first driver that owns the PV if it isn't bound
+func (m *MultiDriver) getPVDriver(pv *v1.PersistentVolume) (Driver, error) {
+	if pv.Spec.ClaimRef != nil {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(pv.Spec.ClaimRef.Name, pv.Spec.ClaimRef.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		return m.getPVCsDriver([]*v1.PersistentVolumeClaim{pvc})
+	}
+	for _, d := range m.drivers {
+		if d.OwnsPV(pv) {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the drivers own pv %v", pv.Name)
+}
+
+// getRestoreDriver returns the driver handling the PVCs being restored
+func (m *MultiDriver) getRestoreDriver(snapRestore *storkapi.VolumeSnapshotRestore) (Driver, error) {
+	pvcs := make([]*v1.PersistentVolumeClaim, 0, len(snapRestore.Status.Volumes))
+	for _, vol := range snapRestore.Status.Volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	return m.getPVCsDriver(pvcs)
+}
+
+// getGroupSnapshotDriver returns the driver handling the PVCs selected by the
+// group snapshot
+func (m *MultiDriver) getGroupSnapshotDriver(snap *storkapi.GroupVolumeSnapshot) (Driver, error) {
+	pvcList, err := core.Instance().GetPersistentVolumeClaims(snap.Namespace, snap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	pvcs := make([]*v1.PersistentVolumeClaim, 0, len(pvcList.Items))
+	for i := range pvcList.Items {
+		pvcs = append(pvcs, &pvcList.Items[i])
+	}
+	return m.getPVCsDriver(pvcs)
+}
+
+// StartVolumeSnapshotRestore calls the driver handling the PVCs being
+// restored
+func (m *MultiDriver) StartVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	d, err := m.getRestoreDriver(snapRestore)
+	if err != nil {
+		return err
+	}
+	return d.StartVolumeSnapshotRestore(snapRestore)
+}
+
+// CompleteVolumeSnapshotRestore calls the driver handling the PVCs being
+// restored
+func (m *MultiDriver) CompleteVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	d, err := m.getRestoreDriver(snapRestore)
+	if err != nil {
+		return err
+	}
+	return d.CompleteVolumeSnapshotRestore(snapRestore)
+}
+
+// GetVolumeSnapshotRestoreStatus calls the driver handling the PVCs being
+// restored
+func (m *MultiDriver) GetVolumeSnapshotRestoreStatus(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	d, err := m.getRestoreDriver(snapRestore)
+	if err != nil {
+		return err
+	}
+	return d.GetVolumeSnapshotRestoreStatus(snapRestore)
+}
+
+// CleanupSnapshotRestoreObjects calls the driver handling the PVCs being
+// restored
+func (m *MultiDriver) CleanupSnapshotRestoreObjects(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	d, err := m.getRestoreDriver(snapRestore)
+	if err != nil {
+		return err
+	}
+	return d.CleanupSnapshotRestoreObjects(snapRestore)
+}
+
+// CreateGroupSnapshot calls the driver handling the PVCs of the group
+func (m *MultiDriver) CreateGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	d, err := m.getGroupSnapshotDriver(snap)
+	if err != nil {
+		return nil, err
+	}
+	return d.CreateGroupSnapshot(snap)
+}
+
+// GetGroupSnapshotStatus calls the driver handling the PVCs of the group
+func (m *MultiDriver) GetGroupSnapshotStatus(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	d, err := m.getGroupSnapshotDriver(snap)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetGroupSnapshotStatus(snap)
+}
+
+// DeleteGroupSnapshot calls the driver handling the PVCs of the group
+func (m *MultiDriver) DeleteGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) error {
+	d, err := m.getGroupSnapshotDriver(snap)
+	if err != nil {
+		return err
+	}
+	return d.DeleteGroupSnapshot(snap)
+}
+
+// GetSnapshotStatuses calls the driver handling the PVCs of the group
+func (m *MultiDriver) GetSnapshotStatuses(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	d, err := m.getGroupSnapshotDriver(snap)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetSnapshotStatuses(snap)
+}
+
+// GetMigrationStatuses calls the driver handling the PVCs of the volumes
+func (m *MultiDriver) GetMigrationStatuses(
+	migration *storkapi.Migration,
+	volumes []*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	pvcs := make([]*v1.PersistentVolumeClaim, 0, len(volumes))
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim, vol.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		pvcs = append(pvcs, pvc)
+	}
+	d, err := m.getPVCsDriver(pvcs)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetMigrationStatuses(migration, volumes)
+}
+
+// QuiesceVolume calls the driver handling the PVC bound to the volume
+func (m *MultiDriver) QuiesceVolume(volumeID string, timeout time.Duration, quiesceID string) error {
+	d, err := m.getVolumeDriver(volumeID)
+	if err != nil {
+		return err
+	}
+	return d.QuiesceVolume(volumeID, timeout, quiesceID)
+}
+
+// UnquiesceVolume calls the driver handling the PVC bound to the volume
+func (m *MultiDriver) UnquiesceVolume(volumeID string) error {
+	d, err := m.getVolumeDriver(volumeID)
+	if err != nil {
+		return err
+	}
+	return d.UnquiesceVolume(volumeID)
+}
+
+// getVolumeDriver returns the driver handling the PV with the given name
+func (m *MultiDriver) getVolumeDriver(volumeID string) (Driver, error) {
+	pv, err := core.Instance().GetPersistentVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return m.getPVDriver(pv)
+}
+
+// SupportsQuiesce returns true if the driver handling the PVC can quiesce IO
+// on its volume. If no PVC is given, it returns true if any of the drivers
+// can.
+func (m *MultiDriver) SupportsQuiesce(pvc *v1.PersistentVolumeClaim) bool {
+	if pvc != nil {
+		d := m.GetPVCDriver(core.Instance(), pvc)
+		return d != nil && IsQuiesceSupported(d, pvc)
+	}
+	for _, d := range m.drivers {
+		if IsQuiesceSupported(d, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetSnapshotPlugin returns a plugin dispatching to the snapshot plugins of
+// the drivers. The plugin of the driver is returned as is if only one of the
+// drivers has a plugin.
+func (m *MultiDriver) GetSnapshotPlugin() snapshotVolume.Plugin {
+	plugins := make(map[Driver]snapshotVolume.Plugin)
+	var plugin snapshotVolume.Plugin
+	for _, d := range m.drivers {
+		if p := d.GetSnapshotPlugin(); p != nil {
+			plugins[d] = p
+			plugin = p
+		}
+	}
+	if len(plugins) <= 1 {
+		return plugin
+	}
+	return &multiSnapshotPlugin{multi: m, plugins: plugins}
+}
+
+// multiSnapshotPlugin dispatches snapshot calls to the plugin of the driver
+// handling the volume. Calls that only have the snapshot are tried with each
+// plugin until one succeeds.
+type multiSnapshotPlugin struct {
+	multi   *MultiDriver
+	plugins map[Driver]snapshotVolume.Plugin
+}
+
+// ordered returns the plugins in the order of the drivers
+func (p *multiSnapshotPlugin) ordered() []snapshotVolume.Plugin {
+	plugins := make([]snapshotVolume.Plugin, 0, len(p.plugins))
+	for _, d := range p.multi.drivers {
+		if plugin, ok := p.plugins[d]; ok {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+func (p *multiSnapshotPlugin) driverPlugin(d Driver) (snapshotVolume.Plugin, error) {
+	plugin, ok := p.plugins[d]
+	if !ok {
+		return nil, fmt.Errorf("driver %v doesn't support snapshots", d.String())
+	}
+	return plugin, nil
+}
+
+func (p *multiSnapshotPlugin) pvPlugin(pv *v1.PersistentVolume) (snapshotVolume.Plugin, error) {
+	d, err := p.multi.getPVDriver(pv)
+	if err != nil {
+		return nil, err
+	}
+	return p.driverPlugin(d)
+}
+
+func (p *multiSnapshotPlugin) Init(config interface{}) error {
+	for _, plugin := range p.ordered() {
+		if err := plugin.Init(config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *multiSnapshotPlugin) SnapshotCreate(
+	snap *snapv1.VolumeSnapshot,
+	pv *v1.PersistentVolume,
+	tags *map[string]string,
+) (*snapv1.VolumeSnapshotDataSource, *[]snapv1.VolumeSnapshotCondition, error) {
+	plugin, err := p.pvPlugin(pv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plugin.SnapshotCreate(snap, pv, tags)
+}
+
+func (p *multiSnapshotPlugin) SnapshotDelete(source *snapv1.VolumeSnapshotDataSource, pv *v1.PersistentVolume) error {
+	if pv != nil {
+		plugin, err := p.pvPlugin(pv)
+		if err != nil {
+			return err
+		}
+		return plugin.SnapshotDelete(source, pv)
+	}
+	// The PV is gone, so try each plugin
+	var err error
+	for _, plugin := range p.ordered() {
+		if err = plugin.SnapshotDelete(source, pv); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (p *multiSnapshotPlugin) SnapshotRestore(
+	snapshotData *snapv1.VolumeSnapshotData,
+	pvc *v1.PersistentVolumeClaim,
+	pvName string,
+	parameters map[string]string,
+) (*v1.PersistentVolumeSource, map[string]string, error) {
+	d, err := p.multi.getPVCsDriver([]*v1.PersistentVolumeClaim{pvc})
+	if err != nil {
+		return nil, nil, err
+	}
+	plugin, err := p.driverPlugin(d)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plugin.SnapshotRestore(snapshotData, pvc, pvName, parameters)
+}
+
+func (p *multiSnapshotPlugin) DescribeSnapshot(snapshotData *snapv1.VolumeSnapshotData) (*[]snapv1.VolumeSnapshotCondition, bool, error) {
+	var err error
+	for _, plugin := range p.ordered() {
+		var conditions *[]snapv1.VolumeSnapshotCondition
+		var isCompleted bool
+		if conditions, isCompleted, err = plugin.DescribeSnapshot(snapshotData); err == nil {
+			return conditions, isCompleted, nil
+		}
+	}
+	return nil, false, err
+}
+
+func (p *multiSnapshotPlugin) FindSnapshot(tags *map[string]string) (*snapv1.VolumeSnapshotDataSource, *[]snapv1.VolumeSnapshotCondition, error) {
+	var err error
+	for _, plugin := range p.ordered() {
+		var source *snapv1.VolumeSnapshotDataSource
+		var conditions *[]snapv1.VolumeSnapshotCondition
+		if source, conditions, err = plugin.FindSnapshot(tags); err == nil {
+			return source, conditions, nil
+		}
+	}
+	return nil, nil, err
+}
+
+func (p *multiSnapshotPlugin) VolumeDelete(pv *v1.PersistentVolume) error {
+	plugin, err := p.pvPlugin(pv)
+	if err != nil {
+		return err
+	}
+	return plugin.VolumeDelete(pv)
+}
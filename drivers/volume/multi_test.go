@@ -0,0 +1,312 @@
+//go:build unittest
+// +build unittest
+
+package volume
+
+import (
+	"testing"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotVolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// provisionerDriver owns the PVCs provisioned by its provisioner
+type provisionerDriver struct {
+	Driver
+	name        string
+	provisioner string
+}
+
+func (p *provisionerDriver) String() string {
+	return p.name
+}
+
+func (p *provisionerDriver) OwnsPVC(_ core.Ops, pvc *v1.PersistentVolumeClaim) bool {
+	return pvc.Annotations["volume.beta.kubernetes.io/storage-provisioner"] == p.provisioner
+}
+
+func TestMultiDriverDispatch(t *testing.T) {
+	pxd := &provisionerDriver{name: "pxd", provisioner: "kubernetes.io/portworx-volume"}
+	csi := &provisionerDriver{name: "csi", provisioner: "ebs.csi.aws.com"}
+	csi2 := &provisionerDriver{name: "csi2", provisioner: "ebs.csi.aws.com"}
+	_, selector, err := ParseDriverSelector("csi:namespaces=ns1;storageclasses=sc1,sc2")
+	require.NoError(t, err, "Error parsing selector")
+	m, err := NewMultiDriver([]Driver{pxd, csi, csi2}, map[string]*DriverSelector{"csi": selector})
+	require.NoError(t, err, "Error creating driver")
+	require.Equal(t, "pxd", m.String(), "First driver should be the primary driver")
+
+	pvc := func(namespace, storageClass, provisioner string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Annotations: map[string]string{"volume.beta.kubernetes.io/storage-provisioner": provisioner},
+			},
+			Spec: v1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+		}
+	}
+	require.Equal(t, pxd, m.GetPVCDriver(nil, pvc("ns1", "sc1", pxd.provisioner)))
+	require.Equal(t, csi, m.GetPVCDriver(nil, pvc("ns1", "sc2", csi.provisioner)))
+	require.Equal(t, csi2, m.GetPVCDriver(nil, pvc("ns2", "sc2", csi.provisioner)), "Namespace isn't selected")
+	require.Equal(t, csi2, m.GetPVCDriver(nil, pvc("ns1", "sc3", csi.provisioner)), "Storage class isn't selected")
+	require.Nil(t, m.GetPVCDriver(nil, pvc("ns1", "sc1", "other")))
+	require.False(t, m.OwnsPVC(nil, pvc("ns1", "sc1", "other")))
+}
+
+func TestParseDriverSelector(t *testing.T) {
+	name, selector, err := ParseDriverSelector("pxd:namespaces=ns1,ns2")
+	require.NoError(t, err, "Error parsing selector")
+	require.Equal(t, "pxd", name)
+	require.Equal(t, []string{"ns1", "ns2"}, selector.Namespaces)
+	require.Empty(t, selector.StorageClasses)
+
+	for _, value := range []string{"pxd", ":namespaces=ns1", "pxd:namespaces", "pxd:nodes=node1"} {
+		_, _, err = ParseDriverSelector(value)
+		require.Error(t, err, "Expected error parsing %v", value)
+	}
+}
+
+// callsDriver records the calls dispatched to it
+type callsDriver struct {
+	provisionerDriver
+	plugin snapshotVolume.Plugin
+	calls  []string
+}
+
+func (c *callsDriver) StartVolumeSnapshotRestore(*storkapi.VolumeSnapshotRestore) error {
+	c.calls = append(c.calls, "StartVolumeSnapshotRestore")
+	return nil
+}
+
+func (c *callsDriver) CompleteVolumeSnapshotRestore(*storkapi.VolumeSnapshotRestore) error {
+	c.calls = append(c.calls, "CompleteVolumeSnapshotRestore")
+	return nil
+}
+
+func (c *callsDriver) CreateGroupSnapshot(*storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	c.calls = append(c.calls, "CreateGroupSnapshot")
+	return &GroupSnapshotCreateResponse{}, nil
+}
+
+func (c *callsDriver) GetSnapshotStatuses(*storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	c.calls = append(c.calls, "GetSnapshotStatuses")
+	return &GroupSnapshotCreateResponse{}, nil
+}
+
+func (c *callsDriver) GetMigrationStatuses(
+	_ *storkapi.Migration,
+	volumes []*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	c.calls = append(c.calls, "GetMigrationStatuses")
+	return volumes, nil
+}
+
+func (c *callsDriver) GetSnapshotPlugin() snapshotVolume.Plugin {
+	return c.plugin
+}
+
+// quiesceDriver can quiesce volumes
+type quiesceDriver struct {
+	*callsDriver
+}
+
+func (q *quiesceDriver) QuiesceVolume(volumeID string, _ time.Duration, _ string) error {
+	q.calls = append(q.calls, "QuiesceVolume "+volumeID)
+	return nil
+}
+
+func (q *quiesceDriver) UnquiesceVolume(volumeID string) error {
+	q.calls = append(q.calls, "UnquiesceVolume "+volumeID)
+	return nil
+}
+
+// noQuiesceDriver can't quiesce volumes
+type noQuiesceDriver struct {
+	*callsDriver
+	QuiesceNotSupported
+}
+
+// restorePlugin records the snapshot restores done through it
+type restorePlugin struct {
+	snapshotVolume.Plugin
+	restored int
+}
+
+func (r *restorePlugin) SnapshotRestore(*snapv1.VolumeSnapshotData, *v1.PersistentVolumeClaim, string, map[string]string) (*v1.PersistentVolumeSource, map[string]string, error) {
+	r.restored++
+	return &v1.PersistentVolumeSource{}, nil, nil
+}
+
+const (
+	pxdProvisioner = "kubernetes.io/portworx-volume"
+	csiProvisioner = "ebs.csi.aws.com"
+)
+
+// setupMultiDriverCallsTest returns a driver dispatching to pxd, which can
+// quiesce volumes, and csi, which can't. pvc1 and pvc2 are handled by pxd
+// and pvc3 by csi. All of them are in the mixed group.
+func setupMultiDriverCallsTest(t *testing.T) (*MultiDriver, *callsDriver, *callsDriver) {
+	var objects []runtime.Object
+	for _, claim := range []struct {
+		name        string
+		provisioner string
+		group       string
+	}{
+		{"pvc1", pxdProvisioner, "pxd"},
+		{"pvc2", pxdProvisioner, "pxd"},
+		{"pvc3", csiProvisioner, "csi"},
+	} {
+		objects = append(objects,
+			&v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        claim.name,
+					Namespace:   "ns",
+					Labels:      map[string]string{"group": claim.group, "mixed": "true"},
+					Annotations: map[string]string{"volume.beta.kubernetes.io/storage-provisioner": claim.provisioner},
+				},
+				Spec: v1.PersistentVolumeClaimSpec{VolumeName: "pv-" + claim.name},
+			},
+			&v1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pv-" + claim.name},
+				Spec: v1.PersistentVolumeSpec{
+					ClaimRef: &v1.ObjectReference{Name: claim.name, Namespace: "ns"},
+				},
+			},
+		)
+	}
+	core.SetInstance(core.New(fake.NewSimpleClientset(objects...)))
+
+	pxd := &callsDriver{provisionerDriver: provisionerDriver{name: "pxd", provisioner: pxdProvisioner}}
+	csi := &callsDriver{provisionerDriver: provisionerDriver{name: "csi", provisioner: csiProvisioner}}
+	m, err := NewMultiDriver([]Driver{&quiesceDriver{pxd}, &noQuiesceDriver{callsDriver: csi}}, nil)
+	require.NoError(t, err, "Error creating driver")
+	return m, pxd, csi
+}
+
+func TestMultiDriverSnapshotRestore(t *testing.T) {
+	m, pxd, csi := setupMultiDriverCallsTest(t)
+	snapRestore := func(pvcs ...string) *storkapi.VolumeSnapshotRestore {
+		snapRestore := &storkapi.VolumeSnapshotRestore{}
+		for _, pvc := range pvcs {
+			snapRestore.Status.Volumes = append(snapRestore.Status.Volumes,
+				&storkapi.RestoreVolumeInfo{PVC: pvc, Namespace: "ns"})
+		}
+		return snapRestore
+	}
+
+	require.NoError(t, m.StartVolumeSnapshotRestore(snapRestore("pvc3")))
+	require.NoError(t, m.CompleteVolumeSnapshotRestore(snapRestore("pvc3")))
+	require.Equal(t, []string{"StartVolumeSnapshotRestore", "CompleteVolumeSnapshotRestore"}, csi.calls)
+	require.Empty(t, pxd.calls, "Restore of csi pvc dispatched to primary driver")
+
+	require.NoError(t, m.StartVolumeSnapshotRestore(snapRestore("pvc1", "pvc2")))
+	require.Equal(t, []string{"StartVolumeSnapshotRestore"}, pxd.calls)
+
+	err := m.CompleteVolumeSnapshotRestore(snapRestore("pvc1", "pvc3"))
+	require.Error(t, err, "Expected error restoring pvcs of different drivers")
+	require.Contains(t, err.Error(), "can't be used with pvcs handled by driver")
+	require.Len(t, pxd.calls, 1)
+	require.Len(t, csi.calls, 2)
+}
+
+func TestMultiDriverGroupSnapshot(t *testing.T) {
+	m, pxd, csi := setupMultiDriverCallsTest(t)
+	groupSnap := func(labels map[string]string) *storkapi.GroupVolumeSnapshot {
+		return &storkapi.GroupVolumeSnapshot{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns"},
+			Spec: storkapi.GroupVolumeSnapshotSpec{
+				PVCSelector: storkapi.PVCSelectorSpec{
+					LabelSelector: metav1.LabelSelector{MatchLabels: labels},
+				},
+			},
+		}
+	}
+
+	_, err := m.CreateGroupSnapshot(groupSnap(map[string]string{"group": "csi"}))
+	require.NoError(t, err)
+	_, err = m.GetSnapshotStatuses(groupSnap(map[string]string{"group": "csi"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"CreateGroupSnapshot", "GetSnapshotStatuses"}, csi.calls)
+
+	_, err = m.CreateGroupSnapshot(groupSnap(map[string]string{"group": "pxd"}))
+	require.NoError(t, err)
+	require.Equal(t, []string{"CreateGroupSnapshot"}, pxd.calls)
+
+	_, err = m.CreateGroupSnapshot(groupSnap(map[string]string{"mixed": "true"}))
+	require.Error(t, err, "Expected error for group of pvcs of different drivers")
+	_, err = m.GetSnapshotStatuses(groupSnap(map[string]string{"mixed": "true"}))
+	require.Error(t, err, "Expected error for group of pvcs of different drivers")
+	require.Len(t, pxd.calls, 1)
+	require.Len(t, csi.calls, 2)
+}
+
+func TestMultiDriverMigrationStatuses(t *testing.T) {
+	m, pxd, csi := setupMultiDriverCallsTest(t)
+	volumes := func(pvcs ...string) []*storkapi.MigrationVolumeInfo {
+		infos := make([]*storkapi.MigrationVolumeInfo, 0)
+		for _, pvc := range pvcs {
+			infos = append(infos, &storkapi.MigrationVolumeInfo{PersistentVolumeClaim: pvc, Namespace: "ns"})
+		}
+		return infos
+	}
+
+	_, err := m.GetMigrationStatuses(&storkapi.Migration{}, volumes("pvc3"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"GetMigrationStatuses"}, csi.calls)
+	require.Empty(t, pxd.calls)
+
+	_, err = m.GetMigrationStatuses(&storkapi.Migration{}, volumes("pvc1", "pvc3"))
+	require.Error(t, err, "Expected error for volumes of different drivers")
+	require.Empty(t, pxd.calls)
+}
+
+func TestMultiDriverQuiesce(t *testing.T) {
+	m, pxd, _ := setupMultiDriverCallsTest(t)
+
+	require.NoError(t, m.QuiesceVolume("pv-pvc1", time.Minute, "id"))
+	require.NoError(t, m.UnquiesceVolume("pv-pvc1"))
+	require.Equal(t, []string{"QuiesceVolume pv-pvc1", "UnquiesceVolume pv-pvc1"}, pxd.calls)
+	require.Error(t, m.QuiesceVolume("pv-pvc3", time.Minute, "id"), "Expected error quiescing volume of csi")
+	require.Error(t, m.QuiesceVolume("pv-missing", time.Minute, "id"), "Expected error for missing volume")
+
+	pvc := func(name string) *v1.PersistentVolumeClaim {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(name, "ns")
+		require.NoError(t, err)
+		return pvc
+	}
+	require.True(t, IsQuiesceSupported(m, nil), "Expected quiesce support if any driver supports it")
+	require.True(t, IsQuiesceSupported(m, pvc("pvc1")))
+	require.False(t, IsQuiesceSupported(m, pvc("pvc3")), "Driver of pvc can't quiesce")
+
+	// Drivers wrapped in a ResilientDriver are checked too
+	r := NewResilientDriver("multi", m, testResilienceConfig())
+	require.True(t, IsQuiesceSupported(r, pvc("pvc1")))
+	require.False(t, IsQuiesceSupported(r, pvc("pvc3")))
+	require.False(t, IsQuiesceSupported(NewResilientDriver("csi", &noQuiesceDriver{}, testResilienceConfig()), nil))
+}
+
+func TestMultiDriverSnapshotPlugin(t *testing.T) {
+	m, pxd, csi := setupMultiDriverCallsTest(t)
+	require.Nil(t, m.GetSnapshotPlugin(), "Expected no plugin if no driver has one")
+
+	pxdPlugin := &restorePlugin{}
+	pxd.plugin = pxdPlugin
+	require.Equal(t, pxdPlugin, m.GetSnapshotPlugin(), "Expected plugin of only driver with one")
+
+	csiPlugin := &restorePlugin{}
+	csi.plugin = csiPlugin
+	plugin := m.GetSnapshotPlugin()
+	pvc, err := core.Instance().GetPersistentVolumeClaim("pvc3", "ns")
+	require.NoError(t, err)
+	_, _, err = plugin.SnapshotRestore(&snapv1.VolumeSnapshotData{}, pvc, "pv", nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, csiPlugin.restored)
+	require.Equal(t, 0, pxdPlugin.restored)
+}
@@ -1,6 +1,7 @@
 package portworx
 
 import (
+	"bytes"
 	"context"
 	"encoding/csv"
 	"fmt"
@@ -40,6 +41,8 @@ import (
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/snapshot"
 	snapshotcontrollers "github.com/libopenstorage/stork/pkg/snapshot/controllers"
+	storksnapshotter "github.com/libopenstorage/stork/pkg/snapshotter"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
 	"github.com/portworx/sched-ops/k8s/storage"
@@ -224,6 +227,7 @@ var restoreStateCallBackoff = wait.Backoff{
 }
 
 type portworx struct {
+	storkvolume.ProgressNotSupported
 	store           cache.Store
 	stopChannel     chan struct{}
 	sdkConn         *portworxGrpcConnection
@@ -1119,6 +1123,15 @@ func (p *portworx) SnapshotCreate(
 		return nil, getErrorSnapshotConditions(err), err
 	}
 
+	if snap.Metadata.Annotations[snapshotcontrollers.QuiesceVolumesAnnotation] == "true" {
+		unquiesce, err := p.quiesceVolumesForSnapshot(snap, pvcsForSnapshot)
+		if err != nil {
+			log.SnapshotLog(snap).Errorf(err.Error())
+			return nil, getErrorSnapshotConditions(err), err
+		}
+		defer unquiesce()
+	}
+
 	snapType, err := getSnapshotType(snap.Metadata.Annotations)
 	if err != nil {
 		return nil, getErrorSnapshotConditions(err), err
@@ -1149,8 +1162,11 @@ func (p *portworx) SnapshotCreate(
 			CredentialUUID: snapshotCredID,
 			Name:           taskID,
 		}
-		request.Labels = make(map[string]string)
-		request.Labels[cloudBackupOwnerLabel] = "stork"
+		// Propagate the labels of the snapshot so that they can be used
+		// to manage the cloudsnaps in the backend
+		request.Labels = storksnapshotter.MergeMetadata(snap.Metadata.Labels, map[string]string{
+			cloudBackupOwnerLabel: "stork",
+		})
 		p.addCloudsnapInfo(request, snap)
 
 		if value, present := snap.Metadata.Annotations[incrementalCountAnnotation]; present {
@@ -1208,10 +1224,10 @@ func (p *portworx) SnapshotCreate(
 		snapName := p.getSnapshotName(tags)
 		locator := &api.VolumeLocator{
 			Name: snapName,
-			VolumeLabels: map[string]string{
+			VolumeLabels: storksnapshotter.MergeMetadata(snap.Metadata.Labels, map[string]string{
 				storkSnapNameLabel: (*tags)[snapshotter.CloudSnapshotCreatedForVolumeSnapshotNameTag],
 				namespaceLabel:     (*tags)[snapshotter.CloudSnapshotCreatedForVolumeSnapshotNamespaceTag],
-			},
+			}),
 		}
 		snapshotID, err = volDriver.Snapshot(volumeID, true, locator, true)
 		if err != nil {
@@ -1398,6 +1414,23 @@ func (p *portworx) StartVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapsh
 		return fmt.Errorf("no restore volumes information")
 	}
 
+	// Check that the keys of encrypted volumes are available before
+	// starting, so that the restore doesn't fail once the volumes are in use
+	for _, vol := range snapRestore.Status.Volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			return err
+		}
+		secretNamespace, secretName, err := getEncryptionSecret(pvc, pvc.Namespace, snapRestore.Spec.EncryptionSecretMapping)
+		if err != nil {
+			vol.Reason = err.Error()
+			return err
+		}
+		if secretName != "" {
+			vol.EncryptionSecret = secretNamespace + "/" + secretName
+		}
+	}
+
 	// anyone snapshot info should suffice to start volumesnapshotrestore
 	_, snapType, _, err := getSnapshotDetails(snapRestore.Status.Volumes[0].Snapshot)
 	if err != nil {
@@ -1425,8 +1458,7 @@ func (p *portworx) StartVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapsh
 
 		var poolID string
 		isRes := true
-		labels := make(map[string]string)
-		locator := &api.VolumeLocator{VolumeLabels: labels}
+		var locator *api.VolumeLocator
 		ok, msg, err = p.ensureNodesHaveMinVersion("2.6.0")
 		if err != nil || !ok {
 			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("Fast restore is supported onword PX version 2.6.0: %v", msg)
@@ -1459,7 +1491,12 @@ func (p *portworx) StartVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapsh
 				}
 			}
 			if isRes {
-				locator.VolumeLabels[api.SpecMatchSrcVolProvision] = "true"
+				locator = &api.VolumeLocator{VolumeLabels: map[string]string{
+					api.SpecMatchSrcVolProvision: "true",
+				}}
+				for k, v := range encryptionSecretLabels(vol.EncryptionSecret) {
+					locator.VolumeLabels[k] = v
+				}
 			} else {
 				replNodes := vols[0].GetReplicaSets()[0]
 				poolID = replNodes.GetPoolUuids()[0]
@@ -1649,6 +1686,110 @@ func (p *portworx) CompleteVolumeSnapshotRestore(snapRestore *storkapi.VolumeSna
 	return p.pxSnapshotRestore(snapRestore)
 }
 
+// getEncryptionSecret returns the namespace and name of the secret holding
+// the encryption key for the volume of the PVC after applying the mapping.
+// namespace is the namespace in which the volume will be used. Empty strings
+// are returned if the volume isn't encrypted with a per-volume secret.
+// Volumes aren't re-encrypted, so a Kubernetes secret can only be replaced by
+// one holding the same key. Keys kept by other secret providers, e.g. Vault,
+// are passed to the driver as they are and can't be mapped.
+func getEncryptionSecret(
+	pvc *v1.PersistentVolumeClaim,
+	namespace string,
+	mapping map[string]string,
+) (string, string, error) {
+	name := pvc.Annotations[pxSecretNameAnnotation]
+	if name == "" {
+		return "", "", nil
+	}
+	sourceNamespace := pvc.Annotations[pxSecretNamespaceAnnotation]
+	if sourceNamespace == "" || sourceNamespace == templatizedNamespace {
+		sourceNamespace = pvc.Namespace
+	}
+	secretNamespace := sourceNamespace
+	if secretNamespace == pvc.Namespace {
+		secretNamespace = namespace
+	}
+	source, err := core.Instance().GetSecret(name, sourceNamespace)
+	if err != nil && !k8s_errors.IsNotFound(err) {
+		return "", "", fmt.Errorf("error getting encryption secret %v/%v for PVC %v/%v: %v", sourceNamespace, name, pvc.Namespace, pvc.Name, err)
+	}
+	external := err != nil
+
+	mapped, ok := mapping[sourceNamespace+"/"+name]
+	if !ok {
+		mapped, ok = mapping[name]
+	}
+	if ok {
+		if external {
+			return "", "", fmt.Errorf("encryption secret %v of PVC %v/%v isn't a Kubernetes secret and can't be mapped", name, pvc.Namespace, pvc.Name)
+		}
+		if parts := strings.SplitN(mapped, "/", 2); len(parts) == 2 {
+			secretNamespace, name = parts[0], parts[1]
+		} else {
+			secretNamespace, name = namespace, mapped
+		}
+	} else if external || secretNamespace == sourceNamespace {
+		return secretNamespace, name, nil
+	}
+
+	secret, err := core.Instance().GetSecret(name, secretNamespace)
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return "", "", fmt.Errorf("encryption secret %v/%v for PVC %v/%v not found, "+
+				"create it or map it to an existing secret with encryptionSecretMapping", secretNamespace, name, pvc.Namespace, pvc.Name)
+		}
+		return "", "", fmt.Errorf("error getting encryption secret %v/%v for PVC %v/%v: %v", secretNamespace, name, pvc.Namespace, pvc.Name, err)
+	}
+	for key, value := range source.Data {
+		if !bytes.Equal(secret.Data[key], value) {
+			return "", "", fmt.Errorf("encryption secret %v/%v for PVC %v/%v doesn't hold the same key as %v/%v, "+
+				"volumes aren't re-encrypted", secretNamespace, name, pvc.Namespace, pvc.Name, source.Namespace, source.Name)
+		}
+	}
+	return secretNamespace, name, nil
+}
+
+// encryptionSecretLabels returns the volume labels referencing the
+// encryption secret recorded as <namespace>/<name>
+func encryptionSecretLabels(secret string) map[string]string {
+	parts := strings.SplitN(secret, "/", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return map[string]string{
+		pxSecretNamespaceAnnotation: parts[0],
+		pxSecretNameAnnotation:      parts[1],
+	}
+}
+
+// updateEncryptionSecret points the volume and its PVC to the secret
+// recorded for the restore if it was changed by the secret mapping. The
+// volume isn't re-encrypted, getEncryptionSecret only allows secrets that
+// hold the same key.
+func (p *portworx) updateEncryptionSecret(volDriver volume.VolumeDriver, vol *storkapi.RestoreVolumeInfo) error {
+	labels := encryptionSecretLabels(vol.EncryptionSecret)
+	if labels == nil {
+		return nil
+	}
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+	if err != nil {
+		return err
+	}
+	if pvc.Annotations[pxSecretNameAnnotation] == labels[pxSecretNameAnnotation] &&
+		pvc.Annotations[pxSecretNamespaceAnnotation] == labels[pxSecretNamespaceAnnotation] {
+		return nil
+	}
+	if err := volDriver.Set(vol.Volume, &api.VolumeLocator{VolumeLabels: labels}, nil); err != nil {
+		return fmt.Errorf("error updating encryption secret of volume %v: %v", vol.Volume, err)
+	}
+	for k, v := range labels {
+		pvc.Annotations[k] = v
+	}
+	_, err = core.Instance().UpdatePersistentVolumeClaim(pvc)
+	return err
+}
+
 // getSnapshotDetails returns PX snapID, snapshotType, credID(if any)
 // error if unable to retrive snap data
 func getSnapshotDetails(snapDataName string) (string, crdv1.PortworxSnapshotType, string, error) {
@@ -1658,7 +1799,7 @@ func getSnapshotDetails(snapDataName string) (string, crdv1.PortworxSnapshotType
 			snapDataName, err)
 	}
 	// Let's verify if source snapshotdata is complete
-	err = k8sextops.Instance().ValidateSnapshotData(snapshotData.Metadata.Name, false, validateSnapshotTimeout, validateSnapshotRetryInterval)
+	err = k8sextops.Instance().ValidateSnapshotData(snapshotData.Metadata.Name, false, storkconfig.GetValidateSnapshotTimeout(validateSnapshotTimeout), validateSnapshotRetryInterval)
 	if err != nil {
 		return "", "", "", fmt.Errorf("snapshot: %s is not complete. %v", snapshotData.Metadata.Name, err)
 	}
@@ -1742,6 +1883,9 @@ func (p *portworx) pxSnapshotRestore(snapRestore *storkapi.VolumeSnapshotRestore
 			return stateErr
 		}
 
+		if err := p.updateEncryptionSecret(volDriver, vol); err != nil {
+			log.VolumeSnapshotRestoreLog(snapRestore).Warnf("Unable to update encryption secret for volume %v: %v", vol.Volume, err)
+		}
 		log.VolumeSnapshotRestoreLog(snapRestore).Infof("Completed restore for volume %v with Snapshotshot %v", vol.Volume, snapID)
 		vol.Reason = "Restore is successful"
 		vol.RestoreStatus = storkapi.VolumeSnapshotRestoreStatusSuccessful
@@ -1787,7 +1931,7 @@ func (p *portworx) SnapshotRestore(
 	}
 
 	// Let's verify if source snapshotdata is complete
-	err = k8sextops.Instance().ValidateSnapshotData(snapshotData.Metadata.Name, false, validateSnapshotTimeout, validateSnapshotRetryInterval)
+	err = k8sextops.Instance().ValidateSnapshotData(snapshotData.Metadata.Name, false, storkconfig.GetValidateSnapshotTimeout(validateSnapshotTimeout), validateSnapshotRetryInterval)
 	if err != nil {
 		return nil, nil, fmt.Errorf("snapshot: %s is not complete. %v", snapshotName, err)
 	}
@@ -2062,7 +2206,12 @@ func (p *portworx) getCloudSnapStatus(volDriver volume.VolumeDriver, op api.Clou
 			msg:      err.Error(),
 		}
 	}
+	return getCloudSnapStatusFromResponse(response, op, taskID)
+}
 
+// getCloudSnapStatusFromResponse returns the status of the task from a
+// response with the statuses of one or more tasks
+func getCloudSnapStatusFromResponse(response *api.CloudBackupStatusResponse, op api.CloudBackupOpType, taskID string) cloudSnapStatus {
 	csStatus, present := response.Statuses[taskID]
 	if !present {
 		return cloudSnapStatus{
@@ -2479,7 +2628,6 @@ func (p *portworx) GetMigrationStatus(migration *storkapi.Migration) ([]*storkap
 	}
 
 	for _, vInfo := range migration.Status.Volumes {
-		found := false
 		taskID := p.getMigrationTaskID(migration, vInfo)
 		clusterID := clusterPair.Status.RemoteStorageID
 		status, err := volDriver.CloudMigrateStatus(
@@ -2495,40 +2643,88 @@ func (p *portworx) GetMigrationStatus(migration *storkapi.Migration) ([]*storkap
 		if !ok {
 			return nil, fmt.Errorf("migration status not found for remote cluster %v", clusterID)
 		}
-		for _, mInfo := range clusterInfo.List {
-			if taskID == mInfo.TaskId {
-				found = true
-				if mInfo.Status == api.CloudMigrate_Failed || mInfo.Status == api.CloudMigrate_Canceled {
-					vInfo.Status = storkapi.MigrationStatusFailed
-					vInfo.Reason = fmt.Sprintf("Migration %v failed for volume: %v", mInfo.CurrentStage, mInfo.ErrorReason)
-				} else if mInfo.CurrentStage == api.CloudMigrate_Done &&
-					mInfo.Status == api.CloudMigrate_Complete {
-					vInfo.Status = storkapi.MigrationStatusSuccessful
-					vInfo.Reason = "Migration successful for volume"
-				} else if mInfo.Status == api.CloudMigrate_InProgress {
-					vInfo.Reason = fmt.Sprintf("Volume migration has started. %v in progress. BytesDone: %v BytesTotal: %v ETA: %v seconds",
-						mInfo.CurrentStage.String(),
-						mInfo.BytesDone,
-						mInfo.BytesTotal,
-						mInfo.EtaSeconds)
-					if mInfo.BytesTotal > 0 {
-						// PX ends up re-setting the BytesTotal value to 0
-						// Only set the bytes total if PX sends a +ve value
-						vInfo.BytesTotal = mInfo.BytesTotal
-					}
-				}
-				break
-			}
+		updateMigrationVolumeInfo(vInfo, taskID, clusterInfo)
+	}
+
+	return migration.Status.Volumes, nil
+}
+
+// GetMigrationStatuses gets the status of all the migrations to the remote
+// cluster with a single call and updates the given volumes from it
+func (p *portworx) GetMigrationStatuses(
+	migration *storkapi.Migration,
+	volumes []*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return nil, err
 		}
+	}
+
+	volDriver, err := p.getUserVolDriver(migration.Annotations, "" /*templatized ns not supported*/)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterPair, err := storkops.Instance().GetClusterPair(migration.Spec.ClusterPair, migration.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting clusterpair: %v", err)
+	}
 
-		// If we didn't get the status for a volume mark it as failed
-		if !found {
-			vInfo.Status = storkapi.MigrationStatusFailed
-			vInfo.Reason = "Unable to find migration status for volume"
+	clusterID := clusterPair.Status.RemoteStorageID
+	status, err := volDriver.CloudMigrateStatus(
+		&api.CloudMigrateStatusRequest{
+			ClusterId: clusterID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo, ok := status.Info[clusterID]
+	if !ok {
+		return nil, fmt.Errorf("migration status not found for remote cluster %v", clusterID)
+	}
+	for _, vInfo := range volumes {
+		updateMigrationVolumeInfo(vInfo, p.getMigrationTaskID(migration, vInfo), clusterInfo)
+	}
+	return volumes, nil
+}
+
+// updateMigrationVolumeInfo updates the status of a volume from the statuses
+// of the migrations to the remote cluster
+func updateMigrationVolumeInfo(vInfo *storkapi.MigrationVolumeInfo, taskID string, clusterInfo *api.CloudMigrateInfoList) {
+	found := false
+	for _, mInfo := range clusterInfo.List {
+		if taskID == mInfo.TaskId {
+			found = true
+			if mInfo.Status == api.CloudMigrate_Failed || mInfo.Status == api.CloudMigrate_Canceled {
+				vInfo.Status = storkapi.MigrationStatusFailed
+				vInfo.Reason = fmt.Sprintf("Migration %v failed for volume: %v", mInfo.CurrentStage, mInfo.ErrorReason)
+			} else if mInfo.CurrentStage == api.CloudMigrate_Done &&
+				mInfo.Status == api.CloudMigrate_Complete {
+				vInfo.Status = storkapi.MigrationStatusSuccessful
+				vInfo.Reason = "Migration successful for volume"
+			} else if mInfo.Status == api.CloudMigrate_InProgress {
+				vInfo.Reason = fmt.Sprintf("Volume migration has started. %v in progress. BytesDone: %v BytesTotal: %v ETA: %v seconds",
+					mInfo.CurrentStage.String(),
+					mInfo.BytesDone,
+					mInfo.BytesTotal,
+					mInfo.EtaSeconds)
+				if mInfo.BytesTotal > 0 {
+					// PX ends up re-setting the BytesTotal value to 0
+					// Only set the bytes total if PX sends a +ve value
+					vInfo.BytesTotal = mInfo.BytesTotal
+				}
+			}
+			break
 		}
 	}
 
-	return migration.Status.Volumes, nil
+	// If we didn't get the status for a volume mark it as failed
+	if !found {
+		vInfo.Status = storkapi.MigrationStatusFailed
+		vInfo.Reason = "Unable to find migration status for volume"
+	}
 }
 
 func (p *portworx) CancelMigration(migration *storkapi.Migration) error {
@@ -2628,7 +2824,7 @@ func (p *portworx) GetGroupSnapshotStatus(snap *storkapi.GroupVolumeSnapshot) (
 	}
 	switch snapType {
 	case crdv1.PortworxSnapshotTypeCloud:
-		return p.getGroupCloudSnapStatus(snap)
+		return p.getGroupCloudSnapStatus(snap, false)
 	case crdv1.PortworxSnapshotTypeLocal:
 		return nil, &errors.ErrNotSupported{
 			Feature: "Group snapshots",
@@ -2639,6 +2835,29 @@ func (p *portworx) GetGroupSnapshotStatus(snap *storkapi.GroupVolumeSnapshot) (
 	return nil, fmt.Errorf("unsupported snapshot type: %s", snapType)
 }
 
+// GetSnapshotStatuses returns the status of the cloudsnaps of a group snapshot
+// with a single call to get the status of all the cloudsnap tasks
+func (p *portworx) GetSnapshotStatuses(snap *storkapi.GroupVolumeSnapshot) (
+	*storkvolume.GroupSnapshotCreateResponse, error) {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return nil, err
+		}
+	}
+
+	snapType, err := getSnapshotType(snap.Spec.Options)
+	if err != nil {
+		return nil, err
+	}
+	if snapType != crdv1.PortworxSnapshotTypeCloud {
+		return nil, &errors.ErrNotSupported{
+			Feature: "Group snapshots",
+			Reason:  "batch status API only supported for cloud group snapshots",
+		}
+	}
+	return p.getGroupCloudSnapStatus(snap, true)
+}
+
 func (p *portworx) DeleteGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) error {
 	if !p.initDone {
 		if err := p.initPortworxClients(); err != nil {
@@ -3018,8 +3237,24 @@ func (p *portworx) StartBackup(backup *storkapi.ApplicationBackup,
 		}
 
 		volumeInfo.Volume = volume
+		// Save the QoS attributes of the volume so that they can be
+		// reapplied on restore
+		if vols, err := volDriver.Inspect([]string{volume}); err != nil || len(vols) == 0 {
+			log.ApplicationBackupLog(backup).Warnf("Unable to get attributes of volume %v: %v", volume, err)
+		} else {
+			if vols[0].Spec != nil {
+				volumeInfo.VolumeAttributes = getVolumeAttributes(vols[0].Spec)
+			}
+			// Save where the replicas are so that they can be placed on the
+			// same nodes on restore
+			replicaNodes, err := p.getReplicaNodes(vols[0])
+			if err != nil {
+				log.ApplicationBackupLog(backup).Warnf("Unable to get replica nodes of volume %v: %v", volume, err)
+			}
+			volumeInfo.ReplicaNodes = replicaNodes
+		}
 		taskID := p.getBackupRestoreTaskID(backup.UID, volumeInfo.Namespace, volumeInfo.PersistentVolumeClaim)
-		credID := p.getCredID(backup.Spec.BackupLocation, backup.Namespace)
+		credID := p.getCredID(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 		request := &api.CloudBackupCreateRequest{
 			VolumeID:       volume,
 			CredentialUUID: credID,
@@ -3194,7 +3429,7 @@ func (p *portworx) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error
 			}
 			input := &api.CloudBackupDeleteRequest{
 				ID:             vInfo.BackupID,
-				CredentialUUID: p.getCredID(backup.Spec.BackupLocation, backup.Namespace),
+				CredentialUUID: p.getCredID(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace()),
 			}
 			if err := volDriver.CloudBackupDelete(input); err != nil {
 				return true, err
@@ -3374,11 +3609,15 @@ func (p *portworx) StartRestore(
 		}
 
 		taskID := p.getBackupRestoreTaskID(restore.UID, volumeInfo.SourceNamespace, volumeInfo.PersistentVolumeClaim)
-		credID := p.getCredID(restore.Spec.BackupLocation, restore.Namespace)
-		locator, restoreSpec, err := p.getCloudBackupRestoreSpec(restore.Spec.StorageClassMapping, backupVolumeInfo.StorageClass, taskID)
+		credID := p.getCredID(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
+		volumeInfo.VolumeAttributes = mapVolumeAttributes(backupVolumeInfo.VolumeAttributes, restore.Spec.VolumeAttributeMapping)
+		locator, restoreSpec, err := p.getCloudBackupRestoreSpec(restore.Spec.StorageClassMapping, backupVolumeInfo.StorageClass, taskID, volumeInfo.VolumeAttributes)
 		if err != nil {
 			return volumeInfos, fmt.Errorf("failed to parse restore volume spec: %v ", err)
 		}
+		if err := p.setReplicaPlacement(restoreSpec, backupVolumeInfo.ReplicaNodes); err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Unable to restore volume %v to its original nodes: %v", backupVolumeInfo.Volume, err)
+		}
 		request := &api.CloudBackupRestoreRequest{
 			Name:              taskID,
 			ID:                backupVolumeInfo.BackupID,
@@ -3419,7 +3658,7 @@ func (p *portworx) getCloudBackupRestoreSpec(
 	storageClassMapping map[string]string,
 	sourceStorageClass string,
 	taskID string,
-
+	volumeAttributes map[string]string,
 ) (*api.VolumeLocator, *api.RestoreVolumeSpec, error) {
 	locator := &api.VolumeLocator{
 		Name: taskID, // always override name with taskID
@@ -3431,6 +3670,9 @@ func (p *portworx) getCloudBackupRestoreSpec(
 			IoProfileBkupSrc: true, // setting this for backward compatibility
 		}
 		// No mapping provided
+		if err := applyVolumeAttributes(restoreSpec, volumeAttributes, nil); err != nil {
+			return nil, nil, err
+		}
 		return locator, restoreSpec, nil
 	}
 	sc, err := storage.Instance().GetStorageClass(destStorageClass)
@@ -3450,10 +3692,193 @@ func (p *portworx) getCloudBackupRestoreSpec(
 		// io profile not specified in storage class
 		restoreSpec.IoProfileBkupSrc = true
 	}
+	// The parameters of the storage class take precedence over the
+	// attributes of the backed up volume
+	if err := applyVolumeAttributes(restoreSpec, volumeAttributes, sc.Parameters); err != nil {
+		return nil, nil, err
+	}
 
 	return locator, restoreSpec, nil
 }
 
+// getReplicaNodes returns the names of the nodes that hold the replicas of
+// the volume
+func (p *portworx) getReplicaNodes(vol *api.Volume) ([]string, error) {
+	if len(vol.ReplicaSets) == 0 {
+		return nil, nil
+	}
+	nodes, err := p.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string)
+	for _, node := range nodes {
+		if node.SchedulerID != "" {
+			names[node.StorageID] = node.SchedulerID
+		} else {
+			names[node.StorageID] = node.Hostname
+		}
+	}
+	replicaNodes := make([]string, 0)
+	for _, replicaSet := range vol.ReplicaSets {
+		for _, id := range replicaSet.Nodes {
+			if name, ok := names[id]; ok && name != "" {
+				replicaNodes = append(replicaNodes, name)
+			}
+		}
+	}
+	return replicaNodes, nil
+}
+
+// setReplicaPlacement places the replicas of the restored volume on the
+// nodes that held them when the volume was backed up. The placement is only
+// set if enough of those nodes are online and the storage class doesn't pick
+// the nodes itself.
+func (p *portworx) setReplicaPlacement(restoreSpec *api.RestoreVolumeSpec, replicaNodes []string) error {
+	if len(replicaNodes) == 0 || restoreSpec.ReplicaSet != nil {
+		return nil
+	}
+	repl := len(replicaNodes)
+	if restoreSpec.HaLevel > 0 && int(restoreSpec.HaLevel) < repl {
+		repl = int(restoreSpec.HaLevel)
+	}
+	nodes, err := p.GetNodes()
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0)
+	for _, name := range replicaNodes {
+		for _, node := range nodes {
+			if node.Status != storkvolume.NodeOnline || (node.SchedulerID != name && node.Hostname != name) {
+				continue
+			}
+			ids = append(ids, node.StorageID)
+			break
+		}
+		if len(ids) == repl {
+			break
+		}
+	}
+	if len(ids) < repl {
+		return fmt.Errorf("only %v of the nodes %v are online", len(ids), replicaNodes)
+	}
+	restoreSpec.ReplicaSet = &api.ReplicaSet{Nodes: ids}
+	return nil
+}
+
+// getVolumeAttributes returns the QoS attributes of the volume that are
+// reapplied when it is restored
+func getVolumeAttributes(spec *api.VolumeSpec) map[string]string {
+	attributes := map[string]string{
+		api.SpecHaLevel:   strconv.FormatInt(spec.HaLevel, 10),
+		api.SpecIoProfile: spec.IoProfile.SimpleString(),
+	}
+	if spec.Cos != api.CosType_NONE {
+		attributes[api.SpecPriority] = spec.Cos.SimpleString()
+	}
+	if throttle := spec.IoThrottle; throttle != nil {
+		for key, value := range map[string]uint32{
+			api.SpecIoThrottleRdIOPS: throttle.ReadIops,
+			api.SpecIoThrottleWrIOPS: throttle.WriteIops,
+			api.SpecIoThrottleRdBW:   throttle.ReadBwMbytes,
+			api.SpecIoThrottleWrBW:   throttle.WriteBwMbytes,
+		} {
+			if value > 0 {
+				attributes[key] = strconv.FormatUint(uint64(value), 10)
+			}
+		}
+	}
+	return attributes
+}
+
+// mapVolumeAttributes applies the mapping from the restore to the attributes
+// of the backed up volume. A mapping for <attribute>=<value> takes precedence
+// over one for the attribute. Attributes mapped to an empty value are
+// dropped, and mapped attributes that weren't backed up are added.
+func mapVolumeAttributes(attributes map[string]string, mapping map[string]string) map[string]string {
+	mapped := make(map[string]string)
+	for key, value := range attributes {
+		if newValue, ok := mapping[key+"="+value]; ok {
+			value = newValue
+		} else if newValue, ok := mapping[key]; ok {
+			value = newValue
+		}
+		if value != "" {
+			mapped[key] = value
+		}
+	}
+	for key, value := range mapping {
+		if _, ok := attributes[key]; !ok && !strings.Contains(key, "=") && value != "" {
+			mapped[key] = value
+		}
+	}
+	if len(mapped) == 0 {
+		return nil
+	}
+	return mapped
+}
+
+// applyVolumeAttributes sets the attributes that are supported by the
+// restore spec, unless they are set in the parameters of the storage class.
+// IOPS limits are applied once the volume has been restored.
+func applyVolumeAttributes(restoreSpec *api.RestoreVolumeSpec, attributes map[string]string, scParameters map[string]string) error {
+	for key, value := range attributes {
+		if _, ok := scParameters[key]; ok {
+			continue
+		}
+		switch key {
+		case api.SpecHaLevel:
+			haLevel, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %v for volume attribute %v: %v", value, key, err)
+			}
+			restoreSpec.HaLevel = haLevel
+		case api.SpecIoProfile:
+			ioProfile, err := api.IoProfileSimpleValueOf(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %v for volume attribute %v: %v", value, key, err)
+			}
+			restoreSpec.IoProfile = ioProfile
+			restoreSpec.IoProfileBkupSrc = false
+		case api.SpecPriority:
+			cos, err := api.CosTypeSimpleValueOf(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %v for volume attribute %v: %v", value, key, err)
+			}
+			restoreSpec.Cos = cos
+		}
+	}
+	return nil
+}
+
+// getIoThrottle returns the IOPS and bandwidth limits from the volume
+// attributes or nil if none are set
+func getIoThrottle(attributes map[string]string) (*api.IoThrottle, error) {
+	throttle := &api.IoThrottle{}
+	found := false
+	for key, limit := range map[string]*uint32{
+		api.SpecIoThrottleRdIOPS: &throttle.ReadIops,
+		api.SpecIoThrottleWrIOPS: &throttle.WriteIops,
+		api.SpecIoThrottleRdBW:   &throttle.ReadBwMbytes,
+		api.SpecIoThrottleWrBW:   &throttle.WriteBwMbytes,
+	} {
+		value, ok := attributes[key]
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %v for volume attribute %v: %v", value, key, err)
+		}
+		*limit = uint32(parsed)
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return throttle, nil
+}
+
 func (p *portworx) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
 	if !p.initDone {
 		if err := p.initPortworxClients(); err != nil {
@@ -3499,6 +3924,15 @@ func (p *portworx) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*st
 			vInfo.TotalSize = csStatus.bytesDone
 			vInfo.Status = storkapi.ApplicationRestoreStatusSuccessful
 			vInfo.Reason = "Restore successful for volume"
+			// The IOPS limits can't be passed in with the restore request
+			throttle, err := getIoThrottle(vInfo.VolumeAttributes)
+			if err == nil && throttle != nil {
+				err = volDriver.Set(vInfo.RestoreVolume, nil, &api.VolumeSpec{IoThrottle: throttle})
+			}
+			if err != nil {
+				log.ApplicationRestoreLog(restore).Warnf("Unable to set IOPS limits on volume %v: %v", vInfo.RestoreVolume, err)
+				vInfo.Reason = fmt.Sprintf("Restore successful for volume, but IOPS limits couldn't be set: %v", err)
+			}
 		}
 		volumeInfos = append(volumeInfos, vInfo)
 	}
@@ -3548,6 +3982,22 @@ func (p *portworx) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
 	if err != nil {
 		return err
 	}
+	// Check that the keys of encrypted volumes are available in the
+	// destination namespace before cloning any of the volumes
+	for _, vInfo := range clone.Status.Volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vInfo.PersistentVolumeClaim, clone.Spec.SourceNamespace)
+		if err != nil {
+			return err
+		}
+		secretNamespace, secretName, err := getEncryptionSecret(pvc, clone.Spec.DestinationNamespace, clone.Spec.EncryptionSecretMapping)
+		if err != nil {
+			vInfo.Reason = err.Error()
+			return err
+		}
+		if secretName != "" {
+			vInfo.EncryptionSecret = secretNamespace + "/" + secretName
+		}
+	}
 	for _, vInfo := range clone.Status.Volumes {
 		locator := &api.VolumeLocator{
 			Name: vInfo.CloneVolume,
@@ -3556,6 +4006,9 @@ func (p *portworx) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
 				namespaceLabel: clone.Spec.DestinationNamespace,
 			},
 		}
+		for k, v := range encryptionSecretLabels(vInfo.EncryptionSecret) {
+			locator.VolumeLabels[k] = v
+		}
 		_, err := volDriver.Snapshot(vInfo.Volume, false, locator, true)
 		if err != nil {
 			// Mark this clone for deletion too if it already existed, so that
@@ -3581,6 +4034,71 @@ func (p *portworx) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
 	return nil
 }
 
+func (p *portworx) QuiesceVolume(volumeID string, timeout time.Duration, quiesceID string) error {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return err
+		}
+	}
+
+	volDriver, err := p.getAdminVolDriver()
+	if err != nil {
+		return err
+	}
+	return volDriver.Quiesce(volumeID, uint64(timeout.Seconds()), quiesceID)
+}
+
+func (p *portworx) UnquiesceVolume(volumeID string) error {
+	if !p.initDone {
+		if err := p.initPortworxClients(); err != nil {
+			return err
+		}
+	}
+
+	volDriver, err := p.getAdminVolDriver()
+	if err != nil {
+		return err
+	}
+	return volDriver.Unquiesce(volumeID)
+}
+
+// GetPlacementAnnotations returns the nodes annotation which is passed to
+// portworx as a volume label when the volume is provisioned. Only as many
+// nodes as the replication level of the storage class are used, portworx
+// picks the nodes for the rest of the replicas.
+func (p *portworx) GetPlacementAnnotations(pvc *v1.PersistentVolumeClaim, nodes []*storkvolume.NodeInfo) (map[string]string, error) {
+	if _, ok := pvc.Annotations[api.SpecNodes]; ok {
+		// Don't override the nodes picked by the user
+		return nil, nil
+	}
+	repl := 1
+	if sc, err := core.Instance().GetStorageClassForPVC(pvc); err == nil {
+		if value, err := strconv.Atoi(sc.Parameters[api.SpecHaLevel]); err == nil && value > 0 {
+			repl = value
+		}
+	}
+	if value, err := strconv.Atoi(pvc.Annotations[api.SpecHaLevel]); err == nil && value > 0 {
+		repl = value
+	}
+
+	nodeIDs := make([]string, 0)
+	for _, node := range nodes {
+		if len(nodeIDs) == repl {
+			break
+		}
+		if node.Status != storkvolume.NodeOnline {
+			continue
+		}
+		nodeIDs = append(nodeIDs, node.StorageID)
+	}
+	if len(nodeIDs) == 0 {
+		return nil, nil
+	}
+	return map[string]string{
+		api.SpecNodes: strings.Join(nodeIDs, ","),
+	}, nil
+}
+
 func (p *portworx) createGroupLocalSnapFromPVCs(groupSnap *storkapi.GroupVolumeSnapshot, volNames []string, options map[string]string) (
 	*storkvolume.GroupSnapshotCreateResponse, error) {
 	volDriver, err := p.getUserVolDriver(groupSnap.Annotations, "" /*templatized ns not supported*/)
@@ -3681,12 +4199,12 @@ func (p *portworx) createGroupCloudSnapFromVolumes(
 		return nil, fmt.Errorf("group cloudsnapshot request returned 0 tasks")
 	}
 
-	return p.generateStatusReponseFromTaskIDs(groupSnap, resp.Names, credID)
+	return p.generateStatusReponseFromTaskIDs(groupSnap, resp.Names, credID, false)
 }
 
 // getGroupCloudSnapStatus fetches the current group cloudsnapshot status by using the task ID in the given
 // volumesnapshot object and returns the updated volumesnapshot object
-func (p *portworx) getGroupCloudSnapStatus(snap *storkapi.GroupVolumeSnapshot) (
+func (p *portworx) getGroupCloudSnapStatus(snap *storkapi.GroupVolumeSnapshot, batch bool) (
 	*storkvolume.GroupSnapshotCreateResponse, error) {
 
 	if len(snap.Status.VolumeSnapshots) == 0 {
@@ -3699,11 +4217,14 @@ func (p *portworx) getGroupCloudSnapStatus(snap *storkapi.GroupVolumeSnapshot) (
 	for _, snapshotStatus := range snap.Status.VolumeSnapshots {
 		taskIDs = append(taskIDs, snapshotStatus.TaskID)
 	}
-	return p.generateStatusReponseFromTaskIDs(snap, taskIDs, credID)
+	return p.generateStatusReponseFromTaskIDs(snap, taskIDs, credID, batch)
 }
 
+// generateStatusReponseFromTaskIDs returns the status of the cloudsnaps of a
+// group snapshot. If batch is set the statuses of all the tasks are fetched
+// with a single call instead of one call per task.
 func (p *portworx) generateStatusReponseFromTaskIDs(
-	groupSnap *storkapi.GroupVolumeSnapshot, taskIDs []string, credID string) (
+	groupSnap *storkapi.GroupVolumeSnapshot, taskIDs []string, credID string, batch bool) (
 	*storkvolume.GroupSnapshotCreateResponse, error) {
 	response := &storkvolume.GroupSnapshotCreateResponse{
 		Snapshots: make([]*storkapi.VolumeSnapshotStatus, 0),
@@ -3715,13 +4236,26 @@ func (p *portworx) generateStatusReponseFromTaskIDs(
 		return nil, err
 	}
 
+	getStatus := func(taskID string) cloudSnapStatus {
+		return p.getCloudSnapStatus(volDriver, api.CloudBackupOp, taskID)
+	}
+	if batch {
+		statuses, err := volDriver.CloudBackupStatus(&api.CloudBackupStatusRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting cloudsnap statuses: %v", err)
+		}
+		getStatus = func(taskID string) cloudSnapStatus {
+			return getCloudSnapStatusFromResponse(statuses, api.CloudBackupOp, taskID)
+		}
+	}
+
 	failedTasks := make([]string, 0)
 	doneTasks := make([]string, 0)
 	activeTasks := make([]string, 0)
 	doneSnapIDs := make([]string, 0)
 	activeSnapIDs := make([]string, 0)
 	for _, taskID := range taskIDs {
-		csStatus := p.getCloudSnapStatus(volDriver, api.CloudBackupOp, taskID)
+		csStatus := getStatus(taskID)
 
 		dataSource := &crdv1.VolumeSnapshotDataSource{
 			PortworxSnapshot: &crdv1.PortworxVolumeSnapshotSource{
@@ -3855,6 +4389,27 @@ func (p *portworx) verifyPortworxPv(pv *v1.PersistentVolume) error {
 	return err
 }
 
+// quiesceVolumesForSnapshot quiesces IO on the volumes of all the PVCs that
+// are being snapshotted
+func (p *portworx) quiesceVolumesForSnapshot(
+	snap *crdv1.VolumeSnapshot,
+	pvcs []v1.PersistentVolumeClaim,
+) (func(), error) {
+	volumeIDs := make([]string, 0)
+	for _, pvc := range pvcs {
+		pv, err := core.Instance().GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, err
+		}
+		volumeID, err := p.getVolumeIDFromPV(pv)
+		if err != nil {
+			return nil, err
+		}
+		volumeIDs = append(volumeIDs, volumeID)
+	}
+	return snapshot.QuiesceVolumes(p, volumeIDs, string(snap.Metadata.UID))
+}
+
 func (p *portworx) getVolumeIDFromPV(pv *v1.PersistentVolume) (string, error) {
 	if pv == nil {
 		return "", fmt.Errorf("nil PV passed into getVolumeIDFromPV")
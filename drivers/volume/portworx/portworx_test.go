@@ -0,0 +1,115 @@
+//go:build unittest
+// +build unittest
+
+package portworx
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	storkvolume "github.com/libopenstorage/stork/drivers/volume"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func newEncryptionSecret(name, namespace, key string) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string][]byte{"key": []byte(key)},
+	}
+}
+
+func newEncryptedPVC(secretName string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pvc",
+			Namespace:   "src",
+			Annotations: map[string]string{pxSecretNameAnnotation: secretName},
+		},
+	}
+}
+
+func TestGetEncryptionSecret(t *testing.T) {
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset(
+		newEncryptionSecret("key", "src", "passphrase"),
+		newEncryptionSecret("key", "dest", "passphrase"),
+		newEncryptionSecret("copy", "dest", "passphrase"),
+		newEncryptionSecret("other", "dest", "different"),
+	)))
+
+	namespace, name, err := getEncryptionSecret(&v1.PersistentVolumeClaim{}, "dest", nil)
+	require.NoError(t, err)
+	require.Empty(t, name, "volumes without a secret shouldn't get one")
+
+	namespace, name, err = getEncryptionSecret(newEncryptedPVC("key"), "src", nil)
+	require.NoError(t, err)
+	require.Equal(t, "src/key", namespace+"/"+name)
+
+	namespace, name, err = getEncryptionSecret(newEncryptedPVC("key"), "dest", nil)
+	require.NoError(t, err)
+	require.Equal(t, "dest/key", namespace+"/"+name)
+
+	namespace, name, err = getEncryptionSecret(newEncryptedPVC("key"), "dest", map[string]string{"src/key": "copy"})
+	require.NoError(t, err)
+	require.Equal(t, "dest/copy", namespace+"/"+name)
+
+	_, _, err = getEncryptionSecret(newEncryptedPVC("key"), "dest", map[string]string{"key": "dest/other"})
+	require.Error(t, err, "secret with a different key should be rejected since volumes aren't re-encrypted")
+
+	_, _, err = getEncryptionSecret(newEncryptedPVC("key"), "dest", map[string]string{"key": "missing"})
+	require.Error(t, err)
+
+	// Keys kept by other secret providers aren't Kubernetes secrets
+	namespace, name, err = getEncryptionSecret(newEncryptedPVC("vault-key"), "dest", nil)
+	require.NoError(t, err)
+	require.Equal(t, "dest/vault-key", namespace+"/"+name)
+	_, _, err = getEncryptionSecret(newEncryptedPVC("vault-key"), "dest", map[string]string{"vault-key": "copy"})
+	require.Error(t, err, "keys of other secret providers can't be mapped")
+}
+
+func TestGetPlacementAnnotations(t *testing.T) {
+	storageClass := "px"
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset(&storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: storageClass},
+		Parameters: map[string]string{api.SpecHaLevel: "2"},
+	})))
+	p := &portworx{}
+	nodes := []*storkvolume.NodeInfo{
+		{StorageID: "node1", Status: storkvolume.NodeOnline},
+		{StorageID: "node2", Status: storkvolume.NodeOffline},
+		{StorageID: "node3", Status: storkvolume.NodeOnline},
+		{StorageID: "node4", Status: storkvolume.NodeOnline},
+	}
+	newPVC := func(annotations map[string]string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "test", Annotations: annotations},
+			Spec:       v1.PersistentVolumeClaimSpec{StorageClassName: &storageClass},
+		}
+	}
+
+	// The online nodes are picked in order for each replica of the storage
+	// class or the PVC
+	annotations, err := p.GetPlacementAnnotations(newPVC(nil), nodes)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{api.SpecNodes: "node1,node3"}, annotations)
+
+	annotations, err = p.GetPlacementAnnotations(newPVC(map[string]string{api.SpecHaLevel: "3"}), nodes)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{api.SpecNodes: "node1,node3,node4"}, annotations)
+
+	annotations, err = p.GetPlacementAnnotations(&v1.PersistentVolumeClaim{}, nodes)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{api.SpecNodes: "node1"}, annotations, "Volumes should have a single replica by default")
+
+	annotations, err = p.GetPlacementAnnotations(newPVC(map[string]string{api.SpecNodes: "node4"}), nodes)
+	require.NoError(t, err)
+	require.Empty(t, annotations, "Nodes picked by the user shouldn't be overridden")
+
+	annotations, err = p.GetPlacementAnnotations(newPVC(nil), nodes[1:2])
+	require.NoError(t, err)
+	require.Empty(t, annotations, "Offline nodes shouldn't be picked")
+}
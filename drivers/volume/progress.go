@@ -0,0 +1,75 @@
+package volume
+
+import (
+	"sync"
+
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ProgressKindBackup is the kind reported for the progress of backups
+	ProgressKindBackup = "ApplicationBackup"
+	// ProgressKindRestore is the kind reported for the progress of restores
+	ProgressKindRestore = "ApplicationRestore"
+	// ProgressKindMigration is the kind reported for the progress of
+	// migrations
+	ProgressKindMigration = "Migration"
+	// ProgressKindGroupSnapshot is the kind reported for the progress of
+	// group snapshots
+	ProgressKindGroupSnapshot = "GroupVolumeSnapshot"
+)
+
+var (
+	progressCallbacks []ProgressCallback
+	progressLock      sync.RWMutex
+	progressWatchOnce sync.Once
+)
+
+// OnProgress registers a callback for the progress reported by all the
+// registered drivers. The drivers are asked to start reporting progress when
+// the first callback is registered.
+func OnProgress(callback ProgressCallback) {
+	progressLock.Lock()
+	progressCallbacks = append(progressCallbacks, callback)
+	progressLock.Unlock()
+
+	progressWatchOnce.Do(func() {
+		for name, d := range volDrivers {
+			if err := d.WatchProgress(ReportProgress); err != nil {
+				if _, ok := err.(*errors.ErrNotSupported); !ok {
+					logrus.Warnf("Error watching progress of volume driver %v, status will only be polled: %v", name, err)
+				}
+			}
+		}
+	})
+}
+
+// HasProgressCallbacks returns true if any callbacks have been registered for
+// the progress reported by the drivers. The controllers only register them
+// once they are started, i.e. on the leader.
+func HasProgressCallbacks() bool {
+	progressLock.RLock()
+	defer progressLock.RUnlock()
+	return len(progressCallbacks) != 0
+}
+
+// IsProgressKind returns true if progress can be reported for the kind
+func IsProgressKind(kind string) bool {
+	switch kind {
+	case ProgressKindBackup, ProgressKindRestore, ProgressKindMigration, ProgressKindGroupSnapshot:
+		return true
+	}
+	return false
+}
+
+// ReportProgress passes the progress reported by a driver to all the
+// registered callbacks. Drivers that can't be watched from stork can also push
+// their progress through the webhook server.
+func ReportProgress(kind, namespace, name string) {
+	progressLock.RLock()
+	defer progressLock.RUnlock()
+	for _, callback := range progressCallbacks {
+		callback(kind, namespace, name)
+	}
+}
@@ -0,0 +1,70 @@
+//go:build unittest
+// +build unittest
+
+package volume
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// progressDriver saves the callback so that the test can report progress
+type progressDriver struct {
+	Driver
+	callback ProgressCallback
+	watches  int
+}
+
+func (p *progressDriver) WatchProgress(callback ProgressCallback) error {
+	p.watches++
+	p.callback = callback
+	return nil
+}
+
+// noProgressDriver can't report progress
+type noProgressDriver struct {
+	Driver
+	ProgressNotSupported
+}
+
+func (n *noProgressDriver) WatchProgress(callback ProgressCallback) error {
+	return n.ProgressNotSupported.WatchProgress(callback)
+}
+
+func TestOnProgress(t *testing.T) {
+	driver := &progressDriver{}
+	require.NoError(t, Register("progress", driver))
+	require.NoError(t, Register("noprogress", &noProgressDriver{}))
+	defer delete(volDrivers, "progress")
+	defer delete(volDrivers, "noprogress")
+
+	backups := make([]string, 0)
+	restores := make([]string, 0)
+	OnProgress(func(kind, namespace, name string) {
+		if kind == ProgressKindBackup {
+			backups = append(backups, namespace+"/"+name)
+		}
+	})
+	OnProgress(func(kind, namespace, name string) {
+		if kind == ProgressKindRestore {
+			restores = append(restores, namespace+"/"+name)
+		}
+	})
+	require.Equal(t, 1, driver.watches, "drivers should only be watched once")
+
+	driver.callback(ProgressKindBackup, "ns", "backup")
+	driver.callback(ProgressKindRestore, "ns", "restore")
+	require.Equal(t, []string{"ns/backup"}, backups)
+	require.Equal(t, []string{"ns/restore"}, restores)
+
+	// Progress pushed by drivers is passed to the same callbacks
+	ReportProgress(ProgressKindBackup, "ns", "pushed")
+	require.Equal(t, []string{"ns/backup", "ns/pushed"}, backups)
+	require.True(t, IsProgressKind(ProgressKindGroupSnapshot))
+	require.False(t, IsProgressKind("Pod"))
+
+	_, ok := (&noProgressDriver{}).WatchProgress(nil).(*errors.ErrNotSupported)
+	require.True(t, ok)
+}
@@ -0,0 +1,916 @@
+package volume
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ResilienceConfig configures the retries and circuit breaker used for calls
+// to a volume driver
+type ResilienceConfig struct {
+	// MaxRetries is the number of times a read-only call is retried
+	MaxRetries int
+	// InitialBackoff is the time to wait before the first retry. It is
+	// doubled for every retry up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff is the maximum time to wait between retries
+	MaxBackoff time.Duration
+	// CallTimeout is the time after which a read-only call is abandoned
+	CallTimeout time.Duration
+	// FailureThreshold is the number of consecutive failed calls after which
+	// the driver is marked as degraded
+	FailureThreshold int
+	// OpenDuration is the time for which calls fail without calling the
+	// driver once it is marked as degraded. A single call is let through
+	// after that to check if the driver has recovered.
+	OpenDuration time.Duration
+	// OnStateChange is called when the driver is marked as degraded or
+	// recovers
+	OnStateChange func(driver string, degraded bool, reason string)
+	// OnCallFailure is called for every failed call to the driver
+	OnCallFailure func(driver string, method string)
+}
+
+// DefaultResilienceConfig is the default config used for volume drivers
+var DefaultResilienceConfig = ResilienceConfig{
+	MaxRetries:       3,
+	InitialBackoff:   500 * time.Millisecond,
+	MaxBackoff:       5 * time.Second,
+	CallTimeout:      1 * time.Minute,
+	FailureThreshold: 5,
+	OpenDuration:     30 * time.Second,
+}
+
+var (
+	resilienceConfig *ResilienceConfig
+	resilientDrivers = make(map[string]*ResilientDriver)
+	resilientLock    sync.Mutex
+)
+
+// EnableResilience makes Get return drivers that retry failed calls and stop
+// calling the driver when it keeps failing
+func EnableResilience(config ResilienceConfig) {
+	resilientLock.Lock()
+	defer resilientLock.Unlock()
+	resilienceConfig = &config
+	resilientDrivers = make(map[string]*ResilientDriver)
+}
+
+// getResilientDriver returns the resilient wrapper for the driver if
+// resilience has been enabled
+func getResilientDriver(name string, d Driver) Driver {
+	resilientLock.Lock()
+	defer resilientLock.Unlock()
+	if resilienceConfig == nil {
+		return d
+	}
+	if r, ok := resilientDrivers[name]; ok {
+		return r
+	}
+	r := NewResilientDriver(name, d, *resilienceConfig)
+	resilientDrivers[name] = r
+	return r
+}
+
+// ErrDriverDegraded is returned when calls to a driver have been failing and
+// the driver isn't being called until it recovers
+type ErrDriverDegraded struct {
+	// Driver is the name of the driver
+	Driver string
+	// Reason is the last error returned by the driver
+	Reason string
+}
+
+func (e *ErrDriverDegraded) Error() string {
+	return fmt.Sprintf("volume driver %v is degraded: %v", e.Driver, e.Reason)
+}
+
+// ErrCallTimeout is returned when a call to the driver is abandoned because
+// it didn't return in time
+type ErrCallTimeout struct {
+	// Method is the driver method that was called
+	Method string
+	// Timeout is the time after which the call was abandoned
+	Timeout time.Duration
+}
+
+func (e *ErrCallTimeout) Error() string {
+	return fmt.Sprintf("call %v to volume driver timed out after %v", e.Method, e.Timeout)
+}
+
+// maxAbandonedCalls is the number of calls that have timed out but are
+// still running in the background after which calls to the driver fail
+// right away, so that a hung driver doesn't pile up goroutines
+const maxAbandonedCalls = 10
+
+const (
+	callRunning int32 = iota
+	callDone
+	callAbandoned
+)
+
+// ResilientDriver wraps a volume driver. Read-only calls are retried with
+// exponential backoff and abandoned after a timeout. Calls that change state
+// aren't retried since they might not be idempotent. If the driver keeps
+// being unreachable the driver is marked as degraded and calls fail
+// immediately until a trial call succeeds. Only transport errors and
+// timeouts of read-only calls count as the driver being unreachable, errors
+// returned by the driver itself don't.
+type ResilientDriver struct {
+	Driver
+	name    string
+	config  ResilienceConfig
+	breaker *circuitBreaker
+	// abandoned is the number of calls that timed out and are still
+	// running. It is shared by all the wrappers of the driver.
+	abandoned *int32
+}
+
+// NewResilientDriver returns a resilient wrapper for the driver
+func NewResilientDriver(name string, d Driver, config ResilienceConfig) *ResilientDriver {
+	return &ResilientDriver{
+		Driver:    d,
+		name:      name,
+		config:    config,
+		breaker:   &circuitBreaker{},
+		abandoned: new(int32),
+	}
+}
+
+// WithoutRetries returns a wrapper of the driver that doesn't retry failed
+// calls and abandons read-only calls after the given timeout, or the
+// configured timeout if it is 0. The wrapper shares the circuit breaker of
+// d. It is used by callers that need to respond quickly, like the scheduler
+// extender, or that retry the calls themselves. Drivers that aren't
+// resilient are returned as is.
+func WithoutRetries(d Driver, timeout time.Duration) Driver {
+	r, ok := d.(*ResilientDriver)
+	if !ok {
+		return d
+	}
+	config := r.config
+	config.MaxRetries = 0
+	if timeout > 0 {
+		config.CallTimeout = timeout
+	}
+	return &ResilientDriver{
+		Driver:    r.Driver,
+		name:      r.name,
+		config:    config,
+		breaker:   r.breaker,
+		abandoned: r.abandoned,
+	}
+}
+
+// Unwrap returns the driver that is wrapped
+func (r *ResilientDriver) Unwrap() Driver {
+	return r.Driver
+}
+
+// SupportsQuiesce returns true if the wrapped driver can quiesce IO on the
+// volume of the PVC
+func (r *ResilientDriver) SupportsQuiesce(pvc *v1.PersistentVolumeClaim) bool {
+	return IsQuiesceSupported(r.Driver, pvc)
+}
+
+// IsDegraded returns true if the driver is currently marked as degraded
+func (r *ResilientDriver) IsDegraded() bool {
+	degraded, _ := r.breaker.state()
+	return degraded
+}
+
+// read calls fn with retries and a timeout. Only transport errors are
+// retried. Calls that time out aren't retried since the abandoned call is
+// still running against the driver.
+func (r *ResilientDriver) read(method string, fn func() error) error {
+	backoff := r.config.InitialBackoff
+	var err error
+	for i := 0; i <= r.config.MaxRetries; i++ {
+		if i > 0 {
+			time.Sleep(backoff)
+			backoff *= 2
+			if backoff > r.config.MaxBackoff {
+				backoff = r.config.MaxBackoff
+			}
+		}
+		err = r.call(method, true, func() error {
+			return r.callWithTimeout(method, fn)
+		})
+		if err == nil || !isTransportError(err) {
+			return err
+		}
+		if isCallTimeout(err) {
+			return err
+		}
+		logrus.Warnf("Call %v to volume driver %v failed, attempt %v/%v: %v", method, r.name, i+1, r.config.MaxRetries+1, err)
+	}
+	return err
+}
+
+// write calls fn once. Failed writes don't count towards marking the driver
+// as degraded since they can fail for reasons that have nothing to do with
+// the driver being reachable, but writes aren't made while it is degraded.
+func (r *ResilientDriver) write(method string, fn func() error) error {
+	return r.call(method, false, fn)
+}
+
+// call calls fn through the circuit breaker. Transport errors count as
+// failures of the driver if countFailure is true. Any other result means
+// the driver could be reached.
+func (r *ResilientDriver) call(method string, countFailure bool, fn func() error) error {
+	if allowed, reason := r.breaker.allow(r.config.OpenDuration); !allowed {
+		return &ErrDriverDegraded{Driver: r.name, Reason: reason}
+	}
+	err := fn()
+	if err != nil && !isPermanentDriverError(err) && r.config.OnCallFailure != nil {
+		r.config.OnCallFailure(r.name, method)
+	}
+	if err != nil && isTransportError(err) {
+		if !countFailure {
+			// The result doesn't say anything about the driver, let the
+			// next trial call through if this was one
+			r.breaker.skip()
+			return err
+		}
+		if r.breaker.failure(err, r.config.FailureThreshold) {
+			logrus.Errorf("Marking volume driver %v as degraded after %v failed calls: %v", r.name, r.config.FailureThreshold, err)
+			if r.config.OnStateChange != nil {
+				r.config.OnStateChange(r.name, true, err.Error())
+			}
+		}
+		return err
+	}
+	if r.breaker.success() {
+		logrus.Infof("Volume driver %v has recovered", r.name)
+		if r.config.OnStateChange != nil {
+			r.config.OnStateChange(r.name, false, "")
+		}
+	}
+	return err
+}
+
+// callWithTimeout calls fn and returns ErrCallTimeout if it doesn't return
+// before the timeout. The call keeps running in the background since
+// drivers can't be cancelled, and no more calls are made while too many
+// abandoned calls are still running. Since an abandoned call can still be
+// using its arguments, objects the caller owns are passed to the driver as
+// copies, which are copied back once the call succeeds.
+func (r *ResilientDriver) callWithTimeout(method string, fn func() error) error {
+	timeout := r.config.CallTimeout
+	if timeout <= 0 {
+		return fn()
+	}
+	if atomic.LoadInt32(r.abandoned) >= maxAbandonedCalls {
+		return &ErrCallTimeout{Method: method, Timeout: timeout}
+	}
+	// state is set to callDone when fn returns, or callAbandoned when it
+	// times out, whichever happens first
+	var state int32
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- fn()
+		if !atomic.CompareAndSwapInt32(&state, callRunning, callDone) {
+			atomic.AddInt32(r.abandoned, -1)
+		}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errChan:
+		return err
+	case <-timer.C:
+		if !atomic.CompareAndSwapInt32(&state, callRunning, callAbandoned) {
+			return <-errChan
+		}
+		atomic.AddInt32(r.abandoned, 1)
+		return &ErrCallTimeout{Method: method, Timeout: timeout}
+	}
+}
+
+// isCallTimeout returns true if the call was abandoned. The results of the
+// call mustn't be used since the driver can still be writing them.
+func isCallTimeout(err error) bool {
+	_, ok := err.(*ErrCallTimeout)
+	return ok
+}
+
+// transportErrors are messages of errors returned when the driver can't be
+// reached. Drivers don't return typed errors for these.
+var transportErrors = []string{
+	"connection refused",
+	"connection reset",
+	"no such host",
+	"i/o timeout",
+	"transport is closing",
+	"broken pipe",
+	"server is unavailable",
+	"context deadline exceeded",
+}
+
+// isTransportError returns true if the error means that the driver couldn't
+// be reached or didn't respond, as opposed to the driver failing the call
+func isTransportError(err error) bool {
+	switch err.(type) {
+	case *ErrCallTimeout:
+		return true
+	case net.Error:
+		return true
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.OK && s.Code() != codes.Unknown {
+		return s.Code() == codes.Unavailable || s.Code() == codes.DeadlineExceeded
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transportErr := range transportErrors {
+		if strings.Contains(msg, transportErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPermanentDriverError returns true for errors that are expected from the
+// driver and won't change when retried
+func isPermanentDriverError(err error) bool {
+	switch err.(type) {
+	case *errors.ErrNotFound,
+		*errors.ErrNotSupported,
+		*errors.ErrNotImplemented,
+		*ErrPVCPending,
+		*ErrStorageProviderBusy,
+		*ErrBackupExists:
+		return true
+	}
+	return false
+}
+
+// circuitBreaker tracks consecutive failures of a driver
+type circuitBreaker struct {
+	sync.Mutex
+	failures  int
+	open      bool
+	openedAt  time.Time
+	trialCall bool
+	reason    string
+}
+
+// allow returns false if calls shouldn't be made to the driver. Once the
+// breaker has been open for openDuration a single trial call is allowed.
+func (b *circuitBreaker) allow(openDuration time.Duration) (bool, string) {
+	b.Lock()
+	defer b.Unlock()
+	if !b.open {
+		return true, ""
+	}
+	if !b.trialCall && time.Since(b.openedAt) >= openDuration {
+		b.trialCall = true
+		return true, ""
+	}
+	return false, b.reason
+}
+
+// failure records a failed call and returns true if the breaker was opened
+func (b *circuitBreaker) failure(err error, threshold int) bool {
+	b.Lock()
+	defer b.Unlock()
+	b.failures++
+	b.reason = err.Error()
+	if b.open {
+		// The trial call failed, wait again before the next one
+		b.openedAt = time.Now()
+		b.trialCall = false
+		return false
+	}
+	if b.failures >= threshold {
+		b.open = true
+		b.openedAt = time.Now()
+		b.trialCall = false
+		return true
+	}
+	return false
+}
+
+// success records a successful call and returns true if the breaker was
+// closed
+func (b *circuitBreaker) success() bool {
+	b.Lock()
+	defer b.Unlock()
+	b.failures = 0
+	if b.open {
+		b.open = false
+		b.trialCall = false
+		b.reason = ""
+		return true
+	}
+	return false
+}
+
+// skip lets the next trial call through if the call that was let through
+// didn't say whether the driver has recovered
+func (b *circuitBreaker) skip() {
+	b.Lock()
+	defer b.Unlock()
+	b.trialCall = false
+}
+
+func (b *circuitBreaker) state() (bool, string) {
+	b.Lock()
+	defer b.Unlock()
+	return b.open, b.reason
+}
+
+// InspectVolume calls the driver with retries
+func (r *ResilientDriver) InspectVolume(volumeID string) (*Info, error) {
+	var info *Info
+	err := r.read("InspectVolume", func() error {
+		var err error
+		info, err = r.Driver.InspectVolume(volumeID)
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, err
+	}
+	return info, err
+}
+
+// GetNodes calls the driver with retries
+func (r *ResilientDriver) GetNodes() ([]*NodeInfo, error) {
+	var nodes []*NodeInfo
+	err := r.read("GetNodes", func() error {
+		var err error
+		nodes, err = r.Driver.GetNodes()
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, err
+	}
+	return nodes, err
+}
+
+// InspectNode calls the driver with retries
+func (r *ResilientDriver) InspectNode(id string) (*NodeInfo, error) {
+	var node *NodeInfo
+	err := r.read("InspectNode", func() error {
+		var err error
+		node, err = r.Driver.InspectNode(id)
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, err
+	}
+	return node, err
+}
+
+// GetPodVolumes calls the driver with retries
+func (r *ResilientDriver) GetPodVolumes(podSpec *v1.PodSpec, namespace string, includePendingWFFC bool) ([]*Info, []*Info, error) {
+	var volumes, pendingVolumes []*Info
+	podSpecCopy := podSpec.DeepCopy()
+	err := r.read("GetPodVolumes", func() error {
+		var err error
+		volumes, pendingVolumes, err = r.Driver.GetPodVolumes(podSpecCopy, namespace, includePendingWFFC)
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, nil, err
+	}
+	return volumes, pendingVolumes, err
+}
+
+// GetVolumeClaimTemplates calls the driver with retries
+func (r *ResilientDriver) GetVolumeClaimTemplates(templates []v1.PersistentVolumeClaim) ([]v1.PersistentVolumeClaim, error) {
+	var claims []v1.PersistentVolumeClaim
+	templatesCopy := make([]v1.PersistentVolumeClaim, len(templates))
+	for i := range templates {
+		templates[i].DeepCopyInto(&templatesCopy[i])
+	}
+	err := r.read("GetVolumeClaimTemplates", func() error {
+		var err error
+		claims, err = r.Driver.GetVolumeClaimTemplates(templatesCopy)
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, err
+	}
+	return claims, err
+}
+
+// GetSnapshotType calls the driver
+func (r *ResilientDriver) GetSnapshotType(snap *snapv1.VolumeSnapshot) (string, error) {
+	// Only checks the snapshot spec, so it's not tracked
+	return r.Driver.GetSnapshotType(snap)
+}
+
+// GetPlacementAnnotations calls the driver
+func (r *ResilientDriver) GetPlacementAnnotations(pvc *v1.PersistentVolumeClaim, nodes []*NodeInfo) (map[string]string, error) {
+	// Only maps the nodes to annotations, so it's not tracked
+	return r.Driver.GetPlacementAnnotations(pvc, nodes)
+}
+
+// GetClusterID calls the driver with retries
+func (r *ResilientDriver) GetClusterID() (string, error) {
+	var clusterID string
+	err := r.read("GetClusterID", func() error {
+		var err error
+		clusterID, err = r.Driver.GetClusterID()
+		return err
+	})
+	if isCallTimeout(err) {
+		return "", err
+	}
+	return clusterID, err
+}
+
+// CreateGroupSnapshot calls the driver
+func (r *ResilientDriver) CreateGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	var resp *GroupSnapshotCreateResponse
+	err := r.write("CreateGroupSnapshot", func() error {
+		var err error
+		resp, err = r.Driver.CreateGroupSnapshot(snap)
+		return err
+	})
+	return resp, err
+}
+
+// GetGroupSnapshotStatus calls the driver with retries
+func (r *ResilientDriver) GetGroupSnapshotStatus(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	var resp *GroupSnapshotCreateResponse
+	snapCopy := snap.DeepCopy()
+	err := r.read("GetGroupSnapshotStatus", func() error {
+		var err error
+		resp, err = r.Driver.GetGroupSnapshotStatus(snapCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return resp, err
+	}
+	*snap = *snapCopy
+	return resp, nil
+}
+
+// GetSnapshotStatuses calls the driver with retries
+func (r *ResilientDriver) GetSnapshotStatuses(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	var resp *GroupSnapshotCreateResponse
+	snapCopy := snap.DeepCopy()
+	err := r.read("GetSnapshotStatuses", func() error {
+		var err error
+		resp, err = r.Driver.GetSnapshotStatuses(snapCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return resp, err
+	}
+	*snap = *snapCopy
+	return resp, nil
+}
+
+// DeleteGroupSnapshot calls the driver
+func (r *ResilientDriver) DeleteGroupSnapshot(snap *storkapi.GroupVolumeSnapshot) error {
+	return r.write("DeleteGroupSnapshot", func() error {
+		return r.Driver.DeleteGroupSnapshot(snap)
+	})
+}
+
+// CreatePair calls the driver
+func (r *ResilientDriver) CreatePair(pair *storkapi.ClusterPair) (string, error) {
+	var id string
+	err := r.write("CreatePair", func() error {
+		var err error
+		id, err = r.Driver.CreatePair(pair)
+		return err
+	})
+	return id, err
+}
+
+// DeletePair calls the driver
+func (r *ResilientDriver) DeletePair(pair *storkapi.ClusterPair) error {
+	return r.write("DeletePair", func() error {
+		return r.Driver.DeletePair(pair)
+	})
+}
+
+// StartMigration calls the driver
+func (r *ResilientDriver) StartMigration(migration *storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
+	var volumes []*storkapi.MigrationVolumeInfo
+	err := r.write("StartMigration", func() error {
+		var err error
+		volumes, err = r.Driver.StartMigration(migration)
+		return err
+	})
+	return volumes, err
+}
+
+// GetMigrationStatus calls the driver with retries
+func (r *ResilientDriver) GetMigrationStatus(migration *storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
+	var volumes []*storkapi.MigrationVolumeInfo
+	migrationCopy := migration.DeepCopy()
+	err := r.read("GetMigrationStatus", func() error {
+		var err error
+		volumes, err = r.Driver.GetMigrationStatus(migrationCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return volumes, err
+	}
+	*migration = *migrationCopy
+	return volumes, nil
+}
+
+// GetMigrationStatuses calls the driver with retries
+func (r *ResilientDriver) GetMigrationStatuses(
+	migration *storkapi.Migration,
+	volumes []*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	var updated []*storkapi.MigrationVolumeInfo
+	// The volumes are usually in the status of the migration too, so only
+	// the volumes are copied back to keep the ones the caller has updated
+	migrationCopy := migration.DeepCopy()
+	volumesCopy := make([]*storkapi.MigrationVolumeInfo, len(volumes))
+	for i, vInfo := range volumes {
+		volumesCopy[i] = vInfo.DeepCopy()
+	}
+	err := r.read("GetMigrationStatuses", func() error {
+		var err error
+		updated, err = r.Driver.GetMigrationStatuses(migrationCopy, volumesCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return updated, err
+	}
+	copied := make(map[*storkapi.MigrationVolumeInfo]*storkapi.MigrationVolumeInfo)
+	for i, vInfo := range volumes {
+		*vInfo = *volumesCopy[i]
+		copied[volumesCopy[i]] = vInfo
+	}
+	for i, vInfo := range updated {
+		if original, ok := copied[vInfo]; ok {
+			updated[i] = original
+		}
+	}
+	return updated, nil
+}
+
+// CancelMigration calls the driver
+func (r *ResilientDriver) CancelMigration(migration *storkapi.Migration) error {
+	return r.write("CancelMigration", func() error {
+		return r.Driver.CancelMigration(migration)
+	})
+}
+
+// UpdateMigratedPersistentVolumeSpec calls the driver
+func (r *ResilientDriver) UpdateMigratedPersistentVolumeSpec(
+	pv *v1.PersistentVolume,
+	volumeInfo *storkapi.ApplicationRestoreVolumeInfo,
+) (*v1.PersistentVolume, error) {
+	var updatedPV *v1.PersistentVolume
+	err := r.write("UpdateMigratedPersistentVolumeSpec", func() error {
+		var err error
+		updatedPV, err = r.Driver.UpdateMigratedPersistentVolumeSpec(pv, volumeInfo)
+		return err
+	})
+	return updatedPV, err
+}
+
+// GetClusterDomains calls the driver with retries
+func (r *ResilientDriver) GetClusterDomains() (*storkapi.ClusterDomains, error) {
+	var domains *storkapi.ClusterDomains
+	err := r.read("GetClusterDomains", func() error {
+		var err error
+		domains, err = r.Driver.GetClusterDomains()
+		return err
+	})
+	if isCallTimeout(err) {
+		return nil, err
+	}
+	return domains, err
+}
+
+// ActivateClusterDomain calls the driver
+func (r *ResilientDriver) ActivateClusterDomain(update *storkapi.ClusterDomainUpdate) error {
+	return r.write("ActivateClusterDomain", func() error {
+		return r.Driver.ActivateClusterDomain(update)
+	})
+}
+
+// DeactivateClusterDomain calls the driver
+func (r *ResilientDriver) DeactivateClusterDomain(update *storkapi.ClusterDomainUpdate) error {
+	return r.write("DeactivateClusterDomain", func() error {
+		return r.Driver.DeactivateClusterDomain(update)
+	})
+}
+
+// StartBackup calls the driver
+func (r *ResilientDriver) StartBackup(
+	backup *storkapi.ApplicationBackup,
+	pvcs []v1.PersistentVolumeClaim,
+) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
+	var volumes []*storkapi.ApplicationBackupVolumeInfo
+	err := r.write("StartBackup", func() error {
+		var err error
+		volumes, err = r.Driver.StartBackup(backup, pvcs)
+		return err
+	})
+	return volumes, err
+}
+
+// GetBackupStatus calls the driver with retries
+func (r *ResilientDriver) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
+	var volumes []*storkapi.ApplicationBackupVolumeInfo
+	backupCopy := backup.DeepCopy()
+	err := r.read("GetBackupStatus", func() error {
+		var err error
+		volumes, err = r.Driver.GetBackupStatus(backupCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return volumes, err
+	}
+	*backup = *backupCopy
+	return volumes, nil
+}
+
+// CancelBackup calls the driver
+func (r *ResilientDriver) CancelBackup(backup *storkapi.ApplicationBackup) error {
+	return r.write("CancelBackup", func() error {
+		return r.Driver.CancelBackup(backup)
+	})
+}
+
+// CleanupBackupResources calls the driver
+func (r *ResilientDriver) CleanupBackupResources(backup *storkapi.ApplicationBackup) error {
+	return r.write("CleanupBackupResources", func() error {
+		return r.Driver.CleanupBackupResources(backup)
+	})
+}
+
+// DeleteBackup calls the driver
+func (r *ResilientDriver) DeleteBackup(backup *storkapi.ApplicationBackup) (bool, error) {
+	var deleted bool
+	err := r.write("DeleteBackup", func() error {
+		var err error
+		deleted, err = r.Driver.DeleteBackup(backup)
+		return err
+	})
+	return deleted, err
+}
+
+// GetPreRestoreResources calls the driver with retries
+func (r *ResilientDriver) GetPreRestoreResources(
+	backup *storkapi.ApplicationBackup,
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, error) {
+	var resources []runtime.Unstructured
+	backupCopy := backup.DeepCopy()
+	restoreCopy := restore.DeepCopy()
+	objectsCopy := make([]runtime.Unstructured, len(objects))
+	for i, object := range objects {
+		objectsCopy[i] = object.DeepCopyObject().(runtime.Unstructured)
+	}
+	err := r.read("GetPreRestoreResources", func() error {
+		var err error
+		resources, err = r.Driver.GetPreRestoreResources(backupCopy, restoreCopy, objectsCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return resources, err
+	}
+	*backup = *backupCopy
+	*restore = *restoreCopy
+	for i, object := range objects {
+		object.SetUnstructuredContent(objectsCopy[i].UnstructuredContent())
+	}
+	return resources, nil
+}
+
+// StartRestore calls the driver
+func (r *ResilientDriver) StartRestore(
+	restore *storkapi.ApplicationRestore,
+	volumeBackupInfos []*storkapi.ApplicationBackupVolumeInfo,
+	preRestoreObjects []runtime.Unstructured,
+) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	var volumes []*storkapi.ApplicationRestoreVolumeInfo
+	err := r.write("StartRestore", func() error {
+		var err error
+		volumes, err = r.Driver.StartRestore(restore, volumeBackupInfos, preRestoreObjects)
+		return err
+	})
+	return volumes, err
+}
+
+// GetRestoreStatus calls the driver with retries
+func (r *ResilientDriver) GetRestoreStatus(restore *storkapi.ApplicationRestore) ([]*storkapi.ApplicationRestoreVolumeInfo, error) {
+	var volumes []*storkapi.ApplicationRestoreVolumeInfo
+	restoreCopy := restore.DeepCopy()
+	err := r.read("GetRestoreStatus", func() error {
+		var err error
+		volumes, err = r.Driver.GetRestoreStatus(restoreCopy)
+		return err
+	})
+	if err != nil {
+		if isCallTimeout(err) {
+			return nil, err
+		}
+		return volumes, err
+	}
+	*restore = *restoreCopy
+	return volumes, nil
+}
+
+// CancelRestore calls the driver
+func (r *ResilientDriver) CancelRestore(restore *storkapi.ApplicationRestore) error {
+	return r.write("CancelRestore", func() error {
+		return r.Driver.CancelRestore(restore)
+	})
+}
+
+// CleanupRestoreResources calls the driver
+func (r *ResilientDriver) CleanupRestoreResources(restore *storkapi.ApplicationRestore) error {
+	return r.write("CleanupRestoreResources", func() error {
+		return r.Driver.CleanupRestoreResources(restore)
+	})
+}
+
+// CreateVolumeClones calls the driver
+func (r *ResilientDriver) CreateVolumeClones(clone *storkapi.ApplicationClone) error {
+	return r.write("CreateVolumeClones", func() error {
+		return r.Driver.CreateVolumeClones(clone)
+	})
+}
+
+// StartVolumeSnapshotRestore calls the driver
+func (r *ResilientDriver) StartVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	return r.write("StartVolumeSnapshotRestore", func() error {
+		return r.Driver.StartVolumeSnapshotRestore(snapRestore)
+	})
+}
+
+// CompleteVolumeSnapshotRestore calls the driver
+func (r *ResilientDriver) CompleteVolumeSnapshotRestore(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	return r.write("CompleteVolumeSnapshotRestore", func() error {
+		return r.Driver.CompleteVolumeSnapshotRestore(snapRestore)
+	})
+}
+
+// GetVolumeSnapshotRestoreStatus calls the driver with retries
+func (r *ResilientDriver) GetVolumeSnapshotRestoreStatus(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	snapRestoreCopy := snapRestore.DeepCopy()
+	err := r.read("GetVolumeSnapshotRestoreStatus", func() error {
+		return r.Driver.GetVolumeSnapshotRestoreStatus(snapRestoreCopy)
+	})
+	if err != nil {
+		return err
+	}
+	*snapRestore = *snapRestoreCopy
+	return nil
+}
+
+// CleanupSnapshotRestoreObjects calls the driver
+func (r *ResilientDriver) CleanupSnapshotRestoreObjects(snapRestore *storkapi.VolumeSnapshotRestore) error {
+	return r.write("CleanupSnapshotRestoreObjects", func() error {
+		return r.Driver.CleanupSnapshotRestoreObjects(snapRestore)
+	})
+}
+
+// QuiesceVolume calls the driver
+func (r *ResilientDriver) QuiesceVolume(volumeID string, timeout time.Duration, quiesceID string) error {
+	return r.write("QuiesceVolume", func() error {
+		return r.Driver.QuiesceVolume(volumeID, timeout, quiesceID)
+	})
+}
+
+// UnquiesceVolume calls the driver
+func (r *ResilientDriver) UnquiesceVolume(volumeID string) error {
+	return r.write("UnquiesceVolume", func() error {
+		return r.Driver.UnquiesceVolume(volumeID)
+	})
+}
@@ -0,0 +1,275 @@
+//go:build unittest
+// +build unittest
+
+package volume
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver fails calls until failures reaches 0
+type fakeDriver struct {
+	Driver
+	failures int
+	err      error
+	calls    int
+	delay    time.Duration
+}
+
+func (f *fakeDriver) InspectVolume(volumeID string) (*Info, error) {
+	f.calls++
+	time.Sleep(f.delay)
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	return &Info{VolumeID: volumeID}, nil
+}
+
+func (f *fakeDriver) StartMigration(migration *storkapi.Migration) ([]*storkapi.MigrationVolumeInfo, error) {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	return nil, nil
+}
+
+func (f *fakeDriver) GetMigrationStatuses(
+	migration *storkapi.Migration,
+	volumes []*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return nil, f.err
+	}
+	for _, vInfo := range volumes {
+		vInfo.Status = storkapi.MigrationStatusSuccessful
+	}
+	return volumes, nil
+}
+
+func (f *fakeDriver) GetBackupStatus(backup *storkapi.ApplicationBackup) ([]*storkapi.ApplicationBackupVolumeInfo, error) {
+	time.Sleep(f.delay)
+	for _, vInfo := range backup.Status.Volumes {
+		vInfo.Status = storkapi.ApplicationBackupStatusSuccessful
+	}
+	return backup.Status.Volumes, nil
+}
+
+func testResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{
+		MaxRetries:       2,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       2 * time.Millisecond,
+		CallTimeout:      time.Second,
+		FailureThreshold: 3,
+		OpenDuration:     50 * time.Millisecond,
+	}
+}
+
+func TestResilientDriver(t *testing.T) {
+	t.Run("retryTest", retryTest)
+	t.Run("permanentErrorTest", permanentErrorTest)
+	t.Run("timeoutTest", timeoutTest)
+	t.Run("circuitBreakerTest", circuitBreakerTest)
+	t.Run("writeFailureTest", writeFailureTest)
+	t.Run("withoutRetriesTest", withoutRetriesTest)
+	t.Run("batchStatusRetryTest", batchStatusRetryTest)
+	t.Run("abandonedUpdateTest", abandonedUpdateTest)
+}
+
+func retryTest(t *testing.T) {
+	fake := &fakeDriver{failures: 2, err: fmt.Errorf("dial tcp: connection refused")}
+	r := NewResilientDriver("fake", fake, testResilienceConfig())
+	info, err := r.InspectVolume("vol1")
+	require.NoError(t, err, "Error inspecting volume")
+	require.Equal(t, "vol1", info.VolumeID)
+	require.Equal(t, 3, fake.calls, "Call should have been retried")
+
+	// Errors returned by the driver itself aren't retried
+	fake = &fakeDriver{failures: 1, err: fmt.Errorf("volume is busy")}
+	r = NewResilientDriver("fake", fake, testResilienceConfig())
+	_, err = r.InspectVolume("vol1")
+	require.Error(t, err, "Expected error inspecting volume")
+	require.Equal(t, 1, fake.calls, "Call shouldn't have been retried")
+
+	// Calls that change state aren't retried
+	fake = &fakeDriver{failures: 1, err: fmt.Errorf("dial tcp: connection refused")}
+	r = NewResilientDriver("fake", fake, testResilienceConfig())
+	_, err = r.StartMigration(&storkapi.Migration{})
+	require.Error(t, err, "Expected error starting migration")
+	require.Equal(t, 1, fake.calls, "Call shouldn't have been retried")
+}
+
+func permanentErrorTest(t *testing.T) {
+	fake := &fakeDriver{failures: 10, err: &errors.ErrNotFound{ID: "vol1", Type: "Volume"}}
+	r := NewResilientDriver("fake", fake, testResilienceConfig())
+	for i := 0; i < 5; i++ {
+		_, err := r.InspectVolume("vol1")
+		require.Error(t, err, "Expected error inspecting volume")
+		_, ok := err.(*errors.ErrNotFound)
+		require.True(t, ok, "Expected ErrNotFound, got %v", err)
+	}
+	require.Equal(t, 5, fake.calls, "Permanent errors shouldn't be retried")
+	require.False(t, r.IsDegraded(), "Permanent errors shouldn't degrade the driver")
+}
+
+func timeoutTest(t *testing.T) {
+	config := testResilienceConfig()
+	config.CallTimeout = 10 * time.Millisecond
+	fake := &fakeDriver{delay: 100 * time.Millisecond}
+	r := NewResilientDriver("fake", fake, config)
+	_, err := r.InspectVolume("vol1")
+	require.Error(t, err, "Expected call to time out")
+	_, ok := err.(*ErrCallTimeout)
+	require.True(t, ok, "Expected ErrCallTimeout, got %v", err)
+	require.Equal(t, int32(1), atomic.LoadInt32(r.abandoned), "Expected abandoned call")
+
+	// Calls that time out aren't retried while the abandoned call runs,
+	// and the abandoned call is accounted for once it returns
+	time.Sleep(2 * fake.delay)
+	require.Equal(t, int32(0), atomic.LoadInt32(r.abandoned), "Abandoned call should have returned")
+
+	// The driver isn't called while too many abandoned calls are running
+	fake = &fakeDriver{}
+	r = NewResilientDriver("fake", fake, config)
+	atomic.StoreInt32(r.abandoned, maxAbandonedCalls)
+	_, err = r.InspectVolume("vol1")
+	_, ok = err.(*ErrCallTimeout)
+	require.True(t, ok, "Expected ErrCallTimeout, got %v", err)
+	require.Equal(t, 0, fake.calls, "Driver shouldn't have been called")
+}
+
+func circuitBreakerTest(t *testing.T) {
+	config := testResilienceConfig()
+	config.MaxRetries = 0
+	var degraded []bool
+	failedCalls := 0
+	config.OnStateChange = func(driver string, d bool, reason string) {
+		require.Equal(t, "fake", driver)
+		degraded = append(degraded, d)
+	}
+	config.OnCallFailure = func(driver string, method string) {
+		require.Equal(t, "InspectVolume", method)
+		failedCalls++
+	}
+	fake := &fakeDriver{failures: 4, err: fmt.Errorf("dial tcp: connection refused")}
+	r := NewResilientDriver("fake", fake, config)
+	for i := 0; i < 3; i++ {
+		_, err := r.InspectVolume("vol1")
+		require.Error(t, err, "Expected error inspecting volume")
+	}
+	require.True(t, r.IsDegraded(), "Driver should be degraded")
+	require.Equal(t, []bool{true}, degraded)
+	require.Equal(t, 3, failedCalls)
+
+	// Calls fail without calling the driver while degraded
+	_, err := r.InspectVolume("vol1")
+	_, ok := err.(*ErrDriverDegraded)
+	require.True(t, ok, "Expected ErrDriverDegraded, got %v", err)
+	require.Equal(t, 3, fake.calls)
+
+	// The trial call fails and the driver stays degraded
+	time.Sleep(config.OpenDuration)
+	_, err = r.InspectVolume("vol1")
+	require.Error(t, err, "Expected error inspecting volume")
+	require.Equal(t, 4, fake.calls)
+	require.True(t, r.IsDegraded(), "Driver should be degraded")
+	_, err = r.InspectVolume("vol1")
+	_, ok = err.(*ErrDriverDegraded)
+	require.True(t, ok, "Expected ErrDriverDegraded, got %v", err)
+
+	// The next trial call succeeds and the driver recovers
+	time.Sleep(config.OpenDuration)
+	_, err = r.InspectVolume("vol1")
+	require.NoError(t, err, "Error inspecting volume")
+	require.False(t, r.IsDegraded(), "Driver should have recovered")
+	require.Equal(t, []bool{true, false}, degraded)
+
+	// Errors returned by the driver itself don't degrade it
+	fake.failures = 5
+	fake.err = fmt.Errorf("volume is busy")
+	for i := 0; i < 5; i++ {
+		_, err := r.InspectVolume("vol1")
+		require.Error(t, err, "Expected error inspecting volume")
+	}
+	require.False(t, r.IsDegraded(), "Driver errors shouldn't degrade the driver")
+}
+
+func writeFailureTest(t *testing.T) {
+	config := testResilienceConfig()
+	fake := &fakeDriver{failures: 5, err: fmt.Errorf("dial tcp: connection refused")}
+	r := NewResilientDriver("fake", fake, config)
+	for i := 0; i < 5; i++ {
+		_, err := r.StartMigration(&storkapi.Migration{})
+		require.Error(t, err, "Expected error starting migration")
+	}
+	require.False(t, r.IsDegraded(), "Failed writes shouldn't degrade the driver")
+}
+
+func withoutRetriesTest(t *testing.T) {
+	config := testResilienceConfig()
+	config.MaxRetries = 5
+	fake := &fakeDriver{failures: 3, err: fmt.Errorf("dial tcp: connection refused")}
+	r := NewResilientDriver("fake", fake, config)
+	d := WithoutRetries(r, 0)
+	for i := 0; i < 3; i++ {
+		_, err := d.InspectVolume("vol1")
+		require.Error(t, err, "Expected error inspecting volume")
+	}
+	require.Equal(t, 3, fake.calls, "Calls shouldn't have been retried")
+	require.True(t, r.IsDegraded(), "Wrapper should share the breaker of the driver")
+
+	require.Equal(t, fake, WithoutRetries(fake, 0), "Drivers that aren't resilient should be returned as is")
+}
+
+func batchStatusRetryTest(t *testing.T) {
+	fake := &fakeDriver{failures: 1, err: fmt.Errorf("dial tcp: connection refused")}
+	r := NewResilientDriver("fake", fake, testResilienceConfig())
+	volumes := []*storkapi.MigrationVolumeInfo{{Volume: "vol1"}, {Volume: "vol2"}}
+	updated, err := r.GetMigrationStatuses(&storkapi.Migration{}, volumes)
+	require.NoError(t, err, "Error getting migration statuses")
+	require.Equal(t, 2, fake.calls, "Call should have been retried")
+	require.Len(t, updated, 2, "Retry should get the status of all the volumes")
+	for _, vInfo := range volumes {
+		require.Equal(t, storkapi.MigrationStatusSuccessful, vInfo.Status)
+	}
+}
+
+func abandonedUpdateTest(t *testing.T) {
+	config := testResilienceConfig()
+	config.CallTimeout = 10 * time.Millisecond
+	fake := &fakeDriver{delay: 100 * time.Millisecond}
+	r := NewResilientDriver("fake", fake, config)
+	backup := &storkapi.ApplicationBackup{
+		Status: storkapi.ApplicationBackupStatus{
+			Volumes: []*storkapi.ApplicationBackupVolumeInfo{{Volume: "vol1", Status: storkapi.ApplicationBackupStatusInProgress}},
+		},
+	}
+	_, err := r.GetBackupStatus(backup)
+	_, ok := err.(*ErrCallTimeout)
+	require.True(t, ok, "Expected ErrCallTimeout, got %v", err)
+
+	// The caller can keep using the backup while the abandoned call is
+	// still updating its copy
+	backup.Status.Volumes[0].Status = storkapi.ApplicationBackupStatusFailed
+	time.Sleep(2 * fake.delay)
+	require.Equal(t, storkapi.ApplicationBackupStatusFailed, backup.Status.Volumes[0].Status,
+		"Abandoned call shouldn't update the backup")
+
+	// Updates of calls that succeed are copied back
+	r = NewResilientDriver("fake", &fakeDriver{}, config)
+	volumes, err := r.GetBackupStatus(backup)
+	require.NoError(t, err, "Error getting backup status")
+	require.Equal(t, storkapi.ApplicationBackupStatusSuccessful, backup.Status.Volumes[0].Status)
+	require.Equal(t, backup.Status.Volumes, volumes)
+}
@@ -5,11 +5,9 @@ import (
 	"net"
 	"regexp"
 	"strings"
+	"time"
 
 	aws_sdk "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
-	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
@@ -151,6 +149,16 @@ type Driver interface {
 	ClonePluginInterface
 	// SnapshotRestorePluginInterface Interface to do in-place restore of volumes
 	SnapshotRestorePluginInterface
+	// QuiescePluginInterface Interface to quiesce IO on volumes
+	QuiescePluginInterface
+	// PlacementPluginInterface Interface to pass placement hints to the driver
+	PlacementPluginInterface
+	// ProgressPluginInterface Interface to report the progress of volume
+	// operations as it happens
+	ProgressPluginInterface
+	// BatchStatusPluginInterface Interface to get the status of many
+	// volumes at once
+	BatchStatusPluginInterface
 }
 
 // GroupSnapshotCreateResponse is the response for the group snapshot operation
@@ -250,6 +258,51 @@ type ClonePluginInterface interface {
 	CreateVolumeClones(*storkapi.ApplicationClone) error
 }
 
+// QuiescePluginInterface Interface to quiesce IO on volumes before they are
+// snapshotted
+type QuiescePluginInterface interface {
+	// QuiesceVolume flushes pending writes and blocks IO on the volume until
+	// UnquiesceVolume is called or the timeout expires
+	QuiesceVolume(volumeID string, timeout time.Duration, quiesceID string) error
+	// UnquiesceVolume resumes IO on a quiesced volume
+	UnquiesceVolume(volumeID string) error
+}
+
+// PlacementPluginInterface Interface to pass placement hints to the driver
+// when volumes are provisioned
+type PlacementPluginInterface interface {
+	// GetPlacementAnnotations returns the annotations to add to a PVC so that
+	// the replicas of its volume are created on the given nodes. The nodes
+	// are ordered by preference.
+	GetPlacementAnnotations(pvc *v1.PersistentVolumeClaim, nodes []*NodeInfo) (map[string]string, error)
+}
+
+// ProgressCallback is called by a driver with the kind, namespace and name
+// of a backup, restore or migration whenever the progress of one of its
+// volumes changes
+type ProgressCallback func(kind, namespace, name string)
+
+// ProgressPluginInterface Interface to report the progress of backups,
+// restores and migrations as it happens, so that their status can be updated
+// right away instead of when it's polled next
+type ProgressPluginInterface interface {
+	// WatchProgress starts reporting progress to the callback. The status
+	// of the volumes is still returned by the Get*Status methods.
+	WatchProgress(callback ProgressCallback) error
+}
+
+// BatchStatusPluginInterface Interface to get the status of the volumes of
+// an operation with a single call to the storage, instead of one call per
+// volume
+type BatchStatusPluginInterface interface {
+	// GetSnapshotStatuses returns the status of the snapshots of all the
+	// volumes in the group snapshot
+	GetSnapshotStatuses(snap *storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error)
+	// GetMigrationStatuses updates the status of the given volumes of the
+	// migration in place and returns them
+	GetMigrationStatuses(migration *storkapi.Migration, volumes []*storkapi.MigrationVolumeInfo) ([]*storkapi.MigrationVolumeInfo, error)
+}
+
 // Info Information about a volume
 type Info struct {
 	// VolumeID is a unique identifier for the volume
@@ -323,7 +376,7 @@ func GetDefaultDriverName() string {
 func Get(name string) (Driver, error) {
 	d, ok := volDrivers[name]
 	if ok {
-		return d, nil
+		return getResilientDriver(name, d), nil
 	}
 
 	return nil, &errors.ErrNotFound{
@@ -339,6 +392,16 @@ func GetPVCDriverForBackup(coreOps core.Ops,
 	cmBackupType string,
 	crBackupType string,
 ) (string, error) {
+	// Backups by snapshot reference only record existing CSI snapshots
+	if crBackupType == storkapi.ApplicationBackupSnapshotReference {
+		if d, ok := volDrivers[CSIDriverName]; ok && d.OwnsPVCForBackup(coreOps, pvc, cmBackupType, crBackupType) {
+			return CSIDriverName, nil
+		}
+		return "", &errors.ErrNotSupported{
+			Feature: "VolumeDriver",
+			Reason:  fmt.Sprintf("PVC %v/%v can't be backed up by snapshot reference since it isn't a CSI volume with snapshot support", pvc.Namespace, pvc.Name),
+		}
+	}
 	for _, driverName := range orderedListOfDrivers {
 		d, ok := volDrivers[driverName]
 		if !ok {
@@ -562,6 +625,76 @@ func (s *SnapshotRestoreNotSupported) CleanupSnapshotRestoreObjects(*storkapi.Vo
 	return &errors.ErrNotImplemented{}
 }
 
+// QuiesceNotSupported to be used by drivers that can't quiesce IO on volumes
+type QuiesceNotSupported struct{}
+
+// QuiesceVolume returns ErrNotSupported
+func (q *QuiesceNotSupported) QuiesceVolume(string, time.Duration, string) error {
+	return &errors.ErrNotSupported{}
+}
+
+// UnquiesceVolume returns ErrNotSupported
+func (q *QuiesceNotSupported) UnquiesceVolume(string) error {
+	return &errors.ErrNotSupported{}
+}
+
+func (q *QuiesceNotSupported) quiesceNotSupported() {}
+
+// QuiesceCapability is implemented by drivers that wrap other drivers, so
+// that the drivers they wrap are checked for quiesce support instead of the
+// wrapper
+type QuiesceCapability interface {
+	// SupportsQuiesce returns true if the driver handling the PVC can
+	// quiesce IO on its volume. If no PVC is given, it returns true if any
+	// of the wrapped drivers can.
+	SupportsQuiesce(pvc *v1.PersistentVolumeClaim) bool
+}
+
+// IsQuiesceSupported returns true if the driver can quiesce IO on volumes.
+// If a PVC is given, the driver handling the PVC is checked.
+func IsQuiesceSupported(d Driver, pvc *v1.PersistentVolumeClaim) bool {
+	if c, ok := d.(QuiesceCapability); ok {
+		return c.SupportsQuiesce(pvc)
+	}
+	_, notSupported := d.(interface{ quiesceNotSupported() })
+	return !notSupported
+}
+
+// PlacementNotSupported to be used by drivers that don't take placement
+// hints when volumes are provisioned
+type PlacementNotSupported struct{}
+
+// GetPlacementAnnotations returns ErrNotSupported
+func (p *PlacementNotSupported) GetPlacementAnnotations(*v1.PersistentVolumeClaim, []*NodeInfo) (map[string]string, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+// ProgressNotSupported to be used by drivers that can't report the progress
+// of volume operations
+type ProgressNotSupported struct{}
+
+// WatchProgress returns ErrNotSupported
+func (p *ProgressNotSupported) WatchProgress(ProgressCallback) error {
+	return &errors.ErrNotSupported{}
+}
+
+// BatchStatusNotSupported to be used by drivers that can only get the status
+// of volumes one at a time
+type BatchStatusNotSupported struct{}
+
+// GetSnapshotStatuses returns ErrNotSupported
+func (b *BatchStatusNotSupported) GetSnapshotStatuses(*storkapi.GroupVolumeSnapshot) (*GroupSnapshotCreateResponse, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
+// GetMigrationStatuses returns ErrNotSupported
+func (b *BatchStatusNotSupported) GetMigrationStatuses(
+	*storkapi.Migration,
+	[]*storkapi.MigrationVolumeInfo,
+) ([]*storkapi.MigrationVolumeInfo, error) {
+	return nil, &errors.ErrNotSupported{}
+}
+
 // IsNodeMatch There are a couple of things that need to be checked to see if the driver
 // node matched the k8s node since different k8s installs set the node name,
 // hostname and IPs differently
@@ -871,11 +1004,28 @@ func GetEBSVolume(volumeID string, filters map[string]string, client *ec2.EC2) (
 		input.Filters = getFiltersFromMap(filters)
 	}
 
-	output, err := client.DescribeVolumes(input)
+	describe := func() (interface{}, error) {
+		return client.DescribeVolumes(input)
+	}
+	throttle := GetCloudAPIThrottle(AWSDriverName)
+	var result interface{}
+	var err error
+	// Only cache lookups by ID, lookups by tags are used to check if a
+	// volume has already been created
+	if volumeID != "" && len(filters) == 0 {
+		result, err = throttle.CachedCall("volume/"+volumeID, describe)
+	} else {
+		err = throttle.Call(func() error {
+			var callErr error
+			result, callErr = describe()
+			return callErr
+		})
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	output := result.(*ec2.DescribeVolumesOutput)
 	if len(output.Volumes) != 1 {
 		return nil, fmt.Errorf("received %v volumes for %v", len(output.Volumes), volumeID)
 	}
@@ -910,14 +1060,10 @@ func GetAWSClient() (*ec2.EC2, error) {
 	if err != nil {
 		return nil, err
 	}
-	creds := credentials.NewChainCredentials(
-		[]credentials.Provider{
-			&credentials.EnvProvider{},
-			&ec2rolecreds.EC2RoleProvider{
-				Client: ec2metadata.New(s),
-			},
-			&credentials.SharedCredentialsProvider{},
-		})
+	creds, err := GetAWSCredentials(s)
+	if err != nil {
+		return nil, err
+	}
 	metadata, err := cloud.NewMetadata()
 	if err != nil {
 		return nil, err
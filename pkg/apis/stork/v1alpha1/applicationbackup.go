@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -11,6 +12,11 @@ const (
 	ApplicationBackupResourcePlural = "applicationbackups"
 	// ApplicationBackupGeneric for using generic driver for backups/restore
 	ApplicationBackupGeneric = "Generic"
+	// ApplicationBackupSnapshotReference for backups that only record the
+	// latest existing CSI snapshot of each volume instead of copying the data,
+	// for storage backends that already replicate the snapshots. Volumes are
+	// restored by cloning from the recorded snapshot handles.
+	ApplicationBackupSnapshotReference = "SnapshotReference"
 	// GenericDriver is name for generic driver
 	GenericDriver = "kdmp"
 )
@@ -40,6 +46,54 @@ type ApplicationBackupSpec struct {
 	IncludeResources []ObjectInfo      `json:"includeResources"`
 	ResourceTypes    []string          `json:"resourceTypes"`
 	BackupType       string            `json:"backupType"`
+	// SecretTypes filters the Secrets to be backed up by their type
+	SecretTypes *SecretTypeFilter `json:"secretTypes,omitempty"`
+	// BackupLocationNamespace is the namespace of the BackupLocation. Defaults
+	// to the namespace of the backup. The BackupLocation needs to allow the
+	// namespace of the backup if it is in a different namespace.
+	BackupLocationNamespace string `json:"backupLocationNamespace,omitempty"`
+	// OCIExport pushes the backup to a container registry as an OCI artifact
+	// once it is complete
+	OCIExport *OCIExportSpec `json:"ociExport,omitempty"`
+	// ResumeFrom is the name of a backup in the same namespace that failed
+	// part way. The volumes it backed up successfully are moved to this
+	// backup and only the remaining volumes are backed up again. Volumes
+	// backed up by the csi and kdmp drivers are always backed up again
+	// since their backups belong to the backup that took them.
+	ResumeFrom string `json:"resumeFrom,omitempty"`
+	// FailOnPartial marks the backup as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be backed up
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// backup is deleted once it has finished. The default from the stork
+	// configuration doesn't apply to backups, so they are kept if it isn't
+	// set. The data in the backup location
+	// is deleted along with it if the reclaim policy is Delete. Backups
+	// synced from a BackupLocation don't expire.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// IncludeVolumeSnapshots backs up the VolumeSnapshots created by the
+	// application in the namespaces along with their VolumeSnapshotContents.
+	// They are restored as references to the same snapshots on the storage
+	// if VolumeSnapshot is included in the optional resource types of the
+	// restore.
+	IncludeVolumeSnapshots bool `json:"includeVolumeSnapshots,omitempty"`
+}
+
+// OCIExportSpec configures the export of a backup as an OCI artifact, so that
+// it can be transferred between sites using registry mirroring
+type OCIExportSpec struct {
+	// Repository to push the artifact to, e.g. registry.example.com/backups/app.
+	// The artifact is tagged with the name of the backup if the repository
+	// doesn't include a tag.
+	Repository string `json:"repository"`
+	// CredentialsSecret is the name of a kubernetes.io/dockerconfigjson Secret
+	// in the namespace of the backup used to authenticate with the registry
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Insecure allows pushing to registries over plain HTTP
+	Insecure bool `json:"insecure,omitempty"`
+	// MaxObjectSize is the size in bytes above which objects in the backup
+	// location are left out of the artifact. Defaults to 100MiB.
+	MaxObjectSize int64 `json:"maxObjectSize,omitempty"`
 }
 
 // ApplicationBackupReclaimPolicyType is the reclaim policy for the application backup
@@ -66,6 +120,106 @@ type ApplicationBackupStatus struct {
 	LastUpdateTimestamp metav1.Time                      `json:"lastUpdateTimestamp"`
 	FinishTimestamp     metav1.Time                      `json:"finishTimestamp"`
 	TotalSize           uint64                           `json:"totalSize"`
+	// Checkpoint is set if stork was shut down while the backup was in
+	// progress. It is cleared once the backup is resumed.
+	Checkpoint *OperationCheckpoint `json:"checkpoint,omitempty"`
+	// ResourceCheckpoints are the batches of namespaces whose resources have
+	// already been collected and uploaded to the backup location
+	ResourceCheckpoints []*ApplicationBackupResourceCheckpoint `json:"resourceCheckpoints,omitempty"`
+	// UploadedObjects are the objects that have already been uploaded to the
+	// backup location
+	UploadedObjects []string `json:"uploadedObjects,omitempty"`
+	// OCIArtifact is the reference, including the digest, of the OCI
+	// artifact the backup was exported to
+	OCIArtifact string `json:"ociArtifact,omitempty"`
+	// Estimate is the estimated size and duration of the backup calculated
+	// before any data is moved
+	Estimate *ApplicationBackupEstimate `json:"estimate,omitempty"`
+	// EventHistory holds the most recent events recorded for the backup
+	EventHistory []*EventHistoryEntry `json:"eventHistory,omitempty"`
+	// References are the restores created from the backup
+	References []ObjectReference `json:"references,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be backed up
+	FailedItems []FailedItem `json:"failedItems,omitempty"`
+	// Conditions holds the Completed condition, which is set once the backup
+	// has finished
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the backup that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CleanupError is the last error hit while cleaning up the backup after it
+	// was deleted. The backup is held back by the cleanup finalizer until the
+	// cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
+}
+
+// ApplicationBackupEstimate is the estimated size and duration of a backup
+type ApplicationBackupEstimate struct {
+	// TotalBytes is the estimated number of bytes to be transferred for the
+	// volumes
+	TotalBytes uint64 `json:"totalBytes"`
+	// Objects is the number of resources that will be backed up
+	Objects int `json:"objects"`
+	// Duration is the estimated time to transfer the data of the volumes
+	Duration metav1.Duration `json:"duration"`
+	// Volumes has the estimated size of each volume
+	Volumes []*ApplicationBackupVolumeEstimate `json:"volumes,omitempty"`
+	// Timestamp when the estimate was calculated
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// ApplicationBackupVolumeEstimate is the estimated size of a volume
+type ApplicationBackupVolumeEstimate struct {
+	Namespace             string `json:"namespace"`
+	PersistentVolumeClaim string `json:"persistentVolumeClaim"`
+	UsedBytes             uint64 `json:"usedBytes"`
+	// Source of the size. Either Kubelet if the used bytes were reported by
+	// kubelet, or Capacity if the volume isn't mounted and the capacity of
+	// the PVC was used instead.
+	Source string `json:"source"`
+}
+
+// ApplicationBackupResourceCheckpoint records a batch of namespaces whose
+// resources have been collected and uploaded to the backup location
+type ApplicationBackupResourceCheckpoint struct {
+	// Namespaces in the batch
+	Namespaces []string `json:"namespaces"`
+	// ObjectName is the name of the object in the backup location holding
+	// the resources collected for the batch
+	ObjectName string `json:"objectName"`
+}
+
+// OperationCheckpoint records where a long running operation was when stork
+// was shut down, so that the next stork instance can resume from there
+type OperationCheckpoint struct {
+	// Stage of the operation when the checkpoint was taken
+	Stage string `json:"stage"`
+	// Owner is the stork instance that took the checkpoint
+	Owner string `json:"owner"`
+	// Timestamp when the checkpoint was taken
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+// EventHistoryEntry is an event recorded for a long running operation. The
+// history is kept in the status of the object since Kubernetes deletes events
+// after an hour.
+type EventHistoryEntry struct {
+	// Timestamp is the last time the event was seen
+	Timestamp metav1.Time `json:"timestamp"`
+	Type      string      `json:"type"`
+	Reason    string      `json:"reason"`
+	Message   string      `json:"message"`
+	// Count is the number of consecutive times the event was seen
+	Count int `json:"count"`
+}
+
+// ObjectReference is a back-link to a stork object that references this
+// object, so that the relationships between the objects can be traversed
+// from either end
+type ObjectReference struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
 }
 
 // ObjectInfo contains info about an object being backed up or restored
@@ -75,6 +229,59 @@ type ObjectInfo struct {
 	metav1.GroupVersionKind `json:",inline"`
 }
 
+// SecretTypeFilter is used to select Secrets by their type, for example
+// Opaque or kubernetes.io/tls. Secrets without a type are treated as Opaque.
+type SecretTypeFilter struct {
+	// IncludeTypes, if set, only selects Secrets of these types
+	IncludeTypes []string `json:"includeTypes,omitempty"`
+	// ExcludeTypes skips Secrets of these types
+	ExcludeTypes []string `json:"excludeTypes,omitempty"`
+}
+
+// JobPolicy decides how Jobs and CronJobs are handled when they are migrated
+// or restored, so that populating a namespace on the destination doesn't
+// start any jobs unexpectedly
+type JobPolicy struct {
+	// IncludeCompletedJobs migrates or restores Jobs that have already
+	// completed or failed. They are skipped by default.
+	IncludeCompletedJobs bool `json:"includeCompletedJobs,omitempty"`
+	// RerunJobs lets Jobs run again on the destination. By default Jobs are
+	// created suspended, so they never run again.
+	RerunJobs bool `json:"rerunJobs,omitempty"`
+	// StartCronJobs keeps the CronJobs scheduled on the destination. By
+	// default CronJobs are suspended until the namespace is activated.
+	StartCronJobs bool `json:"startCronJobs,omitempty"`
+}
+
+// ServiceMeshPolicy decides how the service mesh configuration of the
+// applications is handled when they are migrated or cloned, e.g. to a cluster
+// that doesn't run the mesh, where pods waiting for a sidecar would never
+// start
+type ServiceMeshPolicy struct {
+	// Strip removes the Istio and Linkerd sidecar injection labels and
+	// annotations, manually injected sidecar containers, and skips mesh
+	// specific resources like Sidecars and PeerAuthentications
+	Strip bool `json:"strip,omitempty"`
+	// RevisionMapping maps the Istio revisions in the istio.io/rev labels to
+	// the revisions running on the destination
+	RevisionMapping map[string]string `json:"revisionMapping,omitempty"`
+}
+
+// StorageClassCreationPolicy creates the storage classes of the migrated or
+// restored PVCs that don't exist on the destination from their definitions
+// on the source, so that the PVCs don't fail to be provisioned
+type StorageClassCreationPolicy struct {
+	// ProvisionerMapping maps the provisioners of the source storage classes
+	// to the provisioners of the storage classes created on the destination.
+	// Provisioners that aren't mapped are kept.
+	ProvisionerMapping map[string]string `json:"provisionerMapping,omitempty"`
+	// ParameterMapping changes the parameters of the source storage classes.
+	// The key is either the name of a parameter or <parameter>=<value> to
+	// only change that value. Parameters mapped to an empty value are
+	// dropped.
+	ParameterMapping map[string]string `json:"parameterMapping,omitempty"`
+}
+
 // ApplicationBackupResourceInfo is the info for the backup of a resource
 type ApplicationBackupResourceInfo struct {
 	ObjectInfo `json:",inline"`
@@ -97,6 +304,23 @@ type ApplicationBackupVolumeInfo struct {
 	StorageClass             string                      `json:"storageClass"`
 	Provisioner              string                      `json:"provisioner"`
 	VolumeSnapshot           string                      `json:"volumeSnapshot"`
+	// DataSource is the dataSource of the PVC, e.g. the snapshot it was
+	// created from
+	DataSource *corev1.TypedLocalObjectReference `json:"dataSource,omitempty"`
+	// DataSourceRef is the dataSourceRef of the PVC, e.g. the volume
+	// populator that populated it
+	DataSourceRef *corev1.TypedLocalObjectReference `json:"dataSourceRef,omitempty"`
+	// VolumeAttributes are the driver specific attributes of the volume,
+	// like the replication factor, io_profile and IOPS limits, that are
+	// reapplied when the volume is restored
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
+	// ReplicaNodes are the names of the nodes that held the replicas of the
+	// volume when it was backed up. The volume is restored to the same nodes
+	// if they are still available so that it is local to its workload again.
+	ReplicaNodes []string `json:"replicaNodes,omitempty"`
+	// ResumedFrom is the name of the backup the volume was backed up by if
+	// it was moved to this backup when resuming it
+	ResumedFrom string `json:"resumedFrom,omitempty"`
 }
 
 // ApplicationBackupStatusType is the status of the application backup
@@ -115,6 +339,37 @@ const (
 	ApplicationBackupStatusPartialSuccess ApplicationBackupStatusType = "PartialSuccess"
 	// ApplicationBackupStatusSuccessful for when backup has completed successfully
 	ApplicationBackupStatusSuccessful ApplicationBackupStatusType = "Successful"
+	// ApplicationBackupStatusCancelled for when backup was cancelled while
+	// it was in progress
+	ApplicationBackupStatusCancelled ApplicationBackupStatusType = "Cancelled"
+)
+
+// ApplicationBackupCancelAnnotation can be set to true on an ApplicationBackup
+// that is in progress to cancel it. The data mover jobs and temporary
+// snapshots are cleaned up and the partially uploaded backup is deleted from
+// the BackupLocation.
+const ApplicationBackupCancelAnnotation = "stork.libopenstorage.org/cancel"
+
+// ApplicationBackupResumedByAnnotation is set by stork on a failed
+// ApplicationBackup to the name of the backup that resumed it. The volumes
+// that were backed up successfully are moved to that backup so that they
+// aren't deleted along with the failed backup.
+const ApplicationBackupResumedByAnnotation = "stork.libopenstorage.org/resumed-by"
+
+// ApplicationBackupSyncedFromAnnotation is set by stork to the name of the
+// BackupLocation that an ApplicationBackup was synced from. Synced backups
+// aren't deleted after a TTL since they would be synced again.
+const ApplicationBackupSyncedFromAnnotation = "stork.libopenstorage.org/synced-from"
+
+const (
+	// ApplicationBackupLayoutVersionAnnotation is set by stork to the version
+	// of the layout the backup is stored with in the BackupLocation
+	ApplicationBackupLayoutVersionAnnotation = "stork.libopenstorage.org/backup-layout-version"
+	// ApplicationBackupUpgradeLayoutAnnotation can be set to true on a
+	// completed ApplicationBackup to upgrade the layout of the backup in the
+	// BackupLocation to the latest version. It is removed once the upgrade is
+	// done.
+	ApplicationBackupUpgradeLayoutAnnotation = "stork.libopenstorage.org/upgrade-backup-layout"
 )
 
 // ApplicationBackupStageType is the stage of the backup
@@ -159,3 +414,12 @@ func CreateObjectsMap(
 	}
 	return objectsMap
 }
+
+// GetBackupLocationNamespace returns the namespace of the BackupLocation used
+// by the backup
+func (b *ApplicationBackup) GetBackupLocationNamespace() string {
+	if b.Spec.BackupLocationNamespace != "" {
+		return b.Spec.BackupLocationNamespace
+	}
+	return b.Namespace
+}
@@ -18,6 +18,13 @@ type ApplicationBackupScheduleSpec struct {
 	Suspend            *bool                         `json:"suspend"`
 	ReclaimPolicy      ReclaimPolicyType             `json:"reclaimPolicy"`
 	BackupType         string                        `json:"backupType"`
+	// MissedRunPolicy is the policy for runs missed while stork or the
+	// cluster was down. Runs missed by more than an hour are skipped for
+	// daily, weekly and monthly policies and a single run is triggered for
+	// interval policies if not set.
+	MissedRunPolicy MissedRunPolicyType `json:"missedRunPolicy,omitempty"`
+	// LoadGate defers runs while the application is under heavy load
+	LoadGate *LoadGate `json:"loadGate,omitempty"`
 }
 
 // ApplicationBackupTemplateSpec describes the data a ApplicationBackup should have when created
@@ -29,6 +36,25 @@ type ApplicationBackupTemplateSpec struct {
 // ApplicationBackupScheduleStatus is the status of a applicationbackup schedule
 type ApplicationBackupScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledApplicationBackupStatus `json:"items"`
+	// DeferredSince is the time since which the pending run has been
+	// deferred by the load gate
+	DeferredSince meta.Time `json:"deferredSince,omitempty"`
+	// DeferredReason is the reason the pending run is deferred
+	DeferredReason string `json:"deferredReason,omitempty"`
+	// VolumeStats tracks how much the data of each volume changes between
+	// runs, keyed by <namespace>/<pvc>. It is used to start the backups of
+	// the volumes that take the longest first.
+	VolumeStats map[string]*ScheduledVolumeStats `json:"volumeStats,omitempty"`
+}
+
+// ScheduledVolumeStats is the backup history of a volume backed up by a
+// schedule
+type ScheduledVolumeStats struct {
+	// ChangedBytes is the average size of the data backed up for the volume
+	// by the recent runs
+	ChangedBytes uint64 `json:"changedBytes"`
+	// Runs is the number of runs the average was computed from
+	Runs int `json:"runs"`
 }
 
 // ScheduledApplicationBackupStatus keeps track of the applicationbackup that was triggered by a
@@ -38,6 +64,9 @@ type ScheduledApplicationBackupStatus struct {
 	CreationTimestamp meta.Time                   `json:"creationTimestamp"`
 	FinishTimestamp   meta.Time                   `json:"finishTimestamp"`
 	Status            ApplicationBackupStatusType `json:"status"`
+	// ScheduledTimestamp is the time at which the run was scheduled. It is
+	// only set for runs triggered to catch up for missed runs.
+	ScheduledTimestamp meta.Time `json:"scheduledTimestamp,omitempty"`
 }
 
 // +genclient
@@ -38,6 +38,16 @@ type ApplicationCloneSpec struct {
 	// ReplacePolicy to decide how to react when a object conflict occurs in the cloning process
 	ReplacePolicy                ApplicationCloneReplacePolicyType `json:"replacePolicy"`
 	IncludeOptionalResourceTypes []string                          `json:"includeOptionalResourceTypes"`
+	// ServiceMeshPolicy decides how the service mesh configuration of the
+	// applications is cloned
+	ServiceMeshPolicy *ServiceMeshPolicy `json:"serviceMeshPolicy,omitempty"`
+	// EncryptionSecretMapping maps the secrets holding the encryption keys
+	// of the source volumes to the secrets that should be used for the
+	// cloned volumes. Secrets are given as <namespace>/<name>, or just the
+	// name for secrets in the destination namespace.
+	// The volumes aren't re-encrypted, so the secrets need to hold the same
+	// keys. Only Kubernetes secrets can be mapped.
+	EncryptionSecretMapping map[string]string `json:"encryptionSecretMapping,omitempty"`
 }
 
 // ApplicationCloneStatus defines the status of the clone
@@ -49,6 +59,16 @@ type ApplicationCloneStatus struct {
 	Resources       []*ApplicationCloneResourceInfo `json:"resources"`
 	Volumes         []*ApplicationCloneVolumeInfo   `json:"volumes"`
 	FinishTimestamp meta.Time                       `json:"finishTimestamp"`
+	// Conditions holds the Completed condition, which is set once the clone
+	// has finished
+	Conditions []meta.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the clone that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CleanupError is the last error hit while cleaning up the clone after it
+	// was deleted. The clone is held back by the cleanup finalizer until the
+	// cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // ApplicationCloneResourceInfo is the info for the cloning of a resource
@@ -66,6 +86,9 @@ type ApplicationCloneVolumeInfo struct {
 	CloneVolume           string                     `json:"cloneVolume"`
 	Status                ApplicationCloneStatusType `json:"status"`
 	Reason                string                     `json:"reason"`
+	// EncryptionSecret is the secret, as <namespace>/<name>, holding the
+	// encryption key passed to the driver for the cloned volume
+	EncryptionSecret string `json:"encryptionSecret,omitempty"`
 }
 
 // ApplicationCloneStatusType defines status of the application being cloned
@@ -37,6 +37,10 @@ type ApplicationResource struct {
 	// in addition to parent server
 	// NestedSuspendOptions allow way to suspend such CR server
 	NestedSuspendOptions []SuspendOptions `json:"customSuspendOptions"`
+	// PodTemplatePaths are the paths of the pod templates in the CR, e.g.
+	// spec.template, so that the registries of their images can be mapped
+	// by a RegistryMapping
+	PodTemplatePaths []string `json:"podTemplatePaths,omitempty"`
 }
 
 // SuspendOptions to disable CRD upon migration/restore/clone
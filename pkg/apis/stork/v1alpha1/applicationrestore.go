@@ -1,6 +1,9 @@
 package v1alpha1
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -9,6 +12,8 @@ const (
 	ApplicationRestoreResourceName = "applicationrestore"
 	// ApplicationRestoreResourcePlural is plural for "applicationrestore" resource
 	ApplicationRestoreResourcePlural = "applicationrestores"
+	// DefaultSandboxTTL is the default for SandboxTTL
+	DefaultSandboxTTL = 24 * time.Hour
 )
 
 // +genclient
@@ -31,8 +36,143 @@ type ApplicationRestoreSpec struct {
 	IncludeOptionalResourceTypes []string                            `json:"includeOptionalResourceTypes"`
 	IncludeResources             []ObjectInfo                        `json:"includeResources"`
 	StorageClassMapping          map[string]string                   `json:"storageClassMapping"`
+	// BackupLocationNamespace is the namespace of the BackupLocation. Defaults
+	// to the namespace of the restore. The BackupLocation needs to allow the
+	// namespace of the restore if it is in a different namespace.
+	BackupLocationNamespace string `json:"backupLocationNamespace,omitempty"`
+	// InitContainer is added to the pod template of restored Deployments,
+	// StatefulSets and DaemonSets, e.g. to run schema migrations or fix file
+	// ownership. It is removed once the workload has rolled out successfully,
+	// so it only runs on the first start after the restore.
+	InitContainer *corev1.Container `json:"initContainer,omitempty"`
+	// RepairOwnership runs a job to change the group of the files on volumes
+	// restored by KDMP to the fsGroup of the pods using them
+	RepairOwnership bool `json:"repairOwnership,omitempty"`
+	// Sandbox restores into temporary namespaces created for the restore
+	// instead of the namespaces in NamespaceMapping, e.g. to test that
+	// backups can be restored. The namespaces are isolated from the rest of
+	// the cluster with a NetworkPolicy and are deleted once SandboxTTL has
+	// passed or the restore is deleted. Restores that are still running
+	// when SandboxTTL passes are canceled. Outside the admin namespace only
+	// the namespace of the restore can be restored into a sandbox.
+	Sandbox bool `json:"sandbox,omitempty"`
+	// SandboxTTL is how long the sandbox namespaces are kept after the
+	// restore is created. Defaults to @DefaultSandboxTTL.
+	SandboxTTL *metav1.Duration `json:"sandboxTTL,omitempty"`
+	// VolumeDataSourcePolicy decides how volumes whose PVCs had a data
+	// source or a volume populator when they were backed up are restored.
+	// Defaults to @ApplicationRestoreVolumeDataSourcePolicyRestore.
+	VolumeDataSourcePolicy ApplicationRestoreVolumeDataSourcePolicyType `json:"volumeDataSourcePolicy,omitempty"`
+	// JobPolicy decides how Jobs and CronJobs are restored
+	JobPolicy *JobPolicy `json:"jobPolicy,omitempty"`
+	// VolumeAttributeMapping changes the volume attributes recorded in the
+	// backup before they are reapplied to the restored volumes. The key is
+	// either the name of an attribute, e.g. repl, or <attribute>=<value> to
+	// only change that value. An empty value doesn't reapply the attribute.
+	VolumeAttributeMapping map[string]string `json:"volumeAttributeMapping,omitempty"`
+	// StorageClassCreation, if set, creates the storage classes of the
+	// restored PVCs that don't exist on the destination from the definitions
+	// recorded in the backup, after the StorageClassMapping is applied. Only
+	// allowed for restores in the admin namespace.
+	StorageClassCreation *StorageClassCreationPolicy `json:"storageClassCreation,omitempty"`
+	// FailOnPartial marks the restore as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be restored
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// QuotaPolicy decides what happens when the ResourceQuotas in the
+	// destination namespaces don't have enough headroom for the resources in
+	// the backup. Defaults to @ApplicationRestoreQuotaPolicyIgnore.
+	QuotaPolicy ApplicationRestoreQuotaPolicyType `json:"quotaPolicy,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// restore is deleted once it has finished. The default from the stork
+	// configuration is used if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// ImageCheck, if set, checks that the images of the restored workloads
+	// are available on the destination before anything is restored
+	ImageCheck *ImageCheckPolicy `json:"imageCheck,omitempty"`
+	// RegistryMappingName is the name of a RegistryMapping in the namespace
+	// of the restore used to rewrite the images of the restored workloads.
+	// The images are checked after they are rewritten.
+	RegistryMappingName string `json:"registryMappingName,omitempty"`
+	// CertificatePolicy decides how the TLS certificates issued by
+	// cert-manager are restored
+	CertificatePolicy *CertificatePolicy `json:"certificatePolicy,omitempty"`
+}
+
+// ImageCheckPolicy is the policy for checking that the images of the
+// restored workloads can be pulled on the destination
+type ImageCheckPolicy struct {
+	// QueryRegistry checks the images that haven't been pulled on any node of
+	// the destination with a HEAD request to their registry. Otherwise only
+	// the images already pulled on the nodes are available. Only the
+	// registries allowed in the stork configuration are queried.
+	QueryRegistry bool `json:"queryRegistry,omitempty"`
+	// FailOnMissing fails the restore if images are missing. Otherwise the
+	// missing images are only reported in the status.
+	FailOnMissing bool `json:"failOnMissing,omitempty"`
+}
+
+// CertificatePolicy is the policy for restoring the Secrets of cert-manager
+// Certificates
+type CertificatePolicy struct {
+	// Reissue decides which Secrets of the restored Certificates are skipped
+	// so that cert-manager issues new certificates on the destination instead
+	// of the restored ones being used. Defaults to @CertificateReissueNever.
+	Reissue CertificateReissueType `json:"reissue,omitempty"`
+	// ExpiryThreshold is how long before they expire certificates are
+	// reissued with @CertificateReissueExpired
+	ExpiryThreshold *metav1.Duration `json:"expiryThreshold,omitempty"`
 }
 
+// CertificateReissueType decides when the certificates of restored
+// cert-manager Certificates are reissued
+type CertificateReissueType string
+
+const (
+	// CertificateReissueNever restores the Secrets of the Certificates like
+	// any other Secret
+	CertificateReissueNever CertificateReissueType = "Never"
+	// CertificateReissueAlways doesn't restore the Secrets of the
+	// Certificates, along with their CertificateRequests and ACME Orders and
+	// Challenges, so that all of them are issued again
+	CertificateReissueAlways CertificateReissueType = "Always"
+	// CertificateReissueExpired only skips the Secrets whose certificate has
+	// expired, or expires within the ExpiryThreshold
+	CertificateReissueExpired CertificateReissueType = "Expired"
+)
+
+// ApplicationRestoreQuotaPolicyType is the policy for restoring into
+// namespaces whose ResourceQuotas are too small for the backup
+type ApplicationRestoreQuotaPolicyType string
+
+const (
+	// ApplicationRestoreQuotaPolicyIgnore doesn't check the quotas before
+	// the resources are applied
+	ApplicationRestoreQuotaPolicyIgnore ApplicationRestoreQuotaPolicyType = "Ignore"
+	// ApplicationRestoreQuotaPolicyFail fails the restore before anything is
+	// restored if a quota is too small. The reason lists how much each quota
+	// needs to be raised.
+	ApplicationRestoreQuotaPolicyFail ApplicationRestoreQuotaPolicyType = "Fail"
+	// ApplicationRestoreQuotaPolicyAdjust raises the quotas that are too
+	// small. Only restores created in the admin namespace can adjust quotas,
+	// others fail like with @ApplicationRestoreQuotaPolicyFail.
+	ApplicationRestoreQuotaPolicyAdjust ApplicationRestoreQuotaPolicyType = "Adjust"
+)
+
+// ApplicationRestoreVolumeDataSourcePolicyType is the policy for restoring
+// volumes whose PVCs have a data source
+type ApplicationRestoreVolumeDataSourcePolicyType string
+
+const (
+	// ApplicationRestoreVolumeDataSourcePolicyRestore restores the data
+	// captured in the backup. The data source references are kept on the
+	// restored PVCs.
+	ApplicationRestoreVolumeDataSourcePolicyRestore ApplicationRestoreVolumeDataSourcePolicyType = "Restore"
+	// ApplicationRestoreVolumeDataSourcePolicyRepopulate doesn't restore
+	// the data from the backup. The PVCs are created unbound so that the
+	// volumes are populated again from their data source.
+	ApplicationRestoreVolumeDataSourcePolicyRepopulate ApplicationRestoreVolumeDataSourcePolicyType = "Repopulate"
+)
+
 // ApplicationRestoreReplacePolicyType is the replace policy for the application restore
 // in case there are conflicting resources already present on the cluster
 type ApplicationRestoreReplacePolicyType string
@@ -58,6 +198,34 @@ type ApplicationRestoreStatus struct {
 	FinishTimestamp     metav1.Time                       `json:"finishTimestamp"`
 	LastUpdateTimestamp metav1.Time                       `json:"lastUpdateTimestamp"`
 	TotalSize           uint64                            `json:"totalSize"`
+	// Checkpoint is set if stork was shut down while the restore was in
+	// progress. It is cleared once the restore is resumed.
+	Checkpoint *OperationCheckpoint `json:"checkpoint,omitempty"`
+	// InitContainerRemoved is set once the init container from the spec has
+	// been removed from all the restored workloads
+	InitContainerRemoved bool `json:"initContainerRemoved,omitempty"`
+	// SandboxExpiry is the time after which the sandbox namespaces are
+	// deleted
+	SandboxExpiry *metav1.Time `json:"sandboxExpiry,omitempty"`
+	// SandboxDeleted is set once the sandbox namespaces have been deleted
+	SandboxDeleted bool `json:"sandboxDeleted,omitempty"`
+	// EventHistory holds the most recent events recorded for the restore
+	EventHistory []*EventHistoryEntry `json:"eventHistory,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be restored
+	FailedItems []FailedItem `json:"failedItems,omitempty"`
+	// Conditions holds the Completed condition, which is set once the restore
+	// has finished
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the restore that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// MissingImages are the images of the restored workloads that aren't
+	// available on the destination when the image check is enabled
+	MissingImages []string `json:"missingImages,omitempty"`
+	// CleanupError is the last error hit while cleaning up the restore after
+	// it was deleted. The restore is held back by the cleanup finalizer until
+	// the cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // ApplicationRestoreResourceInfo is the info for the restore of a resource
@@ -80,6 +248,12 @@ type ApplicationRestoreVolumeInfo struct {
 	Reason                   string                       `json:"reason"`
 	TotalSize                uint64                       `json:"totalSize"`
 	Options                  map[string]string            `json:"options"`
+	// Repopulate is set if the volume wasn't restored from the backup
+	// because it is populated again from the data source of its PVC
+	Repopulate bool `json:"repopulate,omitempty"`
+	// VolumeAttributes are the attributes from the backup that were
+	// reapplied to the restored volume after the mapping
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
 }
 
 // ApplicationRestoreStatusType is the status of the application restore
@@ -126,3 +300,12 @@ type ApplicationRestoreList struct {
 
 	Items []ApplicationRestore `json:"items"`
 }
+
+// GetBackupLocationNamespace returns the namespace of the BackupLocation used
+// by the restore
+func (r *ApplicationRestore) GetBackupLocationNamespace() string {
+	if r.Spec.BackupLocationNamespace != "" {
+		return r.Spec.BackupLocationNamespace
+	}
+	return r.Namespace
+}
@@ -3,6 +3,7 @@ package v1alpha1
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,6 +16,21 @@ const (
 	BackupLocationResourceName = "backuplocation"
 	// BackupLocationResourcePlural is plural for "backuplocation" resource
 	BackupLocationResourcePlural = "backuplocations"
+	// AllNamespacesAllowed can be used in AllowedNamespaces to allow all
+	// namespaces to use a BackupLocation
+	AllNamespacesAllowed = "*"
+	// DefaultSwiftRegion is the default location of the S3 API of Swift
+	DefaultSwiftRegion = "us-east-1"
+)
+
+// maxAzureRetentionDays is the longest retention period supported for
+// Azure immutability policies
+const maxAzureRetentionDays = 146000
+
+var (
+	alibabaRegionRegex     = regexp.MustCompile(`^[a-z]{2}(-[a-z0-9]+)+$`)
+	azureLegalHoldTagRegex = regexp.MustCompile(`^[a-zA-Z0-9]{3,23}$`)
+	googleKMSKeyRegex      = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
 )
 
 // +genclient
@@ -26,23 +42,34 @@ type BackupLocation struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 	Location          BackupLocationItem `json:"location"`
 	Cluster           ClusterItem        `json:"cluster"`
+	// AllowedNamespaces are the namespaces other than the namespace of the
+	// BackupLocation from which ApplicationBackups and ApplicationRestores
+	// can use the BackupLocation. "*" allows all namespaces.
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
 }
 
 // BackupLocationItem is the spec used to store a backup location
-// Only one of S3Config, AzureConfig or GoogleConfig should be specified and
-// should match the Type field. Members of the config can be specified inline or
+// Only one of S3Config, AzureConfig, GoogleConfig, SwiftConfig or
+// AlibabaConfig should be specified and should match the Type field. Members of the config can be specified inline or
 // through the SecretConfig
 type BackupLocationItem struct {
 	Type BackupLocationType `json:"type"`
 	// Path is either the bucket or any other path for the backup location
-	Path               string        `json:"path"`
-	EncryptionKey      string        `json:"encryptionKey"`
-	S3Config           *S3Config     `json:"s3Config,omitempty"`
-	AzureConfig        *AzureConfig  `json:"azureConfig,omitempty"`
-	GoogleConfig       *GoogleConfig `json:"googleConfig,omitempty"`
-	SecretConfig       string        `json:"secretConfig"`
-	Sync               bool          `json:"sync"`
-	RepositoryPassword string        `json:"repositoryPassword"`
+	Path               string         `json:"path"`
+	EncryptionKey      string         `json:"encryptionKey"`
+	S3Config           *S3Config      `json:"s3Config,omitempty"`
+	AzureConfig        *AzureConfig   `json:"azureConfig,omitempty"`
+	GoogleConfig       *GoogleConfig  `json:"googleConfig,omitempty"`
+	SwiftConfig        *SwiftConfig   `json:"swiftConfig,omitempty"`
+	AlibabaConfig      *AlibabaConfig `json:"alibabaConfig,omitempty"`
+	SecretConfig       string         `json:"secretConfig"`
+	Sync               bool           `json:"sync"`
+	RepositoryPassword string         `json:"repositoryPassword"`
+	// ExternalSecretConfig, if set, points to credentials kept in an
+	// external secret. They are fetched every time the backup location is
+	// used and take precedence over values from SecretConfig. It isn't
+	// supported for volumes backed up by kdmp.
+	ExternalSecretConfig *ExternalSecretConfig `json:"externalSecretConfig,omitempty"`
 }
 
 // ClusterItem is the spec used to store a the credentials associated with the cluster
@@ -58,6 +85,73 @@ type ClusterItem struct {
 	GCPClusterConfig   *GoogleConfig `json:"gcpClusterConfig,omitempty"`
 	SecretConfig       string        `json:"secretConfig"`
 	Sync               bool          `json:"sync"`
+	// ExternalSecretConfig, if set, points to cluster credentials kept in an
+	// external secret
+	ExternalSecretConfig *ExternalSecretConfig `json:"externalSecretConfig,omitempty"`
+	// Accounts are additional cloud accounts, subscriptions or projects
+	// holding the volumes of some of the storage classes. The volumes of the
+	// other storage classes use the credentials above.
+	Accounts []CloudAccount `json:"accounts,omitempty"`
+}
+
+// CloudAccount is a cloud account, subscription or project holding the
+// volumes of some of the storage classes of the cluster. Only the config
+// matching the Type of the ClusterItem should be specified. Members of the
+// config can be specified inline or through the SecretConfig.
+type CloudAccount struct {
+	// Name identifies the account in the volumes backed up from it, so it
+	// shouldn't be changed while those backups exist
+	Name string `json:"name"`
+	// StorageClasses are the storage classes whose volumes are in the
+	// account
+	StorageClasses     []string      `json:"storageClasses"`
+	AWSClusterConfig   *S3Config     `json:"awsClusterConfig,omitempty"`
+	AzureClusterConfig *AzureConfig  `json:"azureClusterConfig,omitempty"`
+	GCPClusterConfig   *GoogleConfig `json:"gcpClusterConfig,omitempty"`
+	SecretConfig       string        `json:"secretConfig,omitempty"`
+	// ResourceGroup is the Azure resource group holding the volumes. The
+	// resource group of the cluster is used if it is empty.
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+}
+
+// GetAccount returns the account with the given name, or nil if there is no
+// such account
+func (c *ClusterItem) GetAccount(name string) *CloudAccount {
+	for i := range c.Accounts {
+		if c.Accounts[i].Name == name {
+			return &c.Accounts[i]
+		}
+	}
+	return nil
+}
+
+// GetAccountForStorageClass returns the account holding the volumes of the
+// storage class, or nil if they use the cluster credentials
+func (c *ClusterItem) GetAccountForStorageClass(storageClass string) *CloudAccount {
+	if storageClass == "" {
+		return nil
+	}
+	for i := range c.Accounts {
+		for _, sc := range c.Accounts[i].StorageClasses {
+			if sc == storageClass {
+				return &c.Accounts[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ExternalSecretConfig references credential material stored in HashiCorp
+// Vault, or in a Kubernetes Secret in the namespace of the referencing
+// object. The credentials are only fetched by stork, so backup locations with
+// an external secret can't be used for volumes backed up by kdmp.
+type ExternalSecretConfig struct {
+	// Provider is the name of the secret provider, either "vault" or
+	// "kubernetes"
+	Provider string `json:"provider"`
+	// SecretID identifies the secret in the provider, e.g. the Vault path
+	// relative to the path of the namespace
+	SecretID string `json:"secretID"`
 }
 
 // BackupLocationType is the type of the backup location
@@ -70,6 +164,10 @@ const (
 	BackupLocationAzure BackupLocationType = "azure"
 	// BackupLocationGoogle stores the backup in Google Cloud Storage
 	BackupLocationGoogle BackupLocationType = "google"
+	// BackupLocationSwift stores the backup in OpenStack Swift
+	BackupLocationSwift BackupLocationType = "swift"
+	// BackupLocationAlibaba stores the backup in Alibaba Cloud OSS
+	BackupLocationAlibaba BackupLocationType = "alibaba"
 )
 
 // ClusterType is the type of the cluster
@@ -112,12 +210,151 @@ type AzureConfig struct {
 	SubscriptionID     string `json:"subscriptionID"`
 	ClientID           string `json:"clientID"`
 	ClientSecret       string `json:"clientSecret"`
+	// ResourceGroup is the resource group of the storage account. It is
+	// required along with the subscription and client credentials to manage
+	// the immutability of the backup container.
+	ResourceGroup string `json:"resourceGroup,omitempty"`
+	// Immutability configures immutable storage for the backup container.
+	// It is only used for backup locations.
+	Immutability *AzureImmutability `json:"immutability,omitempty"`
+}
+
+// AzureImmutability configures the immutability policy and legal hold of the
+// backup container. Backups can't be deleted from the container while they
+// are protected by either of them.
+type AzureImmutability struct {
+	// RetentionDays is the time-based retention period of the container.
+	// Blobs can't be modified or deleted until they are older than the
+	// retention period. No retention policy is set if it is 0.
+	RetentionDays int32 `json:"retentionDays,omitempty"`
+	// LegalHoldTags are set as legal hold on the container. Blobs can't be
+	// modified or deleted while the container has legal hold tags.
+	LegalHoldTags []string `json:"legalHoldTags,omitempty"`
+}
+
+// ValidateImmutability checks that the immutability settings are valid and
+// that the credentials needed to apply them are present
+func (c *AzureConfig) ValidateImmutability() error {
+	if c == nil || c.Immutability == nil {
+		return nil
+	}
+	if c.Immutability.RetentionDays < 0 || c.Immutability.RetentionDays > maxAzureRetentionDays {
+		return fmt.Errorf("retentionDays for azure immutability should be between 0 and %v", maxAzureRetentionDays)
+	}
+	for _, tag := range c.Immutability.LegalHoldTags {
+		if !azureLegalHoldTagRegex.MatchString(tag) {
+			return fmt.Errorf("invalid legal hold tag %v, tags should have 3 to 23 alphanumeric characters", tag)
+		}
+	}
+	if c.TenantID == "" || c.ClientID == "" || c.ClientSecret == "" ||
+		c.SubscriptionID == "" || c.ResourceGroup == "" {
+		return fmt.Errorf("tenantID, clientID, clientSecret, subscriptionID and resourceGroup need to be " +
+			"specified to manage azure immutability")
+	}
+	return nil
 }
 
 // GoogleConfig specifies the config required to connect to Google Cloud Storage
 type GoogleConfig struct {
 	ProjectID  string `json:"projectID"`
 	AccountKey string `json:"accountKey"`
+	// KMSKeyName is the Cloud KMS key used to encrypt the backups, in the
+	// form projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>.
+	// It is set as the default key of the bucket. Only used for backup
+	// locations.
+	KMSKeyName string `json:"kmsKeyName,omitempty"`
+}
+
+// Validate validates the Google Cloud Storage config
+func (c *GoogleConfig) Validate() error {
+	if c == nil {
+		return fmt.Errorf("googleConfig needs to be specified")
+	}
+	if c.KMSKeyName != "" && !googleKMSKeyRegex.MatchString(c.KMSKeyName) {
+		return fmt.Errorf("invalid kmsKeyName %v, expected "+
+			"projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>", c.KMSKeyName)
+	}
+	return nil
+}
+
+// SwiftConfig specifies the config required to connect to OpenStack Swift.
+// Swift is accessed through its S3 API, so the s3api middleware needs to be
+// enabled on the Swift proxy.
+type SwiftConfig struct {
+	// Endpoint is the URL of the Swift proxy
+	Endpoint string `json:"endpoint"`
+	// AccessKeyID and SecretAccessKey are the EC2 credentials of the
+	// Keystone user
+	AccessKeyID     string `json:"accessKeyID"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	// Region is the location configured for the s3api middleware. It will be
+	// defaulted to us-east-1, the default of the middleware, if not provided
+	Region string `json:"region"`
+	// Disable SSL option if the Swift proxy doesn't have SSL enabled
+	DisableSSL bool `json:"disableSSL"`
+}
+
+// AlibabaConfig specifies the config required to connect to Alibaba Cloud
+// Object Storage Service
+type AlibabaConfig struct {
+	// Region is the region of the bucket, for example cn-hangzhou. The
+	// oss- prefix used in the endpoints is accepted too.
+	Region string `json:"region"`
+	// Endpoint will be defaulted to the public endpoint of the region, or the
+	// internal one if Internal is set, if not provided
+	Endpoint        string `json:"endpoint"`
+	AccessKeyID     string `json:"accessKeyID"`
+	AccessKeySecret string `json:"accessKeySecret"`
+	// Internal uses the internal endpoint of the region, which can only be
+	// reached from ECS instances in the same region
+	Internal bool `json:"internal"`
+}
+
+// Validate validates the Swift config
+func (c *SwiftConfig) Validate() error {
+	if c == nil {
+		return fmt.Errorf("swiftConfig needs to be specified")
+	}
+	if c.Endpoint == "" {
+		return fmt.Errorf("endpoint needs to be specified for swift")
+	}
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("accessKeyID and secretAccessKey need to be specified for swift")
+	}
+	return nil
+}
+
+// GetRegion returns the region of the bucket without the oss- prefix
+func (c *AlibabaConfig) GetRegion() string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(c.Region)), "oss-")
+}
+
+// GetEndpoint returns the endpoint to use for the bucket
+func (c *AlibabaConfig) GetEndpoint() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	if c.Internal {
+		return fmt.Sprintf("oss-%v-internal.aliyuncs.com", c.GetRegion())
+	}
+	return fmt.Sprintf("oss-%v.aliyuncs.com", c.GetRegion())
+}
+
+// Validate validates the Alibaba OSS config
+func (c *AlibabaConfig) Validate() error {
+	if c == nil {
+		return fmt.Errorf("alibabaConfig needs to be specified")
+	}
+	if c.Region == "" {
+		return fmt.Errorf("region needs to be specified for alibaba")
+	}
+	if !alibabaRegionRegex.MatchString(c.GetRegion()) {
+		return fmt.Errorf("invalid region %v for alibaba, expected a region like cn-hangzhou", c.Region)
+	}
+	if c.AccessKeyID == "" || c.AccessKeySecret == "" {
+		return fmt.Errorf("accessKeyID and accessKeySecret need to be specified for alibaba")
+	}
+	return nil
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -130,27 +367,53 @@ type BackupLocationList struct {
 	Items []BackupLocation `json:"items"`
 }
 
+// IsNamespaceAllowed returns true if the BackupLocation can be used from the
+// namespace
+func (bl *BackupLocation) IsNamespaceAllowed(namespace string) bool {
+	if namespace == bl.Namespace {
+		return true
+	}
+	for _, ns := range bl.AllowedNamespaces {
+		if ns == namespace || ns == AllNamespacesAllowed {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateFromSecret updated the config information from the secret if not provided inline
 func (bl *BackupLocation) UpdateFromSecret(client kubernetes.Interface) error {
+	var data map[string][]byte
 	if bl.Location.SecretConfig != "" {
 		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Location.SecretConfig, metav1.GetOptions{})
 		if err != nil {
 			return fmt.Errorf("error getting secretConfig for backupLocation: %v", err)
 		}
-		if val, ok := secretConfig.Data["encryptionKey"]; ok && val != nil {
-			bl.Location.EncryptionKey = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["path"]; ok && val != nil {
-			bl.Location.Path = strings.TrimSuffix(string(val), "\n")
-		}
+		data = secretConfig.Data
+	}
+	return bl.UpdateFromSecretData(data)
+}
+
+// UpdateFromSecretData updates the config information from the given secret
+// data. Keys that are not present in the data are left untouched.
+func (bl *BackupLocation) UpdateFromSecretData(data map[string][]byte) error {
+	if val, ok := data["encryptionKey"]; ok && val != nil {
+		bl.Location.EncryptionKey = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["path"]; ok && val != nil {
+		bl.Location.Path = strings.TrimSuffix(string(val), "\n")
 	}
 	switch bl.Location.Type {
 	case BackupLocationS3:
-		return bl.getMergedS3Config(client)
+		return bl.getMergedS3Config(data)
 	case BackupLocationAzure:
-		return bl.getMergedAzureConfig(client)
+		return bl.getMergedAzureConfig(data)
 	case BackupLocationGoogle:
-		return bl.getMergedGoogleConfig(client)
+		return bl.getMergedGoogleConfig(data)
+	case BackupLocationSwift:
+		return bl.getMergedSwiftConfig(data)
+	case BackupLocationAlibaba:
+		return bl.getMergedAlibabaConfig(data)
 	default:
 		return fmt.Errorf("Invalid BackupLocation type %v", bl.Location.Type)
 	}
@@ -160,145 +423,236 @@ func (bl *BackupLocation) UpdateFromSecret(client kubernetes.Interface) error {
 // UpdateFromClusterSecret updated the config information from the cluster secret if not provided inline
 func (bl *BackupLocation) UpdateFromClusterSecret(client kubernetes.Interface) error {
 	if bl.Cluster.SecretConfig != "" {
-		switch bl.Cluster.Type {
-		case AWSCluster:
-			return bl.getMergedAWSClusterCred(client)
-		case GCPCluster:
-			return bl.getMergedGCPClusterCred(client)
-		case AzureCluster:
-			return bl.getMergedAzureClusterCred(client)
-		default:
+		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Cluster.SecretConfig, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting secretConfig for cluster from backuplocation: %v", err)
+		}
+		if err := bl.UpdateFromClusterSecretData(secretConfig.Data); err != nil {
+			return err
 		}
 	}
+	for i := range bl.Cluster.Accounts {
+		account := &bl.Cluster.Accounts[i]
+		if account.SecretConfig == "" {
+			continue
+		}
+		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), account.SecretConfig, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("error getting secretConfig for account %v from backuplocation: %v", account.Name, err)
+		}
+		account.UpdateFromSecretData(bl.Cluster.Type, secretConfig.Data)
+	}
 	return nil
 }
 
-func (bl *BackupLocation) getMergedS3Config(client kubernetes.Interface) error {
+// UpdateFromSecretData updates the credentials of the account from the
+// given secret data
+func (a *CloudAccount) UpdateFromSecretData(clusterType ClusterType, data map[string][]byte) {
+	switch clusterType {
+	case AWSCluster:
+		a.AWSClusterConfig = mergeAWSClusterCred(a.AWSClusterConfig, data)
+	case GCPCluster:
+		a.GCPClusterConfig = mergeGCPClusterCred(a.GCPClusterConfig, data)
+	case AzureCluster:
+		a.AzureClusterConfig = mergeAzureClusterCred(a.AzureClusterConfig, data)
+	}
+}
+
+// UpdateFromClusterSecretData updates the cluster credentials from the given
+// secret data
+func (bl *BackupLocation) UpdateFromClusterSecretData(data map[string][]byte) error {
+	switch bl.Cluster.Type {
+	case AWSCluster:
+		return bl.getMergedAWSClusterCred(data)
+	case GCPCluster:
+		return bl.getMergedGCPClusterCred(data)
+	case AzureCluster:
+		return bl.getMergedAzureClusterCred(data)
+	default:
+	}
+	return nil
+}
+
+func (bl *BackupLocation) getMergedS3Config(data map[string][]byte) error {
 	if bl.Location.S3Config == nil {
 		bl.Location.S3Config = &S3Config{}
 		bl.Location.S3Config.Endpoint = "s3.amazonaws.com"
 		bl.Location.S3Config.Region = "us-east-1"
 		bl.Location.S3Config.DisableSSL = false
 	}
-	if bl.Location.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Location.SecretConfig, metav1.GetOptions{})
+	if val, ok := data["endpoint"]; ok && val != nil {
+		bl.Location.S3Config.Endpoint = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accessKeyID"]; ok && val != nil {
+		bl.Location.S3Config.AccessKeyID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["secretAccessKey"]; ok && val != nil {
+		bl.Location.S3Config.SecretAccessKey = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["region"]; ok && val != nil {
+		bl.Location.S3Config.Region = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["disableSSL"]; ok && val != nil {
+		var err error
+		bl.Location.S3Config.DisableSSL, err = strconv.ParseBool(strings.TrimSuffix(string(val), "\n"))
 		if err != nil {
-			return fmt.Errorf("error getting secretConfig for backupLocation: %v", err)
-		}
-		if val, ok := secretConfig.Data["endpoint"]; ok && val != nil {
-			bl.Location.S3Config.Endpoint = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["accessKeyID"]; ok && val != nil {
-			bl.Location.S3Config.AccessKeyID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["secretAccessKey"]; ok && val != nil {
-			bl.Location.S3Config.SecretAccessKey = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["region"]; ok && val != nil {
-			bl.Location.S3Config.Region = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["disableSSL"]; ok && val != nil {
-			bl.Location.S3Config.DisableSSL, err = strconv.ParseBool(strings.TrimSuffix(string(val), "\n"))
-			if err != nil {
-				return fmt.Errorf("error parding disableSSL from Secret: %v", err)
-			}
-		}
-		if val, ok := secretConfig.Data["storageClass"]; ok && val != nil {
-			bl.Location.S3Config.StorageClass = strings.TrimSuffix(string(val), "\n")
+			return fmt.Errorf("error parding disableSSL from Secret: %v", err)
 		}
 	}
+	if val, ok := data["storageClass"]; ok && val != nil {
+		bl.Location.S3Config.StorageClass = strings.TrimSuffix(string(val), "\n")
+	}
 	return nil
 }
 
-func (bl *BackupLocation) getMergedAzureConfig(client kubernetes.Interface) error {
+func (bl *BackupLocation) getMergedAzureConfig(data map[string][]byte) error {
 	if bl.Location.AzureConfig == nil {
 		bl.Location.AzureConfig = &AzureConfig{}
 	}
-	if bl.Location.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Location.SecretConfig, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("error getting secretConfig for backupLocation: %v", err)
-		}
-		if val, ok := secretConfig.Data["storageAccountName"]; ok && val != nil {
-			bl.Location.AzureConfig.StorageAccountName = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["storageAccountKey"]; ok && val != nil {
-			bl.Location.AzureConfig.StorageAccountKey = strings.TrimSuffix(string(val), "\n")
-		}
+	if val, ok := data["storageAccountName"]; ok && val != nil {
+		bl.Location.AzureConfig.StorageAccountName = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["storageAccountKey"]; ok && val != nil {
+		bl.Location.AzureConfig.StorageAccountKey = strings.TrimSuffix(string(val), "\n")
+	}
+	bl.Location.AzureConfig = mergeAzureClusterCred(bl.Location.AzureConfig, data)
+	if val, ok := data["resourceGroup"]; ok && val != nil {
+		bl.Location.AzureConfig.ResourceGroup = strings.TrimSuffix(string(val), "\n")
 	}
 	return nil
 
 }
 
-func (bl *BackupLocation) getMergedGoogleConfig(client kubernetes.Interface) error {
+func (bl *BackupLocation) getMergedGoogleConfig(data map[string][]byte) error {
 	if bl.Location.GoogleConfig == nil {
 		bl.Location.GoogleConfig = &GoogleConfig{}
 	}
-	if bl.Location.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Location.SecretConfig, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("error getting secretConfig for backupLocation: %v", err)
-		}
-		if val, ok := secretConfig.Data["projectID"]; ok && val != nil {
-			bl.Location.GoogleConfig.ProjectID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["accountKey"]; ok && val != nil {
-			bl.Location.GoogleConfig.AccountKey = strings.TrimSuffix(string(val), "\n")
-		}
+	if val, ok := data["projectID"]; ok && val != nil {
+		bl.Location.GoogleConfig.ProjectID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accountKey"]; ok && val != nil {
+		bl.Location.GoogleConfig.AccountKey = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["kmsKeyName"]; ok && val != nil {
+		bl.Location.GoogleConfig.KMSKeyName = strings.TrimSuffix(string(val), "\n")
 	}
 	return nil
 }
 
-func (bl *BackupLocation) getMergedAWSClusterCred(client kubernetes.Interface) error {
-	if bl.Cluster.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Cluster.SecretConfig, metav1.GetOptions{})
+func (bl *BackupLocation) getMergedSwiftConfig(data map[string][]byte) error {
+	if bl.Location.SwiftConfig == nil {
+		bl.Location.SwiftConfig = &SwiftConfig{}
+	}
+	if val, ok := data["endpoint"]; ok && val != nil {
+		bl.Location.SwiftConfig.Endpoint = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accessKeyID"]; ok && val != nil {
+		bl.Location.SwiftConfig.AccessKeyID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["secretAccessKey"]; ok && val != nil {
+		bl.Location.SwiftConfig.SecretAccessKey = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["region"]; ok && val != nil {
+		bl.Location.SwiftConfig.Region = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["disableSSL"]; ok && val != nil {
+		var err error
+		bl.Location.SwiftConfig.DisableSSL, err = strconv.ParseBool(strings.TrimSuffix(string(val), "\n"))
 		if err != nil {
-			return fmt.Errorf("error getting secretConfig for cluster from backuplocation:  %v", err)
-		}
-		if val, ok := secretConfig.Data["accessKeyID"]; ok && val != nil {
-			bl.Cluster.AWSClusterConfig.AccessKeyID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["secretAccessKey"]; ok && val != nil {
-			bl.Cluster.AWSClusterConfig.SecretAccessKey = strings.TrimSuffix(string(val), "\n")
+			return fmt.Errorf("error parsing disableSSL from Secret: %v", err)
 		}
 	}
+	if bl.Location.SwiftConfig.Region == "" {
+		bl.Location.SwiftConfig.Region = DefaultSwiftRegion
+	}
 	return nil
 }
 
-func (bl *BackupLocation) getMergedGCPClusterCred(client kubernetes.Interface) error {
-	if bl.Cluster.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Cluster.SecretConfig, metav1.GetOptions{})
+func (bl *BackupLocation) getMergedAlibabaConfig(data map[string][]byte) error {
+	if bl.Location.AlibabaConfig == nil {
+		bl.Location.AlibabaConfig = &AlibabaConfig{}
+	}
+	if val, ok := data["region"]; ok && val != nil {
+		bl.Location.AlibabaConfig.Region = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["endpoint"]; ok && val != nil {
+		bl.Location.AlibabaConfig.Endpoint = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accessKeyID"]; ok && val != nil {
+		bl.Location.AlibabaConfig.AccessKeyID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accessKeySecret"]; ok && val != nil {
+		bl.Location.AlibabaConfig.AccessKeySecret = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["internal"]; ok && val != nil {
+		var err error
+		bl.Location.AlibabaConfig.Internal, err = strconv.ParseBool(strings.TrimSuffix(string(val), "\n"))
 		if err != nil {
-			return fmt.Errorf("error getting secretConfig for cluster from backuplocation: %v", err)
-		}
-		if val, ok := secretConfig.Data["projectID"]; ok && val != nil {
-			bl.Cluster.GCPClusterConfig.ProjectID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["accountKey"]; ok && val != nil {
-			bl.Cluster.GCPClusterConfig.AccountKey = strings.TrimSuffix(string(val), "\n")
+			return fmt.Errorf("error parsing internal from Secret: %v", err)
 		}
 	}
 	return nil
 }
 
-func (bl *BackupLocation) getMergedAzureClusterCred(client kubernetes.Interface) error {
-	if bl.Cluster.SecretConfig != "" {
-		secretConfig, err := client.CoreV1().Secrets(bl.Namespace).Get(context.TODO(), bl.Cluster.SecretConfig, metav1.GetOptions{})
-		if err != nil {
-			return fmt.Errorf("error getting secretConfig for backupLocation: %v", err)
-		}
-		if val, ok := secretConfig.Data["tenantID"]; ok && val != nil {
-			bl.Cluster.AzureClusterConfig.TenantID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["clientID"]; ok && val != nil {
-			bl.Cluster.AzureClusterConfig.ClientID = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["clientSecret"]; ok && val != nil {
-			bl.Cluster.AzureClusterConfig.ClientSecret = strings.TrimSuffix(string(val), "\n")
-		}
-		if val, ok := secretConfig.Data["subscriptionID"]; ok && val != nil {
-			bl.Cluster.AzureClusterConfig.SubscriptionID = strings.TrimSuffix(string(val), "\n")
-		}
-	}
+func (bl *BackupLocation) getMergedAWSClusterCred(data map[string][]byte) error {
+	bl.Cluster.AWSClusterConfig = mergeAWSClusterCred(bl.Cluster.AWSClusterConfig, data)
+	return nil
+}
+
+func (bl *BackupLocation) getMergedGCPClusterCred(data map[string][]byte) error {
+	bl.Cluster.GCPClusterConfig = mergeGCPClusterCred(bl.Cluster.GCPClusterConfig, data)
+	return nil
+}
+
+func (bl *BackupLocation) getMergedAzureClusterCred(data map[string][]byte) error {
+	bl.Cluster.AzureClusterConfig = mergeAzureClusterCred(bl.Cluster.AzureClusterConfig, data)
 	return nil
 }
+
+func mergeAWSClusterCred(config *S3Config, data map[string][]byte) *S3Config {
+	if config == nil {
+		config = &S3Config{}
+	}
+	if val, ok := data["accessKeyID"]; ok && val != nil {
+		config.AccessKeyID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["secretAccessKey"]; ok && val != nil {
+		config.SecretAccessKey = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["region"]; ok && val != nil {
+		config.Region = strings.TrimSuffix(string(val), "\n")
+	}
+	return config
+}
+
+func mergeGCPClusterCred(config *GoogleConfig, data map[string][]byte) *GoogleConfig {
+	if config == nil {
+		config = &GoogleConfig{}
+	}
+	if val, ok := data["projectID"]; ok && val != nil {
+		config.ProjectID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["accountKey"]; ok && val != nil {
+		config.AccountKey = strings.TrimSuffix(string(val), "\n")
+	}
+	return config
+}
+
+func mergeAzureClusterCred(config *AzureConfig, data map[string][]byte) *AzureConfig {
+	if config == nil {
+		config = &AzureConfig{}
+	}
+	if val, ok := data["tenantID"]; ok && val != nil {
+		config.TenantID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["clientID"]; ok && val != nil {
+		config.ClientID = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["clientSecret"]; ok && val != nil {
+		config.ClientSecret = strings.TrimSuffix(string(val), "\n")
+	}
+	if val, ok := data["subscriptionID"]; ok && val != nil {
+		config.SubscriptionID = strings.TrimSuffix(string(val), "\n")
+	}
+	return config
+}
@@ -0,0 +1,113 @@
+//go:build unittest
+// +build unittest
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlibabaConfigValidate(t *testing.T) {
+	config := &AlibabaConfig{
+		AccessKeyID:     "accesskey",
+		AccessKeySecret: "secretKey",
+		Region:          "cn-hangzhou",
+	}
+	require.NoError(t, config.Validate())
+
+	config.Region = "OSS-cn-hangzhou"
+	require.NoError(t, config.Validate())
+	require.Equal(t, "cn-hangzhou", config.GetRegion())
+
+	config.Region = "hangzhou"
+	require.Error(t, config.Validate())
+
+	config.Region = ""
+	require.Error(t, config.Validate())
+
+	config.Region = "cn-hangzhou"
+	config.AccessKeySecret = ""
+	require.Error(t, config.Validate())
+
+	var nilConfig *AlibabaConfig
+	require.Error(t, nilConfig.Validate())
+}
+
+func TestSwiftConfigValidate(t *testing.T) {
+	config := &SwiftConfig{
+		Endpoint:        "swift.example.com",
+		AccessKeyID:     "accesskey",
+		SecretAccessKey: "secretKey",
+	}
+	require.NoError(t, config.Validate())
+
+	config.Endpoint = ""
+	require.Error(t, config.Validate())
+
+	config.Endpoint = "swift.example.com"
+	config.AccessKeyID = ""
+	require.Error(t, config.Validate())
+}
+
+func TestAzureConfigValidateImmutability(t *testing.T) {
+	config := &AzureConfig{
+		StorageAccountName: "account",
+		StorageAccountKey:  "key",
+	}
+	require.NoError(t, config.ValidateImmutability())
+
+	config.Immutability = &AzureImmutability{RetentionDays: 30, LegalHoldTags: []string{"case123"}}
+	require.Error(t, config.ValidateImmutability(), "credentials are needed to manage immutability")
+
+	config.TenantID = "tenant"
+	config.ClientID = "client"
+	config.ClientSecret = "secret"
+	config.SubscriptionID = "subscription"
+	config.ResourceGroup = "group"
+	require.NoError(t, config.ValidateImmutability())
+
+	config.Immutability.RetentionDays = -1
+	require.Error(t, config.ValidateImmutability())
+
+	config.Immutability.RetentionDays = 30
+	config.Immutability.LegalHoldTags = []string{"case-123"}
+	require.Error(t, config.ValidateImmutability())
+}
+
+func TestAzureConfigFromSecret(t *testing.T) {
+	bl := &BackupLocation{
+		Location: BackupLocationItem{
+			Type: BackupLocationAzure,
+			AzureConfig: &AzureConfig{
+				Immutability: &AzureImmutability{RetentionDays: 30},
+			},
+		},
+	}
+	require.NoError(t, bl.UpdateFromSecretData(map[string][]byte{
+		"storageAccountName": []byte("account\n"),
+		"tenantID":           []byte("tenant"),
+		"clientID":           []byte("client"),
+		"clientSecret":       []byte("secret"),
+		"subscriptionID":     []byte("subscription"),
+		"resourceGroup":      []byte("group\n"),
+	}))
+	require.Equal(t, "account", bl.Location.AzureConfig.StorageAccountName)
+	require.Equal(t, "group", bl.Location.AzureConfig.ResourceGroup)
+	require.NoError(t, bl.Location.AzureConfig.ValidateImmutability())
+}
+
+func TestGoogleConfigValidate(t *testing.T) {
+	config := &GoogleConfig{ProjectID: "project"}
+	require.NoError(t, config.Validate())
+
+	config.KMSKeyName = "projects/project/locations/us/keyRings/ring/cryptoKeys/key"
+	require.NoError(t, config.Validate())
+
+	config.KMSKeyName = "projects/project/locations/us/keyRings/ring"
+	require.Error(t, config.Validate())
+
+	var nilConfig *GoogleConfig
+	require.Error(t, nilConfig.Validate())
+}
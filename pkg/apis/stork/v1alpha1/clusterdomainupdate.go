@@ -35,6 +35,9 @@ type ClusterDomainUpdate struct {
 type ClusterDomainUpdateStatus struct {
 	Status ClusterDomainUpdateStatusType `json:"status"`
 	Reason string                        `json:"reason"`
+	// ObservedGeneration is the generation of the update that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
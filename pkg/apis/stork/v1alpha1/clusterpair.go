@@ -27,6 +27,23 @@ type ClusterPair struct {
 type ClusterPairSpec struct {
 	Config  api.Config        `json:"config"`
 	Options map[string]string `json:"options"`
+	// ExternalSecretConfig, if set, points to pairing options (for example
+	// the storage token) kept in an external secret. The values are
+	// merged into Options when the pair is used, except for the
+	// kubeconfig-* keys which set the credentials of the current context
+	// of Config.
+	ExternalSecretConfig *ExternalSecretConfig `json:"externalSecretConfig,omitempty"`
+	// RateLimit, if set, limits the rate of requests made to the remote
+	// cluster when applying resources
+	RateLimit *ClusterPairRateLimit `json:"rateLimit,omitempty"`
+}
+
+// ClusterPairRateLimit is the rate limit for requests to a remote cluster
+type ClusterPairRateLimit struct {
+	// QPS is the maximum number of queries per second to the remote cluster
+	QPS int `json:"qps"`
+	// Burst is the maximum burst of queries to the remote cluster
+	Burst int `json:"burst"`
 }
 
 // ClusterPairStatusType is the status of the pair
@@ -61,6 +78,14 @@ type ClusterPairStatus struct {
 	// ID of the remote storage which is paired
 	// +optional
 	RemoteStorageID string `json:"remoteStorageId"`
+	// References are the migration schedules using the cluster pair
+	// +optional
+	References []ObjectReference `json:"references,omitempty"`
+	// CleanupError is the last error hit while cleaning up the cluster pair
+	// after it was deleted. The cluster pair is held back by the cleanup
+	// finalizer until the cleanup succeeds.
+	// +optional
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
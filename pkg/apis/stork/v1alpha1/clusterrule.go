@@ -0,0 +1,48 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterRuleResourceName is name for "clusterrule" resource
+	ClusterRuleResourceName = "clusterrule"
+	// ClusterRuleResourcePlural is plural for "clusterrule" resource
+	ClusterRuleResourcePlural = "clusterrules"
+	// ClusterRuleReferencePrefix is used in the rule names of namespaced
+	// resources to refer to a ClusterRule instead of a Rule in their
+	// namespace, for example "clusterrule/postgres-hooks". The version can
+	// be pinned with "clusterrule/postgres-hooks@v2".
+	ClusterRuleReferencePrefix = "clusterrule/"
+	// RuleLibraryLabel groups the ClusterRules holding the versions of the
+	// same hook library. A reference pinned to a version that doesn't match
+	// the ClusterRule with the referenced name is resolved to the
+	// ClusterRule with this label set to the name and the pinned version.
+	RuleLibraryLabel = "stork.libopenstorage.org/rule-library"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterRule is a cluster scoped rule that can be referenced from any
+// namespace, so that hook libraries can be maintained in one place instead
+// of copying a Rule into every namespace
+type ClusterRule struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	// Version of the rule that references can be pinned to
+	Version string     `json:"version,omitempty"`
+	Rules   []RuleItem `json:"rules"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterRuleList is a list of cluster rules
+type ClusterRuleList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterRule `json:"items"`
+}
@@ -0,0 +1,84 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConditionCompleted is set once an operation has finished. It is True
+	// if the operation was successful, even partially, and False if it
+	// failed or was cancelled. The reason is the final status of the
+	// operation. It can be used with
+	// "kubectl wait --for=condition=Completed".
+	ConditionCompleted = "Completed"
+	// ConditionScheduleDrift is set on schedules. It is True if the
+	// recent runs of a policy all started late, for example because they
+	// were throttled, so that the actual RPO is worse than the policy.
+	ConditionScheduleDrift = "ScheduleDrift"
+)
+
+// setCompletedCondition sets the Completed condition in the conditions once
+// an operation has finished and removes it while the operation is running
+func setCompletedCondition(conditions *[]metav1.Condition, generation int64, finished, successful bool, status, message string) {
+	if !finished || status == "" {
+		meta.RemoveStatusCondition(conditions, ConditionCompleted)
+		return
+	}
+	conditionStatus := metav1.ConditionFalse
+	if successful {
+		conditionStatus = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               ConditionCompleted,
+		Status:             conditionStatus,
+		ObservedGeneration: generation,
+		Reason:             status,
+		Message:            message,
+	})
+}
+
+// SetCompletedCondition sets the Completed condition once the backup has
+// finished
+func (a *ApplicationBackup) SetCompletedCondition() {
+	setCompletedCondition(&a.Status.Conditions, a.Generation,
+		a.Status.Stage == ApplicationBackupStageFinal,
+		a.Status.Status == ApplicationBackupStatusSuccessful || a.Status.Status == ApplicationBackupStatusPartialSuccess,
+		string(a.Status.Status), a.Status.Reason)
+}
+
+// SetCompletedCondition sets the Completed condition once the restore has
+// finished
+func (a *ApplicationRestore) SetCompletedCondition() {
+	setCompletedCondition(&a.Status.Conditions, a.Generation,
+		a.Status.Stage == ApplicationRestoreStageFinal,
+		a.Status.Status == ApplicationRestoreStatusSuccessful || a.Status.Status == ApplicationRestoreStatusPartialSuccess,
+		string(a.Status.Status), a.Status.Reason)
+}
+
+// SetCompletedCondition sets the Completed condition once the clone has
+// finished
+func (a *ApplicationClone) SetCompletedCondition() {
+	setCompletedCondition(&a.Status.Conditions, a.Generation,
+		a.Status.Stage == ApplicationCloneStageFinal,
+		a.Status.Status == ApplicationCloneStatusSuccessful || a.Status.Status == ApplicationCloneStatusPartialSuccess,
+		string(a.Status.Status), "")
+}
+
+// SetCompletedCondition sets the Completed condition once the migration has
+// finished
+func (m *Migration) SetCompletedCondition() {
+	setCompletedCondition(&m.Status.Conditions, m.Generation,
+		m.Status.Stage == MigrationStageFinal,
+		m.Status.Status == MigrationStatusSuccessful || m.Status.Status == MigrationStatusPartialSuccess,
+		string(m.Status.Status), "")
+}
+
+// SetCompletedCondition sets the Completed condition once the restore has
+// finished
+func (v *VolumeSnapshotRestore) SetCompletedCondition() {
+	setCompletedCondition(&v.Status.Conditions, v.Generation,
+		v.Status.Status == VolumeSnapshotRestoreStatusSuccessful || v.Status.Status == VolumeSnapshotRestoreStatusFailed,
+		v.Status.Status == VolumeSnapshotRestoreStatusSuccessful,
+		string(v.Status.Status), "")
+}
@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DataCopyResourceName is name for "datacopy" resource
+	DataCopyResourceName = "datacopy"
+	// DataCopyResourcePlural is plural for "datacopy" resource
+	DataCopyResourcePlural = "datacopies"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DataCopy copies the data of a PVC to another PVC with the KDMP data mover,
+// e.g. to move an application to another storage class or namespace. The
+// destination PVC is created if it doesn't exist. If it already exists only
+// the files that changed are copied, so a DataCopy to the same destination
+// can be created again to catch up with the source before switching over.
+type DataCopy struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            DataCopySpec   `json:"spec"`
+	Status          DataCopyStatus `json:"status,omitempty"`
+}
+
+// DataCopySpec is the spec for copying the data of a PVC. The source and
+// destination can only be in other namespaces than the DataCopy if it is in
+// the admin namespace.
+type DataCopySpec struct {
+	// Source is the PVC whose data is copied
+	Source DataCopyPVC `json:"source"`
+	// Destination is the PVC the data is copied to
+	Destination DataCopyPVC `json:"destination"`
+	// StorageClassName is the storage class used to create the destination
+	// PVC if it doesn't exist. Defaults to the default storage class.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+	// SnapshotClassName is the CSI VolumeSnapshotClass used to snapshot the
+	// source PVC so that it can be copied while it is in use. It is required
+	// to copy to another namespace. Without it the source PVC can't be used
+	// by any pods during the copy.
+	SnapshotClassName string `json:"snapshotClassName,omitempty"`
+}
+
+// DataCopyPVC is a PVC that is copied from or to
+type DataCopyPVC struct {
+	// Name of the PVC
+	Name string `json:"name"`
+	// Namespace of the PVC. Defaults to the namespace of the DataCopy.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DataCopyStatus is the status of a copy
+type DataCopyStatus struct {
+	Status DataCopyStatusType `json:"status,omitempty"`
+	Reason string             `json:"reason,omitempty"`
+	// ProgressPercentage is how much of the data has been copied
+	ProgressPercentage int `json:"progressPercentage,omitempty"`
+	// DestinationCreated is set if the destination PVC was created for the
+	// copy
+	DestinationCreated bool      `json:"destinationCreated,omitempty"`
+	StartTimestamp     meta.Time `json:"startTimestamp,omitempty"`
+	FinishTimestamp    meta.Time `json:"finishTimestamp,omitempty"`
+	// ObservedGeneration is the generation of the copy that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// DataCopyStatusType is the status of a copy
+type DataCopyStatusType string
+
+const (
+	// DataCopyStatusInitial is the initial status when the copy is created
+	DataCopyStatusInitial DataCopyStatusType = ""
+	// DataCopyStatusInProgress for when the data is being copied
+	DataCopyStatusInProgress DataCopyStatusType = "InProgress"
+	// DataCopyStatusFailed for when the copy has failed
+	DataCopyStatusFailed DataCopyStatusType = "Failed"
+	// DataCopyStatusSuccessful for when the data has been copied
+	DataCopyStatusSuccessful DataCopyStatusType = "Successful"
+)
+
+// GetSourceNamespace returns the namespace of the source PVC
+func (d *DataCopy) GetSourceNamespace() string {
+	if d.Spec.Source.Namespace != "" {
+		return d.Spec.Source.Namespace
+	}
+	return d.Namespace
+}
+
+// GetDestinationNamespace returns the namespace of the destination PVC
+func (d *DataCopy) GetDestinationNamespace() string {
+	if d.Spec.Destination.Namespace != "" {
+		return d.Spec.Destination.Namespace
+	}
+	return d.Namespace
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DataCopyList is a list of DataCopies
+type DataCopyList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []DataCopy `json:"items"`
+}
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DataProtectionStatusResourceName is name for "dataprotectionstatus" resource
+	DataProtectionStatusResourceName = "dataprotectionstatus"
+	// DataProtectionStatusResourcePlural is plural for "dataprotectionstatus" resource
+	DataProtectionStatusResourcePlural = "dataprotectionstatuses"
+	// DataProtectionStatusObjectName is the name of the DataProtectionStatus
+	// object created in each protected namespace
+	DataProtectionStatusObjectName = "stork-data-protection-status"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DataProtectionStatus reports the recovery point and recovery time achieved
+// for the applications in a namespace. It is updated periodically by stork
+// and is meant to be consumed by dashboards.
+type DataProtectionStatus struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Status          NamespaceDataProtectionStatus `json:"status"`
+}
+
+// NamespaceDataProtectionStatus is the data protection status of a namespace
+type NamespaceDataProtectionStatus struct {
+	// LastBackup is the last successful backup of the namespace
+	LastBackup *DataProtectionEvent `json:"lastBackup,omitempty"`
+	// LastMigration is the last successful migration of the namespace
+	LastMigration *DataProtectionEvent `json:"lastMigration,omitempty"`
+	// RPO is the age of the last successful backup or migration, whichever
+	// is more recent
+	RPO *meta.Duration `json:"rpo,omitempty"`
+	// LastDrill is the last successful DR drill that recovered the namespace
+	LastDrill *DataProtectionEvent `json:"lastDrill,omitempty"`
+	// RTO is the recovery time measured by the last successful DR drill
+	RTO *meta.Duration `json:"rto,omitempty"`
+	// LastUpdateTimestamp is the time the status was last updated
+	LastUpdateTimestamp meta.Time `json:"lastUpdateTimestamp"`
+}
+
+// DataProtectionEvent points to the object that completed a data protection
+// operation
+type DataProtectionEvent struct {
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
+	Timestamp meta.Time `json:"timestamp"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DataProtectionStatusList is a list of DataProtectionStatuses
+type DataProtectionStatusList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []DataProtectionStatus `json:"items"`
+}
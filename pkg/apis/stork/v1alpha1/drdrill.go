@@ -0,0 +1,178 @@
+package v1alpha1
+
+import (
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DRDrillResourceName is name for "drdrill" resource
+	DRDrillResourceName = "drdrill"
+	// DRDrillResourcePlural is plural for "drdrill" resource
+	DRDrillResourcePlural = "drdrills"
+	// DRDrillReportResourceName is name for "drdrillreport" resource
+	DRDrillReportResourceName = "drdrillreport"
+	// DRDrillReportResourcePlural is plural for "drdrillreport" resource
+	DRDrillReportResourcePlural = "drdrillreports"
+	// DefaultDRDrillHealthCheckTimeout is the default for HealthCheckTimeout
+	DefaultDRDrillHealthCheckTimeout = 30 * time.Minute
+	// DefaultDRDrillReportsToKeep is the default for ReportsToKeep
+	DefaultDRDrillReportsToKeep = 10
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DRDrill periodically tests that applications can be recovered on the
+// cluster without disrupting them. Each run recovers the applications into
+// isolated namespaces, checks that they become healthy, records the recovery
+// time in a DRDrillReport and then deletes everything it created.
+type DRDrill struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            DRDrillSpec   `json:"spec"`
+	Status          DRDrillStatus `json:"status,omitempty"`
+}
+
+// DRDrillSpec is the spec for a DR drill. Exactly one of BackupScheduleName
+// and MigratedNamespaces needs to be set.
+type DRDrillSpec struct {
+	// SchedulePolicyName is the schedule policy used to trigger the drills
+	SchedulePolicyName string `json:"schedulePolicyName"`
+	// Suspend stops new drills from being triggered
+	Suspend *bool `json:"suspend,omitempty"`
+	// BackupScheduleName is the ApplicationBackupSchedule in the namespace
+	// of the drill whose latest successful backup is restored
+	BackupScheduleName string `json:"backupScheduleName,omitempty"`
+	// MigratedNamespaces are namespaces migrated to this cluster whose
+	// applications are cloned and activated. The drill needs to be in the
+	// admin namespace to clone them.
+	MigratedNamespaces []string `json:"migratedNamespaces,omitempty"`
+	// HealthCheckTimeout is how long to wait for the recovered Deployments
+	// and StatefulSets to become ready. Defaults to
+	// @DefaultDRDrillHealthCheckTimeout.
+	HealthCheckTimeout *meta.Duration `json:"healthCheckTimeout,omitempty"`
+	// ReportsToKeep is the number of DRDrillReports kept for the drill.
+	// Defaults to @DefaultDRDrillReportsToKeep.
+	ReportsToKeep int `json:"reportsToKeep,omitempty"`
+}
+
+// DRDrillStatus is the status of the current or last run of a DR drill
+type DRDrillStatus struct {
+	Stage  DRDrillStageType  `json:"stage"`
+	Status DRDrillStatusType `json:"status"`
+	Reason string            `json:"reason"`
+	// PolicyType is the type of the policy that triggered the run
+	PolicyType SchedulePolicyType `json:"policyType,omitempty"`
+	// TriggerTimestamp is the time the run was triggered
+	TriggerTimestamp meta.Time `json:"triggerTimestamp"`
+	// BackupName is the backup being restored
+	BackupName string `json:"backupName,omitempty"`
+	// RestoreName is the ApplicationRestore created for the run
+	RestoreName string `json:"restoreName,omitempty"`
+	// CloneNames are the ApplicationClones created for the run
+	CloneNames []string `json:"cloneNames,omitempty"`
+	// Namespaces are the isolated namespaces the applications were
+	// recovered into
+	Namespaces []string `json:"namespaces,omitempty"`
+	// RecoveryTime is the time it took from the trigger until the
+	// recovered applications were healthy
+	RecoveryTime *meta.Duration `json:"recoveryTime,omitempty"`
+	// Checks are the results of the health checks
+	Checks []*DRDrillCheck `json:"checks,omitempty"`
+	// LastReport is the name of the DRDrillReport of the last completed run
+	LastReport string `json:"lastReport,omitempty"`
+}
+
+// DRDrillCheck is the result of the health check of a recovered workload
+type DRDrillCheck struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Ready     bool   `json:"ready"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// DRDrillStageType is the stage of a DR drill run
+type DRDrillStageType string
+
+const (
+	// DRDrillStageInitial is when no run has been triggered yet
+	DRDrillStageInitial DRDrillStageType = ""
+	// DRDrillStageRecover is when the applications are being restored or
+	// cloned
+	DRDrillStageRecover DRDrillStageType = "Recover"
+	// DRDrillStageHealthCheck is when waiting for the recovered applications
+	// to become healthy
+	DRDrillStageHealthCheck DRDrillStageType = "HealthCheck"
+	// DRDrillStageTeardown is when the recovered applications are being
+	// deleted
+	DRDrillStageTeardown DRDrillStageType = "Teardown"
+	// DRDrillStageFinal is when the run has completed
+	DRDrillStageFinal DRDrillStageType = "Final"
+)
+
+// DRDrillStatusType is the status of a DR drill run
+type DRDrillStatusType string
+
+const (
+	// DRDrillStatusInitial is when no run has been triggered yet
+	DRDrillStatusInitial DRDrillStatusType = ""
+	// DRDrillStatusInProgress is when a run is in progress
+	DRDrillStatusInProgress DRDrillStatusType = "InProgress"
+	// DRDrillStatusFailed is when the applications couldn't be recovered
+	// or didn't become healthy
+	DRDrillStatusFailed DRDrillStatusType = "Failed"
+	// DRDrillStatusSuccessful is when the applications were recovered and
+	// became healthy
+	DRDrillStatusSuccessful DRDrillStatusType = "Successful"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DRDrillList is a list of DRDrills
+type DRDrillList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []DRDrill `json:"items"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DRDrillReport is the report of a completed DR drill run
+type DRDrillReport struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Report          DRDrillReportSpec `json:"report"`
+}
+
+// DRDrillReportSpec has the results of a DR drill run
+type DRDrillReportSpec struct {
+	// DrillName is the name of the DRDrill
+	DrillName string            `json:"drillName"`
+	Status    DRDrillStatusType `json:"status"`
+	Reason    string            `json:"reason"`
+	// BackupName is the backup that was restored
+	BackupName string `json:"backupName,omitempty"`
+	// MigratedNamespaces are the namespaces that were cloned
+	MigratedNamespaces []string  `json:"migratedNamespaces,omitempty"`
+	TriggerTimestamp   meta.Time `json:"triggerTimestamp"`
+	FinishTimestamp    meta.Time `json:"finishTimestamp"`
+	// RecoveryTime is the time it took from the trigger until the
+	// recovered applications were healthy
+	RecoveryTime *meta.Duration  `json:"recoveryTime,omitempty"`
+	Checks       []*DRDrillCheck `json:"checks,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// DRDrillReportList is a list of DRDrillReports
+type DRDrillReportList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []DRDrillReport `json:"items"`
+}
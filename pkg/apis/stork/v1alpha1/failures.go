@@ -0,0 +1,83 @@
+package v1alpha1
+
+const pvcKind = "PersistentVolumeClaim"
+
+// FailedItem is a volume or resource that couldn't be processed by an
+// operation. Operations that fail for some of their items finish with the
+// PartialSuccess status unless failOnPartial is set in their spec.
+type FailedItem struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// GetFailedItems returns the volumes that couldn't be backed up
+func (a *ApplicationBackup) GetFailedItems() []FailedItem {
+	failed := make([]FailedItem, 0)
+	for _, vInfo := range a.Status.Volumes {
+		if vInfo.Status == ApplicationBackupStatusFailed {
+			failed = append(failed, FailedItem{
+				Kind:      pvcKind,
+				Namespace: vInfo.Namespace,
+				Name:      vInfo.PersistentVolumeClaim,
+				Reason:    vInfo.Reason,
+			})
+		}
+	}
+	return failed
+}
+
+// GetFailedItems returns the volumes and resources that couldn't be
+// restored
+func (a *ApplicationRestore) GetFailedItems() []FailedItem {
+	failed := make([]FailedItem, 0)
+	for _, vInfo := range a.Status.Volumes {
+		if vInfo.Status == ApplicationRestoreStatusFailed {
+			failed = append(failed, FailedItem{
+				Kind:      pvcKind,
+				Namespace: vInfo.SourceNamespace,
+				Name:      vInfo.PersistentVolumeClaim,
+				Reason:    vInfo.Reason,
+			})
+		}
+	}
+	for _, resource := range a.Status.Resources {
+		if resource.Status == ApplicationRestoreStatusFailed {
+			failed = append(failed, FailedItem{
+				Kind:      resource.Kind,
+				Namespace: resource.Namespace,
+				Name:      resource.Name,
+				Reason:    resource.Reason,
+			})
+		}
+	}
+	return failed
+}
+
+// GetFailedItems returns the volumes and resources that couldn't be
+// migrated
+func (m *Migration) GetFailedItems() []FailedItem {
+	failed := make([]FailedItem, 0)
+	for _, vInfo := range m.Status.Volumes {
+		if vInfo.Status == MigrationStatusFailed {
+			failed = append(failed, FailedItem{
+				Kind:      pvcKind,
+				Namespace: vInfo.Namespace,
+				Name:      vInfo.PersistentVolumeClaim,
+				Reason:    vInfo.Reason,
+			})
+		}
+	}
+	for _, resource := range m.Status.Resources {
+		if resource.Status == MigrationStatusFailed {
+			failed = append(failed, FailedItem{
+				Kind:      resource.Kind,
+				Namespace: resource.Namespace,
+				Name:      resource.Name,
+				Reason:    resource.Reason,
+			})
+		}
+	}
+	return failed
+}
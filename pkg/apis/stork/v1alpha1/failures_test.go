@@ -0,0 +1,59 @@
+//go:build unittest
+// +build unittest
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupFailedItems(t *testing.T) {
+	backup := &ApplicationBackup{}
+	require.Empty(t, backup.GetFailedItems())
+
+	backup.Status.Volumes = []*ApplicationBackupVolumeInfo{
+		{Namespace: "ns", PersistentVolumeClaim: "pvc1", Status: ApplicationBackupStatusSuccessful},
+		{Namespace: "ns", PersistentVolumeClaim: "pvc2", Status: ApplicationBackupStatusFailed, Reason: "timeout"},
+	}
+	require.Equal(t, []FailedItem{
+		{Kind: "PersistentVolumeClaim", Namespace: "ns", Name: "pvc2", Reason: "timeout"},
+	}, backup.GetFailedItems())
+}
+
+func TestRestoreFailedItems(t *testing.T) {
+	restore := &ApplicationRestore{}
+	restore.Status.Volumes = []*ApplicationRestoreVolumeInfo{
+		{SourceNamespace: "ns", PersistentVolumeClaim: "pvc1", Status: ApplicationRestoreStatusFailed, Reason: "no space"},
+		{SourceNamespace: "ns", PersistentVolumeClaim: "pvc2", Status: ApplicationRestoreStatusSuccessful},
+	}
+	restore.Status.Resources = []*ApplicationRestoreResourceInfo{
+		{
+			ObjectInfo: ObjectInfo{Name: "app", Namespace: "ns"},
+			Status:     ApplicationRestoreStatusRetained,
+		},
+		{
+			ObjectInfo: ObjectInfo{Name: "config", Namespace: "ns"},
+			Status:     ApplicationRestoreStatusFailed,
+			Reason:     "invalid",
+		},
+	}
+	restore.Status.Resources[1].Kind = "ConfigMap"
+	require.Equal(t, []FailedItem{
+		{Kind: "PersistentVolumeClaim", Namespace: "ns", Name: "pvc1", Reason: "no space"},
+		{Kind: "ConfigMap", Namespace: "ns", Name: "config", Reason: "invalid"},
+	}, restore.GetFailedItems())
+}
+
+func TestMigrationFailedItems(t *testing.T) {
+	migration := &Migration{}
+	migration.Status.Resources = []*MigrationResourceInfo{
+		{Name: "app", Namespace: "ns", Status: MigrationStatusSuccessful},
+		{Name: "svc", Namespace: "ns", Status: MigrationStatusFailed, Reason: "conflict"},
+	}
+	migration.Status.Resources[1].Kind = "Service"
+	require.Equal(t, []FailedItem{
+		{Kind: "Service", Namespace: "ns", Name: "svc", Reason: "conflict"},
+	}, migration.GetFailedItems())
+}
@@ -0,0 +1,165 @@
+package v1alpha1
+
+// IsTerminal returns true once the backup has finished and has been exported
+// if it needed to be
+func (a *ApplicationBackup) IsTerminal() bool {
+	if a.Status.Stage != ApplicationBackupStageFinal {
+		return false
+	}
+	exportPending := a.Spec.OCIExport != nil && a.Status.OCIArtifact == "" &&
+		(a.Status.Status == ApplicationBackupStatusSuccessful || a.Status.Status == ApplicationBackupStatusPartialSuccess)
+	return !exportPending
+}
+
+// GetObservedGeneration returns the generation of the backup that was last
+// handled by the controller
+func (a *ApplicationBackup) GetObservedGeneration() int64 {
+	return a.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the backup that was last
+// handled by the controller
+func (a *ApplicationBackup) SetObservedGeneration(generation int64) {
+	a.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the restore has finished, the init container
+// has been removed from the restored workloads and the sandbox namespaces
+// have expired
+func (a *ApplicationRestore) IsTerminal() bool {
+	if a.Status.Stage != ApplicationRestoreStageFinal {
+		return false
+	}
+	if a.Spec.InitContainer != nil && !a.Status.InitContainerRemoved &&
+		a.Status.Status != ApplicationRestoreStatusFailed {
+		return false
+	}
+	return !a.Spec.Sandbox || a.Status.SandboxDeleted
+}
+
+// GetObservedGeneration returns the generation of the restore that was last
+// handled by the controller
+func (a *ApplicationRestore) GetObservedGeneration() int64 {
+	return a.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the restore that was last
+// handled by the controller
+func (a *ApplicationRestore) SetObservedGeneration(generation int64) {
+	a.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the clone has finished
+func (a *ApplicationClone) IsTerminal() bool {
+	return a.Status.Stage == ApplicationCloneStageFinal
+}
+
+// GetObservedGeneration returns the generation of the clone that was last
+// handled by the controller
+func (a *ApplicationClone) GetObservedGeneration() int64 {
+	return a.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the clone that was last
+// handled by the controller
+func (a *ApplicationClone) SetObservedGeneration(generation int64) {
+	a.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the migration has finished and the
+// applications have been started on the source again if the final sync of a
+// cutover failed
+func (m *Migration) IsTerminal() bool {
+	if m.Status.Stage != MigrationStageFinal {
+		return false
+	}
+	staging := m.Status.Staging
+	rollbackPending := m.Spec.Staging != nil && staging != nil &&
+		staging.CutoverStartTimestamp != nil && staging.CutoverFinishTimestamp == nil &&
+		!staging.RolledBack && m.Status.Status == MigrationStatusFailed
+	return !rollbackPending
+}
+
+// GetObservedGeneration returns the generation of the migration that was
+// last handled by the controller
+func (m *Migration) GetObservedGeneration() int64 {
+	return m.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the migration that was last
+// handled by the controller
+func (m *Migration) SetObservedGeneration(generation int64) {
+	m.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the restore has succeeded or failed
+func (v *VolumeSnapshotRestore) IsTerminal() bool {
+	return v.Status.Status == VolumeSnapshotRestoreStatusSuccessful ||
+		v.Status.Status == VolumeSnapshotRestoreStatusFailed
+}
+
+// GetObservedGeneration returns the generation of the restore that was last
+// handled by the controller
+func (v *VolumeSnapshotRestore) GetObservedGeneration() int64 {
+	return v.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the restore that was last
+// handled by the controller
+func (v *VolumeSnapshotRestore) SetObservedGeneration(generation int64) {
+	v.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the group snapshot has finished. Changes to
+// the restore namespaces in the spec are still handled since they change the
+// generation.
+func (g *GroupVolumeSnapshot) IsTerminal() bool {
+	return g.Status.Stage == GroupSnapshotStageFinal
+}
+
+// GetObservedGeneration returns the generation of the group snapshot that
+// was last handled by the controller
+func (g *GroupVolumeSnapshot) GetObservedGeneration() int64 {
+	return g.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the group snapshot that was
+// last handled by the controller
+func (g *GroupVolumeSnapshot) SetObservedGeneration(generation int64) {
+	g.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the copy has succeeded or failed
+func (d *DataCopy) IsTerminal() bool {
+	return d.Status.Status == DataCopyStatusSuccessful || d.Status.Status == DataCopyStatusFailed
+}
+
+// GetObservedGeneration returns the generation of the copy that was last
+// handled by the controller
+func (d *DataCopy) GetObservedGeneration() int64 {
+	return d.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the copy that was last
+// handled by the controller
+func (d *DataCopy) SetObservedGeneration(generation int64) {
+	d.Status.ObservedGeneration = generation
+}
+
+// IsTerminal returns true once the update has succeeded or failed
+func (c *ClusterDomainUpdate) IsTerminal() bool {
+	return c.Status.Status == ClusterDomainUpdateStatusSuccessful ||
+		c.Status.Status == ClusterDomainUpdateStatusFailed
+}
+
+// GetObservedGeneration returns the generation of the update that was last
+// handled by the controller
+func (c *ClusterDomainUpdate) GetObservedGeneration() int64 {
+	return c.Status.ObservedGeneration
+}
+
+// SetObservedGeneration sets the generation of the update that was last
+// handled by the controller
+func (c *ClusterDomainUpdate) SetObservedGeneration(generation int64) {
+	c.Status.ObservedGeneration = generation
+}
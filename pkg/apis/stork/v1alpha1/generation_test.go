@@ -0,0 +1,41 @@
+//go:build unittest
+// +build unittest
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupIsTerminal(t *testing.T) {
+	backup := &ApplicationBackup{}
+	require.False(t, backup.IsTerminal())
+
+	backup.Status.Stage = ApplicationBackupStageFinal
+	backup.Status.Status = ApplicationBackupStatusSuccessful
+	require.True(t, backup.IsTerminal())
+
+	// The backup still needs to be exported
+	backup.Spec.OCIExport = &OCIExportSpec{}
+	require.False(t, backup.IsTerminal())
+	backup.Status.OCIArtifact = "registry/backup@sha256:1234"
+	require.True(t, backup.IsTerminal())
+}
+
+func TestRestoreIsTerminal(t *testing.T) {
+	restore := &ApplicationRestore{}
+	restore.Status.Stage = ApplicationRestoreStageFinal
+	restore.Status.Status = ApplicationRestoreStatusSuccessful
+	require.True(t, restore.IsTerminal())
+
+	// The sandbox namespaces still need to be deleted once they expire
+	restore.Spec.Sandbox = true
+	require.False(t, restore.IsTerminal())
+	restore.Status.SandboxDeleted = true
+	require.True(t, restore.IsTerminal())
+
+	restore.SetObservedGeneration(3)
+	require.Equal(t, int64(3), restore.GetObservedGeneration())
+}
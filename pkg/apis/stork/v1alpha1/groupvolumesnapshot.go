@@ -40,6 +40,15 @@ type GroupVolumeSnapshotSpec struct {
 	MaxRetries int `json:"maxRetries"`
 	// Options are pass-through parameters that are passed to the driver handling the group snapshot
 	Options map[string]string `json:"options"`
+	// QuiesceVolumes quiesces IO on all the volumes in the group through the
+	// driver while they are snapshotted, for drivers that support it
+	QuiesceVolumes bool `json:"quiesceVolumes,omitempty"`
+	// SnapshotLabels are added to the snapshots that are created. Values
+	// are templates that can use {{.Name}} and {{.Timestamp}}.
+	SnapshotLabels map[string]string `json:"snapshotLabels,omitempty"`
+	// SnapshotAnnotations are added to the snapshots that are created.
+	// Values are templates like for SnapshotLabels.
+	SnapshotAnnotations map[string]string `json:"snapshotAnnotations,omitempty"`
 }
 
 // PVCSelectorSpec is the spec to select the PVCs for group snapshot
@@ -63,6 +72,13 @@ type GroupVolumeSnapshotStatus struct {
 	Status          GroupVolumeSnapshotStatusType `json:"status"`
 	NumRetries      int                           `json:"numRetries"`
 	VolumeSnapshots []*VolumeSnapshotStatus       `json:"volumeSnapshots"`
+	// ObservedGeneration is the generation of the group snapshot that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CleanupError is the last error hit while cleaning up the group snapshot
+	// after it was deleted. The group snapshot is held back by the cleanup
+	// finalizer until the cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // VolumeSnapshotStatus captures the status of a volume snapshot operation
@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	"time"
+
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -9,6 +11,9 @@ const (
 	MigrationResourceName = "migration"
 	// MigrationResourcePlural is plural for "migration" resource
 	MigrationResourcePlural = "migrations"
+	// DefaultMigrationSyncInterval is the default interval between the syncs
+	// of staged migrations
+	DefaultMigrationSyncInterval = 15 * time.Minute
 )
 
 // MigrationSpec is the spec used to migrate apps between clusterpairs
@@ -26,6 +31,69 @@ type MigrationSpec struct {
 	PostExecRule                 string            `json:"postExecRule"`
 	IncludeOptionalResourceTypes []string          `json:"includeOptionalResourceTypes"`
 	SkipDeletedNamespaces        *bool             `json:"skipDeletedNamespaces"`
+	// DiffOnly, if set, only computes what would change on the destination
+	// cluster without migrating any volumes or resources
+	DiffOnly *bool `json:"diffOnly,omitempty"`
+	// SecretTypes filters the Secrets to be migrated by their type
+	SecretTypes *SecretTypeFilter `json:"secretTypes,omitempty"`
+	// JobPolicy decides how Jobs and CronJobs are migrated. CronJobs are
+	// always started if StartApplications is set.
+	JobPolicy *JobPolicy `json:"jobPolicy,omitempty"`
+	// ServiceMeshPolicy decides how the service mesh configuration of the
+	// applications is migrated
+	ServiceMeshPolicy *ServiceMeshPolicy `json:"serviceMeshPolicy,omitempty"`
+	// StorageClassCreation, if set, creates the storage classes of the
+	// migrated PVCs that don't exist on the destination from their
+	// definitions on the source
+	StorageClassCreation *StorageClassCreationPolicy `json:"storageClassCreation,omitempty"`
+	// FailOnPartial marks the migration as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be migrated
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// migration is deleted once it has finished. The default from the stork
+	// configuration is used if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// RegistryMappingName is the name of a RegistryMapping in the namespace
+	// of the migration used to rewrite the images of the migrated workloads
+	RegistryMappingName string `json:"registryMappingName,omitempty"`
+	// Staging, if set, keeps syncing the volumes and resources to the
+	// destination in the background until a cutover is requested. The
+	// applications aren't started on the destination until the cutover. It
+	// isn't supported in the templates of MigrationSchedules.
+	Staging *MigrationStagingPolicy `json:"staging,omitempty"`
+}
+
+// MigrationStagingPolicy is the policy for migrations that are staged on the
+// destination before the applications are cut over
+type MigrationStagingPolicy struct {
+	// SyncInterval is how long to wait after a sync has finished before the
+	// next one is started. Defaults to @DefaultMigrationSyncInterval.
+	SyncInterval *meta.Duration `json:"syncInterval,omitempty"`
+	// Cutover stops the applications on the source once the current sync has
+	// finished, runs a final sync and starts the applications on the
+	// destination. StartApplications is ignored for the final sync. The
+	// applications are started again on the source if the final sync fails.
+	Cutover bool `json:"cutover,omitempty"`
+}
+
+// MigrationStagingStatus is the status of a staged migration
+type MigrationStagingStatus struct {
+	// Syncs is the number of syncs that have finished, including the final
+	// sync of the cutover
+	Syncs int `json:"syncs"`
+	// LastSyncTimestamp is when the last sync finished
+	LastSyncTimestamp meta.Time `json:"lastSyncTimestamp,omitempty"`
+	// CutoverStartTimestamp is when the applications started to be stopped
+	// on the source for the cutover
+	CutoverStartTimestamp *meta.Time `json:"cutoverStartTimestamp,omitempty"`
+	// CutoverFinishTimestamp is when the applications were started on the
+	// destination after the final sync
+	CutoverFinishTimestamp *meta.Time `json:"cutoverFinishTimestamp,omitempty"`
+	// Downtime is how long the applications were down during the cutover
+	Downtime *meta.Duration `json:"downtime,omitempty"`
+	// RolledBack is set once the applications have been started again on
+	// the source after the final sync failed
+	RolledBack bool `json:"rolledBack,omitempty"`
 }
 
 // MigrationStatus is the status of a migration operation
@@ -39,6 +107,47 @@ type MigrationStatus struct {
 	ResourceMigrationFinishTimestamp meta.Time                `json:"resourceMigrationFinishTimestamp"`
 	// Summary provides a short summary on the migration
 	Summary *MigrationSummary `json:"summary"`
+	// Diff is the set of changes that would be made on the destination
+	// cluster. Only set for migrations with DiffOnly set.
+	Diff *MigrationDiff `json:"diff,omitempty"`
+	// EventHistory holds the most recent events recorded for the migration
+	EventHistory []*EventHistoryEntry `json:"eventHistory,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be migrated
+	FailedItems []FailedItem `json:"failedItems,omitempty"`
+	// Conditions holds the Completed condition, which is set once the migration
+	// has finished
+	Conditions []meta.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the migration that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Staging is the status of the syncs and the cutover of a staged
+	// migration
+	Staging *MigrationStagingStatus `json:"staging,omitempty"`
+	// CleanupError is the last error hit while cleaning up the migration after
+	// it was deleted. The migration is held back by the cleanup finalizer
+	// until the cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
+}
+
+// MigrationDiff lists the objects that would be changed on the destination
+// cluster by a migration
+type MigrationDiff struct {
+	// Create are the objects that don't exist on the destination
+	Create []*ObjectInfo `json:"create"`
+	// Update are the objects that exist on the destination but differ from
+	// the source
+	Update []*ObjectInfo `json:"update"`
+	// Delete are the migrated objects on the destination that no longer
+	// exist on the source
+	Delete []*ObjectInfo `json:"delete"`
+}
+
+// MigrationDiffSummary is the number of objects that would be changed on the
+// destination cluster by a migration
+type MigrationDiffSummary struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
 }
 
 // MigrationResourceInfo is the info for the migration of a resource
@@ -127,6 +236,9 @@ const (
 	MigrationStageVolumes MigrationStageType = "Volumes"
 	// MigrationStageApplications for when applications are being migrated
 	MigrationStageApplications MigrationStageType = "Applications"
+	// MigrationStageStaged for when a staged migration is waiting for the
+	// next sync or for the applications to stop for the cutover
+	MigrationStageStaged MigrationStageType = "Staged"
 	// MigrationStageFinal is the final stage for migration
 	MigrationStageFinal MigrationStageType = "Final"
 )
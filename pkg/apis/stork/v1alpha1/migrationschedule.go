@@ -17,6 +17,11 @@ type MigrationScheduleSpec struct {
 	SchedulePolicyName string                `json:"schedulePolicyName"`
 	Suspend            *bool                 `json:"suspend"`
 	AutoSuspend        bool                  `json:"autoSuspend"`
+	// MissedRunPolicy is the policy for runs missed while stork or the
+	// cluster was down. Runs missed by more than an hour are skipped for
+	// daily, weekly and monthly policies and a single run is triggered for
+	// interval policies if not set.
+	MissedRunPolicy MissedRunPolicyType `json:"missedRunPolicy,omitempty"`
 }
 
 // MigrationTemplateSpec describes the data a Migration should have when created
@@ -29,6 +34,15 @@ type MigrationTemplateSpec struct {
 type MigrationScheduleStatus struct {
 	Items                map[SchedulePolicyType][]*ScheduledMigrationStatus `json:"items"`
 	ApplicationActivated bool                                               `json:"applicationActivated"`
+	// DeferredSince is the time since which the pending run has been
+	// deferred because the cluster is under maintenance
+	DeferredSince meta.Time `json:"deferredSince,omitempty"`
+	// DeferredReason is the reason the pending run is deferred
+	DeferredReason string `json:"deferredReason,omitempty"`
+	// CleanupError is the last error hit while cleaning up the migration
+	// schedule after it was deleted. The migration schedule is held back by
+	// the cleanup finalizer until the cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // ScheduledMigrationStatus keeps track of the migration that was triggered by a
@@ -38,6 +52,12 @@ type ScheduledMigrationStatus struct {
 	CreationTimestamp meta.Time           `json:"creationTimestamp"`
 	FinishTimestamp   meta.Time           `json:"finishTimestamp"`
 	Status            MigrationStatusType `json:"status"`
+	// DiffSummary is set for migrations that only compute the diff with
+	// the destination cluster
+	DiffSummary *MigrationDiffSummary `json:"diffSummary,omitempty"`
+	// ScheduledTimestamp is the time at which the run was scheduled. It is
+	// only set for runs triggered to catch up for missed runs.
+	ScheduledTimestamp meta.Time `json:"scheduledTimestamp,omitempty"`
 }
 
 // +genclient
@@ -50,6 +50,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ClusterDomainsStatusList{},
 		&ClusterDomainUpdate{},
 		&ClusterDomainUpdateList{},
+		&ClusterRule{},
+		&ClusterRuleList{},
 		&ApplicationClone{},
 		&ApplicationCloneList{},
 		&ApplicationBackup{},
@@ -66,6 +68,18 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ApplicationBackupScheduleList{},
 		&DataExport{},
 		&DataExportList{},
+		&DataCopy{},
+		&DataCopyList{},
+		&StorkConfiguration{},
+		&StorkConfigurationList{},
+		&DRDrill{},
+		&DRDrillList{},
+		&DRDrillReport{},
+		&DRDrillReportList{},
+		&DataProtectionStatus{},
+		&DataProtectionStatusList{},
+		&RegistryMapping{},
+		&RegistryMappingList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
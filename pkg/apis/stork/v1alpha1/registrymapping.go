@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RegistryMappingResourceName is name for "registrymapping" resource
+	RegistryMappingResourceName = "registrymapping"
+	// RegistryMappingResourcePlural is plural for "registrymapping" resource
+	RegistryMappingResourcePlural = "registrymappings"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegistryMapping rewrites the images of the workloads restored or migrated
+// to a cluster that can't pull from the source registries, e.g. an
+// air-gapped cluster with an internal mirror. Restores and migrations use
+// the RegistryMapping named in their spec from their own namespace.
+type RegistryMapping struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            RegistryMappingSpec `json:"spec"`
+}
+
+// RegistryMappingSpec is the spec for rewriting image references
+type RegistryMappingSpec struct {
+	// Registries maps the registries of the source images, e.g. docker.io,
+	// to the registries used on the destination, optionally with a path
+	// prefix, e.g. mirror.example.com/dockerhub. Images whose registry isn't
+	// mapped are kept.
+	Registries map[string]string `json:"registries"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// RegistryMappingList is a list of RegistryMappings
+type RegistryMappingList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []RegistryMapping `json:"items"`
+}
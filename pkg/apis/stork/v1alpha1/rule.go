@@ -47,6 +47,13 @@ type RuleAction struct {
 	RunInSinglePod bool `json:"runInSinglePod,omitempty"`
 	// Value is the actual action value for e.g the command to run
 	Value string `json:"value"`
+	// Template, if set, resolves Go template variables from the object the
+	// rule is run for in the command when the rule is executed:
+	// {{.Namespace}}, {{.Name}}, {{.Kind}}, {{.SnapshotName}}, {{.PVCs}}
+	// (e.g. {{join .PVCs " "}}), {{.Timestamp}} and {{.Time}}. Commands are
+	// run as is if it isn't set.
+	// +optional
+	Template bool `json:"template,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -43,6 +43,37 @@ const (
 	SchedulePolicyTypeMonthly SchedulePolicyType = "Monthly"
 )
 
+// MissedRunPolicyType is the policy for runs of a schedule that were missed
+// while stork or the cluster was down
+type MissedRunPolicyType string
+
+const (
+	// MissedRunPolicySkipAll skips all missed runs and waits for the next
+	// scheduled run
+	MissedRunPolicySkipAll MissedRunPolicyType = "SkipAll"
+	// MissedRunPolicyRunOnce triggers a single run to catch up for all missed
+	// runs
+	MissedRunPolicyRunOnce MissedRunPolicyType = "RunOnce"
+	// MissedRunPolicyRunAll triggers every missed run one after the other
+	MissedRunPolicyRunAll MissedRunPolicyType = "RunAll"
+)
+
+// LoadGate defers scheduled runs while the application is under heavy load,
+// as reported by a Prometheus query
+type LoadGate struct {
+	// PrometheusURL is the address of the Prometheus server, for example
+	// http://prometheus.monitoring:9090
+	PrometheusURL string `json:"prometheusURL"`
+	// Query is the PromQL query that returns the load of the application.
+	// If the query returns more than one series the highest value is used.
+	Query string `json:"query"`
+	// Threshold above which runs are deferred
+	Threshold string `json:"threshold"`
+	// MaxDeferralMinutes is the longest a run is deferred for. The run is
+	// triggered once this is exceeded even if the load is still high.
+	MaxDeferralMinutes int64 `json:"maxDeferralMinutes"`
+}
+
 // GetValidSchedulePolicyTypes returns the valid types of schedule policies that
 // can be configured
 func GetValidSchedulePolicyTypes() []SchedulePolicyType {
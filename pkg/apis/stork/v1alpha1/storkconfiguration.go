@@ -0,0 +1,200 @@
+package v1alpha1
+
+import (
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// StorkConfigurationResourceName is name for "storkconfiguration" resource
+	StorkConfigurationResourceName = "storkconfiguration"
+	// StorkConfigurationResourcePlural is plural for "storkconfiguration" resource
+	StorkConfigurationResourcePlural = "storkconfigurations"
+	// StorkConfigurationName is the name of the StorkConfiguration object
+	// that stork reads its tunables from. Objects with other names are
+	// ignored.
+	StorkConfigurationName = "stork-config"
+	// AllControllersConfigurationKey can be used as the key in
+	// StorkConfigurationSpec.Controllers to apply settings to all controllers
+	AllControllersConfigurationKey = "*"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StorkConfiguration holds tunables for the stork controllers. Changes are
+// picked up without restarting stork unless noted otherwise.
+type StorkConfiguration struct {
+	meta.TypeMeta   `json:",inline"`
+	meta.ObjectMeta `json:"metadata,omitempty"`
+	Spec            StorkConfigurationSpec   `json:"spec"`
+	Status          StorkConfigurationStatus `json:"status,omitempty"`
+}
+
+// StorkConfigurationSpec is the spec for the stork configuration. Fields that
+// are not set fall back to the defaults (or command line flags) of stork.
+type StorkConfigurationSpec struct {
+	// Controllers holds settings per controller, keyed by the controller
+	// name (for example "application-backup-controller"). Settings under the
+	// "*" key apply to all controllers that don't have their own entry.
+	Controllers map[string]ControllerConfiguration `json:"controllers,omitempty"`
+	// ValidateSnapshotTimeout is the time to wait for a snapshot to be ready
+	// before it is considered failed
+	ValidateSnapshotTimeout *meta.Duration `json:"validateSnapshotTimeout,omitempty"`
+	// MigrationMaxThreads is the number of parallel workers used to apply
+	// resources on the destination cluster during a migration
+	MigrationMaxThreads *int `json:"migrationMaxThreads,omitempty"`
+	// RestoreMaxThreads is the number of parallel workers used to apply
+	// resources during an ApplicationRestore
+	RestoreMaxThreads *int `json:"restoreMaxThreads,omitempty"`
+	// BackupVolumeBatchCount is the number of volumes that are backed up in
+	// one batch by an ApplicationBackup
+	BackupVolumeBatchCount *int `json:"backupVolumeBatchCount,omitempty"`
+	// BackupThroughputBytesPerSecond is the throughput used to estimate how
+	// long it takes to back up the data of volumes
+	BackupThroughputBytesPerSecond *int64 `json:"backupThroughputBytesPerSecond,omitempty"`
+	// Maintenance configures how stork detects cluster maintenance such as
+	// upgrades. Scheduled backups, migrations and snapshots aren't triggered
+	// during maintenance. Runs that were missed are handled as per the
+	// missed run policy of the schedules once the maintenance is over.
+	Maintenance *MaintenanceConfiguration `json:"maintenance,omitempty"`
+	// WatchNamespaces restricts the stork controllers to the objects in the
+	// given namespaces, e.g. when a stork instance is run per tenant. All
+	// namespaces are watched if not set. The --watch-namespaces flag takes
+	// precedence. Changes only take effect after stork is restarted.
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+	// ReconcileScheduling limits the reconciles running at once across all
+	// the controllers so that a storm of objects for one controller doesn't
+	// starve the others
+	ReconcileScheduling *ReconcileSchedulingConfiguration `json:"reconcileScheduling,omitempty"`
+	// HealthMonitor configures how fast the health monitor reacts when the
+	// volume driver goes offline on a node
+	HealthMonitor *HealthMonitorConfiguration `json:"healthMonitor,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which finished
+	// migrations, restores and snapshot restores are deleted if they don't
+	// set their own. It doesn't apply to objects that have an owner, like
+	// the ones created by schedules, since they are pruned by their owner.
+	// It doesn't apply to backups either, since deleting them could delete
+	// the backed up data, so backups only expire if they set their own.
+	// Finished objects are kept if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// ScheduleDriftThreshold is how late a scheduled run can start after it
+	// was due before it counts towards the ScheduleDrift condition of the
+	// schedule. Defaults to 5 minutes.
+	ScheduleDriftThreshold *meta.Duration `json:"scheduleDriftThreshold,omitempty"`
+	// ImageCheckRegistries are the registries, e.g. docker.io or quay.io,
+	// that the image checks of restores can query for images that haven't
+	// been pulled on the nodes. The tokens for a registry can only be
+	// requested from the registry itself or one of these hosts. No registry
+	// is queried if it isn't set.
+	ImageCheckRegistries []string `json:"imageCheckRegistries,omitempty"`
+}
+
+// HealthMonitorConfiguration configures the health monitor, which deletes
+// the pods using driver volumes from nodes where the volume driver is offline
+// so that they get rescheduled
+type HealthMonitorConfiguration struct {
+	// Interval is the interval at which the volume driver is checked on
+	// the nodes. Overrides the --health-monitor-interval flag.
+	Interval *meta.Duration `json:"interval,omitempty"`
+	// RecoveryCount is the number of consecutive checks the volume driver on
+	// a node needs to be online before the node is considered recovered.
+	// Checks where the driver is offline are counted across shorter
+	// recoveries, so nodes that flap are still acted on, while the cool-down
+	// limits how often. Defaults to 1.
+	RecoveryCount *int `json:"recoveryCount,omitempty"`
+	// Defaults are the settings for all pods unless they are overridden for
+	// their namespace or storage class
+	Defaults *HealthMonitorSettings `json:"defaults,omitempty"`
+	// Namespaces overrides the settings for the pods in the given namespaces
+	Namespaces map[string]HealthMonitorSettings `json:"namespaces,omitempty"`
+	// StorageClasses overrides the settings for the pods using PVCs of the
+	// given storage classes. The settings for the namespace take precedence.
+	StorageClasses map[string]HealthMonitorSettings `json:"storageClasses,omitempty"`
+}
+
+// HealthMonitorSettings are the settings of the health monitor for a set of
+// pods
+type HealthMonitorSettings struct {
+	// ConfirmationCount is the number of consecutive checks the volume
+	// driver on a node needs to be offline before the pods are deleted from
+	// the node. Defaults to 1.
+	ConfirmationCount *int `json:"confirmationCount,omitempty"`
+	// Cooldown is the minimum time between deleting pods from the same node,
+	// so that pods aren't deleted over and over from a node that flaps
+	Cooldown *meta.Duration `json:"cooldown,omitempty"`
+}
+
+// ReconcileSchedulingConfiguration limits the reconciles running at once
+// across all the controllers. Each controller is still limited by its own
+// MaxConcurrentReconciles.
+type ReconcileSchedulingConfiguration struct {
+	// MaxConcurrentReconciles is the number of reconciles that can run at
+	// once across all the controllers. Unlimited if not set.
+	MaxConcurrentReconciles int `json:"maxConcurrentReconciles,omitempty"`
+	// ReservedReconciles is the number of those reconciles that can't be
+	// used by low priority controllers, so that migrations and restores can
+	// run during a storm of backups
+	ReservedReconciles int `json:"reservedReconciles,omitempty"`
+}
+
+// MaintenanceConfiguration holds the signals used to detect cluster
+// maintenance. The cluster is in maintenance if any of them is set.
+type MaintenanceConfiguration struct {
+	// Enabled can be set for the duration of planned maintenance
+	Enabled bool `json:"enabled,omitempty"`
+	// NodeSelector is a label selector for nodes that are being upgraded,
+	// for example "upgrade.example.com/in-progress=true"
+	NodeSelector string `json:"nodeSelector,omitempty"`
+	// UnschedulableNodesThreshold is the number of unschedulable (cordoned)
+	// nodes at which a drain storm is assumed. Disabled when not set.
+	UnschedulableNodesThreshold int `json:"unschedulableNodesThreshold,omitempty"`
+}
+
+// ControllerConfiguration holds the settings for a single controller
+type ControllerConfiguration struct {
+	// RequeuePeriod is the period after which an object is reconciled again
+	// after a successful reconcile
+	RequeuePeriod *meta.Duration `json:"requeuePeriod,omitempty"`
+	// RequeuePeriodOnError is the period after which an object is reconciled
+	// again after a failed reconcile
+	RequeuePeriodOnError *meta.Duration `json:"requeuePeriodOnError,omitempty"`
+	// MaxConcurrentReconciles is the number of reconciles the controller
+	// runs at once. It can be raised up to 50 without restarting stork, or
+	// up to the value stork was started with if that is higher.
+	MaxConcurrentReconciles *int `json:"maxConcurrentReconciles,omitempty"`
+	// LowPriority controllers can't use the reconciles reserved by the
+	// reconcile scheduling configuration. The ApplicationBackup controller
+	// is low priority by default.
+	LowPriority *bool `json:"lowPriority,omitempty"`
+}
+
+// StorkConfigurationStatus is the status reported by stork
+type StorkConfigurationStatus struct {
+	// VolumeDrivers holds the condition of the volume drivers used by stork
+	VolumeDrivers []VolumeDriverCondition `json:"volumeDrivers,omitempty"`
+}
+
+// VolumeDriverCondition is the condition of a volume driver
+type VolumeDriverCondition struct {
+	// Driver is the name of the volume driver
+	Driver string `json:"driver"`
+	// Degraded is set when calls to the driver keep failing. Calls to the
+	// driver fail without being sent to the driver while it is degraded.
+	Degraded bool `json:"degraded"`
+	// Reason is the last error returned by the driver when it was marked as
+	// degraded
+	Reason string `json:"reason,omitempty"`
+	// LastTransitionTime is the time the condition last changed
+	LastTransitionTime meta.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StorkConfigurationList is a list of StorkConfigurations
+type StorkConfigurationList struct {
+	meta.TypeMeta `json:",inline"`
+	meta.ListMeta `json:"metadata,omitempty"`
+
+	Items []StorkConfiguration `json:"items"`
+}
@@ -0,0 +1,65 @@
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getFinishTime returns the finish timestamp if it was recorded, or else the
+// time the Completed condition was set. Returns a zero time if neither is
+// set.
+func getFinishTime(finishTimestamp metav1.Time, conditions []metav1.Condition) metav1.Time {
+	if !finishTimestamp.IsZero() {
+		return finishTimestamp
+	}
+	if condition := meta.FindStatusCondition(conditions, ConditionCompleted); condition != nil {
+		return condition.LastTransitionTime
+	}
+	return metav1.Time{}
+}
+
+// GetTTLSecondsAfterFinished returns the number of seconds after which the
+// backup is deleted once it has finished
+func (a *ApplicationBackup) GetTTLSecondsAfterFinished() *int32 {
+	return a.Spec.TTLSecondsAfterFinished
+}
+
+// GetFinishTime returns the time the backup finished
+func (a *ApplicationBackup) GetFinishTime() metav1.Time {
+	return getFinishTime(a.Status.FinishTimestamp, a.Status.Conditions)
+}
+
+// GetTTLSecondsAfterFinished returns the number of seconds after which the
+// restore is deleted once it has finished
+func (a *ApplicationRestore) GetTTLSecondsAfterFinished() *int32 {
+	return a.Spec.TTLSecondsAfterFinished
+}
+
+// GetFinishTime returns the time the restore finished
+func (a *ApplicationRestore) GetFinishTime() metav1.Time {
+	return getFinishTime(a.Status.FinishTimestamp, a.Status.Conditions)
+}
+
+// GetTTLSecondsAfterFinished returns the number of seconds after which the
+// migration is deleted once it has finished
+func (m *Migration) GetTTLSecondsAfterFinished() *int32 {
+	return m.Spec.TTLSecondsAfterFinished
+}
+
+// GetFinishTime returns the time the migration finished
+func (m *Migration) GetFinishTime() metav1.Time {
+	return getFinishTime(m.Status.FinishTimestamp, m.Status.Conditions)
+}
+
+// GetTTLSecondsAfterFinished returns the number of seconds after which the
+// restore is deleted once it has finished
+func (v *VolumeSnapshotRestore) GetTTLSecondsAfterFinished() *int32 {
+	return v.Spec.TTLSecondsAfterFinished
+}
+
+// GetFinishTime returns the time the restore finished. Snapshot restores
+// don't record a finish timestamp, so the time the Completed condition was
+// set is used.
+func (v *VolumeSnapshotRestore) GetFinishTime() metav1.Time {
+	return getFinishTime(metav1.Time{}, v.Status.Conditions)
+}
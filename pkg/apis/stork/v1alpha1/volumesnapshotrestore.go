@@ -21,14 +21,49 @@ type VolumeSnapshotRestoreSpec struct {
 	GroupSnapshot bool `json:"groupSnapshot"`
 	// DestinationPVC list to restore snapshot
 	DestinationPVC map[string]string `json:"pvcs,omitempty"`
+	// RepairOwnership runs a job to change the group of the files on the
+	// restored volumes to the fsGroup of the pods using them
+	RepairOwnership bool `json:"repairOwnership,omitempty"`
+	// Fencing is the name of the fencer used to keep pods that aren't
+	// scheduled by stork from using the volumes during the restore. Restores
+	// of volumes used by such pods fail if it isn't set.
+	Fencing string `json:"fencing,omitempty"`
+	// EncryptionSecretMapping maps the secrets holding the encryption keys
+	// of the volumes to the secrets that should be used for the restored
+	// volumes. Secrets are given as <namespace>/<name>, or just the name for
+	// secrets in the namespace of the PVC.
+	// The volumes aren't re-encrypted, so the secrets need to hold the same
+	// keys. Only Kubernetes secrets can be mapped.
+	EncryptionSecretMapping map[string]string `json:"encryptionSecretMapping,omitempty"`
+	// SnapshotReadyTimeout is how long to wait for the snapshots to be ready
+	// before the restore fails. Defaults to the validateSnapshotTimeout from
+	// the stork configuration.
+	SnapshotReadyTimeout *meta.Duration `json:"snapshotReadyTimeout,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// restore is deleted once it has finished. The default from the stork
+	// configuration is used if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
 }
 
+const (
+	// VolumeSnapshotRestoreFencingVolumeAttachment fences volumes by
+	// scaling down the applications using them and deleting their
+	// VolumeAttachments
+	VolumeSnapshotRestoreFencingVolumeAttachment = "VolumeAttachment"
+	// VolumeSnapshotRestoreFencingDriver fences volumes by blocking IO to
+	// them in the volume driver
+	VolumeSnapshotRestoreFencingDriver = "Driver"
+)
+
 // VolumeSnapshotRestoreStatusType is the status of volume in-place restore
 type VolumeSnapshotRestoreStatusType string
 
 const (
 	// VolumeSnapshotRestoreStatusInitial is the initial state when snapshot restore is initiated
 	VolumeSnapshotRestoreStatusInitial VolumeSnapshotRestoreStatusType = ""
+	// VolumeSnapshotRestoreStatusWaitingForSnapshotReady for when the restore
+	// is waiting for the snapshots to be ready
+	VolumeSnapshotRestoreStatusWaitingForSnapshotReady VolumeSnapshotRestoreStatusType = "WaitingForSnapshotReady"
 	// VolumeSnapshotRestoreStatusPending for when restore is in pending state
 	VolumeSnapshotRestoreStatusPending VolumeSnapshotRestoreStatusType = "Pending"
 	// VolumeSnapshotRestoreStatusStaged for when restore has been staged locally
@@ -45,8 +80,21 @@ const (
 type VolumeSnapshotRestoreStatus struct {
 	// Status of volume restore
 	Status VolumeSnapshotRestoreStatusType `json:"status"`
+	// StartTimestamp is the time the restore started waiting for the
+	// snapshots to be ready
+	StartTimestamp meta.Time `json:"startTimestamp,omitempty"`
 	// Volumes list of volume restore information
 	Volumes []*RestoreVolumeInfo `json:"volumes"`
+	// Conditions holds the Completed condition, which is set once the restore
+	// has finished
+	Conditions []meta.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the restore that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CleanupError is the last error hit while cleaning up the restore after
+	// it was deleted. The restore is held back by the cleanup finalizer until
+	// the cleanup succeeds.
+	CleanupError string `json:"cleanupError,omitempty"`
 }
 
 // RestoreVolumeInfo is the info for the restore of a volume
@@ -57,6 +105,9 @@ type RestoreVolumeInfo struct {
 	Snapshot      string                          `json:"snapshot"`
 	RestoreStatus VolumeSnapshotRestoreStatusType `json:"status"`
 	Reason        string                          `json:"reason"`
+	// EncryptionSecret is the secret, as <namespace>/<name>, holding the
+	// encryption key passed to the driver for the volume
+	EncryptionSecret string `json:"encryptionSecret,omitempty"`
 }
 
 // +genclient
@@ -20,6 +20,27 @@ type VolumeSnapshotScheduleSpec struct {
 	ReclaimPolicy      ReclaimPolicyType          `json:"reclaimPolicy"`
 	PreExecRule        string                     `json:"preExecRule"`
 	PostExecRule       string                     `json:"postExecRule"`
+	// QuiesceVolumes quiesces IO on the volumes through the driver while
+	// they are snapshotted, for drivers that support it
+	QuiesceVolumes bool `json:"quiesceVolumes,omitempty"`
+	// SnapshotLabels are added to the snapshots that are created. Values
+	// are templates that can use {{.Name}}, {{.ScheduleName}},
+	// {{.PolicyType}} and {{.Timestamp}}.
+	SnapshotLabels map[string]string `json:"snapshotLabels,omitempty"`
+	// SnapshotAnnotations are added to the snapshots that are created.
+	// Values are templates like for SnapshotLabels.
+	SnapshotAnnotations map[string]string `json:"snapshotAnnotations,omitempty"`
+	// MissedRunPolicy is the policy for runs missed while stork or the
+	// cluster was down. Runs missed by more than an hour are skipped for
+	// daily, weekly and monthly policies and a single run is triggered for
+	// interval policies if not set.
+	MissedRunPolicy MissedRunPolicyType `json:"missedRunPolicy,omitempty"`
+	// LoadGate defers runs while the application is under heavy load
+	LoadGate *LoadGate `json:"loadGate,omitempty"`
+	// RetainBackendSnapshotOnDelete keeps the snapshots in the storage
+	// backend when old snapshots of the schedule are pruned. Only the
+	// VolumeSnapshot objects are deleted.
+	RetainBackendSnapshotOnDelete bool `json:"retainBackendSnapshotOnDelete,omitempty"`
 }
 
 // VolumeSnapshotTemplateSpec describes the data a VolumeSnapshot should have when created
@@ -43,6 +64,13 @@ const (
 // VolumeSnapshotScheduleStatus is the status of a volumesnapshot schedule
 type VolumeSnapshotScheduleStatus struct {
 	Items map[SchedulePolicyType][]*ScheduledVolumeSnapshotStatus `json:"items"`
+	// DeferredSince is the time since which the pending run has been
+	// deferred by the load gate
+	DeferredSince meta.Time `json:"deferredSince,omitempty"`
+	// DeferredReason is the reason the pending run is deferred
+	DeferredReason string `json:"deferredReason,omitempty"`
+	// Conditions holds the ScheduleDrift condition
+	Conditions []meta.Condition `json:"conditions,omitempty"`
 }
 
 // ScheduledVolumeSnapshotStatus keeps track of the volumesnapshot that was triggered by a
@@ -52,6 +80,9 @@ type ScheduledVolumeSnapshotStatus struct {
 	CreationTimestamp meta.Time                          `json:"creationTimestamp"`
 	FinishTimestamp   meta.Time                          `json:"finishTimestamp"`
 	Status            snapv1.VolumeSnapshotConditionType `json:"status"`
+	// ScheduledTimestamp is the time at which the run was scheduled. It is
+	// only set for runs triggered to catch up for missed runs.
+	ScheduledTimestamp meta.Time `json:"scheduledTimestamp,omitempty"`
 }
 
 // +genclient
@@ -1,3 +1,4 @@
+//go:build !ignore_autogenerated
 // +build !ignore_autogenerated
 
 /*
@@ -22,10 +23,27 @@ package v1alpha1
 
 import (
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlibabaConfig) DeepCopyInto(out *AlibabaConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlibabaConfig.
+func (in *AlibabaConfig) DeepCopy() *AlibabaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlibabaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationBackup) DeepCopyInto(out *ApplicationBackup) {
 	*out = *in
@@ -54,6 +72,35 @@ func (in *ApplicationBackup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationBackupEstimate) DeepCopyInto(out *ApplicationBackupEstimate) {
+	*out = *in
+	out.Duration = in.Duration
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]*ApplicationBackupVolumeEstimate, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ApplicationBackupVolumeEstimate)
+				**out = **in
+			}
+		}
+	}
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationBackupEstimate.
+func (in *ApplicationBackupEstimate) DeepCopy() *ApplicationBackupEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationBackupEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationBackupList) DeepCopyInto(out *ApplicationBackupList) {
 	*out = *in
@@ -87,6 +134,27 @@ func (in *ApplicationBackupList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationBackupResourceCheckpoint) DeepCopyInto(out *ApplicationBackupResourceCheckpoint) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationBackupResourceCheckpoint.
+func (in *ApplicationBackupResourceCheckpoint) DeepCopy() *ApplicationBackupResourceCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationBackupResourceCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationBackupResourceInfo) DeepCopyInto(out *ApplicationBackupResourceInfo) {
 	*out = *in
@@ -174,6 +242,11 @@ func (in *ApplicationBackupScheduleSpec) DeepCopyInto(out *ApplicationBackupSche
 		*out = new(bool)
 		**out = **in
 	}
+	if in.LoadGate != nil {
+		in, out := &in.LoadGate, &out.LoadGate
+		*out = new(LoadGate)
+		**out = **in
+	}
 	return
 }
 
@@ -211,6 +284,22 @@ func (in *ApplicationBackupScheduleStatus) DeepCopyInto(out *ApplicationBackupSc
 			(*out)[key] = outVal
 		}
 	}
+	in.DeferredSince.DeepCopyInto(&out.DeferredSince)
+	if in.VolumeStats != nil {
+		in, out := &in.VolumeStats, &out.VolumeStats
+		*out = make(map[string]*ScheduledVolumeStats, len(*in))
+		for key, val := range *in {
+			var outVal *ScheduledVolumeStats
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ScheduledVolumeStats)
+				**out = **in
+			}
+			(*out)[key] = outVal
+		}
+	}
 	return
 }
 
@@ -256,6 +345,21 @@ func (in *ApplicationBackupSpec) DeepCopyInto(out *ApplicationBackupSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SecretTypes != nil {
+		in, out := &in.SecretTypes, &out.SecretTypes
+		*out = new(SecretTypeFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OCIExport != nil {
+		in, out := &in.OCIExport, &out.OCIExport
+		*out = new(OCIExportSpec)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -297,6 +401,60 @@ func (in *ApplicationBackupStatus) DeepCopyInto(out *ApplicationBackupStatus) {
 	in.TriggerTimestamp.DeepCopyInto(&out.TriggerTimestamp)
 	in.LastUpdateTimestamp.DeepCopyInto(&out.LastUpdateTimestamp)
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(OperationCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ResourceCheckpoints != nil {
+		in, out := &in.ResourceCheckpoints, &out.ResourceCheckpoints
+		*out = make([]*ApplicationBackupResourceCheckpoint, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ApplicationBackupResourceCheckpoint)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.UploadedObjects != nil {
+		in, out := &in.UploadedObjects, &out.UploadedObjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Estimate != nil {
+		in, out := &in.Estimate, &out.Estimate
+		*out = new(ApplicationBackupEstimate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventHistory != nil {
+		in, out := &in.EventHistory, &out.EventHistory
+		*out = make([]*EventHistoryEntry, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(EventHistoryEntry)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = make([]ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedItems != nil {
+		in, out := &in.FailedItems, &out.FailedItems
+		*out = make([]FailedItem, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -327,6 +485,22 @@ func (in *ApplicationBackupTemplateSpec) DeepCopy() *ApplicationBackupTemplateSp
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationBackupVolumeEstimate) DeepCopyInto(out *ApplicationBackupVolumeEstimate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationBackupVolumeEstimate.
+func (in *ApplicationBackupVolumeEstimate) DeepCopy() *ApplicationBackupVolumeEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationBackupVolumeEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationBackupVolumeInfo) DeepCopyInto(out *ApplicationBackupVolumeInfo) {
 	*out = *in
@@ -342,6 +516,28 @@ func (in *ApplicationBackupVolumeInfo) DeepCopyInto(out *ApplicationBackupVolume
 			(*out)[key] = val
 		}
 	}
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(corev1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataSourceRef != nil {
+		in, out := &in.DataSourceRef, &out.DataSourceRef
+		*out = new(corev1.TypedLocalObjectReference)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeAttributes != nil {
+		in, out := &in.VolumeAttributes, &out.VolumeAttributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ReplicaNodes != nil {
+		in, out := &in.ReplicaNodes, &out.ReplicaNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -448,6 +644,18 @@ func (in *ApplicationCloneSpec) DeepCopyInto(out *ApplicationCloneSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ServiceMeshPolicy != nil {
+		in, out := &in.ServiceMeshPolicy, &out.ServiceMeshPolicy
+		*out = new(ServiceMeshPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EncryptionSecretMapping != nil {
+		in, out := &in.EncryptionSecretMapping, &out.EncryptionSecretMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -487,6 +695,13 @@ func (in *ApplicationCloneStatus) DeepCopyInto(out *ApplicationCloneStatus) {
 		}
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -524,7 +739,9 @@ func (in *ApplicationRegistration) DeepCopyInto(out *ApplicationRegistration) {
 	if in.Resources != nil {
 		in, out := &in.Resources, &out.Resources
 		*out = make([]ApplicationResource, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -585,6 +802,16 @@ func (in *ApplicationResource) DeepCopyInto(out *ApplicationResource) {
 	*out = *in
 	out.GroupVersionKind = in.GroupVersionKind
 	out.SuspendOptions = in.SuspendOptions
+	if in.NestedSuspendOptions != nil {
+		in, out := &in.NestedSuspendOptions, &out.NestedSuspendOptions
+		*out = make([]SuspendOptions, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodTemplatePaths != nil {
+		in, out := &in.PodTemplatePaths, &out.PodTemplatePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -703,6 +930,48 @@ func (in *ApplicationRestoreSpec) DeepCopyInto(out *ApplicationRestoreSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.InitContainer != nil {
+		in, out := &in.InitContainer, &out.InitContainer
+		*out = new(corev1.Container)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SandboxTTL != nil {
+		in, out := &in.SandboxTTL, &out.SandboxTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.JobPolicy != nil {
+		in, out := &in.JobPolicy, &out.JobPolicy
+		*out = new(JobPolicy)
+		**out = **in
+	}
+	if in.VolumeAttributeMapping != nil {
+		in, out := &in.VolumeAttributeMapping, &out.VolumeAttributeMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StorageClassCreation != nil {
+		in, out := &in.StorageClassCreation, &out.StorageClassCreation
+		*out = new(StorageClassCreationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ImageCheck != nil {
+		in, out := &in.ImageCheck, &out.ImageCheck
+		*out = new(ImageCheckPolicy)
+		**out = **in
+	}
+	if in.CertificatePolicy != nil {
+		in, out := &in.CertificatePolicy, &out.CertificatePolicy
+		*out = new(CertificatePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -743,6 +1012,43 @@ func (in *ApplicationRestoreStatus) DeepCopyInto(out *ApplicationRestoreStatus)
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
 	in.LastUpdateTimestamp.DeepCopyInto(&out.LastUpdateTimestamp)
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(OperationCheckpoint)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SandboxExpiry != nil {
+		in, out := &in.SandboxExpiry, &out.SandboxExpiry
+		*out = (*in).DeepCopy()
+	}
+	if in.EventHistory != nil {
+		in, out := &in.EventHistory, &out.EventHistory
+		*out = make([]*EventHistoryEntry, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(EventHistoryEntry)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.FailedItems != nil {
+		in, out := &in.FailedItems, &out.FailedItems
+		*out = make([]FailedItem, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MissingImages != nil {
+		in, out := &in.MissingImages, &out.MissingImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -771,6 +1077,13 @@ func (in *ApplicationRestoreVolumeInfo) DeepCopyInto(out *ApplicationRestoreVolu
 			(*out)[key] = val
 		}
 	}
+	if in.VolumeAttributes != nil {
+		in, out := &in.VolumeAttributes, &out.VolumeAttributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -787,6 +1100,11 @@ func (in *ApplicationRestoreVolumeInfo) DeepCopy() *ApplicationRestoreVolumeInfo
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AzureConfig) DeepCopyInto(out *AzureConfig) {
 	*out = *in
+	if in.Immutability != nil {
+		in, out := &in.Immutability, &out.Immutability
+		*out = new(AzureImmutability)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -800,12 +1118,39 @@ func (in *AzureConfig) DeepCopy() *AzureConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureImmutability) DeepCopyInto(out *AzureImmutability) {
+	*out = *in
+	if in.LegalHoldTags != nil {
+		in, out := &in.LegalHoldTags, &out.LegalHoldTags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureImmutability.
+func (in *AzureImmutability) DeepCopy() *AzureImmutability {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureImmutability)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupLocation) DeepCopyInto(out *BackupLocation) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Location.DeepCopyInto(&out.Location)
+	in.Cluster.DeepCopyInto(&out.Cluster)
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -838,13 +1183,28 @@ func (in *BackupLocationItem) DeepCopyInto(out *BackupLocationItem) {
 	if in.AzureConfig != nil {
 		in, out := &in.AzureConfig, &out.AzureConfig
 		*out = new(AzureConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.GoogleConfig != nil {
 		in, out := &in.GoogleConfig, &out.GoogleConfig
 		*out = new(GoogleConfig)
 		**out = **in
 	}
+	if in.SwiftConfig != nil {
+		in, out := &in.SwiftConfig, &out.SwiftConfig
+		*out = new(SwiftConfig)
+		**out = **in
+	}
+	if in.AlibabaConfig != nil {
+		in, out := &in.AlibabaConfig, &out.AlibabaConfig
+		*out = new(AlibabaConfig)
+		**out = **in
+	}
+	if in.ExternalSecretConfig != nil {
+		in, out := &in.ExternalSecretConfig, &out.ExternalSecretConfig
+		*out = new(ExternalSecretConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -892,51 +1252,108 @@ func (in *BackupLocationList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterDomainInfo) DeepCopyInto(out *ClusterDomainInfo) {
+func (in *CertificatePolicy) DeepCopyInto(out *CertificatePolicy) {
 	*out = *in
+	if in.ExpiryThreshold != nil {
+		in, out := &in.ExpiryThreshold, &out.ExpiryThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainInfo.
-func (in *ClusterDomainInfo) DeepCopy() *ClusterDomainInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicy.
+func (in *CertificatePolicy) DeepCopy() *CertificatePolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterDomainInfo)
+	out := new(CertificatePolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterDomainUpdate) DeepCopyInto(out *ClusterDomainUpdate) {
+func (in *CloudAccount) DeepCopyInto(out *CloudAccount) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AWSClusterConfig != nil {
+		in, out := &in.AWSClusterConfig, &out.AWSClusterConfig
+		*out = new(S3Config)
+		**out = **in
+	}
+	if in.AzureClusterConfig != nil {
+		in, out := &in.AzureClusterConfig, &out.AzureClusterConfig
+		*out = new(AzureConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCPClusterConfig != nil {
+		in, out := &in.GCPClusterConfig, &out.GCPClusterConfig
+		*out = new(GoogleConfig)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainUpdate.
-func (in *ClusterDomainUpdate) DeepCopy() *ClusterDomainUpdate {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudAccount.
+func (in *CloudAccount) DeepCopy() *CloudAccount {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterDomainUpdate)
+	out := new(CloudAccount)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ClusterDomainUpdate) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterDomainUpdateList) DeepCopyInto(out *ClusterDomainUpdateList) {
+func (in *ClusterDomainInfo) DeepCopyInto(out *ClusterDomainInfo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainInfo.
+func (in *ClusterDomainInfo) DeepCopy() *ClusterDomainInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDomainInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDomainUpdate) DeepCopyInto(out *ClusterDomainUpdate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterDomainUpdate.
+func (in *ClusterDomainUpdate) DeepCopy() *ClusterDomainUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterDomainUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterDomainUpdate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterDomainUpdateList) DeepCopyInto(out *ClusterDomainUpdateList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
@@ -1081,13 +1498,56 @@ func (in *ClusterDomainsStatusList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterItem) DeepCopyInto(out *ClusterItem) {
+	*out = *in
+	if in.AWSClusterConfig != nil {
+		in, out := &in.AWSClusterConfig, &out.AWSClusterConfig
+		*out = new(S3Config)
+		**out = **in
+	}
+	if in.AzureClusterConfig != nil {
+		in, out := &in.AzureClusterConfig, &out.AzureClusterConfig
+		*out = new(AzureConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCPClusterConfig != nil {
+		in, out := &in.GCPClusterConfig, &out.GCPClusterConfig
+		*out = new(GoogleConfig)
+		**out = **in
+	}
+	if in.ExternalSecretConfig != nil {
+		in, out := &in.ExternalSecretConfig, &out.ExternalSecretConfig
+		*out = new(ExternalSecretConfig)
+		**out = **in
+	}
+	if in.Accounts != nil {
+		in, out := &in.Accounts, &out.Accounts
+		*out = make([]CloudAccount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterItem.
+func (in *ClusterItem) DeepCopy() *ClusterItem {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPair) DeepCopyInto(out *ClusterPair) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -1142,6 +1602,22 @@ func (in *ClusterPairList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterPairRateLimit) DeepCopyInto(out *ClusterPairRateLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPairRateLimit.
+func (in *ClusterPairRateLimit) DeepCopy() *ClusterPairRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterPairRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPairSpec) DeepCopyInto(out *ClusterPairSpec) {
 	*out = *in
@@ -1153,6 +1629,16 @@ func (in *ClusterPairSpec) DeepCopyInto(out *ClusterPairSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.ExternalSecretConfig != nil {
+		in, out := &in.ExternalSecretConfig, &out.ExternalSecretConfig
+		*out = new(ExternalSecretConfig)
+		**out = **in
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(ClusterPairRateLimit)
+		**out = **in
+	}
 	return
 }
 
@@ -1169,6 +1655,11 @@ func (in *ClusterPairSpec) DeepCopy() *ClusterPairSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterPairStatus) DeepCopyInto(out *ClusterPairStatus) {
 	*out = *in
+	if in.References != nil {
+		in, out := &in.References, &out.References
+		*out = make([]ObjectReference, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -1183,50 +1674,32 @@ func (in *ClusterPairStatus) DeepCopy() *ClusterPairStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DailyPolicy) DeepCopyInto(out *DailyPolicy) {
-	*out = *in
-	if in.Options != nil {
-		in, out := &in.Options, &out.Options
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DailyPolicy.
-func (in *DailyPolicy) DeepCopy() *DailyPolicy {
-	if in == nil {
-		return nil
-	}
-	out := new(DailyPolicy)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataExport) DeepCopyInto(out *DataExport) {
+func (in *ClusterRule) DeepCopyInto(out *ClusterRule) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExport.
-func (in *DataExport) DeepCopy() *DataExport {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRule.
+func (in *ClusterRule) DeepCopy() *ClusterRule {
 	if in == nil {
 		return nil
 	}
-	out := new(DataExport)
+	out := new(ClusterRule)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DataExport) DeepCopyObject() runtime.Object {
+func (in *ClusterRule) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1234,34 +1707,13 @@ func (in *DataExport) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataExportDestination) DeepCopyInto(out *DataExportDestination) {
-	*out = *in
-	if in.PersistentVolumeClaim != nil {
-		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
-		*out = new(v1.PersistentVolumeClaim)
-		(*in).DeepCopyInto(*out)
-	}
-	return
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportDestination.
-func (in *DataExportDestination) DeepCopy() *DataExportDestination {
-	if in == nil {
-		return nil
-	}
-	out := new(DataExportDestination)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataExportList) DeepCopyInto(out *DataExportList) {
+func (in *ClusterRuleList) DeepCopyInto(out *ClusterRuleList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]DataExport, len(*in))
+		*out = make([]ClusterRule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1269,18 +1721,18 @@ func (in *DataExportList) DeepCopyInto(out *DataExportList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportList.
-func (in *DataExportList) DeepCopy() *DataExportList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRuleList.
+func (in *ClusterRuleList) DeepCopy() *ClusterRuleList {
 	if in == nil {
 		return nil
 	}
-	out := new(DataExportList)
+	out := new(ClusterRuleList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *DataExportList) DeepCopyObject() runtime.Object {
+func (in *ClusterRuleList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1288,98 +1740,139 @@ func (in *DataExportList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataExportSource) DeepCopyInto(out *DataExportSource) {
+func (in *ControllerConfiguration) DeepCopyInto(out *ControllerConfiguration) {
 	*out = *in
-	if in.PersistentVolumeClaim != nil {
-		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
-		*out = new(v1.PersistentVolumeClaim)
-		(*in).DeepCopyInto(*out)
+	if in.RequeuePeriod != nil {
+		in, out := &in.RequeuePeriod, &out.RequeuePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RequeuePeriodOnError != nil {
+		in, out := &in.RequeuePeriodOnError, &out.RequeuePeriodOnError
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxConcurrentReconciles != nil {
+		in, out := &in.MaxConcurrentReconciles, &out.MaxConcurrentReconciles
+		*out = new(int)
+		**out = **in
+	}
+	if in.LowPriority != nil {
+		in, out := &in.LowPriority, &out.LowPriority
+		*out = new(bool)
+		**out = **in
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportSource.
-func (in *DataExportSource) DeepCopy() *DataExportSource {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ControllerConfiguration.
+func (in *ControllerConfiguration) DeepCopy() *ControllerConfiguration {
 	if in == nil {
 		return nil
 	}
-	out := new(DataExportSource)
+	out := new(ControllerConfiguration)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataExportSpec) DeepCopyInto(out *DataExportSpec) {
+func (in *DRDrill) DeepCopyInto(out *DRDrill) {
 	*out = *in
-	in.Source.DeepCopyInto(&out.Source)
-	in.Destination.DeepCopyInto(&out.Destination)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportSpec.
-func (in *DataExportSpec) DeepCopy() *DataExportSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrill.
+func (in *DRDrill) DeepCopy() *DRDrill {
 	if in == nil {
 		return nil
 	}
-	out := new(DataExportSpec)
+	out := new(DRDrill)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DRDrill) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExportStatus) DeepCopyInto(out *ExportStatus) {
+func (in *DRDrillCheck) DeepCopyInto(out *DRDrillCheck) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportStatus.
-func (in *ExportStatus) DeepCopy() *ExportStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillCheck.
+func (in *DRDrillCheck) DeepCopy() *DRDrillCheck {
 	if in == nil {
 		return nil
 	}
-	out := new(ExportStatus)
+	out := new(DRDrillCheck)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GoogleConfig) DeepCopyInto(out *GoogleConfig) {
+func (in *DRDrillList) DeepCopyInto(out *DRDrillList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DRDrill, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleConfig.
-func (in *GoogleConfig) DeepCopy() *GoogleConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillList.
+func (in *DRDrillList) DeepCopy() *DRDrillList {
 	if in == nil {
 		return nil
 	}
-	out := new(GoogleConfig)
+	out := new(DRDrillList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DRDrillList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GroupVolumeSnapshot) DeepCopyInto(out *GroupVolumeSnapshot) {
+func (in *DRDrillReport) DeepCopyInto(out *DRDrillReport) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	in.Report.DeepCopyInto(&out.Report)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshot.
-func (in *GroupVolumeSnapshot) DeepCopy() *GroupVolumeSnapshot {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillReport.
+func (in *DRDrillReport) DeepCopy() *DRDrillReport {
 	if in == nil {
 		return nil
 	}
-	out := new(GroupVolumeSnapshot)
+	out := new(DRDrillReport)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GroupVolumeSnapshot) DeepCopyObject() runtime.Object {
+func (in *DRDrillReport) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1387,13 +1880,13 @@ func (in *GroupVolumeSnapshot) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GroupVolumeSnapshotList) DeepCopyInto(out *GroupVolumeSnapshotList) {
+func (in *DRDrillReportList) DeepCopyInto(out *DRDrillReportList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]GroupVolumeSnapshot, len(*in))
+		*out = make([]DRDrillReport, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -1401,18 +1894,18 @@ func (in *GroupVolumeSnapshotList) DeepCopyInto(out *GroupVolumeSnapshotList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotList.
-func (in *GroupVolumeSnapshotList) DeepCopy() *GroupVolumeSnapshotList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillReportList.
+func (in *DRDrillReportList) DeepCopy() *DRDrillReportList {
 	if in == nil {
 		return nil
 	}
-	out := new(GroupVolumeSnapshotList)
+	out := new(DRDrillReportList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *GroupVolumeSnapshotList) DeepCopyObject() runtime.Object {
+func (in *DRDrillReportList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -1420,49 +1913,657 @@ func (in *GroupVolumeSnapshotList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GroupVolumeSnapshotSpec) DeepCopyInto(out *GroupVolumeSnapshotSpec) {
+func (in *DRDrillReportSpec) DeepCopyInto(out *DRDrillReportSpec) {
 	*out = *in
-	in.PVCSelector.DeepCopyInto(&out.PVCSelector)
-	if in.RestoreNamespaces != nil {
-		in, out := &in.RestoreNamespaces, &out.RestoreNamespaces
+	if in.MigratedNamespaces != nil {
+		in, out := &in.MigratedNamespaces, &out.MigratedNamespaces
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.Options != nil {
-		in, out := &in.Options, &out.Options
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	in.TriggerTimestamp.DeepCopyInto(&out.TriggerTimestamp)
+	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.RecoveryTime != nil {
+		in, out := &in.RecoveryTime, &out.RecoveryTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]*DRDrillCheck, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DRDrillCheck)
+				**out = **in
+			}
 		}
 	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotSpec.
-func (in *GroupVolumeSnapshotSpec) DeepCopy() *GroupVolumeSnapshotSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillReportSpec.
+func (in *DRDrillReportSpec) DeepCopy() *DRDrillReportSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(GroupVolumeSnapshotSpec)
+	out := new(DRDrillReportSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *GroupVolumeSnapshotStatus) DeepCopyInto(out *GroupVolumeSnapshotStatus) {
+func (in *DRDrillSpec) DeepCopyInto(out *DRDrillSpec) {
 	*out = *in
-	if in.VolumeSnapshots != nil {
-		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
-		*out = make([]*VolumeSnapshotStatus, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = new(VolumeSnapshotStatus)
-				(*in).DeepCopyInto(*out)
-			}
-		}
-	}
-	return
+	if in.Suspend != nil {
+		in, out := &in.Suspend, &out.Suspend
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MigratedNamespaces != nil {
+		in, out := &in.MigratedNamespaces, &out.MigratedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HealthCheckTimeout != nil {
+		in, out := &in.HealthCheckTimeout, &out.HealthCheckTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillSpec.
+func (in *DRDrillSpec) DeepCopy() *DRDrillSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DRDrillSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DRDrillStatus) DeepCopyInto(out *DRDrillStatus) {
+	*out = *in
+	in.TriggerTimestamp.DeepCopyInto(&out.TriggerTimestamp)
+	if in.CloneNames != nil {
+		in, out := &in.CloneNames, &out.CloneNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RecoveryTime != nil {
+		in, out := &in.RecoveryTime, &out.RecoveryTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]*DRDrillCheck, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DRDrillCheck)
+				**out = **in
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DRDrillStatus.
+func (in *DRDrillStatus) DeepCopy() *DRDrillStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DRDrillStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DailyPolicy) DeepCopyInto(out *DailyPolicy) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DailyPolicy.
+func (in *DailyPolicy) DeepCopy() *DailyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(DailyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataCopy) DeepCopyInto(out *DataCopy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataCopy.
+func (in *DataCopy) DeepCopy() *DataCopy {
+	if in == nil {
+		return nil
+	}
+	out := new(DataCopy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataCopy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataCopyList) DeepCopyInto(out *DataCopyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataCopy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataCopyList.
+func (in *DataCopyList) DeepCopy() *DataCopyList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataCopyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataCopyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataCopyPVC) DeepCopyInto(out *DataCopyPVC) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataCopyPVC.
+func (in *DataCopyPVC) DeepCopy() *DataCopyPVC {
+	if in == nil {
+		return nil
+	}
+	out := new(DataCopyPVC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataCopySpec) DeepCopyInto(out *DataCopySpec) {
+	*out = *in
+	out.Source = in.Source
+	out.Destination = in.Destination
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataCopySpec.
+func (in *DataCopySpec) DeepCopy() *DataCopySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataCopySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataCopyStatus) DeepCopyInto(out *DataCopyStatus) {
+	*out = *in
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
+	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataCopyStatus.
+func (in *DataCopyStatus) DeepCopy() *DataCopyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataCopyStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExport) DeepCopyInto(out *DataExport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExport.
+func (in *DataExport) DeepCopy() *DataExport {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataExport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExportDestination) DeepCopyInto(out *DataExportDestination) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(corev1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportDestination.
+func (in *DataExportDestination) DeepCopy() *DataExportDestination {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExportDestination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExportList) DeepCopyInto(out *DataExportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataExport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportList.
+func (in *DataExportList) DeepCopy() *DataExportList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataExportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExportSource) DeepCopyInto(out *DataExportSource) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(corev1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportSource.
+func (in *DataExportSource) DeepCopy() *DataExportSource {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExportSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExportSpec) DeepCopyInto(out *DataExportSpec) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	in.Destination.DeepCopyInto(&out.Destination)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExportSpec.
+func (in *DataExportSpec) DeepCopy() *DataExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataProtectionEvent) DeepCopyInto(out *DataProtectionEvent) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionEvent.
+func (in *DataProtectionEvent) DeepCopy() *DataProtectionEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(DataProtectionEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataProtectionStatus) DeepCopyInto(out *DataProtectionStatus) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionStatus.
+func (in *DataProtectionStatus) DeepCopy() *DataProtectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DataProtectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataProtectionStatus) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataProtectionStatusList) DeepCopyInto(out *DataProtectionStatusList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DataProtectionStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataProtectionStatusList.
+func (in *DataProtectionStatusList) DeepCopy() *DataProtectionStatusList {
+	if in == nil {
+		return nil
+	}
+	out := new(DataProtectionStatusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DataProtectionStatusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventHistoryEntry) DeepCopyInto(out *EventHistoryEntry) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventHistoryEntry.
+func (in *EventHistoryEntry) DeepCopy() *EventHistoryEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(EventHistoryEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExportStatus) DeepCopyInto(out *ExportStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExportStatus.
+func (in *ExportStatus) DeepCopy() *ExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalSecretConfig) DeepCopyInto(out *ExternalSecretConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSecretConfig.
+func (in *ExternalSecretConfig) DeepCopy() *ExternalSecretConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalSecretConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailedItem) DeepCopyInto(out *FailedItem) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailedItem.
+func (in *FailedItem) DeepCopy() *FailedItem {
+	if in == nil {
+		return nil
+	}
+	out := new(FailedItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoogleConfig) DeepCopyInto(out *GoogleConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleConfig.
+func (in *GoogleConfig) DeepCopy() *GoogleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GoogleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshot) DeepCopyInto(out *GroupVolumeSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshot.
+func (in *GroupVolumeSnapshot) DeepCopy() *GroupVolumeSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotList) DeepCopyInto(out *GroupVolumeSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GroupVolumeSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotList.
+func (in *GroupVolumeSnapshotList) DeepCopy() *GroupVolumeSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GroupVolumeSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotSpec) DeepCopyInto(out *GroupVolumeSnapshotSpec) {
+	*out = *in
+	in.PVCSelector.DeepCopyInto(&out.PVCSelector)
+	if in.RestoreNamespaces != nil {
+		in, out := &in.RestoreNamespaces, &out.RestoreNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SnapshotLabels != nil {
+		in, out := &in.SnapshotLabels, &out.SnapshotLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SnapshotAnnotations != nil {
+		in, out := &in.SnapshotAnnotations, &out.SnapshotAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotSpec.
+func (in *GroupVolumeSnapshotSpec) DeepCopy() *GroupVolumeSnapshotSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GroupVolumeSnapshotSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GroupVolumeSnapshotStatus) DeepCopyInto(out *GroupVolumeSnapshotStatus) {
+	*out = *in
+	if in.VolumeSnapshots != nil {
+		in, out := &in.VolumeSnapshots, &out.VolumeSnapshots
+		*out = make([]*VolumeSnapshotStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(VolumeSnapshotStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GroupVolumeSnapshotStatus.
@@ -1475,6 +2576,93 @@ func (in *GroupVolumeSnapshotStatus) DeepCopy() *GroupVolumeSnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthMonitorConfiguration) DeepCopyInto(out *HealthMonitorConfiguration) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RecoveryCount != nil {
+		in, out := &in.RecoveryCount, &out.RecoveryCount
+		*out = new(int)
+		**out = **in
+	}
+	if in.Defaults != nil {
+		in, out := &in.Defaults, &out.Defaults
+		*out = new(HealthMonitorSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make(map[string]HealthMonitorSettings, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.StorageClasses != nil {
+		in, out := &in.StorageClasses, &out.StorageClasses
+		*out = make(map[string]HealthMonitorSettings, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthMonitorConfiguration.
+func (in *HealthMonitorConfiguration) DeepCopy() *HealthMonitorConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthMonitorConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthMonitorSettings) DeepCopyInto(out *HealthMonitorSettings) {
+	*out = *in
+	if in.ConfirmationCount != nil {
+		in, out := &in.ConfirmationCount, &out.ConfirmationCount
+		*out = new(int)
+		**out = **in
+	}
+	if in.Cooldown != nil {
+		in, out := &in.Cooldown, &out.Cooldown
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthMonitorSettings.
+func (in *HealthMonitorSettings) DeepCopy() *HealthMonitorSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthMonitorSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageCheckPolicy) DeepCopyInto(out *ImageCheckPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageCheckPolicy.
+func (in *ImageCheckPolicy) DeepCopy() *ImageCheckPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageCheckPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IntervalPolicy) DeepCopyInto(out *IntervalPolicy) {
 	*out = *in
@@ -1498,6 +2686,54 @@ func (in *IntervalPolicy) DeepCopy() *IntervalPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobPolicy) DeepCopyInto(out *JobPolicy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobPolicy.
+func (in *JobPolicy) DeepCopy() *JobPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(JobPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadGate) DeepCopyInto(out *LoadGate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadGate.
+func (in *LoadGate) DeepCopy() *LoadGate {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadGate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceConfiguration) DeepCopyInto(out *MaintenanceConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceConfiguration.
+func (in *MaintenanceConfiguration) DeepCopy() *MaintenanceConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Migration) DeepCopyInto(out *Migration) {
 	*out = *in
@@ -1526,6 +2762,71 @@ func (in *Migration) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationDiff) DeepCopyInto(out *MigrationDiff) {
+	*out = *in
+	if in.Create != nil {
+		in, out := &in.Create, &out.Create
+		*out = make([]*ObjectInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ObjectInfo)
+				**out = **in
+			}
+		}
+	}
+	if in.Update != nil {
+		in, out := &in.Update, &out.Update
+		*out = make([]*ObjectInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ObjectInfo)
+				**out = **in
+			}
+		}
+	}
+	if in.Delete != nil {
+		in, out := &in.Delete, &out.Delete
+		*out = make([]*ObjectInfo, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ObjectInfo)
+				**out = **in
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationDiff.
+func (in *MigrationDiff) DeepCopy() *MigrationDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationDiffSummary) DeepCopyInto(out *MigrationDiffSummary) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationDiffSummary.
+func (in *MigrationDiffSummary) DeepCopy() *MigrationDiffSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationDiffSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationList) DeepCopyInto(out *MigrationList) {
 	*out = *in
@@ -1683,6 +2984,7 @@ func (in *MigrationScheduleStatus) DeepCopyInto(out *MigrationScheduleStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	in.DeferredSince.DeepCopyInto(&out.DeferredSince)
 	return
 }
 
@@ -1741,6 +3043,46 @@ func (in *MigrationSpec) DeepCopyInto(out *MigrationSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.SkipDeletedNamespaces != nil {
+		in, out := &in.SkipDeletedNamespaces, &out.SkipDeletedNamespaces
+		*out = new(bool)
+		**out = **in
+	}
+	if in.DiffOnly != nil {
+		in, out := &in.DiffOnly, &out.DiffOnly
+		*out = new(bool)
+		**out = **in
+	}
+	if in.SecretTypes != nil {
+		in, out := &in.SecretTypes, &out.SecretTypes
+		*out = new(SecretTypeFilter)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.JobPolicy != nil {
+		in, out := &in.JobPolicy, &out.JobPolicy
+		*out = new(JobPolicy)
+		**out = **in
+	}
+	if in.ServiceMeshPolicy != nil {
+		in, out := &in.ServiceMeshPolicy, &out.ServiceMeshPolicy
+		*out = new(ServiceMeshPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageClassCreation != nil {
+		in, out := &in.StorageClassCreation, &out.StorageClassCreation
+		*out = new(StorageClassCreationPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Staging != nil {
+		in, out := &in.Staging, &out.Staging
+		*out = new(MigrationStagingPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1754,6 +3096,57 @@ func (in *MigrationSpec) DeepCopy() *MigrationSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationStagingPolicy) DeepCopyInto(out *MigrationStagingPolicy) {
+	*out = *in
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStagingPolicy.
+func (in *MigrationStagingPolicy) DeepCopy() *MigrationStagingPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStagingPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationStagingStatus) DeepCopyInto(out *MigrationStagingStatus) {
+	*out = *in
+	in.LastSyncTimestamp.DeepCopyInto(&out.LastSyncTimestamp)
+	if in.CutoverStartTimestamp != nil {
+		in, out := &in.CutoverStartTimestamp, &out.CutoverStartTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.CutoverFinishTimestamp != nil {
+		in, out := &in.CutoverFinishTimestamp, &out.CutoverFinishTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.Downtime != nil {
+		in, out := &in.Downtime, &out.Downtime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationStagingStatus.
+func (in *MigrationStagingStatus) DeepCopy() *MigrationStagingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationStagingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
 	*out = *in
@@ -1780,6 +3173,46 @@ func (in *MigrationStatus) DeepCopyInto(out *MigrationStatus) {
 		}
 	}
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	in.VolumeMigrationFinishTimestamp.DeepCopyInto(&out.VolumeMigrationFinishTimestamp)
+	in.ResourceMigrationFinishTimestamp.DeepCopyInto(&out.ResourceMigrationFinishTimestamp)
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(MigrationSummary)
+		**out = **in
+	}
+	if in.Diff != nil {
+		in, out := &in.Diff, &out.Diff
+		*out = new(MigrationDiff)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventHistory != nil {
+		in, out := &in.EventHistory, &out.EventHistory
+		*out = make([]*EventHistoryEntry, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(EventHistoryEntry)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.FailedItems != nil {
+		in, out := &in.FailedItems, &out.FailedItems
+		*out = make([]FailedItem, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Staging != nil {
+		in, out := &in.Staging, &out.Staging
+		*out = new(MigrationStagingStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1793,6 +3226,22 @@ func (in *MigrationStatus) DeepCopy() *MigrationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationSummary) DeepCopyInto(out *MigrationSummary) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSummary.
+func (in *MigrationSummary) DeepCopy() *MigrationSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(MigrationSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MigrationTemplateSpec) DeepCopyInto(out *MigrationTemplateSpec) {
 	*out = *in
@@ -1849,6 +3298,48 @@ func (in *MonthlyPolicy) DeepCopy() *MonthlyPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespaceDataProtectionStatus) DeepCopyInto(out *NamespaceDataProtectionStatus) {
+	*out = *in
+	if in.LastBackup != nil {
+		in, out := &in.LastBackup, &out.LastBackup
+		*out = new(DataProtectionEvent)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastMigration != nil {
+		in, out := &in.LastMigration, &out.LastMigration
+		*out = new(DataProtectionEvent)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RPO != nil {
+		in, out := &in.RPO, &out.RPO
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.LastDrill != nil {
+		in, out := &in.LastDrill, &out.LastDrill
+		*out = new(DataProtectionEvent)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RTO != nil {
+		in, out := &in.RTO, &out.RTO
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	in.LastUpdateTimestamp.DeepCopyInto(&out.LastUpdateTimestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespaceDataProtectionStatus.
+func (in *NamespaceDataProtectionStatus) DeepCopy() *NamespaceDataProtectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespaceDataProtectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespacedSchedulePolicy) DeepCopyInto(out *NamespacedSchedulePolicy) {
 	*out = *in
@@ -1911,6 +3402,22 @@ func (in *NamespacedSchedulePolicyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OCIExportSpec) DeepCopyInto(out *OCIExportSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OCIExportSpec.
+func (in *OCIExportSpec) DeepCopy() *OCIExportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OCIExportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectInfo) DeepCopyInto(out *ObjectInfo) {
 	*out = *in
@@ -1918,29 +3425,161 @@ func (in *ObjectInfo) DeepCopyInto(out *ObjectInfo) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectInfo.
-func (in *ObjectInfo) DeepCopy() *ObjectInfo {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectInfo.
+func (in *ObjectInfo) DeepCopy() *ObjectInfo {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectInfo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
+func (in *ObjectReference) DeepCopy() *ObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationCheckpoint) DeepCopyInto(out *OperationCheckpoint) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationCheckpoint.
+func (in *OperationCheckpoint) DeepCopy() *OperationCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCSelectorSpec) DeepCopyInto(out *PVCSelectorSpec) {
+	*out = *in
+	in.LabelSelector.DeepCopyInto(&out.LabelSelector)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSelectorSpec.
+func (in *PVCSelectorSpec) DeepCopy() *PVCSelectorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCSelectorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReconcileSchedulingConfiguration) DeepCopyInto(out *ReconcileSchedulingConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReconcileSchedulingConfiguration.
+func (in *ReconcileSchedulingConfiguration) DeepCopy() *ReconcileSchedulingConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ReconcileSchedulingConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryMapping) DeepCopyInto(out *RegistryMapping) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryMapping.
+func (in *RegistryMapping) DeepCopy() *RegistryMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(RegistryMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegistryMapping) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RegistryMappingList) DeepCopyInto(out *RegistryMappingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RegistryMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryMappingList.
+func (in *RegistryMappingList) DeepCopy() *RegistryMappingList {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectInfo)
+	out := new(RegistryMappingList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RegistryMappingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PVCSelectorSpec) DeepCopyInto(out *PVCSelectorSpec) {
+func (in *RegistryMappingSpec) DeepCopyInto(out *RegistryMappingSpec) {
 	*out = *in
-	in.LabelSelector.DeepCopyInto(&out.LabelSelector)
+	if in.Registries != nil {
+		in, out := &in.Registries, &out.Registries
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCSelectorSpec.
-func (in *PVCSelectorSpec) DeepCopy() *PVCSelectorSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RegistryMappingSpec.
+func (in *RegistryMappingSpec) DeepCopy() *RegistryMappingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PVCSelectorSpec)
+	out := new(RegistryMappingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -2188,6 +3827,7 @@ func (in *ScheduledApplicationBackupStatus) DeepCopyInto(out *ScheduledApplicati
 	*out = *in
 	in.CreationTimestamp.DeepCopyInto(&out.CreationTimestamp)
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	in.ScheduledTimestamp.DeepCopyInto(&out.ScheduledTimestamp)
 	return
 }
 
@@ -2206,6 +3846,12 @@ func (in *ScheduledMigrationStatus) DeepCopyInto(out *ScheduledMigrationStatus)
 	*out = *in
 	in.CreationTimestamp.DeepCopyInto(&out.CreationTimestamp)
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	if in.DiffSummary != nil {
+		in, out := &in.DiffSummary, &out.DiffSummary
+		*out = new(MigrationDiffSummary)
+		**out = **in
+	}
+	in.ScheduledTimestamp.DeepCopyInto(&out.ScheduledTimestamp)
 	return
 }
 
@@ -2224,6 +3870,7 @@ func (in *ScheduledVolumeSnapshotStatus) DeepCopyInto(out *ScheduledVolumeSnapsh
 	*out = *in
 	in.CreationTimestamp.DeepCopyInto(&out.CreationTimestamp)
 	in.FinishTimestamp.DeepCopyInto(&out.FinishTimestamp)
+	in.ScheduledTimestamp.DeepCopyInto(&out.ScheduledTimestamp)
 	return
 }
 
@@ -2237,6 +3884,268 @@ func (in *ScheduledVolumeSnapshotStatus) DeepCopy() *ScheduledVolumeSnapshotStat
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduledVolumeStats) DeepCopyInto(out *ScheduledVolumeStats) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledVolumeStats.
+func (in *ScheduledVolumeStats) DeepCopy() *ScheduledVolumeStats {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduledVolumeStats)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTypeFilter) DeepCopyInto(out *SecretTypeFilter) {
+	*out = *in
+	if in.IncludeTypes != nil {
+		in, out := &in.IncludeTypes, &out.IncludeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExcludeTypes != nil {
+		in, out := &in.ExcludeTypes, &out.ExcludeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTypeFilter.
+func (in *SecretTypeFilter) DeepCopy() *SecretTypeFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTypeFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshPolicy) DeepCopyInto(out *ServiceMeshPolicy) {
+	*out = *in
+	if in.RevisionMapping != nil {
+		in, out := &in.RevisionMapping, &out.RevisionMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshPolicy.
+func (in *ServiceMeshPolicy) DeepCopy() *ServiceMeshPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageClassCreationPolicy) DeepCopyInto(out *StorageClassCreationPolicy) {
+	*out = *in
+	if in.ProvisionerMapping != nil {
+		in, out := &in.ProvisionerMapping, &out.ProvisionerMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ParameterMapping != nil {
+		in, out := &in.ParameterMapping, &out.ParameterMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageClassCreationPolicy.
+func (in *StorageClassCreationPolicy) DeepCopy() *StorageClassCreationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageClassCreationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorkConfiguration) DeepCopyInto(out *StorkConfiguration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorkConfiguration.
+func (in *StorkConfiguration) DeepCopy() *StorkConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(StorkConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorkConfiguration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorkConfigurationList) DeepCopyInto(out *StorkConfigurationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]StorkConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorkConfigurationList.
+func (in *StorkConfigurationList) DeepCopy() *StorkConfigurationList {
+	if in == nil {
+		return nil
+	}
+	out := new(StorkConfigurationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *StorkConfigurationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorkConfigurationSpec) DeepCopyInto(out *StorkConfigurationSpec) {
+	*out = *in
+	if in.Controllers != nil {
+		in, out := &in.Controllers, &out.Controllers
+		*out = make(map[string]ControllerConfiguration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.ValidateSnapshotTimeout != nil {
+		in, out := &in.ValidateSnapshotTimeout, &out.ValidateSnapshotTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MigrationMaxThreads != nil {
+		in, out := &in.MigrationMaxThreads, &out.MigrationMaxThreads
+		*out = new(int)
+		**out = **in
+	}
+	if in.RestoreMaxThreads != nil {
+		in, out := &in.RestoreMaxThreads, &out.RestoreMaxThreads
+		*out = new(int)
+		**out = **in
+	}
+	if in.BackupVolumeBatchCount != nil {
+		in, out := &in.BackupVolumeBatchCount, &out.BackupVolumeBatchCount
+		*out = new(int)
+		**out = **in
+	}
+	if in.BackupThroughputBytesPerSecond != nil {
+		in, out := &in.BackupThroughputBytesPerSecond, &out.BackupThroughputBytesPerSecond
+		*out = new(int64)
+		**out = **in
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceConfiguration)
+		**out = **in
+	}
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReconcileScheduling != nil {
+		in, out := &in.ReconcileScheduling, &out.ReconcileScheduling
+		*out = new(ReconcileSchedulingConfiguration)
+		**out = **in
+	}
+	if in.HealthMonitor != nil {
+		in, out := &in.HealthMonitor, &out.HealthMonitor
+		*out = new(HealthMonitorConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ScheduleDriftThreshold != nil {
+		in, out := &in.ScheduleDriftThreshold, &out.ScheduleDriftThreshold
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ImageCheckRegistries != nil {
+		in, out := &in.ImageCheckRegistries, &out.ImageCheckRegistries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorkConfigurationSpec.
+func (in *StorkConfigurationSpec) DeepCopy() *StorkConfigurationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(StorkConfigurationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorkConfigurationStatus) DeepCopyInto(out *StorkConfigurationStatus) {
+	*out = *in
+	if in.VolumeDrivers != nil {
+		in, out := &in.VolumeDrivers, &out.VolumeDrivers
+		*out = make([]VolumeDriverCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorkConfigurationStatus.
+func (in *StorkConfigurationStatus) DeepCopy() *StorkConfigurationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StorkConfigurationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SuspendOptions) DeepCopyInto(out *SuspendOptions) {
 	*out = *in
@@ -2253,6 +4162,39 @@ func (in *SuspendOptions) DeepCopy() *SuspendOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwiftConfig) DeepCopyInto(out *SwiftConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwiftConfig.
+func (in *SwiftConfig) DeepCopy() *SwiftConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SwiftConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeDriverCondition) DeepCopyInto(out *VolumeDriverCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeDriverCondition.
+func (in *VolumeDriverCondition) DeepCopy() *VolumeDriverCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeDriverCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotRestore) DeepCopyInto(out *VolumeSnapshotRestore) {
 	*out = *in
@@ -2324,6 +4266,23 @@ func (in *VolumeSnapshotRestoreSpec) DeepCopyInto(out *VolumeSnapshotRestoreSpec
 			(*out)[key] = val
 		}
 	}
+	if in.EncryptionSecretMapping != nil {
+		in, out := &in.EncryptionSecretMapping, &out.EncryptionSecretMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SnapshotReadyTimeout != nil {
+		in, out := &in.SnapshotReadyTimeout, &out.SnapshotReadyTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
@@ -2340,6 +4299,7 @@ func (in *VolumeSnapshotRestoreSpec) DeepCopy() *VolumeSnapshotRestoreSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeSnapshotRestoreStatus) DeepCopyInto(out *VolumeSnapshotRestoreStatus) {
 	*out = *in
+	in.StartTimestamp.DeepCopyInto(&out.StartTimestamp)
 	if in.Volumes != nil {
 		in, out := &in.Volumes, &out.Volumes
 		*out = make([]*RestoreVolumeInfo, len(*in))
@@ -2351,6 +4311,13 @@ func (in *VolumeSnapshotRestoreStatus) DeepCopyInto(out *VolumeSnapshotRestoreSt
 			}
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2434,6 +4401,25 @@ func (in *VolumeSnapshotScheduleSpec) DeepCopyInto(out *VolumeSnapshotScheduleSp
 		*out = new(bool)
 		**out = **in
 	}
+	if in.SnapshotLabels != nil {
+		in, out := &in.SnapshotLabels, &out.SnapshotLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SnapshotAnnotations != nil {
+		in, out := &in.SnapshotAnnotations, &out.SnapshotAnnotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadGate != nil {
+		in, out := &in.LoadGate, &out.LoadGate
+		*out = new(LoadGate)
+		**out = **in
+	}
 	return
 }
 
@@ -2471,6 +4457,14 @@ func (in *VolumeSnapshotScheduleStatus) DeepCopyInto(out *VolumeSnapshotSchedule
 			(*out)[key] = outVal
 		}
 	}
+	in.DeferredSince.DeepCopyInto(&out.DeferredSince)
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -0,0 +1,107 @@
+package v1alpha2
+
+import (
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationBackup represents applicationbackup object
+type ApplicationBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ApplicationBackupSpec   `json:"spec"`
+	Status            ApplicationBackupStatus `json:"status"`
+}
+
+// ApplicationBackupSpec is the spec used to backup applications
+type ApplicationBackupSpec struct {
+	Namespaces []string `json:"namespaces"`
+	// BackupLocationName is the name of the BackupLocation to upload the
+	// backup to. It was called backupLocation in v1alpha1.
+	BackupLocationName string `json:"backupLocationName"`
+	// BackupLocationNamespace is the namespace of the BackupLocation. Defaults
+	// to the namespace of the backup.
+	BackupLocationNamespace string            `json:"backupLocationNamespace,omitempty"`
+	Selectors               map[string]string `json:"selectors,omitempty"`
+	// PreExecRuleName is the name of the Rule to run before the backup. It
+	// was called preExecRule in v1alpha1.
+	PreExecRuleName string `json:"preExecRuleName,omitempty"`
+	// PostExecRuleName is the name of the Rule to run after the volumes have
+	// been snapshotted. It was called postExecRule in v1alpha1.
+	PostExecRuleName  string                                      `json:"postExecRuleName,omitempty"`
+	ReclaimPolicy     v1alpha1.ApplicationBackupReclaimPolicyType `json:"reclaimPolicy,omitempty"`
+	SkipServiceUpdate bool                                        `json:"skipServiceUpdate,omitempty"`
+	// Options to be passed in to the driver
+	Options          map[string]string     `json:"options,omitempty"`
+	IncludeResources []v1alpha1.ObjectInfo `json:"includeResources,omitempty"`
+	ResourceTypes    []string              `json:"resourceTypes,omitempty"`
+	BackupType       string                `json:"backupType,omitempty"`
+	// SecretTypes filters the Secrets to be backed up by their type
+	SecretTypes *v1alpha1.SecretTypeFilter `json:"secretTypes,omitempty"`
+	// OCIExport pushes the backup to a container registry as an OCI artifact
+	// once it is complete
+	OCIExport *v1alpha1.OCIExportSpec `json:"ociExport,omitempty"`
+	// ResumeFrom is the name of a backup in the same namespace that failed
+	// part way. The volumes it backed up successfully are moved to this
+	// backup and only the remaining volumes are backed up again. Volumes
+	// backed up by the csi and kdmp drivers are always backed up again
+	// since their backups belong to the backup that took them.
+	ResumeFrom string `json:"resumeFrom,omitempty"`
+	// FailOnPartial marks the backup as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be backed up
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// backup is deleted once it has finished. Backups are kept if it isn't
+	// set, the default from the stork configuration doesn't apply to them.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// IncludeVolumeSnapshots backs up the VolumeSnapshots created by the
+	// application in the namespaces along with their VolumeSnapshotContents
+	IncludeVolumeSnapshots bool `json:"includeVolumeSnapshots,omitempty"`
+}
+
+// ApplicationBackupStatus is the status of a application backup operation
+type ApplicationBackupStatus struct {
+	// Conditions are the Progressing and Succeeded conditions of the backup
+	Conditions []metav1.Condition                  `json:"conditions,omitempty"`
+	Stage      v1alpha1.ApplicationBackupStageType `json:"stage,omitempty"`
+	// Phase is the status of the backup. It was called status in v1alpha1.
+	Phase               v1alpha1.ApplicationBackupStatusType      `json:"phase,omitempty"`
+	Reason              string                                    `json:"reason,omitempty"`
+	Resources           []*v1alpha1.ApplicationBackupResourceInfo `json:"resources,omitempty"`
+	Volumes             []*v1alpha1.ApplicationBackupVolumeInfo   `json:"volumes,omitempty"`
+	BackupPath          string                                    `json:"backupPath,omitempty"`
+	TriggerTimestamp    metav1.Time                               `json:"triggerTimestamp,omitempty"`
+	LastUpdateTimestamp metav1.Time                               `json:"lastUpdateTimestamp,omitempty"`
+	FinishTimestamp     metav1.Time                               `json:"finishTimestamp,omitempty"`
+	TotalSize           uint64                                    `json:"totalSize,omitempty"`
+	// Checkpoint is set if stork was shut down while the backup was in
+	// progress
+	Checkpoint          *v1alpha1.OperationCheckpoint                   `json:"checkpoint,omitempty"`
+	ResourceCheckpoints []*v1alpha1.ApplicationBackupResourceCheckpoint `json:"resourceCheckpoints,omitempty"`
+	UploadedObjects     []string                                        `json:"uploadedObjects,omitempty"`
+	OCIArtifact         string                                          `json:"ociArtifact,omitempty"`
+	Estimate            *v1alpha1.ApplicationBackupEstimate             `json:"estimate,omitempty"`
+	EventHistory        []*v1alpha1.EventHistoryEntry                   `json:"eventHistory,omitempty"`
+	// References are the restores created from the backup
+	References []v1alpha1.ObjectReference `json:"references,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be backed up
+	FailedItems []v1alpha1.FailedItem `json:"failedItems,omitempty"`
+	// ObservedGeneration is the generation of the backup that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// CleanupError is the last error hit while cleaning up the backup after
+	// it was deleted
+	CleanupError string `json:"cleanupError,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationBackupList is a list of ApplicationBackups
+type ApplicationBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ApplicationBackup `json:"items"`
+}
@@ -0,0 +1,114 @@
+package v1alpha2
+
+import (
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationRestore represents applicationrestore object
+type ApplicationRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ApplicationRestoreSpec   `json:"spec"`
+	Status            ApplicationRestoreStatus `json:"status"`
+}
+
+// ApplicationRestoreSpec is the spec used to restore applications
+type ApplicationRestoreSpec struct {
+	BackupName string `json:"backupName"`
+	// BackupLocationName is the name of the BackupLocation the backup was
+	// uploaded to. It was called backupLocation in v1alpha1.
+	BackupLocationName string `json:"backupLocationName"`
+	// BackupLocationNamespace is the namespace of the BackupLocation. Defaults
+	// to the namespace of the restore.
+	BackupLocationNamespace      string                                       `json:"backupLocationNamespace,omitempty"`
+	NamespaceMapping             map[string]string                            `json:"namespaceMapping,omitempty"`
+	ReplacePolicy                v1alpha1.ApplicationRestoreReplacePolicyType `json:"replacePolicy,omitempty"`
+	IncludeOptionalResourceTypes []string                                     `json:"includeOptionalResourceTypes,omitempty"`
+	IncludeResources             []v1alpha1.ObjectInfo                        `json:"includeResources,omitempty"`
+	StorageClassMapping          map[string]string                            `json:"storageClassMapping,omitempty"`
+	// InitContainer is added to the pod template of restored workloads until
+	// they have rolled out successfully
+	InitContainer *corev1.Container `json:"initContainer,omitempty"`
+	// RepairOwnership runs a job to change the group of the files on volumes
+	// restored by KDMP to the fsGroup of the pods using them
+	RepairOwnership bool `json:"repairOwnership,omitempty"`
+	// Sandbox restores into temporary isolated namespaces
+	Sandbox bool `json:"sandbox,omitempty"`
+	// SandboxTTL is how long the sandbox namespaces are kept
+	SandboxTTL *metav1.Duration `json:"sandboxTTL,omitempty"`
+	// VolumeDataSourcePolicy decides how volumes whose PVCs had a data
+	// source are restored
+	VolumeDataSourcePolicy v1alpha1.ApplicationRestoreVolumeDataSourcePolicyType `json:"volumeDataSourcePolicy,omitempty"`
+	// JobPolicy decides how Jobs and CronJobs are restored
+	JobPolicy *v1alpha1.JobPolicy `json:"jobPolicy,omitempty"`
+	// VolumeAttributeMapping changes the volume attributes recorded in the
+	// backup before they are reapplied to the restored volumes
+	VolumeAttributeMapping map[string]string `json:"volumeAttributeMapping,omitempty"`
+	// StorageClassCreation, if set, creates the storage classes of the
+	// restored PVCs that don't exist on the destination
+	StorageClassCreation *v1alpha1.StorageClassCreationPolicy `json:"storageClassCreation,omitempty"`
+	// FailOnPartial marks the restore as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be restored
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// QuotaPolicy decides what happens when the destination ResourceQuotas
+	// are too small for the backup
+	QuotaPolicy v1alpha1.ApplicationRestoreQuotaPolicyType `json:"quotaPolicy,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// restore is deleted once it has finished. The default from the stork
+	// configuration is used if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// ImageCheck, if set, checks that the images of the restored workloads
+	// are available on the destination before anything is restored
+	ImageCheck *v1alpha1.ImageCheckPolicy `json:"imageCheck,omitempty"`
+	// RegistryMappingName is the name of a RegistryMapping in the namespace
+	// of the restore used to rewrite the images of the restored workloads
+	RegistryMappingName string `json:"registryMappingName,omitempty"`
+	// CertificatePolicy decides how the TLS certificates issued by
+	// cert-manager are restored
+	CertificatePolicy *v1alpha1.CertificatePolicy `json:"certificatePolicy,omitempty"`
+}
+
+// ApplicationRestoreStatus is the status of a application restore operation
+type ApplicationRestoreStatus struct {
+	// Conditions are the Progressing and Succeeded conditions of the restore
+	Conditions []metav1.Condition                   `json:"conditions,omitempty"`
+	Stage      v1alpha1.ApplicationRestoreStageType `json:"stage,omitempty"`
+	// Phase is the status of the restore. It was called status in v1alpha1.
+	Phase                v1alpha1.ApplicationRestoreStatusType      `json:"phase,omitempty"`
+	Reason               string                                     `json:"reason,omitempty"`
+	Resources            []*v1alpha1.ApplicationRestoreResourceInfo `json:"resources,omitempty"`
+	Volumes              []*v1alpha1.ApplicationRestoreVolumeInfo   `json:"volumes,omitempty"`
+	FinishTimestamp      metav1.Time                                `json:"finishTimestamp,omitempty"`
+	LastUpdateTimestamp  metav1.Time                                `json:"lastUpdateTimestamp,omitempty"`
+	TotalSize            uint64                                     `json:"totalSize,omitempty"`
+	Checkpoint           *v1alpha1.OperationCheckpoint              `json:"checkpoint,omitempty"`
+	InitContainerRemoved bool                                       `json:"initContainerRemoved,omitempty"`
+	SandboxExpiry        *metav1.Time                               `json:"sandboxExpiry,omitempty"`
+	SandboxDeleted       bool                                       `json:"sandboxDeleted,omitempty"`
+	EventHistory         []*v1alpha1.EventHistoryEntry              `json:"eventHistory,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be restored
+	FailedItems []v1alpha1.FailedItem `json:"failedItems,omitempty"`
+	// ObservedGeneration is the generation of the restore that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// MissingImages are the images of the restored workloads that aren't
+	// available on the destination
+	MissingImages []string `json:"missingImages,omitempty"`
+	// CleanupError is the last error hit while cleaning up the restore after
+	// it was deleted
+	CleanupError string `json:"cleanupError,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ApplicationRestoreList is a list of ApplicationRestores
+type ApplicationRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ApplicationRestore `json:"items"`
+}
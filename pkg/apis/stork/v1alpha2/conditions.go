@@ -0,0 +1,28 @@
+package v1alpha2
+
+const (
+	// ConditionProgressing is True while the operation is running and False
+	// once it has finished
+	ConditionProgressing = "Progressing"
+	// ConditionSucceeded is True once the operation has finished
+	// successfully, False if it has failed and Unknown until it finishes
+	ConditionSucceeded = "Succeeded"
+)
+
+const (
+	// ReasonPending is the reason for the conditions of operations that
+	// haven't been started yet
+	ReasonPending = "Pending"
+	// ReasonInProgress is the reason for the conditions of operations that
+	// are running
+	ReasonInProgress = "InProgress"
+	// ReasonSuccessful is the reason for the conditions of operations that
+	// completed successfully
+	ReasonSuccessful = "Successful"
+	// ReasonPartialSuccess is the reason for the conditions of operations
+	// that completed but failed for some of the resources or volumes
+	ReasonPartialSuccess = "PartialSuccess"
+	// ReasonFailed is the reason for the conditions of operations that
+	// failed
+	ReasonFailed = "Failed"
+)
@@ -0,0 +1,430 @@
+package v1alpha2
+
+import (
+	"fmt"
+
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConvertFromV1alpha1 converts a v1alpha1 object to v1alpha2. The conditions
+// are generated from the status, in addition to the Completed condition that
+// is stored in v1alpha1.
+func ConvertFromV1alpha1(in runtime.Object) (runtime.Object, error) {
+	switch obj := in.(type) {
+	case *v1alpha1.ApplicationBackup:
+		return convertApplicationBackupFromV1alpha1(obj.DeepCopy()), nil
+	case *v1alpha1.ApplicationRestore:
+		return convertApplicationRestoreFromV1alpha1(obj.DeepCopy()), nil
+	case *v1alpha1.Migration:
+		return convertMigrationFromV1alpha1(obj.DeepCopy()), nil
+	}
+	return nil, fmt.Errorf("conversion from v1alpha1 not supported for %T", in)
+}
+
+// ConvertToV1alpha1 converts a v1alpha2 object to v1alpha1. The conditions
+// that are generated from the status are dropped.
+func ConvertToV1alpha1(in runtime.Object) (runtime.Object, error) {
+	switch obj := in.(type) {
+	case *ApplicationBackup:
+		return convertApplicationBackupToV1alpha1(obj.DeepCopy()), nil
+	case *ApplicationRestore:
+		return convertApplicationRestoreToV1alpha1(obj.DeepCopy()), nil
+	case *Migration:
+		return convertMigrationToV1alpha1(obj.DeepCopy()), nil
+	}
+	return nil, fmt.Errorf("conversion to v1alpha1 not supported for %T", in)
+}
+
+func convertApplicationBackupFromV1alpha1(in *v1alpha1.ApplicationBackup) *ApplicationBackup {
+	out := &ApplicationBackup{
+		TypeMeta:   typeMeta(SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: ApplicationBackupSpec{
+			Namespaces:              in.Spec.Namespaces,
+			BackupLocationName:      in.Spec.BackupLocation,
+			BackupLocationNamespace: in.Spec.BackupLocationNamespace,
+			Selectors:               in.Spec.Selectors,
+			PreExecRuleName:         in.Spec.PreExecRule,
+			PostExecRuleName:        in.Spec.PostExecRule,
+			ReclaimPolicy:           in.Spec.ReclaimPolicy,
+			SkipServiceUpdate:       in.Spec.SkipServiceUpdate,
+			Options:                 in.Spec.Options,
+			IncludeResources:        in.Spec.IncludeResources,
+			ResourceTypes:           in.Spec.ResourceTypes,
+			BackupType:              in.Spec.BackupType,
+			SecretTypes:             in.Spec.SecretTypes,
+			OCIExport:               in.Spec.OCIExport,
+			ResumeFrom:              in.Spec.ResumeFrom,
+			FailOnPartial:           in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished: in.Spec.TTLSecondsAfterFinished,
+			IncludeVolumeSnapshots:  in.Spec.IncludeVolumeSnapshots,
+		},
+		Status: ApplicationBackupStatus{
+			Stage:               in.Status.Stage,
+			Phase:               in.Status.Status,
+			Reason:              in.Status.Reason,
+			Resources:           in.Status.Resources,
+			Volumes:             in.Status.Volumes,
+			BackupPath:          in.Status.BackupPath,
+			TriggerTimestamp:    in.Status.TriggerTimestamp,
+			LastUpdateTimestamp: in.Status.LastUpdateTimestamp,
+			FinishTimestamp:     in.Status.FinishTimestamp,
+			TotalSize:           in.Status.TotalSize,
+			Checkpoint:          in.Status.Checkpoint,
+			ResourceCheckpoints: in.Status.ResourceCheckpoints,
+			UploadedObjects:     in.Status.UploadedObjects,
+			OCIArtifact:         in.Status.OCIArtifact,
+			Estimate:            in.Status.Estimate,
+			EventHistory:        in.Status.EventHistory,
+			References:          in.Status.References,
+			FailedItems:         in.Status.FailedItems,
+			ObservedGeneration:  in.Status.ObservedGeneration,
+			CleanupError:        in.Status.CleanupError,
+		},
+	}
+	out.Status.Conditions = getConditions(
+		&in.ObjectMeta,
+		in.Status.Stage == v1alpha1.ApplicationBackupStageFinal,
+		string(in.Status.Status),
+		in.Status.Reason,
+		in.Status.LastUpdateTimestamp,
+		in.Status.Conditions,
+	)
+	return out
+}
+
+func convertApplicationBackupToV1alpha1(in *ApplicationBackup) *v1alpha1.ApplicationBackup {
+	return &v1alpha1.ApplicationBackup{
+		TypeMeta:   typeMeta(v1alpha1.SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1alpha1.ApplicationBackupSpec{
+			Namespaces:              in.Spec.Namespaces,
+			BackupLocation:          in.Spec.BackupLocationName,
+			BackupLocationNamespace: in.Spec.BackupLocationNamespace,
+			Selectors:               in.Spec.Selectors,
+			PreExecRule:             in.Spec.PreExecRuleName,
+			PostExecRule:            in.Spec.PostExecRuleName,
+			ReclaimPolicy:           in.Spec.ReclaimPolicy,
+			SkipServiceUpdate:       in.Spec.SkipServiceUpdate,
+			Options:                 in.Spec.Options,
+			IncludeResources:        in.Spec.IncludeResources,
+			ResourceTypes:           in.Spec.ResourceTypes,
+			BackupType:              in.Spec.BackupType,
+			SecretTypes:             in.Spec.SecretTypes,
+			OCIExport:               in.Spec.OCIExport,
+			ResumeFrom:              in.Spec.ResumeFrom,
+			FailOnPartial:           in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished: in.Spec.TTLSecondsAfterFinished,
+			IncludeVolumeSnapshots:  in.Spec.IncludeVolumeSnapshots,
+		},
+		Status: v1alpha1.ApplicationBackupStatus{
+			Stage:               in.Status.Stage,
+			Status:              in.Status.Phase,
+			Reason:              in.Status.Reason,
+			Resources:           in.Status.Resources,
+			Volumes:             in.Status.Volumes,
+			BackupPath:          in.Status.BackupPath,
+			TriggerTimestamp:    in.Status.TriggerTimestamp,
+			LastUpdateTimestamp: in.Status.LastUpdateTimestamp,
+			FinishTimestamp:     in.Status.FinishTimestamp,
+			TotalSize:           in.Status.TotalSize,
+			Checkpoint:          in.Status.Checkpoint,
+			ResourceCheckpoints: in.Status.ResourceCheckpoints,
+			UploadedObjects:     in.Status.UploadedObjects,
+			OCIArtifact:         in.Status.OCIArtifact,
+			Estimate:            in.Status.Estimate,
+			EventHistory:        in.Status.EventHistory,
+			Conditions:          storedConditions(in.Status.Conditions),
+			References:          in.Status.References,
+			FailedItems:         in.Status.FailedItems,
+			ObservedGeneration:  in.Status.ObservedGeneration,
+			CleanupError:        in.Status.CleanupError,
+		},
+	}
+}
+
+func convertApplicationRestoreFromV1alpha1(in *v1alpha1.ApplicationRestore) *ApplicationRestore {
+	out := &ApplicationRestore{
+		TypeMeta:   typeMeta(SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: ApplicationRestoreSpec{
+			BackupName:                   in.Spec.BackupName,
+			BackupLocationName:           in.Spec.BackupLocation,
+			BackupLocationNamespace:      in.Spec.BackupLocationNamespace,
+			NamespaceMapping:             in.Spec.NamespaceMapping,
+			ReplacePolicy:                in.Spec.ReplacePolicy,
+			IncludeOptionalResourceTypes: in.Spec.IncludeOptionalResourceTypes,
+			IncludeResources:             in.Spec.IncludeResources,
+			StorageClassMapping:          in.Spec.StorageClassMapping,
+			InitContainer:                in.Spec.InitContainer,
+			RepairOwnership:              in.Spec.RepairOwnership,
+			Sandbox:                      in.Spec.Sandbox,
+			SandboxTTL:                   in.Spec.SandboxTTL,
+			VolumeDataSourcePolicy:       in.Spec.VolumeDataSourcePolicy,
+			JobPolicy:                    in.Spec.JobPolicy,
+			VolumeAttributeMapping:       in.Spec.VolumeAttributeMapping,
+			StorageClassCreation:         in.Spec.StorageClassCreation,
+			FailOnPartial:                in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished:      in.Spec.TTLSecondsAfterFinished,
+			QuotaPolicy:                  in.Spec.QuotaPolicy,
+			ImageCheck:                   in.Spec.ImageCheck,
+			RegistryMappingName:          in.Spec.RegistryMappingName,
+			CertificatePolicy:            in.Spec.CertificatePolicy,
+		},
+		Status: ApplicationRestoreStatus{
+			Stage:                in.Status.Stage,
+			Phase:                in.Status.Status,
+			Reason:               in.Status.Reason,
+			Resources:            in.Status.Resources,
+			Volumes:              in.Status.Volumes,
+			FinishTimestamp:      in.Status.FinishTimestamp,
+			LastUpdateTimestamp:  in.Status.LastUpdateTimestamp,
+			TotalSize:            in.Status.TotalSize,
+			Checkpoint:           in.Status.Checkpoint,
+			InitContainerRemoved: in.Status.InitContainerRemoved,
+			SandboxExpiry:        in.Status.SandboxExpiry,
+			SandboxDeleted:       in.Status.SandboxDeleted,
+			EventHistory:         in.Status.EventHistory,
+			FailedItems:          in.Status.FailedItems,
+			ObservedGeneration:   in.Status.ObservedGeneration,
+			MissingImages:        in.Status.MissingImages,
+			CleanupError:         in.Status.CleanupError,
+		},
+	}
+	out.Status.Conditions = getConditions(
+		&in.ObjectMeta,
+		in.Status.Stage == v1alpha1.ApplicationRestoreStageFinal,
+		string(in.Status.Status),
+		in.Status.Reason,
+		in.Status.LastUpdateTimestamp,
+		in.Status.Conditions,
+	)
+	return out
+}
+
+func convertApplicationRestoreToV1alpha1(in *ApplicationRestore) *v1alpha1.ApplicationRestore {
+	return &v1alpha1.ApplicationRestore{
+		TypeMeta:   typeMeta(v1alpha1.SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1alpha1.ApplicationRestoreSpec{
+			BackupName:                   in.Spec.BackupName,
+			BackupLocation:               in.Spec.BackupLocationName,
+			BackupLocationNamespace:      in.Spec.BackupLocationNamespace,
+			NamespaceMapping:             in.Spec.NamespaceMapping,
+			ReplacePolicy:                in.Spec.ReplacePolicy,
+			IncludeOptionalResourceTypes: in.Spec.IncludeOptionalResourceTypes,
+			IncludeResources:             in.Spec.IncludeResources,
+			StorageClassMapping:          in.Spec.StorageClassMapping,
+			InitContainer:                in.Spec.InitContainer,
+			RepairOwnership:              in.Spec.RepairOwnership,
+			Sandbox:                      in.Spec.Sandbox,
+			SandboxTTL:                   in.Spec.SandboxTTL,
+			VolumeDataSourcePolicy:       in.Spec.VolumeDataSourcePolicy,
+			JobPolicy:                    in.Spec.JobPolicy,
+			VolumeAttributeMapping:       in.Spec.VolumeAttributeMapping,
+			StorageClassCreation:         in.Spec.StorageClassCreation,
+			FailOnPartial:                in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished:      in.Spec.TTLSecondsAfterFinished,
+			QuotaPolicy:                  in.Spec.QuotaPolicy,
+			ImageCheck:                   in.Spec.ImageCheck,
+			RegistryMappingName:          in.Spec.RegistryMappingName,
+			CertificatePolicy:            in.Spec.CertificatePolicy,
+		},
+		Status: v1alpha1.ApplicationRestoreStatus{
+			Stage:                in.Status.Stage,
+			Status:               in.Status.Phase,
+			Reason:               in.Status.Reason,
+			Resources:            in.Status.Resources,
+			Volumes:              in.Status.Volumes,
+			FinishTimestamp:      in.Status.FinishTimestamp,
+			LastUpdateTimestamp:  in.Status.LastUpdateTimestamp,
+			TotalSize:            in.Status.TotalSize,
+			Checkpoint:           in.Status.Checkpoint,
+			InitContainerRemoved: in.Status.InitContainerRemoved,
+			SandboxExpiry:        in.Status.SandboxExpiry,
+			SandboxDeleted:       in.Status.SandboxDeleted,
+			EventHistory:         in.Status.EventHistory,
+			FailedItems:          in.Status.FailedItems,
+			ObservedGeneration:   in.Status.ObservedGeneration,
+			MissingImages:        in.Status.MissingImages,
+			CleanupError:         in.Status.CleanupError,
+			Conditions:           storedConditions(in.Status.Conditions),
+		},
+	}
+}
+
+func convertMigrationFromV1alpha1(in *v1alpha1.Migration) *Migration {
+	out := &Migration{
+		TypeMeta:   typeMeta(SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: MigrationSpec{
+			ClusterPairName:              in.Spec.ClusterPair,
+			AdminClusterPairName:         in.Spec.AdminClusterPair,
+			Namespaces:                   in.Spec.Namespaces,
+			IncludeResources:             in.Spec.IncludeResources,
+			IncludeVolumes:               in.Spec.IncludeVolumes,
+			StartApplications:            in.Spec.StartApplications,
+			PurgeDeletedResources:        in.Spec.PurgeDeletedResources,
+			SkipServiceUpdate:            in.Spec.SkipServiceUpdate,
+			Selectors:                    in.Spec.Selectors,
+			PreExecRuleName:              in.Spec.PreExecRule,
+			PostExecRuleName:             in.Spec.PostExecRule,
+			IncludeOptionalResourceTypes: in.Spec.IncludeOptionalResourceTypes,
+			SkipDeletedNamespaces:        in.Spec.SkipDeletedNamespaces,
+			DiffOnly:                     in.Spec.DiffOnly,
+			SecretTypes:                  in.Spec.SecretTypes,
+			JobPolicy:                    in.Spec.JobPolicy,
+			ServiceMeshPolicy:            in.Spec.ServiceMeshPolicy,
+			StorageClassCreation:         in.Spec.StorageClassCreation,
+			FailOnPartial:                in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished:      in.Spec.TTLSecondsAfterFinished,
+			RegistryMappingName:          in.Spec.RegistryMappingName,
+			Staging:                      in.Spec.Staging,
+		},
+		Status: MigrationStatus{
+			Stage:                            in.Status.Stage,
+			Phase:                            in.Status.Status,
+			Resources:                        in.Status.Resources,
+			Volumes:                          in.Status.Volumes,
+			FinishTimestamp:                  in.Status.FinishTimestamp,
+			VolumeMigrationFinishTimestamp:   in.Status.VolumeMigrationFinishTimestamp,
+			ResourceMigrationFinishTimestamp: in.Status.ResourceMigrationFinishTimestamp,
+			Summary:                          in.Status.Summary,
+			Diff:                             in.Status.Diff,
+			EventHistory:                     in.Status.EventHistory,
+			FailedItems:                      in.Status.FailedItems,
+			ObservedGeneration:               in.Status.ObservedGeneration,
+			Staging:                          in.Status.Staging,
+			CleanupError:                     in.Status.CleanupError,
+		},
+	}
+	out.Status.Conditions = getConditions(
+		&in.ObjectMeta,
+		in.Status.Stage == v1alpha1.MigrationStageFinal,
+		string(in.Status.Status),
+		"",
+		in.Status.FinishTimestamp,
+		in.Status.Conditions,
+	)
+	return out
+}
+
+func convertMigrationToV1alpha1(in *Migration) *v1alpha1.Migration {
+	return &v1alpha1.Migration{
+		TypeMeta:   typeMeta(v1alpha1.SchemeGroupVersion.String(), in.Kind),
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1alpha1.MigrationSpec{
+			ClusterPair:                  in.Spec.ClusterPairName,
+			AdminClusterPair:             in.Spec.AdminClusterPairName,
+			Namespaces:                   in.Spec.Namespaces,
+			IncludeResources:             in.Spec.IncludeResources,
+			IncludeVolumes:               in.Spec.IncludeVolumes,
+			StartApplications:            in.Spec.StartApplications,
+			PurgeDeletedResources:        in.Spec.PurgeDeletedResources,
+			SkipServiceUpdate:            in.Spec.SkipServiceUpdate,
+			Selectors:                    in.Spec.Selectors,
+			PreExecRule:                  in.Spec.PreExecRuleName,
+			PostExecRule:                 in.Spec.PostExecRuleName,
+			IncludeOptionalResourceTypes: in.Spec.IncludeOptionalResourceTypes,
+			SkipDeletedNamespaces:        in.Spec.SkipDeletedNamespaces,
+			DiffOnly:                     in.Spec.DiffOnly,
+			SecretTypes:                  in.Spec.SecretTypes,
+			JobPolicy:                    in.Spec.JobPolicy,
+			ServiceMeshPolicy:            in.Spec.ServiceMeshPolicy,
+			StorageClassCreation:         in.Spec.StorageClassCreation,
+			FailOnPartial:                in.Spec.FailOnPartial,
+			TTLSecondsAfterFinished:      in.Spec.TTLSecondsAfterFinished,
+			RegistryMappingName:          in.Spec.RegistryMappingName,
+			Staging:                      in.Spec.Staging,
+		},
+		Status: v1alpha1.MigrationStatus{
+			Stage:                            in.Status.Stage,
+			Status:                           in.Status.Phase,
+			Resources:                        in.Status.Resources,
+			Volumes:                          in.Status.Volumes,
+			FinishTimestamp:                  in.Status.FinishTimestamp,
+			VolumeMigrationFinishTimestamp:   in.Status.VolumeMigrationFinishTimestamp,
+			ResourceMigrationFinishTimestamp: in.Status.ResourceMigrationFinishTimestamp,
+			Summary:                          in.Status.Summary,
+			Diff:                             in.Status.Diff,
+			EventHistory:                     in.Status.EventHistory,
+			FailedItems:                      in.Status.FailedItems,
+			ObservedGeneration:               in.Status.ObservedGeneration,
+			Staging:                          in.Status.Staging,
+			CleanupError:                     in.Status.CleanupError,
+			Conditions:                       storedConditions(in.Status.Conditions),
+		},
+	}
+}
+
+func typeMeta(apiVersion, kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: apiVersion,
+		Kind:       kind,
+	}
+}
+
+// getConditions returns the Progressing and Succeeded conditions for an
+// operation with the given status, followed by the stored conditions
+func getConditions(
+	objectMeta *metav1.ObjectMeta,
+	finished bool,
+	status string,
+	message string,
+	lastUpdate metav1.Time,
+	stored []metav1.Condition,
+) []metav1.Condition {
+	reason := status
+	if reason == "" {
+		reason = ReasonPending
+	}
+	transitionTime := lastUpdate
+	if transitionTime.IsZero() {
+		transitionTime = objectMeta.CreationTimestamp
+	}
+
+	progressing := metav1.ConditionTrue
+	succeeded := metav1.ConditionUnknown
+	if finished {
+		progressing = metav1.ConditionFalse
+		succeeded = metav1.ConditionFalse
+		if reason == ReasonSuccessful || reason == ReasonPartialSuccess {
+			succeeded = metav1.ConditionTrue
+		}
+	}
+	conditions := []metav1.Condition{
+		{
+			Type:               ConditionProgressing,
+			Status:             progressing,
+			ObservedGeneration: objectMeta.Generation,
+			LastTransitionTime: transitionTime,
+			Reason:             reason,
+			Message:            message,
+		},
+		{
+			Type:               ConditionSucceeded,
+			Status:             succeeded,
+			ObservedGeneration: objectMeta.Generation,
+			LastTransitionTime: transitionTime,
+			Reason:             reason,
+			Message:            message,
+		},
+	}
+	// The Completed condition is set by the controllers once the operation
+	// has finished, so it is passed through to keep its transition time
+	return append(conditions, storedConditions(stored)...)
+}
+
+// storedConditions returns the conditions that aren't generated from the
+// status, like the Completed condition, which are stored in v1alpha1
+func storedConditions(conditions []metav1.Condition) []metav1.Condition {
+	var stored []metav1.Condition
+	for _, condition := range conditions {
+		if condition.Type != ConditionProgressing && condition.Type != ConditionSucceeded {
+			stored = append(stored, condition)
+		}
+	}
+	return stored
+}
@@ -0,0 +1,104 @@
+//go:build unittest
+// +build unittest
+
+package v1alpha2
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRoundTrip(t *testing.T) {
+	f := fuzz.New().NilChance(0.2).NumElements(1, 2)
+	for i := 0; i < 100; i++ {
+		for _, in := range []runtime.Object{
+			&v1alpha1.ApplicationBackup{},
+			&v1alpha1.ApplicationRestore{},
+			&v1alpha1.Migration{},
+		} {
+			f.Fuzz(in)
+			in.GetObjectKind().SetGroupVersionKind(v1alpha1.SchemeGroupVersion.WithKind(in.GetObjectKind().GroupVersionKind().Kind))
+			converted, err := ConvertFromV1alpha1(in)
+			require.NoError(t, err)
+			require.Equal(t, SchemeGroupVersion.String(), converted.GetObjectKind().GroupVersionKind().GroupVersion().String())
+			out, err := ConvertToV1alpha1(converted)
+			require.NoError(t, err)
+			require.Equal(t, in, out)
+		}
+	}
+
+	_, err := ConvertFromV1alpha1(&v1alpha1.Rule{})
+	require.Error(t, err, "Conversion should fail for unsupported types")
+}
+
+func TestConversion(t *testing.T) {
+	backup := &v1alpha1.ApplicationBackup{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "ApplicationBackup",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "backup",
+			Namespace:  "ns",
+			Generation: 2,
+		},
+		Spec: v1alpha1.ApplicationBackupSpec{
+			BackupLocation: "location",
+			PreExecRule:    "pre",
+		},
+	}
+	converted, err := ConvertFromV1alpha1(backup)
+	require.NoError(t, err)
+	out := converted.(*ApplicationBackup)
+	require.Equal(t, "location", out.Spec.BackupLocationName)
+	require.Equal(t, "pre", out.Spec.PreExecRuleName)
+	require.Len(t, out.Status.Conditions, 2)
+	progressing := out.Status.Conditions[0]
+	require.Equal(t, ConditionProgressing, progressing.Type)
+	require.Equal(t, metav1.ConditionTrue, progressing.Status)
+	require.Equal(t, ReasonPending, progressing.Reason)
+	require.Equal(t, int64(2), progressing.ObservedGeneration)
+	require.Equal(t, metav1.ConditionUnknown, out.Status.Conditions[1].Status)
+
+	backup.Status.Stage = v1alpha1.ApplicationBackupStageFinal
+	backup.Status.Status = v1alpha1.ApplicationBackupStatusFailed
+	backup.Status.Reason = "Backup failed"
+	converted, err = ConvertFromV1alpha1(backup)
+	require.NoError(t, err)
+	out = converted.(*ApplicationBackup)
+	require.Equal(t, v1alpha1.ApplicationBackupStatusFailed, out.Status.Phase)
+	require.Equal(t, metav1.ConditionFalse, out.Status.Conditions[0].Status)
+	succeeded := out.Status.Conditions[1]
+	require.Equal(t, metav1.ConditionFalse, succeeded.Status)
+	require.Equal(t, ReasonFailed, succeeded.Reason)
+	require.Equal(t, "Backup failed", succeeded.Message)
+	require.Len(t, out.Status.Conditions, 2)
+
+	backup.SetCompletedCondition()
+	converted, err = ConvertFromV1alpha1(backup)
+	require.NoError(t, err)
+	out = converted.(*ApplicationBackup)
+	require.Len(t, out.Status.Conditions, 3)
+	completed := out.Status.Conditions[2]
+	require.Equal(t, v1alpha1.ConditionCompleted, completed.Type)
+	require.Equal(t, metav1.ConditionFalse, completed.Status)
+	require.Equal(t, ReasonFailed, completed.Reason)
+	converted, err = ConvertToV1alpha1(out)
+	require.NoError(t, err)
+	require.Equal(t, []metav1.Condition{completed}, converted.(*v1alpha1.ApplicationBackup).Status.Conditions)
+
+	migration := &v1alpha1.Migration{
+		Status: v1alpha1.MigrationStatus{
+			Stage:  v1alpha1.MigrationStageFinal,
+			Status: v1alpha1.MigrationStatusPartialSuccess,
+		},
+	}
+	converted, err = ConvertFromV1alpha1(migration)
+	require.NoError(t, err)
+	require.Equal(t, metav1.ConditionTrue, converted.(*Migration).Status.Conditions[1].Status)
+}
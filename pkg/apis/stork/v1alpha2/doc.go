@@ -0,0 +1,9 @@
+// +k8s:deepcopy-gen=package,register
+
+// Package v1alpha2 is the v1alpha2 version of the API.
+//
+// v1alpha1 is still the storage version. Objects are converted between the
+// versions by the conversion webhook, so both versions can be used for the
+// same objects.
+// +groupName=stork.libopenstorage.org
+package v1alpha2
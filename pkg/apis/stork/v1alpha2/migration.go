@@ -0,0 +1,110 @@
+package v1alpha2
+
+import (
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Migration represents migration status
+type Migration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              MigrationSpec   `json:"spec"`
+	Status            MigrationStatus `json:"status"`
+}
+
+// MigrationSpec is the spec used to migrate apps between clusterpairs
+type MigrationSpec struct {
+	// ClusterPairName is the name of the ClusterPair to migrate to. It was
+	// called clusterPair in v1alpha1.
+	ClusterPairName string `json:"clusterPairName"`
+	// AdminClusterPairName is the name of the ClusterPair in the admin
+	// namespace used to migrate cluster scoped resources. It was called
+	// adminClusterPair in v1alpha1.
+	AdminClusterPairName  string            `json:"adminClusterPairName,omitempty"`
+	Namespaces            []string          `json:"namespaces"`
+	IncludeResources      *bool             `json:"includeResources,omitempty"`
+	IncludeVolumes        *bool             `json:"includeVolumes,omitempty"`
+	StartApplications     *bool             `json:"startApplications,omitempty"`
+	PurgeDeletedResources *bool             `json:"purgeDeletedResources,omitempty"`
+	SkipServiceUpdate     *bool             `json:"skipServiceUpdate,omitempty"`
+	Selectors             map[string]string `json:"selectors,omitempty"`
+	// PreExecRuleName is the name of the Rule to run before the migration.
+	// It was called preExecRule in v1alpha1.
+	PreExecRuleName string `json:"preExecRuleName,omitempty"`
+	// PostExecRuleName is the name of the Rule to run after the volumes have
+	// been migrated. It was called postExecRule in v1alpha1.
+	PostExecRuleName             string   `json:"postExecRuleName,omitempty"`
+	IncludeOptionalResourceTypes []string `json:"includeOptionalResourceTypes,omitempty"`
+	SkipDeletedNamespaces        *bool    `json:"skipDeletedNamespaces,omitempty"`
+	// DiffOnly, if set, only computes what would change on the destination
+	// cluster without migrating any volumes or resources
+	DiffOnly *bool `json:"diffOnly,omitempty"`
+	// SecretTypes filters the Secrets to be migrated by their type
+	SecretTypes *v1alpha1.SecretTypeFilter `json:"secretTypes,omitempty"`
+	// JobPolicy decides how Jobs and CronJobs are migrated
+	JobPolicy *v1alpha1.JobPolicy `json:"jobPolicy,omitempty"`
+	// ServiceMeshPolicy decides how the service mesh configuration of the
+	// applications is migrated
+	ServiceMeshPolicy *v1alpha1.ServiceMeshPolicy `json:"serviceMeshPolicy,omitempty"`
+	// StorageClassCreation, if set, creates the storage classes of the
+	// migrated PVCs that don't exist on the destination
+	StorageClassCreation *v1alpha1.StorageClassCreationPolicy `json:"storageClassCreation,omitempty"`
+	// FailOnPartial marks the migration as Failed instead of PartialSuccess if
+	// some of its volumes or resources couldn't be migrated
+	FailOnPartial bool `json:"failOnPartial,omitempty"`
+	// TTLSecondsAfterFinished is the number of seconds after which the
+	// migration is deleted once it has finished. The default from the stork
+	// configuration is used if it isn't set.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+	// RegistryMappingName is the name of a RegistryMapping in the namespace
+	// of the migration used to rewrite the images of the migrated workloads
+	RegistryMappingName string `json:"registryMappingName,omitempty"`
+	// Staging, if set, keeps syncing the volumes and resources to the
+	// destination in the background until a cutover is requested. The
+	// applications aren't started on the destination until the cutover. It
+	// isn't supported in the templates of MigrationSchedules.
+	Staging *v1alpha1.MigrationStagingPolicy `json:"staging,omitempty"`
+}
+
+// MigrationStatus is the status of a migration operation
+type MigrationStatus struct {
+	// Conditions are the Progressing and Succeeded conditions of the
+	// migration
+	Conditions []metav1.Condition          `json:"conditions,omitempty"`
+	Stage      v1alpha1.MigrationStageType `json:"stage,omitempty"`
+	// Phase is the status of the migration. It was called status in
+	// v1alpha1.
+	Phase                            v1alpha1.MigrationStatusType      `json:"phase,omitempty"`
+	Resources                        []*v1alpha1.MigrationResourceInfo `json:"resources,omitempty"`
+	Volumes                          []*v1alpha1.MigrationVolumeInfo   `json:"volumes,omitempty"`
+	FinishTimestamp                  metav1.Time                       `json:"finishTimestamp,omitempty"`
+	VolumeMigrationFinishTimestamp   metav1.Time                       `json:"volumeMigrationFinishTimestamp,omitempty"`
+	ResourceMigrationFinishTimestamp metav1.Time                       `json:"resourceMigrationFinishTimestamp,omitempty"`
+	Summary                          *v1alpha1.MigrationSummary        `json:"summary,omitempty"`
+	Diff                             *v1alpha1.MigrationDiff           `json:"diff,omitempty"`
+	EventHistory                     []*v1alpha1.EventHistoryEntry     `json:"eventHistory,omitempty"`
+	// FailedItems are the volumes and resources that couldn't be migrated
+	FailedItems []v1alpha1.FailedItem `json:"failedItems,omitempty"`
+	// ObservedGeneration is the generation of the migration that was last
+	// handled by the controller
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Staging is the status of the syncs and the cutover of a staged
+	// migration
+	Staging *v1alpha1.MigrationStagingStatus `json:"staging,omitempty"`
+	// CleanupError is the last error hit while cleaning up the migration after
+	// it was deleted
+	CleanupError string `json:"cleanupError,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MigrationList is a list of Migrations
+type MigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Migration `json:"items"`
+}
@@ -6,15 +6,10 @@ import (
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
-	stork_crd "github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
-	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -30,6 +25,9 @@ type ApplicationManager struct {
 	Recorder          record.EventRecorder
 	ResourceCollector resourcecollector.ResourceCollector
 	RsyncTime         int64
+	// WatchNamespaces the background controllers are restricted to, all
+	// namespaces if it is empty
+	WatchNamespaces []string
 }
 
 // Init Initializes the ApplicationManager and any children controller
@@ -37,12 +35,12 @@ func (a *ApplicationManager) Init(mgr manager.Manager, adminNamespace string, st
 	if err := a.createCRD(); err != nil {
 		return err
 	}
-	backupController := controllers.NewApplicationBackup(mgr, a.Recorder, a.ResourceCollector)
+	backupController := controllers.NewApplicationBackup(mgr, a.Recorder, a.ResourceCollector, a.WatchNamespaces)
 	if err := backupController.Init(mgr, adminNamespace, a.RsyncTime); err != nil {
 		return err
 	}
 
-	restoreController := controllers.NewApplicationRestore(mgr, a.Recorder, a.ResourceCollector)
+	restoreController := controllers.NewApplicationRestore(mgr, a.Recorder, a.ResourceCollector, a.WatchNamespaces)
 	if err := restoreController.Init(mgr, adminNamespace); err != nil {
 		return err
 	}
@@ -56,14 +54,24 @@ func (a *ApplicationManager) Init(mgr manager.Manager, adminNamespace string, st
 	if err := scheduleController.Init(mgr); err != nil {
 		return err
 	}
+	drillController := controllers.NewDRDrill(mgr, a.Recorder)
+	if err := drillController.Init(mgr); err != nil {
+		return err
+	}
 	syncController := &controllers.BackupSyncController{
 		Recorder:     a.Recorder,
 		SyncInterval: 1 * time.Minute,
+		Namespaces:   a.WatchNamespaces,
 	}
 	if err := syncController.Init(stopChannel); err != nil {
 		return err
 	}
 
+	dataProtectionStatusController := controllers.NewDataProtectionStatus(mgr, 1*time.Minute, a.WatchNamespaces)
+	if err := dataProtectionStatusController.Init(stopChannel); err != nil {
+		return err
+	}
+
 	if err := controllers.RegisterDefaultCRDs(); err != nil {
 		return err
 	}
@@ -71,51 +79,9 @@ func (a *ApplicationManager) Init(mgr manager.Manager, adminNamespace string, st
 }
 
 func (a *ApplicationManager) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.BackupLocationResourceName,
-		Plural:  stork_api.BackupLocationResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.BackupLocation{}).Name(),
-	}
-	appReg := apiextensions.CustomResource{
-		Name:    stork_api.ApplicationRegistrationResourceName,
-		Plural:  stork_api.ApplicationRegistrationResourcePlural,
-		Group:   stork_crd.GroupName,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.ClusterScoped,
-		Kind:    reflect.TypeOf(stork_api.ApplicationRegistration{}).Name(),
-	}
-
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		if err := apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval); err != nil {
-			return err
-		}
-		err = k8sutils.CreateCRD(appReg)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(appReg.Plural+"."+appReg.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	if err := apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval); err != nil {
-		return err
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(appReg)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(appReg, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(
+		reflect.TypeOf(stork_api.BackupLocation{}).Name(),
+		reflect.TypeOf(stork_api.ApplicationRegistration{}).Name(),
+		reflect.TypeOf(stork_api.RegistryMapping{}).Name(),
+	)
 }
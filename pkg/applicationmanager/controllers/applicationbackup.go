@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,30 +16,37 @@ import (
 	"github.com/go-openapi/inflect"
 	"github.com/libopenstorage/stork/drivers"
 	"github.com/libopenstorage/stork/drivers/volume"
-	"github.com/libopenstorage/stork/pkg/apis/stork"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backupestimate"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/errors"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
+	"github.com/libopenstorage/stork/pkg/ociexport"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
-	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/apiextensions"
 	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	"gocloud.dev/gcerrors"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -46,13 +54,16 @@ import (
 )
 
 const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
+	applicationBackupControllerName = "application-backup-controller"
 
-	resourceObjectName = "resources.json"
-	crdObjectName      = "crds.json"
-	nsObjectName       = "namespaces.json"
-	metadataObjectName = "metadata.json"
+	resourceObjectName     = "resources.json"
+	crdObjectName          = "crds.json"
+	nsObjectName           = "namespaces.json"
+	metadataObjectName     = "metadata.json"
+	storageClassObjectName = "storageclasses.json"
+	imagesObjectName       = "images.json"
+
+	resourceCheckpointPrefix = "resources-checkpoint"
 
 	backupCancelBackoffInitialDelay = 5 * time.Second
 	backupCancelBackoffFactor       = 1
@@ -78,22 +89,27 @@ var (
 )
 
 // NewApplicationBackup creates a new instance of ApplicationBackupController.
-func NewApplicationBackup(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector) *ApplicationBackupController {
+// The controller is restricted to the given namespaces, or all namespaces if
+// none are given.
+func NewApplicationBackup(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector, namespaces []string) *ApplicationBackupController {
 	return &ApplicationBackupController{
-		client:            mgr.GetClient(),
-		recorder:          r,
+		client:            controllers.NewConditionClient(mgr.GetClient()),
+		recorder:          controllers.NewEventHistoryRecorder(r),
 		resourceCollector: rc,
+		watchNamespaces:   namespaces,
 	}
 }
 
 // ApplicationBackupController reconciles applicationbackup objects
 type ApplicationBackupController struct {
-	client runtimeclient.Client
+	client     runtimeclient.Client
+	kubeClient kubernetes.Interface
 
 	recorder             record.EventRecorder
 	resourceCollector    resourcecollector.ResourceCollector
 	backupAdminNamespace string
 	reconcileTime        time.Duration
+	watchNamespaces      []string
 }
 
 // Init Initialize the application backup controller
@@ -103,13 +119,95 @@ func (a *ApplicationBackupController) Init(mgr manager.Manager, backupAdminNames
 		return err
 	}
 
+	a.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("error getting kubernetes client: %v", err)
+	}
+
 	a.backupAdminNamespace = backupAdminNamespace
 	if err := a.performRuleRecovery(); err != nil {
 		logrus.Errorf("Failed to perform recovery for backup rules: %v", err)
 		return err
 	}
 	a.reconcileTime = time.Duration(syncTime) * time.Second
-	return controllers.RegisterTo(mgr, "application-backup-controller", a, &stork_api.ApplicationBackup{})
+	controllers.RegisterShutdownHandler(a)
+	return controllers.RegisterWithProgressTo(mgr, applicationBackupControllerName, a, volume.ProgressKindBackup,
+		func() runtimeclient.Object { return &stork_api.ApplicationBackup{} }, &stork_api.ApplicationBackup{})
+}
+
+func (a *ApplicationBackupController) estimateBackup(backup *stork_api.ApplicationBackup) error {
+	estimator := backupestimate.New(
+		a.kubeClient,
+		&a.resourceCollector,
+		storkconfig.GetBackupThroughput(backupestimate.DefaultThroughput),
+	)
+	estimate, err := estimator.Estimate(backup.Spec.Namespaces, backup.Spec.Selectors)
+	if err != nil {
+		return err
+	}
+	backup.Status.Estimate = estimate
+	log.ApplicationBackupLog(backup).Infof("Estimated backup size %v bytes for %v volumes and %v resources, duration %v",
+		estimate.TotalBytes, len(estimate.Volumes), estimate.Objects, estimate.Duration.Duration)
+	return a.client.Update(context.TODO(), backup)
+}
+
+// Checkpoint records the current stage of all in-progress backups so that
+// the next stork instance can resume them.
+func (a *ApplicationBackupController) Checkpoint() error {
+	// Only the backups in the watched namespaces are checkpointed, the others
+	// belong to other stork instances
+	backups := make([]stork_api.ApplicationBackup, 0)
+	for _, ns := range controllers.NamespacesToList(a.watchNamespaces) {
+		backupList, err := storkops.Instance().ListApplicationBackups(ns, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing backups to checkpoint: %v", err)
+		}
+		backups = append(backups, backupList.Items...)
+	}
+	var lastErr error
+	for i := range backups {
+		backup := &backups[i]
+		if backup.DeletionTimestamp != nil ||
+			backup.Status.Stage == "" ||
+			backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
+			continue
+		}
+		backup.Status.Checkpoint = controllers.NewCheckpoint(string(backup.Status.Stage))
+		backup.Status.LastUpdateTimestamp = metav1.Now()
+		if _, err := storkops.Instance().UpdateApplicationBackup(backup); err != nil {
+			log.ApplicationBackupLog(backup).Errorf("Error checkpointing backup: %v", err)
+			lastErr = err
+			continue
+		}
+		log.ApplicationBackupLog(backup).Infof("Checkpointed backup in stage %v", backup.Status.Stage)
+	}
+	return lastErr
+}
+
+// getCheckpointedBackupStage returns the stage that a checkpointed backup
+// resumes from. The commands of the pre-exec rule that were running in the
+// background were stopped along with the stork instance that took the
+// checkpoint, so the rule is run again if the volume backups hadn't been
+// started yet. Resources that were already backed up are picked up from the
+// resource checkpoints by the applications stage.
+func getCheckpointedBackupStage(
+	backup *stork_api.ApplicationBackup,
+	checkpoint *stork_api.OperationCheckpoint,
+) stork_api.ApplicationBackupStageType {
+	stage := stork_api.ApplicationBackupStageType(checkpoint.Stage)
+	switch stage {
+	case stork_api.ApplicationBackupStagePreExecRule,
+		stork_api.ApplicationBackupStageVolumes:
+		if backup.Spec.PreExecRule != "" && len(backup.Status.Volumes) == 0 {
+			return stork_api.ApplicationBackupStagePreExecRule
+		}
+		return stage
+	case stork_api.ApplicationBackupStageInitial,
+		stork_api.ApplicationBackupStageApplications:
+		return stage
+	}
+	// Keep the current stage if the checkpoint can't be used
+	return backup.Status.Stage
 }
 
 // Reconcile updates for ApplicationBackup objects.
@@ -127,18 +225,25 @@ func (a *ApplicationBackupController) Reconcile(ctx context.Context, request rec
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationBackupControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup) {
 		controllers.SetFinalizer(backup, controllers.FinalizerCleanup)
 		return reconcile.Result{Requeue: true}, a.client.Update(context.TODO(), backup)
 	}
+
+	// The layout upgrade is requested with an annotation, which doesn't
+	// change the generation
+	if controllers.IsObserved(backup) && !isLayoutUpgradeRequested(backup) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = a.handle(context.TODO(), backup); err != nil && err != errResourceBusy {
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationBackupControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: a.reconcileTime}, nil
+	return controllers.Requeue(ctx, a.client, backup, storkconfig.GetRequeuePeriod(applicationBackupControllerName, a.reconcileTime))
 }
 
 func setKind(snap *stork_api.ApplicationBackup) {
@@ -200,10 +305,24 @@ func (a *ApplicationBackupController) updateWithAllNamespaces(backup *stork_api.
 	return nil
 }
 
+// getBackupLocation returns the BackupLocation after checking that it can be
+// used from the namespace of the backup or restore
+func getBackupLocation(name, namespace, usedFromNamespace string) (*stork_api.BackupLocation, error) {
+	backupLocation, err := storkops.Instance().GetBackupLocation(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !backupLocation.IsNamespaceAllowed(usedFromNamespace) {
+		return nil, fmt.Errorf("backup location %v/%v is not allowed to be used from namespace %v",
+			namespace, name, usedFromNamespace)
+	}
+	return backupLocation, nil
+}
+
 // Try to create the backup location path. Ignore errors since this is best
 // effort
 func (a *ApplicationBackupController) createBackupLocationPath(backup *stork_api.ApplicationBackup) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
 	if err != nil {
 		return fmt.Errorf("error getting backup location path: %v", err)
 	}
@@ -217,17 +336,28 @@ func (a *ApplicationBackupController) createBackupLocationPath(backup *stork_api
 func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_api.ApplicationBackup) error {
 	if backup.DeletionTimestamp != nil {
 		if controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup) {
+			releaseVolumeLocks(backup)
 			canDelete, err := a.deleteBackup(backup)
 			if err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(a), err)
 			}
 			if !canDelete {
+				// Record the error so that backups held back by the
+				// finalizer can be audited
+				if controllers.SetCleanupError(backup, err) {
+					return a.client.Update(ctx, backup)
+				}
 				return nil
 			}
 			// Calling cleanupResources which will cleanup the resources created by applicationbackup controller.
 			// In the case of kdmp driver, it will cleanup the dataexport CRs.
 			err = a.cleanupResources(backup)
 			if err != nil {
+				if controllers.SetCleanupError(backup, err) {
+					if updateErr := a.client.Update(ctx, backup); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(a), updateErr)
+					}
+				}
 				return err
 			}
 		}
@@ -240,6 +370,10 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 		return nil
 	}
 
+	if isBackupCancelRequested(backup) {
+		return a.cancelBackup(ctx, backup)
+	}
+
 	// Check whether namespace is allowed to be backed before each stage
 	// Restrict backup to only the namespace that the object belongs
 	// except for the namespace designated by the admin
@@ -253,6 +387,21 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 		return nil
 	}
 
+	if backup.Status.Checkpoint != nil {
+		checkpoint := backup.Status.Checkpoint
+		backup.Status.Checkpoint = nil
+		backup.Status.Stage = getCheckpointedBackupStage(backup, checkpoint)
+		backup.Status.LastUpdateTimestamp = metav1.Now()
+		message := fmt.Sprintf("Resuming backup from stage %v checkpointed by %v at %v in stage %v",
+			backup.Status.Stage, checkpoint.Owner, checkpoint.Timestamp, checkpoint.Stage)
+		log.ApplicationBackupLog(backup).Infof(message)
+		a.recorder.Event(backup,
+			v1.EventTypeNormal,
+			string(stork_api.ApplicationBackupStatusInProgress),
+			message)
+		return a.client.Update(ctx, backup)
+	}
+
 	var terminationChannels []chan bool
 	var err error
 
@@ -300,6 +449,28 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 			}
 		}
 
+		// Make sure the backup location can be used from the namespace of the
+		// backup if it's in another namespace
+		if backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace()); err == nil &&
+			!backupLocation.IsNamespaceAllowed(backup.Namespace) {
+			backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+			backup.Status.Reason = fmt.Sprintf("Backup location %v/%v is not allowed to be used from namespace %v",
+				backupLocation.Namespace, backupLocation.Name, backup.Namespace)
+			backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+			backup.Status.FinishTimestamp = metav1.Now()
+			backup.Status.LastUpdateTimestamp = metav1.Now()
+			log.ApplicationBackupLog(backup).Errorf(backup.Status.Reason)
+			a.recorder.Event(backup,
+				v1.EventTypeWarning,
+				string(stork_api.ApplicationBackupStatusFailed),
+				backup.Status.Reason)
+			err = a.client.Update(context.TODO(), backup)
+			if err != nil {
+				log.ApplicationBackupLog(backup).Errorf("Error updating: %v", err)
+			}
+			return nil
+		}
+
 		// Try to create the backupLocation path, just log error if it fails
 		err := a.createBackupLocationPath(backup)
 		if err != nil {
@@ -312,7 +483,7 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 
 		// Make sure the rules exist if configured
 		if backup.Spec.PreExecRule != "" {
-			_, err := storkops.Instance().GetRule(backup.Spec.PreExecRule, backup.Namespace)
+			_, err := rule.GetRule(backup.Spec.PreExecRule, backup.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PreExecRule %v: %v", backup.Spec.PreExecRule, err)
 				log.ApplicationBackupLog(backup).Errorf(message)
@@ -324,7 +495,7 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 			}
 		}
 		if backup.Spec.PostExecRule != "" {
-			_, err := storkops.Instance().GetRule(backup.Spec.PostExecRule, backup.Namespace)
+			_, err := rule.GetRule(backup.Spec.PostExecRule, backup.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PostExecRule %v: %v", backup.Spec.PreExecRule, err)
 				log.ApplicationBackupLog(backup).Errorf(message)
@@ -335,6 +506,46 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 				return nil
 			}
 		}
+
+		// Move the volumes that were already backed up by the backup being
+		// resumed before any volume is backed up again
+		if backup.Spec.ResumeFrom != "" {
+			previous, err := a.getResumeFrom(backup)
+			if err != nil {
+				backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+				backup.Status.Reason = fmt.Sprintf("Error resuming from backup %v: %v", backup.Spec.ResumeFrom, err)
+				backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+				backup.Status.FinishTimestamp = metav1.Now()
+				backup.Status.LastUpdateTimestamp = metav1.Now()
+				log.ApplicationBackupLog(backup).Errorf(backup.Status.Reason)
+				a.recorder.Event(backup,
+					v1.EventTypeWarning,
+					string(stork_api.ApplicationBackupStatusFailed),
+					backup.Status.Reason)
+				err = a.client.Update(context.TODO(), backup)
+				if err != nil {
+					log.ApplicationBackupLog(backup).Errorf("Error updating: %v", err)
+				}
+				return nil
+			}
+			if err := a.resumeBackup(backup, previous); err != nil {
+				message := fmt.Sprintf("Error moving volumes from backup %v: %v", previous.Name, err)
+				log.ApplicationBackupLog(backup).Errorf(message)
+				a.recorder.Event(backup,
+					v1.EventTypeWarning,
+					string(stork_api.ApplicationBackupStatusInProgress),
+					message)
+				return err
+			}
+		}
+
+		// Estimate the size of the backup before any data is moved. This is
+		// only informational so the backup continues if it fails.
+		if backup.Status.Estimate == nil {
+			if err := a.estimateBackup(backup); err != nil {
+				log.ApplicationBackupLog(backup).Warnf("Error estimating backup size: %v", err)
+			}
+		}
 		fallthrough
 	case stork_api.ApplicationBackupStagePreExecRule:
 		var inProgress bool
@@ -368,6 +579,15 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 	case stork_api.ApplicationBackupStageVolumes:
 		err := a.backupVolumes(backup, terminationChannels)
 		if err != nil {
+			if _, ok := err.(*pvclock.ErrLocked); ok {
+				message := fmt.Sprintf("Waiting for volume to be unlocked: %v", err)
+				log.ApplicationBackupLog(backup).Infof(message)
+				a.recorder.Event(backup,
+					v1.EventTypeNormal,
+					string(stork_api.ApplicationBackupStatusInProgress),
+					message)
+				return errResourceBusy
+			}
 			message := fmt.Sprintf("Error backing up volumes: %v", err)
 			log.ApplicationBackupLog(backup).Errorf(message)
 			a.recorder.Event(backup,
@@ -392,7 +612,23 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 		}
 
 	case stork_api.ApplicationBackupStageFinal:
-		// Do Nothing
+		if isLayoutUpgradeRequested(backup) {
+			return a.upgradeLayout(backup)
+		}
+		if backup.Spec.OCIExport != nil && backup.Status.OCIArtifact == "" &&
+			(backup.Status.Status == stork_api.ApplicationBackupStatusSuccessful ||
+				backup.Status.Status == stork_api.ApplicationBackupStatusPartialSuccess) {
+			err := a.exportBackup(backup)
+			if err != nil {
+				message := fmt.Sprintf("Error exporting backup to OCI artifact: %v", err)
+				log.ApplicationBackupLog(backup).Errorf(message)
+				a.recorder.Event(backup,
+					v1.EventTypeWarning,
+					string(stork_api.ApplicationBackupStatusFailed),
+					message)
+				return nil
+			}
+		}
 		return nil
 	default:
 		log.ApplicationBackupLog(backup).Errorf("Invalid stage for backup: %v", backup.Status.Stage)
@@ -401,6 +637,42 @@ func (a *ApplicationBackupController) handle(ctx context.Context, backup *stork_
 	return nil
 }
 
+// exportBackup pushes the objects of the backup to the registry configured in
+// the spec and records the reference of the artifact in the status
+func (a *ApplicationBackupController) exportBackup(backup *stork_api.ApplicationBackup) error {
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	var credentials *v1.Secret
+	if backup.Spec.OCIExport.CredentialsSecret != "" {
+		credentials, err = core.Instance().GetSecret(backup.Spec.OCIExport.CredentialsSecret, backup.Namespace)
+		if err != nil {
+			return err
+		}
+	}
+	artifact, err := ociexport.Export(backup, bucket, credentials)
+	if err != nil {
+		return err
+	}
+	backup.Status.OCIArtifact = artifact
+	backup.Status.LastUpdateTimestamp = metav1.Now()
+	if err := a.client.Update(context.TODO(), backup); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Exported backup to OCI artifact %v", artifact)
+	log.ApplicationBackupLog(backup).Infof(message)
+	a.recorder.Event(backup,
+		v1.EventTypeNormal,
+		string(backup.Status.Status),
+		message)
+	return nil
+}
+
 func (a *ApplicationBackupController) namespaceBackupAllowed(backup *stork_api.ApplicationBackup) bool {
 	// If the backup is completed it has probably been synced, don't perform
 	// check for those
@@ -434,6 +706,40 @@ func min(x, y int) int {
 	return y
 }
 
+// setVolumeDataSources records the data sources of the PVCs being backed up
+// so that restores can decide whether to restore the data or to populate the
+// volumes again. The PVCs are read as unstructured objects since the typed
+// PVCs don't have the dataSourceRef field.
+func (a *ApplicationBackupController) setVolumeDataSources(volumeInfos []*stork_api.ApplicationBackupVolumeInfo) {
+	for _, vInfo := range volumeInfos {
+		pvc := &unstructured.Unstructured{}
+		pvc.SetGroupVersionKind(v1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"))
+		err := a.client.Get(context.TODO(), types.NamespacedName{Name: vInfo.PersistentVolumeClaim, Namespace: vInfo.Namespace}, pvc)
+		if err != nil {
+			logrus.Warnf("Error getting data source of PVC %v/%v: %v", vInfo.Namespace, vInfo.PersistentVolumeClaim, err)
+			continue
+		}
+		if vInfo.DataSource, err = getPVCDataSource(pvc, "dataSource"); err != nil {
+			logrus.Warnf("Error getting data source of PVC %v/%v: %v", vInfo.Namespace, vInfo.PersistentVolumeClaim, err)
+		}
+		if vInfo.DataSourceRef, err = getPVCDataSource(pvc, "dataSourceRef"); err != nil {
+			logrus.Warnf("Error getting data source of PVC %v/%v: %v", vInfo.Namespace, vInfo.PersistentVolumeClaim, err)
+		}
+	}
+}
+
+func getPVCDataSource(pvc *unstructured.Unstructured, field string) (*v1.TypedLocalObjectReference, error) {
+	dataSource, found, err := unstructured.NestedMap(pvc.Object, "spec", field)
+	if err != nil || !found {
+		return nil, err
+	}
+	reference := &v1.TypedLocalObjectReference{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(dataSource, reference); err != nil {
+		return nil, err
+	}
+	return reference, nil
+}
+
 func (a *ApplicationBackupController) updateBackupCRInVolumeStage(
 	namespacedName types.NamespacedName,
 	status stork_api.ApplicationBackupStatusType,
@@ -600,10 +906,25 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 						batchCount = defaultBackupVolumeBatchCount
 					}
 				}
+				batchCount = storkconfig.GetBackupVolumeBatchCount(batchCount)
+				orderVolumes(pvcs, backup.Annotations[ApplicationBackupVolumeOrderAnnotation])
 				for i := 0; i < len(pvcs); i += batchCount {
 					batch := pvcs[i:min(i+batchCount, len(pvcs))]
+					// Wait for other operations using the PVCs to finish
+					batchPVCs := make([]types.NamespacedName, 0, len(batch))
+					for _, pvc := range batch {
+						batchPVCs = append(batchPVCs, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name})
+					}
+					if err := pvclock.AcquireAll(batchPVCs, getBackupVolumeLock(backup)); err != nil {
+						return err
+					}
 					volumeInfos, err := driver.StartBackup(backup, batch)
 					if err != nil {
+						// Volumes whose backups were started are locked
+						// again while their status is checked
+						if releaseErr := pvclock.ReleaseAll(batchPVCs, string(backup.UID)); releaseErr != nil {
+							log.ApplicationBackupLog(backup).Warnf("Error releasing locks on PVCs: %v", releaseErr)
+						}
 						// TODO: If starting backup for a drive fails mark the entire backup
 						// as Cancelling, cancel any other started backups and then mark
 						// it as failed
@@ -642,6 +963,7 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 						)
 						return err
 					}
+					a.setVolumeDataSources(volumeInfos)
 					backup, err = a.updateBackupCRInVolumeStage(
 						namespacedName,
 						stork_api.ApplicationBackupStatusInProgress,
@@ -688,6 +1010,10 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 		inProgress := false
 		// Skip checking status if no volumes are being backed up
 		if len(backup.Status.Volumes) != 0 {
+			// Volumes moved from a resumed backup are complete and aren't
+			// known to the drivers under this backup
+			resumedVolumes, volumes := splitResumedVolumes(backup.Status.Volumes)
+			backup.Status.Volumes = volumes
 			drivers := a.getDriversForBackup(backup)
 			volumeInfos := make([]*stork_api.ApplicationBackupVolumeInfo, 0)
 			for driverName := range drivers {
@@ -703,25 +1029,24 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 				}
 				volumeInfos = append(volumeInfos, status...)
 			}
-			backup.Status.Volumes = volumeInfos
+			backup.Status.Volumes = append(resumedVolumes, volumeInfos...)
 
-			// Now check if there is any failure or success
-			// TODO: On failure of one volume cancel other backups?
+			// Now check if there is any failure or success. Failed volumes
+			// only fail the backup once the other volumes are done, unless
+			// it is set to fail on partial backups.
+			var failed *stork_api.ApplicationBackupVolumeInfo
 			for _, vInfo := range volumeInfos {
 				if vInfo.Status == stork_api.ApplicationBackupStatusInProgress || vInfo.Status == stork_api.ApplicationBackupStatusInitial ||
 					vInfo.Status == stork_api.ApplicationBackupStatusPending {
 					log.ApplicationBackupLog(backup).Infof("Volume backup still in progress: %v", vInfo.Volume)
 					inProgress = true
 				} else if vInfo.Status == stork_api.ApplicationBackupStatusFailed {
-					a.recorder.Event(backup,
-						v1.EventTypeWarning,
-						string(vInfo.Status),
-						fmt.Sprintf("Error backing up volume %v: %v", vInfo.Volume, vInfo.Reason))
-					backup.Status.Stage = stork_api.ApplicationBackupStageFinal
-					backup.Status.FinishTimestamp = metav1.Now()
-					backup.Status.Status = stork_api.ApplicationBackupStatusFailed
-					backup.Status.Reason = vInfo.Reason
-					break
+					if failed == nil {
+						failed = vInfo
+					}
+				} else if vInfo.Status == stork_api.ApplicationBackupStatusCancelled {
+					log.ApplicationBackupLog(backup).Infof("Volume backup was cancelled: %v", vInfo.Volume)
+					return a.cancelBackup(context.TODO(), backup)
 				} else if vInfo.Status == stork_api.ApplicationBackupStatusSuccessful {
 					a.recorder.Event(backup,
 						v1.EventTypeNormal,
@@ -729,10 +1054,30 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 						fmt.Sprintf("Volume %v backed up successfully", vInfo.Volume))
 				}
 			}
+			if failed != nil && (backup.Spec.FailOnPartial || !inProgress) {
+				failedItems := backup.GetFailedItems()
+				for _, item := range failedItems {
+					a.recorder.Event(backup,
+						v1.EventTypeWarning,
+						string(stork_api.ApplicationBackupStatusFailed),
+						fmt.Sprintf("Error backing up volume %v/%v: %v", item.Namespace, item.Name, item.Reason))
+				}
+				// The backup is kept as partially successful as long as
+				// some of the volumes were backed up
+				if backup.Spec.FailOnPartial || len(failedItems) == len(backup.Status.Volumes) {
+					backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+					backup.Status.FinishTimestamp = metav1.Now()
+					backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+					backup.Status.Reason = failed.Reason
+					backup.Status.FailedItems = failedItems
+					inProgress = false
+				}
+			}
 		}
 
 		// Return if we have any volume backups still in progress
 		if inProgress {
+			extendVolumeLocks(backup)
 			// temporarily store the volume status, So that it will be used during retry.
 			volumeInfos := backup.Status.Volumes
 			backup.Status.LastUpdateTimestamp = metav1.Now()
@@ -766,6 +1111,9 @@ func (a *ApplicationBackupController) backupVolumes(backup *stork_api.Applicatio
 		}
 	}
 
+	// The volumes aren't used by the backup anymore
+	releaseVolumeLocks(backup)
+
 	// If the backup hasn't failed move on to the next stage.
 	if backup.Status.Status != stork_api.ApplicationBackupStatusFailed {
 		backup.Status.Stage = stork_api.ApplicationBackupStageApplications
@@ -856,7 +1204,7 @@ func (a *ApplicationBackupController) runPreExecRule(backup *stork_api.Applicati
 	}
 
 	terminationChannels := make([]chan bool, 0)
-	r, err := storkops.Instance().GetRule(backup.Spec.PreExecRule, backup.Namespace)
+	r, err := rule.GetRule(backup.Spec.PreExecRule, backup.Namespace)
 	if err != nil {
 		// TODO: For now keep this as is from the existing code, not sure the use of this for loop
 		// as it currently doesn't get executed
@@ -892,7 +1240,7 @@ func (a *ApplicationBackupController) runPreExecRule(backup *stork_api.Applicati
 }
 
 func (a *ApplicationBackupController) runPostExecRule(backup *stork_api.ApplicationBackup) error {
-	r, err := storkops.Instance().GetRule(backup.Spec.PostExecRule, backup.Namespace)
+	r, err := rule.GetRule(backup.Spec.PostExecRule, backup.Namespace)
 	if err != nil {
 		return err
 	}
@@ -926,7 +1274,7 @@ func (a *ApplicationBackupController) uploadObject(
 	objectName string,
 	data []byte,
 ) error {
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
 	if err != nil {
 		return err
 	}
@@ -973,19 +1321,201 @@ func (a *ApplicationBackupController) uploadResources(
 		gvk := obj.GetObjectKind().GroupVersionKind()
 		resKinds[gvk.Kind] = gvk.Version
 	}
-	if err := a.uploadNamespaces(backup); err != nil {
-		return err
+	if !isObjectUploaded(backup, nsObjectName) {
+		if err := a.uploadNamespaces(backup); err != nil {
+			return err
+		}
+		if err := a.markObjectUploaded(backup, nsObjectName); err != nil {
+			return err
+		}
+	}
+	if !isObjectUploaded(backup, storageClassObjectName) {
+		if err := a.uploadStorageClasses(backup); err != nil {
+			return err
+		}
+		if err := a.markObjectUploaded(backup, storageClassObjectName); err != nil {
+			return err
+		}
+	}
+	if !isObjectUploaded(backup, imagesObjectName) {
+		if err := a.uploadImages(backup, objects); err != nil {
+			return err
+		}
+		if err := a.markObjectUploaded(backup, imagesObjectName); err != nil {
+			return err
+		}
 	}
 	// upload CRD to backuplocation
-	if err := a.uploadCRDResources(backup, resKinds); err != nil {
+	if !isObjectUploaded(backup, crdObjectName) {
+		if err := a.uploadCRDResources(backup, resKinds); err != nil {
+			return err
+		}
+		if err := a.markObjectUploaded(backup, crdObjectName); err != nil {
+			return err
+		}
+	}
+	if isObjectUploaded(backup, resourceObjectName) {
+		return nil
+	}
+	if err := a.uploadResourceBlobs(backup, objects); err != nil {
 		return err
 	}
-	jsonBytes, err := json.MarshalIndent(objects, "", " ")
+	return a.markObjectUploaded(backup, resourceObjectName)
+}
+
+// Upload each of the resources as a content-addressed blob so that resources
+// that haven't changed since earlier backups aren't stored again. The hashes
+// of the blobs are recorded in the pending manifest of the backup before they
+// are uploaded.
+func (a *ApplicationBackupController) uploadResourceBlobs(
+	backup *stork_api.ApplicationBackup,
+	objects []runtime.Unstructured,
+) error {
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
 	if err != nil {
 		return err
 	}
-	// TODO: Encrypt if requested
-	return a.uploadObject(backup, resourceObjectName, jsonBytes)
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	items := make([]json.RawMessage, 0, len(objects))
+	for _, object := range objects {
+		jsonBytes, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		items = append(items, jsonBytes)
+	}
+	manifest := backuplayout.NewManifest()
+	manifest.Blobs[resourceObjectName] = backuplayout.HashArray(items, backupLocation.Location.EncryptionKey)
+	if err := backuplayout.WritePending(context.TODO(), bucket, GetObjectPath(backup), manifest); err != nil {
+		return err
+	}
+	_, err = backuplayout.WriteArray(context.TODO(), bucket, items, backupLocation.Location.EncryptionKey)
+	return err
+}
+
+func isObjectUploaded(backup *stork_api.ApplicationBackup, objectName string) bool {
+	for _, uploaded := range backup.Status.UploadedObjects {
+		if uploaded == objectName {
+			return true
+		}
+	}
+	return false
+}
+
+// Record in the backup status that the object has been uploaded so that it
+// isn't uploaded again if the controller restarts
+func (a *ApplicationBackupController) markObjectUploaded(
+	backup *stork_api.ApplicationBackup,
+	objectName string,
+) error {
+	backup.Status.UploadedObjects = append(backup.Status.UploadedObjects, objectName)
+	backup.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), backup)
+}
+
+// Upload the resources collected for a batch of namespaces and return the
+// checkpoint to be recorded in the backup status
+func (a *ApplicationBackupController) uploadResourceCheckpoint(
+	backup *stork_api.ApplicationBackup,
+	namespaces []string,
+	objects []runtime.Unstructured,
+) (*stork_api.ApplicationBackupResourceCheckpoint, error) {
+	jsonBytes, err := json.MarshalIndent(objects, "", " ")
+	if err != nil {
+		return nil, err
+	}
+	objectName := fmt.Sprintf("%v-%v.json", resourceCheckpointPrefix, len(backup.Status.ResourceCheckpoints))
+	if err := a.uploadObject(backup, objectName, jsonBytes); err != nil {
+		return nil, err
+	}
+	return &stork_api.ApplicationBackupResourceCheckpoint{
+		Namespaces: append([]string{}, namespaces...),
+		ObjectName: objectName,
+	}, nil
+}
+
+// Load the resources from the checkpoints recorded in the backup status. If
+// any of the checkpoints can't be read, the checkpoints are discarded and all
+// the resources will be collected again.
+func (a *ApplicationBackupController) loadResourceCheckpoints(
+	backup *stork_api.ApplicationBackup,
+) ([]runtime.Unstructured, map[string]bool) {
+	allObjects := make([]runtime.Unstructured, 0)
+	collectedNamespaces := make(map[string]bool)
+	for _, checkpoint := range backup.Status.ResourceCheckpoints {
+		objects, err := a.downloadResourceCheckpoint(backup, checkpoint.ObjectName)
+		if err != nil {
+			log.ApplicationBackupLog(backup).Warnf("Error loading resource checkpoint %v, collecting all resources again: %v", checkpoint.ObjectName, err)
+			backup.Status.ResourceCheckpoints = nil
+			return make([]runtime.Unstructured, 0), make(map[string]bool)
+		}
+		allObjects = append(allObjects, objects...)
+		for _, ns := range checkpoint.Namespaces {
+			collectedNamespaces[ns] = true
+		}
+	}
+	return allObjects, collectedNamespaces
+}
+
+func (a *ApplicationBackupController) downloadResourceCheckpoint(
+	backup *stork_api.ApplicationBackup,
+	objectName string,
+) ([]runtime.Unstructured, error) {
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	data, err := bucket.ReadAll(context.TODO(), filepath.Join(GetObjectPath(backup), objectName))
+	if err != nil {
+		return nil, err
+	}
+	if backupLocation.Location.EncryptionKey != "" {
+		if data, err = crypto.Decrypt(data, backupLocation.Location.EncryptionKey); err != nil {
+			return nil, err
+		}
+	}
+	objects := make([]*unstructured.Unstructured, 0)
+	if err = json.Unmarshal(data, &objects); err != nil {
+		return nil, err
+	}
+	runtimeObjects := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		runtimeObjects = append(runtimeObjects, o)
+	}
+	return runtimeObjects, nil
+}
+
+// Delete the resource checkpoints from the backup location. Errors are only
+// logged since the checkpoints aren't used once the backup is complete.
+func (a *ApplicationBackupController) deleteResourceCheckpoints(
+	backup *stork_api.ApplicationBackup,
+) {
+	if len(backup.Status.ResourceCheckpoints) == 0 {
+		return
+	}
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
+	if err != nil {
+		log.ApplicationBackupLog(backup).Warnf("Error deleting resource checkpoints: %v", err)
+		return
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		log.ApplicationBackupLog(backup).Warnf("Error deleting resource checkpoints: %v", err)
+		return
+	}
+	objectPath := GetObjectPath(backup)
+	for _, checkpoint := range backup.Status.ResourceCheckpoints {
+		if err := bucket.Delete(context.TODO(), filepath.Join(objectPath, checkpoint.ObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			log.ApplicationBackupLog(backup).Warnf("Error deleting resource checkpoint %v: %v", checkpoint.ObjectName, err)
+		}
+	}
 }
 func (a *ApplicationBackupController) uploadNamespaces(backup *stork_api.ApplicationBackup) error {
 	var namespaces []*v1.Namespace
@@ -1007,6 +1537,64 @@ func (a *ApplicationBackupController) uploadNamespaces(backup *stork_api.Applica
 	return nil
 }
 
+// Upload the storage classes of the backed up volumes so that restores can
+// create the ones that are missing on the destination
+func (a *ApplicationBackupController) uploadStorageClasses(backup *stork_api.ApplicationBackup) error {
+	storageClasses := make([]*storagev1.StorageClass, 0)
+	uploaded := make(map[string]bool)
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.StorageClass == "" || uploaded[vInfo.StorageClass] {
+			continue
+		}
+		uploaded[vInfo.StorageClass] = true
+		sc, err := storage.Instance().GetStorageClass(vInfo.StorageClass)
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		sc.ObjectMeta = metav1.ObjectMeta{
+			Name:        sc.Name,
+			Labels:      sc.Labels,
+			Annotations: sc.Annotations,
+		}
+		storageClasses = append(storageClasses, sc)
+	}
+	jsonBytes, err := json.MarshalIndent(storageClasses, "", " ")
+	if err != nil {
+		return err
+	}
+	return a.uploadObject(backup, storageClassObjectName, jsonBytes)
+}
+
+// Upload the images of the backed up workloads so that restores can check
+// them without downloading all the resources
+func (a *ApplicationBackupController) uploadImages(
+	backup *stork_api.ApplicationBackup,
+	objects []runtime.Unstructured,
+) error {
+	registrations, err := storkops.Instance().ListApplicationRegistrations()
+	if err != nil {
+		return err
+	}
+	images := make([]objectImages, 0)
+	for _, o := range objects {
+		objectImages, err := getObjectImages(o, registrations.Items)
+		if err != nil {
+			return err
+		}
+		if objectImages != nil {
+			images = append(images, *objectImages)
+		}
+	}
+	jsonBytes, err := json.MarshalIndent(images, "", " ")
+	if err != nil {
+		return err
+	}
+	return a.uploadObject(backup, imagesObjectName, jsonBytes)
+}
+
 func (a *ApplicationBackupController) uploadCRDResources(backup *stork_api.ApplicationBackup, resKinds map[string]string) error {
 	crdList, err := storkops.Instance().ListApplicationRegistrations()
 	if err != nil {
@@ -1057,6 +1645,32 @@ func (a *ApplicationBackupController) uploadMetadata(
 	return a.uploadObject(backup, metadataObjectName, jsonBytes)
 }
 
+// Upload the manifest describing the layout of the objects of the backup. The
+// blobs of the resources were already recorded in the pending manifest when
+// they were uploaded. The manifest is only written once so that backups can be
+// stored in buckets that don't allow objects to be modified.
+func (a *ApplicationBackupController) uploadManifest(
+	backup *stork_api.ApplicationBackup,
+) error {
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
+	if err != nil {
+		return err
+	}
+	manifest, err := backuplayout.ReadPending(context.TODO(), bucket, GetObjectPath(backup))
+	if err != nil {
+		return err
+	}
+	if manifest == nil || manifest.SchemaVersion != backuplayout.CurrentVersion {
+		return fmt.Errorf("resources of backup weren't uploaded")
+	}
+	manifest.AddObjects(crdObjectName, nsObjectName, storageClassObjectName, imagesObjectName, metadataObjectName)
+	return backuplayout.Write(context.TODO(), bucket, GetObjectPath(backup), manifest)
+}
+
 func (a *ApplicationBackupController) backupResources(
 	backup *stork_api.ApplicationBackup,
 ) error {
@@ -1083,15 +1697,27 @@ func (a *ApplicationBackupController) backupResources(
 	// Always backup optional resources. When restorting they need to be
 	// explicitly added to the spec
 	objectMap := stork_api.CreateObjectsMap(backup.Spec.IncludeResources)
-	namespacelist := backup.Spec.Namespaces
+	optionalResourceTypes := optionalBackupResources
+	if backup.Spec.IncludeVolumeSnapshots {
+		optionalResourceTypes = append([]string{resourcecollector.VolumeSnapshotResourceType}, optionalBackupResources...)
+	}
+	// Load the resources for the namespaces that were already collected
+	// before the controller was restarted
+	allObjects, collectedNamespaces := a.loadResourceCheckpoints(backup)
+	namespacelist := make([]string, 0)
+	for _, ns := range backup.Spec.Namespaces {
+		if !collectedNamespaces[ns] {
+			namespacelist = append(namespacelist, ns)
+		}
+	}
 	// GetResources takes more time, if we have more number of namespaces
 	// So, submitting it in batches and in between each batch,
 	// updating the LastUpdateTimestamp to show that backup is progressing
-	allObjects := make([]runtime.Unstructured, 0)
 	for i := 0; i < len(namespacelist); i += backupResourcesBatchCount {
 		batch := namespacelist[i:min(i+backupResourcesBatchCount, len(namespacelist))]
 		var incResNsBatch []string
 		var resourceTypeNsBatch []string
+		batchObjects := make([]runtime.Unstructured, 0)
 		for _, ns := range batch {
 			// As we support both includeResource and ResourceType to be mentioned
 			// match out ns for which we want to take includeResource path and
@@ -1111,13 +1737,14 @@ func (a *ApplicationBackupController) backupResources(
 				incResNsBatch,
 				backup.Spec.Selectors,
 				objectMap,
-				optionalBackupResources,
-				true)
+				optionalResourceTypes,
+				true,
+				backup.Spec.SecretTypes)
 			if err != nil {
 				log.ApplicationBackupLog(backup).Errorf("Error getting resources: %v", err)
 				return err
 			}
-			allObjects = append(allObjects, objects...)
+			batchObjects = append(batchObjects, objects...)
 		}
 
 		if len(resourceTypeNsBatch) != 0 {
@@ -1125,16 +1752,24 @@ func (a *ApplicationBackupController) backupResources(
 				for _, resource := range resourceTypes {
 					if resource.Kind == backupResourceType || (backupResourceType == "PersistentVolumeClaim" && resource.Kind == "PersistentVolume") {
 						log.ApplicationBackupLog(backup).Tracef("GetResourcesType for : %v", resource.Kind)
-						objects, err := a.resourceCollector.GetResourcesForType(resource, nil, resourceTypeNsBatch, backup.Spec.Selectors, nil, true)
+						objects, err := a.resourceCollector.GetResourcesForType(resource, nil, resourceTypeNsBatch, backup.Spec.Selectors, nil, true, backup.Spec.SecretTypes)
 						if err != nil {
 							log.ApplicationBackupLog(backup).Errorf("Error getting resources: %v", err)
 							return err
 						}
-						allObjects = append(allObjects, objects.Items...)
+						batchObjects = append(batchObjects, objects.Items...)
 					}
 				}
 			}
 		}
+		allObjects = append(allObjects, batchObjects...)
+
+		// Upload the resources collected for the batch so that they don't
+		// need to be collected again if the controller restarts
+		checkpoint, err := a.uploadResourceCheckpoint(backup, batch, batchObjects)
+		if err != nil {
+			log.ApplicationBackupLog(backup).Warnf("Error checkpointing resources for namespaces %v: %v", batch, err)
+		}
 
 		// Do a dummy update to the backup CR to update only the last update timestamp
 		namespacedName := types.NamespacedName{}
@@ -1149,6 +1784,9 @@ func (a *ApplicationBackupController) backupResources(
 			if backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
 				return nil
 			}
+			if checkpoint != nil {
+				backup.Status.ResourceCheckpoints = append(backup.Status.ResourceCheckpoints, checkpoint)
+			}
 			backup.Status.LastUpdateTimestamp = metav1.Now()
 			err = a.client.Update(context.TODO(), backup)
 			if err != nil {
@@ -1231,17 +1869,29 @@ func (a *ApplicationBackupController) backupResources(
 		log.ApplicationBackupLog(backup).Errorf(message)
 		return err
 	}
+	// The checkpoints aren't required once all the resources have been
+	// uploaded
+	a.deleteResourceCheckpoints(backup)
+	backup.Status.ResourceCheckpoints = nil
+	backup.Status.UploadedObjects = nil
 	backup.Status.BackupPath = GetObjectPath(backup)
 	backup.Status.Stage = stork_api.ApplicationBackupStageFinal
 	backup.Status.FinishTimestamp = metav1.Now()
 	backup.Status.Status = stork_api.ApplicationBackupStatusSuccessful
 	backup.Status.Reason = "Volumes and resources were backed up successfully"
+	backup.Status.FailedItems = backup.GetFailedItems()
+	if len(backup.Status.FailedItems) != 0 {
+		backup.Status.Status = stork_api.ApplicationBackupStatusPartialSuccess
+		backup.Status.Reason = fmt.Sprintf("Resources were backed up successfully. %v of %v volumes failed to back up",
+			len(backup.Status.FailedItems), len(backup.Status.Volumes))
+	}
 
 	// Only on success compute the total backup size
 	for _, vInfo := range backup.Status.Volumes {
 		backup.Status.TotalSize += vInfo.TotalSize
 	}
 	// Upload the metadata for the backup to the backup location
+	setLayoutVersion(backup, backuplayout.CurrentVersion)
 	if err = a.uploadMetadata(backup); err != nil {
 		a.recorder.Event(backup,
 			v1.EventTypeWarning,
@@ -1250,6 +1900,16 @@ func (a *ApplicationBackupController) backupResources(
 		log.ApplicationBackupLog(backup).Errorf("Error uploading metadata: %v", err)
 		return err
 	}
+	// The objects are added to the manifest last so that only complete
+	// backups list them
+	if err = a.uploadManifest(backup); err != nil {
+		a.recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			fmt.Sprintf("Error uploading manifest: %v", err))
+		log.ApplicationBackupLog(backup).Errorf("Error uploading manifest: %v", err)
+		return err
+	}
 
 	backup.Status.LastUpdateTimestamp = metav1.Now()
 
@@ -1295,7 +1955,10 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 			return false, nil
 		}
 	}
-	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.Namespace)
+	// Cleanup the checkpoints left behind by backups that didn't complete
+	a.deleteResourceCheckpoints(backup)
+
+	backupLocation, err := storkops.Instance().GetBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace())
 	if err != nil {
 		// Can't do anything if the backup location is deleted
 		if k8s_errors.IsNotFound(err) {
@@ -1310,51 +1973,317 @@ func (a *ApplicationBackupController) deleteBackup(backup *stork_api.Application
 
 	objectPath := backup.Status.BackupPath
 	if objectPath != "" {
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, resourceObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
-			return true, fmt.Errorf("error deleting resources for backup %v/%v: %v", backup.Namespace, backup.Name, err)
+		for _, object := range []struct {
+			name        string
+			description string
+		}{
+			{resourceObjectName, "resources"},
+			{metadataObjectName, "metadata"},
+			{crdObjectName, "crds"},
+			{nsObjectName, "namespaces"},
+			{storageClassObjectName, "storage classes"},
+			{imagesObjectName, "images"},
+			{backuplayout.ManifestObjectName, "manifest"},
+			{backuplayout.PendingManifestObjectName, "pending manifest"},
+		} {
+			err = bucket.Delete(context.TODO(), filepath.Join(objectPath, object.name))
+			if err == nil || gcerrors.Code(err) == gcerrors.NotFound {
+				continue
+			}
+			// Keep the backup around while it is protected by the bucket so
+			// that the deletion is retried once the protection is lifted
+			if policyErr := objectstore.GetPolicyError(backupLocation, bucket, err); policyErr != nil {
+				a.recorder.Event(backup,
+					v1.EventTypeWarning,
+					string(stork_api.ApplicationBackupStatusFailed),
+					fmt.Sprintf("Error deleting backup: %v", policyErr))
+				return false, policyErr
+			}
+			return true, fmt.Errorf("error deleting %v for backup %v/%v: %v", object.description, backup.Namespace, backup.Name, err)
 		}
+	}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, metadataObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
-			return true, fmt.Errorf("error deleting metadata for backup %v/%v: %v", backup.Namespace, backup.Name, err)
-		}
+	return true, nil
+}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, crdObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
-			return true, fmt.Errorf("error deleting crds for backup %v/%v: %v", backup.Namespace, backup.Name, err)
+// orderVolumes sorts the PVCs in the order given by the schedule, which
+// starts the volumes with the most data to back up first. PVCs that aren't
+// in the order, like new ones, are started last.
+func orderVolumes(pvcs []v1.PersistentVolumeClaim, order string) {
+	if order == "" {
+		return
+	}
+	positions := make(map[string]int)
+	for i, key := range strings.Split(order, ",") {
+		positions[key] = i
+	}
+	position := func(pvc *v1.PersistentVolumeClaim) int {
+		if i, ok := positions[pvc.Namespace+"/"+pvc.Name]; ok {
+			return i
 		}
+		return len(positions)
+	}
+	sort.SliceStable(pvcs, func(i, j int) bool {
+		return position(&pvcs[i]) < position(&pvcs[j])
+	})
+}
 
-		if err = bucket.Delete(context.TODO(), filepath.Join(objectPath, nsObjectName)); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
-			return true, fmt.Errorf("error deleting namespaces for backup %v/%v: %v", backup.Namespace, backup.Name, err)
-		}
+// isLayoutUpgradeRequested returns true if the upgrade annotation has been
+// set on a backup that completed
+func isLayoutUpgradeRequested(backup *stork_api.ApplicationBackup) bool {
+	if backup.Status.Status != stork_api.ApplicationBackupStatusSuccessful &&
+		backup.Status.Status != stork_api.ApplicationBackupStatusPartialSuccess {
+		return false
 	}
+	upgrade, _ := strconv.ParseBool(backup.Annotations[stork_api.ApplicationBackupUpgradeLayoutAnnotation])
+	return upgrade
+}
 
-	return true, nil
+func setLayoutVersion(backup *stork_api.ApplicationBackup, version int) {
+	if backup.Annotations == nil {
+		backup.Annotations = make(map[string]string)
+	}
+	backup.Annotations[stork_api.ApplicationBackupLayoutVersionAnnotation] = strconv.Itoa(version)
 }
 
-func (a *ApplicationBackupController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.ApplicationBackupResourceName,
-		Plural:  stork_api.ApplicationBackupResourcePlural,
-		Group:   stork.GroupName,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.ApplicationBackup{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
+// upgradeLayout migrates the objects of the backup in the backup location to
+// the current layout. The upgrade annotation is removed once the backup has
+// been migrated. Failures are retried on the next reconcile.
+func (a *ApplicationBackupController) upgradeLayout(backup *stork_api.ApplicationBackup) error {
+	backupLocation, err := getBackupLocation(backup.Spec.BackupLocation, backup.GetBackupLocationNamespace(), backup.Namespace)
+	if err != nil {
+		return err
+	}
+	bucket, err := objectstore.GetBucket(backupLocation)
 	if err != nil {
 		return err
 	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !k8s_errors.IsAlreadyExists(err) {
+	fromVersion, err := backuplayout.Migrate(context.TODO(), bucket, backup.Status.BackupPath, backupLocation.Location.EncryptionKey)
+	if err != nil {
+		message := fmt.Sprintf("Error upgrading backup layout: %v", err)
+		log.ApplicationBackupLog(backup).Errorf(message)
+		a.recorder.Event(backup,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			message)
+		return nil
+	}
+	if fromVersion != backuplayout.CurrentVersion {
+		message := fmt.Sprintf("Upgraded backup layout from version %v to %v", fromVersion, backuplayout.CurrentVersion)
+		log.ApplicationBackupLog(backup).Infof(message)
+		a.recorder.Event(backup,
+			v1.EventTypeNormal,
+			string(backup.Status.Status),
+			message)
+	}
+	delete(backup.Annotations, stork_api.ApplicationBackupUpgradeLayoutAnnotation)
+	setLayoutVersion(backup, backuplayout.CurrentVersion)
+	return a.client.Update(context.TODO(), backup)
+}
+
+// getResumeFrom returns the backup that the backup resumes from. It needs to
+// be a backup in the same namespace to the same backup location that failed.
+func (a *ApplicationBackupController) getResumeFrom(backup *stork_api.ApplicationBackup) (*stork_api.ApplicationBackup, error) {
+	if backup.Spec.ResumeFrom == backup.Name {
+		return nil, fmt.Errorf("backup can't resume from itself")
+	}
+	previous := &stork_api.ApplicationBackup{}
+	err := a.client.Get(context.TODO(), types.NamespacedName{Name: backup.Spec.ResumeFrom, Namespace: backup.Namespace}, previous)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status.Stage != stork_api.ApplicationBackupStageFinal ||
+		(previous.Status.Status != stork_api.ApplicationBackupStatusFailed &&
+			previous.Status.Status != stork_api.ApplicationBackupStatusPartialSuccess) {
+		return nil, fmt.Errorf("only failed or partially successful backups can be resumed, backup is %v in stage %v",
+			previous.Status.Status, previous.Status.Stage)
+	}
+	if previous.Spec.BackupLocation != backup.Spec.BackupLocation ||
+		previous.GetBackupLocationNamespace() != backup.GetBackupLocationNamespace() {
+		return nil, fmt.Errorf("backup was taken to backup location %v/%v",
+			previous.GetBackupLocationNamespace(), previous.Spec.BackupLocation)
+	}
+	if resumedBy := previous.Annotations[stork_api.ApplicationBackupResumedByAnnotation]; resumedBy != "" && resumedBy != backup.Name {
+		return nil, fmt.Errorf("backup has already been resumed by backup %v", resumedBy)
+	}
+	return previous, nil
+}
+
+// resumeBackup moves the volumes that were backed up successfully by the
+// previous backup to this backup, so that only the other volumes are backed
+// up again. Volumes whose backups are tied to the previous backup aren't
+// moved, they are backed up again too. The previous backup is claimed first so that no other backup can
+// resume it, and the volumes are only removed from it once they have been
+// recorded in this backup. Each step is retried until all of them are done.
+func (a *ApplicationBackupController) resumeBackup(backup *stork_api.ApplicationBackup, previous *stork_api.ApplicationBackup) error {
+	if previous.Annotations[stork_api.ApplicationBackupResumedByAnnotation] == "" {
+		if previous.Annotations == nil {
+			previous.Annotations = make(map[string]string)
+		}
+		previous.Annotations[stork_api.ApplicationBackupResumedByAnnotation] = backup.Name
+		if err := a.client.Update(context.TODO(), previous); err != nil {
+			return err
+		}
+	}
+
+	resumedVolumes, _ := splitResumedVolumes(backup.Status.Volumes)
+	if len(resumedVolumes) == 0 {
+		for _, vInfo := range previous.Status.Volumes {
+			if vInfo.Status != stork_api.ApplicationBackupStatusSuccessful || !isResumable(vInfo) {
+				continue
+			}
+			resumed := vInfo.DeepCopy()
+			if resumed.ResumedFrom == "" {
+				resumed.ResumedFrom = previous.Name
+			}
+			resumedVolumes = append(resumedVolumes, resumed)
+		}
+		if len(resumedVolumes) == 0 {
+			return nil
+		}
+		backup.Status.Volumes = append(resumedVolumes, backup.Status.Volumes...)
+		backup.Status.LastUpdateTimestamp = metav1.Now()
+		if err := a.client.Update(context.TODO(), backup); err != nil {
 			return err
 		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
+		message := fmt.Sprintf("Resumed %v volumes backed up by backup %v", len(resumedVolumes), previous.Name)
+		log.ApplicationBackupLog(backup).Infof(message)
+		a.recorder.Event(backup,
+			v1.EventTypeNormal,
+			string(stork_api.ApplicationBackupStatusInProgress),
+			message)
+	}
+
+	moved := make(map[string]bool)
+	for _, vInfo := range resumedVolumes {
+		moved[vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim+"/"+vInfo.BackupID] = true
+	}
+	volumes := make([]*stork_api.ApplicationBackupVolumeInfo, 0, len(previous.Status.Volumes))
+	for _, vInfo := range previous.Status.Volumes {
+		if !moved[vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim+"/"+vInfo.BackupID] {
+			volumes = append(volumes, vInfo)
+		}
+	}
+	if len(volumes) == len(previous.Status.Volumes) {
+		return nil
+	}
+	previous.Status.Volumes = volumes
+	previous.Status.LastUpdateTimestamp = metav1.Now()
+	return a.client.Update(context.TODO(), previous)
+}
+
+// isResumable returns true if the backup of the volume can be moved to
+// another backup. CSI stores the VolumeSnapshots of a volume in the objects
+// of the backup that took it and kdmp names its DataExports and repository
+// secrets after that backup, so their volumes would be lost or left behind
+// when the previous backup is deleted.
+func isResumable(vInfo *stork_api.ApplicationBackupVolumeInfo) bool {
+	return vInfo.DriverName != volume.CSIDriverName && vInfo.DriverName != volume.KDMPDriverName
+}
+
+// splitResumedVolumes returns the volumes that were moved from a resumed
+// backup separately from the volumes backed up by the backup itself
+func splitResumedVolumes(
+	volumeInfos []*stork_api.ApplicationBackupVolumeInfo,
+) ([]*stork_api.ApplicationBackupVolumeInfo, []*stork_api.ApplicationBackupVolumeInfo) {
+	resumed := make([]*stork_api.ApplicationBackupVolumeInfo, 0)
+	volumes := make([]*stork_api.ApplicationBackupVolumeInfo, 0, len(volumeInfos))
+	for _, vInfo := range volumeInfos {
+		if vInfo.ResumedFrom != "" {
+			resumed = append(resumed, vInfo)
+		} else {
+			volumes = append(volumes, vInfo)
+		}
+	}
+	return resumed, volumes
+}
+
+// isBackupCancelRequested returns true if the backup is still in progress and
+// the cancel annotation has been set on it
+func isBackupCancelRequested(backup *stork_api.ApplicationBackup) bool {
+	if backup.Status.Stage == stork_api.ApplicationBackupStageFinal {
+		return false
+	}
+	cancel, _ := strconv.ParseBool(backup.Annotations[stork_api.ApplicationBackupCancelAnnotation])
+	return cancel
+}
+
+// cancelBackup stops the volume backups that are in progress, cleans up the
+// resources created for them and deletes whatever was already uploaded to the
+// backup location. The backup is then marked as cancelled.
+func (a *ApplicationBackupController) cancelBackup(ctx context.Context, backup *stork_api.ApplicationBackup) error {
+	releaseVolumeLocks(backup)
+	// The backup is only marked as cancelled once the partial backup has been
+	// deleted, otherwise the cancel is retried
+	canDelete, err := a.deleteBackup(backup)
+	if err != nil {
+		log.ApplicationBackupLog(backup).Errorf("Error deleting partial backup: %v", err)
+		return err
+	}
+	if !canDelete {
+		// Check again once the driver is done deleting the volume backups
+		return nil
 	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !k8s_errors.IsAlreadyExists(err) {
+	if err := a.cleanupResources(backup); err != nil {
 		return err
 	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+
+	message := "Backup was cancelled"
+	backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+	backup.Status.Status = stork_api.ApplicationBackupStatusCancelled
+	backup.Status.Reason = message
+	backup.Status.FinishTimestamp = metav1.Now()
+	backup.Status.LastUpdateTimestamp = metav1.Now()
+	// The volumes that were already backed up have been deleted too
+	for _, vInfo := range backup.Status.Volumes {
+		vInfo.Status = stork_api.ApplicationBackupStatusCancelled
+		vInfo.Reason = message
+	}
+	log.ApplicationBackupLog(backup).Infof(message)
+	a.recorder.Event(backup,
+		v1.EventTypeNormal,
+		string(stork_api.ApplicationBackupStatusCancelled),
+		message)
+	return a.client.Update(ctx, backup)
+}
+
+// getBackupVolumeLock returns the lock held on the PVCs while they are backed
+// up
+func getBackupVolumeLock(backup *stork_api.ApplicationBackup) pvclock.Lock {
+	return pvclock.NewLock("ApplicationBackup", backup, pvclock.OperationBackup)
+}
+
+// extendVolumeLocks extends the locks on the PVCs whose backups are still in
+// progress so that they don't expire
+func extendVolumeLocks(backup *stork_api.ApplicationBackup) {
+	lock := getBackupVolumeLock(backup)
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.ResumedFrom != "" ||
+			(vInfo.Status != stork_api.ApplicationBackupStatusInProgress &&
+				vInfo.Status != stork_api.ApplicationBackupStatusInitial &&
+				vInfo.Status != stork_api.ApplicationBackupStatusPending) {
+			continue
+		}
+		if err := pvclock.Acquire(vInfo.PersistentVolumeClaim, vInfo.Namespace, lock); err != nil && !k8s_errors.IsNotFound(err) {
+			log.ApplicationBackupLog(backup).Warnf("Error extending lock on PVC %v/%v: %v",
+				vInfo.Namespace, vInfo.PersistentVolumeClaim, err)
+		}
+	}
+}
+
+// releaseVolumeLocks releases the locks held by the backup on its PVCs
+func releaseVolumeLocks(backup *stork_api.ApplicationBackup) {
+	pvcs := make([]types.NamespacedName, 0, len(backup.Status.Volumes))
+	for _, vInfo := range backup.Status.Volumes {
+		pvcs = append(pvcs, types.NamespacedName{Namespace: vInfo.Namespace, Name: vInfo.PersistentVolumeClaim})
+	}
+	if err := pvclock.ReleaseAll(pvcs, string(backup.UID)); err != nil {
+		log.ApplicationBackupLog(backup).Warnf("Error releasing locks on PVCs: %v", err)
+	}
+}
+
+func (a *ApplicationBackupController) createCRD() error {
+	return crds.Register(reflect.TypeOf(stork_api.ApplicationBackup{}).Name())
 }
 
 // IsVolsToBeBackedUp for a given backupspec do we need to have volumes backed up
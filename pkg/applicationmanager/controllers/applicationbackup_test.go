@@ -0,0 +1,138 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newResumeTestBackup(name string, status stork_api.ApplicationBackupStatusType) *stork_api.ApplicationBackup {
+	return &stork_api.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Spec:       stork_api.ApplicationBackupSpec{BackupLocation: "location"},
+		Status: stork_api.ApplicationBackupStatus{
+			Stage:  stork_api.ApplicationBackupStageFinal,
+			Status: status,
+		},
+	}
+}
+
+func newResumeTestVolume(pvc string, driver string, status stork_api.ApplicationBackupStatusType) *stork_api.ApplicationBackupVolumeInfo {
+	return &stork_api.ApplicationBackupVolumeInfo{
+		PersistentVolumeClaim: pvc,
+		Namespace:             "test",
+		DriverName:            driver,
+		BackupID:              pvc + "-backup",
+		Status:                status,
+	}
+}
+
+func newResumeTestController(t *testing.T, objects ...runtime.Object) *ApplicationBackupController {
+	scheme := runtime.NewScheme()
+	require.NoError(t, stork_api.AddToScheme(scheme))
+	return &ApplicationBackupController{
+		client:   fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func getResumeTestBackup(t *testing.T, a *ApplicationBackupController, name string) *stork_api.ApplicationBackup {
+	backup := &stork_api.ApplicationBackup{}
+	require.NoError(t, a.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "test"}, backup))
+	return backup
+}
+
+func TestGetResumeFrom(t *testing.T) {
+	failed := newResumeTestBackup("failed", stork_api.ApplicationBackupStatusFailed)
+	successful := newResumeTestBackup("successful", stork_api.ApplicationBackupStatusSuccessful)
+	claimed := newResumeTestBackup("claimed", stork_api.ApplicationBackupStatusFailed)
+	claimed.Annotations = map[string]string{stork_api.ApplicationBackupResumedByAnnotation: "other"}
+	otherLocation := newResumeTestBackup("elsewhere", stork_api.ApplicationBackupStatusFailed)
+	otherLocation.Spec.BackupLocation = "other"
+	a := newResumeTestController(t, failed, successful, claimed, otherLocation)
+
+	backup := newResumeTestBackup("backup", stork_api.ApplicationBackupStatusInProgress)
+	backup.Spec.ResumeFrom = "failed"
+	previous, err := a.getResumeFrom(backup)
+	require.NoError(t, err)
+	require.Equal(t, "failed", previous.Name)
+
+	for _, name := range []string{"backup", "successful", "claimed", "elsewhere", "missing"} {
+		backup.Spec.ResumeFrom = name
+		_, err := a.getResumeFrom(backup)
+		require.Error(t, err, "backup %v shouldn't be resumed", name)
+	}
+}
+
+func TestResumeBackup(t *testing.T) {
+	previous := newResumeTestBackup("failed", stork_api.ApplicationBackupStatusFailed)
+	previous.Status.Volumes = []*stork_api.ApplicationBackupVolumeInfo{
+		newResumeTestVolume("done", volume.PortworxDriverName, stork_api.ApplicationBackupStatusSuccessful),
+		newResumeTestVolume("failed", volume.PortworxDriverName, stork_api.ApplicationBackupStatusFailed),
+		newResumeTestVolume("csi", volume.CSIDriverName, stork_api.ApplicationBackupStatusSuccessful),
+		newResumeTestVolume("kdmp", volume.KDMPDriverName, stork_api.ApplicationBackupStatusSuccessful),
+	}
+	backup := newResumeTestBackup("backup", stork_api.ApplicationBackupStatusInProgress)
+	backup.Spec.ResumeFrom = "failed"
+	a := newResumeTestController(t, previous, backup)
+
+	require.NoError(t, a.resumeBackup(backup, previous))
+	require.Len(t, backup.Status.Volumes, 1, "only volumes that don't depend on the previous backup should be moved")
+	require.Equal(t, "done", backup.Status.Volumes[0].PersistentVolumeClaim)
+	require.Equal(t, "failed", backup.Status.Volumes[0].ResumedFrom)
+
+	updated := getResumeTestBackup(t, a, "failed")
+	require.Equal(t, "backup", updated.Annotations[stork_api.ApplicationBackupResumedByAnnotation])
+	pvcs := make([]string, 0)
+	for _, vInfo := range updated.Status.Volumes {
+		pvcs = append(pvcs, vInfo.PersistentVolumeClaim)
+	}
+	require.Equal(t, []string{"failed", "csi", "kdmp"}, pvcs, "moved volume shouldn't be deleted with the previous backup")
+	require.Len(t, getResumeTestBackup(t, a, "backup").Status.Volumes, 1)
+
+	// Resuming again doesn't move the volumes twice
+	require.NoError(t, a.resumeBackup(backup, updated))
+	require.Len(t, backup.Status.Volumes, 1)
+}
+
+func TestSplitResumedVolumes(t *testing.T) {
+	resumed := newResumeTestVolume("resumed", volume.PortworxDriverName, stork_api.ApplicationBackupStatusSuccessful)
+	resumed.ResumedFrom = "failed"
+	own := newResumeTestVolume("own", volume.PortworxDriverName, stork_api.ApplicationBackupStatusInProgress)
+	resumedVolumes, volumes := splitResumedVolumes([]*stork_api.ApplicationBackupVolumeInfo{own, resumed})
+	require.Equal(t, []*stork_api.ApplicationBackupVolumeInfo{resumed}, resumedVolumes)
+	require.Equal(t, []*stork_api.ApplicationBackupVolumeInfo{own}, volumes)
+}
+
+func TestGetCheckpointedBackupStage(t *testing.T) {
+	backup := newResumeTestBackup("backup", stork_api.ApplicationBackupStatusInProgress)
+	backup.Status.Stage = stork_api.ApplicationBackupStageVolumes
+	checkpoint := &stork_api.OperationCheckpoint{Stage: string(stork_api.ApplicationBackupStageVolumes)}
+	require.Equal(t, stork_api.ApplicationBackupStageVolumes, getCheckpointedBackupStage(backup, checkpoint))
+
+	// The pre-exec rule is run again if the volume backups weren't started
+	backup.Spec.PreExecRule = "rule"
+	require.Equal(t, stork_api.ApplicationBackupStagePreExecRule, getCheckpointedBackupStage(backup, checkpoint))
+	backup.Status.Volumes = []*stork_api.ApplicationBackupVolumeInfo{
+		newResumeTestVolume("pvc", volume.PortworxDriverName, stork_api.ApplicationBackupStatusInProgress),
+	}
+	require.Equal(t, stork_api.ApplicationBackupStageVolumes, getCheckpointedBackupStage(backup, checkpoint))
+
+	checkpoint.Stage = string(stork_api.ApplicationBackupStageApplications)
+	require.Equal(t, stork_api.ApplicationBackupStageApplications, getCheckpointedBackupStage(backup, checkpoint))
+
+	// Unknown stages keep the current stage
+	checkpoint.Stage = "Unknown"
+	require.Equal(t, stork_api.ApplicationBackupStageVolumes, getCheckpointedBackupStage(backup, checkpoint))
+}
@@ -4,22 +4,23 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
+	"github.com/libopenstorage/stork/pkg/objectstore/common"
 	"github.com/libopenstorage/stork/pkg/schedule"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -29,6 +30,8 @@ import (
 )
 
 const (
+	applicationBackupScheduleControllerName = "application-backup-schedule-controller"
+
 	nameTimeSuffixFormat string = "2006-01-02-150405"
 
 	annotationPrefix = "stork.libopenstorage.org/"
@@ -38,11 +41,18 @@ const (
 	// ApplicationBackupSchedulePolicyTypeAnnotation Annotation used to specify the type of the
 	// policy that triggered the backup
 	ApplicationBackupSchedulePolicyTypeAnnotation = annotationPrefix + "applicationBackupSchedulePolicyType"
+	// ApplicationBackupVolumeOrderAnnotation Annotation used to specify the
+	// order, as a comma separated list of <namespace>/<pvc>, in which the
+	// backups of the volumes are started
+	ApplicationBackupVolumeOrderAnnotation = annotationPrefix + "volume-order"
 	// ApplicationBackupObjectLockRetentionAnnotation - object lock retention period annotation
 	// Since this annotation is used in the px-backup, creating with portworx.io annotation prefix.
 	ApplicationBackupObjectLockRetentionAnnotation = "portworx.io/" + "object-lock-retention-period"
 	incrementalCountAnnotation                     = "portworx.io/cloudsnap-incremental-count"
 	dayInSec                                       = 86400
+	// volumeStatsRuns is the number of recent runs averaged in the volume
+	// stats
+	volumeStatsRuns = 5
 	//ObjectLockDefaultIncrementalCount default incremental backup count
 	ObjectLockDefaultIncrementalCount = 5
 	backupTypeKey                     = "portworx.io/backup-type"
@@ -71,7 +81,7 @@ func (s *ApplicationBackupScheduleController) Init(mgr manager.Manager) error {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "application-backup-schedule-controller", s, &stork_api.ApplicationBackupSchedule{})
+	return controllers.RegisterTo(mgr, applicationBackupScheduleControllerName, s, &stork_api.ApplicationBackupSchedule{})
 }
 
 // Reconcile updates for ApplicationBackupSchedule objects.
@@ -89,15 +99,15 @@ func (s *ApplicationBackupScheduleController) Reconcile(ctx context.Context, req
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationBackupScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if err = s.handle(context.TODO(), backup); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(s), backup.Namespace, backup.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationBackupScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(applicationBackupScheduleControllerName, controllers.DefaultRequeue)}, nil
 }
 
 // Handle updates for ApplicationBackupSchedule objects
@@ -121,7 +131,7 @@ func (s *ApplicationBackupScheduleController) handle(ctx context.Context, backup
 
 	if backupSchedule.Spec.Suspend == nil || !*backupSchedule.Spec.Suspend {
 		// Then check if any of the policies require a trigger
-		policyType, start, err := s.shouldStartApplicationBackup(backupSchedule)
+		policyType, scheduledTimestamp, start, err := s.shouldStartApplicationBackup(backupSchedule)
 		if err != nil {
 			msg := fmt.Sprintf("Error checking if backup should be triggered: %v", err)
 			s.recorder.Event(backupSchedule,
@@ -133,7 +143,14 @@ func (s *ApplicationBackupScheduleController) handle(ctx context.Context, backup
 		}
 		// Start a backup for a policy if required
 		if start {
-			err := s.startApplicationBackup(backupSchedule, policyType)
+			deferred, err := s.deferIfRequired(backupSchedule)
+			if err != nil {
+				return err
+			}
+			if deferred {
+				return nil
+			}
+			err = s.startApplicationBackup(backupSchedule, policyType, scheduledTimestamp)
 			if err != nil {
 				msg := fmt.Sprintf("Error triggering backup for schedule(%v): %v", policyType, err)
 				s.recorder.Event(backupSchedule,
@@ -162,6 +179,52 @@ func (s *ApplicationBackupScheduleController) handle(ctx context.Context, backup
 	return nil
 }
 
+// deferIfRequired checks if the cluster is under maintenance and the load
+// gate of the schedule, and records the deferral in the status if the backup
+// that is due should be deferred. The deferral is cleared otherwise. Errors
+// during the checks don't block the backup.
+func (s *ApplicationBackupScheduleController) deferIfRequired(backupSchedule *stork_api.ApplicationBackupSchedule) (bool, error) {
+	deferred, reason, err := schedule.MaintenanceInProgress(storkconfig.GetMaintenanceConfiguration())
+	if err != nil {
+		msg := fmt.Sprintf("Error checking for cluster maintenance, not deferring backup: %v", err)
+		s.recorder.Event(backupSchedule,
+			v1.EventTypeWarning,
+			string(stork_api.ApplicationBackupStatusFailed),
+			msg)
+		log.ApplicationBackupScheduleLog(backupSchedule).Warn(msg)
+	}
+	if !deferred {
+		deferred, reason, err = schedule.DeferRequired(backupSchedule.Spec.LoadGate, backupSchedule.Status.DeferredSince)
+		if err != nil {
+			msg := fmt.Sprintf("Error checking load gate, not deferring backup: %v", err)
+			s.recorder.Event(backupSchedule,
+				v1.EventTypeWarning,
+				string(stork_api.ApplicationBackupStatusFailed),
+				msg)
+			log.ApplicationBackupScheduleLog(backupSchedule).Warn(msg)
+		}
+	}
+	if !deferred {
+		backupSchedule.Status.DeferredSince = meta.Time{}
+		backupSchedule.Status.DeferredReason = ""
+		return false, nil
+	}
+	if backupSchedule.Status.DeferredSince.IsZero() {
+		backupSchedule.Status.DeferredSince = meta.NewTime(schedule.GetCurrentTime())
+		msg := fmt.Sprintf("Deferring backup: %v", reason)
+		s.recorder.Event(backupSchedule,
+			v1.EventTypeNormal,
+			"Deferred",
+			msg)
+		log.ApplicationBackupScheduleLog(backupSchedule).Info(msg)
+	}
+	if backupSchedule.Status.DeferredReason == reason {
+		return true, nil
+	}
+	backupSchedule.Status.DeferredReason = reason
+	return true, s.client.Update(context.TODO(), backupSchedule)
+}
+
 func (s *ApplicationBackupScheduleController) setDefaults(backupSchedule *stork_api.ApplicationBackupSchedule) {
 	if backupSchedule.Spec.ReclaimPolicy == "" {
 		backupSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyRetain
@@ -202,6 +265,9 @@ func (s *ApplicationBackupScheduleController) updateApplicationBackupStatus(back
 				backup.Status = pendingApplicationBackupStatus
 				if s.isApplicationBackupComplete(backup.Status) {
 					backup.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+					if err := updateVolumeStats(backupSchedule, backup.Name); err != nil {
+						log.ApplicationBackupScheduleLog(backupSchedule).Warnf("Error updating volume stats from backup %v: %v", backup.Name, err)
+					}
 					if pendingApplicationBackupStatus == stork_api.ApplicationBackupStatusSuccessful {
 						s.recorder.Event(backupSchedule,
 							v1.EventTypeNormal,
@@ -227,20 +293,85 @@ func (s *ApplicationBackupScheduleController) updateApplicationBackupStatus(back
 	return nil
 }
 
+// updateVolumeStats updates the average size of the data backed up for each
+// volume with the sizes from a completed backup. The stats of volumes that
+// weren't part of a successful backup are removed.
+func updateVolumeStats(backupSchedule *stork_api.ApplicationBackupSchedule, name string) error {
+	backup, err := storkops.Instance().GetApplicationBackup(name, backupSchedule.Namespace)
+	if err != nil {
+		return err
+	}
+	if backup.Status.Status != stork_api.ApplicationBackupStatusSuccessful &&
+		backup.Status.Status != stork_api.ApplicationBackupStatusPartialSuccess {
+		return nil
+	}
+	if backupSchedule.Status.VolumeStats == nil {
+		backupSchedule.Status.VolumeStats = make(map[string]*stork_api.ScheduledVolumeStats)
+	}
+	found := make(map[string]bool)
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.Status != stork_api.ApplicationBackupStatusSuccessful {
+			continue
+		}
+		key := vInfo.Namespace + "/" + vInfo.PersistentVolumeClaim
+		found[key] = true
+		size := vInfo.ActualSize
+		if size == 0 {
+			size = vInfo.TotalSize
+		}
+		stats, ok := backupSchedule.Status.VolumeStats[key]
+		if !ok {
+			stats = &stork_api.ScheduledVolumeStats{}
+			backupSchedule.Status.VolumeStats[key] = stats
+		}
+		// Average over the recent runs so that the order follows changes in
+		// the workload
+		if stats.Runs < volumeStatsRuns {
+			stats.Runs++
+		}
+		stats.ChangedBytes = (stats.ChangedBytes*uint64(stats.Runs-1) + size) / uint64(stats.Runs)
+	}
+	if backup.Status.Status == stork_api.ApplicationBackupStatusSuccessful {
+		for key := range backupSchedule.Status.VolumeStats {
+			if !found[key] {
+				delete(backupSchedule.Status.VolumeStats, key)
+			}
+		}
+	}
+	return nil
+}
+
+// getVolumeOrder returns the volumes with stats ordered by how much data is
+// backed up for them, largest first
+func getVolumeOrder(volumeStats map[string]*stork_api.ScheduledVolumeStats) string {
+	keys := make([]string, 0, len(volumeStats))
+	for key := range volumeStats {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if volumeStats[keys[i]].ChangedBytes != volumeStats[keys[j]].ChangedBytes {
+			return volumeStats[keys[i]].ChangedBytes > volumeStats[keys[j]].ChangedBytes
+		}
+		return keys[i] < keys[j]
+	})
+	return strings.Join(keys, ",")
+}
+
 func (s *ApplicationBackupScheduleController) isApplicationBackupComplete(status stork_api.ApplicationBackupStatusType) bool {
 	return status == stork_api.ApplicationBackupStatusFailed ||
 		status == stork_api.ApplicationBackupStatusPartialSuccess ||
-		status == stork_api.ApplicationBackupStatusSuccessful
+		status == stork_api.ApplicationBackupStatusSuccessful ||
+		status == stork_api.ApplicationBackupStatusCancelled
 }
 
-func (s *ApplicationBackupScheduleController) shouldStartApplicationBackup(backupSchedule *stork_api.ApplicationBackupSchedule) (stork_api.SchedulePolicyType, bool, error) {
+func (s *ApplicationBackupScheduleController) shouldStartApplicationBackup(backupSchedule *stork_api.ApplicationBackupSchedule) (stork_api.SchedulePolicyType, meta.Time, bool, error) {
 	// Don't trigger a new backup if one is already in progress
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		policyApplicationBackup, present := backupSchedule.Status.Items[policyType]
 		if present {
 			for _, backup := range policyApplicationBackup {
 				if !s.isApplicationBackupComplete(backup.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
+					return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 				}
 			}
 		}
@@ -251,25 +382,30 @@ func (s *ApplicationBackupScheduleController) shouldStartApplicationBackup(backu
 		policyApplicationBackup, present := backupSchedule.Status.Items[policyType]
 		if present {
 			for _, backup := range policyApplicationBackup {
-				if latestApplicationBackupTimestamp.Before(&backup.CreationTimestamp) {
-					latestApplicationBackupTimestamp = backup.CreationTimestamp
+				triggerTimestamp := backup.CreationTimestamp
+				if !backup.ScheduledTimestamp.IsZero() {
+					triggerTimestamp = backup.ScheduledTimestamp
+				}
+				if latestApplicationBackupTimestamp.Before(&triggerTimestamp) {
+					latestApplicationBackupTimestamp = triggerTimestamp
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, scheduledTimestamp, err := schedule.TriggerRequiredWithMissedRunPolicy(
 			backupSchedule.Spec.SchedulePolicyName,
 			backupSchedule.Namespace,
 			policyType,
 			latestApplicationBackupTimestamp,
+			backupSchedule.Spec.MissedRunPolicy,
 		)
 		if err != nil {
-			return stork_api.SchedulePolicyTypeInvalid, false, err
+			return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, err
 		}
 		if trigger {
-			return policyType, true, nil
+			return policyType, scheduledTimestamp, true, nil
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+	return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 }
 
 func (s *ApplicationBackupScheduleController) formatApplicationBackupName(backupSchedule *stork_api.ApplicationBackupSchedule, policyType stork_api.SchedulePolicyType) string {
@@ -281,9 +417,13 @@ func getBackupLocationCR(backupSchedule *stork_api.ApplicationBackupSchedule) (*
 	// Get the backuplocation CR name
 	backuplocationCRName := backupSchedule.Spec.Template.Spec.BackupLocation
 	// Get the backuplocation CR content
-	backuplocationCR, err := storkops.Instance().GetBackupLocation(backuplocationCRName, backupSchedule.Namespace)
+	backuplocationNamespace := backupSchedule.Namespace
+	if backupSchedule.Spec.Template.Spec.BackupLocationNamespace != "" {
+		backuplocationNamespace = backupSchedule.Spec.Template.Spec.BackupLocationNamespace
+	}
+	backuplocationCR, err := getBackupLocation(backuplocationCRName, backuplocationNamespace, backupSchedule.Namespace)
 	if err != nil {
-		logrus.Errorf("%s: failed in getting backuplocation CR %v/%v: %v", fn, backuplocationCRName, backupSchedule.Namespace, err)
+		logrus.Errorf("%s: failed in getting backuplocation CR %v/%v: %v", fn, backuplocationNamespace, backuplocationCRName, err)
 		return nil, err
 	}
 	return backuplocationCR, nil
@@ -311,7 +451,7 @@ func getLatestSuccessfullbackupTime(backupSchedule *stork_api.ApplicationBackupS
 	return lastSuccessfulBackupCreateTime
 }
 
-func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSchedule *stork_api.ApplicationBackupSchedule, policyType stork_api.SchedulePolicyType) error {
+func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSchedule *stork_api.ApplicationBackupSchedule, policyType stork_api.SchedulePolicyType, scheduledTimestamp meta.Time) error {
 	funct := "startApplicationBackup"
 	backupName := s.formatApplicationBackupName(backupSchedule, policyType)
 	if backupSchedule.Status.Items == nil {
@@ -322,9 +462,10 @@ func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSched
 	}
 	backupSchedule.Status.Items[policyType] = append(backupSchedule.Status.Items[policyType],
 		&stork_api.ScheduledApplicationBackupStatus{
-			Name:              backupName,
-			CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
-			Status:            stork_api.ApplicationBackupStatusPending,
+			Name:               backupName,
+			CreationTimestamp:  meta.NewTime(schedule.GetCurrentTime()),
+			ScheduledTimestamp: scheduledTimestamp,
+			Status:             stork_api.ApplicationBackupStatusPending,
 		})
 	err := s.client.Update(context.TODO(), backupSchedule)
 	if err != nil {
@@ -345,6 +486,12 @@ func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSched
 	}
 	backup.Annotations[ApplicationBackupScheduleNameAnnotation] = backupSchedule.Name
 	backup.Annotations[ApplicationBackupSchedulePolicyTypeAnnotation] = string(policyType)
+	// Start the volumes that took the longest in the previous runs first so
+	// that they don't hold up the end of the backup
+	if len(backupSchedule.Status.VolumeStats) > 0 {
+		backup.Annotations[ApplicationBackupVolumeOrderAnnotation] = getVolumeOrder(backupSchedule.Status.VolumeStats)
+	}
+	k8sutils.SetProvenanceLabels(backup, backupSchedule.Name, schedule.GetCurrentTime())
 	if val, ok := backupSchedule.Annotations[backupTypeKey]; ok {
 		if val == genericBackupTypeValue {
 			backup.Spec.BackupType = genericBackupTypeValue
@@ -400,7 +547,7 @@ func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSched
 				errMsg := fmt.Sprintf("invalid bucket retention period set for backup location %s, it needs more than minimum number of retention period in days", backupLocationCR.GetName())
 				logrus.Errorf("%s: %v", funct, errMsg)
 				backup.Status.Status = stork_api.ApplicationBackupStatusFailed
-				backup.Status.Reason = fmt.Sprintf("Failed due to insufficient retention period, Please set S3 bucket's minimum retention period to %d days", minRetentionPeriodReqrd)
+				backup.Status.Reason = fmt.Sprintf("Failed due to insufficient retention period, Please set the bucket's minimum retention period to %d days", minRetentionPeriodReqrd)
 				backup.Status.Stage = stork_api.ApplicationBackupStageFinal
 				backup.Annotations[ApplicationBackupObjectLockRetentionAnnotation] = strconv.FormatInt(objectLockInfo.RetentionPeriodDays, 10)
 				// Don't need to process anything further
@@ -408,6 +555,23 @@ func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSched
 				return err
 			}
 		}
+		// Backups pruned by the schedule can't be deleted from a bucket
+		// that still retains them, so fail early if the two conflict
+		if backupLocationCR.Location.Type == stork_api.BackupLocationGoogle &&
+			backup.Spec.ReclaimPolicy != stork_api.ApplicationBackupReclaimPolicyRetain {
+			conflict, err := getRetentionConflict(backupSchedule, policyType, backupLocationCR, objectLockInfo)
+			if err != nil {
+				return err
+			}
+			if conflict != "" {
+				logrus.Errorf("%s: %v", funct, conflict)
+				backup.Status.Status = stork_api.ApplicationBackupStatusFailed
+				backup.Status.Reason = conflict
+				backup.Status.Stage = stork_api.ApplicationBackupStageFinal
+				_, err = storkops.Instance().CreateApplicationBackup(backup)
+				return err
+			}
+		}
 		// Add applicationBackupObjectLockRetentionAnnotation in the applicationbackup CR with configured bucket retention value
 		var retentionPeriod int64
 		if objectLockInfo.RetentionPeriodYears != 0 {
@@ -463,6 +627,39 @@ func (s *ApplicationBackupScheduleController) startApplicationBackup(backupSched
 	return err
 }
 
+// getRetentionConflict returns the reason why backups of the policy type
+// can't be pruned if the schedule prunes them before the retention period of
+// the bucket has expired
+func getRetentionConflict(
+	backupSchedule *stork_api.ApplicationBackupSchedule,
+	policyType stork_api.SchedulePolicyType,
+	backupLocation *stork_api.BackupLocation,
+	objectLockInfo *common.ObjLockInfo,
+) (string, error) {
+	bucketRetention := time.Duration(objectLockInfo.RetentionPeriodDays) * 24 * time.Hour
+	if objectLockInfo.RetentionPeriodYears != 0 {
+		bucketRetention = time.Now().AddDate(int(objectLockInfo.RetentionPeriodYears), 0, 0).Sub(time.Now())
+	}
+	scheduleRetention, err := schedule.GetRetentionPeriod(backupSchedule.Spec.SchedulePolicyName, backupSchedule.Namespace, policyType)
+	if err != nil {
+		return "", err
+	}
+	if scheduleRetention <= 0 || scheduleRetention >= bucketRetention {
+		return "", nil
+	}
+	retain, err := schedule.GetRetain(backupSchedule.Spec.SchedulePolicyName, backupSchedule.Namespace, policyType)
+	if err != nil {
+		return "", err
+	}
+	period := scheduleRetention / time.Duration(retain)
+	minRetain := (bucketRetention + period - 1) / period
+	return fmt.Sprintf("Backups of the %v policy are pruned after %v but bucket %v retains them for %v days, "+
+		"so they can't be deleted. Set retain for the %v policy of %v to at least %v, shorten the retention period "+
+		"of the bucket or set the reclaimPolicy of the backups to Retain",
+		policyType, scheduleRetention, backupLocation.Location.Path, int64(bucketRetention.Hours()/24),
+		policyType, backupSchedule.Spec.SchedulePolicyName, int64(minRetain)), nil
+}
+
 func (s *ApplicationBackupScheduleController) pruneApplicationBackups(backupSchedule *stork_api.ApplicationBackupSchedule) error {
 	for policyType, policyApplicationBackup := range backupSchedule.Status.Items {
 		numApplicationBackups := len(policyApplicationBackup)
@@ -508,28 +705,5 @@ func (s *ApplicationBackupScheduleController) pruneApplicationBackups(backupSche
 }
 
 func (s *ApplicationBackupScheduleController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.ApplicationBackupScheduleResourceName,
-		Plural:  stork_api.ApplicationBackupScheduleResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.ApplicationBackupSchedule{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.ApplicationBackupSchedule{}).Name())
 }
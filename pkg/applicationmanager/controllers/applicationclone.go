@@ -8,17 +8,15 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -33,6 +31,8 @@ import (
 )
 
 const (
+	applicationCloneControllerName = "application-clone-controller"
+
 	pvNamePrefix        = "pvc-"
 	skipModifyResources = "stork.libopenstorage.org/skip-modify-resource"
 )
@@ -40,7 +40,7 @@ const (
 // NewApplicationClone create a new instance of ApplicationCloneController.
 func NewApplicationClone(mgr manager.Manager, d volume.Driver, r record.EventRecorder, rc resourcecollector.ResourceCollector) *ApplicationCloneController {
 	return &ApplicationCloneController{
-		client:            mgr.GetClient(),
+		client:            controllers.NewConditionClient(mgr.GetClient()),
 		volDriver:         d,
 		recorder:          r,
 		resourceCollector: rc,
@@ -81,7 +81,7 @@ func (a *ApplicationCloneController) Init(mgr manager.Manager, adminNamespace st
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "application-clone-controller", a, &stork_api.ApplicationClone{})
+	return controllers.RegisterTo(mgr, applicationCloneControllerName, a, &stork_api.ApplicationClone{})
 }
 
 func (a *ApplicationCloneController) setKind(snap *stork_api.ApplicationClone) {
@@ -126,6 +126,7 @@ func (a *ApplicationCloneController) verifyNamespaces(clone *stork_api.Applicati
 	if err != nil {
 		return fmt.Errorf("error getting source namespace %v: %v", clone.Spec.SourceNamespace, err)
 	}
+	resourcecollector.PrepareServiceMeshMetadata(ns.Labels, ns.Annotations, clone.Spec.ServiceMeshPolicy)
 	_, err = core.Instance().CreateNamespace(&v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        clone.Spec.DestinationNamespace,
@@ -139,10 +140,14 @@ func (a *ApplicationCloneController) verifyNamespaces(clone *stork_api.Applicati
 			log.ApplicationCloneLog(clone).Infof("Updating dest namespace %v", ns.Name)
 			// regardless of replace policy we should always update namespace
 			// to keep latest annotations/labels
+			destNS, err := core.Instance().GetNamespace(clone.Spec.DestinationNamespace)
+			if err != nil {
+				return fmt.Errorf("error getting destination namespace %v: %v", clone.Spec.DestinationNamespace, err)
+			}
 			_, err = core.Instance().UpdateNamespace(&v1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:        clone.Spec.DestinationNamespace,
-					Labels:      ns.Labels,
+					Labels:      keepSandboxOwner(destNS, ns.Labels),
 					Annotations: ns.GetAnnotations(),
 				},
 			})
@@ -169,7 +174,7 @@ func (a *ApplicationCloneController) Reconcile(ctx context.Context, request reco
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationCloneControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(clone, controllers.FinalizerCleanup) {
@@ -177,12 +182,16 @@ func (a *ApplicationCloneController) Reconcile(ctx context.Context, request reco
 		return reconcile.Result{Requeue: true}, a.client.Update(context.TODO(), clone)
 	}
 
+	if controllers.IsObserved(clone) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = a.handle(context.TODO(), clone); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(a), clone.Namespace, clone.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationCloneControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, a.client, clone, storkconfig.GetRequeuePeriod(applicationCloneControllerName, controllers.DefaultRequeue))
 }
 
 // Handle updates for ApplicationClone objects
@@ -191,6 +200,14 @@ func (a *ApplicationCloneController) handle(ctx context.Context, clone *stork_ap
 		if controllers.ContainsFinalizer(clone, controllers.FinalizerCleanup) {
 			if err := a.deleteClone(clone); err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(a), err)
+				// Keep the clone until the cleanup succeeds and record the error
+				// so that it can be audited
+				if controllers.SetCleanupError(clone, err) {
+					if updateErr := a.client.Update(ctx, clone); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(a), updateErr)
+					}
+				}
+				return err
 			}
 		}
 
@@ -232,7 +249,7 @@ func (a *ApplicationCloneController) handle(ctx context.Context, clone *stork_ap
 		}
 		// Make sure the rules exist if configured
 		if clone.Spec.PreExecRule != "" {
-			_, err := storkops.Instance().GetRule(clone.Spec.PreExecRule, clone.Namespace)
+			_, err := rule.GetRule(clone.Spec.PreExecRule, clone.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PreExecRule %v: %v", clone.Spec.PreExecRule, err)
 				log.ApplicationCloneLog(clone).Errorf(message)
@@ -244,7 +261,7 @@ func (a *ApplicationCloneController) handle(ctx context.Context, clone *stork_ap
 			}
 		}
 		if clone.Spec.PostExecRule != "" {
-			_, err := storkops.Instance().GetRule(clone.Spec.PostExecRule, clone.Namespace)
+			_, err := rule.GetRule(clone.Spec.PostExecRule, clone.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PostExecRule %v: %v", clone.Spec.PostExecRule, err)
 				log.ApplicationCloneLog(clone).Errorf(message)
@@ -476,7 +493,7 @@ func (a *ApplicationCloneController) runPreExecRule(clone *stork_api.Application
 			return nil, nil
 		}
 	}
-	r, err := storkops.Instance().GetRule(clone.Spec.PreExecRule, clone.Namespace)
+	r, err := rule.GetRule(clone.Spec.PreExecRule, clone.Namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -489,7 +506,7 @@ func (a *ApplicationCloneController) runPreExecRule(clone *stork_api.Application
 }
 
 func (a *ApplicationCloneController) runPostExecRule(clone *stork_api.ApplicationClone) error {
-	r, err := storkops.Instance().GetRule(clone.Spec.PostExecRule, clone.Namespace)
+	r, err := rule.GetRule(clone.Spec.PostExecRule, clone.Namespace)
 	if err != nil {
 		return err
 	}
@@ -524,6 +541,14 @@ func (a *ApplicationCloneController) prepareResources(
 			return nil, err
 		}
 
+		skip, err := resourcecollector.PrepareServiceMeshForApply(o, clone.Spec.ServiceMeshPolicy)
+		if err != nil {
+			return nil, fmt.Errorf("error preparing service mesh configuration of %v: %v", metadata.GetName(), err)
+		}
+		if skip {
+			continue
+		}
+
 		switch o.GetObjectKind().GroupVersionKind().Kind {
 		case "PersistentVolume":
 			err := a.preparePVResource(o)
@@ -545,6 +570,8 @@ func (a *ApplicationCloneController) prepareResources(
 			pvNameMappings,
 			clone.Spec.IncludeOptionalResourceTypes,
 			nil,
+			nil,
+			nil,
 		)
 		if err != nil {
 			return nil, err
@@ -758,7 +785,8 @@ func (a *ApplicationCloneController) cloneResources(
 		clone.Spec.Selectors,
 		nil,
 		clone.Spec.IncludeOptionalResourceTypes,
-		false)
+		false,
+		nil)
 	if err != nil {
 		log.ApplicationCloneLog(clone).Errorf("Error getting resources: %v", err)
 		return err
@@ -800,28 +828,5 @@ func (a *ApplicationCloneController) deleteClone(clone *stork_api.ApplicationClo
 }
 
 func (a *ApplicationCloneController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.ApplicationCloneResourceName,
-		Plural:  stork_api.ApplicationCloneResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.ApplicationClone{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.ApplicationClone{}).Name())
 }
@@ -6,24 +6,30 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/drivers/volume/kdmp"
-	"github.com/libopenstorage/stork/pkg/apis/stork"
 	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -34,7 +40,9 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,12 +50,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const (
+	applicationRestoreControllerName = "application-restore-controller"
+
+	defaultRestoreMaxThreads = 4
+)
+
 // NewApplicationRestore creates a new instance of ApplicationRestoreController.
-func NewApplicationRestore(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector) *ApplicationRestoreController {
+// The controller is restricted to the given namespaces, or all namespaces if
+// none are given.
+func NewApplicationRestore(mgr manager.Manager, r record.EventRecorder, rc resourcecollector.ResourceCollector, namespaces []string) *ApplicationRestoreController {
 	return &ApplicationRestoreController{
-		client:            mgr.GetClient(),
-		recorder:          r,
+		client:            controllers.NewConditionClient(mgr.GetClient()),
+		recorder:          controllers.NewEventHistoryRecorder(r),
 		resourceCollector: rc,
+		watchNamespaces:   namespaces,
 	}
 }
 
@@ -58,7 +75,10 @@ type ApplicationRestoreController struct {
 	recorder              record.EventRecorder
 	resourceCollector     resourcecollector.ResourceCollector
 	dynamicInterface      dynamic.Interface
+	discoveryInterface    discovery.DiscoveryInterface
+	kubeClient            kubernetes.Interface
 	restoreAdminNamespace string
+	watchNamespaces       []string
 }
 
 // Init Initialize the application restore controller
@@ -74,19 +94,78 @@ func (a *ApplicationRestoreController) Init(mgr manager.Manager, restoreAdminNam
 	if err != nil {
 		return fmt.Errorf("error getting cluster config: %v", err)
 	}
+	// Use the same limits as the resource collector since resources are
+	// applied in parallel
+	if a.resourceCollector.QPS > 0 {
+		config.QPS = a.resourceCollector.QPS
+	}
+	if a.resourceCollector.Burst > 0 {
+		config.Burst = a.resourceCollector.Burst
+	}
 
 	a.dynamicInterface, err = dynamic.NewForConfig(config)
 	if err != nil {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "application-restore-controller", a, &storkapi.ApplicationRestore{})
+	a.discoveryInterface, err = discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	a.kubeClient, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	controllers.RegisterShutdownHandler(a)
+	return controllers.RegisterWithProgressTo(mgr, applicationRestoreControllerName, a, volume.ProgressKindRestore,
+		func() runtimeclient.Object { return &storkapi.ApplicationRestore{} }, &storkapi.ApplicationRestore{})
+}
+
+// Checkpoint records the current stage of all in-progress restores so that
+// the next stork instance can resume them.
+func (a *ApplicationRestoreController) Checkpoint() error {
+	// Only the restores in the watched namespaces are checkpointed, the others
+	// belong to other stork instances
+	restores := make([]storkapi.ApplicationRestore, 0)
+	for _, ns := range controllers.NamespacesToList(a.watchNamespaces) {
+		restoreList, err := storkops.Instance().ListApplicationRestores(ns, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error listing restores to checkpoint: %v", err)
+		}
+		restores = append(restores, restoreList.Items...)
+	}
+	var lastErr error
+	for i := range restores {
+		restore := &restores[i]
+		if restore.DeletionTimestamp != nil ||
+			restore.Status.Stage == "" ||
+			restore.Status.Stage == storkapi.ApplicationRestoreStageFinal {
+			continue
+		}
+		restore.Status.Checkpoint = controllers.NewCheckpoint(string(restore.Status.Stage))
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		if _, err := storkops.Instance().UpdateApplicationRestore(restore); err != nil {
+			log.ApplicationRestoreLog(restore).Errorf("Error checkpointing restore: %v", err)
+			lastErr = err
+			continue
+		}
+		log.ApplicationRestoreLog(restore).Infof("Checkpointed restore in stage %v", restore.Status.Stage)
+	}
+	return lastErr
 }
 
 func (a *ApplicationRestoreController) setDefaults(restore *storkapi.ApplicationRestore) error {
 	if restore.Spec.ReplacePolicy == "" {
 		restore.Spec.ReplacePolicy = storkapi.ApplicationRestoreReplacePolicyRetain
 	}
+	if restore.Spec.VolumeDataSourcePolicy == "" {
+		restore.Spec.VolumeDataSourcePolicy = storkapi.ApplicationRestoreVolumeDataSourcePolicyRestore
+	}
+	if restore.Spec.JobPolicy == nil {
+		restore.Spec.JobPolicy = &storkapi.JobPolicy{}
+	}
 	// If no namespaces mappings are provided add mappings for all of them
 	if len(restore.Spec.NamespaceMapping) == 0 {
 		backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
@@ -100,6 +179,9 @@ func (a *ApplicationRestoreController) setDefaults(restore *storkapi.Application
 			restore.Spec.NamespaceMapping[ns] = ns
 		}
 	}
+	if restore.Spec.Sandbox {
+		setSandboxNamespaceMapping(restore)
+	}
 	return nil
 }
 
@@ -110,11 +192,27 @@ func (a *ApplicationRestoreController) verifyNamespaces(restore *storkapi.Applic
 	if !a.namespaceRestoreAllowed(restore) {
 		return fmt.Errorf("Spec.Namespaces should only contain the current namespace")
 	}
+	// Storage classes are cluster scoped, only the admin can have them created
+	if restore.Spec.StorageClassCreation != nil && restore.Namespace != a.restoreAdminNamespace {
+		return fmt.Errorf("Spec.StorageClassCreation is only allowed for restores in the admin namespace %v", a.restoreAdminNamespace)
+	}
+	// Make sure the backup location can be used from the namespace of the
+	// restore
+	if _, err := getBackupLocation(restore.Spec.BackupLocation, restore.GetBackupLocationNamespace(), restore.Namespace); err != nil {
+		return err
+	}
 	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf("Error getting backup: %v", err)
 		return err
 	}
+	// Sandbox namespaces are created and isolated before anything is
+	// restored into them
+	if restore.Spec.Sandbox {
+		if err := a.setupSandbox(restore); err != nil {
+			return err
+		}
+	}
 	return a.createNamespaces(backup, restore.Spec.BackupLocation, restore)
 }
 
@@ -123,7 +221,7 @@ func (a *ApplicationRestoreController) createNamespaces(backup *storkapi.Applica
 	restore *storkapi.ApplicationRestore) error {
 	var namespaces []*v1.Namespace
 
-	nsData, err := a.downloadObject(backup, backupLocation, restore.Namespace, nsObjectName, true)
+	nsData, err := a.downloadObject(backup, backupLocation, restore.GetBackupLocationNamespace(), nsObjectName, true)
 	if err != nil {
 		return err
 	}
@@ -185,7 +283,7 @@ func (a *ApplicationRestoreController) createNamespaces(backup *storkapi.Applica
 					_, err = core.Instance().UpdateNamespace(&v1.Namespace{
 						ObjectMeta: metav1.ObjectMeta{
 							Name:        ns.Name,
-							Labels:      labels,
+							Labels:      keepSandboxOwner(oldNS, labels),
 							Annotations: annotations,
 						},
 					})
@@ -218,6 +316,25 @@ func (a *ApplicationRestoreController) createNamespaces(backup *storkapi.Applica
 	return nil
 }
 
+// getCheckpointedRestoreStage returns the stage that a checkpointed restore
+// resumes from. Volume restores that were started are tracked in the status,
+// and resources that were already applied by the applications stage are
+// picked up as restored when the stage runs again.
+func getCheckpointedRestoreStage(
+	restore *storkapi.ApplicationRestore,
+	checkpoint *storkapi.OperationCheckpoint,
+) storkapi.ApplicationRestoreStageType {
+	stage := storkapi.ApplicationRestoreStageType(checkpoint.Stage)
+	switch stage {
+	case storkapi.ApplicationRestoreStageInitial,
+		storkapi.ApplicationRestoreStageVolumes,
+		storkapi.ApplicationRestoreStageApplications:
+		return stage
+	}
+	// Keep the current stage if the checkpoint can't be used
+	return restore.Status.Stage
+}
+
 // Reconcile updates for ApplicationRestore objects.
 func (a *ApplicationRestoreController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	logrus.Infof("Reconciling ApplicationRestore %s/%s", request.Namespace, request.Name)
@@ -233,7 +350,7 @@ func (a *ApplicationRestoreController) Reconcile(ctx context.Context, request re
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationRestoreControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(restore, controllers.FinalizerCleanup) {
@@ -241,22 +358,41 @@ func (a *ApplicationRestoreController) Reconcile(ctx context.Context, request re
 		return reconcile.Result{Requeue: true}, a.client.Update(context.TODO(), restore)
 	}
 
+	if controllers.IsObserved(restore) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = a.handle(context.TODO(), restore); err != nil && err != errResourceBusy {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(a), restore.Namespace, restore.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(applicationRestoreControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, a.client, restore, storkconfig.GetRequeuePeriod(applicationRestoreControllerName, controllers.DefaultRequeue))
 }
 
 // Handle updates for ApplicationRestore objects
+func restoreReference(restore *storkapi.ApplicationRestore) storkapi.ObjectReference {
+	return controllers.NewReference("ApplicationRestore", restore)
+}
+
 func (a *ApplicationRestoreController) handle(ctx context.Context, restore *storkapi.ApplicationRestore) error {
 	if restore.DeletionTimestamp != nil {
 		if controllers.ContainsFinalizer(restore, controllers.FinalizerCleanup) {
 			if err := a.cleanupRestore(restore); err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(a), err)
+				// Keep the restore until the cleanup succeeds and record the
+				// error so that it can be audited
+				if controllers.SetCleanupError(restore, err) {
+					if updateErr := a.client.Update(ctx, restore); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(a), updateErr)
+					}
+				}
+				return err
 			}
 		}
+		if err := controllers.UpdateBackupReference(restore.Spec.BackupName, restore.Namespace, restoreReference(restore), false); err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Error removing reference from backup %v: %v", restore.Spec.BackupName, err)
+		}
 
 		if restore.GetFinalizers() != nil {
 			controllers.RemoveFinalizer(restore, controllers.FinalizerCleanup)
@@ -276,6 +412,15 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 		return nil
 	}
 
+	if restore.Spec.Sandbox {
+		if restore.Status.SandboxDeleted {
+			return nil
+		}
+		if restore.Status.SandboxExpiry != nil && metav1.Now().After(restore.Status.SandboxExpiry.Time) {
+			return a.expireSandbox(ctx, restore)
+		}
+	}
+
 	err = a.verifyNamespaces(restore)
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf(err.Error())
@@ -286,13 +431,80 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 		return nil
 	}
 
+	if restore.Spec.Sandbox {
+		if restore.Status.SandboxExpiry == nil {
+			expiry := getSandboxExpiry(restore)
+			restore.Status.SandboxExpiry = &expiry
+			restore.Status.LastUpdateTimestamp = metav1.Now()
+			return a.client.Update(ctx, restore)
+		}
+	}
+
+	if restore.Status.Checkpoint != nil {
+		checkpoint := restore.Status.Checkpoint
+		restore.Status.Checkpoint = nil
+		restore.Status.Stage = getCheckpointedRestoreStage(restore, checkpoint)
+		restore.Status.LastUpdateTimestamp = metav1.Now()
+		message := fmt.Sprintf("Resuming restore from stage %v checkpointed by %v at %v in stage %v",
+			restore.Status.Stage, checkpoint.Owner, checkpoint.Timestamp, checkpoint.Stage)
+		log.ApplicationRestoreLog(restore).Infof(message)
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+		return a.client.Update(ctx, restore)
+	}
+
 	switch restore.Status.Stage {
 	case storkapi.ApplicationRestoreStageInitial:
+		// Link the restore from the backup so that the restores of a backup
+		// can be found from it
+		if err := controllers.UpdateBackupReference(restore.Spec.BackupName, restore.Namespace, restoreReference(restore), true); err != nil {
+			log.ApplicationRestoreLog(restore).Warnf("Error adding reference to backup %v: %v", restore.Spec.BackupName, err)
+		}
+		// Check the quotas before anything is created in the namespaces
+		proceed, err := a.checkQuotas(restore)
+		if err != nil {
+			message := fmt.Sprintf("Error checking quotas: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusInProgress),
+				message)
+			return nil
+		}
+		if !proceed {
+			return nil
+		}
+		// Check the images before the applications are restored so that
+		// missing images don't leave the restored pods unable to start
+		proceed, err = a.checkImages(restore)
+		if err != nil {
+			message := fmt.Sprintf("Error checking images: %v", err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusInProgress),
+				message)
+			return nil
+		}
+		if !proceed {
+			return nil
+		}
 		// Make sure the namespaces exist
 		fallthrough
 	case storkapi.ApplicationRestoreStageVolumes:
 		err := a.restoreVolumes(restore)
 		if err != nil {
+			if _, ok := err.(*pvclock.ErrLocked); ok {
+				message := fmt.Sprintf("Waiting for volume to be unlocked: %v", err)
+				log.ApplicationRestoreLog(restore).Infof(message)
+				a.recorder.Event(restore,
+					v1.EventTypeNormal,
+					string(storkapi.ApplicationRestoreStatusInProgress),
+					message)
+				return errResourceBusy
+			}
 			message := fmt.Sprintf("Error restoring volumes: %v", err)
 			log.ApplicationRestoreLog(restore).Errorf(message)
 			a.recorder.Event(restore,
@@ -317,7 +529,20 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 		}
 
 	case storkapi.ApplicationRestoreStageFinal:
-		// DoNothing
+		if restore.Spec.InitContainer == nil || restore.Status.InitContainerRemoved ||
+			restore.Status.Status == storkapi.ApplicationRestoreStatusFailed {
+			return nil
+		}
+		removed, err := a.removeInitContainers(restore)
+		if err != nil {
+			log.ApplicationRestoreLog(restore).Errorf("Error removing init containers from restored workloads: %v", err)
+			return nil
+		}
+		if removed {
+			restore.Status.InitContainerRemoved = true
+			restore.Status.LastUpdateTimestamp = metav1.Now()
+			return a.client.Update(ctx, restore)
+		}
 		return nil
 	default:
 		log.ApplicationRestoreLog(restore).Errorf("Invalid stage for restore: %v", restore.Status.Stage)
@@ -326,14 +551,57 @@ func (a *ApplicationRestoreController) handle(ctx context.Context, restore *stor
 	return nil
 }
 
+// createStorageClasses creates the storage classes recorded in the backup
+// that don't exist in the cluster, with the provisioners and parameters
+// mapped as set in the restore. Backups taken before the storage classes were
+// recorded don't have any to create. Only restores in the admin namespace can
+// create storage classes.
+func (a *ApplicationRestoreController) createStorageClasses(
+	backup *storkapi.ApplicationBackup,
+	restore *storkapi.ApplicationRestore,
+) error {
+	if restore.Namespace != a.restoreAdminNamespace {
+		return fmt.Errorf("storage classes can only be created by restores in the admin namespace %v", a.restoreAdminNamespace)
+	}
+	data, err := a.downloadObject(backup, restore.Spec.BackupLocation, restore.GetBackupLocationNamespace(), storageClassObjectName, true)
+	if err != nil || data == nil {
+		return err
+	}
+	storageClasses := make([]*storagev1.StorageClass, 0)
+	if err := json.Unmarshal(data, &storageClasses); err != nil {
+		return err
+	}
+	created, err := resourcecollector.CreateMissingStorageClasses(
+		storage.Instance(),
+		storageClasses,
+		restore.Spec.StorageClassMapping,
+		restore.Spec.StorageClassCreation,
+	)
+	for _, name := range created {
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			fmt.Sprintf("Created storage class %v missing in the cluster", name))
+	}
+	return err
+}
+
 func (a *ApplicationRestoreController) namespaceRestoreAllowed(restore *storkapi.ApplicationRestore) bool {
 	// Restrict restores to only the namespace that the object belongs
 	// except for the namespace designated by the admin
-	if restore.Namespace != a.restoreAdminNamespace {
-		for _, ns := range restore.Spec.NamespaceMapping {
-			if ns != restore.Namespace {
+	if restore.Namespace == a.restoreAdminNamespace {
+		return true
+	}
+	for sourceNamespace, ns := range restore.Spec.NamespaceMapping {
+		// Sandbox restores always go to new namespaces created for the
+		// restore, but only the namespace of the restore can be recovered
+		// to them
+		if restore.Spec.Sandbox {
+			if sourceNamespace != restore.Namespace {
 				return false
 			}
+		} else if ns != restore.Namespace {
+			return false
 		}
 	}
 	return true
@@ -438,6 +706,11 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 			if volumeBackup.Namespace != namespace {
 				continue
 			}
+			// Volumes that failed in a partially successful backup can't
+			// be restored, their PVCs are reported as failed resources
+			if volumeBackup.Status == storkapi.ApplicationBackupStatusFailed {
+				continue
+			}
 			// If a list of resources was specified during restore check if
 			// this PVC was included
 			info.Name = volumeBackup.PersistentVolumeClaim
@@ -470,6 +743,22 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 			backupVolumeInfoMappings[volumeBackup.DriverName] = append(backupVolumeInfoMappings[volumeBackup.DriverName], volumeBackup)
 		}
 	}
+	// Existing PVCs are replaced, so wait for other operations using them to
+	// finish and keep new ones from starting
+	if restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
+		pvcs := make([]types.NamespacedName, 0)
+		for _, vInfos := range backupVolumeInfoMappings {
+			for _, vInfo := range vInfos {
+				pvcs = append(pvcs, types.NamespacedName{
+					Namespace: restore.Spec.NamespaceMapping[vInfo.Namespace],
+					Name:      vInfo.PersistentVolumeClaim,
+				})
+			}
+		}
+		if err := pvclock.AcquireAll(pvcs, pvclock.NewLock("ApplicationRestore", restore, pvclock.OperationRestore)); err != nil {
+			return err
+		}
+	}
 	if restore.Status.Volumes == nil {
 		restore.Status.Volumes = make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	}
@@ -478,6 +767,12 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 	namespacedName.Name = restore.Name
 	restoreCompleteList := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
 	if len(restore.Status.Volumes) != pvcCount {
+		if restore.Spec.StorageClassCreation != nil {
+			if err := a.createStorageClasses(backup, restore); err != nil {
+				log.ApplicationRestoreLog(restore).Errorf("Error creating storage classes: %v", err)
+				return err
+			}
+		}
 		for driverName, vInfos := range backupVolumeInfoMappings {
 			backupVolInfos := vInfos
 			existingRestoreVolInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
@@ -488,7 +783,7 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 
 			// For each driver, check if it needs any additional resources to be
 			// restored before starting the volume restore
-			objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.Namespace)
+			objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 			if err != nil {
 				log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
 				return err
@@ -503,6 +798,12 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 				}
 			}
 
+			if restore.Spec.VolumeDataSourcePolicy == storkapi.ApplicationRestoreVolumeDataSourcePolicyRepopulate {
+				var repopulatedVolInfos []*storkapi.ApplicationRestoreVolumeInfo
+				backupVolInfos, repopulatedVolInfos = skipRepopulatedVolumes(driver, backupVolInfos)
+				existingRestoreVolInfos = append(existingRestoreVolInfos, repopulatedVolInfos...)
+			}
+
 			preRestoreObjects, err := driver.GetPreRestoreResources(backup, restore, objects)
 			if err != nil {
 				log.ApplicationRestoreLog(restore).Errorf("Error getting PreRestore Resources: %v", err)
@@ -530,6 +831,8 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 						nil,
 						restore.Spec.IncludeOptionalResourceTypes,
 						nil,
+						restore.Spec.JobPolicy,
+						restore.Spec.CertificatePolicy,
 					)
 					if err != nil {
 						return err
@@ -631,23 +934,21 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 			return err
 		}
 
-		// Now check if there is any failure or success
-		// TODO: On failure of one volume cancel other restores?
+		// Now check if there is any failure or success. Failed volumes
+		// only fail the restore once the other volumes are done, unless it
+		// is set to fail on partial restores.
+		var failed *storkapi.ApplicationRestoreVolumeInfo
+		failedCount := 0
 		for _, vInfo := range volumeInfos {
 			if vInfo.Status == storkapi.ApplicationRestoreStatusInProgress || vInfo.Status == storkapi.ApplicationRestoreStatusInitial ||
 				vInfo.Status == storkapi.ApplicationRestoreStatusPending {
 				log.ApplicationRestoreLog(restore).Infof("Volume restore still in progress: %v->%v", vInfo.SourceVolume, vInfo.RestoreVolume)
 				inProgress = true
 			} else if vInfo.Status == storkapi.ApplicationRestoreStatusFailed {
-				a.recorder.Event(restore,
-					v1.EventTypeWarning,
-					string(vInfo.Status),
-					fmt.Sprintf("Error restoring volume %v->%v: %v", vInfo.SourceVolume, vInfo.RestoreVolume, vInfo.Reason))
-				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
-				restore.Status.FinishTimestamp = metav1.Now()
-				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
-				restore.Status.Reason = vInfo.Reason
-				break
+				if failed == nil {
+					failed = vInfo
+				}
+				failedCount++
 			} else if vInfo.Status == storkapi.ApplicationRestoreStatusSuccessful {
 				a.recorder.Event(restore,
 					v1.EventTypeNormal,
@@ -655,6 +956,26 @@ func (a *ApplicationRestoreController) restoreVolumes(restore *storkapi.Applicat
 					fmt.Sprintf("Volume %v->%v restored successfully", vInfo.SourceVolume, vInfo.RestoreVolume))
 			}
 		}
+		if failed != nil && (restore.Spec.FailOnPartial || !inProgress) {
+			for _, vInfo := range volumeInfos {
+				if vInfo.Status == storkapi.ApplicationRestoreStatusFailed {
+					a.recorder.Event(restore,
+						v1.EventTypeWarning,
+						string(vInfo.Status),
+						fmt.Sprintf("Error restoring volume %v->%v: %v", vInfo.SourceVolume, vInfo.RestoreVolume, vInfo.Reason))
+				}
+			}
+			// The restore is kept as partially successful as long as some
+			// of the volumes were restored
+			if restore.Spec.FailOnPartial || failedCount == len(volumeInfos) {
+				restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+				restore.Status.FinishTimestamp = metav1.Now()
+				restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+				restore.Status.Reason = failed.Reason
+				restore.Status.FailedItems = restore.GetFailedItems()
+				inProgress = false
+			}
+		}
 	}
 
 	// Return if we have any volume restores still in progress
@@ -710,14 +1031,22 @@ func (a *ApplicationRestoreController) downloadObject(
 	}
 
 	objectPath := backup.Status.BackupPath
+	manifest, err := backuplayout.Read(context.TODO(), bucket, objectPath)
+	if err != nil {
+		return nil, err
+	}
+	if hashes, ok := manifest.Blobs[objectName]; ok {
+		return backuplayout.ReadArray(context.TODO(), bucket, hashes, restoreLocation.Location.EncryptionKey)
+	}
+	objectKey := filepath.Join(objectPath, manifest.ObjectKey(objectName))
 	if skipIfNotPresent {
-		exists, err := bucket.Exists(context.TODO(), filepath.Join(objectPath, objectName))
+		exists, err := bucket.Exists(context.TODO(), objectKey)
 		if err != nil || !exists {
 			return nil, nil
 		}
 	}
 
-	data, err := bucket.ReadAll(context.TODO(), filepath.Join(objectPath, objectName))
+	data, err := bucket.ReadAll(context.TODO(), objectKey)
 	if err != nil {
 		return nil, err
 	}
@@ -901,6 +1230,10 @@ func (a *ApplicationRestoreController) getPVNameMappings(
 ) (map[string]string, error) {
 	pvNameMappings := make(map[string]string)
 	for _, vInfo := range restore.Status.Volumes {
+		// Repopulated volumes aren't restored, so their PVs are skipped
+		if vInfo.Repopulate {
+			continue
+		}
 		if vInfo.SourceVolume == "" {
 			return nil, fmt.Errorf("SourceVolume missing for restore")
 		}
@@ -1038,6 +1371,36 @@ func (a *ApplicationRestoreController) skipVolumesFromRestoreList(
 	return newVolInfos, existingInfos, nil
 }
 
+// skipRepopulatedVolumes skips restoring the volumes whose PVCs had a data
+// source when they were backed up, since they are populated again from it
+// when the PVCs are restored
+func skipRepopulatedVolumes(
+	driver volume.Driver,
+	volInfo []*storkapi.ApplicationBackupVolumeInfo,
+) ([]*storkapi.ApplicationBackupVolumeInfo, []*storkapi.ApplicationRestoreVolumeInfo) {
+	repopulatedInfos := make([]*storkapi.ApplicationRestoreVolumeInfo, 0)
+	newVolInfos := make([]*storkapi.ApplicationBackupVolumeInfo, 0)
+	for _, bkupVolInfo := range volInfo {
+		if bkupVolInfo.DataSource == nil && bkupVolInfo.DataSourceRef == nil {
+			newVolInfos = append(newVolInfos, bkupVolInfo)
+			continue
+		}
+		repopulatedInfos = append(repopulatedInfos, &storkapi.ApplicationRestoreVolumeInfo{
+			PersistentVolumeClaim:    bkupVolInfo.PersistentVolumeClaim,
+			PersistentVolumeClaimUID: bkupVolInfo.PersistentVolumeClaimUID,
+			SourceNamespace:          bkupVolInfo.Namespace,
+			SourceVolume:             bkupVolInfo.Volume,
+			DriverName:               driver.String(),
+			Status:                   storkapi.ApplicationRestoreStatusSuccessful,
+			Reason: fmt.Sprintf("Skipped from volume restore as volume data source policy is set to %s",
+				storkapi.ApplicationRestoreVolumeDataSourcePolicyRepopulate),
+			TotalSize:  bkupVolInfo.TotalSize,
+			Repopulate: true,
+		})
+	}
+	return newVolInfos, repopulatedInfos
+}
+
 func (a *ApplicationRestoreController) removeCSIVolumesBeforeApply(
 	restore *storkapi.ApplicationRestore,
 	objects []runtime.Unstructured,
@@ -1140,21 +1503,51 @@ func (a *ApplicationRestoreController) applyResources(
 			pvNameMappings,
 			restore.Spec.IncludeOptionalResourceTypes,
 			restore.Status.Volumes,
+			restore.Spec.JobPolicy,
+			restore.Spec.CertificatePolicy,
 		)
 		if err != nil {
 			return err
 		}
-		if !skip {
-			tempObjects = append(tempObjects, o)
+		if skip {
+			continue
 		}
+		if restore.Spec.Sandbox && isNetworkPolicy(o) {
+			continue
+		}
+		if restore.Spec.InitContainer != nil {
+			if _, err := resourcecollector.InjectInitContainer(o, restore.Spec.InitContainer); err != nil {
+				return err
+			}
+		}
+		tempObjects = append(tempObjects, o)
 	}
 	objects = tempObjects
+	if err := a.mapRegistries(restore, objects); err != nil {
+		return err
+	}
 
 	// skip CSI PV/PVCs before applying
 	objects, err = a.removeCSIVolumesBeforeApply(restore, objects)
 	if err != nil {
 		return err
 	}
+
+	// Make sure the API versions of the objects are served by this cluster,
+	// converting deprecated versions where possible
+	objects, unsupported, err := resourcecollector.ConvertToServedVersions(a.discoveryInterface, objects)
+	if err != nil {
+		return err
+	}
+	for _, u := range unsupported {
+		if err := a.updateResourceStatus(
+			restore,
+			u.Object,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", u.Reason)); err != nil {
+			return err
+		}
+	}
 	// First delete the existing objects if they exist and replace policy is set
 	// to Delete
 	if restore.Spec.ReplacePolicy == storkapi.ApplicationRestoreReplacePolicyDelete {
@@ -1166,60 +1559,129 @@ func (a *ApplicationRestoreController) applyResources(
 		}
 	}
 
-	for _, o := range objects {
-		metadata, err := meta.Accessor(o)
-		if err != nil {
-			return err
-		}
-		objectType, err := meta.TypeAccessor(o)
-		if err != nil {
+	// Objects are applied after the objects they depend on, so only the
+	// objects within a tier are applied in parallel
+	tiers, err := resourcecollector.GetApplyTiers(objects)
+	if err != nil {
+		return err
+	}
+	// The status of each object is updated in the restore so access to it
+	// needs to be serialized
+	var statusLock sync.Mutex
+	maxThreads := storkconfig.GetRestoreMaxThreads(defaultRestoreMaxThreads)
+	log.ApplicationRestoreLog(restore).Infof("Applying %v objects in %v tiers with %v parallel workers", len(objects), len(tiers), maxThreads)
+	for _, tier := range tiers {
+		if err := a.applyResourceTier(restore, tier, maxThreads, &statusLock); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		log.ApplicationRestoreLog(restore).Infof("Applying %v %v/%v", objectType.GetKind(), metadata.GetNamespace(), metadata.GetName())
-		retained := false
+// applyResourceTier applies the objects in parallel and waits for all of
+// them to be applied. Returns the first error hit.
+func (a *ApplicationRestoreController) applyResourceTier(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+	maxThreads int,
+	statusLock *sync.Mutex,
+) error {
+	objectChan := make(chan runtime.Unstructured, len(objects))
+	errorChan := make(chan error, len(objects))
+	for _, o := range objects {
+		objectChan <- o
+	}
+	close(objectChan)
 
-		err = a.resourceCollector.ApplyResource(
-			a.dynamicInterface,
-			o)
-		if err != nil && errors.IsAlreadyExists(err) {
-			switch restore.Spec.ReplacePolicy {
-			case storkapi.ApplicationRestoreReplacePolicyDelete:
-				log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
-			case storkapi.ApplicationRestoreReplacePolicyRetain:
-				log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
-				retained = true
-				err = nil
+	for w := 0; w < maxThreads; w++ {
+		go func() {
+			for o := range objectChan {
+				errorChan <- a.applyResource(restore, o, statusLock)
 			}
+		}()
+	}
+
+	var firstErr error
+	for i := 0; i < len(objects); i++ {
+		if err := <-errorChan; err != nil && firstErr == nil {
+			firstErr = err
 		}
+	}
+	return firstErr
+}
 
-		if err != nil {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusFailed,
-				fmt.Sprintf("Error applying resource: %v", err)); err != nil {
-				return err
-			}
-		} else if retained {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusRetained,
-				"Resource restore skipped as it was already present and ReplacePolicy is set to Retain"); err != nil {
-				return err
-			}
-		} else {
-			if err := a.updateResourceStatus(
-				restore,
-				o,
-				storkapi.ApplicationRestoreStatusSuccessful,
-				"Resource restored successfully"); err != nil {
-				return err
+func (a *ApplicationRestoreController) applyResource(
+	restore *storkapi.ApplicationRestore,
+	o runtime.Unstructured,
+	statusLock *sync.Mutex,
+) error {
+	metadata, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+	objectType, err := meta.TypeAccessor(o)
+	if err != nil {
+		return err
+	}
+
+	log.ApplicationRestoreLog(restore).Infof("Applying %v %v/%v", objectType.GetKind(), metadata.GetNamespace(), metadata.GetName())
+	retained := false
+
+	err = a.resourceCollector.ApplyResource(
+		a.dynamicInterface,
+		o)
+	if err != nil && errors.IsAlreadyExists(err) {
+		switch restore.Spec.ReplacePolicy {
+		case storkapi.ApplicationRestoreReplacePolicyDelete:
+			log.ApplicationRestoreLog(restore).Errorf("Error deleting %v %v during restore: %v", objectType.GetKind(), metadata.GetName(), err)
+		case storkapi.ApplicationRestoreReplacePolicyRetain:
+			if a.createdByRestore(restore, o) {
+				log.ApplicationRestoreLog(restore).Infof("%v %v was already restored", objectType.GetKind(), metadata.GetName())
+				err = nil
+				break
 			}
+			log.ApplicationRestoreLog(restore).Warningf("Error deleting %v %v during restore, ReplacePolicy set to Retain: %v", objectType.GetKind(), metadata.GetName(), err)
+			retained = true
+			err = nil
 		}
 	}
-	return nil
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	if err != nil {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", err))
+	} else if retained {
+		return a.updateResourceStatus(
+			restore,
+			o,
+			storkapi.ApplicationRestoreStatusRetained,
+			"Resource restore skipped as it was already present and ReplacePolicy is set to Retain")
+	}
+	return a.updateResourceStatus(
+		restore,
+		o,
+		storkapi.ApplicationRestoreStatusSuccessful,
+		"Resource restored successfully")
+}
+
+// createdByRestore returns true if the object on the cluster was created
+// after the restore was started. Such objects were applied by an earlier
+// attempt of the restore, for example before the restore was resumed from a
+// checkpoint, so they aren't reported as retained.
+func (a *ApplicationRestoreController) createdByRestore(
+	restore *storkapi.ApplicationRestore,
+	o runtime.Unstructured,
+) bool {
+	existing, err := a.resourceCollector.GetObject(a.dynamicInterface, o)
+	if err != nil {
+		return false
+	}
+	created := existing.GetCreationTimestamp()
+	return !created.Before(&restore.CreationTimestamp)
 }
 
 func (a *ApplicationRestoreController) restoreResources(
@@ -1231,12 +1693,21 @@ func (a *ApplicationRestoreController) restoreResources(
 		return err
 	}
 
-	objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.Namespace)
+	objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
 	if err != nil {
 		log.ApplicationRestoreLog(restore).Errorf("Error downloading resources: %v", err)
 		return err
 	}
 
+	// Repair the ownership before the applications are started
+	if restore.Spec.RepairOwnership {
+		a.repairOwnership(restore, objects)
+	}
+
+	objects, err = a.skipFailedVolumeResources(restore, backup, objects)
+	if err != nil {
+		return err
+	}
 	if err := a.applyResources(restore, objects); err != nil {
 		return err
 	}
@@ -1253,6 +1724,8 @@ func (a *ApplicationRestoreController) restoreResources(
 		return err
 	}
 
+	a.releaseVolumeLocks(restore)
+
 	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
 	restore.Status.FinishTimestamp = metav1.Now()
 	restore.Status.Status = storkapi.ApplicationRestoreStatusSuccessful
@@ -1264,6 +1737,14 @@ func (a *ApplicationRestoreController) restoreResources(
 			break
 		}
 	}
+	restore.Status.FailedItems = restore.GetFailedItems()
+	if len(restore.Status.FailedItems) != 0 {
+		restore.Status.Status = storkapi.ApplicationRestoreStatusPartialSuccess
+		restore.Status.Reason = fmt.Sprintf("%v volumes or resources failed to restore", len(restore.Status.FailedItems))
+		if restore.Spec.FailOnPartial {
+			restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+		}
+	}
 
 	restore.Status.LastUpdateTimestamp = metav1.Now()
 	if err := a.client.Update(context.TODO(), restore); err != nil {
@@ -1273,6 +1754,298 @@ func (a *ApplicationRestoreController) restoreResources(
 	return nil
 }
 
+// skipFailedVolumeResources removes the PVCs and PVs of the volumes that
+// weren't backed up or failed to restore from the objects to be applied. The
+// PVCs are marked as failed in the status of the restore.
+func (a *ApplicationRestoreController) skipFailedVolumeResources(
+	restore *storkapi.ApplicationRestore,
+	backup *storkapi.ApplicationBackup,
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, error) {
+	failed := make(map[string]string)
+	for _, vInfo := range backup.Status.Volumes {
+		if vInfo.Status == storkapi.ApplicationBackupStatusFailed {
+			failed[vInfo.Namespace+"/"+vInfo.PersistentVolumeClaim] = fmt.Sprintf("Volume wasn't backed up: %v", vInfo.Reason)
+		}
+	}
+	for _, vInfo := range restore.Status.Volumes {
+		if vInfo.Status == storkapi.ApplicationRestoreStatusFailed {
+			failed[vInfo.SourceNamespace+"/"+vInfo.PersistentVolumeClaim] = fmt.Sprintf("Error restoring volume: %v", vInfo.Reason)
+		}
+	}
+	if len(failed) == 0 {
+		return objects, nil
+	}
+
+	objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+	tempObjects := make([]runtime.Unstructured, 0, len(objects))
+	for _, o := range objects {
+		objectType, err := meta.TypeAccessor(o)
+		if err != nil {
+			return nil, err
+		}
+		switch objectType.GetKind() {
+		case "PersistentVolumeClaim":
+			metadata, err := meta.Accessor(o)
+			if err != nil {
+				return nil, err
+			}
+			reason, ok := failed[metadata.GetNamespace()+"/"+metadata.GetName()]
+			if !ok {
+				break
+			}
+			// Only PVCs that are part of the restore are reported, in the
+			// namespace they would have been restored to
+			namespace, restored := restore.Spec.NamespaceMapping[metadata.GetNamespace()]
+			info := storkapi.ObjectInfo{
+				Name:      metadata.GetName(),
+				Namespace: metadata.GetNamespace(),
+				GroupVersionKind: metav1.GroupVersionKind{
+					Group:   "core",
+					Version: "v1",
+					Kind:    "PersistentVolumeClaim",
+				},
+			}
+			if len(objectMap) != 0 && !objectMap[info] {
+				restored = false
+			}
+			if restored {
+				pvc := o.DeepCopyObject().(runtime.Unstructured)
+				pvcMetadata, err := meta.Accessor(pvc)
+				if err != nil {
+					return nil, err
+				}
+				pvcMetadata.SetNamespace(namespace)
+				if err := a.updateResourceStatus(restore, pvc, storkapi.ApplicationRestoreStatusFailed, reason); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		case "PersistentVolume":
+			var pv v1.PersistentVolume
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pv); err != nil {
+				return nil, fmt.Errorf("error converting to persistent volume: %v", err)
+			}
+			if pv.Spec.ClaimRef != nil {
+				if _, ok := failed[pv.Spec.ClaimRef.Namespace+"/"+pv.Spec.ClaimRef.Name]; ok {
+					continue
+				}
+			}
+		}
+		tempObjects = append(tempObjects, o)
+	}
+	return tempObjects, nil
+}
+
+// repairOwnership fixes the ownership of the volumes restored by KDMP for
+// workloads that expect the files to be owned by their fsGroup. Failures are
+// reported as events but don't fail the restore.
+func (a *ApplicationRestoreController) repairOwnership(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) {
+	for _, vol := range restore.Status.Volumes {
+		if vol.DriverName != kdmp.GetGenericDriverName() || vol.Status != storkapi.ApplicationRestoreStatusSuccessful {
+			continue
+		}
+		fsGroup, err := getFSGroupForPVC(objects, vol.SourceNamespace, vol.PersistentVolumeClaim)
+		if err != nil {
+			log.ApplicationRestoreLog(restore).Errorf("Error getting fsGroup for PVC %v/%v: %v", vol.SourceNamespace, vol.PersistentVolumeClaim, err)
+			continue
+		}
+		if fsGroup == nil {
+			continue
+		}
+		namespace := restore.Spec.NamespaceMapping[vol.SourceNamespace]
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim, namespace)
+		if err == nil {
+			err = k8sutils.RepairVolumeOwnership(pvc, *fsGroup, "", k8sutils.OwnershipRepairTimeout)
+		}
+		if err != nil {
+			message := fmt.Sprintf("Error repairing ownership of PVC %v/%v: %v", namespace, vol.PersistentVolumeClaim, err)
+			log.ApplicationRestoreLog(restore).Errorf(message)
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusPartialSuccess),
+				message)
+		}
+	}
+}
+
+// getFSGroupForPVC returns the fsGroup of the first workload in the backup
+// that uses the PVC
+func getFSGroupForPVC(objects []runtime.Unstructured, namespace, pvcName string) (*int64, error) {
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		if metadata.GetNamespace() != namespace {
+			continue
+		}
+		content := o.UnstructuredContent()
+		var podSpecFields []string
+		switch o.GetObjectKind().GroupVersionKind().Kind {
+		case "Pod":
+			podSpecFields = []string{"spec"}
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "DeploymentConfig":
+			podSpecFields = []string{"spec", "template", "spec"}
+		default:
+			continue
+		}
+		podSpecContent, found, err := unstructured.NestedMap(content, podSpecFields...)
+		if err != nil || !found {
+			continue
+		}
+		var podSpec v1.PodSpec
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecContent, &podSpec); err != nil {
+			return nil, err
+		}
+		if podSpec.SecurityContext == nil || podSpec.SecurityContext.FSGroup == nil {
+			continue
+		}
+
+		uses := false
+		for _, volume := range podSpec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvcName {
+				uses = true
+				break
+			}
+		}
+		// PVCs created from the volume claim templates of StatefulSets are
+		// named <template>-<statefulset>-<ordinal>
+		if !uses && o.GetObjectKind().GroupVersionKind().Kind == "StatefulSet" {
+			templates, _, err := unstructured.NestedSlice(content, "spec", "volumeClaimTemplates")
+			if err != nil {
+				continue
+			}
+			for _, t := range templates {
+				template, ok := t.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _, _ := unstructured.NestedString(template, "metadata", "name")
+				if name != "" && strings.HasPrefix(pvcName, name+"-"+metadata.GetName()+"-") {
+					uses = true
+					break
+				}
+			}
+		}
+		if uses {
+			return podSpec.SecurityContext.FSGroup, nil
+		}
+	}
+	return nil, nil
+}
+
+// removeInitContainers removes the init container from the restored
+// workloads once they have rolled out successfully. Returns true once it has
+// been removed from all the workloads.
+func (a *ApplicationRestoreController) removeInitContainers(restore *storkapi.ApplicationRestore) (bool, error) {
+	removed := true
+	for _, resource := range restore.Status.Resources {
+		if resource.Status != storkapi.ApplicationRestoreStatusSuccessful {
+			continue
+		}
+		var done bool
+		var err error
+		switch resource.Kind {
+		case "Deployment":
+			done, err = removeDeploymentInitContainer(resource.Name, resource.Namespace)
+		case "StatefulSet":
+			done, err = removeStatefulSetInitContainer(resource.Name, resource.Namespace)
+		case "DaemonSet":
+			done, err = removeDaemonSetInitContainer(resource.Name, resource.Namespace)
+		default:
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if !done {
+			removed = false
+			continue
+		}
+		log.ApplicationRestoreLog(restore).Infof("Removed init container from %v %v/%v", resource.Kind, resource.Namespace, resource.Name)
+	}
+	return removed, nil
+}
+
+func removeDeploymentInitContainer(name, namespace string) (bool, error) {
+	deployment, err := apps.Instance().GetDeployment(name, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if _, present := deployment.Annotations[resourcecollector.RestoreInitContainerAnnotation]; !present {
+		return true, nil
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	// Wait for the pods to be started with the init container
+	if replicas == 0 ||
+		deployment.Status.ObservedGeneration < deployment.Generation ||
+		deployment.Status.UpdatedReplicas != replicas ||
+		deployment.Status.AvailableReplicas != replicas {
+		return false, nil
+	}
+	resourcecollector.RemoveInitContainer(deployment, &deployment.Spec.Template.Spec)
+	_, err = apps.Instance().UpdateDeployment(deployment)
+	return err == nil, err
+}
+
+func removeStatefulSetInitContainer(name, namespace string) (bool, error) {
+	statefulSet, err := apps.Instance().GetStatefulSet(name, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if _, present := statefulSet.Annotations[resourcecollector.RestoreInitContainerAnnotation]; !present {
+		return true, nil
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if replicas == 0 ||
+		statefulSet.Status.ObservedGeneration < statefulSet.Generation ||
+		statefulSet.Status.ReadyReplicas != replicas {
+		return false, nil
+	}
+	resourcecollector.RemoveInitContainer(statefulSet, &statefulSet.Spec.Template.Spec)
+	_, err = apps.Instance().UpdateStatefulSet(statefulSet)
+	return err == nil, err
+}
+
+func removeDaemonSetInitContainer(name, namespace string) (bool, error) {
+	daemonSet, err := apps.Instance().GetDaemonSet(name, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	if _, present := daemonSet.Annotations[resourcecollector.RestoreInitContainerAnnotation]; !present {
+		return true, nil
+	}
+	desired := daemonSet.Status.DesiredNumberScheduled
+	if desired == 0 ||
+		daemonSet.Status.ObservedGeneration < daemonSet.Generation ||
+		daemonSet.Status.UpdatedNumberScheduled != desired ||
+		daemonSet.Status.NumberAvailable != desired {
+		return false, nil
+	}
+	resourcecollector.RemoveInitContainer(daemonSet, &daemonSet.Spec.Template.Spec)
+	_, err = apps.Instance().UpdateDaemonSet(daemonSet)
+	return err == nil, err
+}
+
 func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.ApplicationRestore) error {
 	for _, vrInfo := range restore.Status.Volumes {
 		if vrInfo.DriverName != "csi" && vrInfo.DriverName != "kdmp" {
@@ -1336,7 +2109,56 @@ func (a *ApplicationRestoreController) addCSIVolumeResources(restore *storkapi.A
 	return nil
 }
 
+// expireSandbox deletes the sandbox namespaces once the TTL has passed. The
+// restore is failed if it hadn't completed yet.
+func (a *ApplicationRestoreController) expireSandbox(ctx context.Context, restore *storkapi.ApplicationRestore) error {
+	if restore.Status.Stage != storkapi.ApplicationRestoreStageFinal {
+		if err := a.cancelRestore(restore); err != nil {
+			log.ApplicationRestoreLog(restore).Errorf(err.Error())
+			a.recorder.Event(restore,
+				v1.EventTypeWarning,
+				string(storkapi.ApplicationRestoreStatusFailed),
+				err.Error())
+			return nil
+		}
+	}
+	if err := a.deleteSandbox(restore); err != nil {
+		log.ApplicationRestoreLog(restore).Errorf(err.Error())
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusFailed),
+			err.Error())
+		return nil
+	}
+	restore.Status.SandboxDeleted = true
+	if restore.Status.Stage != storkapi.ApplicationRestoreStageFinal {
+		restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+		restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+		restore.Status.Reason = "Sandbox expired before the restore completed"
+		restore.Status.FinishTimestamp = metav1.Now()
+	}
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	a.recorder.Event(restore,
+		v1.EventTypeNormal,
+		string(restore.Status.Status),
+		"Deleted sandbox namespaces after TTL expired")
+	return a.client.Update(ctx, restore)
+}
+
 func (a *ApplicationRestoreController) cleanupRestore(restore *storkapi.ApplicationRestore) error {
+	if err := a.cancelRestore(restore); err != nil {
+		return err
+	}
+	if restore.Spec.Sandbox && !restore.Status.SandboxDeleted {
+		return a.deleteSandbox(restore)
+	}
+	return nil
+}
+
+// cancelRestore cancels the restore of the volumes in the drivers and
+// releases the locks held on the PVCs
+func (a *ApplicationRestoreController) cancelRestore(restore *storkapi.ApplicationRestore) error {
+	a.releaseVolumeLocks(restore)
 	drivers := a.getDriversForRestore(restore)
 	for driverName := range drivers {
 		driver, err := volume.Get(driverName)
@@ -1350,31 +2172,27 @@ func (a *ApplicationRestoreController) cleanupRestore(restore *storkapi.Applicat
 	return nil
 }
 
-func (a *ApplicationRestoreController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    storkapi.ApplicationRestoreResourceName,
-		Plural:  storkapi.ApplicationRestoreResourcePlural,
-		Group:   stork.GroupName,
-		Version: storkapi.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(storkapi.ApplicationRestore{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
+// releaseVolumeLocks releases the locks held by the restore on the PVCs that
+// it replaced. The locks are usually gone already since the PVCs are
+// recreated.
+func (a *ApplicationRestoreController) releaseVolumeLocks(restore *storkapi.ApplicationRestore) {
+	if restore.Spec.ReplacePolicy != storkapi.ApplicationRestoreReplacePolicyDelete {
+		return
 	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
+	pvcs := make([]types.NamespacedName, 0, len(restore.Status.Volumes))
+	for _, vInfo := range restore.Status.Volumes {
+		pvcs = append(pvcs, types.NamespacedName{
+			Namespace: restore.Spec.NamespaceMapping[vInfo.SourceNamespace],
+			Name:      vInfo.PersistentVolumeClaim,
+		})
 	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
+	if err := pvclock.ReleaseAll(pvcs, string(restore.UID)); err != nil {
+		log.ApplicationRestoreLog(restore).Warnf("Error releasing locks on PVCs: %v", err)
 	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+}
+
+func (a *ApplicationRestoreController) createCRD() error {
+	return crds.Register(reflect.TypeOf(storkapi.ApplicationRestore{}).Name())
 }
 
 func (a *ApplicationRestoreController) cleanupResources(restore *storkapi.ApplicationRestore) error {
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
+	"github.com/libopenstorage/stork/pkg/controllers"
 	"github.com/libopenstorage/stork/pkg/crypto"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/objectstore"
@@ -17,19 +19,35 @@ import (
 	"gocloud.dev/blob"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 )
 
+// blobGCInterval is how often the blobs that aren't referenced by any backup
+// are deleted from a backup location
+const blobGCInterval = 6 * time.Hour
+
+// blobGCLeaseDuration is how long a backup location keeps the lease to
+// collect the garbage of its bucket without renewing it
+const blobGCLeaseDuration = 3 * blobGCInterval
+
 // BackupSyncController reconciles applicationbackup objects
 type BackupSyncController struct {
 	Recorder     record.EventRecorder
 	SyncInterval time.Duration
-	stopChannel  chan os.Signal
+	// Namespaces the controller is restricted to. Backup locations from all
+	// namespaces are synced if it is empty.
+	Namespaces  []string
+	stopChannel chan os.Signal
+	// lastGC is when the blobs were last garbage collected for each backup
+	// location
+	lastGC map[types.UID]time.Time
 }
 
 // Init Initializes the backup sync controller
 func (b *BackupSyncController) Init(stopChannel chan os.Signal) error {
 	b.stopChannel = stopChannel
+	b.lastGC = make(map[types.UID]time.Time)
 	go b.startBackupSync()
 	return nil
 }
@@ -38,17 +56,20 @@ func (b *BackupSyncController) startBackupSync() {
 	for {
 		select {
 		case <-time.After(b.SyncInterval):
-			backupLocations, err := storkops.Instance().ListBackupLocations("", metav1.ListOptions{})
-			if err != nil {
-				logrus.Errorf("Error getting backup location to sync: %v", err)
-				continue
-			}
-			for _, backupLocation := range backupLocations.Items {
-				err := b.syncBackupsFromLocation(&backupLocation)
+			for _, ns := range controllers.NamespacesToList(b.Namespaces) {
+				backupLocations, err := storkops.Instance().ListBackupLocations(ns, metav1.ListOptions{})
 				if err != nil {
-					log.BackupLocationLog(&backupLocation).Errorf("Error syncing backups from location: %v", err)
+					logrus.Errorf("Error getting backup location to sync: %v", err)
 					continue
 				}
+				for _, backupLocation := range backupLocations.Items {
+					err := b.syncBackupsFromLocation(&backupLocation)
+					if err != nil {
+						log.BackupLocationLog(&backupLocation).Errorf("Error syncing backups from location: %v", err)
+						continue
+					}
+					b.collectGarbage(&backupLocation)
+				}
 			}
 
 		case <-b.stopChannel:
@@ -140,6 +161,20 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 				backupInfo.SelfLink = ""
 				backupInfo.OwnerReferences = nil
 				backupInfo.Spec.ReclaimPolicy = storkv1.ApplicationBackupReclaimPolicyRetain
+				// Synced backups don't expire, they would be synced again
+				backupInfo.Spec.TTLSecondsAfterFinished = nil
+				if backupInfo.Annotations == nil {
+					backupInfo.Annotations = make(map[string]string)
+				}
+				backupInfo.Annotations[storkv1.ApplicationBackupSyncedFromAnnotation] = location.Name
+				// Record the layout the backup is stored with so that older
+				// layouts can be found and upgraded
+				manifest, err := backuplayout.Read(context.TODO(), bucket, backupInfo.Status.BackupPath)
+				if err != nil {
+					log.BackupLocationLog(location).Errorf("Error reading layout of backup %v during sync: %v", backupName, err)
+					continue
+				}
+				setLayoutVersion(&backupInfo, manifest.SchemaVersion)
 				_, err = storkops.Instance().CreateApplicationBackup(&backupInfo)
 				if err != nil {
 					return err
@@ -151,6 +186,41 @@ func (b *BackupSyncController) syncBackupsFromLocation(location *storkv1.BackupL
 	return nil
 }
 
+// collectGarbage deletes the blobs in the backup location that aren't
+// referenced by any backup anymore. The blobs of locked buckets aren't
+// collected since they can't be deleted while they are retained. The backup
+// location takes a lease in the bucket so that only one of the backup
+// locations that share a bucket, from this or other clusters, collects its
+// garbage.
+func (b *BackupSyncController) collectGarbage(location *storkv1.BackupLocation) {
+	if time.Since(b.lastGC[location.UID]) < blobGCInterval {
+		return
+	}
+	lockInfo, err := objectstore.GetObjLockInfo(location)
+	if err != nil {
+		log.BackupLocationLog(location).Errorf("Error getting lock info to collect garbage: %v", err)
+		return
+	}
+	if lockInfo.LockEnabled {
+		b.lastGC[location.UID] = time.Now()
+		return
+	}
+	bucket, err := objectstore.GetBucket(location)
+	if err != nil {
+		log.BackupLocationLog(location).Errorf("Error getting bucket to collect garbage: %v", err)
+		return
+	}
+	deleted, err := backuplayout.CollectGarbage(context.TODO(), bucket, string(location.UID), blobGCLeaseDuration)
+	if err != nil {
+		log.BackupLocationLog(location).Errorf("Error collecting garbage: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.BackupLocationLog(location).Infof("Deleted %v blobs that weren't referenced by any backup", deleted)
+	}
+	b.lastGC[location.UID] = time.Now()
+}
+
 func (b *BackupSyncController) getSyncedBackupName(backup *storkv1.ApplicationBackup) string {
 	// For scheduled backups use the original name
 	if _, ok := backup.Annotations[ApplicationBackupScheduleNameAnnotation]; ok {
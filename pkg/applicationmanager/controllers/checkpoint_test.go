@@ -0,0 +1,67 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func setupCheckpointTest(objects ...runtime.Object) {
+	storkops.SetInstance(storkops.New(fakek8s.NewSimpleClientset(), fakestorkclient.NewSimpleClientset(objects...), nil))
+}
+
+func TestBackupCheckpointWatchNamespaces(t *testing.T) {
+	newBackup := func(namespace string) *stork_api.ApplicationBackup {
+		return &stork_api.ApplicationBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: namespace},
+			Status:     stork_api.ApplicationBackupStatus{Stage: stork_api.ApplicationBackupStageVolumes},
+		}
+	}
+	setupCheckpointTest(newBackup("watched"), newBackup("other"))
+	a := &ApplicationBackupController{watchNamespaces: []string{"watched"}}
+	require.NoError(t, a.Checkpoint())
+
+	backup, err := storkops.Instance().GetApplicationBackup("backup", "watched")
+	require.NoError(t, err)
+	require.NotNil(t, backup.Status.Checkpoint, "Expected backup in watched namespace to be checkpointed")
+	require.Equal(t, string(stork_api.ApplicationBackupStageVolumes), backup.Status.Checkpoint.Stage)
+	backup, err = storkops.Instance().GetApplicationBackup("backup", "other")
+	require.NoError(t, err)
+	require.Nil(t, backup.Status.Checkpoint, "Expected backup owned by another instance to be left alone")
+
+	// All namespaces are checkpointed if the controller isn't restricted
+	a.watchNamespaces = nil
+	require.NoError(t, a.Checkpoint())
+	backup, err = storkops.Instance().GetApplicationBackup("backup", "other")
+	require.NoError(t, err)
+	require.NotNil(t, backup.Status.Checkpoint)
+}
+
+func TestRestoreCheckpointWatchNamespaces(t *testing.T) {
+	newRestore := func(namespace string) *stork_api.ApplicationRestore {
+		return &stork_api.ApplicationRestore{
+			ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: namespace},
+			Status:     stork_api.ApplicationRestoreStatus{Stage: stork_api.ApplicationRestoreStageApplications},
+		}
+	}
+	setupCheckpointTest(newRestore("watched"), newRestore("other"))
+	a := &ApplicationRestoreController{watchNamespaces: []string{"watched"}}
+	require.NoError(t, a.Checkpoint())
+
+	restore, err := storkops.Instance().GetApplicationRestore("restore", "watched")
+	require.NoError(t, err)
+	require.NotNil(t, restore.Status.Checkpoint, "Expected restore in watched namespace to be checkpointed")
+	require.Equal(t, string(stork_api.ApplicationRestoreStageApplications), restore.Status.Checkpoint.Stage)
+	restore, err = storkops.Instance().GetApplicationRestore("restore", "other")
+	require.NoError(t, err)
+	require.Nil(t, restore.Status.Checkpoint, "Expected restore owned by another instance to be left alone")
+}
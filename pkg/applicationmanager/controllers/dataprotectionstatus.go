@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// NewDataProtectionStatus creates a new instance of
+// DataProtectionStatusController.
+func NewDataProtectionStatus(mgr manager.Manager, reportInterval time.Duration, namespaces []string) *DataProtectionStatusController {
+	return &DataProtectionStatusController{
+		client:         mgr.GetClient(),
+		ReportInterval: reportInterval,
+		Namespaces:     namespaces,
+	}
+}
+
+// DataProtectionStatusController periodically aggregates the backups,
+// migrations and DR drills of each namespace into a DataProtectionStatus
+// object in the namespace
+type DataProtectionStatusController struct {
+	client         runtimeclient.Client
+	ReportInterval time.Duration
+	// Namespaces the controller is restricted to. The status is reported
+	// for all namespaces if it is empty.
+	Namespaces  []string
+	stopChannel chan os.Signal
+}
+
+// Init Initializes the data protection status controller
+func (d *DataProtectionStatusController) Init(stopChannel chan os.Signal) error {
+	if err := d.createCRD(); err != nil {
+		return err
+	}
+	d.stopChannel = stopChannel
+	go d.startReporting()
+	return nil
+}
+
+func (d *DataProtectionStatusController) startReporting() {
+	for {
+		select {
+		case <-time.After(d.ReportInterval):
+			statuses, err := d.getNamespaceStatuses()
+			if err != nil {
+				logrus.Errorf("Error getting data protection status: %v", err)
+				continue
+			}
+			for namespace, status := range statuses {
+				if err := d.updateStatus(namespace, status); err != nil {
+					logrus.Errorf("Error updating data protection status for namespace %v: %v", namespace, err)
+				}
+			}
+		case <-d.stopChannel:
+			return
+		}
+	}
+}
+
+// getNamespaceStatuses returns the data protection status of all the
+// namespaces that have been backed up, migrated or recovered by a drill
+func (d *DataProtectionStatusController) getNamespaceStatuses() (map[string]*stork_api.NamespaceDataProtectionStatus, error) {
+	statuses := make(map[string]*stork_api.NamespaceDataProtectionStatus)
+	getStatus := func(namespace string) *stork_api.NamespaceDataProtectionStatus {
+		if _, ok := statuses[namespace]; !ok {
+			statuses[namespace] = &stork_api.NamespaceDataProtectionStatus{}
+		}
+		return statuses[namespace]
+	}
+	isNewer := func(last *stork_api.DataProtectionEvent, timestamp meta.Time) bool {
+		return last == nil || last.Timestamp.Before(&timestamp)
+	}
+
+	backups := make([]stork_api.ApplicationBackup, 0)
+	migrations := make([]stork_api.Migration, 0)
+	for _, ns := range controllers.NamespacesToList(d.Namespaces) {
+		nsBackups, err := storkops.Instance().ListApplicationBackups(ns, meta.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		backups = append(backups, nsBackups.Items...)
+		nsMigrations, err := storkops.Instance().ListMigrations(ns)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, nsMigrations.Items...)
+	}
+
+	backupNamespaces := make(map[string][]string)
+	for _, backup := range backups {
+		backupNamespaces[backup.Namespace+"/"+backup.Name] = backup.Spec.Namespaces
+		if backup.Status.Status != stork_api.ApplicationBackupStatusSuccessful {
+			continue
+		}
+		for _, namespace := range d.getProtectedNamespaces(backup.Spec.Namespaces) {
+			status := getStatus(namespace)
+			if isNewer(status.LastBackup, backup.Status.FinishTimestamp) {
+				status.LastBackup = &stork_api.DataProtectionEvent{
+					Kind:      reflect.TypeOf(backup).Name(),
+					Name:      backup.Name,
+					Namespace: backup.Namespace,
+					Timestamp: backup.Status.FinishTimestamp,
+				}
+			}
+		}
+	}
+
+	for _, migration := range migrations {
+		if migration.Status.Status != stork_api.MigrationStatusSuccessful {
+			continue
+		}
+		for _, namespace := range d.getProtectedNamespaces(migration.Spec.Namespaces) {
+			status := getStatus(namespace)
+			if isNewer(status.LastMigration, migration.Status.FinishTimestamp) {
+				status.LastMigration = &stork_api.DataProtectionEvent{
+					Kind:      reflect.TypeOf(migration).Name(),
+					Name:      migration.Name,
+					Namespace: migration.Namespace,
+					Timestamp: migration.Status.FinishTimestamp,
+				}
+			}
+		}
+	}
+
+	reports := &stork_api.DRDrillReportList{}
+	if err := d.client.List(context.TODO(), reports); err != nil {
+		return nil, err
+	}
+	for _, report := range reports.Items {
+		if report.Report.Status != stork_api.DRDrillStatusSuccessful || report.Report.RecoveryTime == nil {
+			continue
+		}
+		namespaces := report.Report.MigratedNamespaces
+		if report.Report.BackupName != "" {
+			namespaces = backupNamespaces[report.Namespace+"/"+report.Report.BackupName]
+		}
+		for _, namespace := range d.getProtectedNamespaces(namespaces) {
+			status := getStatus(namespace)
+			if isNewer(status.LastDrill, report.Report.FinishTimestamp) {
+				status.LastDrill = &stork_api.DataProtectionEvent{
+					Kind:      reflect.TypeOf(report).Name(),
+					Name:      report.Name,
+					Namespace: report.Namespace,
+					Timestamp: report.Report.FinishTimestamp,
+				}
+				status.RTO = report.Report.RecoveryTime.DeepCopy()
+			}
+		}
+	}
+
+	now := meta.Now()
+	for _, status := range statuses {
+		status.LastUpdateTimestamp = now
+		lastPoint := status.LastBackup
+		if lastPoint == nil || (status.LastMigration != nil && lastPoint.Timestamp.Before(&status.LastMigration.Timestamp)) {
+			lastPoint = status.LastMigration
+		}
+		if lastPoint != nil {
+			status.RPO = &meta.Duration{Duration: now.Sub(lastPoint.Timestamp.Time)}
+		}
+	}
+	return statuses, nil
+}
+
+// getProtectedNamespaces skips the namespace patterns since the status is
+// only reported for namespaces that were named explicitly. Namespaces the
+// controller isn't restricted to are skipped too, since operations in the
+// admin namespace can cover them.
+func (d *DataProtectionStatusController) getProtectedNamespaces(namespaces []string) []string {
+	protected := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		if !strings.Contains(namespace, "*") && controllers.IsNamespaceWatched(d.Namespaces, namespace) {
+			protected = append(protected, namespace)
+		}
+	}
+	return protected
+}
+
+func (d *DataProtectionStatusController) updateStatus(namespace string, status *stork_api.NamespaceDataProtectionStatus) error {
+	dataProtectionStatus := &stork_api.DataProtectionStatus{}
+	err := d.client.Get(context.TODO(), types.NamespacedName{Name: stork_api.DataProtectionStatusObjectName, Namespace: namespace}, dataProtectionStatus)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		dataProtectionStatus = &stork_api.DataProtectionStatus{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      stork_api.DataProtectionStatusObjectName,
+				Namespace: namespace,
+			},
+			Status: *status,
+		}
+		return d.client.Create(context.TODO(), dataProtectionStatus)
+	}
+	dataProtectionStatus.Status = *status
+	return d.client.Update(context.TODO(), dataProtectionStatus)
+}
+
+func (d *DataProtectionStatusController) createCRD() error {
+	return crds.Register(reflect.TypeOf(stork_api.DataProtectionStatus{}).Name())
+}
@@ -0,0 +1,213 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newStatusBackup(name string, status storkapi.ApplicationBackupStatusType, age time.Duration, namespaces ...string) *storkapi.ApplicationBackup {
+	return &storkapi.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "admin"},
+		Spec:       storkapi.ApplicationBackupSpec{Namespaces: namespaces},
+		Status: storkapi.ApplicationBackupStatus{
+			Status:          status,
+			FinishTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+	}
+}
+
+func newStatusMigration(name string, status storkapi.MigrationStatusType, age time.Duration, namespaces ...string) *storkapi.Migration {
+	return &storkapi.Migration{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "admin"},
+		Spec:       storkapi.MigrationSpec{Namespaces: namespaces},
+		Status: storkapi.MigrationStatus{
+			Status:          status,
+			FinishTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+	}
+}
+
+func newStatusReport(name string, status storkapi.DRDrillStatusType, age time.Duration, recoveryTime time.Duration) *storkapi.DRDrillReport {
+	return &storkapi.DRDrillReport{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "admin"},
+		Report: storkapi.DRDrillReportSpec{
+			Status:          status,
+			FinishTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			RecoveryTime:    &metav1.Duration{Duration: recoveryTime},
+		},
+	}
+}
+
+func TestDataProtectionStatus(t *testing.T) {
+	// expectedStatus is the expected status of a namespace. RPO and RTO
+	// are zero if they shouldn't be set.
+	type expectedStatus struct {
+		lastBackup    string
+		lastMigration string
+		lastDrill     string
+		rpo           time.Duration
+		rto           time.Duration
+	}
+	fromBackup := func(report *storkapi.DRDrillReport, backup string) *storkapi.DRDrillReport {
+		report.Report.BackupName = backup
+		return report
+	}
+	fromMigration := func(report *storkapi.DRDrillReport, namespaces ...string) *storkapi.DRDrillReport {
+		report.Report.MigratedNamespaces = namespaces
+		return report
+	}
+
+	for _, test := range []struct {
+		name       string
+		namespaces []string
+		objects    []runtime.Object
+		reports    []runtimeclient.Object
+		expected   map[string]expectedStatus
+	}{
+		{
+			name:     "no backups",
+			expected: map[string]expectedStatus{},
+		},
+		{
+			name: "RPO from last successful backup",
+			objects: []runtime.Object{
+				newStatusBackup("old", storkapi.ApplicationBackupStatusSuccessful, 3*time.Hour, "app"),
+				newStatusBackup("last", storkapi.ApplicationBackupStatusSuccessful, time.Hour, "app", "db"),
+				// Later scheduled backups that failed don't lower the RPO
+				newStatusBackup("failed", storkapi.ApplicationBackupStatusFailed, time.Minute, "app"),
+				newStatusBackup("partial", storkapi.ApplicationBackupStatusPartialSuccess, time.Minute, "db"),
+			},
+			expected: map[string]expectedStatus{
+				"app": {lastBackup: "last", rpo: time.Hour},
+				"db":  {lastBackup: "last", rpo: time.Hour},
+			},
+		},
+		{
+			name: "namespace with only failed backups",
+			objects: []runtime.Object{
+				newStatusBackup("failed", storkapi.ApplicationBackupStatusFailed, time.Minute, "app"),
+				newStatusBackup("inprogress", storkapi.ApplicationBackupStatusInProgress, 0, "app"),
+			},
+			expected: map[string]expectedStatus{},
+		},
+		{
+			name: "RPO from more recent migration",
+			objects: []runtime.Object{
+				newStatusBackup("backup", storkapi.ApplicationBackupStatusSuccessful, 2*time.Hour, "app", "db"),
+				newStatusMigration("migration", storkapi.MigrationStatusSuccessful, 10*time.Minute, "app"),
+				newStatusMigration("old", storkapi.MigrationStatusSuccessful, 4*time.Hour, "db"),
+			},
+			expected: map[string]expectedStatus{
+				"app": {lastBackup: "backup", lastMigration: "migration", rpo: 10 * time.Minute},
+				"db":  {lastBackup: "backup", lastMigration: "old", rpo: 2 * time.Hour},
+			},
+		},
+		{
+			name: "namespace patterns skipped",
+			objects: []runtime.Object{
+				newStatusBackup("backup", storkapi.ApplicationBackupStatusSuccessful, time.Hour, "app-*", "app"),
+			},
+			expected: map[string]expectedStatus{
+				"app": {lastBackup: "backup", rpo: time.Hour},
+			},
+		},
+		{
+			name:       "restricted to watched namespaces",
+			namespaces: []string{"admin"},
+			objects: []runtime.Object{
+				newStatusBackup("backup", storkapi.ApplicationBackupStatusSuccessful, time.Hour, "admin", "app"),
+			},
+			expected: map[string]expectedStatus{
+				"admin": {lastBackup: "backup", rpo: time.Hour},
+			},
+		},
+		{
+			name: "RTO from last successful drill",
+			objects: []runtime.Object{
+				newStatusBackup("backup", storkapi.ApplicationBackupStatusSuccessful, time.Hour, "app"),
+			},
+			reports: []runtimeclient.Object{
+				fromBackup(newStatusReport("old", storkapi.DRDrillStatusSuccessful, 2*time.Hour, 3*time.Minute), "backup"),
+				fromBackup(newStatusReport("last", storkapi.DRDrillStatusSuccessful, time.Hour, 5*time.Minute), "backup"),
+				fromBackup(newStatusReport("failed", storkapi.DRDrillStatusFailed, time.Minute, time.Minute), "backup"),
+			},
+			expected: map[string]expectedStatus{
+				"app": {lastBackup: "backup", lastDrill: "last", rpo: time.Hour, rto: 5 * time.Minute},
+			},
+		},
+		{
+			name: "RTO from drill of migrated namespaces without backups",
+			reports: []runtimeclient.Object{
+				fromMigration(newStatusReport("drill", storkapi.DRDrillStatusSuccessful, time.Hour, 2*time.Minute), "app"),
+			},
+			expected: map[string]expectedStatus{
+				"app": {lastDrill: "drill", rto: 2 * time.Minute},
+			},
+		},
+		{
+			name: "drill of deleted backup",
+			reports: []runtimeclient.Object{
+				fromBackup(newStatusReport("drill", storkapi.DRDrillStatusSuccessful, time.Hour, 2*time.Minute), "deleted"),
+			},
+			expected: map[string]expectedStatus{},
+		},
+	} {
+		scheme := runtime.NewScheme()
+		require.NoError(t, storkapi.AddToScheme(scheme))
+		kubeClient := fakek8s.NewSimpleClientset()
+		storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(test.objects...), nil))
+		d := &DataProtectionStatusController{
+			client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(test.reports...).Build(),
+			Namespaces: test.namespaces,
+		}
+
+		statuses, err := d.getNamespaceStatuses()
+		require.NoError(t, err, test.name)
+		require.Len(t, statuses, len(test.expected), test.name)
+		for namespace, expected := range test.expected {
+			status, ok := statuses[namespace]
+			require.True(t, ok, "%v: missing status for namespace %v", test.name, namespace)
+			require.False(t, status.LastUpdateTimestamp.IsZero(), test.name)
+			for _, event := range []struct {
+				expected string
+				actual   *storkapi.DataProtectionEvent
+			}{
+				{expected.lastBackup, status.LastBackup},
+				{expected.lastMigration, status.LastMigration},
+				{expected.lastDrill, status.LastDrill},
+			} {
+				if event.expected == "" {
+					require.Nil(t, event.actual, "%v: %v", test.name, namespace)
+				} else {
+					require.NotNil(t, event.actual, "%v: %v", test.name, namespace)
+					require.Equal(t, event.expected, event.actual.Name, "%v: %v", test.name, namespace)
+				}
+			}
+			if expected.rpo == 0 {
+				require.Nil(t, status.RPO, "%v: %v", test.name, namespace)
+			} else {
+				require.NotNil(t, status.RPO, "%v: %v", test.name, namespace)
+				require.InDelta(t, expected.rpo.Seconds(), status.RPO.Seconds(), 5, "%v: %v", test.name, namespace)
+			}
+			if expected.rto == 0 {
+				require.Nil(t, status.RTO, "%v: %v", test.name, namespace)
+			} else {
+				require.NotNil(t, status.RTO, "%v: %v", test.name, namespace)
+				require.Equal(t, expected.rto, status.RTO.Duration, "%v: %v", test.name, namespace)
+			}
+		}
+	}
+}
@@ -0,0 +1,566 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	"github.com/portworx/sched-ops/k8s/apps"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	drDrillControllerName = "dr-drill-controller"
+
+	// DRDrillNameLabel is set on the DRDrillReports to the name of the drill
+	DRDrillNameLabel = annotationPrefix + "drDrillName"
+)
+
+// NewDRDrill creates a new instance of DRDrillController.
+func NewDRDrill(mgr manager.Manager, r record.EventRecorder) *DRDrillController {
+	return &DRDrillController{
+		client:   mgr.GetClient(),
+		recorder: r,
+	}
+}
+
+// DRDrillController reconciles DRDrill objects
+type DRDrillController struct {
+	client     runtimeclient.Client
+	kubeClient kubernetes.Interface
+
+	recorder record.EventRecorder
+}
+
+// Init Initialize the DR drill controller
+func (d *DRDrillController) Init(mgr manager.Manager) error {
+	err := d.createCRD()
+	if err != nil {
+		return err
+	}
+
+	d.kubeClient, err = kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("error getting kubernetes client: %v", err)
+	}
+
+	return controllers.RegisterTo(mgr, drDrillControllerName, d, &stork_api.DRDrill{})
+}
+
+// Reconcile updates for DRDrill objects.
+func (d *DRDrillController) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	logrus.Tracef("Reconciling DRDrill %s/%s", request.Namespace, request.Name)
+
+	drill := &stork_api.DRDrill{}
+	err := d.client.Get(context.TODO(), request.NamespacedName, drill)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(drDrillControllerName, controllers.DefaultRequeueError)}, err
+	}
+
+	if err = d.handle(context.TODO(), drill); err != nil {
+		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(d), drill.Namespace, drill.Name, err)
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(drDrillControllerName, controllers.DefaultRequeueError)}, err
+	}
+
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(drDrillControllerName, controllers.DefaultRequeue)}, nil
+}
+
+func (d *DRDrillController) handle(ctx context.Context, drill *stork_api.DRDrill) error {
+	if drill.DeletionTimestamp != nil {
+		return nil
+	}
+
+	switch drill.Status.Stage {
+	case stork_api.DRDrillStageInitial, stork_api.DRDrillStageFinal:
+		if drill.Spec.Suspend != nil && *drill.Spec.Suspend {
+			return nil
+		}
+		policyType, start, err := d.shouldStartDrill(drill)
+		if err != nil {
+			msg := fmt.Sprintf("Error checking if drill should be triggered: %v", err)
+			d.recorder.Event(drill, v1.EventTypeWarning, string(stork_api.DRDrillStatusFailed), msg)
+			logrus.Errorf("DRDrill %v/%v: %v", drill.Namespace, drill.Name, msg)
+			return nil
+		}
+		if !start {
+			return nil
+		}
+		return d.startDrill(ctx, drill, policyType)
+	case stork_api.DRDrillStageRecover:
+		return d.recover(ctx, drill)
+	case stork_api.DRDrillStageHealthCheck:
+		return d.healthCheck(ctx, drill)
+	case stork_api.DRDrillStageTeardown:
+		return d.teardown(ctx, drill)
+	default:
+		logrus.Errorf("Invalid stage for DRDrill %v/%v: %v", drill.Namespace, drill.Name, drill.Status.Stage)
+	}
+	return nil
+}
+
+func (d *DRDrillController) shouldStartDrill(drill *stork_api.DRDrill) (stork_api.SchedulePolicyType, bool, error) {
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		trigger, err := schedule.TriggerRequired(
+			drill.Spec.SchedulePolicyName,
+			drill.Namespace,
+			policyType,
+			drill.Status.TriggerTimestamp,
+		)
+		if err != nil {
+			return stork_api.SchedulePolicyTypeInvalid, false, err
+		}
+		if trigger {
+			return policyType, true, nil
+		}
+	}
+	return stork_api.SchedulePolicyTypeInvalid, false, nil
+}
+
+func (d *DRDrillController) startDrill(ctx context.Context, drill *stork_api.DRDrill, policyType stork_api.SchedulePolicyType) error {
+	drill.Status = stork_api.DRDrillStatus{
+		Stage:            stork_api.DRDrillStageRecover,
+		Status:           stork_api.DRDrillStatusInProgress,
+		Reason:           "Recovering applications",
+		PolicyType:       policyType,
+		TriggerTimestamp: meta.NewTime(schedule.GetCurrentTime()),
+		LastReport:       drill.Status.LastReport,
+	}
+	if (drill.Spec.BackupScheduleName == "") == (len(drill.Spec.MigratedNamespaces) == 0) {
+		d.failDrill(drill, "Exactly one of backupScheduleName and migratedNamespaces needs to be set")
+		return d.client.Update(ctx, drill)
+	}
+	msg := fmt.Sprintf("Starting drill for schedule(%v)", policyType)
+	d.recorder.Event(drill, v1.EventTypeNormal, string(stork_api.DRDrillStatusInProgress), msg)
+	logrus.Infof("DRDrill %v/%v: %v", drill.Namespace, drill.Name, msg)
+	return d.client.Update(ctx, drill)
+}
+
+// getRunName returns the name of the objects created for the current run
+func getRunName(drill *stork_api.DRDrill) string {
+	return strings.Join([]string{drill.Name, drill.Status.TriggerTimestamp.Format(nameTimeSuffixFormat)}, "-")
+}
+
+// getRunID returns an ID that is unique for each run of the drill, used in
+// the names of the sandbox namespaces
+func getRunID(drill *stork_api.DRDrill) string {
+	return strconv.FormatInt(drill.Status.TriggerTimestamp.Unix(), 16)
+}
+
+func getDRDrillOwnerReference(drill *stork_api.DRDrill) meta.OwnerReference {
+	return meta.OwnerReference{
+		APIVersion: stork_api.SchemeGroupVersion.String(),
+		Kind:       reflect.TypeOf(stork_api.DRDrill{}).Name(),
+		Name:       drill.Name,
+		UID:        drill.UID,
+	}
+}
+
+func (d *DRDrillController) recover(ctx context.Context, drill *stork_api.DRDrill) error {
+	if drill.Spec.BackupScheduleName != "" {
+		return d.recoverFromBackup(ctx, drill)
+	}
+	return d.recoverFromMigration(ctx, drill)
+}
+
+// recoverFromBackup restores the latest successful backup of the backup
+// schedule in sandbox mode
+func (d *DRDrillController) recoverFromBackup(ctx context.Context, drill *stork_api.DRDrill) error {
+	if drill.Status.RestoreName == "" {
+		backup, err := getLatestSuccessfulBackup(drill.Spec.BackupScheduleName, drill.Namespace)
+		if err != nil {
+			d.failDrill(drill, err.Error())
+			return d.client.Update(ctx, drill)
+		}
+		restore := &stork_api.ApplicationRestore{
+			ObjectMeta: meta.ObjectMeta{
+				Name:            getRunName(drill),
+				Namespace:       drill.Namespace,
+				OwnerReferences: []meta.OwnerReference{getDRDrillOwnerReference(drill)},
+			},
+			Spec: stork_api.ApplicationRestoreSpec{
+				BackupName:              backup.Name,
+				BackupLocation:          backup.Spec.BackupLocation,
+				BackupLocationNamespace: backup.Spec.BackupLocationNamespace,
+				Sandbox:                 true,
+				// The sandbox is deleted during teardown. The TTL makes
+				// sure it is deleted even if the teardown doesn't run.
+				SandboxTTL: &meta.Duration{Duration: 2 * getHealthCheckTimeout(drill)},
+			},
+		}
+		if _, err := storkops.Instance().CreateApplicationRestore(restore); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating restore: %v", err)
+		}
+		drill.Status.BackupName = backup.Name
+		drill.Status.RestoreName = restore.Name
+		return d.client.Update(ctx, drill)
+	}
+
+	restore, err := storkops.Instance().GetApplicationRestore(drill.Status.RestoreName, drill.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			d.failDrill(drill, fmt.Sprintf("Restore %v was deleted", drill.Status.RestoreName))
+			return d.client.Update(ctx, drill)
+		}
+		return err
+	}
+	if restore.Status.Stage != stork_api.ApplicationRestoreStageFinal {
+		return nil
+	}
+	if restore.Status.Status != stork_api.ApplicationRestoreStatusSuccessful &&
+		restore.Status.Status != stork_api.ApplicationRestoreStatusPartialSuccess {
+		d.failDrill(drill, fmt.Sprintf("Restore %v failed: %v", restore.Name, restore.Status.Reason))
+		return d.client.Update(ctx, drill)
+	}
+	drill.Status.Namespaces = make([]string, 0)
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		drill.Status.Namespaces = append(drill.Status.Namespaces, namespace)
+	}
+	sort.Strings(drill.Status.Namespaces)
+	drill.Status.Stage = stork_api.DRDrillStageHealthCheck
+	drill.Status.Reason = "Waiting for applications to become ready"
+	return d.client.Update(ctx, drill)
+}
+
+// recoverFromMigration clones the migrated namespaces into sandbox
+// namespaces and activates the cloned applications
+func (d *DRDrillController) recoverFromMigration(ctx context.Context, drill *stork_api.DRDrill) error {
+	if len(drill.Status.CloneNames) == 0 {
+		for i, namespace := range drill.Spec.MigratedNamespaces {
+			clone := &stork_api.ApplicationClone{
+				ObjectMeta: meta.ObjectMeta{
+					Name:            fmt.Sprintf("%v-%v", getRunName(drill), i),
+					Namespace:       drill.Namespace,
+					OwnerReferences: []meta.OwnerReference{getDRDrillOwnerReference(drill)},
+				},
+				Spec: stork_api.ApplicationCloneSpec{
+					SourceNamespace:      namespace,
+					DestinationNamespace: getSandboxNamespace(namespace, getRunID(drill)),
+				},
+			}
+			// The clone restores the labels of the source namespace into
+			// the sandbox namespace and keeps the owner label
+			if err := createSandboxNamespace(clone.Spec.DestinationNamespace, string(drill.UID)); err != nil {
+				return err
+			}
+			if _, err := storkops.Instance().CreateApplicationClone(clone); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("error creating clone for namespace %v: %v", namespace, err)
+			}
+			drill.Status.CloneNames = append(drill.Status.CloneNames, clone.Name)
+			drill.Status.Namespaces = append(drill.Status.Namespaces, clone.Spec.DestinationNamespace)
+		}
+		return d.client.Update(ctx, drill)
+	}
+
+	for _, cloneName := range drill.Status.CloneNames {
+		clone, err := storkops.Instance().GetApplicationClone(cloneName, drill.Namespace)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				d.failDrill(drill, fmt.Sprintf("Clone %v was deleted", cloneName))
+				return d.client.Update(ctx, drill)
+			}
+			return err
+		}
+		if clone.Status.Stage != stork_api.ApplicationCloneStageFinal {
+			return nil
+		}
+		if clone.Status.Status != stork_api.ApplicationCloneStatusSuccessful &&
+			clone.Status.Status != stork_api.ApplicationCloneStatusPartialSuccess {
+			d.failDrill(drill, fmt.Sprintf("Clone %v failed", clone.Name))
+			return d.client.Update(ctx, drill)
+		}
+	}
+
+	// Isolate the namespaces before scaling up the applications
+	for _, namespace := range drill.Status.Namespaces {
+		if err := isolateSandboxNamespace(d.kubeClient, namespace, string(drill.UID)); err != nil {
+			return err
+		}
+		if err := activateMigratedApps(namespace); err != nil {
+			return err
+		}
+	}
+	drill.Status.Stage = stork_api.DRDrillStageHealthCheck
+	drill.Status.Reason = "Waiting for applications to become ready"
+	return d.client.Update(ctx, drill)
+}
+
+// activateMigratedApps scales up the Deployments and StatefulSets that were
+// scaled down by migrations to their original number of replicas
+func activateMigratedApps(namespace string) error {
+	deployments, err := apps.Instance().ListDeployments(namespace, meta.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing deployments in %v: %v", namespace, err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		replicas, ok, err := getMigrationReplicas(deployment.Annotations)
+		if err != nil || !ok {
+			continue
+		}
+		deployment.Spec.Replicas = &replicas
+		if _, err := apps.Instance().UpdateDeployment(deployment); err != nil {
+			return fmt.Errorf("error activating deployment %v/%v: %v", namespace, deployment.Name, err)
+		}
+	}
+	statefulSets, err := apps.Instance().ListStatefulSets(namespace, meta.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing statefulsets in %v: %v", namespace, err)
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		replicas, ok, err := getMigrationReplicas(statefulSet.Annotations)
+		if err != nil || !ok {
+			continue
+		}
+		statefulSet.Spec.Replicas = &replicas
+		if _, err := apps.Instance().UpdateStatefulSet(statefulSet); err != nil {
+			return fmt.Errorf("error activating statefulset %v/%v: %v", namespace, statefulSet.Name, err)
+		}
+	}
+	return nil
+}
+
+func getMigrationReplicas(annotations map[string]string) (int32, bool, error) {
+	val, ok := annotations[migration.StorkMigrationReplicasAnnotation]
+	if !ok {
+		return 0, false, nil
+	}
+	replicas, err := strconv.ParseInt(val, 10, 32)
+	if err != nil {
+		return 0, false, err
+	}
+	return int32(replicas), true, nil
+}
+
+func getHealthCheckTimeout(drill *stork_api.DRDrill) time.Duration {
+	if drill.Spec.HealthCheckTimeout != nil && drill.Spec.HealthCheckTimeout.Duration > 0 {
+		return drill.Spec.HealthCheckTimeout.Duration
+	}
+	return stork_api.DefaultDRDrillHealthCheckTimeout
+}
+
+// healthCheck waits for all the Deployments and StatefulSets in the sandbox
+// namespaces to become ready
+func (d *DRDrillController) healthCheck(ctx context.Context, drill *stork_api.DRDrill) error {
+	checks := make([]*stork_api.DRDrillCheck, 0)
+	for _, namespace := range drill.Status.Namespaces {
+		nsChecks, err := getWorkloadChecks(namespace)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, nsChecks...)
+	}
+	drill.Status.Checks = checks
+
+	ready := true
+	for _, check := range checks {
+		if !check.Ready {
+			ready = false
+			break
+		}
+	}
+	now := schedule.GetCurrentTime()
+	if ready {
+		drill.Status.RecoveryTime = &meta.Duration{Duration: now.Sub(drill.Status.TriggerTimestamp.Time)}
+		drill.Status.Status = stork_api.DRDrillStatusSuccessful
+		drill.Status.Reason = fmt.Sprintf("Applications recovered in %v", drill.Status.RecoveryTime.Duration)
+		drill.Status.Stage = stork_api.DRDrillStageTeardown
+		return d.client.Update(ctx, drill)
+	}
+	if now.Sub(drill.Status.TriggerTimestamp.Time) > getHealthCheckTimeout(drill) {
+		d.failDrill(drill, "Timed out waiting for applications to become ready")
+	}
+	return d.client.Update(ctx, drill)
+}
+
+func getWorkloadChecks(namespace string) ([]*stork_api.DRDrillCheck, error) {
+	checks := make([]*stork_api.DRDrillCheck, 0)
+	deployments, err := apps.Instance().ListDeployments(namespace, meta.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing deployments in %v: %v", namespace, err)
+	}
+	for _, deployment := range deployments.Items {
+		check := &stork_api.DRDrillCheck{
+			Kind:      reflect.TypeOf(deployment).Name(),
+			Name:      deployment.Name,
+			Namespace: namespace,
+		}
+		desired := int32(1)
+		if deployment.Spec.Replicas != nil {
+			desired = *deployment.Spec.Replicas
+		}
+		check.Ready = deployment.Status.ReadyReplicas >= desired
+		if !check.Ready {
+			check.Reason = fmt.Sprintf("%v/%v replicas ready", deployment.Status.ReadyReplicas, desired)
+		}
+		checks = append(checks, check)
+	}
+	statefulSets, err := apps.Instance().ListStatefulSets(namespace, meta.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing statefulsets in %v: %v", namespace, err)
+	}
+	for _, statefulSet := range statefulSets.Items {
+		check := &stork_api.DRDrillCheck{
+			Kind:      reflect.TypeOf(statefulSet).Name(),
+			Name:      statefulSet.Name,
+			Namespace: namespace,
+		}
+		desired := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			desired = *statefulSet.Spec.Replicas
+		}
+		check.Ready = statefulSet.Status.ReadyReplicas >= desired
+		if !check.Ready {
+			check.Reason = fmt.Sprintf("%v/%v replicas ready", statefulSet.Status.ReadyReplicas, desired)
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// failDrill marks the run as failed and moves it to teardown
+func (d *DRDrillController) failDrill(drill *stork_api.DRDrill, reason string) {
+	drill.Status.Status = stork_api.DRDrillStatusFailed
+	drill.Status.Reason = reason
+	drill.Status.Stage = stork_api.DRDrillStageTeardown
+	d.recorder.Event(drill, v1.EventTypeWarning, string(stork_api.DRDrillStatusFailed), reason)
+	logrus.Errorf("DRDrill %v/%v: %v", drill.Namespace, drill.Name, reason)
+}
+
+// teardown deletes everything created for the run and records the report
+func (d *DRDrillController) teardown(ctx context.Context, drill *stork_api.DRDrill) error {
+	if drill.Status.RestoreName != "" {
+		// The sandbox namespaces are deleted with the restore
+		if err := storkops.Instance().DeleteApplicationRestore(drill.Status.RestoreName, drill.Namespace); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting restore %v: %v", drill.Status.RestoreName, err)
+		}
+	}
+	for _, cloneName := range drill.Status.CloneNames {
+		if err := storkops.Instance().DeleteApplicationClone(cloneName, drill.Namespace); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting clone %v: %v", cloneName, err)
+		}
+	}
+	if len(drill.Status.CloneNames) > 0 {
+		for _, namespace := range drill.Status.Namespaces {
+			if err := deleteSandboxNamespace(namespace, string(drill.UID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	report := &stork_api.DRDrillReport{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      getRunName(drill),
+			Namespace: drill.Namespace,
+			Labels: map[string]string{
+				DRDrillNameLabel: drill.Name,
+			},
+			OwnerReferences: []meta.OwnerReference{getDRDrillOwnerReference(drill)},
+		},
+		Report: stork_api.DRDrillReportSpec{
+			DrillName:          drill.Name,
+			Status:             drill.Status.Status,
+			Reason:             drill.Status.Reason,
+			BackupName:         drill.Status.BackupName,
+			MigratedNamespaces: drill.Spec.MigratedNamespaces,
+			TriggerTimestamp:   drill.Status.TriggerTimestamp,
+			FinishTimestamp:    meta.NewTime(schedule.GetCurrentTime()),
+			RecoveryTime:       drill.Status.RecoveryTime,
+			Checks:             drill.Status.Checks,
+		},
+	}
+	if err := d.client.Create(ctx, report); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating report: %v", err)
+	}
+	if err := d.pruneReports(ctx, drill); err != nil {
+		logrus.Warnf("DRDrill %v/%v: error pruning reports: %v", drill.Namespace, drill.Name, err)
+	}
+
+	if drill.Status.Status == stork_api.DRDrillStatusSuccessful {
+		d.recorder.Event(drill, v1.EventTypeNormal, string(drill.Status.Status), drill.Status.Reason)
+	}
+	drill.Status.Stage = stork_api.DRDrillStageFinal
+	drill.Status.LastReport = report.Name
+	return d.client.Update(ctx, drill)
+}
+
+// pruneReports deletes the oldest reports of the drill beyond the number to
+// keep
+func (d *DRDrillController) pruneReports(ctx context.Context, drill *stork_api.DRDrill) error {
+	reports := &stork_api.DRDrillReportList{}
+	if err := d.client.List(ctx, reports,
+		runtimeclient.InNamespace(drill.Namespace),
+		runtimeclient.MatchingLabels{DRDrillNameLabel: drill.Name},
+	); err != nil {
+		return err
+	}
+	toKeep := drill.Spec.ReportsToKeep
+	if toKeep <= 0 {
+		toKeep = stork_api.DefaultDRDrillReportsToKeep
+	}
+	if len(reports.Items) <= toKeep {
+		return nil
+	}
+	sort.Slice(reports.Items, func(i, j int) bool {
+		return reports.Items[i].CreationTimestamp.Before(&reports.Items[j].CreationTimestamp)
+	})
+	for i := 0; i < len(reports.Items)-toKeep; i++ {
+		if err := d.client.Delete(ctx, &reports.Items[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// getLatestSuccessfulBackup returns the latest successful backup triggered
+// by the backup schedule
+func getLatestSuccessfulBackup(scheduleName string, namespace string) (*stork_api.ApplicationBackup, error) {
+	backupSchedule, err := storkops.Instance().GetApplicationBackupSchedule(scheduleName, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting backup schedule %v: %v", scheduleName, err)
+	}
+	var latest *stork_api.ScheduledApplicationBackupStatus
+	for _, items := range backupSchedule.Status.Items {
+		for _, item := range items {
+			if item.Status != stork_api.ApplicationBackupStatusSuccessful {
+				continue
+			}
+			if latest == nil || latest.CreationTimestamp.Before(&item.CreationTimestamp) {
+				latest = item
+			}
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no successful backups found for backup schedule %v", scheduleName)
+	}
+	return storkops.Instance().GetApplicationBackup(latest.Name, namespace)
+}
+
+func (d *DRDrillController) createCRD() error {
+	return crds.Register(
+		reflect.TypeOf(stork_api.DRDrill{}).Name(),
+		reflect.TypeOf(stork_api.DRDrillReport{}).Name(),
+	)
+}
@@ -0,0 +1,398 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testDrillUID = "drill-uid"
+
+func newDRDrill() *storkapi.DRDrill {
+	return &storkapi.DRDrill{
+		ObjectMeta: metav1.ObjectMeta{Name: "drill", Namespace: "test", UID: testDrillUID},
+		Spec: storkapi.DRDrillSpec{
+			SchedulePolicyName: "hourly",
+			BackupScheduleName: "backupschedule",
+		},
+	}
+}
+
+// newDRDrillController returns a controller with the drill and objects in its
+// client. The stork objects are added to the stork client and the kube
+// objects to the kubernetes client.
+func newDRDrillController(
+	t *testing.T,
+	drill *storkapi.DRDrill,
+	objects []runtimeclient.Object,
+	storkObjects []runtime.Object,
+	kubeObjects ...runtime.Object,
+) *DRDrillController {
+	scheme := runtime.NewScheme()
+	require.NoError(t, storkapi.AddToScheme(scheme))
+	kubeClient := fakek8s.NewSimpleClientset(kubeObjects...)
+	core.SetInstance(core.New(kubeClient))
+	apps.SetInstance(apps.New(kubeClient.AppsV1(), kubeClient.CoreV1()))
+	policy := &storkapi.SchedulePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "hourly"},
+		Policy: storkapi.SchedulePolicyItem{
+			Interval: &storkapi.IntervalPolicy{IntervalMinutes: 60},
+		},
+	}
+	storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(append(storkObjects, policy)...), nil))
+	return &DRDrillController{
+		client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(append(objects, drill)...).Build(),
+		kubeClient: kubeClient,
+		recorder:   record.NewFakeRecorder(10),
+	}
+}
+
+// creationTimestampClient sets the creation timestamp of created objects like
+// the API server does, which the fake client doesn't
+type creationTimestampClient struct {
+	runtimeclient.Client
+}
+
+func (c creationTimestampClient) Create(ctx context.Context, obj runtimeclient.Object, opts ...runtimeclient.CreateOption) error {
+	obj.SetCreationTimestamp(metav1.Now())
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// handleDrill runs the controller once for the drill and returns the updated
+// drill
+func handleDrill(t *testing.T, d *DRDrillController) *storkapi.DRDrill {
+	drill := &storkapi.DRDrill{}
+	key := types.NamespacedName{Name: "drill", Namespace: "test"}
+	require.NoError(t, d.client.Get(context.TODO(), key, drill))
+	require.NoError(t, d.handle(context.TODO(), drill))
+	updated := &storkapi.DRDrill{}
+	require.NoError(t, d.client.Get(context.TODO(), key, updated))
+	return updated
+}
+
+func TestDRDrillTrigger(t *testing.T) {
+	d := newDRDrillController(t, newDRDrill(), nil, nil)
+	drill := handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageRecover, drill.Status.Stage, "First run should be triggered right away")
+	require.Equal(t, storkapi.DRDrillStatusInProgress, drill.Status.Status)
+	require.Equal(t, storkapi.SchedulePolicyTypeInterval, drill.Status.PolicyType)
+	require.False(t, drill.Status.TriggerTimestamp.IsZero())
+
+	// Completed runs are triggered again once the interval has passed
+	for _, test := range []struct {
+		name        string
+		lastTrigger time.Time
+		suspend     bool
+		triggered   bool
+	}{
+		{"interval passed", time.Now().Add(-2 * time.Hour), false, true},
+		{"interval not passed", time.Now().Add(-time.Minute), false, false},
+		{"suspended", time.Now().Add(-2 * time.Hour), true, false},
+	} {
+		drill := newDRDrill()
+		drill.Spec.Suspend = &test.suspend
+		drill.Status = storkapi.DRDrillStatus{
+			Stage:            storkapi.DRDrillStageFinal,
+			Status:           storkapi.DRDrillStatusSuccessful,
+			TriggerTimestamp: metav1.NewTime(test.lastTrigger),
+			LastReport:       "report",
+		}
+		d := newDRDrillController(t, drill, nil, nil)
+		drill = handleDrill(t, d)
+		if test.triggered {
+			require.Equal(t, storkapi.DRDrillStageRecover, drill.Status.Stage, test.name)
+			require.True(t, drill.Status.TriggerTimestamp.After(test.lastTrigger), test.name)
+			require.Equal(t, "report", drill.Status.LastReport, "Last report should be kept while the next run is in progress")
+		} else {
+			require.Equal(t, storkapi.DRDrillStageFinal, drill.Status.Stage, test.name)
+		}
+	}
+}
+
+func TestDRDrillTriggerErrors(t *testing.T) {
+	drill := newDRDrill()
+	drill.Spec.SchedulePolicyName = "missing"
+	d := newDRDrillController(t, drill, nil, nil)
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageInitial, drill.Status.Stage, "Drill shouldn't start without its policy")
+	require.Len(t, d.recorder.(*record.FakeRecorder).Events, 1)
+
+	drill = newDRDrill()
+	drill.Spec.MigratedNamespaces = []string{"app"}
+	d = newDRDrillController(t, drill, nil, nil)
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageTeardown, drill.Status.Stage)
+	require.Equal(t, storkapi.DRDrillStatusFailed, drill.Status.Status)
+	require.Contains(t, drill.Status.Reason, "Exactly one of")
+}
+
+func TestDRDrillRecoverFromBackup(t *testing.T) {
+	now := time.Now()
+	backupSchedule := &storkapi.ApplicationBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "backupschedule", Namespace: "test"},
+		Status: storkapi.ApplicationBackupScheduleStatus{
+			Items: map[storkapi.SchedulePolicyType][]*storkapi.ScheduledApplicationBackupStatus{
+				storkapi.SchedulePolicyTypeInterval: {
+					{Name: "old", CreationTimestamp: metav1.NewTime(now.Add(-2 * time.Hour)), Status: storkapi.ApplicationBackupStatusSuccessful},
+					{Name: "failed", CreationTimestamp: metav1.NewTime(now), Status: storkapi.ApplicationBackupStatusFailed},
+				},
+				storkapi.SchedulePolicyTypeDaily: {
+					{Name: "latest", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)), Status: storkapi.ApplicationBackupStatusSuccessful},
+				},
+			},
+		},
+	}
+	backup := &storkapi.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "latest", Namespace: "test"},
+		Spec:       storkapi.ApplicationBackupSpec{BackupLocation: "location"},
+	}
+	drill := newDRDrill()
+	drill.Spec.HealthCheckTimeout = &metav1.Duration{Duration: 10 * time.Minute}
+	drill.Status = storkapi.DRDrillStatus{
+		Stage:            storkapi.DRDrillStageRecover,
+		Status:           storkapi.DRDrillStatusInProgress,
+		TriggerTimestamp: metav1.NewTime(now),
+	}
+	d := newDRDrillController(t, drill, nil, []runtime.Object{backupSchedule, backup})
+
+	drill = handleDrill(t, d)
+	require.Equal(t, "latest", drill.Status.BackupName, "Latest successful backup should be restored")
+	require.Equal(t, getRunName(drill), drill.Status.RestoreName)
+	restore, err := storkops.Instance().GetApplicationRestore(drill.Status.RestoreName, "test")
+	require.NoError(t, err)
+	require.True(t, restore.Spec.Sandbox)
+	require.Equal(t, "latest", restore.Spec.BackupName)
+	require.Equal(t, "location", restore.Spec.BackupLocation)
+	require.Equal(t, 20*time.Minute, restore.Spec.SandboxTTL.Duration)
+	require.Equal(t, testDrillUID, string(restore.OwnerReferences[0].UID))
+
+	// Wait for the restore to finish
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageRecover, drill.Status.Stage)
+
+	restore.Spec.NamespaceMapping = map[string]string{"b": "b-sandbox", "a": "a-sandbox"}
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.Status = storkapi.ApplicationRestoreStatusSuccessful
+	_, err = storkops.Instance().UpdateApplicationRestore(restore)
+	require.NoError(t, err)
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageHealthCheck, drill.Status.Stage)
+	require.Equal(t, []string{"a-sandbox", "b-sandbox"}, drill.Status.Namespaces)
+}
+
+func TestDRDrillRecoverFromBackupFailed(t *testing.T) {
+	drill := newDRDrill()
+	drill.Status.Stage = storkapi.DRDrillStageRecover
+	d := newDRDrillController(t, drill, nil, []runtime.Object{&storkapi.ApplicationBackupSchedule{
+		ObjectMeta: metav1.ObjectMeta{Name: "backupschedule", Namespace: "test"},
+	}})
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStatusFailed, drill.Status.Status)
+	require.Equal(t, storkapi.DRDrillStageTeardown, drill.Status.Stage)
+	require.Contains(t, drill.Status.Reason, "no successful backups")
+
+	drill = newDRDrill()
+	drill.Status.Stage = storkapi.DRDrillStageRecover
+	drill.Status.RestoreName = "restore"
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test"},
+		Status: storkapi.ApplicationRestoreStatus{
+			Stage:  storkapi.ApplicationRestoreStageFinal,
+			Status: storkapi.ApplicationRestoreStatusFailed,
+			Reason: "volume error",
+		},
+	}
+	d = newDRDrillController(t, drill, nil, []runtime.Object{restore})
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStatusFailed, drill.Status.Status)
+	require.Contains(t, drill.Status.Reason, "volume error")
+}
+
+func newTestDeployment(name string, namespace string, replicas int32, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+	}
+}
+
+func TestDRDrillHealthCheck(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		ready       int32
+		triggerAge  time.Duration
+		stage       storkapi.DRDrillStageType
+		status      storkapi.DRDrillStatusType
+		checkReason string
+	}{
+		{"ready", 2, time.Minute, storkapi.DRDrillStageTeardown, storkapi.DRDrillStatusSuccessful, ""},
+		{"not ready", 1, time.Minute, storkapi.DRDrillStageHealthCheck, storkapi.DRDrillStatusInProgress, "1/2 replicas ready"},
+		{"timed out", 1, time.Hour, storkapi.DRDrillStageTeardown, storkapi.DRDrillStatusFailed, "1/2 replicas ready"},
+	} {
+		drill := newDRDrill()
+		drill.Status = storkapi.DRDrillStatus{
+			Stage:            storkapi.DRDrillStageHealthCheck,
+			Status:           storkapi.DRDrillStatusInProgress,
+			TriggerTimestamp: metav1.NewTime(time.Now().Add(-test.triggerAge)),
+			Namespaces:       []string{"sandbox"},
+		}
+		statefulSet := &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "sandbox"},
+			Status:     appsv1.StatefulSetStatus{ReadyReplicas: 1},
+		}
+		d := newDRDrillController(t, drill, nil, nil,
+			newTestDeployment("web", "sandbox", 2, test.ready),
+			newTestDeployment("other", "other", 1, 0),
+			statefulSet,
+		)
+		drill = handleDrill(t, d)
+		require.Equal(t, test.stage, drill.Status.Stage, test.name)
+		require.Equal(t, test.status, drill.Status.Status, test.name)
+		require.Len(t, drill.Status.Checks, 2, "Only workloads in the sandbox namespaces should be checked")
+		require.Equal(t, "Deployment", drill.Status.Checks[0].Kind)
+		require.Equal(t, test.checkReason, drill.Status.Checks[0].Reason, test.name)
+		require.Equal(t, "StatefulSet", drill.Status.Checks[1].Kind)
+		require.True(t, drill.Status.Checks[1].Ready, "StatefulSet without replicas should need one ready replica")
+		if test.status == storkapi.DRDrillStatusSuccessful {
+			require.NotNil(t, drill.Status.RecoveryTime)
+			require.InDelta(t, test.triggerAge.Seconds(), drill.Status.RecoveryTime.Seconds(), 5)
+		} else {
+			require.Nil(t, drill.Status.RecoveryTime, test.name)
+		}
+	}
+}
+
+func TestDRDrillTeardownReport(t *testing.T) {
+	trigger := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	drill := newDRDrill()
+	drill.Spec.ReportsToKeep = 2
+	drill.Status = storkapi.DRDrillStatus{
+		Stage:            storkapi.DRDrillStageTeardown,
+		Status:           storkapi.DRDrillStatusSuccessful,
+		Reason:           "Applications recovered in 5m0s",
+		TriggerTimestamp: trigger,
+		BackupName:       "backup",
+		RestoreName:      "restore",
+		RecoveryTime:     &metav1.Duration{Duration: 5 * time.Minute},
+		Checks:           []*storkapi.DRDrillCheck{{Kind: "Deployment", Name: "web", Namespace: "sandbox", Ready: true}},
+	}
+	oldReport := func(name string, age time.Duration) runtimeclient.Object {
+		return &storkapi.DRDrillReport{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "test",
+			Labels:            map[string]string{DRDrillNameLabel: "drill"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		}}
+	}
+	otherDrillReport := &storkapi.DRDrillReport{ObjectMeta: metav1.ObjectMeta{
+		Name:              "other",
+		Namespace:         "test",
+		Labels:            map[string]string{DRDrillNameLabel: "other"},
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-72 * time.Hour)),
+	}}
+	restore := &storkapi.ApplicationRestore{ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test"}}
+	d := newDRDrillController(t, drill,
+		[]runtimeclient.Object{oldReport("oldest", 48*time.Hour), oldReport("old", 24*time.Hour), otherDrillReport},
+		[]runtime.Object{restore},
+	)
+	d.client = creationTimestampClient{d.client}
+
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageFinal, drill.Status.Stage)
+	require.Equal(t, getRunName(drill), drill.Status.LastReport)
+	_, err := storkops.Instance().GetApplicationRestore("restore", "test")
+	require.True(t, errors.IsNotFound(err), "Restore should be deleted with its sandbox")
+
+	report := &storkapi.DRDrillReport{}
+	require.NoError(t, d.client.Get(context.TODO(), types.NamespacedName{Name: drill.Status.LastReport, Namespace: "test"}, report))
+	require.Equal(t, "drill", report.Labels[DRDrillNameLabel])
+	require.Equal(t, testDrillUID, string(report.OwnerReferences[0].UID))
+	require.Equal(t, "drill", report.Report.DrillName)
+	require.Equal(t, storkapi.DRDrillStatusSuccessful, report.Report.Status)
+	require.Equal(t, "Applications recovered in 5m0s", report.Report.Reason)
+	require.Equal(t, "backup", report.Report.BackupName)
+	require.True(t, trigger.Equal(&report.Report.TriggerTimestamp))
+	require.True(t, report.Report.FinishTimestamp.After(trigger.Time))
+	require.Equal(t, 5*time.Minute, report.Report.RecoveryTime.Duration)
+	require.Len(t, report.Report.Checks, 1)
+
+	reports := &storkapi.DRDrillReportList{}
+	require.NoError(t, d.client.List(context.TODO(), reports, runtimeclient.InNamespace("test")))
+	names := make([]string, 0)
+	for _, r := range reports.Items {
+		names = append(names, r.Name)
+	}
+	require.ElementsMatch(t, []string{"old", drill.Status.LastReport, "other"}, names,
+		"Oldest report of the drill should be pruned and reports of other drills kept")
+}
+
+func TestDRDrillTeardownClones(t *testing.T) {
+	drill := newDRDrill()
+	drill.Spec.BackupScheduleName = ""
+	drill.Spec.MigratedNamespaces = []string{"app"}
+	drill.Status = storkapi.DRDrillStatus{
+		Stage:            storkapi.DRDrillStageTeardown,
+		Status:           storkapi.DRDrillStatusFailed,
+		Reason:           "Timed out waiting for applications to become ready",
+		TriggerTimestamp: metav1.NewTime(time.Now()),
+		CloneNames:       []string{"clone"},
+		Namespaces:       []string{"app-sandbox", "not-sandbox"},
+	}
+	sandbox := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "app-sandbox",
+		Labels: map[string]string{sandboxOwnerLabel: testDrillUID},
+	}}
+	notSandbox := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "not-sandbox"}}
+	clone := &storkapi.ApplicationClone{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "test"}}
+	d := newDRDrillController(t, drill, nil, []runtime.Object{clone}, sandbox, notSandbox)
+
+	drill = handleDrill(t, d)
+	require.Equal(t, storkapi.DRDrillStageFinal, drill.Status.Stage)
+	require.Equal(t, storkapi.DRDrillStatusFailed, drill.Status.Status)
+	_, err := storkops.Instance().GetApplicationClone("clone", "test")
+	require.True(t, errors.IsNotFound(err), "Clone should be deleted")
+	_, err = core.Instance().GetNamespace("app-sandbox")
+	require.True(t, errors.IsNotFound(err), "Sandbox namespace should be deleted")
+	_, err = core.Instance().GetNamespace("not-sandbox")
+	require.NoError(t, err, "Namespaces that aren't sandboxes of the drill shouldn't be deleted")
+
+	report := &storkapi.DRDrillReport{}
+	require.NoError(t, d.client.Get(context.TODO(), types.NamespacedName{Name: drill.Status.LastReport, Namespace: "test"}, report))
+	require.Equal(t, storkapi.DRDrillStatusFailed, report.Report.Status)
+	require.Equal(t, []string{"app"}, report.Report.MigratedNamespaces)
+	require.Nil(t, report.Report.RecoveryTime)
+}
+
+func TestGetMigrationReplicas(t *testing.T) {
+	replicas, ok, err := getMigrationReplicas(map[string]string{})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	replicas, ok, err = getMigrationReplicas(map[string]string{"stork.libopenstorage.org/migrationReplicas": "3"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int32(3), replicas)
+
+	_, _, err = getMigrationReplicas(map[string]string{"stork.libopenstorage.org/migrationReplicas": "three"})
+	require.Error(t, err)
+}
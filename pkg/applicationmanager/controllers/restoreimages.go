@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/imagecheck"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// objectImages are the images of a backed up workload
+type objectImages struct {
+	storkapi.ObjectInfo `json:",inline"`
+	Images              []string `json:"images"`
+}
+
+// getObjectImages returns the images of the object, or nil if it doesn't run
+// any pods
+func getObjectImages(object runtime.Unstructured, registrations []storkapi.ApplicationRegistration) (*objectImages, error) {
+	images, err := imagecheck.GetImages(object, registrations)
+	if err != nil || len(images) == 0 {
+		return nil, err
+	}
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	info := storkapi.ObjectInfo{
+		Name:             metadata.GetName(),
+		Namespace:        metadata.GetNamespace(),
+		GroupVersionKind: metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+	}
+	if info.Group == "" {
+		info.Group = "core"
+	}
+	return &objectImages{ObjectInfo: info, Images: images}, nil
+}
+
+// mapRegistries replaces the registries of the images of the restored
+// objects with the RegistryMapping of the restore
+func (a *ApplicationRestoreController) mapRegistries(
+	restore *storkapi.ApplicationRestore,
+	objects []runtime.Unstructured,
+) error {
+	registries, err := controllers.GetRegistryMapping(context.TODO(), a.client, restore.Namespace, restore.Spec.RegistryMappingName)
+	if err != nil || len(registries) == 0 {
+		return err
+	}
+	registrations, err := storkops.Instance().ListApplicationRegistrations()
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err := imagecheck.MapRegistries(o, registrations.Items, registries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getRestoredImages returns the images of the workloads in the backup, with
+// their registries mapped like they are when the workloads are restored. The
+// images recorded by the backup are used so that the resources don't need to
+// be downloaded. They are only read from the resources for backups taken
+// before the images were recorded.
+func (a *ApplicationRestoreController) getRestoredImages(restore *storkapi.ApplicationRestore) ([]string, error) {
+	registries, err := controllers.GetRegistryMapping(context.TODO(), a.client, restore.Namespace, restore.Spec.RegistryMappingName)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting backup: %v", err)
+	}
+	data, err := a.downloadObject(backup, restore.Spec.BackupLocation, restore.GetBackupLocationNamespace(), imagesObjectName, true)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		backupImages := make([]objectImages, 0)
+		if err := json.Unmarshal(data, &backupImages); err != nil {
+			return nil, err
+		}
+		return getMappedImages(backupImages, restore, registries), nil
+	}
+
+	objects, err := a.getObjectsToRestore(restore)
+	if err != nil {
+		return nil, err
+	}
+	registrations, err := storkops.Instance().ListApplicationRegistrations()
+	if err != nil {
+		return nil, err
+	}
+	images := make([]string, 0)
+	for _, o := range objects {
+		if err := imagecheck.MapRegistries(o, registrations.Items, registries); err != nil {
+			return nil, err
+		}
+		objectImages, err := imagecheck.GetImages(o, registrations.Items)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, objectImages...)
+	}
+	return images, nil
+}
+
+// getMappedImages returns the images of the objects that are restored, with
+// their registries mapped
+func getMappedImages(
+	backupImages []objectImages,
+	restore *storkapi.ApplicationRestore,
+	registries map[string]string,
+) []string {
+	includeObjects := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+	images := make([]string, 0)
+	for _, o := range backupImages {
+		if _, ok := restore.Spec.NamespaceMapping[o.Namespace]; !ok {
+			continue
+		}
+		if len(includeObjects) != 0 && !includeObjects[o.ObjectInfo] {
+			continue
+		}
+		for _, image := range o.Images {
+			images = append(images, imagecheck.MapRegistry(image, registries))
+		}
+	}
+	return images
+}
+
+// checkImages makes sure the images of the restored workloads can be pulled
+// on the destination. The missing images are recorded in the status and,
+// depending on the image check policy, fail the restore. Returns false if the
+// restore can't continue.
+func (a *ApplicationRestoreController) checkImages(restore *storkapi.ApplicationRestore) (bool, error) {
+	if restore.Spec.ImageCheck == nil {
+		return true, nil
+	}
+	images, err := a.getRestoredImages(restore)
+	if err != nil {
+		return false, err
+	}
+	return a.checkRestoredImages(restore, images)
+}
+
+// checkRestoredImages checks the images of the restored workloads against
+// the images pulled on the nodes and, if allowed, their registries
+func (a *ApplicationRestoreController) checkRestoredImages(restore *storkapi.ApplicationRestore, images []string) (bool, error) {
+	if len(images) == 0 {
+		return true, nil
+	}
+	nodes, err := a.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("error listing nodes: %v", err)
+	}
+	missing := imagecheck.NewChecker(
+		nodes.Items,
+		restore.Spec.ImageCheck.QueryRegistry,
+		storkconfig.GetImageCheckRegistries(),
+	).Missing(images)
+	restore.Status.MissingImages = missing
+	if len(missing) == 0 {
+		return true, nil
+	}
+
+	message := fmt.Sprintf("Images of restored workloads aren't available on the destination: %v",
+		strings.Join(missing, ", "))
+	if !restore.Spec.ImageCheck.FailOnMissing {
+		log.ApplicationRestoreLog(restore).Warnf("%v", message)
+		a.recorder.Event(restore,
+			v1.EventTypeWarning,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+		return true, nil
+	}
+
+	log.ApplicationRestoreLog(restore).Errorf("%v", message)
+	a.recorder.Event(restore,
+		v1.EventTypeWarning,
+		string(storkapi.ApplicationRestoreStatusFailed),
+		message)
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+	restore.Status.Reason = message
+	restore.Status.FinishTimestamp = metav1.Now()
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return false, a.client.Update(context.TODO(), restore)
+}
@@ -0,0 +1,163 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newImageCheckRestore(failOnMissing bool) *storkapi.ApplicationRestore {
+	return &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test"},
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"src": "test"},
+			ImageCheck:       &storkapi.ImageCheckPolicy{FailOnMissing: failOnMissing},
+		},
+		Status: storkapi.ApplicationRestoreStatus{Stage: storkapi.ApplicationRestoreStageInitial},
+	}
+}
+
+func newImageCheckController(t *testing.T, restore *storkapi.ApplicationRestore) *ApplicationRestoreController {
+	scheme := runtime.NewScheme()
+	require.NoError(t, storkapi.AddToScheme(scheme))
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Status: v1.NodeStatus{Images: []v1.ContainerImage{
+			{Names: []string{"mirror.local/library/nginx:1.21"}},
+		}},
+	}
+	return &ApplicationRestoreController{
+		client:     fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore).Build(),
+		recorder:   record.NewFakeRecorder(10),
+		kubeClient: fakek8s.NewSimpleClientset(node),
+	}
+}
+
+func TestGetMappedImages(t *testing.T) {
+	backupImages := []objectImages{
+		{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:             "web",
+				Namespace:        "src",
+				GroupVersionKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			},
+			Images: []string{"nginx:1.21", "quay.io/org/sidecar:v1"},
+		},
+		{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:             "db",
+				Namespace:        "src",
+				GroupVersionKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+			},
+			Images: []string{"postgres:14"},
+		},
+		{
+			ObjectInfo: storkapi.ObjectInfo{
+				Name:             "other",
+				Namespace:        "other",
+				GroupVersionKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			},
+			Images: []string{"redis:7"},
+		},
+	}
+	restore := newImageCheckRestore(false)
+	registries := map[string]string{"docker.io": "mirror.local"}
+
+	images := getMappedImages(backupImages, restore, registries)
+	require.Equal(t, []string{
+		"mirror.local/library/nginx:1.21",
+		"quay.io/org/sidecar:v1",
+		"mirror.local/library/postgres:14",
+	}, images, "images of namespaces that aren't restored should be skipped")
+
+	restore.Spec.IncludeResources = []storkapi.ObjectInfo{{
+		Name:             "db",
+		Namespace:        "src",
+		GroupVersionKind: metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	}}
+	images = getMappedImages(backupImages, restore, registries)
+	require.Equal(t, []string{"mirror.local/library/postgres:14"}, images,
+		"images of resources that aren't included should be skipped")
+}
+
+func TestCheckRestoredImages(t *testing.T) {
+	restore := newImageCheckRestore(false)
+	a := newImageCheckController(t, restore)
+	proceed, err := a.checkRestoredImages(restore, []string{"mirror.local/library/nginx:1.21"})
+	require.NoError(t, err)
+	require.True(t, proceed)
+	require.Empty(t, restore.Status.MissingImages)
+
+	proceed, err = a.checkRestoredImages(restore, []string{"mirror.local/library/nginx:1.21", "redis:7"})
+	require.NoError(t, err)
+	require.True(t, proceed, "missing images should only be reported without FailOnMissing")
+	require.Equal(t, []string{"redis:7"}, restore.Status.MissingImages)
+	require.Equal(t, storkapi.ApplicationRestoreStageInitial, restore.Status.Stage)
+
+	restore = newImageCheckRestore(true)
+	a = newImageCheckController(t, restore)
+	proceed, err = a.checkRestoredImages(restore, []string{"redis:7"})
+	require.NoError(t, err)
+	require.False(t, proceed)
+
+	updated := &storkapi.ApplicationRestore{}
+	require.NoError(t, a.client.Get(context.TODO(), types.NamespacedName{Namespace: "test", Name: "restore"}, updated))
+	require.Equal(t, storkapi.ApplicationRestoreStageFinal, updated.Status.Stage)
+	require.Equal(t, storkapi.ApplicationRestoreStatusFailed, updated.Status.Status)
+	require.Equal(t, []string{"redis:7"}, updated.Status.MissingImages)
+}
+
+func TestMapRegistries(t *testing.T) {
+	restore := newImageCheckRestore(false)
+	restore.Spec.RegistryMappingName = "mirror"
+	mapping := &storkapi.RegistryMapping{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror", Namespace: "test"},
+		Spec:       storkapi.RegistryMappingSpec{Registries: map[string]string{"docker.io": "mirror.local"}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, storkapi.AddToScheme(scheme))
+	a := &ApplicationRestoreController{
+		client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore, mapping).Build(),
+	}
+	storkops.SetInstance(storkops.New(fakek8s.NewSimpleClientset(), fakestorkclient.NewSimpleClientset(), nil))
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "test"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{"name": "web", "image": "nginx:1.21"},
+						map[string]interface{}{"name": "sidecar", "image": "quay.io/org/sidecar:v1"},
+					},
+				},
+			},
+		},
+	}}
+	require.NoError(t, a.mapRegistries(restore, []runtime.Unstructured{deployment}))
+	containers, _, err := unstructured.NestedSlice(deployment.Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	require.Equal(t, "mirror.local/library/nginx:1.21", containers[0].(map[string]interface{})["image"])
+	require.Equal(t, "quay.io/org/sidecar:v1", containers[1].(map[string]interface{})["image"],
+		"images from registries that aren't mapped shouldn't change")
+
+	restore.Spec.RegistryMappingName = "missing"
+	require.Error(t, a.mapRegistries(restore, []runtime.Unstructured{deployment}))
+}
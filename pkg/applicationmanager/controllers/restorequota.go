@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/quota"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// getObjectsToRestore downloads the resources in the backup and prepares
+// them like they are when applied, so that they can be checked before
+// anything is restored. The PVs aren't mapped to the restored volumes yet.
+func (a *ApplicationRestoreController) getObjectsToRestore(
+	restore *storkapi.ApplicationRestore,
+) ([]runtime.Unstructured, error) {
+	backup, err := storkops.Instance().GetApplicationBackup(restore.Spec.BackupName, restore.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting backup: %v", err)
+	}
+	objects, err := a.downloadResources(backup, restore.Spec.BackupLocation, restore.GetBackupLocationNamespace())
+	if err != nil {
+		return nil, fmt.Errorf("error downloading resources: %v", err)
+	}
+
+	objectMap := storkapi.CreateObjectsMap(restore.Spec.IncludeResources)
+	prepared := make([]runtime.Unstructured, 0, len(objects))
+	for _, o := range objects {
+		skip, err := a.resourceCollector.PrepareResourceForApply(
+			o,
+			objects,
+			objectMap,
+			restore.Spec.NamespaceMapping,
+			restore.Spec.StorageClassMapping,
+			nil,
+			restore.Spec.IncludeOptionalResourceTypes,
+			nil,
+			restore.Spec.JobPolicy,
+			restore.Spec.CertificatePolicy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !skip {
+			prepared = append(prepared, o)
+		}
+	}
+	return prepared, nil
+}
+
+// getQuotaDeltas returns the ResourceQuotas in the destination namespaces of
+// the restore that are too small for the resources in the backup, along with
+// the quotas themselves. Resources that already exist don't use more of the
+// quotas, so they aren't counted.
+func (a *ApplicationRestoreController) getQuotaDeltas(
+	restore *storkapi.ApplicationRestore,
+) ([]quota.Delta, []v1.ResourceQuota, error) {
+	quotas := make([]v1.ResourceQuota, 0)
+	quotaNamespaces := make(map[string]bool)
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		list, err := a.kubeClient.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("error listing quotas in namespace %v: %v", namespace, err)
+		}
+		if len(list.Items) != 0 {
+			quotas = append(quotas, list.Items...)
+			quotaNamespaces[namespace] = true
+		}
+	}
+	if len(quotas) == 0 {
+		return nil, nil, nil
+	}
+
+	objects, err := a.getObjectsToRestore(restore)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created := make([]runtime.Unstructured, 0)
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !quotaNamespaces[metadata.GetNamespace()] {
+			continue
+		}
+		exists, err := a.resourceCollector.ObjectExists(a.dynamicInterface, o)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking if %v %v/%v exists: %v",
+				o.GetObjectKind().GroupVersionKind().Kind, metadata.GetNamespace(), metadata.GetName(), err)
+		}
+		if !exists {
+			created = append(created, o)
+		}
+	}
+
+	requirements, err := quota.GetRequirements(created)
+	if err != nil {
+		return nil, nil, err
+	}
+	return quota.Check(quotas, requirements), quotas, nil
+}
+
+// checkQuotas makes sure the ResourceQuotas in the destination namespaces
+// have enough headroom for the restore. Depending on the quota policy, quotas
+// that are too small are either raised or fail the restore with the deltas
+// needed. Returns false if the restore can't continue.
+func (a *ApplicationRestoreController) checkQuotas(restore *storkapi.ApplicationRestore) (bool, error) {
+	policy := restore.Spec.QuotaPolicy
+	if policy != storkapi.ApplicationRestoreQuotaPolicyFail && policy != storkapi.ApplicationRestoreQuotaPolicyAdjust {
+		return true, nil
+	}
+	deltas, quotas, err := a.getQuotaDeltas(restore)
+	if err != nil {
+		return false, err
+	}
+	if len(deltas) == 0 {
+		return true, nil
+	}
+
+	messages := make([]string, 0, len(deltas))
+	for i := range deltas {
+		messages = append(messages, deltas[i].String())
+	}
+	message := strings.Join(messages, "; ")
+
+	// Raising quotas is reserved to the admin since it bypasses the limits
+	// set on the namespaces
+	if policy == storkapi.ApplicationRestoreQuotaPolicyAdjust && restore.Namespace == a.restoreAdminNamespace {
+		for i := range quotas {
+			if !quota.Adjust(&quotas[i], deltas) {
+				continue
+			}
+			if _, err := a.kubeClient.CoreV1().ResourceQuotas(quotas[i].Namespace).Update(
+				context.TODO(), &quotas[i], metav1.UpdateOptions{}); err != nil {
+				return false, fmt.Errorf("error updating quota %v/%v: %v", quotas[i].Namespace, quotas[i].Name, err)
+			}
+		}
+		message = fmt.Sprintf("Raised quotas for restore: %v", message)
+		log.ApplicationRestoreLog(restore).Infof(message)
+		a.recorder.Event(restore,
+			v1.EventTypeNormal,
+			string(storkapi.ApplicationRestoreStatusInProgress),
+			message)
+		return true, nil
+	}
+
+	if policy == storkapi.ApplicationRestoreQuotaPolicyAdjust {
+		message = fmt.Sprintf("Quotas can only be raised by restores in the admin namespace: %v", message)
+	} else {
+		message = fmt.Sprintf("Quotas are too small for restore: %v", message)
+	}
+	log.ApplicationRestoreLog(restore).Errorf(message)
+	a.recorder.Event(restore,
+		v1.EventTypeWarning,
+		string(storkapi.ApplicationRestoreStatusFailed),
+		message)
+	restore.Status.Stage = storkapi.ApplicationRestoreStageFinal
+	restore.Status.Status = storkapi.ApplicationRestoreStatusFailed
+	restore.Status.Reason = message
+	restore.Status.FinishTimestamp = metav1.Now()
+	restore.Status.LastUpdateTimestamp = metav1.Now()
+	return false, a.client.Update(context.TODO(), restore)
+}
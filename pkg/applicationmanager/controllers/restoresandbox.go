@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// sandboxOwnerLabel is set on sandbox namespaces to the UID of the
+	// restore or DR drill they were created for
+	sandboxOwnerLabel = "stork.libopenstorage.org/sandbox-owner-uid"
+	// sandboxNetworkPolicyName is the name of the NetworkPolicy created in
+	// sandbox namespaces to isolate them
+	sandboxNetworkPolicyName = "stork-sandbox-isolation"
+	// Length of the ID used in the names of sandbox namespaces
+	sandboxIDLength = 8
+	// namespaceNameLabel is set by Kubernetes on every namespace to its name
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// getSandboxNamespace returns the name of the sandbox namespace that the
+// source namespace is recovered to. The ID makes the name unique.
+func getSandboxNamespace(sourceNamespace string, id string) string {
+	if len(id) > sandboxIDLength {
+		id = id[:sandboxIDLength]
+	}
+	suffix := "-sandbox-" + id
+	if maxLen := 63 - len(suffix); len(sourceNamespace) > maxLen {
+		sourceNamespace = sourceNamespace[:maxLen]
+	}
+	return sourceNamespace + suffix
+}
+
+// setSandboxNamespaceMapping maps all the namespaces being restored to
+// sandbox namespaces
+func setSandboxNamespaceMapping(restore *storkapi.ApplicationRestore) {
+	for sourceNamespace := range restore.Spec.NamespaceMapping {
+		restore.Spec.NamespaceMapping[sourceNamespace] = getSandboxNamespace(sourceNamespace, string(restore.UID))
+	}
+}
+
+// setupSandbox creates the sandbox namespaces and isolates them from the
+// rest of the cluster
+func (a *ApplicationRestoreController) setupSandbox(restore *storkapi.ApplicationRestore) error {
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		if err := createSandboxNamespace(namespace, string(restore.UID)); err != nil {
+			return err
+		}
+		if err := isolateSandboxNamespace(a.kubeClient, namespace, string(restore.UID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createSandboxNamespace creates a namespace labeled with the UID of its
+// owner. Namespaces that already exist are only used if they were created
+// for the same owner, others are never turned into sandboxes since they
+// would be deleted with them.
+func createSandboxNamespace(namespace string, ownerUID string) error {
+	_, err := core.Instance().CreateNamespace(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   namespace,
+			Labels: map[string]string{sandboxOwnerLabel: ownerUID},
+		},
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating sandbox namespace %v: %v", namespace, err)
+	}
+	ns, err := core.Instance().GetNamespace(namespace)
+	if err != nil {
+		return fmt.Errorf("error getting sandbox namespace %v: %v", namespace, err)
+	}
+	if ns.Labels[sandboxOwnerLabel] != ownerUID {
+		return fmt.Errorf("namespace %v already exists and wasn't created as a sandbox", namespace)
+	}
+	return nil
+}
+
+// keepSandboxOwner adds the sandbox owner label of an existing namespace to
+// the labels it is being updated with, so that a sandbox namespace is still
+// deleted with its owner after its labels are restored
+func keepSandboxOwner(existing *v1.Namespace, labels map[string]string) map[string]string {
+	owner, ok := existing.Labels[sandboxOwnerLabel]
+	if !ok {
+		return labels
+	}
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[sandboxOwnerLabel] = owner
+	return labels
+}
+
+// isolateSandboxNamespace creates a NetworkPolicy so that pods in a sandbox
+// namespace can only talk to each other and to the cluster DNS. Since
+// policies are additive, any other NetworkPolicies in the namespace, like
+// the ones cloned from the source, are deleted so that they can't allow more
+// traffic.
+func isolateSandboxNamespace(kubeClient kubernetes.Interface, namespace string, ownerUID string) error {
+	ns, err := core.Instance().GetNamespace(namespace)
+	if err != nil {
+		return fmt.Errorf("error getting sandbox namespace %v: %v", namespace, err)
+	}
+	if ns.Labels[sandboxOwnerLabel] != ownerUID {
+		return fmt.Errorf("namespace %v wasn't created as a sandbox", namespace)
+	}
+	if _, err := kubeClient.NetworkingV1().NetworkPolicies(namespace).Create(
+		context.TODO(),
+		getSandboxNetworkPolicy(namespace),
+		metav1.CreateOptions{},
+	); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating network policy for sandbox namespace %v: %v", namespace, err)
+	}
+	policies, err := kubeClient.NetworkingV1().NetworkPolicies(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing network policies in sandbox namespace %v: %v", namespace, err)
+	}
+	for _, policy := range policies.Items {
+		if policy.Name == sandboxNetworkPolicyName {
+			continue
+		}
+		if err := kubeClient.NetworkingV1().NetworkPolicies(namespace).Delete(
+			context.TODO(),
+			policy.Name,
+			metav1.DeleteOptions{},
+		); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("error deleting network policy %v from sandbox namespace %v: %v", policy.Name, namespace, err)
+		}
+		logrus.Infof("Deleted network policy %v from sandbox namespace %v", policy.Name, namespace)
+	}
+	return nil
+}
+
+// isNetworkPolicy returns true if the object is a NetworkPolicy. They aren't
+// restored into sandbox namespaces since they could allow more traffic than
+// the isolation policy.
+func isNetworkPolicy(o runtime.Unstructured) bool {
+	return o.GetObjectKind().GroupVersionKind().Kind == "NetworkPolicy"
+}
+
+func getSandboxNetworkPolicy(namespace string) *networkingv1.NetworkPolicy {
+	udp := v1.ProtocolUDP
+	tcp := v1.ProtocolTCP
+	dnsPort := intstr.FromInt(53)
+	// The DNS pods of OpenShift listen on 5353
+	openshiftDNSPort := intstr.FromInt(5353)
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      sandboxNetworkPolicyName,
+			Namespace: namespace,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{},
+			PolicyTypes: []networkingv1.PolicyType{
+				networkingv1.PolicyTypeIngress,
+				networkingv1.PolicyTypeEgress,
+			},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{}},
+					},
+				},
+			},
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					To: []networkingv1.NetworkPolicyPeer{
+						{PodSelector: &metav1.LabelSelector{}},
+					},
+				},
+				{
+					// Only the DNS pods of Kubernetes and OpenShift
+					To: []networkingv1.NetworkPolicyPeer{
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{namespaceNameLabel: "kube-system"},
+							},
+							PodSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+							},
+						},
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{namespaceNameLabel: "openshift-dns"},
+							},
+						},
+					},
+					Ports: []networkingv1.NetworkPolicyPort{
+						{Protocol: &udp, Port: &dnsPort},
+						{Protocol: &tcp, Port: &dnsPort},
+						{Protocol: &udp, Port: &openshiftDNSPort},
+						{Protocol: &tcp, Port: &openshiftDNSPort},
+					},
+				},
+			},
+		},
+	}
+}
+
+// getSandboxExpiry returns the time after which the sandbox namespaces of the
+// restore are deleted
+func getSandboxExpiry(restore *storkapi.ApplicationRestore) metav1.Time {
+	ttl := storkapi.DefaultSandboxTTL
+	if restore.Spec.SandboxTTL != nil && restore.Spec.SandboxTTL.Duration > 0 {
+		ttl = restore.Spec.SandboxTTL.Duration
+	}
+	return metav1.NewTime(restore.CreationTimestamp.Add(ttl))
+}
+
+// deleteSandbox deletes the sandbox namespaces of the restore
+func (a *ApplicationRestoreController) deleteSandbox(restore *storkapi.ApplicationRestore) error {
+	for _, namespace := range restore.Spec.NamespaceMapping {
+		if err := deleteSandboxNamespace(namespace, string(restore.UID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteSandboxNamespace deletes a sandbox namespace. Namespaces that weren't
+// labeled for the owner are left alone.
+func deleteSandboxNamespace(namespace string, ownerUID string) error {
+	ns, err := core.Instance().GetNamespace(namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("error getting sandbox namespace %v: %v", namespace, err)
+	}
+	if ns.Labels[sandboxOwnerLabel] != ownerUID {
+		logrus.Warnf("Not deleting namespace %v since it wasn't created as a sandbox by %v", namespace, ownerUID)
+		return nil
+	}
+	if err := core.Instance().DeleteNamespace(namespace); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting sandbox namespace %v: %v", namespace, err)
+	}
+	logrus.Infof("Deleted sandbox namespace %v", namespace)
+	return nil
+}
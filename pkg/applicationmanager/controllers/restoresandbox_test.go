@@ -0,0 +1,164 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testSandboxUID = "0123456789abcdef"
+
+func newSandboxRestore() *storkapi.ApplicationRestore {
+	restore := &storkapi.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test", UID: testSandboxUID},
+		Spec: storkapi.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"test": "test"},
+			Sandbox:          true,
+		},
+		Status: storkapi.ApplicationRestoreStatus{Stage: storkapi.ApplicationRestoreStageVolumes},
+	}
+	setSandboxNamespaceMapping(restore)
+	return restore
+}
+
+func newSandboxController(t *testing.T, restore *storkapi.ApplicationRestore, objects ...runtime.Object) *ApplicationRestoreController {
+	scheme := runtime.NewScheme()
+	require.NoError(t, storkapi.AddToScheme(scheme))
+	kubeClient := fakek8s.NewSimpleClientset(objects...)
+	core.SetInstance(core.New(kubeClient))
+	return &ApplicationRestoreController{
+		client:                fake.NewClientBuilder().WithScheme(scheme).WithObjects(restore).Build(),
+		recorder:              record.NewFakeRecorder(10),
+		kubeClient:            kubeClient,
+		restoreAdminNamespace: "admin",
+	}
+}
+
+func TestGetSandboxNamespace(t *testing.T) {
+	require.Equal(t, "test-sandbox-01234567", getSandboxNamespace("test", testSandboxUID))
+	namespace := getSandboxNamespace(strings.Repeat("a", 63), testSandboxUID)
+	require.Len(t, namespace, 63)
+	require.True(t, strings.HasSuffix(namespace, "-sandbox-01234567"))
+}
+
+func TestSetupSandbox(t *testing.T) {
+	restore := newSandboxRestore()
+	a := newSandboxController(t, restore)
+	sandbox := restore.Spec.NamespaceMapping["test"]
+
+	require.NoError(t, a.setupSandbox(restore))
+	ns, err := core.Instance().GetNamespace(sandbox)
+	require.NoError(t, err)
+	require.Equal(t, testSandboxUID, ns.Labels[sandboxOwnerLabel])
+	_, err = a.kubeClient.NetworkingV1().NetworkPolicies(sandbox).Get(context.TODO(), sandboxNetworkPolicyName, metav1.GetOptions{})
+	require.NoError(t, err)
+	// Setting up the sandbox again reuses the namespace
+	require.NoError(t, a.setupSandbox(restore))
+
+	existing := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: sandbox}}
+	a = newSandboxController(t, restore, existing)
+	require.Error(t, a.setupSandbox(restore), "existing namespace shouldn't be used as a sandbox")
+	ns, err = core.Instance().GetNamespace(sandbox)
+	require.NoError(t, err)
+	require.NotContains(t, ns.Labels, sandboxOwnerLabel)
+	_, err = a.kubeClient.NetworkingV1().NetworkPolicies(sandbox).Get(context.TODO(), sandboxNetworkPolicyName, metav1.GetOptions{})
+	require.True(t, errors.IsNotFound(err))
+}
+
+func TestSandboxNetworkPolicy(t *testing.T) {
+	policy := getSandboxNetworkPolicy("test")
+	require.Len(t, policy.Spec.Egress, 2)
+	for _, rule := range policy.Spec.Egress {
+		require.NotEmpty(t, rule.To, "egress should only be allowed to selected peers")
+	}
+	dns := policy.Spec.Egress[1]
+	require.Equal(t, "kube-system", dns.To[0].NamespaceSelector.MatchLabels[namespaceNameLabel])
+	require.Equal(t, "kube-dns", dns.To[0].PodSelector.MatchLabels["k8s-app"])
+}
+
+func TestIsolateSandboxNamespace(t *testing.T) {
+	restore := newSandboxRestore()
+	sandbox := restore.Spec.NamespaceMapping["test"]
+	// Policies cloned from the source could allow more traffic
+	cloned := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "allow-all", Namespace: sandbox},
+		Spec: networkingv1.NetworkPolicySpec{
+			Egress: []networkingv1.NetworkPolicyEgressRule{{}},
+		},
+	}
+	a := newSandboxController(t, restore, cloned)
+	require.NoError(t, a.setupSandbox(restore))
+	policies, err := a.kubeClient.NetworkingV1().NetworkPolicies(sandbox).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, policies.Items, 1)
+	require.Equal(t, sandboxNetworkPolicyName, policies.Items[0].Name)
+
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"})
+	require.True(t, isNetworkPolicy(policy))
+	policy.SetKind("ConfigMap")
+	require.False(t, isNetworkPolicy(policy))
+}
+
+func TestSandboxNamespaceRestoreAllowed(t *testing.T) {
+	restore := newSandboxRestore()
+	a := &ApplicationRestoreController{restoreAdminNamespace: "admin"}
+	require.True(t, a.namespaceRestoreAllowed(restore))
+
+	restore.Spec.NamespaceMapping = map[string]string{"other": getSandboxNamespace("other", testSandboxUID)}
+	require.False(t, a.namespaceRestoreAllowed(restore), "other namespaces shouldn't be restored into a sandbox")
+
+	restore.Namespace = "admin"
+	require.True(t, a.namespaceRestoreAllowed(restore))
+}
+
+func TestKeepSandboxOwner(t *testing.T) {
+	existing := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{sandboxOwnerLabel: testSandboxUID, "old": "label"},
+	}}
+	require.Equal(t, map[string]string{sandboxOwnerLabel: testSandboxUID},
+		keepSandboxOwner(existing, nil))
+	require.Equal(t, map[string]string{sandboxOwnerLabel: testSandboxUID, "app": "web"},
+		keepSandboxOwner(existing, map[string]string{"app": "web"}))
+	require.Equal(t, map[string]string{"app": "web"},
+		keepSandboxOwner(&v1.Namespace{}, map[string]string{"app": "web"}))
+}
+
+func TestExpireSandbox(t *testing.T) {
+	restore := newSandboxRestore()
+	sandbox := restore.Spec.NamespaceMapping["test"]
+	unlabeled := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}}
+	a := newSandboxController(t, restore, unlabeled)
+	require.NoError(t, a.setupSandbox(restore))
+	restore.Spec.NamespaceMapping["other"] = "unlabeled"
+
+	require.NoError(t, a.expireSandbox(context.TODO(), restore))
+	_, err := core.Instance().GetNamespace(sandbox)
+	require.True(t, errors.IsNotFound(err), "sandbox namespace should be deleted")
+	_, err = core.Instance().GetNamespace("unlabeled")
+	require.NoError(t, err, "namespaces that aren't sandboxes shouldn't be deleted")
+
+	updated := &storkapi.ApplicationRestore{}
+	require.NoError(t, a.client.Get(context.TODO(), types.NamespacedName{Name: restore.Name, Namespace: restore.Namespace}, updated))
+	require.True(t, updated.Status.SandboxDeleted)
+	require.Equal(t, storkapi.ApplicationRestoreStageFinal, updated.Status.Stage)
+	require.Equal(t, storkapi.ApplicationRestoreStatusFailed, updated.Status.Status, "running restore should be canceled")
+}
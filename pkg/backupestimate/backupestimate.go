@@ -0,0 +1,189 @@
+package backupestimate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultThroughput is the throughput in bytes per second used to
+	// estimate the duration if one isn't configured
+	DefaultThroughput = 50 * 1024 * 1024
+
+	// SourceKubelet is used for volumes whose used bytes were reported by
+	// kubelet
+	SourceKubelet = "Kubelet"
+	// SourceCapacity is used for volumes that aren't mounted, for which the
+	// capacity of the PVC is used as the estimate
+	SourceCapacity = "Capacity"
+)
+
+// summary is the part of the kubelet stats summary with the volume usage
+type summary struct {
+	Pods []struct {
+		Volumes []struct {
+			UsedBytes *uint64 `json:"usedBytes"`
+			PVCRef    *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// Estimator estimates the size and duration of application backups
+type Estimator struct {
+	kubeClient        kubernetes.Interface
+	resourceCollector *resourcecollector.ResourceCollector
+	throughput        int64
+	// getSummary returns the kubelet stats summary for a node
+	getSummary func(node string) ([]byte, error)
+}
+
+// New returns an Estimator that uses the given throughput in bytes per second
+// to estimate the duration. DefaultThroughput is used if it isn't positive.
+func New(
+	kubeClient kubernetes.Interface,
+	resourceCollector *resourcecollector.ResourceCollector,
+	throughput int64,
+) *Estimator {
+	if throughput <= 0 {
+		throughput = DefaultThroughput
+	}
+	e := &Estimator{
+		kubeClient:        kubeClient,
+		resourceCollector: resourceCollector,
+		throughput:        throughput,
+	}
+	e.getSummary = e.getKubeletSummary
+	return e
+}
+
+// Estimate returns the estimated size and duration of a backup of the
+// namespaces. The used bytes of volumes are reported by kubelet on the node
+// where they are mounted. The capacity of the PVC is used for volumes that
+// aren't mounted.
+func (e *Estimator) Estimate(namespaces []string, selectors map[string]string) (*stork_api.ApplicationBackupEstimate, error) {
+	estimate := &stork_api.ApplicationBackupEstimate{
+		Volumes:   make([]*stork_api.ApplicationBackupVolumeEstimate, 0),
+		Timestamp: metav1.Now(),
+	}
+	usage := make(map[string]uint64)
+	nodesQueried := make(map[string]bool)
+	for _, namespace := range namespaces {
+		pvcList, err := e.kubeClient.CoreV1().PersistentVolumeClaims(namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(selectors).String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error getting PVCs in namespace %v: %v", namespace, err)
+		}
+		if len(pvcList.Items) == 0 {
+			continue
+		}
+		podList, err := e.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error getting pods in namespace %v: %v", namespace, err)
+		}
+		for _, node := range getNodesUsingPVCs(podList.Items) {
+			if nodesQueried[node] {
+				continue
+			}
+			nodesQueried[node] = true
+			data, err := e.getSummary(node)
+			if err != nil {
+				return nil, fmt.Errorf("error getting stats from kubelet on node %v: %v", node, err)
+			}
+			if err := parseSummary(data, usage); err != nil {
+				return nil, fmt.Errorf("error parsing stats from kubelet on node %v: %v", node, err)
+			}
+		}
+
+		for _, pvc := range pvcList.Items {
+			if pvc.Status.Phase != v1.ClaimBound {
+				continue
+			}
+			volume := &stork_api.ApplicationBackupVolumeEstimate{
+				Namespace:             pvc.Namespace,
+				PersistentVolumeClaim: pvc.Name,
+			}
+			if used, ok := usage[pvc.Namespace+"/"+pvc.Name]; ok {
+				volume.UsedBytes = used
+				volume.Source = SourceKubelet
+			} else {
+				capacity := pvc.Status.Capacity[v1.ResourceStorage]
+				volume.UsedBytes = uint64(capacity.Value())
+				volume.Source = SourceCapacity
+			}
+			estimate.TotalBytes += volume.UsedBytes
+			estimate.Volumes = append(estimate.Volumes, volume)
+		}
+	}
+	estimate.Duration = metav1.Duration{
+		Duration: time.Duration(estimate.TotalBytes/uint64(e.throughput)) * time.Second,
+	}
+
+	if e.resourceCollector != nil {
+		objects, err := e.resourceCollector.GetResources(namespaces, selectors, nil, nil, true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting resources: %v", err)
+		}
+		estimate.Objects = len(objects)
+	}
+	return estimate, nil
+}
+
+// getNodesUsingPVCs returns the nodes where the running pods have PVCs
+// mounted
+func getNodesUsingPVCs(pods []v1.Pod) []string {
+	nodes := make([]string, 0)
+	found := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != v1.PodRunning || found[pod.Spec.NodeName] {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				found[pod.Spec.NodeName] = true
+				nodes = append(nodes, pod.Spec.NodeName)
+				break
+			}
+		}
+	}
+	return nodes
+}
+
+// parseSummary adds the used bytes of the PVCs in the kubelet stats summary
+// to usage, keyed by namespace/name
+func parseSummary(data []byte, usage map[string]uint64) error {
+	var s summary
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	for _, pod := range s.Pods {
+		for _, volume := range pod.Volumes {
+			if volume.PVCRef == nil || volume.UsedBytes == nil {
+				continue
+			}
+			usage[volume.PVCRef.Namespace+"/"+volume.PVCRef.Name] = *volume.UsedBytes
+		}
+	}
+	return nil
+}
+
+func (e *Estimator) getKubeletSummary(node string) ([]byte, error) {
+	return e.kubeClient.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(node).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(context.TODO())
+}
@@ -0,0 +1,75 @@
+//go:build unittest
+// +build unittest
+
+package backupestimate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testSummary = `{
+	"pods": [{
+		"volume": [
+			{"name": "data", "usedBytes": 2097152, "pvcRef": {"name": "mounted", "namespace": "ns1"}},
+			{"name": "tmp", "usedBytes": 4096}
+		]
+	}]
+}`
+
+func TestEstimate(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		getPVC("mounted", "ns1", "10Gi", v1.ClaimBound),
+		getPVC("unmounted", "ns1", "1Mi", v1.ClaimBound),
+		getPVC("pending", "ns1", "1Gi", v1.ClaimPending),
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: "ns1"},
+			Spec: v1.PodSpec{
+				NodeName: "node1",
+				Volumes: []v1.Volume{{
+					Name: "data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "mounted"},
+					},
+				}},
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		},
+	)
+	e := New(kubeClient, nil, 1024*1024)
+	queried := make([]string, 0)
+	e.getSummary = func(node string) ([]byte, error) {
+		queried = append(queried, node)
+		return []byte(testSummary), nil
+	}
+
+	estimate, err := e.Estimate([]string{"ns1"}, nil)
+	require.NoError(t, err, "Error estimating backup")
+	require.Equal(t, []string{"node1"}, queried)
+	require.Len(t, estimate.Volumes, 2)
+	used := make(map[string]*uint64)
+	for _, volume := range estimate.Volumes {
+		bytes := volume.UsedBytes
+		used[volume.PersistentVolumeClaim+"/"+volume.Source] = &bytes
+	}
+	require.Equal(t, uint64(2*1024*1024), *used["mounted/"+SourceKubelet])
+	require.Equal(t, uint64(1024*1024), *used["unmounted/"+SourceCapacity])
+	require.Equal(t, uint64(3*1024*1024), estimate.TotalBytes)
+	require.Equal(t, 3*time.Second, estimate.Duration.Duration)
+}
+
+func getPVC(name, namespace, capacity string, phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Status: v1.PersistentVolumeClaimStatus{
+			Phase:    phase,
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)},
+		},
+	}
+}
@@ -0,0 +1,174 @@
+package backuplayout
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/crypto"
+)
+
+const (
+	// BlobPrefix is the prefix of the content-addressed blobs in the bucket.
+	// They are shared by all the backups in the bucket. Namespace names
+	// can't start with a dot so it can't clash with the backup paths.
+	BlobPrefix = ".blobs/"
+	// BlobGCGracePeriod is the time for which blobs that aren't referenced
+	// by any manifest are kept, so that blobs uploaded by upgrades that are
+	// still in progress aren't deleted
+	BlobGCGracePeriod = 24 * time.Hour
+	// blobConcurrency is the number of blobs read or written in parallel
+	blobConcurrency = 16
+)
+
+// BlobKey returns the key of the blob with the given hash
+func BlobKey(hash string) string {
+	return BlobPrefix + hash
+}
+
+// blobHash returns the name of the blob for the data. If the data is
+// encrypted the hash is keyed with the encryption key so that the names of
+// the blobs can't be used to guess their content.
+func blobHash(data []byte, encryptionKey string) string {
+	if encryptionKey == "" {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, []byte(encryptionKey))
+	_, _ = mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HashArray returns the hashes of the blobs that the items of a JSON array are
+// stored in
+func HashArray(items []json.RawMessage, encryptionKey string) []string {
+	hashes := make([]string, len(items))
+	for i := range items {
+		hashes[i] = blobHash(items[i], encryptionKey)
+	}
+	return hashes
+}
+
+// WriteArray stores each item of a JSON array as a content-addressed blob and
+// returns the hashes of the blobs in order. Items that are already stored by
+// other backups aren't uploaded again. Existing blobs are never written again
+// so that this works with buckets that don't allow objects to be modified.
+// The hashes should be recorded in a manifest before the blobs are written so
+// that the existing blobs aren't garbage collected in the meantime.
+func WriteArray(ctx context.Context, bucket WritableBucket, items []json.RawMessage, encryptionKey string) ([]string, error) {
+	hashes := HashArray(items, encryptionKey)
+	err := forEach(len(items), func(i int) error {
+		return writeBlob(ctx, bucket, hashes[i], items[i], encryptionKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func writeBlob(ctx context.Context, bucket WritableBucket, hash string, data json.RawMessage, encryptionKey string) error {
+	key := BlobKey(hash)
+	exists, err := bucket.Exists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("error checking blob %v: %v", hash, err)
+	}
+	if exists {
+		return nil
+	}
+	if encryptionKey != "" {
+		if data, err = crypto.Encrypt(data, encryptionKey); err != nil {
+			return err
+		}
+	}
+	if err := bucket.WriteAll(ctx, key, data, nil); err != nil {
+		return fmt.Errorf("error writing blob %v: %v", hash, err)
+	}
+	return nil
+}
+
+// ReadArray reads the blobs with the given hashes and returns them as a JSON
+// array
+func ReadArray(ctx context.Context, bucket Bucket, hashes []string, encryptionKey string) ([]byte, error) {
+	items := make([]json.RawMessage, len(hashes))
+	err := forEach(len(hashes), func(i int) error {
+		data, err := bucket.ReadAll(ctx, BlobKey(hashes[i]))
+		if err != nil {
+			return fmt.Errorf("error reading blob %v: %v", hashes[i], err)
+		}
+		if encryptionKey != "" {
+			if data, err = crypto.Decrypt(data, encryptionKey); err != nil {
+				return fmt.Errorf("error decrypting blob %v: %v", hashes[i], err)
+			}
+		}
+		items[i] = data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(items)
+}
+
+// forEach calls fn for the indexes up to count with at most blobConcurrency
+// calls in parallel. The first error is returned.
+func forEach(count int, fn func(i int) error) error {
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var firstErr error
+	sem := make(chan struct{}, blobConcurrency)
+	for i := 0; i < count; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := fn(i); err != nil {
+				lock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				lock.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// BlobInfo is a blob found in the bucket
+type BlobInfo struct {
+	Key     string
+	ModTime time.Time
+}
+
+// UnreferencedBlobs returns the keys of the blobs that aren't referenced by
+// any of the manifests and are older than the grace period
+func UnreferencedBlobs(blobs []BlobInfo, manifests []*Manifest, now time.Time) []string {
+	referenced := make(map[string]bool)
+	for _, manifest := range manifests {
+		for _, hashes := range manifest.Blobs {
+			for _, hash := range hashes {
+				referenced[BlobKey(hash)] = true
+			}
+		}
+	}
+	unreferenced := make([]string, 0)
+	for _, blob := range blobs {
+		if !strings.HasPrefix(blob.Key, BlobPrefix) || referenced[blob.Key] {
+			continue
+		}
+		if now.Sub(blob.ModTime) < BlobGCGracePeriod {
+			continue
+		}
+		unreferenced = append(unreferenced, blob.Key)
+	}
+	return unreferenced
+}
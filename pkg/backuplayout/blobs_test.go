@@ -0,0 +1,77 @@
+//go:build unittest
+// +build unittest
+
+package backuplayout
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteArrayDedup(t *testing.T) {
+	bucket := newFakeBucket(nil)
+	items := []json.RawMessage{
+		json.RawMessage(`{"kind":"ConfigMap"}`),
+		json.RawMessage(`{"kind":"Secret"}`),
+	}
+	hashes, err := WriteArray(context.TODO(), bucket, items, "")
+	require.NoError(t, err)
+	require.Len(t, hashes, 2)
+	require.Equal(t, 2, bucket.writes)
+
+	// Only the changed item is written for the next backup
+	items[1] = json.RawMessage(`{"kind":"Secret","data":{}}`)
+	newHashes, err := WriteArray(context.TODO(), bucket, items, "")
+	require.NoError(t, err)
+	require.Equal(t, hashes[0], newHashes[0])
+	require.NotEqual(t, hashes[1], newHashes[1])
+	require.Equal(t, 3, bucket.writes)
+
+	// Existing blobs are never written again, however old they are
+	bucket.objects[BlobKey(hashes[0])].modTime = time.Now().Add(-2 * BlobGCGracePeriod)
+	_, err = WriteArray(context.TODO(), bucket, items, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, bucket.writes)
+	require.Equal(t, newHashes, HashArray(items, ""))
+}
+
+func TestReadArrayEncrypted(t *testing.T) {
+	bucket := newFakeBucket(nil)
+	items := []json.RawMessage{
+		json.RawMessage(`{"kind":"ConfigMap"}`),
+		json.RawMessage(`{"kind":"Secret"}`),
+	}
+	hashes, err := WriteArray(context.TODO(), bucket, items, "key")
+	require.NoError(t, err)
+
+	plainHashes, err := WriteArray(context.TODO(), bucket, items, "")
+	require.NoError(t, err)
+	require.NotEqual(t, plainHashes, hashes)
+	require.NotEqual(t, string(items[0]), string(bucket.objects[BlobKey(hashes[0])].data))
+
+	data, err := ReadArray(context.TODO(), bucket, hashes, "key")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"kind":"ConfigMap"},{"kind":"Secret"}]`, string(data))
+
+	_, err = ReadArray(context.TODO(), bucket, hashes, "wrongkey")
+	require.Error(t, err)
+}
+
+func TestUnreferencedBlobs(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-2 * BlobGCGracePeriod)
+	blobs := []BlobInfo{
+		{Key: BlobKey("referenced"), ModTime: old},
+		{Key: BlobKey("unreferenced"), ModTime: old},
+		{Key: BlobKey("new"), ModTime: now},
+	}
+	manifests := []*Manifest{
+		{SchemaVersion: CurrentVersion, Blobs: map[string][]string{"resources.json": {"referenced"}}},
+		NewManifest("metadata.json"),
+	}
+	require.Equal(t, []string{BlobKey("unreferenced")}, UnreferencedBlobs(blobs, manifests, now))
+}
@@ -0,0 +1,222 @@
+package backuplayout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+// GCLeaseObjectName is the name of the object in the bucket that records which
+// owner garbage collects the blobs of the bucket. Namespace names can't start
+// with a dot so it can't clash with the backup paths.
+const GCLeaseObjectName = ".gc-lease"
+
+// gcLease is the content of the lease object
+type gcLease struct {
+	// Owner that collects the garbage of the bucket
+	Owner string `json:"owner"`
+	// RenewTime is when the owner last collected the garbage
+	RenewTime time.Time `json:"renewTime"`
+}
+
+// listingBucket is a WritableBucket whose objects can be listed
+type listingBucket interface {
+	WritableBucket
+	list(ctx context.Context, prefix string, delimiter string) ([]*blob.ListObject, error)
+}
+
+// gcBucket lists the objects of a blob.Bucket
+type gcBucket struct {
+	*blob.Bucket
+}
+
+func (b gcBucket) list(ctx context.Context, prefix string, delimiter string) ([]*blob.ListObject, error) {
+	objects := make([]*blob.ListObject, 0)
+	iterator := b.List(&blob.ListOptions{
+		Prefix:    prefix,
+		Delimiter: delimiter,
+	})
+	for {
+		object, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// CollectGarbage deletes the blobs in the bucket that aren't referenced by
+// any backup anymore. Only one owner collects the garbage of a bucket, the
+// others are skipped until the owner hasn't renewed its lease for the lease
+// duration. The manifests of all the backups in the bucket are read first and
+// nothing is deleted if any of them can't be read. They are read again before
+// deleting the blobs, and blobs that were written again in the meantime are
+// kept. Returns the number of blobs that were deleted.
+func CollectGarbage(ctx context.Context, bucket *blob.Bucket, owner string, leaseDuration time.Duration) (int, error) {
+	return collectGarbage(ctx, gcBucket{bucket}, owner, leaseDuration, time.Now())
+}
+
+func collectGarbage(ctx context.Context, bucket listingBucket, owner string, leaseDuration time.Duration, now time.Time) (int, error) {
+	acquired, err := acquireGCLease(ctx, bucket, owner, leaseDuration, now)
+	if err != nil || !acquired {
+		return 0, err
+	}
+	blobs, err := listBlobs(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	if len(blobs) == 0 {
+		return 0, nil
+	}
+	manifests, err := listManifests(ctx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	candidates := UnreferencedBlobs(blobs, manifests, now)
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	// Backups that started while the blobs were listed might reference
+	// some of them now
+	if manifests, err = listManifests(ctx, bucket); err != nil {
+		return 0, err
+	}
+	candidateBlobs := make([]BlobInfo, 0, len(candidates))
+	for _, key := range candidates {
+		candidateBlobs = append(candidateBlobs, BlobInfo{Key: key})
+	}
+	deleted := 0
+	for _, key := range UnreferencedBlobs(candidateBlobs, manifests, now) {
+		attributes, err := bucket.Attributes(ctx, key)
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("error checking blob %v: %v", key, err)
+		}
+		if now.Sub(attributes.ModTime) < BlobGCGracePeriod {
+			continue
+		}
+		if err := bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return deleted, fmt.Errorf("error deleting blob %v: %v", key, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// acquireGCLease returns true if the owner holds the lease to collect the
+// garbage of the bucket. The lease is taken over if it has expired. The lease
+// is read again after it is written so that only the last of the owners
+// that raced for it is considered to hold it.
+func acquireGCLease(ctx context.Context, bucket WritableBucket, owner string, leaseDuration time.Duration, now time.Time) (bool, error) {
+	lease, err := readGCLease(ctx, bucket)
+	if err != nil {
+		return false, err
+	}
+	if lease != nil && lease.Owner != owner && now.Sub(lease.RenewTime) < leaseDuration {
+		return false, nil
+	}
+	data, err := json.Marshal(&gcLease{Owner: owner, RenewTime: now})
+	if err != nil {
+		return false, err
+	}
+	if err := bucket.WriteAll(ctx, GCLeaseObjectName, data, nil); err != nil {
+		return false, fmt.Errorf("error writing garbage collection lease: %v", err)
+	}
+	if lease, err = readGCLease(ctx, bucket); err != nil {
+		return false, err
+	}
+	return lease != nil && lease.Owner == owner, nil
+}
+
+func readGCLease(ctx context.Context, bucket Bucket) (*gcLease, error) {
+	exists, err := bucket.Exists(ctx, GCLeaseObjectName)
+	if err != nil || !exists {
+		return nil, err
+	}
+	data, err := bucket.ReadAll(ctx, GCLeaseObjectName)
+	if err != nil {
+		return nil, err
+	}
+	lease := &gcLease{}
+	if err := json.Unmarshal(data, lease); err != nil {
+		return nil, fmt.Errorf("error parsing garbage collection lease: %v", err)
+	}
+	return lease, nil
+}
+
+func listBlobs(ctx context.Context, bucket listingBucket) ([]BlobInfo, error) {
+	objects, err := bucket.list(ctx, BlobPrefix, "")
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([]BlobInfo, 0, len(objects))
+	for _, object := range objects {
+		blobs = append(blobs, BlobInfo{Key: object.Key, ModTime: object.ModTime})
+	}
+	return blobs, nil
+}
+
+// listManifests reads the manifests and pending manifests of all the backups
+// in the bucket, which are stored under <namespace>/<name>/<uid>/
+func listManifests(ctx context.Context, bucket listingBucket) ([]*Manifest, error) {
+	manifests := make([]*Manifest, 0)
+	namespaces, err := listDirs(ctx, bucket, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, namespace := range namespaces {
+		if namespace == BlobPrefix {
+			continue
+		}
+		names, err := listDirs(ctx, bucket, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			backupPaths, err := listDirs(ctx, bucket, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, backupPath := range backupPaths {
+				manifest, err := Read(ctx, bucket, backupPath)
+				if err != nil {
+					return nil, err
+				}
+				manifests = append(manifests, manifest)
+				pending, err := ReadPending(ctx, bucket, backupPath)
+				if err != nil {
+					return nil, err
+				}
+				if pending != nil {
+					manifests = append(manifests, pending)
+				}
+			}
+		}
+	}
+	return manifests, nil
+}
+
+func listDirs(ctx context.Context, bucket listingBucket, prefix string) ([]string, error) {
+	objects, err := bucket.list(ctx, prefix, "/")
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0)
+	for _, object := range objects {
+		if object.IsDir {
+			dirs = append(dirs, object.Key)
+		}
+	}
+	return dirs, nil
+}
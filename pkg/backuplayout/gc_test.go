@@ -0,0 +1,79 @@
+//go:build unittest
+// +build unittest
+
+package backuplayout
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newGCBucket(t *testing.T) *fakeBucket {
+	old := time.Now().Add(-2 * BlobGCGracePeriod)
+	bucket := newFakeBucket(nil)
+	for _, hash := range []string{"referenced", "pending", "unreferenced"} {
+		bucket.objects[BlobKey(hash)] = &fakeObject{data: []byte("{}"), modTime: old}
+	}
+	manifest := NewManifest("metadata.json")
+	manifest.Blobs["resources.json"] = []string{"referenced"}
+	require.NoError(t, Write(context.TODO(), bucket, "ns/backup/uid1", manifest))
+	pending := NewManifest()
+	pending.Blobs["resources.json"] = []string{"pending"}
+	require.NoError(t, WritePending(context.TODO(), bucket, "ns/backup/uid2", pending))
+	return bucket
+}
+
+func TestCollectGarbage(t *testing.T) {
+	bucket := newGCBucket(t)
+	deleted, err := collectGarbage(context.TODO(), bucket, "owner", time.Hour, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	for hash, exists := range map[string]bool{"referenced": true, "pending": true, "unreferenced": false} {
+		found, err := bucket.Exists(context.TODO(), BlobKey(hash))
+		require.NoError(t, err)
+		require.Equal(t, exists, found, hash)
+	}
+}
+
+func TestCollectGarbageRewrittenBlob(t *testing.T) {
+	bucket := newGCBucket(t)
+	// The blob was uploaded again after it was listed
+	now := time.Now()
+	bucket.objects[BlobKey("unreferenced")].modTime = now
+	deleted, err := collectGarbage(context.TODO(), bucket, "owner", time.Hour, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 0, deleted)
+}
+
+func TestCollectGarbageLease(t *testing.T) {
+	bucket := newGCBucket(t)
+	now := time.Now()
+	data, err := json.Marshal(&gcLease{Owner: "other", RenewTime: now.Add(-time.Minute)})
+	require.NoError(t, err)
+	bucket.objects[GCLeaseObjectName] = &fakeObject{data: data, modTime: now}
+
+	deleted, err := collectGarbage(context.TODO(), bucket, "owner", time.Hour, now)
+	require.NoError(t, err)
+	require.Equal(t, 0, deleted, "garbage shouldn't be collected while another owner holds the lease")
+	lease, err := readGCLease(context.TODO(), bucket)
+	require.NoError(t, err)
+	require.Equal(t, "other", lease.Owner)
+
+	deleted, err = collectGarbage(context.TODO(), bucket, "owner", time.Hour, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted, "expired lease should be taken over")
+	lease, err = readGCLease(context.TODO(), bucket)
+	require.NoError(t, err)
+	require.Equal(t, "owner", lease.Owner)
+
+	acquired, err := acquireGCLease(context.TODO(), bucket, "other", time.Hour, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.False(t, acquired)
+	acquired, err = acquireGCLease(context.TODO(), bucket, "owner", time.Hour, now.Add(90*time.Minute))
+	require.NoError(t, err)
+	require.True(t, acquired, "owner should be able to renew its lease")
+}
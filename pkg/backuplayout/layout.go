@@ -0,0 +1,299 @@
+package backuplayout
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/libopenstorage/stork/pkg/crypto"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+)
+
+const (
+	// ManifestObjectName is the name of the object in the backup path that
+	// describes the layout of the backup. It isn't encrypted so that the
+	// layout can be inspected without the encryption key.
+	ManifestObjectName = "manifest.json"
+	// PendingManifestObjectName is the name of the object in the backup path
+	// that records the blobs of a backup that is still in progress. It is
+	// written before the blobs so that the blobs that are already stored
+	// aren't garbage collected while the backup references them, and so
+	// that the manifest is only written once the backup is done.
+	PendingManifestObjectName = "manifest.pending.json"
+	// LegacyVersion is the layout of backups taken before the manifest was
+	// introduced. The objects are stored directly in the backup path.
+	LegacyVersion = 0
+	// ManifestVersion is the layout where a manifest lists the objects of
+	// the backup
+	ManifestVersion = 1
+	// BlobVersion is the layout where the resources are stored as
+	// content-addressed blobs shared by all the backups in the bucket
+	BlobVersion = 2
+	// CurrentVersion is the layout version used for new backups
+	CurrentVersion = BlobVersion
+)
+
+// legacyObjects are the objects stored by backups with the legacy layout
+var legacyObjects = []string{"resources.json", "crds.json", "namespaces.json", "metadata.json"}
+
+// blobObjects are the objects that are stored as blobs since BlobVersion.
+// They hold a JSON array of resources.
+var blobObjects = []string{"resources.json"}
+
+// Bucket is the subset of the bucket operations used to read and write the
+// layout of a backup
+type Bucket interface {
+	Exists(ctx context.Context, key string) (bool, error)
+	ReadAll(ctx context.Context, key string) ([]byte, error)
+}
+
+// WritableBucket is a Bucket that objects can be written to
+type WritableBucket interface {
+	Bucket
+	Attributes(ctx context.Context, key string) (*blob.Attributes, error)
+	WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) error
+	Delete(ctx context.Context, key string) error
+}
+
+// Manifest describes the layout of a backup in the backup location
+type Manifest struct {
+	// SchemaVersion is the version of the layout of the backup
+	SchemaVersion int `json:"schemaVersion"`
+	// Objects maps the name of each object of the backup to its key
+	// relative to the backup path
+	Objects map[string]string `json:"objects"`
+	// Blobs maps the name of each object that is stored as content-addressed
+	// blobs to the hashes of the blobs holding the items of the object
+	Blobs map[string][]string `json:"blobs,omitempty"`
+}
+
+// NewManifest returns a manifest with the current layout version for the
+// given objects, which are stored with their names in the backup path
+func NewManifest(objects ...string) *Manifest {
+	manifest := &Manifest{
+		SchemaVersion: CurrentVersion,
+		Objects:       make(map[string]string),
+		Blobs:         make(map[string][]string),
+	}
+	manifest.AddObjects(objects...)
+	return manifest
+}
+
+// AddObjects adds objects stored with their names in the backup path to the
+// manifest
+func (m *Manifest) AddObjects(objects ...string) {
+	if m.Objects == nil {
+		m.Objects = make(map[string]string)
+	}
+	for _, object := range objects {
+		m.Objects[object] = object
+	}
+}
+
+// ObjectKey returns the key of the object relative to the backup path. The
+// name of the object is returned if it isn't part of the manifest.
+func (m *Manifest) ObjectKey(object string) string {
+	if key, ok := m.Objects[object]; ok {
+		return key
+	}
+	return object
+}
+
+// ObjectNames returns the sorted names of the objects in the manifest
+func (m *Manifest) ObjectNames() []string {
+	names := make([]string, 0, len(m.Objects))
+	for name := range m.Objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Read returns the manifest of the backup stored in the backup path. Backups
+// without a manifest are returned with the legacy layout. An error is returned
+// if the backup was written with a layout newer than the one supported.
+func Read(ctx context.Context, bucket Bucket, backupPath string) (*Manifest, error) {
+	key := filepath.Join(backupPath, ManifestObjectName)
+	exists, err := bucket.Exists(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		manifest := NewManifest(legacyObjects...)
+		manifest.SchemaVersion = LegacyVersion
+		return manifest, nil
+	}
+	return readManifest(ctx, bucket, backupPath, key)
+}
+
+// ReadPending returns the pending manifest of the backup stored in the backup
+// path, or nil if the backup doesn't have one
+func ReadPending(ctx context.Context, bucket Bucket, backupPath string) (*Manifest, error) {
+	key := filepath.Join(backupPath, PendingManifestObjectName)
+	exists, err := bucket.Exists(ctx, key)
+	if err != nil || !exists {
+		return nil, err
+	}
+	return readManifest(ctx, bucket, backupPath, key)
+}
+
+func readManifest(ctx context.Context, bucket Bucket, backupPath string, key string) (*Manifest, error) {
+	data, err := bucket.ReadAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest of backup %v: %v", backupPath, err)
+	}
+	if manifest.SchemaVersion > CurrentVersion {
+		return nil, fmt.Errorf("backup %v has layout version %v which is newer than the supported version %v, "+
+			"stork needs to be upgraded to use it", backupPath, manifest.SchemaVersion, CurrentVersion)
+	}
+	if manifest.Objects == nil {
+		manifest.Objects = make(map[string]string)
+	}
+	if manifest.Blobs == nil {
+		manifest.Blobs = make(map[string][]string)
+	}
+	return manifest, nil
+}
+
+// Write stores the manifest in the backup path
+func Write(ctx context.Context, bucket WritableBucket, backupPath string, manifest *Manifest) error {
+	return writeManifest(ctx, bucket, filepath.Join(backupPath, ManifestObjectName), manifest)
+}
+
+// WritePending stores the pending manifest of a backup that is in progress in
+// the backup path
+func WritePending(ctx context.Context, bucket WritableBucket, backupPath string, manifest *Manifest) error {
+	return writeManifest(ctx, bucket, filepath.Join(backupPath, PendingManifestObjectName), manifest)
+}
+
+func writeManifest(ctx context.Context, bucket WritableBucket, key string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", " ")
+	if err != nil {
+		return err
+	}
+	return bucket.WriteAll(ctx, key, data, nil)
+}
+
+// migration upgrades a backup from one layout version to the next one
+type migration func(ctx context.Context, bucket WritableBucket, backupPath string, manifest *Manifest, encryptionKey string) (*Manifest, error)
+
+// migrations are indexed by the version they upgrade from
+var migrations = map[int]migration{
+	LegacyVersion:   migrateLegacy,
+	ManifestVersion: migrateToBlobs,
+}
+
+// Migrate upgrades the layout of the backup in the backup path to the current
+// version. The manifest is only written once all the migrations have succeeded
+// so an interrupted upgrade is started again from the original layout. Objects
+// that aren't used by the new layout are deleted after that, except for the
+// ones moved to blobs which are kept for older versions of stork that read
+// them directly. Returns the version the backup was upgraded from.
+func Migrate(ctx context.Context, bucket WritableBucket, backupPath string, encryptionKey string) (int, error) {
+	manifest, err := Read(ctx, bucket, backupPath)
+	if err != nil {
+		return 0, err
+	}
+	fromVersion := manifest.SchemaVersion
+	originalKeys := make([]string, 0)
+	for _, object := range manifest.ObjectNames() {
+		originalKeys = append(originalKeys, manifest.ObjectKey(object))
+	}
+	for manifest.SchemaVersion < CurrentVersion {
+		migrate, ok := migrations[manifest.SchemaVersion]
+		if !ok {
+			return fromVersion, fmt.Errorf("no migration for layout version %v", manifest.SchemaVersion)
+		}
+		version := manifest.SchemaVersion
+		if manifest, err = migrate(ctx, bucket, backupPath, manifest, encryptionKey); err != nil {
+			return fromVersion, fmt.Errorf("error migrating backup %v from layout version %v: %v", backupPath, version, err)
+		}
+	}
+	if fromVersion == CurrentVersion {
+		return fromVersion, nil
+	}
+	if err := Write(ctx, bucket, backupPath, manifest); err != nil {
+		return fromVersion, err
+	}
+	used := make(map[string]bool)
+	for _, object := range manifest.ObjectNames() {
+		used[manifest.ObjectKey(object)] = true
+	}
+	for _, object := range blobObjects {
+		used[object] = true
+	}
+	for _, key := range originalKeys {
+		if used[key] {
+			continue
+		}
+		err := bucket.Delete(ctx, filepath.Join(backupPath, key))
+		if err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+			return fromVersion, fmt.Errorf("error deleting %v after migrating backup %v: %v", key, backupPath, err)
+		}
+	}
+	return fromVersion, nil
+}
+
+// migrateLegacy records the objects of a legacy backup in a manifest. The
+// objects themselves are left where they are. Optional objects that weren't
+// uploaded by older versions are left out.
+func migrateLegacy(ctx context.Context, bucket WritableBucket, backupPath string, manifest *Manifest, encryptionKey string) (*Manifest, error) {
+	objects := make([]string, 0)
+	for _, object := range manifest.ObjectNames() {
+		exists, err := bucket.Exists(ctx, filepath.Join(backupPath, manifest.ObjectKey(object)))
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			objects = append(objects, object)
+		}
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no objects found for backup")
+	}
+	migrated := NewManifest(objects...)
+	migrated.SchemaVersion = ManifestVersion
+	return migrated, nil
+}
+
+// migrateToBlobs moves the resources of the backup to content-addressed
+// blobs
+func migrateToBlobs(ctx context.Context, bucket WritableBucket, backupPath string, manifest *Manifest, encryptionKey string) (*Manifest, error) {
+	for _, object := range blobObjects {
+		key, ok := manifest.Objects[object]
+		if !ok {
+			continue
+		}
+		data, err := bucket.ReadAll(ctx, filepath.Join(backupPath, key))
+		if err != nil {
+			return nil, err
+		}
+		if encryptionKey != "" {
+			if data, err = crypto.Decrypt(data, encryptionKey); err != nil {
+				return nil, fmt.Errorf("error decrypting %v: %v", object, err)
+			}
+		}
+		items := make([]json.RawMessage, 0)
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("error parsing %v: %v", object, err)
+		}
+		hashes, err := WriteArray(ctx, bucket, items, encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		if manifest.Blobs == nil {
+			manifest.Blobs = make(map[string][]string)
+		}
+		manifest.Blobs[object] = hashes
+		delete(manifest.Objects, object)
+	}
+	manifest.SchemaVersion = BlobVersion
+	return manifest, nil
+}
@@ -0,0 +1,194 @@
+//go:build unittest
+// +build unittest
+
+package backuplayout
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+)
+
+type fakeObject struct {
+	data    []byte
+	modTime time.Time
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string { return "not found" }
+
+type fakeBucket struct {
+	sync.Mutex
+	objects map[string]*fakeObject
+	writes  int
+}
+
+func newFakeBucket(objects map[string]string) *fakeBucket {
+	bucket := &fakeBucket{objects: make(map[string]*fakeObject)}
+	for key, data := range objects {
+		bucket.objects[key] = &fakeObject{data: []byte(data), modTime: time.Now()}
+	}
+	return bucket
+}
+
+func (b *fakeBucket) Exists(ctx context.Context, key string) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+	_, ok := b.objects[key]
+	return ok, nil
+}
+
+func (b *fakeBucket) ReadAll(ctx context.Context, key string) ([]byte, error) {
+	b.Lock()
+	defer b.Unlock()
+	object, ok := b.objects[key]
+	if !ok {
+		return nil, notFoundError{}
+	}
+	return object.data, nil
+}
+
+func (b *fakeBucket) Attributes(ctx context.Context, key string) (*blob.Attributes, error) {
+	b.Lock()
+	defer b.Unlock()
+	object, ok := b.objects[key]
+	if !ok {
+		return nil, notFoundError{}
+	}
+	return &blob.Attributes{ModTime: object.modTime, Size: int64(len(object.data))}, nil
+}
+
+func (b *fakeBucket) WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) error {
+	b.Lock()
+	defer b.Unlock()
+	b.objects[key] = &fakeObject{data: p, modTime: time.Now()}
+	b.writes++
+	return nil
+}
+
+func (b *fakeBucket) Delete(ctx context.Context, key string) error {
+	b.Lock()
+	defer b.Unlock()
+	delete(b.objects, key)
+	return nil
+}
+
+func (b *fakeBucket) list(ctx context.Context, prefix string, delimiter string) ([]*blob.ListObject, error) {
+	b.Lock()
+	defer b.Unlock()
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	objects := make([]*blob.ListObject, 0)
+	dirs := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(rest, delimiter); delimiter != "" && i >= 0 {
+			dir := prefix + rest[:i+len(delimiter)]
+			if !dirs[dir] {
+				dirs[dir] = true
+				objects = append(objects, &blob.ListObject{Key: dir, IsDir: true})
+			}
+			continue
+		}
+		objects = append(objects, &blob.ListObject{Key: key, ModTime: b.objects[key].modTime})
+	}
+	return objects, nil
+}
+
+func TestWriteReadPending(t *testing.T) {
+	bucket := newFakeBucket(nil)
+	manifest, err := ReadPending(context.TODO(), bucket, "ns/backup/uid")
+	require.NoError(t, err)
+	require.Nil(t, manifest)
+
+	pending := NewManifest()
+	pending.Blobs["resources.json"] = []string{"hash"}
+	require.NoError(t, WritePending(context.TODO(), bucket, "ns/backup/uid", pending))
+	manifest, err = ReadPending(context.TODO(), bucket, "ns/backup/uid")
+	require.NoError(t, err)
+	require.Equal(t, []string{"hash"}, manifest.Blobs["resources.json"])
+
+	// The backup isn't done until its manifest is written
+	manifest, err = Read(context.TODO(), bucket, "ns/backup/uid")
+	require.NoError(t, err)
+	require.Equal(t, LegacyVersion, manifest.SchemaVersion)
+}
+
+func TestReadLegacy(t *testing.T) {
+	manifest, err := Read(context.TODO(), newFakeBucket(nil), "ns/backup/uid")
+	require.NoError(t, err)
+	require.Equal(t, LegacyVersion, manifest.SchemaVersion)
+	require.Equal(t, "resources.json", manifest.ObjectKey("resources.json"))
+}
+
+func TestReadNewerVersion(t *testing.T) {
+	data, err := json.Marshal(&Manifest{SchemaVersion: CurrentVersion + 1})
+	require.NoError(t, err)
+	bucket := newFakeBucket(map[string]string{"ns/backup/uid/manifest.json": string(data)})
+
+	_, err = Read(context.TODO(), bucket, "ns/backup/uid")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "stork needs to be upgraded")
+}
+
+func TestWriteRead(t *testing.T) {
+	bucket := newFakeBucket(nil)
+	require.NoError(t, Write(context.TODO(), bucket, "ns/backup/uid", NewManifest("resources.json", "metadata.json")))
+
+	manifest, err := Read(context.TODO(), bucket, "ns/backup/uid")
+	require.NoError(t, err)
+	require.Equal(t, CurrentVersion, manifest.SchemaVersion)
+	require.Equal(t, []string{"metadata.json", "resources.json"}, manifest.ObjectNames())
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	bucket := newFakeBucket(map[string]string{
+		"ns/backup/uid/resources.json": `[{"kind":"ConfigMap"},{"kind":"Secret"}]`,
+		"ns/backup/uid/metadata.json":  "{}",
+	})
+	from, err := Migrate(context.TODO(), bucket, "ns/backup/uid", "")
+	require.NoError(t, err)
+	require.Equal(t, LegacyVersion, from)
+
+	manifest, err := Read(context.TODO(), bucket, "ns/backup/uid")
+	require.NoError(t, err)
+	require.Equal(t, CurrentVersion, manifest.SchemaVersion)
+	require.Equal(t, []string{"metadata.json"}, manifest.ObjectNames())
+	require.Len(t, manifest.Blobs["resources.json"], 2)
+
+	// The resources are kept for older versions of stork after the migration
+	exists, err := bucket.Exists(context.TODO(), "ns/backup/uid/resources.json")
+	require.NoError(t, err)
+	require.True(t, exists)
+	data, err := ReadArray(context.TODO(), bucket, manifest.Blobs["resources.json"], "")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"kind":"ConfigMap"},{"kind":"Secret"}]`, string(data))
+
+	// Migrating again is a no-op
+	from, err = Migrate(context.TODO(), bucket, "ns/backup/uid", "")
+	require.NoError(t, err)
+	require.Equal(t, CurrentVersion, from)
+}
+
+func TestMigrateMissingBackup(t *testing.T) {
+	bucket := newFakeBucket(nil)
+	_, err := Migrate(context.TODO(), bucket, "ns/backup/uid", "")
+	require.Error(t, err)
+	exists, err := bucket.Exists(context.TODO(), "ns/backup/uid/manifest.json")
+	require.NoError(t, err)
+	require.False(t, exists)
+}
@@ -0,0 +1,240 @@
+package cache
+
+import (
+	"context"
+	"errors"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/portworx/sched-ops/k8s/core"
+	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
+	"github.com/portworx/sched-ops/k8s/storage"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// podPVCIndex indexes pods by the names of the PVCs they use
+	podPVCIndex = "spec.volumes.persistentVolumeClaim.claimName"
+	// podNodeIndex indexes pods by the name of the node they are scheduled on
+	podNodeIndex = "spec.nodeName"
+)
+
+var reader client.Reader
+
+// Init sets up the cache to read from the informers of the controller
+// manager, so that the informers are shared with the controllers. Needs to
+// be called before the manager is started. Until the manager is started, or
+// if Init isn't called, objects are read from the API server.
+func Init(mgr manager.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1.Pod{}, podPVCIndex, func(object client.Object) []string {
+		pod, ok := object.(*v1.Pod)
+		if !ok {
+			return nil
+		}
+		var claims []string
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+			}
+		}
+		return claims
+	})
+	if err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.TODO(), &v1.Pod{}, podNodeIndex, func(object client.Object) []string {
+		pod, ok := object.(*v1.Pod)
+		if !ok || pod.Spec.NodeName == "" {
+			return nil
+		}
+		return []string{pod.Spec.NodeName}
+	})
+	if err != nil {
+		return err
+	}
+	reader = mgr.GetCache()
+	return nil
+}
+
+// isCached returns false if the object should be read from the API server
+// instead because the cache hasn't been started
+func isCached(err error) bool {
+	var notStarted *runtimecache.ErrCacheNotStarted
+	return !errors.As(err, &notStarted)
+}
+
+// GetPersistentVolumeClaim returns the PVC with the given name
+func GetPersistentVolumeClaim(name, namespace string) (*v1.PersistentVolumeClaim, error) {
+	if reader != nil {
+		pvc := &v1.PersistentVolumeClaim{}
+		err := reader.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, pvc)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return pvc, nil
+		}
+	}
+	return core.Instance().GetPersistentVolumeClaim(name, namespace)
+}
+
+// GetPodsUsingPVC returns the pods that use the given PVC
+func GetPodsUsingPVC(pvcName, pvcNamespace string) ([]v1.Pod, error) {
+	if reader != nil {
+		pods := &v1.PodList{}
+		err := reader.List(context.TODO(), pods, client.InNamespace(pvcNamespace), client.MatchingFields{podPVCIndex: pvcName})
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return pods.Items, nil
+		}
+	}
+	return core.Instance().GetPodsUsingPVC(pvcName, pvcNamespace)
+}
+
+// GetPodsOnNode returns the pods scheduled on the given node
+func GetPodsOnNode(nodeName string) ([]v1.Pod, error) {
+	if reader != nil {
+		pods := &v1.PodList{}
+		err := reader.List(context.TODO(), pods, client.MatchingFields{podNodeIndex: nodeName})
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return pods.Items, nil
+		}
+	}
+	pods, err := core.Instance().GetPodsByNode(nodeName, "")
+	if err != nil {
+		return nil, err
+	}
+	nodePods := make([]v1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == nodeName {
+			nodePods = append(nodePods, pod)
+		}
+	}
+	return nodePods, nil
+}
+
+// GetNode returns the node with the given name
+func GetNode(name string) (*v1.Node, error) {
+	if reader != nil {
+		node := &v1.Node{}
+		err := reader.Get(context.TODO(), types.NamespacedName{Name: name}, node)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return node, nil
+		}
+	}
+	return core.Instance().GetNodeByName(name)
+}
+
+// ListNodes returns all the nodes
+func ListNodes() ([]v1.Node, error) {
+	if reader != nil {
+		nodes := &v1.NodeList{}
+		err := reader.List(context.TODO(), nodes)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return nodes.Items, nil
+		}
+	}
+	nodes, err := core.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	return nodes.Items, nil
+}
+
+// GetPersistentVolume returns the PV with the given name
+func GetPersistentVolume(name string) (*v1.PersistentVolume, error) {
+	if reader != nil {
+		pv := &v1.PersistentVolume{}
+		err := reader.Get(context.TODO(), types.NamespacedName{Name: name}, pv)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return pv, nil
+		}
+	}
+	return core.Instance().GetPersistentVolume(name)
+}
+
+// ListVolumeAttachments returns all the volume attachments
+func ListVolumeAttachments() ([]storagev1.VolumeAttachment, error) {
+	if reader != nil {
+		attachments := &storagev1.VolumeAttachmentList{}
+		err := reader.List(context.TODO(), attachments)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			return attachments.Items, nil
+		}
+	}
+	attachments, err := storage.Instance().ListVolumeAttachments()
+	if err != nil {
+		return nil, err
+	}
+	return attachments.Items, nil
+}
+
+// GetSnapshot returns the external-storage snapshot with the given name.
+// Snapshots are read as unstructured objects since the snapshot types don't
+// have the object metadata that the controller-runtime client needs.
+func GetSnapshot(name, namespace string) (*snapv1.VolumeSnapshot, error) {
+	if reader != nil {
+		object := &unstructured.Unstructured{}
+		object.SetGroupVersionKind(snapv1.SchemeGroupVersion.WithKind("VolumeSnapshot"))
+		err := reader.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, object)
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			snapshot := &snapv1.VolumeSnapshot{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, snapshot); err != nil {
+				return nil, err
+			}
+			return snapshot, nil
+		}
+	}
+	return k8sextops.Instance().GetSnapshot(name, namespace)
+}
+
+// ListSnapshots returns the external-storage snapshots in the namespace, or
+// in all namespaces if the namespace is empty
+func ListSnapshots(namespace string) (*snapv1.VolumeSnapshotList, error) {
+	if reader != nil {
+		objects := &unstructured.UnstructuredList{}
+		objects.SetGroupVersionKind(snapv1.SchemeGroupVersion.WithKind("VolumeSnapshotList"))
+		err := reader.List(context.TODO(), objects, client.InNamespace(namespace))
+		if isCached(err) {
+			if err != nil {
+				return nil, err
+			}
+			snapshots := &snapv1.VolumeSnapshotList{
+				Items: make([]snapv1.VolumeSnapshot, len(objects.Items)),
+			}
+			for i := range objects.Items {
+				if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objects.Items[i].Object, &snapshots.Items[i]); err != nil {
+					return nil, err
+				}
+			}
+			return snapshots, nil
+		}
+	}
+	return k8sextops.Instance().ListSnapshots(namespace)
+}
@@ -0,0 +1,97 @@
+//go:build unittest
+// +build unittest
+
+package cache
+
+import (
+	"testing"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCachedReads(t *testing.T) {
+	snapshot := &snapv1.VolumeSnapshot{
+		Metadata: metav1.ObjectMeta{
+			Name:      "snap",
+			Namespace: "ns",
+		},
+		Spec: snapv1.VolumeSnapshotSpec{
+			PersistentVolumeClaimName: "pvc",
+		},
+	}
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(snapshot)
+	require.NoError(t, err)
+	unstructuredSnapshot := &unstructured.Unstructured{Object: object}
+	unstructuredSnapshot.SetGroupVersionKind(snapv1.SchemeGroupVersion.WithKind("VolumeSnapshot"))
+
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pvc",
+			Namespace: "ns",
+		},
+	}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pv",
+		},
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+	}
+	attachment := &storagev1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "va",
+		},
+		Spec: storagev1.VolumeAttachmentSpec{
+			NodeName: "node1",
+		},
+	}
+	reader = fake.NewClientBuilder().WithObjects(pvc, pv, node, attachment, unstructuredSnapshot).Build()
+	defer func() {
+		reader = nil
+	}()
+
+	cachedPVC, err := GetPersistentVolumeClaim("pvc", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "pvc", cachedPVC.Name)
+
+	cachedSnapshot, err := GetSnapshot("snap", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "snap", cachedSnapshot.Metadata.Name)
+	require.Equal(t, "pvc", cachedSnapshot.Spec.PersistentVolumeClaimName)
+
+	snapshots, err := ListSnapshots("ns")
+	require.NoError(t, err)
+	require.Len(t, snapshots.Items, 1)
+	require.Equal(t, "snap", snapshots.Items[0].Metadata.Name)
+
+	snapshots, err = ListSnapshots("other")
+	require.NoError(t, err)
+	require.Empty(t, snapshots.Items)
+
+	cachedPV, err := GetPersistentVolume("pv")
+	require.NoError(t, err)
+	require.Equal(t, "pv", cachedPV.Name)
+
+	cachedNode, err := GetNode("node1")
+	require.NoError(t, err)
+	require.Equal(t, "node1", cachedNode.Name)
+
+	nodes, err := ListNodes()
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	attachments, err := ListVolumeAttachments()
+	require.NoError(t, err)
+	require.Len(t, attachments, 1)
+	require.Equal(t, "node1", attachments[0].Spec.NodeName)
+}
@@ -0,0 +1,219 @@
+package cleanupaudit
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// forceRemovedEventReason is the reason for events raised on resources
+	// whose cleanup finalizer was removed without cleaning up
+	forceRemovedEventReason = "CleanupFinalizerRemoved"
+)
+
+// StuckResource is a stork resource that has been deleted but is still held
+// back by the cleanup finalizer
+type StuckResource struct {
+	// Kind of the resource
+	Kind string
+	// Object is the resource itself
+	Object runtime.Object
+	// CleanupError is the last error recorded by the controller while
+	// cleaning up the resource, if any
+	CleanupError string
+}
+
+// GetMeta returns the object metadata of the resource
+func (r *StuckResource) GetMeta() metav1.Object {
+	return r.Object.(metav1.Object)
+}
+
+// TerminatingFor returns how long the resource has been terminating
+func (r *StuckResource) TerminatingFor() time.Duration {
+	deletionTimestamp := r.GetMeta().GetDeletionTimestamp()
+	if deletionTimestamp == nil {
+		return 0
+	}
+	return time.Since(deletionTimestamp.Time)
+}
+
+// List returns the stork resources in the namespace that are held back by
+// the cleanup finalizer, sorted by how long they have been terminating. All
+// namespaces are checked if the namespace is empty.
+func List(namespace string) ([]*StuckResource, error) {
+	var objects []runtime.Object
+
+	backups, err := storkops.Instance().ListApplicationBackups(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups.Items {
+		objects = append(objects, &backups.Items[i])
+	}
+	restores, err := storkops.Instance().ListApplicationRestores(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range restores.Items {
+		objects = append(objects, &restores.Items[i])
+	}
+	clones, err := storkops.Instance().ListApplicationClones(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clones.Items {
+		objects = append(objects, &clones.Items[i])
+	}
+	migrations, err := storkops.Instance().ListMigrations(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations.Items {
+		objects = append(objects, &migrations.Items[i])
+	}
+	migrationSchedules, err := storkops.Instance().ListMigrationSchedules(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrationSchedules.Items {
+		objects = append(objects, &migrationSchedules.Items[i])
+	}
+	clusterPairs, err := storkops.Instance().ListClusterPairs(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusterPairs.Items {
+		objects = append(objects, &clusterPairs.Items[i])
+	}
+	snapshotRestores, err := storkops.Instance().ListVolumeSnapshotRestore(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshotRestores.Items {
+		objects = append(objects, &snapshotRestores.Items[i])
+	}
+	groupSnapshots, err := storkops.Instance().ListGroupSnapshots(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groupSnapshots.Items {
+		objects = append(objects, &groupSnapshots.Items[i])
+	}
+
+	stuck := make([]*StuckResource, 0)
+	for _, object := range objects {
+		meta := object.(metav1.Object)
+		if meta.GetDeletionTimestamp() == nil || !controllers.ContainsFinalizer(meta, controllers.FinalizerCleanup) {
+			continue
+		}
+		stuck = append(stuck, &StuckResource{
+			Kind:         reflect.TypeOf(object).Elem().Name(),
+			Object:       object,
+			CleanupError: controllers.GetCleanupError(meta),
+		})
+	}
+	sort.SliceStable(stuck, func(i, j int) bool {
+		return stuck[i].TerminatingFor() > stuck[j].TerminatingFor()
+	})
+	return stuck, nil
+}
+
+// ForceRemoveFinalizer removes the cleanup finalizer from the resource so
+// that it can be deleted without the controller cleaning it up. Anything the
+// cleanup would have removed, like backups in the objectstore or snapshots
+// on the storage, is left behind. The removal is logged and an event is
+// raised on the resource along with the reason and the cleanup error so that
+// it can be audited later.
+func ForceRemoveFinalizer(r *StuckResource, reason string) error {
+	meta := r.GetMeta()
+	controllers.RemoveFinalizer(meta, controllers.FinalizerCleanup)
+
+	var err error
+	switch object := r.Object.(type) {
+	case *stork_api.ApplicationBackup:
+		_, err = storkops.Instance().UpdateApplicationBackup(object)
+	case *stork_api.ApplicationRestore:
+		_, err = storkops.Instance().UpdateApplicationRestore(object)
+	case *stork_api.ApplicationClone:
+		_, err = storkops.Instance().UpdateApplicationClone(object)
+	case *stork_api.Migration:
+		_, err = storkops.Instance().UpdateMigration(object)
+	case *stork_api.MigrationSchedule:
+		_, err = storkops.Instance().UpdateMigrationSchedule(object)
+	case *stork_api.ClusterPair:
+		_, err = storkops.Instance().UpdateClusterPair(object)
+	case *stork_api.VolumeSnapshotRestore:
+		_, err = storkops.Instance().UpdateVolumeSnapshotRestore(object)
+	case *stork_api.GroupVolumeSnapshot:
+		_, err = storkops.Instance().UpdateGroupSnapshot(object)
+	default:
+		err = fmt.Errorf("unsupported kind %v", r.Kind)
+	}
+	if err != nil {
+		return fmt.Errorf("error removing finalizer from %v %v/%v: %v", r.Kind, meta.GetNamespace(), meta.GetName(), err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"Kind":         r.Kind,
+		"Namespace":    meta.GetNamespace(),
+		"Name":         meta.GetName(),
+		"Terminating":  r.TerminatingFor().Round(time.Second),
+		"CleanupError": r.CleanupError,
+		"Reason":       reason,
+	}).Warnf("Forced removal of finalizer %v, resources left behind by the cleanup need to be removed manually", controllers.FinalizerCleanup)
+
+	msg := fmt.Sprintf("Finalizer %v was removed without cleaning up (%v), resources left behind by the cleanup need to be removed manually",
+		controllers.FinalizerCleanup, reason)
+	if r.CleanupError != "" {
+		msg = fmt.Sprintf("%v: %v", msg, r.CleanupError)
+	}
+	if err := createEvent(r, msg); err != nil {
+		logrus.Warnf("Error creating event for forced removal of finalizer from %v %v/%v: %v",
+			r.Kind, meta.GetNamespace(), meta.GetName(), err)
+	}
+	return nil
+}
+
+// createEvent raises a warning event on the resource. The event is created
+// directly instead of through a recorder so that it isn't lost when storkctl
+// exits right after removing the finalizer.
+func createEvent(r *StuckResource, msg string) error {
+	meta := r.GetMeta()
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", meta.GetName(), now.UnixNano()),
+			Namespace: meta.GetNamespace(),
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:            r.Kind,
+			APIVersion:      stork_api.SchemeGroupVersion.String(),
+			Name:            meta.GetName(),
+			Namespace:       meta.GetNamespace(),
+			UID:             meta.GetUID(),
+			ResourceVersion: meta.GetResourceVersion(),
+		},
+		Reason:         forceRemovedEventReason,
+		Message:        msg,
+		Type:           v1.EventTypeWarning,
+		Count:          1,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Source: v1.EventSource{
+			Component: "stork",
+		},
+	}
+	_, err := core.Instance().CreateEvent(event)
+	return err
+}
@@ -0,0 +1,147 @@
+//go:build unittest
+// +build unittest
+
+package cleanupaudit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func setupTest(objects ...runtime.Object) *fake.Clientset {
+	kubeClient := fake.NewSimpleClientset()
+	core.SetInstance(core.New(kubeClient))
+	storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(objects...), nil))
+	return kubeClient
+}
+
+func stuckMeta(name string, terminatingFor time.Duration) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         "test",
+		DeletionTimestamp: &metav1.Time{Time: time.Now().Add(-terminatingFor)},
+		Finalizers:        []string{controllers.FinalizerCleanup},
+	}
+}
+
+func TestList(t *testing.T) {
+	setupTest(
+		&stork_api.ApplicationBackup{
+			ObjectMeta: stuckMeta("backup", time.Minute),
+			Status:     stork_api.ApplicationBackupStatus{CleanupError: "error deleting backup"},
+		},
+		&stork_api.ApplicationRestore{ObjectMeta: stuckMeta("restore", time.Hour)},
+		&stork_api.GroupVolumeSnapshot{ObjectMeta: stuckMeta("groupsnapshot", 2*time.Hour)},
+		// Resources that aren't being deleted or aren't held back by the
+		// finalizer aren't stuck
+		&stork_api.Migration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "migration",
+				Namespace:  "test",
+				Finalizers: []string{controllers.FinalizerCleanup},
+			},
+		},
+		&stork_api.ApplicationClone{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "clone",
+				Namespace:         "test",
+				DeletionTimestamp: &metav1.Time{Time: time.Now()},
+				Finalizers:        []string{"other"},
+			},
+		},
+	)
+
+	stuck, err := List("test")
+	require.NoError(t, err)
+	require.Len(t, stuck, 3)
+	require.Equal(t, "GroupVolumeSnapshot", stuck[0].Kind, "Expected longest terminating resource first")
+	require.Equal(t, "ApplicationRestore", stuck[1].Kind)
+	require.Equal(t, "ApplicationBackup", stuck[2].Kind)
+	require.Equal(t, "error deleting backup", stuck[2].CleanupError)
+	require.Empty(t, stuck[1].CleanupError)
+
+	stuck, err = List("other")
+	require.NoError(t, err)
+	require.Empty(t, stuck)
+}
+
+func TestForceRemoveFinalizer(t *testing.T) {
+	kubeClient := setupTest(&stork_api.ApplicationBackup{
+		ObjectMeta: stuckMeta("backup", time.Hour),
+		Status:     stork_api.ApplicationBackupStatus{CleanupError: "error deleting backup"},
+	})
+	stuck, err := List("test")
+	require.NoError(t, err)
+	require.Len(t, stuck, 1)
+
+	require.NoError(t, ForceRemoveFinalizer(stuck[0], "testing"))
+	backup, err := storkops.Instance().GetApplicationBackup("backup", "test")
+	require.NoError(t, err)
+	require.False(t, controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup), "Expected finalizer to be removed")
+
+	events, err := kubeClient.CoreV1().Events("test").List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1, "Expected event for removal")
+	event := events.Items[0]
+	require.Equal(t, forceRemovedEventReason, event.Reason)
+	require.Equal(t, "ApplicationBackup", event.InvolvedObject.Kind)
+	require.Equal(t, "backup", event.InvolvedObject.Name)
+	require.Contains(t, event.Message, "testing")
+	require.Contains(t, event.Message, "error deleting backup")
+
+	stuck, err = List("test")
+	require.NoError(t, err)
+	require.Empty(t, stuck)
+
+	_, err = storkops.Instance().CreateVolumeSnapshotRestore(&stork_api.VolumeSnapshotRestore{
+		ObjectMeta: stuckMeta("snaprestore", time.Hour),
+	})
+	require.NoError(t, err)
+	unsupported := &StuckResource{Kind: "Unsupported", Object: &stork_api.BackupLocation{ObjectMeta: stuckMeta("location", time.Hour)}}
+	require.Error(t, ForceRemoveFinalizer(unsupported, "testing"), "Expected error for unsupported kind")
+}
+
+func TestMonitorCheck(t *testing.T) {
+	setupTest(
+		&stork_api.ApplicationBackup{ObjectMeta: stuckMeta("recent", time.Minute)},
+		&stork_api.ApplicationRestore{
+			ObjectMeta: stuckMeta("stuck", time.Hour),
+			Status:     stork_api.ApplicationRestoreStatus{CleanupError: "error deleting volumes"},
+		},
+		&stork_api.Migration{ObjectMeta: stuckMeta("expired", 3*time.Hour)},
+	)
+	recorder := record.NewFakeRecorder(10)
+	m := &Monitor{
+		Recorder:         recorder,
+		WarningThreshold: 30 * time.Minute,
+		RemovalTimeout:   2 * time.Hour,
+	}
+	require.NoError(t, m.check())
+
+	// Only the resource past the warning threshold is reported
+	require.Len(t, recorder.Events, 1)
+	event := <-recorder.Events
+	require.Contains(t, event, stuckEventReason)
+	require.Contains(t, event, "stuck")
+	require.Contains(t, event, "error deleting volumes")
+
+	// The finalizer is removed from the resource past the removal timeout
+	migration, err := storkops.Instance().GetMigration("expired", "test")
+	require.NoError(t, err)
+	require.False(t, controllers.ContainsFinalizer(migration, controllers.FinalizerCleanup))
+	restore, err := storkops.Instance().GetApplicationRestore("stuck", "test")
+	require.NoError(t, err)
+	require.True(t, controllers.ContainsFinalizer(restore, controllers.FinalizerCleanup))
+}
@@ -0,0 +1,91 @@
+package cleanupaudit
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// stuckEventReason is the reason for events raised on resources held
+	// back by the cleanup finalizer
+	stuckEventReason = "CleanupStuck"
+	// forceRemovalReason is the reason logged when the monitor removes the
+	// finalizer
+	forceRemovalReason = "cleanup finalizer timeout exceeded"
+)
+
+// Monitor periodically checks for stork resources held back by the cleanup
+// finalizer. Resources that have been terminating for longer than the
+// warning threshold are reported with an event. If a removal timeout is set,
+// the finalizer is removed from resources that have been terminating for
+// longer than that as a safety net.
+type Monitor struct {
+	Recorder record.EventRecorder
+	// Interval at which resources are checked
+	Interval time.Duration
+	// WarningThreshold after which terminating resources are reported
+	WarningThreshold time.Duration
+	// RemovalTimeout after which the finalizer is removed. Disabled if 0.
+	RemovalTimeout time.Duration
+	// Namespaces the monitor is restricted to. All namespaces are checked
+	// if it is empty.
+	Namespaces  []string
+	stopChannel chan os.Signal
+}
+
+// Start starts monitoring stuck resources in the background
+func (m *Monitor) Start(stopChannel chan os.Signal) {
+	m.stopChannel = stopChannel
+	go m.run()
+}
+
+func (m *Monitor) run() {
+	for {
+		select {
+		case <-time.After(m.Interval):
+			if err := m.check(); err != nil {
+				logrus.Errorf("Error checking for resources held back by the cleanup finalizer: %v", err)
+			}
+		case <-m.stopChannel:
+			return
+		}
+	}
+}
+
+func (m *Monitor) check() error {
+	stuck := make([]*StuckResource, 0)
+	for _, ns := range controllers.NamespacesToList(m.Namespaces) {
+		nsStuck, err := List(ns)
+		if err != nil {
+			return err
+		}
+		stuck = append(stuck, nsStuck...)
+	}
+	for _, r := range stuck {
+		terminatingFor := r.TerminatingFor()
+		if terminatingFor < m.WarningThreshold {
+			continue
+		}
+		meta := r.GetMeta()
+		if m.RemovalTimeout > 0 && terminatingFor >= m.RemovalTimeout {
+			if err := ForceRemoveFinalizer(r, forceRemovalReason); err != nil {
+				logrus.Errorf("%v", err)
+			}
+			continue
+		}
+		msg := fmt.Sprintf("%v %v/%v has been terminating for %v waiting for cleanup",
+			r.Kind, meta.GetNamespace(), meta.GetName(), terminatingFor.Round(time.Second))
+		if r.CleanupError != "" {
+			msg = fmt.Sprintf("%v: %v", msg, r.CleanupError)
+		}
+		logrus.Warnf(msg)
+		m.Recorder.Event(r.Object, v1.EventTypeWarning, stuckEventReason, msg)
+	}
+	return nil
+}
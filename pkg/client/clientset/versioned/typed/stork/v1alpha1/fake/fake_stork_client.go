@@ -64,10 +64,30 @@ func (c *FakeStorkV1alpha1) ClusterPairs(namespace string) v1alpha1.ClusterPairI
 	return &FakeClusterPairs{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) ClusterRules() v1alpha1.ClusterRuleInterface {
+	return &FakeClusterRules{c}
+}
+
+func (c *FakeStorkV1alpha1) DRDrills(namespace string) v1alpha1.DRDrillInterface {
+	return &FakeDRDrills{c, namespace}
+}
+
+func (c *FakeStorkV1alpha1) DRDrillReports(namespace string) v1alpha1.DRDrillReportInterface {
+	return &FakeDRDrillReports{c, namespace}
+}
+
+func (c *FakeStorkV1alpha1) DataCopies(namespace string) v1alpha1.DataCopyInterface {
+	return &FakeDataCopies{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) DataExports(namespace string) v1alpha1.DataExportInterface {
 	return &FakeDataExports{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) DataProtectionStatuses(namespace string) v1alpha1.DataProtectionStatusInterface {
+	return &FakeDataProtectionStatuses{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) GroupVolumeSnapshots(namespace string) v1alpha1.GroupVolumeSnapshotInterface {
 	return &FakeGroupVolumeSnapshots{c, namespace}
 }
@@ -84,6 +104,10 @@ func (c *FakeStorkV1alpha1) NamespacedSchedulePolicies(namespace string) v1alpha
 	return &FakeNamespacedSchedulePolicies{c, namespace}
 }
 
+func (c *FakeStorkV1alpha1) RegistryMappings(namespace string) v1alpha1.RegistryMappingInterface {
+	return &FakeRegistryMappings{c, namespace}
+}
+
 func (c *FakeStorkV1alpha1) Rules(namespace string) v1alpha1.RuleInterface {
 	return &FakeRules{c, namespace}
 }
@@ -92,6 +116,10 @@ func (c *FakeStorkV1alpha1) SchedulePolicies() v1alpha1.SchedulePolicyInterface
 	return &FakeSchedulePolicies{c}
 }
 
+func (c *FakeStorkV1alpha1) StorkConfigurations() v1alpha1.StorkConfigurationInterface {
+	return &FakeStorkConfigurations{c}
+}
+
 func (c *FakeStorkV1alpha1) VolumeSnapshotRestores(namespace string) v1alpha1.VolumeSnapshotRestoreInterface {
 	return &FakeVolumeSnapshotRestores{c, namespace}
 }
@@ -36,8 +36,18 @@ type ClusterDomainsStatusExpansion interface{}
 
 type ClusterPairExpansion interface{}
 
+type ClusterRuleExpansion interface{}
+
+type DRDrillExpansion interface{}
+
+type DRDrillReportExpansion interface{}
+
+type DataCopyExpansion interface{}
+
 type DataExportExpansion interface{}
 
+type DataProtectionStatusExpansion interface{}
+
 type GroupVolumeSnapshotExpansion interface{}
 
 type MigrationExpansion interface{}
@@ -46,10 +56,14 @@ type MigrationScheduleExpansion interface{}
 
 type NamespacedSchedulePolicyExpansion interface{}
 
+type RegistryMappingExpansion interface{}
+
 type RuleExpansion interface{}
 
 type SchedulePolicyExpansion interface{}
 
+type StorkConfigurationExpansion interface{}
+
 type VolumeSnapshotRestoreExpansion interface{}
 
 type VolumeSnapshotScheduleExpansion interface{}
@@ -35,13 +35,20 @@ type StorkV1alpha1Interface interface {
 	ClusterDomainUpdatesGetter
 	ClusterDomainsStatusesGetter
 	ClusterPairsGetter
+	ClusterRulesGetter
+	DRDrillsGetter
+	DRDrillReportsGetter
+	DataCopiesGetter
 	DataExportsGetter
+	DataProtectionStatusesGetter
 	GroupVolumeSnapshotsGetter
 	MigrationsGetter
 	MigrationSchedulesGetter
 	NamespacedSchedulePoliciesGetter
+	RegistryMappingsGetter
 	RulesGetter
 	SchedulePoliciesGetter
+	StorkConfigurationsGetter
 	VolumeSnapshotRestoresGetter
 	VolumeSnapshotSchedulesGetter
 }
@@ -87,10 +94,30 @@ func (c *StorkV1alpha1Client) ClusterPairs(namespace string) ClusterPairInterfac
 	return newClusterPairs(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) ClusterRules() ClusterRuleInterface {
+	return newClusterRules(c)
+}
+
+func (c *StorkV1alpha1Client) DRDrills(namespace string) DRDrillInterface {
+	return newDRDrills(c, namespace)
+}
+
+func (c *StorkV1alpha1Client) DRDrillReports(namespace string) DRDrillReportInterface {
+	return newDRDrillReports(c, namespace)
+}
+
+func (c *StorkV1alpha1Client) DataCopies(namespace string) DataCopyInterface {
+	return newDataCopies(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) DataExports(namespace string) DataExportInterface {
 	return newDataExports(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) DataProtectionStatuses(namespace string) DataProtectionStatusInterface {
+	return newDataProtectionStatuses(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) GroupVolumeSnapshots(namespace string) GroupVolumeSnapshotInterface {
 	return newGroupVolumeSnapshots(c, namespace)
 }
@@ -107,6 +134,10 @@ func (c *StorkV1alpha1Client) NamespacedSchedulePolicies(namespace string) Names
 	return newNamespacedSchedulePolicies(c, namespace)
 }
 
+func (c *StorkV1alpha1Client) RegistryMappings(namespace string) RegistryMappingInterface {
+	return newRegistryMappings(c, namespace)
+}
+
 func (c *StorkV1alpha1Client) Rules(namespace string) RuleInterface {
 	return newRules(c, namespace)
 }
@@ -115,6 +146,10 @@ func (c *StorkV1alpha1Client) SchedulePolicies() SchedulePolicyInterface {
 	return newSchedulePolicies(c)
 }
 
+func (c *StorkV1alpha1Client) StorkConfigurations() StorkConfigurationInterface {
+	return newStorkConfigurations(c)
+}
+
 func (c *StorkV1alpha1Client) VolumeSnapshotRestores(namespace string) VolumeSnapshotRestoreInterface {
 	return newVolumeSnapshotRestores(c, namespace)
 }
@@ -71,8 +71,18 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterDomainsStatuses().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("clusterpairs"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterPairs().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("clusterrules"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().ClusterRules().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("drdrills"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DRDrills().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("drdrillreports"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DRDrillReports().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("datacopies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DataCopies().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("dataexports"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DataExports().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("dataprotectionstatuses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().DataProtectionStatuses().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("groupvolumesnapshots"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().GroupVolumeSnapshots().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("migrations"):
@@ -81,10 +91,14 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().MigrationSchedules().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("namespacedschedulepolicies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().NamespacedSchedulePolicies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("registrymappings"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().RegistryMappings().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("rules"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().Rules().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("schedulepolicies"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().SchedulePolicies().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("storkconfigurations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().StorkConfigurations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("volumesnapshotrestores"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Stork().V1alpha1().VolumeSnapshotRestores().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("volumesnapshotschedules"):
@@ -42,8 +42,18 @@ type Interface interface {
 	ClusterDomainsStatuses() ClusterDomainsStatusInformer
 	// ClusterPairs returns a ClusterPairInformer.
 	ClusterPairs() ClusterPairInformer
+	// ClusterRules returns a ClusterRuleInformer.
+	ClusterRules() ClusterRuleInformer
+	// DRDrills returns a DRDrillInformer.
+	DRDrills() DRDrillInformer
+	// DRDrillReports returns a DRDrillReportInformer.
+	DRDrillReports() DRDrillReportInformer
+	// DataCopies returns a DataCopyInformer.
+	DataCopies() DataCopyInformer
 	// DataExports returns a DataExportInformer.
 	DataExports() DataExportInformer
+	// DataProtectionStatuses returns a DataProtectionStatusInformer.
+	DataProtectionStatuses() DataProtectionStatusInformer
 	// GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 	GroupVolumeSnapshots() GroupVolumeSnapshotInformer
 	// Migrations returns a MigrationInformer.
@@ -52,10 +62,14 @@ type Interface interface {
 	MigrationSchedules() MigrationScheduleInformer
 	// NamespacedSchedulePolicies returns a NamespacedSchedulePolicyInformer.
 	NamespacedSchedulePolicies() NamespacedSchedulePolicyInformer
+	// RegistryMappings returns a RegistryMappingInformer.
+	RegistryMappings() RegistryMappingInformer
 	// Rules returns a RuleInformer.
 	Rules() RuleInformer
 	// SchedulePolicies returns a SchedulePolicyInformer.
 	SchedulePolicies() SchedulePolicyInformer
+	// StorkConfigurations returns a StorkConfigurationInformer.
+	StorkConfigurations() StorkConfigurationInformer
 	// VolumeSnapshotRestores returns a VolumeSnapshotRestoreInformer.
 	VolumeSnapshotRestores() VolumeSnapshotRestoreInformer
 	// VolumeSnapshotSchedules returns a VolumeSnapshotScheduleInformer.
@@ -118,11 +132,36 @@ func (v *version) ClusterPairs() ClusterPairInformer {
 	return &clusterPairInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// ClusterRules returns a ClusterRuleInformer.
+func (v *version) ClusterRules() ClusterRuleInformer {
+	return &clusterRuleInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// DRDrills returns a DRDrillInformer.
+func (v *version) DRDrills() DRDrillInformer {
+	return &dRDrillInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DRDrillReports returns a DRDrillReportInformer.
+func (v *version) DRDrillReports() DRDrillReportInformer {
+	return &dRDrillReportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// DataCopies returns a DataCopyInformer.
+func (v *version) DataCopies() DataCopyInformer {
+	return &dataCopyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // DataExports returns a DataExportInformer.
 func (v *version) DataExports() DataExportInformer {
 	return &dataExportInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// DataProtectionStatuses returns a DataProtectionStatusInformer.
+func (v *version) DataProtectionStatuses() DataProtectionStatusInformer {
+	return &dataProtectionStatusInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // GroupVolumeSnapshots returns a GroupVolumeSnapshotInformer.
 func (v *version) GroupVolumeSnapshots() GroupVolumeSnapshotInformer {
 	return &groupVolumeSnapshotInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -143,6 +182,11 @@ func (v *version) NamespacedSchedulePolicies() NamespacedSchedulePolicyInformer
 	return &namespacedSchedulePolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// RegistryMappings returns a RegistryMappingInformer.
+func (v *version) RegistryMappings() RegistryMappingInformer {
+	return &registryMappingInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // Rules returns a RuleInformer.
 func (v *version) Rules() RuleInformer {
 	return &ruleInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -153,6 +197,11 @@ func (v *version) SchedulePolicies() SchedulePolicyInformer {
 	return &schedulePolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// StorkConfigurations returns a StorkConfigurationInformer.
+func (v *version) StorkConfigurations() StorkConfigurationInformer {
+	return &storkConfigurationInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // VolumeSnapshotRestores returns a VolumeSnapshotRestoreInformer.
 func (v *version) VolumeSnapshotRestores() VolumeSnapshotRestoreInformer {
 	return &volumeSnapshotRestoreInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
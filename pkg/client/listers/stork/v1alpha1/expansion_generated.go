@@ -78,6 +78,34 @@ type ClusterPairListerExpansion interface{}
 // ClusterPairNamespaceLister.
 type ClusterPairNamespaceListerExpansion interface{}
 
+// ClusterRuleListerExpansion allows custom methods to be added to
+// ClusterRuleLister.
+type ClusterRuleListerExpansion interface{}
+
+// DRDrillListerExpansion allows custom methods to be added to
+// DRDrillLister.
+type DRDrillListerExpansion interface{}
+
+// DRDrillNamespaceListerExpansion allows custom methods to be added to
+// DRDrillNamespaceLister.
+type DRDrillNamespaceListerExpansion interface{}
+
+// DRDrillReportListerExpansion allows custom methods to be added to
+// DRDrillReportLister.
+type DRDrillReportListerExpansion interface{}
+
+// DRDrillReportNamespaceListerExpansion allows custom methods to be added to
+// DRDrillReportNamespaceLister.
+type DRDrillReportNamespaceListerExpansion interface{}
+
+// DataCopyListerExpansion allows custom methods to be added to
+// DataCopyLister.
+type DataCopyListerExpansion interface{}
+
+// DataCopyNamespaceListerExpansion allows custom methods to be added to
+// DataCopyNamespaceLister.
+type DataCopyNamespaceListerExpansion interface{}
+
 // DataExportListerExpansion allows custom methods to be added to
 // DataExportLister.
 type DataExportListerExpansion interface{}
@@ -86,6 +114,14 @@ type DataExportListerExpansion interface{}
 // DataExportNamespaceLister.
 type DataExportNamespaceListerExpansion interface{}
 
+// DataProtectionStatusListerExpansion allows custom methods to be added to
+// DataProtectionStatusLister.
+type DataProtectionStatusListerExpansion interface{}
+
+// DataProtectionStatusNamespaceListerExpansion allows custom methods to be added to
+// DataProtectionStatusNamespaceLister.
+type DataProtectionStatusNamespaceListerExpansion interface{}
+
 // GroupVolumeSnapshotListerExpansion allows custom methods to be added to
 // GroupVolumeSnapshotLister.
 type GroupVolumeSnapshotListerExpansion interface{}
@@ -118,6 +154,14 @@ type NamespacedSchedulePolicyListerExpansion interface{}
 // NamespacedSchedulePolicyNamespaceLister.
 type NamespacedSchedulePolicyNamespaceListerExpansion interface{}
 
+// RegistryMappingListerExpansion allows custom methods to be added to
+// RegistryMappingLister.
+type RegistryMappingListerExpansion interface{}
+
+// RegistryMappingNamespaceListerExpansion allows custom methods to be added to
+// RegistryMappingNamespaceLister.
+type RegistryMappingNamespaceListerExpansion interface{}
+
 // RuleListerExpansion allows custom methods to be added to
 // RuleLister.
 type RuleListerExpansion interface{}
@@ -130,6 +174,10 @@ type RuleNamespaceListerExpansion interface{}
 // SchedulePolicyLister.
 type SchedulePolicyListerExpansion interface{}
 
+// StorkConfigurationListerExpansion allows custom methods to be added to
+// StorkConfigurationLister.
+type StorkConfigurationListerExpansion interface{}
+
 // VolumeSnapshotRestoreListerExpansion allows custom methods to be added to
 // VolumeSnapshotRestoreLister.
 type VolumeSnapshotRestoreListerExpansion interface{}
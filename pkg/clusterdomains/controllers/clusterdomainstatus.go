@@ -11,14 +11,12 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/portworx/sched-ops/task"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -28,10 +26,10 @@ import (
 )
 
 const (
-	validateCRDInterval    time.Duration = 5 * time.Second
-	validateCRDTimeout     time.Duration = 1 * time.Minute
-	createCdsTimeout                     = 30 * time.Minute
-	createCdsRetryInterval               = 10 * time.Second
+	clustersDomainsStatusControllerName = "clusters-domains-status-controller"
+
+	createCdsTimeout       = 30 * time.Minute
+	createCdsRetryInterval = 10 * time.Second
 )
 
 var (
@@ -64,7 +62,7 @@ func (c *ClusterDomainsStatusController) Init(mgr manager.Manager) error {
 
 	go func() { c.createClusterDomainsStatusObject() }()
 
-	return controllers.RegisterTo(mgr, "clusters-domains-status-controller", c, &storkv1.ClusterDomainsStatus{})
+	return controllers.RegisterTo(mgr, clustersDomainsStatusControllerName, c, &storkv1.ClusterDomainsStatus{})
 }
 
 // Reconcile updates the cluster about the changes in the ClusterDomainsStatus CRD.
@@ -82,15 +80,15 @@ func (c *ClusterDomainsStatusController) Reconcile(ctx context.Context, request
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clustersDomainsStatusControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if err = c.handle(context.TODO(), apiClusterDomainsStatus); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(c), apiClusterDomainsStatus.Namespace, apiClusterDomainsStatus.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clustersDomainsStatusControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(clustersDomainsStatusControllerName, controllers.DefaultRequeue)}, nil
 }
 
 // Handle updates the cluster about the changes in the ClusterDomainsStatus CRD
@@ -180,31 +178,7 @@ func (c *ClusterDomainsStatusController) createClusterDomainsStatusObject() {
 
 // createCRD creates the CRD for ClusterDomainsStatus object
 func (c *ClusterDomainsStatusController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:       storkv1.ClusterDomainsStatusResourceName,
-		Plural:     storkv1.ClusterDomainsStatusPlural,
-		Group:      storkv1.SchemeGroupVersion.Group,
-		Version:    storkv1.SchemeGroupVersion.Version,
-		Scope:      apiextensionsv1beta1.ClusterScoped,
-		Kind:       reflect.TypeOf(storkv1.ClusterDomainsStatus{}).Name(),
-		ShortNames: []string{storkv1.ClusterDomainsStatusShortName},
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(storkv1.ClusterDomainsStatus{}).Name())
 }
 
 func getNameForClusterDomainsStatus(clusterID string) string {
@@ -8,14 +8,12 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/log"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,10 +21,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
+const clusterDomainUpdateControllerName = "cluster-domain-update-controller"
+
 // NewClusterDomainUpdate creates a new instance of ClusterDomainUpdateController.
 func NewClusterDomainUpdate(mgr manager.Manager, d volume.Driver, r record.EventRecorder) *ClusterDomainUpdateController {
 	return &ClusterDomainUpdateController{
-		client:    mgr.GetClient(),
+		client:    controllers.NewConditionClient(mgr.GetClient()),
 		volDriver: d,
 		recorder:  r,
 	}
@@ -47,7 +47,7 @@ func (c *ClusterDomainUpdateController) Init(mgr manager.Manager) error {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "cluster-domain-update-controller", c, &storkv1.ClusterDomainUpdate{})
+	return controllers.RegisterTo(mgr, clusterDomainUpdateControllerName, c, &storkv1.ClusterDomainUpdate{})
 }
 
 // Reconcile updates ClusterDomainUpdate resources.
@@ -65,15 +65,19 @@ func (c *ClusterDomainUpdateController) Reconcile(ctx context.Context, request r
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clusterDomainUpdateControllerName, controllers.DefaultRequeueError)}, err
+	}
+
+	if controllers.IsObserved(clusterDomainUpdate) {
+		return reconcile.Result{}, nil
 	}
 
 	if err = c.handle(context.TODO(), clusterDomainUpdate); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(c), clusterDomainUpdate.Namespace, clusterDomainUpdate.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clusterDomainUpdateControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, c.client, clusterDomainUpdate, storkconfig.GetRequeuePeriod(clusterDomainUpdateControllerName, controllers.DefaultRequeue))
 }
 
 func (c *ClusterDomainUpdateController) handle(ctx context.Context, clusterDomainUpdate *storkv1.ClusterDomainUpdate) error {
@@ -142,29 +146,5 @@ func (c *ClusterDomainUpdateController) handle(ctx context.Context, clusterDomai
 
 // createCRD creates the CRD for ClusterDomainsStatus object
 func (c *ClusterDomainUpdateController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:       storkv1.ClusterDomainUpdateResourceName,
-		Plural:     storkv1.ClusterDomainUpdatePlural,
-		Group:      storkv1.SchemeGroupVersion.Group,
-		Version:    storkv1.SchemeGroupVersion.Version,
-		Scope:      apiextensionsv1beta1.ClusterScoped,
-		Kind:       reflect.TypeOf(storkv1.ClusterDomainUpdate{}).Name(),
-		ShortNames: []string{storkv1.ClusterDomainUpdateShortName},
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(storkv1.ClusterDomainUpdate{}).Name())
 }
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// completedConditionSetter is implemented by the objects of long running
+// operations that have a Completed condition
+type completedConditionSetter interface {
+	SetCompletedCondition()
+}
+
+// conditionClient sets the Completed condition and the observed generation
+// on objects before they are updated, so that they follow the status however
+// it was reached
+type conditionClient struct {
+	client.Client
+}
+
+// NewConditionClient returns a client that keeps the Completed condition of
+// backups, restores, clones, migrations and snapshot restores in sync with
+// their status when they are updated. The observed generation of objects
+// with a terminal state is recorded too.
+func NewConditionClient(c client.Client) client.Client {
+	return &conditionClient{Client: c}
+}
+
+func (c *conditionClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if setter, ok := obj.(completedConditionSetter); ok {
+		setter.SetCompletedCondition()
+	}
+	setObservedGeneration(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
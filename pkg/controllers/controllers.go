@@ -3,6 +3,7 @@ package controllers
 import (
 	"time"
 
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -20,25 +21,48 @@ const (
 
 	// DefaultRequeueError is a reconcile period for a resource on error.
 	DefaultRequeueError = 2 * time.Second
+
+	// DefaultMaxConcurrentReconciles is the number of workers for a controller.
+	DefaultMaxConcurrentReconciles = 10
+
+	// maxReloadableReconciles is the number of workers that are started for
+	// each controller, so that the number of reconciles allowed by the stork
+	// configuration can be raised up to it without a restart
+	maxReloadableReconciles = 50
 )
 
 // RegisterTo creates a new controller for a provided config and registers it to the controller manager.
+// The controller stops starting new reconciles once shutdown has started and
+// waits for the reconcile scheduler before starting each reconcile. The
+// number of reconciles the controller runs at once is read from the stork
+// configuration and can be changed without a restart.
 func RegisterTo(mgr manager.Manager, name string, r reconcile.Reconciler, watchedObjects ...client.Object) error {
+	_, err := newController(mgr, name, r, watchedObjects...)
+	return err
+}
+
+func newController(mgr manager.Manager, name string, r reconcile.Reconciler, watchedObjects ...client.Object) (controller.Controller, error) {
+	// The scheduler limits the reconciles to the number configured for the
+	// controller, so start enough workers for the limit to be raised later
+	workers := storkconfig.GetMaxConcurrentReconciles(name, DefaultMaxConcurrentReconciles)
+	if workers < maxReloadableReconciles {
+		workers = maxReloadableReconciles
+	}
 	// Create a new controller
 	c, err := controller.New(name, mgr, controller.Options{
-		Reconciler:              r,
-		MaxConcurrentReconciles: 10,
+		Reconciler:              &shutdownAwareReconciler{&scheduledReconciler{name: name, Reconciler: r}},
+		MaxConcurrentReconciles: workers,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Watch for changes to primary resource
 	for _, obj := range watchedObjects {
 		if err = c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestForObject{}); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	return nil
+	return c, nil
 }
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"fmt"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// MaxEventHistory is the number of events kept in the event history of an
+// object. The oldest events are dropped once the limit is reached.
+const MaxEventHistory = 30
+
+// eventHistoryRecorder records events and also appends them to the event
+// history in the status of the object
+type eventHistoryRecorder struct {
+	record.EventRecorder
+}
+
+// NewEventHistoryRecorder returns an event recorder that also appends the
+// events for backups, restores and migrations to the event history in their
+// status. The history is persisted with the next update of the object.
+func NewEventHistoryRecorder(recorder record.EventRecorder) record.EventRecorder {
+	return &eventHistoryRecorder{EventRecorder: recorder}
+}
+
+func (r *eventHistoryRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.EventRecorder.Event(object, eventtype, reason, message)
+	recordEventHistory(object, eventtype, reason, message)
+}
+
+func (r *eventHistoryRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	recordEventHistory(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *eventHistoryRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	recordEventHistory(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func recordEventHistory(object runtime.Object, eventtype, reason, message string) {
+	switch o := object.(type) {
+	case *stork_api.ApplicationBackup:
+		o.Status.EventHistory = AppendEventHistory(o.Status.EventHistory, eventtype, reason, message)
+	case *stork_api.ApplicationRestore:
+		o.Status.EventHistory = AppendEventHistory(o.Status.EventHistory, eventtype, reason, message)
+	case *stork_api.Migration:
+		o.Status.EventHistory = AppendEventHistory(o.Status.EventHistory, eventtype, reason, message)
+	}
+}
+
+// AppendEventHistory appends an event to the history. Repeats of the last
+// event only bump its count and timestamp. The history is bounded to
+// MaxEventHistory entries.
+func AppendEventHistory(history []*stork_api.EventHistoryEntry, eventtype, reason, message string) []*stork_api.EventHistoryEntry {
+	now := metav1.Now()
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		if last.Type == eventtype && last.Reason == reason && last.Message == message {
+			last.Count++
+			last.Timestamp = now
+			return history
+		}
+	}
+	history = append(history, &stork_api.EventHistoryEntry{
+		Timestamp: now,
+		Type:      eventtype,
+		Reason:    reason,
+		Message:   message,
+		Count:     1,
+	})
+	if len(history) > MaxEventHistory {
+		history = history[len(history)-MaxEventHistory:]
+	}
+	return history
+}
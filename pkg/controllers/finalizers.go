@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -40,3 +41,50 @@ func RemoveFinalizer(meta metav1.Object, finalizer string) {
 	}
 	meta.SetFinalizers(new)
 }
+
+// SetCleanupError records the cleanup error in the status of the object.
+// Returns true if the status was changed and the object needs to be updated.
+func SetCleanupError(object metav1.Object, err error) bool {
+	if err == nil {
+		return false
+	}
+	cleanupError := getCleanupErrorField(object)
+	if cleanupError == nil || *cleanupError == err.Error() {
+		return false
+	}
+	*cleanupError = err.Error()
+	return true
+}
+
+// GetCleanupError returns the cleanup error recorded in the status of the
+// object.
+func GetCleanupError(object metav1.Object) string {
+	if cleanupError := getCleanupErrorField(object); cleanupError != nil {
+		return *cleanupError
+	}
+	return ""
+}
+
+// getCleanupErrorField returns the cleanup error field in the status of the
+// object, or nil for kinds that don't have one
+func getCleanupErrorField(object metav1.Object) *string {
+	switch o := object.(type) {
+	case *stork_api.ApplicationBackup:
+		return &o.Status.CleanupError
+	case *stork_api.ApplicationRestore:
+		return &o.Status.CleanupError
+	case *stork_api.ApplicationClone:
+		return &o.Status.CleanupError
+	case *stork_api.Migration:
+		return &o.Status.CleanupError
+	case *stork_api.MigrationSchedule:
+		return &o.Status.CleanupError
+	case *stork_api.ClusterPair:
+		return &o.Status.CleanupError
+	case *stork_api.VolumeSnapshotRestore:
+		return &o.Status.CleanupError
+	case *stork_api.GroupVolumeSnapshot:
+		return &o.Status.CleanupError
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// generationObserver is implemented by the objects of operations that reach
+// a terminal state. The controllers record the generation they last handled
+// so that terminal objects whose spec hasn't changed since aren't reconciled
+// again.
+type generationObserver interface {
+	IsTerminal() bool
+	GetObservedGeneration() int64
+	SetObservedGeneration(int64)
+}
+
+// setObservedGeneration records the generation of the object before it is
+// updated. The stork CRDs don't have a status subresource for the version
+// used by the controllers, so the API server bumps the generation on any
+// change to the spec or the status. Setting the observed generation is
+// itself such a change, so the object is always observed at the generation
+// it will have after the update.
+func setObservedGeneration(obj client.Object) {
+	if observer, ok := obj.(generationObserver); ok {
+		observer.SetObservedGeneration(obj.GetGeneration() + 1)
+	}
+}
+
+// IsObserved returns true if the object is in a terminal state and its
+// current generation has already been handled. Such objects don't need to be
+// reconciled since nothing changes for them until their spec is updated or
+// they are deleted.
+func IsObserved(obj client.Object) bool {
+	observer, ok := obj.(generationObserver)
+	if !ok || obj.GetDeletionTimestamp() != nil || !observer.IsTerminal() {
+		return false
+	}
+	return observer.GetObservedGeneration() >= obj.GetGeneration()
+}
+
+// Requeue returns the result of a successful reconcile of the object.
+// Objects are requeued after the period until they reach a terminal state.
+// Terminal objects that were last updated by something other than the
+// controller have their generation observed so that the following
+// reconciles are skipped.
+func Requeue(ctx context.Context, c client.Client, obj client.Object, period time.Duration) (reconcile.Result, error) {
+	observer, ok := obj.(generationObserver)
+	if !ok || obj.GetDeletionTimestamp() != nil || !observer.IsTerminal() {
+		return reconcile.Result{RequeueAfter: period}, nil
+	}
+	if IsObserved(obj) {
+		return reconcile.Result{}, nil
+	}
+	setObservedGeneration(obj)
+	if err := c.Update(ctx, obj); err != nil {
+		if errors.IsConflict(err) || errors.IsNotFound(err) {
+			// The update triggers another reconcile, or the object is gone
+			return reconcile.Result{RequeueAfter: period}, nil
+		}
+		return reconcile.Result{RequeueAfter: period}, err
+	}
+	return reconcile.Result{}, nil
+}
@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespacesToList returns the namespaces that objects should be listed from
+// by a background loop restricted to the given namespaces. All namespaces
+// are listed if no namespaces are given.
+func NamespacesToList(namespaces []string) []string {
+	if len(namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	return namespaces
+}
+
+// IsNamespaceWatched returns true if objects in the namespace should be
+// handled by a background loop restricted to the given namespaces
+func IsNamespaceWatched(namespaces []string, namespace string) bool {
+	if len(namespaces) == 0 {
+		return true
+	}
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
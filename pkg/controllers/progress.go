@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"github.com/libopenstorage/stork/drivers/volume"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// progressBufferSize is the number of progress events that can be queued for
+// a controller. Events are dropped once it is full since the objects are
+// still requeued periodically.
+const progressBufferSize = 1024
+
+// RegisterWithProgressTo registers a controller like RegisterTo. The objects
+// of the kind are also reconciled whenever a volume driver reports progress
+// for them, so that their status is updated right away instead of at the
+// next requeue. newObject returns an object of the kind with the namespace
+// and name set.
+func RegisterWithProgressTo(
+	mgr manager.Manager,
+	name string,
+	r reconcile.Reconciler,
+	kind string,
+	newObject func() client.Object,
+	watchedObjects ...client.Object,
+) error {
+	c, err := newController(mgr, name, r, watchedObjects...)
+	if err != nil {
+		return err
+	}
+
+	events := make(chan event.GenericEvent, progressBufferSize)
+	if err := c.Watch(&source.Channel{Source: events}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+	volume.OnProgress(func(progressKind, namespace, name string) {
+		if progressKind != kind {
+			return
+		}
+		object := newObject()
+		object.SetNamespace(namespace)
+		object.SetName(name)
+		select {
+		case events <- event.GenericEvent{Object: object}:
+		default:
+		}
+	})
+	return nil
+}
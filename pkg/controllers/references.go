@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxReferenceUpdateRetries is the number of times the update of a
+// back-link is retried if the object was modified concurrently
+const maxReferenceUpdateRetries = 5
+
+// NewReference returns a reference to the object with the given kind
+func NewReference(kind string, meta metav1.Object) stork_api.ObjectReference {
+	return stork_api.ObjectReference{
+		Kind:      kind,
+		Namespace: meta.GetNamespace(),
+		Name:      meta.GetName(),
+	}
+}
+
+// AddReference adds the reference if it doesn't exist. Returns true if the
+// references were changed.
+func AddReference(references []stork_api.ObjectReference, ref stork_api.ObjectReference) ([]stork_api.ObjectReference, bool) {
+	for _, r := range references {
+		if r == ref {
+			return references, false
+		}
+	}
+	return append(references, ref), true
+}
+
+// RemoveReference removes the reference if it exists. Returns true if the
+// references were changed.
+func RemoveReference(references []stork_api.ObjectReference, ref stork_api.ObjectReference) ([]stork_api.ObjectReference, bool) {
+	for i, r := range references {
+		if r == ref {
+			return append(references[:i:i], references[i+1:]...), true
+		}
+	}
+	return references, false
+}
+
+func updateReferences(references []stork_api.ObjectReference, ref stork_api.ObjectReference, add bool) ([]stork_api.ObjectReference, bool) {
+	if add {
+		return AddReference(references, ref)
+	}
+	return RemoveReference(references, ref)
+}
+
+// UpdateBackupReference adds or removes the reference in the status of the
+// backup. Removing a reference from a backup that doesn't exist anymore
+// isn't an error.
+func UpdateBackupReference(name string, namespace string, ref stork_api.ObjectReference, add bool) error {
+	return retryReferenceUpdate(add, func() error {
+		backup, err := storkops.Instance().GetApplicationBackup(name, namespace)
+		if err != nil {
+			return err
+		}
+		references, changed := updateReferences(backup.Status.References, ref, add)
+		if !changed {
+			return nil
+		}
+		backup.Status.References = references
+		_, err = storkops.Instance().UpdateApplicationBackup(backup)
+		return err
+	})
+}
+
+// UpdateClusterPairReference adds or removes the reference in the status of
+// the cluster pair. Removing a reference from a cluster pair that doesn't
+// exist anymore isn't an error.
+func UpdateClusterPairReference(name string, namespace string, ref stork_api.ObjectReference, add bool) error {
+	return retryReferenceUpdate(add, func() error {
+		clusterPair, err := storkops.Instance().GetClusterPair(name, namespace)
+		if err != nil {
+			return err
+		}
+		references, changed := updateReferences(clusterPair.Status.References, ref, add)
+		if !changed {
+			return nil
+		}
+		clusterPair.Status.References = references
+		_, err = storkops.Instance().UpdateClusterPair(clusterPair)
+		return err
+	})
+}
+
+func retryReferenceUpdate(add bool, update func() error) error {
+	var err error
+	for i := 0; i < maxReferenceUpdateRetries; i++ {
+		err = update()
+		if errors.IsNotFound(err) && !add {
+			return nil
+		}
+		if !errors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
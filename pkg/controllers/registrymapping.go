@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetRegistryMapping returns the registries mapped by the RegistryMapping in
+// the namespace. An empty name doesn't map any registries.
+func GetRegistryMapping(ctx context.Context, c client.Client, namespace, name string) (map[string]string, error) {
+	registries := make(map[string]string)
+	if name == "" {
+		return registries, nil
+	}
+	mapping := &stork_api.RegistryMapping{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, mapping); err != nil {
+		return nil, fmt.Errorf("error getting registry mapping %v: %v", name, err)
+	}
+	for source, destination := range mapping.Spec.Registries {
+		registries[source] = destination
+	}
+	return registries, nil
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	// lowPriorityControllers are the controllers that can't use the reserved
+	// reconciles by default. Backups are usually created in bulk by schedules
+	// and shouldn't hold up migrations and restores.
+	lowPriorityControllers = map[string]bool{
+		"application-backup-controller":          true,
+		"application-backup-schedule-controller": true,
+	}
+
+	reconcilesWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_controller_reconciles_waiting",
+		Help: "Number of reconciles waiting for a slot from the reconcile scheduler",
+	}, []string{"controller"})
+	reconcilesActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_controller_reconciles_active",
+		Help: "Number of reconciles running for a controller",
+	}, []string{"controller"})
+
+	scheduler = newReconcileScheduler()
+)
+
+func init() {
+	prometheus.MustRegister(reconcilesWaiting)
+	prometheus.MustRegister(reconcilesActive)
+	// Wake up the waiting reconciles when the limits change
+	storkconfig.AddChangeListener(scheduler.wake)
+}
+
+// reconcileScheduler limits the number of reconciles running at once for
+// each controller and across all the controllers. A number of the reconciles
+// can be reserved for the controllers that aren't low priority so that they
+// still get to run while the low priority controllers are busy.
+type reconcileScheduler struct {
+	lock             sync.Mutex
+	cond             *sync.Cond
+	active           int
+	controllerActive map[string]int
+	// workers returns the number of reconciles the controller can run
+	workers func(name string) int
+}
+
+func newReconcileScheduler() *reconcileScheduler {
+	s := &reconcileScheduler{
+		controllerActive: make(map[string]int),
+		workers: func(name string) int {
+			return storkconfig.GetMaxConcurrentReconciles(name, DefaultMaxConcurrentReconciles)
+		},
+	}
+	s.cond = sync.NewCond(&s.lock)
+	return s
+}
+
+// limit returns the number of reconciles the controller can have running
+// across all the controllers, or 0 if it isn't limited
+func (s *reconcileScheduler) limit(name string) int {
+	limit, reserved := storkconfig.GetReconcileScheduling()
+	if limit == 0 {
+		return 0
+	}
+	if storkconfig.IsLowPriorityController(name, lowPriorityControllers[name]) {
+		limit -= reserved
+		if limit < 1 {
+			limit = 1
+		}
+	}
+	return limit
+}
+
+// acquire waits till the controller can start a reconcile
+func (s *reconcileScheduler) acquire(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	reconcilesWaiting.WithLabelValues(name).Inc()
+	for {
+		limit := s.limit(name)
+		if (limit == 0 || s.active < limit) && s.controllerActive[name] < s.workers(name) {
+			break
+		}
+		s.cond.Wait()
+	}
+	reconcilesWaiting.WithLabelValues(name).Dec()
+	reconcilesActive.WithLabelValues(name).Inc()
+	s.active++
+	s.controllerActive[name]++
+}
+
+// release frees the slot of a finished reconcile
+func (s *reconcileScheduler) release(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.active--
+	s.controllerActive[name]--
+	reconcilesActive.WithLabelValues(name).Dec()
+	s.cond.Broadcast()
+}
+
+// scheduledReconciler waits for a slot from the reconcile scheduler before
+// every reconcile
+type scheduledReconciler struct {
+	name string
+	reconcile.Reconciler
+}
+
+func (s *scheduledReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	scheduler.acquire(s.name)
+	defer scheduler.release(s.name)
+	return s.Reconciler.Reconcile(ctx, request)
+}
+
+// wake makes the waiting reconciles check the limits again
+func (s *reconcileScheduler) wake() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cond.Broadcast()
+}
@@ -0,0 +1,52 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerControllerLimit(t *testing.T) {
+	s := newReconcileScheduler()
+	var workers int32 = 1
+	s.workers = func(name string) int {
+		if name != "test-controller" {
+			return DefaultMaxConcurrentReconciles
+		}
+		return int(atomic.LoadInt32(&workers))
+	}
+
+	s.acquire("test-controller")
+	// Other controllers aren't limited by the test controller
+	s.acquire("other-controller")
+	s.release("other-controller")
+
+	acquired := make(chan struct{})
+	go func() {
+		s.acquire("test-controller")
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		require.Fail(t, "Expected reconcile to wait for the controller limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Raising the limit lets the waiting reconcile start without waiting for
+	// the running one to finish
+	atomic.StoreInt32(&workers, 2)
+	s.wake()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Expected reconcile to start after the limit was raised")
+	}
+	s.release("test-controller")
+	s.release("test-controller")
+	require.Zero(t, s.active)
+}
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ShutdownHandler is implemented by controllers that need to persist the
+// state of in-progress operations before stork exits.
+type ShutdownHandler interface {
+	// Checkpoint records the progress of in-progress operations so that they
+	// can be resumed by the next stork instance.
+	Checkpoint() error
+}
+
+// reconcileWaitInterval is how often the in-flight reconciles are checked
+// while waiting for them to finish on shutdown
+const reconcileWaitInterval = 100 * time.Millisecond
+
+var (
+	shuttingDown     int32
+	inflight         int32
+	shutdownHandlers []ShutdownHandler
+	shutdownLock     sync.Mutex
+)
+
+// RegisterShutdownHandler registers a handler to be called on shutdown.
+func RegisterShutdownHandler(handler ShutdownHandler) {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	shutdownHandlers = append(shutdownHandlers, handler)
+}
+
+// StartShutdown stops the controllers from starting any new reconciles.
+func StartShutdown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+// IsShuttingDown returns true once StartShutdown has been called.
+func IsShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
+// WaitForReconciles waits for the reconciles that were in flight when
+// StartShutdown was called to finish. Returns false if they didn't finish
+// before the timeout.
+func WaitForReconciles(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt32(&inflight) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(reconcileWaitInterval)
+	}
+	return true
+}
+
+// RunShutdownHandlers calls all the registered shutdown handlers. It should
+// be called once the in-flight reconciles have finished, so that they don't
+// update the objects concurrently, and before leadership is given up so
+// that the next leader sees the checkpoints.
+func RunShutdownHandlers() {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	for _, handler := range shutdownHandlers {
+		if err := handler.Checkpoint(); err != nil {
+			logrus.Errorf("Error checkpointing operations during shutdown: %v", err)
+		}
+	}
+}
+
+// NewCheckpoint returns a checkpoint for an operation in the given stage
+// owned by this stork instance.
+func NewCheckpoint(stage string) *stork_api.OperationCheckpoint {
+	owner, err := os.Hostname()
+	if err != nil {
+		logrus.Warnf("Error getting hostname for checkpoint: %v", err)
+	}
+	return &stork_api.OperationCheckpoint{
+		Stage:     stage,
+		Owner:     owner,
+		Timestamp: metav1.Now(),
+	}
+}
+
+// shutdownAwareReconciler skips reconciles once shutdown has started.
+type shutdownAwareReconciler struct {
+	reconcile.Reconciler
+}
+
+func (s *shutdownAwareReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// The reconcile is counted before checking for shutdown so that
+	// WaitForReconciles doesn't miss it
+	atomic.AddInt32(&inflight, 1)
+	defer atomic.AddInt32(&inflight, -1)
+	if IsShuttingDown() {
+		return reconcile.Result{RequeueAfter: DefaultRequeue}, nil
+	}
+	return s.Reconciler.Reconcile(ctx, request)
+}
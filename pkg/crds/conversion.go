@@ -0,0 +1,108 @@
+package crds
+
+import (
+	"sort"
+
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha2"
+	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EnableConversion adds the v1alpha2 version to the CRDs of the kinds that
+// support it and sets up the conversion webhook in the given service to
+// convert objects between the versions. v1alpha1 is kept as the storage
+// version.
+func EnableConversion(service *apiextensionsv1.ServiceReference, caBundle []byte) error {
+	// The webhook can be started before the controllers have registered
+	// the CRDs
+	if err := Register(getConversionKinds()...); err != nil {
+		return err
+	}
+	return updateConversion(func(crd *apiextensionsv1.CustomResourceDefinition, d *definition) {
+		version := getVersion(v1alpha2.SchemeGroupVersion.Version, d.v1alpha2.object, d.v1alpha2.required, d.v1alpha2.columns)
+		version.Subresources = &apiextensionsv1.CustomResourceSubresources{
+			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+		}
+		setVersion(crd, version)
+		crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+			Strategy: apiextensionsv1.WebhookConverter,
+			Webhook: &apiextensionsv1.WebhookConversion{
+				ClientConfig: &apiextensionsv1.WebhookClientConfig{
+					Service:  service,
+					CABundle: caBundle,
+				},
+				ConversionReviewVersions: []string{"v1"},
+			},
+		}
+	})
+}
+
+// DisableConversion removes the v1alpha2 version and the conversion webhook
+// from the CRDs in case they were enabled earlier
+func DisableConversion() error {
+	return updateConversion(func(crd *apiextensionsv1.CustomResourceDefinition, d *definition) {
+		versions := make([]apiextensionsv1.CustomResourceDefinitionVersion, 0, len(crd.Spec.Versions))
+		for _, version := range crd.Spec.Versions {
+			// Never remove the storage version
+			if version.Name != v1alpha2.SchemeGroupVersion.Version || version.Storage {
+				versions = append(versions, version)
+			}
+		}
+		crd.Spec.Versions = versions
+		if len(versions) == 1 {
+			crd.Spec.Conversion = &apiextensionsv1.CustomResourceConversion{
+				Strategy: apiextensionsv1.NoneConverter,
+			}
+		}
+	})
+}
+
+// updateConversion calls update for the CRDs of all the kinds that have a
+// v1alpha2 version and updates the CRDs that were changed
+func updateConversion(update func(*apiextensionsv1.CustomResourceDefinition, *definition)) error {
+	ok, err := version.RequiresV1Registration()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		logrus.Infof("Skipping CRD conversion since v1 CRDs aren't supported")
+		return nil
+	}
+
+	for _, kind := range getConversionKinds() {
+		d := definitions[kind]
+		existing, err := apiextensions.Instance().GetCRD(d.plural+"."+v1alpha2.SchemeGroupVersion.Group, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		crd := existing.DeepCopy()
+		update(crd, d)
+		if equality.Semantic.DeepEqual(existing.Spec, crd.Spec) {
+			continue
+		}
+		logrus.Infof("Updating conversion for CRD %v", crd.Name)
+		if _, err := apiextensions.Instance().UpdateCRD(crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getConversionKinds returns the kinds that have a v1alpha2 version
+func getConversionKinds() []string {
+	kinds := make([]string, 0)
+	for kind, d := range definitions {
+		if d.v1alpha2 != nil {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	return kinds
+}
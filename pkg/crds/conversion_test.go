@@ -0,0 +1,89 @@
+//go:build unittest
+// +build unittest
+
+package crds
+
+import (
+	"context"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha2"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	fakeextclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConversion(t *testing.T) {
+	fakeKubeClient := fakeclient.NewSimpleClientset()
+	fakeKubeClient.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.20.0"}
+	core.SetInstance(core.New(fakeKubeClient))
+	fakeExtClient := fakeextclient.NewSimpleClientset()
+	apiextensions.SetInstance(apiextensions.New(fakeExtClient))
+
+	crdNames := make([]string, 0)
+	for kind, d := range definitions {
+		if d.v1alpha2 == nil {
+			continue
+		}
+		crd := getCRD(apiextensions.CustomResource{
+			Name:       d.name,
+			Plural:     d.plural,
+			Group:      stork_api.SchemeGroupVersion.Group,
+			Version:    stork_api.SchemeGroupVersion.Version,
+			Scope:      d.scope,
+			Kind:       kind,
+			ShortNames: d.shortNames,
+		}, d)
+		crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{
+			{
+				Type:   apiextensionsv1.Established,
+				Status: apiextensionsv1.ConditionTrue,
+			},
+		}
+		_, err := fakeExtClient.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, metav1.CreateOptions{})
+		require.NoError(t, err)
+		crdNames = append(crdNames, crd.Name)
+	}
+	require.Len(t, crdNames, 3)
+
+	service := &apiextensionsv1.ServiceReference{Name: "stork-service", Namespace: "kube-system"}
+	require.NoError(t, EnableConversion(service, []byte("ca")))
+	for _, name := range crdNames {
+		crd, err := fakeExtClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Len(t, crd.Spec.Versions, 2)
+		require.True(t, crd.Spec.Versions[0].Storage, "v1alpha1 should be the storage version")
+		require.Nil(t, crd.Spec.Versions[0].Subresources)
+		require.Equal(t, v1alpha2.SchemeGroupVersion.Version, crd.Spec.Versions[1].Name)
+		require.False(t, crd.Spec.Versions[1].Storage)
+		require.NotNil(t, crd.Spec.Versions[1].Subresources.Status)
+		require.Contains(t, crd.Spec.Versions[1].Schema.OpenAPIV3Schema.Properties["status"].Properties, "conditions")
+		require.Equal(t, apiextensionsv1.WebhookConverter, crd.Spec.Conversion.Strategy)
+		require.Equal(t, service, crd.Spec.Conversion.Webhook.ClientConfig.Service)
+	}
+	// Registering the CRDs again shouldn't remove the v1alpha2 version
+	crd, err := fakeExtClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crdNames[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Nil(t, mergeCRD(crd, &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Names:    crd.Spec.Names,
+			Versions: crd.Spec.Versions[:1],
+		},
+	}))
+
+	require.NoError(t, DisableConversion())
+	for _, name := range crdNames {
+		crd, err := fakeExtClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		require.Len(t, crd.Spec.Versions, 1)
+		require.Equal(t, stork_api.SchemeGroupVersion.Version, crd.Spec.Versions[0].Name)
+		require.Equal(t, apiextensionsv1.NoneConverter, crd.Spec.Conversion.Strategy)
+	}
+}
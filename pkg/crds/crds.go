@@ -0,0 +1,157 @@
+package crds
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	validateCRDInterval time.Duration = 5 * time.Second
+	validateCRDTimeout  time.Duration = 1 * time.Minute
+)
+
+// Register creates the CRDs for the given stork kinds and waits for them to
+// be established. CRDs that already exist are updated, so CRDs created by
+// older versions of stork get the current schema, printer columns and short
+// names.
+func Register(kinds ...string) error {
+	for _, kind := range kinds {
+		d, ok := definitions[kind]
+		if !ok {
+			return fmt.Errorf("no CRD definition for kind %v", kind)
+		}
+		if err := register(kind, d); err != nil {
+			return fmt.Errorf("error registering CRD for %v: %v", kind, err)
+		}
+	}
+	return nil
+}
+
+// Names returns the names of all the CRDs that can be registered by stork,
+// sorted
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for _, d := range definitions {
+		names = append(names, d.plural+"."+stork_api.SchemeGroupVersion.Group)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func register(kind string, d *definition) error {
+	resource := apiextensions.CustomResource{
+		Name:       d.name,
+		Plural:     d.plural,
+		Group:      stork_api.SchemeGroupVersion.Group,
+		Version:    stork_api.SchemeGroupVersion.Version,
+		Scope:      d.scope,
+		Kind:       kind,
+		ShortNames: d.shortNames,
+	}
+	ok, err := version.RequiresV1Registration()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Schemas aren't registered for clusters that don't support v1
+		// CRDs since they don't need to be structural there
+		err = apiextensions.Instance().CreateCRDV1beta1(resource)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	}
+
+	crd := getCRD(resource, d)
+	existing, err := apiextensions.Instance().GetCRD(crd.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if err := apiextensions.Instance().RegisterCRD(crd); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	} else if updated := mergeCRD(existing, crd); updated != nil {
+		logrus.Infof("Updating CRD %v", crd.Name)
+		if _, err := apiextensions.Instance().UpdateCRD(updated); err != nil {
+			return err
+		}
+	}
+	return apiextensions.Instance().ValidateCRD(crd.Name, validateCRDTimeout, validateCRDInterval)
+}
+
+func getCRD(resource apiextensions.CustomResource, d *definition) *apiextensionsv1.CustomResourceDefinition {
+	version := getVersion(resource.Version, d.object, d.required, d.columns)
+	version.Storage = true
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: resource.Plural + "." + resource.Group,
+		},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group:    resource.Group,
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{version},
+			Scope:    apiextensionsv1.ResourceScope(resource.Scope),
+			Names: apiextensionsv1.CustomResourceDefinitionNames{
+				Singular:   resource.Name,
+				Plural:     resource.Plural,
+				Kind:       resource.Kind,
+				ListKind:   resource.Kind + "List",
+				ShortNames: resource.ShortNames,
+			},
+		},
+	}
+}
+
+func getVersion(
+	name string,
+	object interface{},
+	required []string,
+	columns []apiextensionsv1.CustomResourceColumnDefinition,
+) apiextensionsv1.CustomResourceDefinitionVersion {
+	return apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:   name,
+		Served: true,
+		Schema: &apiextensionsv1.CustomResourceValidation{
+			OpenAPIV3Schema: getSchema(object, required),
+		},
+		AdditionalPrinterColumns: columns,
+	}
+}
+
+// mergeCRD returns the existing CRD updated with the names and version from
+// the new CRD. Other versions in the existing CRD are kept as they are.
+// Returns nil if no update is needed.
+func mergeCRD(existing, crd *apiextensionsv1.CustomResourceDefinition) *apiextensionsv1.CustomResourceDefinition {
+	updated := existing.DeepCopy()
+	updated.Spec.Names = crd.Spec.Names
+	setVersion(updated, crd.Spec.Versions[0])
+	if equality.Semantic.DeepEqual(existing.Spec, updated.Spec) {
+		return nil
+	}
+	return updated
+}
+
+// setVersion replaces the version in the CRD, or adds it if the CRD doesn't
+// have it yet. The storage version is left unchanged so that it can be moved
+// to a newer version.
+func setVersion(crd *apiextensionsv1.CustomResourceDefinition, version apiextensionsv1.CustomResourceDefinitionVersion) {
+	for i := range crd.Spec.Versions {
+		if crd.Spec.Versions[i].Name == version.Name {
+			version.Storage = crd.Spec.Versions[i].Storage
+			crd.Spec.Versions[i] = version
+			return
+		}
+	}
+	version.Storage = false
+	crd.Spec.Versions = append(crd.Spec.Versions, version)
+}
@@ -0,0 +1,123 @@
+//go:build unittest
+// +build unittest
+
+package crds
+
+import (
+	"reflect"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// requireStructural checks that every node in the schema has a type, unless
+// it preserves unknown fields or is an int-or-string
+func requireStructural(t *testing.T, path string, schema *apiextensionsv1.JSONSchemaProps) {
+	if schema.Type == "" {
+		require.True(t, schema.XIntOrString || (schema.XPreserveUnknownFields != nil && *schema.XPreserveUnknownFields),
+			"No type for %v", path)
+	}
+	for name, property := range schema.Properties {
+		property := property
+		requireStructural(t, path+"."+name, &property)
+	}
+	if schema.Items != nil && schema.Items.Schema != nil {
+		requireStructural(t, path+"[]", schema.Items.Schema)
+	}
+	if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		requireStructural(t, path+"{}", schema.AdditionalProperties.Schema)
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	for kind, d := range definitions {
+		crd := getCRD(apiextensions.CustomResource{
+			Name:       d.name,
+			Plural:     d.plural,
+			Group:      stork_api.SchemeGroupVersion.Group,
+			Version:    stork_api.SchemeGroupVersion.Version,
+			Scope:      d.scope,
+			Kind:       kind,
+			ShortNames: d.shortNames,
+		}, d)
+		require.Equal(t, d.plural+".stork.libopenstorage.org", crd.Name)
+		require.NotEmpty(t, crd.Spec.Names.ShortNames, "No short names for %v", kind)
+		require.NotEmpty(t, crd.Spec.Versions[0].AdditionalPrinterColumns, "No printer columns for %v", kind)
+		schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema
+		requireStructural(t, kind, schema)
+		for _, path := range d.required {
+			require.NotContains(t, path, "..", "Invalid required path for %v", kind)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := getSchema(&stork_api.ApplicationBackup{}, []string{"spec", "spec.backupLocation", "spec.missing.field"})
+	require.Equal(t, []string{"spec"}, schema.Required)
+	spec := schema.Properties["spec"]
+	require.Equal(t, []string{"backupLocation"}, spec.Required)
+	require.Equal(t, "array", spec.Properties["namespaces"].Type)
+	require.Equal(t, "string", spec.Properties["namespaces"].Items.Schema.Type)
+	require.Equal(t, "object", spec.Properties["selectors"].Type)
+	require.Equal(t, "string", spec.Properties["selectors"].AdditionalProperties.Schema.Type)
+	require.True(t, spec.Properties["ociExport"].Nullable)
+
+	status := schema.Properties["status"]
+	require.Equal(t, "integer", status.Properties["totalSize"].Type)
+	require.Equal(t, "date-time", status.Properties["finishTimestamp"].Format)
+	require.True(t, status.Properties["finishTimestamp"].Nullable)
+	require.True(t, *status.XPreserveUnknownFields, "Unknown fields should be preserved")
+
+	// Embedded structs are inlined
+	restore := getSchema(&stork_api.ApplicationRestore{}, nil)
+	resource := restore.Properties["status"].Properties["resources"].Items.Schema
+	require.Contains(t, resource.Properties, "name")
+	require.Contains(t, resource.Properties, "kind")
+	require.NotContains(t, resource.Properties, "ObjectInfo")
+
+	// Durations and quantities
+	drill := getSchema(&stork_api.DRDrill{}, nil)
+	require.Equal(t, "string", drill.Properties["spec"].Properties["healthCheckTimeout"].Type)
+	initContainer := restore.Properties["spec"].Properties["initContainer"]
+	limits := initContainer.Properties["resources"].Properties["limits"]
+	require.True(t, limits.AdditionalProperties.Schema.XIntOrString)
+}
+
+func TestMergeCRD(t *testing.T) {
+	d := definitions[reflect.TypeOf(stork_api.Migration{}).Name()]
+	resource := apiextensions.CustomResource{
+		Name:    d.name,
+		Plural:  d.plural,
+		Group:   stork_api.SchemeGroupVersion.Group,
+		Version: stork_api.SchemeGroupVersion.Version,
+		Scope:   d.scope,
+		Kind:    "Migration",
+	}
+	crd := getCRD(resource, d)
+
+	require.Nil(t, mergeCRD(crd, getCRD(resource, d)), "No update needed for the same CRD")
+
+	existing := crd.DeepCopy()
+	preserve := true
+	existing.Spec.Names.ShortNames = nil
+	existing.Spec.Versions[0].Storage = false
+	existing.Spec.Versions[0].Schema.OpenAPIV3Schema = &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &preserve}
+	existing.Spec.Versions[0].AdditionalPrinterColumns = nil
+	existing.Spec.Versions = append(existing.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{
+		Name:    "v1alpha2",
+		Served:  true,
+		Storage: true,
+	})
+	updated := mergeCRD(existing, crd)
+	require.NotNil(t, updated)
+	require.Equal(t, crd.Spec.Names.ShortNames, updated.Spec.Names.ShortNames)
+	require.Len(t, updated.Spec.Versions, 2)
+	require.False(t, updated.Spec.Versions[0].Storage, "Storage version should not be changed")
+	require.Equal(t, crd.Spec.Versions[0].Schema, updated.Spec.Versions[0].Schema)
+	require.Equal(t, crd.Spec.Versions[0].AdditionalPrinterColumns, updated.Spec.Versions[0].AdditionalPrinterColumns)
+	require.Equal(t, "v1alpha2", updated.Spec.Versions[1].Name)
+	require.True(t, updated.Spec.Versions[1].Storage)
+}
@@ -0,0 +1,384 @@
+package crds
+
+import (
+	"reflect"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha2"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// definition describes a stork CRD
+type definition struct {
+	name       string
+	plural     string
+	shortNames []string
+	scope      apiextensionsv1beta1.ResourceScope
+	object     interface{}
+	// required are the paths of the fields that need to be set, for
+	// example "spec.backupLocation"
+	required []string
+	// columns are shown by kubectl in addition to the name
+	columns []apiextensionsv1.CustomResourceColumnDefinition
+	// v1alpha2 is set for the kinds that are also served as v1alpha2 when
+	// the conversion webhook is enabled
+	v1alpha2 *versionDefinition
+}
+
+// versionDefinition describes a version of a CRD other than the storage
+// version
+type versionDefinition struct {
+	object   interface{}
+	required []string
+	columns  []apiextensionsv1.CustomResourceColumnDefinition
+}
+
+func column(name, columnType, jsonPath string) apiextensionsv1.CustomResourceColumnDefinition {
+	return apiextensionsv1.CustomResourceColumnDefinition{
+		Name:     name,
+		Type:     columnType,
+		JSONPath: jsonPath,
+	}
+}
+
+var (
+	stageColumn  = column("Stage", "string", ".status.stage")
+	statusColumn = column("Status", "string", ".status.status")
+	phaseColumn  = column("Phase", "string", ".status.phase")
+	ageColumn    = column("Age", "date", ".metadata.creationTimestamp")
+)
+
+// definitions are all the CRDs registered by stork, keyed by kind
+var definitions = map[string]*definition{}
+
+func addDefinition(d *definition) {
+	definitions[reflect.TypeOf(d.object).Elem().Name()] = d
+}
+
+func init() {
+	addDefinition(&definition{
+		name:       stork_api.ApplicationBackupResourceName,
+		plural:     stork_api.ApplicationBackupResourcePlural,
+		shortNames: []string{"appbackup"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.ApplicationBackup{},
+		required:   []string{"spec", "spec.backupLocation", "spec.namespaces"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			stageColumn,
+			statusColumn,
+			column("Size", "integer", ".status.totalSize"),
+			ageColumn,
+		},
+		v1alpha2: &versionDefinition{
+			object:   &v1alpha2.ApplicationBackup{},
+			required: []string{"spec", "spec.backupLocationName", "spec.namespaces"},
+			columns: []apiextensionsv1.CustomResourceColumnDefinition{
+				stageColumn,
+				phaseColumn,
+				column("Size", "integer", ".status.totalSize"),
+				ageColumn,
+			},
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ApplicationBackupScheduleResourceName,
+		plural:     stork_api.ApplicationBackupScheduleResourcePlural,
+		shortNames: []string{"appbackupsched"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.ApplicationBackupSchedule{},
+		required:   []string{"spec", "spec.schedulePolicyName", "spec.template"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Policy", "string", ".spec.schedulePolicyName"),
+			column("Suspend", "boolean", ".spec.suspend"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ApplicationRestoreResourceName,
+		plural:     stork_api.ApplicationRestoreResourcePlural,
+		shortNames: []string{"apprestore"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.ApplicationRestore{},
+		required:   []string{"spec", "spec.backupName", "spec.backupLocation"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			stageColumn,
+			statusColumn,
+			column("Backup", "string", ".spec.backupName"),
+			column("Size", "integer", ".status.totalSize"),
+			ageColumn,
+		},
+		v1alpha2: &versionDefinition{
+			object:   &v1alpha2.ApplicationRestore{},
+			required: []string{"spec", "spec.backupName", "spec.backupLocationName"},
+			columns: []apiextensionsv1.CustomResourceColumnDefinition{
+				stageColumn,
+				phaseColumn,
+				column("Backup", "string", ".spec.backupName"),
+				column("Size", "integer", ".status.totalSize"),
+				ageColumn,
+			},
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ApplicationCloneResourceName,
+		plural:     stork_api.ApplicationCloneResourcePlural,
+		shortNames: []string{"appclone"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.ApplicationClone{},
+		required:   []string{"spec", "spec.destinationNamespace"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Source", "string", ".spec.sourceNamespace"),
+			column("Destination", "string", ".spec.destinationNamespace"),
+			stageColumn,
+			statusColumn,
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ApplicationRegistrationResourceName,
+		plural:     stork_api.ApplicationRegistrationResourcePlural,
+		shortNames: []string{"appreg"},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.ApplicationRegistration{},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.BackupLocationResourceName,
+		plural:     stork_api.BackupLocationResourcePlural,
+		shortNames: []string{"backuploc"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.BackupLocation{},
+		required:   []string{"location", "location.type"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Type", "string", ".location.type"),
+			column("Path", "string", ".location.path"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ClusterPairResourceName,
+		plural:     stork_api.ClusterPairResourcePlural,
+		shortNames: []string{"cpair"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.ClusterPair{},
+		required:   []string{"spec"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Storage Status", "string", ".status.storageStatus"),
+			column("Scheduler Status", "string", ".status.schedulerStatus"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ClusterDomainsStatusResourceName,
+		plural:     stork_api.ClusterDomainsStatusPlural,
+		shortNames: []string{stork_api.ClusterDomainsStatusShortName},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.ClusterDomainsStatus{},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Local Domain", "string", ".status.localDomain"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ClusterDomainUpdateResourceName,
+		plural:     stork_api.ClusterDomainUpdatePlural,
+		shortNames: []string{stork_api.ClusterDomainUpdateShortName},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.ClusterDomainUpdate{},
+		required:   []string{"spec"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Domain", "string", ".spec.clusterdomain"),
+			column("Active", "boolean", ".spec.active"),
+			statusColumn,
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.DataProtectionStatusResourceName,
+		plural:     stork_api.DataProtectionStatusResourcePlural,
+		shortNames: []string{"dpstatus"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.DataProtectionStatus{},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("RPO", "string", ".status.rpo"),
+			column("RTO", "string", ".status.rto"),
+			column("Updated", "date", ".status.lastUpdateTimestamp"),
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.DRDrillResourceName,
+		plural:     stork_api.DRDrillResourcePlural,
+		shortNames: []string{"drill"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.DRDrill{},
+		required:   []string{"spec", "spec.schedulePolicyName"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			stageColumn,
+			statusColumn,
+			column("Last Report", "string", ".status.lastReport"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.DRDrillReportResourceName,
+		plural:     stork_api.DRDrillReportResourcePlural,
+		shortNames: []string{"drillreport"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.DRDrillReport{},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Drill", "string", ".report.drillName"),
+			column("Status", "string", ".report.status"),
+			column("Recovery Time", "string", ".report.recoveryTime"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.DataCopyResourceName,
+		plural:     stork_api.DataCopyResourcePlural,
+		shortNames: []string{"dcopy"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.DataCopy{},
+		required:   []string{"spec", "spec.source", "spec.destination"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Source", "string", ".spec.source.name"),
+			column("Destination", "string", ".spec.destination.name"),
+			statusColumn,
+			column("Progress", "integer", ".status.progressPercentage"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.GroupVolumeSnapshotResourceName,
+		plural:     stork_api.GroupVolumeSnapshotResourcePlural,
+		shortNames: []string{"groupsnap"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.GroupVolumeSnapshot{},
+		required:   []string{"spec"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			stageColumn,
+			statusColumn,
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.MigrationResourceName,
+		plural:     stork_api.MigrationResourcePlural,
+		shortNames: []string{"migr"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.Migration{},
+		required:   []string{"spec", "spec.clusterPair", "spec.namespaces"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Cluster Pair", "string", ".spec.clusterPair"),
+			stageColumn,
+			statusColumn,
+			column("Volumes", "integer", ".status.summary.numOfMigratedVolumes"),
+			column("Resources", "integer", ".status.summary.numOfMigratedResources"),
+			ageColumn,
+		},
+		v1alpha2: &versionDefinition{
+			object:   &v1alpha2.Migration{},
+			required: []string{"spec", "spec.clusterPairName", "spec.namespaces"},
+			columns: []apiextensionsv1.CustomResourceColumnDefinition{
+				column("Cluster Pair", "string", ".spec.clusterPairName"),
+				stageColumn,
+				phaseColumn,
+				column("Volumes", "integer", ".status.summary.numOfMigratedVolumes"),
+				column("Resources", "integer", ".status.summary.numOfMigratedResources"),
+				ageColumn,
+			},
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.MigrationScheduleResourceName,
+		plural:     stork_api.MigrationScheduleResourcePlural,
+		shortNames: []string{"migrsched"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.MigrationSchedule{},
+		required:   []string{"spec", "spec.schedulePolicyName", "spec.template"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Policy", "string", ".spec.schedulePolicyName"),
+			column("Suspend", "boolean", ".spec.suspend"),
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.NamespacedSchedulePolicyResourceName,
+		plural:     stork_api.NamespacedSchedulePolicyResourcePlural,
+		shortNames: []string{"nsschedpolicy"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.NamespacedSchedulePolicy{},
+		required:   []string{"policy"},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.RegistryMappingResourceName,
+		plural:     stork_api.RegistryMappingResourcePlural,
+		shortNames: []string{"regmap"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.RegistryMapping{},
+		required:   []string{"spec", "spec.registries"},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       "rule",
+		plural:     "rules",
+		shortNames: []string{"storkrule"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.Rule{},
+		required:   []string{"rules"},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.ClusterRuleResourceName,
+		plural:     stork_api.ClusterRuleResourcePlural,
+		shortNames: []string{"storkclusterrule"},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.ClusterRule{},
+		required:   []string{"rules"},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{column("Version", "string", ".version"), ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.SchedulePolicyResourceName,
+		plural:     stork_api.SchedulePolicyResourcePlural,
+		shortNames: []string{"schedpolicy"},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.SchedulePolicy{},
+		required:   []string{"policy"},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.StorkConfigurationResourceName,
+		plural:     stork_api.StorkConfigurationResourcePlural,
+		shortNames: []string{"storkconfig"},
+		scope:      apiextensionsv1beta1.ClusterScoped,
+		object:     &stork_api.StorkConfiguration{},
+		columns:    []apiextensionsv1.CustomResourceColumnDefinition{ageColumn},
+	})
+	addDefinition(&definition{
+		name:       stork_api.SnapshotRestoreResourceName,
+		plural:     stork_api.SnapshotRestoreResourcePlural,
+		shortNames: []string{"snaprestore"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.VolumeSnapshotRestore{},
+		required:   []string{"spec", "spec.sourceName", "spec.sourceNamespace"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Source", "string", ".spec.sourceName"),
+			statusColumn,
+			ageColumn,
+		},
+	})
+	addDefinition(&definition{
+		name:       stork_api.VolumeSnapshotScheduleResourceName,
+		plural:     stork_api.VolumeSnapshotScheduleResourcePlural,
+		shortNames: []string{"snapsched"},
+		scope:      apiextensionsv1beta1.NamespaceScoped,
+		object:     &stork_api.VolumeSnapshotSchedule{},
+		required:   []string{"spec", "spec.schedulePolicyName", "spec.template"},
+		columns: []apiextensionsv1.CustomResourceColumnDefinition{
+			column("Policy", "string", ".spec.schedulePolicyName"),
+			column("Suspend", "boolean", ".spec.suspend"),
+			ageColumn,
+		},
+	})
+}
@@ -0,0 +1,168 @@
+package crds
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	timeType         = reflect.TypeOf(metav1.Time{})
+	microTimeType    = reflect.TypeOf(metav1.MicroTime{})
+	durationType     = reflect.TypeOf(metav1.Duration{})
+	quantityType     = reflect.TypeOf(resource.Quantity{})
+	intOrStringType  = reflect.TypeOf(intstr.IntOrString{})
+	typeMetaType     = reflect.TypeOf(metav1.TypeMeta{})
+	objectMetaType   = reflect.TypeOf(metav1.ObjectMeta{})
+	jsonMarshalerTyp = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// getSchema returns a structural schema for the object, generated from the
+// json tags of its type. Objects preserve unknown fields so that fields
+// added by newer versions of stork aren't pruned by older CRDs. The required
+// fields are paths like "spec.backupLocation".
+func getSchema(object interface{}, required []string) *apiextensionsv1.JSONSchemaProps {
+	preserve := true
+	schema := &apiextensionsv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: &preserve,
+		Properties: map[string]apiextensionsv1.JSONSchemaProps{
+			"apiVersion": {Type: "string"},
+			"kind":       {Type: "string"},
+			"metadata":   {Type: "object"},
+		},
+	}
+	addProperties(schema, reflect.TypeOf(object), make(map[reflect.Type]bool))
+	for _, path := range required {
+		setRequired(schema, strings.Split(path, "."))
+	}
+	return schema
+}
+
+// setRequired marks the last field in the path as required in its parent
+// object. Fields along the path that don't exist in the schema are ignored.
+func setRequired(schema *apiextensionsv1.JSONSchemaProps, path []string) {
+	if len(path) == 1 {
+		schema.Required = append(schema.Required, path[0])
+		return
+	}
+	child, ok := schema.Properties[path[0]]
+	if !ok {
+		return
+	}
+	setRequired(&child, path[1:])
+	schema.Properties[path[0]] = child
+}
+
+// addProperties adds the fields of the struct type to the schema. Embedded
+// structs are inlined like encoding/json does.
+func addProperties(schema *apiextensionsv1.JSONSchemaProps, t reflect.Type, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]apiextensionsv1.JSONSchemaProps)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type == typeMetaType || field.Type == objectMetaType {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" && field.Anonymous {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addProperties(schema, fieldType, seen)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			// Unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = *getTypeSchema(field.Type, seen)
+	}
+}
+
+func getTypeSchema(t reflect.Type, seen map[reflect.Type]bool) *apiextensionsv1.JSONSchemaProps {
+	preserve := true
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		nullable = true
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType, microTimeType:
+		// Zero times are serialized as null
+		return &apiextensionsv1.JSONSchemaProps{Type: "string", Format: "date-time", Nullable: true}
+	case durationType:
+		return &apiextensionsv1.JSONSchemaProps{Type: "string", Nullable: nullable}
+	case quantityType, intOrStringType:
+		return &apiextensionsv1.JSONSchemaProps{XIntOrString: true, Nullable: nullable}
+	}
+	if t.Implements(jsonMarshalerTyp) || reflect.PtrTo(t).Implements(jsonMarshalerTyp) {
+		// The serialized form is unknown
+		return &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &preserve, Nullable: true}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &apiextensionsv1.JSONSchemaProps{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &apiextensionsv1.JSONSchemaProps{Type: "integer", Nullable: nullable}
+	case reflect.Float32, reflect.Float64:
+		return &apiextensionsv1.JSONSchemaProps{Type: "number", Nullable: nullable}
+	case reflect.String:
+		return &apiextensionsv1.JSONSchemaProps{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &apiextensionsv1.JSONSchemaProps{Type: "string", Format: "byte", Nullable: true}
+		}
+		return &apiextensionsv1.JSONSchemaProps{
+			Type:     "array",
+			Nullable: true,
+			Items:    &apiextensionsv1.JSONSchemaPropsOrArray{Schema: getTypeSchema(t.Elem(), seen)},
+		}
+	case reflect.Map:
+		return &apiextensionsv1.JSONSchemaProps{
+			Type:     "object",
+			Nullable: true,
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{
+				Allows: true,
+				Schema: getTypeSchema(t.Elem(), seen),
+			},
+		}
+	case reflect.Struct:
+		schema := &apiextensionsv1.JSONSchemaProps{
+			Type:                   "object",
+			Nullable:               nullable,
+			XPreserveUnknownFields: &preserve,
+		}
+		// Recursive types are left unvalidated below the first level
+		if seen[t] {
+			return schema
+		}
+		seen[t] = true
+		addProperties(schema, t, seen)
+		delete(seen, t)
+		return schema
+	}
+	return &apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: &preserve, Nullable: true}
+}
@@ -0,0 +1,150 @@
+package cutover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"text/template"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// HookLabel is the label on ConfigMaps that define cutover hooks
+	HookLabel = "stork.libopenstorage.org/cutover-hook"
+
+	// Keys in the data of a hook ConfigMap
+	apiVersionKey = "apiVersion"
+	resourceKey   = "resource"
+	nameKey       = "name"
+	namespaceKey  = "namespace"
+	patchTypeKey  = "patchType"
+	patchKey      = "patch"
+)
+
+// Hook patches an object when the applications in a namespace are activated
+// or deactivated, so that traffic moves to the cluster that is running the
+// applications. For example it can update the external-dns annotations of a
+// Service or Ingress, a DNSEndpoint that external-dns syncs to Route53 or
+// CloudDNS, or the backend weights of an Ingress or Gateway route.
+//
+// Hooks are defined by ConfigMaps with the HookLabel in the namespace being
+// activated, with the following keys:
+//   - apiVersion: API version of the object to patch, defaults to v1
+//   - resource: plural resource name of the object, e.g. ingresses
+//   - name: name of the object
+//   - namespace: namespace of the object, defaults to the ConfigMap's
+//   - patchType: merge (default), json or strategic
+//   - patch: the patch, as a Go template that is rendered with TemplateData
+type Hook struct {
+	// Source is the namespace/name of the ConfigMap defining the hook
+	Source    string
+	Resource  schema.GroupVersionResource
+	Name      string
+	Namespace string
+	PatchType types.PatchType
+	Patch     *template.Template
+}
+
+// TemplateData is passed to the patch templates of the hooks
+type TemplateData struct {
+	// Namespace that is being activated or deactivated
+	Namespace string
+	// Activate is true when the applications are being activated on this
+	// cluster and false when they are being deactivated
+	Activate bool
+}
+
+var patchTypes = map[string]types.PatchType{
+	"":          types.MergePatchType,
+	"merge":     types.MergePatchType,
+	"json":      types.JSONPatchType,
+	"strategic": types.StrategicMergePatchType,
+}
+
+// GetHooks returns the hooks defined in the namespace
+func GetHooks(namespace string) ([]*Hook, error) {
+	configMaps, err := core.Instance().ListConfigMap(namespace, metav1.ListOptions{LabelSelector: HookLabel + "=true"})
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]*Hook, 0, len(configMaps.Items))
+	for i := range configMaps.Items {
+		hook, err := parseHook(&configMaps.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+func parseHook(configMap *v1.ConfigMap) (*Hook, error) {
+	source := configMap.Namespace + "/" + configMap.Name
+	apiVersion := configMap.Data[apiVersionKey]
+	if apiVersion == "" {
+		apiVersion = "v1"
+	}
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion in cutover hook %v: %v", source, err)
+	}
+	for _, key := range []string{resourceKey, nameKey, patchKey} {
+		if configMap.Data[key] == "" {
+			return nil, fmt.Errorf("%v not set in cutover hook %v", key, source)
+		}
+	}
+	patchType, ok := patchTypes[configMap.Data[patchTypeKey]]
+	if !ok {
+		return nil, fmt.Errorf("invalid patchType %v in cutover hook %v", configMap.Data[patchTypeKey], source)
+	}
+	patch, err := template.New(source).Option("missingkey=error").Parse(configMap.Data[patchKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch template in cutover hook %v: %v", source, err)
+	}
+	namespace := configMap.Data[namespaceKey]
+	if namespace == "" {
+		namespace = configMap.Namespace
+	}
+	return &Hook{
+		Source:    source,
+		Resource:  gv.WithResource(configMap.Data[resourceKey]),
+		Name:      configMap.Data[nameKey],
+		Namespace: namespace,
+		PatchType: patchType,
+		Patch:     patch,
+	}, nil
+}
+
+// Render returns the patch of the hook for the given data. Patches written
+// as YAML are converted to JSON.
+func (h *Hook) Render(data TemplateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := h.Patch.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("error rendering patch for cutover hook %v: %v", h.Source, err)
+	}
+	patch, err := yaml.ToJSON(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error converting patch for cutover hook %v: %v", h.Source, err)
+	}
+	return patch, nil
+}
+
+// Run applies the hook with the given data
+func (h *Hook) Run(client dynamic.Interface, data TemplateData) error {
+	patch, err := h.Render(data)
+	if err != nil {
+		return err
+	}
+	_, err = client.Resource(h.Resource).Namespace(h.Namespace).Patch(context.TODO(), h.Name, h.PatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("error patching %v %v/%v for cutover hook %v: %v", h.Resource.Resource, h.Namespace, h.Name, h.Source, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+//go:build unittest
+// +build unittest
+
+package cutover
+
+import (
+	"context"
+	"testing"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRunHook(t *testing.T) {
+	hookConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dns",
+			Namespace: "app",
+			Labels:    map[string]string{HookLabel: "true"},
+		},
+		Data: map[string]string{
+			"apiVersion": "networking.k8s.io/v1",
+			"resource":   "ingresses",
+			"name":       "web",
+			"patch": `metadata:
+  annotations:
+    external-dns.alpha.kubernetes.io/hostname: '{{ if .Activate }}web.example.com{{ else }}web-standby.example.com{{ end }}'`,
+		},
+	}
+	invalidConfigMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "invalid",
+			Namespace: "other",
+			Labels:    map[string]string{HookLabel: "true"},
+		},
+		Data: map[string]string{
+			"resource":  "services",
+			"patchType": "replace",
+		},
+	}
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset(hookConfigMap, invalidConfigMap)))
+
+	ingress := &unstructured.Unstructured{}
+	ingress.SetAPIVersion("networking.k8s.io/v1")
+	ingress.SetKind("Ingress")
+	ingress.SetName("web")
+	ingress.SetNamespace("app")
+	client := fakedynamic.NewSimpleDynamicClient(runtime.NewScheme(), ingress)
+
+	hooks, err := GetHooks("app")
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	require.Equal(t, "app", hooks[0].Namespace)
+
+	_, err = GetHooks("other")
+	require.Error(t, err, "hooks without a name and patch should be rejected")
+
+	ingressResource := schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}
+	require.NoError(t, hooks[0].Run(client, TemplateData{Namespace: "app", Activate: true}))
+	updated, err := client.Resource(ingressResource).Namespace("app").Get(context.TODO(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "web.example.com", updated.GetAnnotations()["external-dns.alpha.kubernetes.io/hostname"])
+
+	require.NoError(t, hooks[0].Run(client, TemplateData{Namespace: "app", Activate: false}))
+	updated, err = client.Resource(ingressResource).Namespace("app").Get(context.TODO(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "web-standby.example.com", updated.GetAnnotations()["external-dns.alpha.kubernetes.io/hostname"])
+}
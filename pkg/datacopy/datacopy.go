@@ -0,0 +1,295 @@
+package datacopy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	kdmpapi "github.com/portworx/kdmp/pkg/apis/kdmp/v1alpha1"
+	kdmputils "github.com/portworx/kdmp/pkg/drivers/utils"
+	"github.com/portworx/sched-ops/k8s/core"
+	kdmpops "github.com/portworx/sched-ops/k8s/kdmp"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	dataCopyControllerName = "data-copy-controller"
+	dataExportPrefix       = "datacopy-"
+	pvcKind                = "PersistentVolumeClaim"
+)
+
+// NewController creates a new instance of Controller.
+func NewController(mgr manager.Manager, r record.EventRecorder) *Controller {
+	return &Controller{
+		client:   controllers.NewConditionClient(mgr.GetClient()),
+		recorder: r,
+	}
+}
+
+// Controller reconciles DataCopy objects. The data is copied by the KDMP
+// data mover with an rsync DataExport, which only transfers the files that
+// differ between the source and destination PVCs.
+type Controller struct {
+	client         runtimeclient.Client
+	recorder       record.EventRecorder
+	adminNamespace string
+}
+
+// Init initializes the data copy controller
+func (c *Controller) Init(mgr manager.Manager, adminNamespace string) error {
+	if err := crds.Register(reflect.TypeOf(stork_api.DataCopy{}).Name()); err != nil {
+		return err
+	}
+	c.adminNamespace = adminNamespace
+	return controllers.RegisterTo(mgr, dataCopyControllerName, c, &stork_api.DataCopy{})
+}
+
+// Reconcile updates for DataCopy objects.
+func (c *Controller) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	logrus.Tracef("Reconciling DataCopy %s/%s", request.Namespace, request.Name)
+
+	dataCopy := &stork_api.DataCopy{}
+	err := c.client.Get(context.TODO(), request.NamespacedName, dataCopy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(dataCopyControllerName, controllers.DefaultRequeueError)}, err
+	}
+
+	if !controllers.ContainsFinalizer(dataCopy, controllers.FinalizerCleanup) {
+		controllers.SetFinalizer(dataCopy, controllers.FinalizerCleanup)
+		return reconcile.Result{Requeue: true}, c.client.Update(context.TODO(), dataCopy)
+	}
+
+	if controllers.IsObserved(dataCopy) {
+		return reconcile.Result{}, nil
+	}
+
+	if err = c.handle(ctx, dataCopy); err != nil {
+		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(c), dataCopy.Namespace, dataCopy.Name, err)
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(dataCopyControllerName, controllers.DefaultRequeueError)}, err
+	}
+
+	return controllers.Requeue(ctx, c.client, dataCopy, storkconfig.GetRequeuePeriod(dataCopyControllerName, controllers.DefaultRequeue))
+}
+
+func (c *Controller) handle(ctx context.Context, dataCopy *stork_api.DataCopy) error {
+	if dataCopy.DeletionTimestamp != nil {
+		// Stop the data mover before the DataCopy is gone instead of waiting
+		// for the DataExport to be garbage collected
+		if controllers.ContainsFinalizer(dataCopy, controllers.FinalizerCleanup) {
+			if err := deleteDataExport(dataCopy); err != nil {
+				if controllers.SetCleanupError(dataCopy, err) {
+					if updateErr := c.client.Update(ctx, dataCopy); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(c), updateErr)
+					}
+				}
+				return err
+			}
+		}
+
+		if dataCopy.GetFinalizers() != nil {
+			controllers.RemoveFinalizer(dataCopy, controllers.FinalizerCleanup)
+			return c.client.Update(ctx, dataCopy)
+		}
+
+		return nil
+	}
+
+	switch dataCopy.Status.Status {
+	case stork_api.DataCopyStatusInitial:
+		return c.startCopy(ctx, dataCopy)
+	case stork_api.DataCopyStatusInProgress:
+		return c.updateProgress(ctx, dataCopy)
+	}
+	return nil
+}
+
+// validate checks the spec of the copy
+func (c *Controller) validate(dataCopy *stork_api.DataCopy) error {
+	if dataCopy.Spec.Source.Name == "" || dataCopy.Spec.Destination.Name == "" {
+		return fmt.Errorf("names of the source and destination PVCs need to be set")
+	}
+	sourceNamespace := dataCopy.GetSourceNamespace()
+	destinationNamespace := dataCopy.GetDestinationNamespace()
+	if sourceNamespace == destinationNamespace && dataCopy.Spec.Source.Name == dataCopy.Spec.Destination.Name {
+		return fmt.Errorf("source and destination PVCs need to be different")
+	}
+	// Restrict copies to the namespace of the DataCopy except for the
+	// namespace designated by the admin
+	if (sourceNamespace != dataCopy.Namespace || destinationNamespace != dataCopy.Namespace) &&
+		dataCopy.Namespace != c.adminNamespace {
+		return fmt.Errorf("data can only be copied between namespaces by DataCopies in the admin namespace (%v)", c.adminNamespace)
+	}
+	if sourceNamespace != destinationNamespace && dataCopy.Spec.SnapshotClassName == "" {
+		return fmt.Errorf("snapshotClassName needs to be set to copy data to another namespace")
+	}
+	return nil
+}
+
+func (c *Controller) startCopy(ctx context.Context, dataCopy *stork_api.DataCopy) error {
+	if err := c.validate(dataCopy); err != nil {
+		return c.failCopy(ctx, dataCopy, err.Error())
+	}
+	source, err := core.Instance().GetPersistentVolumeClaim(dataCopy.Spec.Source.Name, dataCopy.GetSourceNamespace())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return c.failCopy(ctx, dataCopy, fmt.Sprintf("source PVC not found: %v", err))
+		}
+		return err
+	}
+
+	created, err := createDestinationPVC(dataCopy, source)
+	if err != nil {
+		return c.failCopy(ctx, dataCopy, fmt.Sprintf("error creating destination PVC: %v", err))
+	}
+
+	storkPodNs, err := k8sutils.GetStorkPodNamespace()
+	if err != nil {
+		return err
+	}
+	dataExport := &kdmpapi.DataExport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataExportPrefix + dataCopy.Name,
+			Namespace: dataCopy.Namespace,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: stork_api.SchemeGroupVersion.String(),
+				Kind:       reflect.TypeOf(stork_api.DataCopy{}).Name(),
+				Name:       dataCopy.Name,
+				UID:        dataCopy.UID,
+			}},
+		},
+		Spec: kdmpapi.DataExportSpec{
+			Type:                 kdmpapi.DataExportRsync,
+			SnapshotStorageClass: dataCopy.Spec.SnapshotClassName,
+			TriggeredFrom:        kdmputils.TriggeredFromStork,
+			TriggeredFromNs:      storkPodNs,
+			Source: kdmpapi.DataExportObjectReference{
+				Kind:       pvcKind,
+				Name:       source.Name,
+				Namespace:  source.Namespace,
+				APIVersion: "v1",
+			},
+			Destination: kdmpapi.DataExportObjectReference{
+				Kind:       pvcKind,
+				Name:       dataCopy.Spec.Destination.Name,
+				Namespace:  dataCopy.GetDestinationNamespace(),
+				APIVersion: "v1",
+			},
+		},
+	}
+	if _, err := kdmpops.Instance().CreateDataExport(dataExport); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating DataExport: %v", err)
+	}
+
+	dataCopy.Status.Status = stork_api.DataCopyStatusInProgress
+	dataCopy.Status.Reason = "Copying data"
+	dataCopy.Status.DestinationCreated = created
+	dataCopy.Status.StartTimestamp = metav1.Now()
+	c.recorder.Event(dataCopy,
+		v1.EventTypeNormal,
+		string(stork_api.DataCopyStatusInProgress),
+		fmt.Sprintf("Copying data from PVC %v/%v to PVC %v/%v", source.Namespace, source.Name,
+			dataCopy.GetDestinationNamespace(), dataCopy.Spec.Destination.Name))
+	return c.client.Update(ctx, dataCopy)
+}
+
+// createDestinationPVC creates the destination PVC with the same size and
+// access modes as the source if it doesn't exist. It returns true if the PVC
+// was created.
+func createDestinationPVC(dataCopy *stork_api.DataCopy, source *v1.PersistentVolumeClaim) (bool, error) {
+	_, err := core.Instance().GetPersistentVolumeClaim(dataCopy.Spec.Destination.Name, dataCopy.GetDestinationNamespace())
+	if err == nil {
+		return false, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataCopy.Spec.Destination.Name,
+			Namespace: dataCopy.GetDestinationNamespace(),
+			Labels:    source.Labels,
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      source.Spec.AccessModes,
+			Resources:        source.Spec.Resources,
+			VolumeMode:       source.Spec.VolumeMode,
+			StorageClassName: dataCopy.Spec.StorageClassName,
+		},
+	}
+	if _, err := core.Instance().CreatePersistentVolumeClaim(pvc); err != nil && !errors.IsAlreadyExists(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Controller) updateProgress(ctx context.Context, dataCopy *stork_api.DataCopy) error {
+	dataExport, err := kdmpops.Instance().GetDataExport(dataExportPrefix+dataCopy.Name, dataCopy.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return c.failCopy(ctx, dataCopy, "DataExport for the copy was deleted")
+		}
+		return err
+	}
+
+	switch {
+	case dataExport.Status.Stage == kdmpapi.DataExportStageFinal &&
+		dataExport.Status.Status == kdmpapi.DataExportStatusSuccessful:
+		dataCopy.Status.Status = stork_api.DataCopyStatusSuccessful
+		dataCopy.Status.Reason = "Data copied successfully"
+		dataCopy.Status.ProgressPercentage = 100
+		dataCopy.Status.FinishTimestamp = metav1.Now()
+		c.recorder.Event(dataCopy,
+			v1.EventTypeNormal,
+			string(stork_api.DataCopyStatusSuccessful),
+			dataCopy.Status.Reason)
+	case dataExport.Status.Stage == kdmpapi.DataExportStageFinal &&
+		dataExport.Status.Status == kdmpapi.DataExportStatusFailed:
+		return c.failCopy(ctx, dataCopy, fmt.Sprintf("Error copying data: %v", dataExport.Status.Reason))
+	default:
+		if dataCopy.Status.ProgressPercentage == dataExport.Status.ProgressPercentage {
+			return nil
+		}
+		dataCopy.Status.ProgressPercentage = dataExport.Status.ProgressPercentage
+	}
+	return c.client.Update(ctx, dataCopy)
+}
+
+// deleteDataExport deletes the DataExport of the copy if it exists
+func deleteDataExport(dataCopy *stork_api.DataCopy) error {
+	err := kdmpops.Instance().DeleteDataExport(dataExportPrefix+dataCopy.Name, dataCopy.Namespace)
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error deleting DataExport: %v", err)
+	}
+	return nil
+}
+
+// failCopy deletes the DataExport so that the data mover stops writing to
+// the destination and marks the copy as failed
+func (c *Controller) failCopy(ctx context.Context, dataCopy *stork_api.DataCopy, reason string) error {
+	if err := deleteDataExport(dataCopy); err != nil {
+		return err
+	}
+	dataCopy.Status.Status = stork_api.DataCopyStatusFailed
+	dataCopy.Status.Reason = reason
+	dataCopy.Status.FinishTimestamp = metav1.Now()
+	c.recorder.Event(dataCopy,
+		v1.EventTypeWarning,
+		string(stork_api.DataCopyStatusFailed),
+		reason)
+	logrus.Errorf("DataCopy %v/%v: %v", dataCopy.Namespace, dataCopy.Name, reason)
+	return c.client.Update(ctx, dataCopy)
+}
@@ -0,0 +1,270 @@
+//go:build unittest
+// +build unittest
+
+package datacopy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	kdmpapi "github.com/portworx/kdmp/pkg/apis/kdmp/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	kdmpops "github.com/portworx/sched-ops/k8s/kdmp"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	runtimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeDataExports keeps DataExports in memory
+type fakeDataExports struct {
+	kdmpops.Ops
+	exports   map[string]*kdmpapi.DataExport
+	deleteErr error
+}
+
+func (f *fakeDataExports) CreateDataExport(export *kdmpapi.DataExport) (*kdmpapi.DataExport, error) {
+	key := export.Namespace + "/" + export.Name
+	if _, ok := f.exports[key]; ok {
+		return nil, errors.NewAlreadyExists(schema.GroupResource{Resource: "dataexports"}, export.Name)
+	}
+	f.exports[key] = export.DeepCopy()
+	return export, nil
+}
+
+func (f *fakeDataExports) GetDataExport(name string, namespace string) (*kdmpapi.DataExport, error) {
+	export, ok := f.exports[namespace+"/"+name]
+	if !ok {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "dataexports"}, name)
+	}
+	return export.DeepCopy(), nil
+}
+
+func (f *fakeDataExports) DeleteDataExport(name string, namespace string) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	if _, ok := f.exports[namespace+"/"+name]; !ok {
+		return errors.NewNotFound(schema.GroupResource{Resource: "dataexports"}, name)
+	}
+	delete(f.exports, namespace+"/"+name)
+	return nil
+}
+
+func newDataCopy() *stork_api.DataCopy {
+	storageClass := "fast"
+	return &stork_api.DataCopy{
+		ObjectMeta: metav1.ObjectMeta{Name: "copy", Namespace: "test", UID: "copy-uid"},
+		Spec: stork_api.DataCopySpec{
+			Source:           stork_api.DataCopyPVC{Name: "source"},
+			Destination:      stork_api.DataCopyPVC{Name: "destination"},
+			StorageClassName: &storageClass,
+		},
+	}
+}
+
+func setupController(t *testing.T, dataCopy *stork_api.DataCopy) (*Controller, *fakeDataExports) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, stork_api.AddToScheme(scheme))
+	source := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "test", Labels: map[string]string{"app": "db"}},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	storkPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "stork", Namespace: "kube-system", Labels: map[string]string{"name": "stork"}}}
+	core.SetInstance(core.New(fake.NewSimpleClientset(source, storkPod)))
+	exports := &fakeDataExports{exports: make(map[string]*kdmpapi.DataExport)}
+	kdmpops.SetInstance(exports)
+	return &Controller{
+		client:         runtimefake.NewClientBuilder().WithScheme(scheme).WithObjects(dataCopy).Build(),
+		recorder:       record.NewFakeRecorder(10),
+		adminNamespace: "admin",
+	}, exports
+}
+
+// handleCopy runs the controller once for the copy and returns the updated
+// copy
+func handleCopy(t *testing.T, c *Controller) *stork_api.DataCopy {
+	dataCopy := getCopy(t, c)
+	require.NoError(t, c.handle(context.TODO(), dataCopy))
+	return getCopy(t, c)
+}
+
+func getCopy(t *testing.T, c *Controller) *stork_api.DataCopy {
+	dataCopy := &stork_api.DataCopy{}
+	require.NoError(t, c.client.Get(context.TODO(), types.NamespacedName{Name: "copy", Namespace: "test"}, dataCopy))
+	return dataCopy
+}
+
+func TestDataCopy(t *testing.T) {
+	c, exports := setupController(t, newDataCopy())
+
+	dataCopy := handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusInProgress, dataCopy.Status.Status)
+	require.True(t, dataCopy.Status.DestinationCreated)
+	require.False(t, dataCopy.Status.StartTimestamp.IsZero())
+
+	destination, err := core.Instance().GetPersistentVolumeClaim("destination", "test")
+	require.NoError(t, err)
+	require.Equal(t, "fast", *destination.Spec.StorageClassName)
+	require.Equal(t, "db", destination.Labels["app"])
+	require.Equal(t, resource.MustParse("10Gi"), destination.Spec.Resources.Requests[v1.ResourceStorage])
+
+	export, err := exports.GetDataExport("datacopy-copy", "test")
+	require.NoError(t, err)
+	require.Equal(t, kdmpapi.DataExportRsync, export.Spec.Type)
+	require.Equal(t, "kube-system", export.Spec.TriggeredFromNs)
+	require.Equal(t, "source", export.Spec.Source.Name)
+	require.Equal(t, "destination", export.Spec.Destination.Name)
+	require.Equal(t, "test", export.Spec.Destination.Namespace)
+	require.Equal(t, "copy-uid", string(export.OwnerReferences[0].UID))
+
+	exports.exports["test/datacopy-copy"].Status.ProgressPercentage = 40
+	dataCopy = handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusInProgress, dataCopy.Status.Status)
+	require.Equal(t, 40, dataCopy.Status.ProgressPercentage)
+
+	exports.exports["test/datacopy-copy"].Status.Stage = kdmpapi.DataExportStageFinal
+	exports.exports["test/datacopy-copy"].Status.Status = kdmpapi.DataExportStatusSuccessful
+	dataCopy = handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusSuccessful, dataCopy.Status.Status)
+	require.Equal(t, 100, dataCopy.Status.ProgressPercentage)
+	require.False(t, dataCopy.Status.FinishTimestamp.IsZero())
+	require.Contains(t, exports.exports, "test/datacopy-copy", "DataExport of a successful copy should be kept with it")
+
+	// Successful copies aren't handled again
+	dataCopy = handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusSuccessful, dataCopy.Status.Status)
+}
+
+func TestDataCopyExistingDestination(t *testing.T) {
+	c, _ := setupController(t, newDataCopy())
+	_, err := core.Instance().CreatePersistentVolumeClaim(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "destination", Namespace: "test"},
+	})
+	require.NoError(t, err)
+
+	dataCopy := handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusInProgress, dataCopy.Status.Status)
+	require.False(t, dataCopy.Status.DestinationCreated, "Existing destination shouldn't be recorded as created")
+}
+
+func TestDataCopyValidation(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		modify func(*stork_api.DataCopy)
+		reason string
+	}{
+		{"missing destination", func(d *stork_api.DataCopy) { d.Spec.Destination.Name = "" }, "need to be set"},
+		{"same PVC", func(d *stork_api.DataCopy) { d.Spec.Destination.Name = "source" }, "need to be different"},
+		{"other namespace", func(d *stork_api.DataCopy) { d.Spec.Destination.Namespace = "other" }, "admin namespace"},
+		{"missing snapshot class", func(d *stork_api.DataCopy) {
+			d.Namespace = "admin"
+			d.Spec.Source.Namespace = "test"
+			d.Spec.Destination.Namespace = "other"
+		}, "snapshotClassName"},
+		{"missing source", func(d *stork_api.DataCopy) { d.Spec.Source.Name = "missing" }, "source PVC not found"},
+	} {
+		dataCopy := newDataCopy()
+		test.modify(dataCopy)
+		c, exports := setupController(t, dataCopy)
+		require.NoError(t, c.handle(context.TODO(), dataCopy), test.name)
+		require.Equal(t, stork_api.DataCopyStatusFailed, dataCopy.Status.Status, test.name)
+		require.Contains(t, dataCopy.Status.Reason, test.reason, test.name)
+		require.Empty(t, exports.exports, test.name)
+	}
+}
+
+func TestDataCopyFailed(t *testing.T) {
+	c, exports := setupController(t, newDataCopy())
+	handleCopy(t, c)
+
+	exports.exports["test/datacopy-copy"].Status.Stage = kdmpapi.DataExportStageFinal
+	exports.exports["test/datacopy-copy"].Status.Status = kdmpapi.DataExportStatusFailed
+	exports.exports["test/datacopy-copy"].Status.Reason = "rsync error"
+	dataCopy := handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusFailed, dataCopy.Status.Status)
+	require.Contains(t, dataCopy.Status.Reason, "rsync error")
+	require.False(t, dataCopy.Status.FinishTimestamp.IsZero())
+	require.Empty(t, exports.exports, "DataExport of a failed copy should be deleted")
+
+	// The copy fails if its DataExport is deleted while it is in progress
+	c, exports = setupController(t, newDataCopy())
+	handleCopy(t, c)
+	delete(exports.exports, "test/datacopy-copy")
+	dataCopy = handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusFailed, dataCopy.Status.Status)
+	require.Contains(t, dataCopy.Status.Reason, "DataExport for the copy was deleted")
+}
+
+func TestDataCopyFailedCleanupError(t *testing.T) {
+	c, exports := setupController(t, newDataCopy())
+	handleCopy(t, c)
+
+	exports.exports["test/datacopy-copy"].Status.Stage = kdmpapi.DataExportStageFinal
+	exports.exports["test/datacopy-copy"].Status.Status = kdmpapi.DataExportStatusFailed
+	exports.deleteErr = fmt.Errorf("delete error")
+	dataCopy := getCopy(t, c)
+	err := c.handle(context.TODO(), dataCopy)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "delete error")
+	dataCopy = getCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusInProgress, dataCopy.Status.Status,
+		"Copy shouldn't be marked failed while its data mover can still be running")
+
+	exports.deleteErr = nil
+	dataCopy = handleCopy(t, c)
+	require.Equal(t, stork_api.DataCopyStatusFailed, dataCopy.Status.Status)
+	require.Empty(t, exports.exports)
+}
+
+func TestDataCopyFinalizer(t *testing.T) {
+	c, _ := setupController(t, newDataCopy())
+	result, err := c.Reconcile(context.TODO(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "copy", Namespace: "test"},
+	})
+	require.NoError(t, err)
+	require.True(t, result.Requeue)
+	dataCopy := getCopy(t, c)
+	require.True(t, controllers.ContainsFinalizer(dataCopy, controllers.FinalizerCleanup))
+	require.Equal(t, stork_api.DataCopyStatusInitial, dataCopy.Status.Status)
+}
+
+func TestDataCopyDelete(t *testing.T) {
+	dataCopy := newDataCopy()
+	dataCopy.Finalizers = []string{controllers.FinalizerCleanup}
+	c, exports := setupController(t, dataCopy)
+	handleCopy(t, c)
+	require.Len(t, exports.exports, 1)
+
+	dataCopy = getCopy(t, c)
+	now := metav1.Now()
+	dataCopy.DeletionTimestamp = &now
+	exports.deleteErr = fmt.Errorf("delete error")
+	require.Error(t, c.handle(context.TODO(), dataCopy))
+	require.Len(t, exports.exports, 1)
+	dataCopy = getCopy(t, c)
+	require.True(t, controllers.ContainsFinalizer(dataCopy, controllers.FinalizerCleanup),
+		"Finalizer should be kept until the DataExport is deleted")
+
+	exports.deleteErr = nil
+	dataCopy.DeletionTimestamp = &now
+	require.NoError(t, c.handle(context.TODO(), dataCopy))
+	require.Empty(t, exports.exports, "DataExport should be deleted with the copy")
+	require.False(t, controllers.ContainsFinalizer(dataCopy, controllers.FinalizerCleanup))
+}
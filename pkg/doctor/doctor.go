@@ -0,0 +1,421 @@
+package doctor
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cleanupaudit"
+	"github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/libopenstorage/stork/pkg/schedule"
+	"github.com/portworx/sched-ops/k8s/admissionregistration"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Severity of a finding
+type Severity string
+
+const (
+	// SeverityCritical is for findings that break stork or its operations
+	SeverityCritical Severity = "Critical"
+	// SeverityWarning is for findings that break some of the operations or
+	// will break them soon
+	SeverityWarning Severity = "Warning"
+	// SeverityInfo is for findings that don't need to be acted on
+	SeverityInfo Severity = "Info"
+)
+
+const (
+	// DefaultOverdueGrace is the time after which a scheduled run that
+	// hasn't been triggered is reported as overdue
+	DefaultOverdueGrace = time.Hour
+	// DefaultExpiryWarning is the time before a credential expires at which
+	// it is reported
+	DefaultExpiryWarning = 7 * 24 * time.Hour
+
+	storkService             = "stork-service"
+	storkAdmissionController = "stork-webhooks-cfg"
+)
+
+var severityOrder = map[Severity]int{
+	SeverityCritical: 0,
+	SeverityWarning:  1,
+	SeverityInfo:     2,
+}
+
+// Finding is a problem found by the doctor
+type Finding struct {
+	// Severity of the finding
+	Severity Severity `json:"severity"`
+	// Check that reported the finding
+	Check string `json:"check"`
+	// Object the finding is about, if any
+	Object string `json:"object,omitempty"`
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+// Doctor runs diagnostics of stork across the cluster
+type Doctor struct {
+	storkClient    versioned.Interface
+	storkNamespace string
+	// OverdueGrace is the time after which a scheduled run that hasn't
+	// been triggered is reported as overdue
+	OverdueGrace time.Duration
+	// ExpiryWarning is the time before a credential expires at which it is
+	// reported
+	ExpiryWarning time.Duration
+	findings      []*Finding
+}
+
+type check struct {
+	name string
+	run  func(namespace string) error
+}
+
+// New returns a doctor for the stork running in the given namespace
+func New(storkClient versioned.Interface, storkNamespace string) *Doctor {
+	return &Doctor{
+		storkClient:    storkClient,
+		storkNamespace: storkNamespace,
+		OverdueGrace:   DefaultOverdueGrace,
+		ExpiryWarning:  DefaultExpiryWarning,
+	}
+}
+
+// Run runs all the checks and returns the findings, the most severe ones
+// first. Checks for namespaced resources are limited to the namespace, or
+// are run for all namespaces if it is empty. A check that fails is reported
+// as a finding itself.
+func (d *Doctor) Run(namespace string) []*Finding {
+	d.findings = nil
+	for _, c := range []check{
+		{"crds", d.checkCRDs},
+		{"service", d.checkService},
+		{"webhook", d.checkWebhook},
+		{"drivers", d.checkDrivers},
+		{"finalizers", d.checkFinalizers},
+		{"schedules", d.checkSchedules},
+		{"clusterpairs", d.checkClusterPairs},
+	} {
+		if err := c.run(namespace); err != nil {
+			d.report(SeverityWarning, c.name, "", "Check failed: %v", err)
+		}
+	}
+	sort.SliceStable(d.findings, func(i, j int) bool {
+		return severityOrder[d.findings[i].Severity] < severityOrder[d.findings[j].Severity]
+	})
+	return d.findings
+}
+
+func (d *Doctor) report(severity Severity, check string, object string, format string, args ...interface{}) {
+	d.findings = append(d.findings, &Finding{
+		Severity: severity,
+		Check:    check,
+		Object:   object,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkCRDs reports the stork CRDs that aren't registered or established
+func (d *Doctor) checkCRDs(string) error {
+	var missing []string
+	for _, name := range crds.Names() {
+		crd, err := apiextensions.Instance().GetCRD(name, meta.GetOptions{})
+		if errors.IsNotFound(err) {
+			missing = append(missing, name)
+			continue
+		} else if err != nil {
+			return err
+		}
+		established := false
+		for _, condition := range crd.Status.Conditions {
+			if condition.Type == apiextensionsv1.Established && condition.Status == apiextensionsv1.ConditionTrue {
+				established = true
+			}
+		}
+		if !established {
+			d.report(SeverityCritical, "crds", name, "CRD isn't established")
+		}
+	}
+	// CRDs are only registered for the controllers that are enabled
+	if len(missing) > 0 {
+		d.report(SeverityInfo, "crds", "", "CRDs aren't registered, the features using them are disabled: %v",
+			strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkService reports if the stork service, which serves the scheduler
+// extender and webhook, has no ready endpoints
+func (d *Doctor) checkService(string) error {
+	object := d.storkNamespace + "/" + storkService
+	endpoints, err := core.Instance().GetEndpoints(storkService, d.storkNamespace)
+	if errors.IsNotFound(err) {
+		d.report(SeverityCritical, "service", object, "Service not found, the scheduler extender and webhook are unreachable")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	ready, notReady := 0, 0
+	for _, subset := range endpoints.Subsets {
+		ready += len(subset.Addresses)
+		notReady += len(subset.NotReadyAddresses)
+	}
+	if ready == 0 {
+		d.report(SeverityCritical, "service", object, "No stork replicas are ready, the scheduler extender and webhook are unreachable")
+	} else if notReady > 0 {
+		d.report(SeverityWarning, "service", object, "%v of %v stork replicas aren't ready", notReady, ready+notReady)
+	}
+	return nil
+}
+
+// checkWebhook reports if the certificate of the webhook has expired
+func (d *Doctor) checkWebhook(string) error {
+	config, err := admissionregistration.Instance().GetMutatingWebhookConfiguration(storkAdmissionController)
+	if errors.IsNotFound(err) {
+		d.report(SeverityInfo, "webhook", storkAdmissionController, "Webhook isn't registered")
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, webhook := range config.Webhooks {
+		certs, err := parseCertificates(webhook.ClientConfig.CABundle)
+		if err != nil || len(certs) == 0 {
+			d.report(SeverityCritical, "webhook", webhook.Name, "Webhook doesn't have a valid CA bundle")
+			continue
+		}
+		d.checkExpiry("webhook", webhook.Name, "Webhook certificate", latestExpiry(certs))
+	}
+	return nil
+}
+
+// checkDrivers reports the volume drivers that stork has marked as degraded
+func (d *Doctor) checkDrivers(string) error {
+	config, err := d.storkClient.StorkV1alpha1().StorkConfigurations().Get(context.TODO(), stork_api.StorkConfigurationName, meta.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, driver := range config.Status.VolumeDrivers {
+		if !driver.Degraded {
+			continue
+		}
+		message := "Calls to the driver keep failing"
+		if !driver.LastTransitionTime.IsZero() {
+			message += " since " + driver.LastTransitionTime.Format(time.RFC3339)
+		}
+		d.report(SeverityCritical, "drivers", driver.Driver, "%v: %v", message, driver.Reason)
+	}
+	return nil
+}
+
+// checkFinalizers reports resources held back by the cleanup finalizer
+func (d *Doctor) checkFinalizers(namespace string) error {
+	stuck, err := cleanupaudit.List(namespace)
+	if err != nil {
+		return err
+	}
+	for _, r := range stuck {
+		message := fmt.Sprintf("Terminating for %v", r.TerminatingFor().Round(time.Second))
+		if r.CleanupError != "" {
+			message += ", cleanup failed: " + r.CleanupError
+		}
+		d.report(SeverityWarning, "finalizers", objectName(r.Kind, r.GetMeta()), "%v", message)
+	}
+	return nil
+}
+
+// checkSchedules reports the schedules whose runs are overdue
+func (d *Doctor) checkSchedules(namespace string) error {
+	backupSchedules, err := storkops.Instance().ListApplicationBackupSchedules(namespace, meta.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range backupSchedules.Items {
+		s := &backupSchedules.Items[i]
+		lastTriggers := make(map[stork_api.SchedulePolicyType]meta.Time)
+		for policyType, items := range s.Status.Items {
+			for _, item := range items {
+				lastTriggers[policyType] = laterTime(lastTriggers[policyType], item.CreationTimestamp, item.ScheduledTimestamp)
+			}
+		}
+		d.checkSchedule("ApplicationBackupSchedule", s, s.Spec.SchedulePolicyName, s.Spec.Suspend, lastTriggers)
+	}
+
+	migrationSchedules, err := storkops.Instance().ListMigrationSchedules(namespace)
+	if err != nil {
+		return err
+	}
+	for i := range migrationSchedules.Items {
+		s := &migrationSchedules.Items[i]
+		lastTriggers := make(map[stork_api.SchedulePolicyType]meta.Time)
+		for policyType, items := range s.Status.Items {
+			for _, item := range items {
+				lastTriggers[policyType] = laterTime(lastTriggers[policyType], item.CreationTimestamp, item.ScheduledTimestamp)
+			}
+		}
+		d.checkSchedule("MigrationSchedule", s, s.Spec.SchedulePolicyName, s.Spec.Suspend, lastTriggers)
+	}
+
+	snapshotSchedules, err := storkops.Instance().ListSnapshotSchedules(namespace)
+	if err != nil {
+		return err
+	}
+	for i := range snapshotSchedules.Items {
+		s := &snapshotSchedules.Items[i]
+		lastTriggers := make(map[stork_api.SchedulePolicyType]meta.Time)
+		for policyType, items := range s.Status.Items {
+			for _, item := range items {
+				lastTriggers[policyType] = laterTime(lastTriggers[policyType], item.CreationTimestamp, item.ScheduledTimestamp)
+			}
+		}
+		d.checkSchedule("VolumeSnapshotSchedule", s, s.Spec.SchedulePolicyName, s.Spec.Suspend, lastTriggers)
+	}
+	return nil
+}
+
+func (d *Doctor) checkSchedule(
+	kind string,
+	object meta.Object,
+	policyName string,
+	suspend *bool,
+	lastTriggers map[stork_api.SchedulePolicyType]meta.Time,
+) {
+	if suspend != nil && *suspend {
+		return
+	}
+	name := objectName(kind, object)
+	now := schedule.GetCurrentTime()
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		lastTrigger, ok := lastTriggers[policyType]
+		if !ok {
+			// Runs are only due from when the schedule was created
+			lastTrigger = object.GetCreationTimestamp()
+		}
+		due, err := schedule.DueTrigger(policyName, object.GetNamespace(), policyType, lastTrigger)
+		if err != nil {
+			d.report(SeverityWarning, "schedules", name, "Error checking schedule policy %v: %v", policyName, err)
+			return
+		}
+		if !due.IsZero() && now.Sub(due) > d.OverdueGrace {
+			d.report(SeverityWarning, "schedules", name, "%v run due at %v hasn't been triggered",
+				policyType, due.Format(time.RFC3339))
+		}
+	}
+}
+
+// checkClusterPairs reports the cluster pairs that are in error and the
+// credentials for the remote clusters that have expired or will expire soon
+func (d *Doctor) checkClusterPairs(namespace string) error {
+	clusterPairs, err := storkops.Instance().ListClusterPairs(namespace)
+	if err != nil {
+		return err
+	}
+	for i := range clusterPairs.Items {
+		clusterPair := &clusterPairs.Items[i]
+		name := objectName("ClusterPair", clusterPair)
+		if clusterPair.Status.SchedulerStatus == stork_api.ClusterPairStatusError ||
+			clusterPair.Status.StorageStatus == stork_api.ClusterPairStatusError {
+			d.report(SeverityWarning, "clusterpairs", name, "Pairing failed, scheduler status: %v, storage status: %v",
+				clusterPair.Status.SchedulerStatus, clusterPair.Status.StorageStatus)
+		}
+		for user, authInfo := range clusterPair.Spec.Config.AuthInfos {
+			if authInfo == nil {
+				continue
+			}
+			if expiry := tokenExpiry(authInfo.Token); !expiry.IsZero() {
+				d.checkExpiry("clusterpairs", name, fmt.Sprintf("Token for user %v", user), expiry)
+			}
+			if certs, err := parseCertificates(authInfo.ClientCertificateData); err == nil && len(certs) > 0 {
+				d.checkExpiry("clusterpairs", name, fmt.Sprintf("Client certificate for user %v", user), latestExpiry(certs))
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Doctor) checkExpiry(check string, object string, what string, expiry time.Time) {
+	now := schedule.GetCurrentTime()
+	if now.After(expiry) {
+		d.report(SeverityCritical, check, object, "%v expired at %v", what, expiry.Format(time.RFC3339))
+	} else if expiry.Sub(now) < d.ExpiryWarning {
+		d.report(SeverityWarning, check, object, "%v expires at %v", what, expiry.Format(time.RFC3339))
+	}
+}
+
+// tokenExpiry returns the expiry of a JWT bearer token. It is zero if the
+// token isn't a JWT or doesn't expire. The signature isn't verified.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(claims.Exp, 0)
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// latestExpiry returns the latest expiry of the certificates, since a CA
+// bundle can contain the certificate that is being rotated out
+func latestExpiry(certs []*x509.Certificate) time.Time {
+	var expiry time.Time
+	for _, cert := range certs {
+		if cert.NotAfter.After(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+	return expiry
+}
+
+func laterTime(times ...meta.Time) meta.Time {
+	var latest meta.Time
+	for _, t := range times {
+		if t.After(latest.Time) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+func objectName(kind string, object meta.Object) string {
+	return fmt.Sprintf("%v %v/%v", kind, object.GetNamespace(), object.GetName())
+}
@@ -0,0 +1,189 @@
+//go:build unittest
+// +build unittest
+
+package doctor
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/webhookadmission"
+	"github.com/portworx/sched-ops/k8s/admissionregistration"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	fakeextclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func findingsFor(findings []*Finding, check string) []*Finding {
+	var matching []*Finding
+	for _, finding := range findings {
+		if finding.Check == check {
+			matching = append(matching, finding)
+		}
+	}
+	return matching
+}
+
+func TestDoctor(t *testing.T) {
+	fakeKubeClient := fakekubeclient.NewSimpleClientset()
+	fakeStorkClient := fakestorkclient.NewSimpleClientset()
+	fakeExtClient := fakeextclient.NewSimpleClientset()
+	core.SetInstance(core.New(fakeKubeClient))
+	storkops.SetInstance(storkops.New(fakeKubeClient, fakeStorkClient, nil))
+	apiextensions.SetInstance(apiextensions.New(fakeExtClient))
+	admissionregistration.SetInstance(admissionregistration.New(
+		fakeKubeClient.AdmissionregistrationV1beta1(), fakeKubeClient.AdmissionregistrationV1()))
+
+	// One established CRD, one that isn't and the rest aren't registered
+	for name, status := range map[string]apiextensionsv1.ConditionStatus{
+		"applicationbackups.stork.libopenstorage.org": apiextensionsv1.ConditionTrue,
+		"migrations.stork.libopenstorage.org":         apiextensionsv1.ConditionFalse,
+	} {
+		crd := &apiextensionsv1.CustomResourceDefinition{
+			ObjectMeta: meta.ObjectMeta{Name: name},
+			Status: apiextensionsv1.CustomResourceDefinitionStatus{
+				Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+					{Type: apiextensionsv1.Established, Status: status},
+				},
+			},
+		}
+		_, err := fakeExtClient.ApiextensionsV1().CustomResourceDefinitions().Create(context.TODO(), crd, meta.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	_, err := fakeKubeClient.CoreV1().Endpoints("kube-system").Create(context.TODO(), &v1.Endpoints{
+		ObjectMeta: meta.ObjectMeta{Name: storkService, Namespace: "kube-system"},
+		Subsets: []v1.EndpointSubset{{
+			Addresses:         []v1.EndpointAddress{{IP: "10.0.0.1"}},
+			NotReadyAddresses: []v1.EndpointAddress{{IP: "10.0.0.2"}},
+		}},
+	}, meta.CreateOptions{})
+	require.NoError(t, err)
+
+	cert, _, err := webhookadmission.GenerateCertificateWithLifetime("stork", "stork-service.kube-system.svc", 24*time.Hour)
+	require.NoError(t, err)
+	_, err = fakeKubeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().Create(context.TODO(), &admissionv1.MutatingWebhookConfiguration{
+		ObjectMeta: meta.ObjectMeta{Name: storkAdmissionController},
+		Webhooks: []admissionv1.MutatingWebhook{{
+			Name:         "webhook.stork.libopenstorage.org",
+			ClientConfig: admissionv1.WebhookClientConfig{CABundle: cert},
+		}},
+	}, meta.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = fakeStorkClient.StorkV1alpha1().StorkConfigurations().Create(context.TODO(), &stork_api.StorkConfiguration{
+		ObjectMeta: meta.ObjectMeta{Name: stork_api.StorkConfigurationName},
+		Status: stork_api.StorkConfigurationStatus{
+			VolumeDrivers: []stork_api.VolumeDriverCondition{
+				{Driver: "pxd", Degraded: true, Reason: "connection refused"},
+				{Driver: "csi"},
+			},
+		},
+	}, meta.CreateOptions{})
+	require.NoError(t, err)
+
+	_, err = storkops.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{Name: "hourly"},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{IntervalMinutes: 60},
+		},
+	})
+	require.NoError(t, err)
+	suspend := true
+	for name, suspend := range map[string]*bool{"overdue": nil, "suspended": &suspend} {
+		_, err = storkops.Instance().CreateApplicationBackupSchedule(&stork_api.ApplicationBackupSchedule{
+			ObjectMeta: meta.ObjectMeta{
+				Name:              name,
+				Namespace:         "ns",
+				CreationTimestamp: meta.NewTime(time.Now().Add(-3 * time.Hour)),
+			},
+			Spec: stork_api.ApplicationBackupScheduleSpec{
+				SchedulePolicyName: "hourly",
+				Suspend:            suspend,
+			},
+		})
+		require.NoError(t, err)
+	}
+	_, err = storkops.Instance().CreateApplicationBackupSchedule(&stork_api.ApplicationBackupSchedule{
+		ObjectMeta: meta.ObjectMeta{
+			Name:              "ontime",
+			Namespace:         "ns",
+			CreationTimestamp: meta.NewTime(time.Now().Add(-3 * time.Hour)),
+		},
+		Spec: stork_api.ApplicationBackupScheduleSpec{SchedulePolicyName: "hourly"},
+		Status: stork_api.ApplicationBackupScheduleStatus{
+			Items: map[stork_api.SchedulePolicyType][]*stork_api.ScheduledApplicationBackupStatus{
+				stork_api.SchedulePolicyTypeInterval: {
+					{Name: "ontime-1", CreationTimestamp: meta.NewTime(time.Now().Add(-30 * time.Minute))},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%v}`, time.Now().Add(-time.Hour).Unix())))
+	_, err = storkops.Instance().CreateClusterPair(&stork_api.ClusterPair{
+		ObjectMeta: meta.ObjectMeta{Name: "remote", Namespace: "ns"},
+		Spec: stork_api.ClusterPairSpec{
+			Config: clientcmdapi.Config{
+				AuthInfos: map[string]*clientcmdapi.AuthInfo{
+					"admin":  {Token: "header." + claims + ".signature"},
+					"opaque": {Token: "not-a-jwt"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	findings := New(fakeStorkClient, "kube-system").Run("")
+	// The most severe findings are first
+	for i := 1; i < len(findings); i++ {
+		require.LessOrEqual(t, severityOrder[findings[i-1].Severity], severityOrder[findings[i].Severity])
+	}
+
+	crdFindings := findingsFor(findings, "crds")
+	require.Len(t, crdFindings, 2)
+	require.Equal(t, SeverityCritical, crdFindings[0].Severity)
+	require.Equal(t, "migrations.stork.libopenstorage.org", crdFindings[0].Object)
+	require.Equal(t, SeverityInfo, crdFindings[1].Severity)
+	require.NotContains(t, crdFindings[1].Message, "applicationbackups.stork.libopenstorage.org")
+
+	serviceFindings := findingsFor(findings, "service")
+	require.Len(t, serviceFindings, 1)
+	require.Equal(t, SeverityWarning, serviceFindings[0].Severity)
+	require.Equal(t, "1 of 2 stork replicas aren't ready", serviceFindings[0].Message)
+
+	webhookFindings := findingsFor(findings, "webhook")
+	require.Len(t, webhookFindings, 1)
+	require.Equal(t, SeverityWarning, webhookFindings[0].Severity)
+	require.Contains(t, webhookFindings[0].Message, "Webhook certificate expires at")
+
+	driverFindings := findingsFor(findings, "drivers")
+	require.Len(t, driverFindings, 1)
+	require.Equal(t, "pxd", driverFindings[0].Object)
+	require.Contains(t, driverFindings[0].Message, "connection refused")
+
+	scheduleFindings := findingsFor(findings, "schedules")
+	require.Len(t, scheduleFindings, 1)
+	require.Equal(t, "ApplicationBackupSchedule ns/overdue", scheduleFindings[0].Object)
+
+	clusterPairFindings := findingsFor(findings, "clusterpairs")
+	require.Len(t, clusterPairFindings, 1)
+	require.Equal(t, SeverityCritical, clusterPairFindings[0].Severity)
+	require.Contains(t, clusterPairFindings[0].Message, "Token for user admin expired at")
+
+	require.Empty(t, findingsFor(findings, "finalizers"))
+}
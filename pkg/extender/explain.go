@@ -0,0 +1,286 @@
+package extender
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s/core"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	schedulerapi "k8s.io/kube-scheduler/extender/v1"
+)
+
+const (
+	explain = "explain"
+	// ExplainPort is the port on the stork service that serves the explain
+	// requests
+	ExplainPort = 8099
+	// ExplainPath is the path of the explain requests
+	ExplainPath = "/" + explain
+)
+
+// ExplainRequest is a request to explain the scheduling decisions for a pod
+type ExplainRequest struct {
+	// Pod to explain the decisions for. It doesn't need to exist.
+	Pod *v1.Pod `json:"pod"`
+	// Nodes that are considered for the pod. All the nodes in the cluster
+	// are considered if not set.
+	Nodes *v1.NodeList `json:"nodes,omitempty"`
+}
+
+// SchedulingExplanation explains how the extender would filter and score the
+// nodes for a pod
+type SchedulingExplanation struct {
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// Namespace is the namespace of the pod
+	Namespace string `json:"namespace"`
+	// FilterError is the error returned for the filter request. The pod
+	// can't be scheduled on any of the nodes if set.
+	FilterError string `json:"filterError,omitempty"`
+	// PreferLocalNodeOnly is set if the pod is only scheduled on nodes that
+	// have replicas for all the volumes
+	PreferLocalNodeOnly bool `json:"preferLocalNodeOnly,omitempty"`
+	// HyperconvergenceDisabled is set if all the nodes are scored the same
+	HyperconvergenceDisabled bool `json:"hyperconvergenceDisabled,omitempty"`
+	// Volumes are the driver volumes used by the pod
+	Volumes []VolumeExplanation `json:"volumes,omitempty"`
+	// Nodes are the nodes that were considered, in the order of the request
+	Nodes []NodeExplanation `json:"nodes"`
+}
+
+// VolumeExplanation is a driver volume used by the pod
+type VolumeExplanation struct {
+	// Name of the volume
+	Name string `json:"name"`
+	// ReplicaNodes are the storage nodes with replicas for the volume
+	ReplicaNodes []string `json:"replicaNodes,omitempty"`
+	// SkipScoring is set if the volume isn't used to score the nodes
+	SkipScoring bool `json:"skipScoring,omitempty"`
+}
+
+// NodeExplanation explains the decision for a node
+type NodeExplanation struct {
+	// Name of the node
+	Name string `json:"name"`
+	// StorageID is the ID of the storage node on the node
+	StorageID string `json:"storageID,omitempty"`
+	// DriverStatus is the status of the driver on the node
+	DriverStatus string `json:"driverStatus,omitempty"`
+	// Rack, Zone and Region are the locality of the storage node
+	Rack   string `json:"rack,omitempty"`
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+	// Replicas is the number of volumes of the pod with a replica on the
+	// node
+	Replicas int `json:"replicas"`
+	// Filtered is set if the node passes the filter
+	Filtered bool `json:"filtered"`
+	// Score is the score given to the node
+	Score int64 `json:"score"`
+	// Reason explains the decision
+	Reason string `json:"reason"`
+}
+
+func (e *Extender) processExplainRequest(w http.ResponseWriter, req *http.Request) {
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+
+	var explainRequest ExplainRequest
+	if err := decoder.Decode(&explainRequest); err != nil {
+		log.Errorf("Error decoding explain request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+	if explainRequest.Pod == nil {
+		http.Error(w, "Empty pod received in explain request", http.StatusBadRequest)
+		return
+	}
+
+	explanation, err := e.explain(explainRequest.Pod, explainRequest.Nodes)
+	if err != nil {
+		storklog.PodLog(explainRequest.Pod).Errorf("Error explaining scheduling: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(explanation); err != nil {
+		storklog.PodLog(explainRequest.Pod).Errorf("Error encoding explain response: %v", err)
+	}
+}
+
+// explain runs the filter and prioritize requests for the pod and explains
+// the results for each node
+func (e *Extender) explain(pod *v1.Pod, nodes *v1.NodeList) (*SchedulingExplanation, error) {
+	if nodes == nil {
+		var err error
+		if nodes, err = core.Instance().GetNodes(); err != nil {
+			return nil, fmt.Errorf("error getting nodes: %v", err)
+		}
+	}
+	// The requests are sent to an extender that doesn't record events since
+	// the pod might not exist and nothing is scheduled
+	explainer := &Extender{
+		Driver:   e.Driver,
+		Recorder: &record.FakeRecorder{},
+	}
+	args := &schedulerapi.ExtenderArgs{Pod: pod, Nodes: nodes}
+
+	explanation := &SchedulingExplanation{
+		Pod:                      pod.Name,
+		Namespace:                pod.Namespace,
+		PreferLocalNodeOnly:      isAnnotationSet(pod, preferLocalNodeOnlyAnnotation),
+		HyperconvergenceDisabled: isAnnotationSet(pod, disableHyperconvergenceAnnotation),
+	}
+
+	// Like the scheduler, only the nodes that pass the filter are scored
+	filtered := make(map[string]bool)
+	filterResponse := &schedulerapi.ExtenderFilterResult{}
+	if filterError, err := runExplainRequest(explainer.processFilterRequest, args, filterResponse); err != nil {
+		return nil, err
+	} else if filterError != "" {
+		explanation.FilterError = filterError
+	} else if filterResponse.Nodes != nil {
+		for _, node := range filterResponse.Nodes.Items {
+			filtered[node.Name] = true
+		}
+	}
+
+	scores := make(map[string]int64)
+	if len(filtered) > 0 {
+		prioritizeResponse := schedulerapi.HostPriorityList{}
+		args.Nodes = filterResponse.Nodes
+		if _, err := runExplainRequest(explainer.processPrioritizeRequest, args, &prioritizeResponse); err != nil {
+			return nil, err
+		}
+		for _, hostPriority := range prioritizeResponse {
+			scores[hostPriority.Host] = hostPriority.Score
+		}
+	}
+
+	driverVolumes, _, err := e.Driver.GetPodVolumes(&pod.Spec, pod.Namespace, true)
+	if err != nil {
+		storklog.PodLog(pod).Debugf("Error getting volumes to explain scheduling: %v", err)
+	}
+	replicaCounts := make(map[string]int)
+	for _, volumeInfo := range driverVolumes {
+		skipScoring, _ := strconv.ParseBool(volumeInfo.Labels[skipScoringLabel])
+		explanation.Volumes = append(explanation.Volumes, VolumeExplanation{
+			Name:         volumeInfo.VolumeName,
+			ReplicaNodes: volumeInfo.DataNodes,
+			SkipScoring:  skipScoring,
+		})
+		for _, dataNode := range volumeInfo.DataNodes {
+			replicaCounts[dataNode]++
+		}
+	}
+
+	var driverNodes []*volume.NodeInfo
+	if len(driverVolumes) > 0 {
+		if driverNodes, err = e.Driver.GetNodes(); err != nil {
+			storklog.PodLog(pod).Debugf("Error getting driver nodes to explain scheduling: %v", err)
+		}
+	}
+
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		nodeExplanation := NodeExplanation{
+			Name:     node.Name,
+			Filtered: filtered[node.Name],
+			Score:    scores[node.Name],
+		}
+		var driverNode *volume.NodeInfo
+		for _, dnode := range driverNodes {
+			if volume.IsNodeMatch(node, dnode) {
+				driverNode = dnode
+				break
+			}
+		}
+		if driverNode != nil {
+			nodeExplanation.StorageID = driverNode.StorageID
+			nodeExplanation.DriverStatus = string(driverNode.Status)
+			nodeExplanation.Rack = driverNode.Rack
+			nodeExplanation.Zone = driverNode.Zone
+			nodeExplanation.Region = driverNode.Region
+			nodeExplanation.Replicas = replicaCounts[driverNode.StorageID]
+		}
+		nodeExplanation.Reason = explanation.getNodeReason(&nodeExplanation, driverNode, len(driverVolumes))
+		explanation.Nodes = append(explanation.Nodes, nodeExplanation)
+	}
+	return explanation, nil
+}
+
+// getNodeReason returns the reason for the decision for a node
+func (s *SchedulingExplanation) getNodeReason(
+	node *NodeExplanation,
+	driverNode *volume.NodeInfo,
+	volumeCount int,
+) string {
+	if volumeCount == 0 {
+		return "Pod doesn't use any volumes of the driver"
+	}
+	if s.FilterError != "" {
+		return "No node passes the filter: " + s.FilterError
+	}
+	if driverNode == nil {
+		return "Storage driver isn't running on the node"
+	}
+	if driverNode.Status != volume.NodeOnline && driverNode.Status != volume.NodeDegraded {
+		return fmt.Sprintf("Storage driver is %v on the node", driverNode.Status)
+	}
+	replicas := fmt.Sprintf("Node has replicas for %v of %v volumes", node.Replicas, volumeCount)
+	if !node.Filtered {
+		if s.PreferLocalNodeOnly && node.Replicas != volumeCount {
+			return replicas + ", the pod prefers nodes with replicas for all the volumes"
+		}
+		return "Node was filtered out"
+	}
+	if s.HyperconvergenceDisabled {
+		return replicas + ", all nodes are scored the same since hyperconvergence is disabled for the pod"
+	}
+	reasons := []string{replicas}
+	if node.Replicas == 0 && node.Score > int64(defaultScore) {
+		reasons = append(reasons, "scored for being close to the replicas")
+	}
+	if driverNode.Status == volume.NodeDegraded {
+		reasons = append(reasons, "score is reduced since the storage driver is degraded")
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// runExplainRequest sends the args to the handler and decodes the response.
+// The error message is returned if the handler failed the request.
+func runExplainRequest(
+	handler http.HandlerFunc,
+	args *schedulerapi.ExtenderArgs,
+	response interface{},
+) (string, error) {
+	body, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	req := httptest.NewRequest(http.MethodPost, ExplainPath, bytes.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+	if recorder.Code != http.StatusOK {
+		return strings.TrimSpace(recorder.Body.String()), nil
+	}
+	return "", json.NewDecoder(recorder.Body).Decode(response)
+}
+
+func isAnnotationSet(object metav1.Object, annotation string) bool {
+	value, err := strconv.ParseBool(object.GetAnnotations()[annotation])
+	return err == nil && value
+}
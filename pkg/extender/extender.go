@@ -8,11 +8,12 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	storklog "github.com/libopenstorage/stork/pkg/log"
-	restore "github.com/libopenstorage/stork/pkg/snapshot/controllers"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
@@ -50,6 +51,13 @@ const (
 	skipScoringLabel = "stork.libopenstorage.org/skipSchedulerScoring"
 	// annotation to disable hyperconvergence for a pod
 	disableHyperconvergenceAnnotation = "stork.libopenstorage.org/disableHyperconvergence"
+	// warmUpRetryInterval is the interval at which the driver is queried
+	// until the extender is warmed up
+	warmUpRetryInterval = 5 * time.Second
+	// driverCallTimeout is the time after which calls to the driver are
+	// abandoned while handling a request. The scheduler waits for the
+	// extender, so calls aren't retried either.
+	driverCallTimeout = 5 * time.Second
 )
 
 var (
@@ -80,6 +88,10 @@ type Extender struct {
 	server   *http.Server
 	lock     sync.Mutex
 	started  bool
+	// warmedUp is set once the driver has returned its nodes, since
+	// requests can't be scored before that
+	warmedUp int32
+	stopChan chan struct{}
 }
 
 // Start Starts the extender
@@ -90,6 +102,7 @@ func (e *Extender) Start() error {
 	if e.started {
 		return fmt.Errorf("Extender has already been started")
 	}
+	e.Driver = volume.WithoutRetries(e.Driver, driverCallTimeout)
 	// TODO: Make the listen port configurable
 	e.server = &http.Server{Addr: ":8099"}
 	http.HandleFunc("/", e.serveHTTP)
@@ -106,10 +119,44 @@ func (e *Extender) Start() error {
 		return err
 	}
 
+	e.stopChan = make(chan struct{})
+	go e.warmUp(e.stopChan)
 	e.started = true
 	return nil
 }
 
+// warmUp queries the driver for its nodes until it responds so that the
+// replica isn't ready before it can score requests
+func (e *Extender) warmUp(stopChan chan struct{}) {
+	for {
+		_, err := e.Driver.GetNodes()
+		if err == nil {
+			atomic.StoreInt32(&e.warmedUp, 1)
+			log.Infof("Scheduler extender is ready")
+			return
+		}
+		log.Debugf("Waiting for driver nodes to warm up extender: %v", err)
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(warmUpRetryInterval):
+		}
+	}
+}
+
+// Ready returns an error if the extender isn't ready to serve requests
+func (e *Extender) Ready() error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if !e.started {
+		return fmt.Errorf("extender has not been started")
+	}
+	if atomic.LoadInt32(&e.warmedUp) == 0 {
+		return fmt.Errorf("waiting for nodes from the driver")
+	}
+	return nil
+}
+
 // Stop Stops the extender
 func (e *Extender) Stop() error {
 	e.lock.Lock()
@@ -122,6 +169,7 @@ func (e *Extender) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	close(e.stopChan)
 	if err := e.server.Shutdown(ctx); err != nil {
 		return err
 	}
@@ -134,6 +182,8 @@ func (e *Extender) serveHTTP(w http.ResponseWriter, req *http.Request) {
 		e.processFilterRequest(w, req)
 	} else if strings.Contains(req.URL.Path, prioritize) {
 		e.processPrioritizeRequest(w, req)
+	} else if strings.Contains(req.URL.Path, explain) {
+		e.processExplainRequest(w, req)
 	} else {
 		http.Error(w, "Unsupported request", http.StatusNotFound)
 	}
@@ -183,7 +233,7 @@ func (e *Extender) processFilterRequest(w http.ResponseWriter, req *http.Request
 			e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
 			http.Error(w, msg, http.StatusBadRequest)
 			return
-		} else if pvc.Annotations != nil && pvc.Annotations[restore.RestoreAnnotation] == "true" {
+		} else if pvclock.IsLocked(pvc, pvclock.OperationRestore) {
 			msg := "Volume restore is in progress for pvc: " + pvc.Name
 			storklog.PodLog(pod).Warnf(msg)
 			e.Recorder.Event(pod, v1.EventTypeWarning, schedulingFailureEventReason, msg)
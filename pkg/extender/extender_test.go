@@ -16,7 +16,7 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	"github.com/libopenstorage/stork/drivers/volume/mock"
 	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
-	restore "github.com/libopenstorage/stork/pkg/snapshot/controllers"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	fakeocpclient "github.com/openshift/client-go/apps/clientset/versioned/fake"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/openshift"
@@ -243,6 +243,37 @@ func sendPrioritizeRequest(
 	return &priorityList, nil
 }
 
+func sendExplainRequest(
+	pod *v1.Pod,
+	nodeList *v1.NodeList,
+) (*SchedulingExplanation, error) {
+	b, err := json.Marshal(&ExplainRequest{Pod: pod, Nodes: nodeList})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.Post("http://localhost:8099"+ExplainPath, "application/json", strings.NewReader(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Warnf("Error closing decoder: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		contents, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New(strings.TrimSpace(string(contents)))
+	}
+	var explanation SchedulingExplanation
+	if err := json.NewDecoder(resp.Body).Decode(&explanation); err != nil {
+		return nil, err
+	}
+	return &explanation, nil
+}
+
 func verifyFilterResponse(
 	t *testing.T,
 	requestNodes *v1.NodeList,
@@ -335,6 +366,7 @@ func TestExtender(t *testing.T) {
 	t.Run("restorePVCTest", restorePVCTest)
 	t.Run("preferLocalNodeTest", preferLocalNodeTest)
 	t.Run("extenderMetricsTest", extenderMetricsTest)
+	t.Run("explainTest", explainTest)
 	t.Run("teardown", teardown)
 }
 
@@ -1248,8 +1280,15 @@ func restorePVCTest(t *testing.T) {
 	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
 	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack1", "", ""))
 
+	restoreLock, err := json.Marshal(&pvclock.Lock{
+		OwnerUID:  "restore-uid",
+		Owner:     "VolumeSnapshotRestore default/restore",
+		Operation: pvclock.OperationRestore,
+		Expiry:    metav1.NewTime(time.Now().Add(pvclock.TTL)),
+	})
+	require.NoError(t, err)
 	restoreAnnotation := make(map[string]string)
-	restoreAnnotation[restore.RestoreAnnotation] = "true"
+	restoreAnnotation[pvclock.LockAnnotation] = string(restoreLock)
 	invalidRestorePod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{Name: "invalidRestorePod"},
 	}
@@ -1290,7 +1329,7 @@ func restorePVCTest(t *testing.T) {
 	require.Error(t, err, "Expected error since pvc has restore annotation")
 	require.Contains(t, err.Error(), "Volume restore is in progress for pvc")
 
-	delete(pvc.Annotations, restore.RestoreAnnotation)
+	delete(pvc.Annotations, pvclock.LockAnnotation)
 	_, err = core.Instance().UpdatePersistentVolumeClaim(pvc)
 	require.NoError(t, err)
 	_, err = sendFilterRequest(pod, nodes)
@@ -1396,3 +1435,46 @@ func extenderMetricsTest(t *testing.T) {
 	time.Sleep(3 * time.Second)
 	require.Equal(t, testutil.ToFloat64(NonHyperConvergePodsCounter), float64(1), "non_hyperconverged_pods_total not matched")
 }
+
+// Explain the decisions for a pod with a volume that has replicas on n1 and
+// n2 while the driver is offline on n3 and not running on n4
+func explainTest(t *testing.T) {
+	nodes := &v1.NodeList{}
+	nodes.Items = append(nodes.Items, *newNode("node1", "node1", "192.168.0.1", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node2", "node2", "192.168.0.2", "rack2", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node3", "node3", "192.168.0.3", "rack1", "", ""))
+	nodes.Items = append(nodes.Items, *newNode("node4", "node4", "192.168.0.4", "rack1", "", ""))
+
+	if err := driver.CreateCluster(3, nodes); err != nil {
+		t.Fatalf("Error creating cluster: %v", err)
+	}
+	require.NoError(t, driver.UpdateNodeStatus(2, volume.NodeOffline))
+	pod := newPod("explainTest", map[string]bool{"explainTest": false})
+	require.NoError(t, driver.ProvisionVolume("explainTest", []int{0, 1}, 1, nil))
+
+	explanation, err := sendExplainRequest(pod, nodes)
+	require.NoError(t, err)
+	require.Empty(t, explanation.FilterError)
+	require.Len(t, explanation.Volumes, 1)
+	require.Len(t, explanation.Volumes[0].ReplicaNodes, 2)
+	require.Len(t, explanation.Nodes, 4)
+	for i := 0; i < 2; i++ {
+		require.True(t, explanation.Nodes[i].Filtered)
+		require.Equal(t, 1, explanation.Nodes[i].Replicas)
+		require.Equal(t, int64(nodePriorityScore), explanation.Nodes[i].Score)
+		require.Equal(t, "Node has replicas for 1 of 1 volumes", explanation.Nodes[i].Reason)
+	}
+	require.False(t, explanation.Nodes[2].Filtered)
+	require.Equal(t, "Storage driver is Offline on the node", explanation.Nodes[2].Reason)
+	require.False(t, explanation.Nodes[3].Filtered)
+	require.Equal(t, "Storage driver isn't running on the node", explanation.Nodes[3].Reason)
+
+	pod.Annotations[preferLocalNodeOnlyAnnotation] = "true"
+	explanation, err = sendExplainRequest(pod, &v1.NodeList{Items: nodes.Items[2:]})
+	require.NoError(t, err)
+	require.Equal(t, "No nodes with volume replica available", explanation.FilterError)
+	require.Contains(t, explanation.Nodes[0].Reason, explanation.FilterError)
+
+	_, err = sendExplainRequest(nil, nodes)
+	require.Error(t, err)
+}
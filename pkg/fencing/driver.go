@@ -0,0 +1,85 @@
+package fencing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// driverFenceTimeout is the time after which the driver stops fencing a
+	// volume if it isn't unfenced or extended, so that volumes aren't
+	// blocked forever if stork goes down during a restore. The fence is
+	// extended while the restore is running.
+	driverFenceTimeout = 10 * time.Minute
+	driverFenceID      = "stork-restore-fence"
+)
+
+// driverFencer blocks IO to the volumes in the volume driver
+type driverFencer struct {
+	driver volume.Driver
+}
+
+// RegisterDriverFencer registers a fencer that blocks IO to the volumes
+// using the given volume driver. It isn't registered if the driver can't
+// quiesce volumes, so restores that ask for it fail right away. Restores of
+// PVCs handled by a driver that can't quiesce volumes fail when they are
+// fenced.
+func RegisterDriverFencer(d volume.Driver) error {
+	if !volume.IsQuiesceSupported(d, nil) {
+		logrus.Infof("Volume driver %v doesn't support quiescing volumes, not registering driver fencer", d)
+		return nil
+	}
+	return Register(stork_api.VolumeSnapshotRestoreFencingDriver, &driverFencer{driver: d})
+}
+
+func (d *driverFencer) String() string {
+	return stork_api.VolumeSnapshotRestoreFencingDriver
+}
+
+func (d *driverFencer) Fence(volumes []*stork_api.RestoreVolumeInfo) error {
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			return err
+		}
+		if !volume.IsQuiesceSupported(d.driver, pvc) {
+			return fmt.Errorf("volume driver handling pvc %v/%v can't quiesce volumes to fence them", vol.Namespace, vol.PVC)
+		}
+	}
+	for i, vol := range volumes {
+		if err := d.driver.QuiesceVolume(vol.Volume, driverFenceTimeout, driverFenceID); err != nil {
+			// Don't leave the volumes that were already fenced blocked
+			_ = d.Unfence(volumes[:i])
+			return err
+		}
+	}
+	return nil
+}
+
+// Extend quiesces the volumes again so that the timeout of the fence starts
+// over. Volumes that are already quiesced with the same ID stay blocked.
+func (d *driverFencer) Extend(volumes []*stork_api.RestoreVolumeInfo) error {
+	var extendErr error
+	for _, vol := range volumes {
+		if err := d.driver.QuiesceVolume(vol.Volume, driverFenceTimeout, driverFenceID); err != nil {
+			extendErr = multierror.Append(extendErr, err)
+		}
+	}
+	return extendErr
+}
+
+func (d *driverFencer) Unfence(volumes []*stork_api.RestoreVolumeInfo) error {
+	var unfenceErr error
+	for _, vol := range volumes {
+		if err := d.driver.UnquiesceVolume(vol.Volume); err != nil {
+			unfenceErr = multierror.Append(unfenceErr, err)
+		}
+	}
+	return unfenceErr
+}
@@ -0,0 +1,66 @@
+//go:build unittest
+// +build unittest
+
+package fencing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// quiesceDriver can only quiesce the volumes of PVCs labeled with quiesce
+type quiesceDriver struct {
+	volume.Driver
+	quiesced map[string]bool
+}
+
+func (q *quiesceDriver) SupportsQuiesce(pvc *v1.PersistentVolumeClaim) bool {
+	return pvc == nil || pvc.Labels["quiesce"] == "true"
+}
+
+func (q *quiesceDriver) QuiesceVolume(volumeID string, _ time.Duration, _ string) error {
+	q.quiesced[volumeID] = true
+	return nil
+}
+
+func (q *quiesceDriver) UnquiesceVolume(volumeID string) error {
+	delete(q.quiesced, volumeID)
+	return nil
+}
+
+func TestDriverFencer(t *testing.T) {
+	pvc := func(name, quiesce string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: map[string]string{"quiesce": quiesce}},
+		}
+	}
+	core.SetInstance(core.New(fake.NewSimpleClientset(pvc("pvc1", "true"), pvc("pvc2", "true"), pvc("pvc3", "false"))))
+
+	driver := &quiesceDriver{quiesced: make(map[string]bool)}
+	require.NoError(t, RegisterDriverFencer(driver))
+	f, err := Get(stork_api.VolumeSnapshotRestoreFencingDriver)
+	require.NoError(t, err)
+
+	volumes := []*stork_api.RestoreVolumeInfo{
+		{PVC: "pvc1", Namespace: "test", Volume: "vol1"},
+		{PVC: "pvc2", Namespace: "test", Volume: "vol2"},
+	}
+	require.NoError(t, f.Fence(volumes))
+	require.Len(t, driver.quiesced, 2)
+	require.NoError(t, f.Unfence(volumes))
+	require.Empty(t, driver.quiesced)
+
+	volumes = append(volumes, &stork_api.RestoreVolumeInfo{PVC: "pvc3", Namespace: "test", Volume: "vol3"})
+	err = f.Fence(volumes)
+	require.Error(t, err, "Expected error fencing pvc whose driver can't quiesce volumes")
+	require.Contains(t, err.Error(), "test/pvc3")
+	require.Empty(t, driver.quiesced, "Expected no volumes to be fenced")
+}
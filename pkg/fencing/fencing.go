@@ -0,0 +1,80 @@
+package fencing
+
+import (
+	goerrors "errors"
+	"fmt"
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Fencer keeps pods that aren't scheduled by stork from using volumes while
+// they are being restored in place. Pods scheduled by stork don't need to be
+// fenced since the extender doesn't place them while their PVCs are marked
+// for restore.
+type Fencer interface {
+	// String returns the name of the fencer
+	String() string
+	// Fence stops the volumes from being used by pods until Unfence is
+	// called
+	Fence(volumes []*stork_api.RestoreVolumeInfo) error
+	// Extend keeps the volumes fenced. It is called periodically while the
+	// volumes are being restored.
+	Extend(volumes []*stork_api.RestoreVolumeInfo) error
+	// Unfence allows the volumes to be used by pods again. Returns
+	// ErrFenceBreached if any of the volumes were used while they were
+	// fenced.
+	Unfence(volumes []*stork_api.RestoreVolumeInfo) error
+}
+
+// ErrFenceBreached is returned when a volume was used by a pod while it was
+// fenced, so the data restored to it can't be trusted
+type ErrFenceBreached struct {
+	// Namespace of the PVC of the volume
+	Namespace string
+	// PVC of the volume
+	PVC string
+	// Reason describes how the volume was used
+	Reason string
+}
+
+func (e *ErrFenceBreached) Error() string {
+	return fmt.Sprintf("volume of PVC %v/%v was used while it was fenced: %v", e.Namespace, e.PVC, e.Reason)
+}
+
+// IsFenceBreached returns true if the error, or any of the errors it wraps,
+// is ErrFenceBreached
+func IsFenceBreached(err error) bool {
+	var breached *ErrFenceBreached
+	return goerrors.As(err, &breached)
+}
+
+var (
+	fencers     = make(map[string]Fencer)
+	fencersLock sync.Mutex
+)
+
+// Register registers the given fencer so that it can be selected by name in
+// VolumeSnapshotRestores
+func Register(name string, f Fencer) error {
+	logrus.Debugf("Registering fencer: %v", name)
+	fencersLock.Lock()
+	defer fencersLock.Unlock()
+	fencers[name] = f
+	return nil
+}
+
+// Get returns the fencer registered with the given name
+func Get(name string) (Fencer, error) {
+	fencersLock.Lock()
+	defer fencersLock.Unlock()
+	if f, ok := fencers[name]; ok {
+		return f, nil
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   name,
+		Type: "Fencer",
+	}
+}
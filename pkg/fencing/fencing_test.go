@@ -0,0 +1,56 @@
+//go:build unittest
+// +build unittest
+
+package fencing
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+type testFencer struct {
+	fenced map[string]bool
+}
+
+func (t *testFencer) String() string {
+	return "test"
+}
+
+func (t *testFencer) Fence(volumes []*stork_api.RestoreVolumeInfo) error {
+	for _, vol := range volumes {
+		t.fenced[vol.Volume] = true
+	}
+	return nil
+}
+
+func (t *testFencer) Extend(volumes []*stork_api.RestoreVolumeInfo) error {
+	return nil
+}
+
+func (t *testFencer) Unfence(volumes []*stork_api.RestoreVolumeInfo) error {
+	for _, vol := range volumes {
+		delete(t.fenced, vol.Volume)
+	}
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	f, err := Get(stork_api.VolumeSnapshotRestoreFencingVolumeAttachment)
+	require.NoError(t, err)
+	require.Equal(t, stork_api.VolumeSnapshotRestoreFencingVolumeAttachment, f.String())
+
+	_, err = Get("test")
+	require.Error(t, err, "Expected error for fencer that isn't registered")
+
+	fencer := &testFencer{fenced: make(map[string]bool)}
+	require.NoError(t, Register("test", fencer))
+	f, err = Get("test")
+	require.NoError(t, err)
+	volumes := []*stork_api.RestoreVolumeInfo{{Volume: "vol1"}, {Volume: "vol2"}}
+	require.NoError(t, f.Fence(volumes))
+	require.Len(t, fencer.fenced, 2)
+	require.NoError(t, f.Unfence(volumes))
+	require.Empty(t, fencer.fenced)
+}
@@ -0,0 +1,286 @@
+package fencing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// FencedReplicasAnnotation is set on the applications that were scaled
+	// down to keep their pods off fenced volumes, to the replicas they had
+	// before
+	FencedReplicasAnnotation = "stork.libopenstorage.org/fenced-replicas"
+	// FencedOwnersAnnotation is set on fenced PVCs to the applications that
+	// were scaled down for them, so that they are scaled up again by Unfence
+	// even if stork restarts during the restore
+	FencedOwnersAnnotation = "stork.libopenstorage.org/fenced-owners"
+
+	kindDeployment  = "Deployment"
+	kindStatefulSet = "StatefulSet"
+	kindReplicaSet  = "ReplicaSet"
+)
+
+// owner is an application in the namespace of a PVC that manages pods using
+// it
+type owner struct {
+	kind string
+	name string
+}
+
+func (o owner) String() string {
+	return o.kind + "/" + o.name
+}
+
+// parseOwners parses the value of FencedOwnersAnnotation
+func parseOwners(value string) ([]owner, error) {
+	owners := make([]owner, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item == "" {
+			continue
+		}
+		parts := strings.SplitN(item, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid value for annotation %v: %v", FencedOwnersAnnotation, value)
+		}
+		owners = append(owners, owner{kind: parts[0], name: parts[1]})
+	}
+	return owners, nil
+}
+
+// formatOwners returns the value of FencedOwnersAnnotation for the owners
+func formatOwners(owners map[string]bool) string {
+	items := make([]string, 0, len(owners))
+	for item := range owners {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// getPodOwner returns the application that recreates the pod if it is
+// deleted, or nil if nothing does. Returns an error if the pod is managed by
+// something that can't be scaled down.
+func getPodOwner(pod *v1.Pod) (*owner, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return nil, nil
+	}
+	switch ref.Kind {
+	case kindStatefulSet:
+		return &owner{kind: kindStatefulSet, name: ref.Name}, nil
+	case kindReplicaSet:
+		rs, err := apps.Instance().GetReplicaSet(ref.Name, pod.Namespace)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("error getting ReplicaSet %v/%v: %v", pod.Namespace, ref.Name, err)
+		}
+		if rsRef := metav1.GetControllerOf(rs); rsRef != nil {
+			if rsRef.Kind != kindDeployment {
+				return nil, fmt.Errorf("pod %v/%v is managed by %v %v which can't be scaled down to fence its volumes",
+					pod.Namespace, pod.Name, rsRef.Kind, rsRef.Name)
+			}
+			return &owner{kind: kindDeployment, name: rsRef.Name}, nil
+		}
+		return &owner{kind: kindReplicaSet, name: ref.Name}, nil
+	}
+	return nil, fmt.Errorf("pod %v/%v is managed by %v %v which can't be scaled down to fence its volumes",
+		pod.Namespace, pod.Name, ref.Kind, ref.Name)
+}
+
+// scaleDownOwners scales down the applications managing the pods that use the
+// volumes, so that the pods aren't started again while the volumes are
+// fenced. The applications are recorded on the PVCs before they are scaled
+// down, and the ones that were recorded before are scaled down again in case
+// they were scaled up since.
+func scaleDownOwners(volumes []*stork_api.RestoreVolumeInfo) error {
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			return err
+		}
+		owners, err := parseOwners(pvc.Annotations[FencedOwnersAnnotation])
+		if err != nil {
+			return err
+		}
+		pods, err := core.Instance().GetPodsUsingPVC(vol.PVC, vol.Namespace)
+		if err != nil {
+			return err
+		}
+		for i := range pods {
+			// Pods that completed aren't started again
+			if pods[i].Status.Phase == v1.PodSucceeded || pods[i].Status.Phase == v1.PodFailed {
+				continue
+			}
+			o, err := getPodOwner(&pods[i])
+			if err != nil {
+				return err
+			}
+			if o != nil {
+				owners = append(owners, *o)
+			}
+		}
+		if len(owners) == 0 {
+			continue
+		}
+		if err := recordOwners(vol, owners); err != nil {
+			return err
+		}
+		for _, o := range owners {
+			if err := scaleDown(vol.Namespace, o); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordOwners adds the owners to FencedOwnersAnnotation on the PVC
+func recordOwners(vol *stork_api.RestoreVolumeInfo, owners []owner) error {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+	if err != nil {
+		return err
+	}
+	recorded, err := parseOwners(pvc.Annotations[FencedOwnersAnnotation])
+	if err != nil {
+		return err
+	}
+	items := make(map[string]bool)
+	for _, o := range recorded {
+		items[o.String()] = true
+	}
+	updated := false
+	for _, o := range owners {
+		if !items[o.String()] {
+			items[o.String()] = true
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = make(map[string]string)
+	}
+	pvc.Annotations[FencedOwnersAnnotation] = formatOwners(items)
+	if _, err := core.Instance().UpdatePersistentVolumeClaim(pvc); err != nil {
+		return fmt.Errorf("error recording scaled down applications on pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
+	}
+	return nil
+}
+
+// scaleUpOwners scales the applications recorded on the PVCs back to the
+// replicas they had before they were fenced
+func scaleUpOwners(vol *stork_api.RestoreVolumeInfo, pvc *v1.PersistentVolumeClaim) error {
+	owners, err := parseOwners(pvc.Annotations[FencedOwnersAnnotation])
+	if err != nil {
+		return err
+	}
+	for _, o := range owners {
+		if err := scaleUp(vol.Namespace, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scaleDown sets the replicas of the application to 0 and stores the current
+// replicas in FencedReplicasAnnotation. Applications that were already scaled
+// down for the fence are scaled down again in case they were scaled up since.
+func scaleDown(namespace string, o owner) error {
+	return updateReplicas(namespace, o, func(metadata *metav1.ObjectMeta, replicas **int32) (bool, error) {
+		if _, ok := metadata.Annotations[FencedReplicasAnnotation]; !ok {
+			current := int32(1)
+			if *replicas != nil {
+				current = **replicas
+			}
+			if metadata.Annotations == nil {
+				metadata.Annotations = make(map[string]string)
+			}
+			metadata.Annotations[FencedReplicasAnnotation] = strconv.FormatInt(int64(current), 10)
+		} else if *replicas != nil && **replicas == 0 {
+			return false, nil
+		}
+		logrus.Infof("Scaling down %v in %v to fence its volumes", o, namespace)
+		zero := int32(0)
+		*replicas = &zero
+		return true, nil
+	})
+}
+
+// scaleUp sets the replicas of the application back to the ones stored in
+// FencedReplicasAnnotation and removes the annotation. Applications that
+// weren't scaled down or are gone are left alone.
+func scaleUp(namespace string, o owner) error {
+	return updateReplicas(namespace, o, func(metadata *metav1.ObjectMeta, replicas **int32) (bool, error) {
+		value, ok := metadata.Annotations[FencedReplicasAnnotation]
+		if !ok {
+			return false, nil
+		}
+		previous, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return false, fmt.Errorf("invalid value for annotation %v on %v in %v: %v", FencedReplicasAnnotation, o, namespace, value)
+		}
+		logrus.Infof("Scaling %v in %v back to %v replicas after unfencing its volumes", o, namespace, previous)
+		restored := int32(previous)
+		*replicas = &restored
+		delete(metadata.Annotations, FencedReplicasAnnotation)
+		return true, nil
+	})
+}
+
+// updateReplicas gets the application, calls update with its metadata and
+// replicas and updates the application if update returns true. Applications
+// that are gone are skipped.
+func updateReplicas(namespace string, o owner, update func(*metav1.ObjectMeta, **int32) (bool, error)) error {
+	err := func() error {
+		switch o.kind {
+		case kindDeployment:
+			deployment, err := apps.Instance().GetDeployment(o.name, namespace)
+			if err != nil {
+				return err
+			}
+			if updated, err := update(&deployment.ObjectMeta, &deployment.Spec.Replicas); err != nil || !updated {
+				return err
+			}
+			_, err = apps.Instance().UpdateDeployment(deployment)
+			return err
+		case kindStatefulSet:
+			ss, err := apps.Instance().GetStatefulSet(o.name, namespace)
+			if err != nil {
+				return err
+			}
+			if updated, err := update(&ss.ObjectMeta, &ss.Spec.Replicas); err != nil || !updated {
+				return err
+			}
+			_, err = apps.Instance().UpdateStatefulSet(ss)
+			return err
+		case kindReplicaSet:
+			rs, err := apps.Instance().GetReplicaSet(o.name, namespace)
+			if err != nil {
+				return err
+			}
+			if updated, err := update(&rs.ObjectMeta, &rs.Spec.Replicas); err != nil || !updated {
+				return err
+			}
+			_, err = apps.Instance().UpdateReplicaSet(rs)
+			return err
+		}
+		return fmt.Errorf("unsupported kind %v", o.kind)
+	}()
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("error updating replicas of %v in %v: %v", o, namespace, err)
+	}
+	return nil
+}
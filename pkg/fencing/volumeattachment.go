@@ -0,0 +1,394 @@
+package fencing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// FencedAnnotation is set on the PVCs that are fenced by the
+	// VolumeAttachment fencer to the time at which they were fenced. The
+	// fence is kept on the PVC so that it is still enforced and checked if
+	// stork restarts or loses leadership during the restore.
+	FencedAnnotation = "stork.libopenstorage.org/fenced-at"
+	// FenceBreachedAnnotation is set on fenced PVCs whose volume was used
+	// while it was fenced, to how it was used. It is kept on the PVC so that
+	// Unfence fails even if the volume was detached again since.
+	FenceBreachedAnnotation = "stork.libopenstorage.org/fence-breached"
+
+	detachRetryInterval = 5 * time.Second
+	detachTimeout       = 2 * time.Minute
+	podDeleteTimeout    = 2 * time.Minute
+)
+
+// volumeAttachmentFencer detaches the volumes by stopping the pods using them
+// and deleting their VolumeAttachments. It only works for CSI and other
+// attachable volumes. The Deployments, StatefulSets and ReplicaSets managing
+// the pods are scaled down so that the pods aren't started again while the
+// volumes are fenced, and scaled back up by Unfence. Volumes used by pods
+// managed by anything else can't be fenced. Pods that are started anyway are
+// deleted along with the VolumeAttachments created for the volumes every time
+// the fence is extended, and since the volumes could have been written to in
+// the meantime, Unfence fails if any of the volumes were attached or used by
+// a pod while they were fenced.
+type volumeAttachmentFencer struct{}
+
+func init() {
+	if err := Register(stork_api.VolumeSnapshotRestoreFencingVolumeAttachment, &volumeAttachmentFencer{}); err != nil {
+		logrus.Panicf("Error registering VolumeAttachment fencer: %v", err)
+	}
+}
+
+func (v *volumeAttachmentFencer) String() string {
+	return stork_api.VolumeSnapshotRestoreFencingVolumeAttachment
+}
+
+func (v *volumeAttachmentFencer) Fence(volumes []*stork_api.RestoreVolumeInfo) error {
+	pvNames, err := markFenced(volumes)
+	if err != nil {
+		return err
+	}
+	if err := scaleDownOwners(volumes); err != nil {
+		return err
+	}
+	deleted, err := detach(volumes, pvNames)
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return nil
+	}
+	deletedNames := make(map[string]bool)
+	for _, attachment := range deleted {
+		deletedNames[attachment.Name] = true
+	}
+
+	// Wait for the volumes to be detached
+	return wait.PollImmediate(detachRetryInterval, detachTimeout, func() (bool, error) {
+		attachments, err := storage.Instance().ListVolumeAttachments()
+		if err != nil {
+			return false, nil
+		}
+		for _, attachment := range attachments.Items {
+			if deletedNames[attachment.Name] {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// Extend scales down the applications that were scaled up or started with
+// the volumes since they were fenced, stops their pods and detaches the
+// volumes again if they were attached. Volumes that were attached or used by
+// a pod are recorded as breached so that Unfence fails.
+func (v *volumeAttachmentFencer) Extend(volumes []*stork_api.RestoreVolumeInfo) error {
+	pvNames, err := getFencedPVNames(volumes)
+	if err != nil {
+		return err
+	}
+	if len(pvNames) == 0 {
+		return nil
+	}
+	var extendErr error
+	for _, vol := range pvNames {
+		reason, err := getPodUsage(vol)
+		if err != nil {
+			extendErr = multierror.Append(extendErr, err)
+		} else if reason != "" {
+			if err := recordBreach(vol, reason); err != nil {
+				extendErr = multierror.Append(extendErr, err)
+			}
+		}
+	}
+	if err := scaleDownOwners(volumes); err != nil {
+		extendErr = multierror.Append(extendErr, err)
+	}
+	deleted, err := detach(volumes, pvNames)
+	if err != nil {
+		return multierror.Append(extendErr, err)
+	}
+	for _, attachment := range deleted {
+		logrus.Warnf("Detached VolumeAttachment %v that was created while its volume was fenced", attachment.Name)
+		if !attachment.Status.Attached {
+			continue
+		}
+		vol := pvNames[*attachment.Spec.Source.PersistentVolumeName]
+		if err := recordBreach(vol, getAttachedReason(&attachment)); err != nil {
+			extendErr = multierror.Append(extendErr, err)
+		}
+	}
+	return extendErr
+}
+
+// Unfence removes the fence from the PVCs and scales the applications that
+// were scaled down for it back up. It returns ErrFenceBreached for the
+// volumes that are still attached or were used while they were fenced, since
+// they could have been written to during the restore. The fence is kept on
+// PVCs whose applications couldn't be scaled back up so that Unfence can be
+// retried.
+func (v *volumeAttachmentFencer) Unfence(volumes []*stork_api.RestoreVolumeInfo) error {
+	pvNames, err := getFencedPVNames(volumes)
+	if err != nil {
+		return err
+	}
+
+	breaches := make(map[string]string)
+	if len(pvNames) != 0 {
+		attachments, err := storage.Instance().ListVolumeAttachments()
+		if err != nil {
+			return err
+		}
+		for i := range attachments.Items {
+			attachment := &attachments.Items[i]
+			pvName := attachment.Spec.Source.PersistentVolumeName
+			if pvName != nil && pvNames[*pvName] != nil && attachment.Status.Attached {
+				breaches[*pvName] = getAttachedReason(attachment)
+			}
+		}
+	}
+	var unfenceErr error
+	for pvName, vol := range pvNames {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				unfenceErr = multierror.Append(unfenceErr, err)
+			}
+			continue
+		}
+		// Breaches recorded while the fence was extended take precedence
+		if reason, ok := pvc.Annotations[FenceBreachedAnnotation]; ok {
+			breaches[pvName] = reason
+		}
+		if _, ok := breaches[pvName]; !ok {
+			reason, err := getPodUsage(vol)
+			if err != nil {
+				unfenceErr = multierror.Append(unfenceErr, err)
+			} else if reason != "" {
+				breaches[pvName] = reason
+			}
+		}
+		if reason, ok := breaches[pvName]; ok {
+			unfenceErr = multierror.Append(unfenceErr, &ErrFenceBreached{Namespace: vol.Namespace, PVC: vol.PVC, Reason: reason})
+		}
+	}
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				unfenceErr = multierror.Append(unfenceErr, err)
+			}
+			continue
+		}
+		if err := scaleUpOwners(vol, pvc); err != nil {
+			unfenceErr = multierror.Append(unfenceErr, err)
+			continue
+		}
+		if err := setFencedAnnotation(vol, ""); err != nil && !errors.IsNotFound(err) {
+			unfenceErr = multierror.Append(unfenceErr, err)
+		}
+	}
+	return unfenceErr
+}
+
+// markFenced sets the fence annotation on the PVCs and returns the volumes
+// keyed by the names of their PVs. The time of PVCs that are already fenced
+// is kept so that restores that are resumed don't move it.
+func markFenced(volumes []*stork_api.RestoreVolumeInfo) (map[string]*stork_api.RestoreVolumeInfo, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	pvNames := make(map[string]*stork_api.RestoreVolumeInfo)
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("error getting pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
+		}
+		if _, ok := pvc.Annotations[FencedAnnotation]; !ok {
+			if err := setFencedAnnotation(vol, now); err != nil {
+				return nil, err
+			}
+		}
+		if pvc.Spec.VolumeName != "" {
+			pvNames[pvc.Spec.VolumeName] = vol
+		}
+	}
+	return pvNames, nil
+}
+
+// getFencedPVNames returns the volumes whose PVCs have the fence annotation,
+// keyed by the names of their PVs
+func getFencedPVNames(volumes []*stork_api.RestoreVolumeInfo) (map[string]*stork_api.RestoreVolumeInfo, error) {
+	pvNames := make(map[string]*stork_api.RestoreVolumeInfo)
+	for _, vol := range volumes {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error getting pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
+		}
+		if _, ok := pvc.Annotations[FencedAnnotation]; ok && pvc.Spec.VolumeName != "" {
+			pvNames[pvc.Spec.VolumeName] = vol
+		}
+	}
+	return pvNames, nil
+}
+
+// setFencedAnnotation sets the fence annotation on the PVC to value, or
+// removes it along with any recorded breach and scaled down applications if
+// value is empty
+func setFencedAnnotation(vol *stork_api.RestoreVolumeInfo, value string) error {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		_, fenced := pvc.Annotations[FencedAnnotation]
+		_, breached := pvc.Annotations[FenceBreachedAnnotation]
+		_, scaled := pvc.Annotations[FencedOwnersAnnotation]
+		if !fenced && !breached && !scaled {
+			return nil
+		}
+		delete(pvc.Annotations, FencedAnnotation)
+		delete(pvc.Annotations, FenceBreachedAnnotation)
+		delete(pvc.Annotations, FencedOwnersAnnotation)
+	} else {
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[FencedAnnotation] = value
+	}
+	if _, err := core.Instance().UpdatePersistentVolumeClaim(pvc); err != nil {
+		return fmt.Errorf("error updating fence on pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
+	}
+	return nil
+}
+
+// detach stops the pods using the volumes, since volumes can't be detached
+// while they are mounted, and deletes the VolumeAttachments of the PVs. The
+// deleted VolumeAttachments are returned.
+func detach(
+	volumes []*stork_api.RestoreVolumeInfo,
+	pvNames map[string]*stork_api.RestoreVolumeInfo,
+) ([]storagev1.VolumeAttachment, error) {
+	if err := stopPods(volumes); err != nil {
+		return nil, err
+	}
+	attachments, err := storage.Instance().ListVolumeAttachments()
+	if err != nil {
+		return nil, err
+	}
+	deleted := make([]storagev1.VolumeAttachment, 0)
+	for _, attachment := range attachments.Items {
+		pvName := attachment.Spec.Source.PersistentVolumeName
+		if pvName == nil || pvNames[*pvName] == nil {
+			continue
+		}
+		logrus.Infof("Deleting VolumeAttachment %v for %v to fence it for restore", attachment.Name, *pvName)
+		if err := storage.Instance().DeleteVolumeAttachment(attachment.Name); err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("error deleting VolumeAttachment %v: %v", attachment.Name, err)
+		}
+		deleted = append(deleted, attachment)
+	}
+	return deleted, nil
+}
+
+// getAttachedReason returns the reason recorded for a volume that was
+// attached while it was fenced
+func getAttachedReason(attachment *storagev1.VolumeAttachment) string {
+	return fmt.Sprintf("volume was attached to node %v by VolumeAttachment %v", attachment.Spec.NodeName, attachment.Name)
+}
+
+// recordBreach records on the PVC how its volume was used while it was
+// fenced. The first breach that was recorded is kept.
+func recordBreach(vol *stork_api.RestoreVolumeInfo, reason string) error {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+	if err != nil {
+		return err
+	}
+	if _, ok := pvc.Annotations[FenceBreachedAnnotation]; ok {
+		return nil
+	}
+	logrus.Warnf("Volume of PVC %v/%v was used while it was fenced: %v", vol.Namespace, vol.PVC, reason)
+	if pvc.Annotations == nil {
+		pvc.Annotations = make(map[string]string)
+	}
+	pvc.Annotations[FenceBreachedAnnotation] = reason
+	if _, err := core.Instance().UpdatePersistentVolumeClaim(pvc); err != nil {
+		return fmt.Errorf("error recording fence breach on pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
+	}
+	return nil
+}
+
+// getPodUsage returns how the volume was used by a pod that was created after
+// the volume was fenced and whose containers started, or an empty string if
+// no such pod exists. Pods that completed are included since their
+// VolumeAttachments could already be gone.
+func getPodUsage(vol *stork_api.RestoreVolumeInfo) (string, error) {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
+	if err != nil {
+		return "", err
+	}
+	fencedAt, err := time.Parse(time.RFC3339, pvc.Annotations[FencedAnnotation])
+	if err != nil {
+		return "", nil
+	}
+	pods, err := core.Instance().GetPodsUsingPVC(vol.PVC, vol.Namespace)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if !pod.CreationTimestamp.Time.After(fencedAt) {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Running != nil || status.State.Terminated != nil {
+				return fmt.Sprintf("pod %v/%v ran with the volume on node %v", pod.Namespace, pod.Name, pod.Spec.NodeName), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// stopPods deletes the pods that are using the volumes and waits for them to
+// be gone. Pods that are started again by their owners are stopped the next
+// time the fence is extended.
+func stopPods(volumes []*stork_api.RestoreVolumeInfo) error {
+	pods := make([]v1.Pod, 0)
+	for _, vol := range volumes {
+		volPods, err := core.Instance().GetPodsUsingPVC(vol.PVC, vol.Namespace)
+		if err != nil {
+			return err
+		}
+		for _, pod := range volPods {
+			if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	logrus.Infof("Deleting %v pods using the volumes being fenced", len(pods))
+	if err := core.Instance().DeletePods(pods, false); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	var stopErr error
+	for _, pod := range pods {
+		if err := core.Instance().WaitForPodDeletion(pod.UID, pod.Namespace, podDeleteTimeout); err != nil {
+			stopErr = multierror.Append(stopErr, fmt.Errorf("pod %v/%v using the fenced volumes wasn't deleted: %v",
+				pod.Namespace, pod.Name, err))
+		}
+	}
+	return stopErr
+}
@@ -0,0 +1,289 @@
+//go:build unittest
+// +build unittest
+
+package fencing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const (
+	testNamespace = "test"
+	testPVC       = "pvc1"
+	testPV        = "pv1"
+)
+
+func setupVolumeAttachmentFencerTest(t *testing.T) *fake.Clientset {
+	client := fake.NewSimpleClientset(
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: testPVC, Namespace: testNamespace},
+			Spec:       v1.PersistentVolumeClaimSpec{VolumeName: testPV},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: testNamespace, UID: "pod1"},
+			Spec: v1.PodSpec{
+				Volumes: []v1.Volume{{
+					Name: "data",
+					VolumeSource: v1.VolumeSource{
+						PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: testPVC},
+					},
+				}},
+			},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		},
+	)
+	core.SetInstance(core.New(client))
+	apps.SetInstance(apps.New(client.AppsV1(), client.CoreV1()))
+	storage.SetInstance(storage.New(client.StorageV1()))
+	createAttachment(t, client, "va1", true)
+	return client
+}
+
+func createAttachment(t *testing.T, client *fake.Clientset, name string, attached bool) {
+	pvName := testPV
+	_, err := client.StorageV1().VolumeAttachments().Create(context.TODO(), &storagev1.VolumeAttachment{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: storagev1.VolumeAttachmentSpec{
+			NodeName: "node1",
+			Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+		},
+		Status: storagev1.VolumeAttachmentStatus{Attached: attached},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func getFencedAnnotation(t *testing.T) (string, bool) {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(testPVC, testNamespace)
+	require.NoError(t, err)
+	value, ok := pvc.Annotations[FencedAnnotation]
+	return value, ok
+}
+
+func TestVolumeAttachmentFencer(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	require.NoError(t, fencer.Fence(volumes))
+	fencedAt, ok := getFencedAnnotation(t)
+	require.True(t, ok, "Expected fence annotation on pvc")
+	pods, err := client.CoreV1().Pods(testNamespace).List(context.TODO(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, pods.Items, "Expected pod using the pvc to be deleted")
+	attachments, err := storage.Instance().ListVolumeAttachments()
+	require.NoError(t, err)
+	require.Empty(t, attachments.Items, "Expected VolumeAttachment to be deleted")
+
+	// Fencing again, like when the restore is resumed, keeps the time
+	require.NoError(t, fencer.Fence(volumes))
+	value, _ := getFencedAnnotation(t)
+	require.Equal(t, fencedAt, value)
+
+	// VolumeAttachments created during the restore are deleted before the
+	// volumes are attached
+	createAttachment(t, client, "va2", false)
+	require.NoError(t, fencer.Extend(volumes))
+	attachments, err = storage.Instance().ListVolumeAttachments()
+	require.NoError(t, err)
+	require.Empty(t, attachments.Items, "Expected new VolumeAttachment to be deleted")
+
+	require.NoError(t, fencer.Unfence(volumes))
+	_, ok = getFencedAnnotation(t)
+	require.False(t, ok, "Expected fence annotation to be removed")
+}
+
+func TestVolumeAttachmentFencerUnfenceAttached(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	require.NoError(t, fencer.Fence(volumes))
+	createAttachment(t, client, "va2", true)
+	err := fencer.Unfence(volumes)
+	require.Error(t, err, "Expected error for volume attached while fenced")
+	require.True(t, errors.As(err, new(*ErrFenceBreached)), "Expected ErrFenceBreached, got %v", err)
+	_, ok := getFencedAnnotation(t)
+	require.False(t, ok, "Expected fence annotation to be removed")
+
+	// Volumes that aren't fenced aren't checked
+	require.NoError(t, fencer.Unfence(volumes))
+}
+
+func TestVolumeAttachmentFencerBreachRecorded(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	require.NoError(t, fencer.Fence(volumes))
+	// The volume is attached during the restore and detached again when the
+	// fence is extended, so it has to be reported when unfencing
+	createAttachment(t, client, "va2", true)
+	require.NoError(t, fencer.Extend(volumes))
+	pvc, err := core.Instance().GetPersistentVolumeClaim(testPVC, testNamespace)
+	require.NoError(t, err)
+	require.Contains(t, pvc.Annotations, FenceBreachedAnnotation)
+	attachments, err := storage.Instance().ListVolumeAttachments()
+	require.NoError(t, err)
+	require.Empty(t, attachments.Items, "Expected new VolumeAttachment to be deleted")
+
+	err = fencer.Unfence(volumes)
+	require.True(t, errors.As(err, new(*ErrFenceBreached)), "Expected ErrFenceBreached, got %v", err)
+	pvc, err = core.Instance().GetPersistentVolumeClaim(testPVC, testNamespace)
+	require.NoError(t, err)
+	require.NotContains(t, pvc.Annotations, FencedAnnotation)
+	require.NotContains(t, pvc.Annotations, FenceBreachedAnnotation)
+}
+
+func TestVolumeAttachmentFencerPodRan(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	require.NoError(t, fencer.Fence(volumes))
+	fencedAt, _ := getFencedAnnotation(t)
+	parsed, err := time.Parse(time.RFC3339, fencedAt)
+	require.NoError(t, err)
+
+	// A pod that ran with the volume after it was fenced is reported even if
+	// its VolumeAttachment is already gone
+	_, err = client.CoreV1().Pods(testNamespace).Create(context.TODO(), &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pod2",
+			Namespace:         testNamespace,
+			CreationTimestamp: metav1.NewTime(parsed.Add(time.Minute)),
+		},
+		Spec: v1.PodSpec{
+			NodeName: "node1",
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: testPVC},
+				},
+			}},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodSucceeded,
+			ContainerStatuses: []v1.ContainerStatus{{
+				Name:  "app",
+				State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}},
+			}},
+		},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	err = fencer.Unfence(volumes)
+	var breached *ErrFenceBreached
+	require.True(t, errors.As(err, &breached), "Expected ErrFenceBreached, got %v", err)
+	require.Equal(t, testPVC, breached.PVC)
+}
+
+func createOwnedPod(t *testing.T, client *fake.Clientset, name string, owner metav1.OwnerReference) {
+	controller := true
+	owner.Controller = &controller
+	_, err := client.CoreV1().Pods(testNamespace).Create(context.TODO(), &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       testNamespace,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: testPVC},
+				},
+			}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func TestVolumeAttachmentFencerScalesOwners(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	replicas := int32(3)
+	controller := true
+	_, err := client.AppsV1().Deployments(testNamespace).Create(context.TODO(), &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy", Namespace: testNamespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.AppsV1().ReplicaSets(testNamespace).Create(context.TODO(), &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "deploy-rs",
+			Namespace:       testNamespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "deploy", Controller: &controller}},
+		},
+		Spec: appsv1.ReplicaSetSpec{Replicas: &replicas},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.AppsV1().StatefulSets(testNamespace).Create(context.TODO(), &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sts", Namespace: testNamespace},
+		Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	createOwnedPod(t, client, "deploy-pod", metav1.OwnerReference{Kind: "ReplicaSet", Name: "deploy-rs"})
+	createOwnedPod(t, client, "sts-0", metav1.OwnerReference{Kind: "StatefulSet", Name: "sts"})
+
+	require.NoError(t, fencer.Fence(volumes))
+	deployment, err := apps.Instance().GetDeployment("deploy", testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *deployment.Spec.Replicas, "Expected Deployment to be scaled down")
+	require.Equal(t, "3", deployment.Annotations[FencedReplicasAnnotation])
+	ss, err := apps.Instance().GetStatefulSet("sts", testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *ss.Spec.Replicas, "Expected StatefulSet to be scaled down")
+	pvc, err := core.Instance().GetPersistentVolumeClaim(testPVC, testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, "Deployment/deploy,StatefulSet/sts", pvc.Annotations[FencedOwnersAnnotation])
+
+	// Applications scaled up during the restore are scaled down again
+	ss.Spec.Replicas = &replicas
+	_, err = apps.Instance().UpdateStatefulSet(ss)
+	require.NoError(t, err)
+	require.NoError(t, fencer.Extend(volumes))
+	ss, err = apps.Instance().GetStatefulSet("sts", testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *ss.Spec.Replicas, "Expected StatefulSet to be scaled down again")
+	require.Equal(t, "3", ss.Annotations[FencedReplicasAnnotation])
+
+	require.NoError(t, fencer.Unfence(volumes))
+	deployment, err = apps.Instance().GetDeployment("deploy", testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *deployment.Spec.Replicas, "Expected Deployment to be scaled back up")
+	require.NotContains(t, deployment.Annotations, FencedReplicasAnnotation)
+	ss, err = apps.Instance().GetStatefulSet("sts", testNamespace)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *ss.Spec.Replicas, "Expected StatefulSet to be scaled back up")
+	pvc, err = core.Instance().GetPersistentVolumeClaim(testPVC, testNamespace)
+	require.NoError(t, err)
+	require.NotContains(t, pvc.Annotations, FencedOwnersAnnotation)
+}
+
+func TestVolumeAttachmentFencerUnscalableOwner(t *testing.T) {
+	client := setupVolumeAttachmentFencerTest(t)
+	fencer := &volumeAttachmentFencer{}
+	volumes := []*stork_api.RestoreVolumeInfo{{PVC: testPVC, Namespace: testNamespace}}
+
+	createOwnedPod(t, client, "ds-pod", metav1.OwnerReference{Kind: "DaemonSet", Name: "ds"})
+	err := fencer.Fence(volumes)
+	require.Error(t, err, "Expected error for pod managed by a DaemonSet")
+	require.Contains(t, err.Error(), "can't be scaled down")
+}
@@ -11,21 +11,26 @@ import (
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cache"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/libopenstorage/stork/pkg/rule"
+	"github.com/libopenstorage/stork/pkg/snapshot"
 	snapshotcontrollers "github.com/libopenstorage/stork/pkg/snapshot/controllers"
-	k8s_version "github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/snapshotter"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,8 +39,7 @@ import (
 )
 
 const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
+	groupSnapshotControllerName = "group-snapshot-controller"
 
 	updateCRD = true
 
@@ -57,7 +61,7 @@ var snapDeleteBackoff = wait.Backoff{
 // NewGroupSnapshot creates a new instance of GroupSnapshotController.
 func NewGroupSnapshot(mgr manager.Manager, d volume.Driver, r record.EventRecorder) *GroupSnapshotController {
 	return &GroupSnapshotController{
-		client:    mgr.GetClient(),
+		client:    controllers.NewConditionClient(mgr.GetClient()),
 		volDriver: d,
 		recorder:  r,
 	}
@@ -83,7 +87,8 @@ func (m *GroupSnapshotController) Init(mgr manager.Manager) error {
 	m.bgChannelsForRules = make(map[string]chan bool)
 	m.minResourceVersions = make(map[string]string)
 
-	return controllers.RegisterTo(mgr, "group-snapshot-controller", m, &stork_api.GroupVolumeSnapshot{})
+	return controllers.RegisterWithProgressTo(mgr, groupSnapshotControllerName, m, volume.ProgressKindGroupSnapshot,
+		func() runtimeclient.Object { return &stork_api.GroupVolumeSnapshot{} }, &stork_api.GroupVolumeSnapshot{})
 }
 
 // Reconcile reads that state of the cluster for an object and makes changes based on the state read
@@ -105,7 +110,7 @@ func (m *GroupSnapshotController) Reconcile(ctx context.Context, request reconci
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(groupSnapshotControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(groupSnapshot, controllers.FinalizerCleanup) {
@@ -113,18 +118,29 @@ func (m *GroupSnapshotController) Reconcile(ctx context.Context, request reconci
 		return reconcile.Result{Requeue: true}, m.client.Update(context.TODO(), groupSnapshot)
 	}
 
+	if controllers.IsObserved(groupSnapshot) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = m.handle(context.TODO(), groupSnapshot); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(m), groupSnapshot.Namespace, groupSnapshot.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(groupSnapshotControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, m.client, groupSnapshot, storkconfig.GetRequeuePeriod(groupSnapshotControllerName, controllers.DefaultRequeue))
 }
 
 func (m *GroupSnapshotController) handle(ctx context.Context, groupSnapshot *stork_api.GroupVolumeSnapshot) error {
 	if groupSnapshot.DeletionTimestamp != nil {
 		if controllers.ContainsFinalizer(groupSnapshot, controllers.FinalizerCleanup) {
 			if err := m.handleDelete(groupSnapshot); err != nil {
+				// Record the error so that group snapshots held back by the
+				// finalizer can be audited
+				if controllers.SetCleanupError(groupSnapshot, err) {
+					if updateErr := m.client.Update(ctx, groupSnapshot); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(m), updateErr)
+					}
+				}
 				return fmt.Errorf("cleanup: %s", err)
 			}
 		}
@@ -231,30 +247,7 @@ func (m *GroupSnapshotController) handle(ctx context.Context, groupSnapshot *sto
 }
 
 func (m *GroupSnapshotController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.GroupVolumeSnapshotResourceName,
-		Plural:  stork_api.GroupVolumeSnapshotResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.GroupVolumeSnapshot{}).Name(),
-	}
-	ok, err := k8s_version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.GroupVolumeSnapshot{}).Name())
 }
 
 func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolumeSnapshot) (bool, error) {
@@ -287,14 +280,14 @@ func (m *GroupSnapshotController) handleInitial(groupSnap *stork_api.GroupVolume
 		// Validate pre and post snap rules
 		preSnapRuleName := groupSnap.Spec.PreExecRule
 		if len(preSnapRuleName) > 0 {
-			if _, err := storkops.Instance().GetRule(preSnapRuleName, groupSnap.Namespace); err != nil {
+			if _, err := rule.GetRule(preSnapRuleName, groupSnap.Namespace); err != nil {
 				return !updateCRD, err
 			}
 		}
 
 		postSnapRuleName := groupSnap.Spec.PostExecRule
 		if len(postSnapRuleName) > 0 {
-			if _, err := storkops.Instance().GetRule(postSnapRuleName, groupSnap.Namespace); err != nil {
+			if _, err := rule.GetRule(postSnapRuleName, groupSnap.Namespace); err != nil {
 				return !updateCRD, err
 			}
 		}
@@ -324,7 +317,7 @@ func (m *GroupSnapshotController) handlePreSnap(groupSnap *stork_api.GroupVolume
 	}
 
 	log.GroupSnapshotLog(groupSnap).Infof("Running pre-snapshot rule: %s", ruleName)
-	r, err := storkops.Instance().GetRule(ruleName, groupSnap.Namespace)
+	r, err := rule.GetRule(ruleName, groupSnap.Namespace)
 	if err != nil {
 		return nil, !updateCRD, err
 	}
@@ -364,8 +357,25 @@ func (m *GroupSnapshotController) handleSnap(groupSnap *stork_api.GroupVolumeSna
 
 	if len(groupSnap.Status.VolumeSnapshots) > 0 {
 		log.GroupSnapshotLog(groupSnap).Infof("Group snapshot already active. Checking status")
-		response, err = m.volDriver.GetGroupSnapshotStatus(groupSnap)
+		if err := lockGroupSnapshotPVCs(groupSnap); err != nil {
+			log.GroupSnapshotLog(groupSnap).Warnf("Error extending locks on PVCs: %v", err)
+		}
+		response, err = m.volDriver.GetSnapshotStatuses(groupSnap)
+		if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+			response, err = m.volDriver.GetGroupSnapshotStatus(groupSnap)
+		}
 	} else {
+		// Wait for other operations using the PVCs to finish
+		if err := lockGroupSnapshotPVCs(groupSnap); err != nil {
+			return !updateCRD, err
+		}
+		if groupSnap.Spec.QuiesceVolumes {
+			unquiesce, err := m.quiesceVolumes(groupSnap)
+			if err != nil {
+				return !updateCRD, err
+			}
+			defer unquiesce()
+		}
 		log.GroupSnapshotLog(groupSnap).Infof("Creating new group snapshot")
 		response, err = m.volDriver.CreateGroupSnapshot(groupSnap)
 	}
@@ -410,10 +420,13 @@ func (m *GroupSnapshotController) handleSnap(groupSnap *stork_api.GroupVolumeSna
 			err.Error())
 	} else if areAllSnapshotsDone(response.Snapshots) {
 		log.GroupSnapshotLog(groupSnap).Infof("All snapshots in group are done")
-		// Create volumesnapshot and volumesnapshotdata objects in API
-		response.Snapshots, err = m.createSnapAndDataObjects(groupSnap, response.Snapshots)
-		if err != nil {
-			return !updateCRD, err
+		// Create volumesnapshot and volumesnapshotdata objects in API. The
+		// CSI driver creates the VolumeSnapshots itself.
+		if !m.isCSIGroupSnapshot() {
+			response.Snapshots, err = m.createSnapAndDataObjects(groupSnap, response.Snapshots)
+			if err != nil {
+				return !updateCRD, err
+			}
 		}
 
 		stage = stork_api.GroupSnapshotStagePostSnapshot
@@ -427,10 +440,28 @@ func (m *GroupSnapshotController) handleSnap(groupSnap *stork_api.GroupVolumeSna
 	groupSnap.Status.VolumeSnapshots = response.Snapshots
 	groupSnap.Status.Status = status
 	groupSnap.Status.Stage = stage
+	// The PVCs are locked again when the snapshots are retried
+	if stage != stork_api.GroupSnapshotStageSnapshot || len(response.Snapshots) == 0 {
+		unlockGroupSnapshotPVCs(groupSnap)
+	}
 
 	return updateCRD, nil
 }
 
+// quiesceVolumes quiesces IO on the volumes of all the PVCs in the group
+func (m *GroupSnapshotController) quiesceVolumes(groupSnap *stork_api.GroupVolumeSnapshot) (func(), error) {
+	pvcs, err := k8sutils.GetPVCsForGroupSnapshot(groupSnap.Namespace, groupSnap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	volumeIDs := make([]string, 0)
+	for _, pvc := range pvcs {
+		volumeIDs = append(volumeIDs, pvc.Spec.VolumeName)
+	}
+	log.GroupSnapshotLog(groupSnap).Infof("Quiescing %v volumes", len(volumeIDs))
+	return snapshot.QuiesceVolumes(m.volDriver, volumeIDs, string(groupSnap.UID))
+}
+
 func (m *GroupSnapshotController) replaceSnapshotData(
 	snapData *crdv1.VolumeSnapshotData,
 ) error {
@@ -482,15 +513,15 @@ func (m *GroupSnapshotController) createSnapAndDataObjects(
 		parentNamespace = metav1.NamespaceDefault
 	}
 	parentUUID := groupSnap.GetUID()
-	snapLabels := groupSnap.GetLabels()
-	snapAnnotations := groupSnap.GetAnnotations()
+	extraLabels, extraAnnotations, err := snapshotter.RenderGroupSnapshotMetadata(groupSnap)
+	if err != nil {
+		return nil, err
+	}
+	snapLabels := snapshotter.MergeMetadata(groupSnap.GetLabels(), extraLabels)
+	snapAnnotations := snapshotter.MergeMetadata(groupSnap.GetAnnotations(), extraAnnotations)
 	createSnapObjects := make([]*crdv1.VolumeSnapshot, 0)
 
 	if len(groupSnap.Spec.RestoreNamespaces) > 0 {
-		if len(snapAnnotations) == 0 {
-			snapAnnotations = make(map[string]string)
-		}
-
 		snapAnnotations[snapshotcontrollers.StorkSnapshotRestoreNamespacesAnnotation] = strings.Join(groupSnap.Spec.RestoreNamespaces, ",")
 	}
 
@@ -650,7 +681,7 @@ func (m *GroupSnapshotController) getPVCNameFromVolumeID(volID string) (string,
 		return volID, nil
 	}
 
-	pvc, err := core.Instance().GetPersistentVolumeClaim(parentPV.Spec.ClaimRef.Name, parentPV.Spec.ClaimRef.Namespace)
+	pvc, err := cache.GetPersistentVolumeClaim(parentPV.Spec.ClaimRef.Name, parentPV.Spec.ClaimRef.Namespace)
 	if err != nil {
 		return volID, nil
 	}
@@ -671,7 +702,7 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 	}
 
 	logrus.Infof("Running post-snapshot rule: %s", ruleName)
-	r, err := storkops.Instance().GetRule(ruleName, groupSnap.Namespace)
+	r, err := rule.GetRule(ruleName, groupSnap.Namespace)
 	if err != nil {
 		return nil, !updateCRD, err
 	}
@@ -698,11 +729,11 @@ func (m *GroupSnapshotController) handlePostSnap(groupSnap *stork_api.GroupVolum
 func (m *GroupSnapshotController) handleFinal(groupSnap *stork_api.GroupVolumeSnapshot) error {
 	// Check if user has updated restore namespace
 	childSnapshots := groupSnap.Status.VolumeSnapshots
-	if len(childSnapshots) > 0 {
+	if len(childSnapshots) > 0 && !m.isCSIGroupSnapshot() {
 		currentRestoreNamespaces := ""
 		latestRestoreNamespacesInCSV := strings.Join(groupSnap.Spec.RestoreNamespaces, ",")
 
-		vsObject, err := k8sextops.Instance().GetSnapshot(childSnapshots[0].VolumeSnapshotName, groupSnap.GetNamespace())
+		vsObject, err := cache.GetSnapshot(childSnapshots[0].VolumeSnapshotName, groupSnap.GetNamespace())
 		if err != nil {
 			return err
 		}
@@ -744,6 +775,7 @@ func (m *GroupSnapshotController) handleFinal(groupSnap *stork_api.GroupVolumeSn
 func (m *GroupSnapshotController) handleDelete(groupSnap *stork_api.GroupVolumeSnapshot) error {
 	// no need to track minResourceVersion for this group snap any longer
 	delete(m.minResourceVersions, string(groupSnap.UID))
+	unlockGroupSnapshotPVCs(groupSnap)
 
 	if err := m.volDriver.DeleteGroupSnapshot(groupSnap); err != nil {
 		return err
@@ -752,6 +784,47 @@ func (m *GroupSnapshotController) handleDelete(groupSnap *stork_api.GroupVolumeS
 	return nil
 }
 
+// lockGroupSnapshotPVCs locks the PVCs of the group snapshot while the
+// snapshots are taken. Locks already held by the group snapshot are extended.
+func lockGroupSnapshotPVCs(groupSnap *stork_api.GroupVolumeSnapshot) error {
+	pvcs, err := getGroupSnapshotPVCs(groupSnap)
+	if err != nil {
+		return err
+	}
+	return pvclock.AcquireAll(pvcs, pvclock.NewLock("GroupVolumeSnapshot", groupSnap, pvclock.OperationSnapshot))
+}
+
+// unlockGroupSnapshotPVCs releases the locks held by the group snapshot on
+// its PVCs
+func unlockGroupSnapshotPVCs(groupSnap *stork_api.GroupVolumeSnapshot) {
+	pvcs, err := getGroupSnapshotPVCs(groupSnap)
+	if err == nil {
+		err = pvclock.ReleaseAll(pvcs, string(groupSnap.UID))
+	}
+	if err != nil {
+		log.GroupSnapshotLog(groupSnap).Warnf("Error releasing locks on PVCs: %v", err)
+	}
+}
+
+func getGroupSnapshotPVCs(groupSnap *stork_api.GroupVolumeSnapshot) ([]types.NamespacedName, error) {
+	pvcList, err := k8sutils.GetPVCsForGroupSnapshot(groupSnap.Namespace, groupSnap.Spec.PVCSelector.MatchLabels)
+	if err != nil {
+		return nil, err
+	}
+	pvcs := make([]types.NamespacedName, 0, len(pvcList))
+	for _, pvc := range pvcList {
+		pvcs = append(pvcs, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name})
+	}
+	return pvcs, nil
+}
+
+// isCSIGroupSnapshot returns true if the group snapshots are taken by the CSI
+// driver, which creates CSI VolumeSnapshots instead of volumesnapshot and
+// volumesnapshotdata objects
+func (m *GroupSnapshotController) isCSIGroupSnapshot() bool {
+	return m.volDriver.String() == volume.CSIDriverName
+}
+
 // isAnySnapshotFailed checks if any of the given snapshots is in error state and returns
 // task IDs of failed snapshots
 func isAnySnapshotFailed(snapshots []*stork_api.VolumeSnapshotStatus) (bool, []string) {
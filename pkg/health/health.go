@@ -0,0 +1,121 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// LivenessPath is the path of the liveness endpoint
+	LivenessPath = "/healthz"
+	// ReadinessPath is the path of the readiness endpoint
+	ReadinessPath = "/readyz"
+)
+
+// Check returns an error if a component isn't ready to serve requests
+type Check func() error
+
+var (
+	checks   = make(map[string]Check)
+	lock     sync.Mutex
+	draining int32
+	server   *http.Server
+)
+
+// Register adds a readiness check for a component
+func Register(name string, check Check) {
+	lock.Lock()
+	defer lock.Unlock()
+	checks[name] = check
+}
+
+// StartDraining fails the readiness checks so that the replica is removed
+// from the endpoints of the stork service before its servers are stopped.
+func StartDraining() {
+	atomic.StoreInt32(&draining, 1)
+}
+
+// IsDraining returns true once StartDraining has been called
+func IsDraining() bool {
+	return atomic.LoadInt32(&draining) == 1
+}
+
+// Ready returns an error listing the components that aren't ready
+func Ready() error {
+	if IsDraining() {
+		return fmt.Errorf("draining connections for shutdown")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	failures := make([]string, 0)
+	for name, check := range checks {
+		if err := check(); err != nil {
+			failures = append(failures, fmt.Sprintf("%v: %v", name, err))
+		}
+	}
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("%v", strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+// Start starts serving the liveness and readiness endpoints on the given
+// address
+func Start(addr string) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if server != nil {
+		return fmt.Errorf("health server has already been started")
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivenessPath, serveLiveness)
+	mux.HandleFunc(ReadinessPath, serveReadiness)
+	server = &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		if err := server.ListenAndServe(); err != http.ErrServerClosed {
+			log.Errorf("Error starting health server: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Stop stops the health server
+func Stop() error {
+	lock.Lock()
+	defer lock.Unlock()
+	if server == nil {
+		return fmt.Errorf("health server has not been started")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		return err
+	}
+	server = nil
+	return nil
+}
+
+func serveLiveness(w http.ResponseWriter, req *http.Request) {
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Warnf("Error writing liveness response: %v", err)
+	}
+}
+
+func serveReadiness(w http.ResponseWriter, req *http.Request) {
+	if err := Ready(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if _, err := w.Write([]byte("ok")); err != nil {
+		log.Warnf("Error writing readiness response: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build unittest
+// +build unittest
+
+package health
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadiness(t *testing.T) {
+	defer func() {
+		checks = make(map[string]Check)
+		atomic.StoreInt32(&draining, 0)
+	}()
+
+	w := httptest.NewRecorder()
+	serveLiveness(w, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	warm := false
+	Register("extender", func() error {
+		if !warm {
+			return fmt.Errorf("warming up")
+		}
+		return nil
+	})
+	w = httptest.NewRecorder()
+	serveReadiness(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.Contains(t, w.Body.String(), "extender: warming up")
+
+	warm = true
+	w = httptest.NewRecorder()
+	serveReadiness(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	StartDraining()
+	w = httptest.NewRecorder()
+	serveReadiness(w, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	// Liveness isn't affected by draining
+	w = httptest.NewRecorder()
+	serveLiveness(w, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+}
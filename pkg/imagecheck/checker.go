@@ -0,0 +1,247 @@
+package imagecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultRegistryHost is the host serving the API of the default
+	// registry
+	defaultRegistryHost = "registry-1.docker.io"
+	// defaultRegistryRealmHost is the host issuing the tokens for the
+	// default registry
+	defaultRegistryRealmHost = "auth.docker.io"
+	// registryTimeout is the timeout for the requests to the registries
+	registryTimeout = 10 * time.Second
+)
+
+// manifestMediaTypes are the manifests accepted from the registries, image
+// indexes are included for multi-arch images
+var manifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}
+
+// Checker checks whether images can be pulled on a cluster
+type Checker struct {
+	// QueryRegistry checks the images that haven't been pulled on any node
+	// with a HEAD request to their registry
+	QueryRegistry bool
+	// Client is used for the requests to the registries
+	Client *http.Client
+	// allowedHosts are the registries that can be queried, along with the
+	// hosts their tokens can be requested from
+	allowedHosts map[string]bool
+	nodeImages   map[string]bool
+}
+
+// NewChecker returns a checker for the images already pulled on the nodes.
+// The kubelet only reports a limited number of images per node, so images
+// can be pulled on a node without being found. Only the allowed registries
+// are queried, so that restores can't have stork send requests to arbitrary
+// hosts.
+func NewChecker(nodes []v1.Node, queryRegistry bool, allowedRegistries []string) *Checker {
+	nodeImages := make(map[string]bool)
+	for _, node := range nodes {
+		for _, image := range node.Status.Images {
+			for _, name := range image.Names {
+				nodeImages[ParseReference(name).key()] = true
+			}
+		}
+	}
+	allowedHosts := make(map[string]bool)
+	for _, registry := range allowedRegistries {
+		allowedHosts[registry] = true
+		if registry == defaultRegistry {
+			allowedHosts[defaultRegistryHost] = true
+			allowedHosts[defaultRegistryRealmHost] = true
+		}
+	}
+	return &Checker{
+		QueryRegistry: queryRegistry,
+		Client: &http.Client{
+			Timeout: registryTimeout,
+			// Don't follow redirects to hosts that aren't allowed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		allowedHosts: allowedHosts,
+		nodeImages:   nodeImages,
+	}
+}
+
+// Missing returns the images that can't be pulled, sorted and without
+// duplicates. Images whose registry can't be queried, e.g. private
+// registries that need credentials, aren't reported since they could still
+// be pulled with the pull secrets of the pods.
+func (c *Checker) Missing(images []string) []string {
+	checked := make(map[string]bool)
+	missing := make([]string, 0)
+	for _, image := range images {
+		if checked[image] {
+			continue
+		}
+		checked[image] = true
+		ref := ParseReference(image)
+		if c.nodeImages[ref.key()] {
+			continue
+		}
+		if !c.QueryRegistry {
+			missing = append(missing, image)
+			continue
+		}
+		if !c.allowedHosts[ref.Registry] {
+			logrus.Debugf("Not checking image %v since registry %v isn't allowed", image, ref.Registry)
+			continue
+		}
+		found, err := c.inRegistry(ref)
+		if err != nil {
+			logrus.Warnf("Unable to check image %v in registry: %v", image, err)
+			continue
+		}
+		if !found {
+			missing = append(missing, image)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// inRegistry checks if the manifest of the image exists in its registry.
+// Anonymous tokens are requested from registries that need them, like the
+// default registry.
+func (c *Checker) inRegistry(ref Reference) (bool, error) {
+	host := ref.Registry
+	if host == defaultRegistry {
+		host = defaultRegistryHost
+	}
+	reference := ref.Digest
+	if reference == "" {
+		reference = ref.Tag
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Repository, reference)
+
+	resp, err := c.headManifest(manifestURL, "")
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.getToken(resp.Header.Get("Www-Authenticate"), host, ref.Repository)
+		if err != nil {
+			return false, err
+		}
+		if resp, err = c.headManifest(manifestURL, token); err != nil {
+			return false, err
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("unexpected status %v from registry %v", resp.Status, ref.Registry)
+}
+
+func (c *Checker) headManifest(manifestURL string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ","))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Body.Close(); err != nil {
+		logrus.Debugf("Error closing response from registry: %v", err)
+	}
+	return resp, nil
+}
+
+// getToken gets an anonymous token to pull the repository from the realm in
+// the Bearer challenge of a registry. The realm needs to be served over https
+// by the registry itself or one of the allowed hosts.
+func (c *Checker) getToken(challenge string, registryHost string, repository string) (string, error) {
+	params := parseChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry requires authentication")
+	}
+	tokenURL, err := url.Parse(realm)
+	if err != nil {
+		return "", fmt.Errorf("invalid realm %v: %v", realm, err)
+	}
+	if tokenURL.Scheme != "https" {
+		return "", fmt.Errorf("realm %v of registry %v isn't served over https", realm, registryHost)
+	}
+	if tokenURL.Host != registryHost && !c.allowedHosts[tokenURL.Host] {
+		return "", fmt.Errorf("realm %v isn't served by registry %v or an allowed host", realm, registryHost)
+	}
+	query := tokenURL.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", repository)
+	}
+	query.Set("scope", scope)
+	tokenURL.RawQuery = query.Encode()
+
+	resp, err := c.Client.Get(tokenURL.String())
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.Debugf("Error closing token response: %v", err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %v getting registry token", resp.Status)
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("error decoding registry token: %v", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge returns the parameters of a Bearer challenge, e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(challenge string) map[string]string {
+	params := make(map[string]string)
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return params
+	}
+	for _, param := range strings.Split(challenge[len("bearer "):], ",") {
+		parts := strings.SplitN(strings.TrimSpace(param), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		params[strings.ToLower(parts[0])] = strings.Trim(parts[1], `"`)
+	}
+	return params
+}
@@ -0,0 +1,202 @@
+package imagecheck
+
+import (
+	"strings"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// defaultRegistry is the registry of images that don't have one
+	defaultRegistry = "docker.io"
+	// defaultTag is the tag of images that don't have a tag or digest
+	defaultTag = "latest"
+	// officialRepositoryPrefix is the prefix of the repositories of the
+	// official images on the default registry
+	officialRepositoryPrefix = "library/"
+)
+
+// podSpecPath is the path of the pod spec in the workloads that run pods
+var podSpecPath = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"DeploymentConfig":      {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// Reference is a parsed image reference
+type Reference struct {
+	// Registry is the host of the registry, e.g. docker.io
+	Registry string
+	// Repository is the path of the image in the registry, e.g.
+	// library/nginx
+	Repository string
+	// Tag of the image. It is empty if the image is referenced by digest
+	// only.
+	Tag string
+	// Digest of the image, e.g. sha256:...
+	Digest string
+}
+
+// ParseReference parses an image reference, filling in the default registry
+// and tag like the container runtimes do
+func ParseReference(image string) Reference {
+	ref := Reference{}
+	if i := strings.Index(image, "@"); i >= 0 {
+		ref.Digest = image[i+1:]
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		ref.Tag = image[i+1:]
+		image = image[:i]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	ref.Registry = defaultRegistry
+	if i := strings.Index(image, "/"); i >= 0 {
+		domain := image[:i]
+		if strings.ContainsAny(domain, ".:") || domain == "localhost" {
+			ref.Registry = domain
+			image = image[i+1:]
+		}
+	}
+	if ref.Registry == "index.docker.io" {
+		ref.Registry = defaultRegistry
+	}
+	if ref.Registry == defaultRegistry && !strings.Contains(image, "/") {
+		image = officialRepositoryPrefix + image
+	}
+	ref.Repository = image
+	return ref
+}
+
+// String returns the fully qualified reference
+func (r Reference) String() string {
+	image := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		image += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		image += "@" + r.Digest
+	}
+	return image
+}
+
+// key identifies the image when comparing it with the images on the nodes.
+// Images with a digest are identified by it since their tag can be moved.
+func (r Reference) key() string {
+	if r.Digest != "" {
+		return r.Registry + "/" + r.Repository + "@" + r.Digest
+	}
+	return r.Registry + "/" + r.Repository + ":" + r.Tag
+}
+
+// MapRegistry replaces the registry of the image if it is in the mapping.
+// Images whose registry isn't mapped are returned unchanged.
+func MapRegistry(image string, registryMapping map[string]string) string {
+	if len(registryMapping) == 0 {
+		return image
+	}
+	ref := ParseReference(image)
+	registry, ok := registryMapping[ref.Registry]
+	if !ok || registry == "" {
+		return image
+	}
+	ref.Registry = strings.TrimSuffix(registry, "/")
+	return ref.String()
+}
+
+// GetImages returns the images of the containers and init containers of the
+// object. The pod templates of CRs are found from the ApplicationRegistrations
+// of their kind. Objects that don't run pods don't have any images.
+func GetImages(object runtime.Unstructured, registrations []storkapi.ApplicationRegistration) ([]string, error) {
+	images := make([]string, 0)
+	err := forEachContainer(object, registrations, func(container map[string]interface{}) {
+		if image, ok := container["image"].(string); ok && image != "" {
+			images = append(images, image)
+		}
+	})
+	return images, err
+}
+
+// MapRegistries replaces the registries of the images of the containers and
+// init containers of the object with the mapping
+func MapRegistries(
+	object runtime.Unstructured,
+	registrations []storkapi.ApplicationRegistration,
+	registryMapping map[string]string,
+) error {
+	if len(registryMapping) == 0 {
+		return nil
+	}
+	return forEachContainer(object, registrations, func(container map[string]interface{}) {
+		if image, ok := container["image"].(string); ok && image != "" {
+			container["image"] = MapRegistry(image, registryMapping)
+		}
+	})
+}
+
+// getPodSpecPaths returns the paths of the pod specs in the object
+func getPodSpecPaths(object runtime.Unstructured, registrations []storkapi.ApplicationRegistration) [][]string {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if path, ok := podSpecPath[gvk.Kind]; ok {
+		return [][]string{path}
+	}
+	paths := make([][]string, 0)
+	for _, registration := range registrations {
+		for _, resource := range registration.Resources {
+			if resource.Group != gvk.Group || resource.Version != gvk.Version || resource.Kind != gvk.Kind {
+				continue
+			}
+			for _, templatePath := range resource.PodTemplatePaths {
+				paths = append(paths, append(strings.Split(templatePath, "."), "spec"))
+			}
+		}
+	}
+	return paths
+}
+
+// forEachContainer calls the function with each container and init container
+// of the object. Changes made to the containers are saved in the object.
+func forEachContainer(
+	object runtime.Unstructured,
+	registrations []storkapi.ApplicationRegistration,
+	f func(container map[string]interface{}),
+) error {
+	paths := getPodSpecPaths(object, registrations)
+	if len(paths) == 0 {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	for _, path := range paths {
+		for _, field := range []string{"initContainers", "containers"} {
+			fieldPath := append(append([]string{}, path...), field)
+			containers, found, err := unstructured.NestedSlice(content, fieldPath...)
+			if err != nil {
+				return err
+			}
+			if !found {
+				continue
+			}
+			for _, c := range containers {
+				if container, ok := c.(map[string]interface{}); ok {
+					f(container)
+				}
+			}
+			if err := unstructured.SetNestedSlice(content, containers, fieldPath...); err != nil {
+				return err
+			}
+		}
+	}
+	object.SetUnstructuredContent(content)
+	return nil
+}
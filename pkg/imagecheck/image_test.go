@@ -0,0 +1,173 @@
+//go:build unittest
+// +build unittest
+
+package imagecheck
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                                   "docker.io/library/nginx:latest",
+		"nginx:1.21":                              "docker.io/library/nginx:1.21",
+		"bitnami/redis:7":                         "docker.io/bitnami/redis:7",
+		"index.docker.io/library/nginx":           "docker.io/library/nginx:latest",
+		"quay.io/org/app:v1":                      "quay.io/org/app:v1",
+		"localhost/app":                           "localhost/app:latest",
+		"registry.local:5000/app:v2":              "registry.local:5000/app:v2",
+		"quay.io/org/app@sha256:abc":              "quay.io/org/app@sha256:abc",
+		"quay.io/org/app:v1@sha256:abc":           "quay.io/org/app:v1@sha256:abc",
+		"registry.local:5000/team/app@sha256:def": "registry.local:5000/team/app@sha256:def",
+	}
+	for image, expected := range tests {
+		require.Equal(t, expected, ParseReference(image).String(), image)
+	}
+}
+
+func TestMapRegistry(t *testing.T) {
+	mapping := map[string]string{
+		"docker.io": "mirror.local/dockerhub/",
+		"quay.io":   "mirror.local",
+	}
+	require.Equal(t, "mirror.local/dockerhub/library/nginx:1.21", MapRegistry("nginx:1.21", mapping))
+	require.Equal(t, "mirror.local/org/app@sha256:abc", MapRegistry("quay.io/org/app@sha256:abc", mapping))
+	require.Equal(t, "gcr.io/project/app:v1", MapRegistry("gcr.io/project/app:v1", mapping),
+		"images whose registry isn't mapped should be unchanged")
+	require.Equal(t, "nginx", MapRegistry("nginx", nil))
+}
+
+func TestMapRegistries(t *testing.T) {
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata":   map[string]interface{}{"name": "report", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{
+				"template": map[string]interface{}{"spec": map[string]interface{}{
+					"initContainers": []interface{}{map[string]interface{}{"name": "init", "image": "busybox"}},
+					"containers":     []interface{}{map[string]interface{}{"name": "report", "image": "quay.io/org/report:v1"}},
+				}},
+			}},
+		},
+	}}
+	require.NoError(t, MapRegistries(cronJob, nil, map[string]string{"quay.io": "mirror.local"}))
+	images, err := GetImages(cronJob, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"busybox", "mirror.local/org/report:v1"}, images)
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "config", "namespace": "app"},
+	}}
+	images, err = GetImages(configMap, nil)
+	require.NoError(t, err)
+	require.Empty(t, images)
+
+	// The pod templates of CRs are found from their registrations
+	cluster := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "db.example.com/v1",
+		"kind":       "Cluster",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "app"},
+		"spec": map[string]interface{}{
+			"primary": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "db", "image": "postgres:14"}},
+			}}},
+		},
+	}}
+	registrations := []storkapi.ApplicationRegistration{{
+		Resources: []storkapi.ApplicationResource{{
+			GroupVersionKind: metav1.GroupVersionKind{Group: "db.example.com", Version: "v1", Kind: "Cluster"},
+			PodTemplatePaths: []string{"spec.primary.template", "spec.replica.template"},
+		}},
+	}}
+	images, err = GetImages(cluster, nil)
+	require.NoError(t, err)
+	require.Empty(t, images, "pod templates of CRs that aren't registered should be ignored")
+	require.NoError(t, MapRegistries(cluster, registrations, map[string]string{"docker.io": "mirror.local"}))
+	images, err = GetImages(cluster, registrations)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mirror.local/library/postgres:14"}, images)
+}
+
+func TestMissing(t *testing.T) {
+	nodes := []v1.Node{{Status: v1.NodeStatus{Images: []v1.ContainerImage{
+		{Names: []string{"docker.io/library/nginx:1.21", "docker.io/library/nginx@sha256:abc"}},
+	}}}}
+
+	checker := NewChecker(nodes, false, nil)
+	require.Equal(t, []string{"redis:7"}, checker.Missing([]string{"nginx:1.21", "nginx@sha256:abc", "redis:7", "redis:7"}))
+
+	// Images that haven't been pulled are looked up in their registry
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/token":
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case req.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("Www-Authenticate",
+				fmt.Sprintf(`Bearer realm="https://%s/token",service="registry"`, req.Host))
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasPrefix(req.URL.Path, "/v2/org/app/manifests/v1"):
+			w.WriteHeader(http.StatusOK)
+		case strings.HasPrefix(req.URL.Path, "/v2/org/private/"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	checker = NewChecker(nodes, true, []string{registry})
+	checker.Client = server.Client()
+	missing := checker.Missing([]string{
+		"nginx:1.21",
+		registry + "/org/app:v1",
+		registry + "/org/app:v2",
+		registry + "/org/private:v1",
+	})
+	require.Equal(t, []string{registry + "/org/app:v2"}, missing,
+		"images that can't be checked in their registry shouldn't be reported")
+
+	checker = NewChecker(nodes, true, nil)
+	checker.Client = server.Client()
+	require.Empty(t, checker.Missing([]string{registry + "/org/app:v2"}),
+		"registries that aren't allowed shouldn't be queried")
+}
+
+func TestGetTokenRealm(t *testing.T) {
+	requested := false
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = true
+		fmt.Fprint(w, `{"token": "anonymous"}`)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	checker := NewChecker(nil, true, []string{"registry.example.com"})
+	checker.Client = server.Client()
+	for _, realm := range []string{
+		"http://registry.example.com/token",
+		"https://169.254.169.254/latest/meta-data",
+		"https://" + host + "/token",
+	} {
+		_, err := checker.getToken(fmt.Sprintf(`Bearer realm="%s"`, realm), "registry.example.com", "org/app")
+		require.Error(t, err, "realm %v should be rejected", realm)
+	}
+	require.False(t, requested, "rejected realms shouldn't be requested")
+
+	token, err := checker.getToken(fmt.Sprintf(`Bearer realm="https://%s/token"`, host), host, "org/app")
+	require.NoError(t, err)
+	require.Equal(t, "anonymous", token)
+}
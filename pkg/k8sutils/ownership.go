@@ -0,0 +1,142 @@
+package k8sutils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/portworx/kdmp/pkg/drivers/utils"
+	"github.com/portworx/sched-ops/k8s/batch"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ownershipRepairJobPrefix = "stork-repair-"
+	// OwnershipRepairTimeout is the default time to wait for the ownership
+	// of a volume to be repaired
+	OwnershipRepairTimeout = 30 * time.Minute
+)
+
+// GetFSGroupForPVC returns the fsGroup from the security context of the pods
+// using the PVC along with the node that the pod is running on. Returns nil if
+// none of the pods have an fsGroup set.
+func GetFSGroupForPVC(pvcName, namespace string) (*int64, string, error) {
+	pods, err := core.Instance().GetPodsUsingPVC(pvcName, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, pod := range pods {
+		if pod.Spec.SecurityContext != nil && pod.Spec.SecurityContext.FSGroup != nil {
+			return pod.Spec.SecurityContext.FSGroup, pod.Spec.NodeName, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// RepairVolumeOwnership runs a job that changes the group of all the files
+// on the PVC to fsGroup and makes them group readable and writable, the same
+// way kubelet does when the volume is mounted. If nodeName is set the job is
+// run on that node so that it can mount volumes that are already attached
+// there. The job is deleted once it completes.
+func RepairVolumeOwnership(
+	pvc *v1.PersistentVolumeClaim,
+	fsGroup int64,
+	nodeName string,
+	timeout time.Duration,
+) error {
+	job, err := buildOwnershipRepairJob(pvc, fsGroup, nodeName)
+	if err != nil {
+		return err
+	}
+	if _, err := batch.Instance().CreateJob(job); err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating job to repair ownership of PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+	}
+	defer func() {
+		if err := batch.Instance().DeleteJob(job.Name, job.Namespace); err != nil && !errors.IsNotFound(err) {
+			logrus.Warnf("Error deleting job %v/%v: %v", job.Namespace, job.Name, err)
+		}
+	}()
+	if err := batch.Instance().ValidateJob(job.Name, job.Namespace, timeout); err != nil {
+		return fmt.Errorf("error repairing ownership of PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+	}
+	logrus.Infof("Repaired ownership of PVC %v/%v for fsGroup %v", pvc.Namespace, pvc.Name, fsGroup)
+	return nil
+}
+
+func buildOwnershipRepairJob(
+	pvc *v1.PersistentVolumeClaim,
+	fsGroup int64,
+	nodeName string,
+) (*batchv1.Job, error) {
+	storkPodNs, err := GetStorkPodNamespace()
+	if err != nil {
+		return nil, err
+	}
+	imageRegistry, imageRegistrySecret, err := utils.GetKopiaExecutorImageRegistryAndSecret(
+		utils.TriggeredFromStork,
+		storkPodNs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	image := utils.GetKopiaExecutorImageName()
+	if len(imageRegistry) != 0 {
+		image = fmt.Sprintf("%s/%s", imageRegistry, image)
+	}
+
+	cmd := fmt.Sprintf("chgrp -R %d /data && chmod -R g+rwX /data && find /data -type d -exec chmod g+s {} +", fsGroup)
+	backoffLimit := int32(1)
+	runAsRoot := int64(0)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ownershipRepairJobPrefix + string(pvc.UID),
+			Namespace: pvc.Namespace,
+			Annotations: map[string]string{
+				utils.SkipResourceAnnotation: "true",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					RestartPolicy:    v1.RestartPolicyOnFailure,
+					ImagePullSecrets: utils.ToImagePullSecret(imageRegistrySecret),
+					NodeName:         nodeName,
+					SecurityContext: &v1.PodSecurityContext{
+						RunAsUser: &runAsRoot,
+					},
+					Containers: []v1.Container{
+						{
+							Name:            "repair",
+							Image:           image,
+							ImagePullPolicy: v1.PullIfNotPresent,
+							Command:         []string{"/bin/sh", "-c", cmd},
+							VolumeMounts: []v1.VolumeMount{
+								{
+									Name:      "vol",
+									MountPath: "/data",
+								},
+							},
+						},
+					},
+					Volumes: []v1.Volume{
+						{
+							Name: "vol",
+							VolumeSource: v1.VolumeSource{
+								PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+									ClaimName: pvc.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	SetJobProvenanceLabels(job)
+	return job, nil
+}
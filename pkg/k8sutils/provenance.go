@@ -0,0 +1,90 @@
+package k8sutils
+
+import (
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/version"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// CreatedByLabel is set to CreatedByStork on the objects created by stork
+	CreatedByLabel = "stork.libopenstorage.org/created-by"
+	// ScheduleNameLabel is the name of the schedule that triggered the
+	// creation of the object
+	ScheduleNameLabel = "stork.libopenstorage.org/schedule-name"
+	// TriggerTimeLabel is the time at which the operation that created the
+	// object was triggered, in TriggerTimeFormat
+	TriggerTimeLabel = "stork.libopenstorage.org/trigger-time"
+	// StorkVersionLabel is the version of stork that created the object
+	StorkVersionLabel = "stork.libopenstorage.org/stork-version"
+
+	// CreatedByStork is the value of CreatedByLabel
+	CreatedByStork = "stork"
+	// TriggerTimeFormat is the format of TriggerTimeLabel. Label values can't
+	// contain colons, so RFC3339 can't be used.
+	TriggerTimeFormat = "20060102T150405Z"
+)
+
+// ProvenanceLabels returns the labels recording where an object created by
+// stork came from. scheduleName is empty for objects that weren't created
+// for a schedule.
+func ProvenanceLabels(scheduleName string, triggerTime time.Time) map[string]string {
+	labels := map[string]string{
+		CreatedByLabel:   CreatedByStork,
+		TriggerTimeLabel: triggerTime.UTC().Format(TriggerTimeFormat),
+	}
+	if scheduleName != "" {
+		labels[ScheduleNameLabel] = toLabelValue(scheduleName)
+	}
+	if storkVersion := toLabelValue(version.Version); storkVersion != "" {
+		labels[StorkVersionLabel] = storkVersion
+	}
+	return labels
+}
+
+// SetProvenanceLabels adds the provenance labels to the object. The labels
+// are copied, so the object can share its labels with another object.
+func SetProvenanceLabels(meta metav1.Object, scheduleName string, triggerTime time.Time) {
+	labels := make(map[string]string)
+	for k, v := range meta.GetLabels() {
+		labels[k] = v
+	}
+	for k, v := range ProvenanceLabels(scheduleName, triggerTime) {
+		labels[k] = v
+	}
+	meta.SetLabels(labels)
+}
+
+// IsCreatedBySchedule returns true if the provenance labels of the object
+// show that it was created for the schedule
+func IsCreatedBySchedule(meta metav1.Object, scheduleName string) bool {
+	return meta.GetLabels()[ScheduleNameLabel] == toLabelValue(scheduleName)
+}
+
+// SetJobProvenanceLabels adds the provenance labels to a job started by
+// stork and to its pods
+func SetJobProvenanceLabels(job *batchv1.Job) {
+	now := time.Now()
+	SetProvenanceLabels(job, "", now)
+	SetProvenanceLabels(&job.Spec.Template, "", now)
+}
+
+// toLabelValue converts the value to a valid label value by replacing the
+// characters that aren't allowed and truncating it to the maximum length
+func toLabelValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, value)
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(value, "-_.")
+}
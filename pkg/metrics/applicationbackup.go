@@ -30,6 +30,12 @@ var (
 		Name: "stork_application_backup_size",
 		Help: "Size of application backups",
 	}, []string{metricName, metricNamespace, metricSchedule})
+	// backupNamespaceSizeCounter for size of the volumes of each namespace in
+	// application backups
+	backupNamespaceSizeCounter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_application_backup_namespace_size",
+		Help: "Size of the volumes of a namespace in application backups",
+	}, []string{metricName, metricNamespace, metricSchedule, metricBackupNamespace})
 	// backupScheduleStatusCounter for application backup schedule CR status on server
 	backupScheduleStatusCounter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "stork_application_backup_schedule_status",
@@ -46,6 +52,7 @@ var (
 		stork_api.ApplicationBackupStatusFailed:         3,
 		stork_api.ApplicationBackupStatusPartialSuccess: 4,
 		stork_api.ApplicationBackupStatusSuccessful:     5,
+		stork_api.ApplicationBackupStatusCancelled:      6,
 	}
 	// backupStage map of application backup stage to enum
 	backupStage = map[stork_api.ApplicationBackupStageType]float64{
@@ -77,6 +84,9 @@ func watchBackupCR(object runtime.Object) error {
 		backupStageCounter.Delete(labels)
 		backupDurationCounter.Delete(labels)
 		backupSizeCounter.Delete(labels)
+		for ns := range getBackupNamespaceSizes(backup) {
+			backupNamespaceSizeCounter.Delete(getBackupNamespaceLabels(labels, ns))
+		}
 		return nil
 	}
 	// Set Backup Status counter
@@ -92,11 +102,33 @@ func watchBackupCR(object runtime.Object) error {
 		backupDurationCounter.With(labels).Set(float64(et - st))
 		// Set BackupSize
 		backupSizeCounter.With(labels).Set(float64(backup.Status.TotalSize))
+		for ns, size := range getBackupNamespaceSizes(backup) {
+			backupNamespaceSizeCounter.With(getBackupNamespaceLabels(labels, ns)).Set(float64(size))
+		}
 	}
 
 	return nil
 }
 
+// getBackupNamespaceSizes returns the total size of the volumes in the backup
+// for each namespace
+func getBackupNamespaceSizes(backup *stork_api.ApplicationBackup) map[string]uint64 {
+	sizes := make(map[string]uint64)
+	for _, vInfo := range backup.Status.Volumes {
+		sizes[vInfo.Namespace] += vInfo.TotalSize
+	}
+	return sizes
+}
+
+func getBackupNamespaceLabels(labels prometheus.Labels, namespace string) prometheus.Labels {
+	nsLabels := make(prometheus.Labels)
+	for k, v := range labels {
+		nsLabels[k] = v
+	}
+	nsLabels[metricBackupNamespace] = namespace
+	return nsLabels
+}
+
 func watchBackupScheduleCR(object runtime.Object) error {
 	bkpSched, ok := object.(*stork_api.ApplicationBackupSchedule)
 	if !ok {
@@ -120,5 +152,6 @@ func init() {
 	prometheus.MustRegister(backupStageCounter)
 	prometheus.MustRegister(backupDurationCounter)
 	prometheus.MustRegister(backupSizeCounter)
+	prometheus.MustRegister(backupNamespaceSizeCounter)
 	prometheus.MustRegister(backupScheduleStatusCounter)
 }
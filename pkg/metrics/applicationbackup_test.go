@@ -102,6 +102,10 @@ func TestBackupSuccessMetrics(t *testing.T) {
 	resp.Status.Stage = storkv1.ApplicationBackupStageFinal
 	resp.Status.Status = storkv1.ApplicationBackupStatusSuccessful
 	resp.Status.TotalSize = 1024
+	for _, vInfo := range resp.Status.Volumes {
+		vInfo.Namespace = "app"
+		vInfo.TotalSize = 1024
+	}
 	_, err = stork.Instance().UpdateApplicationBackup(resp)
 	require.NoError(t, err)
 	time.Sleep(3 * time.Second)
@@ -111,6 +115,8 @@ func TestBackupSuccessMetrics(t *testing.T) {
 	require.Equal(t, float64(backupStage[storkv1.ApplicationBackupStageFinal]), testutil.ToFloat64(backupStageCounter), "application_backup_stage does not matched")
 	// Size
 	require.Equal(t, float64(1024), testutil.ToFloat64(backupSizeCounter), "application_backup_size does not matched")
+	nsLabels := prometheus.Labels{metricName: "test", metricNamespace: "test", metricSchedule: "", metricBackupNamespace: "app"}
+	require.Equal(t, float64(1024*len(resp.Status.Volumes)), testutil.ToFloat64(backupNamespaceSizeCounter.With(nsLabels)), "application_backup_namespace_size does not matched")
 
 	err = stork.Instance().DeleteApplicationBackup("test", "test")
 	require.NoError(t, err)
@@ -20,6 +20,9 @@ const (
 	metricNamespace = "namespace"
 	// metricSchedule for stork prometheus metrics
 	metricSchedule = "schedule"
+	// metricBackupNamespace is the namespace of the volumes for backup
+	// metrics
+	metricBackupNamespace = "backup_namespace"
 	// metricPolicy for stork prometheus metrics
 	metricPolicy = "policy"
 	// waitInterval to wait for crd registration
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// metricDriver for volume driver metrics
+	metricDriver = "driver"
+	// metricMethod for volume driver metrics
+	metricMethod = "method"
+)
+
+var (
+	// volumeDriverDegradedCounter for volume drivers marked as degraded
+	volumeDriverDegradedCounter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_volume_driver_degraded",
+		Help: "Set to 1 if calls to the volume driver keep failing",
+	}, []string{metricDriver})
+	// volumeDriverCallFailuresCounter for failed calls to volume drivers
+	volumeDriverCallFailuresCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "stork_volume_driver_call_failures_total",
+		Help: "Number of failed calls to the volume driver",
+	}, []string{metricDriver, metricMethod})
+)
+
+// SetVolumeDriverDegraded sets whether the volume driver is degraded
+func SetVolumeDriverDegraded(driver string, degraded bool) {
+	labels := prometheus.Labels{metricDriver: driver}
+	if degraded {
+		volumeDriverDegradedCounter.With(labels).Set(1)
+	} else {
+		volumeDriverDegradedCounter.With(labels).Set(0)
+	}
+}
+
+// IncVolumeDriverCallFailures increments the failed calls for a volume driver
+// method
+func IncVolumeDriverCallFailures(driver string, method string) {
+	volumeDriverCallFailuresCounter.With(prometheus.Labels{
+		metricDriver: driver,
+		metricMethod: method,
+	}).Inc()
+}
+
+func init() {
+	prometheus.MustRegister(volumeDriverDegradedCounter)
+	prometheus.MustRegister(volumeDriverCallFailuresCounter)
+}
@@ -5,6 +5,7 @@ import (
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -13,6 +14,10 @@ var (
 		Name: "stork_volume_snapshotschedule_status",
 		Help: "Status of volume snapshot schedules",
 	}, []string{metricName, metricNamespace, metricPolicy})
+	volumeSnapshotScheduleDriftGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_volume_snapshotschedule_drift",
+		Help: "Set to 1 if the recent snapshots of the schedule all started late",
+	}, []string{metricName, metricNamespace, metricPolicy})
 )
 
 func watchVolumeSnapshotScheduleCR(object runtime.Object) error {
@@ -29,8 +34,14 @@ func watchVolumeSnapshotScheduleCR(object runtime.Object) error {
 
 	if volumeSnapshotSchedule.DeletionTimestamp != nil {
 		volumeSnapshotScheduleStatusCounter.Delete(labels)
+		volumeSnapshotScheduleDriftGauge.Delete(labels)
 		return nil
 	}
+	if meta.IsStatusConditionTrue(volumeSnapshotSchedule.Status.Conditions, stork_api.ConditionScheduleDrift) {
+		volumeSnapshotScheduleDriftGauge.With(labels).Set(1)
+	} else {
+		volumeSnapshotScheduleDriftGauge.With(labels).Set(0)
+	}
 	if suspend != nil && *suspend {
 		volumeSnapshotScheduleStatusCounter.With(labels).Set(1)
 	} else {
@@ -41,4 +52,5 @@ func watchVolumeSnapshotScheduleCR(object runtime.Object) error {
 
 func init() {
 	prometheus.MustRegister(volumeSnapshotScheduleStatusCounter)
+	prometheus.MustRegister(volumeSnapshotScheduleDriftGauge)
 }
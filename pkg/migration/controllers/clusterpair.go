@@ -4,19 +4,17 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/libopenstorage/stork/pkg/secretprovider"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -29,8 +27,7 @@ import (
 )
 
 const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
+	clusterPairControllerName = "cluster-pair-controller"
 )
 
 // NewClusterPair creates a new instance of ClusterPairController.
@@ -57,7 +54,7 @@ func (c *ClusterPairController) Init(mgr manager.Manager) error {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "cluster-pair-controller", c, &stork_api.ClusterPair{})
+	return controllers.RegisterTo(mgr, clusterPairControllerName, c, &stork_api.ClusterPair{})
 }
 
 // Reconcile manages ClusterPair resources.
@@ -75,7 +72,7 @@ func (c *ClusterPairController) Reconcile(ctx context.Context, request reconcile
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clusterPairControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup) {
@@ -85,10 +82,10 @@ func (c *ClusterPairController) Reconcile(ctx context.Context, request reconcile
 
 	if err = c.handle(context.TODO(), backup); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(c), backup.Namespace, backup.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(clusterPairControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(clusterPairControllerName, controllers.DefaultRequeue)}, nil
 }
 
 func (c *ClusterPairController) handle(ctx context.Context, clusterPair *stork_api.ClusterPair) error {
@@ -103,6 +100,9 @@ func (c *ClusterPairController) handle(ctx context.Context, clusterPair *stork_a
 					fmt.Sprintf("Cluster Pair delete failed: %v", err.Error()),
 				)
 				// Do not delete the cluster pair CR
+				if controllers.SetCleanupError(clusterPair, err) {
+					return c.client.Update(ctx, clusterPair)
+				}
 				return nil
 			}
 		}
@@ -115,7 +115,7 @@ func (c *ClusterPairController) handle(ctx context.Context, clusterPair *stork_a
 		return nil
 	}
 
-	if _, ok := clusterPair.Spec.Options["token"]; !ok {
+	if _, ok := clusterPair.Spec.Options["token"]; !ok && clusterPair.Spec.ExternalSecretConfig == nil {
 		clusterPair.Status.StorageStatus = stork_api.ClusterPairStatusNotProvided
 		c.recorder.Event(clusterPair,
 			v1.EventTypeNormal,
@@ -127,7 +127,7 @@ func (c *ClusterPairController) handle(ctx context.Context, clusterPair *stork_a
 		}
 	} else {
 		if clusterPair.Status.StorageStatus != stork_api.ClusterPairStatusReady {
-			remoteID, err := c.volDriver.CreatePair(clusterPair)
+			remoteID, err := c.createPair(clusterPair)
 			if err != nil {
 				clusterPair.Status.StorageStatus = stork_api.ClusterPairStatusError
 				c.recorder.Event(clusterPair,
@@ -185,20 +185,75 @@ func (c *ClusterPairController) handle(ctx context.Context, clusterPair *stork_a
 		}
 	}
 
+	if c.pruneReferences(clusterPair) {
+		return c.client.Update(context.TODO(), clusterPair)
+	}
 	return nil
 }
 
+// pruneReferences removes the references to migration schedules that have
+// been deleted or don't use the cluster pair anymore. Returns true if the
+// references were changed.
+func (c *ClusterPairController) pruneReferences(clusterPair *stork_api.ClusterPair) bool {
+	pruned := false
+	references := make([]stork_api.ObjectReference, 0, len(clusterPair.Status.References))
+	for _, ref := range clusterPair.Status.References {
+		if ref.Kind == "MigrationSchedule" {
+			schedule, err := storkops.Instance().GetMigrationSchedule(ref.Name, ref.Namespace)
+			if (err != nil && errors.IsNotFound(err)) ||
+				(err == nil && schedule.Spec.Template.Spec.ClusterPair != clusterPair.Name) {
+				pruned = true
+				continue
+			}
+		}
+		references = append(references, ref)
+	}
+	if pruned {
+		clusterPair.Status.References = references
+	}
+	return pruned
+}
+
+// createPair pairs the storage with the options from the external secret
+// merged in. The merged options are only passed to the driver and never
+// persisted in the ClusterPair object.
+func (c *ClusterPairController) createPair(clusterPair *stork_api.ClusterPair) (string, error) {
+	if clusterPair.Spec.ExternalSecretConfig == nil {
+		return c.volDriver.CreatePair(clusterPair)
+	}
+	pair := clusterPair.DeepCopy()
+	if err := secretprovider.UpdateClusterPair(pair); err != nil {
+		return "", err
+	}
+	return c.volDriver.CreatePair(pair)
+}
+
 func getClusterPairSchedulerConfig(clusterPairName string, namespace string) (*restclient.Config, error) {
 	clusterPair, err := storkops.Instance().GetClusterPair(clusterPairName, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("error getting clusterpair (%v/%v): %v", namespace, clusterPairName, err)
 	}
+	if err := secretprovider.UpdateClusterPair(clusterPair); err != nil {
+		return nil, err
+	}
 	remoteClientConfig := clientcmd.NewNonInteractiveClientConfig(
 		clusterPair.Spec.Config,
 		clusterPair.Spec.Config.CurrentContext,
 		&clientcmd.ConfigOverrides{},
 		clientcmd.NewDefaultClientConfigLoadingRules())
-	return remoteClientConfig.ClientConfig()
+	config, err := remoteClientConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	if clusterPair.Spec.RateLimit != nil {
+		if clusterPair.Spec.RateLimit.QPS > 0 {
+			config.QPS = float32(clusterPair.Spec.RateLimit.QPS)
+		}
+		if clusterPair.Spec.RateLimit.Burst > 0 {
+			config.Burst = clusterPair.Spec.RateLimit.Burst
+		}
+	}
+	return config, nil
 }
 
 func getClusterPairStorageStatus(clusterPairName string, namespace string) (stork_api.ClusterPairStatusType, error) {
@@ -242,30 +297,7 @@ func (c *ClusterPairController) cleanup(clusterPair *stork_api.ClusterPair) erro
 }
 
 func (c *ClusterPairController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.ClusterPairResourceName,
-		Plural:  stork_api.ClusterPairResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.ClusterPair{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.ClusterPair{}).Name())
 }
 
 func (c *ClusterPairController) createBackupLocationOnRemote(remoteConfig *restclient.Config, clusterPair *stork_api.ClusterPair) error {
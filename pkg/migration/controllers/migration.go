@@ -13,25 +13,31 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/libopenstorage/stork/pkg/imagecheck"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/libopenstorage/stork/pkg/resourcecollector"
 	"github.com/libopenstorage/stork/pkg/rule"
-	"github.com/libopenstorage/stork/pkg/version"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/mitchellh/hashstructure"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
 	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -43,6 +49,8 @@ import (
 )
 
 const (
+	migrationControllerName = "migration-controller"
+
 	// StorkMigrationReplicasAnnotation is the annotation used to keep track of
 	// the number of replicas for an application when it was migrated
 	StorkMigrationReplicasAnnotation = "stork.libopenstorage.org/migrationReplicas"
@@ -52,6 +60,9 @@ const (
 	// StorkMigrationName is the annotation used to identify resource migrated by
 	// migration CRD name
 	StorkMigrationName = "stork.libopenstorage.org/migrationName"
+	// MigrationFieldManager is the field manager used when applying migrated
+	// resources on the destination cluster
+	MigrationFieldManager = "stork-migration"
 	// StorkMigrationTime is the annotation used to specify time of migration
 	StorkMigrationTime = "stork.libopenstorage.org/migrationTime"
 	// StorkMigrationCRDActivateAnnotation is the annotation used to keep track of
@@ -60,6 +71,14 @@ const (
 	// StorkMigrationCRDDeactivateAnnotation is the annotation used to keep track of
 	// the value to be set for deactivating crds
 	StorkMigrationCRDDeactivateAnnotation = "stork.libopenstorage.org/migrationCRDDeactivate"
+	// StorkMigrationHPAReplicasAnnotation is the annotation used to keep track
+	// of the min and max replicas of a HorizontalPodAutoscaler when it was
+	// migrated, as "min,max"
+	StorkMigrationHPAReplicasAnnotation = "stork.libopenstorage.org/migrationHPAReplicas"
+	// StorkMigrationPDBAnnotation is the annotation used to keep track of the
+	// disruption budget of a PodDisruptionBudget when it was migrated, as
+	// "minAvailable=value" or "maxUnavailable=value"
+	StorkMigrationPDBAnnotation = "stork.libopenstorage.org/migrationPDB"
 	// PVReclaimAnnotation for pvc's reclaim policy
 	PVReclaimAnnotation = "stork.libopenstorage.org/reclaimPolicy"
 	// StorkAnnotationPrefix for resources created/managed by stork
@@ -76,9 +95,9 @@ const (
 // NewMigration creates a new instance of MigrationController.
 func NewMigration(mgr manager.Manager, d volume.Driver, r record.EventRecorder, rc resourcecollector.ResourceCollector) *MigrationController {
 	return &MigrationController{
-		client:            mgr.GetClient(),
+		client:            controllers.NewConditionClient(mgr.GetClient()),
 		volDriver:         d,
-		recorder:          r,
+		recorder:          controllers.NewEventHistoryRecorder(r),
 		resourceCollector: rc,
 	}
 }
@@ -108,7 +127,8 @@ func (m *MigrationController) Init(mgr manager.Manager, migrationAdminNamespace
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "migration-controller", m, &stork_api.Migration{})
+	return controllers.RegisterWithProgressTo(mgr, migrationControllerName, m, volume.ProgressKindMigration,
+		func() runtimeclient.Object { return &stork_api.Migration{} }, &stork_api.Migration{})
 }
 
 // Reconcile manages Migration resources.
@@ -126,7 +146,7 @@ func (m *MigrationController) Reconcile(ctx context.Context, request reconcile.R
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(migrationControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(migration, controllers.FinalizerCleanup) {
@@ -134,12 +154,16 @@ func (m *MigrationController) Reconcile(ctx context.Context, request reconcile.R
 		return reconcile.Result{Requeue: true}, m.client.Update(context.TODO(), migration)
 	}
 
+	if controllers.IsObserved(migration) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = m.handle(context.TODO(), migration); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(m), migration.Namespace, migration.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(migrationControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, m.client, migration, storkconfig.GetRequeuePeriod(migrationControllerName, controllers.DefaultRequeue))
 }
 
 func setKind(snap *stork_api.Migration) {
@@ -192,6 +216,13 @@ func setDefaults(spec stork_api.MigrationSpec) stork_api.MigrationSpec {
 		defaultBool := false
 		spec.SkipServiceUpdate = &defaultBool
 	}
+	if spec.DiffOnly == nil {
+		defaultBool := false
+		spec.DiffOnly = &defaultBool
+	}
+	if spec.JobPolicy == nil {
+		spec.JobPolicy = &stork_api.JobPolicy{}
+	}
 	return spec
 }
 
@@ -205,6 +236,14 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 		if controllers.ContainsFinalizer(migration, controllers.FinalizerCleanup) {
 			if err := m.cleanup(migration); err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(m), err)
+				// Keep the migration until the cleanup succeeds and record the
+				// error so that it can be audited
+				if controllers.SetCleanupError(migration, err) {
+					if updateErr := m.client.Update(ctx, migration); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(m), updateErr)
+					}
+				}
+				return err
 			}
 		}
 
@@ -368,7 +407,7 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 		}
 		// Make sure the rules exist if configured
 		if migration.Spec.PreExecRule != "" {
-			_, err := storkops.Instance().GetRule(migration.Spec.PreExecRule, migration.Namespace)
+			_, err := rule.GetRule(migration.Spec.PreExecRule, migration.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PreExecRule %v: %v", migration.Spec.PreExecRule, err)
 				log.MigrationLog(migration).Errorf(message)
@@ -380,7 +419,7 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 			}
 		}
 		if migration.Spec.PostExecRule != "" {
-			_, err := storkops.Instance().GetRule(migration.Spec.PostExecRule, migration.Namespace)
+			_, err := rule.GetRule(migration.Spec.PostExecRule, migration.Namespace)
 			if err != nil {
 				message := fmt.Sprintf("Error getting PostExecRule %v: %v", migration.Spec.PreExecRule, err)
 				log.MigrationLog(migration).Errorf(message)
@@ -391,6 +430,13 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 				return nil
 			}
 		}
+		// Nothing is migrated when only computing the diff, so skip the
+		// rules and volumes
+		if *migration.Spec.DiffOnly {
+			migration.Status.Stage = stork_api.MigrationStageApplications
+			migration.Status.Status = stork_api.MigrationStatusInProgress
+			return m.updateMigrationCR(context.Background(), migration)
+		}
 		fallthrough
 	case stork_api.MigrationStagePreExecRule:
 		terminationChannels, err = m.runPreExecRule(migration)
@@ -414,6 +460,15 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 		if *migration.Spec.IncludeVolumes {
 			err := m.migrateVolumes(migration, terminationChannels)
 			if err != nil {
+				if _, ok := err.(*pvclock.ErrLocked); ok {
+					message := fmt.Sprintf("Waiting for volume to be unlocked: %v", err)
+					log.MigrationLog(migration).Infof(message)
+					m.recorder.Event(migration,
+						v1.EventTypeNormal,
+						string(stork_api.MigrationStatusInProgress),
+						message)
+					return nil
+				}
 				message := fmt.Sprintf("Error migrating volumes: %v", err)
 				log.MigrationLog(migration).Errorf(message)
 				m.recorder.Event(migration,
@@ -442,7 +497,25 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 				message)
 			return nil
 		}
+	case stork_api.MigrationStageStaged:
+		if err := m.handleStaged(migration); err != nil {
+			message := fmt.Sprintf("Error handling staged migration: %v", err)
+			log.MigrationLog(migration).Errorf(message)
+			m.recorder.Event(migration,
+				v1.EventTypeWarning,
+				string(stork_api.MigrationStatusFailed),
+				message)
+			return nil
+		}
 	case stork_api.MigrationStageFinal:
+		if err := m.rollbackCutover(migration); err != nil {
+			message := fmt.Sprintf("Error rolling back cutover: %v", err)
+			log.MigrationLog(migration).Errorf(message)
+			m.recorder.Event(migration,
+				v1.EventTypeWarning,
+				string(stork_api.MigrationStatusFailed),
+				message)
+		}
 		return nil
 	default:
 		log.MigrationLog(migration).Errorf("Invalid stage for migration: %v", migration.Status.Stage)
@@ -451,6 +524,11 @@ func (m *MigrationController) handle(ctx context.Context, migration *stork_api.M
 	return nil
 }
 
+// purgeMigratedResources deletes resources on the destination cluster that
+// were migrated earlier but have since been deleted from the source cluster.
+// The resources applied on the destination are tracked in a ledger so that
+// only resources created by the migration are removed. If there is no ledger
+// yet, migrated resources are identified using the migration annotation.
 func (m *MigrationController) purgeMigratedResources(migration *stork_api.Migration) error {
 	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
 	if err != nil {
@@ -458,76 +536,112 @@ func (m *MigrationController) purgeMigratedResources(migration *stork_api.Migrat
 	}
 
 	log.MigrationLog(migration).Infof("Purging old unused resources ...")
+	ledger, found, err := getMigrationLedger(migration)
+	if err != nil {
+		return err
+	}
+	var toBeDeleted []*stork_api.ObjectInfo
+	if found {
+		toBeDeleted = getLedgerStaleResources(migration, ledger)
+	} else {
+		staleObjects, err := m.getStaleMigratedResources(migration, remoteConfig)
+		if err != nil {
+			return err
+		}
+		for _, o := range staleObjects {
+			objectInfo, err := getMigrationObjectInfo(o)
+			if err != nil {
+				// log error and skip purging the object
+				log.MigrationLog(migration).Errorf("Unable to get object details: %v", err)
+				continue
+			}
+			toBeDeleted = append(toBeDeleted, objectInfo)
+		}
+	}
+
+	newLedger := buildMigrationLedger(migration, ledger)
+	dynamicInterface, err := dynamic.NewForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+	logLedgerEntries(migration, toBeDeleted)
+	err = m.resourceCollector.DeleteResources(dynamicInterface, ledgerEntriesToObjects(toBeDeleted))
+	if err != nil {
+		// Keep track of the resources so that they are purged next time
+		if ledgerErr := updateMigrationLedger(migration, append(newLedger, toBeDeleted...)); ledgerErr != nil {
+			log.MigrationLog(migration).Errorf("Error updating migration ledger: %v", ledgerErr)
+		}
+		return err
+	}
+	if err := updateMigrationLedger(migration, newLedger); err != nil {
+		return fmt.Errorf("error updating migration ledger: %v", err)
+	}
+
+	// update status of cleaned up objects migration info
+	for _, r := range toBeDeleted {
+		resourceInfo := &stork_api.MigrationResourceInfo{
+			Name:             r.Name,
+			Namespace:        r.Namespace,
+			GroupVersionKind: r.GroupVersionKind,
+			Status:           stork_api.MigrationStatusPurged,
+		}
+		migration.Status.Resources = append(migration.Status.Resources, resourceInfo)
+	}
+
+	return nil
+}
+
+// getStaleMigratedResources returns the resources on the destination cluster
+// that were migrated but no longer exist on the source cluster
+func (m *MigrationController) getStaleMigratedResources(
+	migration *stork_api.Migration,
+	remoteConfig *rest.Config,
+) ([]runtime.Unstructured, error) {
 	// use seperate resource collector for collecting resources
 	// from destination cluster
 	rc := resourcecollector.ResourceCollector{
 		Driver: m.volDriver,
 	}
-	err = rc.Init(remoteConfig)
+	err := rc.Init(remoteConfig)
 	if err != nil {
 		log.MigrationLog(migration).Errorf("Error initializing resource collector: %v", err)
-		return err
+		return nil, err
 	}
 	destObjects, err := rc.GetResources(
 		migration.Spec.Namespaces,
 		migration.Spec.Selectors,
 		nil,
 		migration.Spec.IncludeOptionalResourceTypes,
-		false)
+		false,
+		migration.Spec.SecretTypes)
 	if err != nil {
 		m.recorder.Event(migration,
 			v1.EventTypeWarning,
 			string(stork_api.MigrationStatusFailed),
 			fmt.Sprintf("Error getting resources from destination: %v", err))
 		log.MigrationLog(migration).Errorf("Error getting resources: %v", err)
-		return err
+		return nil, err
 	}
 	srcObjects, err := m.resourceCollector.GetResources(
 		migration.Spec.Namespaces,
 		migration.Spec.Selectors,
 		nil,
 		migration.Spec.IncludeOptionalResourceTypes,
-		false)
+		false,
+		migration.Spec.SecretTypes)
 	if err != nil {
 		m.recorder.Event(migration,
 			v1.EventTypeWarning,
 			string(stork_api.MigrationStatusFailed),
 			fmt.Sprintf("Error getting resources from source: %v", err))
 		log.MigrationLog(migration).Errorf("Error getting resources: %v", err)
-		return err
+		return nil, err
 	}
 	obj, err := objectToCollect(destObjects)
 	if err != nil {
-		return err
-	}
-	toBeDeleted := objectTobeDeleted(srcObjects, obj)
-	dynamicInterface, err := dynamic.NewForConfig(remoteConfig)
-	if err != nil {
-		return err
-	}
-	err = m.resourceCollector.DeleteResources(dynamicInterface, toBeDeleted)
-	if err != nil {
-		return err
-	}
-
-	// update status of cleaned up objects migration info
-	for _, r := range toBeDeleted {
-		nm, ns, kind, err := getObjectDetails(r)
-		if err != nil {
-			// log error and skip adding object to status
-			log.MigrationLog(migration).Errorf("Unable to get object details: %v", err)
-			continue
-		}
-		resourceInfo := &stork_api.MigrationResourceInfo{
-			Name:      nm,
-			Namespace: ns,
-			Status:    stork_api.MigrationStatusPurged,
-		}
-		resourceInfo.Kind = kind
-		migration.Status.Resources = append(migration.Status.Resources, resourceInfo)
+		return nil, err
 	}
-
-	return nil
+	return objectTobeDeleted(srcObjects, obj), nil
 }
 
 func getObjectDetails(o interface{}) (name, namespace, kind string, err error) {
@@ -630,8 +744,28 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 				storageStatus, err)
 		}
 
+		// Wait for other operations using the PVCs to finish
+		pvcs, err := getMigrationPVCs(migration)
+		if err != nil {
+			return err
+		}
+		lock := pvclock.NewLock("Migration", migration, pvclock.OperationMigration)
+		if err := pvclock.AcquireAll(pvcs, lock); err != nil {
+			// Run the preExecRule again once the PVCs are unlocked
+			if _, ok := err.(*pvclock.ErrLocked); ok && migration.Spec.PreExecRule != "" {
+				migration.Status.Stage = stork_api.MigrationStageInitial
+				if updateErr := m.updateMigrationCR(context.TODO(), migration); updateErr != nil {
+					return updateErr
+				}
+			}
+			return err
+		}
+
 		volumeInfos, err := m.volDriver.StartMigration(migration)
 		if err != nil {
+			if releaseErr := pvclock.ReleaseAll(pvcs, lock.OwnerUID); releaseErr != nil {
+				log.MigrationLog(migration).Warnf("Error releasing locks on PVCs: %v", releaseErr)
+			}
 			return err
 		}
 		if volumeInfos == nil {
@@ -682,7 +816,7 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 	// Skip checking status if no volumes are being migrated
 	if len(migration.Status.Volumes) != 0 {
 		// Now check the status
-		volumeInfos, err := m.volDriver.GetMigrationStatus(migration)
+		volumeInfos, err := m.getMigrationVolumeStatuses(migration)
 		if err != nil {
 			return err
 		}
@@ -710,6 +844,7 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 				migration.Status.Stage = stork_api.MigrationStageFinal
 				migration.Status.FinishTimestamp = metav1.Now()
 				migration.Status.Status = stork_api.MigrationStatusFailed
+				migration.Status.FailedItems = migration.GetFailedItems()
 			} else if vInfo.Status == stork_api.MigrationStatusSuccessful {
 				m.recorder.Event(migration,
 					v1.EventTypeNormal,
@@ -721,8 +856,10 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 
 	// Return if we have any volume migrations still in progress
 	if inProgress {
+		extendVolumeLocks(migration)
 		return nil
 	}
+	releaseVolumeLocks(migration)
 
 	migration.Status.VolumeMigrationFinishTimestamp = metav1.Now()
 	// If the migration hasn't failed move on to the next stage.
@@ -747,9 +884,12 @@ func (m *MigrationController) migrateVolumes(migration *stork_api.Migration, ter
 				log.MigrationLog(migration).Errorf("Error migrating resources: %v", err)
 				return err
 			}
-			migration.Status.Stage = stork_api.MigrationStageFinal
-			migration.Status.FinishTimestamp = metav1.Now()
-			migration.Status.Status = stork_api.MigrationStatusSuccessful
+			// The migration waits for its next sync if it is staged
+			if migration.Status.Stage != stork_api.MigrationStageStaged {
+				migration.Status.Stage = stork_api.MigrationStageFinal
+				migration.Status.FinishTimestamp = metav1.Now()
+				migration.Status.Status = stork_api.MigrationStatusSuccessful
+			}
 		}
 	}
 
@@ -787,7 +927,7 @@ func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]
 	}
 	terminationChannels := make([]chan bool, 0)
 	for _, ns := range migration.Spec.Namespaces {
-		r, err := storkops.Instance().GetRule(migration.Spec.PreExecRule, ns)
+		r, err := rule.GetRule(migration.Spec.PreExecRule, ns)
 		if err != nil {
 			for _, channel := range terminationChannels {
 				channel <- true
@@ -811,7 +951,7 @@ func (m *MigrationController) runPreExecRule(migration *stork_api.Migration) ([]
 
 func (m *MigrationController) runPostExecRule(migration *stork_api.Migration) error {
 	for _, ns := range migration.Spec.Namespaces {
-		r, err := storkops.Instance().GetRule(migration.Spec.PostExecRule, ns)
+		r, err := rule.GetRule(migration.Spec.PostExecRule, ns)
 		if err != nil {
 			return err
 		}
@@ -864,7 +1004,8 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 			migration.Spec.Selectors,
 			nil,
 			migration.Spec.IncludeOptionalResourceTypes,
-			false)
+			false,
+			migration.Spec.SecretTypes)
 		if err != nil {
 			m.recorder.Event(migration,
 				v1.EventTypeWarning,
@@ -891,6 +1032,22 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 				continue
 			}
 		}
+		if gvk.Kind == "Job" {
+			skip, err := resourcecollector.SkipJob(obj, migration.Spec.JobPolicy)
+			if err != nil {
+				return err
+			}
+			if skip {
+				continue
+			}
+		}
+		skip, err := resourcecollector.PrepareServiceMeshForApply(obj, migration.Spec.ServiceMeshPolicy)
+		if err != nil {
+			return err
+		}
+		if skip {
+			continue
+		}
 		resourceInfo := &stork_api.MigrationResourceInfo{
 			Name:      metadata.GetName(),
 			Namespace: metadata.GetNamespace(),
@@ -924,6 +1081,19 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 		log.MigrationLog(migration).Errorf("Error preparing resources: %v", err)
 		return err
 	}
+	if *migration.Spec.DiffOnly {
+		return m.diffResources(migration, updateObjects)
+	}
+	if migration.Spec.StorageClassCreation != nil {
+		if err := m.createStorageClasses(migration, updateObjects); err != nil {
+			m.recorder.Event(migration,
+				v1.EventTypeWarning,
+				string(stork_api.MigrationStatusFailed),
+				fmt.Sprintf("Error creating storage classes: %v", err))
+			log.MigrationLog(migration).Errorf("Error creating storage classes: %v", err)
+			return err
+		}
+	}
 	err = m.applyResources(migration, updateObjects, resKinds)
 	if err != nil {
 		m.recorder.Event(migration,
@@ -943,7 +1113,24 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 			break
 		}
 	}
-	if *migration.Spec.PurgeDeletedResources {
+	migration.Status.FailedItems = migration.GetFailedItems()
+	if len(migration.Status.FailedItems) != 0 && migration.Spec.FailOnPartial {
+		message := fmt.Sprintf("%v volumes or resources failed to migrate", len(migration.Status.FailedItems))
+		log.MigrationLog(migration).Errorf(message)
+		m.recorder.Event(migration,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			message)
+		migration.Status.Status = stork_api.MigrationStatusFailed
+	}
+	if *migration.Spec.PurgeDeletedResources && migration.Status.Status != stork_api.MigrationStatusSuccessful {
+		// Resources that couldn't be migrated might only be missing on the
+		// source temporarily, so only record what was applied and leave the
+		// purge to the next successful migration
+		if err := addToMigrationLedger(migration); err != nil {
+			log.MigrationLog(migration).Errorf("Error updating migration ledger: %v", err)
+		}
+	} else if *migration.Spec.PurgeDeletedResources {
 		if err := m.purgeMigratedResources(migration); err != nil {
 			message := fmt.Sprintf("Error cleaning up resources: %v", err)
 			log.MigrationLog(migration).Errorf(message)
@@ -955,6 +1142,9 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 		}
 	}
 
+	if migration.Status.Status != stork_api.MigrationStatusFailed && m.finishSync(migration) {
+		return m.updateMigrationCR(context.TODO(), migration)
+	}
 	migration.Status.FinishTimestamp = metav1.Now()
 	err = m.updateMigrationCR(context.TODO(), migration)
 	if err != nil {
@@ -963,6 +1153,55 @@ func (m *MigrationController) migrateResources(migration *stork_api.Migration, v
 	return nil
 }
 
+// createStorageClasses creates the storage classes of the migrated PVCs that
+// don't exist on the destination from their definitions in this cluster
+func (m *MigrationController) createStorageClasses(
+	migration *stork_api.Migration,
+	objects []runtime.Unstructured,
+) error {
+	names, err := resourcecollector.GetPVCStorageClasses(objects)
+	if err != nil {
+		return err
+	}
+	storageClasses := make([]*storagev1.StorageClass, 0)
+	for _, name := range names {
+		sc, err := storage.Instance().GetStorageClass(name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		storageClasses = append(storageClasses, sc)
+	}
+	if len(storageClasses) == 0 {
+		return nil
+	}
+	adminClient, err := m.getRemoteAdminConfig(migration)
+	if err != nil {
+		return err
+	}
+	created, err := resourcecollector.CreateMissingStorageClasses(
+		storage.New(adminClient.StorageV1()),
+		storageClasses,
+		nil,
+		migration.Spec.StorageClassCreation,
+	)
+	for _, name := range created {
+		m.recorder.Event(migration,
+			v1.EventTypeNormal,
+			string(stork_api.MigrationStatusInProgress),
+			fmt.Sprintf("Created storage class %v missing on the destination", name))
+	}
+	return err
+}
+
+// setMigrationProvenanceLabels labels a migrated object with the schedule
+// and time of the migration that created it
+func setMigrationProvenanceLabels(object metav1.Object, migration *stork_api.Migration) {
+	k8sutils.SetProvenanceLabels(object, migration.Annotations[StorkMigrationScheduleName], migration.CreationTimestamp.Time)
+}
+
 func (m *MigrationController) prepareResources(
 	migration *stork_api.Migration,
 	objects []runtime.Unstructured,
@@ -971,12 +1210,19 @@ func (m *MigrationController) prepareResources(
 	if err != nil {
 		return err
 	}
+	registries, err := controllers.GetRegistryMapping(context.TODO(), m.client, migration.Namespace, migration.Spec.RegistryMappingName)
+	if err != nil {
+		return err
+	}
 
 	for _, o := range objects {
 		metadata, err := meta.Accessor(o)
 		if err != nil {
 			return err
 		}
+		if err := imagecheck.MapRegistries(o, crdList.Items, registries); err != nil {
+			return fmt.Errorf("error mapping image registries of %v %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+		}
 		resource := o.GetObjectKind().GroupVersionKind()
 		switch resource.Kind {
 		case "PersistentVolume":
@@ -994,6 +1240,21 @@ func (m *MigrationController) prepareResources(
 			if err != nil {
 				return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
 			}
+		case "Job":
+			err := resourcecollector.PrepareJobForApply(o, migration.Spec.JobPolicy)
+			if err != nil {
+				return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+			}
+		case "HorizontalPodAutoscaler":
+			err := m.prepareHPAResource(migration, o)
+			if err != nil {
+				return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+			}
+		case "PodDisruptionBudget":
+			err := m.preparePDBResource(migration, o)
+			if err != nil {
+				return fmt.Errorf("error preparing %v resource %v: %v", o.GetObjectKind().GroupVersionKind().Kind, metadata.GetName(), err)
+			}
 		}
 
 		// prepare CR resources
@@ -1047,6 +1308,28 @@ func (m *MigrationController) updateResourceStatus(
 			return
 		}
 	}
+
+	// The object might have been converted to a different API version for
+	// the destination cluster, so match on the kind and record the version
+	// that was applied
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return
+	}
+	gkv := object.GetObjectKind().GroupVersionKind()
+	for _, resource := range migration.Status.Resources {
+		if resource.Name == metadata.GetName() &&
+			resource.Namespace == metadata.GetNamespace() &&
+			resource.Kind == gkv.Kind {
+			resource.Group = gkv.Group
+			if resource.Group == "" {
+				resource.Group = "core"
+			}
+			resource.Version = gkv.Version
+			m.updateResourceStatus(migration, object, status, reason)
+			return
+		}
+	}
 }
 
 func (m *MigrationController) getRemoteAdminConfig(migration *stork_api.Migration) (*kubernetes.Clientset, error) {
@@ -1206,21 +1489,101 @@ func (m *MigrationController) prepareJobResource(
 	migration *stork_api.Migration,
 	object runtime.Unstructured,
 ) error {
-	if *migration.Spec.StartApplications {
+	if startApplications(migration) {
+		return resumeCutoverCronJob(object)
+	}
+	// Staged migrations don't start CronJobs before the cutover either, they
+	// are still running on the source until then
+	if migration.Spec.Staging == nil && migration.Spec.JobPolicy.StartCronJobs {
+		return nil
+	}
+	// Suspend the CronJobs until the namespace is activated
+	return resourcecollector.PrepareCronJobForApply(object)
+}
+
+// prepareHPAResource pins the HorizontalPodAutoscaler to a single replica
+// while the applications aren't started. The original min and max replicas
+// are stored in an annotation so that they can be restored on activation.
+func (m *MigrationController) prepareHPAResource(
+	migration *stork_api.Migration,
+	object runtime.Unstructured,
+) error {
+	if startApplications(migration) {
 		return nil
 	}
 	content := object.UnstructuredContent()
-	// set suspend to true to disable Cronjobs
-	return unstructured.SetNestedField(content, true, "spec", "suspend")
+	minReplicas, found, err := unstructured.NestedInt64(content, "spec", "minReplicas")
+	if err != nil {
+		return err
+	}
+	if !found {
+		minReplicas = 1
+	}
+	maxReplicas, _, err := unstructured.NestedInt64(content, "spec", "maxReplicas")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(content, int64(1), "spec", "minReplicas"); err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(content, int64(1), "spec", "maxReplicas"); err != nil {
+		return err
+	}
+	return setMigrationAnnotation(content, StorkMigrationHPAReplicasAnnotation, fmt.Sprintf("%v,%v", minReplicas, maxReplicas))
 }
 
-func (m *MigrationController) prepareApplicationResource(
+// preparePDBResource allows all the pods of the PodDisruptionBudget to be
+// disrupted while the applications aren't started, so that it doesn't
+// block maintenance on the destination cluster. The original budget is
+// stored in an annotation so that it can be restored on activation.
+func (m *MigrationController) preparePDBResource(
 	migration *stork_api.Migration,
 	object runtime.Unstructured,
 ) error {
-	if *migration.Spec.StartApplications {
+	if startApplications(migration) {
 		return nil
 	}
+	content := object.UnstructuredContent()
+	var budget string
+	for _, field := range []string{"minAvailable", "maxUnavailable"} {
+		value, found, err := unstructured.NestedFieldNoCopy(content, "spec", field)
+		if err != nil {
+			return err
+		}
+		if found && value != nil {
+			budget = fmt.Sprintf("%v=%v", field, value)
+			break
+		}
+	}
+	if budget == "" {
+		return nil
+	}
+	unstructured.RemoveNestedField(content, "spec", "minAvailable")
+	if err := unstructured.SetNestedField(content, "100%", "spec", "maxUnavailable"); err != nil {
+		return err
+	}
+	return setMigrationAnnotation(content, StorkMigrationPDBAnnotation, budget)
+}
+
+func setMigrationAnnotation(content map[string]interface{}, key, value string) error {
+	annotations, found, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	if !found {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
+
+func (m *MigrationController) prepareApplicationResource(
+	migration *stork_api.Migration,
+	object runtime.Unstructured,
+) error {
+	if startApplications(migration) {
+		return restoreCutoverReplicas(object)
+	}
 
 	content := object.UnstructuredContent()
 	// Reset the replicas to 0 and store the current replicas in an annotation
@@ -1265,12 +1628,22 @@ func (m *MigrationController) prepareCRDClusterResource(
 	suspendOpts []stork_api.SuspendOptions,
 
 ) error {
-	if *migration.Spec.StartApplications {
-		return nil
+	if startApplications(migration) {
+		// CRs that were suspended on the source for the cutover are resumed
+		// on the destination
+		_, err := resumeCutoverCR(object, suspendOpts)
+		return err
 	}
 	if len(suspendOpts) == 0 {
 		return nil
 	}
+	return suspendCR(object, suspendOpts)
+}
+
+// suspendCR sets the suspend paths of a CR to their suspend values. The
+// current values are stored in annotations so that they can be restored when
+// the CR is activated.
+func suspendCR(object runtime.Unstructured, suspendOpts []stork_api.SuspendOptions) error {
 	content := object.UnstructuredContent()
 	annotations, found, err := unstructured.NestedStringMap(content, "metadata", "annotations")
 	if err != nil {
@@ -1319,6 +1692,50 @@ func (m *MigrationController) prepareCRDClusterResource(
 	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
 }
 
+// resumeCR sets the suspend paths of a CR back to the values stored in its
+// annotations by suspendCR and removes the annotations
+func resumeCR(content map[string]interface{}, suspendOpts []stork_api.SuspendOptions) error {
+	annotations, _, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	for _, suspend := range suspendOpts {
+		fields := strings.Split(suspend.Path, ".")
+		if len(fields) <= 1 {
+			continue
+		}
+		key := StorkAnnotationPrefix + suspend.Path
+		annotation, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		currVal := strings.Split(annotation, ",")[0]
+		var activateVersion interface{}
+		if suspend.Type == "bool" {
+			if val, err := strconv.ParseBool(suspend.Value); err != nil {
+				activateVersion = false
+			} else {
+				activateVersion = !val
+			}
+		} else if suspend.Type == "int" {
+			curr, err := strconv.ParseInt(currVal, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value for annotation %v: %v", key, annotation)
+			}
+			activateVersion = curr
+		} else if suspend.Type == "string" {
+			activateVersion = currVal
+		} else {
+			return fmt.Errorf("invalid type %v to suspend cr", suspend.Type)
+		}
+		if err := unstructured.SetNestedField(content, activateVersion, fields...); err != nil {
+			return err
+		}
+		delete(annotations, key)
+	}
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
+
 func (m *MigrationController) getPrunedAnnotations(annotations map[string]string) map[string]string {
 	a := make(map[string]string)
 	for k, v := range annotations {
@@ -1338,6 +1755,7 @@ func (m *MigrationController) applyResources(
 	if err != nil {
 		return err
 	}
+	m.setDefaultRateLimit(remoteConfig)
 	remoteAdminConfig := remoteConfig
 	// Use the admin cluter pair for cluster scoped resources if it has been configured
 	if migration.Spec.AdminClusterPair != "" {
@@ -1345,6 +1763,7 @@ func (m *MigrationController) applyResources(
 		if err != nil {
 			return err
 		}
+		m.setDefaultRateLimit(remoteAdminConfig)
 	}
 
 	adminClient, err := kubernetes.NewForConfig(remoteAdminConfig)
@@ -1461,6 +1880,7 @@ func (m *MigrationController) applyResources(
 		}
 
 		annotations := m.getPrunedAnnotations(namespace.Annotations)
+		resourcecollector.PrepareServiceMeshMetadata(namespace.Labels, annotations, migration.Spec.ServiceMeshPolicy)
 		_, err = adminClient.CoreV1().Namespaces().Create(context.TODO(), &v1.Namespace{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        namespace.Name,
@@ -1484,6 +1904,23 @@ func (m *MigrationController) applyResources(
 			return err
 		}
 	}
+	// Make sure the API versions of the objects are served by the remote
+	// cluster, converting deprecated versions where possible
+	remoteDiscovery, err := discovery.NewDiscoveryClientForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+	objects, unsupported, err := resourcecollector.ConvertToServedVersions(remoteDiscovery, objects)
+	if err != nil {
+		return err
+	}
+	for _, u := range unsupported {
+		m.updateResourceStatus(
+			migration,
+			u.Object,
+			stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Error applying resource: %v", u.Reason))
+	}
 	var pvObjects, pvcObjects, updatedObjects []runtime.Unstructured
 	pvMapping := make(map[string]v1.ObjectReference)
 	// collect pv,pvc separately
@@ -1520,6 +1957,7 @@ func (m *MigrationController) applyResources(
 		pv.Annotations[StorkMigrationAnnotation] = "true"
 		pv.Annotations[StorkMigrationName] = migration.GetName()
 		pv.Annotations[StorkMigrationTime] = time.Now().Format(nameTimeSuffixFormat)
+		setMigrationProvenanceLabels(&pv, migration)
 		_, err = adminClient.CoreV1().PersistentVolumes().Create(context.TODO(), &pv, metav1.CreateOptions{})
 		if err != nil {
 			if err != nil && errors.IsAlreadyExists(err) {
@@ -1645,6 +2083,7 @@ func (m *MigrationController) applyResources(
 		pvc.Annotations[StorkMigrationName] = migration.GetName()
 		pvc.Annotations[StorkMigrationTime] = time.Now().Format(nameTimeSuffixFormat)
 		pvc.Annotations[resourcecollector.StorkResourceHash] = strconv.FormatUint(objHash, 10)
+		setMigrationProvenanceLabels(&pvc, migration)
 		_, err = adminClient.CoreV1().PersistentVolumeClaims(pvc.GetNamespace()).Create(context.TODO(), &pvc, metav1.CreateOptions{})
 		if err != nil {
 			msg := fmt.Errorf("error in recreating pvc %s/%s during migration: %v", pvc.GetNamespace(), pvc.GetName(), err)
@@ -1785,10 +2224,12 @@ func (m *MigrationController) applyResources(
 			metadata, err := meta.Accessor(o)
 			if err != nil {
 				errorChan <- err
+				continue
 			}
 			objectType, err := meta.TypeAccessor(o)
 			if err != nil {
 				errorChan <- err
+				continue
 			}
 			resource := &metav1.APIResource{
 				Name:       ruleset.Pluralize(strings.ToLower(objectType.GetKind())),
@@ -1806,6 +2247,7 @@ func (m *MigrationController) applyResources(
 			unstructured, ok := o.(*unstructured.Unstructured)
 			if !ok {
 				errorChan <- fmt.Errorf("unable to cast object to unstructured: %v", o)
+				continue
 			}
 
 			// set migration annotations
@@ -1822,62 +2264,22 @@ func (m *MigrationController) applyResources(
 			}
 			migrAnnot[resourcecollector.StorkResourceHash] = strconv.FormatUint(objHash, 10)
 			unstructured.SetAnnotations(migrAnnot)
-			retries := 0
-			log.MigrationLog(migration).Infof("Applying %v %v", objectType.GetKind(), metadata.GetName())
-			for {
-				_, err = dynamicClient.Create(context.TODO(), unstructured, metav1.CreateOptions{})
-				if err != nil && (errors.IsAlreadyExists(err) || strings.Contains(err.Error(), portallocator.ErrAllocated.Error())) {
-					switch objectType.GetKind() {
-					case "ServiceAccount":
-						err = m.checkAndUpdateDefaultSA(migration, o)
-					case "Service":
-						var skipUpdate bool
-						skipUpdate, err = m.checkAndUpdateService(migration, o, objHash)
-						if err == nil && skipUpdate {
-							break
-						}
-						fallthrough
-					default:
-						// Delete the resource if it already exists on the destination
-						// cluster and try creating again
-						deleteStart := metav1.Now()
-						err = dynamicClient.Delete(context.TODO(), metadata.GetName(), metav1.DeleteOptions{})
-						if err != nil && !errors.IsNotFound(err) {
-							log.MigrationLog(migration).Errorf("Error deleting %v %v during migrate: %v", objectType.GetKind(), metadata.GetName(), err)
-						} else {
-							// wait for resources to get deleted
-							// 2 mins
-							for i := 0; i < deletedMaxRetries; i++ {
-								obj, err := dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
-								if err != nil && errors.IsNotFound(err) {
-									break
-								}
-								createTime := obj.GetCreationTimestamp()
-								if deleteStart.Before(&createTime) {
-									log.MigrationLog(migration).Warnf("Object[%v] got re-created after deletion. So, Ignore wait. deleteStart time:[%v], create time:[%v]",
-										obj.GetName(), deleteStart, createTime)
-									break
-								}
-								log.MigrationLog(migration).Warnf("Object %v still present, retrying in %v", metadata.GetName(), deletedRetryInterval)
-								time.Sleep(deletedRetryInterval)
-							}
-							_, err = dynamicClient.Create(context.TODO(), unstructured, metav1.CreateOptions{})
-						}
-					}
-				}
-				// Retry a few times for Unauthorized errors
-				if err != nil && errors.IsUnauthorized(err) && retries < maxApplyRetries {
-					retries++
-					continue
-				}
-				break
-			}
+			// The labels are set after hashing the object, otherwise the hash
+			// would change with every migration
+			setMigrationProvenanceLabels(unstructured, migration)
+			skippedFields, err := m.applyResource(migration, dynamicClient, unstructured, objHash)
 			if err != nil {
 				m.updateResourceStatus(
 					migration,
 					o,
 					stork_api.MigrationStatusFailed,
 					fmt.Sprintf("Error applying resource: %v", err))
+			} else if len(skippedFields) != 0 {
+				m.updateResourceStatus(
+					migration,
+					o,
+					stork_api.MigrationStatusSuccessful,
+					fmt.Sprintf("Resource migrated successfully, skipped fields managed on the destination: %v", strings.Join(skippedFields, ", ")))
 			} else {
 				m.updateResourceStatus(
 					migration,
@@ -1889,7 +2291,106 @@ func (m *MigrationController) applyResources(
 		}
 	}
 
-	return m.parallelWorker(worker, updatedObjects, true)
+	// Objects are applied after the objects they depend on, so only the
+	// objects within a tier are applied in parallel
+	tiers, err := resourcecollector.GetApplyTiers(updatedObjects)
+	if err != nil {
+		return err
+	}
+	for _, tier := range tiers {
+		if err := m.parallelWorker(worker, tier, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyResource applies the object on the destination cluster and returns the
+// fields that were left alone since they are managed on the destination.
+// Objects are created, or deleted and created again if they already exist,
+// for kinds that need to be merged with what's on the destination and if
+// server-side apply fails for any reason other than a field conflict, for
+// example when an immutable field was changed.
+func (m *MigrationController) applyResource(
+	migration *stork_api.Migration,
+	dynamicClient dynamic.ResourceInterface,
+	object *unstructured.Unstructured,
+	objHash uint64,
+) ([]string, error) {
+	retries := 0
+	log.MigrationLog(migration).Infof("Applying %v %v", object.GetKind(), object.GetName())
+	// Use server-side apply so that fields managed by controllers on
+	// the destination aren't overwritten on every migration
+	var skippedFields []string
+	var err error
+	if object.GetKind() != "ServiceAccount" {
+		skippedFields, err = resourcecollector.ServerSideApply(dynamicClient, object, MigrationFieldManager)
+		if err == nil || resourcecollector.IsServerSideApplyConflict(err) {
+			return skippedFields, err
+		}
+		log.MigrationLog(migration).Warnf("Error applying %v %v, recreating it: %v", object.GetKind(), object.GetName(), err)
+	}
+	for {
+		_, err = dynamicClient.Create(context.TODO(), object, metav1.CreateOptions{})
+		if err != nil && (errors.IsAlreadyExists(err) || strings.Contains(err.Error(), portallocator.ErrAllocated.Error())) {
+			switch object.GetKind() {
+			case "ServiceAccount":
+				err = m.checkAndUpdateDefaultSA(migration, object)
+			case "Service":
+				var skipUpdate bool
+				skipUpdate, err = m.checkAndUpdateService(migration, object, objHash)
+				if err == nil && skipUpdate {
+					break
+				}
+				fallthrough
+			default:
+				// Delete the resource if it already exists on the destination
+				// cluster and try creating again
+				deleteStart := metav1.Now()
+				err = dynamicClient.Delete(context.TODO(), object.GetName(), metav1.DeleteOptions{})
+				if err != nil && !errors.IsNotFound(err) {
+					log.MigrationLog(migration).Errorf("Error deleting %v %v during migrate: %v", object.GetKind(), object.GetName(), err)
+				} else {
+					// wait for resources to get deleted
+					// 2 mins
+					for i := 0; i < deletedMaxRetries; i++ {
+						obj, err := dynamicClient.Get(context.TODO(), object.GetName(), metav1.GetOptions{})
+						if err != nil && errors.IsNotFound(err) {
+							break
+						}
+						createTime := obj.GetCreationTimestamp()
+						if deleteStart.Before(&createTime) {
+							log.MigrationLog(migration).Warnf("Object[%v] got re-created after deletion. So, Ignore wait. deleteStart time:[%v], create time:[%v]",
+								obj.GetName(), deleteStart, createTime)
+							break
+						}
+						log.MigrationLog(migration).Warnf("Object %v still present, retrying in %v", object.GetName(), deletedRetryInterval)
+						time.Sleep(deletedRetryInterval)
+					}
+					_, err = dynamicClient.Create(context.TODO(), object, metav1.CreateOptions{})
+				}
+			}
+		}
+		// Retry a few times for Unauthorized errors
+		if err != nil && errors.IsUnauthorized(err) && retries < maxApplyRetries {
+			retries++
+			continue
+		}
+		break
+	}
+	return nil, err
+}
+
+// setDefaultRateLimit uses the rate limits configured for the local cluster
+// for the remote cluster unless the cluster pair sets its own, so that the
+// parallel workers aren't throttled by the client defaults
+func (m *MigrationController) setDefaultRateLimit(config *rest.Config) {
+	if config.QPS == 0 && m.resourceCollector.QPS > 0 {
+		config.QPS = m.resourceCollector.QPS
+	}
+	if config.Burst == 0 && m.resourceCollector.Burst > 0 {
+		config.Burst = m.resourceCollector.Burst
+	}
 }
 
 func (m *MigrationController) parallelWorker(
@@ -1898,8 +2399,10 @@ func (m *MigrationController) parallelWorker(
 	shuffle bool,
 ) error {
 	numObjects := len(objects)
-	objectChan := make(chan runtime.Unstructured)
-	errorChan := make(chan error)
+	// Both channels are buffered so that the workers never block, even if
+	// one of them fails
+	objectChan := make(chan runtime.Unstructured, numObjects)
+	errorChan := make(chan error, numObjects)
 
 	if shuffle {
 		// Shuffle Object order before applying so we can get parallelism between resource types
@@ -1907,26 +2410,27 @@ func (m *MigrationController) parallelWorker(
 		rand.Shuffle(len(objects), func(i, j int) { objects[i], objects[j] = objects[j], objects[i] })
 	}
 
-	logrus.Infof("Updating %v objects with %v parallel workers", numObjects, m.migrationMaxThreads)
-	for w := 0; w < m.migrationMaxThreads; w++ {
+	maxThreads := storkconfig.GetMigrationMaxThreads(m.migrationMaxThreads)
+	logrus.Infof("Updating %v objects with %v parallel workers", numObjects, maxThreads)
+	for w := 0; w < maxThreads; w++ {
 		go worker(objectChan, errorChan)
 	}
 
-	go func() {
-		for _, o := range objects {
-			objectChan <- o
-		}
-	}()
+	for _, o := range objects {
+		objectChan <- o
+	}
+	close(objectChan)
 
+	// Wait for all the objects to be processed so that the workers don't
+	// update the migration after returning
+	var firstErr error
 	for result := 0; result < numObjects; result++ {
 		workerErr := <-errorChan
-		if workerErr != nil {
-			close(objectChan)
-			return workerErr
+		if workerErr != nil && firstErr == nil {
+			firstErr = workerErr
 		}
 	}
-	close(objectChan)
-	return nil
+	return firstErr
 }
 
 func (m *MigrationController) getMigrationSummary(migration *stork_api.Migration) *stork_api.MigrationSummary {
@@ -1988,37 +2492,83 @@ func (m *MigrationController) getMigrationSummary(migration *stork_api.Migration
 }
 
 func (m *MigrationController) cleanup(migration *stork_api.Migration) error {
+	releaseVolumeLocks(migration)
 	if migration.Status.Stage != stork_api.MigrationStageFinal {
 		return m.volDriver.CancelMigration(migration)
 	}
 	return nil
 }
 
-func (m *MigrationController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.MigrationResourceName,
-		Plural:  stork_api.MigrationResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.Migration{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
+// getMigrationPVCs returns the bound PVCs selected by the migration
+func getMigrationPVCs(migration *stork_api.Migration) ([]types.NamespacedName, error) {
+	pvcs := make([]types.NamespacedName, 0)
+	for _, namespace := range migration.Spec.Namespaces {
+		pvcList, err := core.Instance().GetPersistentVolumeClaims(namespace, migration.Spec.Selectors)
+		if err != nil {
+			return nil, fmt.Errorf("error getting list of volumes to migrate: %v", err)
+		}
+		for _, pvc := range pvcList.Items {
+			if pvc.Status.Phase != v1.ClaimBound || pvc.DeletionTimestamp != nil {
+				continue
+			}
+			pvcs = append(pvcs, types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name})
+		}
 	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
+	return pvcs, nil
+}
+
+// getMigrationVolumeStatuses gets the status of the volumes that are still
+// being migrated with a single call to the driver. Drivers that can't get the
+// statuses in bulk are asked for the status of all the volumes.
+func (m *MigrationController) getMigrationVolumeStatuses(migration *stork_api.Migration) ([]*stork_api.MigrationVolumeInfo, error) {
+	pending := make([]*stork_api.MigrationVolumeInfo, 0)
+	for _, vInfo := range migration.Status.Volumes {
+		if vInfo.Status != stork_api.MigrationStatusSuccessful && vInfo.Status != stork_api.MigrationStatusFailed {
+			pending = append(pending, vInfo)
 		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
 	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
+	if len(pending) == 0 {
+		return migration.Status.Volumes, nil
 	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	if _, err := m.volDriver.GetMigrationStatuses(migration, pending); err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+			return m.volDriver.GetMigrationStatus(migration)
+		}
+		return nil, err
+	}
+	return migration.Status.Volumes, nil
+}
+
+// extendVolumeLocks extends the locks on the PVCs whose migrations are still
+// in progress so that they don't expire
+func extendVolumeLocks(migration *stork_api.Migration) {
+	lock := pvclock.NewLock("Migration", migration, pvclock.OperationMigration)
+	for _, vInfo := range migration.Status.Volumes {
+		if vInfo.Status != stork_api.MigrationStatusInProgress {
+			continue
+		}
+		if err := pvclock.Acquire(vInfo.PersistentVolumeClaim, vInfo.Namespace, lock); err != nil && !errors.IsNotFound(err) {
+			log.MigrationLog(migration).Warnf("Error extending lock on PVC %v/%v: %v",
+				vInfo.Namespace, vInfo.PersistentVolumeClaim, err)
+		}
+	}
+}
+
+// releaseVolumeLocks releases the locks held by the migration on its PVCs.
+// PVCs that were locked but aren't migrated by the driver are released too.
+func releaseVolumeLocks(migration *stork_api.Migration) {
+	pvcs, err := getMigrationPVCs(migration)
+	if err != nil {
+		log.MigrationLog(migration).Warnf("Error releasing locks on PVCs: %v", err)
+		return
+	}
+	if err := pvclock.ReleaseAll(pvcs, string(migration.UID)); err != nil {
+		log.MigrationLog(migration).Warnf("Error releasing locks on PVCs: %v", err)
+	}
+}
+
+func (m *MigrationController) createCRD() error {
+	return crds.Register(reflect.TypeOf(stork_api.Migration{}).Name())
 }
 
 func (m *MigrationController) getVolumeOnlyMigrationResources(migration *stork_api.Migration) ([]runtime.Unstructured, error) {
@@ -2036,7 +2586,8 @@ func (m *MigrationController) getVolumeOnlyMigrationResources(migration *stork_a
 		migration.Spec.Namespaces,
 		migration.Spec.Selectors,
 		nil,
-		false)
+		false,
+		migration.Spec.SecretTypes)
 	if err != nil {
 		m.recorder.Event(migration,
 			v1.EventTypeWarning,
@@ -2059,7 +2610,8 @@ func (m *MigrationController) getVolumeOnlyMigrationResources(migration *stork_a
 		migration.Spec.Namespaces,
 		migration.Spec.Selectors,
 		nil,
-		false)
+		false,
+		migration.Spec.SecretTypes)
 	if err != nil {
 		m.recorder.Event(migration,
 			v1.EventTypeWarning,
@@ -0,0 +1,147 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestMigration(startApplications bool) *stork_api.Migration {
+	return &stork_api.Migration{
+		ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "test"},
+		Spec: stork_api.MigrationSpec{
+			ClusterPair:       "pair",
+			Namespaces:        []string{"test"},
+			StartApplications: &startApplications,
+		},
+	}
+}
+
+func newTestMigrationController(t *testing.T, objects ...runtime.Object) *MigrationController {
+	scheme := runtime.NewScheme()
+	require.NoError(t, stork_api.AddToScheme(scheme))
+	storkops.SetInstance(storkops.New(fakek8s.NewSimpleClientset(), fakestorkclient.NewSimpleClientset(), nil))
+	return &MigrationController{
+		client:   fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(objects...).Build(),
+		recorder: record.NewFakeRecorder(10),
+	}
+}
+
+func newTestDeployment(replicas int64, images ...string) *unstructured.Unstructured {
+	containers := make([]interface{}, 0)
+	for _, image := range images {
+		containers = append(containers, map[string]interface{}{"name": "container", "image": image})
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "test"},
+		"spec": map[string]interface{}{
+			"replicas": replicas,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"containers": containers},
+			},
+		},
+	}}
+}
+
+func getTestImages(t *testing.T, object *unstructured.Unstructured) []string {
+	containers, _, err := unstructured.NestedSlice(object.Object, "spec", "template", "spec", "containers")
+	require.NoError(t, err)
+	images := make([]string, 0)
+	for _, c := range containers {
+		images = append(images, c.(map[string]interface{})["image"].(string))
+	}
+	return images
+}
+
+func TestPrepareResourcesMapsRegistries(t *testing.T) {
+	mapping := &stork_api.RegistryMapping{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror", Namespace: "test"},
+		Spec:       stork_api.RegistryMappingSpec{Registries: map[string]string{"docker.io": "mirror.local"}},
+	}
+	migration := newTestMigration(false)
+	migration.Spec.RegistryMappingName = "mirror"
+	m := newTestMigrationController(t, migration, mapping)
+
+	deployment := newTestDeployment(3, "nginx:1.21", "quay.io/org/sidecar:v1")
+	require.NoError(t, m.prepareResources(migration, []runtime.Unstructured{deployment}))
+	require.Equal(t, []string{"mirror.local/library/nginx:1.21", "quay.io/org/sidecar:v1"}, getTestImages(t, deployment))
+	replicas, _, err := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), replicas, "applications should be stopped on the destination")
+
+	migration.Spec.RegistryMappingName = ""
+	deployment = newTestDeployment(3, "nginx:1.21")
+	require.NoError(t, m.prepareResources(migration, []runtime.Unstructured{deployment}))
+	require.Equal(t, []string{"nginx:1.21"}, getTestImages(t, deployment), "images shouldn't change without a mapping")
+
+	migration.Spec.RegistryMappingName = "missing"
+	require.Error(t, m.prepareResources(migration, []runtime.Unstructured{newTestDeployment(3, "nginx:1.21")}))
+}
+
+func TestApplyResourceRecreatesOnInvalid(t *testing.T) {
+	m := newTestMigrationController(t)
+	migration := newTestMigration(false)
+	existing := newTestDeployment(1, "nginx:1.0")
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+	// The selector of a Deployment is immutable, so it can't be applied
+	client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewInvalid(schema.GroupKind{Group: "apps", Kind: "Deployment"}, "web",
+			field.ErrorList{field.Invalid(field.NewPath("spec", "selector"), nil, "field is immutable")})
+	})
+
+	skippedFields, err := m.applyResource(migration, client.Resource(gvr).Namespace("test"), newTestDeployment(2, "nginx:2.0"), 0)
+	require.NoError(t, err)
+	require.Empty(t, skippedFields)
+	object, err := client.Resource(gvr).Namespace("test").Get(context.TODO(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nginx:2.0"}, getTestImages(t, object), "deployment should have been recreated")
+}
+
+func TestApplyResourceFailsOnConflict(t *testing.T) {
+	m := newTestMigrationController(t)
+	migration := newTestMigration(false)
+	gvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newTestDeployment(1, "nginx:1.0"))
+	client.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status: metav1.StatusFailure,
+			Code:   http.StatusConflict,
+			Reason: metav1.StatusReasonConflict,
+			Details: &metav1.StatusDetails{Causes: []metav1.StatusCause{{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kubectl" using apps/v1`,
+				Field:   `.spec.template.spec.containers[name="container"].image`,
+			}}},
+		}}
+	})
+
+	_, err := m.applyResource(migration, client.Resource(gvr).Namespace("test"), newTestDeployment(2, "nginx:2.0"), 0)
+	require.Error(t, err)
+	require.True(t, resourcecollector.IsServerSideApplyConflict(err))
+	object, err := client.Resource(gvr).Namespace("test").Get(context.TODO(), "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nginx:1.0"}, getTestImages(t, object), "deployment shouldn't have been recreated")
+}
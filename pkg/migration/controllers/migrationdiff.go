@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/inflect"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	"github.com/mitchellh/hashstructure"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// diffResources computes the changes that the migration would make on the
+// destination cluster and records them in the migration status without
+// applying anything
+func (m *MigrationController) diffResources(
+	migration *stork_api.Migration,
+	objects []runtime.Unstructured,
+) error {
+	remoteConfig, err := getClusterPairSchedulerConfig(migration.Spec.ClusterPair, migration.Namespace)
+	if err != nil {
+		return err
+	}
+	m.setDefaultRateLimit(remoteConfig)
+	remoteAdminConfig := remoteConfig
+	if migration.Spec.AdminClusterPair != "" {
+		remoteAdminConfig, err = getClusterPairSchedulerConfig(migration.Spec.AdminClusterPair, m.migrationAdminNamespace)
+		if err != nil {
+			return err
+		}
+		m.setDefaultRateLimit(remoteAdminConfig)
+	}
+	remoteInterface, err := dynamic.NewForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+	remoteAdminInterface, err := dynamic.NewForConfig(remoteAdminConfig)
+	if err != nil {
+		return err
+	}
+	remoteDiscovery, err := discovery.NewDiscoveryClientForConfig(remoteConfig)
+	if err != nil {
+		return err
+	}
+
+	objects, unsupported, err := resourcecollector.ConvertToServedVersions(remoteDiscovery, objects)
+	if err != nil {
+		return err
+	}
+	for _, u := range unsupported {
+		m.updateResourceStatus(
+			migration,
+			u.Object,
+			stork_api.MigrationStatusFailed,
+			fmt.Sprintf("Resource can't be migrated: %v", u.Reason))
+	}
+
+	diff := &stork_api.MigrationDiff{
+		Create: make([]*stork_api.ObjectInfo, 0),
+		Update: make([]*stork_api.ObjectInfo, 0),
+		Delete: make([]*stork_api.ObjectInfo, 0),
+	}
+	for _, o := range objects {
+		objectInfo, err := getMigrationObjectInfo(o)
+		if err != nil {
+			return err
+		}
+		var dynamicClient dynamic.ResourceInterface
+		if objectInfo.Namespace != "" {
+			dynamicClient = getRemoteResourceInterface(remoteInterface, o, objectInfo)
+		} else {
+			dynamicClient = getRemoteResourceInterface(remoteAdminInterface, o, objectInfo)
+		}
+		destObject, err := dynamicClient.Get(context.TODO(), objectInfo.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				diff.Create = append(diff.Create, objectInfo)
+				m.updateResourceStatus(
+					migration,
+					o,
+					stork_api.MigrationStatusSuccessful,
+					"Resource would be created on the destination")
+				continue
+			}
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error getting resource from the destination: %v", err))
+			continue
+		}
+
+		changed, err := resourceChanged(o, destObject)
+		if err != nil {
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusFailed,
+				fmt.Sprintf("Error comparing resource with the destination: %v", err))
+			continue
+		}
+		if changed {
+			diff.Update = append(diff.Update, objectInfo)
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusSuccessful,
+				"Resource would be updated on the destination")
+		} else {
+			m.updateResourceStatus(
+				migration,
+				o,
+				stork_api.MigrationStatusSuccessful,
+				"Resource is up to date on the destination")
+		}
+	}
+
+	ledger, found, err := getMigrationLedger(migration)
+	if err != nil {
+		return err
+	}
+	if found {
+		diff.Delete = getLedgerStaleResources(migration, ledger)
+	} else {
+		staleObjects, err := m.getStaleMigratedResources(migration, remoteConfig)
+		if err != nil {
+			return err
+		}
+		for _, o := range staleObjects {
+			objectInfo, err := getMigrationObjectInfo(o)
+			if err != nil {
+				return err
+			}
+			diff.Delete = append(diff.Delete, objectInfo)
+		}
+	}
+
+	migration.Status.Diff = diff
+	migration.Status.ResourceMigrationFinishTimestamp = metav1.Now()
+	migration.Status.Stage = stork_api.MigrationStageFinal
+	migration.Status.Status = stork_api.MigrationStatusSuccessful
+	for _, resource := range migration.Status.Resources {
+		if resource.Status != stork_api.MigrationStatusSuccessful {
+			migration.Status.Status = stork_api.MigrationStatusPartialSuccess
+			break
+		}
+	}
+	migration.Status.FinishTimestamp = metav1.Now()
+	m.recorder.Event(migration,
+		v1.EventTypeNormal,
+		string(migration.Status.Status),
+		fmt.Sprintf("Resources to create on the destination: %v, to update: %v, to delete: %v",
+			len(diff.Create), len(diff.Update), len(diff.Delete)))
+	return m.updateMigrationCR(context.TODO(), migration)
+}
+
+// resourceChanged returns true if applying the object would change the
+// object on the destination. The hash of the object recorded on the
+// destination during the last migration is used for the comparison.
+func resourceChanged(object runtime.Unstructured, destObject *unstructured.Unstructured) (bool, error) {
+	// Only the reclaim policy and annotations of existing PVs are updated
+	if object.GetObjectKind().GroupVersionKind().Kind == "PersistentVolume" {
+		return false, nil
+	}
+	hash, ok := destObject.GetAnnotations()[resourcecollector.StorkResourceHash]
+	if !ok {
+		return true, nil
+	}
+	oldHash, err := strconv.ParseUint(hash, 10, 64)
+	if err != nil {
+		return true, nil
+	}
+	objHash, err := hashstructure.Hash(object, &hashstructure.HashOptions{})
+	if err != nil {
+		return false, err
+	}
+	return objHash != oldHash, nil
+}
+
+func getMigrationObjectInfo(object runtime.Unstructured) (*stork_api.ObjectInfo, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	objectInfo := &stork_api.ObjectInfo{
+		Name:      metadata.GetName(),
+		Namespace: metadata.GetNamespace(),
+	}
+	objectInfo.Kind = gvk.Kind
+	objectInfo.Group = gvk.Group
+	// core Group doesn't have a name, so override it
+	if objectInfo.Group == "" {
+		objectInfo.Group = "core"
+	}
+	objectInfo.Version = gvk.Version
+	return objectInfo, nil
+}
+
+func getRemoteResourceInterface(
+	remoteInterface dynamic.Interface,
+	object runtime.Unstructured,
+	objectInfo *stork_api.ObjectInfo,
+) dynamic.ResourceInterface {
+	ruleset := inflect.NewDefaultRuleset()
+	ruleset.AddPlural("quota", "quotas")
+	ruleset.AddPlural("prometheus", "prometheuses")
+	ruleset.AddPlural("mongodbcommunity", "mongodbcommunity")
+	resource := object.GetObjectKind().GroupVersionKind().GroupVersion().WithResource(
+		ruleset.Pluralize(strings.ToLower(objectInfo.Kind)))
+	if objectInfo.Namespace != "" {
+		return remoteInterface.Resource(resource).Namespace(objectInfo.Namespace)
+	}
+	return remoteInterface.Resource(resource)
+}
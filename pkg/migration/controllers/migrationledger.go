@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/portworx/sched-ops/k8s/core"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// migrationLedgerPrefix is the prefix for the name of the config maps
+	// used to keep track of the resources applied on the destination cluster
+	migrationLedgerPrefix = "stork-migration-ledger-"
+	// migrationLedgerKey is the key in the ledger config maps holding the
+	// applied resources
+	migrationLedgerKey = "resources"
+	// migrationLedgerLabel is the label set on the ledger config maps
+	migrationLedgerLabel = StorkAnnotationPrefix + "migrationLedger"
+	// migrationLedgerNameAnnotation is the name of the ledger a config map
+	// belongs to
+	migrationLedgerNameAnnotation = StorkAnnotationPrefix + "migrationLedgerName"
+	// migrationLedgerShardsAnnotation is the number of config maps the
+	// ledger was split into by a migration
+	migrationLedgerShardsAnnotation = StorkAnnotationPrefix + "migrationLedgerShards"
+	// migrationLedgerShardSize is the maximum size of the resources stored in
+	// one config map, to stay well below the size limit for objects
+	migrationLedgerShardSize = 512 * 1024
+)
+
+// getMigrationLedgerName returns the name of the ledger for a migration.
+// Migrations triggered by the same schedule share a ledger.
+func getMigrationLedgerName(migration *stork_api.Migration) string {
+	name := migration.Name
+	if schedName, ok := migration.GetAnnotations()[StorkMigrationScheduleName]; ok {
+		name = schedName
+	}
+	return name
+}
+
+// getMigrationLedgerShardName returns the name of the config map holding a
+// shard of the ledger saved by a migration
+func getMigrationLedgerShardName(migration *stork_api.Migration, shard int) string {
+	return fmt.Sprintf("%v%v-%v", migrationLedgerPrefix, migration.Name, shard)
+}
+
+// listMigrationLedgerShards returns the config maps of the ledger for the
+// migration, grouped by the migration that saved them
+func listMigrationLedgerShards(migration *stork_api.Migration) (map[string][]v1.ConfigMap, error) {
+	configMaps, err := core.Instance().ListConfigMap(migration.Namespace, metav1.ListOptions{
+		LabelSelector: migrationLedgerLabel + "=true",
+	})
+	if err != nil {
+		return nil, err
+	}
+	ledgerName := getMigrationLedgerName(migration)
+	shards := make(map[string][]v1.ConfigMap)
+	for _, configMap := range configMaps.Items {
+		if configMap.Annotations[migrationLedgerNameAnnotation] != ledgerName {
+			continue
+		}
+		migrationName := configMap.Annotations[StorkMigrationName]
+		shards[migrationName] = append(shards[migrationName], configMap)
+	}
+	return shards, nil
+}
+
+// getMigrationLedger returns the resources recorded in the ledger for the
+// migration. The ledger is split across config maps for each migration, and
+// the latest migration that saved all of its shards is used. Returns false if
+// the ledger doesn't exist yet.
+func getMigrationLedger(migration *stork_api.Migration) ([]*stork_api.ObjectInfo, bool, error) {
+	shards, err := listMigrationLedgerShards(migration)
+	if err != nil {
+		return nil, false, err
+	}
+	var latest []v1.ConfigMap
+	for _, configMaps := range shards {
+		count, err := strconv.Atoi(configMaps[0].Annotations[migrationLedgerShardsAnnotation])
+		if err != nil || count != len(configMaps) {
+			// The migration didn't finish saving the ledger
+			continue
+		}
+		if latest == nil || latest[0].CreationTimestamp.Before(&configMaps[0].CreationTimestamp) {
+			latest = configMaps
+		}
+	}
+	if latest == nil {
+		return nil, false, nil
+	}
+
+	ledger := make([]*stork_api.ObjectInfo, 0)
+	for _, configMap := range latest {
+		shard := make([]*stork_api.ObjectInfo, 0)
+		if data, ok := configMap.Data[migrationLedgerKey]; ok {
+			if err := json.Unmarshal([]byte(data), &shard); err != nil {
+				return nil, false, fmt.Errorf("error parsing migration ledger %v: %v", configMap.Name, err)
+			}
+		}
+		ledger = append(ledger, shard...)
+	}
+	return ledger, true, nil
+}
+
+// updateMigrationLedger saves the resources in the ledger for the migration.
+// The resources are split across config maps owned by the migration schedule
+// for scheduled migrations and by the migration otherwise. The config maps
+// saved by earlier migrations are deleted once the ledger has been saved.
+func updateMigrationLedger(migration *stork_api.Migration, ledger []*stork_api.ObjectInfo) error {
+	shards, err := splitMigrationLedger(ledger)
+	if err != nil {
+		return err
+	}
+	ownerReferences := migration.OwnerReferences
+	if _, ok := migration.GetAnnotations()[StorkMigrationScheduleName]; !ok || len(ownerReferences) == 0 {
+		ownerReferences = []metav1.OwnerReference{
+			{
+				Name:       migration.Name,
+				UID:        migration.UID,
+				Kind:       "Migration",
+				APIVersion: stork_api.SchemeGroupVersion.String(),
+			},
+		}
+	}
+
+	existing, err := listMigrationLedgerShards(migration)
+	if err != nil {
+		return err
+	}
+	// Remove shards from an earlier attempt by this migration so that they
+	// aren't mixed with the new ones
+	for _, configMap := range existing[migration.Name] {
+		if err := core.Instance().DeleteConfigMap(configMap.Name, configMap.Namespace); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	for i, data := range shards {
+		_, err := core.Instance().CreateConfigMap(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      getMigrationLedgerShardName(migration, i),
+				Namespace: migration.Namespace,
+				Labels: map[string]string{
+					migrationLedgerLabel: "true",
+				},
+				Annotations: map[string]string{
+					migrationLedgerNameAnnotation:   getMigrationLedgerName(migration),
+					migrationLedgerShardsAnnotation: strconv.Itoa(len(shards)),
+					StorkMigrationName:              migration.Name,
+				},
+				OwnerReferences: ownerReferences,
+			},
+			Data: map[string]string{
+				migrationLedgerKey: data,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for migrationName, configMaps := range existing {
+		if migrationName == migration.Name {
+			continue
+		}
+		for _, configMap := range configMaps {
+			if err := core.Instance().DeleteConfigMap(configMap.Name, configMap.Namespace); err != nil && !errors.IsNotFound(err) {
+				log.MigrationLog(migration).Warnf("Error deleting old migration ledger %v: %v", configMap.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitMigrationLedger returns the ledger serialized into shards that are
+// each at most migrationLedgerShardSize bytes. An empty ledger is saved in a
+// single shard.
+func splitMigrationLedger(ledger []*stork_api.ObjectInfo) ([]string, error) {
+	shards := make([]string, 0)
+	shard := make([]json.RawMessage, 0)
+	size := 0
+	addShard := func() error {
+		data, err := json.Marshal(shard)
+		if err != nil {
+			return err
+		}
+		shards = append(shards, string(data))
+		shard = make([]json.RawMessage, 0)
+		size = 0
+		return nil
+	}
+	for _, entry := range ledger {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		if len(shard) > 0 && size+len(data)+1 > migrationLedgerShardSize {
+			if err := addShard(); err != nil {
+				return nil, err
+			}
+		}
+		shard = append(shard, data)
+		size += len(data) + 1
+	}
+	if len(shard) > 0 || len(shards) == 0 {
+		if err := addShard(); err != nil {
+			return nil, err
+		}
+	}
+	return shards, nil
+}
+
+// addToMigrationLedger records the resources applied successfully by the
+// migration in an existing ledger without removing any entries. It is used
+// when the migration didn't succeed, so that entries are kept for the next
+// migration to retry the purge.
+func addToMigrationLedger(migration *stork_api.Migration) error {
+	ledger, found, err := getMigrationLedger(migration)
+	if err != nil || !found {
+		return err
+	}
+	updated := false
+	for _, resource := range migration.Status.Resources {
+		if resource.Namespace == "" || resource.Status != stork_api.MigrationStatusSuccessful {
+			continue
+		}
+		entry := &stork_api.ObjectInfo{
+			Name:             resource.Name,
+			Namespace:        resource.Namespace,
+			GroupVersionKind: resource.GroupVersionKind,
+		}
+		if !isInMigrationLedger(ledger, entry) {
+			ledger = append(ledger, entry)
+			updated = true
+		}
+	}
+	if !updated {
+		return nil
+	}
+	return updateMigrationLedger(migration, ledger)
+}
+
+func isInMigrationLedger(ledger []*stork_api.ObjectInfo, entry *stork_api.ObjectInfo) bool {
+	for _, e := range ledger {
+		if e.Name == entry.Name &&
+			e.Namespace == entry.Namespace &&
+			e.Group == entry.Group &&
+			e.Kind == entry.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// getLedgerStaleResources returns the resources in the ledger that are no
+// longer present on the source cluster. Only resources in the namespaces being
+// migrated are considered.
+func getLedgerStaleResources(
+	migration *stork_api.Migration,
+	ledger []*stork_api.ObjectInfo,
+) []*stork_api.ObjectInfo {
+	stale := make([]*stork_api.ObjectInfo, 0)
+	for _, entry := range ledger {
+		if !isLedgerEntryInScope(migration, entry) {
+			continue
+		}
+		if findMigrationResource(migration, entry) == nil {
+			stale = append(stale, entry)
+		}
+	}
+	return stale
+}
+
+// buildMigrationLedger returns the ledger to be saved after the migration.
+// It contains the namespaced resources that were applied successfully,
+// resources from the previous ledger that still exist on the source but
+// couldn't be migrated this time, and resources from the previous ledger that
+// are outside the scope of the migration.
+func buildMigrationLedger(
+	migration *stork_api.Migration,
+	previous []*stork_api.ObjectInfo,
+) []*stork_api.ObjectInfo {
+	ledger := make([]*stork_api.ObjectInfo, 0)
+	for _, resource := range migration.Status.Resources {
+		if resource.Namespace == "" || resource.Status != stork_api.MigrationStatusSuccessful {
+			continue
+		}
+		ledger = append(ledger, &stork_api.ObjectInfo{
+			Name:             resource.Name,
+			Namespace:        resource.Namespace,
+			GroupVersionKind: resource.GroupVersionKind,
+		})
+	}
+	for _, entry := range previous {
+		if !isLedgerEntryInScope(migration, entry) {
+			ledger = append(ledger, entry)
+			continue
+		}
+		resource := findMigrationResource(migration, entry)
+		if resource != nil && resource.Status != stork_api.MigrationStatusSuccessful {
+			ledger = append(ledger, entry)
+		}
+	}
+	return ledger
+}
+
+func isLedgerEntryInScope(migration *stork_api.Migration, entry *stork_api.ObjectInfo) bool {
+	if entry.Namespace == "" {
+		return false
+	}
+	for _, ns := range migration.Spec.Namespaces {
+		if ns == entry.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// findMigrationResource returns the resource in the migration status matching
+// the ledger entry. The version isn't compared since it can change when
+// objects are converted for the destination cluster.
+func findMigrationResource(
+	migration *stork_api.Migration,
+	entry *stork_api.ObjectInfo,
+) *stork_api.MigrationResourceInfo {
+	for _, resource := range migration.Status.Resources {
+		if resource.Name == entry.Name &&
+			resource.Namespace == entry.Namespace &&
+			resource.Group == entry.Group &&
+			resource.Kind == entry.Kind {
+			return resource
+		}
+	}
+	return nil
+}
+
+// ledgerEntriesToObjects converts ledger entries to objects that can be
+// deleted using the resource collector
+func ledgerEntriesToObjects(entries []*stork_api.ObjectInfo) []runtime.Unstructured {
+	objects := make([]runtime.Unstructured, 0, len(entries))
+	for _, entry := range entries {
+		group := entry.Group
+		// core Group doesn't have a name in the API version
+		if group == "core" {
+			group = ""
+		}
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.GroupVersionKind{
+			Group:   group,
+			Version: entry.Version,
+			Kind:    entry.Kind,
+		})
+		obj.SetName(entry.Name)
+		obj.SetNamespace(entry.Namespace)
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// logLedgerEntries logs the resources being purged from the destination
+func logLedgerEntries(migration *stork_api.Migration, entries []*stork_api.ObjectInfo) {
+	for _, entry := range entries {
+		log.MigrationLog(migration).Infof("Deleting object from destination(%v:%v:%v)", entry.Name, entry.Namespace, entry.Kind)
+	}
+}
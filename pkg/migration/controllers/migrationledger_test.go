@@ -0,0 +1,134 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func newLedgerMigration(name string) *stork_api.Migration {
+	migration := newTestMigration(false)
+	migration.Name = name
+	migration.Annotations = map[string]string{StorkMigrationScheduleName: "schedule"}
+	return migration
+}
+
+func newLedgerEntries(count int) []*stork_api.ObjectInfo {
+	entries := make([]*stork_api.ObjectInfo, 0, count)
+	for i := 0; i < count; i++ {
+		entries = append(entries, &stork_api.ObjectInfo{
+			Name:      fmt.Sprintf("config-%v", i),
+			Namespace: "test",
+			GroupVersionKind: metav1.GroupVersionKind{
+				Group:   "core",
+				Version: "v1",
+				Kind:    "ConfigMap",
+			},
+		})
+	}
+	return entries
+}
+
+func listLedgerConfigMaps(t *testing.T) []v1.ConfigMap {
+	configMaps, err := core.Instance().ListConfigMap("test", metav1.ListOptions{LabelSelector: migrationLedgerLabel + "=true"})
+	require.NoError(t, err)
+	return configMaps.Items
+}
+
+func TestMigrationLedgerShards(t *testing.T) {
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset()))
+
+	first := newLedgerMigration("schedule-interval-1")
+	_, found, err := getMigrationLedger(first)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// Large ledgers are split across config maps
+	entries := newLedgerEntries(10000)
+	require.NoError(t, updateMigrationLedger(first, entries))
+	configMaps := listLedgerConfigMaps(t)
+	require.Greater(t, len(configMaps), 1, "Expected ledger to be sharded")
+	for _, configMap := range configMaps {
+		require.LessOrEqual(t, len(configMap.Data[migrationLedgerKey]), migrationLedgerShardSize)
+	}
+	ledger, found, err := getMigrationLedger(first)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.ElementsMatch(t, entries, ledger)
+
+	// Migrations from the same schedule share the ledger and replace the
+	// shards of earlier migrations
+	second := newLedgerMigration("schedule-interval-2")
+	ledger, found, err = getMigrationLedger(second)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, ledger, len(entries))
+	require.NoError(t, updateMigrationLedger(second, entries[:1]))
+	configMaps = listLedgerConfigMaps(t)
+	require.Len(t, configMaps, 1)
+	require.Equal(t, getMigrationLedgerShardName(second, 0), configMaps[0].Name)
+	ledger, _, err = getMigrationLedger(first)
+	require.NoError(t, err)
+	require.Equal(t, entries[:1], ledger)
+
+	// Ledgers of other migrations aren't used
+	_, found, err = getMigrationLedger(newTestMigration(false))
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// Shards from a migration that didn't save all of them are ignored
+	_, err = core.Instance().CreateConfigMap(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stork-migration-ledger-schedule-interval-3-0",
+			Namespace: "test",
+			Labels:    map[string]string{migrationLedgerLabel: "true"},
+			Annotations: map[string]string{
+				migrationLedgerNameAnnotation:   "schedule",
+				migrationLedgerShardsAnnotation: "2",
+				StorkMigrationName:              "schedule-interval-3",
+			},
+			CreationTimestamp: metav1.Now(),
+		},
+		Data: map[string]string{migrationLedgerKey: "[]"},
+	})
+	require.NoError(t, err)
+	ledger, _, err = getMigrationLedger(first)
+	require.NoError(t, err)
+	require.Equal(t, entries[:1], ledger)
+}
+
+func TestAddToMigrationLedger(t *testing.T) {
+	core.SetInstance(core.New(fakek8s.NewSimpleClientset()))
+	entries := newLedgerEntries(2)
+	migration := newLedgerMigration("schedule-interval-1")
+	migration.Status.Resources = []*stork_api.MigrationResourceInfo{
+		{Name: "config-0", Namespace: "test", GroupVersionKind: entries[0].GroupVersionKind, Status: stork_api.MigrationStatusSuccessful},
+		{Name: "config-1", Namespace: "test", GroupVersionKind: entries[1].GroupVersionKind, Status: stork_api.MigrationStatusFailed},
+	}
+
+	// The ledger isn't created by a migration that didn't succeed
+	require.NoError(t, addToMigrationLedger(migration))
+	_, found, err := getMigrationLedger(migration)
+	require.NoError(t, err)
+	require.False(t, found)
+
+	// Entries are only added, not removed
+	previous := []*stork_api.ObjectInfo{
+		{Name: "deleted", Namespace: "test", GroupVersionKind: entries[0].GroupVersionKind},
+	}
+	require.NoError(t, updateMigrationLedger(newLedgerMigration("schedule-interval-0"), previous))
+	require.NoError(t, addToMigrationLedger(migration))
+	ledger, found, err := getMigrationLedger(migration)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.ElementsMatch(t, append(previous, entries[0]), ledger)
+}
@@ -10,17 +10,16 @@ import (
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
 	"github.com/libopenstorage/stork/pkg/schedule"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -30,6 +29,8 @@ import (
 )
 
 const (
+	migrationScheduleControllerName = "migration-schedule-controller"
+
 	nameTimeSuffixFormat string = "2006-01-02-150405"
 	domainsRetryInterval        = 5 * time.Second
 	skipResource                = "stork.libopenstorage.org/skip-resource"
@@ -67,7 +68,7 @@ func (m *MigrationScheduleController) Init(mgr manager.Manager) error {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "migration-schedule-controller", m, &stork_api.MigrationSchedule{})
+	return controllers.RegisterTo(mgr, migrationScheduleControllerName, m, &stork_api.MigrationSchedule{})
 }
 
 // Reconcile manages MigrationSchedule resources.
@@ -85,7 +86,7 @@ func (m *MigrationScheduleController) Reconcile(ctx context.Context, request rec
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(migrationScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(migrationSchedule, controllers.FinalizerCleanup) {
@@ -95,10 +96,10 @@ func (m *MigrationScheduleController) Reconcile(ctx context.Context, request rec
 
 	if err = m.handle(context.TODO(), migrationSchedule); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(m), migrationSchedule.Namespace, migrationSchedule.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(migrationScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(migrationScheduleControllerName, controllers.DefaultRequeue)}, nil
 }
 
 func (m *MigrationScheduleController) handle(ctx context.Context, migrationSchedule *stork_api.MigrationSchedule) error {
@@ -107,8 +108,20 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 		if controllers.ContainsFinalizer(migrationSchedule, controllers.FinalizerCleanup) {
 			if err := m.deleteMigrations(migrationSchedule); err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(m), err)
+				// Keep the migration schedule until the cleanup succeeds and
+				// record the error so that it can be audited
+				if controllers.SetCleanupError(migrationSchedule, err) {
+					if updateErr := m.client.Update(ctx, migrationSchedule); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(m), updateErr)
+					}
+				}
+				return err
 			}
 		}
+		if err := controllers.UpdateClusterPairReference(migrationSchedule.Spec.Template.Spec.ClusterPair, migrationSchedule.Namespace,
+			controllers.NewReference("MigrationSchedule", migrationSchedule), false); err != nil {
+			log.MigrationScheduleLog(migrationSchedule).Warnf("Error removing reference from cluster pair: %v", err)
+		}
 
 		if migrationSchedule.GetFinalizers() != nil {
 			controllers.RemoveFinalizer(migrationSchedule, controllers.FinalizerCleanup)
@@ -118,6 +131,17 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 		return nil
 	}
 	migrationSchedule.Spec = setScheduleDefaults(migrationSchedule.Spec)
+	if migrationSchedule.Spec.Template.Spec.Staging != nil {
+		// Each migration of a schedule is a single sync, there is nothing to
+		// cut over
+		msg := "Staging isn't supported in the template of migration schedules"
+		m.recorder.Event(migrationSchedule,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			msg)
+		log.MigrationScheduleLog(migrationSchedule).Warn(msg)
+		return nil
+	}
 	if migrationSchedule.GetAnnotations() != nil {
 		if _, ok := migrationSchedule.GetAnnotations()[StorkMigrationScheduleCopied]; ok {
 			// check status of all migrated app in cluster
@@ -126,7 +150,12 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 			if err != nil {
 				return err
 			}
-			migrationSchedule.Status.ApplicationActivated = isActivated
+			// Activations done with storkctl are kept, the migrated apps
+			// are scaled up only after the schedule has been activated
+			if migrationSchedule.Status.ApplicationActivated || !isActivated {
+				return nil
+			}
+			migrationSchedule.Status.ApplicationActivated = true
 			msg := fmt.Sprintf("Setting AppActive status to: %v", isActivated)
 			m.recorder.Event(migrationSchedule,
 				v1.EventTypeWarning,
@@ -137,6 +166,12 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 
 		}
 	}
+	// Link the schedule from the cluster pair so that the schedules using a
+	// cluster pair can be found from it
+	if err := controllers.UpdateClusterPairReference(migrationSchedule.Spec.Template.Spec.ClusterPair, migrationSchedule.Namespace,
+		controllers.NewReference("MigrationSchedule", migrationSchedule), true); err != nil {
+		log.MigrationScheduleLog(migrationSchedule).Warnf("Error adding reference to cluster pair: %v", err)
+	}
 	if !(*migrationSchedule.Spec.Suspend) {
 		remoteConfig, err := getClusterPairSchedulerConfig(migrationSchedule.Spec.Template.Spec.ClusterPair, migrationSchedule.Namespace)
 		if err != nil {
@@ -249,7 +284,7 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 			}
 		}
 
-		policyType, start, err := m.shouldStartMigration(migrationSchedule)
+		policyType, scheduledTimestamp, start, err := m.shouldStartMigration(migrationSchedule)
 		if err != nil {
 			msg := fmt.Sprintf("Error checking if migration should be triggered: %v", err)
 			m.recorder.Event(migrationSchedule,
@@ -262,7 +297,14 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 
 		// Start a migration for a policy if required
 		if start {
-			err := m.startMigration(migrationSchedule, policyType)
+			deferred, err := m.deferIfRequired(migrationSchedule)
+			if err != nil {
+				return err
+			}
+			if deferred {
+				return nil
+			}
+			err = m.startMigration(migrationSchedule, policyType, scheduledTimestamp)
 			if err != nil {
 				msg := fmt.Sprintf("Error triggering migration for schedule(%v): %v", policyType, err)
 				m.recorder.Event(migrationSchedule,
@@ -292,6 +334,41 @@ func (m *MigrationScheduleController) handle(ctx context.Context, migrationSched
 	return nil
 }
 
+// deferIfRequired checks if the cluster is under maintenance and records the
+// deferral in the status if the migration that is due should be deferred. The
+// deferral is cleared otherwise. Errors during the check don't block the
+// migration.
+func (m *MigrationScheduleController) deferIfRequired(migrationSchedule *stork_api.MigrationSchedule) (bool, error) {
+	deferred, reason, err := schedule.MaintenanceInProgress(storkconfig.GetMaintenanceConfiguration())
+	if err != nil {
+		msg := fmt.Sprintf("Error checking for cluster maintenance, not deferring migration: %v", err)
+		m.recorder.Event(migrationSchedule,
+			v1.EventTypeWarning,
+			string(stork_api.MigrationStatusFailed),
+			msg)
+		log.MigrationScheduleLog(migrationSchedule).Warn(msg)
+	}
+	if !deferred {
+		migrationSchedule.Status.DeferredSince = meta.Time{}
+		migrationSchedule.Status.DeferredReason = ""
+		return false, nil
+	}
+	if migrationSchedule.Status.DeferredSince.IsZero() {
+		migrationSchedule.Status.DeferredSince = meta.NewTime(schedule.GetCurrentTime())
+		msg := fmt.Sprintf("Deferring migration: %v", reason)
+		m.recorder.Event(migrationSchedule,
+			v1.EventTypeNormal,
+			"Deferred",
+			msg)
+		log.MigrationScheduleLog(migrationSchedule).Info(msg)
+	}
+	if migrationSchedule.Status.DeferredReason == reason {
+		return true, nil
+	}
+	migrationSchedule.Status.DeferredReason = reason
+	return true, m.client.Update(context.TODO(), migrationSchedule)
+}
+
 func (m *MigrationScheduleController) updateMigrationStatus(migrationSchedule *stork_api.MigrationSchedule) error {
 	updated := false
 	for _, policyMigration := range migrationSchedule.Status.Items {
@@ -308,6 +385,13 @@ func (m *MigrationScheduleController) updateMigrationStatus(migrationSchedule *s
 					updatedStatus = stork_api.MigrationStatusFailed
 				} else {
 					updatedStatus = pendingMigration.Status.Status
+					if diff := pendingMigration.Status.Diff; diff != nil {
+						migration.DiffSummary = &stork_api.MigrationDiffSummary{
+							Create: len(diff.Create),
+							Update: len(diff.Update),
+							Delete: len(diff.Delete),
+						}
+					}
 				}
 
 				if updatedStatus == stork_api.MigrationStatusInitial {
@@ -318,6 +402,14 @@ func (m *MigrationScheduleController) updateMigrationStatus(migrationSchedule *s
 				migration.Status = updatedStatus
 				if m.isMigrationComplete(migration.Status) {
 					migration.FinishTimestamp = meta.NewTime(schedule.GetCurrentTime())
+					if summary := migration.DiffSummary; summary != nil &&
+						(summary.Create != 0 || summary.Update != 0 || summary.Delete != 0) {
+						m.recorder.Event(migrationSchedule,
+							v1.EventTypeWarning,
+							"DriftDetected",
+							fmt.Sprintf("Scheduled migration (%v) found differences with the destination: %v to create, %v to update, %v to delete",
+								migration.Name, summary.Create, summary.Update, summary.Delete))
+					}
 					if updatedStatus == stork_api.MigrationStatusSuccessful {
 						m.recorder.Event(migrationSchedule,
 							v1.EventTypeNormal,
@@ -363,14 +455,14 @@ func (m *MigrationScheduleController) isMigrationComplete(status stork_api.Migra
 // type of polivy that should trigger it.
 func (m *MigrationScheduleController) shouldStartMigration(
 	migrationSchedule *stork_api.MigrationSchedule,
-) (stork_api.SchedulePolicyType, bool, error) {
+) (stork_api.SchedulePolicyType, meta.Time, bool, error) {
 	// Don't trigger a new migration if one is already in progress
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		policyMigration, present := migrationSchedule.Status.Items[policyType]
 		if present {
 			for _, migration := range policyMigration {
 				if !m.isMigrationComplete(migration.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
+					return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 				}
 			}
 		}
@@ -381,25 +473,30 @@ func (m *MigrationScheduleController) shouldStartMigration(
 		policyMigration, present := migrationSchedule.Status.Items[policyType]
 		if present {
 			for _, migration := range policyMigration {
-				if latestMigrationTimestamp.Before(&migration.CreationTimestamp) {
-					latestMigrationTimestamp = migration.CreationTimestamp
+				triggerTimestamp := migration.CreationTimestamp
+				if !migration.ScheduledTimestamp.IsZero() {
+					triggerTimestamp = migration.ScheduledTimestamp
+				}
+				if latestMigrationTimestamp.Before(&triggerTimestamp) {
+					latestMigrationTimestamp = triggerTimestamp
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, scheduledTimestamp, err := schedule.TriggerRequiredWithMissedRunPolicy(
 			migrationSchedule.Spec.SchedulePolicyName,
 			migrationSchedule.Namespace,
 			policyType,
 			latestMigrationTimestamp,
+			migrationSchedule.Spec.MissedRunPolicy,
 		)
 		if err != nil {
-			return stork_api.SchedulePolicyTypeInvalid, false, err
+			return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, err
 		}
 		if trigger {
-			return policyType, true, nil
+			return policyType, scheduledTimestamp, true, nil
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+	return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 }
 
 func (m *MigrationScheduleController) formatMigrationName(
@@ -414,6 +511,7 @@ func (m *MigrationScheduleController) formatMigrationName(
 func (m *MigrationScheduleController) startMigration(
 	migrationSchedule *stork_api.MigrationSchedule,
 	policyType stork_api.SchedulePolicyType,
+	scheduledTimestamp meta.Time,
 ) error {
 	migrationName := m.formatMigrationName(migrationSchedule, policyType)
 	if migrationSchedule.Status.Items == nil {
@@ -424,9 +522,10 @@ func (m *MigrationScheduleController) startMigration(
 	}
 	migrationSchedule.Status.Items[policyType] = append(migrationSchedule.Status.Items[policyType],
 		&stork_api.ScheduledMigrationStatus{
-			Name:              migrationName,
-			CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
-			Status:            stork_api.MigrationStatusPending,
+			Name:               migrationName,
+			CreationTimestamp:  meta.NewTime(schedule.GetCurrentTime()),
+			ScheduledTimestamp: scheduledTimestamp,
+			Status:             stork_api.MigrationStatusPending,
 		})
 	err := m.client.Update(context.TODO(), migrationSchedule)
 	if err != nil {
@@ -456,6 +555,7 @@ func (m *MigrationScheduleController) startMigration(
 		migration.Annotations[k] = v
 	}
 	migration.Annotations[StorkMigrationScheduleName] = migrationSchedule.GetName()
+	k8sutils.SetProvenanceLabels(migration, migrationSchedule.Name, schedule.GetCurrentTime())
 	log.MigrationScheduleLog(migrationSchedule).Infof("Starting migration %s", migrationName)
 	_, err = storkops.Instance().CreateMigration(migration)
 	return err
@@ -511,30 +611,7 @@ func (m *MigrationScheduleController) deleteMigrations(migrationSchedule *stork_
 }
 
 func (m *MigrationScheduleController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.MigrationScheduleResourceName,
-		Plural:  stork_api.MigrationScheduleResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.MigrationSchedule{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.MigrationSchedule{}).Name())
 }
 
 func getMigratedAppStatus(migrationSchedule *stork_api.MigrationSchedule) (bool, error) {
@@ -0,0 +1,572 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/log"
+	ocpappsv1 "github.com/openshift/api/apps/v1"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/batch"
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	"github.com/portworx/sched-ops/k8s/openshift"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// StorkCutoverReplicasAnnotation is the annotation used to keep track of the
+// number of replicas of an application on the source before it was stopped
+// for the cutover of a staged migration
+const StorkCutoverReplicasAnnotation = "stork.libopenstorage.org/cutoverReplicas"
+
+// StorkCutoverSuspendedAnnotation is the annotation used to mark the CronJobs
+// and registered CRs that were suspended on the source for the cutover of a
+// staged migration
+const StorkCutoverSuspendedAnnotation = "stork.libopenstorage.org/cutoverSuspended"
+
+// cutoverStarted returns true once the applications have started to be
+// stopped on the source for the cutover
+func cutoverStarted(migration *stork_api.Migration) bool {
+	return migration.Status.Staging != nil && migration.Status.Staging.CutoverStartTimestamp != nil
+}
+
+// startApplications returns true if the migrated applications are started
+// on the destination. Staged migrations only start them with the final sync
+// of the cutover, the applications are still running on the source until
+// then.
+func startApplications(migration *stork_api.Migration) bool {
+	if migration.Spec.Staging != nil {
+		return cutoverStarted(migration)
+	}
+	return *migration.Spec.StartApplications
+}
+
+// finishSync records a sync of a staged migration that has finished
+// successfully. Returns true if the migration waits for the next sync or the
+// cutover instead of finishing.
+func (m *MigrationController) finishSync(migration *stork_api.Migration) bool {
+	if migration.Spec.Staging == nil {
+		return false
+	}
+	if migration.Status.Staging == nil {
+		migration.Status.Staging = &stork_api.MigrationStagingStatus{}
+	}
+	staging := migration.Status.Staging
+	staging.Syncs++
+	staging.LastSyncTimestamp = metav1.Now()
+	if staging.CutoverStartTimestamp != nil {
+		finish := metav1.Now()
+		staging.CutoverFinishTimestamp = &finish
+		staging.Downtime = &metav1.Duration{Duration: finish.Sub(staging.CutoverStartTimestamp.Time)}
+		message := fmt.Sprintf("Cutover finished, applications were down for %v", staging.Downtime.Duration.Round(time.Second))
+		log.MigrationLog(migration).Infof(message)
+		m.recorder.Event(migration,
+			v1.EventTypeNormal,
+			string(stork_api.MigrationStatusSuccessful),
+			message)
+		return false
+	}
+
+	log.MigrationLog(migration).Infof("Sync %v of staged migration finished", staging.Syncs)
+	migration.Status.Stage = stork_api.MigrationStageStaged
+	migration.Status.Status = stork_api.MigrationStatusInProgress
+	return true
+}
+
+// handleStaged starts the next sync of a staged migration once the sync
+// interval has passed. If a cutover was requested, the applications are
+// stopped on the source first and the final sync is started once all their
+// pods are gone.
+func (m *MigrationController) handleStaged(migration *stork_api.Migration) error {
+	if migration.Spec.Staging == nil {
+		// Staging was removed from the spec, keep what has been synced
+		migration.Status.Stage = stork_api.MigrationStageFinal
+		migration.Status.Status = stork_api.MigrationStatusSuccessful
+		migration.Status.FinishTimestamp = metav1.Now()
+		return m.updateMigrationCR(context.TODO(), migration)
+	}
+	if migration.Status.Staging == nil {
+		migration.Status.Staging = &stork_api.MigrationStagingStatus{}
+	}
+
+	if !migration.Spec.Staging.Cutover {
+		interval := stork_api.DefaultMigrationSyncInterval
+		if migration.Spec.Staging.SyncInterval != nil {
+			interval = migration.Spec.Staging.SyncInterval.Duration
+		}
+		if time.Since(migration.Status.Staging.LastSyncTimestamp.Time) < interval {
+			return nil
+		}
+		return m.startSync(migration)
+	}
+
+	if !cutoverStarted(migration) {
+		start := metav1.Now()
+		migration.Status.Staging.CutoverStartTimestamp = &start
+		message := "Stopping applications for the cutover"
+		log.MigrationLog(migration).Infof(message)
+		m.recorder.Event(migration,
+			v1.EventTypeNormal,
+			string(stork_api.MigrationStatusInProgress),
+			message)
+		// Save the start of the downtime before anything is stopped
+		if err := m.updateMigrationCR(context.TODO(), migration); err != nil {
+			return err
+		}
+	}
+	stopped, err := m.stopApplications(migration)
+	if err != nil {
+		return fmt.Errorf("error stopping applications for the cutover: %v", err)
+	}
+	if !stopped {
+		log.MigrationLog(migration).Infof("Waiting for applications to stop for the cutover")
+		return nil
+	}
+	return m.startSync(migration)
+}
+
+// startSync resets the status of the volumes and resources so that they are
+// migrated again
+func (m *MigrationController) startSync(migration *stork_api.Migration) error {
+	migration.Status.Stage = stork_api.MigrationStageInitial
+	migration.Status.Status = stork_api.MigrationStatusInitial
+	migration.Status.Volumes = nil
+	migration.Status.Resources = nil
+	migration.Status.FailedItems = nil
+	return m.updateMigrationCR(context.TODO(), migration)
+}
+
+// stopApplications stops the applications being migrated on the source.
+// Deployments, StatefulSets and DeploymentConfigs are scaled down to 0 and
+// their replicas are stored in an annotation so that the applications are
+// started with them on the destination. CronJobs and registered CRs are
+// suspended. Returns true once all their pods are gone.
+func (m *MigrationController) stopApplications(migration *stork_api.Migration) (bool, error) {
+	stopped := true
+	options := metav1.ListOptions{LabelSelector: labels.Set(migration.Spec.Selectors).String()}
+	for _, ns := range migration.Spec.Namespaces {
+		deployments, err := apps.Instance().ListDeployments(ns, options)
+		if err != nil {
+			return false, err
+		}
+		for i := range deployments.Items {
+			deployment := &deployments.Items[i]
+			if scaleDownForCutover(&deployment.ObjectMeta, &deployment.Spec.Replicas) {
+				if _, err := apps.Instance().UpdateDeployment(deployment); err != nil {
+					return false, err
+				}
+				stopped = false
+			} else if deployment.Status.Replicas != 0 {
+				stopped = false
+			}
+		}
+
+		statefulSets, err := apps.Instance().ListStatefulSets(ns, options)
+		if err != nil {
+			return false, err
+		}
+		for i := range statefulSets.Items {
+			statefulSet := &statefulSets.Items[i]
+			if scaleDownForCutover(&statefulSet.ObjectMeta, &statefulSet.Spec.Replicas) {
+				if _, err := apps.Instance().UpdateStatefulSet(statefulSet); err != nil {
+					return false, err
+				}
+				stopped = false
+			} else if statefulSet.Status.Replicas != 0 {
+				stopped = false
+			}
+		}
+
+		deploymentConfigs, err := listDeploymentConfigs(migration, ns)
+		if err != nil {
+			return false, err
+		}
+		for i := range deploymentConfigs {
+			deploymentConfig := &deploymentConfigs[i]
+			replicas := &deploymentConfig.Spec.Replicas
+			if scaleDownForCutover(&deploymentConfig.ObjectMeta, &replicas) {
+				deploymentConfig.Spec.Replicas = *replicas
+				if _, err := openshift.Instance().UpdateDeploymentConfig(deploymentConfig); err != nil {
+					return false, err
+				}
+				stopped = false
+			} else if deploymentConfig.Status.Replicas != 0 {
+				stopped = false
+			}
+		}
+
+		cronJobs, err := batch.Instance().ListCronJobs(ns, options)
+		if err != nil {
+			return false, err
+		}
+		for i := range cronJobs.Items {
+			cronJob := &cronJobs.Items[i]
+			if cronJob.Spec.Suspend == nil || !*cronJob.Spec.Suspend {
+				suspend := true
+				cronJob.Spec.Suspend = &suspend
+				setCutoverSuspended(&cronJob.ObjectMeta)
+				if _, err := batch.Instance().UpdateCronJob(cronJob); err != nil {
+					return false, err
+				}
+				stopped = false
+			} else if len(cronJob.Status.Active) != 0 {
+				stopped = false
+			}
+		}
+
+		crsStopped, err := m.suspendCRs(migration, ns, options)
+		if err != nil {
+			return false, err
+		}
+		stopped = stopped && crsStopped
+	}
+	return stopped, nil
+}
+
+// suspendCRs suspends the CRs in the namespace whose kinds are registered
+// with suspend options. Returns true once the pods of all of them are gone.
+func (m *MigrationController) suspendCRs(
+	migration *stork_api.Migration,
+	namespace string,
+	options metav1.ListOptions,
+) (bool, error) {
+	registrations, err := storkops.Instance().ListApplicationRegistrations()
+	if err != nil {
+		return false, err
+	}
+	stopped := true
+	for _, registration := range registrations.Items {
+		for _, resource := range registration.Resources {
+			suspendOpts := getSuspendOptions(resource)
+			if len(suspendOpts) == 0 {
+				continue
+			}
+			objects, err := listCRs(resource, namespace, options)
+			if err != nil {
+				return false, err
+			}
+			for i := range objects {
+				object := &objects[i]
+				annotations := object.GetAnnotations()
+				if _, ok := annotations[StorkCutoverSuspendedAnnotation]; !ok {
+					if err := suspendCR(object, suspendOpts); err != nil {
+						return false, fmt.Errorf("error suspending %v %v: %v", resource.Kind, object.GetName(), err)
+					}
+					annotations = object.GetAnnotations()
+					annotations[StorkCutoverSuspendedAnnotation] = "true"
+					object.SetAnnotations(annotations)
+					if _, err := dynamic.Instance().UpdateObject(object); err != nil {
+						return false, err
+					}
+					stopped = false
+					continue
+				}
+				if resource.PodsPath == "" {
+					continue
+				}
+				pods, _, err := unstructured.NestedStringSlice(object.Object, strings.Split(resource.PodsPath, ".")...)
+				if err != nil {
+					return false, err
+				}
+				if len(pods) != 0 {
+					stopped = false
+				}
+			}
+		}
+	}
+	return stopped, nil
+}
+
+// rollbackCutover starts the applications on the source again if the final
+// sync of a cutover failed, so that they aren't left stopped on both
+// clusters
+func (m *MigrationController) rollbackCutover(migration *stork_api.Migration) error {
+	staging := migration.Status.Staging
+	if migration.Spec.Staging == nil || !cutoverStarted(migration) ||
+		staging.CutoverFinishTimestamp != nil || staging.RolledBack ||
+		migration.Status.Status != stork_api.MigrationStatusFailed {
+		return nil
+	}
+	options := metav1.ListOptions{LabelSelector: labels.Set(migration.Spec.Selectors).String()}
+	for _, ns := range migration.Spec.Namespaces {
+		deployments, err := apps.Instance().ListDeployments(ns, options)
+		if err != nil {
+			return err
+		}
+		for i := range deployments.Items {
+			deployment := &deployments.Items[i]
+			if update, err := scaleUpAfterCutover(&deployment.ObjectMeta, &deployment.Spec.Replicas); err != nil {
+				return err
+			} else if update {
+				if _, err := apps.Instance().UpdateDeployment(deployment); err != nil {
+					return err
+				}
+			}
+		}
+
+		statefulSets, err := apps.Instance().ListStatefulSets(ns, options)
+		if err != nil {
+			return err
+		}
+		for i := range statefulSets.Items {
+			statefulSet := &statefulSets.Items[i]
+			if update, err := scaleUpAfterCutover(&statefulSet.ObjectMeta, &statefulSet.Spec.Replicas); err != nil {
+				return err
+			} else if update {
+				if _, err := apps.Instance().UpdateStatefulSet(statefulSet); err != nil {
+					return err
+				}
+			}
+		}
+
+		deploymentConfigs, err := listDeploymentConfigs(migration, ns)
+		if err != nil {
+			return err
+		}
+		for i := range deploymentConfigs {
+			deploymentConfig := &deploymentConfigs[i]
+			replicas := &deploymentConfig.Spec.Replicas
+			if update, err := scaleUpAfterCutover(&deploymentConfig.ObjectMeta, &replicas); err != nil {
+				return err
+			} else if update {
+				deploymentConfig.Spec.Replicas = *replicas
+				if _, err := openshift.Instance().UpdateDeploymentConfig(deploymentConfig); err != nil {
+					return err
+				}
+			}
+		}
+
+		cronJobs, err := batch.Instance().ListCronJobs(ns, options)
+		if err != nil {
+			return err
+		}
+		for i := range cronJobs.Items {
+			cronJob := &cronJobs.Items[i]
+			if _, ok := cronJob.Annotations[StorkCutoverSuspendedAnnotation]; !ok {
+				continue
+			}
+			suspend := false
+			cronJob.Spec.Suspend = &suspend
+			delete(cronJob.Annotations, StorkCutoverSuspendedAnnotation)
+			if _, err := batch.Instance().UpdateCronJob(cronJob); err != nil {
+				return err
+			}
+		}
+
+		if err := m.resumeCRs(ns, options); err != nil {
+			return err
+		}
+	}
+
+	staging.RolledBack = true
+	message := "Cutover failed, applications were started on the source again"
+	log.MigrationLog(migration).Warnf(message)
+	m.recorder.Event(migration,
+		v1.EventTypeWarning,
+		string(stork_api.MigrationStatusFailed),
+		message)
+	return m.updateMigrationCR(context.TODO(), migration)
+}
+
+// resumeCRs resumes the registered CRs in the namespace that were suspended
+// for the cutover
+func (m *MigrationController) resumeCRs(namespace string, options metav1.ListOptions) error {
+	registrations, err := storkops.Instance().ListApplicationRegistrations()
+	if err != nil {
+		return err
+	}
+	for _, registration := range registrations.Items {
+		for _, resource := range registration.Resources {
+			suspendOpts := getSuspendOptions(resource)
+			if len(suspendOpts) == 0 {
+				continue
+			}
+			objects, err := listCRs(resource, namespace, options)
+			if err != nil {
+				return err
+			}
+			for i := range objects {
+				object := &objects[i]
+				resumed, err := resumeCutoverCR(object, suspendOpts)
+				if err != nil {
+					return fmt.Errorf("error resuming %v %v: %v", resource.Kind, object.GetName(), err)
+				}
+				if !resumed {
+					continue
+				}
+				if _, err := dynamic.Instance().UpdateObject(object); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// listDeploymentConfigs returns the DeploymentConfigs in the namespace that
+// match the selectors of the migration. No DeploymentConfigs are returned if
+// they aren't supported by the cluster.
+func listDeploymentConfigs(migration *stork_api.Migration, namespace string) ([]ocpappsv1.DeploymentConfig, error) {
+	deploymentConfigs, err := openshift.Instance().ListDeploymentConfigs(namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	selector := labels.SelectorFromSet(migration.Spec.Selectors)
+	matched := make([]ocpappsv1.DeploymentConfig, 0)
+	for _, deploymentConfig := range deploymentConfigs.Items {
+		if selector.Matches(labels.Set(deploymentConfig.Labels)) {
+			matched = append(matched, deploymentConfig)
+		}
+	}
+	return matched, nil
+}
+
+// listCRs returns the CRs of a registered resource in the namespace. No CRs
+// are returned if the resource isn't installed in the cluster.
+func listCRs(resource stork_api.ApplicationResource, namespace string, options metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	options.TypeMeta = metav1.TypeMeta{
+		Kind:       resource.Kind,
+		APIVersion: schema.GroupVersion{Group: resource.Group, Version: resource.Version}.String(),
+	}
+	objects, err := dynamic.Instance().ListObjects(&options, namespace)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i := range objects.Items {
+		objects.Items[i].SetAPIVersion(options.APIVersion)
+		objects.Items[i].SetKind(resource.Kind)
+	}
+	return objects.Items, nil
+}
+
+// getSuspendOptions returns all the suspend options of a registered resource
+func getSuspendOptions(resource stork_api.ApplicationResource) []stork_api.SuspendOptions {
+	suspendOpts := make([]stork_api.SuspendOptions, 0)
+	for _, suspend := range append(resource.NestedSuspendOptions, resource.SuspendOptions) {
+		if len(strings.Split(suspend.Path, ".")) > 1 {
+			suspendOpts = append(suspendOpts, suspend)
+		}
+	}
+	return suspendOpts
+}
+
+// setCutoverSuspended marks an object as suspended for the cutover
+func setCutoverSuspended(metadata *metav1.ObjectMeta) {
+	if metadata.Annotations == nil {
+		metadata.Annotations = make(map[string]string)
+	}
+	metadata.Annotations[StorkCutoverSuspendedAnnotation] = "true"
+}
+
+// clearCutoverSuspended removes the annotation marking an object as
+// suspended for the cutover. Returns false if the object wasn't marked.
+func clearCutoverSuspended(content map[string]interface{}) (bool, error) {
+	annotations, _, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return false, err
+	}
+	if _, ok := annotations[StorkCutoverSuspendedAnnotation]; !ok {
+		return false, nil
+	}
+	delete(annotations, StorkCutoverSuspendedAnnotation)
+	return true, unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
+
+// resumeCutoverCronJob starts a CronJob that was suspended on the source for
+// the cutover again
+func resumeCutoverCronJob(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	suspended, err := clearCutoverSuspended(content)
+	if err != nil || !suspended {
+		return err
+	}
+	return unstructured.SetNestedField(content, false, "spec", "suspend")
+}
+
+// resumeCutoverCR resumes a CR that was suspended on the source for the
+// cutover. Returns false if it wasn't suspended for the cutover.
+func resumeCutoverCR(object runtime.Unstructured, suspendOpts []stork_api.SuspendOptions) (bool, error) {
+	content := object.UnstructuredContent()
+	suspended, err := clearCutoverSuspended(content)
+	if err != nil || !suspended {
+		return false, err
+	}
+	return true, resumeCR(content, suspendOpts)
+}
+
+// scaleDownForCutover sets the replicas to 0 and stores the current replicas
+// in an annotation. Returns false if the application was already scaled down.
+func scaleDownForCutover(metadata *metav1.ObjectMeta, replicas **int32) bool {
+	current := int32(1)
+	if *replicas != nil {
+		current = **replicas
+	}
+	if current == 0 {
+		return false
+	}
+	if metadata.Annotations == nil {
+		metadata.Annotations = make(map[string]string)
+	}
+	metadata.Annotations[StorkCutoverReplicasAnnotation] = strconv.FormatInt(int64(current), 10)
+	zero := int32(0)
+	*replicas = &zero
+	return true
+}
+
+// scaleUpAfterCutover sets the replicas back to the ones stored in the
+// annotation when the application was stopped for the cutover and removes the
+// annotation. Returns false if the application wasn't stopped for the
+// cutover.
+func scaleUpAfterCutover(metadata *metav1.ObjectMeta, replicas **int32) (bool, error) {
+	value, ok := metadata.Annotations[StorkCutoverReplicasAnnotation]
+	if !ok {
+		return false, nil
+	}
+	current, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for annotation %v: %v", StorkCutoverReplicasAnnotation, value)
+	}
+	restored := int32(current)
+	*replicas = &restored
+	delete(metadata.Annotations, StorkCutoverReplicasAnnotation)
+	return true, nil
+}
+
+// restoreCutoverReplicas sets the replicas of an application that was
+// stopped on the source for the cutover back to the ones it had before
+func restoreCutoverReplicas(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	annotations, _, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	value, ok := annotations[StorkCutoverReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+	replicas, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for annotation %v: %v", StorkCutoverReplicasAnnotation, value)
+	}
+	if err := unstructured.SetNestedField(content, replicas, "spec", "replicas"); err != nil {
+		return err
+	}
+	delete(annotations, StorkCutoverReplicasAnnotation)
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
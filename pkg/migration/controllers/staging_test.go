@@ -0,0 +1,370 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	ocpappsv1 "github.com/openshift/api/apps/v1"
+	fakeocpclient "github.com/openshift/client-go/apps/clientset/versioned/fake"
+	fakeocpconfigclient "github.com/openshift/client-go/config/clientset/versioned/fake"
+	fakeocpsecurityclient "github.com/openshift/client-go/security/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/batch"
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	"github.com/portworx/sched-ops/k8s/openshift"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var testLabels = map[string]string{"app": "web"}
+
+func newStagedMigration() *stork_api.Migration {
+	migration := newTestMigration(false)
+	migration.Spec.Selectors = testLabels
+	migration.Spec.Staging = &stork_api.MigrationStagingPolicy{}
+	migration.Status.Stage = stork_api.MigrationStageStaged
+	migration.Status.Status = stork_api.MigrationStatusInProgress
+	return migration
+}
+
+func newTestWidget(replicas int64, pods ...string) *unstructured.Unstructured {
+	podList := make([]interface{}, 0)
+	for _, pod := range pods {
+		podList = append(podList, pod)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name":      "widget",
+			"namespace": "test",
+			"labels":    map[string]interface{}{"app": "web"},
+		},
+		"spec":   map[string]interface{}{"replicas": replicas},
+		"status": map[string]interface{}{"pods": podList},
+	}}
+}
+
+// setupApplications creates a Deployment, a StatefulSet, a DeploymentConfig,
+// a CronJob and a registered CR matching the selectors of the staged
+// migration, and a Deployment that doesn't match them. It has to be called
+// after the controller is created since it replaces the stork client.
+func setupApplications(t *testing.T) {
+	replicas := int32(3)
+	suspend := false
+	kube := fakek8s.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test", Labels: testLabels},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{Replicas: replicas},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "test"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test", Labels: testLabels},
+			Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "test", Labels: testLabels},
+			Spec:       batchv1.CronJobSpec{Suspend: &suspend},
+		},
+	)
+	apps.SetInstance(apps.New(kube.AppsV1(), kube.CoreV1()))
+	batch.SetInstance(batch.New(kube.BatchV1(), kube.BatchV1beta1()))
+	openshift.SetInstance(openshift.New(kube, fakeocpclient.NewSimpleClientset(&ocpappsv1.DeploymentConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "dc", Namespace: "test", Labels: testLabels},
+		Spec:       ocpappsv1.DeploymentConfigSpec{Replicas: 2},
+	}), fakeocpsecurityclient.NewSimpleClientset(), fakeocpconfigclient.NewSimpleClientset()))
+
+	registration := &stork_api.ApplicationRegistration{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets"},
+		Resources: []stork_api.ApplicationResource{{
+			GroupVersionKind: metav1.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"},
+			SuspendOptions:   stork_api.SuspendOptions{Path: "spec.replicas", Type: "int"},
+			PodsPath:         "status.pods",
+		}},
+	}
+	storkops.SetInstance(storkops.New(kube, fakestorkclient.NewSimpleClientset(registration), nil))
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: "example.com", Version: "v1", Resource: "widgets"}: "WidgetList",
+	}
+	dynamic.SetInstance(dynamic.New(dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(), listKinds, newTestWidget(2, "widget-0"))))
+}
+
+func getTestWidget(t *testing.T) *unstructured.Unstructured {
+	object, err := dynamic.Instance().GetObject(newTestWidget(0))
+	require.NoError(t, err)
+	return object.(*unstructured.Unstructured)
+}
+
+func TestStartApplications(t *testing.T) {
+	migration := newTestMigration(true)
+	require.True(t, startApplications(migration))
+
+	migration.Spec.Staging = &stork_api.MigrationStagingPolicy{}
+	require.False(t, startApplications(migration), "staged applications shouldn't be started before the cutover")
+
+	start := metav1.Now()
+	migration.Status.Staging = &stork_api.MigrationStagingStatus{CutoverStartTimestamp: &start}
+	require.True(t, startApplications(migration))
+}
+
+func TestFinishSync(t *testing.T) {
+	migration := newStagedMigration()
+	m := newTestMigrationController(t, migration)
+
+	require.False(t, m.finishSync(newTestMigration(false)), "migrations without staging should finish")
+
+	require.True(t, m.finishSync(migration))
+	require.Equal(t, 1, migration.Status.Staging.Syncs)
+	require.Equal(t, stork_api.MigrationStageStaged, migration.Status.Stage)
+	require.Equal(t, stork_api.MigrationStatusInProgress, migration.Status.Status)
+
+	start := metav1.NewTime(time.Now().Add(-time.Minute))
+	migration.Status.Staging.CutoverStartTimestamp = &start
+	require.False(t, m.finishSync(migration), "migration should finish after the cutover")
+	require.Equal(t, 2, migration.Status.Staging.Syncs)
+	require.NotNil(t, migration.Status.Staging.CutoverFinishTimestamp)
+	require.GreaterOrEqual(t, migration.Status.Staging.Downtime.Duration, time.Minute)
+}
+
+func TestHandleStaged(t *testing.T) {
+	migration := newStagedMigration()
+	migration.Status.Staging = &stork_api.MigrationStagingStatus{LastSyncTimestamp: metav1.Now()}
+	m := newTestMigrationController(t, migration)
+	setupApplications(t)
+
+	require.NoError(t, m.handleStaged(migration))
+	require.Equal(t, stork_api.MigrationStageStaged, migration.Status.Stage, "sync shouldn't start before the interval")
+
+	migration.Status.Staging.LastSyncTimestamp = metav1.NewTime(time.Now().Add(-2 * stork_api.DefaultMigrationSyncInterval))
+	require.NoError(t, m.handleStaged(migration))
+	require.Equal(t, stork_api.MigrationStageInitial, migration.Status.Stage)
+
+	migration.Status.Stage = stork_api.MigrationStageStaged
+	migration.Spec.Staging.Cutover = true
+	require.NoError(t, m.handleStaged(migration))
+	require.True(t, cutoverStarted(migration))
+	require.Equal(t, stork_api.MigrationStageStaged, migration.Status.Stage, "final sync shouldn't start before the applications are stopped")
+	deployment, err := apps.Instance().GetDeployment("web", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *deployment.Spec.Replicas)
+
+	migration.Spec.Staging = nil
+	require.NoError(t, m.handleStaged(migration))
+	require.Equal(t, stork_api.MigrationStageFinal, migration.Status.Stage)
+	require.Equal(t, stork_api.MigrationStatusSuccessful, migration.Status.Status)
+}
+
+func TestStopApplications(t *testing.T) {
+	migration := newStagedMigration()
+	m := newTestMigrationController(t, migration)
+	setupApplications(t)
+
+	stopped, err := m.stopApplications(migration)
+	require.NoError(t, err)
+	require.False(t, stopped)
+
+	deployment, err := apps.Instance().GetDeployment("web", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *deployment.Spec.Replicas)
+	require.Equal(t, "3", deployment.Annotations[StorkCutoverReplicasAnnotation])
+	other, err := apps.Instance().GetDeployment("other", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *other.Spec.Replicas, "deployments that aren't migrated shouldn't be stopped")
+	statefulSet, err := apps.Instance().GetStatefulSet("db", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), *statefulSet.Spec.Replicas)
+	deploymentConfig, err := openshift.Instance().GetDeploymentConfig("dc", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(0), deploymentConfig.Spec.Replicas)
+	require.Equal(t, "2", deploymentConfig.Annotations[StorkCutoverReplicasAnnotation])
+	cronJob, err := batch.Instance().GetCronJob("job", "test")
+	require.NoError(t, err)
+	require.True(t, *cronJob.Spec.Suspend)
+	require.Contains(t, cronJob.Annotations, StorkCutoverSuspendedAnnotation)
+	widget := getTestWidget(t)
+	replicas, _, err := unstructured.NestedInt64(widget.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), replicas)
+	require.Contains(t, widget.GetAnnotations(), StorkCutoverSuspendedAnnotation)
+
+	// The pods of the deployment and the CR are still running
+	stopped, err = m.stopApplications(migration)
+	require.NoError(t, err)
+	require.False(t, stopped)
+
+	deployment.Status.Replicas = 0
+	_, err = apps.Instance().UpdateDeployment(deployment)
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedStringSlice(widget.Object, []string{}, "status", "pods"))
+	_, err = dynamic.Instance().UpdateObject(widget)
+	require.NoError(t, err)
+	stopped, err = m.stopApplications(migration)
+	require.NoError(t, err)
+	require.True(t, stopped)
+}
+
+func TestRollbackCutover(t *testing.T) {
+	migration := newStagedMigration()
+	m := newTestMigrationController(t, migration)
+	setupApplications(t)
+	_, err := m.stopApplications(migration)
+	require.NoError(t, err)
+
+	start := metav1.Now()
+	migration.Status.Staging = &stork_api.MigrationStagingStatus{CutoverStartTimestamp: &start}
+	migration.Status.Stage = stork_api.MigrationStageFinal
+	migration.Status.Status = stork_api.MigrationStatusSuccessful
+	require.NoError(t, m.rollbackCutover(migration))
+	require.False(t, migration.Status.Staging.RolledBack, "successful cutover shouldn't be rolled back")
+
+	migration.Status.Status = stork_api.MigrationStatusFailed
+	require.NoError(t, m.rollbackCutover(migration))
+	require.True(t, migration.Status.Staging.RolledBack)
+
+	deployment, err := apps.Instance().GetDeployment("web", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *deployment.Spec.Replicas)
+	require.NotContains(t, deployment.Annotations, StorkCutoverReplicasAnnotation)
+	statefulSet, err := apps.Instance().GetStatefulSet("db", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *statefulSet.Spec.Replicas)
+	deploymentConfig, err := openshift.Instance().GetDeploymentConfig("dc", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), deploymentConfig.Spec.Replicas)
+	cronJob, err := batch.Instance().GetCronJob("job", "test")
+	require.NoError(t, err)
+	require.False(t, *cronJob.Spec.Suspend)
+	require.NotContains(t, cronJob.Annotations, StorkCutoverSuspendedAnnotation)
+	widget := getTestWidget(t)
+	replicas, _, err := unstructured.NestedInt64(widget.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), replicas)
+	require.NotContains(t, widget.GetAnnotations(), StorkCutoverSuspendedAnnotation)
+	require.NotContains(t, widget.GetAnnotations(), StorkAnnotationPrefix+"spec.replicas")
+
+	updated := &stork_api.Migration{}
+	require.NoError(t, m.client.Get(context.TODO(), types.NamespacedName{Name: migration.Name, Namespace: migration.Namespace}, updated))
+	require.True(t, updated.Status.Staging.RolledBack)
+}
+
+func TestReconcileRollsBackFailedCutover(t *testing.T) {
+	// The failure of the final sync was recorded by the controller, so the
+	// generation of the migration has been observed
+	migration := newStagedMigration()
+	migration.Generation = 1
+	migration.Finalizers = []string{controllers.FinalizerCleanup}
+	start := metav1.Now()
+	migration.Status.Staging = &stork_api.MigrationStagingStatus{CutoverStartTimestamp: &start}
+	migration.Status.Stage = stork_api.MigrationStageFinal
+	migration.Status.Status = stork_api.MigrationStatusFailed
+	migration.Status.ObservedGeneration = 2
+	m := newTestMigrationController(t, migration)
+	setupApplications(t)
+	_, err := m.stopApplications(migration)
+	require.NoError(t, err)
+	require.False(t, migration.IsTerminal(), "failed cutover should be rolled back before the migration is terminal")
+
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: migration.Name, Namespace: migration.Namespace}}
+	_, err = m.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+
+	updated := &stork_api.Migration{}
+	require.NoError(t, m.client.Get(context.TODO(), request.NamespacedName, updated))
+	require.True(t, updated.Status.Staging.RolledBack)
+	require.True(t, updated.IsTerminal())
+	deployment, err := apps.Instance().GetDeployment("web", "test")
+	require.NoError(t, err)
+	require.Equal(t, int32(3), *deployment.Spec.Replicas)
+	cronJob, err := batch.Instance().GetCronJob("job", "test")
+	require.NoError(t, err)
+	require.False(t, *cronJob.Spec.Suspend)
+
+	// The rolled back migration isn't handled again
+	result, err := m.Reconcile(context.TODO(), request)
+	require.NoError(t, err)
+	require.Equal(t, reconcile.Result{}, result)
+}
+
+func TestRestoreCutoverReplicas(t *testing.T) {
+	deployment := newTestDeployment(0)
+	deployment.SetAnnotations(map[string]string{StorkCutoverReplicasAnnotation: "3", "other": "value"})
+	require.NoError(t, restoreCutoverReplicas(deployment))
+	replicas, _, err := unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), replicas)
+	require.Equal(t, map[string]string{"other": "value"}, deployment.GetAnnotations())
+
+	deployment = newTestDeployment(2)
+	require.NoError(t, restoreCutoverReplicas(deployment))
+	replicas, _, err = unstructured.NestedInt64(deployment.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), replicas, "replicas shouldn't change without the annotation")
+
+	deployment.SetAnnotations(map[string]string{StorkCutoverReplicasAnnotation: "invalid"})
+	require.Error(t, restoreCutoverReplicas(deployment))
+}
+
+func TestPrepareResourcesResumesCutover(t *testing.T) {
+	migration := newStagedMigration()
+	m := newTestMigrationController(t, migration)
+	start := metav1.Now()
+	migration.Status.Staging = &stork_api.MigrationStagingStatus{CutoverStartTimestamp: &start}
+
+	cronJob := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "CronJob",
+		"metadata": map[string]interface{}{
+			"name":        "job",
+			"namespace":   "test",
+			"annotations": map[string]interface{}{StorkCutoverSuspendedAnnotation: "true"},
+		},
+		"spec": map[string]interface{}{"suspend": true},
+	}}
+	require.NoError(t, m.prepareResources(migration, []runtime.Unstructured{cronJob}))
+	suspend, _, err := unstructured.NestedBool(cronJob.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.False(t, suspend, "CronJob suspended for the cutover should be started on the destination")
+	require.NotContains(t, cronJob.GetAnnotations(), StorkCutoverSuspendedAnnotation)
+
+	widget := newTestWidget(0)
+	widget.SetAnnotations(map[string]string{
+		StorkCutoverSuspendedAnnotation:         "true",
+		StorkAnnotationPrefix + "spec.replicas": "2,",
+	})
+	suspendOpts := []stork_api.SuspendOptions{{Path: "spec.replicas", Type: "int"}}
+	require.NoError(t, m.prepareCRDClusterResource(migration, widget, suspendOpts))
+	replicas, _, err := unstructured.NestedInt64(widget.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), replicas)
+	require.Empty(t, widget.GetAnnotations())
+
+	// Before the cutover the CR is suspended on the destination
+	migration.Status.Staging = nil
+	widget = newTestWidget(2)
+	require.NoError(t, m.prepareCRDClusterResource(migration, widget, suspendOpts))
+	replicas, _, err = unstructured.NestedInt64(widget.Object, "spec", "replicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), replicas)
+}
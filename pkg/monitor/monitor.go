@@ -6,7 +6,10 @@ import (
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/cache"
+	"github.com/libopenstorage/stork/pkg/controllers"
 	storklog "github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/storage"
 	"github.com/prometheus/client_golang/prometheus"
@@ -16,8 +19,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
+	k8shelper "k8s.io/component-helpers/storage/volume"
 	"k8s.io/kubernetes/pkg/util/node"
 )
 
@@ -30,6 +35,9 @@ const (
 	nodeWaitSteps        = 5
 
 	storageDriverOfflineReason = "StorageDriverOffline"
+	// ownershipCacheTTL is how long the driver ownership of PVCs is cached
+	// before it is looked up from the driver again
+	ownershipCacheTTL = 10 * time.Minute
 )
 
 var (
@@ -51,11 +59,35 @@ type Monitor struct {
 	Driver      volume.Driver
 	IntervalSec int64
 	Recorder    record.EventRecorder
+	// Namespaces the monitor is restricted to. Pods and volume attachments
+	// for claims in other namespaces are left alone. All namespaces are
+	// monitored if it is empty.
+	Namespaces  []string
 	lock        sync.Mutex
 	wg          sync.WaitGroup
 	started     bool
 	stopChannel chan int
 	done        chan int
+	// ownedPVCs caches whether the driver owns the volumes of PVCs, keyed by
+	// the UID of the PVC, so that the driver isn't queried for every pod
+	ownedPVCs      map[types.UID]bool
+	ownedPVCsReset time.Time
+	ownedPVCsLock  sync.Mutex
+	// nodeHealth tracks the checks of the volume driver on the nodes, keyed
+	// by the storage ID of the node
+	nodeHealth     map[string]*nodeHealth
+	nodeHealthLock sync.Mutex
+}
+
+// nodeHealth tracks the checks of the volume driver on a node
+type nodeHealth struct {
+	// offlineCount is the number of checks the driver was offline since the
+	// node last recovered
+	offlineCount int
+	// onlineCount is the number of consecutive checks the driver was online
+	onlineCount int
+	// lastAction is the last time pods were deleted from the node
+	lastAction time.Time
 }
 
 // Start Starts the monitor
@@ -104,16 +136,20 @@ func (m *Monitor) Stop() error {
 	return nil
 }
 
-func (m *Monitor) isSameNode(k8sNodeName string, driverNode *volume.NodeInfo) bool {
-	if k8sNodeName == driverNode.Hostname {
-		return true
-	}
-	node, err := core.Instance().GetNodeByName(k8sNodeName)
+// getK8sNodeNames returns the names of the kubernetes nodes that match the
+// driver node
+func (m *Monitor) getK8sNodeNames(driverNode *volume.NodeInfo) (map[string]bool, error) {
+	nodes, err := cache.ListNodes()
 	if err != nil {
-		log.Errorf("Error getting node %v: %v", k8sNodeName, err)
-		return false
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for i, node := range nodes {
+		if node.Name == driverNode.Hostname || volume.IsNodeMatch(&nodes[i], driverNode) {
+			names[node.Name] = true
+		}
 	}
-	return volume.IsNodeMatch(node, driverNode)
+	return names, nil
 }
 
 func (m *Monitor) podMonitor() error {
@@ -129,7 +165,7 @@ func (m *Monitor) podMonitor() error {
 		if pod.Status.Reason == node.NodeUnreachablePodReason {
 			podUnknownState = true
 		} else if pod.ObjectMeta.DeletionTimestamp != nil {
-			n, err := core.Instance().GetNodeByName(pod.Spec.NodeName)
+			n, err := cache.GetNode(pod.Spec.NodeName)
 			if err != nil {
 				return err
 			}
@@ -177,9 +213,11 @@ func (m *Monitor) podMonitor() error {
 		return nil
 	}
 
-	if err := core.Instance().WatchPods("", fn, metav1.ListOptions{}); err != nil {
-		log.Errorf("failed to watch pods due to: %v", err)
-		return err
+	for _, ns := range controllers.NamespacesToList(m.Namespaces) {
+		if err := core.Instance().WatchPods(ns, fn, metav1.ListOptions{}); err != nil {
+			log.Errorf("failed to watch pods due to: %v", err)
+			return err
+		}
 	}
 
 	return nil
@@ -188,16 +226,21 @@ func (m *Monitor) podMonitor() error {
 func (m *Monitor) driverMonitor() {
 	defer close(m.done)
 
+	// The nodes are polled here, so the calls to the driver aren't retried
+	driver := volume.WithoutRetries(m.Driver, 0)
 	for {
 		select {
 		default:
 			log.Debugf("Monitoring storage nodes")
-			nodes, err := m.Driver.GetNodes()
+			nodes, err := driver.GetNodes()
 			if err != nil {
 				log.Errorf("Error getting nodes: %v", err)
 				time.Sleep(2 * time.Second)
 			}
 			nodes = volume.RemoveDuplicateOfflineNodes(nodes)
+			if err == nil {
+				m.updateNodeHealth(nodes)
+			}
 			for _, node := range nodes {
 				// Check if nodes are reported online by the storage driver
 				// If not online, look at all the pods on that node
@@ -205,7 +248,7 @@ func (m *Monitor) driverMonitor() {
 				if node.Status != volume.NodeOnline {
 					m.wg.Add(1)
 					// wait for 1 min if node is upgrading
-					go m.cleanupDriverNodePods(node)
+					go m.cleanupDriverNodePods(node, m.getNodeHealth(node.StorageID))
 				}
 			}
 			// lets all node to finish processing and then start sleep
@@ -213,17 +256,110 @@ func (m *Monitor) driverMonitor() {
 
 			// With this default sleep of 2 minutes and the backoff of 2.5 minutes
 			// stork will delete the pods if a driver is down within 4.5 minutes
-			time.Sleep(time.Duration(m.IntervalSec) * time.Second)
+			time.Sleep(m.getInterval())
 		case <-m.stopChannel:
 			return
 		}
 	}
 }
 
-func (m *Monitor) cleanupDriverNodePods(node *volume.NodeInfo) {
+// getInterval returns the interval between the checks of the volume driver
+func (m *Monitor) getInterval() time.Duration {
+	interval := storkconfig.GetHealthMonitorInterval(time.Duration(m.IntervalSec) * time.Second)
+	if interval < minimumIntervalSec*time.Second {
+		return minimumIntervalSec * time.Second
+	}
+	return interval
+}
+
+// updateNodeHealth records the status of the volume driver on the nodes. The
+// offline checks of a node are only reset once the node has been online for
+// the recovery count, so that nodes that flap still get acted on.
+func (m *Monitor) updateNodeHealth(nodes []*volume.NodeInfo) {
+	recoveryCount := storkconfig.GetHealthMonitorRecoveryCount(1)
+	m.nodeHealthLock.Lock()
+	defer m.nodeHealthLock.Unlock()
+	if m.nodeHealth == nil {
+		m.nodeHealth = make(map[string]*nodeHealth)
+	}
+	seen := make(map[string]bool)
+	for _, node := range nodes {
+		seen[node.StorageID] = true
+		health, ok := m.nodeHealth[node.StorageID]
+		if !ok {
+			health = &nodeHealth{}
+			m.nodeHealth[node.StorageID] = health
+		}
+		if node.Status != volume.NodeOnline {
+			health.onlineCount = 0
+			health.offlineCount++
+			continue
+		}
+		health.onlineCount++
+		if health.onlineCount >= recoveryCount {
+			health.offlineCount = 0
+		}
+	}
+	for id := range m.nodeHealth {
+		if !seen[id] {
+			delete(m.nodeHealth, id)
+		}
+	}
+}
+
+// getNodeHealth returns a copy of the health of the node
+func (m *Monitor) getNodeHealth(storageID string) nodeHealth {
+	m.nodeHealthLock.Lock()
+	defer m.nodeHealthLock.Unlock()
+	if health, ok := m.nodeHealth[storageID]; ok {
+		return *health
+	}
+	return nodeHealth{offlineCount: 1}
+}
+
+// setLastAction records that pods were deleted from the node
+func (m *Monitor) setLastAction(storageID string) {
+	m.nodeHealthLock.Lock()
+	defer m.nodeHealthLock.Unlock()
+	if health, ok := m.nodeHealth[storageID]; ok {
+		health.lastAction = time.Now()
+	}
+}
+
+// canAct returns true if the pods using the settings can be deleted from a
+// node with the given health
+func canAct(health nodeHealth, confirmationCount int, cooldown time.Duration) bool {
+	if health.offlineCount < confirmationCount {
+		return false
+	}
+	return health.lastAction.IsZero() || time.Since(health.lastAction) >= cooldown
+}
+
+// getHealthMonitorSettings returns the confirmation count and cool-down for
+// the pod based on its namespace and the storage classes of its PVCs
+func (m *Monitor) getHealthMonitorSettings(pod *v1.Pod) (int, time.Duration) {
+	storageClasses := make([]string, 0)
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := cache.GetPersistentVolumeClaim(vol.PersistentVolumeClaim.ClaimName, pod.Namespace)
+		if err != nil {
+			continue
+		}
+		if storageClass := k8shelper.GetPersistentVolumeClaimClass(pvc); storageClass != "" {
+			storageClasses = append(storageClasses, storageClass)
+		}
+	}
+	return storkconfig.GetHealthMonitorSettings(pod.Namespace, storageClasses)
+}
+
+func (m *Monitor) cleanupDriverNodePods(node *volume.NodeInfo, health nodeHealth) {
 	defer m.wg.Done()
+	// The node is polled here, so the calls to the driver aren't retried
+	driver := volume.WithoutRetries(m.Driver, 0)
 	err := wait.ExponentialBackoff(nodeWaitCallBackoff, func() (bool, error) {
-		n, err := m.Driver.InspectNode(node.StorageID)
+		n, err := driver.InspectNode(node.StorageID)
 		if err != nil {
 			return false, nil
 		}
@@ -236,24 +372,45 @@ func (m *Monitor) cleanupDriverNodePods(node *volume.NodeInfo) {
 	if err == nil {
 		return
 	}
-	pods, err := core.Instance().GetPods("", nil)
+	nodeNames, err := m.getK8sNodeNames(node)
 	if err != nil {
-		log.Errorf("Error getting pods: %v", err)
+		log.Errorf("Error getting nodes for driver node %v: %v", node.StorageID, err)
+		return
 	}
 
 	// delete volume attachments if the node is down for this pod
-	err = m.cleanupVolumeAttachmentsByNode(node)
-	if err != nil {
-		log.Errorf("Error cleaning up volume attachments: %v", err)
+	acted := false
+	confirmationCount, cooldown := storkconfig.GetHealthMonitorSettings("", nil)
+	if canAct(health, confirmationCount, cooldown) {
+		acted = true
+		err = m.cleanupVolumeAttachmentsByNode(node, nodeNames)
+		if err != nil {
+			log.Errorf("Error cleaning up volume attachments: %v", err)
+		}
 	}
 
-	for _, pod := range pods.Items {
-		owns, err := m.doesDriverOwnPodVolumes(&pod)
-		if err != nil || !owns {
+	for nodeName := range nodeNames {
+		pods, err := cache.GetPodsOnNode(nodeName)
+		if err != nil {
+			log.Errorf("Error getting pods on node %v: %v", nodeName, err)
 			continue
 		}
+		for _, pod := range pods {
+			if !controllers.IsNamespaceWatched(m.Namespaces, pod.Namespace) {
+				continue
+			}
+			owns, err := m.doesDriverOwnPodVolumes(&pod)
+			if err != nil || !owns {
+				continue
+			}
+			confirmationCount, cooldown := m.getHealthMonitorSettings(&pod)
+			if !canAct(health, confirmationCount, cooldown) {
+				storklog.PodLog(&pod).Infof("Not deleting pod from node %v yet, volume driver offline for %v checks, pods last deleted at %v",
+					pod.Spec.NodeName, health.offlineCount, health.lastAction)
+				continue
+			}
 
-		if m.isSameNode(pod.Spec.NodeName, node) {
+			acted = true
 			msg := fmt.Sprintf("Deleting Pod from Node %v due to volume driver status: %v (%v)", pod.Spec.NodeName, node.Status, node.RawStatus)
 			storklog.PodLog(&pod).Infof(msg)
 			m.Recorder.Event(&pod, v1.EventTypeWarning, storageDriverOfflineReason, msg)
@@ -265,10 +422,87 @@ func (m *Monitor) cleanupDriverNodePods(node *volume.NodeInfo) {
 			HealthCounter.Inc()
 		}
 	}
+	if acted {
+		m.setLastAction(node.StorageID)
+	}
 }
 
+// doesDriverOwnPodVolumes returns true if the driver owns any of the volumes
+// of the pod. The ownership of the PVCs is cached, pods with inline volumes
+// are always checked with the driver.
 func (m *Monitor) doesDriverOwnPodVolumes(pod *v1.Pod) (bool, error) {
-	volumes, _, err := m.Driver.GetPodVolumes(&pod.Spec, pod.Namespace, false)
+	if hasInlineVolumes(&pod.Spec) {
+		return m.doesDriverOwnVolumes(&pod.Spec, pod)
+	}
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		owns, err := m.doesDriverOwnPVC(vol.PersistentVolumeClaim.ClaimName, pod)
+		if err != nil || owns {
+			return owns, err
+		}
+	}
+	storklog.PodLog(pod).Debugf("Pod doesn't have any volumes by driver")
+	return false, nil
+}
+
+// doesDriverOwnPVC returns true if the driver owns the volume of the PVC
+// used by the pod
+func (m *Monitor) doesDriverOwnPVC(claimName string, pod *v1.Pod) (bool, error) {
+	podSpec := &v1.PodSpec{
+		Volumes: []v1.Volume{{
+			Name: claimName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			},
+		}},
+	}
+	pvc, err := cache.GetPersistentVolumeClaim(claimName, pod.Namespace)
+	if err != nil {
+		// Leave it to the driver to look up the PVC
+		storklog.PodLog(pod).Debugf("Error getting PVC %v for pod: %v", claimName, err)
+		return m.doesDriverOwnVolumes(podSpec, pod)
+	}
+
+	m.ownedPVCsLock.Lock()
+	if m.ownedPVCs == nil || time.Since(m.ownedPVCsReset) > ownershipCacheTTL {
+		m.ownedPVCs = make(map[types.UID]bool)
+		m.ownedPVCsReset = time.Now()
+	}
+	owns, ok := m.ownedPVCs[pvc.UID]
+	m.ownedPVCsLock.Unlock()
+	if ok {
+		return owns, nil
+	}
+
+	owns, err = m.doesDriverOwnVolumes(podSpec, pod)
+	if err != nil {
+		return false, err
+	}
+	// The driver can only tell once the PVC is bound
+	if pvc.Spec.VolumeName != "" {
+		m.ownedPVCsLock.Lock()
+		m.ownedPVCs[pvc.UID] = owns
+		m.ownedPVCsLock.Unlock()
+	}
+	return owns, nil
+}
+
+// hasInlineVolumes returns true if the pod has volumes that could be owned
+// by the driver without a PVC
+func hasInlineVolumes(podSpec *v1.PodSpec) bool {
+	for _, vol := range podSpec.Volumes {
+		if vol.PortworxVolume != nil || vol.CSI != nil || vol.AWSElasticBlockStore != nil ||
+			vol.GCEPersistentDisk != nil || vol.AzureDisk != nil || vol.Cinder != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Monitor) doesDriverOwnVolumes(podSpec *v1.PodSpec, pod *v1.Pod) (bool, error) {
+	volumes, _, err := m.Driver.GetPodVolumes(podSpec, pod.Namespace, false)
 	if err != nil {
 		storklog.PodLog(pod).Errorf("Error getting volumes for pod: %v", err)
 		return false, err
@@ -283,13 +517,19 @@ func (m *Monitor) doesDriverOwnPodVolumes(pod *v1.Pod) (bool, error) {
 }
 
 func (m *Monitor) doesDriverOwnVolumeAttachment(va *storagev1.VolumeAttachment) (bool, error) {
-	pv, err := core.Instance().GetPersistentVolume(*va.Spec.Source.PersistentVolumeName)
+	if va.Spec.Source.PersistentVolumeName == nil {
+		return false, nil
+	}
+	pv, err := cache.GetPersistentVolume(*va.Spec.Source.PersistentVolumeName)
 	if err != nil {
 		log.Errorf("Error getting persistent volume from volume attachment: %v", err)
 		return false, err
 	}
+	if pv.Spec.ClaimRef == nil || !controllers.IsNamespaceWatched(m.Namespaces, pv.Spec.ClaimRef.Namespace) {
+		return false, nil
+	}
 
-	pvc, err := core.Instance().GetPersistentVolumeClaim(pv.Spec.ClaimRef.Name, pv.Spec.ClaimRef.Namespace)
+	pvc, err := cache.GetPersistentVolumeClaim(pv.Spec.ClaimRef.Name, pv.Spec.ClaimRef.Namespace)
 	if err != nil {
 		log.Errorf("Error getting persistent volume claim from volume attachment: %v", err)
 		return false, err
@@ -298,19 +538,35 @@ func (m *Monitor) doesDriverOwnVolumeAttachment(va *storagev1.VolumeAttachment)
 	return m.Driver.OwnsPVC(core.Instance(), pvc), nil
 }
 
+// isVolumeAttachmentWatched returns true if the volume attachment is for a
+// claim in the namespaces the monitor is restricted to
+func (m *Monitor) isVolumeAttachmentWatched(va *storagev1.VolumeAttachment) bool {
+	if len(m.Namespaces) == 0 {
+		return true
+	}
+	if va.Spec.Source.PersistentVolumeName == nil {
+		return false
+	}
+	pv, err := cache.GetPersistentVolume(*va.Spec.Source.PersistentVolumeName)
+	if err != nil || pv.Spec.ClaimRef == nil {
+		return false
+	}
+	return controllers.IsNamespaceWatched(m.Namespaces, pv.Spec.ClaimRef.Namespace)
+}
+
 func (m *Monitor) cleanupVolumeAttachmentsByPod(pod *v1.Pod) error {
 	log.Infof("Cleaning up volume attachments for pod %s", pod.Name)
 
 	// Get all vol attachments
-	vaList, err := storage.Instance().ListVolumeAttachments()
+	vaList, err := cache.ListVolumeAttachments()
 	if err != nil {
 		return err
 	}
 
-	if len(vaList.Items) > 0 {
-		for _, va := range vaList.Items {
+	if len(vaList) > 0 {
+		for _, va := range vaList {
 			// Delete attachments for this pod
-			if pod.Spec.NodeName == va.Spec.NodeName {
+			if pod.Spec.NodeName == va.Spec.NodeName && m.isVolumeAttachmentWatched(&va) {
 				err := storage.Instance().DeleteVolumeAttachment(va.Name)
 				if err != nil {
 					return err
@@ -324,31 +580,32 @@ func (m *Monitor) cleanupVolumeAttachmentsByPod(pod *v1.Pod) error {
 	return nil
 }
 
-func (m *Monitor) cleanupVolumeAttachmentsByNode(node *volume.NodeInfo) error {
+func (m *Monitor) cleanupVolumeAttachmentsByNode(node *volume.NodeInfo, nodeNames map[string]bool) error {
 	log.Infof("Cleaning up volume attachments for node %s", node.StorageID)
 
 	// Get all vol attachments
-	vaList, err := storage.Instance().ListVolumeAttachments()
+	vaList, err := cache.ListVolumeAttachments()
 	if err != nil {
 		return err
 	}
 
-	if len(vaList.Items) > 0 {
-		for _, va := range vaList.Items {
+	if len(vaList) > 0 {
+		for _, va := range vaList {
+			if !nodeNames[va.Spec.NodeName] {
+				continue
+			}
 			owns, err := m.doesDriverOwnVolumeAttachment(&va)
 			if err != nil || !owns {
 				continue
 			}
 
-			// Delete attachments for this pod
-			if m.isSameNode(va.Spec.NodeName, node) {
-				err := storage.Instance().DeleteVolumeAttachment(va.Name)
-				if err != nil {
-					return err
-				}
-
-				log.Infof("Deleted volume attachment: %s", va.Name)
+			// Delete attachments for this node
+			err = storage.Instance().DeleteVolumeAttachment(va.Name)
+			if err != nil {
+				return err
 			}
+
+			log.Infof("Deleted volume attachment: %s", va.Name)
 		}
 	}
 
@@ -48,6 +48,7 @@ var (
 )
 
 func TestMonitor(t *testing.T) {
+	t.Run("testNodeHealth", testNodeHealth)
 	t.Run("setup", setup)
 	t.Run("testUnknownDriverPod", testUnknownDriverPod)
 	t.Run("testUnknownOtherDriverPod", testUnknownOtherDriverPod)
@@ -57,6 +58,7 @@ func TestMonitor(t *testing.T) {
 	t.Run("testOfflineStorageNode", testOfflineStorageNode)
 	t.Run("testOfflineStorageNodeDuplicateIP", testOfflineStorageNodeDuplicateIP)
 	t.Run("testVolumeAttachmentCleanup", testVolumeAttachmentCleanup)
+	t.Run("testWatchNamespaces", testWatchNamespaces)
 	t.Run("teardown", teardown)
 }
 
@@ -493,3 +495,48 @@ func testVolumeAttachmentCleanup(t *testing.T) {
 	// total pods rescheduled during UT's
 	require.Equal(t, testutil.ToFloat64(HealthCounter), float64(8), "pods_reschduled_total not matched")
 }
+
+func testNodeHealth(t *testing.T) {
+	m := &Monitor{}
+	offline := &volume.NodeInfo{StorageID: "node1", Status: volume.NodeOffline}
+	online := &volume.NodeInfo{StorageID: "node1", Status: volume.NodeOnline}
+
+	m.updateNodeHealth([]*volume.NodeInfo{offline})
+	require.False(t, canAct(m.getNodeHealth("node1"), 2, 0))
+	m.updateNodeHealth([]*volume.NodeInfo{offline})
+	require.True(t, canAct(m.getNodeHealth("node1"), 2, 0))
+
+	// The cool-down applies once pods have been deleted from the node
+	m.setLastAction("node1")
+	require.False(t, canAct(m.getNodeHealth("node1"), 2, time.Minute))
+	require.True(t, canAct(m.getNodeHealth("node1"), 2, 0))
+
+	// A recovery resets the offline checks with the default recovery count
+	m.updateNodeHealth([]*volume.NodeInfo{online})
+	require.Equal(t, 0, m.getNodeHealth("node1").offlineCount)
+
+	// Nodes that aren't reported anymore are forgotten
+	m.updateNodeHealth([]*volume.NodeInfo{})
+	require.Empty(t, m.nodeHealth)
+}
+
+func testWatchNamespaces(t *testing.T) {
+	va := &storagev1.VolumeAttachment{
+		Spec: storagev1.VolumeAttachmentSpec{
+			Source: storagev1.VolumeAttachmentSource{
+				PersistentVolumeName: &attachmentVolumeName,
+			},
+		},
+	}
+	m := &Monitor{Driver: monitor.Driver}
+	require.True(t, m.isVolumeAttachmentWatched(va), "all namespaces should be monitored by default")
+
+	m.Namespaces = []string{defaultNamespace}
+	require.True(t, m.isVolumeAttachmentWatched(va))
+
+	m.Namespaces = []string{"other"}
+	require.False(t, m.isVolumeAttachmentWatched(va), "attachments for claims in other namespaces should be left alone")
+	owns, err := m.doesDriverOwnVolumeAttachment(va)
+	require.NoError(t, err)
+	require.False(t, owns)
+}
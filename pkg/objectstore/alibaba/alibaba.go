@@ -0,0 +1,71 @@
+package alibaba
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore/common"
+	"github.com/sirupsen/logrus"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/s3blob"
+)
+
+// OSS is accessed through its S3 compatible API. It only supports virtual
+// hosted style requests and expects the region to be signed with the oss-
+// prefix.
+func getSession(backupLocation *stork_api.BackupLocation) (*session.Session, error) {
+	config := backupLocation.Location.AlibabaConfig
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return session.NewSession(&aws.Config{
+		Endpoint:         aws.String(config.GetEndpoint()),
+		Credentials:      credentials.NewStaticCredentials(config.AccessKeyID, config.AccessKeySecret, ""),
+		Region:           aws.String("oss-" + config.GetRegion()),
+		S3ForcePathStyle: aws.Bool(false),
+	})
+}
+
+// GetBucket gets a reference to the bucket for that backup location
+func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
+	sess, err := getSession(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	return s3blob.OpenBucket(context.Background(), sess, backupLocation.Location.Path, nil)
+}
+
+// CreateBucket creates a bucket for the bucket location
+func CreateBucket(backupLocation *stork_api.BackupLocation) error {
+	sess, err := getSession(backupLocation)
+	if err != nil {
+		return err
+	}
+
+	// Buckets are created in the region of the endpoint
+	input := &s3.CreateBucketInput{
+		Bucket: &backupLocation.Location.Path,
+	}
+	_, err = s3.New(sess).CreateBucket(input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok {
+			// OSS doesn't tell apart buckets owned by other accounts
+			if awsErr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou ||
+				awsErr.Code() == s3.ErrCodeBucketAlreadyExists {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+// GetObjLockInfo fetches the object lock configuration of a bucket
+func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockInfo, error) {
+	logrus.Infof("object lock is not supported for alibaba provider")
+	return &common.ObjLockInfo{}, nil
+}
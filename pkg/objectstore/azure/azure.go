@@ -3,10 +3,14 @@ package azure
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 
 	"github.com/Azure/azure-pipeline-go/pipeline"
 	"github.com/Azure/azure-storage-blob-go/azblob"
+	"github.com/Azure/go-autorest/autorest"
+	azurerest "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/objectstore/common"
 	"github.com/sirupsen/logrus"
@@ -14,6 +18,34 @@ import (
 	"gocloud.dev/blob/azureblob"
 )
 
+const (
+	// storageAPIVersion is the version of the storage resource provider API
+	// used to manage the immutability of containers
+	storageAPIVersion = "2019-06-01"
+	// Error codes returned when deleting blobs protected by a time-based
+	// retention policy or a legal hold
+	serviceCodeBlobImmutableDueToPolicy    azblob.ServiceCodeType = "BlobImmutableDueToPolicy"
+	serviceCodeBlobImmutableDueToLegalHold azblob.ServiceCodeType = "BlobImmutableDueToLegalHold"
+	// lockModeCompliance and lockModeGovernance are the S3 object lock modes
+	// matching locked and unlocked immutability policies
+	lockModeCompliance = "COMPLIANCE"
+	lockModeGovernance = "GOVERNANCE"
+	policyStateLocked  = "Locked"
+)
+
+type immutabilityPolicy struct {
+	Properties immutabilityPolicyProperties `json:"properties"`
+}
+
+type immutabilityPolicyProperties struct {
+	ImmutabilityPeriodSinceCreationInDays int32  `json:"immutabilityPeriodSinceCreationInDays"`
+	State                                 string `json:"state,omitempty"`
+}
+
+type legalHold struct {
+	Tags []string `json:"tags"`
+}
+
 func getPipeline(backupLocation *stork_api.BackupLocation) (pipeline.Pipeline, error) {
 	accountName := azureblob.AccountName(backupLocation.Location.AzureConfig.StorageAccountName)
 	accountKey := azureblob.AccountKey(backupLocation.Location.AzureConfig.StorageAccountKey)
@@ -24,6 +56,20 @@ func getPipeline(backupLocation *stork_api.BackupLocation) (pipeline.Pipeline, e
 	return azureblob.NewPipeline(credential, azblob.PipelineOptions{}), nil
 }
 
+func getContainerURL(backupLocation *stork_api.BackupLocation) (*azblob.ContainerURL, error) {
+	accountName := azureblob.AccountName(backupLocation.Location.AzureConfig.StorageAccountName)
+	pipeline, err := getPipeline(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	url, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net", accountName))
+	if err != nil {
+		return nil, err
+	}
+	containerURL := azblob.NewServiceURL(*url, pipeline).NewContainerURL(backupLocation.Location.Path)
+	return &containerURL, nil
+}
+
 // GetBucket gets a reference to the bucket for that backup location
 func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	accountName := azureblob.AccountName(backupLocation.Location.AzureConfig.StorageAccountName)
@@ -34,33 +80,170 @@ func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	return azureblob.OpenBucket(context.Background(), pipeline, accountName, backupLocation.Location.Path, nil)
 }
 
-// CreateBucket creates a bucket for the bucket location
+// CreateBucket creates a bucket for the bucket location. The immutability
+// settings of the backup location are applied to the bucket too.
 func CreateBucket(backupLocation *stork_api.BackupLocation) error {
-	accountName := azureblob.AccountName(backupLocation.Location.AzureConfig.StorageAccountName)
-	pipeline, err := getPipeline(backupLocation)
-	if err != nil {
+	if err := backupLocation.Location.AzureConfig.ValidateImmutability(); err != nil {
 		return err
 	}
-	url, err := url.Parse(fmt.Sprintf("https://%s.blob.core.windows.net", accountName))
+	containerURL, err := getContainerURL(backupLocation)
 	if err != nil {
 		return err
 	}
 
-	_, err = azblob.NewServiceURL(*url, pipeline).
-		NewContainerURL(backupLocation.Location.Path).
-		Create(context.Background(), azblob.Metadata{}, azblob.PublicAccessNone)
+	_, err = containerURL.Create(context.Background(), azblob.Metadata{}, azblob.PublicAccessNone)
 	if err != nil {
-		if azblobErr, ok := err.(azblob.StorageError); ok {
-			if azblobErr.ServiceCode() == azblob.ServiceCodeContainerAlreadyExists {
-				return nil
-			}
+		if azblobErr, ok := err.(azblob.StorageError); !ok ||
+			azblobErr.ServiceCode() != azblob.ServiceCodeContainerAlreadyExists {
+			return err
 		}
 	}
-	return err
+	return applyImmutability(backupLocation)
 }
 
-// GetObjLockInfo fetches the object lock configuration of a bucket
+// GetObjLockInfo fetches the immutability policy of the container. Legal
+// holds don't have a retention period so they aren't reported.
 func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockInfo, error) {
-	logrus.Infof("object lock is not supported for azure provider")
-	return &common.ObjLockInfo{}, nil
+	config := backupLocation.Location.AzureConfig
+	objLockInfo := &common.ObjLockInfo{}
+	containerURL, err := getContainerURL(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	props, err := containerURL.GetProperties(context.Background(), azblob.LeaseAccessConditions{})
+	if err != nil {
+		// The container is created when the backup starts
+		if azblobErr, ok := err.(azblob.StorageError); ok &&
+			azblobErr.ServiceCode() == azblob.ServiceCodeContainerNotFound {
+			return objLockInfo, nil
+		}
+		return nil, err
+	}
+	if props.HasImmutabilityPolicy() != "true" {
+		return objLockInfo, nil
+	}
+
+	objLockInfo.LockEnabled = true
+	objLockInfo.LockMode = lockModeGovernance
+	if config.Immutability == nil || config.ValidateImmutability() != nil {
+		// The policy can only be read with the management credentials
+		logrus.Infof("container %v has an immutability policy but the credentials to read it aren't configured",
+			backupLocation.Location.Path)
+		if config.Immutability != nil {
+			objLockInfo.RetentionPeriodDays = int64(config.Immutability.RetentionDays)
+		}
+		return objLockInfo, nil
+	}
+	policy := &immutabilityPolicy{}
+	if err := sendManagementRequest(config, backupLocation.Location.Path, http.MethodGet,
+		"/immutabilityPolicies/default", nil, policy); err != nil {
+		return nil, fmt.Errorf("error getting immutability policy of container %v: %v", backupLocation.Location.Path, err)
+	}
+	if policy.Properties.State == policyStateLocked {
+		objLockInfo.LockMode = lockModeCompliance
+	}
+	objLockInfo.RetentionPeriodDays = int64(policy.Properties.ImmutabilityPeriodSinceCreationInDays)
+	return objLockInfo, nil
+}
+
+// GetPolicyError returns an error explaining why the object couldn't be
+// deleted if it is protected by the immutability policy or a legal hold of
+// the container. nil is returned for other errors.
+func GetPolicyError(backupLocation *stork_api.BackupLocation, bucket *blob.Bucket, err error) error {
+	var storageErr azblob.StorageError
+	if err == nil || !bucket.ErrorAs(err, &storageErr) {
+		return nil
+	}
+	switch storageErr.ServiceCode() {
+	case serviceCodeBlobImmutableDueToPolicy:
+		return fmt.Errorf("backup is protected by the immutability policy of container %v, "+
+			"it can only be deleted once the retention period has expired", backupLocation.Location.Path)
+	case serviceCodeBlobImmutableDueToLegalHold:
+		return fmt.Errorf("backup is protected by a legal hold on container %v, "+
+			"it can only be deleted once the legal hold has been cleared", backupLocation.Location.Path)
+	}
+	return nil
+}
+
+// applyImmutability sets the retention policy and legal hold configured for
+// the backup location on the container
+func applyImmutability(backupLocation *stork_api.BackupLocation) error {
+	config := backupLocation.Location.AzureConfig
+	if config.Immutability == nil {
+		return nil
+	}
+	container := backupLocation.Location.Path
+	if config.Immutability.RetentionDays > 0 {
+		policy := &immutabilityPolicy{
+			Properties: immutabilityPolicyProperties{
+				ImmutabilityPeriodSinceCreationInDays: config.Immutability.RetentionDays,
+			},
+		}
+		if err := sendManagementRequest(config, container, http.MethodPut,
+			"/immutabilityPolicies/default", policy, nil); err != nil {
+			return fmt.Errorf("error setting immutability policy on container %v: %v", container, err)
+		}
+	}
+	if len(config.Immutability.LegalHoldTags) > 0 {
+		hold := &legalHold{Tags: config.Immutability.LegalHoldTags}
+		if err := sendManagementRequest(config, container, http.MethodPost,
+			"/setLegalHold", hold, nil); err != nil {
+			return fmt.Errorf("error setting legal hold on container %v: %v", container, err)
+		}
+	}
+	return nil
+}
+
+// sendManagementRequest sends a request for the container to the storage
+// resource provider. Immutability can't be managed through the blob API.
+func sendManagementRequest(
+	config *stork_api.AzureConfig,
+	container string,
+	method string,
+	path string,
+	body interface{},
+	result interface{},
+) error {
+	authorizer, err := auth.NewClientCredentialsConfig(config.ClientID, config.ClientSecret, config.TenantID).Authorizer()
+	if err != nil {
+		return err
+	}
+	client := autorest.NewClientWithUserAgent("stork")
+	client.Authorizer = authorizer
+
+	decorators := []autorest.PrepareDecorator{
+		autorest.AsContentType("application/json; charset=utf-8"),
+		autorest.WithMethod(method),
+		autorest.WithBaseURL(azurerest.PublicCloud.ResourceManagerEndpoint),
+		autorest.WithPathParameters(
+			"/subscriptions/{subscriptionId}/resourceGroups/{resourceGroupName}/providers/Microsoft.Storage"+
+				"/storageAccounts/{accountName}/blobServices/default/containers/{containerName}"+path,
+			map[string]interface{}{
+				"subscriptionId":    autorest.Encode("path", config.SubscriptionID),
+				"resourceGroupName": autorest.Encode("path", config.ResourceGroup),
+				"accountName":       autorest.Encode("path", config.StorageAccountName),
+				"containerName":     autorest.Encode("path", container),
+			}),
+		autorest.WithQueryParameters(map[string]interface{}{"api-version": storageAPIVersion}),
+	}
+	if body != nil {
+		decorators = append(decorators, autorest.WithJSON(body))
+	}
+	req, err := autorest.Prepare(&http.Request{}, decorators...)
+	if err != nil {
+		return err
+	}
+	resp, err := autorest.SendWithSender(client, req, azurerest.DoRetryWithRegistration(client))
+	if err != nil {
+		return err
+	}
+	responders := []autorest.RespondDecorator{
+		client.ByInspecting(),
+		azurerest.WithErrorUnlessStatusCode(http.StatusOK),
+	}
+	if result != nil {
+		responders = append(responders, autorest.ByUnmarshallingJSON(result))
+	}
+	responders = append(responders, autorest.ByClosing())
+	return autorest.Respond(resp, responders...)
 }
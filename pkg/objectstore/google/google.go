@@ -2,12 +2,13 @@ package google
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"cloud.google.com/go/storage"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/objectstore/common"
-	"github.com/sirupsen/logrus"
 	"gocloud.dev/blob"
 	"gocloud.dev/blob/gcsblob"
 	"gocloud.dev/gcp"
@@ -17,12 +18,30 @@ import (
 	"google.golang.org/api/option"
 )
 
+const (
+	// lockModeCompliance and lockModeGovernance are the S3 object lock modes
+	// matching locked and unlocked retention policies
+	lockModeCompliance = "COMPLIANCE"
+	lockModeGovernance = "GOVERNANCE"
+	// reasonRetentionPolicyNotMet is returned when deleting objects that
+	// are younger than the retention period of the bucket
+	reasonRetentionPolicyNotMet = "retentionPolicyNotMet"
+)
+
 func getConfig(backupLocation *stork_api.BackupLocation) (*jwt.Config, error) {
 	return google.JWTConfigFromJSON(
 		[]byte(backupLocation.Location.GoogleConfig.AccountKey),
 		storage.ScopeFullControl)
 }
 
+func getClient(ctx context.Context, backupLocation *stork_api.BackupLocation) (*storage.Client, error) {
+	conf, err := getConfig(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewClient(ctx, option.WithTokenSource(conf.TokenSource(ctx)))
+}
+
 // GetBucket gets a reference to the bucket for that backup location
 func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	conf, err := getConfig(backupLocation)
@@ -39,31 +58,107 @@ func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	return gcsblob.OpenBucket(context.Background(), client, backupLocation.Location.Path, nil)
 }
 
-// CreateBucket creates a bucket for the bucket location
+// CreateBucket creates a bucket for the bucket location. The KMS key of the
+// backup location is set as the default key of the bucket.
 func CreateBucket(backupLocation *stork_api.BackupLocation) error {
-	conf, err := getConfig(backupLocation)
-	if err != nil {
+	if err := backupLocation.Location.GoogleConfig.Validate(); err != nil {
 		return err
 	}
 	ctx := context.Background()
-	client, err := storage.NewClient(ctx, option.WithTokenSource(conf.TokenSource(ctx)))
+	client, err := getClient(ctx, backupLocation)
 	if err != nil {
 		return err
 	}
-	err = client.Bucket(backupLocation.Location.Path).
-		Create(ctx, backupLocation.Location.GoogleConfig.ProjectID, nil)
+	kmsKeyName := backupLocation.Location.GoogleConfig.KMSKeyName
+	attrs := &storage.BucketAttrs{}
+	if kmsKeyName != "" {
+		attrs.Encryption = &storage.BucketEncryption{DefaultKMSKeyName: kmsKeyName}
+	}
+	bucket := client.Bucket(backupLocation.Location.Path)
+	err = bucket.Create(ctx, backupLocation.Location.GoogleConfig.ProjectID, attrs)
 	if err != nil {
 		if googleErr, ok := err.(*googleapi.Error); ok {
 			if googleErr.Code == http.StatusConflict {
-				return nil
+				return ensureEncryption(ctx, bucket, backupLocation.Location.Path, kmsKeyName)
 			}
 		}
+		if kmsKeyName != "" {
+			return fmt.Errorf("error creating bucket %v encrypted with %v, make sure the Cloud Storage "+
+				"service agent of the project can use the key: %v", backupLocation.Location.Path, kmsKeyName, err)
+		}
 	}
 	return err
 }
 
-// GetObjLockInfo fetches the object lock configuration of a bucket
+// GetObjLockInfo fetches the retention policy of a bucket
 func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockInfo, error) {
-	logrus.Infof("object lock is not supported for google provider")
-	return &common.ObjLockInfo{}, nil
+	ctx := context.Background()
+	client, err := getClient(ctx, backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	objLockInfo := &common.ObjLockInfo{}
+	attrs, err := client.Bucket(backupLocation.Location.Path).Attrs(ctx)
+	if err != nil {
+		// The bucket is created when the backup starts
+		if err == storage.ErrBucketNotExist {
+			return objLockInfo, nil
+		}
+		return nil, err
+	}
+	if attrs.RetentionPolicy == nil || attrs.RetentionPolicy.RetentionPeriod <= 0 {
+		return objLockInfo, nil
+	}
+	objLockInfo.LockEnabled = true
+	objLockInfo.LockMode = lockModeGovernance
+	if attrs.RetentionPolicy.IsLocked {
+		objLockInfo.LockMode = lockModeCompliance
+	}
+	// Round up so that objects aren't expected to expire before they do
+	objLockInfo.RetentionPeriodDays = int64((attrs.RetentionPolicy.RetentionPeriod + 24*time.Hour - 1) / (24 * time.Hour))
+	return objLockInfo, nil
+}
+
+// GetPolicyError returns an error explaining why the object couldn't be
+// deleted if it is protected by the retention policy of the bucket. nil is
+// returned for other errors.
+func GetPolicyError(backupLocation *stork_api.BackupLocation, bucket *blob.Bucket, err error) error {
+	var googleErr *googleapi.Error
+	if err == nil || !bucket.ErrorAs(err, &googleErr) {
+		return nil
+	}
+	for _, item := range googleErr.Errors {
+		if item.Reason == reasonRetentionPolicyNotMet {
+			return fmt.Errorf("backup is protected by the retention policy of bucket %v, "+
+				"it can only be deleted once the retention period has expired", backupLocation.Location.Path)
+		}
+	}
+	return nil
+}
+
+// ensureEncryption makes sure that an existing bucket uses the KMS key as
+// its default key
+func ensureEncryption(ctx context.Context, bucket *storage.BucketHandle, name, kmsKeyName string) error {
+	if kmsKeyName == "" {
+		return nil
+	}
+	attrs, err := bucket.Attrs(ctx)
+	if err != nil {
+		return err
+	}
+	if attrs.Encryption != nil && attrs.Encryption.DefaultKMSKeyName != "" {
+		if attrs.Encryption.DefaultKMSKeyName != kmsKeyName {
+			return fmt.Errorf("bucket %v is encrypted with %v by default but the backup location uses %v, "+
+				"update kmsKeyName in the backup location or the default key of the bucket",
+				name, attrs.Encryption.DefaultKMSKeyName, kmsKeyName)
+		}
+		return nil
+	}
+	_, err = bucket.Update(ctx, storage.BucketAttrsToUpdate{
+		Encryption: &storage.BucketEncryption{DefaultKMSKeyName: kmsKeyName},
+	})
+	if err != nil {
+		return fmt.Errorf("error setting %v as the default key of bucket %v: %v", kmsKeyName, name, err)
+	}
+	return nil
 }
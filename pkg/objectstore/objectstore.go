@@ -4,10 +4,13 @@ import (
 	"fmt"
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore/alibaba"
 	"github.com/libopenstorage/stork/pkg/objectstore/azure"
 	"github.com/libopenstorage/stork/pkg/objectstore/common"
 	"github.com/libopenstorage/stork/pkg/objectstore/google"
 	"github.com/libopenstorage/stork/pkg/objectstore/s3"
+	"github.com/libopenstorage/stork/pkg/objectstore/swift"
+	"github.com/libopenstorage/stork/pkg/secretprovider"
 	"gocloud.dev/blob"
 )
 
@@ -16,6 +19,9 @@ func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 	if backupLocation == nil {
 		return nil, fmt.Errorf("nil backupLocation")
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		return nil, err
+	}
 
 	switch backupLocation.Location.Type {
 	case stork_api.BackupLocationGoogle:
@@ -24,6 +30,10 @@ func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
 		return azure.GetBucket(backupLocation)
 	case stork_api.BackupLocationS3:
 		return s3.GetBucket(backupLocation)
+	case stork_api.BackupLocationSwift:
+		return swift.GetBucket(backupLocation)
+	case stork_api.BackupLocationAlibaba:
+		return alibaba.GetBucket(backupLocation)
 	default:
 		return nil, fmt.Errorf("invalid backupLocation type: %v", backupLocation.Location.Type)
 	}
@@ -34,6 +44,9 @@ func CreateBucket(backupLocation *stork_api.BackupLocation) error {
 	if backupLocation == nil {
 		return fmt.Errorf("nil backupLocation")
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		return err
+	}
 
 	switch backupLocation.Location.Type {
 	case stork_api.BackupLocationGoogle:
@@ -42,6 +55,10 @@ func CreateBucket(backupLocation *stork_api.BackupLocation) error {
 		return azure.CreateBucket(backupLocation)
 	case stork_api.BackupLocationS3:
 		return s3.CreateBucket(backupLocation)
+	case stork_api.BackupLocationSwift:
+		return swift.CreateBucket(backupLocation)
+	case stork_api.BackupLocationAlibaba:
+		return alibaba.CreateBucket(backupLocation)
 	default:
 		return fmt.Errorf("invalid backupLocation type: %v", backupLocation.Location.Type)
 	}
@@ -52,6 +69,9 @@ func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockIn
 	if backupLocation == nil {
 		return nil, fmt.Errorf("nil backupLocation")
 	}
+	if err := secretprovider.UpdateBackupLocation(backupLocation); err != nil {
+		return nil, err
+	}
 	switch backupLocation.Location.Type {
 	case stork_api.BackupLocationGoogle:
 		return google.GetObjLockInfo(backupLocation)
@@ -59,7 +79,28 @@ func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockIn
 		return azure.GetObjLockInfo(backupLocation)
 	case stork_api.BackupLocationS3:
 		return s3.GetObjLockInfo(backupLocation)
+	case stork_api.BackupLocationSwift:
+		return swift.GetObjLockInfo(backupLocation)
+	case stork_api.BackupLocationAlibaba:
+		return alibaba.GetObjLockInfo(backupLocation)
 	default:
 		return nil, fmt.Errorf("invalid backupLocation type: %v", backupLocation.Location.Type)
 	}
 }
+
+// GetPolicyError returns an error explaining why an object couldn't be
+// deleted from the bucket if it is protected by a retention policy or legal
+// hold of the bucket. nil is returned for other errors.
+func GetPolicyError(backupLocation *stork_api.BackupLocation, bucket *blob.Bucket, err error) error {
+	if backupLocation == nil || bucket == nil || err == nil {
+		return nil
+	}
+	switch backupLocation.Location.Type {
+	case stork_api.BackupLocationAzure:
+		return azure.GetPolicyError(backupLocation, bucket, err)
+	case stork_api.BackupLocationGoogle:
+		return google.GetPolicyError(backupLocation, bucket, err)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package swift
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/objectstore/common"
+	"github.com/sirupsen/logrus"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/s3blob"
+)
+
+// Swift is accessed through the S3 API served by the s3api middleware of the
+// Swift proxy
+func getSession(backupLocation *stork_api.BackupLocation) (*session.Session, error) {
+	config := backupLocation.Location.SwiftConfig
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	region := config.Region
+	if region == "" {
+		region = stork_api.DefaultSwiftRegion
+	}
+	return session.NewSession(&aws.Config{
+		Endpoint:         aws.String(config.Endpoint),
+		Credentials:      credentials.NewStaticCredentials(config.AccessKeyID, config.SecretAccessKey, ""),
+		Region:           aws.String(region),
+		DisableSSL:       aws.Bool(config.DisableSSL),
+		S3ForcePathStyle: aws.Bool(true),
+	})
+}
+
+// GetBucket gets a reference to the bucket for that backup location
+func GetBucket(backupLocation *stork_api.BackupLocation) (*blob.Bucket, error) {
+	sess, err := getSession(backupLocation)
+	if err != nil {
+		return nil, err
+	}
+	return s3blob.OpenBucket(context.Background(), sess, backupLocation.Location.Path, nil)
+}
+
+// CreateBucket creates a bucket for the bucket location
+func CreateBucket(backupLocation *stork_api.BackupLocation) error {
+	sess, err := getSession(backupLocation)
+	if err != nil {
+		return err
+	}
+
+	input := &s3.CreateBucketInput{
+		Bucket: &backupLocation.Location.Path,
+	}
+	_, err = s3.New(sess).CreateBucket(input)
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok {
+			// Swift returns BucketAlreadyExists for containers owned by the
+			// same account
+			if awsErr.Code() == s3.ErrCodeBucketAlreadyOwnedByYou ||
+				awsErr.Code() == s3.ErrCodeBucketAlreadyExists {
+				return nil
+			}
+		}
+	}
+	return err
+}
+
+// GetObjLockInfo fetches the object lock configuration of a bucket
+func GetObjLockInfo(backupLocation *stork_api.BackupLocation) (*common.ObjLockInfo, error) {
+	logrus.Infof("object lock is not supported for swift provider")
+	return &common.ObjLockInfo{}, nil
+}
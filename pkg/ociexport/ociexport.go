@@ -0,0 +1,225 @@
+package ociexport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
+	"github.com/libopenstorage/stork/pkg/log"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gocloud.dev/blob"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ConfigMediaType is the media type of the config of the artifact, which
+	// holds the metadata of the backup
+	ConfigMediaType = "application/vnd.libopenstorage.stork.backup.config.v1+json"
+	// ObjectMediaType is the media type of the layers of the artifact. Each
+	// layer is an object from the backup location, titled with its path
+	// relative to the backup. Blobs referenced by the backup are titled with
+	// their key in the backup location.
+	ObjectMediaType = "application/vnd.libopenstorage.stork.backup.object.v1"
+	// DefaultMaxObjectSize is the size above which objects are left out of
+	// the artifact if not configured in the backup
+	DefaultMaxObjectSize = 100 * 1024 * 1024
+)
+
+// backupConfig is stored as the config of the artifact
+type backupConfig struct {
+	Name             string   `json:"name"`
+	Namespace        string   `json:"namespace"`
+	UID              string   `json:"uid"`
+	Namespaces       []string `json:"namespaces"`
+	TriggerTimestamp string   `json:"triggerTimestamp"`
+	BackupPath       string   `json:"backupPath"`
+	// SkippedObjects were larger than the max object size
+	SkippedObjects []string `json:"skippedObjects,omitempty"`
+}
+
+// dockerConfig is the content of a kubernetes.io/dockerconfigjson Secret
+type dockerConfig struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// Export pushes the objects of a completed backup from the bucket to the
+// repository in the spec as an OCI artifact. The objects are pushed as they
+// are stored, so they stay encrypted if the backup location uses encryption.
+// Returns the reference of the artifact including its digest.
+func Export(
+	backup *stork_api.ApplicationBackup,
+	bucket *blob.Bucket,
+	credentials *v1.Secret,
+) (string, error) {
+	spec := backup.Spec.OCIExport
+	if spec == nil || spec.Repository == "" {
+		return "", fmt.Errorf("repository is required to export backup to OCI artifact")
+	}
+	ref := spec.Repository
+	if !hasTag(ref) {
+		ref = ref + ":" + backup.Name
+	}
+	maxObjectSize := spec.MaxObjectSize
+	if maxObjectSize <= 0 {
+		maxObjectSize = DefaultMaxObjectSize
+	}
+
+	ctx := context.TODO()
+	store := content.NewMemoryStore()
+	descriptors := make([]ocispec.Descriptor, 0)
+	config := backupConfig{
+		Name:             backup.Name,
+		Namespace:        backup.Namespace,
+		UID:              string(backup.UID),
+		Namespaces:       backup.Spec.Namespaces,
+		TriggerTimestamp: backup.Status.TriggerTimestamp.String(),
+		BackupPath:       backup.Status.BackupPath,
+	}
+
+	prefix := strings.TrimSuffix(backup.Status.BackupPath, "/") + "/"
+	iterator := bucket.List(&blob.ListOptions{Prefix: prefix})
+	for {
+		object, err := iterator.Next(ctx)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if object.IsDir {
+			continue
+		}
+		name := strings.TrimPrefix(object.Key, prefix)
+		if object.Size > maxObjectSize {
+			log.ApplicationBackupLog(backup).Warnf("Skipping object %v of size %v from OCI artifact", name, object.Size)
+			config.SkippedObjects = append(config.SkippedObjects, name)
+			continue
+		}
+		data, err := bucket.ReadAll(ctx, object.Key)
+		if err != nil {
+			return "", err
+		}
+		descriptors = append(descriptors, store.Add(name, ObjectMediaType, data))
+	}
+	if len(descriptors) == 0 {
+		return "", fmt.Errorf("no objects found in backup location at %v", backup.Status.BackupPath)
+	}
+	// The blobs referenced by the backup are shared with other backups so
+	// they are added with their key in the bucket
+	layout, err := backuplayout.Read(ctx, bucket, backup.Status.BackupPath)
+	if err != nil {
+		return "", err
+	}
+	added := make(map[string]bool)
+	for _, object := range sortedKeys(layout.Blobs) {
+		for _, hash := range layout.Blobs[object] {
+			key := backuplayout.BlobKey(hash)
+			if added[key] {
+				continue
+			}
+			data, err := bucket.ReadAll(ctx, key)
+			if err != nil {
+				return "", err
+			}
+			descriptors = append(descriptors, store.Add(key, ObjectMediaType, data))
+			added[key] = true
+		}
+	}
+
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	configDesc := store.Add("", ConfigMediaType, configBytes)
+
+	resolver, err := getResolver(spec, credentials)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := oras.Push(ctx, resolver, ref, store, descriptors,
+		oras.WithConfig(configDesc),
+		oras.WithManifestAnnotations(map[string]string{
+			ocispec.AnnotationTitle: backup.Name,
+		}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("error pushing backup to %v: %v", ref, err)
+	}
+	return fmt.Sprintf("%v@%v", ref, manifest.Digest), nil
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hasTag returns true if the reference already has a tag or digest
+func hasTag(ref string) bool {
+	if strings.Contains(ref, "@") {
+		return true
+	}
+	lastSlash := strings.LastIndex(ref, "/")
+	return strings.Contains(ref[lastSlash+1:], ":")
+}
+
+func getResolver(spec *stork_api.OCIExportSpec, credentials *v1.Secret) (remotes.Resolver, error) {
+	var config dockerConfig
+	if credentials != nil {
+		data, ok := credentials.Data[v1.DockerConfigJsonKey]
+		if !ok {
+			return nil, fmt.Errorf("secret %v/%v doesn't have %v", credentials.Namespace, credentials.Name, v1.DockerConfigJsonKey)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("error parsing credentials from secret %v/%v: %v", credentials.Namespace, credentials.Name, err)
+		}
+	}
+	creds := func(host string) (string, string, error) {
+		for registry, auth := range config.Auths {
+			registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+			if strings.TrimSuffix(registry, "/") != host {
+				continue
+			}
+			if auth.Username != "" {
+				return auth.Username, auth.Password, nil
+			}
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return "", "", err
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) != 2 {
+				return "", "", fmt.Errorf("invalid auth for registry %v", host)
+			}
+			return parts[0], parts[1], nil
+		}
+		return "", "", nil
+	}
+	plainHTTP := docker.MatchLocalhost
+	if spec.Insecure {
+		plainHTTP = docker.MatchAllHosts
+	}
+	return docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(
+			docker.WithPlainHTTP(plainHTTP),
+			docker.WithAuthorizer(docker.NewDockerAuthorizer(docker.WithAuthCreds(creds))),
+		),
+	}), nil
+}
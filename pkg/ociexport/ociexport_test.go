@@ -0,0 +1,18 @@
+//go:build unittest
+// +build unittest
+
+package ociexport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasTag(t *testing.T) {
+	require.False(t, hasTag("registry.example.com/backups/app"))
+	require.False(t, hasTag("registry.example.com:5000/backups/app"))
+	require.True(t, hasTag("registry.example.com:5000/backups/app:v1"))
+	require.True(t, hasTag("registry.example.com/backups/app@sha256:abcd"))
+	require.True(t, hasTag("app:latest"))
+}
@@ -0,0 +1,82 @@
+package orphanedpv
+
+import (
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	orphanedPVLabels = []string{"pv", "kind", "name", "namespace"}
+
+	orphanedPVSizeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_orphaned_pv_size_bytes",
+		Help: "Size of the PVs left behind by failed restores, clones and migrations",
+	}, orphanedPVLabels)
+	orphanedPVAgeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "stork_orphaned_pv_age_seconds",
+		Help: "Age of the PVs left behind by failed restores, clones and migrations",
+	}, orphanedPVLabels)
+)
+
+// Monitor periodically reports the orphaned PVs in the cluster with metrics,
+// so that operators can be alerted about storage that isn't used anymore and
+// can delete the PVs with storkctl
+type Monitor struct {
+	// Interval at which the orphaned PVs are reported
+	Interval time.Duration
+	// Namespaces the monitor is restricted to. Only the PVs bound to claims
+	// in these namespaces are reported, or all of them if it is empty.
+	Namespaces  []string
+	stopChannel chan os.Signal
+}
+
+// Start starts reporting orphaned PVs in the background
+func (m *Monitor) Start(stopChannel chan os.Signal) {
+	m.stopChannel = stopChannel
+	go m.run()
+}
+
+func (m *Monitor) run() {
+	for {
+		select {
+		case <-time.After(m.Interval):
+			if err := m.report(); err != nil {
+				logrus.Errorf("Error reporting orphaned PVs: %v", err)
+			}
+		case <-m.stopChannel:
+			return
+		}
+	}
+}
+
+func (m *Monitor) report() error {
+	orphaned, err := List(m.Namespaces)
+	if err != nil {
+		return err
+	}
+	// PVs that have been deleted since the last report are removed
+	orphanedPVSizeGauge.Reset()
+	orphanedPVAgeGauge.Reset()
+	for _, o := range orphaned {
+		labels := prometheus.Labels{
+			"pv":        o.PV.Name,
+			"kind":      o.Kind,
+			"name":      o.Name,
+			"namespace": o.Namespace,
+		}
+		orphanedPVSizeGauge.With(labels).Set(float64(o.Size()))
+		orphanedPVAgeGauge.With(labels).Set(o.Age().Seconds())
+	}
+	if len(orphaned) > 0 {
+		logrus.Infof("Found %v orphaned PVs, they can be listed with storkctl audit orphanedpvs", len(orphaned))
+	}
+	return nil
+}
+
+func init() {
+	prometheus.MustRegister(orphanedPVSizeGauge)
+	prometheus.MustRegister(orphanedPVAgeGauge)
+}
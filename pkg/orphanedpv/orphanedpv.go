@@ -0,0 +1,205 @@
+package orphanedpv
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// OrphanedPV is a PV with the Retain reclaim policy that was left behind
+// after its claim was deleted during a failed restore or clone, or after a
+// migrated claim was deleted on the destination
+type OrphanedPV struct {
+	// PV is the persistent volume itself
+	PV *v1.PersistentVolume
+	// Kind of the operation that created the PV
+	Kind string
+	// Name of the operation that created the PV
+	Name string
+	// Namespace of the operation that created the PV
+	Namespace string
+}
+
+// Size returns the capacity of the PV
+func (o *OrphanedPV) Size() int64 {
+	capacity := o.PV.Spec.Capacity[v1.ResourceStorage]
+	return capacity.Value()
+}
+
+// Age returns how long ago the PV was created
+func (o *OrphanedPV) Age() time.Duration {
+	return time.Since(o.PV.CreationTimestamp.Time)
+}
+
+// owner is the operation that created a volume for a claim
+type owner struct {
+	kind      string
+	name      string
+	namespace string
+	claim     types.NamespacedName
+}
+
+// List returns the PVs in the cluster that were orphaned by stork
+// operations, sorted by their age. Only the PVs that were bound to claims in
+// the given namespaces are returned, or all of them if no namespaces are
+// given.
+func List(namespaces []string) ([]*OrphanedPV, error) {
+	pvs, err := core.Instance().GetPersistentVolumes()
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[string]owner)
+	for _, ns := range controllers.NamespacesToList(namespaces) {
+		if err := getFailedOperationOwners(ns, owners); err != nil {
+			return nil, err
+		}
+	}
+
+	orphaned := make([]*OrphanedPV, 0)
+	for i := range pvs.Items {
+		pv := &pvs.Items[i]
+		if pv.Spec.PersistentVolumeReclaimPolicy != v1.PersistentVolumeReclaimRetain ||
+			pv.Status.Phase != v1.VolumeReleased || pv.Spec.ClaimRef == nil {
+			continue
+		}
+		claim := types.NamespacedName{Namespace: pv.Spec.ClaimRef.Namespace, Name: pv.Spec.ClaimRef.Name}
+		if !controllers.IsNamespaceWatched(namespaces, claim.Namespace) {
+			continue
+		}
+		if o, ok := getOwner(pv, owners); ok && o.claim == claim {
+			orphaned = append(orphaned, &OrphanedPV{PV: pv, Kind: o.kind, Name: o.name, Namespace: o.namespace})
+		} else if pv.Annotations[migration.StorkMigrationAnnotation] == "true" {
+			orphaned = append(orphaned, &OrphanedPV{
+				PV:        pv,
+				Kind:      "Migration",
+				Name:      pv.Annotations[migration.StorkMigrationName],
+				Namespace: claim.Namespace,
+			})
+		}
+	}
+	sort.SliceStable(orphaned, func(i, j int) bool {
+		return orphaned[i].PV.CreationTimestamp.Before(&orphaned[j].PV.CreationTimestamp)
+	})
+	return orphaned, nil
+}
+
+// getOwner returns the operation that created the volume of the PV. The
+// volume is matched by the name of the PV or the ID of the volume on the
+// storage, depending on what the driver recorded.
+func getOwner(pv *v1.PersistentVolume, owners map[string]owner) (owner, bool) {
+	for _, id := range getVolumeIDs(pv) {
+		if o, ok := owners[id]; ok {
+			return o, true
+		}
+	}
+	return owner{}, false
+}
+
+// getVolumeIDs returns the name of the PV and the ID of its volume
+func getVolumeIDs(pv *v1.PersistentVolume) []string {
+	ids := []string{pv.Name}
+	switch {
+	case pv.Spec.CSI != nil:
+		ids = append(ids, pv.Spec.CSI.VolumeHandle)
+	case pv.Spec.PortworxVolume != nil:
+		ids = append(ids, pv.Spec.PortworxVolume.VolumeID)
+	case pv.Spec.AWSElasticBlockStore != nil:
+		ids = append(ids, pv.Spec.AWSElasticBlockStore.VolumeID)
+	case pv.Spec.GCEPersistentDisk != nil:
+		ids = append(ids, pv.Spec.GCEPersistentDisk.PDName)
+	case pv.Spec.AzureDisk != nil:
+		ids = append(ids, pv.Spec.AzureDisk.DiskName)
+	}
+	return ids
+}
+
+// getFailedOperationOwners adds the failed or partially successful restores
+// and clones in the namespace that created each of the volumes to owners
+func getFailedOperationOwners(namespace string, owners map[string]owner) error {
+	restores, err := storkops.Instance().ListApplicationRestores(namespace, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, restore := range restores.Items {
+		if restore.Status.Status != stork_api.ApplicationRestoreStatusFailed &&
+			restore.Status.Status != stork_api.ApplicationRestoreStatusPartialSuccess {
+			continue
+		}
+		for _, vInfo := range restore.Status.Volumes {
+			claimNamespace, ok := restore.Spec.NamespaceMapping[vInfo.SourceNamespace]
+			if !ok || vInfo.RestoreVolume == "" {
+				continue
+			}
+			owners[vInfo.RestoreVolume] = owner{
+				kind:      "ApplicationRestore",
+				name:      restore.Name,
+				namespace: restore.Namespace,
+				claim:     types.NamespacedName{Namespace: claimNamespace, Name: vInfo.PersistentVolumeClaim},
+			}
+		}
+	}
+	clones, err := storkops.Instance().ListApplicationClones(namespace)
+	if err != nil {
+		return err
+	}
+	for _, clone := range clones.Items {
+		if clone.Status.Status != stork_api.ApplicationCloneStatusFailed &&
+			clone.Status.Status != stork_api.ApplicationCloneStatusPartialSuccess {
+			continue
+		}
+		for _, vInfo := range clone.Status.Volumes {
+			if vInfo.CloneVolume == "" {
+				continue
+			}
+			owners[vInfo.CloneVolume] = owner{
+				kind:      "ApplicationClone",
+				name:      clone.Name,
+				namespace: clone.Namespace,
+				claim:     types.NamespacedName{Namespace: clone.Spec.DestinationNamespace, Name: vInfo.PersistentVolumeClaim},
+			}
+		}
+	}
+	return nil
+}
+
+// Delete deletes an orphaned PV. If the volume on the storage is deleted too,
+// the reclaim policy of the PV is changed to Delete and the PV controller
+// deletes both the volume and the PV. The deletion is logged so that it can be
+// audited later.
+func Delete(o *OrphanedPV, deleteVolume bool) error {
+	pv, err := core.Instance().GetPersistentVolume(o.PV.Name)
+	if err != nil {
+		return err
+	}
+	// Make sure the PV hasn't been bound again since it was listed
+	if pv.Status.Phase != v1.VolumeReleased {
+		return fmt.Errorf("PV %v is %v and no longer orphaned", pv.Name, pv.Status.Phase)
+	}
+	if deleteVolume {
+		pv.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimDelete
+		if _, err := core.Instance().UpdatePersistentVolume(pv); err != nil {
+			return err
+		}
+	} else if err := core.Instance().DeletePersistentVolume(pv.Name); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if deleteVolume {
+		logrus.Infof("Set PV %v orphaned by %v %v/%v to be deleted along with its volume",
+			pv.Name, o.Kind, o.Namespace, o.Name)
+	} else {
+		logrus.Infof("Deleted PV %v orphaned by %v %v/%v, volume retained",
+			pv.Name, o.Kind, o.Namespace, o.Name)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+//go:build unittest
+// +build unittest
+
+package orphanedpv
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func setupTest(pvs []runtime.Object, storkObjects ...runtime.Object) {
+	kubeClient := fake.NewSimpleClientset(pvs...)
+	core.SetInstance(core.New(kubeClient))
+	storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(storkObjects...), nil))
+}
+
+func newPV(name, claimNamespace, claimName string, age time.Duration) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.Time{Time: time.Now().Add(-age)},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity: v1.ResourceList{
+				v1.ResourceStorage: resource.MustParse("1Gi"),
+			},
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+			ClaimRef: &v1.ObjectReference{
+				Namespace: claimNamespace,
+				Name:      claimName,
+			},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeReleased},
+	}
+}
+
+func newRestore(name string, status stork_api.ApplicationRestoreStatusType, volume string) *stork_api.ApplicationRestore {
+	return &stork_api.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "dest"},
+		Spec: stork_api.ApplicationRestoreSpec{
+			NamespaceMapping: map[string]string{"src": "dest"},
+		},
+		Status: stork_api.ApplicationRestoreStatus{
+			Status: status,
+			Volumes: []*stork_api.ApplicationRestoreVolumeInfo{
+				{
+					PersistentVolumeClaim: "data",
+					SourceNamespace:       "src",
+					RestoreVolume:         volume,
+				},
+			},
+		},
+	}
+}
+
+func TestList(t *testing.T) {
+	// Volume of a failed restore, matched by the name of the PV
+	restored := newPV("restored", "dest", "data", time.Hour)
+	// Volume of a partially successful clone, matched by the volume handle
+	cloned := newPV("pvc-cloned", "clone", "db", 2*time.Hour)
+	cloned.Spec.CSI = &v1.CSIPersistentVolumeSource{VolumeHandle: "clone-volume"}
+	// Volume of a migrated claim that was deleted on the destination
+	migrated := newPV("migrated", "app", "logs", 3*time.Hour)
+	migrated.Annotations = map[string]string{
+		migration.StorkMigrationAnnotation: "true",
+		migration.StorkMigrationName:       "migration",
+	}
+
+	// Volume of a successful restore
+	succeeded := newPV("succeeded", "dest", "data", time.Hour)
+	// Volume that is still bound
+	bound := newPV("bound", "dest", "data", time.Hour)
+	bound.Status.Phase = v1.VolumeBound
+	// Volume that is deleted along with the claim
+	deleted := newPV("deleted", "dest", "data", time.Hour)
+	deleted.Spec.PersistentVolumeReclaimPolicy = v1.PersistentVolumeReclaimDelete
+	// Volume of a failed restore that was bound to another claim since
+	rebound := newPV("rebound", "dest", "other", time.Hour)
+	// Released volume that wasn't created by stork
+	unknown := newPV("unknown", "dest", "data", time.Hour)
+
+	setupTest(
+		[]runtime.Object{restored, cloned, migrated, succeeded, bound, deleted, rebound, unknown},
+		newRestore("failed", stork_api.ApplicationRestoreStatusFailed, "restored"),
+		newRestore("successful", stork_api.ApplicationRestoreStatusSuccessful, "succeeded"),
+		newRestore("bound", stork_api.ApplicationRestoreStatusFailed, "bound"),
+		newRestore("deleted", stork_api.ApplicationRestoreStatusFailed, "deleted"),
+		newRestore("rebound", stork_api.ApplicationRestoreStatusPartialSuccess, "rebound"),
+		&stork_api.ApplicationClone{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "admin"},
+			Spec:       stork_api.ApplicationCloneSpec{DestinationNamespace: "clone"},
+			Status: stork_api.ApplicationCloneStatus{
+				Status: stork_api.ApplicationCloneStatusPartialSuccess,
+				Volumes: []*stork_api.ApplicationCloneVolumeInfo{
+					{PersistentVolumeClaim: "db", CloneVolume: "clone-volume"},
+				},
+			},
+		},
+	)
+
+	orphaned, err := List(nil)
+	require.NoError(t, err)
+	require.Len(t, orphaned, 3)
+	// Oldest PVs first
+	require.Equal(t, "migrated", orphaned[0].PV.Name)
+	require.Equal(t, "Migration", orphaned[0].Kind)
+	require.Equal(t, "migration", orphaned[0].Name)
+	require.Equal(t, "app", orphaned[0].Namespace)
+	require.Equal(t, "pvc-cloned", orphaned[1].PV.Name)
+	require.Equal(t, "ApplicationClone", orphaned[1].Kind)
+	require.Equal(t, "clone", orphaned[1].Name)
+	require.Equal(t, "admin", orphaned[1].Namespace)
+	require.Equal(t, "restored", orphaned[2].PV.Name)
+	require.Equal(t, "ApplicationRestore", orphaned[2].Kind)
+	require.Equal(t, "failed", orphaned[2].Name)
+	require.Equal(t, "dest", orphaned[2].Namespace)
+	require.Equal(t, int64(1<<30), orphaned[2].Size())
+	require.InDelta(t, time.Hour.Seconds(), orphaned[2].Age().Seconds(), 60)
+
+	// Only PVs bound to claims in the namespaces are returned
+	orphaned, err = List([]string{"dest"})
+	require.NoError(t, err)
+	require.Len(t, orphaned, 1)
+	require.Equal(t, "restored", orphaned[0].PV.Name)
+}
+
+func TestDelete(t *testing.T) {
+	bound := newPV("bound", "dest", "data", time.Hour)
+	bound.Status.Phase = v1.VolumeBound
+	setupTest([]runtime.Object{
+		newPV("keep-volume", "dest", "data", time.Hour),
+		newPV("delete-volume", "dest", "data", time.Hour),
+		bound,
+	})
+	orphaned := func(pv *v1.PersistentVolume) *OrphanedPV {
+		return &OrphanedPV{PV: pv, Kind: "ApplicationRestore", Name: "restore", Namespace: "dest"}
+	}
+
+	// The PV is deleted and the volume is retained
+	require.NoError(t, Delete(orphaned(newPV("keep-volume", "dest", "data", time.Hour)), false))
+	_, err := core.Instance().GetPersistentVolume("keep-volume")
+	require.True(t, errors.IsNotFound(err), "Expected PV to be deleted")
+
+	// The PV controller deletes the PV along with the volume
+	require.NoError(t, Delete(orphaned(newPV("delete-volume", "dest", "data", time.Hour)), true))
+	pv, err := core.Instance().GetPersistentVolume("delete-volume")
+	require.NoError(t, err)
+	require.Equal(t, v1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+
+	// PVs that were bound again since they were listed are left alone
+	require.Error(t, Delete(orphaned(newPV("bound", "dest", "data", time.Hour)), false))
+	pv, err = core.Instance().GetPersistentVolume("bound")
+	require.NoError(t, err)
+	require.Equal(t, v1.PersistentVolumeReclaimRetain, pv.Spec.PersistentVolumeReclaimPolicy)
+
+	require.Error(t, Delete(orphaned(newPV("missing", "dest", "data", time.Hour)), false))
+}
@@ -0,0 +1,180 @@
+package pvcbinding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const resyncPeriod = 5 * time.Minute
+
+var (
+	instance     *WaitPool
+	instanceErr  error
+	instanceOnce sync.Once
+)
+
+// WaitPool waits for PVCs to be bound. All the waits are served from one
+// shared informer on the PVCs in the cluster, so waiting for hundreds of PVCs
+// doesn't poll the API server for each of them.
+type WaitPool struct {
+	lock       sync.Mutex
+	store      cache.Store
+	controller cache.Controller
+	// waiters are the channels to close when the PVC with the key is bound
+	waiters map[string][]chan struct{}
+}
+
+// Instance returns the wait pool shared by the whole process. The informer is
+// started the first time it is called.
+func Instance() (*WaitPool, error) {
+	instanceOnce.Do(func() {
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			instanceErr = fmt.Errorf("error getting cluster config: %v", err)
+			return
+		}
+		client, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			instanceErr = fmt.Errorf("error getting client, %v", err)
+			return
+		}
+		pool := NewWaitPool(client)
+		if err := pool.Start(wait.NeverStop); err != nil {
+			instanceErr = err
+			return
+		}
+		instance = pool
+	})
+	return instance, instanceErr
+}
+
+// NewWaitPool returns a wait pool that watches the PVCs with the client. It
+// needs to be started before it is used.
+func NewWaitPool(client kubernetes.Interface) *WaitPool {
+	pool := &WaitPool{
+		waiters: make(map[string][]chan struct{}),
+	}
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return client.CoreV1().PersistentVolumeClaims(v1.NamespaceAll).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return client.CoreV1().PersistentVolumeClaims(v1.NamespaceAll).Watch(context.TODO(), options)
+		},
+	}
+	pool.store, pool.controller = cache.NewInformer(lw, &v1.PersistentVolumeClaim{}, resyncPeriod,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: pool.notify,
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				pool.notify(newObj)
+			},
+		},
+	)
+	return pool
+}
+
+// Start runs the informer until the stop channel is closed and waits for the
+// PVCs to be listed
+func (p *WaitPool) Start(stopCh <-chan struct{}) error {
+	go p.controller.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, p.controller.HasSynced) {
+		return fmt.Errorf("error waiting for PVCs to sync")
+	}
+	return nil
+}
+
+// notify wakes up the waiters for the PVC if it is bound
+func (p *WaitPool) notify(obj interface{}) {
+	pvc, ok := obj.(*v1.PersistentVolumeClaim)
+	if !ok || pvc.Status.Phase != v1.ClaimBound {
+		return
+	}
+	key := pvc.Namespace + "/" + pvc.Name
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, waiter := range p.waiters[key] {
+		close(waiter)
+	}
+	delete(p.waiters, key)
+}
+
+// isBound returns true if the PVC with the key is bound in the cache. It is
+// called with the lock held so that a PVC that gets bound after the check
+// notifies the waiter registered along with the check.
+func (p *WaitPool) isBound(key string) bool {
+	obj, exists, err := p.store.GetByKey(key)
+	if err != nil || !exists {
+		return false
+	}
+	pvc, ok := obj.(*v1.PersistentVolumeClaim)
+	return ok && pvc.Status.Phase == v1.ClaimBound
+}
+
+// Wait blocks until all the PVCs are bound or the context is done. The keys
+// of the PVCs are of the form <namespace>/<name>. The sorted keys of the PVCs
+// that weren't bound are returned along with the error of the context.
+func (p *WaitPool) Wait(ctx context.Context, keys []string) ([]string, error) {
+	pending := make(map[string]chan struct{})
+	p.lock.Lock()
+	for _, key := range keys {
+		if _, ok := pending[key]; ok || p.isBound(key) {
+			continue
+		}
+		waiter := make(chan struct{})
+		p.waiters[key] = append(p.waiters[key], waiter)
+		pending[key] = waiter
+	}
+	p.lock.Unlock()
+
+	for key, waiter := range pending {
+		select {
+		case <-waiter:
+			delete(pending, key)
+		case <-ctx.Done():
+			return p.cancel(pending), ctx.Err()
+		}
+	}
+	return nil, nil
+}
+
+// cancel removes the waiters that are still registered and returns the keys
+// of the PVCs that weren't bound
+func (p *WaitPool) cancel(pending map[string]chan struct{}) []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	unbound := make([]string, 0)
+	for key, waiter := range pending {
+		select {
+		case <-waiter:
+			continue
+		default:
+		}
+		unbound = append(unbound, key)
+		waiters := p.waiters[key]
+		for i := range waiters {
+			if waiters[i] == waiter {
+				waiters = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(waiters) == 0 {
+			delete(p.waiters, key)
+		} else {
+			p.waiters[key] = waiters
+		}
+	}
+	sort.Strings(unbound)
+	return unbound
+}
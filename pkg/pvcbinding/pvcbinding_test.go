@@ -0,0 +1,73 @@
+//go:build unittest
+// +build unittest
+
+package pvcbinding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPVC(name string, phase v1.PersistentVolumeClaimPhase) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+}
+
+func startPool(t *testing.T, client *fake.Clientset) *WaitPool {
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	pool := NewWaitPool(client)
+	require.NoError(t, pool.Start(stopCh))
+	return pool
+}
+
+func TestWaitAlreadyBound(t *testing.T) {
+	client := fake.NewSimpleClientset(newPVC("pvc1", v1.ClaimBound))
+	pool := startPool(t, client)
+
+	unbound, err := pool.Wait(context.TODO(), []string{"ns/pvc1"})
+	require.NoError(t, err)
+	require.Empty(t, unbound)
+}
+
+func TestWaitForBinding(t *testing.T) {
+	client := fake.NewSimpleClientset(newPVC("pvc1", v1.ClaimPending), newPVC("pvc2", v1.ClaimBound))
+	pool := startPool(t, client)
+
+	done := make(chan error)
+	go func() {
+		_, err := pool.Wait(context.TODO(), []string{"ns/pvc1", "ns/pvc2"})
+		done <- err
+	}()
+
+	_, err := client.CoreV1().PersistentVolumeClaims("ns").Update(context.TODO(), newPVC("pvc1", v1.ClaimBound), metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for PVCs to be bound")
+	}
+	require.Empty(t, pool.waiters)
+}
+
+func TestWaitTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset(newPVC("pvc1", v1.ClaimPending), newPVC("pvc2", v1.ClaimBound))
+	pool := startPool(t, client)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	unbound, err := pool.Wait(ctx, []string{"ns/pvc2", "ns/pvc1", "ns/missing"})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, []string{"ns/missing", "ns/pvc1"}, unbound)
+	require.Empty(t, pool.waiters)
+}
@@ -0,0 +1,346 @@
+package pvclock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// LockAnnotation is set on PVCs that are locked by an operation. The
+	// value is the JSON encoded Lock.
+	LockAnnotation = "stork.libopenstorage.org/lock"
+	// TTL is how long a lock is held unless it is extended. Owners extend
+	// their locks while the operation is running, so locks left behind by
+	// operations that didn't release them expire.
+	TTL = 30 * time.Minute
+)
+
+// Operation is the type of operation holding a lock
+type Operation string
+
+const (
+	// OperationSnapshot is used while snapshots of the PVC are taken
+	OperationSnapshot Operation = "snapshot"
+	// OperationBackup is used while the PVC is backed up
+	OperationBackup Operation = "backup"
+	// OperationRestore is used while data is restored to the PVC. Pods
+	// using the PVC aren't scheduled while it is locked for a restore.
+	OperationRestore Operation = "restore"
+	// OperationMigration is used while the PVC is migrated
+	OperationMigration Operation = "migration"
+)
+
+var updateBackoff = wait.Backoff{
+	Duration: 100 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+}
+
+var (
+	clientLock sync.Mutex
+	client     kubernetes.Interface
+)
+
+// SetClient sets the client used to patch the locks on PVCs. The in-cluster
+// client is used if it isn't set.
+func SetClient(c kubernetes.Interface) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	client = c
+}
+
+func getClient() (kubernetes.Interface, error) {
+	clientLock.Lock()
+	defer clientLock.Unlock()
+	if client != nil {
+		return client, nil
+	}
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster config: %v", err)
+	}
+	c, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client: %v", err)
+	}
+	client = c
+	return client, nil
+}
+
+// Lock is held on a PVC by the operation of an owner
+type Lock struct {
+	// OwnerUID is the UID of the object that holds the lock
+	OwnerUID string `json:"ownerUID"`
+	// Owner is the kind, namespace and name of the object that holds the
+	// lock
+	Owner     string      `json:"owner"`
+	Operation Operation   `json:"operation"`
+	Expiry    metav1.Time `json:"expiry"`
+}
+
+// NewLock returns a lock for the operation of the owner
+func NewLock(kind string, owner metav1.Object, operation Operation) Lock {
+	return Lock{
+		OwnerUID:  string(owner.GetUID()),
+		Owner:     fmt.Sprintf("%v %v/%v", kind, owner.GetNamespace(), owner.GetName()),
+		Operation: operation,
+	}
+}
+
+// Expired returns true if the lock is no longer held
+func (l *Lock) Expired() bool {
+	return time.Now().After(l.Expiry.Time)
+}
+
+// ErrLocked is returned when a PVC is locked by another owner
+type ErrLocked struct {
+	Namespace string
+	Name      string
+	Lock      Lock
+}
+
+func (e *ErrLocked) Error() string {
+	return fmt.Sprintf("PVC %v/%v is locked for %v by %v (UID %v) until %v",
+		e.Namespace, e.Name, e.Lock.Operation, e.Lock.Owner, e.Lock.OwnerUID,
+		e.Lock.Expiry.UTC().Format(time.RFC3339))
+}
+
+// Get returns the lock held on the PVC, or nil if it isn't locked. Expired
+// locks are returned too.
+func Get(pvc *v1.PersistentVolumeClaim) (*Lock, error) {
+	value, ok := pvc.Annotations[LockAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	lock := &Lock{}
+	if err := json.Unmarshal([]byte(value), lock); err != nil {
+		return nil, fmt.Errorf("error parsing lock of PVC %v/%v: %v", pvc.Namespace, pvc.Name, err)
+	}
+	return lock, nil
+}
+
+// IsLocked returns true if the PVC is locked for the operation and the lock
+// hasn't expired. Locks that can't be parsed aren't honored.
+func IsLocked(pvc *v1.PersistentVolumeClaim, operation Operation) bool {
+	lock, err := Get(pvc)
+	return err == nil && lock != nil && lock.Operation == operation && !lock.Expired()
+}
+
+// Check returns ErrLocked if the PVC is locked by an owner other than the
+// given one
+func Check(pvc *v1.PersistentVolumeClaim, ownerUID string) error {
+	lock, err := Get(pvc)
+	if err != nil || lock == nil || lock.Expired() || lock.OwnerUID == ownerUID {
+		return nil
+	}
+	return &ErrLocked{Namespace: pvc.Namespace, Name: pvc.Name, Lock: *lock}
+}
+
+// Acquire locks the PVC with the lock. A lock that is already held by the
+// same owner is extended once half of its TTL has passed. Returns ErrLocked
+// if the PVC is locked by another owner.
+func Acquire(name, namespace string, lock Lock) error {
+	return update(name, namespace, func(pvc *v1.PersistentVolumeClaim) (bool, error) {
+		current, err := Get(pvc)
+		if err != nil {
+			// Locks that can't be parsed are replaced
+			current = nil
+		}
+		if current != nil && !current.Expired() {
+			if current.OwnerUID != lock.OwnerUID {
+				return false, &ErrLocked{Namespace: namespace, Name: name, Lock: *current}
+			}
+			if current.Operation == lock.Operation && time.Until(current.Expiry.Time) > TTL/2 {
+				return false, nil
+			}
+		}
+		lock.Expiry = metav1.NewTime(time.Now().Add(TTL))
+		value, err := json.Marshal(&lock)
+		if err != nil {
+			return false, err
+		}
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+		pvc.Annotations[LockAnnotation] = string(value)
+		return true, nil
+	})
+}
+
+// Release removes the lock from the PVC if it is held by the owner. Missing
+// PVCs and locks held by other owners are ignored.
+func Release(name, namespace string, ownerUID string) error {
+	err := update(name, namespace, func(pvc *v1.PersistentVolumeClaim) (bool, error) {
+		lock, err := Get(pvc)
+		if err != nil || lock == nil || lock.OwnerUID != ownerUID {
+			return false, nil
+		}
+		delete(pvc.Annotations, LockAnnotation)
+		return true, nil
+	})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// AcquireAll locks all the PVCs with the lock. PVCs that don't exist are
+// skipped. If one of the PVCs can't be locked, the locks acquired by the call
+// are released and the error is returned.
+func AcquireAll(pvcs []types.NamespacedName, lock Lock) error {
+	acquired := make([]types.NamespacedName, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		if err := Acquire(pvc.Name, pvc.Namespace, lock); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			if releaseErr := ReleaseAll(acquired, lock.OwnerUID); releaseErr != nil {
+				logrus.Warnf("Error releasing locks of %v: %v", lock.Owner, releaseErr)
+			}
+			return err
+		}
+		acquired = append(acquired, pvc)
+	}
+	return nil
+}
+
+// ReleaseAll releases the locks held by the owner on the PVCs. All the PVCs
+// are released even if some of them fail.
+func ReleaseAll(pvcs []types.NamespacedName, ownerUID string) error {
+	var releaseErr error
+	for _, pvc := range pvcs {
+		if err := Release(pvc.Name, pvc.Namespace, ownerUID); err != nil {
+			releaseErr = multierror.Append(releaseErr, err)
+		}
+	}
+	return releaseErr
+}
+
+// Remove removes the lock seen on the PVC regardless of its owner, along with
+// the given annotations. Returns false if the lock on the PVC changed since
+// it was read, in which case nothing is removed.
+func Remove(pvc *v1.PersistentVolumeClaim, annotations ...string) (bool, error) {
+	value, locked := pvc.Annotations[LockAnnotation]
+	ops := []patchOperation{testLock(pvc.Annotations, value, locked)}
+	for _, annotation := range append([]string{LockAnnotation}, annotations...) {
+		if _, ok := pvc.Annotations[annotation]; ok {
+			ops = append(ops, patchOperation{Op: "remove", Path: annotationPath(annotation)})
+		}
+	}
+	if len(ops) == 1 {
+		return true, nil
+	}
+	err := patch(pvc.Name, pvc.Namespace, ops)
+	if errors.IsInvalid(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// patchOperation is an operation of a JSON patch. The value is always set so
+// that tests for missing values are sent with a null value.
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// annotationPath returns the JSON pointer to the annotation
+func annotationPath(annotation string) string {
+	return "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(annotation, "~", "~0"), "/", "~1")
+}
+
+// testLock returns the operation checking that the lock on the PVC still has
+// the value that was read, so that the patch fails if the lock was changed
+// since. Locks that were missing are tested against null, which matches
+// missing values. Empty annotations aren't serialized, so they are tested like
+// missing ones.
+func testLock(annotations map[string]string, value string, locked bool) patchOperation {
+	if len(annotations) == 0 {
+		return patchOperation{Op: "test", Path: "/metadata/annotations"}
+	}
+	if !locked {
+		return patchOperation{Op: "test", Path: annotationPath(LockAnnotation)}
+	}
+	return patchOperation{Op: "test", Path: annotationPath(LockAnnotation), Value: value}
+}
+
+// lockPatch returns the operations changing the lock on the PVC to the one on
+// the updated PVC
+func lockPatch(pvc, updated *v1.PersistentVolumeClaim) []patchOperation {
+	old, wasLocked := pvc.Annotations[LockAnnotation]
+	ops := []patchOperation{testLock(pvc.Annotations, old, wasLocked)}
+	value, locked := updated.Annotations[LockAnnotation]
+	switch {
+	case !locked:
+		ops = append(ops, patchOperation{Op: "remove", Path: annotationPath(LockAnnotation)})
+	case len(pvc.Annotations) == 0:
+		ops = append(ops, patchOperation{Op: "add", Path: "/metadata/annotations", Value: map[string]string{LockAnnotation: value}})
+	default:
+		ops = append(ops, patchOperation{Op: "add", Path: annotationPath(LockAnnotation), Value: value})
+	}
+	return ops
+}
+
+// patch applies the JSON patch to the PVC
+func patch(name, namespace string, ops []patchOperation) error {
+	c, err := getClient()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+	_, err = c.CoreV1().PersistentVolumeClaims(namespace).Patch(context.TODO(), name, types.JSONPatchType, data, metav1.PatchOptions{})
+	return err
+}
+
+// update gets the PVC and patches its lock if modify returns true. The patch
+// only applies if the lock is still the one that was read, so owners racing
+// for the lock don't overwrite each other. It is retried with the latest PVC
+// if the lock changed.
+func update(name, namespace string, modify func(*v1.PersistentVolumeClaim) (bool, error)) error {
+	var updateErr error
+	err := wait.ExponentialBackoff(updateBackoff, func() (bool, error) {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(name, namespace)
+		if err != nil {
+			return false, err
+		}
+		updated := pvc.DeepCopy()
+		modified, err := modify(updated)
+		if err != nil || !modified {
+			return true, err
+		}
+		updateErr = patch(name, namespace, lockPatch(pvc, updated))
+		if updateErr == nil {
+			return true, nil
+		}
+		// The test of the lock fails with Invalid if it was changed
+		if errors.IsInvalid(updateErr) || errors.IsConflict(updateErr) ||
+			errors.IsTooManyRequests(updateErr) || errors.IsServerTimeout(updateErr) {
+			return false, nil
+		}
+		return false, updateErr
+	})
+	if err == wait.ErrWaitTimeout {
+		return updateErr
+	}
+	return err
+}
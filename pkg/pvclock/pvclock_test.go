@@ -0,0 +1,198 @@
+//go:build unittest
+// +build unittest
+
+package pvclock
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func owner(name string) metav1.Object {
+	return &metav1.ObjectMeta{Name: name, Namespace: "ns", UID: types.UID(name + "-uid")}
+}
+
+// setupClients sets the fake client used to get and patch the PVCs. Patches
+// that don't apply fail with Invalid like they do on the API server.
+func setupClients(objects ...runtime.Object) *fakeclient.Clientset {
+	client := fakeclient.NewSimpleClientset(objects...)
+	client.PrependReactor("patch", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		_, obj, err := k8stesting.ObjectReaction(client.Tracker())(action)
+		if err != nil && !errors.IsNotFound(err) {
+			patch := action.(k8stesting.PatchAction)
+			return true, nil, errors.NewInvalid(schema.GroupKind{Kind: "PersistentVolumeClaim"}, patch.GetName(), nil)
+		}
+		return true, obj, err
+	})
+	core.SetInstance(core.New(client))
+	SetClient(client)
+	return client
+}
+
+func getPVC(t *testing.T) *v1.PersistentVolumeClaim {
+	pvc, err := core.Instance().GetPersistentVolumeClaim("pvc", "ns")
+	require.NoError(t, err)
+	return pvc
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	setupClients(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "ns"},
+	})
+
+	backup := NewLock("ApplicationBackup", owner("backup"), OperationBackup)
+	require.NoError(t, Acquire("pvc", "ns", backup))
+	lock, err := Get(getPVC(t))
+	require.NoError(t, err)
+	require.Equal(t, "backup-uid", lock.OwnerUID)
+	require.Equal(t, "ApplicationBackup ns/backup", lock.Owner)
+	require.Equal(t, OperationBackup, lock.Operation)
+	require.False(t, lock.Expired())
+	require.False(t, IsLocked(getPVC(t), OperationRestore))
+
+	// The same owner can acquire the lock again
+	require.NoError(t, Acquire("pvc", "ns", backup))
+
+	restore := NewLock("VolumeSnapshotRestore", owner("restore"), OperationRestore)
+	err = Acquire("pvc", "ns", restore)
+	require.Error(t, err)
+	locked, ok := err.(*ErrLocked)
+	require.True(t, ok)
+	require.Equal(t, "backup-uid", locked.Lock.OwnerUID)
+	require.Contains(t, err.Error(), "PVC ns/pvc is locked for backup by ApplicationBackup ns/backup")
+	require.Error(t, Check(getPVC(t), "restore-uid"))
+	require.NoError(t, Check(getPVC(t), "backup-uid"))
+
+	// Only the owner releases the lock
+	require.NoError(t, Release("pvc", "ns", "restore-uid"))
+	require.Contains(t, getPVC(t).Annotations, LockAnnotation)
+	require.NoError(t, Release("pvc", "ns", "backup-uid"))
+	require.NotContains(t, getPVC(t).Annotations, LockAnnotation)
+	require.NoError(t, Release("missing", "ns", "backup-uid"))
+
+	require.NoError(t, Acquire("pvc", "ns", restore))
+	require.True(t, IsLocked(getPVC(t), OperationRestore))
+}
+
+func TestAcquireExpired(t *testing.T) {
+	expired := NewLock("Migration", owner("migration"), OperationMigration)
+	expired.Expiry = metav1.NewTime(time.Now().Add(-time.Minute))
+	value, err := json.Marshal(&expired)
+	require.NoError(t, err)
+	setupClients(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pvc",
+			Namespace:   "ns",
+			Annotations: map[string]string{LockAnnotation: string(value)},
+		},
+	})
+
+	require.False(t, IsLocked(getPVC(t), OperationMigration))
+	require.NoError(t, Check(getPVC(t), "backup-uid"))
+	require.NoError(t, Acquire("pvc", "ns", NewLock("ApplicationBackup", owner("backup"), OperationBackup)))
+	lock, err := Get(getPVC(t))
+	require.NoError(t, err)
+	require.Equal(t, "backup-uid", lock.OwnerUID)
+	require.False(t, lock.Expired())
+}
+
+func TestAcquireAll(t *testing.T) {
+	setupClients(
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "ns"}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc2", Namespace: "ns"}},
+	)
+	pvcs := []types.NamespacedName{
+		{Namespace: "ns", Name: "pvc1"},
+		{Namespace: "ns", Name: "missing"},
+		{Namespace: "ns", Name: "pvc2"},
+	}
+	require.NoError(t, Acquire("pvc2", "ns", NewLock("Migration", owner("migration"), OperationMigration)))
+
+	// pvc1 is released again since pvc2 is locked by the migration
+	err := AcquireAll(pvcs, NewLock("ApplicationBackup", owner("backup"), OperationBackup))
+	require.Error(t, err)
+	require.IsType(t, &ErrLocked{}, err)
+	pvc, err := core.Instance().GetPersistentVolumeClaim("pvc1", "ns")
+	require.NoError(t, err)
+	require.NotContains(t, pvc.Annotations, LockAnnotation)
+
+	require.NoError(t, ReleaseAll(pvcs, "migration-uid"))
+	require.NoError(t, AcquireAll(pvcs, NewLock("ApplicationBackup", owner("backup"), OperationBackup)))
+	for _, name := range []string{"pvc1", "pvc2"} {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(name, "ns")
+		require.NoError(t, err)
+		require.True(t, IsLocked(pvc, OperationBackup))
+	}
+}
+
+func TestAcquireLockChanged(t *testing.T) {
+	client := setupClients(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "ns"},
+	})
+	migration := NewLock("Migration", owner("migration"), OperationMigration)
+	migration.Expiry = metav1.NewTime(time.Now().Add(TTL))
+	value, err := json.Marshal(&migration)
+	require.NoError(t, err)
+	changed := false
+	client.PrependReactor("patch", "persistentvolumeclaims", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if !changed {
+			changed = true
+			// The migration locks the PVC after the backup read it
+			require.NoError(t, client.Tracker().Update(v1.SchemeGroupVersion.WithResource("persistentvolumeclaims"), &v1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "pvc",
+					Namespace:   "ns",
+					Annotations: map[string]string{LockAnnotation: string(value)},
+				},
+			}, "ns"))
+		}
+		return false, nil, nil
+	})
+
+	err = Acquire("pvc", "ns", NewLock("ApplicationBackup", owner("backup"), OperationBackup))
+	require.Error(t, err, "Expected backup to see the lock of the migration")
+	require.IsType(t, &ErrLocked{}, err)
+	lock, err := Get(getPVC(t))
+	require.NoError(t, err)
+	require.Equal(t, "migration-uid", lock.OwnerUID, "Expected lock of migration to be kept")
+}
+
+func TestRemove(t *testing.T) {
+	setupClients(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "pvc",
+			Namespace:   "ns",
+			Annotations: map[string]string{"legacy": "true", "other": "true"},
+		},
+	})
+	require.NoError(t, Acquire("pvc", "ns", NewLock("ApplicationBackup", owner("backup"), OperationBackup)))
+	stale := getPVC(t)
+
+	// The lock is replaced after the PVC was read
+	require.NoError(t, Release("pvc", "ns", "backup-uid"))
+	require.NoError(t, Acquire("pvc", "ns", NewLock("VolumeSnapshotRestore", owner("restore"), OperationRestore)))
+	removed, err := Remove(stale, "legacy")
+	require.NoError(t, err)
+	require.False(t, removed, "Expected lock that changed to be kept")
+	require.True(t, IsLocked(getPVC(t), OperationRestore))
+	require.Contains(t, getPVC(t).Annotations, "legacy")
+
+	removed, err = Remove(getPVC(t), "legacy")
+	require.NoError(t, err)
+	require.True(t, removed)
+	require.NotContains(t, getPVC(t).Annotations, LockAnnotation)
+	require.NotContains(t, getPVC(t).Annotations, "legacy")
+	require.Contains(t, getPVC(t).Annotations, "other")
+}
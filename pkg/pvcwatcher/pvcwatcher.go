@@ -4,12 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	"github.com/libopenstorage/stork/drivers/volume"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
@@ -26,6 +26,8 @@ import (
 )
 
 const (
+	pvcWatcherControllerName = "pvc-watcher"
+
 	annotationPrefix                       = "stork.libopenstorage.org/"
 	snapshotSchedulePolicyAnnotationPrefix = "snapshotschedule." + annotationPrefix
 	scheduleCreatedAnnotation              = annotationPrefix + "snapshot-schedule-created"
@@ -56,7 +58,7 @@ type policyInfo struct {
 
 // Start Starts the controller to watch updates on PVCs
 func (p *PVCWatcher) Start(mgr manager.Manager) error {
-	return controllers.RegisterTo(mgr, "pvc-watcher", p, &corev1.PersistentVolumeClaim{})
+	return controllers.RegisterTo(mgr, pvcWatcherControllerName, p, &corev1.PersistentVolumeClaim{})
 }
 
 // Reconcile handles snapshot schedule updates for persistent volume claims.
@@ -74,14 +76,14 @@ func (p *PVCWatcher) Reconcile(ctx context.Context, request reconcile.Request) (
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(pvcWatcherControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if err = p.handleSnapshotScheduleUpdates(pvc); err != nil {
-		return reconcile.Result{RequeueAfter: 2 * time.Second}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(pvcWatcherControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(pvcWatcherControllerName, controllers.DefaultRequeue)}, nil
 }
 
 func getPoliciesFromMap(options map[string]string, scheduleNamePrefix string) (map[string]*policyInfo, error) {
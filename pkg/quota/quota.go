@@ -0,0 +1,305 @@
+package quota
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/inflect"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	storageClassSuffix = ".storageclass.storage.k8s.io/"
+	// storageClassAnnotation is the beta annotation used for the storage
+	// class of PVCs before the storageClassName field
+	storageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+)
+
+// workloadReplicasPath is the path of the number of pods run by the
+// workloads with a pod template. The number of pods of DaemonSets depends on
+// the nodes so they aren't counted.
+var workloadReplicasPath = map[string][]string{
+	"Deployment":            {"spec", "replicas"},
+	"ReplicaSet":            {"spec", "replicas"},
+	"StatefulSet":           {"spec", "replicas"},
+	"ReplicationController": {"spec", "replicas"},
+	"DeploymentConfig":      {"spec", "replicas"},
+	"Job":                   {"spec", "parallelism"},
+}
+
+// Requirements are the quota resources used by objects, keyed by their
+// namespace
+type Requirements map[string]v1.ResourceList
+
+func (r Requirements) add(namespace string, name v1.ResourceName, quantity resource.Quantity) {
+	if r[namespace] == nil {
+		r[namespace] = make(v1.ResourceList)
+	}
+	total := r[namespace][name]
+	total.Add(quantity)
+	r[namespace][name] = total
+}
+
+func (r Requirements) addCount(namespace string, name v1.ResourceName, count int64) {
+	r.add(namespace, name, *resource.NewQuantity(count, resource.DecimalSI))
+}
+
+// GetRequirements returns the quota resources that are used once the objects
+// are created. Only the resources tracked by ResourceQuotas are returned.
+func GetRequirements(objects []runtime.Unstructured) (Requirements, error) {
+	requirements := make(Requirements)
+	ruleset := inflect.NewDefaultRuleset()
+	ruleset.AddPlural("quota", "quotas")
+	ruleset.AddPlural("prometheus", "prometheuses")
+	ruleset.AddPlural("mongodbcommunity", "mongodbcommunity")
+	for _, o := range objects {
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return nil, err
+		}
+		namespace := metadata.GetNamespace()
+		if namespace == "" {
+			continue
+		}
+		gvk := o.GetObjectKind().GroupVersionKind()
+		countName := "count/" + ruleset.Pluralize(strings.ToLower(gvk.Kind))
+		if gvk.Group != "" {
+			countName += "." + gvk.Group
+		}
+		requirements.addCount(namespace, v1.ResourceName(countName), 1)
+
+		switch gvk.Kind {
+		case "PersistentVolumeClaim":
+			var pvc v1.PersistentVolumeClaim
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
+				return nil, fmt.Errorf("error converting PVC %v/%v: %v", namespace, metadata.GetName(), err)
+			}
+			addPVC(requirements, &pvc)
+		case "Pod":
+			var pod v1.Pod
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pod); err != nil {
+				return nil, fmt.Errorf("error converting pod %v/%v: %v", namespace, metadata.GetName(), err)
+			}
+			addPods(requirements, namespace, &pod.Spec, 1)
+		case "Service":
+			var service v1.Service
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &service); err != nil {
+				return nil, fmt.Errorf("error converting service %v/%v: %v", namespace, metadata.GetName(), err)
+			}
+			addService(requirements, &service)
+		case "ConfigMap":
+			requirements.addCount(namespace, v1.ResourceConfigMaps, 1)
+		case "Secret":
+			requirements.addCount(namespace, v1.ResourceSecrets, 1)
+		default:
+			replicasPath, ok := workloadReplicasPath[gvk.Kind]
+			if !ok {
+				continue
+			}
+			if err := addWorkload(requirements, o, replicasPath); err != nil {
+				return nil, fmt.Errorf("error getting pods of %v %v/%v: %v", gvk.Kind, namespace, metadata.GetName(), err)
+			}
+		}
+	}
+	return requirements, nil
+}
+
+func addPVC(requirements Requirements, pvc *v1.PersistentVolumeClaim) {
+	storage := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	requirements.addCount(pvc.Namespace, v1.ResourcePersistentVolumeClaims, 1)
+	requirements.add(pvc.Namespace, v1.ResourceRequestsStorage, storage)
+
+	storageClass := pvc.Annotations[storageClassAnnotation]
+	if pvc.Spec.StorageClassName != nil {
+		storageClass = *pvc.Spec.StorageClassName
+	}
+	if storageClass != "" {
+		prefix := storageClass + storageClassSuffix
+		requirements.addCount(pvc.Namespace, v1.ResourceName(prefix+string(v1.ResourcePersistentVolumeClaims)), 1)
+		requirements.add(pvc.Namespace, v1.ResourceName(prefix+string(v1.ResourceRequestsStorage)), storage)
+	}
+}
+
+func addService(requirements Requirements, service *v1.Service) {
+	requirements.addCount(service.Namespace, v1.ResourceServices, 1)
+	switch service.Spec.Type {
+	case v1.ServiceTypeLoadBalancer:
+		requirements.addCount(service.Namespace, v1.ResourceServicesLoadBalancers, 1)
+		requirements.addCount(service.Namespace, v1.ResourceServicesNodePorts, int64(len(service.Spec.Ports)))
+	case v1.ServiceTypeNodePort:
+		requirements.addCount(service.Namespace, v1.ResourceServicesNodePorts, int64(len(service.Spec.Ports)))
+	}
+}
+
+func addWorkload(requirements Requirements, o runtime.Unstructured, replicasPath []string) error {
+	content := o.UnstructuredContent()
+	replicas, found, err := unstructured.NestedInt64(content, replicasPath...)
+	if err != nil {
+		return err
+	}
+	if !found {
+		replicas = 1
+	}
+	if replicas <= 0 {
+		return nil
+	}
+	podSpec, found, err := unstructured.NestedMap(content, "spec", "template", "spec")
+	if err != nil || !found {
+		return err
+	}
+	var spec v1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpec, &spec); err != nil {
+		return err
+	}
+	metadata, err := meta.Accessor(o)
+	if err != nil {
+		return err
+	}
+	addPods(requirements, metadata.GetNamespace(), &spec, replicas)
+	return nil
+}
+
+// addPods adds the resources used by the given number of pods with the spec.
+// Like the scheduler, the resources of a pod are the larger of the sum of its
+// containers and of each of its init containers.
+func addPods(requirements Requirements, namespace string, spec *v1.PodSpec, count int64) {
+	requests := make(v1.ResourceList)
+	limits := make(v1.ResourceList)
+	for _, container := range spec.Containers {
+		addResources(requests, container.Resources.Requests)
+		addResources(limits, container.Resources.Limits)
+	}
+	for _, container := range spec.InitContainers {
+		maxResources(requests, container.Resources.Requests)
+		maxResources(limits, container.Resources.Limits)
+	}
+
+	requirements.addCount(namespace, v1.ResourcePods, count)
+	for name, quantity := range requests {
+		total := multiply(quantity, count)
+		requirements.add(namespace, v1.ResourceName("requests."+string(name)), total)
+		// cpu and memory are short for their requests in quotas
+		if name == v1.ResourceCPU || name == v1.ResourceMemory {
+			requirements.add(namespace, name, total)
+		}
+	}
+	for name, quantity := range limits {
+		requirements.add(namespace, v1.ResourceName("limits."+string(name)), multiply(quantity, count))
+	}
+}
+
+func addResources(total v1.ResourceList, resources v1.ResourceList) {
+	for name, quantity := range resources {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+func maxResources(total v1.ResourceList, resources v1.ResourceList) {
+	for name, quantity := range resources {
+		if current, ok := total[name]; !ok || quantity.Cmp(current) > 0 {
+			total[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+func multiply(quantity resource.Quantity, count int64) resource.Quantity {
+	return *resource.NewMilliQuantity(quantity.MilliValue()*count, quantity.Format)
+}
+
+// Delta is a resource of a ResourceQuota that doesn't have enough headroom
+// for the objects to be created
+type Delta struct {
+	Namespace string
+	Quota     string
+	Resource  v1.ResourceName
+	Hard      resource.Quantity
+	Used      resource.Quantity
+	Required  resource.Quantity
+}
+
+// Needed returns the hard limit needed for the objects to fit in the quota
+func (d *Delta) Needed() resource.Quantity {
+	needed := d.Used.DeepCopy()
+	needed.Add(d.Required)
+	return needed
+}
+
+// Missing returns by how much the hard limit of the quota needs to be raised
+func (d *Delta) Missing() resource.Quantity {
+	missing := d.Needed()
+	missing.Sub(d.Hard)
+	return missing
+}
+
+func (d *Delta) String() string {
+	missing := d.Missing()
+	needed := d.Needed()
+	return fmt.Sprintf("%v/%v: %v needs to be raised by %v to %v (used %v, required %v, hard %v)",
+		d.Namespace, d.Quota, d.Resource, missing.String(), needed.String(),
+		d.Used.String(), d.Required.String(), d.Hard.String())
+}
+
+// Check returns the resources of the quotas that don't have enough headroom
+// for the requirements, sorted by namespace, quota and resource. Quotas with
+// scopes only apply to some of the pods, so they aren't checked.
+func Check(quotas []v1.ResourceQuota, requirements Requirements) []Delta {
+	deltas := make([]Delta, 0)
+	for _, quota := range quotas {
+		if len(quota.Spec.Scopes) != 0 || quota.Spec.ScopeSelector != nil {
+			continue
+		}
+		required, ok := requirements[quota.Namespace]
+		if !ok {
+			continue
+		}
+		for name, hard := range quota.Spec.Hard {
+			requiredQuantity, ok := required[name]
+			if !ok || requiredQuantity.IsZero() {
+				continue
+			}
+			delta := Delta{
+				Namespace: quota.Namespace,
+				Quota:     quota.Name,
+				Resource:  name,
+				Hard:      hard,
+				Used:      quota.Status.Used[name],
+				Required:  requiredQuantity,
+			}
+			needed := delta.Needed()
+			if needed.Cmp(hard) > 0 {
+				deltas = append(deltas, delta)
+			}
+		}
+	}
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Namespace != deltas[j].Namespace {
+			return deltas[i].Namespace < deltas[j].Namespace
+		}
+		if deltas[i].Quota != deltas[j].Quota {
+			return deltas[i].Quota < deltas[j].Quota
+		}
+		return deltas[i].Resource < deltas[j].Resource
+	})
+	return deltas
+}
+
+// Adjust raises the hard limits of the quota to what is needed by the deltas
+// for it. Returns true if the quota was changed.
+func Adjust(quota *v1.ResourceQuota, deltas []Delta) bool {
+	adjusted := false
+	for i := range deltas {
+		if deltas[i].Namespace != quota.Namespace || deltas[i].Quota != quota.Name {
+			continue
+		}
+		quota.Spec.Hard[deltas[i].Resource] = deltas[i].Needed()
+		adjusted = true
+	}
+	return adjusted
+}
@@ -0,0 +1,162 @@
+//go:build unittest
+// +build unittest
+
+package quota
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func toUnstructured(t *testing.T, apiVersion, kind string, obj interface{}) runtime.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	return u
+}
+
+func container(cpu, memory string) v1.Container {
+	return v1.Container{
+		Name: "app",
+		Resources: v1.ResourceRequirements{
+			Requests: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse(cpu),
+				v1.ResourceMemory: resource.MustParse(memory),
+			},
+			Limits: v1.ResourceList{
+				v1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func requireQuantity(t *testing.T, expected string, list v1.ResourceList, name v1.ResourceName) {
+	quantity, ok := list[name]
+	require.True(t, ok, "missing %v", name)
+	expectedQuantity := resource.MustParse(expected)
+	require.Equal(t, 0, expectedQuantity.Cmp(quantity), "%v is %v", name, quantity.String())
+}
+
+func TestGetRequirements(t *testing.T) {
+	storageClass := "fast"
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "ns"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+	}
+	deployment := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "app", "namespace": "ns"},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+		},
+	}
+	podSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&v1.PodSpec{
+		Containers:     []v1.Container{container("100m", "128Mi"), container("200m", "64Mi")},
+		InitContainers: []v1.Container{container("500m", "32Mi")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedMap(deployment, podSpec, "spec", "template", "spec"))
+	deploymentObject := &unstructured.Unstructured{Object: deployment}
+	deploymentObject.SetAPIVersion("apps/v1")
+	deploymentObject.SetKind("Deployment")
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "ns"},
+		Spec: v1.ServiceSpec{
+			Type:  v1.ServiceTypeLoadBalancer,
+			Ports: []v1.ServicePort{{Port: 80}, {Port: 443}},
+		},
+	}
+	namespace := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}}
+
+	requirements, err := GetRequirements([]runtime.Unstructured{
+		toUnstructured(t, "v1", "PersistentVolumeClaim", pvc),
+		deploymentObject,
+		toUnstructured(t, "v1", "Service", service),
+		toUnstructured(t, "v1", "Namespace", namespace),
+	})
+	require.NoError(t, err)
+	require.Len(t, requirements, 1)
+	required := requirements["ns"]
+	requireQuantity(t, "1", required, v1.ResourcePersistentVolumeClaims)
+	requireQuantity(t, "10Gi", required, v1.ResourceRequestsStorage)
+	requireQuantity(t, "10Gi", required, "fast.storageclass.storage.k8s.io/requests.storage")
+	requireQuantity(t, "1", required, "count/persistentvolumeclaims")
+	requireQuantity(t, "1", required, "count/deployments.apps")
+	requireQuantity(t, "3", required, v1.ResourcePods)
+	// The init container needs more CPU than the containers together
+	requireQuantity(t, "1500m", required, v1.ResourceRequestsCPU)
+	requireQuantity(t, "1500m", required, v1.ResourceCPU)
+	requireQuantity(t, "576Mi", required, v1.ResourceRequestsMemory)
+	requireQuantity(t, "576Mi", required, v1.ResourceLimitsMemory)
+	requireQuantity(t, "1", required, v1.ResourceServices)
+	requireQuantity(t, "1", required, v1.ResourceServicesLoadBalancers)
+	requireQuantity(t, "2", required, v1.ResourceServicesNodePorts)
+}
+
+func TestCheckAndAdjust(t *testing.T) {
+	quotas := []v1.ResourceQuota{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "ns"},
+			Spec: v1.ResourceQuotaSpec{
+				Hard: v1.ResourceList{
+					v1.ResourceRequestsStorage:        resource.MustParse("20Gi"),
+					v1.ResourcePersistentVolumeClaims: resource.MustParse("5"),
+					v1.ResourcePods:                   resource.MustParse("10"),
+				},
+			},
+			Status: v1.ResourceQuotaStatus{
+				Used: v1.ResourceList{
+					v1.ResourceRequestsStorage:        resource.MustParse("15Gi"),
+					v1.ResourcePersistentVolumeClaims: resource.MustParse("2"),
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "besteffort", Namespace: "ns"},
+			Spec: v1.ResourceQuotaSpec{
+				Hard:   v1.ResourceList{v1.ResourcePods: resource.MustParse("0")},
+				Scopes: []v1.ResourceQuotaScope{v1.ResourceQuotaScopeBestEffort},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+			Spec: v1.ResourceQuotaSpec{
+				Hard: v1.ResourceList{v1.ResourcePods: resource.MustParse("0")},
+			},
+		},
+	}
+	requirements := Requirements{
+		"ns": v1.ResourceList{
+			v1.ResourceRequestsStorage:        resource.MustParse("10Gi"),
+			v1.ResourcePersistentVolumeClaims: resource.MustParse("1"),
+			v1.ResourcePods:                   resource.MustParse("3"),
+		},
+	}
+
+	deltas := Check(quotas, requirements)
+	require.Len(t, deltas, 1)
+	require.Equal(t, "compute", deltas[0].Quota)
+	require.Equal(t, v1.ResourceRequestsStorage, deltas[0].Resource)
+	missing := deltas[0].Missing()
+	require.Equal(t, "5Gi", missing.String())
+	require.Equal(t, "ns/compute: requests.storage needs to be raised by 5Gi to 25Gi (used 15Gi, required 10Gi, hard 20Gi)",
+		deltas[0].String())
+
+	require.False(t, Adjust(&quotas[2], deltas))
+	require.True(t, Adjust(&quotas[0], deltas))
+	hard := quotas[0].Spec.Hard[v1.ResourceRequestsStorage]
+	require.Equal(t, "25Gi", hard.String())
+	require.Empty(t, Check(quotas, requirements))
+}
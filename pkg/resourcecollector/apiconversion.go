@@ -0,0 +1,393 @@
+package resourcecollector
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// apiVersionStep is a version of a resource in an upgrade path
+type apiVersionStep struct {
+	gvk schema.GroupVersionKind
+	// convert converts an object from the previous step in the path to this
+	// one. nil if only the apiVersion needs to be updated.
+	convert func(object *unstructured.Unstructured) error
+}
+
+// apiUpgradePaths are the versions of resources that have been deprecated,
+// ordered from the oldest to the newest version
+var apiUpgradePaths = [][]apiVersionStep{
+	{
+		{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Ingress"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}, convert: convertIngressToV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "IngressClass"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "IngressClass"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "NetworkPolicy"}},
+		{gvk: schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "NetworkPolicy"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "batch", Version: "v2alpha1", Kind: "CronJob"}},
+		{gvk: schema.GroupVersionKind{Group: "batch", Version: "v1beta1", Kind: "CronJob"}},
+		{gvk: schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "HorizontalPodAutoscaler"}},
+		{gvk: schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}, convert: convertHPAFromV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "autoscaling", Version: "v2beta1", Kind: "HorizontalPodAutoscaler"}},
+		{gvk: schema.GroupVersionKind{Group: "autoscaling", Version: "v2beta2", Kind: "HorizontalPodAutoscaler"}, convert: convertHPAFromV2beta1},
+		{gvk: schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodDisruptionBudget"}},
+		{gvk: schema.GroupVersionKind{Group: "policy", Version: "v1", Kind: "PodDisruptionBudget"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "Deployment"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "Deployment"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "Deployment"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, convert: convertWorkloadToAppsV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "DaemonSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "DaemonSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "DaemonSet"}, convert: convertWorkloadToAppsV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "extensions", Version: "v1beta1", Kind: "ReplicaSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "ReplicaSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}, convert: convertWorkloadToAppsV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta1", Kind: "StatefulSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1beta2", Kind: "StatefulSet"}},
+		{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "StatefulSet"}, convert: convertWorkloadToAppsV1},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1alpha1", Kind: "PriorityClass"}},
+		{gvk: schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1beta1", Kind: "PriorityClass"}},
+		{gvk: schema.GroupVersionKind{Group: "scheduling.k8s.io", Version: "v1", Kind: "PriorityClass"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1beta1", Kind: "StorageClass"}},
+		{gvk: schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "StorageClass"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1beta1", Kind: "CSIDriver"}},
+		{gvk: schema.GroupVersionKind{Group: "storage.k8s.io", Version: "v1", Kind: "CSIDriver"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "Role"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "Role"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "Role"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "RoleBinding"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "RoleBinding"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "RoleBinding"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "ClusterRole"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRole"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}},
+	},
+	{
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1alpha1", Kind: "ClusterRoleBinding"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1beta1", Kind: "ClusterRoleBinding"}},
+		{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"}},
+	},
+}
+
+// removedAPIs are resources that have been removed without a replacement
+var removedAPIs = map[schema.GroupKind]string{
+	{Group: "extensions", Kind: "PodSecurityPolicy"}: "PodSecurityPolicy was removed in Kubernetes v1.25, use Pod Security Admission instead",
+	{Group: "policy", Kind: "PodSecurityPolicy"}:     "PodSecurityPolicy was removed in Kubernetes v1.25, use Pod Security Admission instead",
+}
+
+type apiUpgradePathIndex struct {
+	path  []apiVersionStep
+	index int
+}
+
+var apiUpgradePathsByGVK = make(map[schema.GroupVersionKind]apiUpgradePathIndex)
+
+func init() {
+	for _, path := range apiUpgradePaths {
+		// The newest version doesn't need to be indexed since there is
+		// nothing to convert it to
+		for i, step := range path[:len(path)-1] {
+			if _, ok := apiUpgradePathsByGVK[step.gvk]; ok {
+				panic(fmt.Sprintf("duplicate API upgrade path for %v", step.gvk))
+			}
+			apiUpgradePathsByGVK[step.gvk] = apiUpgradePathIndex{path: path, index: i}
+		}
+	}
+}
+
+// ConvertObject converts an object to the oldest newer version of its
+// resource for which isServed returns true. The object is returned as is if
+// its version is served. An error is returned if there is no served version
+// the object can be converted to.
+func ConvertObject(
+	object runtime.Unstructured,
+	isServed func(schema.GroupVersionKind) bool,
+) (runtime.Unstructured, error) {
+	gvk := object.GetObjectKind().GroupVersionKind()
+	if isServed(gvk) {
+		return object, nil
+	}
+	if reason, ok := removedAPIs[gvk.GroupKind()]; ok {
+		return nil, fmt.Errorf("%v is not served by the destination cluster: %v", gvk, reason)
+	}
+	upgradePath, ok := apiUpgradePathsByGVK[gvk]
+	if !ok {
+		return nil, fmt.Errorf("%v is not served by the destination cluster", gvk)
+	}
+	obj, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %T for object of kind %v", object, gvk)
+	}
+
+	// Work on a copy so that the object isn't modified if none of the newer
+	// versions are served
+	converted := obj.DeepCopy()
+	for _, step := range upgradePath.path[upgradePath.index+1:] {
+		if step.convert != nil {
+			if err := step.convert(converted); err != nil {
+				return nil, fmt.Errorf("error converting %v to %v: %v", gvk, step.gvk, err)
+			}
+		}
+		converted.SetGroupVersionKind(step.gvk)
+		if isServed(step.gvk) {
+			return converted, nil
+		}
+	}
+	return nil, fmt.Errorf("%v and none of its newer versions are served by the destination cluster", gvk)
+}
+
+// convertIngressToV1 converts a networking.k8s.io/v1beta1 Ingress to
+// networking.k8s.io/v1
+func convertIngressToV1(object *unstructured.Unstructured) error {
+	spec, found, err := unstructured.NestedMap(object.Object, "spec")
+	if err != nil || !found {
+		return err
+	}
+
+	if backend, found, err := unstructured.NestedMap(spec, "backend"); err != nil {
+		return err
+	} else if found {
+		delete(spec, "backend")
+		spec["defaultBackend"] = convertIngressBackendToV1(backend)
+	}
+
+	rules, _, err := unstructured.NestedSlice(spec, "rules")
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _, err := unstructured.NestedSlice(rule, "http", "paths")
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// pathType is required in v1
+			if _, ok := path["pathType"]; !ok {
+				path["pathType"] = "ImplementationSpecific"
+			}
+			if backend, ok := path["backend"].(map[string]interface{}); ok {
+				path["backend"] = convertIngressBackendToV1(backend)
+			}
+		}
+		if len(paths) != 0 {
+			if err := unstructured.SetNestedSlice(rule, paths, "http", "paths"); err != nil {
+				return err
+			}
+		}
+	}
+	if len(rules) != 0 {
+		spec["rules"] = rules
+	}
+	return unstructured.SetNestedMap(object.Object, spec, "spec")
+}
+
+// convertIngressBackendToV1 converts the serviceName/servicePort backend used
+// by the v1beta1 APIs to the service backend used by v1. Resource backends
+// are the same in both versions.
+func convertIngressBackendToV1(backend map[string]interface{}) map[string]interface{} {
+	serviceName, ok := backend["serviceName"]
+	if !ok {
+		return backend
+	}
+	converted := map[string]interface{}{
+		"service": map[string]interface{}{
+			"name": serviceName,
+			"port": convertPort(backend["servicePort"]),
+		},
+	}
+	if resource, ok := backend["resource"]; ok {
+		converted["resource"] = resource
+	}
+	return converted
+}
+
+func convertPort(port interface{}) map[string]interface{} {
+	converted := make(map[string]interface{})
+	switch p := port.(type) {
+	case string:
+		converted["name"] = p
+	case int64, float64:
+		converted["number"] = p
+	}
+	return converted
+}
+
+// convertHPAFromV1 converts an autoscaling/v1 HorizontalPodAutoscaler to
+// autoscaling/v2, which replaces the CPU utilization target with metrics
+func convertHPAFromV1(object *unstructured.Unstructured) error {
+	utilization, found, err := unstructured.NestedFieldNoCopy(object.Object, "spec", "targetCPUUtilizationPercentage")
+	if err != nil || !found {
+		return err
+	}
+	unstructured.RemoveNestedField(object.Object, "spec", "targetCPUUtilizationPercentage")
+	metrics := []interface{}{
+		map[string]interface{}{
+			"type": "Resource",
+			"resource": map[string]interface{}{
+				"name": "cpu",
+				"target": map[string]interface{}{
+					"type":               "Utilization",
+					"averageUtilization": utilization,
+				},
+			},
+		},
+	}
+	return unstructured.SetNestedSlice(object.Object, metrics, "spec", "metrics")
+}
+
+// convertHPAFromV2beta1 converts an autoscaling/v2beta1
+// HorizontalPodAutoscaler to autoscaling/v2beta2, which groups the metric
+// identifiers and targets
+func convertHPAFromV2beta1(object *unstructured.Unstructured) error {
+	metrics, found, err := unstructured.NestedSlice(object.Object, "spec", "metrics")
+	if err != nil || !found {
+		return err
+	}
+	for _, m := range metrics {
+		metric, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch metric["type"] {
+		case "Resource":
+			if source, ok := metric["resource"].(map[string]interface{}); ok {
+				metric["resource"] = map[string]interface{}{
+					"name":   source["name"],
+					"target": convertHPATarget(source, "targetAverageUtilization", "targetAverageValue", ""),
+				}
+			}
+		case "Pods":
+			if source, ok := metric["pods"].(map[string]interface{}); ok {
+				metric["pods"] = map[string]interface{}{
+					"metric": convertHPAMetricIdentifier(source, "selector"),
+					"target": convertHPATarget(source, "", "targetAverageValue", ""),
+				}
+			}
+		case "Object":
+			if source, ok := metric["object"].(map[string]interface{}); ok {
+				metric["object"] = map[string]interface{}{
+					"describedObject": source["target"],
+					"metric":          convertHPAMetricIdentifier(source, "selector"),
+					"target":          convertHPATarget(source, "", "averageValue", "targetValue"),
+				}
+			}
+		case "External":
+			if source, ok := metric["external"].(map[string]interface{}); ok {
+				metric["external"] = map[string]interface{}{
+					"metric": convertHPAMetricIdentifier(source, "metricSelector"),
+					"target": convertHPATarget(source, "", "targetAverageValue", "targetValue"),
+				}
+			}
+		}
+	}
+	return unstructured.SetNestedSlice(object.Object, metrics, "spec", "metrics")
+}
+
+func convertHPAMetricIdentifier(source map[string]interface{}, selectorField string) map[string]interface{} {
+	identifier := map[string]interface{}{
+		"name": source["metricName"],
+	}
+	if selector, ok := source[selectorField]; ok {
+		identifier["selector"] = selector
+	}
+	return identifier
+}
+
+// convertHPATarget returns the v2beta2 target for a v2beta1 metric source
+// using the first of the given fields that is set
+func convertHPATarget(
+	source map[string]interface{},
+	utilizationField string,
+	averageValueField string,
+	valueField string,
+) map[string]interface{} {
+	if utilization, ok := source[utilizationField]; ok && utilizationField != "" {
+		return map[string]interface{}{
+			"type":               "Utilization",
+			"averageUtilization": utilization,
+		}
+	}
+	if averageValue, ok := source[averageValueField]; ok && averageValueField != "" {
+		return map[string]interface{}{
+			"type":         "AverageValue",
+			"averageValue": averageValue,
+		}
+	}
+	if value, ok := source[valueField]; ok && valueField != "" {
+		return map[string]interface{}{
+			"type":  "Value",
+			"value": value,
+		}
+	}
+	return map[string]interface{}{}
+}
+
+// convertWorkloadToAppsV1 converts a Deployment, DaemonSet, ReplicaSet or
+// StatefulSet from the beta APIs to apps/v1, which requires a selector and
+// drops fields that are no longer supported
+func convertWorkloadToAppsV1(object *unstructured.Unstructured) error {
+	unstructured.RemoveNestedField(object.Object, "spec", "rollbackTo")
+	unstructured.RemoveNestedField(object.Object, "spec", "templateGeneration")
+
+	// The beta APIs defaulted the selector to the labels of the pod template
+	if _, found, err := unstructured.NestedMap(object.Object, "spec", "selector"); err != nil || found {
+		return err
+	}
+	labels, found, err := unstructured.NestedStringMap(object.Object, "spec", "template", "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	if !found || len(labels) == 0 {
+		return fmt.Errorf("selector is required and the pod template doesn't have any labels")
+	}
+	matchLabels := make(map[string]interface{})
+	for k, v := range labels {
+		matchLabels[k] = v
+	}
+	return unstructured.SetNestedMap(object.Object, matchLabels, "spec", "selector", "matchLabels")
+}
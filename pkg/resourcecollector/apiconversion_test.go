@@ -0,0 +1,172 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAPIConversion(t *testing.T) {
+	t.Run("cronJobConversionTest", cronJobConversionTest)
+	t.Run("hpaV1ConversionTest", hpaV1ConversionTest)
+	t.Run("hpaV2beta1ConversionTest", hpaV2beta1ConversionTest)
+	t.Run("deploymentConversionTest", deploymentConversionTest)
+	t.Run("podSecurityPolicyTest", podSecurityPolicyTest)
+	t.Run("oldestServedVersionTest", oldestServedVersionTest)
+}
+
+func servedVersions(gvks ...schema.GroupVersionKind) func(schema.GroupVersionKind) bool {
+	served := make(map[schema.GroupVersionKind]bool)
+	for _, gvk := range gvks {
+		served[gvk] = true
+	}
+	return func(gvk schema.GroupVersionKind) bool {
+		return served[gvk]
+	}
+}
+
+func cronJobConversionTest(t *testing.T) {
+	cronJob := newObject("batch/v1beta1", "CronJob", map[string]interface{}{
+		"schedule": "*/5 * * * *",
+	})
+	converted, err := ConvertObject(cronJob, servedVersions(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}))
+	require.NoError(t, err)
+	obj := converted.(*unstructured.Unstructured)
+	require.Equal(t, "batch/v1", obj.GetAPIVersion())
+	schedule, _, err := unstructured.NestedString(obj.Object, "spec", "schedule")
+	require.NoError(t, err)
+	require.Equal(t, "*/5 * * * *", schedule)
+	// The original object shouldn't be modified
+	require.Equal(t, "batch/v1beta1", cronJob.GetAPIVersion())
+}
+
+func hpaV1ConversionTest(t *testing.T) {
+	hpa := newObject("autoscaling/v1", "HorizontalPodAutoscaler", map[string]interface{}{
+		"maxReplicas":                    int64(5),
+		"targetCPUUtilizationPercentage": int64(80),
+	})
+	converted, err := ConvertObject(hpa, servedVersions(schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}))
+	require.NoError(t, err)
+	obj := converted.(*unstructured.Unstructured)
+	require.Equal(t, "autoscaling/v2", obj.GetAPIVersion())
+	_, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "targetCPUUtilizationPercentage")
+	require.NoError(t, err)
+	require.False(t, found)
+	metrics, _, err := unstructured.NestedSlice(obj.Object, "spec", "metrics")
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	utilization, _, err := unstructured.NestedInt64(metrics[0].(map[string]interface{}), "resource", "target", "averageUtilization")
+	require.NoError(t, err)
+	require.Equal(t, int64(80), utilization)
+}
+
+func hpaV2beta1ConversionTest(t *testing.T) {
+	hpa := newObject("autoscaling/v2beta1", "HorizontalPodAutoscaler", map[string]interface{}{
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"type": "Resource",
+				"resource": map[string]interface{}{
+					"name":                     "memory",
+					"targetAverageUtilization": int64(60),
+				},
+			},
+			map[string]interface{}{
+				"type": "Pods",
+				"pods": map[string]interface{}{
+					"metricName":         "requests",
+					"targetAverageValue": "10",
+				},
+			},
+		},
+	})
+	converted, err := ConvertObject(hpa, servedVersions(schema.GroupVersionKind{Group: "autoscaling", Version: "v2", Kind: "HorizontalPodAutoscaler"}))
+	require.NoError(t, err)
+	obj := converted.(*unstructured.Unstructured)
+	require.Equal(t, "autoscaling/v2", obj.GetAPIVersion())
+	metrics, _, err := unstructured.NestedSlice(obj.Object, "spec", "metrics")
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+
+	resource := metrics[0].(map[string]interface{})
+	targetType, _, err := unstructured.NestedString(resource, "resource", "target", "type")
+	require.NoError(t, err)
+	require.Equal(t, "Utilization", targetType)
+	utilization, _, err := unstructured.NestedInt64(resource, "resource", "target", "averageUtilization")
+	require.NoError(t, err)
+	require.Equal(t, int64(60), utilization)
+
+	pods := metrics[1].(map[string]interface{})
+	name, _, err := unstructured.NestedString(pods, "pods", "metric", "name")
+	require.NoError(t, err)
+	require.Equal(t, "requests", name)
+	averageValue, _, err := unstructured.NestedString(pods, "pods", "target", "averageValue")
+	require.NoError(t, err)
+	require.Equal(t, "10", averageValue)
+}
+
+func deploymentConversionTest(t *testing.T) {
+	deployment := newObject("extensions/v1beta1", "Deployment", map[string]interface{}{
+		"rollbackTo": map[string]interface{}{
+			"revision": int64(1),
+		},
+		"template": map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{
+					"app": "web",
+				},
+			},
+		},
+	})
+	converted, err := ConvertObject(deployment, servedVersions(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+	require.NoError(t, err)
+	obj := converted.(*unstructured.Unstructured)
+	require.Equal(t, "apps/v1", obj.GetAPIVersion())
+	_, found, err := unstructured.NestedFieldNoCopy(obj.Object, "spec", "rollbackTo")
+	require.NoError(t, err)
+	require.False(t, found)
+	labels, _, err := unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app": "web"}, labels)
+
+	// A selector can't be defaulted without pod template labels
+	deployment = newObject("apps/v1beta1", "Deployment", map[string]interface{}{})
+	_, err = ConvertObject(deployment, servedVersions(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+	require.Error(t, err)
+}
+
+func podSecurityPolicyTest(t *testing.T) {
+	psp := newObject("policy/v1beta1", "PodSecurityPolicy", map[string]interface{}{})
+	_, err := ConvertObject(psp, servedVersions())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Pod Security Admission")
+
+	// Should be left alone if it is still served
+	converted, err := ConvertObject(psp, servedVersions(schema.GroupVersionKind{Group: "policy", Version: "v1beta1", Kind: "PodSecurityPolicy"}))
+	require.NoError(t, err)
+	require.Equal(t, psp, converted)
+}
+
+func oldestServedVersionTest(t *testing.T) {
+	// Should be converted to the oldest newer version that is served
+	ingress := newObject("extensions/v1beta1", "Ingress", map[string]interface{}{
+		"backend": map[string]interface{}{
+			"serviceName": "default",
+			"servicePort": int64(80),
+		},
+	})
+	converted, err := ConvertObject(ingress, servedVersions(
+		schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1beta1", Kind: "Ingress"},
+		schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	))
+	require.NoError(t, err)
+	obj := converted.(*unstructured.Unstructured)
+	require.Equal(t, "networking.k8s.io/v1beta1", obj.GetAPIVersion())
+	serviceName, _, err := unstructured.NestedString(obj.Object, "spec", "backend", "serviceName")
+	require.NoError(t, err)
+	require.Equal(t, "default", serviceName)
+}
@@ -0,0 +1,85 @@
+package resourcecollector
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// UnsupportedObject is an object whose API version isn't served by the
+// destination cluster and couldn't be converted to one that is
+type UnsupportedObject struct {
+	Object runtime.Unstructured
+	Reason string
+}
+
+// ConvertToServedVersions checks that the API version of each object is
+// served by the cluster. Objects using a version that isn't served are
+// converted to a served version if a conversion is known. Returns the objects
+// that can be applied on the cluster and the ones that can't.
+func ConvertToServedVersions(
+	discoveryClient discovery.DiscoveryInterface,
+	objects []runtime.Unstructured,
+) ([]runtime.Unstructured, []UnsupportedObject, error) {
+	served, failedGroups, err := getServedKinds(discoveryClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	isServed := func(gvk schema.GroupVersionKind) bool {
+		// Don't reject objects whose group couldn't be discovered, applying
+		// them will report a better error if they really aren't supported
+		return served[gvk] || failedGroups[gvk.GroupVersion()]
+	}
+
+	supported := make([]runtime.Unstructured, 0, len(objects))
+	unsupported := make([]UnsupportedObject, 0)
+	for _, o := range objects {
+		converted, err := ConvertObject(o, isServed)
+		if err != nil {
+			unsupported = append(unsupported, UnsupportedObject{
+				Object: o,
+				Reason: err.Error(),
+			})
+			continue
+		}
+		if converted != o {
+			logrus.Debugf("Converted %v to %v", o.GetObjectKind().GroupVersionKind(), converted.GetObjectKind().GroupVersionKind())
+		}
+		supported = append(supported, converted)
+	}
+	return supported, unsupported, nil
+}
+
+// getServedKinds returns the kinds served by the cluster along with the group
+// versions that couldn't be discovered
+func getServedKinds(
+	discoveryClient discovery.DiscoveryInterface,
+) (map[schema.GroupVersionKind]bool, map[schema.GroupVersion]bool, error) {
+	failedGroups := make(map[schema.GroupVersion]bool)
+	_, resourceLists, err := discoveryClient.ServerGroupsAndResources()
+	if err != nil {
+		failedErr, ok := err.(*discovery.ErrGroupDiscoveryFailed)
+		if !ok {
+			return nil, nil, fmt.Errorf("error getting API resources from cluster: %v", err)
+		}
+		for gv := range failedErr.Groups {
+			failedGroups[gv] = true
+		}
+	}
+
+	served := make(map[schema.GroupVersionKind]bool)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, resource := range resourceList.APIResources {
+			served[gv.WithKind(resource.Kind)] = true
+		}
+	}
+	return served, failedGroups, nil
+}
@@ -0,0 +1,133 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAPIVersion(t *testing.T) {
+	t.Run("servedVersionTest", servedVersionTest)
+	t.Run("ingressConversionTest", ingressConversionTest)
+	t.Run("unsupportedVersionTest", unsupportedVersionTest)
+}
+
+func newFakeDiscovery(resources ...*metav1.APIResourceList) *fake.FakeDiscovery {
+	return &fake.FakeDiscovery{
+		Fake: &k8stesting.Fake{
+			Resources: resources,
+		},
+	}
+}
+
+func newObject(apiVersion, kind string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      "test",
+				"namespace": "test-ns",
+			},
+			"spec": spec,
+		},
+	}
+}
+
+var networkingV1Resources = &metav1.APIResourceList{
+	GroupVersion: "networking.k8s.io/v1",
+	APIResources: []metav1.APIResource{
+		{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+	},
+}
+
+func servedVersionTest(t *testing.T) {
+	discovery := newFakeDiscovery(networkingV1Resources)
+	ingress := newObject("networking.k8s.io/v1", "Ingress", map[string]interface{}{})
+	supported, unsupported, err := ConvertToServedVersions(discovery, []runtime.Unstructured{ingress})
+	require.NoError(t, err)
+	require.Len(t, unsupported, 0)
+	require.Len(t, supported, 1)
+	require.Equal(t, "networking.k8s.io/v1", supported[0].GetObjectKind().GroupVersionKind().GroupVersion().String())
+}
+
+func ingressConversionTest(t *testing.T) {
+	discovery := newFakeDiscovery(networkingV1Resources)
+	ingress := newObject("extensions/v1beta1", "Ingress", map[string]interface{}{
+		"backend": map[string]interface{}{
+			"serviceName": "default",
+			"servicePort": int64(80),
+		},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"host": "example.com",
+				"http": map[string]interface{}{
+					"paths": []interface{}{
+						map[string]interface{}{
+							"path": "/",
+							"backend": map[string]interface{}{
+								"serviceName": "web",
+								"servicePort": "http",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	supported, unsupported, err := ConvertToServedVersions(discovery, []runtime.Unstructured{ingress})
+	require.NoError(t, err)
+	require.Len(t, unsupported, 0)
+	require.Len(t, supported, 1)
+
+	converted := supported[0].(*unstructured.Unstructured)
+	require.Equal(t, "networking.k8s.io/v1", converted.GetAPIVersion())
+	_, found, err := unstructured.NestedMap(converted.Object, "spec", "backend")
+	require.NoError(t, err)
+	require.False(t, found)
+	port, found, err := unstructured.NestedInt64(converted.Object, "spec", "defaultBackend", "service", "port", "number")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, int64(80), port)
+
+	rules, _, err := unstructured.NestedSlice(converted.Object, "spec", "rules")
+	require.NoError(t, err)
+	paths, _, err := unstructured.NestedSlice(rules[0].(map[string]interface{}), "http", "paths")
+	require.NoError(t, err)
+	path := paths[0].(map[string]interface{})
+	require.Equal(t, "ImplementationSpecific", path["pathType"])
+	name, _, err := unstructured.NestedString(path, "backend", "service", "name")
+	require.NoError(t, err)
+	require.Equal(t, "web", name)
+	portName, _, err := unstructured.NestedString(path, "backend", "service", "port", "name")
+	require.NoError(t, err)
+	require.Equal(t, "http", portName)
+}
+
+func unsupportedVersionTest(t *testing.T) {
+	discovery := newFakeDiscovery(networkingV1Resources)
+	ingress := newObject("networking.k8s.io/v1beta1", "Ingress", map[string]interface{}{})
+	widget := newObject("example.com/v1", "Widget", map[string]interface{}{})
+	supported, unsupported, err := ConvertToServedVersions(discovery, []runtime.Unstructured{ingress, widget})
+	require.NoError(t, err)
+	require.Len(t, supported, 1)
+	require.Len(t, unsupported, 1)
+	require.Equal(t, "Widget", unsupported[0].Object.GetObjectKind().GroupVersionKind().Kind)
+	require.Contains(t, unsupported[0].Reason, "not served")
+
+	// Neither the deprecated nor the converted version are served
+	discovery = newFakeDiscovery()
+	ingress = newObject("networking.k8s.io/v1beta1", "Ingress", map[string]interface{}{})
+	supported, unsupported, err = ConvertToServedVersions(discovery, []runtime.Unstructured{ingress})
+	require.NoError(t, err)
+	require.Len(t, supported, 0)
+	require.Len(t, unsupported, 1)
+}
@@ -0,0 +1,49 @@
+package resourcecollector
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// applyTiers are the kinds that other objects depend on, in the order they
+// need to be applied. Objects of kinds that aren't listed are applied last.
+var applyTiers = [][]string{
+	{"CustomResourceDefinition", "Namespace"},
+	{"ServiceAccount", "ClusterRole", "Role", "PriorityClass", "StorageClass",
+		"ResourceQuota", "LimitRange", "ConfigMap", "Secret", "PersistentVolume"},
+	{"ClusterRoleBinding", "RoleBinding", "PersistentVolumeClaim", "Service"},
+}
+
+// GetApplyTiers groups the objects into tiers that need to be applied in
+// order, so that objects are only applied after the ones they depend on.
+// Objects within a tier don't depend on each other and can be applied in
+// parallel. Empty tiers are left out and the order of the objects within a
+// tier is preserved.
+func GetApplyTiers(objects []runtime.Unstructured) ([][]runtime.Unstructured, error) {
+	tierIndex := make(map[string]int)
+	for i, kinds := range applyTiers {
+		for _, kind := range kinds {
+			tierIndex[kind] = i
+		}
+	}
+	tiers := make([][]runtime.Unstructured, len(applyTiers)+1)
+	for _, o := range objects {
+		objectType, err := meta.TypeAccessor(o)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := tierIndex[objectType.GetKind()]
+		if !ok {
+			i = len(applyTiers)
+		}
+		tiers[i] = append(tiers[i], o)
+	}
+
+	nonEmpty := make([][]runtime.Unstructured, 0, len(tiers))
+	for _, tier := range tiers {
+		if len(tier) > 0 {
+			nonEmpty = append(nonEmpty, tier)
+		}
+	}
+	return nonEmpty, nil
+}
@@ -0,0 +1,47 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newApplyObject(kind, name string) *unstructured.Unstructured {
+	o := &unstructured.Unstructured{}
+	o.SetKind(kind)
+	o.SetName(name)
+	return o
+}
+
+func TestGetApplyTiers(t *testing.T) {
+	tiers, err := GetApplyTiers(nil)
+	require.NoError(t, err)
+	require.Empty(t, tiers)
+
+	deployment := newApplyObject("Deployment", "web")
+	binding := newApplyObject("RoleBinding", "web")
+	account := newApplyObject("ServiceAccount", "web")
+	crd := newApplyObject("CustomResourceDefinition", "apps.example.com")
+	custom := newApplyObject("App", "web")
+	namespace := newApplyObject("Namespace", "test")
+	pvc := newApplyObject("PersistentVolumeClaim", "data")
+
+	tiers, err = GetApplyTiers([]runtime.Unstructured{deployment, binding, account, crd, custom, namespace, pvc})
+	require.NoError(t, err)
+	require.Equal(t, [][]runtime.Unstructured{
+		{crd, namespace},
+		{account},
+		{binding, pvc},
+		{deployment, custom},
+	}, tiers)
+
+	// Empty tiers are left out
+	tiers, err = GetApplyTiers([]runtime.Unstructured{deployment, namespace})
+	require.NoError(t, err)
+	require.Equal(t, [][]runtime.Unstructured{{namespace}, {deployment}}, tiers)
+}
@@ -0,0 +1,129 @@
+package resourcecollector
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	certManagerGroup     = "cert-manager.io"
+	certManagerACMEGroup = "acme.cert-manager.io"
+	// certificateNameAnnotation is set by cert-manager on the Secrets it
+	// issues for a Certificate
+	certificateNameAnnotation = "cert-manager.io/certificate-name"
+)
+
+// skipCertificateIssuance returns true if the object is part of the issuance
+// of a cert-manager certificate that is issued again on the destination
+func skipCertificateIssuance(object runtime.Unstructured, policy *stork_api.CertificatePolicy) (bool, error) {
+	if policy == nil || policy.Reissue != stork_api.CertificateReissueAlways {
+		return false, nil
+	}
+	gvk := object.GetObjectKind().GroupVersionKind()
+	switch gvk.Kind {
+	case "CertificateRequest":
+		return gvk.Group == certManagerGroup, nil
+	case "Order", "Challenge":
+		return gvk.Group == certManagerACMEGroup, nil
+	}
+	return false, nil
+}
+
+// skipCertificateSecret returns true if the Secret was issued by cert-manager
+// for a Certificate that is restored too and the policy reissues its
+// certificate. cert-manager then issues a new certificate on the destination
+// since the Secret of the Certificate is missing.
+func skipCertificateSecret(
+	object runtime.Unstructured,
+	allObjects []runtime.Unstructured,
+	namespaceMappings map[string]string,
+	policy *stork_api.CertificatePolicy,
+	now time.Time,
+) (bool, error) {
+	if policy == nil || policy.Reissue == "" || policy.Reissue == stork_api.CertificateReissueNever {
+		return false, nil
+	}
+	var secret v1.Secret
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &secret); err != nil {
+		return false, fmt.Errorf("error converting Secret object %v: %v", object, err)
+	}
+	certificateName, ok := secret.Annotations[certificateNameAnnotation]
+	if !ok {
+		return false, nil
+	}
+	restored, err := certificateRestored(certificateName, secret.Namespace, allObjects, namespaceMappings)
+	if err != nil || !restored {
+		return false, err
+	}
+
+	switch policy.Reissue {
+	case stork_api.CertificateReissueAlways:
+		return true, nil
+	case stork_api.CertificateReissueExpired:
+		var threshold time.Duration
+		if policy.ExpiryThreshold != nil {
+			threshold = policy.ExpiryThreshold.Duration
+		}
+		notAfter, err := certificateNotAfter(secret.Data[v1.TLSCertKey])
+		if err != nil {
+			// Keep the certificate if it can't be checked, cert-manager
+			// reissues it if it isn't valid
+			logrus.Warnf("Error checking the certificate in Secret %v/%v: %v", secret.Namespace, secret.Name, err)
+			return false, nil
+		}
+		return !now.Add(threshold).Before(notAfter), nil
+	default:
+		return false, fmt.Errorf("invalid certificate reissue policy %v", policy.Reissue)
+	}
+}
+
+// certificateRestored returns true if the cert-manager Certificate is one of
+// the restored objects. The namespaces of the objects may or may not have
+// been mapped already.
+func certificateRestored(
+	name string,
+	namespace string,
+	allObjects []runtime.Unstructured,
+	namespaceMappings map[string]string,
+) (bool, error) {
+	for _, o := range allObjects {
+		gvk := o.GetObjectKind().GroupVersionKind()
+		if gvk.GroupKind() != (schema.GroupKind{Group: certManagerGroup, Kind: "Certificate"}) {
+			continue
+		}
+		metadata, err := meta.Accessor(o)
+		if err != nil {
+			return false, err
+		}
+		if metadata.GetName() != name {
+			continue
+		}
+		if ns := metadata.GetNamespace(); ns == namespace || namespaceMappings[ns] == namespace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// certificateNotAfter returns the expiry of the first certificate in the PEM
+// encoded chain
+func certificateNotAfter(data []byte) (time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
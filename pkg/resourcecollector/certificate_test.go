@@ -0,0 +1,119 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newCertificateSecret(t *testing.T, certificateName string, notAfter time.Time) *unstructured.Unstructured {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app.example.com"},
+		NotBefore:    notAfter.Add(-90 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	secret := newSecret(certificateName+"-tls", v1.SecretTypeTLS)
+	secret.SetAnnotations(map[string]string{certificateNameAnnotation: certificateName})
+	secret.Object["data"] = map[string]interface{}{
+		v1.TLSCertKey: base64.StdEncoding.EncodeToString(cert),
+	}
+	return secret
+}
+
+func newCertManagerObject(apiVersion, kind, name, namespace string) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetName(name)
+	object.SetNamespace(namespace)
+	return object
+}
+
+func TestSkipCertificateSecret(t *testing.T) {
+	now := time.Now()
+	expired := newCertificateSecret(t, "expired", now.Add(-time.Hour))
+	valid := newCertificateSecret(t, "valid", now.Add(30*24*time.Hour))
+	unmanaged := newCertificateSecret(t, "unmanaged", now.Add(-time.Hour))
+	// The Certificates are still in the source namespace
+	allObjects := []runtime.Unstructured{
+		newCertManagerObject("cert-manager.io/v1", "Certificate", "expired", "source"),
+		newCertManagerObject("cert-manager.io/v1", "Certificate", "valid", "source"),
+		expired, valid, unmanaged,
+	}
+	namespaceMappings := map[string]string{"source": "test"}
+
+	skip, err := skipCertificateSecret(expired, allObjects, namespaceMappings, nil, now)
+	require.NoError(t, err)
+	require.False(t, skip, "Secrets should be restored without a policy")
+
+	policy := &stork_api.CertificatePolicy{Reissue: stork_api.CertificateReissueAlways}
+	skip, err = skipCertificateSecret(valid, allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.True(t, skip)
+	skip, err = skipCertificateSecret(unmanaged, allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.False(t, skip, "Secrets of Certificates that aren't restored should be restored")
+	skip, err = skipCertificateSecret(newSecret("other", v1.SecretTypeTLS), allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.False(t, skip, "Secrets not issued by cert-manager should be restored")
+
+	policy = &stork_api.CertificatePolicy{Reissue: stork_api.CertificateReissueExpired}
+	skip, err = skipCertificateSecret(expired, allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.True(t, skip)
+	skip, err = skipCertificateSecret(valid, allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.False(t, skip)
+
+	policy.ExpiryThreshold = &metav1.Duration{Duration: 60 * 24 * time.Hour}
+	skip, err = skipCertificateSecret(valid, allObjects, namespaceMappings, policy, now)
+	require.NoError(t, err)
+	require.True(t, skip, "certificates expiring within the threshold should be reissued")
+}
+
+func TestSkipCertificateIssuance(t *testing.T) {
+	request := newCertManagerObject("cert-manager.io/v1", "CertificateRequest", "app-1", "test")
+	order := newCertManagerObject("acme.cert-manager.io/v1", "Order", "app-1-123", "test")
+	otherOrder := newCertManagerObject("shop.example.com/v1", "Order", "order", "test")
+
+	for _, policy := range []*stork_api.CertificatePolicy{nil, {Reissue: stork_api.CertificateReissueExpired}} {
+		skip, err := skipCertificateIssuance(request, policy)
+		require.NoError(t, err)
+		require.False(t, skip)
+	}
+
+	policy := &stork_api.CertificatePolicy{Reissue: stork_api.CertificateReissueAlways}
+	skip, err := skipCertificateIssuance(request, policy)
+	require.NoError(t, err)
+	require.True(t, skip)
+	skip, err = skipCertificateIssuance(order, policy)
+	require.NoError(t, err)
+	require.True(t, skip)
+	skip, err = skipCertificateIssuance(otherOrder, policy)
+	require.NoError(t, err)
+	require.False(t, skip, "Orders of other groups should be restored")
+}
@@ -0,0 +1,91 @@
+package resourcecollector
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// RestoreInitContainerAnnotation is set on restored workloads with the
+	// name of the init container that was added by the restore
+	RestoreInitContainerAnnotation = "stork.libopenstorage.org/restore-init-container"
+)
+
+// InjectInitContainer adds the init container to the pod template of
+// Deployments, StatefulSets and DaemonSets and annotates the object with the
+// name of the container so that it can be removed later. Returns false if the
+// object doesn't have a pod template.
+func InjectInitContainer(object runtime.Unstructured, container *v1.Container) (bool, error) {
+	switch object.GetObjectKind().GroupVersionKind().Kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+	default:
+		return false, nil
+	}
+	if container.Name == "" {
+		return false, fmt.Errorf("name is required for the init container")
+	}
+
+	content := object.UnstructuredContent()
+	initContainers, _, err := unstructured.NestedSlice(content, "spec", "template", "spec", "initContainers")
+	if err != nil {
+		return false, err
+	}
+	newContainer, err := runtime.DefaultUnstructuredConverter.ToUnstructured(container)
+	if err != nil {
+		return false, err
+	}
+	// Replace an existing container with the same name so that restoring
+	// the same resources again doesn't fail
+	updatedContainers := make([]interface{}, 0, len(initContainers)+1)
+	for _, c := range initContainers {
+		if existing, ok := c.(map[string]interface{}); ok && existing["name"] == container.Name {
+			continue
+		}
+		updatedContainers = append(updatedContainers, c)
+	}
+	// Run the container after the existing ones so that it can depend on
+	// them
+	updatedContainers = append(updatedContainers, newContainer)
+	if err := unstructured.SetNestedSlice(content, updatedContainers, "spec", "template", "spec", "initContainers"); err != nil {
+		return false, err
+	}
+	object.SetUnstructuredContent(content)
+
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	annotations := metadata.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[RestoreInitContainerAnnotation] = container.Name
+	metadata.SetAnnotations(annotations)
+	return true, nil
+}
+
+// RemoveInitContainer removes the init container added by
+// InjectInitContainer from the pod spec and the annotation from the object.
+// Returns false if the object doesn't have the annotation.
+func RemoveInitContainer(objectMeta metav1.Object, podSpec *v1.PodSpec) bool {
+	annotations := objectMeta.GetAnnotations()
+	name, present := annotations[RestoreInitContainerAnnotation]
+	if !present {
+		return false
+	}
+	initContainers := make([]v1.Container, 0, len(podSpec.InitContainers))
+	for _, c := range podSpec.InitContainers {
+		if c.Name != name {
+			initContainers = append(initContainers, c)
+		}
+	}
+	podSpec.InitContainers = initContainers
+	delete(annotations, RestoreInitContainerAnnotation)
+	objectMeta.SetAnnotations(annotations)
+	return true
+}
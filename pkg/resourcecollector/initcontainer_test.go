@@ -0,0 +1,70 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestInitContainer(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mysql",
+			Namespace: "test",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "init", Image: "busybox"}},
+					Containers:     []v1.Container{{Name: "mysql", Image: "mysql"}},
+				},
+			},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	require.NoError(t, err)
+	object := &unstructured.Unstructured{Object: content}
+
+	hook := &v1.Container{Name: "fix-ownership", Image: "busybox", Command: []string{"chown", "-R", "999", "/data"}}
+	injected, err := InjectInitContainer(object, hook)
+	require.NoError(t, err)
+	require.True(t, injected)
+	// Injecting again shouldn't add a second container
+	injected, err = InjectInitContainer(object, hook)
+	require.NoError(t, err)
+	require.True(t, injected)
+
+	restored := &appsv1.Deployment{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), restored)
+	require.NoError(t, err)
+	require.Len(t, restored.Spec.Template.Spec.InitContainers, 2)
+	require.Equal(t, "init", restored.Spec.Template.Spec.InitContainers[0].Name)
+	require.Equal(t, *hook, restored.Spec.Template.Spec.InitContainers[1])
+	require.Equal(t, "fix-ownership", restored.Annotations[RestoreInitContainerAnnotation])
+
+	require.True(t, RemoveInitContainer(restored, &restored.Spec.Template.Spec))
+	require.Len(t, restored.Spec.Template.Spec.InitContainers, 1)
+	require.Equal(t, "init", restored.Spec.Template.Spec.InitContainers[0].Name)
+	require.NotContains(t, restored.Annotations, RestoreInitContainerAnnotation)
+	require.False(t, RemoveInitContainer(restored, &restored.Spec.Template.Spec))
+
+	// Objects without a pod template are left unchanged
+	injected, err = InjectInitContainer(newSecret("secret", v1.SecretTypeOpaque), hook)
+	require.NoError(t, err)
+	require.False(t, injected)
+
+	_, err = InjectInitContainer(object, &v1.Container{Image: "busybox"})
+	require.Error(t, err, "Expected error for init container without a name")
+}
@@ -1,12 +1,25 @@
 package resourcecollector
 
 import (
+	"strconv"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const (
 	controllerUIDLabel = "controller-uid"
+
+	// JobParallelismAnnotation is the parallelism of a Job before it was
+	// suspended on the destination
+	JobParallelismAnnotation = "stork.libopenstorage.org/jobParallelism"
+	// CronJobSuspendAnnotation is the suspend value of a CronJob before it
+	// was suspended on the destination
+	CronJobSuspendAnnotation = "stork.libopenstorage.org/cronJobSuspend"
 )
 
 func (r *ResourceCollector) prepareJobForCollection(
@@ -35,3 +48,100 @@ func (r *ResourceCollector) prepareJobForCollection(
 
 	return nil
 }
+
+// IsJobCompleted returns true if the Job has completed or failed
+func IsJobCompleted(object runtime.Unstructured) (bool, error) {
+	var job batchv1.Job
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &job); err != nil {
+		return false, err
+	}
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == v1.ConditionTrue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SkipJob returns true if the Job shouldn't be migrated or restored with
+// the given policy. Jobs created by CronJobs are always skipped since the
+// CronJob creates them again when it runs on the destination.
+func SkipJob(object runtime.Unstructured, policy *stork_api.JobPolicy) (bool, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	for _, owner := range metadata.GetOwnerReferences() {
+		if owner.Kind == "CronJob" {
+			return true, nil
+		}
+	}
+	if policy.IncludeCompletedJobs {
+		return false, nil
+	}
+	return IsJobCompleted(object)
+}
+
+// PrepareJobForApply suspends the Job unless the policy allows it to run
+// again. The parallelism is set to 0 as well for clusters that don't support
+// suspending Jobs, and the original parallelism is saved in an annotation.
+func PrepareJobForApply(object runtime.Unstructured, policy *stork_api.JobPolicy) error {
+	if policy.RerunJobs {
+		return nil
+	}
+	content := object.UnstructuredContent()
+	if !hasAnnotation(content, JobParallelismAnnotation) {
+		parallelism, found, err := unstructured.NestedInt64(content, "spec", "parallelism")
+		if err != nil {
+			return err
+		}
+		if !found {
+			parallelism = 1
+		}
+		if err := setAnnotation(content, JobParallelismAnnotation, strconv.FormatInt(parallelism, 10)); err != nil {
+			return err
+		}
+	}
+	if err := unstructured.SetNestedField(content, true, "spec", "suspend"); err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(content, int64(0), "spec", "parallelism")
+}
+
+// PrepareCronJobForApply suspends the CronJob until the namespace is
+// activated. The original suspend value is saved in an annotation so that
+// CronJobs that were suspended on the source stay suspended on activation.
+func PrepareCronJobForApply(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	// Don't overwrite the original value if the CronJob has already been
+	// prepared, e.g. by a previous migration
+	if !hasAnnotation(content, CronJobSuspendAnnotation) {
+		suspend, _, err := unstructured.NestedBool(content, "spec", "suspend")
+		if err != nil {
+			return err
+		}
+		if err := setAnnotation(content, CronJobSuspendAnnotation, strconv.FormatBool(suspend)); err != nil {
+			return err
+		}
+	}
+	return unstructured.SetNestedField(content, true, "spec", "suspend")
+}
+
+func hasAnnotation(content map[string]interface{}, key string) bool {
+	annotations, _, _ := unstructured.NestedStringMap(content, "metadata", "annotations")
+	_, ok := annotations[key]
+	return ok
+}
+
+func setAnnotation(content map[string]interface{}, key, value string) error {
+	annotations, found, err := unstructured.NestedStringMap(content, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	if !found {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	return unstructured.SetNestedStringMap(content, annotations, "metadata", "annotations")
+}
@@ -0,0 +1,96 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newJob(t *testing.T, conditions []batchv1.JobCondition, owners []metav1.OwnerReference) *unstructured.Unstructured {
+	parallelism := int32(3)
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "job",
+			Namespace:       "test",
+			OwnerReferences: owners,
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism: &parallelism,
+		},
+		Status: batchv1.JobStatus{
+			Conditions: conditions,
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: content}
+}
+
+func TestSkipJob(t *testing.T) {
+	completed := []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: v1.ConditionTrue}}
+	policy := &stork_api.JobPolicy{}
+
+	skip, err := SkipJob(newJob(t, nil, nil), policy)
+	require.NoError(t, err)
+	require.False(t, skip, "running Jobs should not be skipped")
+
+	skip, err = SkipJob(newJob(t, completed, nil), policy)
+	require.NoError(t, err)
+	require.True(t, skip, "completed Jobs should be skipped by default")
+
+	skip, err = SkipJob(newJob(t, completed, nil), &stork_api.JobPolicy{IncludeCompletedJobs: true})
+	require.NoError(t, err)
+	require.False(t, skip, "completed Jobs should be included if requested")
+
+	skip, err = SkipJob(newJob(t, nil, []metav1.OwnerReference{{Kind: "CronJob", Name: "cronjob"}}), policy)
+	require.NoError(t, err)
+	require.True(t, skip, "Jobs created by CronJobs should be skipped")
+}
+
+func TestPrepareJobForApply(t *testing.T) {
+	job := newJob(t, nil, nil)
+	require.NoError(t, PrepareJobForApply(job, &stork_api.JobPolicy{}))
+	// Preparing the Job again shouldn't lose the original parallelism
+	require.NoError(t, PrepareJobForApply(job, &stork_api.JobPolicy{}))
+	suspend, _, err := unstructured.NestedBool(job.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.True(t, suspend)
+	parallelism, _, err := unstructured.NestedInt64(job.Object, "spec", "parallelism")
+	require.NoError(t, err)
+	require.Equal(t, int64(0), parallelism)
+	require.Equal(t, "3", job.GetAnnotations()[JobParallelismAnnotation])
+
+	job = newJob(t, nil, nil)
+	require.NoError(t, PrepareJobForApply(job, &stork_api.JobPolicy{RerunJobs: true}))
+	_, found, err := unstructured.NestedBool(job.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.False(t, found, "Jobs should not be suspended if they are allowed to run again")
+}
+
+func TestPrepareCronJobForApply(t *testing.T) {
+	cronJob := &unstructured.Unstructured{}
+	cronJob.SetKind("CronJob")
+	cronJob.SetName("cronjob")
+	require.NoError(t, unstructured.SetNestedField(cronJob.Object, true, "spec", "suspend"))
+	require.NoError(t, PrepareCronJobForApply(cronJob))
+	require.Equal(t, "true", cronJob.GetAnnotations()[CronJobSuspendAnnotation])
+
+	cronJob = &unstructured.Unstructured{}
+	cronJob.SetKind("CronJob")
+	cronJob.SetName("cronjob")
+	require.NoError(t, PrepareCronJobForApply(cronJob))
+	require.NoError(t, PrepareCronJobForApply(cronJob))
+	suspend, _, err := unstructured.NestedBool(cronJob.Object, "spec", "suspend")
+	require.NoError(t, err)
+	require.True(t, suspend)
+	require.Equal(t, "false", cronJob.GetAnnotations()[CronJobSuspendAnnotation])
+}
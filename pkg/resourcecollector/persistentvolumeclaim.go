@@ -5,9 +5,11 @@ import (
 
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/portworx/sched-ops/k8s/core"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	pvutil "k8s.io/kubernetes/pkg/controller/volume/persistentvolume/util"
 )
@@ -65,16 +67,37 @@ func (r *ResourceCollector) preparePVCResourceForApply(
 		return false, err
 	}
 
+	// The dataSourceRef isn't part of the typed PVC, so it's copied over
+	// from the object to not lose it in the conversion
+	dataSourceRef, dataSourceRefFound, err := unstructured.NestedMap(object.UnstructuredContent(), "spec", "dataSourceRef")
+	if err != nil {
+		return false, fmt.Errorf("error getting dataSourceRef of PVC %v: %v", metadata.GetName(), err)
+	}
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &pvc); err != nil {
 		return false, fmt.Errorf("error converting PVC object: %v: %v", object, err)
 	}
 
-	if len(pvNameMappings) != 0 {
+	// Volumes that are repopulated from their data source weren't restored,
+	// so the PVC is left unbound for the provisioner or populator
+	repopulate := false
+	for _, vol := range vInfos {
+		if vol.PersistentVolumeClaim == pvc.Name && vol.Repopulate {
+			repopulate = true
+			break
+		}
+	}
+	if len(pvNameMappings) != 0 && !repopulate {
 		if updatedName, present = pvNameMappings[pvc.Spec.VolumeName]; !present {
 			return false, fmt.Errorf("PV name mapping not found for %v", metadata.GetName())
 		}
 	}
 	pvc.Spec.VolumeName = updatedName
+	// Backups taken while the PVC was locked can still have the lock
+	delete(pvc.Annotations, pvclock.LockAnnotation)
+	if repopulate {
+		delete(pvc.Annotations, pvutil.AnnBindCompleted)
+		delete(pvc.Annotations, pvutil.AnnBoundByController)
+	}
 	nodes, err := core.Instance().GetNodes()
 	if err != nil {
 		return false, fmt.Errorf("failed in getting the nodes: %v", err)
@@ -106,6 +129,11 @@ func (r *ResourceCollector) preparePVCResourceForApply(
 	if err != nil {
 		return false, err
 	}
+	if dataSourceRefFound {
+		if err := unstructured.SetNestedMap(o, dataSourceRef, "spec", "dataSourceRef"); err != nil {
+			return false, err
+		}
+	}
 	object.SetUnstructuredContent(o)
 	return false, nil
 }
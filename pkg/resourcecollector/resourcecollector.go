@@ -11,6 +11,7 @@ import (
 	"github.com/heptio/ark/pkg/discovery"
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/pvclock"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/rbac"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
@@ -116,9 +117,10 @@ func (r *ResourceCollector) Init(config *restclient.Config) error {
 }
 
 func resourceToBeCollected(resource metav1.APIResource, grp schema.GroupVersion, crdKinds []metav1.GroupVersionKind, optionalResourceTypes []string) bool {
-	// Ignore CSI Snapshot object
-	if resource.Kind == "VolumeSnapshot" {
-		return false
+	// CSI snapshots are only collected if requested
+	switch resource.Kind {
+	case "VolumeSnapshot", "VolumeSnapshotContent":
+		return grp.Group == snapshotGroup && includesVolumeSnapshots(optionalResourceTypes)
 	}
 
 	// Include all namespaced CRDs
@@ -152,7 +154,8 @@ func resourceToBeCollected(resource metav1.APIResource, grp schema.GroupVersion,
 		"ReplicaSet",
 		"LimitRange",
 		"NetworkPolicy",
-		"PodDisruptionBudget":
+		"PodDisruptionBudget",
+		"HorizontalPodAutoscaler":
 		return true
 	case "Job":
 		return slice.ContainsString(optionalResourceTypes, "job", strings.ToLower) ||
@@ -207,7 +210,8 @@ func (r *ResourceCollector) GetResourcesForType(
 	namespaces []string,
 	labelSelectors map[string]string,
 	includeObjects map[stork_api.ObjectInfo]bool,
-	allDrivers bool) (*Objects, error) {
+	allDrivers bool,
+	secretTypes *stork_api.SecretTypeFilter) (*Objects, error) {
 
 	if objects == nil {
 		objects = &Objects{
@@ -240,9 +244,10 @@ func (r *ResourceCollector) GetResourcesForType(
 		}
 
 		var selectors string
-		// PVs don't get the labels from their PVCs, so don't use the label selector
+		// PVs and VolumeSnapshotContents don't get the labels from their
+		// claims, so don't use the label selector
 		switch resource.Kind {
-		case "PersistentVolume":
+		case "PersistentVolume", "VolumeSnapshotContent":
 		default:
 			selectors = labels.Set(labelSelectors).String()
 		}
@@ -262,7 +267,7 @@ func (r *ResourceCollector) GetResourcesForType(
 				return nil, fmt.Errorf("error casting object: %v", o)
 			}
 
-			collect, err := r.objectToBeCollected(includeObjects, labelSelectors, objects.resourceMap, runtimeObject, crbs, ns, allDrivers)
+			collect, err := r.objectToBeCollected(includeObjects, labelSelectors, objects.resourceMap, runtimeObject, crbs, ns, allDrivers, secretTypes)
 			if err != nil {
 				return nil, fmt.Errorf("error processing object %v: %v", runtimeObject, err)
 			}
@@ -296,7 +301,8 @@ func (r *ResourceCollector) GetResources(
 	labelSelectors map[string]string,
 	includeObjects map[stork_api.ObjectInfo]bool,
 	optionalResourceTypes []string,
-	allDrivers bool) ([]runtime.Unstructured, error) {
+	allDrivers bool,
+	secretTypes *stork_api.SecretTypeFilter) ([]runtime.Unstructured, error) {
 	err := r.discoveryHelper.Refresh()
 	if err != nil {
 		return nil, err
@@ -348,9 +354,10 @@ func (r *ResourceCollector) GetResources(
 				}
 
 				var selectors string
-				// PVs don't get the labels from their PVCs, so don't use the label selector
+				// PVs and VolumeSnapshotContents don't get the labels from their
+				// claims, so don't use the label selector
 				switch resource.Kind {
-				case "PersistentVolume":
+				case "PersistentVolume", "VolumeSnapshotContent":
 				default:
 					selectors = labels.Set(labelSelectors).String()
 				}
@@ -382,7 +389,7 @@ func (r *ResourceCollector) GetResources(
 					// With this now a user can choose to backup all resources in a ns and some
 					// selected resources from different ns
 
-					collect, err = r.objectToBeCollected(objectToInclude, labelSelectors, resourceMap, runtimeObject, crbs, ns, allDrivers)
+					collect, err = r.objectToBeCollected(objectToInclude, labelSelectors, resourceMap, runtimeObject, crbs, ns, allDrivers, secretTypes)
 					if err != nil {
 						if apierrors.IsForbidden(err) {
 							continue
@@ -465,6 +472,7 @@ func (r *ResourceCollector) objectToBeCollected(
 	crbs *rbacv1.ClusterRoleBindingList,
 	namespace string,
 	allDrivers bool,
+	secretTypes *stork_api.SecretTypeFilter,
 ) (bool, error) {
 	metadata, err := meta.Accessor(object)
 	if err != nil {
@@ -505,7 +513,7 @@ func (r *ResourceCollector) objectToBeCollected(
 	case "ServiceAccount":
 		return r.serviceAccountToBeCollected(object)
 	case "Secret":
-		return r.secretToBeCollected(object)
+		return r.secretToBeCollected(object, secretTypes)
 	case "Role":
 		return r.roleToBeCollected(object)
 	case "RoleBinding":
@@ -522,6 +530,10 @@ func (r *ResourceCollector) objectToBeCollected(
 		return r.dataVolumesToBeCollected(object)
 	case "VirtualMachineInstance":
 		return r.virtualMachineInstanceToBeCollected(object)
+	case "VolumeSnapshot":
+		return volumeSnapshotToBeCollected(object)
+	case "VolumeSnapshotContent":
+		return r.volumeSnapshotContentToBeCollected(includeObjects, labelSelectors, object, namespace)
 	}
 
 	return true, nil
@@ -607,6 +619,9 @@ func (r *ResourceCollector) prepareResourcesForCollection(
 			if err != nil {
 				return fmt.Errorf("error preparing PV resource %v: %v", metadata.GetName(), err)
 			}
+		case "PersistentVolumeClaim":
+			// Locks are only held on the PVCs of this cluster
+			unstructured.RemoveNestedField(o.UnstructuredContent(), "metadata", "annotations", pvclock.LockAnnotation)
 		case "Service":
 			if _, ok := r.Opts[ServiceKind]; !ok {
 				err := r.prepareServiceResourceForCollection(o)
@@ -629,6 +644,16 @@ func (r *ResourceCollector) prepareResourcesForCollection(
 			if err != nil {
 				return fmt.Errorf("error preparing VirtualMachine resource %v: %v", metadata.GetName(), err)
 			}
+		case "VolumeSnapshot":
+			err := prepareVolumeSnapshotForCollection(o)
+			if err != nil {
+				return fmt.Errorf("error preparing VolumeSnapshot resource %v/%v: %v", metadata.GetNamespace(), metadata.GetName(), err)
+			}
+		case "VolumeSnapshotContent":
+			err := prepareVolumeSnapshotContentForCollection(o)
+			if err != nil {
+				return fmt.Errorf("error preparing VolumeSnapshotContent resource %v: %v", metadata.GetName(), err)
+			}
 		}
 
 		content := o.UnstructuredContent()
@@ -684,9 +709,9 @@ func (r *ResourceCollector) includeObject(
 		return false, err
 	}
 
-	// Even if PV isn't specified need to check if the corresponding PVC is, so
-	// skip the check here
-	if objectType.GetKind() != "PersistentVolume" {
+	// Even if PV or VolumeSnapshotContent isn't specified need to check if
+	// the corresponding PVC or VolumeSnapshot is, so skip the check here
+	if objectType.GetKind() != "PersistentVolume" && objectType.GetKind() != "VolumeSnapshotContent" {
 		info := stork_api.ObjectInfo{
 			GroupVersionKind: metav1.GroupVersionKind{
 				Group:   object.GetObjectKind().GroupVersionKind().Group,
@@ -721,6 +746,8 @@ func (r *ResourceCollector) PrepareResourceForApply(
 	pvNameMappings map[string]string,
 	optionalResourceTypes []string,
 	vInfo []*stork_api.ApplicationRestoreVolumeInfo,
+	jobPolicy *stork_api.JobPolicy,
+	certificatePolicy *stork_api.CertificatePolicy,
 ) (bool, error) {
 
 	objectType, err := meta.TypeAccessor(object)
@@ -752,11 +779,22 @@ func (r *ResourceCollector) PrepareResourceForApply(
 
 	switch objectType.GetKind() {
 	case "Job":
-		if slice.ContainsString(optionalResourceTypes, "job", strings.ToLower) ||
-			slice.ContainsString(optionalResourceTypes, "jobs", strings.ToLower) {
+		if !slice.ContainsString(optionalResourceTypes, "job", strings.ToLower) &&
+			!slice.ContainsString(optionalResourceTypes, "jobs", strings.ToLower) {
+			return true, nil
+		}
+		if jobPolicy == nil {
 			return false, nil
 		}
-		return true, nil
+		if skip, err := SkipJob(object, jobPolicy); err != nil || skip {
+			return skip, err
+		}
+		return false, PrepareJobForApply(object, jobPolicy)
+	case "CronJob":
+		if jobPolicy == nil || jobPolicy.StartCronJobs {
+			return false, nil
+		}
+		return false, PrepareCronJobForApply(object)
 	case "PersistentVolume":
 		return r.preparePVResourceForApply(object, pvNameMappings, vInfo)
 	case "PersistentVolumeClaim":
@@ -765,6 +803,20 @@ func (r *ResourceCollector) PrepareResourceForApply(
 		return false, r.prepareClusterRoleBindingForApply(object, namespaceMappings)
 	case "RoleBinding":
 		return false, r.prepareRoleBindingForApply(object, namespaceMappings)
+	case "VolumeSnapshot":
+		if !includesVolumeSnapshots(optionalResourceTypes) {
+			return true, nil
+		}
+		return false, prepareVolumeSnapshotForApply(object)
+	case "VolumeSnapshotContent":
+		if !includesVolumeSnapshots(optionalResourceTypes) {
+			return true, nil
+		}
+		return prepareVolumeSnapshotContentForApply(object, namespaceMappings)
+	case "Secret":
+		return skipCertificateSecret(object, allObjects, namespaceMappings, certificatePolicy, time.Now())
+	case "CertificateRequest", "Order", "Challenge":
+		return skipCertificateIssuance(object, certificatePolicy)
 	}
 	return false, nil
 }
@@ -831,6 +883,46 @@ func (r *ResourceCollector) ApplyResource(
 	return err
 }
 
+// ObjectExists returns true if the object already exists on the cluster of
+// the client interface
+func (r *ResourceCollector) ObjectExists(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (bool, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return false, err
+	}
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return false, err
+	}
+	_, err = dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetObject returns the object from the cluster of the client interface
+func (r *ResourceCollector) GetObject(
+	dynamicInterface dynamic.Interface,
+	object runtime.Unstructured,
+) (*unstructured.Unstructured, error) {
+	metadata, err := meta.Accessor(object)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := r.getDynamicClient(dynamicInterface, object)
+	if err != nil {
+		return nil, err
+	}
+	return dynamicClient.Get(context.TODO(), metadata.GetName(), metav1.GetOptions{})
+}
+
 // DeleteResources deletes given resources using the provided client interface
 func (r *ResourceCollector) DeleteResources(
 	dynamicInterface dynamic.Interface,
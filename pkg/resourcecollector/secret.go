@@ -3,6 +3,7 @@ package resourcecollector
 import (
 	"strings"
 
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -10,6 +11,7 @@ import (
 
 func (r *ResourceCollector) secretToBeCollected(
 	object runtime.Unstructured,
+	secretTypes *stork_api.SecretTypeFilter,
 ) (bool, error) {
 	var secret v1.Secret
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(object.UnstructuredContent(), &secret); err != nil {
@@ -23,6 +25,29 @@ func (r *ResourceCollector) secretToBeCollected(
 			return false, nil
 		}
 	}
-	return true, nil
+	return secretTypeToBeCollected(secret.Type, secretTypes), nil
 
 }
+
+// secretTypeToBeCollected checks the type of the Secret against the filter
+func secretTypeToBeCollected(secretType v1.SecretType, filter *stork_api.SecretTypeFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if secretType == "" {
+		secretType = v1.SecretTypeOpaque
+	}
+	if len(filter.IncludeTypes) != 0 && !containsSecretType(filter.IncludeTypes, secretType) {
+		return false
+	}
+	return !containsSecretType(filter.ExcludeTypes, secretType)
+}
+
+func containsSecretType(types []string, secretType v1.SecretType) bool {
+	for _, t := range types {
+		if strings.TrimSpace(t) == string(secretType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newSecret(name string, secretType v1.SecretType) *unstructured.Unstructured {
+	secret := &unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetName(name)
+	secret.SetNamespace("test")
+	if secretType != "" {
+		secret.Object["type"] = string(secretType)
+	}
+	return secret
+}
+
+func TestSecretTypeFilter(t *testing.T) {
+	r := &ResourceCollector{}
+	collect, err := r.secretToBeCollected(newSecret("release", "helm.sh/release.v1"), nil)
+	require.NoError(t, err)
+	require.True(t, collect, "all Secrets should be collected without a filter")
+
+	filter := &stork_api.SecretTypeFilter{
+		IncludeTypes: []string{string(v1.SecretTypeOpaque), string(v1.SecretTypeTLS)},
+	}
+	collect, err = r.secretToBeCollected(newSecret("untyped", ""), filter)
+	require.NoError(t, err)
+	require.True(t, collect, "Secrets without a type should be treated as Opaque")
+	collect, err = r.secretToBeCollected(newSecret("cert", v1.SecretTypeTLS), filter)
+	require.NoError(t, err)
+	require.True(t, collect)
+	collect, err = r.secretToBeCollected(newSecret("release", "helm.sh/release.v1"), filter)
+	require.NoError(t, err)
+	require.False(t, collect)
+
+	filter = &stork_api.SecretTypeFilter{
+		ExcludeTypes: []string{"helm.sh/release.v1", string(v1.SecretTypeServiceAccountToken)},
+	}
+	collect, err = r.secretToBeCollected(newSecret("token", v1.SecretTypeServiceAccountToken), filter)
+	require.NoError(t, err)
+	require.False(t, collect)
+	collect, err = r.secretToBeCollected(newSecret("cert", v1.SecretTypeTLS), filter)
+	require.NoError(t, err)
+	require.True(t, collect)
+
+	collect, err = r.secretToBeCollected(newSecret("token", v1.SecretTypeServiceAccountToken), nil)
+	require.NoError(t, err)
+	require.True(t, collect, "all Secrets should be collected without a filter")
+}
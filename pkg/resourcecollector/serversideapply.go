@@ -0,0 +1,161 @@
+package resourcecollector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldConflict is a field of an object that is managed by another field
+// manager on the cluster
+type FieldConflict struct {
+	Field   string
+	Manager string
+}
+
+// FieldConflictError is returned by ServerSideApply when fields of the object
+// are managed by other field managers and can't be skipped
+type FieldConflictError struct {
+	Conflicts []FieldConflict
+}
+
+func (e *FieldConflictError) Error() string {
+	conflicts := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		conflicts = append(conflicts, fmt.Sprintf("%v (managed by %v)", c.Field, c.Manager))
+	}
+	return fmt.Sprintf("fields are managed by other controllers on the cluster: %v", strings.Join(conflicts, ", "))
+}
+
+// ServerSideApply applies the object using server-side apply with the given
+// field manager. Fields that are managed by other field managers on the
+// cluster, for example the replicas of a Deployment scaled by an HPA, are left
+// alone and returned. Fields previously set by stork without server-side
+// apply are taken over.
+func ServerSideApply(
+	dynamicClient dynamic.ResourceInterface,
+	object *unstructured.Unstructured,
+	fieldManager string,
+) ([]string, error) {
+	err := serverSideApply(dynamicClient, object, fieldManager, false)
+	if err == nil {
+		return nil, nil
+	}
+	conflicts := getFieldConflicts(err)
+	if len(conflicts) == 0 {
+		return nil, err
+	}
+
+	// Drop the fields that are managed by others and force the apply for
+	// the rest
+	skippedFields := make([]string, 0)
+	unresolved := make([]FieldConflict, 0)
+	obj := object.DeepCopy()
+	for _, c := range conflicts {
+		if isStorkFieldManager(c.Manager) {
+			continue
+		}
+		path, ok := parseFieldPath(c.Field)
+		if !ok {
+			unresolved = append(unresolved, c)
+			continue
+		}
+		unstructured.RemoveNestedField(obj.Object, path...)
+		skippedFields = append(skippedFields, c.Field)
+	}
+	if len(unresolved) != 0 {
+		return nil, &FieldConflictError{Conflicts: unresolved}
+	}
+	if err := serverSideApply(dynamicClient, obj, fieldManager, true); err != nil {
+		return nil, err
+	}
+	return skippedFields, nil
+}
+
+// IsServerSideApplyConflict returns true if the error from ServerSideApply
+// is caused by fields that are managed by other field managers
+func IsServerSideApplyConflict(err error) bool {
+	if _, ok := err.(*FieldConflictError); ok {
+		return true
+	}
+	return apierrors.IsConflict(err)
+}
+
+func serverSideApply(
+	dynamicClient dynamic.ResourceInterface,
+	object *unstructured.Unstructured,
+	fieldManager string,
+	force bool,
+) error {
+	data, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	_, err = dynamicClient.Patch(
+		context.TODO(),
+		object.GetName(),
+		types.ApplyPatchType,
+		data,
+		metav1.PatchOptions{
+			FieldManager: fieldManager,
+			Force:        &force,
+		})
+	return err
+}
+
+// getFieldConflicts returns the field manager conflicts reported in an apply
+// error
+func getFieldConflicts(err error) []FieldConflict {
+	if !apierrors.IsConflict(err) {
+		return nil
+	}
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || status.Status().Details == nil {
+		return nil
+	}
+	conflicts := make([]FieldConflict, 0)
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, FieldConflict{
+			Field:   cause.Field,
+			Manager: parseConflictManager(cause.Message),
+		})
+	}
+	return conflicts
+}
+
+// parseConflictManager returns the manager from a conflict message of the
+// form `conflict with "manager" using apps/v1`
+func parseConflictManager(message string) string {
+	start := strings.Index(message, "\"")
+	if start == -1 {
+		return message
+	}
+	end := strings.Index(message[start+1:], "\"")
+	if end == -1 {
+		return message
+	}
+	return message[start+1 : start+1+end]
+}
+
+// parseFieldPath converts a field path like .spec.replicas to its
+// components. Paths into lists can't be converted.
+func parseFieldPath(field string) ([]string, bool) {
+	if !strings.HasPrefix(field, ".") || strings.ContainsAny(field, "[]") {
+		return nil, false
+	}
+	return strings.Split(strings.TrimPrefix(field, "."), "."), true
+}
+
+func isStorkFieldManager(manager string) bool {
+	return strings.HasPrefix(manager, "stork")
+}
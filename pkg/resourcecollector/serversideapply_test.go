@@ -0,0 +1,63 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestServerSideApply(t *testing.T) {
+	t.Run("fieldConflictsTest", fieldConflictsTest)
+	t.Run("parseFieldPathTest", parseFieldPathTest)
+}
+
+func fieldConflictsTest(t *testing.T) {
+	err := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   http.StatusConflict,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kube-controller-manager" using apps/v1`,
+					Field:   ".spec.replicas",
+				},
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "stork" using apps/v1`,
+					Field:   ".spec.template.spec.containers[name=\"nginx\"].image",
+				},
+			},
+		},
+	}}
+	conflicts := getFieldConflicts(err)
+	require.Len(t, conflicts, 2)
+	require.Equal(t, FieldConflict{Field: ".spec.replicas", Manager: "kube-controller-manager"}, conflicts[0])
+	require.Equal(t, "stork", conflicts[1].Manager)
+	require.True(t, isStorkFieldManager(conflicts[1].Manager))
+	require.False(t, isStorkFieldManager(conflicts[0].Manager))
+
+	conflictErr := &FieldConflictError{Conflicts: conflicts[:1]}
+	require.Contains(t, conflictErr.Error(), ".spec.replicas (managed by kube-controller-manager)")
+
+	require.Len(t, getFieldConflicts(apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "test")), 0)
+}
+
+func parseFieldPathTest(t *testing.T) {
+	path, ok := parseFieldPath(".spec.replicas")
+	require.True(t, ok)
+	require.Equal(t, []string{"spec", "replicas"}, path)
+
+	_, ok = parseFieldPath(".spec.template.spec.containers[name=\"nginx\"].image")
+	require.False(t, ok)
+	_, ok = parseFieldPath("spec")
+	require.False(t, ok)
+}
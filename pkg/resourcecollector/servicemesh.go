@@ -0,0 +1,200 @@
+package resourcecollector
+
+import (
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	istioRevisionKey = "istio.io/rev"
+)
+
+// serviceMeshGroups are the API groups of the Istio and Linkerd resources
+var serviceMeshGroups = map[string]bool{
+	"networking.istio.io": true,
+	"security.istio.io":   true,
+	"telemetry.istio.io":  true,
+	"extensions.istio.io": true,
+	"linkerd.io":          true,
+	"policy.linkerd.io":   true,
+}
+
+// serviceMeshKeys are the labels and annotations that control sidecar
+// injection or configure the sidecars
+var serviceMeshKeys = map[string]bool{
+	"istio-injection":   true,
+	istioRevisionKey:    true,
+	"linkerd.io/inject": true,
+}
+
+var serviceMeshKeyPrefixes = []string{
+	"sidecar.istio.io/",
+	"proxy.istio.io/",
+	"traffic.sidecar.istio.io/",
+	"config.linkerd.io/",
+	"config.alpha.linkerd.io/",
+	"linkerd.io/proxy-",
+}
+
+// serviceMeshContainers are the containers and volumes added to pods that
+// were injected manually, e.g. with istioctl kube-inject
+var serviceMeshContainers = map[string]bool{
+	"istio-proxy":               true,
+	"istio-init":                true,
+	"istio-validation":          true,
+	"linkerd-proxy":             true,
+	"linkerd-init":              true,
+	"linkerd-network-validator": true,
+}
+
+var serviceMeshVolumes = map[string]bool{
+	"istio-envoy":                     true,
+	"istio-data":                      true,
+	"istio-podinfo":                   true,
+	"istio-token":                     true,
+	"istiod-ca-cert":                  true,
+	"linkerd-proxy-init-xtables-lock": true,
+	"linkerd-identity-end-entity":     true,
+}
+
+// Paths to the objects with metadata and pod specs that can carry mesh
+// configuration: the object itself, the pod template of workloads and the
+// pod template of CronJobs
+var serviceMeshTemplatePaths = [][]string{
+	{},
+	{"spec", "template"},
+	{"spec", "jobTemplate", "spec", "template"},
+}
+
+func isServiceMeshKey(key string) bool {
+	if serviceMeshKeys[key] {
+		return true
+	}
+	for _, prefix := range serviceMeshKeyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsServiceMeshResource returns true if the resource belongs to the Istio or
+// Linkerd APIs, e.g. Sidecars or PeerAuthentications
+func IsServiceMeshResource(gvk schema.GroupVersionKind) bool {
+	return serviceMeshGroups[gvk.Group]
+}
+
+// PrepareServiceMeshMetadata strips or remaps the service mesh labels and
+// annotations in place according to the policy
+func PrepareServiceMeshMetadata(labels, annotations map[string]string, policy *stork_api.ServiceMeshPolicy) {
+	if policy == nil {
+		return
+	}
+	for _, m := range []map[string]string{labels, annotations} {
+		for key, value := range m {
+			if policy.Strip {
+				if isServiceMeshKey(key) {
+					delete(m, key)
+				}
+			} else if key == istioRevisionKey {
+				if revision, ok := policy.RevisionMapping[value]; ok {
+					m[key] = revision
+				}
+			}
+		}
+	}
+}
+
+// PrepareServiceMeshForApply updates the service mesh configuration of the
+// object according to the policy. It returns true if the object is a mesh
+// resource that should be skipped because the mesh is being stripped.
+func PrepareServiceMeshForApply(object runtime.Unstructured, policy *stork_api.ServiceMeshPolicy) (bool, error) {
+	if policy == nil {
+		return false, nil
+	}
+	if policy.Strip && IsServiceMeshResource(object.GetObjectKind().GroupVersionKind()) {
+		return true, nil
+	}
+
+	content := object.UnstructuredContent()
+
+	for _, path := range serviceMeshTemplatePaths {
+		template := content
+		if len(path) != 0 {
+			field, found, err := unstructured.NestedFieldNoCopy(content, path...)
+			if err != nil || !found {
+				continue
+			}
+			var ok bool
+			if template, ok = field.(map[string]interface{}); !ok {
+				continue
+			}
+		}
+		if err := prepareServiceMeshTemplate(template, policy); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func prepareServiceMeshTemplate(template map[string]interface{}, policy *stork_api.ServiceMeshPolicy) error {
+	labels, _, err := unstructured.NestedStringMap(template, "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	annotations, _, err := unstructured.NestedStringMap(template, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	PrepareServiceMeshMetadata(labels, annotations, policy)
+	if len(labels) != 0 {
+		if err := unstructured.SetNestedStringMap(template, labels, "metadata", "labels"); err != nil {
+			return err
+		}
+	} else {
+		unstructured.RemoveNestedField(template, "metadata", "labels")
+	}
+	if len(annotations) != 0 {
+		if err := unstructured.SetNestedStringMap(template, annotations, "metadata", "annotations"); err != nil {
+			return err
+		}
+	} else {
+		unstructured.RemoveNestedField(template, "metadata", "annotations")
+	}
+
+	if !policy.Strip {
+		return nil
+	}
+	// Only pods and pod templates have containers
+	if _, found, _ := unstructured.NestedSlice(template, "spec", "containers"); !found {
+		return nil
+	}
+	if err := removeNamedItems(template, serviceMeshContainers, "spec", "containers"); err != nil {
+		return err
+	}
+	if err := removeNamedItems(template, serviceMeshContainers, "spec", "initContainers"); err != nil {
+		return err
+	}
+	return removeNamedItems(template, serviceMeshVolumes, "spec", "volumes")
+}
+
+func removeNamedItems(content map[string]interface{}, names map[string]bool, fields ...string) error {
+	items, found, err := unstructured.NestedSlice(content, fields...)
+	if err != nil || !found {
+		return err
+	}
+	filtered := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			if name, _, _ := unstructured.NestedString(m, "name"); names[name] {
+				continue
+			}
+		}
+		filtered = append(filtered, item)
+	}
+	return unstructured.SetNestedSlice(content, filtered, fields...)
+}
@@ -0,0 +1,99 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newMeshDeployment(t *testing.T) *unstructured.Unstructured {
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "test",
+			Labels:    map[string]string{"app": "web", "istio.io/rev": "1-10"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"app": "web", "istio.io/rev": "1-10"},
+					Annotations: map[string]string{
+						"sidecar.istio.io/inject": "true",
+						"linkerd.io/inject":       "enabled",
+						"prometheus.io/scrape":    "true",
+					},
+				},
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "istio-init"}, {Name: "migrate"}},
+					Containers:     []v1.Container{{Name: "web"}, {Name: "istio-proxy"}},
+					Volumes:        []v1.Volume{{Name: "data"}, {Name: "istio-envoy"}},
+				},
+			},
+		},
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: content}
+}
+
+func TestPrepareServiceMeshForApplyStrip(t *testing.T) {
+	policy := &stork_api.ServiceMeshPolicy{Strip: true}
+
+	peerAuthentication := &unstructured.Unstructured{}
+	peerAuthentication.SetAPIVersion("security.istio.io/v1beta1")
+	peerAuthentication.SetKind("PeerAuthentication")
+	skip, err := PrepareServiceMeshForApply(peerAuthentication, policy)
+	require.NoError(t, err)
+	require.True(t, skip, "mesh resources should be skipped when stripping the mesh")
+
+	object := newMeshDeployment(t)
+	skip, err = PrepareServiceMeshForApply(object, policy)
+	require.NoError(t, err)
+	require.False(t, skip)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &deployment))
+	require.Equal(t, map[string]string{"app": "web"}, deployment.Labels)
+	require.Equal(t, map[string]string{"app": "web"}, deployment.Spec.Template.Labels)
+	require.Equal(t, map[string]string{"prometheus.io/scrape": "true"}, deployment.Spec.Template.Annotations)
+	require.Equal(t, []v1.Container{{Name: "migrate"}}, deployment.Spec.Template.Spec.InitContainers)
+	require.Equal(t, []v1.Container{{Name: "web"}}, deployment.Spec.Template.Spec.Containers)
+	require.Equal(t, []v1.Volume{{Name: "data"}}, deployment.Spec.Template.Spec.Volumes)
+}
+
+func TestPrepareServiceMeshForApplyRevisionMapping(t *testing.T) {
+	policy := &stork_api.ServiceMeshPolicy{RevisionMapping: map[string]string{"1-10": "1-12"}}
+
+	object := newMeshDeployment(t)
+	skip, err := PrepareServiceMeshForApply(object, policy)
+	require.NoError(t, err)
+	require.False(t, skip)
+
+	var deployment appsv1.Deployment
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(object.Object, &deployment))
+	require.Equal(t, "1-12", deployment.Labels["istio.io/rev"])
+	require.Equal(t, "1-12", deployment.Spec.Template.Labels["istio.io/rev"])
+	require.Equal(t, "true", deployment.Spec.Template.Annotations["sidecar.istio.io/inject"])
+	require.Len(t, deployment.Spec.Template.Spec.Containers, 2, "sidecars should only be removed when stripping the mesh")
+}
+
+func TestPrepareServiceMeshMetadata(t *testing.T) {
+	labels := map[string]string{"istio-injection": "enabled", "team": "web"}
+	annotations := map[string]string{"linkerd.io/inject": "enabled", "config.linkerd.io/proxy-cpu-limit": "1"}
+	PrepareServiceMeshMetadata(labels, annotations, nil)
+	require.Len(t, labels, 2, "metadata shouldn't change without a policy")
+
+	PrepareServiceMeshMetadata(labels, annotations, &stork_api.ServiceMeshPolicy{Strip: true})
+	require.Equal(t, map[string]string{"team": "web"}, labels)
+	require.Empty(t, annotations)
+}
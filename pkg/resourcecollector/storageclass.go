@@ -0,0 +1,150 @@
+package resourcecollector
+
+import (
+	"fmt"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/storage"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8shelper "k8s.io/component-helpers/storage/volume"
+)
+
+const (
+	// StorageClassSourceAnnotation is set on the storage classes created on
+	// the destination to the name of the storage class they were created from
+	StorageClassSourceAnnotation = "stork.libopenstorage.org/source-storage-class"
+
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+)
+
+// GetPVCStorageClasses returns the names of the storage classes used by the
+// PVCs in the objects
+func GetPVCStorageClasses(objects []runtime.Unstructured) ([]string, error) {
+	names := make([]string, 0)
+	found := make(map[string]bool)
+	for _, o := range objects {
+		if o.GetObjectKind().GroupVersionKind().Kind != "PersistentVolumeClaim" {
+			continue
+		}
+		var pvc v1.PersistentVolumeClaim
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(o.UnstructuredContent(), &pvc); err != nil {
+			return nil, err
+		}
+		name := k8shelper.GetPersistentVolumeClaimClass(&pvc)
+		if name == "" || found[name] {
+			continue
+		}
+		found[name] = true
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// MapStorageClass returns the storage class to create on the destination
+// with the given name from the storage class on the source. The provisioner
+// and the parameters are changed as set in the policy. The created storage
+// class is never made the default one.
+func MapStorageClass(
+	sc *storagev1.StorageClass,
+	name string,
+	policy *stork_api.StorageClassCreationPolicy,
+) *storagev1.StorageClass {
+	annotations := make(map[string]string)
+	for k, v := range sc.Annotations {
+		if k == defaultStorageClassAnnotation || k == betaDefaultStorageClassAnnotation ||
+			k == v1.LastAppliedConfigAnnotation {
+			continue
+		}
+		annotations[k] = v
+	}
+	annotations[StorageClassSourceAnnotation] = sc.Name
+
+	mapped := &storagev1.StorageClass{
+		TypeMeta: sc.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      sc.Labels,
+			Annotations: annotations,
+		},
+		Provisioner:          sc.Provisioner,
+		Parameters:           sc.Parameters,
+		ReclaimPolicy:        sc.ReclaimPolicy,
+		MountOptions:         sc.MountOptions,
+		AllowVolumeExpansion: sc.AllowVolumeExpansion,
+		VolumeBindingMode:    sc.VolumeBindingMode,
+		AllowedTopologies:    sc.AllowedTopologies,
+	}
+	if policy == nil {
+		return mapped
+	}
+	if provisioner, ok := policy.ProvisionerMapping[sc.Provisioner]; ok && provisioner != "" {
+		mapped.Provisioner = provisioner
+	}
+	mapped.Parameters = mapStorageClassParameters(sc.Parameters, policy.ParameterMapping)
+	return mapped
+}
+
+// mapStorageClassParameters applies the mapping to the parameters of a
+// storage class. A mapping for <parameter>=<value> takes precedence over one
+// for the parameter. Parameters mapped to an empty value are dropped, and
+// mapped parameters that aren't set are added.
+func mapStorageClassParameters(parameters map[string]string, mapping map[string]string) map[string]string {
+	if len(mapping) == 0 {
+		return parameters
+	}
+	mapped := make(map[string]string)
+	for key, value := range parameters {
+		if newValue, ok := mapping[key+"="+value]; ok {
+			value = newValue
+		} else if newValue, ok := mapping[key]; ok {
+			value = newValue
+		}
+		if value != "" {
+			mapped[key] = value
+		}
+	}
+	for key, value := range mapping {
+		if _, ok := parameters[key]; !ok && !strings.Contains(key, "=") && value != "" {
+			mapped[key] = value
+		}
+	}
+	if len(mapped) == 0 {
+		return nil
+	}
+	return mapped
+}
+
+// CreateMissingStorageClasses creates the storage classes from the source
+// that don't exist on the destination. The storage classes are renamed with
+// the storage class mapping before they are looked up. Returns the names of
+// the storage classes that were created.
+func CreateMissingStorageClasses(
+	destination storage.Ops,
+	sources []*storagev1.StorageClass,
+	storageClassMapping map[string]string,
+	policy *stork_api.StorageClassCreationPolicy,
+) ([]string, error) {
+	created := make([]string, 0)
+	for _, sc := range sources {
+		name := sc.Name
+		if mapped, ok := storageClassMapping[name]; ok && mapped != "" {
+			name = mapped
+		}
+		if _, err := destination.GetStorageClass(name); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return created, fmt.Errorf("error getting storage class %v: %v", name, err)
+		}
+		if _, err := destination.CreateStorageClass(MapStorageClass(sc, name, policy)); err != nil && !errors.IsAlreadyExists(err) {
+			return created, fmt.Errorf("error creating storage class %v: %v", name, err)
+		}
+		created = append(created, name)
+	}
+	return created, nil
+}
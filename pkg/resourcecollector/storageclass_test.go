@@ -0,0 +1,89 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/storage"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func newStorageClass(name, provisioner string, parameters map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{defaultStorageClassAnnotation: "true", "team": "db"},
+		},
+		Provisioner: provisioner,
+		Parameters:  parameters,
+	}
+}
+
+func TestMapStorageClass(t *testing.T) {
+	sc := newStorageClass("fast", "kubernetes.io/aws-ebs", map[string]string{"type": "gp2", "fsType": "ext4", "iopsPerGB": "10"})
+	policy := &stork_api.StorageClassCreationPolicy{
+		ProvisionerMapping: map[string]string{"kubernetes.io/aws-ebs": "ebs.csi.aws.com"},
+		ParameterMapping: map[string]string{
+			"type=gp2":                  "gp3",
+			"iopsPerGB":                 "",
+			"csi.storage.k8s.io/fstype": "ext4",
+		},
+	}
+	mapped := MapStorageClass(sc, "fast-dest", policy)
+	require.Equal(t, "fast-dest", mapped.Name)
+	require.Equal(t, "ebs.csi.aws.com", mapped.Provisioner)
+	require.Equal(t, map[string]string{"type": "gp3", "fsType": "ext4", "csi.storage.k8s.io/fstype": "ext4"}, mapped.Parameters)
+	require.Equal(t, map[string]string{"team": "db", StorageClassSourceAnnotation: "fast"}, mapped.Annotations,
+		"created storage classes aren't made the default")
+
+	mapped = MapStorageClass(sc, "fast", nil)
+	require.Equal(t, sc.Provisioner, mapped.Provisioner)
+	require.Equal(t, sc.Parameters, mapped.Parameters)
+}
+
+func TestCreateMissingStorageClasses(t *testing.T) {
+	destination := storage.New(fakekubeclient.NewSimpleClientset(newStorageClass("existing", "px", nil)).StorageV1())
+	sources := []*storagev1.StorageClass{
+		newStorageClass("existing", "px", nil),
+		newStorageClass("missing", "px", nil),
+		newStorageClass("renamed", "px", nil),
+	}
+	created, err := CreateMissingStorageClasses(destination, sources, map[string]string{"renamed": "mapped"}, &stork_api.StorageClassCreationPolicy{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"missing", "mapped"}, created)
+	sc, err := destination.GetStorageClass("mapped")
+	require.NoError(t, err)
+	require.Equal(t, "renamed", sc.Annotations[StorageClassSourceAnnotation])
+
+	created, err = CreateMissingStorageClasses(destination, sources, map[string]string{"renamed": "mapped"}, &stork_api.StorageClassCreationPolicy{})
+	require.NoError(t, err)
+	require.Empty(t, created)
+}
+
+func TestGetPVCStorageClasses(t *testing.T) {
+	objects := make([]runtime.Unstructured, 0)
+	for _, class := range []string{"fast", "", "fast", "slow"} {
+		pvc := &v1.PersistentVolumeClaim{
+			TypeMeta:   metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc", Namespace: "test"},
+		}
+		if class != "" {
+			pvc.Spec.StorageClassName = &class
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pvc)
+		require.NoError(t, err)
+		objects = append(objects, &unstructured.Unstructured{Object: content})
+	}
+	names, err := GetPVCStorageClasses(objects)
+	require.NoError(t, err)
+	require.Equal(t, []string{"fast", "slow"}, names)
+}
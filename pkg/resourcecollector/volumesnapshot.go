@@ -0,0 +1,199 @@
+package resourcecollector
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/kubernetes/pkg/util/slice"
+)
+
+const (
+	// VolumeSnapshotResourceType is the optional resource type used to
+	// collect and restore the CSI VolumeSnapshots of an application along
+	// with their VolumeSnapshotContents
+	VolumeSnapshotResourceType = "VolumeSnapshot"
+
+	snapshotGroup            = "snapshot.storage.k8s.io"
+	volumeSnapshotResource   = "volumesnapshots"
+	snapshotDeletionRetain   = "Retain"
+	volumeSnapshotRefField   = "volumeSnapshotRef"
+	volumeSnapshotSourceName = "volumeSnapshotContentName"
+)
+
+func includesVolumeSnapshots(optionalResourceTypes []string) bool {
+	return slice.ContainsString(optionalResourceTypes, "volumesnapshot", strings.ToLower) ||
+		slice.ContainsString(optionalResourceTypes, "volumesnapshots", strings.ToLower)
+}
+
+// volumeSnapshotToBeCollected only collects the snapshots that are ready.
+// Snapshots created by stork itself, e.g. for CSI backups, are skipped.
+func volumeSnapshotToBeCollected(object runtime.Unstructured) (bool, error) {
+	content := object.UnstructuredContent()
+	snapshotLabels, _, err := unstructured.NestedStringMap(content, "metadata", "labels")
+	if err != nil {
+		return false, err
+	}
+	if snapshotLabels[k8sutils.CreatedByLabel] == k8sutils.CreatedByStork {
+		return false, nil
+	}
+	ready, _, err := unstructured.NestedBool(content, "status", "readyToUse")
+	if err != nil || !ready {
+		return false, err
+	}
+	contentName, _, err := unstructured.NestedString(content, "status", "boundVolumeSnapshotContentName")
+	if err != nil {
+		return false, err
+	}
+	return contentName != "", nil
+}
+
+// volumeSnapshotContentToBeCollected collects the contents bound to the
+// snapshots that are collected from the namespace
+func (r *ResourceCollector) volumeSnapshotContentToBeCollected(
+	includeObjects map[stork_api.ObjectInfo]bool,
+	labelSelectors map[string]string,
+	object runtime.Unstructured,
+	namespace string,
+) (bool, error) {
+	content := object.UnstructuredContent()
+	snapshotHandle, _, err := unstructured.NestedString(content, "status", "snapshotHandle")
+	if err != nil || snapshotHandle == "" {
+		return false, err
+	}
+	snapshotRef, found, err := unstructured.NestedStringMap(content, "spec", volumeSnapshotRefField)
+	if err != nil || !found {
+		return false, err
+	}
+	if snapshotRef["namespace"] != namespace || snapshotRef["name"] == "" {
+		return false, nil
+	}
+
+	gv := object.GetObjectKind().GroupVersionKind().GroupVersion()
+	snapshot, err := r.dynamicInterface.Resource(gv.WithResource(volumeSnapshotResource)).Namespace(namespace).Get(
+		context.TODO(), snapshotRef["name"], metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	// The snapshot could have been recreated with the same name
+	if uid := snapshotRef["uid"]; uid != "" && uid != string(snapshot.GetUID()) {
+		return false, nil
+	}
+
+	if len(includeObjects) > 0 {
+		info := stork_api.ObjectInfo{
+			GroupVersionKind: metav1.GroupVersionKind{
+				Group:   gv.Group,
+				Version: gv.Version,
+				Kind:    "VolumeSnapshot",
+			},
+			Name:      snapshot.GetName(),
+			Namespace: snapshot.GetNamespace(),
+		}
+		if val, present := includeObjects[info]; !present || !val {
+			return false, nil
+		}
+	}
+	if !isSubset(labels.Set(labelSelectors), labels.Set(snapshot.GetLabels())) {
+		return false, nil
+	}
+	return volumeSnapshotToBeCollected(snapshot)
+}
+
+// prepareVolumeSnapshotForCollection points the snapshot to its content, so
+// that it is restored as a pre-provisioned snapshot
+func prepareVolumeSnapshotForCollection(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	contentName, _, err := unstructured.NestedString(content, "status", "boundVolumeSnapshotContentName")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedStringMap(content, map[string]string{volumeSnapshotSourceName: contentName}, "spec", "source"); err != nil {
+		return err
+	}
+	delete(content, "status")
+	return nil
+}
+
+// prepareVolumeSnapshotContentForCollection points the content to the
+// snapshot on the storage instead of the volume it was taken from. The
+// deletion policy is set to Retain so that deleting the restored snapshots
+// doesn't delete the snapshots on the storage, which are still referenced
+// by the source.
+func prepareVolumeSnapshotContentForCollection(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	snapshotHandle, _, err := unstructured.NestedString(content, "status", "snapshotHandle")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedStringMap(content, map[string]string{"snapshotHandle": snapshotHandle}, "spec", "source"); err != nil {
+		return err
+	}
+	for _, field := range []string{"uid", "resourceVersion"} {
+		unstructured.RemoveNestedField(content, "spec", volumeSnapshotRefField, field)
+	}
+	if err := unstructured.SetNestedField(content, snapshotDeletionRetain, "spec", "deletionPolicy"); err != nil {
+		return err
+	}
+	delete(content, "status")
+	return nil
+}
+
+// restoredVolumeSnapshotContentName returns the name of the content restored
+// for a snapshot in the namespace. The contents are renamed since they are
+// cluster scoped and the source contents can still exist.
+func restoredVolumeSnapshotContentName(name, namespace string) string {
+	return fmt.Sprintf("%s-%s", name, namespace)
+}
+
+// prepareVolumeSnapshotForApply updates the snapshot to point to the
+// restored content. The namespace of the snapshot has already been mapped.
+func prepareVolumeSnapshotForApply(object runtime.Unstructured) error {
+	content := object.UnstructuredContent()
+	contentName, found, err := unstructured.NestedString(content, "spec", "source", volumeSnapshotSourceName)
+	if err != nil || !found {
+		return err
+	}
+	namespace, _, err := unstructured.NestedString(content, "metadata", "namespace")
+	if err != nil {
+		return err
+	}
+	return unstructured.SetNestedField(content, restoredVolumeSnapshotContentName(contentName, namespace),
+		"spec", "source", volumeSnapshotSourceName)
+}
+
+// prepareVolumeSnapshotContentForApply renames the content and binds it to
+// the snapshot in the mapped namespace. Contents of snapshots in namespaces
+// that aren't restored are skipped.
+func prepareVolumeSnapshotContentForApply(
+	object runtime.Unstructured,
+	namespaceMappings map[string]string,
+) (bool, error) {
+	content := object.UnstructuredContent()
+	namespace, _, err := unstructured.NestedString(content, "spec", volumeSnapshotRefField, "namespace")
+	if err != nil {
+		return false, err
+	}
+	destNamespace, ok := namespaceMappings[namespace]
+	if !ok {
+		return true, nil
+	}
+	name, _, err := unstructured.NestedString(content, "metadata", "name")
+	if err != nil {
+		return false, err
+	}
+	if err := unstructured.SetNestedField(content, restoredVolumeSnapshotContentName(name, destNamespace), "metadata", "name"); err != nil {
+		return false, err
+	}
+	return false, unstructured.SetNestedField(content, destNamespace, "spec", volumeSnapshotRefField, "namespace")
+}
@@ -0,0 +1,89 @@
+//go:build unittest
+// +build unittest
+
+package resourcecollector
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newVolumeSnapshot(labels map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": snapshotGroup + "/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata":   map[string]interface{}{"name": "daily", "namespace": "app", "labels": labels},
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{"persistentVolumeClaimName": "data"},
+		},
+		"status": map[string]interface{}{"readyToUse": true, "boundVolumeSnapshotContentName": "snapcontent-1"},
+	}}
+}
+
+func newVolumeSnapshotContent() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": snapshotGroup + "/v1",
+		"kind":       "VolumeSnapshotContent",
+		"metadata":   map[string]interface{}{"name": "snapcontent-1"},
+		"spec": map[string]interface{}{
+			"deletionPolicy": "Delete",
+			"driver":         "pd.csi.storage.gke.io",
+			"source":         map[string]interface{}{"volumeHandle": "vol-1"},
+			volumeSnapshotRefField: map[string]interface{}{
+				"name": "daily", "namespace": "app", "uid": "1234", "resourceVersion": "10",
+			},
+		},
+		"status": map[string]interface{}{"snapshotHandle": "snap-1", "readyToUse": true},
+	}}
+}
+
+func TestVolumeSnapshotToBeCollected(t *testing.T) {
+	collect, err := volumeSnapshotToBeCollected(newVolumeSnapshot(nil))
+	require.NoError(t, err)
+	require.True(t, collect)
+
+	collect, err = volumeSnapshotToBeCollected(newVolumeSnapshot(map[string]interface{}{k8sutils.CreatedByLabel: k8sutils.CreatedByStork}))
+	require.NoError(t, err)
+	require.False(t, collect, "snapshots created by stork should not be collected")
+
+	snapshot := newVolumeSnapshot(nil)
+	require.NoError(t, unstructured.SetNestedField(snapshot.Object, false, "status", "readyToUse"))
+	collect, err = volumeSnapshotToBeCollected(snapshot)
+	require.NoError(t, err)
+	require.False(t, collect, "snapshots that aren't ready should not be collected")
+}
+
+func TestVolumeSnapshotRestoredAsReference(t *testing.T) {
+	snapshot := newVolumeSnapshot(nil)
+	require.NoError(t, prepareVolumeSnapshotForCollection(snapshot))
+	snapshot.SetNamespace("app-restored")
+	require.NoError(t, prepareVolumeSnapshotForApply(snapshot))
+	source, _, err := unstructured.NestedStringMap(snapshot.Object, "spec", "source")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{volumeSnapshotSourceName: "snapcontent-1-app-restored"}, source)
+	_, found := snapshot.Object["status"]
+	require.False(t, found)
+
+	content := newVolumeSnapshotContent()
+	require.NoError(t, prepareVolumeSnapshotContentForCollection(content))
+	skip, err := prepareVolumeSnapshotContentForApply(content, map[string]string{"app": "app-restored"})
+	require.NoError(t, err)
+	require.False(t, skip)
+	require.Equal(t, "snapcontent-1-app-restored", content.GetName())
+	source, _, err = unstructured.NestedStringMap(content.Object, "spec", "source")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"snapshotHandle": "snap-1"}, source)
+	ref, _, err := unstructured.NestedStringMap(content.Object, "spec", volumeSnapshotRefField)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"name": "daily", "namespace": "app-restored"}, ref)
+	policy, _, err := unstructured.NestedString(content.Object, "spec", "deletionPolicy")
+	require.NoError(t, err)
+	require.Equal(t, snapshotDeletionRetain, policy)
+
+	skip, err = prepareVolumeSnapshotContentForApply(newVolumeSnapshotContent(), map[string]string{"other": "other"})
+	require.NoError(t, err)
+	require.True(t, skip, "contents of snapshots in namespaces that aren't restored should be skipped")
+}
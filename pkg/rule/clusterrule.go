@@ -0,0 +1,115 @@
+package rule
+
+import (
+	"fmt"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var clusterRuleTypeMeta = metav1.TypeMeta{
+	Kind:       "ClusterRule",
+	APIVersion: stork_api.SchemeGroupVersion.String(),
+}
+
+// GetRule returns the rule referenced by name from a resource in namespace.
+// Names starting with stork_api.ClusterRuleReferencePrefix refer to a
+// ClusterRule, which is returned as a Rule.
+func GetRule(name, namespace string) (*stork_api.Rule, error) {
+	clusterRuleName, version, ok := parseClusterRuleReference(name)
+	if !ok {
+		return storkops.Instance().GetRule(name, namespace)
+	}
+	clusterRule, err := getClusterRule(clusterRuleName, version)
+	if err != nil {
+		return nil, err
+	}
+	return &stork_api.Rule{
+		ObjectMeta: clusterRule.ObjectMeta,
+		Rules:      clusterRule.Rules,
+	}, nil
+}
+
+// parseClusterRuleReference returns the name and the pinned version, if
+// any, of a reference to a ClusterRule. Returns false if the name refers to
+// a Rule.
+func parseClusterRuleReference(name string) (string, string, bool) {
+	if !strings.HasPrefix(name, stork_api.ClusterRuleReferencePrefix) {
+		return "", "", false
+	}
+	name = strings.TrimPrefix(name, stork_api.ClusterRuleReferencePrefix)
+	version := ""
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		name, version = name[:i], name[i+1:]
+	}
+	return name, version, true
+}
+
+// getClusterRule returns the ClusterRule with the given name. If a version
+// is pinned and the ClusterRule with the name has another version, the
+// ClusterRule of the library with the name and the pinned version is
+// returned instead.
+func getClusterRule(name, version string) (*stork_api.ClusterRule, error) {
+	object, err := dynamic.Instance().GetObject(&stork_api.ClusterRule{
+		TypeMeta:   clusterRuleTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		clusterRule := &stork_api.ClusterRule{}
+		if err := fromUnstructured(object, clusterRule); err != nil {
+			return nil, err
+		}
+		if version == "" || clusterRule.Version == version {
+			return clusterRule, nil
+		}
+	} else if version == "" {
+		return nil, err
+	}
+
+	list, err := dynamic.Instance().ListObjects(&metav1.ListOptions{
+		TypeMeta:      clusterRuleTypeMeta,
+		LabelSelector: fmt.Sprintf("%v=%v", stork_api.RuleLibraryLabel, name),
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+	var found *stork_api.ClusterRule
+	for i := range list.Items {
+		clusterRule := &stork_api.ClusterRule{}
+		if err := fromUnstructured(&list.Items[i], clusterRule); err != nil {
+			return nil, err
+		}
+		if clusterRule.Version != version {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("ClusterRules %v and %v both have version %v of rule library %v",
+				found.Name, clusterRule.Name, version, name)
+		}
+		found = clusterRule
+	}
+	if found == nil {
+		return nil, errors.NewNotFound(
+			schema.GroupResource{Group: stork_api.SchemeGroupVersion.Group, Resource: stork_api.ClusterRuleResourcePlural},
+			name+"@"+version)
+	}
+	return found, nil
+}
+
+func fromUnstructured(object runtime.Object, into interface{}) error {
+	u, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for ClusterRule", object)
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), into)
+}
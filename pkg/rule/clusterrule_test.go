@@ -0,0 +1,67 @@
+//go:build unittest
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func clusterRule(name, library, version, command string) *stork_api.ClusterRule {
+	r := &stork_api.ClusterRule{
+		TypeMeta:   clusterRuleTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Version:    version,
+		Rules:      commandRule(command).Rules,
+	}
+	if library != "" {
+		r.Labels = map[string]string{stork_api.RuleLibraryLabel: library}
+	}
+	return r
+}
+
+func TestGetRule(t *testing.T) {
+	storkops.SetInstance(storkops.New(fakekubeclient.NewSimpleClientset(), fakestorkclient.NewSimpleClientset(commandRule("echo namespaced")), nil))
+	scheme := runtime.NewScheme()
+	require.NoError(t, stork_api.AddToScheme(scheme))
+	dynamic.SetInstance(dynamic.New(fakedynamic.NewSimpleDynamicClient(scheme,
+		clusterRule("postgres-hooks", "postgres-hooks", "v2", "echo v2"),
+		clusterRule("postgres-hooks-v1", "postgres-hooks", "v1", "echo v1"),
+		clusterRule("mysql-hooks", "", "", "echo mysql"),
+	)))
+
+	r, err := GetRule("rule", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "echo namespaced", r.Rules[0].Actions[0].Value)
+
+	r, err = GetRule("clusterrule/mysql-hooks", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "mysql-hooks", r.Name)
+	require.Equal(t, "echo mysql", r.Rules[0].Actions[0].Value)
+
+	r, err = GetRule("clusterrule/postgres-hooks@v2", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "echo v2", r.Rules[0].Actions[0].Value)
+
+	// Older versions of a library are found by its label
+	r, err = GetRule("clusterrule/postgres-hooks@v1", "ns")
+	require.NoError(t, err)
+	require.Equal(t, "postgres-hooks-v1", r.Name)
+	require.Equal(t, "echo v1", r.Rules[0].Actions[0].Value)
+
+	_, err = GetRule("clusterrule/postgres-hooks@v3", "ns")
+	require.True(t, errors.IsNotFound(err), "unexpected error %v", err)
+	_, err = GetRule("clusterrule/missing", "ns")
+	require.True(t, errors.IsNotFound(err), "unexpected error %v", err)
+}
@@ -14,18 +14,15 @@ import (
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/cmdexecutor"
 	"github.com/libopenstorage/stork/pkg/cmdexecutor/status"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/k8s/dynamic"
 	errors "github.com/portworx/sched-ops/k8s/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/skyrings/skyring-common/tools/uuid"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
-	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -35,8 +32,6 @@ import (
 )
 
 const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
 	// environment variable for command executor image registry and image registry secret.
 	cmdExecutorImageRegistryEnvVar       = "CMD-EXECUTOR-IMAGE-REGISTRY"
 	cmdExecutorImageRegistrySecretEnvVar = "CMD-EXECUTOR-IMAGE-REGISTRY-SECRET"
@@ -102,31 +97,10 @@ var ownerAPICallBackoff = wait.Backoff{
 
 // Init initializes the rule executor
 func Init() error {
-	storkRuleResource := apiextensions.CustomResource{
-		Name:    "rule",
-		Plural:  "rules",
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.Rule{}).Name(),
-	}
-
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(storkRuleResource)
-		if err != nil && !k8s_errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(storkRuleResource.Plural+"."+storkRuleResource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(storkRuleResource)
-	if err != nil && !k8s_errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(storkRuleResource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(
+		reflect.TypeOf(stork_api.Rule{}).Name(),
+		reflect.TypeOf(stork_api.ClusterRule{}).Name(),
+	)
 }
 
 // ValidateRule validates a rule
@@ -137,6 +111,10 @@ func ValidateRule(rule *stork_api.Rule, ruleType Type) error {
 				if action.Background && ruleType == PostExecRule {
 					return fmt.Errorf("background actions are not supported for post exec rules")
 				}
+				if err := validateTemplate(&action); err != nil {
+					return fmt.Errorf("invalid template variables in command %q of rule: [%s] %s: %v",
+						action.Value, rule.GetNamespace(), rule.GetName(), err)
+				}
 			} else {
 				return fmt.Errorf("unsupported action type: %s in rule: [%s] %s",
 					action.Type, rule.GetNamespace(), rule.GetName())
@@ -239,6 +217,13 @@ func ExecuteRule(
 		return nil, err
 	}
 
+	// Resolve the template variables once so that all the commands see the
+	// same trigger time
+	rule, err := renderRule(rule, owner, podNamespace, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
 	log.RuleLog(rule, owner).Infof("Running %v", rType)
 	taskID, err := uuid.New()
 	if err != nil {
@@ -0,0 +1,144 @@
+package rule
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
+	"github.com/portworx/sched-ops/k8s/core"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// TemplateVariables are the variables that can be used in the commands of
+// rule actions that have Template set, for example
+// "pg_dump -f /backup/{{.Namespace}}-{{.Timestamp}}.sql". They are resolved
+// from the object the rule is run for when the rule is executed.
+type TemplateVariables struct {
+	// Namespace is the namespace of the pods the rule is run on
+	Namespace string
+	// Name is the name of the object the rule is run for
+	Name string
+	// Kind is the kind of the object the rule is run for, for example
+	// "ApplicationBackup"
+	Kind string
+	// SnapshotName is the name of the snapshot or group snapshot being taken.
+	// It is empty for other operations.
+	SnapshotName string
+	// PVCs are the names of the PVCs being snapshotted for snapshots and
+	// group snapshots, or else the PVCs in the namespace
+	PVCs []string
+	// Timestamp is the time the rule was triggered in UTC, formatted as
+	// 20060102T150405Z so that it can be used in file names
+	Timestamp string
+	// Time is the time the rule was triggered. It can be used for other
+	// formats, for example {{.Time.Format "2006-01-02"}}.
+	Time time.Time
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// isTemplate returns true if the command of the action needs its template
+// variables resolved. Commands of other actions are run as is, even if they
+// contain braces.
+func isTemplate(action *stork_api.RuleAction) bool {
+	return action.Type == stork_api.RuleActionCommand && action.Template
+}
+
+func renderCommand(command string, vars *TemplateVariables) (string, error) {
+	t, err := template.New("command").Funcs(templateFuncs).Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// validateTemplate checks that the command of the action is a valid template
+// and only uses the known variables
+func validateTemplate(action *stork_api.RuleAction) error {
+	if !isTemplate(action) {
+		return nil
+	}
+	_, err := renderCommand(action.Value, &TemplateVariables{})
+	return err
+}
+
+// getTemplateVariables returns the variables for the rule run for owner on the
+// pods in namespace
+func getTemplateVariables(owner runtime.Object, namespace string, triggerTime time.Time) (*TemplateVariables, error) {
+	vars := &TemplateVariables{
+		Namespace: namespace,
+		Timestamp: triggerTime.UTC().Format(k8sutils.TriggerTimeFormat),
+		Time:      triggerTime,
+	}
+	if metadata, err := meta.Accessor(owner); err == nil {
+		vars.Name = metadata.GetName()
+	}
+	if objectType, err := meta.TypeAccessor(owner); err == nil {
+		vars.Kind = objectType.GetKind()
+	}
+
+	var selector map[string]string
+	switch o := owner.(type) {
+	case *snapv1.VolumeSnapshot:
+		vars.SnapshotName = o.Metadata.Name
+		if o.Spec.PersistentVolumeClaimName != "" {
+			vars.PVCs = []string{o.Spec.PersistentVolumeClaimName}
+			return vars, nil
+		}
+	case *stork_api.GroupVolumeSnapshot:
+		vars.SnapshotName = o.Name
+		selector = o.Spec.PVCSelector.MatchLabels
+	}
+	pvcs, err := core.Instance().GetPersistentVolumeClaims(namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PVCs for rule template variables: %v", err)
+	}
+	vars.PVCs = make([]string, 0, len(pvcs.Items))
+	for _, pvc := range pvcs.Items {
+		vars.PVCs = append(vars.PVCs, pvc.Name)
+	}
+	return vars, nil
+}
+
+// renderRule returns the rule with the template variables in the commands
+// resolved. The rule is returned as is if none of the actions are templates.
+func renderRule(rule *stork_api.Rule, owner runtime.Object, namespace string, triggerTime time.Time) (*stork_api.Rule, error) {
+	var vars *TemplateVariables
+	rendered := rule.DeepCopy()
+	for i := range rendered.Rules {
+		for j := range rendered.Rules[i].Actions {
+			action := &rendered.Rules[i].Actions[j]
+			if !isTemplate(action) {
+				continue
+			}
+			if vars == nil {
+				var err error
+				if vars, err = getTemplateVariables(owner, namespace, triggerTime); err != nil {
+					return nil, err
+				}
+			}
+			value, err := renderCommand(action.Value, vars)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve template variables in command %q of rule [%s] %s: %v",
+					action.Value, rule.GetNamespace(), rule.GetName(), err)
+			}
+			action.Value = value
+		}
+	}
+	if vars == nil {
+		return rule, nil
+	}
+	return rendered, nil
+}
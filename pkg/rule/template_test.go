@@ -0,0 +1,81 @@
+//go:build unittest
+// +build unittest
+
+package rule
+
+import (
+	"testing"
+	"time"
+
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func commandRule(commands ...string) *stork_api.Rule {
+	actions := make([]stork_api.RuleAction, 0)
+	for _, c := range commands {
+		actions = append(actions, stork_api.RuleAction{Type: stork_api.RuleActionCommand, Value: c})
+	}
+	return &stork_api.Rule{
+		ObjectMeta: metav1.ObjectMeta{Name: "rule", Namespace: "ns"},
+		Rules:      []stork_api.RuleItem{{Actions: actions}},
+	}
+}
+
+// templateRule returns a rule whose commands have their template variables
+// resolved
+func templateRule(commands ...string) *stork_api.Rule {
+	r := commandRule(commands...)
+	for i := range r.Rules[0].Actions {
+		r.Rules[0].Actions[i].Template = true
+	}
+	return r
+}
+
+func TestValidateRuleTemplate(t *testing.T) {
+	require.NoError(t, ValidateRule(templateRule("pg_dump -f /backup/{{.Namespace}}-{{.Timestamp}}.sql"), PreExecRule))
+	require.NoError(t, ValidateRule(templateRule("echo {{join .PVCs \",\"}} {{.Time.Format \"2006\"}}"), PreExecRule))
+	require.Error(t, ValidateRule(templateRule("echo {{.Namespace"), PreExecRule), "invalid template")
+	require.Error(t, ValidateRule(templateRule("echo {{.Unknown}}"), PreExecRule), "unknown variable")
+	// Commands of actions that aren't templates aren't parsed
+	require.NoError(t, ValidateRule(commandRule("echo {{.Unknown}}"), PreExecRule))
+}
+
+func TestRenderRule(t *testing.T) {
+	core.SetInstance(core.New(fakekubeclient.NewSimpleClientset(
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "ns"}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc2", Namespace: "ns"}},
+		&v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	)))
+	triggerTime := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	// Commands of actions that aren't templates are left as is
+	r := commandRule("echo hello", "docker inspect -f '{{.State.Pid}}' app")
+	rendered, err := renderRule(r, &stork_api.ApplicationBackup{}, "ns", triggerTime)
+	require.NoError(t, err)
+	require.True(t, r == rendered)
+
+	backup := &stork_api.ApplicationBackup{
+		TypeMeta:   metav1.TypeMeta{Kind: "ApplicationBackup"},
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"},
+	}
+	r = templateRule("dump > /{{.Kind}}/{{.Name}}/{{.Namespace}}-{{.Timestamp}}", "echo {{join .PVCs \" \"}}{{.SnapshotName}}")
+	rendered, err = renderRule(r, backup, "ns", triggerTime)
+	require.NoError(t, err)
+	require.Equal(t, "dump > /ApplicationBackup/backup/ns-20210304T050607Z", rendered.Rules[0].Actions[0].Value)
+	require.Equal(t, "echo pvc1 pvc2", rendered.Rules[0].Actions[1].Value)
+	require.Contains(t, r.Rules[0].Actions[0].Value, "{{", "the rule passed in is not modified")
+
+	snap := &snapv1.VolumeSnapshot{
+		Metadata: metav1.ObjectMeta{Name: "snap", Namespace: "ns"},
+		Spec:     snapv1.VolumeSnapshotSpec{PersistentVolumeClaimName: "pvc2"},
+	}
+	rendered, err = renderRule(templateRule("snap {{.SnapshotName}} of {{.PVCs}}"), snap, "ns", triggerTime)
+	require.NoError(t, err)
+	require.Equal(t, "snap snap of [pvc2]", rendered.Rules[0].Actions[0].Value)
+}
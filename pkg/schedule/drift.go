@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DriftRunCount is the number of consecutive runs that need to start
+	// late before the drift of a schedule is reported, so that a single
+	// late run doesn't raise it
+	DriftRunCount = 3
+	// DefaultDriftThreshold is how late a run can start after it was due
+	// before it is considered late
+	DefaultDriftThreshold = 5 * time.Minute
+)
+
+// Run is a run triggered for a schedule
+type Run struct {
+	// CreationTimestamp is the time the run was started
+	CreationTimestamp meta.Time
+	// ScheduledTimestamp is the time the run was scheduled at. It is only
+	// set for runs that caught up for missed runs.
+	ScheduledTimestamp meta.Time
+}
+
+// StartDelays returns how late each of the runs of a policy started after
+// it was due, in the order of the runs, which need to be sorted by creation.
+// Runs that caught up for missed runs are late by design and skipped, as is
+// the first run of interval policies since the runs are due relative to the
+// previous one.
+func StartDelays(
+	policyName string,
+	namespace string,
+	policyType stork_api.SchedulePolicyType,
+	runs []Run,
+) ([]time.Duration, error) {
+	schedulePolicy, err := getSchedulePolicy(policyName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
+		return nil, err
+	}
+
+	delays := make([]time.Duration, 0)
+	var lastTrigger meta.Time
+	for _, run := range runs {
+		started := run.CreationTimestamp.Time
+		previous := lastTrigger
+		lastTrigger = run.CreationTimestamp
+		if !run.ScheduledTimestamp.IsZero() {
+			lastTrigger = run.ScheduledTimestamp
+			continue
+		}
+
+		var due time.Time
+		if policyType == stork_api.SchedulePolicyTypeInterval {
+			if schedulePolicy.Policy.Interval == nil || previous.IsZero() {
+				continue
+			}
+			due = previous.Add(time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute)
+		} else {
+			due, err = previousTrigger(schedulePolicy, policyType, started)
+			if err != nil {
+				return nil, err
+			}
+			if due.IsZero() {
+				continue
+			}
+		}
+		delay := started.Sub(due)
+		if delay < 0 {
+			delay = 0
+		}
+		delays = append(delays, delay)
+	}
+	return delays, nil
+}
+
+// Drifting returns true if the last DriftRunCount runs all started later than
+// the threshold after they were due
+func Drifting(delays []time.Duration, threshold time.Duration) bool {
+	if len(delays) < DriftRunCount {
+		return false
+	}
+	for _, delay := range delays[len(delays)-DriftRunCount:] {
+		if delay <= threshold {
+			return false
+		}
+	}
+	return true
+}
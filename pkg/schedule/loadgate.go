@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	loadGateQueryTimeout = 10 * time.Second
+)
+
+// prometheusResponse is the response of the Prometheus instant query API
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// DeferRequired checks if a run that is due should be deferred because the
+// load reported by the gate is above its threshold. deferredSince is the time
+// the run was first deferred and is used to bound the deferral. Returns the
+// reason when the run should be deferred.
+func DeferRequired(gate *stork_api.LoadGate, deferredSince meta.Time) (bool, string, error) {
+	if gate == nil {
+		return false, "", nil
+	}
+	if !deferredSince.IsZero() && gate.MaxDeferralMinutes > 0 &&
+		GetCurrentTime().Sub(deferredSince.Time) >= time.Duration(gate.MaxDeferralMinutes)*time.Minute {
+		return false, "", nil
+	}
+	threshold, err := strconv.ParseFloat(gate.Threshold, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("invalid threshold %v for load gate: %v", gate.Threshold, err)
+	}
+	value, err := queryLoad(gate.PrometheusURL, gate.Query)
+	if err != nil {
+		return false, "", err
+	}
+	if value <= threshold {
+		return false, "", nil
+	}
+	return true, fmt.Sprintf("Load %v is above threshold %v", value, gate.Threshold), nil
+}
+
+// queryLoad runs an instant query against Prometheus and returns the highest
+// value in the result
+func queryLoad(prometheusURL string, query string) (float64, error) {
+	if prometheusURL == "" || query == "" {
+		return 0, fmt.Errorf("prometheusURL and query are required for load gate")
+	}
+	client := &http.Client{Timeout: loadGateQueryTimeout}
+	queryURL := strings.TrimSuffix(prometheusURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	resp, err := client.Get(queryURL)
+	if err != nil {
+		return 0, fmt.Errorf("error querying prometheus: %v", err)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	var promResp prometheusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&promResp); err != nil {
+		return 0, fmt.Errorf("error decoding response from prometheus (%v): %v", resp.Status, err)
+	}
+	if promResp.Status != "success" {
+		return 0, fmt.Errorf("error querying prometheus: %v: %v", promResp.ErrorType, promResp.Error)
+	}
+
+	var values [][2]interface{}
+	switch promResp.Data.ResultType {
+	case "scalar":
+		var sample [2]interface{}
+		if err := json.Unmarshal(promResp.Data.Result, &sample); err != nil {
+			return 0, err
+		}
+		values = append(values, sample)
+	case "vector":
+		var samples []struct {
+			Value [2]interface{} `json:"value"`
+		}
+		if err := json.Unmarshal(promResp.Data.Result, &samples); err != nil {
+			return 0, err
+		}
+		for _, s := range samples {
+			values = append(values, s.Value)
+		}
+	default:
+		return 0, fmt.Errorf("unsupported result type %v for load gate query", promResp.Data.ResultType)
+	}
+	if len(values) == 0 {
+		return 0, fmt.Errorf("load gate query returned no results")
+	}
+
+	var max float64
+	for i, v := range values {
+		str, ok := v[1].(string)
+		if !ok {
+			return 0, fmt.Errorf("invalid value %v in prometheus response", v[1])
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %v in prometheus response: %v", str, err)
+		}
+		if i == 0 || f > max {
+			max = f
+		}
+	}
+	return max, nil
+}
@@ -0,0 +1,69 @@
+//go:build unittest
+// +build unittest
+
+package schedule
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadGate(t *testing.T) {
+	load := "0.5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/query", r.URL.Path)
+		require.Equal(t, "rate(requests[5m])", r.URL.Query().Get("query"))
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"pod":"a"},"value":[1600000000,"0.1"]},`+
+			`{"metric":{"pod":"b"},"value":[1600000000,"%v"]}]}}`, load)
+	}))
+	defer server.Close()
+
+	mockNow := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	defer setMockTime(nil)
+
+	deferred, _, err := DeferRequired(nil, meta.Time{})
+	require.NoError(t, err)
+	require.False(t, deferred, "Should not defer without a load gate")
+
+	gate := &stork_api.LoadGate{
+		PrometheusURL:      server.URL,
+		Query:              "rate(requests[5m])",
+		Threshold:          "0.8",
+		MaxDeferralMinutes: 30,
+	}
+	deferred, _, err = DeferRequired(gate, meta.Time{})
+	require.NoError(t, err)
+	require.False(t, deferred, "Should not defer when load is below threshold")
+
+	load = "0.9"
+	deferred, reason, err := DeferRequired(gate, meta.Time{})
+	require.NoError(t, err)
+	require.True(t, deferred, "Should defer when load is above threshold")
+	require.Contains(t, reason, "0.9")
+
+	deferred, _, err = DeferRequired(gate, meta.NewTime(mockNow.Add(-29*time.Minute)))
+	require.NoError(t, err)
+	require.True(t, deferred, "Should defer within max deferral")
+
+	deferred, _, err = DeferRequired(gate, meta.NewTime(mockNow.Add(-30*time.Minute)))
+	require.NoError(t, err)
+	require.False(t, deferred, "Should not defer beyond max deferral")
+
+	gate.Threshold = "high"
+	_, _, err = DeferRequired(gate, meta.Time{})
+	require.Error(t, err, "Expected error for invalid threshold")
+
+	gate.Threshold = "0.8"
+	gate.PrometheusURL = ""
+	_, _, err = DeferRequired(gate, meta.Time{})
+	require.Error(t, err, "Expected error without prometheus URL")
+}
@@ -0,0 +1,82 @@
+package schedule
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// maintenanceCheckInterval is how long the result of the node checks is
+	// reused so that the nodes aren't listed on every reconcile of every
+	// schedule
+	maintenanceCheckInterval = 30 * time.Second
+)
+
+var (
+	maintenanceLock      sync.Mutex
+	maintenanceCheckedAt time.Time
+	maintenanceConfig    stork_api.MaintenanceConfiguration
+	maintenanceReason    string
+)
+
+// MaintenanceInProgress checks if the cluster is under maintenance as per the
+// given configuration. Returns the reason when it is. Scheduled runs
+// shouldn't be triggered during maintenance.
+func MaintenanceInProgress(config *stork_api.MaintenanceConfiguration) (bool, string, error) {
+	if config == nil {
+		return false, "", nil
+	}
+	if config.Enabled {
+		return true, "Maintenance is enabled in the stork configuration", nil
+	}
+	if config.NodeSelector == "" && config.UnschedulableNodesThreshold <= 0 {
+		return false, "", nil
+	}
+
+	maintenanceLock.Lock()
+	defer maintenanceLock.Unlock()
+	now := GetCurrentTime()
+	if maintenanceConfig == *config && now.Sub(maintenanceCheckedAt) < maintenanceCheckInterval {
+		return maintenanceReason != "", maintenanceReason, nil
+	}
+	reason, err := checkNodesForMaintenance(config)
+	if err != nil {
+		return false, "", err
+	}
+	maintenanceConfig = *config
+	maintenanceCheckedAt = now
+	maintenanceReason = reason
+	return reason != "", reason, nil
+}
+
+func checkNodesForMaintenance(config *stork_api.MaintenanceConfiguration) (string, error) {
+	selector := labels.Nothing()
+	if config.NodeSelector != "" {
+		var err error
+		if selector, err = labels.Parse(config.NodeSelector); err != nil {
+			return "", fmt.Errorf("invalid node selector %v for maintenance: %v", config.NodeSelector, err)
+		}
+	}
+	nodes, err := core.Instance().GetNodes()
+	if err != nil {
+		return "", fmt.Errorf("error getting nodes to check for maintenance: %v", err)
+	}
+	unschedulable := 0
+	for _, node := range nodes.Items {
+		if selector.Matches(labels.Set(node.Labels)) {
+			return fmt.Sprintf("Node %v is being upgraded", node.Name), nil
+		}
+		if node.Spec.Unschedulable {
+			unschedulable++
+		}
+	}
+	if config.UnschedulableNodesThreshold > 0 && unschedulable >= config.UnschedulableNodesThreshold {
+		return fmt.Sprintf("%v nodes are unschedulable", unschedulable), nil
+	}
+	return "", nil
+}
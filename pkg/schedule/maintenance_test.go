@@ -0,0 +1,73 @@
+//go:build unittest
+// +build unittest
+
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMaintenanceInProgress(t *testing.T) {
+	fakeKubeClient := kubernetes.NewSimpleClientset(
+		&v1.Node{ObjectMeta: meta.ObjectMeta{Name: "node1"}, Spec: v1.NodeSpec{Unschedulable: true}},
+		&v1.Node{ObjectMeta: meta.ObjectMeta{Name: "node2"}, Spec: v1.NodeSpec{Unschedulable: true}},
+		&v1.Node{ObjectMeta: meta.ObjectMeta{Name: "node3", Labels: map[string]string{"upgrade": "pending"}}},
+	)
+	core.SetInstance(core.New(fakeKubeClient))
+
+	mockNow := time.Date(2019, time.February, 7, 23, 16, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	defer setMockTime(nil)
+
+	inMaintenance, _, err := MaintenanceInProgress(nil)
+	require.NoError(t, err)
+	require.False(t, inMaintenance, "Should not be in maintenance without configuration")
+
+	inMaintenance, reason, err := MaintenanceInProgress(&stork_api.MaintenanceConfiguration{Enabled: true})
+	require.NoError(t, err)
+	require.True(t, inMaintenance, "Should be in maintenance when enabled")
+	require.NotEmpty(t, reason)
+
+	inMaintenance, _, err = MaintenanceInProgress(&stork_api.MaintenanceConfiguration{NodeSelector: "upgrade=running"})
+	require.NoError(t, err)
+	require.False(t, inMaintenance, "Should not be in maintenance when no nodes match the selector")
+
+	inMaintenance, reason, err = MaintenanceInProgress(&stork_api.MaintenanceConfiguration{NodeSelector: "upgrade"})
+	require.NoError(t, err)
+	require.True(t, inMaintenance, "Should be in maintenance when a node matches the selector")
+	require.Contains(t, reason, "node3")
+
+	inMaintenance, _, err = MaintenanceInProgress(&stork_api.MaintenanceConfiguration{UnschedulableNodesThreshold: 3})
+	require.NoError(t, err)
+	require.False(t, inMaintenance, "Should not be in maintenance below the threshold")
+
+	config := &stork_api.MaintenanceConfiguration{UnschedulableNodesThreshold: 2}
+	inMaintenance, _, err = MaintenanceInProgress(config)
+	require.NoError(t, err)
+	require.True(t, inMaintenance, "Should be in maintenance at the threshold")
+
+	// The result is reused until the check interval has passed
+	_, err = fakeKubeClient.CoreV1().Nodes().Update(context.TODO(),
+		&v1.Node{ObjectMeta: meta.ObjectMeta{Name: "node1"}}, meta.UpdateOptions{})
+	require.NoError(t, err)
+	inMaintenance, _, err = MaintenanceInProgress(config)
+	require.NoError(t, err)
+	require.True(t, inMaintenance, "Should reuse the result within the check interval")
+
+	mockNow = mockNow.Add(maintenanceCheckInterval)
+	inMaintenance, _, err = MaintenanceInProgress(config)
+	require.NoError(t, err)
+	require.False(t, inMaintenance, "Should not be in maintenance after the node is uncordoned")
+
+	_, _, err = MaintenanceInProgress(&stork_api.MaintenanceConfiguration{NodeSelector: "upgrade in (("})
+	require.Error(t, err, "Should fail for an invalid selector")
+}
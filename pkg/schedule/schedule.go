@@ -7,22 +7,17 @@ import (
 	"time"
 
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
-	"github.com/libopenstorage/stork/pkg/k8sutils"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const (
-	validateCRDInterval time.Duration = 5 * time.Second
-	validateCRDTimeout  time.Duration = 1 * time.Minute
 	// storkTestModeEnvVariable is env variable to enable test mode features in stork
 	storkTestModeEnvVariable = "TEST_MODE"
 	// MockTimeConfigMapName is the name of the config map used to mock times
@@ -64,7 +59,212 @@ func TriggerRequired(
 	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
 		return false, err
 	}
+	return triggerRequired(schedulePolicy, policyType, lastTrigger)
+}
+
+// TriggerRequiredWithMissedRunPolicy checks if a trigger is required for a
+// policy given the last trigger time, handling runs that were missed since
+// the last trigger as per the missed run policy. It also returns the time at
+// which the triggered run was scheduled, which should be used as the last
+// trigger time for the next check. The time is zero if the run isn't catching
+// up for a missed run.
+func TriggerRequiredWithMissedRunPolicy(
+	policyName string,
+	namespace string,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+	missedRunPolicy stork_api.MissedRunPolicyType,
+) (bool, meta.Time, error) {
+	schedulePolicy, err := getSchedulePolicy(policyName, namespace)
+	if err != nil {
+		return false, meta.Time{}, err
+	}
+
+	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
+		return false, meta.Time{}, err
+	}
+
+	switch missedRunPolicy {
+	case "":
+		trigger, err := triggerRequired(schedulePolicy, policyType, lastTrigger)
+		return trigger, meta.Time{}, err
+	case stork_api.MissedRunPolicySkipAll, stork_api.MissedRunPolicyRunOnce, stork_api.MissedRunPolicyRunAll:
+	default:
+		return false, meta.Time{}, fmt.Errorf("invalid missed run policy %v", missedRunPolicy)
+	}
 
+	now := GetCurrentTime()
+	if policyType == stork_api.SchedulePolicyTypeInterval {
+		if schedulePolicy.Policy.Interval == nil {
+			return false, meta.Time{}, nil
+		}
+		// Always trigger the first run
+		if lastTrigger.IsZero() {
+			return true, meta.Time{}, nil
+		}
+		duration := time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute
+		nextTrigger := lastTrigger.Add(duration)
+		if now.Before(nextTrigger) {
+			return false, meta.Time{}, nil
+		}
+		missed := int64(now.Sub(lastTrigger.Time) / duration)
+		switch missedRunPolicy {
+		case stork_api.MissedRunPolicySkipAll:
+			// Only trigger close to one of the scheduled times
+			latestTrigger := lastTrigger.Add(time.Duration(missed) * duration)
+			window := time.Hour
+			if duration < window {
+				window = duration
+			}
+			return missed == 1 || now.Sub(latestTrigger) < window, meta.Time{}, nil
+		case stork_api.MissedRunPolicyRunAll:
+			if missed > 1 {
+				return true, meta.NewTime(nextTrigger), nil
+			}
+		}
+		return true, meta.Time{}, nil
+	}
+
+	latestTrigger, err := previousTrigger(schedulePolicy, policyType, now)
+	if err != nil || latestTrigger.IsZero() {
+		return false, meta.Time{}, err
+	}
+	if !latestTrigger.After(lastTrigger.Time) {
+		return false, meta.Time{}, nil
+	}
+	// Runs aren't caught up for new schedules
+	if lastTrigger.IsZero() || missedRunPolicy == stork_api.MissedRunPolicySkipAll {
+		return now.Sub(latestTrigger) < time.Hour, meta.Time{}, nil
+	}
+	if missedRunPolicy == stork_api.MissedRunPolicyRunAll {
+		// Find the earliest run that was missed
+		earliestTrigger := latestTrigger
+		for {
+			trigger, err := previousTrigger(schedulePolicy, policyType, earliestTrigger.Add(-time.Second))
+			if err != nil {
+				return false, meta.Time{}, err
+			}
+			if !trigger.After(lastTrigger.Time) || !trigger.Before(earliestTrigger) {
+				break
+			}
+			earliestTrigger = trigger
+		}
+		if earliestTrigger.Before(latestTrigger) {
+			return true, meta.NewTime(earliestTrigger), nil
+		}
+	}
+	return true, meta.Time{}, nil
+}
+
+// DueTrigger returns the earliest time at which a run of the policy was due
+// after the last trigger time. The time is zero if no run is due.
+func DueTrigger(
+	policyName string,
+	namespace string,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+) (time.Time, error) {
+	schedulePolicy, err := getSchedulePolicy(policyName, namespace)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := ValidateSchedulePolicy(schedulePolicy); err != nil {
+		return time.Time{}, err
+	}
+
+	now := GetCurrentTime()
+	if policyType == stork_api.SchedulePolicyTypeInterval {
+		if schedulePolicy.Policy.Interval == nil {
+			return time.Time{}, nil
+		}
+		due := lastTrigger.Add(time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute)
+		if due.After(now) {
+			return time.Time{}, nil
+		}
+		return due, nil
+	}
+
+	dueTrigger, err := previousTrigger(schedulePolicy, policyType, now)
+	if err != nil || !dueTrigger.After(lastTrigger.Time) {
+		return time.Time{}, err
+	}
+	if lastTrigger.IsZero() {
+		return dueTrigger, nil
+	}
+	for {
+		trigger, err := previousTrigger(schedulePolicy, policyType, dueTrigger.Add(-time.Second))
+		if err != nil {
+			return time.Time{}, err
+		}
+		if !trigger.After(lastTrigger.Time) || !trigger.Before(dueTrigger) {
+			return dueTrigger, nil
+		}
+		dueTrigger = trigger
+	}
+}
+
+// previousTrigger returns the latest time at or before the given time at
+// which the daily, weekly or monthly policy was scheduled to run
+func previousTrigger(
+	schedulePolicy *stork_api.SchedulePolicy,
+	policyType stork_api.SchedulePolicyType,
+	before time.Time,
+) (time.Time, error) {
+	switch policyType {
+	case stork_api.SchedulePolicyTypeDaily:
+		if schedulePolicy.Policy.Daily == nil {
+			return time.Time{}, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Daily.GetHourMinute()
+		if err != nil {
+			return time.Time{}, err
+		}
+		trigger := time.Date(before.Year(), before.Month(), before.Day(), policyHour, policyMinute, 0, 0, time.Local)
+		if trigger.After(before) {
+			trigger = trigger.AddDate(0, 0, -1)
+		}
+		return trigger, nil
+	case stork_api.SchedulePolicyTypeWeekly:
+		if schedulePolicy.Policy.Weekly == nil {
+			return time.Time{}, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Weekly.GetHourMinute()
+		if err != nil {
+			return time.Time{}, err
+		}
+		scheduledDay := stork_api.Days[schedulePolicy.Policy.Weekly.Day]
+		trigger := time.Date(before.Year(), before.Month(), before.Day(), policyHour, policyMinute, 0, 0, time.Local)
+		trigger = trigger.AddDate(0, 0, -int((before.Weekday()-scheduledDay+7)%7))
+		if trigger.After(before) {
+			trigger = trigger.AddDate(0, 0, -7)
+		}
+		return trigger, nil
+	case stork_api.SchedulePolicyTypeMonthly:
+		if schedulePolicy.Policy.Monthly == nil {
+			return time.Time{}, nil
+		}
+		policyHour, policyMinute, err := schedulePolicy.Policy.Monthly.GetHourMinute()
+		if err != nil {
+			return time.Time{}, err
+		}
+		// Dates that don't exist in a month roll over to the next month, so
+		// go back until the trigger is before the given time
+		month := before.Month()
+		trigger := time.Date(before.Year(), month, schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
+		for trigger.After(before) {
+			month--
+			trigger = time.Date(before.Year(), month, schedulePolicy.Policy.Monthly.Date, policyHour, policyMinute, 0, 0, time.Local)
+		}
+		return trigger, nil
+	}
+	return time.Time{}, nil
+}
+
+func triggerRequired(
+	schedulePolicy *stork_api.SchedulePolicy,
+	policyType stork_api.SchedulePolicyType,
+	lastTrigger meta.Time,
+) (bool, error) {
 	now := GetCurrentTime()
 	switch policyType {
 	case stork_api.SchedulePolicyTypeInterval:
@@ -219,6 +419,35 @@ func GetRetain(policyName string, namespace string, policyType stork_api.Schedul
 	return 1, nil
 }
 
+// GetRetentionPeriod returns the shortest time for which the objects created
+// for the policy type are retained before they are pruned, which is the
+// retain value times the shortest time between two runs
+func GetRetentionPeriod(policyName string, namespace string, policyType stork_api.SchedulePolicyType) (time.Duration, error) {
+	retain, err := GetRetain(policyName, namespace, policyType)
+	if err != nil {
+		return 0, err
+	}
+	schedulePolicy, err := getSchedulePolicy(policyName, namespace)
+	if err != nil {
+		return 0, err
+	}
+	var period time.Duration
+	switch policyType {
+	case stork_api.SchedulePolicyTypeInterval:
+		if schedulePolicy.Policy.Interval != nil {
+			period = time.Duration(schedulePolicy.Policy.Interval.IntervalMinutes) * time.Minute
+		}
+	case stork_api.SchedulePolicyTypeDaily:
+		period = 24 * time.Hour
+	case stork_api.SchedulePolicyTypeWeekly:
+		period = 7 * 24 * time.Hour
+	case stork_api.SchedulePolicyTypeMonthly:
+		// February is the shortest month
+		period = 28 * 24 * time.Hour
+	}
+	return time.Duration(retain) * period, nil
+}
+
 // GetOptions Returns the options set for a policy type
 func GetOptions(policyName string, namespace string, policyType stork_api.SchedulePolicyType) (map[string]string, error) {
 	schedulePolicy, err := getSchedulePolicy(policyName, namespace)
@@ -392,51 +621,8 @@ func createDefaultPolicy() error {
 }
 
 func createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.SchedulePolicyResourceName,
-		Plural:  stork_api.SchedulePolicyResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.ClusterScoped,
-		Kind:    reflect.TypeOf(stork_api.SchedulePolicy{}).Name(),
-	}
-	policy := apiextensions.CustomResource{
-		Name:    stork_api.NamespacedSchedulePolicyResourceName,
-		Plural:  stork_api.NamespacedSchedulePolicyResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.NamespacedSchedulePolicy{}).Name(),
-	}
-
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		if err := apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval); err != nil {
-			return err
-		}
-		err = k8sutils.CreateCRD(policy)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(policy.Plural+"."+policy.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	if err := apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval); err != nil {
-		return err
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(policy)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(policy, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(
+		reflect.TypeOf(stork_api.SchedulePolicy{}).Name(),
+		reflect.TypeOf(stork_api.NamespacedSchedulePolicy{}).Name(),
+	)
 }
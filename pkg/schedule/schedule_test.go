@@ -34,8 +34,12 @@ func TestSchedule(t *testing.T) {
 	t.Run("triggerDailyRequiredTest", triggerDailyRequiredTest)
 	t.Run("triggerWeeklyRequiredTest", triggerWeeklyRequiredTest)
 	t.Run("triggerMonthlyRequiredTest", triggerMonthlyRequiredTest)
+	t.Run("missedRunPolicyTest", missedRunPolicyTest)
+	t.Run("dueTriggerTest", dueTriggerTest)
+	t.Run("startDelaysTest", startDelaysTest)
 	t.Run("validateSchedulePolicyTest", validateSchedulePolicyTest)
 	t.Run("policyRetainTest", policyRetainTest)
+	t.Run("policyRetentionPeriodTest", policyRetentionPeriodTest)
 	t.Run("policyOptionsTest", policyOptionsTest)
 }
 
@@ -228,6 +232,75 @@ func triggerMonthlyRequiredTest(t *testing.T) {
 	require.False(t, required, "Trigger should not have been required")
 }
 
+func missedRunPolicyTest(t *testing.T) {
+	defer func() {
+		err := storkops.Instance().DeleteSchedulePolicy("missedrunpolicy")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := storkops.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "missedrunpolicy",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 180,
+			},
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	_, _, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeDaily, meta.Time{}, "Invalid")
+	require.Error(t, err, "Should return error for invalid missed run policy")
+
+	// Interval runs at 3AM, 6AM and 9AM were missed
+	mockNow := time.Date(2019, time.February, 8, 10, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	lastTrigger := meta.Date(2019, time.February, 8, 0, 0, 0, 0, time.Local)
+	required, scheduled, err := TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeInterval, lastTrigger, stork_api.MissedRunPolicySkipAll)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+	require.True(t, scheduled.IsZero())
+
+	required, scheduled, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeInterval, lastTrigger, stork_api.MissedRunPolicyRunOnce)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	require.True(t, scheduled.IsZero())
+
+	required, scheduled, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeInterval, lastTrigger, stork_api.MissedRunPolicyRunAll)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	require.Equal(t, time.Date(2019, time.February, 8, 3, 0, 0, 0, time.Local), scheduled.Time)
+
+	// Daily runs on the 6th and 7th were missed
+	mockNow = time.Date(2019, time.February, 8, 21, 15, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	lastTrigger = meta.Date(2019, time.February, 5, 23, 15, 0, 0, time.Local)
+	required, _, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.MissedRunPolicySkipAll)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.False(t, required, "Trigger should not have been required")
+
+	required, scheduled, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.MissedRunPolicyRunOnce)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	require.True(t, scheduled.IsZero())
+
+	required, scheduled, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.MissedRunPolicyRunAll)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	require.Equal(t, time.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), scheduled.Time)
+
+	// Catching up the last missed run doesn't need a scheduled time
+	lastTrigger = meta.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local)
+	required, scheduled, err = TriggerRequiredWithMissedRunPolicy("missedrunpolicy", "default", stork_api.SchedulePolicyTypeDaily, lastTrigger, stork_api.MissedRunPolicyRunAll)
+	require.NoError(t, err, "Error checking if trigger required")
+	require.True(t, required, "Trigger should have been required")
+	require.True(t, scheduled.IsZero())
+}
+
 func validateSchedulePolicyTest(t *testing.T) {
 	policy := &stork_api.SchedulePolicy{
 		ObjectMeta: meta.ObjectMeta{
@@ -426,6 +499,25 @@ func policyRetainTest(t *testing.T) {
 	require.Equal(t, policy.Policy.Monthly.Retain, retain, "Wrong default retain for monthly policy")
 }
 
+func policyRetentionPeriodTest(t *testing.T) {
+	// Uses the retain values set by policyRetainTest
+	period, err := GetRetentionPeriod("policy", "default", stork_api.SchedulePolicyTypeInterval)
+	require.NoError(t, err, "Error getting retention period")
+	require.Equal(t, 5*time.Hour, period, "Wrong retention period for interval policy")
+
+	period, err = GetRetentionPeriod("policy", "default", stork_api.SchedulePolicyTypeDaily)
+	require.NoError(t, err, "Error getting retention period")
+	require.Equal(t, 10*24*time.Hour, period, "Wrong retention period for daily policy")
+
+	period, err = GetRetentionPeriod("policy", "default", stork_api.SchedulePolicyTypeWeekly)
+	require.NoError(t, err, "Error getting retention period")
+	require.Equal(t, 20*7*24*time.Hour, period, "Wrong retention period for weekly policy")
+
+	period, err = GetRetentionPeriod("policy", "default", stork_api.SchedulePolicyTypeMonthly)
+	require.NoError(t, err, "Error getting retention period")
+	require.Equal(t, 30*28*24*time.Hour, period, "Wrong retention period for monthly policy")
+}
+
 func policyOptionsTest(t *testing.T) {
 	policyName := "options"
 	policy, err := storkops.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
@@ -476,3 +568,101 @@ func policyOptionsTest(t *testing.T) {
 	require.NoError(t, err, "Error getting options")
 	require.Equal(t, policy.Policy.Monthly.Options, options, "Options mismatch for monthly policy")
 }
+
+func dueTriggerTest(t *testing.T) {
+	defer func() {
+		err := storkops.Instance().DeleteSchedulePolicy("duetrigger")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := storkops.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "duetrigger",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 180,
+			},
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	mockNow := time.Date(2019, time.February, 8, 10, 30, 0, 0, time.Local)
+	setMockTime(&mockNow)
+	defer setMockTime(nil)
+
+	// The first of the interval runs at 3AM, 6AM and 9AM is due
+	due, err := DueTrigger("duetrigger", "default", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 8, 0, 0, 0, 0, time.Local))
+	require.NoError(t, err, "Error getting due trigger")
+	require.Equal(t, time.Date(2019, time.February, 8, 3, 0, 0, 0, time.Local), due)
+
+	due, err = DueTrigger("duetrigger", "default", stork_api.SchedulePolicyTypeInterval, meta.Date(2019, time.February, 8, 9, 0, 0, 0, time.Local))
+	require.NoError(t, err, "Error getting due trigger")
+	require.True(t, due.IsZero(), "No run should be due")
+
+	due, err = DueTrigger("duetrigger", "default", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 5, 23, 15, 0, 0, time.Local))
+	require.NoError(t, err, "Error getting due trigger")
+	require.Equal(t, time.Date(2019, time.February, 6, 23, 15, 0, 0, time.Local), due)
+
+	due, err = DueTrigger("duetrigger", "default", stork_api.SchedulePolicyTypeDaily, meta.Date(2019, time.February, 7, 23, 15, 0, 0, time.Local))
+	require.NoError(t, err, "Error getting due trigger")
+	require.True(t, due.IsZero(), "No run should be due")
+
+	due, err = DueTrigger("duetrigger", "default", stork_api.SchedulePolicyTypeWeekly, meta.Time{})
+	require.NoError(t, err, "Error getting due trigger")
+	require.True(t, due.IsZero(), "No run should be due for a type that isn't in the policy")
+}
+
+func startDelaysTest(t *testing.T) {
+	defer func() {
+		err := storkops.Instance().DeleteSchedulePolicy("startdelays")
+		require.NoError(t, err, "Error cleaning up schedule policy")
+	}()
+
+	_, err := storkops.Instance().CreateSchedulePolicy(&stork_api.SchedulePolicy{
+		ObjectMeta: meta.ObjectMeta{
+			Name: "startdelays",
+		},
+		Policy: stork_api.SchedulePolicyItem{
+			Interval: &stork_api.IntervalPolicy{
+				IntervalMinutes: 60,
+			},
+			Daily: &stork_api.DailyPolicy{
+				Time: "11:15PM",
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating policy")
+
+	run := func(day, hour, minute int) Run {
+		return Run{CreationTimestamp: meta.Date(2019, time.February, day, hour, minute, 0, 0, time.Local)}
+	}
+
+	// Interval runs are due an interval after the previous one and the
+	// first run is skipped
+	delays, err := StartDelays("startdelays", "default", stork_api.SchedulePolicyTypeInterval,
+		[]Run{run(8, 0, 0), run(8, 1, 10), run(8, 2, 20), run(8, 3, 30)})
+	require.NoError(t, err, "Error getting start delays")
+	require.Equal(t, []time.Duration{10 * time.Minute, 10 * time.Minute, 10 * time.Minute}, delays)
+	require.True(t, Drifting(delays, 5*time.Minute))
+	require.False(t, Drifting(delays, 10*time.Minute))
+	require.False(t, Drifting(delays[1:], 5*time.Minute), "Drift needs enough late runs")
+
+	// Runs catching up for missed runs are skipped
+	catchUp := run(8, 3, 30)
+	catchUp.ScheduledTimestamp = meta.Date(2019, time.February, 8, 2, 0, 0, 0, time.Local)
+	delays, err = StartDelays("startdelays", "default", stork_api.SchedulePolicyTypeInterval,
+		[]Run{run(8, 0, 0), catchUp, run(8, 3, 1)})
+	require.NoError(t, err, "Error getting start delays")
+	require.Equal(t, []time.Duration{time.Minute}, delays)
+
+	// Daily runs are due at the time of the policy
+	delays, err = StartDelays("startdelays", "default", stork_api.SchedulePolicyTypeDaily,
+		[]Run{run(6, 23, 15), run(7, 23, 45), run(8, 23, 16)})
+	require.NoError(t, err, "Error getting start delays")
+	require.Equal(t, []time.Duration{0, 30 * time.Minute, time.Minute}, delays)
+	require.False(t, Drifting(delays, 5*time.Minute))
+}
@@ -0,0 +1,146 @@
+package secretprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AWSSecretsManagerProviderName is the name of the provider that reads
+	// secrets from AWS Secrets Manager. The credentials and region are picked
+	// up the same way as the AWS SDK does (AWS_REGION, AWS_ACCESS_KEY_ID,
+	// shared config, instance or IRSA roles etc).
+	AWSSecretsManagerProviderName = "aws-secrets-manager"
+
+	// awsSecretNameTemplateEnv is the prefix of the names of the secrets of
+	// a namespace. {namespace} is replaced with the namespace of the object
+	// referencing the secret and secret IDs are relative to it.
+	awsSecretNameTemplateEnv = "AWS_SECRETS_MANAGER_SECRET_NAME_TEMPLATE"
+	// awsSecretsManagerEndpointEnv can be set to use a custom endpoint, for
+	// example a VPC endpoint
+	awsSecretsManagerEndpointEnv = "AWS_SECRETS_MANAGER_ENDPOINT"
+
+	defaultAWSSecretNameTemplate = "stork/{namespace}"
+	awsSecretsManagerService     = "secretsmanager"
+	awsGetSecretValueTarget      = "secretsmanager.GetSecretValue"
+	awsJSONContentType           = "application/x-amz-json-1.1"
+)
+
+type awsSecretsManagerProvider struct {
+	sync.Mutex
+	sess *session.Session
+}
+
+type awsGetSecretValueOutput struct {
+	SecretString *string `json:"SecretString"`
+	SecretBinary []byte  `json:"SecretBinary"`
+}
+
+type awsErrorOutput struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (a *awsSecretsManagerProvider) Name() string {
+	return AWSSecretsManagerProviderName
+}
+
+// GetSecret reads the secret named by secretID under the prefix of the
+// namespace. The secret needs to hold a JSON object of key/value pairs, which
+// is what the key/value editor of the AWS console stores.
+func (a *awsSecretsManagerProvider) GetSecret(secretID string, namespace string) (map[string][]byte, error) {
+	name, err := getNamespacedSecretPath(secretID, namespace, awsSecretNameTemplateEnv, defaultAWSSecretNameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := a.getSession()
+	if err != nil {
+		return nil, err
+	}
+	region := aws.StringValue(sess.Config.Region)
+	if region == "" {
+		return nil, fmt.Errorf("region needs to be set to read secrets from AWS Secrets Manager")
+	}
+	endpoint := os.Getenv(awsSecretsManagerEndpointEnv)
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%v.%v.amazonaws.com", awsSecretsManagerService, region)
+	}
+
+	body, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for AWS Secrets Manager: %v", err)
+	}
+	req.Header.Set("Content-Type", awsJSONContentType)
+	req.Header.Set("X-Amz-Target", awsGetSecretValueTarget)
+	signer := v4.NewSigner(sess.Config.Credentials)
+	if _, err := signer.Sign(req, bytes.NewReader(body), awsSecretsManagerService, region, time.Now()); err != nil {
+		return nil, fmt.Errorf("error signing request for AWS Secrets Manager: %v", err)
+	}
+	resp, err := sess.Config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		awsErr := &awsErrorOutput{}
+		if err := json.Unmarshal(respBody, awsErr); err != nil || awsErr.Type == "" {
+			return nil, fmt.Errorf("error reading secret %v: %v", name, resp.Status)
+		}
+		return nil, fmt.Errorf("error reading secret %v: %v: %v", name, awsErr.Type, awsErr.Message)
+	}
+
+	output := &awsGetSecretValueOutput{}
+	if err := json.Unmarshal(respBody, output); err != nil {
+		return nil, fmt.Errorf("error parsing response from AWS Secrets Manager: %v", err)
+	}
+	secretValue := output.SecretBinary
+	if output.SecretString != nil {
+		secretValue = []byte(*output.SecretString)
+	}
+	secretData := make(map[string]interface{})
+	if err := json.Unmarshal(secretValue, &secretData); err != nil {
+		return nil, fmt.Errorf("secret %v needs to be a JSON object of key/value pairs: %v", name, err)
+	}
+	return toSecretData(secretData), nil
+}
+
+func (a *awsSecretsManagerProvider) getSession() (*session.Session, error) {
+	a.Lock()
+	defer a.Unlock()
+	if a.sess != nil {
+		return a.sess, nil
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating AWS session: %v", err)
+	}
+	a.sess = sess
+	return a.sess, nil
+}
+
+func init() {
+	if err := Register(AWSSecretsManagerProviderName, &awsSecretsManagerProvider{}); err != nil {
+		logrus.Panicf("Error registering AWS Secrets Manager secret provider: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package secretprovider
+
+import (
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// KubernetesProviderName is the name of the provider that reads secrets
+	// from Kubernetes Secrets in the namespace of the referencing object
+	KubernetesProviderName = "kubernetes"
+)
+
+type kubernetesProvider struct{}
+
+func (k *kubernetesProvider) Name() string {
+	return KubernetesProviderName
+}
+
+func (k *kubernetesProvider) GetSecret(secretID string, namespace string) (map[string][]byte, error) {
+	secret, err := core.Instance().GetSecret(secretID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Data, nil
+}
+
+func init() {
+	if err := Register(KubernetesProviderName, &kubernetesProvider{}); err != nil {
+		logrus.Panicf("Error registering kubernetes secret provider: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package secretprovider
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// ClusterPairTokenKey is the key of the bearer token for the remote
+	// cluster in the external secret of a cluster pair
+	ClusterPairTokenKey = "kubeconfig-token"
+	// ClusterPairClientCertificateKey is the key of the PEM encoded client
+	// certificate for the remote cluster in the external secret of a cluster
+	// pair
+	ClusterPairClientCertificateKey = "kubeconfig-client-certificate-data"
+	// ClusterPairClientKeyKey is the key of the PEM encoded client key for the
+	// remote cluster in the external secret of a cluster pair
+	ClusterPairClientKeyKey = "kubeconfig-client-key-data"
+	// ClusterPairPasswordKey is the key of the basic auth password for the
+	// remote cluster in the external secret of a cluster pair
+	ClusterPairPasswordKey = "kubeconfig-password"
+
+	namespacePlaceholder = "{namespace}"
+)
+
+// Provider fetches credential material from a secret store at the time it is
+// used, so that stork CRs don't have to carry raw credentials
+type Provider interface {
+	// Name returns the name of the provider
+	Name() string
+	// GetSecret returns the key/value pairs stored for secretID. namespace is
+	// the namespace of the object referencing the secret and can be used by
+	// providers that scope secrets per namespace.
+	GetSecret(secretID string, namespace string) (map[string][]byte, error)
+}
+
+var (
+	providers = make(map[string]Provider)
+	lock      sync.RWMutex
+)
+
+// Register registers the given secret provider
+func Register(name string, provider Provider) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if _, ok := providers[name]; ok {
+		return fmt.Errorf("secret provider %v is already registered", name)
+	}
+	providers[name] = provider
+	return nil
+}
+
+// Get returns the secret provider registered with the given name
+func Get(name string) (Provider, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+	if provider, ok := providers[name]; ok {
+		return provider, nil
+	}
+	return nil, fmt.Errorf("secret provider %v is not registered", name)
+}
+
+// GetSecret fetches the secret referenced by the config from its provider
+func GetSecret(config *stork_api.ExternalSecretConfig, namespace string) (map[string][]byte, error) {
+	if config == nil {
+		return nil, fmt.Errorf("nil external secret config")
+	}
+	if config.SecretID == "" {
+		return nil, fmt.Errorf("secretID needs to be specified for external secret")
+	}
+	provider, err := Get(config.Provider)
+	if err != nil {
+		return nil, err
+	}
+	data, err := provider.GetSecret(config.SecretID, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("error getting secret %v from provider %v: %v", config.SecretID, config.Provider, err)
+	}
+	return data, nil
+}
+
+// UpdateBackupLocation merges the credentials referenced by the external
+// secret configs of the backup location into it. It is a no-op if no external
+// secrets are configured.
+func UpdateBackupLocation(backupLocation *stork_api.BackupLocation) error {
+	if backupLocation == nil {
+		return fmt.Errorf("nil backupLocation")
+	}
+	if backupLocation.Location.ExternalSecretConfig != nil {
+		data, err := GetSecret(backupLocation.Location.ExternalSecretConfig, backupLocation.Namespace)
+		if err != nil {
+			return fmt.Errorf("error getting external secret for backupLocation %v/%v: %v",
+				backupLocation.Namespace, backupLocation.Name, err)
+		}
+		if err := backupLocation.UpdateFromSecretData(data); err != nil {
+			return err
+		}
+	}
+	if backupLocation.Cluster.ExternalSecretConfig != nil {
+		data, err := GetSecret(backupLocation.Cluster.ExternalSecretConfig, backupLocation.Namespace)
+		if err != nil {
+			return fmt.Errorf("error getting external cluster secret for backupLocation %v/%v: %v",
+				backupLocation.Namespace, backupLocation.Name, err)
+		}
+		if err := backupLocation.UpdateFromClusterSecretData(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateClusterPair merges the options referenced by the external secret
+// config of the cluster pair into its options. The kubeconfig-* keys of the
+// secret are set as the credentials of the current context of the kubeconfig
+// instead, so that the pair doesn't need to carry them.
+func UpdateClusterPair(clusterPair *stork_api.ClusterPair) error {
+	if clusterPair == nil {
+		return fmt.Errorf("nil clusterPair")
+	}
+	if clusterPair.Spec.ExternalSecretConfig == nil {
+		return nil
+	}
+	data, err := GetSecret(clusterPair.Spec.ExternalSecretConfig, clusterPair.Namespace)
+	if err != nil {
+		return fmt.Errorf("error getting external secret for clusterpair %v/%v: %v",
+			clusterPair.Namespace, clusterPair.Name, err)
+	}
+	if clusterPair.Spec.Options == nil {
+		clusterPair.Spec.Options = make(map[string]string)
+	}
+	for k, v := range data {
+		switch k {
+		case ClusterPairTokenKey, ClusterPairClientCertificateKey, ClusterPairClientKeyKey, ClusterPairPasswordKey:
+			if err := updateClusterPairAuthInfo(clusterPair, k, v); err != nil {
+				return err
+			}
+		default:
+			clusterPair.Spec.Options[k] = string(v)
+		}
+	}
+	return nil
+}
+
+func updateClusterPairAuthInfo(clusterPair *stork_api.ClusterPair, key string, value []byte) error {
+	config := &clusterPair.Spec.Config
+	context, ok := config.Contexts[config.CurrentContext]
+	if !ok || context == nil {
+		return fmt.Errorf("current context %v not found in config of clusterpair %v/%v",
+			config.CurrentContext, clusterPair.Namespace, clusterPair.Name)
+	}
+	if config.AuthInfos == nil {
+		config.AuthInfos = make(map[string]*clientcmdapi.AuthInfo)
+	}
+	authInfo := config.AuthInfos[context.AuthInfo]
+	if authInfo == nil {
+		authInfo = clientcmdapi.NewAuthInfo()
+		config.AuthInfos[context.AuthInfo] = authInfo
+	}
+	switch key {
+	case ClusterPairTokenKey:
+		authInfo.Token = strings.TrimSpace(string(value))
+	case ClusterPairClientCertificateKey:
+		authInfo.ClientCertificateData = value
+	case ClusterPairClientKeyKey:
+		authInfo.ClientKeyData = value
+	case ClusterPairPasswordKey:
+		authInfo.Password = string(value)
+	}
+	return nil
+}
+
+// getNamespacedSecretPath returns the path of the secret in the namespace
+// using the path template set in templateEnv. Secret IDs that would leave the
+// path of the namespace are rejected.
+func getNamespacedSecretPath(secretID string, namespace string, templateEnv string, defaultTemplate string) (string, error) {
+	if namespace == "" {
+		return "", fmt.Errorf("namespace is required to read external secrets")
+	}
+	template := os.Getenv(templateEnv)
+	if template == "" {
+		template = defaultTemplate
+	}
+	if !strings.Contains(template, namespacePlaceholder) {
+		return "", fmt.Errorf("%v needs to contain %v", templateEnv, namespacePlaceholder)
+	}
+	for _, segment := range strings.Split(secretID, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid secret ID %v, it needs to be a path relative to the namespace", secretID)
+		}
+	}
+	prefix := strings.Trim(strings.ReplaceAll(template, namespacePlaceholder, namespace), "/")
+	return prefix + "/" + secretID, nil
+}
+
+// toSecretData converts the values of a JSON secret to the key/value pairs
+// returned by the providers
+func toSecretData(secretData map[string]interface{}) map[string][]byte {
+	data := make(map[string][]byte)
+	for k, val := range secretData {
+		switch val := val.(type) {
+		case string:
+			data[k] = []byte(val)
+		case nil:
+		default:
+			data[k] = []byte(fmt.Sprintf("%v", val))
+		}
+	}
+	return data
+}
@@ -0,0 +1,249 @@
+//go:build unittest
+// +build unittest
+
+package secretprovider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const testProviderName = "test"
+
+type testProvider struct {
+	secrets map[string]map[string][]byte
+}
+
+func (t *testProvider) Name() string {
+	return testProviderName
+}
+
+func (t *testProvider) GetSecret(secretID string, namespace string) (map[string][]byte, error) {
+	return t.secrets[secretID], nil
+}
+
+func TestSecretProvider(t *testing.T) {
+	err := Register(testProviderName, &testProvider{
+		secrets: map[string]map[string][]byte{
+			"s3creds": {
+				"accessKeyID":     []byte("access"),
+				"secretAccessKey": []byte("secret\n"),
+				"encryptionKey":   []byte("key"),
+			},
+			"awscluster": {
+				"accessKeyID":     []byte("clusteraccess"),
+				"secretAccessKey": []byte("clustersecret"),
+			},
+			"pair": {
+				"token":                         []byte("pairtoken"),
+				ClusterPairTokenKey:             []byte("kubetoken\n"),
+				ClusterPairClientKeyKey:         []byte("clientkey"),
+				ClusterPairClientCertificateKey: []byte("clientcert"),
+			},
+		},
+	})
+	require.NoError(t, err, "Error registering test provider")
+
+	t.Run("registerTest", registerTest)
+	t.Run("updateBackupLocationTest", updateBackupLocationTest)
+	t.Run("updateClusterPairTest", updateClusterPairTest)
+	t.Run("kubernetesProviderTest", kubernetesProviderTest)
+	t.Run("vaultSecretPathTest", vaultSecretPathTest)
+	t.Run("awsSecretsManagerProviderTest", awsSecretsManagerProviderTest)
+}
+
+func registerTest(t *testing.T) {
+	err := Register(testProviderName, &testProvider{})
+	require.Error(t, err, "Registering the same provider twice should fail")
+
+	_, err = Get("missing")
+	require.Error(t, err, "Get should fail for unregistered provider")
+
+	provider, err := Get(VaultProviderName)
+	require.NoError(t, err, "Vault provider should be registered")
+	require.Equal(t, VaultProviderName, provider.Name())
+}
+
+func updateBackupLocationTest(t *testing.T) {
+	backupLocation := &stork_api.BackupLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "location",
+			Namespace: "test",
+		},
+		Location: stork_api.BackupLocationItem{
+			Type: stork_api.BackupLocationS3,
+			Path: "bucket",
+			ExternalSecretConfig: &stork_api.ExternalSecretConfig{
+				Provider: testProviderName,
+				SecretID: "s3creds",
+			},
+		},
+		Cluster: stork_api.ClusterItem{
+			Type: stork_api.AWSCluster,
+			ExternalSecretConfig: &stork_api.ExternalSecretConfig{
+				Provider: testProviderName,
+				SecretID: "awscluster",
+			},
+		},
+	}
+	err := UpdateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error updating backup location")
+	require.Equal(t, "bucket", backupLocation.Location.Path)
+	require.Equal(t, "key", backupLocation.Location.EncryptionKey)
+	require.Equal(t, "access", backupLocation.Location.S3Config.AccessKeyID)
+	require.Equal(t, "secret", backupLocation.Location.S3Config.SecretAccessKey)
+	require.Equal(t, "us-east-1", backupLocation.Location.S3Config.Region)
+	require.Equal(t, "clusteraccess", backupLocation.Cluster.AWSClusterConfig.AccessKeyID)
+	require.Equal(t, "clustersecret", backupLocation.Cluster.AWSClusterConfig.SecretAccessKey)
+
+	backupLocation.Location.ExternalSecretConfig.Provider = "missing"
+	err = UpdateBackupLocation(backupLocation)
+	require.Error(t, err, "Update should fail for unregistered provider")
+
+	backupLocation.Location.ExternalSecretConfig = &stork_api.ExternalSecretConfig{Provider: testProviderName}
+	err = UpdateBackupLocation(backupLocation)
+	require.Error(t, err, "Update should fail without secretID")
+}
+
+func updateClusterPairTest(t *testing.T) {
+	clusterPair := &stork_api.ClusterPair{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pair",
+			Namespace: "test",
+		},
+		Spec: stork_api.ClusterPairSpec{
+			Config: clientcmdapi.Config{
+				CurrentContext: "remote",
+				Contexts: map[string]*clientcmdapi.Context{
+					"remote": {Cluster: "remote", AuthInfo: "admin"},
+				},
+			},
+			Options: map[string]string{
+				"ip": "10.0.0.1",
+			},
+			ExternalSecretConfig: &stork_api.ExternalSecretConfig{
+				Provider: testProviderName,
+				SecretID: "pair",
+			},
+		},
+	}
+	err := UpdateClusterPair(clusterPair)
+	require.NoError(t, err, "Error updating cluster pair")
+	require.Equal(t, "10.0.0.1", clusterPair.Spec.Options["ip"])
+	require.Equal(t, "pairtoken", clusterPair.Spec.Options["token"])
+	require.NotContains(t, clusterPair.Spec.Options, ClusterPairTokenKey, "Kubeconfig credentials should not be merged into options")
+	authInfo := clusterPair.Spec.Config.AuthInfos["admin"]
+	require.NotNil(t, authInfo, "Auth info for current context should be set")
+	require.Equal(t, "kubetoken", authInfo.Token)
+	require.Equal(t, []byte("clientkey"), authInfo.ClientKeyData)
+	require.Equal(t, []byte("clientcert"), authInfo.ClientCertificateData)
+
+	clusterPair.Spec.Config.CurrentContext = "missing"
+	err = UpdateClusterPair(clusterPair)
+	require.Error(t, err, "Update should fail if the current context doesn't exist")
+}
+
+func kubernetesProviderTest(t *testing.T) {
+	core.SetInstance(core.New(kubernetes.NewSimpleClientset()))
+	_, err := core.Instance().CreateSecret(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "creds",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"storageAccountName": []byte("account"),
+			"storageAccountKey":  []byte("key"),
+		},
+	})
+	require.NoError(t, err, "Error creating secret")
+
+	backupLocation := &stork_api.BackupLocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "location",
+			Namespace: "test",
+		},
+		Location: stork_api.BackupLocationItem{
+			Type: stork_api.BackupLocationAzure,
+			ExternalSecretConfig: &stork_api.ExternalSecretConfig{
+				Provider: KubernetesProviderName,
+				SecretID: "creds",
+			},
+		},
+	}
+	err = UpdateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error updating backup location")
+	require.Equal(t, "account", backupLocation.Location.AzureConfig.StorageAccountName)
+	require.Equal(t, "key", backupLocation.Location.AzureConfig.StorageAccountKey)
+
+	backupLocation.Namespace = "other"
+	err = UpdateBackupLocation(backupLocation)
+	require.Error(t, err, "Secrets from other namespaces should not be readable")
+}
+
+func vaultSecretPathTest(t *testing.T) {
+	path, err := getVaultSecretPath("s3/creds", "test")
+	require.NoError(t, err)
+	require.Equal(t, "secret/data/test/s3/creds", path)
+
+	for _, secretID := range []string{"../other/creds", "/secret/data/other/creds", "s3//creds", ""} {
+		_, err = getVaultSecretPath(secretID, "test")
+		require.Error(t, err, "Secret ID %v outside the namespace should be rejected", secretID)
+	}
+	_, err = getVaultSecretPath("creds", "")
+	require.Error(t, err, "Secrets should not be read without a namespace")
+
+	t.Setenv(vaultSecretPathTemplateEnv, "kv/stork/{namespace}/")
+	path, err = getVaultSecretPath("creds", "test")
+	require.NoError(t, err)
+	require.Equal(t, "kv/stork/test/creds", path)
+
+	t.Setenv(vaultSecretPathTemplateEnv, "kv/stork")
+	_, err = getVaultSecretPath("creds", "test")
+	require.Error(t, err, "Templates without the namespace should be rejected")
+}
+
+func awsSecretsManagerProviderTest(t *testing.T) {
+	var requestedSecret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, awsGetSecretValueTarget, r.Header.Get("X-Amz-Target"))
+		require.Contains(t, r.Header.Get("Authorization"), "AWS4-HMAC-SHA256")
+		input := make(map[string]string)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&input))
+		requestedSecret = input["SecretId"]
+		if requestedSecret != "stork/test/s3/creds" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"Name":"stork/test/s3/creds","SecretString":"{\"accessKeyID\":\"access\",\"port\":9000}"}`))
+	}))
+	defer server.Close()
+	t.Setenv(awsSecretsManagerEndpointEnv, server.URL)
+	t.Setenv("AWS_REGION", "us-west-2")
+	t.Setenv("AWS_ACCESS_KEY_ID", "access")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	provider := &awsSecretsManagerProvider{}
+	data, err := provider.GetSecret("s3/creds", "test")
+	require.NoError(t, err, "Error getting secret from AWS Secrets Manager")
+	require.Equal(t, "stork/test/s3/creds", requestedSecret)
+	require.Equal(t, []byte("access"), data["accessKeyID"])
+	require.Equal(t, []byte("9000"), data["port"])
+
+	_, err = provider.GetSecret("other", "test")
+	require.Error(t, err, "Missing secrets should return an error")
+	require.Contains(t, err.Error(), "ResourceNotFoundException")
+
+	_, err = provider.GetSecret("../other/creds", "test")
+	require.Error(t, err, "Secret ID outside the namespace should be rejected")
+}
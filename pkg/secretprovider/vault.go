@@ -0,0 +1,147 @@
+package secretprovider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// VaultProviderName is the name of the provider that reads secrets from
+	// HashiCorp Vault. The vault client is configured through the standard
+	// VAULT_* environment variables (VAULT_ADDR, VAULT_TOKEN, VAULT_CACERT,
+	// VAULT_NAMESPACE etc).
+	VaultProviderName = "vault"
+
+	// vaultAuthMethodEnv can be set to "kubernetes" to log in to vault with
+	// the service account token of the stork pod instead of VAULT_TOKEN
+	vaultAuthMethodEnv = "VAULT_AUTH_METHOD"
+	// vaultAuthRoleEnv is the vault role used for the kubernetes auth method
+	vaultAuthRoleEnv = "VAULT_AUTH_KUBERNETES_ROLE"
+	// vaultAuthMountPathEnv is the mount path of the kubernetes auth method
+	vaultAuthMountPathEnv = "VAULT_AUTH_MOUNT_PATH"
+	// vaultSecretPathTemplateEnv is the path under which the secrets of a
+	// namespace are stored. {namespace} is replaced with the namespace of the
+	// object referencing the secret and secret IDs are relative to it, so
+	// that objects can only read the secrets of their own namespace.
+	vaultSecretPathTemplateEnv = "VAULT_SECRET_PATH_TEMPLATE"
+
+	vaultKubernetesAuthMethod   = "kubernetes"
+	defaultVaultAuthMountPath   = "kubernetes"
+	defaultVaultSecretPathTmpl  = "secret/data/{namespace}"
+	serviceAccountTokenFilePath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+)
+
+type vaultProvider struct {
+	sync.Mutex
+	client *api.Client
+}
+
+func (v *vaultProvider) Name() string {
+	return VaultProviderName
+}
+
+// GetSecret reads the secret at the path given by secretID under the path of
+// the namespace. Both KV version 1 and version 2 secret engines are supported.
+func (v *vaultProvider) GetSecret(secretID string, namespace string) (map[string][]byte, error) {
+	path, err := getVaultSecretPath(secretID, namespace)
+	if err != nil {
+		return nil, err
+	}
+	client, err := v.getClient()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		// Force a new login on the next request in case the token expired
+		v.resetClient()
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret %v not found in vault", secretID)
+	}
+	secretData := secret.Data
+	// KV version 2 nests the key/value pairs under "data"
+	if nested, ok := secretData["data"].(map[string]interface{}); ok {
+		if _, ok := secretData["metadata"]; ok {
+			secretData = nested
+		}
+	}
+	return toSecretData(secretData), nil
+}
+
+// getVaultSecretPath returns the path of the secret in the namespace
+func getVaultSecretPath(secretID string, namespace string) (string, error) {
+	return getNamespacedSecretPath(secretID, namespace, vaultSecretPathTemplateEnv, defaultVaultSecretPathTmpl)
+}
+
+func (v *vaultProvider) getClient() (*api.Client, error) {
+	v.Lock()
+	defer v.Unlock()
+	if v.client != nil {
+		return v.client, nil
+	}
+
+	config := api.DefaultConfig()
+	if config.Error != nil {
+		return nil, fmt.Errorf("error reading vault config: %v", config.Error)
+	}
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating vault client: %v", err)
+	}
+	if os.Getenv(vaultAuthMethodEnv) == vaultKubernetesAuthMethod {
+		if err := kubernetesLogin(client); err != nil {
+			return nil, err
+		}
+	}
+	v.client = client
+	return v.client, nil
+}
+
+func (v *vaultProvider) resetClient() {
+	v.Lock()
+	defer v.Unlock()
+	v.client = nil
+}
+
+func kubernetesLogin(client *api.Client) error {
+	role := os.Getenv(vaultAuthRoleEnv)
+	if role == "" {
+		return fmt.Errorf("%v needs to be set for kubernetes auth with vault", vaultAuthRoleEnv)
+	}
+	mountPath := os.Getenv(vaultAuthMountPathEnv)
+	if mountPath == "" {
+		mountPath = defaultVaultAuthMountPath
+	}
+	jwt, err := ioutil.ReadFile(serviceAccountTokenFilePath)
+	if err != nil {
+		return fmt.Errorf("error reading service account token: %v", err)
+	}
+	secret, err := client.Logical().Write(
+		"auth/"+strings.Trim(mountPath, "/")+"/login",
+		map[string]interface{}{
+			"jwt":  string(jwt),
+			"role": role,
+		})
+	if err != nil {
+		return fmt.Errorf("error logging in to vault: %v", err)
+	}
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("no auth info returned by vault login")
+	}
+	client.SetToken(secret.Auth.ClientToken)
+	return nil
+}
+
+func init() {
+	if err := Register(VaultProviderName, &vaultProvider{}); err != nil {
+		logrus.Panicf("Error registering vault secret provider: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+
+	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	snapshotvolume "github.com/kubernetes-incubator/external-storage/snapshot/pkg/volume"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// retainingPlugin keeps snapshots in the storage backend when their
+// VolumeSnapshotData has been annotated to be retained, e.g. by snapshot
+// schedules that retain backend snapshots when pruning old snapshots
+type retainingPlugin struct {
+	snapshotvolume.Plugin
+	restClient rest.Interface
+}
+
+// SnapshotDelete deletes the snapshot from the storage backend unless it
+// should be retained
+func (r *retainingPlugin) SnapshotDelete(source *crdv1.VolumeSnapshotDataSource, pv *v1.PersistentVolume) error {
+	snapshotDataName, err := r.getRetainedSnapshotData(source)
+	if err != nil {
+		return err
+	}
+	if snapshotDataName != "" {
+		log.Infof("Retaining snapshot for VolumeSnapshotData %v in the storage backend", snapshotDataName)
+		return nil
+	}
+	return r.Plugin.SnapshotDelete(source, pv)
+}
+
+// getRetainedSnapshotData returns the name of the VolumeSnapshotData for the
+// source if it has been annotated to be retained. The plugin only gets the
+// source of the snapshot, so the VolumeSnapshotData has to be looked up from
+// it.
+func (r *retainingPlugin) getRetainedSnapshotData(source *crdv1.VolumeSnapshotDataSource) (string, error) {
+	var snapshotDataList crdv1.VolumeSnapshotDataList
+	err := r.restClient.Get().
+		Resource(crdv1.VolumeSnapshotDataResourcePlural).
+		Do(context.TODO()).Into(&snapshotDataList)
+	if err != nil {
+		return "", err
+	}
+	for _, snapshotData := range snapshotDataList.Items {
+		if snapshotData.Metadata.Annotations[RetainBackendSnapshotAnnotation] != "true" {
+			continue
+		}
+		if reflect.DeepEqual(snapshotData.Spec.VolumeSnapshotDataSource, *source) {
+			return snapshotData.Metadata.Name, nil
+		}
+	}
+	return "", nil
+}
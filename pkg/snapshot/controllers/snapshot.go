@@ -86,7 +86,14 @@ func (s *Snapshotter) Start(stopChannel <-chan struct{}) error {
 	}
 
 	plugins := make(map[string]snapshotvolume.Plugin)
-	plugins[s.Driver.String()] = s.Driver.GetSnapshotPlugin()
+	if plugin := s.Driver.GetSnapshotPlugin(); plugin != nil {
+		plugins[s.Driver.String()] = &retainingPlugin{
+			Plugin:     plugin,
+			restClient: snapshotClient,
+		}
+	} else {
+		plugins[s.Driver.String()] = plugin
+	}
 
 	snapController := snapshotcontroller.NewSnapshotController(snapshotClient, snapshotScheme,
 		clientset, &plugins, defaultSyncDuration)
@@ -11,18 +11,22 @@ import (
 	snap_v1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	"github.com/libopenstorage/stork/drivers/volume"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cache"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/libopenstorage/stork/pkg/fencing"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/pvclock"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	"github.com/portworx/sched-ops/k8s/core"
-	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -30,20 +34,25 @@ import (
 )
 
 const (
-	annotationPrefix   = "stork.libopenstorage.org/"
-	storkSchedulerName = "stork"
-	// RestoreAnnotation for pvc which has in-place restore in progress
-	RestoreAnnotation            = annotationPrefix + "restore-in-progress"
-	validateSnapshotTimeout      = 1 * time.Minute
-	validateSnapshotRetryTimeout = 5 * time.Second
+	snapshotRestoreControllerName = "snapshot-restore-controller"
+
+	storkSchedulerName      = "stork"
+	validateSnapshotTimeout = 1 * time.Minute
+	// pvcUpdateConcurrency is the number of PVCs that are updated in
+	// parallel when marking them for restore
+	pvcUpdateConcurrency = 10
+	// lockExtendInterval is how often the locks and the fence of the volumes
+	// are extended while they are being restored
+	lockExtendInterval = 15 * time.Second
 )
 
 // NewSnapshotRestoreController creates a new instance of SnapshotRestoreController.
-func NewSnapshotRestoreController(mgr manager.Manager, d volume.Driver, r record.EventRecorder) *SnapshotRestoreController {
+func NewSnapshotRestoreController(mgr manager.Manager, d volume.Driver, r record.EventRecorder, namespaces []string) *SnapshotRestoreController {
 	return &SnapshotRestoreController{
-		client:    mgr.GetClient(),
-		volDriver: d,
-		recorder:  r,
+		client:     controllers.NewConditionClient(mgr.GetClient()),
+		volDriver:  d,
+		recorder:   r,
+		namespaces: namespaces,
 	}
 }
 
@@ -53,6 +62,9 @@ type SnapshotRestoreController struct {
 
 	volDriver volume.Driver
 	recorder  record.EventRecorder
+	// namespaces the restore janitor is restricted to, all namespaces if
+	// it is empty
+	namespaces []string
 }
 
 // Init initialize the cluster pair controller
@@ -62,7 +74,16 @@ func (c *SnapshotRestoreController) Init(mgr manager.Manager) error {
 		return err
 	}
 
-	return controllers.RegisterTo(mgr, "snapshot-restore-controller", c, &stork_api.VolumeSnapshotRestore{})
+	if err := fencing.RegisterDriverFencer(c.volDriver); err != nil {
+		return err
+	}
+
+	// Periodically clean up restore annotations left behind on PVCs
+	if err := mgr.Add(manager.RunnableFunc(c.startRestoreJanitor)); err != nil {
+		return err
+	}
+
+	return controllers.RegisterTo(mgr, snapshotRestoreControllerName, c, &stork_api.VolumeSnapshotRestore{})
 }
 
 // Reconcile manages SnapShot resources.
@@ -80,7 +101,7 @@ func (c *SnapshotRestoreController) Reconcile(ctx context.Context, request recon
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(snapshotRestoreControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if !controllers.ContainsFinalizer(restore, controllers.FinalizerCleanup) {
@@ -88,12 +109,16 @@ func (c *SnapshotRestoreController) Reconcile(ctx context.Context, request recon
 		return reconcile.Result{Requeue: true}, c.client.Update(context.TODO(), restore)
 	}
 
+	if controllers.IsObserved(restore) {
+		return reconcile.Result{}, nil
+	}
+
 	if err = c.handle(context.TODO(), restore); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(c), restore.Namespace, restore.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(snapshotRestoreControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return controllers.Requeue(ctx, c.client, restore, storkconfig.GetRequeuePeriod(snapshotRestoreControllerName, controllers.DefaultRequeue))
 }
 
 // Handle updates for SnapshotRestore objects
@@ -102,6 +127,14 @@ func (c *SnapshotRestoreController) handle(ctx context.Context, snapRestore *sto
 		if controllers.ContainsFinalizer(snapRestore, controllers.FinalizerCleanup) {
 			if err := c.handleDelete(snapRestore); err != nil {
 				logrus.Errorf("%s: cleanup: %s", reflect.TypeOf(c), err)
+				// Keep the restore until the cleanup succeeds and record the
+				// error so that it can be audited
+				if controllers.SetCleanupError(snapRestore, err) {
+					if updateErr := c.client.Update(ctx, snapRestore); updateErr != nil {
+						logrus.Errorf("%s: recording cleanup error: %s", reflect.TypeOf(c), updateErr)
+					}
+				}
+				return err
 			}
 		}
 
@@ -117,6 +150,13 @@ func (c *SnapshotRestoreController) handle(ctx context.Context, snapRestore *sto
 	switch snapRestore.Status.Status {
 	case stork_api.VolumeSnapshotRestoreStatusInitial:
 		err = c.handleInitial(snapRestore)
+	case stork_api.VolumeSnapshotRestoreStatusWaitingForSnapshotReady:
+		var ready bool
+		ready, err = c.handleWaitingForSnapshot(snapRestore)
+		if err == nil && !ready {
+			// Nothing to update till the snapshots are ready
+			return nil
+		}
 	case stork_api.VolumeSnapshotRestoreStatusPending,
 		stork_api.VolumeSnapshotRestoreStatusInProgress:
 		err = c.handleStartRestore(snapRestore)
@@ -169,109 +209,334 @@ func (c *SnapshotRestoreController) handleStartRestore(snapRestore *stork_api.Vo
 }
 
 func (c *SnapshotRestoreController) handleInitial(snapRestore *stork_api.VolumeSnapshotRestore) error {
-	// snapshot is list of snapshots
-	snapshotList := []*snap_v1.VolumeSnapshot{}
-	var err error
+	log.VolumeSnapshotRestoreLog(snapRestore).Infof("Starting in place restore for snapshot %v", snapRestore.Spec.SourceName)
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusWaitingForSnapshotReady
+	snapRestore.Status.StartTimestamp = metav1.Now()
+	return nil
+}
 
-	snapName := snapRestore.Spec.SourceName
-	snapNamespace := snapRestore.Spec.SourceNamespace
-	log.VolumeSnapshotRestoreLog(snapRestore).Infof("Starting in place restore for snapshot %v", snapName)
-	if snapRestore.Spec.GroupSnapshot {
-		log.VolumeSnapshotRestoreLog(snapRestore).Infof("GroupVolumeSnapshot In-place restore request for %v", snapName)
-		snapshotList, err = storkops.Instance().GetSnapshotsForGroupSnapshot(snapName, snapNamespace)
-		if err != nil {
-			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to get group snapshot details %v", err)
-			return err
+// handleWaitingForSnapshot checks if the snapshots being restored are ready
+// without waiting for them, so that the worker isn't held up by snapshots
+// that take a while. The restore fails if they aren't ready before the
+// timeout. Returns false if the restore is still waiting.
+func (c *SnapshotRestoreController) handleWaitingForSnapshot(snapRestore *stork_api.VolumeSnapshotRestore) (bool, error) {
+	timeout := storkconfig.GetValidateSnapshotTimeout(validateSnapshotTimeout)
+	if snapRestore.Spec.SnapshotReadyTimeout != nil && snapRestore.Spec.SnapshotReadyTimeout.Duration > 0 {
+		timeout = snapRestore.Spec.SnapshotReadyTimeout.Duration
+	}
+	timedOut := time.Since(snapRestore.Status.StartTimestamp.Time) > timeout
+
+	snapshotList, err := getRestoreSnapshots(snapRestore)
+	if err != nil {
+		if timedOut {
+			snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
 		}
-	} else {
-		// GetSnapshot Details
-		snapshot, err := k8sextops.Instance().GetSnapshot(snapName, snapNamespace)
+		return false, err
+	}
+	for _, snapshot := range snapshotList {
+		ready, err := isSnapshotReady(snapshot)
 		if err != nil {
-			return fmt.Errorf("unable to get get snapshot  details %s: %v",
-				snapName, err)
+			snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			return false, err
 		}
-		if err := k8sextops.Instance().ValidateSnapshot(snapName,
-			snapNamespace, false,
-			validateSnapshotRetryTimeout,
-			validateSnapshotTimeout); err != nil {
-			return fmt.Errorf("snapshot is not complete %v", err)
+		if ready {
+			continue
+		}
+		if timedOut {
+			snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			return false, fmt.Errorf("snapshot %v/%v wasn't ready after %v",
+				snapshot.Metadata.Namespace, snapshot.Metadata.Name, timeout)
 		}
-		snapshotList = append(snapshotList, snapshot)
+		log.VolumeSnapshotRestoreLog(snapRestore).Debugf("Waiting for snapshot %v to be ready", snapshot.Metadata.Name)
+		return false, nil
 	}
 
 	// get map of snapID and pvcs
-	err = initRestoreVolumesInfo(snapshotList, snapRestore)
+	if err := initRestoreVolumesInfo(snapshotList, snapRestore); err != nil {
+		return false, err
+	}
+	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusPending
+	return true, nil
+}
+
+// getRestoreSnapshots returns the snapshot being restored, or the snapshots
+// of the group snapshot
+func getRestoreSnapshots(snapRestore *stork_api.VolumeSnapshotRestore) ([]*snap_v1.VolumeSnapshot, error) {
+	snapName := snapRestore.Spec.SourceName
+	snapNamespace := snapRestore.Spec.SourceNamespace
+	if snapRestore.Spec.GroupSnapshot {
+		snapshotList, err := storkops.Instance().GetSnapshotsForGroupSnapshot(snapName, snapNamespace)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get group snapshot details %v: %v", snapName, err)
+		}
+		return snapshotList, nil
+	}
+	snapshot, err := cache.GetSnapshot(snapName, snapNamespace)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("unable to get snapshot details %v: %v", snapName, err)
 	}
+	return []*snap_v1.VolumeSnapshot{snapshot}, nil
+}
 
-	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusPending
-	return nil
+// isSnapshotReady returns true if the snapshot is ready to be restored.
+// Returns an error if the snapshot failed.
+func isSnapshotReady(snapshot *snap_v1.VolumeSnapshot) (bool, error) {
+	for _, condition := range snapshot.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case snap_v1.VolumeSnapshotConditionReady:
+			return true, nil
+		case snap_v1.VolumeSnapshotConditionError:
+			return false, fmt.Errorf("snapshot %v/%v failed: %v",
+				snapshot.Metadata.Namespace, snapshot.Metadata.Name, condition.Message)
+		}
+	}
+	return false, nil
 }
 
 func (c *SnapshotRestoreController) handleFinal(snapRestore *stork_api.VolumeSnapshotRestore) error {
 	var err error
 
-	// annotate and delete pods using pvcs
-	err = markPVCForRestore(snapRestore.Status.Volumes)
+	var fencer fencing.Fencer
+	if snapRestore.Spec.Fencing != "" {
+		fencer, err = fencing.Get(snapRestore.Spec.Fencing)
+		if err != nil {
+			snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+			return fmt.Errorf("invalid fencing for restore: %v", err)
+		}
+	}
+
+	// lock the pvcs and delete pods using them
+	lock := pvclock.NewLock("VolumeSnapshotRestore", snapRestore, pvclock.OperationRestore)
+	err = c.markPVCForRestore(snapRestore.Status.Volumes, lock, fencer)
 	if err != nil {
 		log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to mark pvc for restore %v", err)
 		return err
 	}
-	// Do driver volume snapshot restore here
-	err = c.volDriver.CompleteVolumeSnapshotRestore(snapRestore)
-	if err != nil {
-		if err := unmarkPVCForRestore(snapRestore.Status.Volumes); err != nil {
-			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to umark pvc for restore %v", err)
-			return err
+	// Do driver volume snapshot restore here, keeping the volumes locked and
+	// fenced while it runs
+	stopExtend := make(chan struct{})
+	go c.keepLocked(snapRestore, lock, fencer, stopExtend)
+	restoreErr := c.volDriver.CompleteVolumeSnapshotRestore(snapRestore)
+	close(stopExtend)
+
+	// The restore isn't retried once it has run, so the locks are released
+	// and the restore fails if the volumes can't be unfenced or were used
+	// while they were being restored
+	var unfenceErr error
+	if fencer != nil {
+		if unfenceErr = fencer.Unfence(snapRestore.Status.Volumes); unfenceErr != nil {
+			log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to unfence volumes after restore %v", unfenceErr)
 		}
+	}
+	unlockErr := c.unmarkPVCForRestore(snapRestore.Status.Volumes, lock.OwnerUID)
+	if unlockErr != nil {
+		log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to unmark pvc for restore %v", unlockErr)
+	}
+	if restoreErr != nil {
 		snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
-		return fmt.Errorf("failed to restore pvc %v", err)
+		return fmt.Errorf("failed to restore pvc %v", restoreErr)
 	}
-	err = unmarkPVCForRestore(snapRestore.Status.Volumes)
-	if err != nil {
-		log.VolumeSnapshotRestoreLog(snapRestore).Errorf("unable to unmark pvc for restore %v", err)
-		return err
+	if unfenceErr != nil {
+		snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusFailed
+		if fencing.IsFenceBreached(unfenceErr) {
+			return fmt.Errorf("volumes were used while they were being restored, the restored data can't be trusted: %v", unfenceErr)
+		}
+		return fmt.Errorf("failed to unfence volumes after restore: %v", unfenceErr)
+	}
+	if unlockErr != nil {
+		return unlockErr
 	}
 
+	if snapRestore.Spec.RepairOwnership {
+		c.repairOwnership(snapRestore)
+	}
 	snapRestore.Status.Status = stork_api.VolumeSnapshotRestoreStatusSuccessful
 	return nil
 }
 
-func markPVCForRestore(volumes []*stork_api.RestoreVolumeInfo) error {
-	// Get a list of pods that need to be deleted
-	for _, vol := range volumes {
-		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get pvc details %v", err)
+// keepLocked extends the locks on the PVCs being restored, and their fence if
+// a fencer is given, until stop is closed so that they don't expire during
+// long restores. Errors are reported as events since the restore itself can
+// still succeed, and Unfence fails if the volumes were used during the
+// restore.
+func (c *SnapshotRestoreController) keepLocked(
+	snapRestore *stork_api.VolumeSnapshotRestore,
+	lock pvclock.Lock,
+	fencer fencing.Fencer,
+	stop <-chan struct{},
+) {
+	wait.Until(func() {
+		for _, vol := range snapRestore.Status.Volumes {
+			if err := pvclock.Acquire(vol.PVC, vol.Namespace, lock); err != nil {
+				log.VolumeSnapshotRestoreLog(snapRestore).Warnf("Error extending lock on PVC %v/%v: %v", vol.Namespace, vol.PVC, err)
+				c.recorder.Event(snapRestore,
+					v1.EventTypeWarning,
+					string(stork_api.VolumeSnapshotRestoreStatusInProgress),
+					fmt.Sprintf("Error extending lock on PVC %v/%v: %v", vol.Namespace, vol.PVC, err))
+			}
 		}
-		if pvc.Annotations == nil {
-			pvc.Annotations = make(map[string]string)
+		if fencer == nil {
+			return
+		}
+		if err := fencer.Extend(snapRestore.Status.Volumes); err != nil {
+			log.VolumeSnapshotRestoreLog(snapRestore).Warnf("Error extending fence using %v: %v", fencer, err)
+			c.recorder.Event(snapRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusInProgress),
+				fmt.Sprintf("Error extending fence of the volumes: %v", err))
 		}
-		pvc.Annotations[RestoreAnnotation] = "true"
-		newPvc, err := core.Instance().UpdatePersistentVolumeClaim(pvc)
+	}, lockExtendInterval, stop)
+}
+
+// repairOwnership fixes the ownership of the restored volumes for pods that
+// expect the files to be owned by their fsGroup. Failures are reported as
+// events but don't fail the restore since the data has already been restored.
+func (c *SnapshotRestoreController) repairOwnership(snapRestore *stork_api.VolumeSnapshotRestore) {
+	for _, vol := range snapRestore.Status.Volumes {
+		pvc, err := cache.GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
 		if err != nil {
-			return err
+			c.recorder.Event(snapRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusFailed),
+				fmt.Sprintf("Error getting PVC %v/%v to repair ownership: %v", vol.Namespace, vol.PVC, err))
+			continue
 		}
-		pods, err := core.Instance().GetPodsUsingPVC(newPvc.Name, newPvc.Namespace)
+		fsGroup, nodeName, err := k8sutils.GetFSGroupForPVC(pvc.Name, pvc.Namespace)
 		if err != nil {
-			return err
+			c.recorder.Event(snapRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusFailed),
+				fmt.Sprintf("Error getting fsGroup for PVC %v/%v: %v", vol.Namespace, vol.PVC, err))
+			continue
 		}
-		for _, pod := range pods {
-			if pod.Spec.SchedulerName != storkSchedulerName {
-				return fmt.Errorf("application not scheduled by stork scheduler")
+		if fsGroup == nil {
+			log.VolumeSnapshotRestoreLog(snapRestore).Infof("No pods with fsGroup using PVC %v/%v, skipping ownership repair", vol.Namespace, vol.PVC)
+			continue
+		}
+		if err := k8sutils.RepairVolumeOwnership(pvc, *fsGroup, nodeName, k8sutils.OwnershipRepairTimeout); err != nil {
+			c.recorder.Event(snapRestore,
+				v1.EventTypeWarning,
+				string(stork_api.VolumeSnapshotRestoreStatusFailed),
+				err.Error())
+		}
+	}
+}
+
+// markPVCForRestore locks the PVCs so that the extender doesn't schedule
+// pods using them and other operations don't use them, and deletes the pods
+// that are using them. Pods that aren't
+// scheduled by stork would be started again right away, so their volumes
+// need to be fenced by the fencer instead. Restores of volumes used by such
+// pods fail if no fencer is given.
+func (c *SnapshotRestoreController) markPVCForRestore(
+	volumes []*stork_api.RestoreVolumeInfo,
+	lock pvclock.Lock,
+	fencer fencing.Fencer,
+) error {
+	err := forEachVolume(volumes, func(vol *stork_api.RestoreVolumeInfo) error {
+		if err := pvclock.Acquire(vol.PVC, vol.Namespace, lock); err != nil {
+			return fmt.Errorf("failed to lock pvc %v/%v for restore: %v", vol.Namespace, vol.PVC, err)
+		}
+		return nil
+	})
+	if err != nil {
+		// Don't keep the volumes that were locked while waiting for the
+		// others
+		if unmarkErr := c.unmarkPVCForRestore(volumes, lock.OwnerUID); unmarkErr != nil {
+			logrus.Warnf("Failed to unlock pvcs after failing to lock them: %v", unmarkErr)
+		}
+		return err
+	}
+
+	if err := stopPodsForRestore(volumes, fencer); err != nil {
+		// Start the applications that were already stopped again and
+		// release the locks so that the volumes can be used until the
+		// restore is retried
+		if fencer != nil {
+			if unfenceErr := fencer.Unfence(volumes); unfenceErr != nil {
+				logrus.Warnf("Failed to unfence volumes after failing to stop their pods: %v", unfenceErr)
 			}
 		}
+		if unmarkErr := c.unmarkPVCForRestore(volumes, lock.OwnerUID); unmarkErr != nil {
+			logrus.Warnf("Failed to unlock pvcs after failing to stop their pods: %v", unmarkErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// stopPodsForRestore fences the volumes if a fencer is given and deletes the
+// pods that are using them
+func stopPodsForRestore(volumes []*stork_api.RestoreVolumeInfo, fencer fencing.Fencer) error {
+	// Fence the volumes before their pods are deleted so that the pods
+	// aren't started again by their owners
+	if fencer != nil {
+		logrus.Infof("Fencing the volumes being restored using %v", fencer)
+		if err := fencer.Fence(volumes); err != nil {
+			return fmt.Errorf("failed to fence volumes using %v: %v", fencer, err)
+		}
+	}
 
-		logrus.Infof("Deleting pods using volume %v/%v", vol.PVC, vol.Namespace)
-		if err := ensurePodsDeletion(pods); err != nil {
-			logrus.Errorf("Failed to delete pods using volume %v/%v: %v", vol.PVC, vol.Namespace, err)
+	// Get a list of pods that need to be deleted. A pod can use more than
+	// one of the volumes, so it's only added once.
+	pods := make([]v1.Pod, 0)
+	podUIDs := make(map[types.UID]bool)
+	for _, vol := range volumes {
+		volPods, err := cache.GetPodsUsingPVC(vol.PVC, vol.Namespace)
+		if err != nil {
 			return err
 		}
+		for _, pod := range volPods {
+			if pod.Spec.SchedulerName != storkSchedulerName && fencer == nil {
+				return fmt.Errorf("application not scheduled by stork scheduler, fencing needs to be set for the restore")
+			}
+			if !podUIDs[pod.UID] {
+				podUIDs[pod.UID] = true
+				pods = append(pods, pod)
+			}
+		}
+	}
+
+	logrus.Infof("Deleting %v pods using the volumes being restored", len(pods))
+	if err := ensurePodsDeletion(pods); err != nil {
+		logrus.Errorf("Failed to delete pods using the volumes being restored: %v", err)
+		return err
 	}
 	return nil
 }
 
+// forEachVolume calls update for each of the volumes, with at most
+// pvcUpdateConcurrency updates running in parallel. The errors from all the
+// updates are returned.
+func forEachVolume(volumes []*stork_api.RestoreVolumeInfo, update func(*stork_api.RestoreVolumeInfo) error) error {
+	var (
+		wg            sync.WaitGroup
+		updateErr     error
+		updateErrLock sync.Mutex
+	)
+	semaphore := make(chan struct{}, pvcUpdateConcurrency)
+	for _, vol := range volumes {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(vol *stork_api.RestoreVolumeInfo) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			if err := update(vol); err != nil {
+				updateErrLock.Lock()
+				updateErr = multierror.Append(updateErr, err)
+				updateErrLock.Unlock()
+			}
+		}(vol)
+	}
+	wg.Wait()
+	return updateErr
+}
+
 func ensurePodsDeletion(pods []v1.Pod) error {
 	if err := core.Instance().DeletePods(pods, false); err != nil {
 		return err
@@ -313,40 +578,24 @@ func ensurePodsDeletion(pods []v1.Pod) error {
 	return podDeleteErr
 }
 
-func unmarkPVCForRestore(volumes []*stork_api.RestoreVolumeInfo) error {
-	// remove annotation from pvc's
-	for _, vol := range volumes {
-		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PVC, vol.Namespace)
-		if err != nil {
-			return fmt.Errorf("failed to get pvc details %v", err)
-		}
-		logrus.Infof("Removing annotation for %v", pvc.Name)
-		if pvc.Annotations == nil {
-			// somehow annotation got deleted but since restore is done,
-			// we shouldn't care
-			log.PVCLog(pvc).Warnf("No annotation found for %v", pvc.Name)
-			continue
-		}
-		if _, ok := pvc.Annotations[RestoreAnnotation]; !ok {
-			log.PVCLog(pvc).Warnf("Restore annotation not found for %v", pvc.Name)
-			continue
-		}
-		delete(pvc.Annotations, RestoreAnnotation)
-		_, err = core.Instance().UpdatePersistentVolumeClaim(pvc)
-		if err != nil {
-			log.PVCLog(pvc).Warnf("failed to update pvc %v", err)
+func (c *SnapshotRestoreController) unmarkPVCForRestore(volumes []*stork_api.RestoreVolumeInfo, ownerUID string) error {
+	// release the locks held by the restore. Locks that were already
+	// released or are held by others are left alone.
+	return forEachVolume(volumes, func(vol *stork_api.RestoreVolumeInfo) error {
+		logrus.Infof("Removing lock for %v/%v", vol.Namespace, vol.PVC)
+		if err := pvclock.Release(vol.PVC, vol.Namespace, ownerUID); err != nil {
+			logrus.Warnf("failed to update pvc %v/%v: %v", vol.Namespace, vol.PVC, err)
 			return err
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 func initRestoreVolumesInfo(snapshotList []*snap_v1.VolumeSnapshot, snapRestore *stork_api.VolumeSnapshotRestore) error {
 	for _, snap := range snapshotList {
 		snapData := string(snap.Spec.SnapshotDataName)
 		logrus.Debugf("Getting volume ID for pvc %v", snap.Spec.PersistentVolumeClaimName)
-		pvc, err := core.Instance().GetPersistentVolumeClaim(snap.Spec.PersistentVolumeClaimName, snap.Metadata.Namespace)
+		pvc, err := cache.GetPersistentVolumeClaim(snap.Spec.PersistentVolumeClaimName, snap.Metadata.Namespace)
 		if err != nil {
 			return fmt.Errorf("failed to get pvc details for snapshot %v", err)
 		}
@@ -374,33 +623,13 @@ func initRestoreVolumesInfo(snapshotList []*snap_v1.VolumeSnapshot, snapRestore
 }
 
 func (c *SnapshotRestoreController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.SnapshotRestoreResourceName,
-		Plural:  stork_api.SnapshotRestoreResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
-	if err != nil {
-		return err
-	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
-	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return crds.Register(reflect.TypeOf(stork_api.VolumeSnapshotRestore{}).Name())
 }
 
 func (c *SnapshotRestoreController) handleDelete(snapRestore *stork_api.VolumeSnapshotRestore) error {
+	if err := c.unmarkPVCForRestore(snapRestore.Status.Volumes, string(snapRestore.UID)); err != nil {
+		log.VolumeSnapshotRestoreLog(snapRestore).Warnf("unable to unlock pvcs of deleted restore: %v", err)
+	}
 	return c.volDriver.CleanupSnapshotRestoreObjects(snapRestore)
 }
 
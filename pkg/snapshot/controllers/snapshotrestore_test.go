@@ -0,0 +1,176 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/fencing"
+	"github.com/libopenstorage/stork/pkg/pvclock"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// restoreDriver is a volume driver that only completes in-place restores
+type restoreDriver struct {
+	volume.Driver
+	restoreErr error
+	restored   int
+}
+
+func (d *restoreDriver) CompleteVolumeSnapshotRestore(*stork_api.VolumeSnapshotRestore) error {
+	d.restored++
+	return d.restoreErr
+}
+
+// breachedFencer reports every volume as breached when it is unfenced
+type breachedFencer struct {
+	fenced bool
+}
+
+func (f *breachedFencer) String() string {
+	return "breached"
+}
+
+func (f *breachedFencer) Fence([]*stork_api.RestoreVolumeInfo) error {
+	f.fenced = true
+	return nil
+}
+
+func (f *breachedFencer) Extend([]*stork_api.RestoreVolumeInfo) error {
+	return nil
+}
+
+func (f *breachedFencer) Unfence(volumes []*stork_api.RestoreVolumeInfo) error {
+	f.fenced = false
+	return &fencing.ErrFenceBreached{Namespace: volumes[0].Namespace, PVC: volumes[0].PVC, Reason: "test"}
+}
+
+func setupHandleFinalTest(t *testing.T, restoreErr error) (*SnapshotRestoreController, *restoreDriver, *breachedFencer, *stork_api.VolumeSnapshotRestore) {
+	kubeClient := fake.NewSimpleClientset(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "test"},
+	})
+	core.SetInstance(core.New(kubeClient))
+	pvclock.SetClient(kubeClient)
+	fencer := &breachedFencer{}
+	require.NoError(t, fencing.Register(fencer.String(), fencer))
+
+	driver := &restoreDriver{restoreErr: restoreErr}
+	c := &SnapshotRestoreController{volDriver: driver, recorder: record.NewFakeRecorder(20)}
+	snapRestore := &stork_api.VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test", UID: "restore-uid"},
+		Spec:       stork_api.VolumeSnapshotRestoreSpec{Fencing: fencer.String()},
+		Status: stork_api.VolumeSnapshotRestoreStatus{
+			Status:  stork_api.VolumeSnapshotRestoreStatusStaged,
+			Volumes: []*stork_api.RestoreVolumeInfo{{PVC: "pvc1", Namespace: "test"}},
+		},
+	}
+	return c, driver, fencer, snapRestore
+}
+
+func isPVCLocked(t *testing.T) bool {
+	pvc, err := core.Instance().GetPersistentVolumeClaim("pvc1", "test")
+	require.NoError(t, err)
+	_, ok := pvc.Annotations[pvclock.LockAnnotation]
+	return ok
+}
+
+func TestHandleFinalFenceBreached(t *testing.T) {
+	c, driver, fencer, snapRestore := setupHandleFinalTest(t, nil)
+
+	err := c.handleFinal(snapRestore)
+	require.Error(t, err, "Expected error for volumes used while they were restored")
+	require.Contains(t, err.Error(), "can't be trusted")
+	require.Equal(t, stork_api.VolumeSnapshotRestoreStatusFailed, snapRestore.Status.Status)
+	require.Equal(t, 1, driver.restored)
+	require.False(t, fencer.fenced, "Expected volumes to be unfenced")
+	require.False(t, isPVCLocked(t), "Expected lock to be released")
+}
+
+func TestHandleFinalRestoreAndUnfenceFailed(t *testing.T) {
+	c, _, fencer, snapRestore := setupHandleFinalTest(t, fmt.Errorf("restore error"))
+
+	err := c.handleFinal(snapRestore)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "restore error", "Expected the restore error to be kept")
+	require.Equal(t, stork_api.VolumeSnapshotRestoreStatusFailed, snapRestore.Status.Status)
+	require.False(t, fencer.fenced, "Expected volumes to be unfenced")
+	require.False(t, isPVCLocked(t), "Expected lock to be released")
+}
+
+func TestMarkPVCForRestoreReleasesOnFailure(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "test", UID: "pod-uid"},
+		Spec: v1.PodSpec{
+			SchedulerName: "default-scheduler",
+			Volumes: []v1.Volume{{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc1"},
+				},
+			}},
+		},
+	}
+	for _, test := range []struct {
+		name    string
+		fenced  bool
+		reactor func(*fake.Clientset)
+		err     string
+	}{
+		{
+			name: "pods not scheduled by stork without fencing",
+			err:  "fencing needs to be set",
+		},
+		{
+			name:   "error listing pods",
+			fenced: true,
+			reactor: func(kubeClient *fake.Clientset) {
+				kubeClient.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("list error")
+				})
+			},
+			err: "list error",
+		},
+		{
+			name:   "error deleting pods",
+			fenced: true,
+			reactor: func(kubeClient *fake.Clientset) {
+				kubeClient.PrependReactor("delete", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("delete error")
+				})
+			},
+			err: "delete error",
+		},
+	} {
+		c, _, fencer, snapRestore := setupHandleFinalTest(t, nil)
+		kubeClient := fake.NewSimpleClientset(&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: "test"},
+		}, pod)
+		if test.reactor != nil {
+			test.reactor(kubeClient)
+		}
+		core.SetInstance(core.New(kubeClient))
+		pvclock.SetClient(kubeClient)
+
+		var volFencer fencing.Fencer
+		if test.fenced {
+			volFencer = fencer
+		}
+		lock := pvclock.NewLock("VolumeSnapshotRestore", snapRestore, pvclock.OperationRestore)
+		err := c.markPVCForRestore(snapRestore.Status.Volumes, lock, volFencer)
+		require.Error(t, err, test.name)
+		require.Contains(t, err.Error(), test.err, test.name)
+		require.False(t, fencer.fenced, "%v: Expected volumes to be unfenced", test.name)
+		require.False(t, isPVCLocked(t), "%v: Expected lock to be released", test.name)
+	}
+}
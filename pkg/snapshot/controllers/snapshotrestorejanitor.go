@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/pvclock"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	restoreJanitorInterval = 5 * time.Minute
+	// staleLockReason is the event reason used when a stale lock is removed
+	// from a PVC
+	staleLockReason = "StaleLockRemoved"
+	// legacyRestoreAnnotation and legacyRestoreOwnerAnnotation were set on
+	// PVCs being restored in place before PVCs were locked with
+	// pvclock.LockAnnotation
+	legacyRestoreAnnotation      = "stork.libopenstorage.org/restore-in-progress"
+	legacyRestoreOwnerAnnotation = "stork.libopenstorage.org/restore-owner"
+)
+
+// startRestoreJanitor periodically removes the locks from PVCs whose owner
+// didn't release them. Locks can be left behind if stork crashes while an
+// operation is in progress. Restore locks keep pods from being scheduled, so
+// they are removed as soon as their owner is gone or has finished. Other
+// locks aren't honored once they expire, but they are removed so that they
+// don't show up on the PVCs.
+func (c *SnapshotRestoreController) startRestoreJanitor(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(context.Context) {
+		if err := c.cleanupStaleLocks(); err != nil {
+			logrus.Errorf("Error cleaning up stale PVC locks: %v", err)
+		}
+	}, restoreJanitorInterval)
+	return nil
+}
+
+func (c *SnapshotRestoreController) cleanupStaleLocks() error {
+	pvcs := make([]v1.PersistentVolumeClaim, 0)
+	for _, ns := range controllers.NamespacesToList(c.namespaces) {
+		nsPVCs, err := core.Instance().GetPersistentVolumeClaims(ns, nil)
+		if err != nil {
+			return err
+		}
+		pvcs = append(pvcs, nsPVCs.Items...)
+	}
+	for i := range pvcs {
+		pvc := &pvcs[i]
+		reason := getStaleLockReason(pvc)
+		if reason == "" {
+			continue
+		}
+
+		removed, err := pvclock.Remove(pvc, legacyRestoreAnnotation, legacyRestoreOwnerAnnotation)
+		if err != nil {
+			log.PVCLog(pvc).Errorf("Error removing stale lock: %v", err)
+			continue
+		}
+		if !removed {
+			log.PVCLog(pvc).Infof("Lock changed while it was checked, not removing it")
+			continue
+		}
+		msg := fmt.Sprintf("Removed stale lock: %v", reason)
+		log.PVCLog(pvc).Warnf(msg)
+		c.recorder.Event(pvc, v1.EventTypeWarning, staleLockReason, msg)
+	}
+	return nil
+}
+
+// getStaleLockReason returns why the lock on the PVC is stale, or an empty
+// string if the PVC doesn't have a stale lock. Restores that were in
+// progress with the legacy annotations lock the PVCs again when they are
+// resumed, so the legacy annotations are always stale. Restore locks are
+// stale once their owner is gone or has finished, other locks once they
+// expire.
+func getStaleLockReason(pvc *v1.PersistentVolumeClaim) string {
+	if _, ok := pvc.Annotations[legacyRestoreAnnotation]; ok {
+		return "restore-in-progress annotation was replaced by " + pvclock.LockAnnotation
+	}
+	lock, err := pvclock.Get(pvc)
+	if err != nil {
+		return err.Error()
+	}
+	if lock == nil {
+		return ""
+	}
+	reason, err := getLockOwnerStaleReason(lock)
+	if err != nil {
+		log.PVCLog(pvc).Errorf("Error checking owner of lock: %v", err)
+	} else if reason != "" {
+		return reason
+	}
+	if lock.Expired() {
+		return fmt.Sprintf("lock for %v by %v expired at %v", lock.Operation, lock.Owner, lock.Expiry.UTC().Format(time.RFC3339))
+	}
+	return ""
+}
+
+// getLockOwnerStaleReason returns why the owner of a restore lock no longer
+// holds it, or an empty string if the owner is still running or can't be
+// looked up
+func getLockOwnerStaleReason(lock *pvclock.Lock) (string, error) {
+	parts := strings.SplitN(lock.Owner, " ", 2)
+	if len(parts) != 2 {
+		return "", nil
+	}
+	kind := parts[0]
+	nameParts := strings.SplitN(parts[1], "/", 2)
+	if len(nameParts) != 2 {
+		return "", nil
+	}
+	namespace, name := nameParts[0], nameParts[1]
+
+	var owner metav1.Object
+	var finished bool
+	var status string
+	var err error
+	switch kind {
+	case "VolumeSnapshotRestore":
+		var snapRestore *stork_api.VolumeSnapshotRestore
+		snapRestore, err = storkops.Instance().GetVolumeSnapshotRestore(name, namespace)
+		if err == nil {
+			finished = snapRestore.Status.Status == stork_api.VolumeSnapshotRestoreStatusSuccessful ||
+				snapRestore.Status.Status == stork_api.VolumeSnapshotRestoreStatusFailed
+			status = string(snapRestore.Status.Status)
+		}
+		owner = snapRestore
+	case "ApplicationRestore":
+		var restore *stork_api.ApplicationRestore
+		restore, err = storkops.Instance().GetApplicationRestore(name, namespace)
+		if err == nil {
+			finished = restore.Status.Stage == stork_api.ApplicationRestoreStageFinal
+			status = string(restore.Status.Status)
+		}
+		owner = restore
+	default:
+		return "", nil
+	}
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Sprintf("%v doesn't exist", lock.Owner), nil
+		}
+		return "", err
+	}
+	if string(owner.GetUID()) != lock.OwnerUID {
+		return fmt.Sprintf("%v (UID %v) doesn't exist", lock.Owner, lock.OwnerUID), nil
+	}
+	if owner.GetDeletionTimestamp() != nil {
+		return fmt.Sprintf("%v is being deleted", lock.Owner), nil
+	}
+	if finished {
+		return fmt.Sprintf("%v finished with status %v", lock.Owner, status), nil
+	}
+	return "", nil
+}
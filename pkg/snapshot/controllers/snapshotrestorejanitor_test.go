@@ -0,0 +1,93 @@
+//go:build unittest
+// +build unittest
+
+package controllers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/libopenstorage/stork/pkg/pvclock"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func newLockedPVC(t *testing.T, name string, lock pvclock.Lock, expiry time.Duration) *v1.PersistentVolumeClaim {
+	lock.Expiry = metav1.NewTime(time.Now().Add(expiry))
+	value, err := json.Marshal(&lock)
+	require.NoError(t, err)
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test",
+			Annotations: map[string]string{pvclock.LockAnnotation: string(value)},
+		},
+	}
+}
+
+func newJanitorSnapshotRestore(name string, status stork_api.VolumeSnapshotRestoreStatusType) *stork_api.VolumeSnapshotRestore {
+	return &stork_api.VolumeSnapshotRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", UID: types.UID(name + "-uid")},
+		Status:     stork_api.VolumeSnapshotRestoreStatus{Status: status},
+	}
+}
+
+func TestCleanupStaleLocks(t *testing.T) {
+	running := newJanitorSnapshotRestore("running", stork_api.VolumeSnapshotRestoreStatusInProgress)
+	failed := newJanitorSnapshotRestore("failed", stork_api.VolumeSnapshotRestoreStatusFailed)
+	successful := newJanitorSnapshotRestore("successful", stork_api.VolumeSnapshotRestoreStatusSuccessful)
+	deleted := newJanitorSnapshotRestore("deleted", stork_api.VolumeSnapshotRestoreStatusInProgress)
+	recreated := newJanitorSnapshotRestore("recreated", stork_api.VolumeSnapshotRestoreStatusInProgress)
+	restore := &stork_api.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "test", UID: "restore-uid"},
+		Status:     stork_api.ApplicationRestoreStatus{Stage: stork_api.ApplicationRestoreStageFinal},
+	}
+	migration := &stork_api.Migration{ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "test", UID: "migration-uid"}}
+
+	recreatedLock := pvclock.NewLock("VolumeSnapshotRestore", recreated, pvclock.OperationRestore)
+	recreatedLock.OwnerUID = "old-uid"
+	pvcs := []runtime.Object{
+		newLockedPVC(t, "running", pvclock.NewLock("VolumeSnapshotRestore", running, pvclock.OperationRestore), time.Hour),
+		newLockedPVC(t, "expired", pvclock.NewLock("VolumeSnapshotRestore", running, pvclock.OperationRestore), -time.Minute),
+		newLockedPVC(t, "failed", pvclock.NewLock("VolumeSnapshotRestore", failed, pvclock.OperationRestore), time.Hour),
+		newLockedPVC(t, "successful", pvclock.NewLock("VolumeSnapshotRestore", successful, pvclock.OperationRestore), time.Hour),
+		newLockedPVC(t, "missing", pvclock.NewLock("VolumeSnapshotRestore", deleted, pvclock.OperationRestore), time.Hour),
+		newLockedPVC(t, "recreated", recreatedLock, time.Hour),
+		newLockedPVC(t, "apprestore", pvclock.NewLock("ApplicationRestore", restore, pvclock.OperationRestore), time.Hour),
+		newLockedPVC(t, "migration", pvclock.NewLock("Migration", migration, pvclock.OperationMigration), time.Hour),
+	}
+	kubeClient := fake.NewSimpleClientset(pvcs...)
+	core.SetInstance(core.New(kubeClient))
+	pvclock.SetClient(kubeClient)
+	storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(running, failed, successful, recreated, restore), nil))
+
+	recorder := record.NewFakeRecorder(20)
+	c := &SnapshotRestoreController{recorder: recorder}
+	require.NoError(t, c.cleanupStaleLocks())
+
+	isLocked := func(name string) bool {
+		pvc, err := core.Instance().GetPersistentVolumeClaim(name, "test")
+		require.NoError(t, err)
+		_, ok := pvc.Annotations[pvclock.LockAnnotation]
+		return ok
+	}
+	require.True(t, isLocked("running"), "Expected lock of running restore to be kept")
+	require.True(t, isLocked("migration"), "Expected lock that hasn't expired to be kept for owners that aren't checked")
+	require.False(t, isLocked("expired"), "Expected expired lock to be removed")
+	require.False(t, isLocked("failed"), "Expected lock of failed restore to be removed")
+	require.False(t, isLocked("successful"), "Expected lock of successful restore to be removed")
+	require.False(t, isLocked("missing"), "Expected lock of missing restore to be removed")
+	require.False(t, isLocked("recreated"), "Expected lock of recreated restore to be removed")
+	require.False(t, isLocked("apprestore"), "Expected lock of finished ApplicationRestore to be removed")
+	require.Len(t, recorder.Events, 6)
+}
@@ -9,19 +9,23 @@ import (
 
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cache"
 	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/libopenstorage/stork/pkg/log"
+	"github.com/libopenstorage/stork/pkg/rule"
 	"github.com/libopenstorage/stork/pkg/schedule"
-	"github.com/libopenstorage/stork/pkg/version"
-	"github.com/portworx/sched-ops/k8s/apiextensions"
+	"github.com/libopenstorage/stork/pkg/snapshotter"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
 	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
-	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
-	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -29,19 +33,24 @@ import (
 )
 
 const (
-	nameTimeSuffixFormat string        = "2006-01-02-150405"
-	validateCRDInterval  time.Duration = 5 * time.Second
-	validateCRDTimeout   time.Duration = 1 * time.Minute
+	snapshotScheduleControllerName = "snapshot-schedule-controller"
 
+	nameTimeSuffixFormat string = "2006-01-02-150405"
 	// SnapshotScheduleNameAnnotation Annotation used to specify the name of schedule that
 	// created the snapshot
 	SnapshotScheduleNameAnnotation = "stork.libopenstorage.org/snapshotScheduleName"
 	// SnapshotSchedulePolicyTypeAnnotation Annotation used to specify the type of the
 	// policy that triggered the snapshot
 	SnapshotSchedulePolicyTypeAnnotation = "stork.libopenstorage.org/snapshotSchedulePolicyType"
-	storkRuleAnnotationPrefix            = "stork.libopenstorage.org"
-	preSnapRuleAnnotationKey             = storkRuleAnnotationPrefix + "/pre-snapshot-rule"
-	postSnapRuleAnnotationKey            = storkRuleAnnotationPrefix + "/post-snapshot-rule"
+	// QuiesceVolumesAnnotation Annotation used to specify that IO on the
+	// volumes should be quiesced by the driver while the snapshot is taken
+	QuiesceVolumesAnnotation = "stork.libopenstorage.org/quiesce-volumes"
+	// RetainBackendSnapshotAnnotation Annotation set on VolumeSnapshotData
+	// to keep the snapshot in the storage backend when it is deleted
+	RetainBackendSnapshotAnnotation = "stork.libopenstorage.org/retain-backend-snapshot"
+	storkRuleAnnotationPrefix       = "stork.libopenstorage.org"
+	preSnapRuleAnnotationKey        = storkRuleAnnotationPrefix + "/pre-snapshot-rule"
+	postSnapRuleAnnotationKey       = storkRuleAnnotationPrefix + "/post-snapshot-rule"
 )
 
 // NewSnapshotScheduleController creates a new instance of SnapshotScheduleController.
@@ -66,7 +75,7 @@ func (s *SnapshotScheduleController) Init(mgr manager.Manager) error {
 		return fmt.Errorf("register crd: %s", err)
 	}
 
-	return controllers.RegisterTo(mgr, "snapshot-schedule-controller", s, &stork_api.VolumeSnapshotSchedule{})
+	return controllers.RegisterTo(mgr, snapshotScheduleControllerName, s, &stork_api.VolumeSnapshotSchedule{})
 }
 
 // Reconcile manages SnapshotSchedule resources.
@@ -84,15 +93,15 @@ func (s *SnapshotScheduleController) Reconcile(ctx context.Context, request reco
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(snapshotScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
 	if err = s.handle(context.TODO(), snapshotSchedule); err != nil {
 		logrus.Errorf("%s: %s/%s: %s", reflect.TypeOf(s), snapshotSchedule.Namespace, snapshotSchedule.Name, err)
-		return reconcile.Result{RequeueAfter: controllers.DefaultRequeueError}, err
+		return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriodOnError(snapshotScheduleControllerName, controllers.DefaultRequeueError)}, err
 	}
 
-	return reconcile.Result{RequeueAfter: controllers.DefaultRequeue}, nil
+	return reconcile.Result{RequeueAfter: storkconfig.GetRequeuePeriod(snapshotScheduleControllerName, controllers.DefaultRequeue)}, nil
 }
 
 // Handle updates for VolumeSnapshotSchedule objects
@@ -115,9 +124,13 @@ func (s *SnapshotScheduleController) handle(ctx context.Context, snapshotSchedul
 		return err
 	}
 
+	if err := s.checkScheduleDrift(snapshotSchedule); err != nil {
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error checking schedule drift: %v", err)
+	}
+
 	if snapshotSchedule.Spec.Suspend == nil || !*snapshotSchedule.Spec.Suspend {
 		// Then check if any of the policies require a trigger
-		policyType, start, err := s.shouldStartVolumeSnapshot(snapshotSchedule)
+		policyType, scheduledTimestamp, start, err := s.shouldStartVolumeSnapshot(snapshotSchedule)
 		if err != nil {
 			msg := fmt.Sprintf("Error checking if snapshot should be triggered: %v", err)
 			s.recorder.Event(snapshotSchedule,
@@ -130,7 +143,14 @@ func (s *SnapshotScheduleController) handle(ctx context.Context, snapshotSchedul
 
 		// Start a snapshot for a policy if required
 		if start {
-			err := s.startVolumeSnapshot(snapshotSchedule, policyType)
+			deferred, err := s.deferIfRequired(snapshotSchedule)
+			if err != nil {
+				return err
+			}
+			if deferred {
+				return nil
+			}
+			err = s.startVolumeSnapshot(snapshotSchedule, policyType, scheduledTimestamp)
 			if err != nil {
 				msg := fmt.Sprintf("Error triggering snapshot for schedule(%v): %v", policyType, err)
 				s.recorder.Event(snapshotSchedule,
@@ -159,6 +179,106 @@ func (s *SnapshotScheduleController) handle(ctx context.Context, snapshotSchedul
 	return nil
 }
 
+// deferIfRequired checks if the cluster is under maintenance and the load
+// gate of the schedule, and records the deferral in the status if the snapshot
+// that is due should be deferred. The deferral is cleared otherwise. Errors
+// during the checks don't block the snapshot.
+func (s *SnapshotScheduleController) deferIfRequired(snapshotSchedule *stork_api.VolumeSnapshotSchedule) (bool, error) {
+	deferred, reason, err := schedule.MaintenanceInProgress(storkconfig.GetMaintenanceConfiguration())
+	if err != nil {
+		msg := fmt.Sprintf("Error checking for cluster maintenance, not deferring snapshot: %v", err)
+		s.recorder.Event(snapshotSchedule,
+			v1.EventTypeWarning,
+			string(snapv1.VolumeSnapshotConditionError),
+			msg)
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Warn(msg)
+	}
+	if !deferred {
+		deferred, reason, err = schedule.DeferRequired(snapshotSchedule.Spec.LoadGate, snapshotSchedule.Status.DeferredSince)
+		if err != nil {
+			msg := fmt.Sprintf("Error checking load gate, not deferring snapshot: %v", err)
+			s.recorder.Event(snapshotSchedule,
+				v1.EventTypeWarning,
+				string(snapv1.VolumeSnapshotConditionError),
+				msg)
+			log.VolumeSnapshotScheduleLog(snapshotSchedule).Warn(msg)
+		}
+	}
+	if !deferred {
+		snapshotSchedule.Status.DeferredSince = meta.Time{}
+		snapshotSchedule.Status.DeferredReason = ""
+		return false, nil
+	}
+	if snapshotSchedule.Status.DeferredSince.IsZero() {
+		snapshotSchedule.Status.DeferredSince = meta.NewTime(schedule.GetCurrentTime())
+		msg := fmt.Sprintf("Deferring snapshot: %v", reason)
+		s.recorder.Event(snapshotSchedule,
+			v1.EventTypeNormal,
+			"Deferred",
+			msg)
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Info(msg)
+	}
+	if snapshotSchedule.Status.DeferredReason == reason {
+		return true, nil
+	}
+	snapshotSchedule.Status.DeferredReason = reason
+	return true, s.client.Update(context.TODO(), snapshotSchedule)
+}
+
+// checkScheduleDrift sets the ScheduleDrift condition if the last runs of one
+// of the policies all started later than the drift threshold after they were
+// due, and clears it otherwise
+func (s *SnapshotScheduleController) checkScheduleDrift(snapshotSchedule *stork_api.VolumeSnapshotSchedule) error {
+	threshold := storkconfig.GetScheduleDriftThreshold(schedule.DefaultDriftThreshold)
+	condition := meta.Condition{
+		Type:               stork_api.ConditionScheduleDrift,
+		Status:             meta.ConditionFalse,
+		ObservedGeneration: snapshotSchedule.Generation,
+		Reason:             "OnSchedule",
+		Message:            "Snapshots are starting on schedule",
+	}
+	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
+		runs := make([]schedule.Run, 0)
+		for _, snapshot := range snapshotSchedule.Status.Items[policyType] {
+			runs = append(runs, schedule.Run{
+				CreationTimestamp:  snapshot.CreationTimestamp,
+				ScheduledTimestamp: snapshot.ScheduledTimestamp,
+			})
+		}
+		if len(runs) == 0 {
+			continue
+		}
+		delays, err := schedule.StartDelays(snapshotSchedule.Spec.SchedulePolicyName, snapshotSchedule.Namespace, policyType, runs)
+		if err != nil {
+			return err
+		}
+		if schedule.Drifting(delays, threshold) {
+			condition.Status = meta.ConditionTrue
+			condition.Reason = "StartingLate"
+			condition.Message = fmt.Sprintf("The last %v %v snapshots started more than %v after they were due, the latest one %v late",
+				schedule.DriftRunCount, policyType, threshold, delays[len(delays)-1].Round(time.Second))
+			break
+		}
+	}
+
+	existing := apimeta.FindStatusCondition(snapshotSchedule.Status.Conditions, stork_api.ConditionScheduleDrift)
+	if existing != nil && existing.Status == condition.Status && existing.Message == condition.Message {
+		return nil
+	}
+	if condition.Status == meta.ConditionTrue {
+		s.recorder.Event(snapshotSchedule,
+			v1.EventTypeWarning,
+			stork_api.ConditionScheduleDrift,
+			condition.Message)
+		log.VolumeSnapshotScheduleLog(snapshotSchedule).Warn(condition.Message)
+	} else if existing == nil {
+		// Only record the condition once there is drift
+		return nil
+	}
+	apimeta.SetStatusCondition(&snapshotSchedule.Status.Conditions, condition)
+	return s.client.Update(context.TODO(), snapshotSchedule)
+}
+
 func (s *SnapshotScheduleController) setDefaults(snapshotSchedule *stork_api.VolumeSnapshotSchedule) {
 	if snapshotSchedule.Spec.ReclaimPolicy == "" {
 		snapshotSchedule.Spec.ReclaimPolicy = stork_api.ReclaimPolicyDelete
@@ -166,7 +286,7 @@ func (s *SnapshotScheduleController) setDefaults(snapshotSchedule *stork_api.Vol
 }
 
 func getVolumeSnapshotStatus(name string, namespace string) (snapv1.VolumeSnapshotConditionType, error) {
-	snapshot, err := k8sextops.Instance().GetSnapshot(name, namespace)
+	snapshot, err := cache.GetSnapshot(name, namespace)
 	if err != nil {
 		return snapv1.VolumeSnapshotConditionError, err
 	}
@@ -241,14 +361,14 @@ func (s *SnapshotScheduleController) isVolumeSnapshotComplete(status snapv1.Volu
 	return status != snapv1.VolumeSnapshotConditionPending
 }
 
-func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule) (stork_api.SchedulePolicyType, bool, error) {
+func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule) (stork_api.SchedulePolicyType, meta.Time, bool, error) {
 	// Don't trigger a new snapshot if one is already in progress
 	for _, policyType := range stork_api.GetValidSchedulePolicyTypes() {
 		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
 		if present {
 			for _, snapshot := range policyVolumeSnapshot {
 				if !s.isVolumeSnapshotComplete(snapshot.Status) {
-					return stork_api.SchedulePolicyTypeInvalid, false, nil
+					return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 				}
 			}
 		}
@@ -259,33 +379,47 @@ func (s *SnapshotScheduleController) shouldStartVolumeSnapshot(snapshotSchedule
 		policyVolumeSnapshot, present := snapshotSchedule.Status.Items[policyType]
 		if present {
 			for _, snapshot := range policyVolumeSnapshot {
-				if latestVolumeSnapshotTimestamp.Before(&snapshot.CreationTimestamp) {
-					latestVolumeSnapshotTimestamp = snapshot.CreationTimestamp
+				triggerTimestamp := snapshot.CreationTimestamp
+				if !snapshot.ScheduledTimestamp.IsZero() {
+					triggerTimestamp = snapshot.ScheduledTimestamp
+				}
+				if latestVolumeSnapshotTimestamp.Before(&triggerTimestamp) {
+					latestVolumeSnapshotTimestamp = triggerTimestamp
 				}
 			}
 		}
-		trigger, err := schedule.TriggerRequired(
+		trigger, scheduledTimestamp, err := schedule.TriggerRequiredWithMissedRunPolicy(
 			snapshotSchedule.Spec.SchedulePolicyName,
 			snapshotSchedule.Namespace,
 			policyType,
 			latestVolumeSnapshotTimestamp,
+			snapshotSchedule.Spec.MissedRunPolicy,
 		)
 		if err != nil {
-			return stork_api.SchedulePolicyTypeInvalid, false, err
+			return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, err
 		}
 		if trigger {
-			return policyType, true, nil
+			return policyType, scheduledTimestamp, true, nil
 		}
 	}
-	return stork_api.SchedulePolicyTypeInvalid, false, nil
+	return stork_api.SchedulePolicyTypeInvalid, meta.Time{}, false, nil
 }
 
 func (s *SnapshotScheduleController) formatVolumeSnapshotName(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) string {
 	return strings.Join([]string{snapshotSchedule.Name, strings.ToLower(string(policyType)), time.Now().Format(nameTimeSuffixFormat)}, "-")
 }
 
-func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType) error {
+func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork_api.VolumeSnapshotSchedule, policyType stork_api.SchedulePolicyType, scheduledTimestamp meta.Time) error {
 	snapshotName := s.formatVolumeSnapshotName(snapshotSchedule, policyType)
+	templateData := snapshotter.NewMetadataTemplateData(snapshotName, snapshotSchedule.Name, string(policyType), schedule.GetCurrentTime())
+	snapshotLabels, err := snapshotter.RenderLabels(snapshotSchedule.Spec.SnapshotLabels, templateData)
+	if err != nil {
+		return err
+	}
+	snapshotAnnotations, err := snapshotter.RenderAnnotations(snapshotSchedule.Spec.SnapshotAnnotations, templateData)
+	if err != nil {
+		return err
+	}
 	if snapshotSchedule.Status.Items == nil {
 		snapshotSchedule.Status.Items = make(map[stork_api.SchedulePolicyType][]*stork_api.ScheduledVolumeSnapshotStatus)
 	}
@@ -294,11 +428,12 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 	}
 	snapshotSchedule.Status.Items[policyType] = append(snapshotSchedule.Status.Items[policyType],
 		&stork_api.ScheduledVolumeSnapshotStatus{
-			Name:              snapshotName,
-			CreationTimestamp: meta.NewTime(schedule.GetCurrentTime()),
-			Status:            snapv1.VolumeSnapshotConditionPending,
+			Name:               snapshotName,
+			CreationTimestamp:  meta.NewTime(schedule.GetCurrentTime()),
+			ScheduledTimestamp: scheduledTimestamp,
+			Status:             snapv1.VolumeSnapshotConditionPending,
 		})
-	err := s.client.Update(context.TODO(), snapshotSchedule)
+	err = s.client.Update(context.TODO(), snapshotSchedule)
 	if err != nil {
 		return err
 	}
@@ -307,8 +442,8 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 		Metadata: meta.ObjectMeta{
 			Name:        snapshotName,
 			Namespace:   snapshotSchedule.Namespace,
-			Annotations: snapshotSchedule.Annotations,
-			Labels:      snapshotSchedule.Labels,
+			Annotations: snapshotter.MergeMetadata(snapshotSchedule.Annotations, snapshotAnnotations),
+			Labels:      snapshotter.MergeMetadata(snapshotSchedule.Labels, snapshotLabels),
 		},
 		Spec: snapshotSchedule.Spec.Template.Spec,
 	}
@@ -317,8 +452,9 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 	}
 	snapshot.Metadata.Annotations[SnapshotScheduleNameAnnotation] = snapshotSchedule.Name
 	snapshot.Metadata.Annotations[SnapshotSchedulePolicyTypeAnnotation] = string(policyType)
+	k8sutils.SetProvenanceLabels(&snapshot.Metadata, snapshotSchedule.Name, schedule.GetCurrentTime())
 	if snapshotSchedule.Spec.PreExecRule != "" {
-		_, err := storkops.Instance().GetRule(snapshotSchedule.Spec.PreExecRule, snapshotSchedule.Namespace)
+		_, err := rule.GetRule(snapshotSchedule.Spec.PreExecRule, snapshotSchedule.Namespace)
 		if err != nil {
 			msg := fmt.Sprintf("error retrieving pre-exec rule %v", err)
 			s.recorder.Event(snapshotSchedule,
@@ -330,8 +466,11 @@ func (s *SnapshotScheduleController) startVolumeSnapshot(snapshotSchedule *stork
 		}
 	}
 	snapshot.Metadata.Annotations[preSnapRuleAnnotationKey] = snapshotSchedule.Spec.PreExecRule
+	if snapshotSchedule.Spec.QuiesceVolumes {
+		snapshot.Metadata.Annotations[QuiesceVolumesAnnotation] = "true"
+	}
 	if snapshotSchedule.Spec.PostExecRule != "" {
-		_, err := storkops.Instance().GetRule(snapshotSchedule.Spec.PostExecRule, snapshotSchedule.Namespace)
+		_, err := rule.GetRule(snapshotSchedule.Spec.PostExecRule, snapshotSchedule.Namespace)
 		if err != nil {
 			msg := fmt.Sprintf("error retrieving post-exec rule %v", err)
 			s.recorder.Event(snapshotSchedule,
@@ -395,6 +534,13 @@ func (s *SnapshotScheduleController) pruneVolumeSnapshots(snapshotSchedule *stor
 			failedDeletes := make([]*stork_api.ScheduledVolumeSnapshotStatus, 0)
 			if numReady > int(retainNum) {
 				for i := 0; i < deleteBefore; i++ {
+					if snapshotSchedule.Spec.RetainBackendSnapshotOnDelete {
+						if err := s.retainBackendSnapshot(policyVolumeSnapshot[i].Name, snapshotSchedule.Namespace); err != nil {
+							log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error retaining backend snapshot for %v: %v", policyVolumeSnapshot[i].Name, err)
+							failedDeletes = append(failedDeletes, policyVolumeSnapshot[i])
+							continue
+						}
+					}
 					err := k8sextops.Instance().DeleteSnapshot(policyVolumeSnapshot[i].Name, snapshotSchedule.Namespace)
 					if err != nil && !errors.IsNotFound(err) {
 						log.VolumeSnapshotScheduleLog(snapshotSchedule).Warnf("Error deleting %v: %v", policyVolumeSnapshot[i].Name, err)
@@ -413,29 +559,32 @@ func (s *SnapshotScheduleController) pruneVolumeSnapshots(snapshotSchedule *stor
 	return s.client.Update(context.TODO(), snapshotSchedule)
 }
 
-func (s *SnapshotScheduleController) createCRD() error {
-	resource := apiextensions.CustomResource{
-		Name:    stork_api.VolumeSnapshotScheduleResourceName,
-		Plural:  stork_api.VolumeSnapshotScheduleResourcePlural,
-		Group:   stork_api.SchemeGroupVersion.Group,
-		Version: stork_api.SchemeGroupVersion.Version,
-		Scope:   apiextensionsv1beta1.NamespaceScoped,
-		Kind:    reflect.TypeOf(stork_api.VolumeSnapshotSchedule{}).Name(),
-	}
-	ok, err := version.RequiresV1Registration()
+// retainBackendSnapshot annotates the VolumeSnapshotData of the snapshot so
+// that the snapshot plugin keeps the snapshot in the storage backend when the
+// VolumeSnapshot is deleted
+func (s *SnapshotScheduleController) retainBackendSnapshot(name, namespace string) error {
+	snapshot, err := k8sextops.Instance().GetSnapshot(name, namespace)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
 		return err
 	}
-	if ok {
-		err := k8sutils.CreateCRD(resource)
-		if err != nil && !errors.IsAlreadyExists(err) {
-			return err
-		}
-		return apiextensions.Instance().ValidateCRD(resource.Plural+"."+resource.Group, validateCRDTimeout, validateCRDInterval)
+	// Nothing was created in the backend yet
+	if snapshot.Spec.SnapshotDataName == "" {
+		return nil
 	}
-	err = apiextensions.Instance().CreateCRDV1beta1(resource)
-	if err != nil && !errors.IsAlreadyExists(err) {
+	snapshotData := &unstructured.Unstructured{}
+	snapshotData.SetGroupVersionKind(snapv1.SchemeGroupVersion.WithKind("VolumeSnapshotData"))
+	snapshotData.SetName(snapshot.Spec.SnapshotDataName)
+	patch := []byte(fmt.Sprintf(`{"metadata":{"annotations":{%q:"true"}}}`, RetainBackendSnapshotAnnotation))
+	err = s.client.Patch(context.TODO(), snapshotData, runtimeclient.RawPatch(types.MergePatchType, patch))
+	if err != nil && !errors.IsNotFound(err) {
 		return err
 	}
-	return apiextensions.Instance().ValidateCRDV1beta1(resource, validateCRDTimeout, validateCRDInterval)
+	return nil
+}
+
+func (s *SnapshotScheduleController) createCRD() error {
+	return crds.Register(reflect.TypeOf(stork_api.VolumeSnapshotSchedule{}).Name())
 }
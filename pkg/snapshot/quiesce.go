@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// QuiesceTimeout is the time after which the driver resumes IO on a
+	// quiesced volume even if it wasn't unquiesced, so that applications
+	// aren't blocked if stork fails while taking the snapshot
+	QuiesceTimeout = 1 * time.Minute
+)
+
+// QuiesceVolumes quiesces IO on all the volumes in parallel so that snapshots
+// taken while they are quiesced are crash consistent with each other. Returns
+// a function that unquiesces the volumes, which must be called once the
+// snapshots have been taken. Volumes are skipped if the driver doesn't
+// support quiesce. If any volume fails to quiesce the volumes that were
+// already quiesced are unquiesced and an error is returned.
+func QuiesceVolumes(driver volume.Driver, volumeIDs []string, quiesceID string) (func(), error) {
+	var (
+		lock      sync.Mutex
+		wg        sync.WaitGroup
+		quiesced  = make([]string, 0)
+		errorMsgs = make([]string, 0)
+	)
+	for _, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(volumeID string) {
+			defer wg.Done()
+			err := driver.QuiesceVolume(volumeID, QuiesceTimeout, quiesceID)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+					logrus.Debugf("Quiesce not supported for volume %v by driver %v", volumeID, driver.String())
+					return
+				}
+				errorMsgs = append(errorMsgs, fmt.Sprintf("%v: %v", volumeID, err))
+				return
+			}
+			quiesced = append(quiesced, volumeID)
+		}(volumeID)
+	}
+	wg.Wait()
+
+	unquiesce := func() {
+		unquiesceVolumes(driver, quiesced)
+	}
+	if len(errorMsgs) > 0 {
+		unquiesce()
+		return nil, fmt.Errorf("error quiescing volumes: %v", strings.Join(errorMsgs, ", "))
+	}
+	return unquiesce, nil
+}
+
+func unquiesceVolumes(driver volume.Driver, volumeIDs []string) {
+	var wg sync.WaitGroup
+	for _, volumeID := range volumeIDs {
+		wg.Add(1)
+		go func(volumeID string) {
+			defer wg.Done()
+			if err := driver.UnquiesceVolume(volumeID); err != nil {
+				// IO is resumed by the driver once the quiesce times out
+				logrus.Warnf("Error unquiescing volume %v: %v", volumeID, err)
+			}
+		}(volumeID)
+	}
+	wg.Wait()
+}
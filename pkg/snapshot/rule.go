@@ -2,9 +2,8 @@ package snapshot
 
 import (
 	crdv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
+	"github.com/libopenstorage/stork/pkg/cache"
 	"github.com/libopenstorage/stork/pkg/rule"
-	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
-	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 )
@@ -37,7 +36,7 @@ func validateSnapRules(snap *crdv1.VolumeSnapshot) error {
 		for _, annotation := range ruleAnnotations {
 			ruleName, present := snap.Metadata.Annotations[annotation]
 			if present && len(ruleName) > 0 {
-				r, err := storkops.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+				r, err := rule.GetRule(ruleName, snap.Metadata.Namespace)
 				if err != nil {
 					return err
 				}
@@ -75,7 +74,7 @@ func ExecutePreSnapRule(snap *crdv1.VolumeSnapshot, pvcs []v1.PersistentVolumeCl
 		if ruleName == "" {
 			return nil, nil
 		}
-		r, err := storkops.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+		r, err := rule.GetRule(ruleName, snap.Metadata.Namespace)
 		if err != nil {
 			return nil, err
 		}
@@ -102,7 +101,7 @@ func ExecutePostSnapRule(pvcs []v1.PersistentVolumeClaim, snap *crdv1.VolumeSnap
 		if ruleName == "" {
 			return nil
 		}
-		r, err := storkops.Instance().GetRule(ruleName, snap.Metadata.Namespace)
+		r, err := rule.GetRule(ruleName, snap.Metadata.Namespace)
 		if err != nil {
 			return err
 		}
@@ -115,7 +114,7 @@ func ExecutePostSnapRule(pvcs []v1.PersistentVolumeClaim, snap *crdv1.VolumeSnap
 // performRuleRecovery terminates potential background commands running pods for
 // the given snapshot
 func performRuleRecovery() error {
-	allSnaps, err := k8sextops.Instance().ListSnapshots(v1.NamespaceAll)
+	allSnaps, err := cache.ListSnapshots(v1.NamespaceAll)
 	if err != nil {
 		logrus.Errorf("Failed to list all snapshots due to: %v. Will retry.", err)
 		return err
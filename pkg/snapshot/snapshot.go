@@ -35,6 +35,9 @@ type Snapshot struct {
 	provisioner                *controller.ProvisionController
 	Driver                     volume.Driver
 	Recorder                   record.EventRecorder
+	// WatchNamespaces the restore janitor is restricted to, all namespaces
+	// if it is empty
+	WatchNamespaces []string
 }
 
 // GetProvisionerName Gets the name of the provisioner
@@ -117,7 +120,7 @@ func (s *Snapshot) Start(mgr manager.Manager) error {
 		return fmt.Errorf("error initializing snapshot schedule controller: %v", err)
 	}
 
-	s.snapshotRestoreController = controllers.NewSnapshotRestoreController(mgr, s.Driver, s.Recorder)
+	s.snapshotRestoreController = controllers.NewSnapshotRestoreController(mgr, s.Driver, s.Recorder, s.WatchNamespaces)
 	err = s.snapshotRestoreController.Init(mgr)
 	if err != nil {
 		return fmt.Errorf("error initializing snapshot restore controller: %v", err)
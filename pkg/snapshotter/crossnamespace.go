@@ -0,0 +1,156 @@
+package snapshotter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sdynamic "k8s.io/client-go/dynamic"
+)
+
+const (
+	snapshotAPIGroup      = "snapshot.storage.k8s.io"
+	volumeSnapshotKind    = "VolumeSnapshot"
+	referenceGrantKind    = "ReferenceGrant"
+	referenceGrantGroup   = "gateway.networking.k8s.io"
+	persistentVolumeClaim = "PersistentVolumeClaim"
+)
+
+// referenceGrantVersions are the versions of the ReferenceGrant API that are
+// looked up, in order of preference
+var referenceGrantVersions = []string{"v1beta1", "v1alpha2"}
+
+// CheckCrossNamespaceSnapshotAccess returns an error unless a ReferenceGrant
+// in the namespace of the VolumeSnapshot allows PVCs in pvcNamespace to use
+// it as their data source. Snapshots in the namespace of the PVC don't need
+// a grant.
+func CheckCrossNamespaceSnapshotAccess(snapshotName, snapshotNamespace, pvcNamespace string) error {
+	if snapshotNamespace == pvcNamespace {
+		return nil
+	}
+	var err error
+	for _, version := range referenceGrantVersions {
+		var grants *unstructured.UnstructuredList
+		grants, err = dynamic.Instance().ListObjects(&metav1.ListOptions{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       referenceGrantKind,
+				APIVersion: referenceGrantGroup + "/" + version,
+			},
+		}, snapshotNamespace)
+		if k8s_errors.IsNotFound(err) {
+			// The version isn't served by the cluster, try the next one
+			continue
+		} else if err != nil {
+			break
+		}
+		for _, grant := range grants.Items {
+			if referenceGrantAllows(grant.Object, snapshotName, pvcNamespace) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no ReferenceGrant in namespace %v allows PVCs in namespace %v to be restored from volumesnapshot %v",
+			snapshotNamespace, pvcNamespace, snapshotName)
+	}
+	return fmt.Errorf("failed to list ReferenceGrants in namespace %v: %v", snapshotNamespace, err)
+}
+
+// referenceGrantAllows returns true if the ReferenceGrant allows PVCs from
+// pvcNamespace to refer to the VolumeSnapshot with the given name
+func referenceGrantAllows(grant map[string]interface{}, snapshotName, pvcNamespace string) bool {
+	from, _, _ := unstructured.NestedSlice(grant, "spec", "from")
+	to, _, _ := unstructured.NestedSlice(grant, "spec", "to")
+	fromAllowed := false
+	for _, f := range from {
+		ref, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if ref["group"] == "" && ref["kind"] == persistentVolumeClaim && ref["namespace"] == pvcNamespace {
+			fromAllowed = true
+			break
+		}
+	}
+	if !fromAllowed {
+		return false
+	}
+	for _, t := range to {
+		ref, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := ref["name"].(string)
+		if ref["group"] == snapshotAPIGroup && ref["kind"] == volumeSnapshotKind && (name == "" || name == snapshotName) {
+			return true
+		}
+	}
+	return false
+}
+
+// createCrossNamespacePVC creates the PVC with a dataSourceRef to the
+// VolumeSnapshot in another namespace. The PVC is created as an unstructured
+// object since the typed PVCs don't have the namespace of the dataSourceRef.
+func createCrossNamespacePVC(
+	client k8sdynamic.Interface,
+	pvc *v1.PersistentVolumeClaim,
+	snapshotName string,
+	snapshotNamespace string,
+) (*v1.PersistentVolumeClaim, error) {
+	pvc.Spec.DataSource = nil
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pvc)
+	if err != nil {
+		return nil, err
+	}
+	object := &unstructured.Unstructured{Object: content}
+	object.SetAPIVersion(v1.SchemeGroupVersion.String())
+	object.SetKind(persistentVolumeClaim)
+	if err := unstructured.SetNestedStringMap(object.Object, map[string]string{
+		"apiGroup":  snapshotAPIGroup,
+		"kind":      volumeSnapshotKind,
+		"name":      snapshotName,
+		"namespace": snapshotNamespace,
+	}, "spec", "dataSourceRef"); err != nil {
+		return nil, err
+	}
+
+	created, err := client.Resource(v1.SchemeGroupVersion.WithResource("persistentvolumeclaims")).
+		Namespace(pvc.Namespace).Create(context.TODO(), object, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	result := &v1.PersistentVolumeClaim{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(created.Object, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getPVCSnapshotSource returns the name and namespace of the VolumeSnapshot
+// the PVC is restored from, which is set in the dataSourceRef for snapshots
+// in other namespaces
+func getPVCSnapshotSource(client k8sdynamic.Interface, pvc *v1.PersistentVolumeClaim) (string, string, error) {
+	if pvc.Spec.DataSource != nil {
+		return pvc.Spec.DataSource.Name, pvc.Namespace, nil
+	}
+	if client == nil {
+		return "", "", fmt.Errorf("PVC %v/%v has no data source", pvc.Namespace, pvc.Name)
+	}
+	object, err := client.Resource(v1.SchemeGroupVersion.WithResource("persistentvolumeclaims")).
+		Namespace(pvc.Namespace).Get(context.TODO(), pvc.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	name, _, _ := unstructured.NestedString(object.Object, "spec", "dataSourceRef", "name")
+	if name == "" {
+		return "", "", fmt.Errorf("PVC %v/%v has no data source", pvc.Namespace, pvc.Name)
+	}
+	namespace, _, _ := unstructured.NestedString(object.Object, "spec", "dataSourceRef", "namespace")
+	if namespace == "" {
+		namespace = pvc.Namespace
+	}
+	return name, namespace, nil
+}
@@ -0,0 +1,77 @@
+//go:build unittest
+// +build unittest
+
+package snapshotter
+
+import (
+	"testing"
+
+	"github.com/portworx/sched-ops/k8s/dynamic"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+)
+
+func referenceGrant(namespace, name, fromNamespace, snapshotName string) *unstructured.Unstructured {
+	to := map[string]interface{}{"group": snapshotAPIGroup, "kind": volumeSnapshotKind}
+	if snapshotName != "" {
+		to["name"] = snapshotName
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": referenceGrantGroup + "/v1beta1",
+		"kind":       referenceGrantKind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec": map[string]interface{}{
+			"from": []interface{}{map[string]interface{}{"group": "", "kind": persistentVolumeClaim, "namespace": fromNamespace}},
+			"to":   []interface{}{to},
+		},
+	}}
+}
+
+func newFakeDynamicClient(objects ...runtime.Object) *fakedynamic.FakeDynamicClient {
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Group: referenceGrantGroup, Version: "v1beta1", Resource: "referencegrants"}: "ReferenceGrantList",
+		v1.SchemeGroupVersion.WithResource("persistentvolumeclaims"):                  "PersistentVolumeClaimList",
+	}, objects...)
+}
+
+func TestCheckCrossNamespaceSnapshotAccess(t *testing.T) {
+	dynamic.SetInstance(dynamic.New(newFakeDynamicClient(
+		referenceGrant("golden", "all-snapshots", "tenant-a", ""),
+		referenceGrant("golden", "one-snapshot", "tenant-b", "pg-golden"),
+	)))
+
+	require.NoError(t, CheckCrossNamespaceSnapshotAccess("snap", "tenant-c", "tenant-c"), "same namespace")
+	require.NoError(t, CheckCrossNamespaceSnapshotAccess("pg-golden", "golden", "tenant-a"))
+	require.NoError(t, CheckCrossNamespaceSnapshotAccess("mysql-golden", "golden", "tenant-a"))
+	require.NoError(t, CheckCrossNamespaceSnapshotAccess("pg-golden", "golden", "tenant-b"))
+	require.Error(t, CheckCrossNamespaceSnapshotAccess("mysql-golden", "golden", "tenant-b"), "snapshot not granted")
+	require.Error(t, CheckCrossNamespaceSnapshotAccess("pg-golden", "golden", "tenant-c"), "namespace not granted")
+}
+
+func TestCreateCrossNamespacePVC(t *testing.T) {
+	client := newFakeDynamicClient()
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "tenant-a"},
+		Spec:       v1.PersistentVolumeClaimSpec{AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce}},
+	}
+	created, err := createCrossNamespacePVC(client, pvc, "pg-golden", "golden")
+	require.NoError(t, err)
+	require.Equal(t, "data", created.Name)
+	require.Nil(t, created.Spec.DataSource)
+
+	name, namespace, err := getPVCSnapshotSource(client, created)
+	require.NoError(t, err)
+	require.Equal(t, "pg-golden", name)
+	require.Equal(t, "golden", namespace)
+
+	created.Spec.DataSource = &v1.TypedLocalObjectReference{Kind: volumeSnapshotKind, Name: "local"}
+	name, namespace, err = getPVCSnapshotSource(client, created)
+	require.NoError(t, err)
+	require.Equal(t, "local", name)
+	require.Equal(t, "tenant-a", namespace)
+}
@@ -0,0 +1,109 @@
+package snapshotter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	storkapi "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// MetadataTimestampFormat is the format of the timestamp available to
+	// snapshot label and annotation templates. It only uses characters that
+	// are valid in label values.
+	MetadataTimestampFormat = "20060102-150405"
+)
+
+// MetadataTemplateData is the data available to the templates in the labels
+// and annotations applied to snapshots
+type MetadataTemplateData struct {
+	// Name is the name of the snapshot or group snapshot
+	Name string
+	// ScheduleName is the name of the schedule that triggered the snapshot
+	ScheduleName string
+	// PolicyType is the type of the schedule policy that triggered the
+	// snapshot
+	PolicyType string
+	// Timestamp is the time the snapshot was triggered in
+	// MetadataTimestampFormat
+	Timestamp string
+}
+
+// NewMetadataTemplateData returns the template data for a snapshot triggered
+// at the given time
+func NewMetadataTemplateData(name, scheduleName, policyType string, triggerTime time.Time) MetadataTemplateData {
+	return MetadataTemplateData{
+		Name:         name,
+		ScheduleName: scheduleName,
+		PolicyType:   policyType,
+		Timestamp:    triggerTime.UTC().Format(MetadataTimestampFormat),
+	}
+}
+
+// RenderGroupSnapshotMetadata returns the labels and annotations from the
+// spec of the group snapshot to be applied to its snapshots
+func RenderGroupSnapshotMetadata(groupSnap *storkapi.GroupVolumeSnapshot) (map[string]string, map[string]string, error) {
+	data := NewMetadataTemplateData(groupSnap.Name, "", "", groupSnap.CreationTimestamp.Time)
+	labels, err := RenderLabels(groupSnap.Spec.SnapshotLabels, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	annotations, err := RenderAnnotations(groupSnap.Spec.SnapshotAnnotations, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labels, annotations, nil
+}
+
+// RenderLabels renders the templates in the values of the labels and checks
+// that the results are valid label values
+func RenderLabels(labels map[string]string, data MetadataTemplateData) (map[string]string, error) {
+	rendered, err := renderMetadata(labels, data)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range rendered {
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value %q for label %v: %v", v, k, strings.Join(errs, ", "))
+		}
+	}
+	return rendered, nil
+}
+
+// RenderAnnotations renders the templates in the values of the annotations
+func RenderAnnotations(annotations map[string]string, data MetadataTemplateData) (map[string]string, error) {
+	return renderMetadata(annotations, data)
+}
+
+func renderMetadata(metadata map[string]string, data MetadataTemplateData) (map[string]string, error) {
+	rendered := make(map[string]string)
+	for k, v := range metadata {
+		tmpl, err := template.New(k).Option("missingkey=error").Parse(v)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing template for %v: %v", k, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("error rendering template for %v: %v", k, err)
+		}
+		rendered[k] = buf.String()
+	}
+	return rendered, nil
+}
+
+// MergeMetadata returns a copy of the labels or annotations in base with the
+// extra ones added. Values in extra replace the ones in base.
+func MergeMetadata(base map[string]string, extra map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
@@ -28,6 +28,9 @@ type Options struct {
 	SnapshotClassName string
 	// RestoreSnapshotName is the name of the snapshot from which restore will be performed
 	RestoreSnapshotName string
+	// SnapshotNamespace is the namespace of the snapshot from which restore will
+	// be performed. Defaults to RestoreNamespace.
+	SnapshotNamespace string
 	// Annotations are the annotations that can be applied on the snapshot related objects
 	Annotations map[string]string
 	// Labels are the labels that can be applied on the snapshot related objects
@@ -113,6 +116,16 @@ func RestoreSnapshotName(name string) Option {
 	}
 }
 
+// SnapshotNamespace is the namespace of the snapshot from which a PVC will be
+// restored. If it is different from the restore namespace, a ReferenceGrant in
+// it needs to allow the PVC to be restored from the snapshot.
+func SnapshotNamespace(namespace string) Option {
+	return func(opts *Options) error {
+		opts.SnapshotNamespace = namespace
+		return nil
+	}
+}
+
 // Annotations are the annotations applied on the snapshot related objects
 func Annotations(annotations map[string]string) Option {
 	return func(opts *Options) error {
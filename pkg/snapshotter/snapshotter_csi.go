@@ -33,6 +33,7 @@ import (
 	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sdynamic "k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	k8shelper "k8s.io/component-helpers/storage/volume"
 )
@@ -86,6 +87,10 @@ func NewCSIDriver() (Driver, error) {
 		return nil, err
 	}
 	cs.snapshotClient = snapClient
+	cs.dynamicClient, err = k8sdynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 	cs.snapshotClassCreatedForDriver = make(map[string]bool)
 	cs.v1SnapshotRequired, err = version.RequiresV1VolumeSnapshot()
 	if err != nil {
@@ -98,6 +103,7 @@ func NewCSIDriver() (Driver, error) {
 // csiDriver is the csi implementation of the snapshotter.Driver interface
 type csiDriver struct {
 	snapshotClient                *kSnapshotClient.Clientset
+	dynamicClient                 k8sdynamic.Interface
 	snapshotClassCreatedForDriver map[string]bool
 	v1SnapshotRequired            bool
 }
@@ -547,10 +553,18 @@ func (c *csiDriver) RestoreVolumeClaim(opts ...Option) (*v1.PersistentVolumeClai
 	pvc.ResourceVersion = ""
 	pvc.Spec.VolumeName = ""
 
+	snapshotNamespace := o.RestoreNamespace
+	if o.SnapshotNamespace != "" {
+		snapshotNamespace = o.SnapshotNamespace
+	}
+	if err := CheckCrossNamespaceSnapshotAccess(o.RestoreSnapshotName, snapshotNamespace, pvc.Namespace); err != nil {
+		return nil, err
+	}
+
 	if c.v1SnapshotRequired {
-		snapshot, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(o.RestoreNamespace).Get(context.TODO(), o.RestoreSnapshotName, metav1.GetOptions{})
+		snapshot, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(snapshotNamespace).Get(context.TODO(), o.RestoreSnapshotName, metav1.GetOptions{})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get volumesnapshot %s/%s", o.RestoreNamespace, o.RestoreSnapshotName)
+			return nil, fmt.Errorf("failed to get volumesnapshot %s/%s", snapshotNamespace, o.RestoreSnapshotName)
 		}
 
 		checkVsStatus := func() (interface{}, bool, error) {
@@ -582,9 +596,9 @@ func (c *csiDriver) RestoreVolumeClaim(opts ...Option) (*v1.PersistentVolumeClai
 			pvc.Spec.Resources.Requests[v1.ResourceStorage] = quantity
 		}
 	} else {
-		snapshot, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(o.RestoreNamespace).Get(context.TODO(), o.RestoreSnapshotName, metav1.GetOptions{})
+		snapshot, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(snapshotNamespace).Get(context.TODO(), o.RestoreSnapshotName, metav1.GetOptions{})
 		if err != nil {
-			return nil, fmt.Errorf("failed to get volumesnapshot %s/%s", o.RestoreNamespace, o.RestoreSnapshotName)
+			return nil, fmt.Errorf("failed to get volumesnapshot %s/%s", snapshotNamespace, o.RestoreSnapshotName)
 		}
 
 		checkVsStatus := func() (interface{}, bool, error) {
@@ -617,22 +631,27 @@ func (c *csiDriver) RestoreVolumeClaim(opts ...Option) (*v1.PersistentVolumeClai
 		}
 	}
 
-	pvc.Spec.DataSource = &v1.TypedLocalObjectReference{
-		APIGroup: stringPtr("snapshot.storage.k8s.io"),
-		Kind:     "VolumeSnapshot",
-		Name:     o.RestoreSnapshotName,
-	}
 	pvc.Status = v1.PersistentVolumeClaimStatus{
 		Phase: v1.ClaimPending,
 	}
-	pvc, err = core.Instance().CreatePersistentVolumeClaim(pvc)
+	var created *v1.PersistentVolumeClaim
+	if snapshotNamespace != pvc.Namespace {
+		created, err = createCrossNamespacePVC(c.dynamicClient, pvc, o.RestoreSnapshotName, snapshotNamespace)
+	} else {
+		pvc.Spec.DataSource = &v1.TypedLocalObjectReference{
+			APIGroup: stringPtr(snapshotAPIGroup),
+			Kind:     volumeSnapshotKind,
+			Name:     o.RestoreSnapshotName,
+		}
+		created, err = core.Instance().CreatePersistentVolumeClaim(pvc)
+	}
 	if err != nil {
 		if k8s_errors.IsAlreadyExists(err) {
 			return pvc, nil
 		}
 		return nil, fmt.Errorf("failed to create PVC %s: %s", pvc.Name, err.Error())
 	}
-	return pvc, nil
+	return created, nil
 }
 
 func (c *csiDriver) RestoreStatus(pvcName, namespace string) (RestoreInfo, error) {
@@ -644,12 +663,15 @@ func (c *csiDriver) RestoreStatus(pvcName, namespace string) (RestoreInfo, error
 	}
 
 	// Try to get VS. May not exist yet or may be cleaned up already.
-	vsName := pvc.Spec.DataSource.Name
+	vsName, vsNamespace, err := getPVCSnapshotSource(c.dynamicClient, pvc)
+	if err != nil {
+		return restoreInfo, err
+	}
 	var vsContentName string
 	var restoreSize uint64
 	var vsError string
 	if c.v1SnapshotRequired {
-		if vs, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(namespace).Get(context.TODO(), vsName, metav1.GetOptions{}); err == nil && vs != nil {
+		if vs, err := c.snapshotClient.SnapshotV1().VolumeSnapshots(vsNamespace).Get(context.TODO(), vsName, metav1.GetOptions{}); err == nil && vs != nil {
 			// Leave vs as inline to avoid accessing volumesnapshot when it could be nil
 			restoreSize = c.getSnapshotSize(vs)
 			if vs.Status != nil && vs.Status.Error != nil && vs.Status.Error.Message != nil {
@@ -662,7 +684,7 @@ func (c *csiDriver) RestoreStatus(pvcName, namespace string) (RestoreInfo, error
 			logrus.Warnf("did not find volume snapshot %s: %v", vsName, err)
 		}
 	} else {
-		if vs, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(namespace).Get(context.TODO(), vsName, metav1.GetOptions{}); err == nil && vs != nil {
+		if vs, err := c.snapshotClient.SnapshotV1beta1().VolumeSnapshots(vsNamespace).Get(context.TODO(), vsName, metav1.GetOptions{}); err == nil && vs != nil {
 			// Leave vs as inline to avoid accessing volumesnapshot when it could be nil
 			restoreSize = c.getSnapshotSize(vs)
 			if vs.Status != nil && vs.Status.Error != nil && vs.Status.Error.Message != nil {
@@ -1771,6 +1793,7 @@ func buildJobSpec(
 			},
 		},
 	}
+	k8sutils.SetJobProvenanceLabels(job)
 
 	return job, nil
 }
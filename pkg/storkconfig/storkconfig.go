@@ -0,0 +1,462 @@
+package storkconfig
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/libopenstorage/stork/pkg/crds"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	resyncPeriod = 30 * time.Second
+)
+
+var (
+	config      *stork_api.StorkConfigurationSpec
+	lock        sync.RWMutex
+	storkClient storkclientset.Interface
+	statusLock  sync.Mutex
+
+	listeners    []func()
+	listenerLock sync.Mutex
+)
+
+// Init creates the StorkConfiguration CRD and starts watching the stork
+// configuration object. It waits for the initial sync so that settings that
+// are only read at startup are available to the controllers.
+func Init() error {
+	if err := createCRD(); err != nil {
+		return err
+	}
+	return startConfigurationWatch()
+}
+
+// AddChangeListener registers a function that is called every time the
+// stork configuration changes, so that settings which are cached can be
+// picked up again
+func AddChangeListener(fn func()) {
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// GetWatchNamespaces returns the namespaces the controllers are restricted
+// to. It reads the stork configuration object directly since it is needed
+// to set up the controllers before Init is called. Returns nil if all
+// namespaces should be watched.
+func GetWatchNamespaces() ([]string, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error getting cluster config: %v", err)
+	}
+	client, err := storkclientset.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error getting client, %v", err)
+	}
+	storkConfig, err := client.StorkV1alpha1().StorkConfigurations().Get(context.TODO(), stork_api.StorkConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		// The CRD is only created by Init, so it may not exist yet
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return storkConfig.Spec.WatchNamespaces, nil
+}
+
+// GetRequeuePeriod returns the period after which the given controller
+// should reconcile an object again after a successful reconcile
+func GetRequeuePeriod(controllerName string, defaultPeriod time.Duration) time.Duration {
+	controllerConfig := getControllerConfiguration(controllerName, func(c stork_api.ControllerConfiguration) bool {
+		return c.RequeuePeriod != nil
+	})
+	if controllerConfig == nil || controllerConfig.RequeuePeriod.Duration <= 0 {
+		return defaultPeriod
+	}
+	return controllerConfig.RequeuePeriod.Duration
+}
+
+// GetRequeuePeriodOnError returns the period after which the given controller
+// should reconcile an object again after a failed reconcile
+func GetRequeuePeriodOnError(controllerName string, defaultPeriod time.Duration) time.Duration {
+	controllerConfig := getControllerConfiguration(controllerName, func(c stork_api.ControllerConfiguration) bool {
+		return c.RequeuePeriodOnError != nil
+	})
+	if controllerConfig == nil || controllerConfig.RequeuePeriodOnError.Duration <= 0 {
+		return defaultPeriod
+	}
+	return controllerConfig.RequeuePeriodOnError.Duration
+}
+
+// GetMaxConcurrentReconciles returns the number of workers to be used for the
+// given controller
+func GetMaxConcurrentReconciles(controllerName string, defaultCount int) int {
+	controllerConfig := getControllerConfiguration(controllerName, func(c stork_api.ControllerConfiguration) bool {
+		return c.MaxConcurrentReconciles != nil
+	})
+	if controllerConfig == nil || *controllerConfig.MaxConcurrentReconciles <= 0 {
+		return defaultCount
+	}
+	return *controllerConfig.MaxConcurrentReconciles
+}
+
+// IsLowPriorityController returns true if the given controller can't use
+// the reconciles reserved for the other controllers
+func IsLowPriorityController(controllerName string, defaultValue bool) bool {
+	controllerConfig := getControllerConfiguration(controllerName, func(c stork_api.ControllerConfiguration) bool {
+		return c.LowPriority != nil
+	})
+	if controllerConfig == nil {
+		return defaultValue
+	}
+	return *controllerConfig.LowPriority
+}
+
+// GetReconcileScheduling returns the number of reconciles that can run at
+// once across all controllers and how many of those are reserved for the
+// controllers that aren't low priority. A limit of 0 means unlimited.
+func GetReconcileScheduling() (int, int) {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.ReconcileScheduling == nil || config.ReconcileScheduling.MaxConcurrentReconciles <= 0 {
+		return 0, 0
+	}
+	reserved := config.ReconcileScheduling.ReservedReconciles
+	if reserved < 0 {
+		reserved = 0
+	}
+	return config.ReconcileScheduling.MaxConcurrentReconciles, reserved
+}
+
+// GetValidateSnapshotTimeout returns the time to wait for a snapshot to be
+// ready
+func GetValidateSnapshotTimeout(defaultTimeout time.Duration) time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.ValidateSnapshotTimeout == nil || config.ValidateSnapshotTimeout.Duration <= 0 {
+		return defaultTimeout
+	}
+	return config.ValidateSnapshotTimeout.Duration
+}
+
+// GetMigrationMaxThreads returns the number of parallel workers to be used
+// to apply resources during migrations
+func GetMigrationMaxThreads(defaultCount int) int {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.MigrationMaxThreads == nil || *config.MigrationMaxThreads <= 0 {
+		return defaultCount
+	}
+	return *config.MigrationMaxThreads
+}
+
+// GetRestoreMaxThreads returns the number of parallel workers to be used to
+// apply resources during restores
+func GetRestoreMaxThreads(defaultCount int) int {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.RestoreMaxThreads == nil || *config.RestoreMaxThreads <= 0 {
+		return defaultCount
+	}
+	return *config.RestoreMaxThreads
+}
+
+// GetBackupVolumeBatchCount returns the number of volumes to be backed up in
+// one batch
+func GetBackupVolumeBatchCount(defaultCount int) int {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.BackupVolumeBatchCount == nil || *config.BackupVolumeBatchCount <= 0 {
+		return defaultCount
+	}
+	return *config.BackupVolumeBatchCount
+}
+
+// GetBackupThroughput returns the throughput in bytes per second used to
+// estimate the duration of backups
+func GetBackupThroughput(defaultThroughput int64) int64 {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.BackupThroughputBytesPerSecond == nil || *config.BackupThroughputBytesPerSecond <= 0 {
+		return defaultThroughput
+	}
+	return *config.BackupThroughputBytesPerSecond
+}
+
+// GetMaintenanceConfiguration returns the signals used to detect cluster
+// maintenance. Returns nil if maintenance detection isn't configured.
+func GetMaintenanceConfiguration() *stork_api.MaintenanceConfiguration {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.Maintenance == nil {
+		return nil
+	}
+	return config.Maintenance.DeepCopy()
+}
+
+// GetHealthMonitorInterval returns the interval at which the health monitor
+// checks the volume driver on the nodes
+func GetHealthMonitorInterval(defaultInterval time.Duration) time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.HealthMonitor == nil || config.HealthMonitor.Interval == nil ||
+		config.HealthMonitor.Interval.Duration <= 0 {
+		return defaultInterval
+	}
+	return config.HealthMonitor.Interval.Duration
+}
+
+// GetHealthMonitorRecoveryCount returns the number of consecutive checks the
+// volume driver on a node needs to be online to be considered recovered
+func GetHealthMonitorRecoveryCount(defaultCount int) int {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.HealthMonitor == nil || config.HealthMonitor.RecoveryCount == nil ||
+		*config.HealthMonitor.RecoveryCount <= 0 {
+		return defaultCount
+	}
+	return *config.HealthMonitor.RecoveryCount
+}
+
+// GetHealthMonitorSettings returns the number of consecutive checks the
+// volume driver needs to be offline and the cool-down between deleting pods
+// for a pod in the given namespace using the given storage classes. The
+// settings of the namespace take precedence over those of the storage
+// classes, which take precedence over the defaults.
+func GetHealthMonitorSettings(namespace string, storageClasses []string) (int, time.Duration) {
+	lock.RLock()
+	defer lock.RUnlock()
+	confirmationCount := 1
+	var cooldown time.Duration
+	if config == nil || config.HealthMonitor == nil {
+		return confirmationCount, cooldown
+	}
+	settings := make([]stork_api.HealthMonitorSettings, 0)
+	if s, ok := config.HealthMonitor.Namespaces[namespace]; ok {
+		settings = append(settings, s)
+	}
+	for _, storageClass := range storageClasses {
+		if s, ok := config.HealthMonitor.StorageClasses[storageClass]; ok {
+			settings = append(settings, s)
+		}
+	}
+	if config.HealthMonitor.Defaults != nil {
+		settings = append(settings, *config.HealthMonitor.Defaults)
+	}
+	countSet, cooldownSet := false, false
+	for _, s := range settings {
+		if !countSet && s.ConfirmationCount != nil && *s.ConfirmationCount > 0 {
+			confirmationCount = *s.ConfirmationCount
+			countSet = true
+		}
+		if !cooldownSet && s.Cooldown != nil {
+			cooldown = s.Cooldown.Duration
+			cooldownSet = true
+		}
+	}
+	return confirmationCount, cooldown
+}
+
+// GetTTLSecondsAfterFinished returns the default number of seconds after
+// which finished operations are deleted, or nil if they are kept
+func GetTTLSecondsAfterFinished() *int32 {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.TTLSecondsAfterFinished == nil || *config.TTLSecondsAfterFinished < 0 {
+		return nil
+	}
+	ttl := *config.TTLSecondsAfterFinished
+	return &ttl
+}
+
+// GetScheduleDriftThreshold returns how late a scheduled run can start after
+// it was due before it is considered late
+func GetScheduleDriftThreshold(defaultThreshold time.Duration) time.Duration {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil || config.ScheduleDriftThreshold == nil || config.ScheduleDriftThreshold.Duration <= 0 {
+		return defaultThreshold
+	}
+	return config.ScheduleDriftThreshold.Duration
+}
+
+// GetImageCheckRegistries returns the registries that image checks can query
+func GetImageCheckRegistries() []string {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil {
+		return nil
+	}
+	return append([]string{}, config.ImageCheckRegistries...)
+}
+
+// SetVolumeDriverCondition records the condition of a volume driver in the
+// status of the stork configuration object, creating the object if it
+// doesn't exist
+func SetVolumeDriverCondition(driver string, degraded bool, reason string) error {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	if storkClient == nil {
+		return fmt.Errorf("stork configuration hasn't been initialized")
+	}
+	configs := storkClient.StorkV1alpha1().StorkConfigurations()
+	storkConfig, err := configs.Get(context.TODO(), stork_api.StorkConfigurationName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		storkConfig, err = configs.Create(context.TODO(), &stork_api.StorkConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: stork_api.StorkConfigurationName,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	condition := stork_api.VolumeDriverCondition{
+		Driver:             driver,
+		Degraded:           degraded,
+		Reason:             reason,
+		LastTransitionTime: metav1.Now(),
+	}
+	found := false
+	for i, c := range storkConfig.Status.VolumeDrivers {
+		if c.Driver == driver {
+			storkConfig.Status.VolumeDrivers[i] = condition
+			found = true
+			break
+		}
+	}
+	if !found {
+		storkConfig.Status.VolumeDrivers = append(storkConfig.Status.VolumeDrivers, condition)
+	}
+	// The CRD doesn't have a status subresource, so the status is updated
+	// along with the object
+	_, err = configs.Update(context.TODO(), storkConfig, metav1.UpdateOptions{})
+	return err
+}
+
+// getControllerConfiguration returns the configuration for the controller if
+// it has the setting checked by isSet, falling back to the configuration
+// for all controllers
+func getControllerConfiguration(
+	controllerName string,
+	isSet func(stork_api.ControllerConfiguration) bool,
+) *stork_api.ControllerConfiguration {
+	lock.RLock()
+	defer lock.RUnlock()
+	if config == nil {
+		return nil
+	}
+	if controllerConfig, ok := config.Controllers[controllerName]; ok && isSet(controllerConfig) {
+		return &controllerConfig
+	}
+	if controllerConfig, ok := config.Controllers[stork_api.AllControllersConfigurationKey]; ok && isSet(controllerConfig) {
+		return &controllerConfig
+	}
+	return nil
+}
+
+func setConfiguration(storkConfig *stork_api.StorkConfiguration) {
+	if !updateConfiguration(storkConfig) {
+		return
+	}
+	listenerLock.Lock()
+	defer listenerLock.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// updateConfiguration saves the spec of the stork configuration and returns
+// true if it changed
+func updateConfiguration(storkConfig *stork_api.StorkConfiguration) bool {
+	lock.Lock()
+	defer lock.Unlock()
+	if storkConfig == nil {
+		if config == nil {
+			return false
+		}
+		logrus.Infof("StorkConfiguration %v deleted, using defaults", stork_api.StorkConfigurationName)
+		config = nil
+		return true
+	}
+	if config != nil && reflect.DeepEqual(*config, storkConfig.Spec) {
+		return false
+	}
+	logrus.Infof("Loaded StorkConfiguration %v", storkConfig.Name)
+	config = storkConfig.Spec.DeepCopy()
+	return true
+}
+
+func startConfigurationWatch() error {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("error getting cluster config: %v", err)
+	}
+
+	client, err := storkclientset.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("error getting client, %v", err)
+	}
+	storkClient = client
+
+	restClient := client.StorkV1alpha1().RESTClient()
+
+	watchlist := cache.NewListWatchFromClient(
+		restClient,
+		stork_api.StorkConfigurationResourcePlural,
+		metav1.NamespaceAll,
+		fields.OneTermEqualSelector("metadata.name", stork_api.StorkConfigurationName),
+	)
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return watchlist.List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watchlist.Watch(options)
+		},
+	}
+	_, controller := cache.NewInformer(lw, &stork_api.StorkConfiguration{}, resyncPeriod,
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if storkConfig, ok := obj.(*stork_api.StorkConfiguration); ok {
+					setConfiguration(storkConfig)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if storkConfig, ok := newObj.(*stork_api.StorkConfiguration); ok {
+					setConfiguration(storkConfig)
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				setConfiguration(nil)
+			},
+		},
+	)
+	go controller.Run(wait.NeverStop)
+	if !cache.WaitForCacheSync(wait.NeverStop, controller.HasSynced) {
+		return fmt.Errorf("error waiting for StorkConfiguration to sync")
+	}
+	return nil
+}
+
+func createCRD() error {
+	return crds.Register(reflect.TypeOf(stork_api.StorkConfiguration{}).Name())
+}
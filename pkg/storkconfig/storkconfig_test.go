@@ -0,0 +1,223 @@
+//go:build unittest
+// +build unittest
+
+package storkconfig
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStorkConfig(t *testing.T) {
+	t.Run("defaultsTest", defaultsTest)
+	t.Run("controllerOverridesTest", controllerOverridesTest)
+	t.Run("globalSettingsTest", globalSettingsTest)
+	t.Run("reconcileSchedulingTest", reconcileSchedulingTest)
+	t.Run("healthMonitorTest", healthMonitorTest)
+	t.Run("changeListenerTest", changeListenerTest)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func defaultsTest(t *testing.T) {
+	setConfiguration(nil)
+	require.Equal(t, 10*time.Second, GetRequeuePeriod("test-controller", 10*time.Second))
+	require.Equal(t, 2*time.Second, GetRequeuePeriodOnError("test-controller", 2*time.Second))
+	require.Equal(t, 10, GetMaxConcurrentReconciles("test-controller", 10))
+	require.Equal(t, time.Minute, GetValidateSnapshotTimeout(time.Minute))
+	require.Equal(t, 4, GetMigrationMaxThreads(4))
+	require.Equal(t, 4, GetRestoreMaxThreads(4))
+	require.Equal(t, 3, GetBackupVolumeBatchCount(3))
+	require.Equal(t, int64(2048), GetBackupThroughput(2048))
+	require.Nil(t, GetMaintenanceConfiguration())
+	require.Nil(t, GetTTLSecondsAfterFinished())
+	require.Equal(t, 5*time.Minute, GetScheduleDriftThreshold(5*time.Minute))
+	require.Empty(t, GetImageCheckRegistries())
+}
+
+func controllerOverridesTest(t *testing.T) {
+	defer setConfiguration(nil)
+	setConfiguration(&stork_api.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: stork_api.StorkConfigurationName,
+		},
+		Spec: stork_api.StorkConfigurationSpec{
+			Controllers: map[string]stork_api.ControllerConfiguration{
+				stork_api.AllControllersConfigurationKey: {
+					RequeuePeriod:        &metav1.Duration{Duration: time.Minute},
+					RequeuePeriodOnError: &metav1.Duration{Duration: 30 * time.Second},
+				},
+				"test-controller": {
+					RequeuePeriod:           &metav1.Duration{Duration: 5 * time.Second},
+					MaxConcurrentReconciles: intPtr(2),
+				},
+				"invalid-controller": {
+					RequeuePeriod:           &metav1.Duration{Duration: -5 * time.Second},
+					MaxConcurrentReconciles: intPtr(0),
+				},
+			},
+		},
+	})
+	require.Equal(t, 5*time.Second, GetRequeuePeriod("test-controller", 10*time.Second))
+	// Settings not set for a controller should fall back to the ones for all
+	// controllers
+	require.Equal(t, 30*time.Second, GetRequeuePeriodOnError("test-controller", 2*time.Second))
+	require.Equal(t, 2, GetMaxConcurrentReconciles("test-controller", 10))
+
+	require.Equal(t, time.Minute, GetRequeuePeriod("other-controller", 10*time.Second))
+	require.Equal(t, 10, GetMaxConcurrentReconciles("other-controller", 10))
+
+	// Invalid values should be ignored
+	require.Equal(t, 10*time.Second, GetRequeuePeriod("invalid-controller", 10*time.Second))
+	require.Equal(t, 10, GetMaxConcurrentReconciles("invalid-controller", 10))
+}
+
+func globalSettingsTest(t *testing.T) {
+	defer setConfiguration(nil)
+	setConfiguration(&stork_api.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: stork_api.StorkConfigurationName,
+		},
+		Spec: stork_api.StorkConfigurationSpec{
+			ValidateSnapshotTimeout:        &metav1.Duration{Duration: 10 * time.Minute},
+			MigrationMaxThreads:            intPtr(16),
+			RestoreMaxThreads:              intPtr(8),
+			BackupVolumeBatchCount:         intPtr(-1),
+			BackupThroughputBytesPerSecond: int64Ptr(1024),
+			Maintenance:                    &stork_api.MaintenanceConfiguration{NodeSelector: "upgrading"},
+			TTLSecondsAfterFinished:        int32Ptr(3600),
+			ScheduleDriftThreshold:         &metav1.Duration{Duration: 15 * time.Minute},
+			ImageCheckRegistries:           []string{"docker.io"},
+		},
+	})
+	require.Equal(t, 10*time.Minute, GetValidateSnapshotTimeout(time.Minute))
+	require.Equal(t, 16, GetMigrationMaxThreads(4))
+	require.Equal(t, 8, GetRestoreMaxThreads(4))
+	require.Equal(t, 3, GetBackupVolumeBatchCount(3))
+	require.Equal(t, int64(1024), GetBackupThroughput(2048))
+	require.Equal(t, "upgrading", GetMaintenanceConfiguration().NodeSelector)
+	require.Equal(t, int32(3600), *GetTTLSecondsAfterFinished())
+	require.Equal(t, 15*time.Minute, GetScheduleDriftThreshold(5*time.Minute))
+	require.Equal(t, []string{"docker.io"}, GetImageCheckRegistries())
+}
+
+func reconcileSchedulingTest(t *testing.T) {
+	defer setConfiguration(nil)
+	limit, reserved := GetReconcileScheduling()
+	require.Equal(t, 0, limit)
+	require.Equal(t, 0, reserved)
+	require.True(t, IsLowPriorityController("backup", true))
+	require.False(t, IsLowPriorityController("migration", false))
+
+	setConfiguration(&stork_api.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: stork_api.StorkConfigurationName,
+		},
+		Spec: stork_api.StorkConfigurationSpec{
+			ReconcileScheduling: &stork_api.ReconcileSchedulingConfiguration{
+				MaxConcurrentReconciles: 20,
+				ReservedReconciles:      5,
+			},
+			Controllers: map[string]stork_api.ControllerConfiguration{
+				"*":         {LowPriority: boolPtr(true)},
+				"migration": {LowPriority: boolPtr(false)},
+			},
+		},
+	})
+	limit, reserved = GetReconcileScheduling()
+	require.Equal(t, 20, limit)
+	require.Equal(t, 5, reserved)
+	require.True(t, IsLowPriorityController("backup", false))
+	require.False(t, IsLowPriorityController("migration", true))
+}
+
+func healthMonitorTest(t *testing.T) {
+	defer setConfiguration(nil)
+	count, cooldown := GetHealthMonitorSettings("ns", []string{"sc"})
+	require.Equal(t, 1, count)
+	require.Equal(t, time.Duration(0), cooldown)
+	require.Equal(t, time.Minute, GetHealthMonitorInterval(time.Minute))
+	require.Equal(t, 1, GetHealthMonitorRecoveryCount(1))
+
+	setConfiguration(&stork_api.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: stork_api.StorkConfigurationName,
+		},
+		Spec: stork_api.StorkConfigurationSpec{
+			HealthMonitor: &stork_api.HealthMonitorConfiguration{
+				Interval:      &metav1.Duration{Duration: 45 * time.Second},
+				RecoveryCount: intPtr(3),
+				Defaults: &stork_api.HealthMonitorSettings{
+					ConfirmationCount: intPtr(2),
+					Cooldown:          &metav1.Duration{Duration: 10 * time.Minute},
+				},
+				Namespaces: map[string]stork_api.HealthMonitorSettings{
+					"critical": {ConfirmationCount: intPtr(1)},
+				},
+				StorageClasses: map[string]stork_api.HealthMonitorSettings{
+					"shared": {
+						ConfirmationCount: intPtr(4),
+						Cooldown:          &metav1.Duration{Duration: time.Hour},
+					},
+				},
+			},
+		},
+	})
+	require.Equal(t, 45*time.Second, GetHealthMonitorInterval(time.Minute))
+	require.Equal(t, 3, GetHealthMonitorRecoveryCount(1))
+
+	count, cooldown = GetHealthMonitorSettings("ns", nil)
+	require.Equal(t, 2, count)
+	require.Equal(t, 10*time.Minute, cooldown)
+
+	count, cooldown = GetHealthMonitorSettings("ns", []string{"shared"})
+	require.Equal(t, 4, count)
+	require.Equal(t, time.Hour, cooldown)
+
+	// The namespace takes precedence, unset fields fall through
+	count, cooldown = GetHealthMonitorSettings("critical", []string{"shared"})
+	require.Equal(t, 1, count)
+	require.Equal(t, time.Hour, cooldown)
+}
+
+func changeListenerTest(t *testing.T) {
+	defer setConfiguration(nil)
+	setConfiguration(nil)
+	changes := 0
+	AddChangeListener(func() { changes++ })
+	storkConfig := &stork_api.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: stork_api.StorkConfigurationName,
+		},
+		Spec: stork_api.StorkConfigurationSpec{
+			MigrationMaxThreads: intPtr(16),
+		},
+	}
+	setConfiguration(storkConfig)
+	require.Equal(t, 1, changes)
+	// Listeners are only called when the configuration changes
+	setConfiguration(storkConfig)
+	require.Equal(t, 1, changes)
+	setConfiguration(nil)
+	require.Equal(t, 2, changes)
+	setConfiguration(nil)
+	require.Equal(t, 2, changes)
+}
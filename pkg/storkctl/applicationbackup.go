@@ -7,6 +7,7 @@ import (
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/portworx/sched-ops/task"
 	"github.com/spf13/cobra"
@@ -33,6 +34,7 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 	var postExecRule string
 	var waitForCompletion bool
 	var backupLocation string
+	var resumeFrom string
 
 	createApplicationBackupCommand := &cobra.Command{
 		Use:     applicationBackupSubcommand,
@@ -58,6 +60,7 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 					PreExecRule:    preExecRule,
 					PostExecRule:   postExecRule,
 					BackupLocation: backupLocation,
+					ResumeFrom:     resumeFrom,
 				},
 			}
 			applicationBackup.Name = applicationBackupName
@@ -86,11 +89,13 @@ func newCreateApplicationBackupCommand(cmdFactory Factory, ioStreams genericclio
 	createApplicationBackupCommand.Flags().StringVarP(&preExecRule, "preExecRule", "", "", "Rule to run before executing applicationbackup")
 	createApplicationBackupCommand.Flags().StringVarP(&postExecRule, "postExecRule", "", "", "Rule to run after executing applicationbackup")
 	createApplicationBackupCommand.Flags().StringVarP(&backupLocation, "backupLocation", "b", "", "BackupLocation to use for the backup")
+	createApplicationBackupCommand.Flags().StringVarP(&resumeFrom, "resumeFrom", "", "", "Failed applicationbackup to resume, only the volumes that it didn't back up are backed up again")
 
 	return createApplicationBackupCommand
 }
 
 func newGetApplicationBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var bySchedule string
 	getApplicationBackupCommand := &cobra.Command{
 		Use:     applicationBackupSubcommand,
 		Aliases: applicationBackupAliases,
@@ -129,6 +134,16 @@ func newGetApplicationBackupCommand(cmdFactory Factory, ioStreams genericcliopti
 				applicationBackups = &tempApplicationBackups
 			}
 
+			if len(bySchedule) != 0 {
+				var tempApplicationBackups storkv1.ApplicationBackupList
+				for _, applicationBackup := range applicationBackups.Items {
+					if k8sutils.IsCreatedBySchedule(&applicationBackup, bySchedule) {
+						tempApplicationBackups.Items = append(tempApplicationBackups.Items, applicationBackup)
+					}
+				}
+				applicationBackups = &tempApplicationBackups
+			}
+
 			if len(applicationBackups.Items) == 0 {
 				handleEmptyList(ioStreams.Out)
 				return
@@ -147,6 +162,7 @@ func newGetApplicationBackupCommand(cmdFactory Factory, ioStreams genericcliopti
 			}
 		},
 	}
+	getApplicationBackupCommand.Flags().StringVarP(&bySchedule, "by-schedule", "", "", "Name of the schedule for which to list the applicationbackups it created")
 	cmdFactory.BindGetFlags(getApplicationBackupCommand.Flags())
 
 	return getApplicationBackupCommand
@@ -261,6 +277,10 @@ func waitForApplicationBackup(name, namespace string, ioStreams genericclioption
 			msg = fmt.Sprintf("ApplicationBackup %v failed", name)
 			return "", false, nil
 		}
+		if backup.Status.Status == storkv1.ApplicationBackupStatusCancelled {
+			msg = fmt.Sprintf("ApplicationBackup %v was cancelled", name)
+			return "", false, nil
+		}
 		return "", true, fmt.Errorf("%v", backup.Status.Status)
 	}
 	// sleep just so that instead of blank initial stage/status,
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	"github.com/portworx/sched-ops/k8s/core"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/stretchr/testify/require"
@@ -124,6 +125,26 @@ func TestGetApplicationBackupsWithStatusAndProgress(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestGetApplicationBackupsBySchedule(t *testing.T) {
+	defer resetTest()
+	createApplicationBackupAndVerify(t, "getbackupscheduletest1", "test", []string{"namespace1"}, "backuplocation", "", "")
+	createApplicationBackupAndVerify(t, "getbackupscheduletest2", "test", []string{"namespace1"}, "backuplocation", "", "")
+	backup, err := storkops.Instance().GetApplicationBackup("getbackupscheduletest1", "test")
+	require.NoError(t, err, "Error getting backup")
+	k8sutils.SetProvenanceLabels(backup, "schedule1", time.Now())
+	_, err = storkops.Instance().UpdateApplicationBackup(backup)
+	require.NoError(t, err, "Error updating backup")
+
+	expected := "NAME                     STAGE   STATUS   VOLUMES   RESOURCES   CREATED   ELAPSED\n" +
+		"getbackupscheduletest1                    0/0       0                     \n"
+	cmdArgs := []string{"get", "backups", "-n", "test", "--by-schedule", "schedule1"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	expected = "No resources found.\n"
+	cmdArgs = []string{"get", "backups", "-n", "test", "--by-schedule", "schedule2"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
 func TestCreateApplicationBackupsNoNamespace(t *testing.T) {
 	cmdArgs := []string{"create", "backups", "backup1"}
 
@@ -143,6 +164,19 @@ func TestCreateApplicationBackups(t *testing.T) {
 	createApplicationBackupAndVerify(t, "createbackup", "default", []string{"namespace1"}, "backuplocation", "", "")
 }
 
+func TestCreateApplicationBackupResumeFrom(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"create", "backups", "--namespaces", "namespace1", "resumebackup",
+		"--backupLocation", "backuplocation", "--resumeFrom", "failedbackup"}
+
+	expected := "ApplicationBackup resumebackup started successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	backup, err := storkops.Instance().GetApplicationBackup("resumebackup", "default")
+	require.NoError(t, err, "Error getting backup")
+	require.Equal(t, "failedbackup", backup.Spec.ResumeFrom, "ApplicationBackup resumeFrom mismatch")
+}
+
 func TestCreateDuplicateApplicationBackups(t *testing.T) {
 	defer resetTest()
 	createApplicationBackupAndVerify(t, "createbackup", "default", []string{"namespace1"}, "backuplocation", "", "")
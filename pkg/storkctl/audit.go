@@ -0,0 +1,206 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/libopenstorage/stork/pkg/cleanupaudit"
+	"github.com/libopenstorage/stork/pkg/orphanedpv"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	finalizersSubcommand      = "finalizers"
+	orphanedPVsSubcommand     = "orphanedpvs"
+	defaultForceRemovalReason = "forced from storkctl"
+)
+
+var finalizerAuditColumns = []string{"KIND", "NAMESPACE", "NAME", "DELETED", "CLEANUP ERROR"}
+var orphanedPVAuditColumns = []string{"PV", "SIZE", "CREATED", "KIND", "NAMESPACE", "NAME"}
+
+func newAuditCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	auditCommands := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit stork resources",
+	}
+
+	auditCommands.AddCommand(
+		newAuditFinalizersCommand(cmdFactory, ioStreams),
+		newAuditOrphanedPVsCommand(cmdFactory, ioStreams),
+	)
+
+	return auditCommands
+}
+
+func newAuditFinalizersCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var force bool
+	var reason string
+
+	auditFinalizersCommand := &cobra.Command{
+		Use:   finalizersSubcommand,
+		Short: "List resources stuck in Terminating because their cleanup failed",
+		Long: "List stork resources that have been deleted but are held back by the cleanup finalizer along with\n" +
+			"the error that failed the cleanup. With --force the finalizer is removed from the named resources so\n" +
+			"that they can be deleted. Anything the cleanup would have removed is left behind.",
+		Run: func(c *cobra.Command, args []string) {
+			if force && len(args) == 0 {
+				util.CheckErr(fmt.Errorf("need to provide the names of the resources to force remove the finalizer from"))
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			if cmdFactory.AllNamespaces() {
+				namespace = ""
+			}
+			stuck, err := cleanupaudit.List(namespace)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			stuck = filterStuckResources(stuck, args)
+			if len(stuck) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+
+			if !force {
+				if err := printStuckResources(stuck, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+			for _, r := range stuck {
+				if err := cleanupaudit.ForceRemoveFinalizer(r, reason); err != nil {
+					util.CheckErr(err)
+					return
+				}
+				meta := r.GetMeta()
+				printMsg(fmt.Sprintf("Removed cleanup finalizer from %v %v/%v", r.Kind, meta.GetNamespace(), meta.GetName()), ioStreams.Out)
+			}
+		},
+	}
+	auditFinalizersCommand.Flags().BoolVarP(&force, "force", "", false, "Remove the cleanup finalizer from the named resources")
+	auditFinalizersCommand.Flags().StringVarP(&reason, "reason", "", defaultForceRemovalReason, "Reason for removing the finalizer, logged for auditing")
+	cmdFactory.BindGetFlags(auditFinalizersCommand.Flags())
+
+	return auditFinalizersCommand
+}
+
+func filterStuckResources(stuck []*cleanupaudit.StuckResource, names []string) []*cleanupaudit.StuckResource {
+	if len(names) == 0 {
+		return stuck
+	}
+	filtered := make([]*cleanupaudit.StuckResource, 0)
+	for _, r := range stuck {
+		for _, name := range names {
+			if r.GetMeta().GetName() == name {
+				filtered = append(filtered, r)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func printStuckResources(stuck []*cleanupaudit.StuckResource, out io.Writer) error {
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(finalizerAuditColumns, "\t")); err != nil {
+		return err
+	}
+	for _, r := range stuck {
+		meta := r.GetMeta()
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n",
+			r.Kind, meta.GetNamespace(), meta.GetName(),
+			toTimeString(meta.GetDeletionTimestamp().Time), r.CleanupError); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func newAuditOrphanedPVsCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var deletePVs bool
+	var deleteVolumes bool
+
+	auditOrphanedPVsCommand := &cobra.Command{
+		Use:   orphanedPVsSubcommand,
+		Short: "List PVs left behind by failed restores, clones and migrations",
+		Long: "List PVs with the Retain reclaim policy whose claims were deleted after a failed restore or clone, or\n" +
+			"after a migration, along with their size and creation time. With --delete the named PVs are deleted. The volumes\n" +
+			"on the storage are only deleted along with the PVs if --delete-volumes is set too.",
+		Run: func(c *cobra.Command, args []string) {
+			if deletePVs && len(args) == 0 {
+				util.CheckErr(fmt.Errorf("need to provide the names of the PVs to delete"))
+				return
+			}
+			orphaned, err := orphanedpv.List(nil)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			orphaned = filterOrphanedPVs(orphaned, args)
+			if len(orphaned) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+
+			if !deletePVs {
+				if err := printOrphanedPVs(orphaned, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+			for _, o := range orphaned {
+				if err := orphanedpv.Delete(o, deleteVolumes); err != nil {
+					util.CheckErr(err)
+					return
+				}
+				if deleteVolumes {
+					printMsg(fmt.Sprintf("PV %v will be deleted along with its volume", o.PV.Name), ioStreams.Out)
+				} else {
+					printMsg(fmt.Sprintf("Deleted PV %v", o.PV.Name), ioStreams.Out)
+				}
+			}
+		},
+	}
+	auditOrphanedPVsCommand.Flags().BoolVarP(&deletePVs, "delete", "", false, "Delete the named PVs")
+	auditOrphanedPVsCommand.Flags().BoolVarP(&deleteVolumes, "delete-volumes", "", false, "Delete the volumes on the storage along with the PVs")
+
+	return auditOrphanedPVsCommand
+}
+
+func filterOrphanedPVs(orphaned []*orphanedpv.OrphanedPV, names []string) []*orphanedpv.OrphanedPV {
+	if len(names) == 0 {
+		return orphaned
+	}
+	filtered := make([]*orphanedpv.OrphanedPV, 0)
+	for _, o := range orphaned {
+		for _, name := range names {
+			if o.PV.Name == name {
+				filtered = append(filtered, o)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func printOrphanedPVs(orphaned []*orphanedpv.OrphanedPV, out io.Writer) error {
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(orphanedPVAuditColumns, "\t")); err != nil {
+		return err
+	}
+	for _, o := range orphaned {
+		capacity := o.PV.Spec.Capacity[v1.ResourceStorage]
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			o.PV.Name, capacity.String(), toTimeString(o.PV.CreationTimestamp.Time),
+			o.Kind, o.Namespace, o.Name); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,175 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+	"time"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createStuckBackup(t *testing.T, name string, namespace string, deletionTimestamp time.Time) {
+	backup := &storkv1.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			DeletionTimestamp: &metav1.Time{Time: deletionTimestamp},
+			Finalizers:        []string{controllers.FinalizerCleanup},
+		},
+		Status: storkv1.ApplicationBackupStatus{
+			CleanupError: "error deleting snapshot",
+		},
+	}
+	_, err := storkops.Instance().CreateApplicationBackup(backup)
+	require.NoError(t, err, "Error creating backup")
+}
+
+func TestAuditFinalizersNoResources(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"audit", "finalizers", "-n", "test"}
+
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestAuditFinalizers(t *testing.T) {
+	defer resetTest()
+	deletionTimestamp := time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)
+	createStuckBackup(t, "stuckbackup", "test", deletionTimestamp)
+	_, err := storkops.Instance().CreateApplicationBackup(&storkv1.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "activebackup",
+			Namespace:  "test",
+			Finalizers: []string{controllers.FinalizerCleanup},
+		},
+	})
+	require.NoError(t, err, "Error creating backup")
+
+	cmdArgs := []string{"audit", "finalizers", "-n", "test"}
+	expected := "KIND                NAMESPACE   NAME          DELETED               CLEANUP ERROR\n" +
+		"ApplicationBackup   test        stuckbackup   01 Mar 21 10:00 UTC   error deleting snapshot\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestAuditFinalizersForceWithoutNames(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"audit", "finalizers", "-n", "test", "--force"}
+
+	expected := "error: need to provide the names of the resources to force remove the finalizer from"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestAuditFinalizersForce(t *testing.T) {
+	defer resetTest()
+	createStuckBackup(t, "stuckbackup1", "test", time.Now().Add(-time.Hour))
+	createStuckBackup(t, "stuckbackup2", "test", time.Now().Add(-time.Hour))
+
+	cmdArgs := []string{"audit", "finalizers", "-n", "test", "--force", "stuckbackup1"}
+	expected := "Removed cleanup finalizer from ApplicationBackup test/stuckbackup1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	backup, err := storkops.Instance().GetApplicationBackup("stuckbackup1", "test")
+	require.NoError(t, err, "Error getting backup")
+	require.False(t, controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup))
+	backup, err = storkops.Instance().GetApplicationBackup("stuckbackup2", "test")
+	require.NoError(t, err, "Error getting backup")
+	require.True(t, controllers.ContainsFinalizer(backup, controllers.FinalizerCleanup))
+}
+
+func createReleasedPV(t *testing.T, name string, claimNamespace string, claimName string, annotations map[string]string) {
+	_, err := core.Instance().CreatePersistentVolume(&v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Annotations:       annotations,
+			CreationTimestamp: metav1.Time{Time: time.Date(2021, time.March, 1, 10, 0, 0, 0, time.UTC)},
+		},
+		Spec: v1.PersistentVolumeSpec{
+			Capacity:                      v1.ResourceList{v1.ResourceStorage: resource.MustParse("2Gi")},
+			PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+			ClaimRef:                      &v1.ObjectReference{Namespace: claimNamespace, Name: claimName},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: v1.VolumeReleased},
+	})
+	require.NoError(t, err, "Error creating PV")
+}
+
+func createOrphanedPVs(t *testing.T) {
+	_, err := storkops.Instance().CreateApplicationRestore(&storkv1.ApplicationRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "failedrestore", Namespace: "test"},
+		Spec:       storkv1.ApplicationRestoreSpec{NamespaceMapping: map[string]string{"src": "dest"}},
+		Status: storkv1.ApplicationRestoreStatus{
+			Status: storkv1.ApplicationRestoreStatusFailed,
+			Volumes: []*storkv1.ApplicationRestoreVolumeInfo{
+				{PersistentVolumeClaim: "data", SourceNamespace: "src", RestoreVolume: "restorepv"},
+				{PersistentVolumeClaim: "unmapped", SourceNamespace: "other", RestoreVolume: "unmappedpv"},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating restore")
+	createReleasedPV(t, "restorepv", "dest", "data", nil)
+	createReleasedPV(t, "migratedpv", "app", "logs", map[string]string{
+		migration.StorkMigrationAnnotation: "true",
+		migration.StorkMigrationName:       "migration1",
+	})
+	createReleasedPV(t, "otherpv", "app", "other", nil)
+	// PVs of claims with the same name that weren't created by the restore
+	createReleasedPV(t, "samenamepv", "dest", "data", nil)
+	createReleasedPV(t, "unmappedpv", "other", "unmapped", nil)
+}
+
+func TestAuditOrphanedPVs(t *testing.T) {
+	defer resetTest()
+	createOrphanedPVs(t)
+
+	cmdArgs := []string{"audit", "orphanedpvs"}
+	expected := "PV           SIZE   CREATED               KIND                 NAMESPACE   NAME\n" +
+		"migratedpv   2Gi    01 Mar 21 10:00 UTC   Migration            app         migration1\n" +
+		"restorepv    2Gi    01 Mar 21 10:00 UTC   ApplicationRestore   test        failedrestore\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestAuditOrphanedPVsDeleteWithoutNames(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"audit", "orphanedpvs", "--delete"}
+
+	expected := "error: need to provide the names of the PVs to delete"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestAuditOrphanedPVsDelete(t *testing.T) {
+	defer resetTest()
+	createOrphanedPVs(t)
+
+	cmdArgs := []string{"audit", "orphanedpvs", "--delete", "restorepv", "otherpv"}
+	expected := "Deleted PV restorepv\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	_, err := core.Instance().GetPersistentVolume("restorepv")
+	require.Error(t, err, "orphaned PV should have been deleted")
+	_, err = core.Instance().GetPersistentVolume("otherpv")
+	require.NoError(t, err, "PVs that aren't orphaned aren't deleted")
+}
+
+func TestAuditOrphanedPVsDeleteVolumes(t *testing.T) {
+	defer resetTest()
+	createOrphanedPVs(t)
+
+	cmdArgs := []string{"audit", "orphanedpvs", "--delete", "--delete-volumes", "restorepv"}
+	expected := "PV restorepv will be deleted along with its volume\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	// The PV controller deletes the PV once it has deleted the volume
+	pv, err := core.Instance().GetPersistentVolume("restorepv")
+	require.NoError(t, err, "Error getting PV")
+	require.Equal(t, v1.PersistentVolumeReclaimDelete, pv.Spec.PersistentVolumeReclaimPolicy)
+}
@@ -21,6 +21,8 @@ const (
 var s3BackupLocationColumns = []string{"NAME", "PATH", "ACCESS-KEY-ID", "SECRET-ACCESS-KEY", "REGION", "ENDPOINT", "SSL-DISABLED"}
 var azureBackupLocationColumns = []string{"NAME", "PATH", "STORAGE-ACCOUNT-NAME", "STORAGE-ACCOUNT-KEY"}
 var googleBackupLocationColumns = []string{"NAME", "PATH", "PROJECT-ID"}
+var swiftBackupLocationColumns = []string{"NAME", "PATH", "ACCESS-KEY-ID", "SECRET-ACCESS-KEY", "REGION", "ENDPOINT", "SSL-DISABLED"}
+var alibabaBackupLocationColumns = []string{"NAME", "PATH", "ACCESS-KEY-ID", "ACCESS-KEY-SECRET", "REGION", "ENDPOINT"}
 
 func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var showSecrets bool
@@ -74,6 +76,8 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 			s3BackupLocations := &storkv1.BackupLocationList{}
 			azureBackupLocations := &storkv1.BackupLocationList{}
 			googleBackupLocations := &storkv1.BackupLocationList{}
+			swiftBackupLocations := &storkv1.BackupLocationList{}
+			alibabaBackupLocations := &storkv1.BackupLocationList{}
 			unknownBackupLocations := &storkv1.BackupLocationList{}
 			for _, bl := range backupLocations.Items {
 				switch bl.Location.Type {
@@ -92,6 +96,16 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 						bl.Location.GoogleConfig.AccountKey = hiddenString
 					}
 					googleBackupLocations.Items = append(googleBackupLocations.Items, bl)
+				case storkv1.BackupLocationSwift:
+					if !showSecrets && bl.Location.SwiftConfig != nil {
+						bl.Location.SwiftConfig.SecretAccessKey = hiddenString
+					}
+					swiftBackupLocations.Items = append(swiftBackupLocations.Items, bl)
+				case storkv1.BackupLocationAlibaba:
+					if !showSecrets && bl.Location.AlibabaConfig != nil {
+						bl.Location.AlibabaConfig.AccessKeySecret = hiddenString
+					}
+					alibabaBackupLocations.Items = append(alibabaBackupLocations.Items, bl)
 				default:
 					unknownBackupLocations.Items = append(unknownBackupLocations.Items, bl)
 				}
@@ -133,6 +147,26 @@ func newGetBackupLocationCommand(cmdFactory Factory, ioStreams genericclioptions
 						return
 					}
 				}
+				if len(swiftBackupLocations.Items) != 0 {
+					if _, err := fmt.Fprintf(ioStreams.Out, "\nSwift:\n------\n"); err != nil {
+						util.CheckErr(err)
+						return
+					}
+					if err := printObjects(c, swiftBackupLocations, cmdFactory, swiftBackupLocationColumns, swiftBackupLocationPrinter, ioStreams.Out); err != nil {
+						util.CheckErr(err)
+						return
+					}
+				}
+				if len(alibabaBackupLocations.Items) != 0 {
+					if _, err := fmt.Fprintf(ioStreams.Out, "\nAlibabaOSS:\n-----------\n"); err != nil {
+						util.CheckErr(err)
+						return
+					}
+					if err := printObjects(c, alibabaBackupLocations, cmdFactory, alibabaBackupLocationColumns, alibabaBackupLocationPrinter, ioStreams.Out); err != nil {
+						util.CheckErr(err)
+						return
+					}
+				}
 			} else {
 				if err := printObjects(c, backupLocations, cmdFactory, nil, nil, ioStreams.Out); err != nil {
 					util.CheckErr(err)
@@ -212,3 +246,50 @@ func googleBackupLocationPrinter(
 	}
 	return rows, nil
 }
+
+func swiftBackupLocationPrinter(
+	backupLocationList *storkv1.BackupLocationList,
+	options printers.GenerateOptions,
+) ([]metav1beta1.TableRow, error) {
+	if backupLocationList == nil {
+		return nil, nil
+	}
+
+	rows := make([]metav1beta1.TableRow, 0)
+	for _, backupLocation := range backupLocationList.Items {
+		row := getRow(&backupLocation,
+			[]interface{}{backupLocation.Name,
+				backupLocation.Location.Path,
+				backupLocation.Location.SwiftConfig.AccessKeyID,
+				backupLocation.Location.SwiftConfig.SecretAccessKey,
+				backupLocation.Location.SwiftConfig.Region,
+				backupLocation.Location.SwiftConfig.Endpoint,
+				backupLocation.Location.SwiftConfig.DisableSSL},
+		)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func alibabaBackupLocationPrinter(
+	backupLocationList *storkv1.BackupLocationList,
+	options printers.GenerateOptions,
+) ([]metav1beta1.TableRow, error) {
+	if backupLocationList == nil {
+		return nil, nil
+	}
+
+	rows := make([]metav1beta1.TableRow, 0)
+	for _, backupLocation := range backupLocationList.Items {
+		row := getRow(&backupLocation,
+			[]interface{}{backupLocation.Name,
+				backupLocation.Location.Path,
+				backupLocation.Location.AlibabaConfig.AccessKeyID,
+				backupLocation.Location.AlibabaConfig.AccessKeySecret,
+				backupLocation.Location.AlibabaConfig.GetRegion(),
+				backupLocation.Location.AlibabaConfig.GetEndpoint()},
+		)
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
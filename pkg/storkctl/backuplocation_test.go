@@ -168,6 +168,77 @@ func TestGoogleBackupLocation(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestSwiftBackupLocation(t *testing.T) {
+	defer resetTest()
+
+	backupLocation := &storkv1.BackupLocation{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "swiftlocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type: storkv1.BackupLocationSwift,
+			Path: "testpath",
+			SwiftConfig: &storkv1.SwiftConfig{
+				AccessKeyID:     "accesskey",
+				SecretAccessKey: "secretKey",
+				Endpoint:        "swift.example.com",
+			},
+		},
+	}
+	_, err := storkops.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	expected := "\nSwift:\n------\n" +
+		"NAME            PATH       ACCESS-KEY-ID   SECRET-ACCESS-KEY   REGION      ENDPOINT            SSL-DISABLED\n" +
+		"swiftlocation   testpath   accesskey       <HIDDEN>            us-east-1   swift.example.com   false\n"
+	cmdArgs := []string{"get", "backuplocation", "swiftlocation"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	expected = "\nSwift:\n------\n" +
+		"NAME            PATH       ACCESS-KEY-ID   SECRET-ACCESS-KEY   REGION      ENDPOINT            SSL-DISABLED\n" +
+		"swiftlocation   testpath   accesskey       secretKey           us-east-1   swift.example.com   false\n"
+	cmdArgs = []string{"get", "backuplocation", "swiftlocation", "-s"}
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestAlibabaBackupLocation(t *testing.T) {
+	defer resetTest()
+
+	backupLocation := &storkv1.BackupLocation{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      "alibabalocation",
+			Namespace: "default",
+		},
+		Location: storkv1.BackupLocationItem{
+			Type: storkv1.BackupLocationAlibaba,
+			Path: "testpath",
+			AlibabaConfig: &storkv1.AlibabaConfig{
+				AccessKeyID:     "accesskey",
+				AccessKeySecret: "secretKey",
+				Region:          "oss-cn-hangzhou",
+			},
+		},
+	}
+	_, err := storkops.Instance().CreateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error creating backuplocation")
+
+	expected := "\nAlibabaOSS:\n-----------\n" +
+		"NAME              PATH       ACCESS-KEY-ID   ACCESS-KEY-SECRET   REGION        ENDPOINT\n" +
+		"alibabalocation   testpath   accesskey       <HIDDEN>            cn-hangzhou   oss-cn-hangzhou.aliyuncs.com\n"
+	cmdArgs := []string{"get", "backuplocation", "alibabalocation"}
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	backupLocation.Location.AlibabaConfig.Internal = true
+	_, err = storkops.Instance().UpdateBackupLocation(backupLocation)
+	require.NoError(t, err, "Error updating backuplocation")
+
+	expected = "\nAlibabaOSS:\n-----------\n" +
+		"NAME              PATH       ACCESS-KEY-ID   ACCESS-KEY-SECRET   REGION        ENDPOINT\n" +
+		"alibabalocation   testpath   accesskey       <HIDDEN>            cn-hangzhou   oss-cn-hangzhou-internal.aliyuncs.com\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
 func TestAllBackupLocation(t *testing.T) {
 	_, err := core.Instance().CreateNamespace(&v1.Namespace{ObjectMeta: meta.ObjectMeta{Name: "s3"}})
 	require.NoError(t, err, "Error creating s3 namespace")
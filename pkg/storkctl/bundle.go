@@ -0,0 +1,345 @@
+package storkctl
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/drivers/volume/kdmp"
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	kdmputils "github.com/portworx/kdmp/pkg/drivers/utils"
+	kdmpops "github.com/portworx/sched-ops/k8s/kdmp"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	storkPodSelector = "name=stork"
+	jobNameLabel     = "job-name"
+)
+
+// bundleCollector collects the information about a failed operation into a
+// tarball. Errors while collecting are written to the bundle so that the
+// rest of the information is still collected.
+type bundleCollector struct {
+	kubeClient     kubernetes.Interface
+	storkClient    versioned.Interface
+	storkNamespace string
+	kind           string
+	object         metav1.Object
+	// dataExportSelector selects the KDMP DataExports of the operation, if
+	// any
+	dataExportSelector string
+	tarWriter          *tar.Writer
+	errors             []string
+}
+
+func newBundleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var resource string
+	var outputFile string
+	var storkNamespace string
+
+	bundleCommand := &cobra.Command{
+		Use:   "bundle",
+		Short: "Collect the information needed to debug a failed operation into a tarball",
+		Long: "Collect the resource, its events, the stork logs for it, the status of the volume drivers and the\n" +
+			"logs of the KDMP jobs for its volumes into a tarball that can be attached to a support ticket.\n" +
+			"Supported resources are applicationbackup/<name>, applicationrestore/<name> and migration/<name>.",
+		Run: func(c *cobra.Command, args []string) {
+			collector, err := newBundleCollector(resource, cmdFactory.GetNamespace())
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			collector.kubeClient, err = kubernetes.NewForConfig(config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			collector.storkClient, err = versioned.NewForConfig(config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			collector.storkNamespace = storkNamespace
+			if outputFile == "" {
+				outputFile = fmt.Sprintf("stork-bundle-%v-%v-%v.tar.gz",
+					strings.ToLower(collector.kind), collector.object.GetName(), time.Now().Format("20060102-150405"))
+			}
+			file, err := os.Create(outputFile)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := collector.collect(file); err != nil {
+				_ = file.Close()
+				util.CheckErr(err)
+				return
+			}
+			if err := file.Close(); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			printMsg(fmt.Sprintf("Bundle for %v %v/%v written to %v",
+				collector.kind, collector.object.GetNamespace(), collector.object.GetName(), outputFile), ioStreams.Out)
+		},
+	}
+	bundleCommand.Flags().StringVarP(&resource, "for", "", "", "Resource to collect the information for, for example applicationbackup/<name>")
+	bundleCommand.Flags().StringVarP(&outputFile, "output", "o", "", "File to write the tarball to")
+	bundleCommand.Flags().StringVarP(&storkNamespace, "stork-namespace", "", defaultStorkNS, "Namespace where stork is running")
+
+	return bundleCommand
+}
+
+// newBundleCollector returns a collector for the resource given as
+// <type>/<name>
+func newBundleCollector(resource string, namespace string) (*bundleCollector, error) {
+	if resource == "" {
+		return nil, fmt.Errorf("need to provide the resource to collect the bundle for with --for")
+	}
+	parts := strings.Split(resource, "/")
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("resource should be of the form <type>/<name>, got %v", resource)
+	}
+	resourceType, name := strings.ToLower(parts[0]), parts[1]
+
+	collector := &bundleCollector{}
+	switch {
+	case resourceType == applicationBackupSubcommand || isAlias(resourceType, applicationBackupAliases):
+		backup, err := storkops.Instance().GetApplicationBackup(name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		collector.kind = "ApplicationBackup"
+		collector.object = backup
+		collector.dataExportSelector = kdmp.BackupDataExportSelector(backup)
+	case resourceType == applicationRestoreSubcommand || isAlias(resourceType, applicationRestoreAliases):
+		restore, err := storkops.Instance().GetApplicationRestore(name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		collector.kind = "ApplicationRestore"
+		collector.object = restore
+		collector.dataExportSelector = kdmp.RestoreDataExportSelector(restore)
+	case resourceType == migrationSubcommand || isAlias(resourceType, migrationAliases):
+		migration, err := storkops.Instance().GetMigration(name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		collector.kind = "Migration"
+		collector.object = migration
+	default:
+		return nil, fmt.Errorf("bundles aren't supported for %v", parts[0])
+	}
+	return collector, nil
+}
+
+func isAlias(resourceType string, aliases []string) bool {
+	for _, alias := range aliases {
+		if resourceType == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// collect writes the bundle as a gzipped tarball
+func (b *bundleCollector) collect(out io.Writer) error {
+	gzipWriter := gzip.NewWriter(out)
+	b.tarWriter = tar.NewWriter(gzipWriter)
+
+	if err := b.writeJSON(strings.ToLower(b.kind)+".json", b.object); err != nil {
+		return err
+	}
+	b.collectEvents()
+	b.collectDriverStatus()
+	b.collectStorkLogs()
+	b.collectDataExports()
+
+	if len(b.errors) > 0 {
+		if err := b.writeFile("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := b.tarWriter.Close(); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func (b *bundleCollector) addError(format string, args ...interface{}) {
+	b.errors = append(b.errors, fmt.Sprintf(format, args...))
+}
+
+func (b *bundleCollector) writeFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := b.tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := b.tarWriter.Write(data)
+	return err
+}
+
+func (b *bundleCollector) writeJSON(name string, object interface{}) error {
+	data, err := json.MarshalIndent(object, "", "  ")
+	if err != nil {
+		return err
+	}
+	return b.writeFile(name, data)
+}
+
+// collectEvents collects the events for the resource
+func (b *bundleCollector) collectEvents() {
+	events, err := b.kubeClient.CoreV1().Events(b.object.GetNamespace()).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.uid": string(b.object.GetUID())}.String(),
+	})
+	if err != nil {
+		b.addError("Error getting events: %v", err)
+		return
+	}
+	if err := b.writeJSON("events.json", events.Items); err != nil {
+		b.addError("Error writing events: %v", err)
+	}
+}
+
+// collectDriverStatus collects the status of the volume drivers reported in
+// the stork configuration
+func (b *bundleCollector) collectDriverStatus() {
+	config, err := b.storkClient.StorkV1alpha1().StorkConfigurations().Get(context.TODO(), storkv1.StorkConfigurationName, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return
+	} else if err != nil {
+		b.addError("Error getting stork configuration: %v", err)
+		return
+	}
+	if err := b.writeJSON("driver-status.json", config.Status.VolumeDrivers); err != nil {
+		b.addError("Error writing driver status: %v", err)
+	}
+}
+
+// collectStorkLogs collects the lines of the stork logs that are about the
+// resource
+func (b *bundleCollector) collectStorkLogs() {
+	pods, err := b.kubeClient.CoreV1().Pods(b.storkNamespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: storkPodSelector,
+	})
+	if err != nil {
+		b.addError("Error getting stork pods: %v", err)
+		return
+	}
+	for _, pod := range pods.Items {
+		b.collectPodLogs("stork-logs/"+pod.Name+".log", &pod, b.matchesResource)
+	}
+}
+
+// matchesResource returns true if the log line is about the resource. The
+// controllers log the name of the resource in a field named after the kind,
+// and the namespace and name in messages.
+func (b *bundleCollector) matchesResource(line string) bool {
+	name := b.object.GetName()
+	field := b.kind + "Name=" + name
+	if i := strings.Index(line, field); i >= 0 {
+		rest := line[i+len(field):]
+		if rest == "" || rest[0] == ' ' || rest[0] == '"' {
+			return true
+		}
+	}
+	return strings.Contains(line, b.object.GetNamespace()+"/"+name)
+}
+
+// collectDataExports collects the KDMP DataExports of the operation and the
+// logs of their jobs
+func (b *bundleCollector) collectDataExports() {
+	if b.dataExportSelector == "" {
+		return
+	}
+	dataExports, err := kdmpops.Instance().ListDataExport("", metav1.ListOptions{LabelSelector: b.dataExportSelector})
+	if err != nil {
+		b.addError("Error getting KDMP DataExports: %v", err)
+		return
+	}
+	if len(dataExports.Items) == 0 {
+		return
+	}
+	if err := b.writeJSON("kdmp/dataexports.json", dataExports.Items); err != nil {
+		b.addError("Error writing KDMP DataExports: %v", err)
+	}
+	for _, dataExport := range dataExports.Items {
+		if dataExport.Status.TransferID == "" {
+			continue
+		}
+		namespace, name, err := kdmputils.ParseJobID(dataExport.Status.TransferID)
+		if err != nil {
+			b.addError("Error parsing job of DataExport %v/%v: %v", dataExport.Namespace, dataExport.Name, err)
+			continue
+		}
+		pods, err := b.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: jobNameLabel + "=" + name,
+		})
+		if err != nil {
+			b.addError("Error getting pods for job %v/%v: %v", namespace, name, err)
+			continue
+		}
+		for _, pod := range pods.Items {
+			b.collectPodLogs(fmt.Sprintf("kdmp/%v-%v/%v.log", namespace, name, pod.Name), &pod, nil)
+		}
+	}
+}
+
+// collectPodLogs collects the logs of all the containers of the pod, only
+// keeping the lines that match the filter if one is given
+func (b *bundleCollector) collectPodLogs(fileName string, pod *v1.Pod, filter func(string) bool) {
+	var logs bytes.Buffer
+	for _, container := range pod.Spec.Containers {
+		stream, err := b.kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+			Container: container.Name,
+		}).Stream(context.TODO())
+		if err != nil {
+			b.addError("Error getting logs of %v/%v container %v: %v", pod.Namespace, pod.Name, container.Name, err)
+			continue
+		}
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if filter == nil || filter(scanner.Text()) {
+				logs.WriteString(scanner.Text())
+				logs.WriteString("\n")
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			b.addError("Error reading logs of %v/%v container %v: %v", pod.Namespace, pod.Name, container.Name, err)
+		}
+		if err := stream.Close(); err != nil {
+			b.addError("Error closing logs of %v/%v container %v: %v", pod.Namespace, pod.Name, container.Name, err)
+		}
+	}
+	if err := b.writeFile(fileName, logs.Bytes()); err != nil {
+		b.addError("Error writing logs of %v/%v: %v", pod.Namespace, pod.Name, err)
+	}
+}
@@ -0,0 +1,131 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakeclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetes "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBundleNoResource(t *testing.T) {
+	cmdArgs := []string{"bundle"}
+
+	expected := "error: need to provide the resource to collect the bundle for with --for"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestBundleBadResource(t *testing.T) {
+	cmdArgs := []string{"bundle", "--for", "applicationbackup"}
+
+	expected := "error: resource should be of the form <type>/<name>, got applicationbackup"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestBundleUnsupportedResource(t *testing.T) {
+	cmdArgs := []string{"bundle", "--for", "pod/test"}
+
+	expected := "error: bundles aren't supported for pod"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestBundleMissingResource(t *testing.T) {
+	cmdArgs := []string{"bundle", "--for", "migration/missing"}
+
+	expected := "Error from server (NotFound): migrations.stork.libopenstorage.org \"missing\" not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestBundleCollect(t *testing.T) {
+	migration := &storkv1.Migration{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "ns", UID: types.UID("uid1")},
+		Status:     storkv1.MigrationStatus{Status: storkv1.MigrationStatusFailed},
+	}
+	kubeClient := kubernetes.NewSimpleClientset(
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "event1", Namespace: "ns"},
+			InvolvedObject: v1.ObjectReference{Kind: "Migration", Name: "failed", UID: migration.UID},
+			Reason:         "Failed",
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "stork-1", Namespace: defaultStorkNS, Labels: map[string]string{"name": "stork"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "stork"}}},
+		},
+	)
+	storkClient := fakeclient.NewSimpleClientset(&storkv1.StorkConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: storkv1.StorkConfigurationName},
+		Status: storkv1.StorkConfigurationStatus{
+			VolumeDrivers: []storkv1.VolumeDriverCondition{{Driver: "pxd", Degraded: true, Reason: "timeout"}},
+		},
+	})
+	collector := &bundleCollector{
+		kubeClient:     kubeClient,
+		storkClient:    storkClient,
+		storkNamespace: defaultStorkNS,
+		kind:           "Migration",
+		object:         migration,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, collector.collect(&out))
+
+	files := readBundle(t, &out)
+	require.Len(t, files, 4)
+	require.Contains(t, files, "migration.json")
+	require.Contains(t, files, "stork-logs/stork-1.log")
+
+	bundled := &storkv1.Migration{}
+	require.NoError(t, json.Unmarshal(files["migration.json"], bundled))
+	require.Equal(t, storkv1.MigrationStatusFailed, bundled.Status.Status)
+
+	var events []v1.Event
+	require.NoError(t, json.Unmarshal(files["events.json"], &events))
+	require.Len(t, events, 1)
+	require.Equal(t, "Failed", events[0].Reason)
+
+	var drivers []storkv1.VolumeDriverCondition
+	require.NoError(t, json.Unmarshal(files["driver-status.json"], &drivers))
+	require.Equal(t, "timeout", drivers[0].Reason)
+}
+
+func TestBundleMatchesResource(t *testing.T) {
+	collector := &bundleCollector{
+		kind:   "ApplicationBackup",
+		object: &storkv1.ApplicationBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "ns"}},
+	}
+	require.True(t, collector.matchesResource(`level=error msg="Error backing up" ApplicationBackupName=backup ApplicationBackupNamespace=ns`))
+	require.True(t, collector.matchesResource(`level=info msg="Started backup ns/backup"`))
+	require.False(t, collector.matchesResource(`level=error msg="Error backing up" ApplicationBackupName=backup2 ApplicationBackupNamespace=ns`))
+	require.False(t, collector.matchesResource(`level=info msg="Started backup ns/other"`))
+}
+
+func readBundle(t *testing.T, bundle io.Reader) map[string][]byte {
+	gzipReader, err := gzip.NewReader(bundle)
+	require.NoError(t, err)
+	tarReader := tar.NewReader(gzipReader)
+	files := make(map[string][]byte)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(tarReader)
+		require.NoError(t, err)
+		files[header.Name] = data
+	}
+	return files
+}
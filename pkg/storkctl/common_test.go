@@ -25,6 +25,7 @@ import (
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	fakedynamicclient "k8s.io/client-go/dynamic/fake"
@@ -37,6 +38,7 @@ import (
 var codec runtime.Codec
 var fakeRestClient *fake.RESTClient
 var testFactory *TestFactory
+var fakeDynamicClient *fakedynamicclient.FakeDynamicClient
 
 func init() {
 	resetTest()
@@ -61,7 +63,10 @@ func resetTest() {
 	fakeOCPClient := fakeocpclient.NewSimpleClientset()
 	fakeOCPSecurityClient := fakeocpsecurityclient.NewSimpleClientset()
 	fakeOCPConfigClient := fakeocpconfigclient.NewSimpleClientset()
-	fakeDynamicClient := fakedynamicclient.NewSimpleDynamicClientWithCustomListKinds(scheme, appregistration.GetSupportedGVR())
+	listKinds := appregistration.GetSupportedGVR()
+	listKinds[schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}] = "HorizontalPodAutoscalerList"
+	listKinds[schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}] = "PodDisruptionBudgetList"
+	fakeDynamicClient = fakedynamicclient.NewSimpleDynamicClientWithCustomListKinds(scheme, listKinds)
 
 	if testFactory != nil {
 		testFactory.TestFactory.WithNamespace("test").Cleanup()
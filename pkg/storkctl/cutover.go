@@ -0,0 +1,19 @@
+package storkctl
+
+import (
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func newCutoverCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cutoverCommands := &cobra.Command{
+		Use:   "cutover",
+		Short: "Cut over staged migrations",
+	}
+
+	cutoverCommands.AddCommand(
+		newCutoverMigrationCommand(cmdFactory, ioStreams),
+	)
+
+	return cutoverCommands
+}
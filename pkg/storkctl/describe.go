@@ -0,0 +1,246 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	applicationmanager "github.com/libopenstorage/stork/pkg/applicationmanager/controllers"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+// describeField is a single line in the description of an object
+type describeField struct {
+	name  string
+	value string
+}
+
+// describeSection lists the objects linked to the described object
+type describeSection struct {
+	title string
+	items []string
+}
+
+func newDescribeCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	describeCommands := &cobra.Command{
+		Use:   "describe",
+		Short: "Describe stork resources and the resources linked to them",
+	}
+
+	describeCommands.AddCommand(
+		newDescribeApplicationBackupCommand(cmdFactory, ioStreams),
+		newDescribeApplicationRestoreCommand(cmdFactory, ioStreams),
+		newDescribeApplicationBackupScheduleCommand(cmdFactory, ioStreams),
+		newDescribeMigrationScheduleCommand(cmdFactory, ioStreams),
+		newDescribeClusterPairCommand(cmdFactory, ioStreams),
+	)
+
+	return describeCommands
+}
+
+// newDescribeSubcommand returns a command that describes each of the named
+// objects with describeFunc
+func newDescribeSubcommand(
+	cmdFactory Factory,
+	ioStreams genericclioptions.IOStreams,
+	use string,
+	aliases []string,
+	short string,
+	describeFunc func(name string, namespace string, out io.Writer) error,
+) *cobra.Command {
+	return &cobra.Command{
+		Use:     use,
+		Aliases: aliases,
+		Short:   short,
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) == 0 {
+				util.CheckErr(fmt.Errorf("at least one name needs to be provided"))
+				return
+			}
+			for i, name := range args {
+				if i != 0 {
+					printMsg("", ioStreams.Out)
+				}
+				if err := describeFunc(name, cmdFactory.GetNamespace(), ioStreams.Out); err != nil {
+					util.CheckErr(err)
+					return
+				}
+			}
+		},
+	}
+}
+
+func newDescribeApplicationBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return newDescribeSubcommand(cmdFactory, ioStreams, applicationBackupSubcommand, applicationBackupAliases,
+		"Describe applicationbackups along with the schedule that created them and their restores",
+		func(name string, namespace string, out io.Writer) error {
+			backup, err := storkops.Instance().GetApplicationBackup(name, namespace)
+			if err != nil {
+				return err
+			}
+			fields := []describeField{
+				{"Name", backup.Name},
+				{"Namespace", backup.Namespace},
+				{"Kind", "ApplicationBackup"},
+				{"Stage", string(backup.Status.Stage)},
+				{"Status", string(backup.Status.Status)},
+				{"BackupLocation", backupLocationName(backup.Spec, backup.Namespace)},
+			}
+			if schedule := backup.Annotations[applicationmanager.ApplicationBackupScheduleNameAnnotation]; schedule != "" {
+				fields = append(fields, describeField{"Schedule", backup.Namespace + "/" + schedule})
+			}
+			return printDescription(out, fields, []describeSection{
+				{"Restores", referencesOfKind(backup.Status.References, "ApplicationRestore")},
+			})
+		})
+}
+
+func newDescribeApplicationRestoreCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return newDescribeSubcommand(cmdFactory, ioStreams, applicationRestoreSubcommand, applicationRestoreAliases,
+		"Describe applicationrestores along with the backup they were restored from",
+		func(name string, namespace string, out io.Writer) error {
+			restore, err := storkops.Instance().GetApplicationRestore(name, namespace)
+			if err != nil {
+				return err
+			}
+			return printDescription(out, []describeField{
+				{"Name", restore.Name},
+				{"Namespace", restore.Namespace},
+				{"Kind", "ApplicationRestore"},
+				{"Stage", string(restore.Status.Stage)},
+				{"Status", string(restore.Status.Status)},
+				{"Backup", restore.Namespace + "/" + restore.Spec.BackupName},
+			}, nil)
+		})
+}
+
+func newDescribeApplicationBackupScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return newDescribeSubcommand(cmdFactory, ioStreams, applicationBackupScheduleSubcommand, applicationBackupScheduleAliases,
+		"Describe applicationbackupschedules along with the backups they created",
+		func(name string, namespace string, out io.Writer) error {
+			schedule, err := storkops.Instance().GetApplicationBackupSchedule(name, namespace)
+			if err != nil {
+				return err
+			}
+			backups := make([]string, 0)
+			for policyType, items := range schedule.Status.Items {
+				for _, item := range items {
+					backups = append(backups, fmt.Sprintf("%v/%v (%v, %v)", schedule.Namespace, item.Name, policyType, item.Status))
+				}
+			}
+			sort.Strings(backups)
+			return printDescription(out, []describeField{
+				{"Name", schedule.Name},
+				{"Namespace", schedule.Namespace},
+				{"Kind", "ApplicationBackupSchedule"},
+				{"SchedulePolicy", schedule.Spec.SchedulePolicyName},
+				{"Suspended", fmt.Sprintf("%v", schedule.Spec.Suspend != nil && *schedule.Spec.Suspend)},
+				{"BackupLocation", backupLocationName(schedule.Spec.Template.Spec, schedule.Namespace)},
+			}, []describeSection{
+				{"Backups", backups},
+			})
+		})
+}
+
+func newDescribeMigrationScheduleCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return newDescribeSubcommand(cmdFactory, ioStreams, migrationScheduleSubcommand, migrationScheduleAliases,
+		"Describe migrationschedules along with the migrations they created",
+		func(name string, namespace string, out io.Writer) error {
+			schedule, err := storkops.Instance().GetMigrationSchedule(name, namespace)
+			if err != nil {
+				return err
+			}
+			migrations := make([]string, 0)
+			for policyType, items := range schedule.Status.Items {
+				for _, item := range items {
+					migrations = append(migrations, fmt.Sprintf("%v/%v (%v, %v)", schedule.Namespace, item.Name, policyType, item.Status))
+				}
+			}
+			sort.Strings(migrations)
+			return printDescription(out, []describeField{
+				{"Name", schedule.Name},
+				{"Namespace", schedule.Namespace},
+				{"Kind", "MigrationSchedule"},
+				{"SchedulePolicy", schedule.Spec.SchedulePolicyName},
+				{"Suspended", fmt.Sprintf("%v", schedule.Spec.Suspend != nil && *schedule.Spec.Suspend)},
+				{"ClusterPair", schedule.Namespace + "/" + schedule.Spec.Template.Spec.ClusterPair},
+			}, []describeSection{
+				{"Migrations", migrations},
+			})
+		})
+}
+
+func newDescribeClusterPairCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return newDescribeSubcommand(cmdFactory, ioStreams, clusterPairSubcommand, nil,
+		"Describe clusterpairs along with the migration schedules using them",
+		func(name string, namespace string, out io.Writer) error {
+			clusterPair, err := storkops.Instance().GetClusterPair(name, namespace)
+			if err != nil {
+				return err
+			}
+			return printDescription(out, []describeField{
+				{"Name", clusterPair.Name},
+				{"Namespace", clusterPair.Namespace},
+				{"Kind", "ClusterPair"},
+				{"StorageStatus", string(clusterPair.Status.StorageStatus)},
+				{"SchedulerStatus", string(clusterPair.Status.SchedulerStatus)},
+			}, []describeSection{
+				{"MigrationSchedules", referencesOfKind(clusterPair.Status.References, "MigrationSchedule")},
+			})
+		})
+}
+
+// backupLocationName returns the namespace/name of the BackupLocation used by
+// the backup spec
+func backupLocationName(spec storkv1.ApplicationBackupSpec, namespace string) string {
+	if spec.BackupLocationNamespace != "" {
+		namespace = spec.BackupLocationNamespace
+	}
+	return namespace + "/" + spec.BackupLocation
+}
+
+// referencesOfKind returns the namespace/name of the references of the kind
+func referencesOfKind(references []storkv1.ObjectReference, kind string) []string {
+	items := make([]string, 0)
+	for _, ref := range references {
+		if ref.Kind == kind {
+			items = append(items, ref.Namespace+"/"+ref.Name)
+		}
+	}
+	sort.Strings(items)
+	return items
+}
+
+func printDescription(out io.Writer, fields []describeField, sections []describeSection) error {
+	w := printers.GetNewTabWriter(out)
+	for _, field := range fields {
+		if _, err := fmt.Fprintf(w, "%v:\t%v\n", field.name, field.value); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, section := range sections {
+		if len(section.items) == 0 {
+			if _, err := fmt.Fprintf(out, "%v: <none>\n", section.title); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(out, "%v:\n", section.title); err != nil {
+			return err
+		}
+		for _, item := range section.items {
+			if _, err := fmt.Fprintf(out, "  %v\n", item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
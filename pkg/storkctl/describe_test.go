@@ -0,0 +1,81 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDescribeNoName(t *testing.T) {
+	defer resetTest()
+	cmdArgs := []string{"describe", "applicationbackups", "-n", "test"}
+
+	expected := "error: at least one name needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestDescribeApplicationBackup(t *testing.T) {
+	defer resetTest()
+	_, err := storkops.Instance().CreateApplicationBackup(&storkv1.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "backup1",
+			Namespace:   "test",
+			Annotations: map[string]string{"stork.libopenstorage.org/applicationBackupScheduleName": "schedule1"},
+		},
+		Spec: storkv1.ApplicationBackupSpec{
+			BackupLocation: "location1",
+		},
+		Status: storkv1.ApplicationBackupStatus{
+			Stage:  storkv1.ApplicationBackupStageFinal,
+			Status: storkv1.ApplicationBackupStatusSuccessful,
+			References: []storkv1.ObjectReference{
+				{Kind: "ApplicationRestore", Namespace: "test", Name: "restore2"},
+				{Kind: "ApplicationRestore", Namespace: "test", Name: "restore1"},
+			},
+		},
+	})
+	require.NoError(t, err, "Error creating backup")
+
+	cmdArgs := []string{"describe", "applicationbackups", "-n", "test", "backup1"}
+	expected := "Name:             backup1\n" +
+		"Namespace:        test\n" +
+		"Kind:             ApplicationBackup\n" +
+		"Stage:            Final\n" +
+		"Status:           Successful\n" +
+		"BackupLocation:   test/location1\n" +
+		"Schedule:         test/schedule1\n" +
+		"Restores:\n" +
+		"  test/restore1\n" +
+		"  test/restore2\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestDescribeClusterPair(t *testing.T) {
+	defer resetTest()
+	_, err := storkops.Instance().CreateClusterPair(&storkv1.ClusterPair{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pair1",
+			Namespace: "test",
+		},
+		Status: storkv1.ClusterPairStatus{
+			StorageStatus:   storkv1.ClusterPairStatusReady,
+			SchedulerStatus: storkv1.ClusterPairStatusReady,
+		},
+	})
+	require.NoError(t, err, "Error creating cluster pair")
+
+	cmdArgs := []string{"describe", "clusterpair", "-n", "test", "pair1"}
+	expected := "Name:              pair1\n" +
+		"Namespace:         test\n" +
+		"Kind:              ClusterPair\n" +
+		"StorageStatus:     Ready\n" +
+		"SchedulerStatus:   Ready\n" +
+		"MigrationSchedules: <none>\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
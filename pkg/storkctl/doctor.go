@@ -0,0 +1,106 @@
+package storkctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	"github.com/libopenstorage/stork/pkg/doctor"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+const outputFormatText = "text"
+
+var doctorColumns = []string{"SEVERITY", "CHECK", "OBJECT", "MESSAGE"}
+
+func newDoctorCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var outputFormat string
+	var storkNamespace string
+	var overdueGrace time.Duration
+	var expiryWarning time.Duration
+
+	doctorCommand := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the health of stork across the cluster",
+		Long: "Check the stork CRDs, the replicas serving the scheduler extender and webhook, the volume drivers,\n" +
+			"resources stuck in cleanup, overdue schedules and the credentials of cluster pairs. Findings are\n" +
+			"listed with the most severe first.",
+		Run: func(c *cobra.Command, args []string) {
+			if outputFormat != outputFormatText && outputFormat != outputFormatJSON {
+				util.CheckErr(fmt.Errorf("unsupported output format %v, should be %v or %v", outputFormat, outputFormatText, outputFormatJSON))
+				return
+			}
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			storkClient, err := versioned.NewForConfig(config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			namespace := cmdFactory.GetNamespace()
+			if cmdFactory.AllNamespaces() {
+				namespace = ""
+			}
+			d := doctor.New(storkClient, storkNamespace)
+			d.OverdueGrace = overdueGrace
+			d.ExpiryWarning = expiryWarning
+			findings := d.Run(namespace)
+
+			if outputFormat == outputFormatJSON {
+				if err := printDoctorFindingsJSON(findings, ioStreams.Out); err != nil {
+					util.CheckErr(err)
+				}
+				return
+			}
+			if err := printDoctorFindings(findings, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	doctorCommand.Flags().StringVarP(&outputFormat, "output", "o", outputFormatText, "Output format, one of text or json")
+	doctorCommand.Flags().StringVarP(&storkNamespace, "stork-namespace", "", defaultStorkNS, "Namespace where stork is running")
+	doctorCommand.Flags().DurationVarP(&overdueGrace, "overdue-grace", "", doctor.DefaultOverdueGrace, "Time after which a scheduled run that hasn't been triggered is reported")
+	doctorCommand.Flags().DurationVarP(&expiryWarning, "expiry-warning", "", doctor.DefaultExpiryWarning, "Time before certificates and tokens expire at which they are reported")
+
+	return doctorCommand
+}
+
+func printDoctorFindings(findings []*doctor.Finding, out io.Writer) error {
+	if len(findings) == 0 {
+		printMsg("No problems found.", out)
+		return nil
+	}
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(doctorColumns, "\t")); err != nil {
+		return err
+	}
+	for _, finding := range findings {
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\n",
+			finding.Severity, finding.Check, finding.Object, finding.Message); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func printDoctorFindingsJSON(findings []*doctor.Finding, out io.Writer) error {
+	if findings == nil {
+		findings = []*doctor.Finding{}
+	}
+	data, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return err
+	}
+	printMsg(string(data), out)
+	return nil
+}
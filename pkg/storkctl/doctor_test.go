@@ -0,0 +1,40 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/doctor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoctorInvalidOutputFormat(t *testing.T) {
+	cmdArgs := []string{"doctor", "-o", "yaml"}
+
+	expected := "error: unsupported output format yaml, should be text or json"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestPrintDoctorFindings(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printDoctorFindings(nil, &out))
+	require.Equal(t, "No problems found.\n", out.String())
+
+	findings := []*doctor.Finding{
+		{Severity: doctor.SeverityCritical, Check: "drivers", Object: "pxd", Message: "Calls to the driver keep failing: timeout"},
+		{Severity: doctor.SeverityWarning, Check: "service", Object: "kube-system/stork-service", Message: "1 of 2 stork replicas aren't ready"},
+	}
+	out.Reset()
+	require.NoError(t, printDoctorFindings(findings, &out))
+	expected := "SEVERITY   CHECK     OBJECT                      MESSAGE\n" +
+		"Critical   drivers   pxd                         Calls to the driver keep failing: timeout\n" +
+		"Warning    service   kube-system/stork-service   1 of 2 stork replicas aren't ready\n"
+	require.Equal(t, expected, out.String())
+
+	out.Reset()
+	require.NoError(t, printDoctorFindingsJSON(nil, &out))
+	require.Equal(t, "[]\n", out.String())
+}
@@ -0,0 +1,98 @@
+package storkctl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backupestimate"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+var backupEstimateColumns = []string{"NAMESPACE", "PVC", "USED BYTES", "SOURCE"}
+
+func newEstimateCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	estimateCommands := &cobra.Command{
+		Use:   "estimate",
+		Short: "Estimate the size and duration of operations",
+	}
+
+	estimateCommands.AddCommand(
+		newEstimateBackupCommand(cmdFactory, ioStreams),
+	)
+
+	return estimateCommands
+}
+
+func newEstimateBackupCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var namespaceList []string
+	var selectors map[string]string
+	var throughput int64
+
+	estimateBackupCommand := &cobra.Command{
+		Use:     applicationBackupSubcommand,
+		Aliases: applicationBackupAliases,
+		Short:   "Estimate the size and duration of an applicationbackup",
+		Run: func(c *cobra.Command, args []string) {
+			if len(namespaceList) == 0 {
+				util.CheckErr(fmt.Errorf("need to provide atleast one namespace to estimate backup"))
+				return
+			}
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			kubeClient, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			rc := &resourcecollector.ResourceCollector{
+				QPS:   float32(cmdFactory.GetQPS()),
+				Burst: cmdFactory.GetBurst(),
+			}
+			if err := rc.Init(config); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			estimate, err := backupestimate.New(kubeClient, rc, throughput).Estimate(namespaceList, selectors)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+
+			if err := printVolumeEstimates(estimate.Volumes, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			printMsg(fmt.Sprintf("\nVolumes: %v, Total bytes: %v, Resources: %v, Estimated duration: %v",
+				len(estimate.Volumes), estimate.TotalBytes, estimate.Objects, estimate.Duration.Duration), ioStreams.Out)
+		},
+	}
+	estimateBackupCommand.Flags().StringSliceVarP(&namespaceList, "namespaces", "", nil, "Comma separated list of namespaces to backup")
+	estimateBackupCommand.Flags().StringToStringVarP(&selectors, "selectors", "", nil, "Label selectors of the resources to backup")
+	estimateBackupCommand.Flags().Int64VarP(&throughput, "throughput", "", backupestimate.DefaultThroughput, "Throughput in bytes per second used to estimate the duration")
+
+	return estimateBackupCommand
+}
+
+func printVolumeEstimates(volumes []*storkv1.ApplicationBackupVolumeEstimate, out io.Writer) error {
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(backupEstimateColumns, "\t")); err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\n",
+			volume.Namespace, volume.PersistentVolumeClaim, volume.UsedBytes, volume.Source); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,15 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+)
+
+func TestEstimateBackupNoNamespace(t *testing.T) {
+	cmdArgs := []string{"estimate", "backups"}
+
+	expected := "error: need to provide atleast one namespace to estimate backup"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
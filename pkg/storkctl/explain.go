@@ -0,0 +1,168 @@
+package storkctl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+const (
+	schedulingSubcommand = "scheduling"
+	defaultStorkService  = "stork-service"
+	defaultStorkNS       = "kube-system"
+)
+
+var schedulingExplanationColumns = []string{"NODE", "DRIVER STATUS", "REPLICAS", "FILTERED", "SCORE", "REASON"}
+
+func newExplainCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	explainCommands := &cobra.Command{
+		Use:   "explain",
+		Short: "Explain decisions made by stork",
+	}
+
+	explainCommands.AddCommand(
+		newExplainSchedulingCommand(cmdFactory, ioStreams),
+	)
+
+	return explainCommands
+}
+
+func newExplainSchedulingCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	var podFile string
+	var storkNamespace string
+	var storkService string
+
+	explainSchedulingCommand := &cobra.Command{
+		Use:   schedulingSubcommand + " [pod]",
+		Short: "Explain how the stork scheduler extender filters and scores the nodes for a pod",
+		Long: "Explain how the stork scheduler extender filters and scores the nodes for a pod, based on the status\n" +
+			"of the storage driver on the nodes and where the replicas of the volumes are located. The pod can be\n" +
+			"an existing pod or a pod spec from a file that hasn't been created yet.",
+		Run: func(c *cobra.Command, args []string) {
+			pod, err := getPodToExplain(args, podFile, cmdFactory.GetNamespace())
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			config, err := cmdFactory.GetConfig()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			kubeClient, err := kubernetes.NewForConfig(config)
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			body, err := json.Marshal(&extender.ExplainRequest{Pod: pod})
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			// The request is sent to the extender through the API server so
+			// that the extender port doesn't need to be reachable
+			result, err := kubeClient.CoreV1().RESTClient().Post().
+				Namespace(storkNamespace).
+				Resource("services").
+				Name(fmt.Sprintf("%v:%v", storkService, extender.ExplainPort)).
+				SubResource("proxy").
+				Suffix(extender.ExplainPath).
+				Body(body).
+				Do(context.TODO()).
+				Raw()
+			if err != nil {
+				util.CheckErr(fmt.Errorf("error explaining scheduling for pod %v: %v", pod.Name, err))
+				return
+			}
+			explanation := &extender.SchedulingExplanation{}
+			if err := json.Unmarshal(result, explanation); err != nil {
+				util.CheckErr(err)
+				return
+			}
+			if err := printSchedulingExplanation(explanation, ioStreams.Out); err != nil {
+				util.CheckErr(err)
+				return
+			}
+		},
+	}
+	explainSchedulingCommand.Flags().StringVarP(&podFile, "file", "f", "", "File with the spec of a pod that doesn't exist yet")
+	explainSchedulingCommand.Flags().StringVarP(&storkNamespace, "stork-namespace", "", defaultStorkNS, "Namespace where stork is running")
+	explainSchedulingCommand.Flags().StringVarP(&storkService, "stork-service", "", defaultStorkService, "Name of the stork service")
+
+	return explainSchedulingCommand
+}
+
+// getPodToExplain returns the named pod or the pod from the file
+func getPodToExplain(args []string, podFile string, namespace string) (*v1.Pod, error) {
+	if podFile == "" {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("exactly one pod needs to be provided")
+		}
+		return core.Instance().GetPodByName(args[0], namespace)
+	}
+	if len(args) != 0 {
+		return nil, fmt.Errorf("pod name can't be provided along with a file")
+	}
+	contents, err := ioutil.ReadFile(podFile)
+	if err != nil {
+		return nil, err
+	}
+	pod := &v1.Pod{}
+	if err := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(contents), len(contents)).Decode(pod); err != nil {
+		return nil, fmt.Errorf("error parsing pod from %v: %v", podFile, err)
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = namespace
+	}
+	return pod, nil
+}
+
+func printSchedulingExplanation(explanation *extender.SchedulingExplanation, out io.Writer) error {
+	printMsg(fmt.Sprintf("Pod: %v/%v", explanation.Namespace, explanation.Pod), out)
+	if explanation.PreferLocalNodeOnly {
+		printMsg("Only nodes with replicas for all the volumes are allowed", out)
+	}
+	if explanation.HyperconvergenceDisabled {
+		printMsg("Hyperconvergence is disabled", out)
+	}
+	if explanation.FilterError != "" {
+		printMsg("Filter failed: "+explanation.FilterError, out)
+	}
+	if len(explanation.Volumes) > 0 {
+		printMsg("Volumes:", out)
+		for _, volume := range explanation.Volumes {
+			msg := fmt.Sprintf("  %v: replicas on %v", volume.Name, strings.Join(volume.ReplicaNodes, ", "))
+			if volume.SkipScoring {
+				msg += " (skipped for scoring)"
+			}
+			printMsg(msg, out)
+		}
+	}
+	printMsg("", out)
+
+	w := printers.GetNewTabWriter(out)
+	if _, err := fmt.Fprintln(w, strings.Join(schedulingExplanationColumns, "\t")); err != nil {
+		return err
+	}
+	for _, node := range explanation.Nodes {
+		if _, err := fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			node.Name, node.DriverStatus, node.Replicas, node.Filtered, node.Score, node.Reason); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,67 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/libopenstorage/stork/pkg/extender"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainSchedulingNoPod(t *testing.T) {
+	cmdArgs := []string{"explain", "scheduling"}
+
+	expected := "error: exactly one pod needs to be provided"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestExplainSchedulingPodAndFile(t *testing.T) {
+	cmdArgs := []string{"explain", "scheduling", "pod", "-f", "pod.yaml"}
+
+	expected := "error: pod name can't be provided along with a file"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestGetPodToExplainFromFile(t *testing.T) {
+	file, err := ioutil.TempFile("", "pod")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: hypothetical\nspec:\n  containers:\n  - name: app\n    image: app\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	pod, err := getPodToExplain(nil, file.Name(), "ns")
+	require.NoError(t, err)
+	require.Equal(t, "hypothetical", pod.Name)
+	require.Equal(t, "ns", pod.Namespace)
+	require.Len(t, pod.Spec.Containers, 1)
+}
+
+func TestPrintSchedulingExplanation(t *testing.T) {
+	explanation := &extender.SchedulingExplanation{
+		Pod:       "pod",
+		Namespace: "ns",
+		Volumes: []extender.VolumeExplanation{
+			{Name: "vol1", ReplicaNodes: []string{"id1", "id2"}},
+		},
+		Nodes: []extender.NodeExplanation{
+			{Name: "node1", DriverStatus: "Online", Replicas: 1, Filtered: true, Score: 100, Reason: "Node has replicas for 1 of 1 volumes"},
+			{Name: "node2", DriverStatus: "Offline", Reason: "Storage driver is Offline on the node"},
+		},
+	}
+	var out bytes.Buffer
+	require.NoError(t, printSchedulingExplanation(explanation, &out))
+	expected := "Pod: ns/pod\n" +
+		"Volumes:\n" +
+		"  vol1: replicas on id1, id2\n" +
+		"\n" +
+		"NODE    DRIVER STATUS   REPLICAS   FILTERED   SCORE   REASON\n" +
+		"node1   Online          1          true       100     Node has replicas for 1 of 1 volumes\n" +
+		"node2   Offline         0          false      0       Storage driver is Offline on the node\n"
+	require.Equal(t, expected, out.String())
+}
@@ -3,11 +3,14 @@ package storkctl
 import (
 	"fmt"
 
+	"github.com/portworx/sched-ops/k8s/admissionregistration"
+	"github.com/portworx/sched-ops/k8s/apiextensions"
 	appsops "github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/batch"
 	"github.com/portworx/sched-ops/k8s/core"
 	dynamicops "github.com/portworx/sched-ops/k8s/dynamic"
 	externalstorageops "github.com/portworx/sched-ops/k8s/externalstorage"
+	kdmpops "github.com/portworx/sched-ops/k8s/kdmp"
 	ocpops "github.com/portworx/sched-ops/k8s/openshift"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/spf13/pflag"
@@ -147,6 +150,9 @@ func (f *factory) UpdateConfig() error {
 	appsops.Instance().SetConfig(config)
 	dynamicops.Instance().SetConfig(config)
 	externalstorageops.Instance().SetConfig(config)
+	apiextensions.Instance().SetConfig(config)
+	admissionregistration.Instance().SetConfig(config)
+	kdmpops.Instance().SetConfig(config)
 	return nil
 }
 
@@ -14,7 +14,11 @@ import (
 
 	"github.com/go-openapi/inflect"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/cutover"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/libopenstorage/stork/pkg/resourcecollector"
+	"github.com/libopenstorage/stork/pkg/rule"
 	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/batch"
 	"github.com/portworx/sched-ops/k8s/core"
@@ -28,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	metav1beta1 "k8s.io/apimachinery/pkg/apis/meta/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	k8sdynamic "k8s.io/client-go/dynamic"
@@ -41,6 +46,10 @@ const (
 	stage       = "STAGE"
 	status      = "STATUS"
 	statusOk    = "Ok\n"
+
+	defaultHPAMetricsTimeout = 1 * time.Minute
+	hpaMetricsRetryInterval  = 5 * time.Second
+	suspendedPDBBudget       = "maxUnavailable=100%"
 )
 
 var (
@@ -143,6 +152,7 @@ func newCreateMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.I
 
 func newActivateMigrationsCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var allNamespaces bool
+	var hpaMetricsTimeout time.Duration
 
 	activateMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
@@ -150,6 +160,7 @@ func newActivateMigrationsCommand(cmdFactory Factory, ioStreams genericclioption
 		Short:   "Activate apps that were created from a migration",
 		Run: func(c *cobra.Command, args []string) {
 			activationNamespaces := make([]string, 0)
+			hpas := make([]*unstructured.Unstructured, 0)
 			config, err := cmdFactory.GetConfig()
 			if err != nil {
 				util.CheckErr(err)
@@ -201,11 +212,16 @@ func newActivateMigrationsCommand(cmdFactory Factory, ioStreams genericclioption
 				updateVMObjects("VirtualMachine", ns, true, ioStreams)
 				updateCRDObjects(ns, true, ioStreams, config)
 				updateCronJobObjects(ns, true, ioStreams)
+				updatePDBObjects(ns, true, ioStreams)
+				hpas = append(hpas, updateHPAObjects(ns, true, ioStreams)...)
+				runCutoverHooks(ns, true, ioStreams, config)
 			}
+			verifyHPAMetrics(hpas, hpaMetricsTimeout, ioStreams)
 
 		},
 	}
 	activateMigrationCommand.Flags().BoolVarP(&allNamespaces, "all-namespaces", "a", false, "Activate applications in all namespaces")
+	activateMigrationCommand.Flags().DurationVarP(&hpaMetricsTimeout, "hpa-metrics-timeout", "", defaultHPAMetricsTimeout, "Time to wait for metrics to be available for the activated HorizontalPodAutoscalers")
 
 	return activateMigrationCommand
 }
@@ -249,6 +265,9 @@ func newDeactivateMigrationsCommand(cmdFactory Factory, ioStreams genericcliopti
 				updateVMObjects("VirtualMachine", ns, true, ioStreams)
 				updateCRDObjects(ns, false, ioStreams, config)
 				updateCronJobObjects(ns, false, ioStreams)
+				updatePDBObjects(ns, false, ioStreams)
+				updateHPAObjects(ns, false, ioStreams)
+				runCutoverHooks(ns, false, ioStreams, config)
 			}
 
 		},
@@ -487,16 +506,221 @@ func updateCronJobObjects(namespace string, activate bool, ioStreams genericclio
 	}
 
 	for _, cronJob := range cronJobs.Items {
-		*cronJob.Spec.Suspend = !activate
+		suspend := !activate
+		// CronJobs that were suspended on the source stay suspended
+		if val, present := cronJob.Annotations[resourcecollector.CronJobSuspendAnnotation]; present && activate {
+			if suspend, err = strconv.ParseBool(val); err != nil {
+				printMsg(fmt.Sprintf("Invalid value for annotation %v on cronJob %v/%v : %v", resourcecollector.CronJobSuspendAnnotation, cronJob.Namespace, cronJob.Name, err), ioStreams.ErrOut)
+				continue
+			}
+		}
+		cronJob.Spec.Suspend = &suspend
 		_, err = batch.Instance().UpdateCronJob(&cronJob)
 		if err != nil {
 			printMsg(fmt.Sprintf("Error updating suspend option for cronJob %v/%v : %v", cronJob.Namespace, cronJob.Name, err), ioStreams.ErrOut)
 			continue
 		}
-		printMsg(fmt.Sprintf("Updated suspend option for cronjob %v/%v to %v", cronJob.Namespace, cronJob.Name, !activate), ioStreams.Out)
+		printMsg(fmt.Sprintf("Updated suspend option for cronjob %v/%v to %v", cronJob.Namespace, cronJob.Name, suspend), ioStreams.Out)
 	}
 
 }
+
+// runCutoverHooks runs the cutover hooks defined in the namespace once the
+// applications have been activated or deactivated, so that traffic is moved
+// to the cluster running the applications
+func runCutoverHooks(namespace string, activate bool, ioStreams genericclioptions.IOStreams, config *rest.Config) {
+	hooks, err := cutover.GetHooks(namespace)
+	if err != nil {
+		util.CheckErr(err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	client, err := k8sdynamic.NewForConfig(config)
+	if err != nil {
+		util.CheckErr(err)
+		return
+	}
+	data := cutover.TemplateData{
+		Namespace: namespace,
+		Activate:  activate,
+	}
+	for _, hook := range hooks {
+		if err := hook.Run(client, data); err != nil {
+			printMsg(fmt.Sprintf("Error running cutover hook %v : %v", hook.Source, err), ioStreams.ErrOut)
+			continue
+		}
+		printMsg(fmt.Sprintf("Ran cutover hook %v on %v %v/%v", hook.Source, hook.Resource.Resource, hook.Namespace, hook.Name), ioStreams.Out)
+	}
+}
+
+// listObjects lists the objects of the kind in the first of the API versions
+// that is served by the cluster
+func listObjects(kind string, apiVersions []string, namespace string) (*unstructured.UnstructuredList, error) {
+	var err error
+	for _, apiVersion := range apiVersions {
+		var objects *unstructured.UnstructuredList
+		objects, err = dynamic.Instance().ListObjects(
+			&metav1.ListOptions{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       kind,
+					APIVersion: apiVersion},
+			},
+			namespace)
+		if err == nil || !errors.IsNotFound(err) {
+			return objects, err
+		}
+	}
+	return nil, err
+}
+
+// updateHPAObjects restores the min and max replicas of the migrated
+// HorizontalPodAutoscalers on activation, and pins them to a single replica
+// on deactivation. Returns the updated HorizontalPodAutoscalers.
+func updateHPAObjects(namespace string, activate bool, ioStreams genericclioptions.IOStreams) []*unstructured.Unstructured {
+	updated := make([]*unstructured.Unstructured, 0)
+	objects, err := listObjects("HorizontalPodAutoscaler", []string{"autoscaling/v2", "autoscaling/v2beta2"}, namespace)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			util.CheckErr(err)
+		}
+		return updated
+	}
+	for i := range objects.Items {
+		o := &objects.Items[i]
+		val, present := o.GetAnnotations()[migration.StorkMigrationHPAReplicasAnnotation]
+		if !present {
+			continue
+		}
+		minReplicas, maxReplicas := int64(1), int64(1)
+		if activate {
+			replicas := strings.Split(val, ",")
+			if len(replicas) != 2 {
+				printMsg(fmt.Sprintf("Invalid value for annotation %v on horizontalpodautoscaler %v/%v : %v", migration.StorkMigrationHPAReplicasAnnotation, o.GetNamespace(), o.GetName(), val), ioStreams.ErrOut)
+				continue
+			}
+			if minReplicas, err = strconv.ParseInt(replicas[0], 10, 64); err == nil {
+				maxReplicas, err = strconv.ParseInt(replicas[1], 10, 64)
+			}
+			if err != nil {
+				printMsg(fmt.Sprintf("Error parsing replicas for horizontalpodautoscaler %v/%v : %v", o.GetNamespace(), o.GetName(), err), ioStreams.ErrOut)
+				continue
+			}
+		}
+		if err := unstructured.SetNestedField(o.Object, minReplicas, "spec", "minReplicas"); err != nil {
+			printMsg(fmt.Sprintf("Error updating replicas for horizontalpodautoscaler %v/%v : %v", o.GetNamespace(), o.GetName(), err), ioStreams.ErrOut)
+			continue
+		}
+		if err := unstructured.SetNestedField(o.Object, maxReplicas, "spec", "maxReplicas"); err != nil {
+			printMsg(fmt.Sprintf("Error updating replicas for horizontalpodautoscaler %v/%v : %v", o.GetNamespace(), o.GetName(), err), ioStreams.ErrOut)
+			continue
+		}
+		if _, err := dynamic.Instance().UpdateObject(o); err != nil {
+			printMsg(fmt.Sprintf("Error updating replicas for horizontalpodautoscaler %v/%v : %v", o.GetNamespace(), o.GetName(), err), ioStreams.ErrOut)
+			continue
+		}
+		printMsg(fmt.Sprintf("Updated replicas for horizontalpodautoscaler %v/%v to %v-%v", o.GetNamespace(), o.GetName(), minReplicas, maxReplicas), ioStreams.Out)
+		updated = append(updated, o)
+	}
+	return updated
+}
+
+// updatePDBObjects restores the disruption budget of the migrated
+// PodDisruptionBudgets on activation, and allows all pods to be disrupted
+// on deactivation
+func updatePDBObjects(namespace string, activate bool, ioStreams genericclioptions.IOStreams) {
+	objects, err := listObjects("PodDisruptionBudget", []string{"policy/v1", "policy/v1beta1"}, namespace)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			util.CheckErr(err)
+		}
+		return
+	}
+	for i := range objects.Items {
+		o := &objects.Items[i]
+		budget, present := o.GetAnnotations()[migration.StorkMigrationPDBAnnotation]
+		if !present {
+			continue
+		}
+		if !activate {
+			budget = suspendedPDBBudget
+		}
+		budgetOpts := strings.SplitN(budget, "=", 2)
+		if len(budgetOpts) != 2 || (budgetOpts[0] != "minAvailable" && budgetOpts[0] != "maxUnavailable") {
+			printMsg(fmt.Sprintf("Invalid value for annotation %v on poddisruptionbudget %v/%v : %v", migration.StorkMigrationPDBAnnotation, o.GetNamespace(), o.GetName(), budget), ioStreams.ErrOut)
+			continue
+		}
+		unstructured.RemoveNestedField(o.Object, "spec", "minAvailable")
+		unstructured.RemoveNestedField(o.Object, "spec", "maxUnavailable")
+		value := intstr.Parse(budgetOpts[1])
+		var err error
+		if value.Type == intstr.Int {
+			err = unstructured.SetNestedField(o.Object, int64(value.IntVal), "spec", budgetOpts[0])
+		} else {
+			err = unstructured.SetNestedField(o.Object, value.StrVal, "spec", budgetOpts[0])
+		}
+		if err == nil {
+			_, err = dynamic.Instance().UpdateObject(o)
+		}
+		if err != nil {
+			printMsg(fmt.Sprintf("Error updating disruption budget for poddisruptionbudget %v/%v : %v", o.GetNamespace(), o.GetName(), err), ioStreams.ErrOut)
+			continue
+		}
+		printMsg(fmt.Sprintf("Updated disruption budget for poddisruptionbudget %v/%v to %v", o.GetNamespace(), o.GetName(), budget), ioStreams.Out)
+	}
+}
+
+// verifyHPAMetrics waits for the HorizontalPodAutoscalers to be able to get
+// the metrics they scale on, and warns about the ones that can't, since
+// they won't scale the applications
+func verifyHPAMetrics(hpas []*unstructured.Unstructured, timeout time.Duration, ioStreams genericclioptions.IOStreams) {
+	for _, hpa := range hpas {
+		var reason string
+		t := func() (interface{}, bool, error) {
+			latest, err := dynamic.Instance().GetObject(hpa)
+			if err != nil {
+				return nil, true, err
+			}
+			var active bool
+			if active, reason = isHPAScalingActive(latest.(*unstructured.Unstructured)); !active {
+				return nil, true, fmt.Errorf("%v", reason)
+			}
+			return nil, false, nil
+		}
+		var err error
+		if timeout > 0 {
+			_, err = task.DoRetryWithTimeout(t, timeout, hpaMetricsRetryInterval)
+		} else {
+			_, _, err = t()
+		}
+		if err != nil {
+			printMsg(fmt.Sprintf("Metrics are not available for horizontalpodautoscaler %v/%v : %v", hpa.GetNamespace(), hpa.GetName(), err), ioStreams.ErrOut)
+		}
+	}
+}
+
+// isHPAScalingActive returns true if the ScalingActive condition of the
+// HorizontalPodAutoscaler is true, which means that it was able to get the
+// metrics it scales on. Otherwise also returns the reason.
+func isHPAScalingActive(hpa *unstructured.Unstructured) (bool, string) {
+	conditions, _, err := unstructured.NestedSlice(hpa.Object, "status", "conditions")
+	if err != nil {
+		return false, err.Error()
+	}
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "ScalingActive" {
+			continue
+		}
+		if condition["status"] == "True" {
+			return true, ""
+		}
+		return false, fmt.Sprintf("%v: %v", condition["reason"], condition["message"])
+	}
+	return false, "status not reported yet"
+}
+
 func getSuspendStringOpts(annotations map[string]string, activate bool, path string, ioStreams genericclioptions.IOStreams) (string, error) {
 	if val, present := annotations[migration.StorkAnnotationPrefix+path]; present {
 		suspend := strings.Split(val, ",")
@@ -565,6 +789,7 @@ func getUpdatedReplicaCount(annotations map[string]string, activate bool, ioStre
 
 func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var clusterPair string
+	var bySchedule string
 	getMigrationCommand := &cobra.Command{
 		Use:     migrationSubcommand,
 		Aliases: migrationAliases,
@@ -614,6 +839,16 @@ func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOSt
 				migrations = &tempMigrations
 			}
 
+			if len(bySchedule) != 0 {
+				var tempMigrations storkv1.MigrationList
+				for _, migration := range migrations.Items {
+					if k8sutils.IsCreatedBySchedule(&migration, bySchedule) {
+						tempMigrations.Items = append(tempMigrations.Items, migration)
+					}
+				}
+				migrations = &tempMigrations
+			}
+
 			if len(migrations.Items) == 0 {
 				handleEmptyList(ioStreams.Out)
 				return
@@ -632,6 +867,7 @@ func newGetMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOSt
 		},
 	}
 	getMigrationCommand.Flags().StringVarP(&clusterPair, "clusterpair", "c", "", "Name of the cluster pair for which to list migrations")
+	getMigrationCommand.Flags().StringVarP(&bySchedule, "by-schedule", "", "", "Name of the migration schedule for which to list the migrations it created")
 	cmdFactory.BindGetFlags(getMigrationCommand.Flags())
 
 	return getMigrationCommand
@@ -690,6 +926,44 @@ func deleteMigrations(migrations []string, namespace string, ioStreams genericcl
 	}
 }
 
+func newCutoverMigrationCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cutoverMigrationCommand := &cobra.Command{
+		Use:     migrationSubcommand,
+		Aliases: migrationAliases,
+		Short:   "Stop the applications on the source and run the final sync of staged migrations",
+		Run: func(c *cobra.Command, args []string) {
+			if len(args) == 0 {
+				util.CheckErr(fmt.Errorf("at least one argument needs to be provided for migration name"))
+				return
+			}
+			cutoverMigrations(args, cmdFactory.GetNamespace(), ioStreams)
+		},
+	}
+
+	return cutoverMigrationCommand
+}
+
+func cutoverMigrations(migrations []string, namespace string, ioStreams genericclioptions.IOStreams) {
+	for _, name := range migrations {
+		migration, err := storkops.Instance().GetMigration(name, namespace)
+		if err != nil {
+			util.CheckErr(err)
+			return
+		}
+		if migration.Spec.Staging == nil {
+			util.CheckErr(fmt.Errorf("migration %v isn't staged", name))
+			return
+		}
+		migration.Spec.Staging.Cutover = true
+		if _, err := storkops.Instance().UpdateMigration(migration); err != nil {
+			util.CheckErr(err)
+			return
+		}
+		msg := fmt.Sprintf("Cutover of migration %v started", name)
+		printMsg(msg, ioStreams.Out)
+	}
+}
+
 func migrationPrinter(
 	migrationList *storkv1.MigrationList,
 	options printers.GenerateOptions,
@@ -879,7 +1153,7 @@ func ValidateMigration(migr *storkv1.Migration) error {
 	if migr.Spec.PreExecRule == "" {
 		status = "NotConfigured\n"
 	} else {
-		if _, err := storkops.Instance().GetRule(migr.Spec.PreExecRule, migr.Namespace); err != nil && errors.IsNotFound(err) {
+		if _, err := rule.GetRule(migr.Spec.PreExecRule, migr.Namespace); err != nil && errors.IsNotFound(err) {
 			status = "NotFound\n"
 		} else if err != nil {
 			return err
@@ -892,7 +1166,7 @@ func ValidateMigration(migr *storkv1.Migration) error {
 	if migr.Spec.PostExecRule == "" {
 		status = "NotConfigured\n"
 	} else {
-		if _, err := storkops.Instance().GetRule(migr.Spec.PostExecRule, migr.Namespace); err != nil && errors.IsNotFound(err) {
+		if _, err := rule.GetRule(migr.Spec.PostExecRule, migr.Namespace); err != nil && errors.IsNotFound(err) {
 			status = "NotFound\n"
 		} else if err != nil {
 			return err
@@ -4,6 +4,7 @@
 package storkctl
 
 import (
+	"context"
 	"strings"
 	"testing"
 	"time"
@@ -19,6 +20,8 @@ import (
 	appv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestGetMigrationsNoMigration(t *testing.T) {
@@ -247,6 +250,27 @@ func TestDeleteMigrations(t *testing.T) {
 	testCommon(t, cmdArgs, nil, expected, false)
 }
 
+func TestCutoverMigrations(t *testing.T) {
+	defer resetTest()
+	createMigrationAndVerify(t, "cutovermigration", "default", "clusterpair1", []string{"namespace1"}, "", "")
+
+	cmdArgs := []string{"cutover", "migrations", "cutovermigration"}
+	expected := "error: migration cutovermigration isn't staged"
+	testCommon(t, cmdArgs, nil, expected, true)
+
+	migr, err := storkops.Instance().GetMigration("cutovermigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	migr.Spec.Staging = &storkv1.MigrationStagingPolicy{}
+	_, err = storkops.Instance().UpdateMigration(migr)
+	require.NoError(t, err, "Error updating migration")
+
+	expected = "Cutover of migration cutovermigration started\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+	migr, err = storkops.Instance().GetMigration("cutovermigration", "default")
+	require.NoError(t, err, "Error getting migration")
+	require.True(t, migr.Spec.Staging.Cutover, "Cutover not requested")
+}
+
 func TestExclueVolumesForMigrations(t *testing.T) {
 	defer resetTest()
 	name := "excludevolumestest"
@@ -434,3 +458,85 @@ func setMigrationStatus(name, namespace string, isFail bool, t *testing.T) {
 	_, err = storkops.Instance().UpdateMigration(migrResp)
 	require.NoError(t, err, "Error updating Migrations")
 }
+
+func TestActivateDeactivateHPAAndPDB(t *testing.T) {
+	defer resetTest()
+	hpaResource := schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+	pdbResource := schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}
+
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"minReplicas": int64(1),
+			"maxReplicas": int64(1),
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":    "ScalingActive",
+					"status":  "False",
+					"reason":  "FailedGetResourceMetric",
+					"message": "unable to get metrics",
+				},
+			},
+		},
+	}}
+	hpa.SetAPIVersion("autoscaling/v2")
+	hpa.SetKind("HorizontalPodAutoscaler")
+	hpa.SetName("migratedHPA")
+	hpa.SetNamespace("hpa")
+	hpa.SetAnnotations(map[string]string{migration.StorkMigrationHPAReplicasAnnotation: "2,5"})
+	_, err := fakeDynamicClient.Resource(hpaResource).Namespace("hpa").Create(context.TODO(), hpa, metav1.CreateOptions{})
+	require.NoError(t, err, "Error creating hpa")
+
+	pdb := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"maxUnavailable": "100%",
+		},
+	}}
+	pdb.SetAPIVersion("policy/v1")
+	pdb.SetKind("PodDisruptionBudget")
+	pdb.SetName("migratedPDB")
+	pdb.SetNamespace("hpa")
+	pdb.SetAnnotations(map[string]string{migration.StorkMigrationPDBAnnotation: "minAvailable=2"})
+	_, err = fakeDynamicClient.Resource(pdbResource).Namespace("hpa").Create(context.TODO(), pdb, metav1.CreateOptions{})
+	require.NoError(t, err, "Error creating pdb")
+
+	cmdArgs := []string{"activate", "migrations", "-n", "hpa", "--hpa-metrics-timeout", "0"}
+	expected := "Updated disruption budget for poddisruptionbudget hpa/migratedPDB to minAvailable=2\n"
+	expected += "Updated replicas for horizontalpodautoscaler hpa/migratedHPA to 2-5\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	updatedPDB, err := fakeDynamicClient.Resource(pdbResource).Namespace("hpa").Get(context.TODO(), "migratedPDB", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting pdb")
+	minAvailable, _, err := unstructured.NestedInt64(updatedPDB.Object, "spec", "minAvailable")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), minAvailable)
+	_, found, err := unstructured.NestedFieldNoCopy(updatedPDB.Object, "spec", "maxUnavailable")
+	require.NoError(t, err)
+	require.False(t, found, "maxUnavailable should be removed on activation")
+
+	updatedHPA, err := fakeDynamicClient.Resource(hpaResource).Namespace("hpa").Get(context.TODO(), "migratedHPA", metav1.GetOptions{})
+	require.NoError(t, err, "Error getting hpa")
+	maxReplicas, _, err := unstructured.NestedInt64(updatedHPA.Object, "spec", "maxReplicas")
+	require.NoError(t, err)
+	require.Equal(t, int64(5), maxReplicas)
+
+	cmdArgs = []string{"deactivate", "migrations", "-n", "hpa"}
+	expected = "Updated disruption budget for poddisruptionbudget hpa/migratedPDB to maxUnavailable=100%\n"
+	expected += "Updated replicas for horizontalpodautoscaler hpa/migratedHPA to 1-1\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestIsHPAScalingActive(t *testing.T) {
+	hpa := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	active, reason := isHPAScalingActive(hpa)
+	require.False(t, active)
+	require.Equal(t, "status not reported yet", reason)
+
+	require.NoError(t, unstructured.SetNestedSlice(hpa.Object, []interface{}{
+		map[string]interface{}{"type": "AbleToScale", "status": "True"},
+		map[string]interface{}{"type": "ScalingActive", "status": "True"},
+	}, "status", "conditions"))
+	active, _ = isHPAScalingActive(hpa)
+	require.True(t, active)
+}
@@ -7,6 +7,7 @@ import (
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	"github.com/libopenstorage/stork/drivers/volume"
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/k8sutils"
 	k8sextops "github.com/portworx/sched-ops/k8s/externalstorage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
 	"github.com/spf13/cobra"
@@ -71,6 +72,7 @@ func newCreateSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IO
 
 func newGetSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
 	var pvcName string
+	var bySchedule string
 	getSnapshotCommand := &cobra.Command{
 		Use:     snapSubcommand,
 		Aliases: snapAliases,
@@ -121,6 +123,16 @@ func newGetSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStr
 				snapshots = &tempSnapshots
 			}
 
+			if len(bySchedule) != 0 {
+				var tempSnapshots snapv1.VolumeSnapshotList
+				for _, snap := range snapshots.Items {
+					if k8sutils.IsCreatedBySchedule(&snap.Metadata, bySchedule) {
+						tempSnapshots.Items = append(tempSnapshots.Items, snap)
+					}
+				}
+				snapshots = &tempSnapshots
+			}
+
 			if len(snapshots.Items) == 0 {
 				handleEmptyList(ioStreams.Out)
 				return
@@ -133,6 +145,7 @@ func newGetSnapshotCommand(cmdFactory Factory, ioStreams genericclioptions.IOStr
 		},
 	}
 	getSnapshotCommand.Flags().StringVarP(&pvcName, "pvc", "p", "", "Name of the PVC for which to list snapshots")
+	getSnapshotCommand.Flags().StringVarP(&bySchedule, "by-schedule", "", "", "Name of the snapshot schedule for which to list the snapshots it created")
 	cmdFactory.BindGetFlags(getSnapshotCommand.Flags())
 
 	return getSnapshotCommand
@@ -31,11 +31,19 @@ func NewCommand(cmdFactory Factory, in io.Reader, out io.Writer, errOut io.Write
 		newCreateCommand(cmdFactory, ioStreams),
 		newDeleteCommand(cmdFactory, ioStreams),
 		newGetCommand(cmdFactory, ioStreams),
+		newDescribeCommand(cmdFactory, ioStreams),
 		newActivateCommand(cmdFactory, ioStreams),
 		newDeactivateCommand(cmdFactory, ioStreams),
 		newGenerateCommand(cmdFactory, ioStreams),
+		newEstimateCommand(cmdFactory, ioStreams),
 		newSuspendCommand(cmdFactory, ioStreams),
 		newResumeCommand(cmdFactory, ioStreams),
+		newCutoverCommand(cmdFactory, ioStreams),
+		newAuditCommand(cmdFactory, ioStreams),
+		newExplainCommand(cmdFactory, ioStreams),
+		newDoctorCommand(cmdFactory, ioStreams),
+		newBundleCommand(cmdFactory, ioStreams),
+		newUpgradeCommand(cmdFactory, ioStreams),
 		newVersionCommand(cmdFactory, ioStreams),
 	)
 
@@ -0,0 +1,104 @@
+package storkctl
+
+import (
+	"fmt"
+	"strconv"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/backuplayout"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+const backupLayoutSubcommand = "backuplayout"
+
+var backupLayoutAliases = []string{"backuplayouts"}
+
+func newUpgradeCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	upgradeCommands := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade resources managed by stork",
+	}
+
+	upgradeCommands.AddCommand(
+		newUpgradeBackupLayoutCommand(cmdFactory, ioStreams),
+	)
+
+	return upgradeCommands
+}
+
+func newUpgradeBackupLayoutCommand(cmdFactory Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	upgradeBackupLayoutCommand := &cobra.Command{
+		Use:     backupLayoutSubcommand + " [applicationbackups]",
+		Aliases: backupLayoutAliases,
+		Short:   "Upgrade the layout of applicationbackups in their backup location",
+		Long: "Upgrade the layout of completed applicationbackups in their backup location to the latest version.\n" +
+			"The upgrade is done by stork in the background. All the applicationbackups in the namespace are\n" +
+			"upgraded if none are provided.",
+		Run: func(c *cobra.Command, args []string) {
+			namespaces, err := cmdFactory.GetAllNamespaces()
+			if err != nil {
+				util.CheckErr(err)
+				return
+			}
+			backups := make([]storkv1.ApplicationBackup, 0)
+			for _, ns := range namespaces {
+				if len(args) > 0 {
+					for _, name := range args {
+						backup, err := storkops.Instance().GetApplicationBackup(name, ns)
+						if err != nil {
+							util.CheckErr(err)
+							return
+						}
+						backups = append(backups, *backup)
+					}
+					continue
+				}
+				backupList, err := storkops.Instance().ListApplicationBackups(ns, metav1.ListOptions{})
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+				backups = append(backups, backupList.Items...)
+			}
+			if len(backups) == 0 {
+				handleEmptyList(ioStreams.Out)
+				return
+			}
+			for i := range backups {
+				msg, err := requestLayoutUpgrade(&backups[i])
+				if err != nil {
+					util.CheckErr(err)
+					return
+				}
+				printMsg(msg, ioStreams.Out)
+			}
+		},
+	}
+
+	return upgradeBackupLayoutCommand
+}
+
+// requestLayoutUpgrade sets the upgrade annotation on the backup if it is
+// complete and doesn't use the latest layout
+func requestLayoutUpgrade(backup *storkv1.ApplicationBackup) (string, error) {
+	if backup.Status.Status != storkv1.ApplicationBackupStatusSuccessful &&
+		backup.Status.Status != storkv1.ApplicationBackupStatusPartialSuccess {
+		return fmt.Sprintf("ApplicationBackup %v isn't complete, skipping", backup.Name), nil
+	}
+	version, err := strconv.Atoi(backup.Annotations[storkv1.ApplicationBackupLayoutVersionAnnotation])
+	if err == nil && version >= backuplayout.CurrentVersion {
+		return fmt.Sprintf("ApplicationBackup %v already uses layout version %v", backup.Name, version), nil
+	}
+	if backup.Annotations == nil {
+		backup.Annotations = make(map[string]string)
+	}
+	backup.Annotations[storkv1.ApplicationBackupUpgradeLayoutAnnotation] = "true"
+	if _, err := storkops.Instance().UpdateApplicationBackup(backup); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ApplicationBackup %v layout upgrade requested successfully", backup.Name), nil
+}
@@ -0,0 +1,66 @@
+//go:build unittest
+// +build unittest
+
+package storkctl
+
+import (
+	"testing"
+
+	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpgradeBackupLayoutNoBackups(t *testing.T) {
+	cmdArgs := []string{"upgrade", "backuplayout", "-n", "test"}
+
+	expected := "No resources found.\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+}
+
+func TestUpgradeBackupLayoutMissingBackup(t *testing.T) {
+	cmdArgs := []string{"upgrade", "backuplayout", "missing", "-n", "test"}
+
+	expected := "Error from server (NotFound): applicationbackups.stork.libopenstorage.org \"missing\" not found"
+	testCommon(t, cmdArgs, nil, expected, true)
+}
+
+func TestUpgradeBackupLayout(t *testing.T) {
+	defer resetTest()
+	for _, backup := range []*storkv1.ApplicationBackup{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test"},
+			Status:     storkv1.ApplicationBackupStatus{Status: storkv1.ApplicationBackupStatusSuccessful},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "current",
+				Namespace:   "test",
+				Annotations: map[string]string{storkv1.ApplicationBackupLayoutVersionAnnotation: "2"},
+			},
+			Status: storkv1.ApplicationBackupStatus{Status: storkv1.ApplicationBackupStatusSuccessful},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "inprogress", Namespace: "test"},
+			Status:     storkv1.ApplicationBackupStatus{Status: storkv1.ApplicationBackupStatusInProgress},
+		},
+	} {
+		_, err := storkops.Instance().CreateApplicationBackup(backup)
+		require.NoError(t, err, "Error creating backup")
+	}
+
+	cmdArgs := []string{"upgrade", "backuplayout", "-n", "test"}
+	expected := "ApplicationBackup current already uses layout version 2\n" +
+		"ApplicationBackup inprogress isn't complete, skipping\n" +
+		"ApplicationBackup legacy layout upgrade requested successfully\n"
+	testCommon(t, cmdArgs, nil, expected, false)
+
+	backup, err := storkops.Instance().GetApplicationBackup("legacy", "test")
+	require.NoError(t, err, "Error getting backup")
+	require.Equal(t, "true", backup.Annotations[storkv1.ApplicationBackupUpgradeLayoutAnnotation])
+
+	backup, err = storkops.Instance().GetApplicationBackup("current", "test")
+	require.NoError(t, err, "Error getting backup")
+	require.NotContains(t, backup.Annotations, storkv1.ApplicationBackupUpgradeLayoutAnnotation)
+}
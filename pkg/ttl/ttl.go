@@ -0,0 +1,158 @@
+package ttl
+
+import (
+	"os"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/controllers"
+	"github.com/libopenstorage/stork/pkg/storkconfig"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// finishedObject is implemented by the operations that can be deleted once
+// they have been finished for their TTL
+type finishedObject interface {
+	metav1.Object
+	IsTerminal() bool
+	GetTTLSecondsAfterFinished() *int32
+	GetFinishTime() metav1.Time
+}
+
+// expirable is an operation along with the function that deletes it
+type expirable struct {
+	kind   string
+	object finishedObject
+	delete func(name, namespace string) error
+}
+
+// Janitor periodically deletes the migrations, backups, restores and
+// snapshot restores that have been finished for longer than their TTL, so
+// that finished operations don't pile up in etcd
+type Janitor struct {
+	// Interval at which the operations are checked
+	Interval time.Duration
+	// Namespaces the janitor is restricted to. All namespaces are checked
+	// if it is empty.
+	Namespaces  []string
+	stopChannel chan os.Signal
+}
+
+// Start starts deleting expired operations in the background
+func (j *Janitor) Start(stopChannel chan os.Signal) {
+	j.stopChannel = stopChannel
+	go j.run()
+}
+
+func (j *Janitor) run() {
+	for {
+		select {
+		case <-time.After(j.Interval):
+			if err := j.check(); err != nil {
+				logrus.Errorf("Error deleting operations whose TTL expired: %v", err)
+			}
+		case <-j.stopChannel:
+			return
+		}
+	}
+}
+
+// GetExpiry returns the time after which the finished operation is deleted.
+// Returns false if the operation hasn't finished or doesn't have a TTL. The
+// default TTL from the stork configuration doesn't apply to operations that
+// have an owner, since they are pruned by their owner, or to backups, since
+// expiring them could delete the backed up data. Backups synced from a
+// BackupLocation never expire since they would be synced again.
+func GetExpiry(object finishedObject) (time.Time, bool) {
+	return getExpiry(object, storkconfig.GetTTLSecondsAfterFinished())
+}
+
+func getExpiry(object finishedObject, defaultTTL *int32) (time.Time, bool) {
+	if !object.IsTerminal() {
+		return time.Time{}, false
+	}
+	if _, ok := object.GetAnnotations()[stork_api.ApplicationBackupSyncedFromAnnotation]; ok {
+		return time.Time{}, false
+	}
+	ttl := object.GetTTLSecondsAfterFinished()
+	if ttl == nil && len(object.GetOwnerReferences()) == 0 {
+		if _, isBackup := object.(*stork_api.ApplicationBackup); !isBackup {
+			ttl = defaultTTL
+		}
+	}
+	if ttl == nil || *ttl < 0 {
+		return time.Time{}, false
+	}
+	finishTime := object.GetFinishTime()
+	if finishTime.IsZero() {
+		return time.Time{}, false
+	}
+	return finishTime.Add(time.Duration(*ttl) * time.Second), true
+}
+
+func (j *Janitor) check() error {
+	objects := make([]expirable, 0)
+	for _, ns := range controllers.NamespacesToList(j.Namespaces) {
+		nsObjects, err := list(ns)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, nsObjects...)
+	}
+	now := time.Now()
+	for _, o := range objects {
+		if o.object.GetDeletionTimestamp() != nil {
+			continue
+		}
+		expiry, ok := GetExpiry(o.object)
+		if !ok || now.Before(expiry) {
+			continue
+		}
+		if err := o.delete(o.object.GetName(), o.object.GetNamespace()); err != nil && !errors.IsNotFound(err) {
+			logrus.Errorf("Error deleting %v %v/%v whose TTL expired: %v",
+				o.kind, o.object.GetNamespace(), o.object.GetName(), err)
+			continue
+		}
+		logrus.Infof("Deleted %v %v/%v since it finished at %v and its TTL expired",
+			o.kind, o.object.GetNamespace(), o.object.GetName(), o.object.GetFinishTime().UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// list returns the operations from the namespace that can expire
+func list(namespace string) ([]expirable, error) {
+	objects := make([]expirable, 0)
+
+	migrations, err := storkops.Instance().ListMigrations(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range migrations.Items {
+		objects = append(objects, expirable{"Migration", &migrations.Items[i], storkops.Instance().DeleteMigration})
+	}
+	backups, err := storkops.Instance().ListApplicationBackups(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups.Items {
+		objects = append(objects, expirable{"ApplicationBackup", &backups.Items[i], storkops.Instance().DeleteApplicationBackup})
+	}
+	restores, err := storkops.Instance().ListApplicationRestores(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range restores.Items {
+		objects = append(objects, expirable{"ApplicationRestore", &restores.Items[i], storkops.Instance().DeleteApplicationRestore})
+	}
+	snapshotRestores, err := storkops.Instance().ListVolumeSnapshotRestore(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range snapshotRestores.Items {
+		objects = append(objects, expirable{"VolumeSnapshotRestore", &snapshotRestores.Items[i], storkops.Instance().DeleteVolumeSnapshotRestore})
+	}
+	return objects, nil
+}
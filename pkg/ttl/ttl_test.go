@@ -0,0 +1,109 @@
+//go:build unittest
+// +build unittest
+
+package ttl
+
+import (
+	"testing"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakekubeclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func backup(name string, stage stork_api.ApplicationBackupStageType, ttl *int32, finished time.Time) *stork_api.ApplicationBackup {
+	return &stork_api.ApplicationBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       stork_api.ApplicationBackupSpec{TTLSecondsAfterFinished: ttl},
+		Status: stork_api.ApplicationBackupStatus{
+			Stage:           stage,
+			Status:          stork_api.ApplicationBackupStatusSuccessful,
+			FinishTimestamp: metav1.NewTime(finished),
+		},
+	}
+}
+
+func TestGetExpiry(t *testing.T) {
+	finished := time.Now().Add(-time.Hour)
+	expiry, ok := GetExpiry(backup("backup", stork_api.ApplicationBackupStageFinal, int32Ptr(60), finished))
+	require.True(t, ok)
+	require.Equal(t, finished.Add(time.Minute).Unix(), expiry.Unix())
+
+	_, ok = GetExpiry(backup("backup", stork_api.ApplicationBackupStageVolumes, int32Ptr(60), finished))
+	require.False(t, ok, "in progress operations don't expire")
+	_, ok = GetExpiry(backup("backup", stork_api.ApplicationBackupStageFinal, nil, finished))
+	require.False(t, ok, "operations without a TTL don't expire")
+	_, ok = getExpiry(backup("backup", stork_api.ApplicationBackupStageFinal, nil, finished), int32Ptr(60))
+	require.False(t, ok, "the default TTL doesn't apply to backups")
+	migration := &stork_api.Migration{
+		Status: stork_api.MigrationStatus{
+			Stage:           stork_api.MigrationStageFinal,
+			Status:          stork_api.MigrationStatusSuccessful,
+			FinishTimestamp: metav1.NewTime(finished),
+		},
+	}
+	_, ok = getExpiry(migration, int32Ptr(60))
+	require.True(t, ok, "the default TTL applies to migrations")
+	synced := backup("backup", stork_api.ApplicationBackupStageFinal, int32Ptr(60), finished)
+	synced.Annotations = map[string]string{stork_api.ApplicationBackupSyncedFromAnnotation: "location"}
+	_, ok = GetExpiry(synced)
+	require.False(t, ok, "synced backups don't expire")
+
+	// Snapshot restores use the time the Completed condition was set
+	restore := &stork_api.VolumeSnapshotRestore{
+		Spec:   stork_api.VolumeSnapshotRestoreSpec{TTLSecondsAfterFinished: int32Ptr(0)},
+		Status: stork_api.VolumeSnapshotRestoreStatus{Status: stork_api.VolumeSnapshotRestoreStatusSuccessful},
+	}
+	_, ok = GetExpiry(restore)
+	require.False(t, ok)
+	restore.SetCompletedCondition()
+	expiry, ok = GetExpiry(restore)
+	require.True(t, ok)
+	require.WithinDuration(t, time.Now(), expiry, time.Minute)
+}
+
+func TestCheck(t *testing.T) {
+	fakeStorkClient := fakestorkclient.NewSimpleClientset(
+		backup("expired", stork_api.ApplicationBackupStageFinal, int32Ptr(60), time.Now().Add(-time.Hour)),
+		backup("not-expired", stork_api.ApplicationBackupStageFinal, int32Ptr(7200), time.Now().Add(-time.Hour)),
+		backup("in-progress", stork_api.ApplicationBackupStageVolumes, int32Ptr(60), time.Now().Add(-time.Hour)),
+		&stork_api.Migration{
+			ObjectMeta: metav1.ObjectMeta{Name: "migration", Namespace: "ns"},
+			Spec:       stork_api.MigrationSpec{TTLSecondsAfterFinished: int32Ptr(0)},
+			Status: stork_api.MigrationStatus{
+				Stage:           stork_api.MigrationStageFinal,
+				Status:          stork_api.MigrationStatusFailed,
+				FinishTimestamp: metav1.Now(),
+			},
+		},
+	)
+	storkops.SetInstance(storkops.New(fakekubeclient.NewSimpleClientset(), fakeStorkClient, nil))
+
+	// Operations in namespaces the janitor isn't restricted to are kept
+	j := &Janitor{Namespaces: []string{"other"}}
+	require.NoError(t, j.check())
+	backups, err := storkops.Instance().ListApplicationBackups("ns", metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, backups.Items, 3)
+
+	j = &Janitor{Namespaces: []string{"ns"}}
+	require.NoError(t, j.check())
+	backups, err = storkops.Instance().ListApplicationBackups("ns", metav1.ListOptions{})
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, b := range backups.Items {
+		names = append(names, b.Name)
+	}
+	require.ElementsMatch(t, []string{"not-expired", "in-progress"}, names)
+	migrations, err := storkops.Instance().ListMigrations("ns")
+	require.NoError(t, err)
+	require.Empty(t, migrations.Items)
+}
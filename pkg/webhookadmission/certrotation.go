@@ -0,0 +1,157 @@
+package webhookadmission
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8serr "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// prevCert is the key in the certificate secret for the certificate
+	// that was replaced by the last rotation. It stays in the CA bundles
+	// until it expires so that the replicas that haven't picked up the new
+	// certificate yet are still trusted.
+	prevCert = "prevCert"
+	// defaultCertLifetime is the lifetime of the generated certificates if
+	// none is configured
+	defaultCertLifetime = 365 * 24 * time.Hour
+	// certCheckInterval is the interval at which the certificate secret is
+	// checked for rotations
+	certCheckInterval = time.Hour
+)
+
+func (c *Controller) getDNSName() string {
+	return storkService + "." + c.namespace + ".svc"
+}
+
+// getCertificate returns the current serving certificate
+func (c *Controller) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.certLock.RLock()
+	defer c.certLock.RUnlock()
+	return c.tlsCert, nil
+}
+
+// getCABundle returns the CA bundle for the webhook configurations
+func (c *Controller) getCABundle() []byte {
+	c.certLock.RLock()
+	defer c.certLock.RUnlock()
+	return c.caBundle
+}
+
+// loadCertificate starts serving the certificate in the secret. It returns
+// true if the CA bundle changed.
+func (c *Controller) loadCertificate(secret *v1.Secret) (bool, error) {
+	key, ok := secret.Data[privKey]
+	if !ok {
+		return false, fmt.Errorf("invalid secret key data")
+	}
+	cert, ok := secret.Data[privCert]
+	if !ok {
+		return false, fmt.Errorf("invalid secret certificate")
+	}
+	tlsCert, err := GetTLSCertificate(cert, key)
+	if err != nil {
+		return false, err
+	}
+	certificate, err := x509.ParseCertificate(tlsCert.Certificate[0])
+	if err != nil {
+		return false, err
+	}
+	caBundle := cert
+	if previous, ok := secret.Data[prevCert]; ok && !isExpired(previous) {
+		caBundle = append(append([]byte{}, cert...), previous...)
+	}
+
+	c.certLock.Lock()
+	defer c.certLock.Unlock()
+	changed := !bytes.Equal(c.caBundle, caBundle)
+	c.tlsCert = &tlsCert
+	c.certificate = certificate
+	c.caBundle = caBundle
+	return changed, nil
+}
+
+// isExpired returns true if the PEM encoded certificate has expired or can't
+// be parsed
+func isExpired(pemCert []byte) bool {
+	block, _ := pem.Decode(pemCert)
+	if block == nil {
+		return true
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(certificate.NotAfter)
+}
+
+// needsRotation returns true once less than a third of the lifetime of the
+// certificate is left
+func needsRotation(certificate *x509.Certificate, lifetime time.Duration) bool {
+	return time.Until(certificate.NotAfter) < lifetime/3
+}
+
+// rotateCertificates checks the certificate periodically until stopped
+func (c *Controller) rotateCertificates(stopChan chan struct{}) {
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-time.After(certCheckInterval):
+			if err := c.checkCertificate(true); err != nil {
+				log.Errorf("Error checking webhook certificate: %v", err)
+			}
+		}
+	}
+}
+
+// checkCertificate picks up certificates rotated by other replicas and
+// rotates the certificate if it is about to expire. The webhook
+// configurations are updated with the new CA bundle if configure is set.
+func (c *Controller) checkCertificate(configure bool) error {
+	secret, err := core.Instance().GetSecret(secretName, c.namespace)
+	if err != nil {
+		return err
+	}
+	changed, err := c.loadCertificate(secret)
+	if err != nil {
+		return err
+	}
+	c.certLock.RLock()
+	rotate := needsRotation(c.certificate, c.CertLifetime)
+	c.certLock.RUnlock()
+	if rotate {
+		cert, key, err := GenerateCertificateWithLifetime("Stork CA", c.getDNSName(), c.CertLifetime)
+		if err != nil {
+			return err
+		}
+		secret.Data[prevCert] = secret.Data[privCert]
+		secret.Data[privCert] = cert
+		secret.Data[privKey] = key
+		// The update fails with a conflict if another replica rotated the
+		// certificate first, which is picked up on the next check
+		secret, err = core.Instance().UpdateSecret(secret)
+		if err != nil {
+			if k8serr.IsConflict(err) {
+				return nil
+			}
+			return err
+		}
+		log.Infof("Rotated webhook certificate")
+		if changed, err = c.loadCertificate(secret); err != nil {
+			return err
+		}
+	}
+	if configure && changed {
+		return c.configureWebhooks(c.getCABundle())
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/libopenstorage/stork/pkg/apis/stork/v1alpha2"
+	log "github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+const (
+	// convertWebHook is the path for the webhook that converts stork CRs
+	// between the v1alpha1 and v1alpha2 versions
+	convertWebHook = "/convert"
+)
+
+var (
+	convertWebhookPath = convertWebHook
+	conversionScheme   = runtime.NewScheme()
+	conversionCodecs   = serializer.NewCodecFactory(conversionScheme)
+)
+
+func init() {
+	utilruntime.Must(stork_api.AddToScheme(conversionScheme))
+	utilruntime.Must(v1alpha2.AddToScheme(conversionScheme))
+}
+
+// processConvertRequest converts the objects in a ConversionReview sent by
+// the API server to the desired version
+func (c *Controller) processConvertRequest(w http.ResponseWriter, req *http.Request) {
+	conversionReview := apiextensionsv1.ConversionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&conversionReview); err != nil || conversionReview.Request == nil {
+		log.Errorf("Error decoding conversion review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	conversionRequest := conversionReview.Request
+	conversionResponse := &apiextensionsv1.ConversionResponse{
+		UID: conversionRequest.UID,
+		Result: metav1.Status{
+			Status: metav1.StatusSuccess,
+		},
+	}
+	for _, object := range conversionRequest.Objects {
+		converted, err := convertObject(object.Raw, conversionRequest.DesiredAPIVersion)
+		if err != nil {
+			log.Errorf("Error converting object to %v: %v", conversionRequest.DesiredAPIVersion, err)
+			conversionResponse.ConvertedObjects = nil
+			conversionResponse.Result = metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: err.Error(),
+			}
+			break
+		}
+		conversionResponse.ConvertedObjects = append(conversionResponse.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+
+	conversionReview.Request = nil
+	conversionReview.Response = conversionResponse
+	resp, err := json.Marshal(conversionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// convertObject converts the serialized object to the desired version
+func convertObject(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	desired, err := schema.ParseGroupVersion(desiredAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	obj, gvk, err := conversionCodecs.UniversalDeserializer().Decode(raw, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if gvk.GroupVersion() == desired {
+		return raw, nil
+	}
+
+	var converted runtime.Object
+	switch desired {
+	case v1alpha2.SchemeGroupVersion:
+		converted, err = v1alpha2.ConvertFromV1alpha1(obj)
+	case stork_api.SchemeGroupVersion:
+		converted, err = v1alpha2.ConvertToV1alpha1(obj)
+	default:
+		err = fmt.Errorf("unsupported version %v", desiredAPIVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+	converted.GetObjectKind().SetGroupVersionKind(desired.WithKind(gvk.Kind))
+	return json.Marshal(converted)
+}
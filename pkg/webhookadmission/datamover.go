@@ -0,0 +1,290 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	kdmpdrivers "github.com/portworx/kdmp/pkg/drivers"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/k8s/storage"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// dataMoverWebHook is the path for the webhook that adds node affinity
+	// to the pods of the data mover jobs
+	dataMoverWebHook = "/datamover"
+	// dataMoverWebhookName is the name of the webhook that adds node
+	// affinity to the pods of the data mover jobs
+	dataMoverWebhookName = "datamover.stork.libopenstorage.org"
+	// nodeNameField is the field selecting nodes by their name
+	nodeNameField = "metadata.name"
+	// dataNodeWeight is the weight of the preference for the nodes that hold
+	// the data of a volume
+	dataNodeWeight = 10
+)
+
+var (
+	dataMoverWebhookPath = dataMoverWebHook
+	dataMoverResources   = []string{"pods"}
+	// dataMoverSelector selects the pods of the jobs created by the KDMP
+	// data mover drivers
+	dataMoverSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      kdmpdrivers.DriverNameLabel,
+				Operator: metav1.LabelSelectorOpExists,
+			},
+		},
+	}
+)
+
+// processDataMoverRequest adds node affinity to the pods of the KDMP data
+// mover jobs so that they run on the node where their PVCs are attached.
+// This avoids multi-attach errors for ReadWriteOnce volumes and moving the
+// data across zones. Volumes with topology constraints, like cloud disks, are
+// already restricted to their zone by the node affinity of their PVs.
+func (c *Controller) processDataMoverRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	patch, err := c.getDataMoverPatch(arReq)
+	if err != nil {
+		// The scheduler places the pod if the affinity can't be added
+		log.Errorf("Error adding node affinity for data mover pod %v/%v: %v", arReq.Namespace, arReq.Name, err)
+		admissionResponse.Result = &metav1.Status{
+			Message: fmt.Sprintf("error adding node affinity: %v", err),
+		}
+	} else if patch != nil {
+		patchType := v1beta1.PatchTypeJSONPatch
+		admissionResponse.Patch = patch
+		admissionResponse.PatchType = &patchType
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// getDataMoverPatch returns the patch adding the node affinity to the data
+// mover pod in the request. It returns nil if the pod doesn't need it.
+func (c *Controller) getDataMoverPatch(arReq *v1beta1.AdmissionRequest) ([]byte, error) {
+	if arReq.Kind.Kind != "Pod" {
+		return nil, nil
+	}
+	pod := &v1.Pod{}
+	if err := json.Unmarshal(arReq.Object.Raw, pod); err != nil {
+		return nil, err
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = arReq.Namespace
+	}
+	if _, ok := pod.Labels[kdmpdrivers.DriverNameLabel]; !ok {
+		return nil, nil
+	}
+	// Leave the pods that are already pinned by the data mover alone
+	if pod.Spec.NodeName != "" || (pod.Spec.Affinity != nil && pod.Spec.Affinity.NodeAffinity != nil) {
+		return nil, nil
+	}
+
+	nodeAffinity, err := c.getDataMoverNodeAffinity(pod)
+	if err != nil || nodeAffinity == nil {
+		return nil, err
+	}
+	log.Infof("Adding node affinity for data mover pod %v/%v: %v", pod.Namespace, pod.Name, nodeAffinity)
+	patch := make([]map[string]interface{}, 0)
+	if pod.Spec.Affinity == nil {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/spec/affinity",
+			"value": &v1.Affinity{NodeAffinity: nodeAffinity},
+		})
+	} else {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/spec/affinity/nodeAffinity",
+			"value": nodeAffinity,
+		})
+	}
+	return json.Marshal(patch)
+}
+
+// getDataMoverNodeAffinity returns the node affinity for the pod based on
+// where its PVCs are attached. The pod is required to run on the node of an
+// attached ReadWriteOnce PVC, since the volume can't be attached anywhere
+// else, and prefers the nodes of the other PVCs. PVCs that aren't attached
+// prefer the nodes that hold their data if the driver owns them.
+func (c *Controller) getDataMoverNodeAffinity(pod *v1.Pod) (*v1.NodeAffinity, error) {
+	var required string
+	preferred := make([]string, 0)
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := core.Instance().GetPersistentVolumeClaim(vol.PersistentVolumeClaim.ClaimName, pod.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if pvc.Status.Phase != v1.ClaimBound {
+			continue
+		}
+		node, err := getAttachedNode(pvc)
+		if err != nil {
+			return nil, err
+		}
+		if node == "" {
+			dataNodes, err := c.getDataNodes(pvc)
+			if err != nil {
+				return nil, err
+			}
+			preferred = append(preferred, dataNodes...)
+			continue
+		}
+		if isReadWriteOnce(pvc) {
+			if required != "" && required != node {
+				return nil, fmt.Errorf("ReadWriteOnce PVCs used by the pod are attached to different nodes: %v and %v", required, node)
+			}
+			required = node
+		} else {
+			preferred = append(preferred, node)
+		}
+	}
+
+	if required != "" {
+		return &v1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{
+					{
+						MatchFields: []v1.NodeSelectorRequirement{
+							{
+								Key:      nodeNameField,
+								Operator: v1.NodeSelectorOpIn,
+								Values:   []string{required},
+							},
+						},
+					},
+				},
+			},
+		}, nil
+	}
+	if len(preferred) == 0 {
+		return nil, nil
+	}
+	return &v1.NodeAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []v1.PreferredSchedulingTerm{
+			{
+				Weight: dataNodeWeight,
+				Preference: v1.NodeSelectorTerm{
+					MatchFields: []v1.NodeSelectorRequirement{
+						{
+							Key:      nodeNameField,
+							Operator: v1.NodeSelectorOpIn,
+							Values:   preferred,
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// getAttachedNode returns the node where the volume of the PVC is attached,
+// using the VolumeAttachments of CSI volumes and the running pods using the
+// PVC for the other volumes. It returns an empty string if the volume isn't
+// attached.
+func getAttachedNode(pvc *v1.PersistentVolumeClaim) (string, error) {
+	attachments, err := storage.Instance().ListVolumeAttachments()
+	if err != nil {
+		return "", err
+	}
+	for _, attachment := range attachments.Items {
+		if attachment.Spec.Source.PersistentVolumeName != nil &&
+			*attachment.Spec.Source.PersistentVolumeName == pvc.Spec.VolumeName &&
+			attachment.Status.Attached {
+			return attachment.Spec.NodeName, nil
+		}
+	}
+
+	pods, err := core.Instance().GetPodsUsingPVC(pvc.Name, pvc.Namespace)
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" && pod.Status.Phase == v1.PodRunning {
+			return pod.Spec.NodeName, nil
+		}
+	}
+	return "", nil
+}
+
+// getDataNodes returns the names of the online nodes that hold the data of
+// the volume of the PVC if it is owned by the driver
+func (c *Controller) getDataNodes(pvc *v1.PersistentVolumeClaim) ([]string, error) {
+	if c.Driver == nil || !c.Driver.OwnsPVC(core.Instance(), pvc) {
+		return nil, nil
+	}
+	podSpec := &v1.PodSpec{
+		Volumes: []v1.Volume{{
+			Name: pvc.Name,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name},
+			},
+		}},
+	}
+	volumes, _, err := c.Driver.GetPodVolumes(podSpec, pvc.Namespace, false)
+	if err != nil || len(volumes) == 0 {
+		return nil, err
+	}
+	driverNodes, err := c.Driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]string, 0)
+	for _, dataNode := range volumes[0].DataNodes {
+		for _, node := range driverNodes {
+			if node.StorageID != dataNode || node.Status != volume.NodeOnline {
+				continue
+			}
+			if node.SchedulerID != "" {
+				nodes = append(nodes, node.SchedulerID)
+			} else {
+				nodes = append(nodes, node.Hostname)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+func isReadWriteOnce(pvc *v1.PersistentVolumeClaim) bool {
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode != v1.ReadWriteOnce {
+			return false
+		}
+	}
+	return len(pvc.Spec.AccessModes) > 0
+}
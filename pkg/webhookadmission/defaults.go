@@ -0,0 +1,227 @@
+package webhookadmission
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// defaultWebHook is the path for the webhook that sets the defaults on
+	// stork CRs
+	defaultWebHook = "/default"
+	// defaultWebhookName is the name of the webhook that sets the defaults
+	// on stork CRs
+	defaultWebhookName = "defaults.stork.libopenstorage.org"
+)
+
+var (
+	defaultWebhookPath = defaultWebHook
+	defaultResources   = []string{
+		stork_api.ApplicationBackupResourcePlural,
+		stork_api.ApplicationBackupScheduleResourcePlural,
+		stork_api.ApplicationCloneResourcePlural,
+		stork_api.ApplicationRestoreResourcePlural,
+		stork_api.DRDrillResourcePlural,
+		stork_api.MigrationResourcePlural,
+		stork_api.MigrationScheduleResourcePlural,
+		stork_api.NamespacedSchedulePolicyResourcePlural,
+		stork_api.SchedulePolicyResourcePlural,
+		stork_api.VolumeSnapshotScheduleResourcePlural,
+	}
+)
+
+// defaultValue is the value set for a field if it isn't set in the object
+type defaultValue struct {
+	fields []string
+	value  interface{}
+}
+
+// processDefaultRequest sets the defaults on stork CRs when they are created
+// so that the objects show the values used by the controllers
+func (c *Controller) processDefaultRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	patch, err := getDefaultsPatch(arReq.Kind.Kind, arReq.Object.Raw)
+	if err != nil {
+		// Leave the object as it is, the controllers fall back to the
+		// defaults
+		log.Errorf("Error setting defaults for %v %v/%v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, err)
+		admissionResponse.Result = &metav1.Status{
+			Message: fmt.Sprintf("error setting defaults: %v", err),
+		}
+	} else if patch != nil {
+		patchType := v1beta1.PatchTypeJSONPatch
+		admissionResponse.Patch = patch
+		admissionResponse.PatchType = &patchType
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// getDefaults returns the defaults for the fields of the kind
+func getDefaults(kind string) []defaultValue {
+	migrationDefaults := func(fields ...string) []defaultValue {
+		return []defaultValue{
+			{append(fields, "includeVolumes"), true},
+			{append(fields, "includeResources"), true},
+			{append(fields, "startApplications"), false},
+			{append(fields, "purgeDeletedResources"), false},
+			{append(fields, "skipServiceUpdate"), false},
+		}
+	}
+	schedulePolicyDefaults := func(fields ...string) []defaultValue {
+		return []defaultValue{
+			{append(fields, "interval", "retain"), int64(stork_api.DefaultIntervalPolicyRetain)},
+			{append(fields, "daily", "retain"), int64(stork_api.DefaultDailyPolicyRetain)},
+			{append(fields, "weekly", "retain"), int64(stork_api.DefaultWeeklyPolicyRetain)},
+			{append(fields, "monthly", "retain"), int64(stork_api.DefaultMonthlyPolicyRetain)},
+		}
+	}
+
+	switch kind {
+	case reflect.TypeOf(stork_api.ApplicationBackup{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "reclaimPolicy"}, string(stork_api.ApplicationBackupReclaimPolicyDelete)},
+		}
+	case reflect.TypeOf(stork_api.ApplicationBackupSchedule{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "suspend"}, false},
+			{[]string{"spec", "reclaimPolicy"}, string(stork_api.ReclaimPolicyRetain)},
+			{[]string{"spec", "template", "spec", "reclaimPolicy"}, string(stork_api.ApplicationBackupReclaimPolicyDelete)},
+		}
+	case reflect.TypeOf(stork_api.ApplicationClone{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "replacePolicy"}, string(stork_api.ApplicationCloneReplacePolicyRetain)},
+		}
+	case reflect.TypeOf(stork_api.ApplicationRestore{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "replacePolicy"}, string(stork_api.ApplicationRestoreReplacePolicyRetain)},
+			{[]string{"spec", "volumeDataSourcePolicy"}, string(stork_api.ApplicationRestoreVolumeDataSourcePolicyRestore)},
+		}
+	case reflect.TypeOf(stork_api.DRDrill{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "suspend"}, false},
+			{[]string{"spec", "healthCheckTimeout"}, stork_api.DefaultDRDrillHealthCheckTimeout.String()},
+			{[]string{"spec", "reportsToKeep"}, int64(stork_api.DefaultDRDrillReportsToKeep)},
+		}
+	case reflect.TypeOf(stork_api.Migration{}).Name():
+		return migrationDefaults("spec")
+	case reflect.TypeOf(stork_api.MigrationSchedule{}).Name():
+		return append([]defaultValue{
+			{[]string{"spec", "suspend"}, false},
+		}, migrationDefaults("spec", "template", "spec")...)
+	case reflect.TypeOf(stork_api.SchedulePolicy{}).Name(),
+		reflect.TypeOf(stork_api.NamespacedSchedulePolicy{}).Name():
+		return schedulePolicyDefaults("policy")
+	case reflect.TypeOf(stork_api.VolumeSnapshotSchedule{}).Name():
+		return []defaultValue{
+			{[]string{"spec", "suspend"}, false},
+			{[]string{"spec", "reclaimPolicy"}, string(stork_api.ReclaimPolicyDelete)},
+		}
+	}
+	return nil
+}
+
+// getDefaultsPatch returns a JSON patch that sets the defaults for the fields
+// that aren't set in the object. Returns nil if no fields need to be set.
+func getDefaultsPatch(kind string, raw []byte) ([]byte, error) {
+	object := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	// Keep large integers intact in the patched spec
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	defaulted := false
+	for _, d := range getDefaults(kind) {
+		parent := d.fields[:len(d.fields)-1]
+		// Only set defaults for the policies and templates that are used
+		if len(parent) > 1 {
+			if _, found, _ := unstructured.NestedMap(object, parent...); !found {
+				continue
+			}
+		}
+		value, found, _ := unstructured.NestedFieldNoCopy(object, d.fields...)
+		if found && !isUnset(value) {
+			continue
+		}
+		if err := unstructured.SetNestedField(object, d.value, d.fields...); err != nil {
+			return nil, err
+		}
+		defaulted = true
+	}
+	if kind == reflect.TypeOf(stork_api.ApplicationRestore{}).Name() {
+		sandbox, _, _ := unstructured.NestedBool(object, "spec", "sandbox")
+		if ttl, _, _ := unstructured.NestedFieldNoCopy(object, "spec", "sandboxTTL"); sandbox && isUnset(ttl) {
+			if err := unstructured.SetNestedField(object, stork_api.DefaultSandboxTTL.String(), "spec", "sandboxTTL"); err != nil {
+				return nil, err
+			}
+			defaulted = true
+		}
+	}
+	if !defaulted {
+		return nil, nil
+	}
+
+	// The whole spec is replaced since the parents of the defaulted fields
+	// might not exist
+	patch := make([]map[string]interface{}, 0)
+	for _, field := range []string{"spec", "policy"} {
+		if value, ok := object[field]; ok {
+			patch = append(patch, map[string]interface{}{
+				"op":    "add",
+				"path":  "/" + field,
+				"value": value,
+			})
+		}
+	}
+	return json.Marshal(patch)
+}
+
+// isUnset returns true if the value is the zero value that the controllers
+// replace with the default
+func isUnset(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int64:
+		return v == 0
+	case json.Number:
+		return v.String() == "0"
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	"github.com/portworx/sched-ops/k8s/core"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// validateDeletionWebHook is the path for the webhook that blocks
+	// deleting stork CRs that are still in use
+	validateDeletionWebHook = "/validate-deletion"
+	// validateDeletionWebhookName is the name of the webhook that blocks
+	// deleting stork CRs that are still in use
+	validateDeletionWebhookName = "deletion.stork.libopenstorage.org"
+	// SkipDeletionProtectionAnnotation can be set to true on a stork CR to
+	// allow deleting it while it is still referenced
+	SkipDeletionProtectionAnnotation = "stork.libopenstorage.org/skip-deletion-protection"
+)
+
+// referrer is a stork object referencing the object being deleted
+type referrer struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+func (r referrer) String() string {
+	return fmt.Sprintf("%v %v/%v", r.kind, r.namespace, r.name)
+}
+
+// processDeletionRequest denies deleting BackupLocations, ClusterPairs and
+// schedule policies that are still referenced by active schedules, or by
+// backups and migrations that are in progress
+func (c *Controller) processDeletionRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	message, denied, err := c.checkDeletion(arReq)
+	if err != nil {
+		// Don't block deleting objects if the references can't be listed
+		log.Errorf("Error checking references to %v %v/%v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, err)
+	} else if denied {
+		log.Warnf("Denying deletion of %v %v/%v by %v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, arReq.UserInfo.Username, message)
+		admissionResponse = &v1beta1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: message,
+				Reason:  metav1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+			},
+			Allowed: false,
+		}
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// checkDeletion returns true along with the reason if the object being
+// deleted is still referenced
+func (c *Controller) checkDeletion(arReq *v1beta1.AdmissionRequest) (string, bool, error) {
+	if arReq.Operation != v1beta1.Delete {
+		return "", false, nil
+	}
+	if len(arReq.OldObject.Raw) != 0 {
+		var object metav1.PartialObjectMetadata
+		if err := json.Unmarshal(arReq.OldObject.Raw, &object); err != nil {
+			return "", false, err
+		}
+		if object.Annotations[SkipDeletionProtectionAnnotation] == "true" {
+			return "", false, nil
+		}
+	}
+	// Everything in a namespace that is being deleted has to go
+	if arReq.Namespace != "" {
+		ns, err := core.Instance().GetNamespace(arReq.Namespace)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", false, err
+		}
+		if err != nil || ns.DeletionTimestamp != nil {
+			return "", false, nil
+		}
+	}
+
+	referrers, err := c.getReferrers(arReq.Kind.Kind, arReq.Namespace, arReq.Name)
+	if err != nil {
+		return "", false, err
+	}
+	if len(referrers) == 0 {
+		return "", false, nil
+	}
+	names := make([]string, 0, len(referrers))
+	for _, r := range referrers {
+		names = append(names, r.String())
+	}
+	name := arReq.Name
+	if arReq.Namespace != "" {
+		name = arReq.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%v %v is still referenced by %v. Remove the references or set the %v annotation to true to delete it",
+		arReq.Kind.Kind, name, strings.Join(names, ", "), SkipDeletionProtectionAnnotation), true, nil
+}
+
+// getReferrers returns the active stork objects referencing the object
+func (c *Controller) getReferrers(kind string, namespace string, name string) ([]referrer, error) {
+	switch kind {
+	case "BackupLocation":
+		return getBackupLocationReferrers(namespace, name)
+	case "ClusterPair":
+		return c.getClusterPairReferrers(namespace, name)
+	case "SchedulePolicy":
+		referrers, err := getSchedulePolicyReferrers("", name)
+		if err != nil {
+			return nil, err
+		}
+		// Schedules use the namespaced policy with the same name if there
+		// is one
+		policies, err := storkops.Instance().ListNamespacedSchedulePolicies("", metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		shadowed := make(map[string]bool)
+		for _, policy := range policies.Items {
+			if policy.Name == name {
+				shadowed[policy.Namespace] = true
+			}
+		}
+		filtered := make([]referrer, 0, len(referrers))
+		for _, r := range referrers {
+			if !shadowed[r.namespace] {
+				filtered = append(filtered, r)
+			}
+		}
+		return filtered, nil
+	case "NamespacedSchedulePolicy":
+		return getSchedulePolicyReferrers(namespace, name)
+	}
+	return nil, nil
+}
+
+func getBackupLocationReferrers(namespace string, name string) ([]referrer, error) {
+	referrers := make([]referrer, 0)
+	isBackupLocation := func(spec stork_api.ApplicationBackupSpec, specNamespace string) bool {
+		backupLocationNamespace := spec.BackupLocationNamespace
+		if backupLocationNamespace == "" {
+			backupLocationNamespace = specNamespace
+		}
+		return spec.BackupLocation == name && backupLocationNamespace == namespace
+	}
+
+	schedules, err := storkops.Instance().ListApplicationBackupSchedules("", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules.Items {
+		if !isSuspended(schedule.Spec.Suspend) && isBackupLocation(schedule.Spec.Template.Spec, schedule.Namespace) {
+			referrers = append(referrers, referrer{"ApplicationBackupSchedule", schedule.Namespace, schedule.Name})
+		}
+	}
+	backups, err := storkops.Instance().ListApplicationBackups("", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups.Items {
+		if isBackupInProgress(backup.Status.Status) && isBackupLocation(backup.Spec, backup.Namespace) {
+			referrers = append(referrers, referrer{"ApplicationBackup", backup.Namespace, backup.Name})
+		}
+	}
+	return referrers, nil
+}
+
+func (c *Controller) getClusterPairReferrers(namespace string, name string) ([]referrer, error) {
+	// Admin cluster pairs can be used from any namespace
+	listNamespace := namespace
+	isAdmin := c.AdminNamespace != "" && namespace == c.AdminNamespace
+	if isAdmin {
+		listNamespace = ""
+	}
+	isClusterPair := func(spec stork_api.MigrationSpec, specNamespace string) bool {
+		return (spec.ClusterPair == name && specNamespace == namespace) ||
+			(isAdmin && spec.AdminClusterPair == name)
+	}
+
+	referrers := make([]referrer, 0)
+	schedules, err := storkops.Instance().ListMigrationSchedules(listNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules.Items {
+		if !isSuspended(schedule.Spec.Suspend) && isClusterPair(schedule.Spec.Template.Spec, schedule.Namespace) {
+			referrers = append(referrers, referrer{"MigrationSchedule", schedule.Namespace, schedule.Name})
+		}
+	}
+	migrations, err := storkops.Instance().ListMigrations(listNamespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range migrations.Items {
+		if isMigrationInProgress(migration.Status.Status) && isClusterPair(migration.Spec, migration.Namespace) {
+			referrers = append(referrers, referrer{"Migration", migration.Namespace, migration.Name})
+		}
+	}
+	return referrers, nil
+}
+
+// getSchedulePolicyReferrers returns the active schedules in the namespace
+// using the policy
+func getSchedulePolicyReferrers(namespace string, name string) ([]referrer, error) {
+	referrers := make([]referrer, 0)
+	backupSchedules, err := storkops.Instance().ListApplicationBackupSchedules(namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range backupSchedules.Items {
+		if !isSuspended(schedule.Spec.Suspend) && schedule.Spec.SchedulePolicyName == name {
+			referrers = append(referrers, referrer{"ApplicationBackupSchedule", schedule.Namespace, schedule.Name})
+		}
+	}
+	migrationSchedules, err := storkops.Instance().ListMigrationSchedules(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range migrationSchedules.Items {
+		if !isSuspended(schedule.Spec.Suspend) && schedule.Spec.SchedulePolicyName == name {
+			referrers = append(referrers, referrer{"MigrationSchedule", schedule.Namespace, schedule.Name})
+		}
+	}
+	snapshotSchedules, err := storkops.Instance().ListSnapshotSchedules(namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, schedule := range snapshotSchedules.Items {
+		if !isSuspended(schedule.Spec.Suspend) && schedule.Spec.SchedulePolicyName == name {
+			referrers = append(referrers, referrer{"VolumeSnapshotSchedule", schedule.Namespace, schedule.Name})
+		}
+	}
+	return referrers, nil
+}
+
+func isSuspended(suspend *bool) bool {
+	return suspend != nil && *suspend
+}
+
+func isBackupInProgress(status stork_api.ApplicationBackupStatusType) bool {
+	switch status {
+	case stork_api.ApplicationBackupStatusSuccessful,
+		stork_api.ApplicationBackupStatusPartialSuccess,
+		stork_api.ApplicationBackupStatusFailed,
+		stork_api.ApplicationBackupStatusCancelled:
+		return false
+	}
+	return true
+}
+
+func isMigrationInProgress(status stork_api.MigrationStatusType) bool {
+	switch status {
+	case stork_api.MigrationStatusSuccessful,
+		stork_api.MigrationStatusPartialSuccess,
+		stork_api.MigrationStatusFailed,
+		stork_api.MigrationStatusPurged:
+		return false
+	}
+	return true
+}
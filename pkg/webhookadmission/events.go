@@ -0,0 +1,144 @@
+package webhookadmission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// driverEventsWebHook is the path to which volume drivers push events
+	// when their snapshots, restores and migrations make progress
+	driverEventsWebHook = "/events"
+	// maxDriverEventSize is the maximum size of the body of an event
+	maxDriverEventSize = 4096
+)
+
+// driverEventResources are the resources of the kinds of stork objects for
+// which events can be pushed
+var driverEventResources = map[string]string{
+	volume.ProgressKindBackup:        stork_api.ApplicationBackupResourcePlural,
+	volume.ProgressKindRestore:       stork_api.ApplicationRestoreResourcePlural,
+	volume.ProgressKindMigration:     stork_api.MigrationResourcePlural,
+	volume.ProgressKindGroupSnapshot: stork_api.GroupVolumeSnapshotResourcePlural,
+}
+
+// DriverEvent is pushed by volume drivers when an operation for a stork
+// object makes progress or completes, e.g. when the snapshots of a backup are
+// done
+type DriverEvent struct {
+	// Kind of the stork object, e.g. ApplicationBackup
+	Kind string `json:"kind"`
+	// Namespace of the stork object
+	Namespace string `json:"namespace"`
+	// Name of the stork object
+	Name string `json:"name"`
+}
+
+// processDriverEventRequest reconciles the object of an event pushed by a
+// volume driver right away instead of waiting for it to be requeued. The
+// events only trigger a reconcile, the status is still read from the driver.
+// Drivers must authenticate with a service account token that is allowed to
+// get the object. Events are rejected with 503 by the replicas that aren't
+// the leader so that drivers retry them.
+func (c *Controller) processDriverEventRequest(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "Unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+	if !volume.HasProgressCallbacks() {
+		http.Error(w, "controllers aren't running on this replica", http.StatusServiceUnavailable)
+		return
+	}
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	event := DriverEvent{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxDriverEventSize)).Decode(&event); err != nil {
+		log.Errorf("Error decoding driver event: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+	if !volume.IsProgressKind(event.Kind) {
+		http.Error(w, fmt.Sprintf("unsupported kind %v", event.Kind), http.StatusBadRequest)
+		return
+	}
+	if event.Namespace == "" || event.Name == "" {
+		http.Error(w, "namespace and name are required", http.StatusBadRequest)
+		return
+	}
+	status, err := c.authorizeDriverEvent(req, &event)
+	if err != nil {
+		log.Warnf("Rejecting driver event for %v %v/%v: %v", event.Kind, event.Namespace, event.Name, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	log.Debugf("Received driver event for %v %v/%v", event.Kind, event.Namespace, event.Name)
+	volume.ReportProgress(event.Kind, event.Namespace, event.Name)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// authorizeDriverEvent checks that the bearer token of the request belongs to
+// a user that is allowed to get the object of the event. Returns the HTTP
+// status to reply with if it isn't.
+func (c *Controller) authorizeDriverEvent(req *http.Request, event *DriverEvent) (int, error) {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == req.Header.Get("Authorization") {
+		return http.StatusUnauthorized, fmt.Errorf("bearer token is required")
+	}
+	client := c.kubeClient
+	if client == nil {
+		var err error
+		if client, err = getAdmissionClient(); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	tokenReview, err := client.AuthenticationV1().TokenReviews().Create(context.TODO(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error reviewing token: %v", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return http.StatusUnauthorized, fmt.Errorf("invalid token")
+	}
+	user := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	accessReview, err := client.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: event.Namespace,
+				Verb:      "get",
+				Group:     stork_api.SchemeGroupVersion.Group,
+				Resource:  driverEventResources[event.Kind],
+				Name:      event.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("error reviewing access: %v", err)
+	}
+	if !accessReview.Status.Allowed {
+		return http.StatusForbidden, fmt.Errorf("user %v is not allowed to get %v %v/%v",
+			user.Username, driverEventResources[event.Kind], event.Namespace, event.Name)
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,80 @@
+//go:build unittest
+// +build unittest
+
+package webhookadmission
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newDriverEventController() *Controller {
+	kubeClient := fakek8s.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		switch review.Spec.Token {
+		case "driver-token":
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:driver"}
+		case "user-token":
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "user"}
+		}
+		return true, review, nil
+	})
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "system:serviceaccount:kube-system:driver" &&
+			review.Spec.ResourceAttributes.Resource == "applicationbackups"
+		return true, review, nil
+	})
+	return &Controller{DriverEvents: true, kubeClient: kubeClient}
+}
+
+func sendDriverEvent(c *Controller, method, token, body string) int {
+	req := httptest.NewRequest(method, driverEventsWebHook, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	c.serveHTTP(w, req)
+	return w.Code
+}
+
+func TestProcessDriverEventRequest(t *testing.T) {
+	c := newDriverEventController()
+	event := `{"kind": "ApplicationBackup", "namespace": "test", "name": "backup"}`
+	require.Equal(t, http.StatusServiceUnavailable, sendDriverEvent(c, http.MethodPost, "driver-token", event),
+		"events should be rejected until the controllers are running")
+
+	reported := make([]string, 0)
+	volume.OnProgress(func(kind, namespace, name string) {
+		reported = append(reported, kind+" "+namespace+"/"+name)
+	})
+
+	require.Equal(t, http.StatusMethodNotAllowed, sendDriverEvent(c, http.MethodGet, "driver-token", ""))
+	require.Equal(t, http.StatusBadRequest, sendDriverEvent(c, http.MethodPost, "driver-token", "{"))
+	require.Equal(t, http.StatusBadRequest, sendDriverEvent(c, http.MethodPost, "driver-token",
+		`{"kind": "Pod", "namespace": "test", "name": "pod"}`))
+	require.Equal(t, http.StatusBadRequest, sendDriverEvent(c, http.MethodPost, "driver-token",
+		`{"kind": "ApplicationBackup", "namespace": "test"}`))
+	require.Equal(t, http.StatusUnauthorized, sendDriverEvent(c, http.MethodPost, "", event))
+	require.Equal(t, http.StatusUnauthorized, sendDriverEvent(c, http.MethodPost, "invalid-token", event))
+	require.Equal(t, http.StatusForbidden, sendDriverEvent(c, http.MethodPost, "user-token", event))
+	require.Equal(t, http.StatusForbidden, sendDriverEvent(c, http.MethodPost, "driver-token",
+		`{"kind": "Migration", "namespace": "test", "name": "migration"}`))
+	require.Empty(t, reported, "rejected events shouldn't be reported")
+
+	require.Equal(t, http.StatusAccepted, sendDriverEvent(c, http.MethodPost, "driver-token", event))
+	require.Equal(t, []string{"ApplicationBackup test/backup"}, reported)
+}
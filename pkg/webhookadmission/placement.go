@@ -0,0 +1,331 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// placementWebHook is the path for the webhook that adds placement hints
+	// to PVCs
+	placementWebHook = "/placement"
+	// placementWebhookName is the name of the webhook that adds placement
+	// hints to PVCs
+	placementWebhookName = "placement.stork.libopenstorage.org"
+	// PlacementNodesAnnotation records the nodes, ordered by preference, that
+	// were passed to the driver as placement hints for the volume of a PVC
+	PlacementNodesAnnotation = "stork.libopenstorage.org/placement-nodes"
+	// selectedNodeAnnotation is set on PVCs using WaitForFirstConsumer
+	// binding once the scheduler has picked the node for the pod
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+	// disableHyperconvergenceAnnotation disables the placement hints for the
+	// volumes of a pod, since the extender doesn't prefer any node for it
+	disableHyperconvergenceAnnotation = "stork.libopenstorage.org/disableHyperconvergence"
+)
+
+var (
+	placementWebhookPath = placementWebHook
+	placementResources   = []string{"persistentvolumeclaims"}
+)
+
+// processPlacementRequest adds annotations to PVCs owned by the driver so
+// that the replicas of their volumes are created on the nodes where the pod
+// using them is most likely to be scheduled. For PVCs using
+// WaitForFirstConsumer binding that is the node picked by the scheduler.
+// Otherwise it is the node where the extender would place the pod based on
+// the other volumes used by it.
+func (c *Controller) processPlacementRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	patch, err := c.getPlacementPatch(arReq)
+	if err != nil {
+		// The driver picks the nodes if the hints can't be added
+		log.Errorf("Error adding placement hints for PVC %v/%v: %v", arReq.Namespace, arReq.Name, err)
+		admissionResponse.Result = &metav1.Status{
+			Message: fmt.Sprintf("error adding placement hints: %v", err),
+		}
+	} else if patch != nil {
+		patchType := v1beta1.PatchTypeJSONPatch
+		admissionResponse.Patch = patch
+		admissionResponse.PatchType = &patchType
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// getPlacementPatch returns the patch adding the placement annotations to
+// the PVC in the request. It returns nil if the PVC doesn't need hints.
+func (c *Controller) getPlacementPatch(arReq *v1beta1.AdmissionRequest) ([]byte, error) {
+	if arReq.Kind.Kind != "PersistentVolumeClaim" {
+		return nil, nil
+	}
+	pvc := &v1.PersistentVolumeClaim{}
+	if err := json.Unmarshal(arReq.Object.Raw, pvc); err != nil {
+		return nil, err
+	}
+	if pvc.Namespace == "" {
+		pvc.Namespace = arReq.Namespace
+	}
+	if pvc.Spec.VolumeName != "" || pvc.Annotations[PlacementNodesAnnotation] != "" {
+		return nil, nil
+	}
+	if !c.Driver.OwnsPVC(core.Instance(), pvc) {
+		return nil, nil
+	}
+
+	var nodes []*volume.NodeInfo
+	var err error
+	if selectedNode := pvc.Annotations[selectedNodeAnnotation]; selectedNode != "" {
+		nodes, err = c.getSelectedNodePlacement(selectedNode)
+	} else if arReq.Operation == v1beta1.Create {
+		nodes, err = c.getWorkloadPlacement(pvc)
+	}
+	if err != nil || len(nodes) == 0 {
+		return nil, err
+	}
+
+	annotations, err := c.Driver.GetPlacementAnnotations(pvc, nodes)
+	if err != nil {
+		if _, ok := err.(*storkerrors.ErrNotSupported); ok {
+			// The driver doesn't take hints and picks the nodes itself
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(annotations) == 0 {
+		return nil, nil
+	}
+	nodeNames := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if node.SchedulerID != "" {
+			nodeNames = append(nodeNames, node.SchedulerID)
+		} else {
+			nodeNames = append(nodeNames, node.Hostname)
+		}
+	}
+	annotations[PlacementNodesAnnotation] = strings.Join(nodeNames, ",")
+	log.Infof("Adding placement hints for PVC %v/%v: %v", pvc.Namespace, pvc.Name, annotations)
+	return createAnnotationsPatch(pvc.Annotations, annotations)
+}
+
+// getSelectedNodePlacement returns the node picked by the scheduler followed
+// by the other online nodes of the driver, closest first
+func (c *Controller) getSelectedNodePlacement(selectedNode string) ([]*volume.NodeInfo, error) {
+	k8sNode, err := core.Instance().GetNodeByName(selectedNode)
+	if err != nil {
+		return nil, err
+	}
+	driverNodes, err := c.Driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	var selected *volume.NodeInfo
+	for _, node := range driverNodes {
+		if volume.IsNodeMatch(k8sNode, node) {
+			selected = node
+			break
+		}
+	}
+	if selected == nil || selected.Status != volume.NodeOnline {
+		// The pod doesn't run on a storage node, leave the placement to the
+		// driver
+		return nil, nil
+	}
+
+	others := make([]*volume.NodeInfo, 0)
+	for _, node := range driverNodes {
+		if node.StorageID != selected.StorageID && node.Status == volume.NodeOnline {
+			others = append(others, node)
+		}
+	}
+	sort.SliceStable(others, func(i, j int) bool {
+		return localityScore(selected, others[i]) > localityScore(selected, others[j])
+	})
+	return append([]*volume.NodeInfo{selected}, others...), nil
+}
+
+// localityScore returns how close the node is to the selected node, using
+// the same order of preference as the extender
+func localityScore(selected, node *volume.NodeInfo) int {
+	switch {
+	case selected.Rack != "" && node.Rack == selected.Rack && node.Zone == selected.Zone && node.Region == selected.Region:
+		return 3
+	case selected.Zone != "" && node.Zone == selected.Zone && node.Region == selected.Region:
+		return 2
+	case selected.Region != "" && node.Region == selected.Region:
+		return 1
+	}
+	return 0
+}
+
+// getWorkloadPlacement returns the nodes holding the data of the other
+// volumes used by the pod or StatefulSet replica that will use the PVC, with
+// the nodes holding the most volumes first. These are the nodes the extender
+// prefers when the pod is scheduled.
+func (c *Controller) getWorkloadPlacement(pvc *v1.PersistentVolumeClaim) ([]*volume.NodeInfo, error) {
+	podSpec, annotations, siblings, err := getPVCWorkload(pvc)
+	if err != nil || podSpec == nil {
+		return nil, err
+	}
+	if disabled, _ := strconv.ParseBool(annotations[disableHyperconvergenceAnnotation]); disabled {
+		return nil, nil
+	}
+
+	// Only the bound volumes have data nodes
+	boundSpec := &v1.PodSpec{}
+	for _, claimName := range siblings {
+		sibling, err := core.Instance().GetPersistentVolumeClaim(claimName, pvc.Namespace)
+		if err != nil || sibling.Status.Phase != v1.ClaimBound {
+			continue
+		}
+		boundSpec.Volumes = append(boundSpec.Volumes, v1.Volume{
+			Name: claimName,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+			},
+		})
+	}
+	if len(boundSpec.Volumes) == 0 {
+		return nil, nil
+	}
+	volumes, _, err := c.Driver.GetPodVolumes(boundSpec, pvc.Namespace, false)
+	if err != nil || len(volumes) == 0 {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, vol := range volumes {
+		for _, dataNode := range vol.DataNodes {
+			counts[dataNode]++
+		}
+	}
+	driverNodes, err := c.Driver.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*volume.NodeInfo, 0)
+	for _, node := range driverNodes {
+		if counts[node.StorageID] > 0 && node.Status == volume.NodeOnline {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return counts[nodes[i].StorageID] > counts[nodes[j].StorageID]
+	})
+	return nodes, nil
+}
+
+// getPVCWorkload returns the pod spec and annotations of the pod or
+// StatefulSet that uses the PVC, along with the other claims used by the
+// same pod. It returns a nil spec if the PVC isn't used yet.
+func getPVCWorkload(pvc *v1.PersistentVolumeClaim) (*v1.PodSpec, map[string]string, []string, error) {
+	pods, err := core.Instance().GetPods(pvc.Namespace, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, pod := range pods.Items {
+		siblings := make([]string, 0)
+		found := false
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim == nil {
+				continue
+			}
+			if vol.PersistentVolumeClaim.ClaimName == pvc.Name {
+				found = true
+			} else {
+				siblings = append(siblings, vol.PersistentVolumeClaim.ClaimName)
+			}
+		}
+		if found {
+			return &pod.Spec, pod.Annotations, siblings, nil
+		}
+	}
+
+	// The StatefulSet controller creates the PVCs of a replica before its pod
+	statefulSets, err := apps.Instance().ListStatefulSets(pvc.Namespace, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, ss := range statefulSets.Items {
+		for _, template := range ss.Spec.VolumeClaimTemplates {
+			prefix := template.Name + "-" + ss.Name + "-"
+			if !strings.HasPrefix(pvc.Name, prefix) {
+				continue
+			}
+			ordinal := strings.TrimPrefix(pvc.Name, prefix)
+			if _, err := strconv.Atoi(ordinal); err != nil {
+				continue
+			}
+			siblings := make([]string, 0)
+			for _, other := range ss.Spec.VolumeClaimTemplates {
+				if other.Name != template.Name {
+					siblings = append(siblings, other.Name+"-"+ss.Name+"-"+ordinal)
+				}
+			}
+			return &ss.Spec.Template.Spec, ss.Spec.Template.Annotations, siblings, nil
+		}
+	}
+	return nil, nil, nil, nil
+}
+
+// createAnnotationsPatch returns a JSON patch adding the annotations
+func createAnnotationsPatch(existing map[string]string, annotations map[string]string) ([]byte, error) {
+	patch := make([]map[string]interface{}, 0)
+	if existing == nil {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations",
+			"value": annotations,
+		})
+		return json.Marshal(patch)
+	}
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		patch = append(patch, map[string]interface{}{
+			"op":    "add",
+			"path":  "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1"),
+			"value": annotations[k],
+		})
+	}
+	return json.Marshal(patch)
+}
@@ -0,0 +1,285 @@
+//go:build unittest
+// +build unittest
+
+package webhookadmission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/libopenstorage/stork/drivers/volume"
+	storkerrors "github.com/libopenstorage/stork/pkg/errors"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	appv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+const testHintAnnotation = "example.com/nodes"
+
+// placementDriver owns the PVCs that aren't named other and adds the
+// storage IDs of the nodes it is passed as hints
+type placementDriver struct {
+	volume.Driver
+	nodes        []*volume.NodeInfo
+	dataNodes    map[string][]string
+	placementErr error
+}
+
+func (d *placementDriver) OwnsPVC(_ core.Ops, pvc *v1.PersistentVolumeClaim) bool {
+	return pvc.Name != "other"
+}
+
+func (d *placementDriver) GetNodes() ([]*volume.NodeInfo, error) {
+	return d.nodes, nil
+}
+
+func (d *placementDriver) GetPodVolumes(podSpec *v1.PodSpec, _ string, _ bool) ([]*volume.Info, []*volume.Info, error) {
+	volumes := make([]*volume.Info, 0)
+	for _, vol := range podSpec.Volumes {
+		volumes = append(volumes, &volume.Info{
+			VolumeName: vol.PersistentVolumeClaim.ClaimName,
+			DataNodes:  d.dataNodes[vol.PersistentVolumeClaim.ClaimName],
+		})
+	}
+	return volumes, nil, nil
+}
+
+func (d *placementDriver) GetPlacementAnnotations(_ *v1.PersistentVolumeClaim, nodes []*volume.NodeInfo) (map[string]string, error) {
+	if d.placementErr != nil {
+		return nil, d.placementErr
+	}
+	ids := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		ids = append(ids, node.StorageID)
+	}
+	return map[string]string{testHintAnnotation: strings.Join(ids, ",")}, nil
+}
+
+func newPlacementController(objects ...runtime.Object) (*Controller, *placementDriver) {
+	kubeClient := fakek8s.NewSimpleClientset(objects...)
+	core.SetInstance(core.New(kubeClient))
+	apps.SetInstance(apps.New(kubeClient.AppsV1(), kubeClient.CoreV1()))
+	driver := &placementDriver{
+		nodes: []*volume.NodeInfo{
+			{StorageID: "id1", SchedulerID: "node1", Rack: "r1", Zone: "z1", Region: "us", Status: volume.NodeOnline},
+			{StorageID: "id2", SchedulerID: "node2", Rack: "r2", Zone: "z1", Region: "us", Status: volume.NodeOnline},
+			{StorageID: "id3", Hostname: "host3", Rack: "r1", Zone: "z1", Region: "us", Status: volume.NodeOnline},
+			{StorageID: "id4", SchedulerID: "node4", Rack: "r1", Zone: "z1", Region: "us", Status: volume.NodeOffline},
+			{StorageID: "id5", SchedulerID: "node5", Zone: "z2", Region: "us", Status: volume.NodeOnline},
+			{StorageID: "id6", SchedulerID: "node6", Region: "eu", Status: volume.NodeOnline},
+		},
+		dataNodes: map[string][]string{},
+	}
+	return &Controller{Driver: driver}, driver
+}
+
+func newBoundPVC(name string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+}
+
+func newPlacementRequest(t *testing.T, operation v1beta1.Operation, pvc *v1.PersistentVolumeClaim) *v1beta1.AdmissionRequest {
+	raw, err := json.Marshal(pvc)
+	require.NoError(t, err)
+	return &v1beta1.AdmissionRequest{
+		UID:       "uid",
+		Kind:      metav1.GroupVersionKind{Kind: "PersistentVolumeClaim"},
+		Name:      pvc.Name,
+		Namespace: "test",
+		Operation: operation,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+// applyPlacementPatch returns the annotations of the PVC in the request
+// after the patch is applied by the API server
+func applyPlacementPatch(t *testing.T, arReq *v1beta1.AdmissionRequest, patch []byte) map[string]string {
+	pvc := &v1.PersistentVolumeClaim{}
+	require.NoError(t, json.Unmarshal(arReq.Object.Raw, pvc))
+	pvc.Namespace = arReq.Namespace
+	pvcs := fakek8s.NewSimpleClientset(pvc).CoreV1().PersistentVolumeClaims(pvc.Namespace)
+	patched, err := pvcs.Patch(context.TODO(), pvc.Name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	require.NoError(t, err)
+	return patched.Annotations
+}
+
+func TestPlacementSelectedNode(t *testing.T) {
+	c, _ := newPlacementController(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:        "data",
+		Annotations: map[string]string{selectedNodeAnnotation: "node1"},
+	}}
+	arReq := newPlacementRequest(t, v1beta1.Update, pvc)
+
+	patch, err := c.getPlacementPatch(arReq)
+	require.NoError(t, err)
+	// The selected node is followed by the online nodes in the same rack,
+	// zone and region
+	require.JSONEq(t, `[
+		{"op": "add", "path": "/metadata/annotations/example.com~1nodes", "value": "id1,id3,id2,id5,id6"},
+		{"op": "add", "path": "/metadata/annotations/stork.libopenstorage.org~1placement-nodes", "value": "node1,host3,node2,node5,node6"}
+	]`, string(patch))
+	annotations := applyPlacementPatch(t, arReq, patch)
+	require.Equal(t, "node1", annotations[selectedNodeAnnotation])
+	require.Equal(t, "id1,id3,id2,id5,id6", annotations[testHintAnnotation])
+
+	// No hints are added for pods scheduled on nodes without storage
+	pvc.Annotations[selectedNodeAnnotation] = "node4"
+	c, _ = newPlacementController(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node4"}})
+	patch, err = c.getPlacementPatch(newPlacementRequest(t, v1beta1.Update, pvc))
+	require.NoError(t, err)
+	require.Nil(t, patch, "Expected no hints for an offline node")
+}
+
+func TestPlacementPod(t *testing.T) {
+	claim := func(name string) v1.Volume {
+		return v1.Volume{
+			Name:         name,
+			VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: name}},
+		}
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"},
+		Spec: v1.PodSpec{Volumes: []v1.Volume{
+			claim("data"), claim("logs"), claim("db"), claim("pending"),
+			{Name: "config", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		}},
+	}
+	pending := newBoundPVC("pending")
+	pending.Status.Phase = v1.ClaimPending
+	c, driver := newPlacementController(pod, newBoundPVC("logs"), newBoundPVC("db"), pending)
+	driver.dataNodes["logs"] = []string{"id2", "id5"}
+	driver.dataNodes["db"] = []string{"id5", "id4"}
+	driver.dataNodes["pending"] = []string{"id1"}
+
+	arReq := newPlacementRequest(t, v1beta1.Create, &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data"}})
+	patch, err := c.getPlacementPatch(arReq)
+	require.NoError(t, err)
+	// The annotations are added at once to PVCs without annotations, with
+	// the nodes holding most volumes first
+	require.JSONEq(t, `[{"op": "add", "path": "/metadata/annotations", "value": {
+		"example.com/nodes": "id5,id2",
+		"stork.libopenstorage.org/placement-nodes": "node5,node2"
+	}}]`, string(patch))
+	require.Equal(t, "id5,id2", applyPlacementPatch(t, arReq, patch)[testHintAnnotation])
+
+	// The hints are only added on creation
+	patch, err = c.getPlacementPatch(newPlacementRequest(t, v1beta1.Update, &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data"}}))
+	require.NoError(t, err)
+	require.Nil(t, patch)
+
+	pod.Annotations = map[string]string{disableHyperconvergenceAnnotation: "true"}
+	c, _ = newPlacementController(pod, newBoundPVC("logs"))
+	patch, err = c.getPlacementPatch(arReq)
+	require.NoError(t, err)
+	require.Nil(t, patch, "Expected no hints when hyperconvergence is disabled")
+}
+
+func TestPlacementStatefulSet(t *testing.T) {
+	template := func(name string) v1.PersistentVolumeClaim {
+		return v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	ss := &appv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+		Spec: appv1.StatefulSetSpec{
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{template("data"), template("logs")},
+		},
+	}
+	c, driver := newPlacementController(ss, newBoundPVC("logs-web-0"), newBoundPVC("logs-web-1"))
+	driver.dataNodes["logs-web-0"] = []string{"id1"}
+	driver.dataNodes["logs-web-1"] = []string{"id2"}
+
+	// The PVCs of a replica are placed with the other PVCs of the replica
+	for ordinal, expected := range []string{"id1", "id2"} {
+		arReq := newPlacementRequest(t, v1beta1.Create, &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("data-web-%v", ordinal)},
+		})
+		patch, err := c.getPlacementPatch(arReq)
+		require.NoError(t, err)
+		require.Equal(t, expected, applyPlacementPatch(t, arReq, patch)[testHintAnnotation])
+	}
+
+	for _, name := range []string{"data-web-x", "data-other-0", "data"} {
+		patch, err := c.getPlacementPatch(newPlacementRequest(t, v1beta1.Create, &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}))
+		require.NoError(t, err)
+		require.Nil(t, patch, "Expected no hints for PVC %v not used by the StatefulSet", name)
+	}
+}
+
+func TestPlacementSkipped(t *testing.T) {
+	c, _ := newPlacementController(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	newPVC := func(name string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{selectedNodeAnnotation: "node1"},
+		}}
+	}
+
+	arReq := newPlacementRequest(t, v1beta1.Update, newPVC("data"))
+	arReq.Kind.Kind = "Pod"
+	patch, err := c.getPlacementPatch(arReq)
+	require.NoError(t, err)
+	require.Nil(t, patch, "Expected other kinds to be skipped")
+
+	bound := newPVC("data")
+	bound.Spec.VolumeName = "pv"
+	annotated := newPVC("data")
+	annotated.Annotations[PlacementNodesAnnotation] = "node2"
+	for _, pvc := range []*v1.PersistentVolumeClaim{bound, annotated, newPVC("other")} {
+		patch, err := c.getPlacementPatch(newPlacementRequest(t, v1beta1.Update, pvc))
+		require.NoError(t, err)
+		require.Nil(t, patch, "Expected no hints for bound, annotated or other drivers' PVCs")
+	}
+}
+
+func TestProcessPlacementRequest(t *testing.T) {
+	c, driver := newPlacementController(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	process := func() *v1beta1.AdmissionResponse {
+		pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:        "data",
+			Annotations: map[string]string{selectedNodeAnnotation: "node1"},
+		}}
+		body, err := json.Marshal(v1beta1.AdmissionReview{Request: newPlacementRequest(t, v1beta1.Update, pvc)})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		c.processPlacementRequest(w, httptest.NewRequest("POST", placementWebhookPath, bytes.NewReader(body)))
+		review := v1beta1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &review))
+		require.Equal(t, "uid", string(review.Response.UID))
+		require.True(t, review.Response.Allowed, "PVCs should always be allowed")
+		return review.Response
+	}
+
+	response := process()
+	require.Equal(t, v1beta1.PatchTypeJSONPatch, *response.PatchType)
+	require.Contains(t, string(response.Patch), "/metadata/annotations/example.com~1nodes")
+	require.Nil(t, response.Result)
+
+	// Drivers that don't take hints pick the nodes without an error
+	driver.placementErr = &storkerrors.ErrNotSupported{}
+	response = process()
+	require.Nil(t, response.Patch)
+	require.Nil(t, response.PatchType)
+	require.Nil(t, response.Result)
+
+	driver.placementErr = fmt.Errorf("driver error")
+	response = process()
+	require.Nil(t, response.Patch)
+	require.Contains(t, response.Result.Message, "driver error")
+}
@@ -0,0 +1,224 @@
+package webhookadmission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// validateReferencesWebHook is the path for the webhook that checks the
+	// objects referenced by stork CRs
+	validateReferencesWebHook = "/validate-references"
+	// validateReferencesWebhookName is the name of the webhook that checks
+	// that users can read the objects referenced by stork CRs
+	validateReferencesWebhookName = "references.stork.libopenstorage.org"
+	// namespaceNameLabel is set by Kubernetes on every namespace to its name
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+)
+
+// objectReference is a stork object referenced by another stork CR
+type objectReference struct {
+	resource  string
+	namespace string
+	name      string
+}
+
+// processReferenceRequest denies requests that reference BackupLocations or
+// ClusterPairs that the user isn't allowed to read. This prevents users from
+// using credentials set up by other users in namespaces shared by multiple
+// tenants, or by the cluster admin in the admin namespace.
+func (c *Controller) processReferenceRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	message, denied, err := c.checkReferences(arReq)
+	if err != nil {
+		log.Errorf("Error checking references for %v %v/%v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, err)
+		message = fmt.Sprintf("error checking access to referenced objects: %v", err)
+		denied = true
+	}
+	if denied {
+		log.Warnf("Denying %v of %v %v/%v by %v: %v", arReq.Operation, arReq.Kind.Kind, arReq.Namespace, arReq.Name, arReq.UserInfo.Username, message)
+		admissionResponse = &v1beta1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: message,
+				Reason:  metav1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+			},
+			Allowed: false,
+		}
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// checkReferences returns true along with the reason if the user making the
+// request can't read one of the objects referenced by the CR
+func (c *Controller) checkReferences(arReq *v1beta1.AdmissionRequest) (string, bool, error) {
+	references, err := c.getReferences(arReq.Kind.Kind, arReq.Namespace, arReq.Object.Raw)
+	if err != nil {
+		return "", false, err
+	}
+	if len(references) == 0 {
+		return "", false, nil
+	}
+	// Status updates from the controllers don't change the references, so
+	// only check them if they have changed
+	if arReq.Operation == v1beta1.Update {
+		oldReferences, err := c.getReferences(arReq.Kind.Kind, arReq.Namespace, arReq.OldObject.Raw)
+		if err != nil {
+			return "", false, err
+		}
+		if reflect.DeepEqual(references, oldReferences) {
+			return "", false, nil
+		}
+	}
+	client := c.kubeClient
+	if client == nil {
+		if client, err = getAdmissionClient(); err != nil {
+			return "", false, err
+		}
+	}
+	extra := make(map[string]authorizationv1.ExtraValue)
+	for k, v := range arReq.UserInfo.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	for _, ref := range references {
+		if ref.resource == stork_api.BackupLocationResourcePlural && ref.namespace != arReq.Namespace {
+			// BackupLocations shared with the namespace can be used without
+			// being able to read them
+			backupLocation, err := storkops.Instance().GetBackupLocation(ref.name, ref.namespace)
+			if err != nil && !errors.IsNotFound(err) {
+				return "", false, err
+			}
+			if err == nil && backupLocation.IsNamespaceAllowed(arReq.Namespace) {
+				continue
+			}
+		}
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   arReq.UserInfo.Username,
+				UID:    arReq.UserInfo.UID,
+				Groups: arReq.UserInfo.Groups,
+				Extra:  extra,
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: ref.namespace,
+					Verb:      "get",
+					Group:     stork_api.SchemeGroupVersion.Group,
+					Resource:  ref.resource,
+					Name:      ref.name,
+				},
+			},
+		}
+		resp, err := client.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+		if err != nil {
+			return "", false, err
+		}
+		if !resp.Status.Allowed {
+			return fmt.Sprintf("user %v is not allowed to read %v %v/%v", arReq.UserInfo.Username, ref.resource, ref.namespace, ref.name), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// getReferences returns the BackupLocations and ClusterPairs referenced by
+// the CR
+func (c *Controller) getReferences(kind string, namespace string, raw []byte) ([]objectReference, error) {
+	references := make([]objectReference, 0)
+	addBackupLocation := func(name string, backupLocationNamespace string) {
+		if backupLocationNamespace == "" {
+			backupLocationNamespace = namespace
+		}
+		if name != "" {
+			references = append(references, objectReference{
+				resource:  stork_api.BackupLocationResourcePlural,
+				namespace: backupLocationNamespace,
+				name:      name,
+			})
+		}
+	}
+	addClusterPairs := func(spec stork_api.MigrationSpec) {
+		if spec.ClusterPair != "" {
+			references = append(references, objectReference{
+				resource:  stork_api.ClusterPairResourcePlural,
+				namespace: namespace,
+				name:      spec.ClusterPair,
+			})
+		}
+		// The admin cluster pair is always read from the admin namespace
+		if spec.AdminClusterPair != "" && c.AdminNamespace != "" {
+			references = append(references, objectReference{
+				resource:  stork_api.ClusterPairResourcePlural,
+				namespace: c.AdminNamespace,
+				name:      spec.AdminClusterPair,
+			})
+		}
+	}
+
+	switch kind {
+	case "ApplicationBackup":
+		var backup stork_api.ApplicationBackup
+		if err := json.Unmarshal(raw, &backup); err != nil {
+			return nil, err
+		}
+		addBackupLocation(backup.Spec.BackupLocation, backup.Spec.BackupLocationNamespace)
+	case "ApplicationBackupSchedule":
+		var schedule stork_api.ApplicationBackupSchedule
+		if err := json.Unmarshal(raw, &schedule); err != nil {
+			return nil, err
+		}
+		addBackupLocation(schedule.Spec.Template.Spec.BackupLocation, schedule.Spec.Template.Spec.BackupLocationNamespace)
+	case "ApplicationRestore":
+		var restore stork_api.ApplicationRestore
+		if err := json.Unmarshal(raw, &restore); err != nil {
+			return nil, err
+		}
+		addBackupLocation(restore.Spec.BackupLocation, restore.Spec.BackupLocationNamespace)
+	case "Migration":
+		var migration stork_api.Migration
+		if err := json.Unmarshal(raw, &migration); err != nil {
+			return nil, err
+		}
+		addClusterPairs(migration.Spec)
+	case "MigrationSchedule":
+		var schedule stork_api.MigrationSchedule
+		if err := json.Unmarshal(raw, &schedule); err != nil {
+			return nil, err
+		}
+		addClusterPairs(schedule.Spec.Template.Spec)
+	}
+	return references, nil
+}
@@ -0,0 +1,191 @@
+//go:build unittest
+// +build unittest
+
+package webhookadmission
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// newReferenceController returns a controller whose access reviews only
+// allow reading the objects in allowed, keyed by namespace/name
+func newReferenceController(allowed map[string]bool, backupLocations ...runtime.Object) *Controller {
+	kubeClient := fakek8s.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "user" &&
+			allowed[attributes.Namespace+"/"+attributes.Name]
+		return true, review, nil
+	})
+	storkops.SetInstance(storkops.New(kubeClient, fakestorkclient.NewSimpleClientset(backupLocations...), nil))
+	return &Controller{AdminNamespace: "admin", kubeClient: kubeClient}
+}
+
+func newReferenceRequest(t *testing.T, kind string, obj interface{}) *v1beta1.AdmissionRequest {
+	raw, err := json.Marshal(obj)
+	require.NoError(t, err)
+	return &v1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: kind},
+		Namespace: "test",
+		Operation: v1beta1.Create,
+		UserInfo:  authenticationv1.UserInfo{Username: "user"},
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+func TestGetReferences(t *testing.T) {
+	c := &Controller{AdminNamespace: "admin"}
+	backup := stork_api.ApplicationBackup{
+		Spec: stork_api.ApplicationBackupSpec{BackupLocation: "location"},
+	}
+	raw, err := json.Marshal(backup)
+	require.NoError(t, err)
+	references, err := c.getReferences("ApplicationBackup", "test", raw)
+	require.NoError(t, err)
+	require.Equal(t, []objectReference{
+		{resource: stork_api.BackupLocationResourcePlural, namespace: "test", name: "location"},
+	}, references)
+
+	restore := stork_api.ApplicationRestore{
+		Spec: stork_api.ApplicationRestoreSpec{BackupLocation: "location", BackupLocationNamespace: "shared"},
+	}
+	raw, err = json.Marshal(restore)
+	require.NoError(t, err)
+	references, err = c.getReferences("ApplicationRestore", "test", raw)
+	require.NoError(t, err)
+	require.Equal(t, []objectReference{
+		{resource: stork_api.BackupLocationResourcePlural, namespace: "shared", name: "location"},
+	}, references)
+
+	schedule := stork_api.MigrationSchedule{
+		Spec: stork_api.MigrationScheduleSpec{
+			Template: stork_api.MigrationTemplateSpec{
+				Spec: stork_api.MigrationSpec{ClusterPair: "pair", AdminClusterPair: "admin-pair"},
+			},
+		},
+	}
+	raw, err = json.Marshal(schedule)
+	require.NoError(t, err)
+	references, err = c.getReferences("MigrationSchedule", "test", raw)
+	require.NoError(t, err)
+	require.Equal(t, []objectReference{
+		{resource: stork_api.ClusterPairResourcePlural, namespace: "test", name: "pair"},
+		{resource: stork_api.ClusterPairResourcePlural, namespace: "admin", name: "admin-pair"},
+	}, references, "admin cluster pair should be read from the admin namespace")
+
+	references, err = c.getReferences("VolumeSnapshotSchedule", "test", raw)
+	require.NoError(t, err)
+	require.Empty(t, references)
+}
+
+func TestCheckReferences(t *testing.T) {
+	sharedLocation := &stork_api.BackupLocation{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: "admin"},
+		Location: stork_api.BackupLocationItem{
+			Type:     stork_api.BackupLocationS3,
+			S3Config: &stork_api.S3Config{},
+		},
+		AllowedNamespaces: []string{"test"},
+	}
+	c := newReferenceController(map[string]bool{"test/location": true}, sharedLocation)
+
+	backup := &stork_api.ApplicationBackup{
+		Spec: stork_api.ApplicationBackupSpec{BackupLocation: "location"},
+	}
+	message, denied, err := c.checkReferences(newReferenceRequest(t, "ApplicationBackup", backup))
+	require.NoError(t, err)
+	require.False(t, denied, message)
+
+	backup.Spec.BackupLocationNamespace = "admin"
+	message, denied, err = c.checkReferences(newReferenceRequest(t, "ApplicationBackup", backup))
+	require.NoError(t, err)
+	require.True(t, denied, "BackupLocation in another namespace should be denied")
+	require.Contains(t, message, "admin/location")
+
+	backup.Spec.BackupLocation = "shared"
+	message, denied, err = c.checkReferences(newReferenceRequest(t, "ApplicationBackup", backup))
+	require.NoError(t, err)
+	require.False(t, denied, "BackupLocation shared with the namespace should be allowed: %v", message)
+
+	migration := &stork_api.Migration{
+		Spec: stork_api.MigrationSpec{ClusterPair: "location", AdminClusterPair: "pair"},
+	}
+	message, denied, err = c.checkReferences(newReferenceRequest(t, "Migration", migration))
+	require.NoError(t, err)
+	require.True(t, denied, "admin cluster pair should be denied")
+	require.Contains(t, message, "admin/pair")
+
+	// Updates that don't change the references shouldn't be checked
+	request := newReferenceRequest(t, "Migration", migration)
+	request.Operation = v1beta1.Update
+	request.OldObject = request.Object
+	message, denied, err = c.checkReferences(request)
+	require.NoError(t, err)
+	require.False(t, denied, message)
+}
+
+func TestReferenceCheckNamespaceSelector(t *testing.T) {
+	selector := referenceCheckNamespaceSelector("stork")
+	require.Len(t, selector.MatchExpressions, 1)
+	require.Equal(t, namespaceNameLabel, selector.MatchExpressions[0].Key)
+	require.Equal(t, metav1.LabelSelectorOpNotIn, selector.MatchExpressions[0].Operator)
+	require.Equal(t, []string{"kube-system", "stork"}, selector.MatchExpressions[0].Values)
+
+	selector = referenceCheckNamespaceSelector("kube-system")
+	require.Equal(t, []string{"kube-system"}, selector.MatchExpressions[0].Values)
+}
+
+func TestUserRoles(t *testing.T) {
+	content, err := os.ReadFile("../../specs/stork-rbac.yaml")
+	require.NoError(t, err)
+	roles := make(map[string]rbacv1.ClusterRole)
+	for _, doc := range strings.Split(string(content), "\n---\n") {
+		var role rbacv1.ClusterRole
+		require.NoError(t, yaml.Unmarshal([]byte(doc), &role))
+		if role.Kind == "ClusterRole" {
+			roles[role.Name] = role
+		}
+	}
+	require.Contains(t, roles, "stork-viewer")
+	require.Contains(t, roles, "stork-backup-admin")
+	require.Contains(t, roles, "stork-dr-operator")
+
+	for name, role := range roles {
+		granted := false
+		for _, rule := range role.Rules {
+			for _, resource := range rule.Resources {
+				granted = granted || resource == stork_api.RegistryMappingResourcePlural
+			}
+		}
+		require.True(t, granted, "%v should grant access to RegistryMappings", name)
+		if role.Labels["rbac.authorization.k8s.io/aggregate-to-view"] != "true" {
+			continue
+		}
+		for _, rule := range role.Rules {
+			for _, resource := range rule.Resources {
+				require.NotEqual(t, stork_api.BackupLocationResourcePlural, resource,
+					"%v shouldn't allow viewers to read BackupLocations", name)
+				require.NotEqual(t, stork_api.ClusterPairResourcePlural, resource,
+					"%v shouldn't allow viewers to read ClusterPairs", name)
+			}
+		}
+	}
+}
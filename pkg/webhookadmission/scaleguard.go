@@ -0,0 +1,259 @@
+package webhookadmission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	storkclientset "github.com/libopenstorage/stork/pkg/client/clientset/versioned"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	"github.com/portworx/sched-ops/k8s/apps"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/admission/v1beta1"
+	appv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// storkValidatingAdmissionController is the name of the validating
+	// webhook config
+	storkValidatingAdmissionController = "stork-validating-webhooks-cfg"
+	// validateWebhookName is the name of the webhook that blocks scaling up
+	// migrated applications that haven't been activated
+	validateWebhookName = "scaleguard.stork.libopenstorage.org"
+
+	migrationScheduleResyncPeriod = 30 * time.Second
+)
+
+// processValidateRequest blocks scaling up migrated Deployments and
+// StatefulSets on a cluster where the migrated applications haven't been
+// activated. This prevents the applications from accidentally running on both
+// the source and destination clusters at the same time.
+func (c *Controller) processValidateRequest(w http.ResponseWriter, req *http.Request) {
+	admissionReview := v1beta1.AdmissionReview{}
+	decoder := json.NewDecoder(req.Body)
+	defer func() {
+		if err := req.Body.Close(); err != nil {
+			log.Warnf("Error closing decoder")
+		}
+	}()
+	if err := decoder.Decode(&admissionReview); err != nil {
+		log.Errorf("Error decoding admission review request: %v", err)
+		http.Error(w, "Decode error", http.StatusBadRequest)
+		return
+	}
+
+	arReq := admissionReview.Request
+	admissionResponse := &v1beta1.AdmissionResponse{
+		Allowed: true,
+	}
+	message, blocked, err := c.checkScaleUp(arReq)
+	if err != nil {
+		// Don't block applications if the request can't be checked
+		log.Errorf("Error checking scale up for %v %v/%v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, err)
+	} else if blocked {
+		log.Warnf("Blocking scale up of %v %v/%v: %v", arReq.Kind.Kind, arReq.Namespace, arReq.Name, message)
+		admissionResponse = &v1beta1.AdmissionResponse{
+			Result: &metav1.Status{
+				Message: message,
+				Reason:  metav1.StatusReasonForbidden,
+				Code:    http.StatusForbidden,
+			},
+			Allowed: false,
+		}
+	}
+
+	admissionResponse.UID = arReq.UID
+	admissionReview.Response = admissionResponse
+	resp, err := json.Marshal(admissionReview)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("could not marshal response: %v", err), http.StatusInternalServerError)
+	}
+	if _, err := w.Write(resp); err != nil {
+		http.Error(w, fmt.Sprintf("could not write http response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// checkScaleUp returns true along with the reason if the request scales up a
+// migrated application that hasn't been activated
+func (c *Controller) checkScaleUp(arReq *v1beta1.AdmissionRequest) (string, bool, error) {
+	var objectMeta metav1.ObjectMeta
+	var oldReplicas, newReplicas int32
+	if arReq.SubResource == "scale" {
+		var scale autoscalingv1.Scale
+		if err := json.Unmarshal(arReq.Object.Raw, &scale); err != nil {
+			return "", false, err
+		}
+		newReplicas = scale.Spec.Replicas
+		switch arReq.Resource.Resource {
+		case "deployments":
+			deployment, err := apps.Instance().GetDeployment(arReq.Name, arReq.Namespace)
+			if err != nil {
+				return "", false, err
+			}
+			objectMeta = deployment.ObjectMeta
+			oldReplicas = getReplicas(deployment.Spec.Replicas)
+		case "statefulsets":
+			sts, err := apps.Instance().GetStatefulSet(arReq.Name, arReq.Namespace)
+			if err != nil {
+				return "", false, err
+			}
+			objectMeta = sts.ObjectMeta
+			oldReplicas = getReplicas(sts.Spec.Replicas)
+		default:
+			return "", false, nil
+		}
+	} else {
+		switch arReq.Kind.Kind {
+		case "Deployment":
+			var deployment, oldDeployment appv1.Deployment
+			if err := json.Unmarshal(arReq.Object.Raw, &deployment); err != nil {
+				return "", false, err
+			}
+			if err := json.Unmarshal(arReq.OldObject.Raw, &oldDeployment); err != nil {
+				return "", false, err
+			}
+			objectMeta = deployment.ObjectMeta
+			newReplicas = getReplicas(deployment.Spec.Replicas)
+			oldReplicas = getReplicas(oldDeployment.Spec.Replicas)
+		case "StatefulSet":
+			var sts, oldSts appv1.StatefulSet
+			if err := json.Unmarshal(arReq.Object.Raw, &sts); err != nil {
+				return "", false, err
+			}
+			if err := json.Unmarshal(arReq.OldObject.Raw, &oldSts); err != nil {
+				return "", false, err
+			}
+			objectMeta = sts.ObjectMeta
+			newReplicas = getReplicas(sts.Spec.Replicas)
+			oldReplicas = getReplicas(oldSts.Spec.Replicas)
+		default:
+			return "", false, nil
+		}
+	}
+
+	if oldReplicas != 0 || newReplicas == 0 {
+		return "", false, nil
+	}
+	if !isMigratedApp(objectMeta.Labels) || c.skipScaleGuard(objectMeta.Annotations) {
+		return "", false, nil
+	}
+	if c.isStorkRequest(arReq) {
+		return "", false, nil
+	}
+	return c.getInactiveMigrationSchedule(arReq.Namespace)
+}
+
+// isStorkRequest returns true if the request was made by stork itself, which
+// scales up the migrated applications when they are activated
+func (c *Controller) isStorkRequest(arReq *v1beta1.AdmissionRequest) bool {
+	serviceAccount := storkAccount
+	if c.ServiceAccount != "" {
+		serviceAccount = c.ServiceAccount
+	}
+	return arReq.UserInfo.Username == serviceaccount.MakeUsername(c.namespace, serviceAccount)
+}
+
+// startMigrationScheduleCache starts an informer for the migration schedules
+// so that the scale guard doesn't list them from the API server for every
+// request
+func (c *Controller) startMigrationScheduleCache(stopChan chan struct{}) error {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("error getting cluster config: %v", err)
+	}
+	storkClient, err := storkclientset.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("error getting client, %v", err)
+	}
+	watchlist := cache.NewListWatchFromClient(storkClient.StorkV1alpha1().RESTClient(),
+		stork_api.MigrationScheduleResourcePlural, v1.NamespaceAll, fields.Everything())
+	c.migrationScheduleStore, c.migrationScheduleInformer = cache.NewInformer(watchlist,
+		&stork_api.MigrationSchedule{}, migrationScheduleResyncPeriod, cache.ResourceEventHandlerFuncs{})
+	go c.migrationScheduleInformer.Run(stopChan)
+	return nil
+}
+
+// listMigrationSchedules returns the migration schedules from the cache. The
+// API server is used until the cache has synced, and for UTs since the
+// ListWatcher doesn't work with the fake client.
+func (c *Controller) listMigrationSchedules() ([]*stork_api.MigrationSchedule, error) {
+	if c.migrationScheduleStore == nil || !c.migrationScheduleInformer.HasSynced() {
+		schedules, err := storkops.Instance().ListMigrationSchedules("")
+		if err != nil {
+			return nil, err
+		}
+		result := make([]*stork_api.MigrationSchedule, 0, len(schedules.Items))
+		for i := range schedules.Items {
+			result = append(result, &schedules.Items[i])
+		}
+		return result, nil
+	}
+	result := make([]*stork_api.MigrationSchedule, 0)
+	for _, obj := range c.migrationScheduleStore.List() {
+		if schedule, ok := obj.(*stork_api.MigrationSchedule); ok {
+			result = append(result, schedule)
+		}
+	}
+	return result, nil
+}
+
+// getInactiveMigrationSchedule returns true if the namespace is being
+// migrated to this cluster by a migration schedule and the migrated
+// applications haven't been activated
+func (c *Controller) getInactiveMigrationSchedule(namespace string) (string, bool, error) {
+	schedules, err := c.listMigrationSchedules()
+	if err != nil {
+		return "", false, err
+	}
+	for _, schedule := range schedules {
+		if _, ok := schedule.Annotations[migration.StorkMigrationScheduleCopied]; !ok {
+			continue
+		}
+		if schedule.Status.ApplicationActivated {
+			continue
+		}
+		for _, ns := range schedule.Spec.Template.Spec.Namespaces {
+			if ns == namespace {
+				return fmt.Sprintf("application is migrated by migration schedule %v/%v and hasn't been activated on this cluster, "+
+					"activate the migrations before scaling up the application", schedule.Namespace, schedule.Name), true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+func (c *Controller) skipScaleGuard(annotations map[string]string) bool {
+	skipHookAnnotation := defaultSkipAnnotation
+	if c.SkipResource != "" {
+		skipHookAnnotation = c.SkipResource
+	}
+	return skipSchedulerUpdate(skipHookAnnotation, annotations)
+}
+
+func isMigratedApp(labels map[string]string) bool {
+	if value, ok := labels[migration.StorkMigrationAnnotation]; ok {
+		if migrated, err := strconv.ParseBool(value); err == nil && migrated {
+			return true
+		}
+	}
+	return false
+}
+
+func getReplicas(replicas *int32) int32 {
+	// Replicas default to 1 if not specified
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,89 @@
+//go:build unittest
+// +build unittest
+
+package webhookadmission
+
+import (
+	"encoding/json"
+	"testing"
+
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
+	fakestorkclient "github.com/libopenstorage/stork/pkg/client/clientset/versioned/fake"
+	migration "github.com/libopenstorage/stork/pkg/migration/controllers"
+	storkops "github.com/portworx/sched-ops/k8s/stork"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/admission/v1beta1"
+	appv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+)
+
+func newScaleGuardController(activated bool) *Controller {
+	schedule := &stork_api.MigrationSchedule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "schedule",
+			Namespace:   "admin",
+			Annotations: map[string]string{migration.StorkMigrationScheduleCopied: "true"},
+		},
+		Spec: stork_api.MigrationScheduleSpec{
+			Template: stork_api.MigrationTemplateSpec{
+				Spec: stork_api.MigrationSpec{Namespaces: []string{"test"}},
+			},
+		},
+		Status: stork_api.MigrationScheduleStatus{ApplicationActivated: activated},
+	}
+	storkops.SetInstance(storkops.New(fakek8s.NewSimpleClientset(), fakestorkclient.NewSimpleClientset(schedule), nil))
+	return &Controller{BlockInactiveAppScaleUp: true, namespace: "kube-system"}
+}
+
+func newScaleUpRequest(t *testing.T, username string) *v1beta1.AdmissionRequest {
+	var oldReplicas, newReplicas int32 = 0, 3
+	deployment := appv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "test",
+			Labels:    map[string]string{migration.StorkMigrationAnnotation: "true"},
+		},
+		Spec: appv1.DeploymentSpec{Replicas: &newReplicas},
+	}
+	raw, err := json.Marshal(deployment)
+	require.NoError(t, err)
+	deployment.Spec.Replicas = &oldReplicas
+	oldRaw, err := json.Marshal(deployment)
+	require.NoError(t, err)
+	return &v1beta1.AdmissionRequest{
+		Kind:      metav1.GroupVersionKind{Kind: "Deployment"},
+		Name:      "app",
+		Namespace: "test",
+		Operation: v1beta1.Update,
+		UserInfo:  authenticationv1.UserInfo{Username: username},
+		Object:    runtime.RawExtension{Raw: raw},
+		OldObject: runtime.RawExtension{Raw: oldRaw},
+	}
+}
+
+func TestCheckScaleUp(t *testing.T) {
+	c := newScaleGuardController(false)
+	_, blocked, err := c.checkScaleUp(newScaleUpRequest(t, "user"))
+	require.NoError(t, err)
+	require.True(t, blocked, "Expected scale up of inactive app to be blocked")
+
+	// Stork scales up the apps when they are activated
+	_, blocked, err = c.checkScaleUp(newScaleUpRequest(t, "system:serviceaccount:kube-system:stork-account"))
+	require.NoError(t, err)
+	require.False(t, blocked, "Expected scale up by stork to be allowed")
+	c.ServiceAccount = "custom-account"
+	_, blocked, err = c.checkScaleUp(newScaleUpRequest(t, "system:serviceaccount:kube-system:custom-account"))
+	require.NoError(t, err)
+	require.False(t, blocked, "Expected scale up by configured service account to be allowed")
+	_, blocked, err = c.checkScaleUp(newScaleUpRequest(t, "system:serviceaccount:test:stork-account"))
+	require.NoError(t, err)
+	require.True(t, blocked, "Expected scale up by service account in another namespace to be blocked")
+
+	c = newScaleGuardController(true)
+	_, blocked, err = c.checkScaleUp(newScaleUpRequest(t, "user"))
+	require.NoError(t, err)
+	require.False(t, blocked, "Expected scale up of activated app to be allowed")
+}
@@ -2,6 +2,7 @@ package webhookadmission
 
 import (
 	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -12,6 +13,7 @@ import (
 	"math/big"
 	"time"
 
+	stork_api "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	"github.com/libopenstorage/stork/pkg/version"
 	"github.com/portworx/sched-ops/k8s/admissionregistration"
 	"github.com/portworx/sched-ops/k8s/core"
@@ -21,21 +23,47 @@ import (
 	v1 "k8s.io/api/core/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 )
 
 const (
 	webhookName       = "webhook.stork.libopenstorage.org"
 	storkService      = "stork-service"
+	storkAccount      = "stork-account"
 	storkNamespaceEnv = "STORK-NAMESPACE"
 	defaultNamespace  = "kube-system"
 )
 
 var (
-	webhookPath = "/mutate"
+	webhookPath         = "/mutate"
+	validateWebhookPath = validateWebHook
+	scaleGuardResources = []string{"deployments", "statefulsets", "deployments/scale", "statefulsets/scale"}
+
+	validateReferencesWebhookPath = validateReferencesWebHook
+	referenceCheckResources       = []string{
+		stork_api.ApplicationBackupResourcePlural,
+		stork_api.ApplicationBackupScheduleResourcePlural,
+		stork_api.ApplicationRestoreResourcePlural,
+		stork_api.MigrationResourcePlural,
+		stork_api.MigrationScheduleResourcePlural,
+	}
+
+	validateDeletionWebhookPath = validateDeletionWebHook
+	deletionGuardResources      = []string{
+		stork_api.BackupLocationResourcePlural,
+		stork_api.ClusterPairResourcePlural,
+		stork_api.SchedulePolicyResourcePlural,
+		stork_api.NamespacedSchedulePolicyResourcePlural,
+	}
 )
 
-// CreateMutateWebhook create new webhookconfig for stork if not exist already
-func CreateMutateWebhook(caBundle []byte, ns string) error {
+// CreateMutateWebhook create new webhookconfig for stork if not exist already.
+// setDefaults adds the webhook that sets the defaults on stork CRs,
+// placementHints adds the webhook that adds placement hints to PVCs and
+// dataMoverAffinity adds the webhook that adds node affinity to the pods of
+// the data mover jobs.
+func CreateMutateWebhook(caBundle []byte, ns string, setDefaults, placementHints, dataMoverAffinity bool) error {
 
 	ok, err := version.RequiresV1Registration()
 	if err != nil {
@@ -43,7 +71,7 @@ func CreateMutateWebhook(caBundle []byte, ns string) error {
 	}
 	if ok {
 		// register v1 crds
-		return createWebhookV1(caBundle, ns)
+		return createWebhookV1(caBundle, ns, setDefaults, placementHints, dataMoverAffinity)
 	}
 	// We make best efforts to change incoming apps scheduler to stork, if application is
 	// using stork supported storage drivers.
@@ -70,11 +98,94 @@ func CreateMutateWebhook(caBundle []byte, ns string) error {
 		},
 		SideEffects: &sideEffect,
 	}
+	webhooks := []admissionv1beta1.MutatingWebhook{webhook}
+	if setDefaults {
+		defaultSideEffect := admissionv1beta1.SideEffectClassNone
+		failurePolicy := admissionv1beta1.Ignore
+		webhooks = append(webhooks, admissionv1beta1.MutatingWebhook{
+			Name: defaultWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &defaultWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   defaultResources,
+					},
+				},
+			},
+			SideEffects:   &defaultSideEffect,
+			FailurePolicy: &failurePolicy,
+		})
+	}
+	if placementHints {
+		placementSideEffect := admissionv1beta1.SideEffectClassNone
+		failurePolicy := admissionv1beta1.Ignore
+		webhooks = append(webhooks, admissionv1beta1.MutatingWebhook{
+			Name: placementWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &placementWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create, admissionv1beta1.Update},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   placementResources,
+					},
+				},
+			},
+			SideEffects:   &placementSideEffect,
+			FailurePolicy: &failurePolicy,
+		})
+	}
+	if dataMoverAffinity {
+		dataMoverSideEffect := admissionv1beta1.SideEffectClassNone
+		failurePolicy := admissionv1beta1.Ignore
+		webhooks = append(webhooks, admissionv1beta1.MutatingWebhook{
+			Name: dataMoverWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &dataMoverWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   dataMoverResources,
+					},
+				},
+			},
+			ObjectSelector: dataMoverSelector,
+			SideEffects:    &dataMoverSideEffect,
+			FailurePolicy:  &failurePolicy,
+		})
+	}
 	req := &admissionv1beta1.MutatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: storkAdmissionController,
 		},
-		Webhooks: []admissionv1beta1.MutatingWebhook{webhook},
+		Webhooks: webhooks,
 	}
 
 	resp, err := admissionregistration.Instance().GetMutatingWebhookConfigurationV1beta1(storkAdmissionController)
@@ -94,8 +205,15 @@ func CreateMutateWebhook(caBundle []byte, ns string) error {
 }
 
 // GenerateCertificate Self Signed certificate using given CN, returns x509 cert
-// and priv key in PEM format
+// and private key valid for 10 years
 func GenerateCertificate(cn string, dnsName string) ([]byte, []byte, error) {
+	return GenerateCertificateWithLifetime(cn, dnsName, 10*365*24*time.Hour)
+}
+
+// GenerateCertificateWithLifetime generates a self signed certificate using
+// the given CN that is valid for the given duration, returns x509 cert
+// and priv key in PEM format
+func GenerateCertificateWithLifetime(cn string, dnsName string, lifetime time.Duration) ([]byte, []byte, error) {
 	var err error
 	pemCert := &bytes.Buffer{}
 
@@ -106,14 +224,22 @@ func GenerateCertificate(cn string, dnsName string) ([]byte, []byte, error) {
 		return nil, nil, err
 	}
 
+	// Use random serial numbers since the old and new certificates are both
+	// trusted during a rotation
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		log.Errorf("error generating certificate serial number: %v", err)
+		return nil, nil, err
+	}
+
 	// create certificate
 	template := x509.Certificate{
-		SerialNumber: big.NewInt(1),
+		SerialNumber: serialNumber,
 		Subject: pkix.Name{
 			CommonName: cn,
 		},
 		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(10, 0, 0),
+		NotAfter:              time.Now().Add(lifetime),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
@@ -167,7 +293,7 @@ func CreateCertSecrets(cert, key []byte, ns string) (*v1.Secret, error) {
 	return core.Instance().CreateSecret(secret)
 }
 
-func createWebhookV1(caBundle []byte, ns string) error {
+func createWebhookV1(caBundle []byte, ns string, setDefaults, placementHints, dataMoverAffinity bool) error {
 	// We make best efforts to change incoming apps scheduler to stork, if application is
 	// using stork supported storage drivers.
 	sideEffect := admissionv1.SideEffectClassNoneOnDryRun
@@ -198,11 +324,97 @@ func createWebhookV1(caBundle []byte, ns string) error {
 		AdmissionReviewVersions: []string{"v1"},
 		MatchPolicy:             &matchPolicy,
 	}
+	webhooks := []admissionv1.MutatingWebhook{webhook}
+	if setDefaults {
+		defaultSideEffect := admissionv1.SideEffectClassNone
+		webhooks = append(webhooks, admissionv1.MutatingWebhook{
+			Name: defaultWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &defaultWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Create},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   defaultResources,
+					},
+				},
+			},
+			SideEffects:             &defaultSideEffect,
+			FailurePolicy:           &failurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
+	if placementHints {
+		placementSideEffect := admissionv1.SideEffectClassNone
+		webhooks = append(webhooks, admissionv1.MutatingWebhook{
+			Name: placementWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &placementWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Create, admissionv1.Update},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   placementResources,
+					},
+				},
+			},
+			SideEffects:             &placementSideEffect,
+			FailurePolicy:           &failurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
+	if dataMoverAffinity {
+		dataMoverSideEffect := admissionv1.SideEffectClassNone
+		webhooks = append(webhooks, admissionv1.MutatingWebhook{
+			Name: dataMoverWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &dataMoverWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Create},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{""},
+						APIVersions: []string{"v1"},
+						Resources:   dataMoverResources,
+					},
+				},
+			},
+			ObjectSelector:          dataMoverSelector,
+			SideEffects:             &dataMoverSideEffect,
+			FailurePolicy:           &failurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
 	req := &admissionv1.MutatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: storkAdmissionController,
 		},
-		Webhooks: []admissionv1.MutatingWebhook{webhook},
+		Webhooks: webhooks,
 	}
 
 	// recreate webhook
@@ -217,3 +429,280 @@ func createWebhookV1(caBundle []byte, ns string) error {
 	log.Debugf("stork webhook v1 configured: %v", webhookName)
 	return nil
 }
+
+// CreateValidateWebhook creates the validating webhook config. blockScaleUp
+// adds the webhook that blocks scaling up migrated applications that haven't
+// been activated, checkReferences adds the webhook that checks that users
+// can read the objects referenced by stork CRs and protectReferenced adds the
+// webhook that blocks deleting stork CRs that are still in use.
+func CreateValidateWebhook(caBundle []byte, ns string, blockScaleUp, checkReferences, protectReferenced bool) error {
+	client, err := getAdmissionClient()
+	if err != nil {
+		return err
+	}
+	ok, err := version.RequiresV1Registration()
+	if err != nil {
+		return err
+	}
+	if ok {
+		return createValidateWebhookV1(client, caBundle, ns, blockScaleUp, checkReferences, protectReferenced)
+	}
+
+	sideEffect := admissionv1beta1.SideEffectClassNone
+	failurePolicy := admissionv1beta1.Ignore
+	// Block requests if the references can't be checked, except in the
+	// namespaces excluded by the namespace selector
+	referenceFailurePolicy := admissionv1beta1.Fail
+	webhooks := make([]admissionv1beta1.ValidatingWebhook, 0)
+	if blockScaleUp {
+		webhooks = append(webhooks, admissionv1beta1.ValidatingWebhook{
+			Name: validateWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Update},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{"apps"},
+						APIVersions: []string{"v1"},
+						Resources:   scaleGuardResources,
+					},
+				},
+			},
+			SideEffects:   &sideEffect,
+			FailurePolicy: &failurePolicy,
+		})
+	}
+	if checkReferences {
+		webhooks = append(webhooks, admissionv1beta1.ValidatingWebhook{
+			Name: validateReferencesWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateReferencesWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Create, admissionv1beta1.Update},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   referenceCheckResources,
+					},
+				},
+			},
+			NamespaceSelector: referenceCheckNamespaceSelector(ns),
+			SideEffects:       &sideEffect,
+			FailurePolicy:     &referenceFailurePolicy,
+		})
+	}
+	if protectReferenced {
+		webhooks = append(webhooks, admissionv1beta1.ValidatingWebhook{
+			Name: validateDeletionWebhookName,
+			ClientConfig: admissionv1beta1.WebhookClientConfig{
+				Service: &admissionv1beta1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateDeletionWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1beta1.RuleWithOperations{
+				{
+					Operations: []admissionv1beta1.OperationType{admissionv1beta1.Delete},
+					Rule: admissionv1beta1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   deletionGuardResources,
+					},
+				},
+			},
+			SideEffects:   &sideEffect,
+			FailurePolicy: &failurePolicy,
+		})
+	}
+	req := &admissionv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: storkValidatingAdmissionController,
+		},
+		Webhooks: webhooks,
+	}
+
+	err = client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Delete(context.TODO(), storkValidatingAdmissionController, metav1.DeleteOptions{})
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if _, err := client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Create(context.TODO(), req, metav1.CreateOptions{}); err != nil {
+		log.Errorf("unable to create validating webhook configuration: %v", err)
+		return err
+	}
+	log.Debugf("stork validating webhook v1beta1 configured: %v", storkValidatingAdmissionController)
+	return nil
+}
+
+// DeleteValidateWebhook deletes the validating webhook config
+func DeleteValidateWebhook() error {
+	client, err := getAdmissionClient()
+	if err != nil {
+		return err
+	}
+	ok, err := version.RequiresV1Registration()
+	if err != nil {
+		return err
+	}
+	if ok {
+		err = client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(context.TODO(), storkValidatingAdmissionController, metav1.DeleteOptions{})
+	} else {
+		err = client.AdmissionregistrationV1beta1().ValidatingWebhookConfigurations().Delete(context.TODO(), storkValidatingAdmissionController, metav1.DeleteOptions{})
+	}
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func createValidateWebhookV1(client kubernetes.Interface, caBundle []byte, ns string, blockScaleUp, checkReferences, protectReferenced bool) error {
+	sideEffect := admissionv1.SideEffectClassNone
+	failurePolicy := admissionv1.Ignore
+	// Block requests if the references can't be checked, except in the
+	// namespaces excluded by the namespace selector
+	referenceFailurePolicy := admissionv1.Fail
+	matchPolicy := admissionv1.Exact
+	webhooks := make([]admissionv1.ValidatingWebhook, 0)
+	if blockScaleUp {
+		webhooks = append(webhooks, admissionv1.ValidatingWebhook{
+			Name: validateWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Update},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{"apps"},
+						APIVersions: []string{"v1"},
+						Resources:   scaleGuardResources,
+					},
+				},
+			},
+			SideEffects:             &sideEffect,
+			FailurePolicy:           &failurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
+	if checkReferences {
+		webhooks = append(webhooks, admissionv1.ValidatingWebhook{
+			Name: validateReferencesWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateReferencesWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Create, admissionv1.Update},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   referenceCheckResources,
+					},
+				},
+			},
+			NamespaceSelector:       referenceCheckNamespaceSelector(ns),
+			SideEffects:             &sideEffect,
+			FailurePolicy:           &referenceFailurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
+	if protectReferenced {
+		webhooks = append(webhooks, admissionv1.ValidatingWebhook{
+			Name: validateDeletionWebhookName,
+			ClientConfig: admissionv1.WebhookClientConfig{
+				Service: &admissionv1.ServiceReference{
+					Name:      storkService,
+					Namespace: ns,
+					Path:      &validateDeletionWebhookPath,
+				},
+				CABundle: caBundle,
+			},
+			Rules: []admissionv1.RuleWithOperations{
+				{
+					Operations: []admissionv1.OperationType{admissionv1.Delete},
+					Rule: admissionv1.Rule{
+						APIGroups:   []string{stork_api.SchemeGroupVersion.Group},
+						APIVersions: []string{stork_api.SchemeGroupVersion.Version},
+						Resources:   deletionGuardResources,
+					},
+				},
+			},
+			SideEffects:             &sideEffect,
+			FailurePolicy:           &failurePolicy,
+			AdmissionReviewVersions: []string{"v1beta1"},
+			MatchPolicy:             &matchPolicy,
+		})
+	}
+	req := &admissionv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: storkValidatingAdmissionController,
+		},
+		Webhooks: webhooks,
+	}
+
+	// recreate webhook
+	err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Delete(context.TODO(), storkValidatingAdmissionController, metav1.DeleteOptions{})
+	if err != nil && !k8serr.IsNotFound(err) {
+		return err
+	}
+	if _, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(context.TODO(), req, metav1.CreateOptions{}); err != nil {
+		log.Errorf("unable to create validating webhook configuration: %v", err)
+		return err
+	}
+	log.Debugf("stork validating webhook v1 configured: %v", storkValidatingAdmissionController)
+	return nil
+}
+
+// referenceCheckNamespaceSelector excludes the namespace stork runs in and
+// kube-system from the reference check, so that stork and the cluster can
+// still be managed while the webhook is unavailable
+func referenceCheckNamespaceSelector(ns string) *metav1.LabelSelector {
+	excluded := []string{metav1.NamespaceSystem}
+	if ns != metav1.NamespaceSystem {
+		excluded = append(excluded, ns)
+	}
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      namespaceNameLabel,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   excluded,
+			},
+		},
+	}
+}
+
+func getAdmissionClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
@@ -3,6 +3,7 @@ package webhookadmission
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/libopenstorage/stork/drivers/volume"
+	"github.com/libopenstorage/stork/pkg/crds"
 	"github.com/portworx/sched-ops/k8s/admissionregistration"
 	"github.com/portworx/sched-ops/k8s/core"
 	log "github.com/sirupsen/logrus"
@@ -20,8 +22,11 @@ import (
 	admissionv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	appv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 )
 
@@ -48,13 +53,79 @@ type Controller struct {
 	server       *http.Server
 	lock         sync.Mutex
 	started      bool
+	namespace    string
 	SkipResource string
+	// kubeClient is used to check the access of users, the in-cluster
+	// client is used if it isn't set
+	kubeClient kubernetes.Interface
+	// certLock protects the serving certificate, which is rotated while
+	// the server is running
+	certLock         sync.RWMutex
+	tlsCert          *tls.Certificate
+	certificate      *x509.Certificate
+	caBundle         []byte
+	rotationStopChan chan struct{}
+	// migrationScheduleStore caches the migration schedules checked by the
+	// scale guard, it is nil if the cache hasn't been started
+	migrationScheduleStore    cache.Store
+	migrationScheduleInformer cache.Controller
+	cacheStopChan             chan struct{}
+	// ServiceAccount is the service account stork runs as. Stork's own
+	// requests aren't blocked by the scale guard, since they are made when
+	// the migrated applications are activated.
+	ServiceAccount string
+	// BlockInactiveAppScaleUp, if set, blocks scaling up migrated
+	// applications on a destination cluster until they are activated
+	BlockInactiveAppScaleUp bool
+	// CheckReferences, if set, denies stork CRs referencing BackupLocations
+	// or ClusterPairs that the user isn't allowed to read
+	CheckReferences bool
+	// ProtectReferencedCRs, if set, denies deleting BackupLocations,
+	// ClusterPairs and schedule policies that are still in use
+	ProtectReferencedCRs bool
+	// AdminNamespace is the namespace from which admin cluster pairs are read
+	AdminNamespace string
+	// DefaultCRs, if set, fills in the defaults on stork CRs when they are
+	// created
+	DefaultCRs bool
+	// ConvertCRDs, if set, serves the v1alpha2 version of the stork CRDs
+	// that support it and converts objects between the versions
+	ConvertCRDs bool
+	// PlacementHints, if set, adds annotations to PVCs owned by the driver
+	// so that their volumes are placed on the nodes where their pods are
+	// scheduled
+	PlacementHints bool
+	// DataMoverAffinity, if set, adds node affinity to the pods of the data
+	// mover jobs so that they run where their PVCs are attached
+	DataMoverAffinity bool
+	// DriverEvents, if set, lets volume drivers push events so that the
+	// objects whose operations made progress are reconciled right away
+	DriverEvents bool
+	// CertLifetime is the lifetime of the generated serving certificate.
+	// The certificate is rotated once a third of its lifetime is left.
+	CertLifetime time.Duration
 }
 
 // Serve method for webhook server
 func (c *Controller) serveHTTP(w http.ResponseWriter, req *http.Request) {
-	if strings.Contains(req.URL.Path, mutateWebHook) {
+	if strings.Contains(req.URL.Path, convertWebHook) && c.ConvertCRDs {
+		c.processConvertRequest(w, req)
+	} else if strings.Contains(req.URL.Path, defaultWebHook) && c.DefaultCRs {
+		c.processDefaultRequest(w, req)
+	} else if strings.Contains(req.URL.Path, placementWebHook) && c.PlacementHints {
+		c.processPlacementRequest(w, req)
+	} else if strings.Contains(req.URL.Path, dataMoverWebHook) && c.DataMoverAffinity {
+		c.processDataMoverRequest(w, req)
+	} else if strings.Contains(req.URL.Path, driverEventsWebHook) && c.DriverEvents {
+		c.processDriverEventRequest(w, req)
+	} else if strings.Contains(req.URL.Path, mutateWebHook) {
 		c.processMutateRequest(w, req)
+	} else if strings.Contains(req.URL.Path, validateReferencesWebHook) && c.CheckReferences {
+		c.processReferenceRequest(w, req)
+	} else if strings.Contains(req.URL.Path, validateDeletionWebHook) && c.ProtectReferencedCRs {
+		c.processDeletionRequest(w, req)
+	} else if strings.Contains(req.URL.Path, validateWebHook) && c.BlockInactiveAppScaleUp {
+		c.processValidateRequest(w, req)
 	} else {
 		http.Error(w, "Unsupported request", http.StatusNotFound)
 	}
@@ -209,10 +280,6 @@ func (c *Controller) Start() error {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
-	var secretData, key, caBundle []byte
-	var err error
-	var ok bool
-	var tlsCert tls.Certificate
 	if c.started {
 		return fmt.Errorf("webhook server has already been started")
 	}
@@ -221,51 +288,80 @@ func (c *Controller) Start() error {
 	if ns == "" {
 		ns = defaultNamespace
 	}
+	c.namespace = ns
+	if c.CertLifetime <= 0 {
+		c.CertLifetime = defaultCertLifetime
+	}
 	certSecrets, err := core.Instance().GetSecret(secretName, ns)
 	if err != nil && !k8serr.IsNotFound(err) {
 		log.Errorf("Unable to retrieve %v secret: %v", secretName, err)
 		return err
 	} else if k8serr.IsNotFound(err) {
 		// create CN string
-		dnsName := storkService + "." + ns + ".svc"
-		caBundle, key, err = GenerateCertificate("Stork CA", dnsName)
+		caBundle, key, err := GenerateCertificateWithLifetime("Stork CA", c.getDNSName(), c.CertLifetime)
 		if err != nil {
 			log.Errorf("Unable to generate x509 certificate: %v", err)
 			return err
 		}
-		tlsCert, err = GetTLSCertificate(caBundle, key)
-		if err != nil {
-			log.Errorf("Unable to create tls certificate: %v", err)
-			return err
+		certSecrets, err = CreateCertSecrets(caBundle, key, ns)
+		if k8serr.IsAlreadyExists(err) {
+			// Another replica created the certificate first, all the
+			// replicas need to serve the same one
+			certSecrets, err = core.Instance().GetSecret(secretName, ns)
 		}
-		_, err = CreateCertSecrets(caBundle, key, ns)
 		if err != nil {
 			log.Errorf("unable to create secrets for cert details: %v", err)
 			return err
 		}
-	} else {
-		if secretData, ok = certSecrets.Data[privKey]; !ok {
-			return fmt.Errorf("invalid secret key data")
-		}
-		if caBundle, ok = certSecrets.Data[privCert]; !ok {
-			return fmt.Errorf("invalid secret certificate")
-		}
-
-		tlsCert, err = GetTLSCertificate(caBundle, secretData)
-		if err != nil {
-			log.Errorf("unable to generate tls certs: %v", err)
-			return err
-		}
+	}
+	if _, err := c.loadCertificate(certSecrets); err != nil {
+		log.Errorf("unable to generate tls certs: %v", err)
+		return err
+	}
+	// Rotate the certificate right away if it is about to expire
+	if err := c.checkCertificate(false); err != nil {
+		return err
 	}
 	// Cleanup the old webhook cert
 	err = core.Instance().DeleteSecret(oldSecretName, ns)
 	if err != nil && !k8serr.IsNotFound(err) {
 		log.Warnf("Failed to delete old webhook secret: %v", err)
 	}
+	// The certificate is looked up for every connection so that it can be
+	// rotated without restarting the server
 	c.server = &http.Server{Addr: ":443",
-		TLSConfig: &tls.Config{Certificates: []tls.Certificate{tlsCert}}}
+		TLSConfig: &tls.Config{GetCertificate: c.getCertificate}}
 
 	http.HandleFunc("/mutate", c.serveHTTP)
+	if c.BlockInactiveAppScaleUp {
+		c.cacheStopChan = make(chan struct{})
+		if err := c.startMigrationScheduleCache(c.cacheStopChan); err != nil {
+			log.Errorf("unable to start migration schedule cache: %v", err)
+			return err
+		}
+		http.HandleFunc(validateWebHook, c.serveHTTP)
+	}
+	if c.CheckReferences {
+		http.HandleFunc(validateReferencesWebHook, c.serveHTTP)
+	}
+	if c.ProtectReferencedCRs {
+		http.HandleFunc(validateDeletionWebHook, c.serveHTTP)
+	}
+	if c.DefaultCRs {
+		http.HandleFunc(defaultWebHook, c.serveHTTP)
+	}
+	if c.ConvertCRDs {
+		http.HandleFunc(convertWebHook, c.serveHTTP)
+	}
+	if c.PlacementHints {
+		http.HandleFunc(placementWebHook, c.serveHTTP)
+	}
+	if c.DataMoverAffinity {
+		http.HandleFunc(dataMoverWebHook, c.serveHTTP)
+	}
+	if c.DriverEvents {
+		http.HandleFunc(driverEventsWebHook, c.serveHTTP)
+	}
 	go func() {
 		if err := c.server.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
 			log.Errorf("Error starting webhook server: %v", err)
@@ -273,7 +369,35 @@ func (c *Controller) Start() error {
 	}()
 	c.started = true
 	log.Debugf("Webhook server started")
-	return CreateMutateWebhook(caBundle, ns)
+	c.rotationStopChan = make(chan struct{})
+	go c.rotateCertificates(c.rotationStopChan)
+	return c.configureWebhooks(c.getCABundle())
+}
+
+// configureWebhooks creates or updates the webhook configurations and the
+// CRD conversion with the given CA bundle
+func (c *Controller) configureWebhooks(caBundle []byte) error {
+	if err := CreateMutateWebhook(caBundle, c.namespace, c.DefaultCRs, c.PlacementHints, c.DataMoverAffinity); err != nil {
+		return err
+	}
+	if c.ConvertCRDs {
+		service := &apiextensionsv1.ServiceReference{
+			Name:      storkService,
+			Namespace: c.namespace,
+			Path:      &convertWebhookPath,
+		}
+		if err := crds.EnableConversion(service, caBundle); err != nil {
+			return err
+		}
+	} else if err := crds.DisableConversion(); err != nil {
+		// Remove the v1alpha2 version in case it was enabled earlier
+		return err
+	}
+	if c.BlockInactiveAppScaleUp || c.CheckReferences || c.ProtectReferencedCRs {
+		return CreateValidateWebhook(caBundle, c.namespace, c.BlockInactiveAppScaleUp, c.CheckReferences, c.ProtectReferencedCRs)
+	}
+	// Remove the config in case it was enabled earlier
+	return DeleteValidateWebhook()
 }
 
 // Stop Stops the webhook server
@@ -284,9 +408,29 @@ func (c *Controller) Stop() error {
 	if !c.started {
 		return fmt.Errorf("webhook server has not been started")
 	}
-	if err := admissionregistration.Instance().DeleteMutatingWebhookConfiguration(storkAdmissionController); err != nil {
-		log.Errorf("unable to delete webhook configuration, %v", err)
-		return err
+	// Leave the webhook configurations to the other replicas that are still
+	// serving requests
+	otherReplicas, err := hasOtherReplicas(c.namespace)
+	if err != nil {
+		log.Warnf("unable to check for other webhook replicas: %v", err)
+	}
+	if !otherReplicas {
+		if err := admissionregistration.Instance().DeleteMutatingWebhookConfiguration(storkAdmissionController); err != nil {
+			log.Errorf("unable to delete webhook configuration, %v", err)
+			return err
+		}
+		if c.BlockInactiveAppScaleUp || c.CheckReferences || c.ProtectReferencedCRs {
+			if err := DeleteValidateWebhook(); err != nil {
+				log.Errorf("unable to delete validating webhook configuration, %v", err)
+				return err
+			}
+		}
+	}
+	close(c.rotationStopChan)
+	if c.cacheStopChan != nil {
+		close(c.cacheStopChan)
+		c.cacheStopChan = nil
+		c.migrationScheduleStore = nil
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -298,6 +442,47 @@ func (c *Controller) Stop() error {
 	return nil
 }
 
+// Ready returns an error if the webhook server isn't ready to serve requests
+// or its certificate isn't valid
+func (c *Controller) Ready() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if !c.started {
+		return fmt.Errorf("webhook server has not been started")
+	}
+	c.certLock.RLock()
+	defer c.certLock.RUnlock()
+	now := time.Now()
+	if now.Before(c.certificate.NotBefore) || now.After(c.certificate.NotAfter) {
+		return fmt.Errorf("webhook certificate is only valid from %v to %v", c.certificate.NotBefore, c.certificate.NotAfter)
+	}
+	return nil
+}
+
+// hasOtherReplicas returns true if other stork pods are ready to serve
+// requests for the stork service
+func hasOtherReplicas(ns string) (bool, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return false, err
+	}
+	endpoints, err := core.Instance().GetEndpoints(storkService, ns)
+	if err != nil {
+		if k8serr.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef == nil || address.TargetRef.Name != hostname {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // createJson patch to update container spec scheduler path
 func createPatch(schedpath string) []byte {
 	p := []map[string]string{}